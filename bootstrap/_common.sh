@@ -274,7 +274,13 @@ setup_workspace_symlink() {
 # ============================================================
 link_blackdot() {
     echo "Linking blackdot..."
-    "$BLACKDOT_DIR/bootstrap/bootstrap-blackdot.sh"
+    # Prefer the Go links manager (links.yaml); the script is the fallback
+    # when the binary has not been built yet
+    if [[ -x "$BLACKDOT_DIR/bin/blackdot" ]]; then
+        "$BLACKDOT_DIR/bin/blackdot" links apply
+    else
+        "$BLACKDOT_DIR/bootstrap/bootstrap-blackdot.sh"
+    fi
 }
 
 # ============================================================
@@ -3,15 +3,20 @@ package cli
 import (
 	"archive/tar"
 	"compress/gzip"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/safefile"
 	"github.com/fatih/color"
+	"github.com/klauspost/compress/zstd"
 	"github.com/spf13/cobra"
 )
 
@@ -28,13 +33,27 @@ var defaultBackupFiles = []string{
 	".config/blackdot/config.json",
 }
 
+// Archive extensions recognized by list/restore/prune, newest format first.
+// New backups are always tar.zst; tar.gz and tar come from older releases.
+var backupExtensions = []string{".tar.zst", ".tar.gz", ".tar"}
+
 type backupConfig struct {
-	backupDir   string
-	maxBackups  int
-	compress    bool
-	blackdotDir string
+	backupDir     string
+	legacyDir     string
+	maxBackups    int
+	retentionDays int
+	compress      bool
+	enabled       bool
+	blackdotDir   string
 }
 
+// getBackupConfig resolves backup settings from the layered config:
+//
+//	backup.location        storage directory (default ~/.local/share/blackdot/backups)
+//	backup.max_snapshots   number of backups to keep (default 10)
+//	backup.retention_days  delete backups older than this (default 30, 0 = forever)
+//	backup.compress        zstd-compress archives (default true)
+//	backup.enabled         auto-snapshot before vault restore/push (default true)
 func getBackupConfig() *backupConfig {
 	home, _ := os.UserHomeDir()
 	blackdotDir := os.Getenv("BLACKDOT_DIR")
@@ -42,12 +61,59 @@ func getBackupConfig() *backupConfig {
 		blackdotDir = filepath.Join(home, ".blackdot")
 	}
 
-	return &backupConfig{
-		backupDir:   filepath.Join(home, ".blackdot-backups"),
-		maxBackups:  10,
-		compress:    true,
-		blackdotDir: blackdotDir,
+	cfg := &backupConfig{
+		backupDir:     filepath.Join(paths.DataDir(), "backups"),
+		legacyDir:     filepath.Join(home, ".blackdot-backups"),
+		maxBackups:    10,
+		retentionDays: 30,
+		compress:      true,
+		enabled:       true,
+		blackdotDir:   blackdotDir,
+	}
+
+	// Where backups go and whether they are taken at all are not up to
+	// the project layer: a repository could redirect them or switch them off
+	if location, _ := trustedConfigLookup("backup.location"); location != "" {
+		cfg.backupDir = expandPath(location)
+	}
+	if n, err := strconv.Atoi(configLookup("backup.max_snapshots")); err == nil && n > 0 {
+		cfg.maxBackups = n
 	}
+	if n, err := strconv.Atoi(configLookup("backup.retention_days")); err == nil && n >= 0 {
+		cfg.retentionDays = n
+	}
+	if configLookup("backup.compress") == "false" {
+		cfg.compress = false
+	}
+	if enabled, _ := trustedConfigLookup("backup.enabled"); enabled == "false" {
+		cfg.enabled = false
+	}
+
+	return cfg
+}
+
+// backupArchive describes a backup on disk
+type backupArchive struct {
+	ID      string
+	Name    string
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// backupManifest is stored as manifest.json inside each archive
+type backupManifest struct {
+	Version  int       `json:"version"`
+	Created  time.Time `json:"created"`
+	Reason   string    `json:"reason,omitempty"`
+	Hostname string    `json:"hostname,omitempty"`
+	Files    []string  `json:"files"`
+}
+
+// backupEntry is a tracked file and its name inside the archive
+type backupEntry struct {
+	name string
+	path string
 }
 
 func newBackupCmd() *cobra.Command {
@@ -72,31 +138,64 @@ func newBackupCmd() *cobra.Command {
 
 Examples:
   blackdot backup restore                  # Restore latest
-  blackdot backup restore 20231207_120000  # Restore specific
+  blackdot backup restore 20231207-120000  # Restore specific
   blackdot backup restore --dry-run        # Preview what would be restored`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runBackupRestoreWithDryRun(cmd, args, restoreDryRun)
+			return runBackupRestoreImpl(cmd, args, restoreDryRun)
 		},
 	}
 	restoreCmd.Flags().BoolVarP(&restoreDryRun, "dry-run", "n", false, "preview what would be restored without making changes")
 
-	cmd.AddCommand(
-		&cobra.Command{
-			Use:   "create",
-			Short: "Create backup of current config",
-			RunE:  runBackupCreate,
+	var reason string
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create backup of current config",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, _, err := createBackup(getBackupConfig(), reason, false)
+			return err
 		},
+	}
+	createCmd.Flags().StringVar(&reason, "reason", "", "label recorded in the backup name and manifest")
+
+	var pruneKeep, pruneDays int
+	var pruneDryRun bool
+	pruneCmd := &cobra.Command{
+		Use:     "prune",
+		Aliases: []string{"clean"},
+		Short:   "Remove backups outside the retention policy",
+		Long: `Remove backups outside the retention policy.
+
+By default uses backup.max_snapshots and backup.retention_days from config.
+The newest backup is always kept.
+
+Examples:
+  blackdot backup prune                # Apply configured policy
+  blackdot backup prune --keep 3       # Keep only the newest 3
+  blackdot backup prune --days 7 -n    # Preview removing backups older than 7 days`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := getBackupConfig()
+			if cmd.Flags().Changed("keep") {
+				cfg.maxBackups = pruneKeep
+			}
+			if cmd.Flags().Changed("days") {
+				cfg.retentionDays = pruneDays
+			}
+			return runBackupPrune(cfg, pruneDryRun)
+		},
+	}
+	pruneCmd.Flags().IntVar(&pruneKeep, "keep", 0, "number of backups to keep")
+	pruneCmd.Flags().IntVar(&pruneDays, "days", 0, "remove backups older than this many days (0 = no age limit)")
+	pruneCmd.Flags().BoolVarP(&pruneDryRun, "dry-run", "n", false, "show what would be removed")
+
+	cmd.AddCommand(
+		createCmd,
 		&cobra.Command{
 			Use:   "list",
 			Short: "List all backups",
 			RunE:  runBackupList,
 		},
 		restoreCmd,
-		&cobra.Command{
-			Use:   "clean",
-			Short: "Remove old backups (keeps newest 10)",
-			RunE:  runBackupClean,
-		},
+		pruneCmd,
 	)
 
 	return cmd
@@ -128,8 +227,8 @@ func printBackupHelp() {
 	Yellow.Printf("%-12s", "restore")
 	Dim.Println("Restore specific backup")
 	fmt.Print("  ")
-	Yellow.Printf("%-12s", "clean")
-	Dim.Println("Remove old backups (keeps newest 10)")
+	Yellow.Printf("%-12s", "prune")
+	Dim.Println("Remove backups outside the retention policy")
 	fmt.Println()
 
 	// Backed up files
@@ -140,6 +239,13 @@ func printBackupHelp() {
 	Dim.Println("  - Zsh configuration")
 	Dim.Println("  - Blackdot config.json")
 	Dim.Println("  - Template variables")
+	Dim.Println("  - Files managed by vault-items.json")
+	fmt.Println()
+
+	// Storage
+	BoldCyan.Println("Storage:")
+	Dim.Println("  ~/.local/share/blackdot/backups/backup-<timestamp>.tar.zst")
+	Dim.Println("  Snapshots are taken automatically before vault restore/push")
 	fmt.Println()
 
 	// Examples
@@ -157,118 +263,387 @@ func printBackupHelp() {
 	fmt.Print("      ")
 	Dim.Println("# Restore latest backup")
 	fmt.Print("  ")
-	Yellow.Print("blackdot backup clean")
+	Yellow.Print("blackdot backup prune")
 	fmt.Print("        ")
 	Dim.Println("# Remove old backups")
 	fmt.Println()
 }
 
 func runBackupCreate(cmd *cobra.Command, args []string) error {
-	cfg := getBackupConfig()
+	_, _, err := createBackup(getBackupConfig(), "", false)
+	return err
+}
+
+// backupTrackedFiles returns the files a snapshot should capture: the
+// default config files, local paths from vault-items.json, and template
+// variables. Archive names are home-relative, or prefixed with "blackdot/"
+// for files inside the blackdot directory.
+func backupTrackedFiles(cfg *backupConfig) []backupEntry {
 	home, _ := os.UserHomeDir()
+	seen := make(map[string]bool)
+	var entries []backupEntry
 
+	add := func(name, path string) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		entries = append(entries, backupEntry{name: filepath.ToSlash(name), path: path})
+	}
+
+	for _, relPath := range defaultBackupFiles {
+		add(relPath, filepath.Join(home, relPath))
+	}
+
+	if items, err := loadVaultItems(); err == nil {
+		names := make([]string, 0, len(items))
+		for name := range items {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			path := expandPath(items[name].Path)
+			rel, err := filepath.Rel(home, path)
+			if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			add(rel, path)
+		}
+	}
+
+	add("blackdot/templates/_variables.local.sh", filepath.Join(cfg.blackdotDir, "templates", "_variables.local.sh"))
+
+	return entries
+}
+
+// createBackup snapshots tracked files into a new tar.zst archive.
+// reason is recorded in the file name and manifest (e.g. "pre-restore").
+// When quiet is set, per-file output is suppressed.
+func createBackup(cfg *backupConfig, reason string, quiet bool) (*backupArchive, int, error) {
 	green := color.New(color.FgGreen).SprintFunc()
 	cyan := color.New(color.FgCyan).SprintFunc()
 	yellow := color.New(color.FgYellow).SprintFunc()
 
 	// Create backup directory
 	if err := os.MkdirAll(cfg.backupDir, 0700); err != nil {
-		return fmt.Errorf("creating backup directory: %w", err)
+		return nil, 0, fmt.Errorf("creating backup directory: %w", err)
 	}
 
 	// Generate backup name with timestamp (matches bash: backup-YYYYMMDD-HHMMSS)
-	timestamp := time.Now().Format("20060102-150405")
-	backupName := fmt.Sprintf("backup-%s.tar.gz", timestamp)
-	backupPath := filepath.Join(cfg.backupDir, backupName)
+	id := time.Now().Format("20060102-150405")
+	if reason = sanitizeBackupReason(reason); reason != "" {
+		id += "-" + reason
+	}
+	ext := ".tar.zst"
+	if !cfg.compress {
+		ext = ".tar"
+	}
+	backupPath := filepath.Join(cfg.backupDir, "backup-"+id+ext)
+	for n := 2; ; n++ {
+		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+			break
+		}
+		backupPath = filepath.Join(cfg.backupDir, fmt.Sprintf("backup-%s-%d%s", id, n, ext))
+	}
 
-	fmt.Println()
-	fmt.Println(color.New(color.Bold).Sprint("Creating Backup"))
-	fmt.Println("================")
-	fmt.Println()
+	if !quiet {
+		fmt.Println()
+		fmt.Println(color.New(color.Bold).Sprint("Creating Backup"))
+		fmt.Println("================")
+		fmt.Println()
+	}
 
-	// Create tar.gz file
-	file, err := os.Create(backupPath)
+	// Write to a temp file and rename so an interrupted backup never
+	// looks like a valid archive
+	tmpPath := backupPath + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
 	if err != nil {
-		return fmt.Errorf("creating backup file: %w", err)
+		return nil, 0, fmt.Errorf("creating backup file: %w", err)
 	}
+	defer os.Remove(tmpPath)
 	defer file.Close()
 
-	gw := gzip.NewWriter(file)
-	defer gw.Close()
-
-	tw := tar.NewWriter(gw)
-	defer tw.Close()
+	var out io.WriteCloser = nopWriteCloser{file}
+	if cfg.compress {
+		zw, err := zstd.NewWriter(file)
+		if err != nil {
+			return nil, 0, fmt.Errorf("creating compressor: %w", err)
+		}
+		out = zw
+	}
+	tw := tar.NewWriter(out)
+
+	hostname, _ := os.Hostname()
+	manifest := backupManifest{
+		Version:  1,
+		Created:  time.Now().UTC(),
+		Reason:   reason,
+		Hostname: hostname,
+	}
 
 	// Add files to backup
-	var backedUp int
-	for _, relPath := range defaultBackupFiles {
-		fullPath := filepath.Join(home, relPath)
-
+	for _, entry := range backupTrackedFiles(cfg) {
 		// Check if file exists
-		info, err := os.Stat(fullPath)
+		info, err := os.Stat(entry.path)
 		if os.IsNotExist(err) {
-			fmt.Printf("  %s %s (not found, skipped)\n", yellow("-"), relPath)
+			if !quiet {
+				fmt.Printf("  %s %s (not found, skipped)\n", yellow("-"), entry.name)
+			}
 			continue
 		}
-		if err != nil {
-			fmt.Printf("  %s %s: %v\n", yellow("⚠"), relPath, err)
+		if err == nil && info.IsDir() {
 			continue
 		}
-
-		// Read file
-		data, err := os.ReadFile(fullPath)
+		var data []byte
+		if err == nil {
+			data, err = os.ReadFile(entry.path)
+		}
 		if err != nil {
-			fmt.Printf("  %s %s: %v\n", yellow("⚠"), relPath, err)
+			if !quiet {
+				fmt.Printf("  %s %s: %v\n", yellow("⚠"), entry.name, err)
+			}
 			continue
 		}
 
-		// Create tar header
 		header := &tar.Header{
-			Name:    relPath,
-			Size:    info.Size(),
-			Mode:    int64(info.Mode()),
+			Name:    entry.name,
+			Size:    int64(len(data)),
+			Mode:    int64(info.Mode().Perm()),
 			ModTime: info.ModTime(),
 		}
-
 		if err := tw.WriteHeader(header); err != nil {
-			return fmt.Errorf("writing tar header: %w", err)
+			return nil, 0, fmt.Errorf("writing tar header: %w", err)
 		}
-
 		if _, err := tw.Write(data); err != nil {
-			return fmt.Errorf("writing file to tar: %w", err)
+			return nil, 0, fmt.Errorf("writing file to tar: %w", err)
 		}
 
-		fmt.Printf("  %s %s\n", green("✓"), relPath)
-		backedUp++
+		if !quiet {
+			fmt.Printf("  %s %s\n", green("✓"), entry.name)
+		}
+		manifest.Files = append(manifest.Files, entry.name)
 	}
 
-	// Also backup templates/_variables.local.sh if it exists
-	varFile := filepath.Join(cfg.blackdotDir, "templates", "_variables.local.sh")
-	if info, err := os.Stat(varFile); err == nil {
-		data, err := os.ReadFile(varFile)
-		if err == nil {
-			header := &tar.Header{
-				Name:    "blackdot/templates/_variables.local.sh",
-				Size:    info.Size(),
-				Mode:    int64(info.Mode()),
-				ModTime: info.ModTime(),
+	manifestData, _ := json.MarshalIndent(manifest, "", "  ")
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    "manifest.json",
+		Size:    int64(len(manifestData)),
+		Mode:    0600,
+		ModTime: manifest.Created,
+	}); err != nil {
+		return nil, 0, fmt.Errorf("writing manifest: %w", err)
+	}
+	if _, err := tw.Write(manifestData); err != nil {
+		return nil, 0, fmt.Errorf("writing manifest: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, 0, fmt.Errorf("finalizing archive: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return nil, 0, fmt.Errorf("finalizing archive: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, 0, fmt.Errorf("finalizing archive: %w", err)
+	}
+	if err := os.Rename(tmpPath, backupPath); err != nil {
+		return nil, 0, fmt.Errorf("saving backup: %w", err)
+	}
+
+	archive := &backupArchive{
+		ID:      backupIDFromName(filepath.Base(backupPath)),
+		Name:    filepath.Base(backupPath),
+		Path:    backupPath,
+		ModTime: time.Now(),
+	}
+	if info, err := os.Stat(backupPath); err == nil {
+		archive.Size = info.Size()
+	}
+
+	if !quiet {
+		fmt.Println()
+		fmt.Printf("Backup created: %s\n", cyan(backupPath))
+		fmt.Printf("Files backed up: %d\n", len(manifest.Files))
+	}
+
+	// Apply retention policy
+	pruneBackups(cfg, false)
+
+	return archive, len(manifest.Files), nil
+}
+
+// nopWriteCloser lets an uncompressed archive share the compressor code path
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// snapshotBeforeWrite takes an automatic backup before a destructive
+// operation. Failures are reported but never block the caller.
+func snapshotBeforeWrite(operation string) {
+	cfg := getBackupConfig()
+	if !cfg.enabled {
+		Debug("Auto-snapshot disabled (backup.enabled=false)")
+		return
+	}
+
+	Info("Creating backup before %s...", operation)
+	archive, count, err := createBackup(cfg, "pre-"+operation, true)
+	if err != nil {
+		Warn("Backup failed (continuing anyway): %v", err)
+		return
+	}
+	Pass("Backup created: %s (%d files)", archive.ID, count)
+}
+
+// sanitizeBackupReason reduces a reason label to [a-z0-9-]
+func sanitizeBackupReason(reason string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(reason)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '-' || r == '_' || r == ' ' || r == '.':
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// isBackupArchiveName reports whether name looks like a backup archive.
+// Supports both naming conventions: backup- (bash) and backup_ (legacy Go).
+func isBackupArchiveName(name string) bool {
+	if !strings.HasPrefix(name, "backup-") && !strings.HasPrefix(name, "backup_") {
+		return false
+	}
+	for _, ext := range backupExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// backupIDFromName strips the prefix and extension from an archive name
+func backupIDFromName(name string) string {
+	id := strings.TrimPrefix(strings.TrimPrefix(name, "backup-"), "backup_")
+	for _, ext := range backupExtensions {
+		if strings.HasSuffix(id, ext) {
+			return strings.TrimSuffix(id, ext)
+		}
+	}
+	return id
+}
+
+// listBackups returns archives from the backup directory and the legacy
+// ~/.blackdot-backups directory, newest first
+func listBackups(cfg *backupConfig) ([]backupArchive, error) {
+	var backups []backupArchive
+	seen := make(map[string]bool)
+
+	for _, dir := range []string{cfg.backupDir, cfg.legacyDir} {
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
 			}
-			if err := tw.WriteHeader(header); err == nil {
-				tw.Write(data)
-				fmt.Printf("  %s %s\n", green("✓"), "templates/_variables.local.sh")
-				backedUp++
+			return nil, fmt.Errorf("reading backup directory: %w", err)
+		}
+
+		for _, e := range entries {
+			if e.IsDir() || !isBackupArchiveName(e.Name()) {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
 			}
+			backups = append(backups, backupArchive{
+				ID:      backupIDFromName(e.Name()),
+				Name:    e.Name(),
+				Path:    filepath.Join(dir, e.Name()),
+				Size:    info.Size(),
+				ModTime: info.ModTime(),
+			})
 		}
 	}
 
-	fmt.Println()
-	fmt.Printf("Backup created: %s\n", cyan(backupPath))
-	fmt.Printf("Files backed up: %d\n", backedUp)
+	// Newest first; IDs start with the timestamp so they break ties
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].ModTime.Equal(backups[j].ModTime) {
+			return backups[i].ModTime.After(backups[j].ModTime)
+		}
+		return backups[i].ID > backups[j].ID
+	})
 
-	// Clean old backups
-	cleanOldBackups(cfg)
+	return backups, nil
+}
 
-	return nil
+// findBackup resolves a backup by ID, file name, or path. An empty ID
+// returns the latest backup.
+func findBackup(cfg *backupConfig, id string) (*backupArchive, error) {
+	backups, err := listBackups(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		if len(backups) == 0 {
+			return nil, fmt.Errorf("no backups found in %s", cfg.backupDir)
+		}
+		return &backups[0], nil
+	}
+
+	for i := range backups {
+		b := &backups[i]
+		if b.ID == id || b.Name == id || b.Path == id || "backup-"+b.ID == id {
+			return b, nil
+		}
+	}
+
+	// Allow restoring an archive copied from elsewhere
+	if info, err := os.Stat(id); err == nil && !info.IsDir() {
+		return &backupArchive{
+			ID:      backupIDFromName(filepath.Base(id)),
+			Name:    filepath.Base(id),
+			Path:    id,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("backup not found: %s", id)
+}
+
+// openBackupArchive returns a tar reader for any supported archive format
+func openBackupArchive(path string) (*tar.Reader, func(), error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening backup: %w", err)
+	}
+
+	switch {
+	case strings.HasSuffix(path, ".tar.zst"):
+		zr, err := zstd.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("decompressing backup: %w", err)
+		}
+		return tar.NewReader(zr), func() { zr.Close(); file.Close() }, nil
+	case strings.HasSuffix(path, ".tar.gz"):
+		gr, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("decompressing backup: %w", err)
+		}
+		return tar.NewReader(gr), func() { gr.Close(); file.Close() }, nil
+	default:
+		return tar.NewReader(file), func() { file.Close() }, nil
+	}
 }
 
 func runBackupList(cmd *cobra.Command, args []string) error {
@@ -277,27 +652,19 @@ func runBackupList(cmd *cobra.Command, args []string) error {
 	cyan := color.New(color.FgCyan).SprintFunc()
 	yellow := color.New(color.FgYellow).SprintFunc()
 
+	retention := "forever"
+	if cfg.retentionDays > 0 {
+		retention = fmt.Sprintf("%dd", cfg.retentionDays)
+	}
+
 	fmt.Println()
-	fmt.Printf("Available backups (max: %d):\n", cfg.maxBackups)
+	fmt.Printf("Available backups (max: %d, retention: %s):\n", cfg.maxBackups, retention)
 	fmt.Println("==========================================")
 	fmt.Println()
 
-	entries, err := os.ReadDir(cfg.backupDir)
+	backups, err := listBackups(cfg)
 	if err != nil {
-		if os.IsNotExist(err) {
-			fmt.Println("No backups found.")
-			return nil
-		}
-		return fmt.Errorf("reading backup directory: %w", err)
-	}
-
-	var backups []os.DirEntry
-	for _, e := range entries {
-		// Support both naming conventions: backup- (bash) and backup_ (legacy Go)
-		if (strings.HasPrefix(e.Name(), "backup-") || strings.HasPrefix(e.Name(), "backup_")) &&
-			(strings.HasSuffix(e.Name(), ".tar.gz") || strings.HasSuffix(e.Name(), ".tar")) {
-			backups = append(backups, e)
-		}
+		return err
 	}
 
 	if len(backups) == 0 {
@@ -305,48 +672,26 @@ func runBackupList(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Sort by name (which includes timestamp)
-	sort.Slice(backups, func(i, j int) bool {
-		return backups[i].Name() > backups[j].Name() // Newest first
-	})
-
 	for i, b := range backups {
-		info, _ := b.Info()
-		name := b.Name()
-
-		// Parse timestamp from name
-		timestamp := strings.TrimPrefix(name, "backup_")
-		timestamp = strings.TrimSuffix(timestamp, ".tar.gz")
-
-		// Format size
-		size := "?"
-		if info != nil {
-			size = formatSize(info.Size())
-		}
-
 		marker := " "
 		if i == 0 {
 			marker = yellow("→")
 		}
 
-		fmt.Printf("  %s %s  %s  %s\n", marker, cyan(timestamp), size, name)
+		legacy := ""
+		if filepath.Dir(b.Path) == cfg.legacyDir && cfg.legacyDir != cfg.backupDir {
+			legacy = Dim.Sprint(" (legacy)")
+		}
+
+		fmt.Printf("  %s %-32s %s  %8s%s\n", marker, cyan(b.ID), b.ModTime.Format("2006-01-02 15:04"), formatSize(b.Size), legacy)
 	}
 
 	fmt.Println()
-	fmt.Println("Restore with: blackdot backup restore [backup-name]")
+	fmt.Println("Restore with: blackdot backup restore [backup-id]")
 	fmt.Printf("Location: %s\n", cfg.backupDir)
 	return nil
 }
 
-// runBackupRestoreWithDryRun wraps runBackupRestore with dry-run support
-func runBackupRestoreWithDryRun(cmd *cobra.Command, args []string, dryRun bool) error {
-	return runBackupRestoreImpl(cmd, args, dryRun)
-}
-
-func runBackupRestore(cmd *cobra.Command, args []string) error {
-	return runBackupRestoreImpl(cmd, args, false)
-}
-
 func runBackupRestoreImpl(cmd *cobra.Command, args []string, dryRun bool) error {
 	cfg := getBackupConfig()
 	home, _ := os.UserHomeDir()
@@ -356,58 +701,15 @@ func runBackupRestoreImpl(cmd *cobra.Command, args []string, dryRun bool) error
 	cyan := color.New(color.FgCyan).SprintFunc()
 
 	// Find backup to restore
-	var backupPath string
+	id := ""
 	if len(args) > 0 {
-		// Specific backup requested - try multiple naming patterns
-		patterns := []string{
-			filepath.Join(cfg.backupDir, fmt.Sprintf("backup-%s.tar.gz", args[0])),
-			filepath.Join(cfg.backupDir, fmt.Sprintf("backup_%s.tar.gz", args[0])),
-			filepath.Join(cfg.backupDir, fmt.Sprintf("%s.tar.gz", args[0])),
-			filepath.Join(cfg.backupDir, args[0]),
-		}
-		for _, p := range patterns {
-			if _, err := os.Stat(p); err == nil {
-				backupPath = p
-				break
-			}
-		}
-		if backupPath == "" {
-			return fmt.Errorf("backup not found: %s", args[0])
-		}
-	} else {
-		// Find latest backup by modification time
-		entries, err := os.ReadDir(cfg.backupDir)
-		if err != nil {
-			return fmt.Errorf("reading backup directory: %w", err)
-		}
-
-		var latestName string
-		var latestTime time.Time
-		for _, e := range entries {
-			// Support both naming conventions
-			if (strings.HasPrefix(e.Name(), "backup-") || strings.HasPrefix(e.Name(), "backup_")) &&
-				(strings.HasSuffix(e.Name(), ".tar.gz") || strings.HasSuffix(e.Name(), ".tar")) {
-				info, err := e.Info()
-				if err != nil {
-					continue
-				}
-				if info.ModTime().After(latestTime) {
-					latestTime = info.ModTime()
-					latestName = e.Name()
-				}
-			}
-		}
-
-		if latestName == "" {
-			return fmt.Errorf("no backups found in %s", cfg.backupDir)
-		}
-		backupPath = filepath.Join(cfg.backupDir, latestName)
+		id = args[0]
 	}
-
-	// Check backup exists
-	if _, err := os.Stat(backupPath); err != nil {
-		return fmt.Errorf("backup not found: %s", backupPath)
+	archive, err := findBackup(cfg, id)
+	if err != nil {
+		return err
 	}
+	backupPath := archive.Path
 
 	fmt.Println()
 	if dryRun {
@@ -420,19 +722,11 @@ func runBackupRestoreImpl(cmd *cobra.Command, args []string, dryRun bool) error
 	fmt.Printf("From: %s\n\n", backupPath)
 
 	// Open backup
-	file, err := os.Open(backupPath)
-	if err != nil {
-		return fmt.Errorf("opening backup: %w", err)
-	}
-	defer file.Close()
-
-	gr, err := gzip.NewReader(file)
+	tr, closeArchive, err := openBackupArchive(backupPath)
 	if err != nil {
-		return fmt.Errorf("decompressing backup: %w", err)
+		return err
 	}
-	defer gr.Close()
-
-	tr := tar.NewReader(gr)
+	defer closeArchive()
 
 	// Detect archive format - bash wraps in backup-YYYYMMDD-HHMMSS/ directory
 	var wrapperDir string
@@ -456,7 +750,7 @@ func runBackupRestoreImpl(cmd *cobra.Command, args []string, dryRun bool) error
 			continue
 		}
 
-		// Skip manifest.json (bash metadata file)
+		// Skip manifest.json (metadata file)
 		if strings.HasSuffix(header.Name, "manifest.json") {
 			continue
 		}
@@ -467,6 +761,12 @@ func runBackupRestoreImpl(cmd *cobra.Command, args []string, dryRun bool) error
 			relPath = strings.TrimPrefix(relPath, wrapperDir+"/")
 		}
 
+		// Refuse entries that would escape the destination
+		if filepath.IsAbs(relPath) || strings.Contains(filepath.ToSlash(filepath.Clean(relPath)), "../") || filepath.Clean(relPath) == ".." {
+			fmt.Printf("  %s %s: unsafe path, skipped\n", yellow("⚠"), relPath)
+			continue
+		}
+
 		// Determine destination
 		var destPath string
 		if strings.HasPrefix(relPath, "blackdot/") {
@@ -494,19 +794,12 @@ func runBackupRestoreImpl(cmd *cobra.Command, args []string, dryRun bool) error
 			continue
 		}
 
-		// Create file
-		outFile, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
-		if err != nil {
-			fmt.Printf("  %s %s: %v\n", yellow("⚠"), relPath, err)
-			continue
-		}
-
-		if _, err := io.Copy(outFile, tr); err != nil {
-			outFile.Close()
+		// Write through a temp file so an interrupted restore leaves the
+		// previous content rather than a truncated file
+		if err := safefile.WriteFrom(destPath, tr, os.FileMode(header.Mode).Perm()); err != nil {
 			fmt.Printf("  %s %s: %v\n", yellow("⚠"), relPath, err)
 			continue
 		}
-		outFile.Close()
 
 		fmt.Printf("  %s %s\n", green("✓"), relPath)
 		restored++
@@ -522,42 +815,62 @@ func runBackupRestoreImpl(cmd *cobra.Command, args []string, dryRun bool) error
 	return nil
 }
 
-func runBackupClean(cmd *cobra.Command, args []string) error {
-	cfg := getBackupConfig()
-	removed := cleanOldBackups(cfg)
-	fmt.Printf("Removed %d old backups (keeping newest %d)\n", removed, cfg.maxBackups)
-	return nil
-}
+func runBackupPrune(cfg *backupConfig, dryRun bool) error {
+	removed := pruneBackups(cfg, dryRun)
 
-func cleanOldBackups(cfg *backupConfig) int {
-	entries, err := os.ReadDir(cfg.backupDir)
-	if err != nil {
-		return 0
+	if len(removed) == 0 {
+		fmt.Println("No backups to remove")
+		return nil
 	}
 
-	var backups []string
-	for _, e := range entries {
-		// Support both naming conventions
-		if (strings.HasPrefix(e.Name(), "backup-") || strings.HasPrefix(e.Name(), "backup_")) &&
-			(strings.HasSuffix(e.Name(), ".tar.gz") || strings.HasSuffix(e.Name(), ".tar")) {
-			backups = append(backups, e.Name())
+	for _, b := range removed {
+		if dryRun {
+			fmt.Printf("  Would remove %s\n", b.ID)
+		} else {
+			fmt.Printf("  Removed %s\n", b.ID)
 		}
 	}
 
-	if len(backups) <= cfg.maxBackups {
-		return 0
+	retention := "no age limit"
+	if cfg.retentionDays > 0 {
+		retention = fmt.Sprintf("older than %dd removed", cfg.retentionDays)
+	}
+	if dryRun {
+		fmt.Printf("Would remove %d backups (keeping newest %d, %s)\n", len(removed), cfg.maxBackups, retention)
+	} else {
+		fmt.Printf("Removed %d backups (keeping newest %d, %s)\n", len(removed), cfg.maxBackups, retention)
 	}
+	return nil
+}
 
-	// Sort newest first
-	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+// pruneBackups applies the retention policy: keep the newest maxBackups and
+// drop anything older than retentionDays. The newest backup always survives.
+func pruneBackups(cfg *backupConfig, dryRun bool) []backupArchive {
+	backups, err := listBackups(cfg)
+	if err != nil {
+		return nil
+	}
+
+	var cutoff time.Time
+	if cfg.retentionDays > 0 {
+		cutoff = time.Now().AddDate(0, 0, -cfg.retentionDays)
+	}
 
-	// Remove old ones
-	var removed int
-	for i := cfg.maxBackups; i < len(backups); i++ {
-		path := filepath.Join(cfg.backupDir, backups[i])
-		if err := os.Remove(path); err == nil {
-			removed++
+	var removed []backupArchive
+	for i, b := range backups {
+		if i == 0 {
+			continue
+		}
+		expired := !cutoff.IsZero() && b.ModTime.Before(cutoff)
+		if i < cfg.maxBackups && !expired {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(b.Path); err != nil {
+				continue
+			}
 		}
+		removed = append(removed, b)
 	}
 
 	return removed
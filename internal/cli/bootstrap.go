@@ -0,0 +1,356 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type bootstrapOptions struct {
+	Port         string
+	Identity     string
+	ForwardAgent bool
+	Version      string
+	LocalBinary  bool
+	InstallDir   string
+	Repo         string
+	NoRepo       bool
+	Features     []string
+	Backend      string
+	Restore      bool
+	DryRun       bool
+}
+
+// remotePlatform is what the probe learned about the host
+type remotePlatform struct {
+	GOOS   string
+	GOARCH string
+}
+
+func newBootstrapCmd() *cobra.Command {
+	var opts bootstrapOptions
+
+	cmd := &cobra.Command{
+		Use:   "bootstrap [user@]host",
+		Short: "Install blackdot and your config on a remote host over SSH",
+		Long: `Onboard a server in one command. Over SSH, bootstrap:
+
+  1. Detects the host's OS and architecture
+  2. Installs the matching blackdot release binary (downloaded and
+     checksum-verified here, then copied over SSH) to ~/.local/bin
+  3. Clones your dotfiles repository to ~/.blackdot
+  4. Applies a pairing code with your vault backend and enabled features
+     (see 'blackdot pair'); --features and --backend override them
+  5. With --restore, runs 'blackdot vault pull' in an interactive session
+     so you can unlock the vault on the host
+
+No secrets are copied: the host gets non-secret config only and reads
+secrets from the vault itself. --forward-agent (-A) forwards your SSH
+agent so a private repository can be cloned and SSH-based vault access
+works during the bootstrap. Only forward your agent to hosts you trust.
+
+Linux and macOS hosts are supported.
+
+Examples:
+  blackdot bootstrap ubuntu@10.0.0.5
+  blackdot bootstrap -A --restore me@devbox
+  blackdot bootstrap --features vault,aws_helpers --backend pass ci@runner
+  blackdot bootstrap --local-binary me@vm     # Copy this binary (same OS/arch)
+  blackdot bootstrap -n me@vm                 # Show the plan`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			return runBootstrap(ctx, args[0], opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Port, "port", "p", "", "SSH port")
+	cmd.Flags().StringVarP(&opts.Identity, "identity", "i", "", "SSH identity file")
+	cmd.Flags().BoolVarP(&opts.ForwardAgent, "forward-agent", "A", false, "Forward the SSH agent to the host")
+	cmd.Flags().StringVar(&opts.Version, "version", "", "Release tag to install (default: latest)")
+	cmd.Flags().BoolVar(&opts.LocalBinary, "local-binary", false, "Copy this blackdot binary instead of downloading a release")
+	cmd.Flags().StringVar(&opts.InstallDir, "install-dir", "~/.local/bin", "Where to install blackdot on the host")
+	cmd.Flags().StringVar(&opts.Repo, "repo", "", "Dotfiles repository to clone (default: this repository's origin)")
+	cmd.Flags().BoolVar(&opts.NoRepo, "no-repo", false, "Don't clone the dotfiles repository")
+	cmd.Flags().StringSliceVar(&opts.Features, "features", nil, "Features to enable (default: those enabled here)")
+	cmd.Flags().StringVar(&opts.Backend, "backend", "", "Vault backend (default: this machine's)")
+	cmd.Flags().BoolVar(&opts.Restore, "restore", false, "Run 'blackdot vault pull' on the host afterwards")
+	cmd.Flags().BoolVarP(&opts.DryRun, "dry-run", "n", false, "Show what would run on the host")
+	return cmd
+}
+
+// bootstrapSSHArgs builds the ssh arguments for one remote command. tty
+// allocates a terminal for interactive steps.
+func bootstrapSSHArgs(target string, opts bootstrapOptions, tty bool, command string) []string {
+	var args []string
+	if opts.Port != "" {
+		args = append(args, "-p", opts.Port)
+	}
+	if opts.Identity != "" {
+		args = append(args, "-i", expandPath(opts.Identity))
+	}
+	if opts.ForwardAgent {
+		args = append(args, "-A")
+	}
+	if tty {
+		args = append(args, "-t")
+	}
+	return append(args, target, command)
+}
+
+// remotePath quotes a path for the remote shell, keeping a leading ~/ as
+// $HOME so it expands there rather than here
+func remotePath(p string) string {
+	if p == "~" {
+		return `"$HOME"`
+	}
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		return `"$HOME"/` + shellQuote(rest)
+	}
+	return shellQuote(p)
+}
+
+// parseRemotePlatform maps `uname -s; uname -m` output to a release platform
+func parseRemotePlatform(out string) (remotePlatform, error) {
+	var p remotePlatform
+	fields := strings.Fields(out)
+	if len(fields) < 2 {
+		return p, fmt.Errorf("unexpected uname output %q", strings.TrimSpace(out))
+	}
+	switch fields[0] {
+	case "Linux":
+		p.GOOS = "linux"
+	case "Darwin":
+		p.GOOS = "darwin"
+	default:
+		return p, fmt.Errorf("unsupported host OS %s (Linux and macOS hosts are supported)", fields[0])
+	}
+	switch fields[1] {
+	case "x86_64", "amd64":
+		p.GOARCH = "amd64"
+	case "aarch64", "arm64":
+		p.GOARCH = "arm64"
+	default:
+		return p, fmt.Errorf("unsupported host architecture %s", fields[1])
+	}
+	return p, nil
+}
+
+// bootstrapRepoURL is the repository the host clones: --repo, or this
+// repository's origin with credentials stripped
+func bootstrapRepoURL(opts bootstrapOptions) string {
+	if opts.Repo != "" {
+		return opts.Repo
+	}
+	out, err := exec.Command("git", "-C", BlackdotDir(), "remote", "get-url", "origin").Output()
+	if err != nil {
+		return ""
+	}
+	return sanitizePairURL(strings.TrimSpace(string(out)))
+}
+
+// bootstrapPayload is this machine's pairing payload with the overrides
+func bootstrapPayload(opts bootstrapOptions) (pairPayload, error) {
+	p := collectPairPayload()
+	if opts.Features != nil {
+		p.Features = opts.Features
+	}
+	if opts.Backend != "" {
+		if err := validateBackendName(opts.Backend); err != nil {
+			return p, err
+		}
+		p.Backend = opts.Backend
+	}
+	// Overlay repos live on this machine's paths; the host only gets the
+	// main repository
+	p.Repos = nil
+	return p, nil
+}
+
+// bootstrapStep is one command run on the host
+type bootstrapStep struct {
+	desc    string
+	command string
+	upload  bool // the binary is streamed to stdin
+	tty     bool
+}
+
+// bootstrapSteps is the plan for a host
+func bootstrapSteps(opts bootstrapOptions, repoURL, code string) []bootstrapStep {
+	dir := remotePath(strings.TrimSuffix(opts.InstallDir, "/"))
+	bin := dir + "/blackdot"
+	steps := []bootstrapStep{{
+		desc:    "Install blackdot to " + opts.InstallDir,
+		command: fmt.Sprintf("set -e; mkdir -p %[1]s; cat > %[1]s/.blackdot.new; chmod 755 %[1]s/.blackdot.new; mv -f %[1]s/.blackdot.new %[2]s; %[2]s version", dir, bin),
+		upload:  true,
+	}}
+	if repoURL != "" {
+		steps = append(steps, bootstrapStep{
+			desc: "Clone " + repoURL + " to ~/.blackdot",
+			command: fmt.Sprintf(`if [ -d "$HOME/.blackdot/.git" ]; then git -C "$HOME/.blackdot" pull --ff-only; else git clone %s "$HOME/.blackdot"; fi`,
+				shellQuote(repoURL)),
+		})
+	}
+	steps = append(steps, bootstrapStep{
+		desc:    "Apply vault backend and features",
+		command: fmt.Sprintf("%s pair import --yes %s", bin, code),
+	})
+	if opts.Restore {
+		steps = append(steps, bootstrapStep{
+			desc:    "Restore secrets from the vault",
+			command: bin + " vault pull",
+			tty:     true,
+		})
+	}
+	return steps
+}
+
+func runBootstrap(ctx context.Context, target string, opts bootstrapOptions) error {
+	if _, err := exec.LookPath("ssh"); err != nil {
+		Fail("ssh not found")
+		return err
+	}
+
+	p, err := bootstrapPayload(opts)
+	if err != nil {
+		return err
+	}
+	code, err := encodePairCode(p)
+	if err != nil {
+		return err
+	}
+	repoURL := ""
+	if !opts.NoRepo {
+		repoURL = bootstrapRepoURL(opts)
+	}
+	steps := bootstrapSteps(opts, repoURL, code)
+
+	PrintHeader("Bootstrap " + target)
+	printPairPayload(p)
+	fmt.Println()
+
+	if opts.DryRun {
+		DryRun("Would run on %s:", target)
+		for _, step := range steps {
+			fmt.Printf("  %s\n", step.desc)
+			fmt.Printf("    %s\n", Dim.Sprint("ssh "+strings.Join(bootstrapSSHArgs(target, opts, step.tty, step.command), " ")))
+		}
+		return nil
+	}
+	if repoURL == "" && !opts.NoRepo {
+		Warn("No git remote for %s - skipping the repository clone (use --repo)", tildePath(BlackdotDir()))
+	}
+
+	Info("Connecting to %s...", target)
+	out, err := exec.CommandContext(ctx, "ssh", bootstrapSSHArgs(target, opts, false, "uname -s; uname -m")...).Output()
+	if err != nil {
+		Fail("Cannot reach %s: %v", target, err)
+		return err
+	}
+	platform, err := parseRemotePlatform(string(out))
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+	Pass("Host is %s/%s", platform.GOOS, platform.GOARCH)
+
+	binary, cleanup, err := bootstrapBinary(ctx, platform, opts)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	for _, step := range steps {
+		Info("%s...", step.desc)
+		c := exec.CommandContext(ctx, "ssh", bootstrapSSHArgs(target, opts, step.tty, step.command)...)
+		var stderr bytes.Buffer
+		if step.tty {
+			c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		} else {
+			c.Stderr = &stderr
+		}
+		if step.upload {
+			f, err := os.Open(binary)
+			if err != nil {
+				Fail("Cannot read %s: %v", binary, err)
+				return err
+			}
+			defer f.Close()
+			c.Stdin = f
+		}
+		if err := c.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				fmt.Fprintln(os.Stderr, Dim.Sprint(msg))
+			}
+			Fail("%s failed: %v", step.desc, err)
+			return err
+		}
+		Pass("%s", step.desc)
+	}
+
+	fmt.Println()
+	Pass("%s is bootstrapped", target)
+	fmt.Println()
+	fmt.Println("On the host:")
+	fmt.Printf("  export PATH=\"%s:$PATH\"   %s\n", opts.InstallDir, Dim.Sprint("# if it is not on PATH yet"))
+	fmt.Println("  blackdot setup")
+	if !opts.Restore {
+		fmt.Println("  blackdot vault pull")
+	}
+	return nil
+}
+
+// bootstrapBinary returns a local copy of the blackdot binary for the
+// host's platform: this executable with --local-binary, otherwise the
+// verified release download
+func bootstrapBinary(ctx context.Context, platform remotePlatform, opts bootstrapOptions) (string, func(), error) {
+	noop := func() {}
+	if opts.LocalBinary {
+		if platform.GOOS != runtime.GOOS || platform.GOARCH != runtime.GOARCH {
+			err := fmt.Errorf("--local-binary needs a %s/%s host, not %s/%s", runtime.GOOS, runtime.GOARCH, platform.GOOS, platform.GOARCH)
+			Fail("%v", err)
+			return "", noop, err
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			Fail("Cannot locate the running binary: %v", err)
+			return "", noop, err
+		}
+		return exe, noop, nil
+	}
+
+	release, err := fetchRelease(ctx, opts.Version)
+	if err != nil {
+		Fail("Cannot look up release: %v", err)
+		return "", noop, err
+	}
+	name := releaseAssetName(platform.GOOS, platform.GOARCH)
+	asset := release.asset(name)
+	if asset == nil {
+		err := fmt.Errorf("release %s has no %s", release.TagName, name)
+		Fail("%v", err)
+		return "", noop, err
+	}
+	expected, err := releaseChecksum(ctx, release, name)
+	if err != nil {
+		Fail("Cannot verify release: %v", err)
+		return "", noop, err
+	}
+	Info("Downloading %s %s...", name, release.TagName)
+	path, err := downloadVerified(ctx, asset.URL, os.TempDir(), expected)
+	if err != nil {
+		Fail("Download failed: %v", err)
+		return "", noop, err
+	}
+	Pass("Checksum verified")
+	return path, func() { os.Remove(path) }, nil
+}
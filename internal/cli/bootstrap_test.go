@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestParseRemotePlatform verifies uname output maps to release platforms
+// and unsupported hosts are refused
+func TestParseRemotePlatform(t *testing.T) {
+	cases := map[string]string{
+		"Linux\nx86_64\n":      "linux/amd64",
+		"Linux\naarch64\n":     "linux/arm64",
+		"Darwin\narm64\n":      "darwin/arm64",
+		"MINGW64_NT\nx86_64\n": "",
+		"Linux\nmips\n":        "",
+		"":                     "",
+	}
+	for out, want := range cases {
+		p, err := parseRemotePlatform(out)
+		got := ""
+		if err == nil {
+			got = p.GOOS + "/" + p.GOARCH
+		}
+		if got != want {
+			t.Errorf("%q: got %q (%v), want %q", out, got, err, want)
+		}
+	}
+}
+
+// TestBootstrapSteps verifies the remote commands install the binary under
+// the host's $HOME, clone the repository once, and apply the pairing code
+func TestBootstrapSteps(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("remote commands are POSIX shell")
+	}
+	home := t.TempDir()
+	origin := filepath.Join(t.TempDir(), "dotfiles")
+	if out, err := exec.Command("git", "init", "-q", origin).CombinedOutput(); err != nil {
+		t.Skipf("git unavailable: %s", out)
+	}
+	if out, err := exec.Command("git", "-C", origin, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "--allow-empty", "-m", "init").CombinedOutput(); err != nil {
+		t.Fatalf("commit: %s", out)
+	}
+
+	opts := bootstrapOptions{InstallDir: "~/.local/bin", Restore: true}
+	steps := bootstrapSteps(opts, origin, "BD1-TEST")
+	if len(steps) != 4 || !steps[0].upload || !steps[3].tty {
+		t.Fatalf("steps = %+v", steps)
+	}
+	if args := bootstrapSSHArgs("me@host", bootstrapOptions{Port: "2222", ForwardAgent: true}, true, "true"); strings.Join(args, " ") != "-p 2222 -A -t me@host true" {
+		t.Errorf("ssh args = %v", args)
+	}
+
+	// A stand-in binary records how it was called
+	fake := "#!/bin/sh\necho \"$@\" >> \"$HOME/calls\"\n"
+	run := func(step bootstrapStep) {
+		t.Helper()
+		c := exec.Command("sh", "-c", step.command)
+		c.Env = append(os.Environ(), "HOME="+home)
+		if step.upload {
+			c.Stdin = strings.NewReader(fake)
+		}
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("%s: %v\n%s", step.desc, err, out)
+		}
+	}
+	for _, step := range steps[:3] {
+		run(step)
+	}
+	run(steps[1]) // a second bootstrap pulls instead of cloning
+
+	info, err := os.Stat(filepath.Join(home, ".local", "bin", "blackdot"))
+	if err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Fatalf("binary not installed executable: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".blackdot", ".git")); err != nil {
+		t.Errorf("repository not cloned: %v", err)
+	}
+	calls, _ := os.ReadFile(filepath.Join(home, "calls"))
+	if string(calls) != "version\npair import --yes BD1-TEST\n" {
+		t.Errorf("calls = %q", calls)
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/plan"
+	"github.com/spf13/cobra"
+)
+
+// addPlanFormatFlag registers --plan-format on a command with --dry-run
+func addPlanFormatFlag(cmd *cobra.Command, format *string) {
+	cmd.Flags().StringVar(format, "plan-format", "table", "With --dry-run, print the change plan as: table, json")
+}
+
+// startChangePlan returns the plan a dry run records its changes in and a
+// func to defer that prints it when the command is done. Outside a dry
+// run the plan is nil, so recording into it does nothing. With json the
+// command's other output goes to stderr and stdout holds only the plan.
+func startChangePlan(command string, dryRun bool, format string) (*plan.Plan, func(), error) {
+	if !dryRun {
+		return nil, func() {}, nil
+	}
+	switch format {
+	case "", "table", "json":
+	default:
+		return nil, nil, fmt.Errorf("invalid --plan-format %q (use table or json)", format)
+	}
+	changes := plan.New(command)
+	if format != "json" {
+		return changes, func() { printChangePlan(changes) }, nil
+	}
+	restore := redirectStdoutForReport("-")
+	return changes, func() {
+		restore()
+		if err := changes.WriteJSON(os.Stdout); err != nil {
+			Warn("Failed to write plan: %v", err)
+		}
+	}, nil
+}
+
+// planKindMark is the symbol the plan table shows for a kind
+func planKindMark(kind plan.Kind) string {
+	switch kind {
+	case plan.Create:
+		return Green.Sprint("+")
+	case plan.Update:
+		return Yellow.Sprint("~")
+	case plan.Delete:
+		return Red.Sprint("-")
+	case plan.Symlink:
+		return Cyan.Sprint("→")
+	}
+	return Cyan.Sprint("*")
+}
+
+// printChangePlan prints the plan as aligned columns: kind, target, and
+// the before → after change, with details dimmed
+func printChangePlan(changes *plan.Plan) {
+	fmt.Println()
+	fmt.Printf("Change plan (%s)\n", changes.Summary())
+	if len(changes.Ops) == 0 {
+		return
+	}
+	targets := make([]string, len(changes.Ops))
+	width := 0
+	for i, op := range changes.Ops {
+		targets[i] = op.Target
+		if !strings.HasPrefix(op.Target, "vault:") {
+			targets[i] = tildePath(op.Target)
+		}
+		width = max(width, len(targets[i]))
+	}
+	for i, op := range changes.Ops {
+		line := fmt.Sprintf("  %s %-7s %-*s", planKindMark(op.Kind), op.Kind, width, targets[i])
+		if op.Before != "" || op.After != "" {
+			line += "  " + valueOr(op.Before, "-") + " → " + valueOr(op.After, "-")
+		}
+		if op.Detail != "" {
+			line += "  " + Dim.Sprintf("(%s)", op.Detail)
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+}
+
+// describeFileForPlan is the before state of a path in a plan: its size,
+// "link → dest" for a symlink, or empty when it does not exist
+func describeFileForPlan(path string) string {
+	info, err := os.Lstat(path)
+	switch {
+	case err != nil:
+		return ""
+	case info.Mode()&os.ModeSymlink != 0:
+		dest, _ := os.Readlink(path)
+		return "link → " + tildePath(dest)
+	case info.IsDir():
+		return "directory"
+	}
+	return formatSize(info.Size())
+}
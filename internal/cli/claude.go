@@ -0,0 +1,663 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// claudeProfilesItem is the vault item holding ~/.claude/profiles.json
+const claudeProfilesItem = "Claude-Profiles"
+
+// claudeProfiles is ~/.claude/profiles.json. Profiles are kept raw so
+// fields blackdot doesn't know (written by dotclaude, say) survive a save.
+type claudeProfiles struct {
+	Active   string                     `json:"active"`
+	Profiles map[string]json.RawMessage `json:"profiles"`
+}
+
+// claudeProfile is what blackdot reads from a profile
+type claudeProfile struct {
+	Backend     string            `json:"backend,omitempty"` // bedrock or max
+	Description string            `json:"description,omitempty"`
+	Created     string            `json:"created,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+}
+
+// claudeProfileName is what a profile may be called: it ends up in the
+// CLAUDE.md template and in shell exports
+var claudeProfileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func claudeDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".claude")
+}
+
+func claudeProfilesPath() string {
+	return filepath.Join(claudeDir(), "profiles.json")
+}
+
+// loadClaudeProfiles reads profiles.json; a missing file is no profiles
+func loadClaudeProfiles() (*claudeProfiles, error) {
+	data, err := os.ReadFile(claudeProfilesPath())
+	if os.IsNotExist(err) {
+		return &claudeProfiles{Profiles: map[string]json.RawMessage{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseClaudeProfiles(data)
+}
+
+func parseClaudeProfiles(data []byte) (*claudeProfiles, error) {
+	var p claudeProfiles
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("profiles.json: %w", err)
+	}
+	if p.Profiles == nil {
+		p.Profiles = map[string]json.RawMessage{}
+	}
+	for name := range p.Profiles {
+		if _, err := p.profile(name); err != nil {
+			return nil, err
+		}
+	}
+	if p.Active != "" && p.Profiles[p.Active] == nil {
+		return nil, fmt.Errorf("profiles.json: active profile %q is not defined", p.Active)
+	}
+	return &p, nil
+}
+
+func saveClaudeProfiles(p *claudeProfiles) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(claudeDir(), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(claudeProfilesPath(), append(data, '\n'), 0600)
+}
+
+// profile decodes one profile
+func (p *claudeProfiles) profile(name string) (claudeProfile, error) {
+	var profile claudeProfile
+	raw, ok := p.Profiles[name]
+	if !ok {
+		return profile, fmt.Errorf("no Claude profile %q (see: blackdot claude profiles)", name)
+	}
+	if err := json.Unmarshal(raw, &profile); err != nil {
+		return profile, fmt.Errorf("profiles.json: %s: %w", name, err)
+	}
+	switch profile.Backend {
+	case "", "bedrock", "max":
+	default:
+		return profile, fmt.Errorf("profiles.json: %s: unknown backend %q (expected bedrock or max)", name, profile.Backend)
+	}
+	return profile, nil
+}
+
+// names returns the profile names in order
+func (p *claudeProfiles) names() []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// activeClaudeProfile is the active profile's name, or "" without one
+func activeClaudeProfile() string {
+	if p, err := loadClaudeProfiles(); err == nil {
+		return p.Active
+	}
+	return ""
+}
+
+// claudeProfileExports are the shell commands that switch to a profile:
+// its backend's settings, then its own env
+func claudeProfileExports(profile claudeProfile, cfg claudeConfig) ([]string, error) {
+	var lines []string
+	switch profile.Backend {
+	case "bedrock":
+		if profile.Env["AWS_PROFILE"] != "" {
+			cfg.BedrockProfile = profile.Env["AWS_PROFILE"]
+		}
+		if cfg.BedrockProfile == "" {
+			return nil, fmt.Errorf("bedrock profile needs CLAUDE_BEDROCK_PROFILE or an AWS_PROFILE in its env")
+		}
+		lines = claudeBedrockExports(cfg)
+	case "max":
+		lines = append(lines, claudeMaxUnsets...)
+	}
+
+	keys := make([]string, 0, len(profile.Env))
+	for k := range profile.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("export %s=%s", k, shellQuote(profile.Env[k])))
+	}
+	return lines, nil
+}
+
+func newClaudeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "claude",
+		Short: "Claude Code profiles, settings and CLAUDE.md",
+		Long: `Manage Claude Code configuration natively, without dotclaude.
+
+Profiles live in ~/.claude/profiles.json (the Claude-Profiles vault
+item): each names a backend (bedrock or max) and extra environment
+variables. Using a profile re-renders the CLAUDE.md template, if you have
+one, and links ~/.claude/CLAUDE.md to it.
+
+Commands:
+  status     - Configuration, active profile, settings.json and CLAUDE.md
+  profiles   - List, create, use and remove profiles
+  sync       - Sync profiles.json with the vault
+
+Backend switching without profiles stays in 'blackdot tools claude'.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runClaudeStatus()
+		},
+	}
+
+	cmd.AddCommand(
+		newClaudeStatusCmd(),
+		newClaudeProfilesCmd(),
+		newClaudeSyncCmd(),
+	)
+	return cmd
+}
+
+func newClaudeProfilesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "profiles",
+		Aliases: []string{"profile"},
+		Short:   "List Claude Code profiles",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := loadClaudeProfiles()
+			if err != nil {
+				Fail("%v", err)
+				return err
+			}
+			if len(p.Profiles) == 0 {
+				Info("No Claude profiles (create one: blackdot claude profiles create <name> --backend max)")
+				return nil
+			}
+			for _, name := range p.names() {
+				profile, _ := p.profile(name)
+				marker := "  "
+				if name == p.Active {
+					marker = Green.Sprint("* ")
+				}
+				backend := profile.Backend
+				if backend == "" {
+					backend = "-"
+				}
+				fmt.Printf("%s%-20s %-8s %s\n", marker, name, backend, Dim.Sprint(profile.Description))
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(
+		newClaudeProfilesCreateCmd(),
+		newClaudeProfilesUseCmd(),
+		newClaudeProfilesRemoveCmd(),
+	)
+	return cmd
+}
+
+// completeClaudeProfiles completes profile names
+func completeClaudeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	p, err := loadClaudeProfiles()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return p.names(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func newClaudeProfilesCreateCmd() *cobra.Command {
+	var backend, description string
+	var env []string
+
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Add a profile",
+		Example: `  blackdot claude profiles create personal --backend max
+  blackdot claude profiles create work --backend bedrock --env AWS_PROFILE=work-sso`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if !claudeProfileName.MatchString(name) {
+				return fmt.Errorf("invalid profile name %q (letters, digits, . _ -)", name)
+			}
+			profile := claudeProfile{Backend: backend, Description: description, Created: time.Now().Format("2006-01-02")}
+			for _, kv := range env {
+				k, v, ok := strings.Cut(kv, "=")
+				if !ok || k == "" {
+					return fmt.Errorf("invalid --env %q (expected NAME=value)", kv)
+				}
+				if profile.Env == nil {
+					profile.Env = map[string]string{}
+				}
+				profile.Env[k] = v
+			}
+
+			p, err := loadClaudeProfiles()
+			if err != nil {
+				Fail("%v", err)
+				return err
+			}
+			if _, exists := p.Profiles[name]; exists {
+				return fmt.Errorf("Claude profile %q already exists", name)
+			}
+			raw, err := json.Marshal(profile)
+			if err != nil {
+				return err
+			}
+			p.Profiles[name] = raw
+			if _, err := p.profile(name); err != nil {
+				return err
+			}
+			if err := saveClaudeProfiles(p); err != nil {
+				Fail("Failed to save %s: %v", tildePath(claudeProfilesPath()), err)
+				return err
+			}
+			Pass("Created Claude profile %s", name)
+			Info("Switch to it with: blackdot claude profiles use %s", name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&backend, "backend", "", "Backend: bedrock or max")
+	cmd.Flags().StringVar(&description, "description", "", "What the profile is for")
+	cmd.Flags().StringArrayVar(&env, "env", nil, "Environment variable NAME=value (repeatable)")
+	cmd.RegisterFlagCompletionFunc("backend", cobra.FixedCompletions([]string{"bedrock", "max"}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+func newClaudeProfilesUseCmd() *cobra.Command {
+	var evalMode bool
+
+	cmd := &cobra.Command{
+		Use:   "use <name>",
+		Short: "Make a profile active and print its environment",
+		Long: `Make a profile active, re-render and link CLAUDE.md for it, and print
+the exports that switch the current shell to its backend.
+
+Use with eval to switch the current shell:
+  eval "$(blackdot claude profiles use work --eval)"
+
+The claude-profile shell function does this for you.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeClaudeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runClaudeProfilesUse(args[0], evalMode)
+		},
+	}
+
+	cmd.Flags().BoolVar(&evalMode, "eval", false, "Print only the exports, for eval")
+	return cmd
+}
+
+func runClaudeProfilesUse(name string, evalMode bool) error {
+	// With --eval stdout is evaluated by the shell, so messages go to stderr
+	say := func(format string, a ...interface{}) {
+		if evalMode {
+			fmt.Fprintf(os.Stderr, format+"\n", a...)
+		} else {
+			fmt.Printf(format+"\n", a...)
+		}
+	}
+
+	p, err := loadClaudeProfiles()
+	if err != nil {
+		return err
+	}
+	profile, err := p.profile(name)
+	if err != nil {
+		return err
+	}
+	exports, err := claudeProfileExports(profile, getClaudeConfig())
+	if err != nil {
+		return err
+	}
+
+	if p.Active != name {
+		p.Active = name
+		if err := saveClaudeProfiles(p); err != nil {
+			return fmt.Errorf("saving %s: %w", tildePath(claudeProfilesPath()), err)
+		}
+	}
+	say("%s Active Claude profile: %s", Green.Sprint("✓"), name)
+
+	if target, err := linkClaudeMD(); err != nil {
+		say("%s CLAUDE.md not updated: %v", Yellow.Sprint("!"), err)
+	} else if target != "" {
+		say("%s Linked %s", Green.Sprint("✓"), tildePath(target))
+	}
+
+	if evalMode {
+		for _, line := range exports {
+			fmt.Println(line)
+		}
+		return nil
+	}
+	if len(exports) > 0 {
+		fmt.Println()
+		fmt.Println("Run the following to switch this shell:")
+		fmt.Println()
+		for _, line := range exports {
+			fmt.Printf("  %s\n", line)
+		}
+		fmt.Println()
+		fmt.Printf("Or use: eval \"$(blackdot claude profiles use %s --eval)\"\n", name)
+	}
+	return nil
+}
+
+func newClaudeProfilesRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "remove <name>",
+		Aliases:           []string{"rm"},
+		Short:             "Delete a profile",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeClaudeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := loadClaudeProfiles()
+			if err != nil {
+				return err
+			}
+			if _, ok := p.Profiles[args[0]]; !ok {
+				return fmt.Errorf("no Claude profile %q", args[0])
+			}
+			delete(p.Profiles, args[0])
+			if p.Active == args[0] {
+				p.Active = ""
+			}
+			if err := saveClaudeProfiles(p); err != nil {
+				return err
+			}
+			Pass("Removed Claude profile %s", args[0])
+			return nil
+		},
+	}
+}
+
+// claudeMDTemplate is the template ~/.claude/CLAUDE.md is rendered from
+const claudeMDTemplate = "CLAUDE.md.tmpl"
+
+// linkClaudeMD renders the CLAUDE.md template (which sees the active
+// profile as claude_profile) and deploys it to its target. It returns the
+// target, or "" when there is no template.
+func linkClaudeMD() (string, error) {
+	cfg, err := getTemplateConfig()
+	if err != nil {
+		return "", err
+	}
+	templates, err := resolveTemplatePaths(cfg, []string{claudeMDTemplate})
+	if err != nil || !fileExists(templates[0]) {
+		return "", err
+	}
+	targets, err := templateTargets(cfg, templates)
+	if err != nil || len(targets) == 0 {
+		return "", err
+	}
+	t := targets[0]
+
+	engine := newTemplateEngine(cfg)
+	if err := loadTemplateVariables(engine, cfg); err != nil {
+		return "", err
+	}
+	result, err := engine.RenderFile(t.Template)
+	if err != nil {
+		return "", err
+	}
+	rendered := loadRenderedState()
+	if _, edited := rendered.editedByHand(t.Name, t.Output); edited {
+		return "", fmt.Errorf("generated/%s was edited by hand (see: blackdot template render)", t.Name)
+	}
+	if err := os.MkdirAll(cfg.generatedDir, 0755); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(t.Output, []byte(result), generatedFileMode()); err != nil {
+		return "", err
+	}
+	if err := rendered.record(t.Name, t.Template, result); err != nil {
+		return "", err
+	}
+	return t.Target, deployTemplateTarget(t, rendered)
+}
+
+func newClaudeSyncCmd() *cobra.Command {
+	var push, pull, dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Sync ~/.claude/profiles.json with the vault (" + claudeProfilesItem + ")",
+		Long: `Compare ~/.claude/profiles.json with the ` + claudeProfilesItem + ` vault item and copy
+whichever side exists to the other. When both exist and differ, choose
+with --push (local wins) or --pull (vault wins).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if push && pull {
+				return fmt.Errorf("--push and --pull cannot be combined")
+			}
+			return runClaudeSync(push, pull, dryRun)
+		},
+	}
+
+	cmd.Flags().BoolVar(&push, "push", false, "Overwrite the vault item with the local file")
+	cmd.Flags().BoolVar(&pull, "pull", false, "Overwrite the local file with the vault item")
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be copied")
+	return cmd
+}
+
+func runClaudeSync(push, pull, dryRun bool) error {
+	local, err := os.ReadFile(claudeProfilesPath())
+	hasLocal := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if hasLocal {
+		if _, err := parseClaudeProfiles(local); err != nil {
+			Fail("%v", err)
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	reader, err := openVaultReader(ctx)
+	if err != nil {
+		Fail("Vault not available: %v", err)
+		return err
+	}
+	defer reader.Close()
+	remote, _, err := reader.GetNotes(ctx, claudeProfilesItem)
+	hasRemote := err == nil && remote != ""
+
+	switch {
+	case !hasLocal && !hasRemote:
+		Info("No profiles.json and no %s vault item; nothing to sync", claudeProfilesItem)
+		return nil
+	case hasLocal && hasRemote && strings.TrimSpace(remote) == strings.TrimSpace(string(local)):
+		Pass("profiles.json matches %s", claudeProfilesItem)
+		return nil
+	case hasLocal && hasRemote && !push && !pull:
+		Warn("profiles.json and %s differ", claudeProfilesItem)
+		Info("Keep the local file with --push, or the vault copy with --pull")
+		return fmt.Errorf("profiles.json and %s differ", claudeProfilesItem)
+	case !hasRemote && pull:
+		return fmt.Errorf("no %s vault item to pull", claudeProfilesItem)
+	case !hasLocal && push:
+		return fmt.Errorf("no %s to push", tildePath(claudeProfilesPath()))
+	}
+
+	if hasLocal && (push || !hasRemote) {
+		if dryRun {
+			fmt.Printf("%s push %s -> %s\n", Cyan.Sprint("[dry-run]"), tildePath(claudeProfilesPath()), claudeProfilesItem)
+			return nil
+		}
+		svc, err := newVaultService()
+		if err != nil {
+			Fail("Failed to create backend: %v", err)
+			return err
+		}
+		defer svc.Close()
+		if _, err := svc.Create(ctx, claudeProfilesItem, string(local), true); err != nil {
+			failVaultOp("Failed to push "+claudeProfilesItem, err)
+			return err
+		}
+		Pass("Pushed profiles.json to %s", claudeProfilesItem)
+		return nil
+	}
+
+	p, err := parseClaudeProfiles([]byte(remote))
+	if err != nil {
+		Fail("%s: %v", claudeProfilesItem, err)
+		return err
+	}
+	if dryRun {
+		fmt.Printf("%s pull %s -> %s (%d profiles)\n", Cyan.Sprint("[dry-run]"), claudeProfilesItem, tildePath(claudeProfilesPath()), len(p.Profiles))
+		return nil
+	}
+	if err := saveClaudeProfiles(p); err != nil {
+		Fail("Failed to write %s: %v", tildePath(claudeProfilesPath()), err)
+		return err
+	}
+	Pass("Restored %d Claude profile(s) from %s", len(p.Profiles), claudeProfilesItem)
+	return nil
+}
+
+// printClaudeProfileStatus is the profiles, settings.json and CLAUDE.md
+// part of 'blackdot claude status'
+func printClaudeProfileStatus() {
+	fmt.Println(Cyan.Sprint("Profiles:"))
+	if p, err := loadClaudeProfiles(); err != nil {
+		fmt.Printf("  %s\n", Red.Sprint(err))
+	} else if len(p.Profiles) == 0 {
+		fmt.Printf("  %s\n", Dim.Sprint("none (blackdot claude profiles create <name>)"))
+	} else {
+		active := p.Active
+		if active == "" {
+			active = Yellow.Sprint("none (blackdot claude profiles use <name>)")
+		}
+		fmt.Printf("  Active:   %s\n", active)
+		fmt.Printf("  Profiles: %s\n", strings.Join(p.names(), ", "))
+		fmt.Printf("  %s\n", Dim.Sprint("Synced as vault item "+claudeProfilesItem+" (blackdot claude sync)"))
+	}
+	fmt.Println()
+
+	fmt.Println(Cyan.Sprint("Settings:"))
+	settingsPath := filepath.Join(claudeDir(), "settings.json")
+	if data, err := os.ReadFile(settingsPath); err != nil {
+		fmt.Printf("  settings.json: %s\n", Dim.Sprint("not found"))
+	} else if problems := validateClaudeSettings(data); len(problems) > 0 {
+		fmt.Printf("  settings.json: %s\n", Red.Sprintf("%d problem(s)", len(problems)))
+		for _, problem := range problems {
+			fmt.Printf("    - %s\n", problem)
+		}
+	} else {
+		fmt.Printf("  settings.json: %s\n", Green.Sprint("valid"))
+	}
+
+	claudeMD := filepath.Join(claudeDir(), "CLAUDE.md")
+	cfg, _ := getTemplateConfig()
+	switch dest, err := os.Readlink(claudeMD); {
+	case err == nil && cfg != nil && strings.HasPrefix(dest, cfg.generatedDir):
+		fmt.Printf("  CLAUDE.md:     %s\n", Green.Sprint("linked to generated/"+filepath.Base(dest)))
+	case fileExists(claudeMD):
+		fmt.Printf("  CLAUDE.md:     %s\n", "not from a template")
+	default:
+		fmt.Printf("  CLAUDE.md:     %s\n", Dim.Sprint("not found"))
+	}
+}
+
+// claudeHookEvents are the settings.json hook events Claude Code runs
+var claudeHookEvents = map[string]bool{
+	"PreToolUse": true, "PostToolUse": true, "Notification": true, "UserPromptSubmit": true,
+	"Stop": true, "SubagentStop": true, "PreCompact": true, "SessionStart": true, "SessionEnd": true,
+}
+
+// validateClaudeSettings checks settings.json: valid JSON, hooks under
+// known events with a command each, permission lists of strings, and
+// string env values. It returns one line per problem.
+func validateClaudeSettings(data []byte) []string {
+	var settings struct {
+		Hooks map[string][]struct {
+			Matcher *string `json:"matcher"`
+			Hooks   []struct {
+				Type    string `json:"type"`
+				Command string `json:"command"`
+			} `json:"hooks"`
+		} `json:"hooks"`
+		Permissions map[string]json.RawMessage `json:"permissions"`
+		Env         map[string]json.RawMessage `json:"env"`
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return []string{fmt.Sprintf("invalid JSON: %v", err)}
+	}
+
+	var problems []string
+	events := make([]string, 0, len(settings.Hooks))
+	for event := range settings.Hooks {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	for _, event := range events {
+		if !claudeHookEvents[event] {
+			problems = append(problems, fmt.Sprintf("hooks: unknown event %q", event))
+			continue
+		}
+		for i, group := range settings.Hooks[event] {
+			if len(group.Hooks) == 0 {
+				problems = append(problems, fmt.Sprintf("hooks.%s[%d]: no hooks", event, i))
+			}
+			for j, hook := range group.Hooks {
+				if hook.Type != "command" {
+					problems = append(problems, fmt.Sprintf("hooks.%s[%d].hooks[%d]: type %q (expected command)", event, i, j, hook.Type))
+				} else if strings.TrimSpace(hook.Command) == "" {
+					problems = append(problems, fmt.Sprintf("hooks.%s[%d].hooks[%d]: empty command", event, i, j))
+				}
+			}
+		}
+	}
+	for _, key := range []string{"allow", "deny", "ask"} {
+		if raw, ok := settings.Permissions[key]; ok {
+			var rules []string
+			if json.Unmarshal(raw, &rules) != nil {
+				problems = append(problems, fmt.Sprintf("permissions.%s: must be a list of strings", key))
+			}
+		}
+	}
+	keys := make([]string, 0, len(settings.Env))
+	for key := range settings.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var value string
+		if json.Unmarshal(settings.Env[key], &value) != nil {
+			problems = append(problems, fmt.Sprintf("env.%s: must be a string", key))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestClaudeProfiles verifies profiles.json round-trips with unknown
+// fields kept and that broken files are rejected
+func TestClaudeProfiles(t *testing.T) {
+	setScheduleEnv(t, "")
+	os.MkdirAll(claudeDir(), 0755)
+	os.WriteFile(claudeProfilesPath(), []byte(`{
+  "active": "work",
+  "profiles": {
+    "work": {"backend": "bedrock", "created": "2025-11-30", "path": "~/code/dotclaude/profiles/work"},
+    "personal": {"backend": "max"}
+  }
+}`), 0600)
+
+	p, err := loadClaudeProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"personal", "work"}; !reflect.DeepEqual(p.names(), want) {
+		t.Errorf("names = %v", p.names())
+	}
+	p.Active = "personal"
+	if err := saveClaudeProfiles(p); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(claudeProfilesPath())
+	if !strings.Contains(string(data), "dotclaude/profiles/work") {
+		t.Errorf("unknown profile field dropped:\n%s", data)
+	}
+	if activeClaudeProfile() != "personal" {
+		t.Errorf("active = %q", activeClaudeProfile())
+	}
+
+	for _, bad := range []string{
+		`{"active": "gone", "profiles": {}}`,
+		`{"profiles": {"x": {"backend": "vertex"}}}`,
+		`not json`,
+	} {
+		if _, err := parseClaudeProfiles([]byte(bad)); err == nil {
+			t.Errorf("accepted %s", bad)
+		}
+	}
+}
+
+func TestClaudeProfileExports(t *testing.T) {
+	lines, err := claudeProfileExports(claudeProfile{Backend: "max", Env: map[string]string{"CLAUDE_CODE_MAX_OUTPUT_TOKENS": "32000"}}, claudeConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines[0] != "unset CLAUDE_CODE_USE_BEDROCK" || lines[len(lines)-1] != "export CLAUDE_CODE_MAX_OUTPUT_TOKENS=32000" {
+		t.Errorf("max exports = %v", lines)
+	}
+
+	if _, err := claudeProfileExports(claudeProfile{Backend: "bedrock"}, claudeConfig{}); err == nil {
+		t.Error("bedrock profile without an AWS profile accepted")
+	}
+	lines, _ = claudeProfileExports(claudeProfile{Backend: "bedrock", Env: map[string]string{"AWS_PROFILE": "work sso"}}, claudeConfig{BedrockRegion: "us-west-2"})
+	if lines[0] != "export AWS_PROFILE='work sso'" {
+		t.Errorf("bedrock exports = %v", lines)
+	}
+}
+
+func TestValidateClaudeSettings(t *testing.T) {
+	shipped, err := os.ReadFile(filepath.Join("..", "..", "claude", "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := validateClaudeSettings(shipped); len(problems) > 0 {
+		t.Errorf("shipped settings.json: %v", problems)
+	}
+
+	bad := `{
+  "hooks": {
+    "BeforeTool": [],
+    "PreToolUse": [{"matcher": "Bash", "hooks": [{"type": "command", "command": " "}]}],
+    "Stop": [{"hooks": []}]
+  },
+  "permissions": {"allow": "Bash(*)"},
+  "env": {"DEBUG": 1}
+}`
+	want := []string{
+		`hooks: unknown event "BeforeTool"`,
+		"hooks.PreToolUse[0].hooks[0]: empty command",
+		"hooks.Stop[0]: no hooks",
+		"permissions.allow: must be a list of strings",
+		"env.DEBUG: must be a string",
+	}
+	if got := validateClaudeSettings([]byte(bad)); !reflect.DeepEqual(got, want) {
+		t.Errorf("problems = %q", got)
+	}
+}
+
+// TestLinkClaudeMD verifies the shipped CLAUDE.md example renders for the
+// active profile and ~/.claude/CLAUDE.md is linked to the output
+func TestLinkClaudeMD(t *testing.T) {
+	home := setupTemplateDeploy(t)
+	example, err := os.ReadFile(filepath.Join("..", "..", "templates", "configs", claudeMDTemplate+".example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := os.Getenv("BLACKDOT_DIR")
+	os.WriteFile(filepath.Join(dir, "templates", "configs", claudeMDTemplate), example, 0644)
+	os.MkdirAll(claudeDir(), 0755)
+	os.WriteFile(claudeProfilesPath(), []byte(`{"active": "work", "profiles": {"work": {"backend": "max"}}}`), 0600)
+
+	target, err := linkClaudeMD()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != filepath.Join(home, ".claude", "CLAUDE.md") {
+		t.Errorf("target = %s", target)
+	}
+	if dest, _ := os.Readlink(target); dest != filepath.Join(dir, "generated", "CLAUDE.md") {
+		t.Errorf("CLAUDE.md links to %q", dest)
+	}
+	data, _ := os.ReadFile(target)
+	if !strings.Contains(string(data), "## Work profile") || strings.Contains(string(data), "Personal profile") {
+		t.Errorf("rendered CLAUDE.md:\n%s", data)
+	}
+	if strings.Contains(string(data), "target:") {
+		t.Errorf("front-matter rendered:\n%s", data)
+	}
+}
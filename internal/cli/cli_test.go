@@ -51,6 +51,17 @@ func TestSubcommandExists(t *testing.T) {
 		"tools",
 		"import",
 		"devcontainer",
+		"git-credential",
+		"workspace",
+		"repos",
+		"schedule",
+		"links",
+		"pair",
+		"bootstrap",
+		"machines",
+		"support",
+		"scan", "upgrade", "env", "githooks",
+		"install-completions", "release", "shell",
 	}
 
 	commands := make(map[string]bool)
@@ -206,7 +217,9 @@ func TestToolsSubcommands(t *testing.T) {
 
 	expectedTools := []string{
 		"ssh",
+		"gpg",
 		"aws",
+		"k8s",
 		"cdk",
 		"go",
 		"rust",
@@ -364,7 +377,7 @@ func TestAWSSubcommands(t *testing.T) {
 	}
 
 	expectedCommands := []string{
-		"profiles", "who", "login", "switch", "assume", "clear", "status",
+		"profiles", "who", "login", "expiry", "switch", "assume", "credential-process", "clear", "status",
 	}
 
 	commands := make(map[string]bool)
@@ -389,7 +402,7 @@ func TestSSHSubcommands(t *testing.T) {
 
 	expectedCommands := []string{
 		"keys", "gen", "list", "agent", "fp", "copy", "tunnel", "socks", "status",
-		"load", "unload", "clear", "tunnels", "add-host",
+		"load", "unload", "clear", "tunnels", "add-host", "hosts",
 	}
 
 	commands := make(map[string]bool)
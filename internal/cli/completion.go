@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -12,6 +14,9 @@ func newCompletionCmd() *cobra.Command {
 		Short: "Generate shell completion script",
 		Long: `Generate shell completion script for blackdot.
 
+To install it where your shell finds it automatically, and wire it into
+~/.zshrc or $PROFILE when needed, use 'blackdot completion install'.
+
 Bash:
   # Add to ~/.bashrc or ~/.bash_profile
   source <(blackdot completion bash)
@@ -46,19 +51,25 @@ PowerShell:
 		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
 		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			switch args[0] {
-			case "bash":
-				return cmd.Root().GenBashCompletion(os.Stdout)
-			case "zsh":
-				return cmd.Root().GenZshCompletion(os.Stdout)
-			case "fish":
-				return cmd.Root().GenFishCompletion(os.Stdout, true)
-			case "powershell":
-				return cmd.Root().GenPowerShellCompletionWithDesc(os.Stdout)
-			}
-			return nil
+			return writeCompletion(cmd.Root(), args[0], os.Stdout)
 		},
 	}
 
+	cmd.AddCommand(newCompletionInstallCmd("install", "blackdot completion install"))
 	return cmd
 }
+
+// writeCompletion writes the completion script for shell
+func writeCompletion(root *cobra.Command, shell string, w io.Writer) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletion(w)
+	case "zsh":
+		return root.GenZshCompletion(w)
+	case "fish":
+		return root.GenFishCompletion(w, true)
+	case "powershell":
+		return root.GenPowerShellCompletionWithDesc(w)
+	}
+	return fmt.Errorf("unsupported shell %q (expected bash, zsh, fish, powershell)", shell)
+}
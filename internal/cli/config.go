@@ -4,10 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/blackwell-systems/blackdot/internal/config"
+	"github.com/blackwell-systems/blackdot/internal/safefile"
 	"github.com/spf13/cobra"
 )
 
@@ -18,10 +19,8 @@ var (
 )
 
 func init() {
-	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
-		configLayerUser = filepath.Join(xdg, "blackdot", "config.json")
-		configLayerMachine = filepath.Join(xdg, "blackdot", "machine.json")
-	}
+	configLayerUser = filepath.Join(ConfigDir(), "config.json")
+	configLayerMachine = filepath.Join(ConfigDir(), "machine.json")
 }
 
 func newConfigCmd() *cobra.Command {
@@ -43,12 +42,18 @@ func newConfigCmd() *cobra.Command {
 	cmd.AddCommand(
 		newConfigGetCmd(),
 		newConfigSetCmd(),
+		newConfigUnsetCmd(),
 		newConfigShowCmd(),
 		newConfigSourceCmd(),
 		newConfigListCmd(),
 		newConfigMergedCmd(),
+		newConfigEffectiveCmd(),
+		newConfigDefaultsCmd(),
 		newConfigInitCmd(),
 		newConfigEditCmd(),
+		newConfigPathsCmd(),
+		newConfigDocgenCmd(),
+		newConfigValidateCmd(),
 	)
 
 	return cmd
@@ -71,12 +76,19 @@ func printConfigHelp() {
 	BoldCyan.Println("Commands:")
 	printCmd("get <key>", "Get config value with layer resolution")
 	printCmd("set <layer> <k> <v>", "Set config value in specific layer")
+	printCmd("unset <layer> <key>", "Remove config value from specific layer")
 	printCmd("show <key>", "Show where a config value comes from")
 	printCmd("source <key>", "Get value with source information (JSON)")
 	printCmd("list", "Show configuration layer status")
 	printCmd("merged", "Show merged config from all layers")
+	printCmd("effective", "Show merged settings and the layer of each")
+	printCmd("defaults", "Show per-command flag defaults")
 	printCmd("init <layer>", "Initialize machine or project config")
-	printCmd("edit [layer]", "Edit config in $EDITOR")
+	printCmd("edit [layer]", "Edit config in $EDITOR, validated before saving")
+	printCmd("paths", "Show resolved config/cache/data/state directories")
+	printCmd("paths migrate <s>", "Move files to xdg or platform-native layout")
+	printCmd("docgen", "Write the effective setup to SETUP.md")
+	printCmd("validate [layer]", "Check config files against the schema")
 	fmt.Println()
 
 	// Layers
@@ -88,7 +100,7 @@ func printConfigHelp() {
 	fmt.Print("  ")
 	Yellow.Print("2. project")
 	fmt.Print("   ")
-	Dim.Println(".blackdot.json in current repo")
+	Dim.Println(".blackdot.json or .blackdot.yaml in current repo")
 	fmt.Print("  ")
 	Yellow.Print("3. machine")
 	fmt.Print("   ")
@@ -144,11 +156,18 @@ func newConfigSetCmd() *cobra.Command {
 		Short: "Set config value in specific layer",
 		Long: `Set config value in specific layer.
 
+The value takes the type the config schema gives the key: true/false
+(or yes/no, on/off, 1/0) for booleans, numbers, a JSON or comma-separated
+list for arrays, JSON for objects. A value the schema rejects is not
+written. Keys the schema does not describe are stored as JSON when the
+value parses as JSON, and as a string otherwise.
+
 Layers: user, machine, project
 
 Examples:
   blackdot config set user vault.backend 1password
   blackdot config set machine features.debug true
+  blackdot config set user vault.fallback pass,keychain
   blackdot config set project shell.theme minimal`,
 		Args: cobra.ExactArgs(3),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -157,6 +176,23 @@ Examples:
 	}
 }
 
+func newConfigUnsetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset <layer> <key>",
+		Short: "Remove config value from specific layer",
+		Long: `Remove a config value from one layer, so the next layer down applies.
+
+Layers: user, machine, project
+
+Examples:
+  blackdot config unset machine vault.backend`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return configUnset(args[0], args[1])
+		},
+	}
+}
+
 func newConfigShowCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "show <key>",
@@ -208,6 +244,24 @@ func newConfigMergedCmd() *cobra.Command {
 	}
 }
 
+func newConfigEffectiveCmd() *cobra.Command {
+	var jsonOutput bool
+	cmd := &cobra.Command{
+		Use:   "effective",
+		Short: "Show merged settings and the layer of each",
+		Long: `Show the effective configuration for the current directory: config
+keys, feature toggles, and the template variables, required vault items
+and hooks added by the repository's .blackdot.yaml, each with the layer
+and file it came from.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return configEffective(jsonOutput)
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	return cmd
+}
+
 func newConfigInitCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "init <layer> [identifier]",
@@ -235,7 +289,13 @@ func newConfigEditCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "edit [layer]",
 		Short: "Edit config file (default: user)",
-		Long: `Open config file in editor.
+		Long: `Open a config file in $EDITOR (vim if unset).
+
+The edit is made on a copy. When the editor exits, the copy is checked:
+invalid JSON and schema errors are reported and the config is left
+unchanged (in a terminal you can edit again; otherwise the copy is kept
+and its path printed). Unknown keys are warnings. A valid edit is shown
+as a diff and then saved, unless the file changed while it was open.
 
 Layers: user (default), machine, project`,
 		Args: cobra.MaximumNArgs(1),
@@ -254,67 +314,126 @@ Layers: user (default), machine, project`,
 // ============================================================
 
 func configGet(key, defaultVal string) error {
-	// Check environment first
-	envKey := "BLACKDOT_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
-	if val := os.Getenv(envKey); val != "" {
+	if val := configLookup(key); val != "" {
 		fmt.Println(val)
 		return nil
 	}
 
-	// Check project config
-	if projectConfig := findProjectConfig(); projectConfig != "" {
-		if val := getFromJSONFile(projectConfig, key); val != "" {
-			fmt.Println(val)
-			return nil
-		}
+	// Return default
+	if defaultVal != "" {
+		fmt.Println(defaultVal)
+	}
+	return nil
+}
+
+// configLookup resolves a key through env > project > machine > user,
+// returning "" when no layer sets it
+func configLookup(key string) string {
+	// Check environment first
+	envKey := config.EnvKey(key)
+	if val := os.Getenv(envKey); val != "" {
+		return val
+	}
+
+	// Check project config (.blackdot.json, then .blackdot.yaml)
+	if val, _ := projectLayerValue(key); val != "" {
+		return val
 	}
 
 	// Check machine config
 	if val := getFromJSONFile(configLayerMachine, key); val != "" {
-		fmt.Println(val)
-		return nil
+		return val
 	}
 
 	// Check user config
-	if val := getFromJSONFile(configLayerUser, key); val != "" {
-		fmt.Println(val)
-		return nil
-	}
+	return getFromJSONFile(configLayerUser, key)
+}
 
-	// Return default
-	if defaultVal != "" {
-		fmt.Println(defaultVal)
+// trustedConfigLookup resolves a key through env > machine > user and
+// names the layer that set it. It is for settings a project file must not
+// control.
+func trustedConfigLookup(key string) (string, string) {
+	envKey := config.EnvKey(key)
+	if val := os.Getenv(envKey); val != "" {
+		return val, "env"
 	}
-	return nil
+	if val := getFromJSONFile(configLayerMachine, key); val != "" {
+		return val, "machine"
+	}
+	if val := getFromJSONFile(configLayerUser, key); val != "" {
+		return val, "user"
+	}
+	return "", ""
 }
 
-func configSet(layer, key, value string) error {
-	var configFile string
-
+// configLayerFile returns the file a writable layer is stored in
+func configLayerFile(layer string) (string, error) {
 	switch layer {
 	case "user":
-		configFile = configLayerUser
+		return configLayerUser, nil
 	case "machine":
-		configFile = configLayerMachine
+		return configLayerMachine, nil
 	case "project":
-		configFile = findProjectConfig()
+		configFile := findProjectConfig()
 		if configFile == "" {
 			Fail("No project config found")
 			fmt.Println("Create one with: blackdot config init project")
-			return fmt.Errorf("no project config")
+			return "", fmt.Errorf("no project config")
 		}
+		return configFile, nil
 	default:
 		Fail("Unknown layer: %s", layer)
 		fmt.Println("Valid layers: user, machine, project")
-		return fmt.Errorf("unknown layer: %s", layer)
+		return "", fmt.Errorf("unknown layer: %s", layer)
+	}
+}
+
+func configSet(layer, key, value string) error {
+	configFile, err := configLayerFile(layer)
+	if err != nil {
+		return err
+	}
+
+	// Store the value as the type the schema expects for the key
+	parsed, err := config.ParseValue(key, value)
+	if err != nil {
+		Fail("%v", err)
+		return err
 	}
+	encoded, _ := json.Marshal(parsed)
 
-	if err := setInJSONFile(configFile, key, value); err != nil {
+	if err := setInJSONFile(configFile, key, string(encoded)); err != nil {
 		Fail("Failed to set config: %v", err)
 		return err
 	}
 
-	Pass("Set %s = %s in %s config", key, value, layer)
+	Pass("Set %s = %s in %s config", key, formatEffectiveValue(parsed), layer)
+	if strings.HasPrefix(key, "features.") {
+		refreshFeatureSnippets()
+	}
+	return nil
+}
+
+func configUnset(layer, key string) error {
+	configFile, err := configLayerFile(layer)
+	if err != nil {
+		return err
+	}
+
+	removed, err := deleteFromJSONFile(configFile, key)
+	if err != nil {
+		Fail("Failed to unset config: %v", err)
+		return err
+	}
+	if !removed {
+		Info("%s is not set in %s config", key, layer)
+		return nil
+	}
+
+	Pass("Removed %s from %s config", key, layer)
+	if strings.HasPrefix(key, "features.") {
+		refreshFeatureSnippets()
+	}
 	return nil
 }
 
@@ -322,7 +441,7 @@ func configShow(key string) error {
 	PrintHeader("Config: " + key)
 
 	// Environment
-	envKey := "BLACKDOT_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	envKey := config.EnvKey(key)
 	if val := os.Getenv(envKey); val != "" {
 		fmt.Printf("  env:      %s  %s\n", val, Green.Sprint("← active"))
 		return nil
@@ -331,15 +450,10 @@ func configShow(key string) error {
 
 	// Project
 	active := false
-	projectConfig := findProjectConfig()
-	if projectConfig != "" {
-		if val := getFromJSONFile(projectConfig, key); val != "" {
-			if !active {
-				fmt.Printf("  project:  %s  %s\n", val, Green.Sprint("← active"))
-				active = true
-			} else {
-				fmt.Printf("  project:  %s\n", val)
-			}
+	if findProjectConfig() != "" || findProjectOverlay() != "" {
+		if val, path := projectLayerValue(key); val != "" {
+			fmt.Printf("  project:  %s  %s %s\n", val, Green.Sprint("← active"), Dim.Sprint("("+tildePath(path)+")"))
+			active = true
 		} else {
 			fmt.Printf("  project:  %s\n", Dim.Sprint("(not set)"))
 		}
@@ -383,7 +497,7 @@ func configSource(key, defaultVal string) error {
 	var result sourceResult
 
 	// Check environment first
-	envKey := "BLACKDOT_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	envKey := config.EnvKey(key)
 	if val := os.Getenv(envKey); val != "" {
 		result = sourceResult{Value: val, Layer: "env"}
 		data, _ := json.Marshal(result)
@@ -392,13 +506,11 @@ func configSource(key, defaultVal string) error {
 	}
 
 	// Check project config
-	if projectConfig := findProjectConfig(); projectConfig != "" {
-		if val := getFromJSONFile(projectConfig, key); val != "" {
-			result = sourceResult{Value: val, Layer: "project", Path: projectConfig}
-			data, _ := json.Marshal(result)
-			fmt.Println(string(data))
-			return nil
-		}
+	if val, path := projectLayerValue(key); val != "" {
+		result = sourceResult{Value: val, Layer: "project", Path: path}
+		data, _ := json.Marshal(result)
+		fmt.Println(string(data))
+		return nil
 	}
 
 	// Check machine config
@@ -444,6 +556,11 @@ func configList() error {
 	} else {
 		fmt.Printf("  project:   %s\n", Dim.Sprint(".blackdot.json (not found)"))
 	}
+	if overlay := findProjectOverlay(); overlay != "" {
+		fmt.Printf("  project:   %s %s\n", overlay, Green.Sprint("✓"))
+	} else {
+		fmt.Printf("  project:   %s\n", Dim.Sprint(".blackdot.yaml in repo root (not found)"))
+	}
 
 	// Machine
 	if _, err := os.Stat(configLayerMachine); err == nil {
@@ -466,6 +583,69 @@ func configList() error {
 	return nil
 }
 
+func configEffective(jsonOutput bool) error {
+	eff := collectEffectiveConfig()
+	if jsonOutput {
+		data, _ := json.MarshalIndent(eff, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	PrintHeader("Effective Configuration")
+	if eff.Overlay != "" {
+		fmt.Printf("Project overlay: %s\n", tildePath(eff.Overlay))
+	} else {
+		Dim.Println("No .blackdot.yaml in this repository")
+	}
+
+	sections := []struct {
+		title  string
+		values []effectiveValue
+	}{
+		{"Config", eff.Config},
+		{"Features", eff.Features},
+		{"Template variables", eff.Variables},
+		{"Required vault items", eff.Vault},
+		{"Hooks", eff.Hooks},
+	}
+	for _, sec := range sections {
+		if len(sec.values) == 0 {
+			continue
+		}
+		fmt.Println()
+		BoldCyan.Println(sec.title + ":")
+		for _, v := range sec.values {
+			source := v.Layer
+			if v.Path != "" {
+				source += " " + tildePath(v.Path)
+			}
+			fmt.Printf("  %-32s %-24v %s\n", v.Key, formatEffectiveValue(v.Value), Dim.Sprint("← "+source))
+		}
+	}
+	if len(eff.Hooks) > 0 && !projectHooksAllowed() {
+		fmt.Println()
+		Warn("Project hooks are listed but will not run until hooks.allow_project is true in your user or machine config")
+	}
+	if overlay := currentProjectOverlay(); overlay != nil && len(overlay.Vault.Items) > 0 && !projectVaultItemsAllowed() {
+		fmt.Println()
+		Warn("Project vault items are ignored until vault.allow_project_items is true in your user or machine config")
+	}
+	fmt.Println()
+	return nil
+}
+
+func formatEffectiveValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(val)
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
 func configMerged() error {
 	PrintHeader("Merged Configuration")
 
@@ -477,7 +657,12 @@ func configMerged() error {
 	// Load machine config
 	loadJSONInto(configLayerMachine, merged)
 
-	// Load project config
+	// Load project overlay, then project config
+	if overlay := currentProjectOverlay(); overlay != nil {
+		for k, v := range overlay.Config {
+			merged[k] = v
+		}
+	}
 	if projectConfig := findProjectConfig(); projectConfig != "" {
 		loadJSONInto(projectConfig, merged)
 	}
@@ -532,7 +717,7 @@ func configInitMachine(identifier string) error {
 	}
 
 	data, _ := json.MarshalIndent(initialConfig, "", "  ")
-	if err := os.WriteFile(configLayerMachine, data, 0644); err != nil {
+	if err := writeFileAtomic(configLayerMachine, data, 0644); err != nil {
 		Fail("Failed to create machine config: %v", err)
 		return err
 	}
@@ -562,7 +747,7 @@ func configInitProject() error {
 	}
 
 	data, _ := json.MarshalIndent(initialConfig, "", "  ")
-	if err := os.WriteFile(projectConfig, data, 0644); err != nil {
+	if err := writeFileAtomic(projectConfig, data, 0644); err != nil {
 		Fail("Failed to create project config: %v", err)
 		return err
 	}
@@ -573,44 +758,6 @@ func configInitProject() error {
 	return nil
 }
 
-func configEdit(layer string) error {
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = "vim"
-	}
-
-	var configFile string
-	switch layer {
-	case "user":
-		configFile = configLayerUser
-	case "machine":
-		configFile = configLayerMachine
-	case "project":
-		configFile = findProjectConfig()
-		if configFile == "" {
-			Fail("No project config found")
-			fmt.Println("Create one with: blackdot config init project")
-			return fmt.Errorf("no project config")
-		}
-	default:
-		Fail("Unknown layer: %s", layer)
-		fmt.Println("Valid layers: user, machine, project")
-		return fmt.Errorf("unknown layer: %s", layer)
-	}
-
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		Fail("Config file does not exist: %s", configFile)
-		fmt.Printf("Create it with: blackdot config init %s\n", layer)
-		return err
-	}
-
-	cmd := exec.Command(editor, configFile)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
 // ============================================================
 // Helper Functions
 // ============================================================
@@ -643,7 +790,12 @@ func getFromJSONFile(path, key string) string {
 	if err := json.Unmarshal(data, &obj); err != nil {
 		return ""
 	}
+	return lookupNested(obj, key)
+}
 
+// lookupNested resolves a dot-notation key in decoded JSON and formats the
+// value as a string
+func lookupNested(obj map[string]interface{}, key string) string {
 	// Navigate nested keys
 	parts := strings.Split(key, ".")
 	current := obj
@@ -677,12 +829,21 @@ func getFromJSONFile(path, key string) string {
 }
 
 func setInJSONFile(path, key, value string) error {
-	// Read existing file or create new
-	var obj map[string]interface{}
+	// Create directory if needed
+	os.MkdirAll(filepath.Dir(path), 0755)
 
-	if data, err := os.ReadFile(path); err == nil {
-		json.Unmarshal(data, &obj)
-	}
+	// Read, change, and write under the file's lock so concurrent sets
+	// are not lost
+	return safefile.Update(path, 0644, func(data []byte) ([]byte, error) {
+		return setInJSON(data, key, value)
+	})
+}
+
+// setInJSON returns the JSON document data with a dotted key set
+func setInJSON(data []byte, key, value string) ([]byte, error) {
+	// Read existing document or create new
+	var obj map[string]interface{}
+	json.Unmarshal(data, &obj)
 	if obj == nil {
 		obj = make(map[string]interface{})
 	}
@@ -706,17 +867,48 @@ func setInJSONFile(path, key, value string) error {
 			if nested, ok := current[part].(map[string]interface{}); ok {
 				current = nested
 			} else {
-				return fmt.Errorf("cannot set nested key: %s is not an object", part)
+				return nil, fmt.Errorf("cannot set nested key: %s is not an object", part)
 			}
 		}
 	}
 
-	// Create directory if needed
-	os.MkdirAll(filepath.Dir(path), 0755)
+	return json.MarshalIndent(obj, "", "  ")
+}
+
+// deleteFromJSONFile removes a dotted key from a JSON file. Returns false
+// when the key was not present.
+func deleteFromJSONFile(path, key string) (bool, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	}
+	deleted := false
+	err := safefile.Update(path, 0644, func(data []byte) ([]byte, error) {
+		if data == nil {
+			return nil, nil
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
 
-	// Write back
-	data, _ := json.MarshalIndent(obj, "", "  ")
-	return os.WriteFile(path, data, 0644)
+		parts := strings.Split(key, ".")
+		current := obj
+		for _, part := range parts[:len(parts)-1] {
+			nested, ok := current[part].(map[string]interface{})
+			if !ok {
+				return nil, nil
+			}
+			current = nested
+		}
+		last := parts[len(parts)-1]
+		if _, ok := current[last]; !ok {
+			return nil, nil
+		}
+		delete(current, last)
+		deleted = true
+		return json.MarshalIndent(obj, "", "  ")
+	})
+	return deleted, err
 }
 
 func loadJSONInto(path string, target map[string]interface{}) {
@@ -0,0 +1,294 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/feature"
+	"github.com/spf13/cobra"
+)
+
+// docgenSecretVar matches template variable names whose values are left
+// out of the setup document
+var docgenSecretVar = regexp.MustCompile(`(?i)(token|secret|passw|api_?key|private|credential)`)
+
+// configDocPath is where config docgen writes, relative to the blackdot
+// directory unless absolute (docgen.path)
+func configDocPath() string {
+	p := configLookup("docgen.path")
+	if p == "" {
+		p = "SETUP.md"
+	}
+	p = expandPath(p)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(BlackdotDir(), p)
+	}
+	return p
+}
+
+func newConfigDocgenCmd() *cobra.Command {
+	var output string
+	var toStdout, check bool
+
+	cmd := &cobra.Command{
+		Use:   "docgen",
+		Short: "Write the effective setup as a markdown document",
+		Long: `Render the resolved configuration into a markdown document you can
+commit with your dotfiles: enabled features, config settings and the
+layer each comes from, templates and their variables, vault items, links,
+and packages.
+
+Vault item contents are never included, and template variables whose
+names look secret (token, password, api_key, ...) are shown as hidden.
+
+The document is written to SETUP.md in the blackdot directory, or to
+docgen.path. Once it exists, 'blackdot sync' regenerates it after every
+successful sync.
+
+Examples:
+  blackdot config docgen                  # Write SETUP.md
+  blackdot config docgen --stdout
+  blackdot config docgen --check          # Exit 1 if SETUP.md is out of date
+  blackdot config set user docgen.path docs/my-setup.md`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			doc := buildConfigDoc()
+			if toStdout {
+				fmt.Print(doc)
+				return nil
+			}
+			path := configDocPath()
+			if output != "" {
+				path = expandPath(output)
+			}
+			current, _ := os.ReadFile(path)
+			if check {
+				if string(current) != doc {
+					Fail("%s is out of date (run: blackdot config docgen)", tildePath(path))
+					return fmt.Errorf("%s is out of date", filepath.Base(path))
+				}
+				Pass("%s is up to date", tildePath(path))
+				return nil
+			}
+			if string(current) == doc {
+				Pass("%s is up to date", tildePath(path))
+				return nil
+			}
+			if err := writeFileAtomic(path, []byte(doc), 0644); err != nil {
+				Fail("Failed to write %s: %v", tildePath(path), err)
+				return err
+			}
+			Pass("Wrote %s", tildePath(path))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write to this file instead of docgen.path")
+	cmd.Flags().BoolVar(&toStdout, "stdout", false, "Print the document instead of writing it")
+	cmd.Flags().BoolVar(&check, "check", false, "Fail if the document is out of date")
+	return cmd
+}
+
+// refreshConfigDoc regenerates the setup document if one was generated
+// before. sync calls it so the document follows the configuration.
+func refreshConfigDoc() {
+	path := configDocPath()
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	doc := buildConfigDoc()
+	if string(current) == doc {
+		return
+	}
+	if err := writeFileAtomic(path, []byte(doc), 0644); err != nil {
+		Warn("Could not update %s: %v", tildePath(path), err)
+		return
+	}
+	Info("Updated %s", tildePath(path))
+}
+
+// buildConfigDoc renders the setup document. It holds no timestamps, so
+// regenerating an unchanged setup produces an identical file.
+func buildConfigDoc() string {
+	var b strings.Builder
+	blackdotDir := BlackdotDir()
+	rel := func(p string) string {
+		if r, err := filepath.Rel(blackdotDir, p); err == nil && !strings.HasPrefix(r, "..") {
+			return r
+		}
+		return tildePath(p)
+	}
+
+	b.WriteString("# Dotfiles Setup\n\n")
+	b.WriteString("<!-- Generated by `blackdot config docgen`; edit the configuration, not this file. -->\n\n")
+	b.WriteString("The effective blackdot configuration for this repository: what is enabled,\n")
+	b.WriteString("where each setting comes from, and what gets installed and linked.\n")
+
+	eff := collectEffectiveConfig()
+
+	// Features
+	b.WriteString("\n## Features\n\n")
+	reg := initRegistry()
+	rows := [][]string{}
+	for _, f := range eff.Features {
+		desc := ""
+		if feat, ok := reg.Get(f.Key); ok {
+			desc = feat.Description
+		}
+		enabled := "no"
+		if f.Value == true {
+			enabled = "yes"
+		}
+		rows = append(rows, []string{"`" + f.Key + "`", enabled, f.Layer, desc})
+	}
+	for _, f := range reg.ByCategory(feature.CategoryCore) {
+		rows = append(rows, []string{"`" + f.Name + "`", "yes", "core", f.Description})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+	writeDocTable(&b, []string{"Feature", "Enabled", "Set by", "Description"}, rows)
+
+	// Config settings
+	b.WriteString("\n## Settings\n\n")
+	if len(eff.Config) == 0 {
+		b.WriteString("All settings are at their defaults.\n")
+	} else {
+		rows = rows[:0]
+		for _, v := range eff.Config {
+			rows = append(rows, []string{"`" + v.Key + "`", "`" + formatEffectiveValue(v.Value) + "`", v.Layer})
+		}
+		writeDocTable(&b, []string{"Key", "Value", "Layer"}, rows)
+	}
+
+	// Templates
+	b.WriteString("\n## Templates\n\n")
+	if cfg, err := getTemplateConfig(); err == nil {
+		templates, _ := resolveTemplatePaths(cfg, nil)
+		targets, _ := templateTargets(cfg, templates)
+		deployed := make(map[string]string)
+		for _, t := range targets {
+			deployed[t.Template] = tildePath(t.Target)
+		}
+		if len(templates) == 0 {
+			b.WriteString("No templates.\n")
+		} else {
+			rows = rows[:0]
+			for _, tmpl := range templates {
+				target := deployed[tmpl]
+				if target == "" {
+					target = "generated/ only"
+				}
+				rows = append(rows, []string{"`" + rel(tmpl) + "`", target})
+			}
+			writeDocTable(&b, []string{"Template", "Deployed to"}, rows)
+		}
+
+		engine := newTemplateEngine(cfg)
+		if loadTemplateVariables(engine, cfg) == nil {
+			vars := engine.Vars()
+			b.WriteString("\n### Variables\n\n")
+			rows = rows[:0]
+			for _, name := range sortedKeys(vars) {
+				value := "`" + vars[name] + "`"
+				if docgenSecretVar.MatchString(name) {
+					value = "*(hidden)*"
+				}
+				rows = append(rows, []string{"`" + name + "`", value})
+			}
+			writeDocTable(&b, []string{"Variable", "Value"}, rows)
+		}
+	}
+
+	// Vault items
+	b.WriteString("\n## Vault Items\n\n")
+	if items, err := loadVaultItems(); err == nil && len(items) > 0 {
+		rows = rows[:0]
+		for _, name := range sortedKeys(items) {
+			item := items[name]
+			required := "no"
+			if item.Required {
+				required = "yes"
+			}
+			if item.RequiresFeature != "" {
+				required += " (with " + item.RequiresFeature + ")"
+			}
+			rows = append(rows, []string{"`" + name + "`", item.Type, tildePath(expandPath(item.Path)), required})
+		}
+		writeDocTable(&b, []string{"Item", "Type", "Restored to", "Required"}, rows)
+		b.WriteString("\nRestore with `blackdot vault pull`. Contents live only in the vault.\n")
+	} else {
+		b.WriteString("No vault items configured.\n")
+	}
+
+	// Links
+	b.WriteString("\n## Links\n\n")
+	if resolved, err := declaredLinks(); err == nil && len(resolved) > 0 {
+		rows = rows[:0]
+		for _, r := range resolved {
+			note := r.Backup
+			if r.Optional {
+				note += ", optional"
+			}
+			rows = append(rows, []string{tildePath(r.Target), "`" + rel(r.Source) + "`", note})
+		}
+		writeDocTable(&b, []string{"Target", "Source", "Existing file"}, rows)
+	} else {
+		b.WriteString("No links declared.\n")
+	}
+
+	// Packages
+	b.WriteString("\n## Packages\n\n")
+	tier := getPackageTier("", blackdotDir)
+	if brewfile, resolvedTier, err := resolveBrewfile(blackdotDir, tier); err == nil {
+		fmt.Fprintf(&b, "Tier **%s** (`%s`", resolvedTier, rel(brewfile))
+		layered, overlays, cleanup, err := layeredBrewfile(brewfile)
+		if err == nil {
+			defer cleanup()
+			brewfile = layered
+		}
+		formulas, casks, _ := parseBrewfile(brewfile)
+		if len(overlays) > 0 {
+			fmt.Fprintf(&b, " + overlays: %s", strings.Join(overlays, ", "))
+		}
+		b.WriteString("). Install with `blackdot packages --install`.\n")
+		for _, group := range []struct {
+			title string
+			names []string
+		}{{"Formulae", formulas}, {"Casks", casks}} {
+			if len(group.names) == 0 {
+				continue
+			}
+			names := append([]string(nil), group.names...)
+			sort.Strings(names)
+			fmt.Fprintf(&b, "\n**%s (%d):** ", group.title, len(names))
+			for i, n := range names {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				b.WriteString("`" + n + "`")
+			}
+			b.WriteString("\n")
+		}
+	} else {
+		b.WriteString("No Brewfile.\n")
+	}
+
+	return b.String()
+}
+
+// writeDocTable writes a markdown table, escaping pipes in cells
+func writeDocTable(b *strings.Builder, header []string, rows [][]string) {
+	b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat("---|", len(header)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			cells[i] = strings.ReplaceAll(strings.ReplaceAll(c, "|", "\\|"), "\n", " ")
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+}
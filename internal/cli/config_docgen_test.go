@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestBuildConfigDoc verifies the setup document lists templates,
+// variables, and packages, hides secret-looking variables, and is
+// identical when regenerated
+func TestBuildConfigDoc(t *testing.T) {
+	t.Cleanup(initConfig) // runs after the environment is restored
+	setScheduleEnv(t, "")
+	dir := filepath.Join(os.Getenv("HOME"), ".blackdot")
+	t.Setenv("BLACKDOT_DIR", dir)
+	initConfig()
+	os.MkdirAll(filepath.Join(dir, "templates", "configs"), 0755)
+	os.MkdirAll(filepath.Join(dir, "brew"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "_variables.local.sh"), []byte("git_email=\"me@example.com\"\ngithub_token=\"ghp_secret\"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "templates", "configs", "gitconfig.tmpl"), []byte("[user]\n  email = {{ git_email }}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "brew", "Brewfile"), []byte("brew \"jq\"\nbrew \"git\"\ncask \"iterm2\"\n"), 0644)
+
+	doc := buildConfigDoc()
+	for _, want := range []string{
+		"| `templates/configs/gitconfig.tmpl` | ~/.gitconfig |",
+		"| `git_email` | `me@example.com` |",
+		"| `github_token` | *(hidden)* |",
+		"**Formulae (2):** `git`, `jq`",
+		"**Casks (1):** `iterm2`",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document missing %q", want)
+		}
+	}
+	if strings.Contains(doc, "ghp_secret") {
+		t.Error("secret variable value in document")
+	}
+	if again := buildConfigDoc(); again != doc {
+		t.Error("regenerating an unchanged setup changed the document")
+	}
+
+	// sync only refreshes a document that was generated before
+	refreshConfigDoc()
+	if _, err := os.Stat(configDocPath()); !os.IsNotExist(err) {
+		t.Fatal("refresh created a document")
+	}
+	os.WriteFile(configDocPath(), []byte("old"), 0644)
+	refreshConfigDoc()
+	if data, _ := os.ReadFile(filepath.Join(dir, "SETUP.md")); string(data) != doc {
+		t.Error("refresh did not regenerate SETUP.md")
+	}
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/config"
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+	"github.com/blackwell-systems/blackdot/internal/safefile"
+)
+
+// errConfigChanged means the config file was written by something else
+// while it was open in the editor
+var errConfigChanged = errors.New("changed while it was being edited")
+
+// configEditorRun opens path in $EDITOR, which may include arguments
+// ("code --wait"). A package var so tests can stand in for the editor.
+var configEditorRun = func(path string) error {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vim"}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// configEdit edits a copy of a layer's config file and saves it only once
+// it is valid JSON that passes the schema
+func configEdit(layer string) error {
+	configFile, err := configLayerFile(layer)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(configFile); os.IsNotExist(err) {
+		Fail("Config file does not exist: %s", configFile)
+		fmt.Printf("Create it with: blackdot config init %s\n", layer)
+		return err
+	}
+	original, err := os.ReadFile(configFile)
+	if err != nil {
+		Fail("Failed to read %s: %v", configFile, err)
+		return err
+	}
+	info, err := os.Stat(configFile)
+	if err != nil {
+		return err
+	}
+
+	// Edit a copy in the same directory, keeping the .json extension for
+	// editor syntax highlighting
+	tmp, err := os.CreateTemp(filepath.Dir(configFile), "."+strings.TrimSuffix(filepath.Base(configFile), ".json")+".edit-*.json")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	if err := os.WriteFile(tmpPath, original, 0600); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	var edited []byte
+	var issues []config.Issue
+	for {
+		if err := configEditorRun(tmpPath); err != nil {
+			return fmt.Errorf("editor failed: %w", err)
+		}
+		if edited, err = os.ReadFile(tmpPath); err != nil {
+			return err
+		}
+		if bytes.Equal(edited, original) {
+			Info("No changes to %s", tildePath(configFile))
+			return nil
+		}
+
+		var problems []string
+		issues, problems = configEditProblems(edited)
+		if len(problems) == 0 {
+			break
+		}
+		Fail("%s is not valid - not saved", tildePath(configFile))
+		for _, p := range problems {
+			fmt.Printf("    %s   %s\n", Red.Sprint("error"), p)
+		}
+		fmt.Println()
+		again := false
+		if stdinIsTerminal() {
+			again, _ = prompts.Confirm("Edit again?", true)
+		}
+		if !again {
+			keep = true
+			Info("Your edit is in %s", tildePath(tmpPath))
+			return fmt.Errorf("%s: %d config error(s)", configFile, len(problems))
+		}
+	}
+
+	for _, issue := range issues {
+		fmt.Printf("    %s %s\n", Yellow.Sprint("warning"), issue)
+	}
+	fmt.Println()
+	printUnifiedDiff(os.Stdout, tildePath(configFile), string(original), tildePath(configFile)+" (edited)", string(edited))
+	fmt.Println()
+
+	err = safefile.Update(configFile, info.Mode().Perm(), func(current []byte) ([]byte, error) {
+		if !bytes.Equal(current, original) {
+			return nil, errConfigChanged
+		}
+		return edited, nil
+	})
+	if err != nil {
+		keep = true
+		Fail("%s %v - not saved", tildePath(configFile), err)
+		Info("Your edit is in %s", tildePath(tmpPath))
+		return err
+	}
+
+	Pass("Saved %s", tildePath(configFile))
+	if !reflect.DeepEqual(configFeatures(original), configFeatures(edited)) {
+		refreshFeatureSnippets()
+	}
+	return nil
+}
+
+// configEditProblems validates an edited config. Problems block saving;
+// the returned issues are the warnings that do not.
+func configEditProblems(data []byte) ([]config.Issue, []string) {
+	issues, err := config.ValidateJSON(data)
+	if err != nil {
+		return nil, []string{"invalid JSON: " + jsonErrorLocation(data, err)}
+	}
+	var warnings []config.Issue
+	var problems []string
+	for _, issue := range issues {
+		if issue.Warning {
+			warnings = append(warnings, issue)
+		} else {
+			problems = append(problems, issue.String())
+		}
+	}
+	return warnings, problems
+}
+
+// jsonErrorLocation adds the line and column to a JSON syntax error
+func jsonErrorLocation(data []byte, err error) string {
+	var syntax *json.SyntaxError
+	if !errors.As(err, &syntax) {
+		return err.Error()
+	}
+	before := data[:min(int(syntax.Offset), len(data))]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := len(before) - bytes.LastIndexByte(before, '\n') - 1
+	return fmt.Sprintf("line %d, column %d: %v", line, col, err)
+}
+
+// configFeatures returns the features section of a config document
+func configFeatures(data []byte) interface{} {
+	var doc map[string]interface{}
+	json.Unmarshal(data, &doc)
+	return doc["features"]
+}
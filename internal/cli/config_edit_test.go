@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubConfigEditor replaces the editor with one that writes each of
+// edits in turn
+func stubConfigEditor(t *testing.T, edits ...string) {
+	t.Helper()
+	orig := configEditorRun
+	t.Cleanup(func() { configEditorRun = orig })
+	// Not a terminal, so an invalid edit is not offered again
+	if r, w, err := os.Pipe(); err == nil {
+		w.Close()
+		stdin := os.Stdin
+		os.Stdin = r
+		t.Cleanup(func() { os.Stdin = stdin; r.Close() })
+	}
+	configEditorRun = func(path string) error {
+		if len(edits) == 0 {
+			t.Fatal("editor opened more often than expected")
+		}
+		err := os.WriteFile(path, []byte(edits[0]), 0600)
+		edits = edits[1:]
+		return err
+	}
+}
+
+// editTempFiles lists the editing copies left next to the config
+func editTempFiles(t *testing.T) []string {
+	t.Helper()
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(configLayerUser), ".config.edit-*.json"))
+	return matches
+}
+
+// TestConfigEdit verifies a valid edit is saved and invalid JSON or
+// schema errors leave the config unchanged with the edit kept
+func TestConfigEdit(t *testing.T) {
+	const original = `{"version": 3, "vault": {"backend": "pass"}}`
+
+	setScheduleEnv(t, original)
+	stubConfigEditor(t, `{"version": 3, "vault": {"backend": "keychain"}, "custom": 1}`)
+	if err := configEdit("user"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(configLayerUser); !strings.Contains(string(data), "keychain") {
+		t.Errorf("valid edit not saved:\n%s", data)
+	}
+	if left := editTempFiles(t); len(left) != 0 {
+		t.Errorf("editing copy left behind: %v", left)
+	}
+
+	for _, edit := range []string{
+		`{"version": 3, "vault": {"backend": "keychain",}}`,
+		`{"version": 3, "vault": {"auto_sync": "yes"}}`,
+	} {
+		setScheduleEnv(t, original)
+		stubConfigEditor(t, edit)
+		if err := configEdit("user"); err == nil {
+			t.Errorf("invalid edit %s was accepted", edit)
+		}
+		if data, _ := os.ReadFile(configLayerUser); string(data) != original {
+			t.Errorf("config changed by invalid edit:\n%s", data)
+		}
+		left := editTempFiles(t)
+		if len(left) != 1 {
+			t.Fatalf("editing copy should be kept, found %v", left)
+		}
+		if data, _ := os.ReadFile(left[0]); string(data) != edit {
+			t.Errorf("kept copy = %s", data)
+		}
+	}
+}
+
+// TestConfigEditConcurrentChange verifies an edit is not saved over a
+// config that changed while the editor was open
+func TestConfigEditConcurrentChange(t *testing.T) {
+	setScheduleEnv(t, `{"version": 3}`)
+	orig := configEditorRun
+	t.Cleanup(func() { configEditorRun = orig })
+	configEditorRun = func(path string) error {
+		os.WriteFile(configLayerUser, []byte(`{"version": 3, "setup": {"completed": ["vault"]}}`), 0600)
+		return os.WriteFile(path, []byte(`{"version": 3, "vault": {"backend": "pass"}}`), 0600)
+	}
+
+	if err := configEdit("user"); err != errConfigChanged {
+		t.Errorf("err = %v, want errConfigChanged", err)
+	}
+	if data, _ := os.ReadFile(configLayerUser); !strings.Contains(string(data), "setup") {
+		t.Errorf("concurrent change overwritten:\n%s", data)
+	}
+}
+
+// TestJSONErrorLocation verifies syntax errors name their line and column
+func TestJSONErrorLocation(t *testing.T) {
+	data := []byte("{\n  \"a\": 1,\n  \"b\": ]\n}")
+	_, problems := configEditProblems(data)
+	if len(problems) != 1 || !strings.Contains(problems[0], "line 3, column 8") {
+		t.Errorf("problems = %v", problems)
+	}
+}
+
+// TestConfigSetUnset verifies set stores schema types and rejects bad
+// values, and unset removes the key
+func TestConfigSetUnset(t *testing.T) {
+	setScheduleEnv(t, `{"version": 3}`)
+
+	if err := configSet("user", "vault.auto_sync", "yes"); err != nil {
+		t.Fatal(err)
+	}
+	if err := configSet("user", "vault.location", "12345"); err != nil {
+		t.Fatal(err)
+	}
+	if err := configSet("user", "vault.backend", "lastpass"); err == nil {
+		t.Error("value outside the schema's enum should be rejected")
+	}
+	data, _ := os.ReadFile(configLayerUser)
+	for _, want := range []string{`"auto_sync": true`, `"location": "12345"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("config missing %s:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "lastpass") {
+		t.Errorf("rejected value written:\n%s", data)
+	}
+
+	if err := configUnset("user", "vault.auto_sync"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(configLayerUser); strings.Contains(string(data), "auto_sync") {
+		t.Errorf("auto_sync still set:\n%s", data)
+	}
+	if err := configUnset("user", "vault.auto_sync"); err != nil {
+		t.Errorf("unsetting a missing key: %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+func newConfigPathsCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "paths",
+		Short: "Show resolved config/cache/data/state directories",
+		Long: `Show where blackdot keeps its files under the active path strategy.
+
+The strategy is set by paths.strategy in config.json (or
+BLACKDOT_PATHS_STRATEGY):
+  xdg              ~/.config, ~/.cache, ~/.local/share, ~/.local/state (default)
+  platform-native  ~/Library/Application Support on macOS, %APPDATA% on Windows
+
+Switch strategies with 'blackdot config paths migrate <strategy>' so existing
+files move with the setting.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return configPaths(jsonOutput)
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	cmd.AddCommand(newConfigPathsMigrateCmd())
+	return cmd
+}
+
+func newConfigPathsMigrateCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate <xdg|platform-native>",
+		Short: "Move files to another path strategy and switch to it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return configPathsMigrate(args[0], dryRun)
+		},
+	}
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would move without moving anything")
+	return cmd
+}
+
+func configPaths(jsonOutput bool) error {
+	dirs := paths.Current()
+
+	if jsonOutput {
+		data, _ := json.MarshalIndent(dirs, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	PrintHeader("Blackdot Paths")
+	source := "default"
+	if os.Getenv("BLACKDOT_PATHS_STRATEGY") != "" {
+		source = "BLACKDOT_PATHS_STRATEGY"
+	} else if dirs.Strategy != paths.DefaultStrategy {
+		source = "paths.strategy"
+	}
+	fmt.Printf("  strategy:  %s %s\n", dirs.Strategy, Dim.Sprintf("(%s)", source))
+	fmt.Println()
+	for _, d := range []struct{ kind, path string }{
+		{"config", dirs.Config},
+		{"cache", dirs.Cache},
+		{"data", dirs.Data},
+		{"state", dirs.State},
+	} {
+		if _, err := os.Stat(d.path); err == nil {
+			fmt.Printf("  %-9s  %s %s\n", d.kind+":", d.path, Green.Sprint("✓"))
+		} else {
+			fmt.Printf("  %-9s  %s\n", d.kind+":", Dim.Sprint(d.path+" (not created)"))
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// pathsMigrationPlan extends the generic plan with blackdot-specific
+// subdirectories. When config and data share a directory (macOS
+// platform-native) the whole directory follows config, so backups are
+// moved to the data location first.
+func pathsMigrationPlan(from, to paths.Strategy) []paths.Move {
+	src, dst := paths.For(from), paths.For(to)
+	var plan []paths.Move
+	if src.Data == src.Config && dst.Data != dst.Config {
+		backups := filepath.Join(src.Data, "backups")
+		if _, err := os.Stat(backups); err == nil {
+			plan = append(plan, paths.Move{Kind: "backups", From: backups, To: filepath.Join(dst.Data, "backups")})
+		}
+	}
+	return append(plan, paths.MigrationPlan(from, to)...)
+}
+
+func configPathsMigrate(target string, dryRun bool) error {
+	to, err := paths.ParseStrategy(target)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+	from := paths.CurrentStrategy()
+
+	if os.Getenv("BLACKDOT_PATHS_STRATEGY") != "" {
+		Warn("BLACKDOT_PATHS_STRATEGY is set and overrides paths.strategy; unset it after migrating")
+	}
+
+	if from == to {
+		Info("Already using the %s strategy", to)
+		return nil
+	}
+	plan := pathsMigrationPlan(from, to)
+
+	PrintHeader(fmt.Sprintf("Migrate paths: %s → %s", from, to))
+	if len(plan) == 0 {
+		Info("Nothing to move (both strategies resolve to the same directories here)")
+	}
+	for _, m := range plan {
+		fmt.Printf("  %-8s %s\n", m.Kind, m.From)
+		fmt.Printf("  %-8s → %s\n", "", m.To)
+	}
+	fmt.Println()
+
+	configFile := filepath.Join(paths.For(to).Config, "config.json")
+	if dryRun {
+		Info("Would set paths.strategy = %s in %s", to, configFile)
+		return nil
+	}
+
+	conflicts, err := paths.Migrate(plan)
+	for _, c := range conflicts {
+		Warn("Left in place (already exists at destination): %s", c)
+	}
+	if err != nil {
+		Fail("Migration stopped: %v", err)
+		return err
+	}
+
+	if err := setInJSONFile(configFile, "paths.strategy", string(to)); err != nil {
+		Fail("Failed to record paths.strategy: %v", err)
+		return err
+	}
+	defer paths.ResetStrategy()
+
+	// A config.json left behind at the old location would still be read
+	// first when looking up the strategy, so record it there too.
+	oldConfig := filepath.Join(paths.For(from).Config, "config.json")
+	if oldConfig != configFile {
+		if _, err := os.Stat(oldConfig); err == nil {
+			if err := setInJSONFile(oldConfig, "paths.strategy", string(to)); err != nil {
+				Warn("Failed to update %s: %v", oldConfig, err)
+			}
+		}
+	}
+
+	Pass("Now using the %s path strategy", to)
+	if len(conflicts) > 0 {
+		fmt.Println("Review the files left in the old location and remove them when done.")
+	}
+	return nil
+}
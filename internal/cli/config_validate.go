@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/blackwell-systems/blackdot/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newConfigValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [layer]",
+		Short: "Check config files against the schema",
+		Long: `Check the user, machine, and project config files against the config
+schema. Wrong types and values outside the allowed set (an unknown
+vault.backend, a string where a boolean belongs) are errors; keys the
+schema does not know are warnings.
+
+Older config.json formats are migrated automatically when blackdot
+starts. The original is kept as config.json.v<N>.<timestamp>.bak and
+each migration is recorded in config-migrations.jsonl in the state
+directory.
+
+Examples:
+  blackdot config validate            # All layers that exist
+  blackdot config validate user
+  blackdot config validate --schema   # Print the JSON Schema`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"user", "machine", "project"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if printSchema, _ := cmd.Flags().GetBool("schema"); printSchema {
+				os.Stdout.Write(config.SchemaJSON)
+				return nil
+			}
+			layer := ""
+			if len(args) > 0 {
+				layer = args[0]
+			}
+			return configValidate(layer)
+		},
+	}
+	cmd.Flags().Bool("schema", false, "Print the JSON Schema and exit")
+	return cmd
+}
+
+func configValidate(layer string) error {
+	layers := []struct{ name, path string }{
+		{"user", configLayerUser},
+		{"machine", configLayerMachine},
+		{"project", findProjectConfig()},
+	}
+	if layer != "" {
+		found := false
+		for _, l := range layers {
+			if l.name == layer {
+				layers = []struct{ name, path string }{l}
+				found = true
+				break
+			}
+		}
+		if !found {
+			Fail("Unknown layer: %s", layer)
+			fmt.Println("Valid layers: user, machine, project")
+			return fmt.Errorf("unknown layer: %s", layer)
+		}
+	}
+
+	PrintHeader("Config Validation")
+	fmt.Println()
+
+	errors, checked := 0, 0
+	for _, l := range layers {
+		if l.path == "" {
+			continue
+		}
+		data, err := os.ReadFile(l.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		checked++
+		if err != nil {
+			Fail("%s: %v", l.path, err)
+			errors++
+			continue
+		}
+		issues, err := config.ValidateJSON(data)
+		if err != nil {
+			Fail("%s: invalid JSON: %v", l.path, err)
+			errors++
+			continue
+		}
+		if l.name == "user" {
+			if v, ok := versionOf(data); ok && v > config.CurrentVersion {
+				issues = append(issues, config.Issue{Path: "version", Message: fmt.Sprintf("%d is newer than this blackdot supports (%d); upgrade blackdot", v, config.CurrentVersion)})
+			}
+		}
+
+		failed := 0
+		for _, issue := range issues {
+			if !issue.Warning {
+				failed++
+			}
+		}
+		errors += failed
+		switch {
+		case failed > 0:
+			Fail("%s (%s)", l.path, l.name)
+		case len(issues) > 0:
+			Warn("%s (%s)", l.path, l.name)
+		default:
+			Pass("%s (%s)", l.path, l.name)
+		}
+		for _, issue := range issues {
+			if issue.Warning {
+				fmt.Printf("    %s %s\n", Yellow.Sprint("warning"), issue)
+			} else {
+				fmt.Printf("    %s   %s\n", Red.Sprint("error"), issue)
+			}
+		}
+	}
+
+	fmt.Println()
+	if checked == 0 {
+		Info("No config files found")
+		return nil
+	}
+	if errors > 0 {
+		return fmt.Errorf("%d config error(s)", errors)
+	}
+	return nil
+}
+
+// versionOf reads the top-level version of a config file
+func versionOf(data []byte) (int, bool) {
+	var doc struct {
+		Version *float64 `json:"version"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil || doc.Version == nil {
+		return 0, false
+	}
+	return int(*doc.Version), true
+}
+
+// migrateUserConfig upgrades an older config.json before any command
+// reads it
+func migrateUserConfig() {
+	record, err := config.DefaultManager().MigrateUserConfig()
+	if err != nil {
+		Warn("Could not migrate config: %v", err)
+		return
+	}
+	if record != nil {
+		Info("Migrated %s from v%d to v%d (backup: %s)", record.File, record.From, record.To, record.Backup)
+	}
+}
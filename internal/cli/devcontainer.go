@@ -1,14 +1,13 @@
 package cli
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 
+	"github.com/blackwell-systems/blackdot/internal/prompts"
 	"github.com/spf13/cobra"
 )
 
@@ -243,6 +242,7 @@ var serviceStacks = map[string][]string{
 type DevcontainerConfig struct {
 	Name              string                       `json:"name"`
 	Image             string                       `json:"image,omitempty"`
+	Build             *DevcontainerBuild           `json:"build,omitempty"`
 	DockerComposeFile string                       `json:"dockerComposeFile,omitempty"`
 	Service           string                       `json:"service,omitempty"`
 	Features          map[string]map[string]string `json:"features"`
@@ -254,6 +254,12 @@ type DevcontainerConfig struct {
 	WorkspaceFolder   string                       `json:"workspaceFolder,omitempty"`
 }
 
+// DevcontainerBuild points devcontainer.json at a generated Dockerfile
+type DevcontainerBuild struct {
+	Dockerfile string            `json:"dockerfile"`
+	Args       map[string]string `json:"args,omitempty"`
+}
+
 type DevcontainerCustomizations struct {
 	VSCode *VSCodeCustomizations `json:"vscode,omitempty"`
 }
@@ -279,6 +285,7 @@ configuration into containerized environments.`,
 		newDevcontainerInitCmd(),
 		newDevcontainerImagesCmd(),
 		newDevcontainerServicesCmd(),
+		newDevcontainerUpdateCmd(),
 	)
 
 	return cmd
@@ -286,13 +293,15 @@ configuration into containerized environments.`,
 
 func newDevcontainerInitCmd() *cobra.Command {
 	var (
-		image    string
-		preset   string
-		output   string
-		force    bool
-		noVSExt  bool
-		services []string
-		stack    string
+		image         string
+		preset        string
+		output        string
+		force         bool
+		noVSExt       bool
+		services      []string
+		stack         string
+		dockerfile    devcontainerDockerfileOptions
+		useDockerfile bool
 	)
 
 	cmd := &cobra.Command{
@@ -306,6 +315,12 @@ This command creates a devcontainer configuration that includes:
   - VS Code extension recommendations
   - Optional supporting services (postgres, redis, etc.)
 
+With --dockerfile, a Dockerfile extending the base image is generated
+instead. It installs the packages from your Brewfile (apt or apk where a
+package exists, Homebrew with --with-brew), maps the container user to
+your UID/GID and bakes in a checksum-verified blackdot binary. Run
+'blackdot devcontainer update' after changing the Brewfile.
+
 Available stacks (predefined service combinations):
   web    - postgres, redis (common web app)
   api    - postgres, redis (API backend)
@@ -318,7 +333,8 @@ Examples:
   blackdot devcontainer init --image go --preset developer
   blackdot devcontainer init --image go --stack web       # Use predefined stack
   blackdot devcontainer init --image go --services postgres,redis
-  blackdot devcontainer init --image node --services postgres,redis,localstack`,
+  blackdot devcontainer init --image node --services postgres,redis,localstack
+  blackdot devcontainer init --image debian --dockerfile --with-brew`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Expand stack to services if specified
 			if stack != "" {
@@ -332,6 +348,9 @@ Examples:
 				}
 				services = append(services, stackServices...)
 			}
+			if useDockerfile {
+				return initDevcontainer(image, preset, output, force, noVSExt, services, &dockerfile)
+			}
 			return runDevcontainerInit(image, preset, output, force, noVSExt, services)
 		},
 	}
@@ -343,6 +362,9 @@ Examples:
 	cmd.Flags().BoolVar(&noVSExt, "no-extensions", false, "Skip VS Code extension recommendations")
 	cmd.Flags().StringSliceVar(&services, "services", nil, "Supporting services (postgres, redis, mysql, mongo, sqlite, localstack, minio)")
 	cmd.Flags().StringVar(&stack, "stack", "", "Predefined service stack (web, api, aws, full, mongo)")
+	cmd.Flags().BoolVar(&useDockerfile, "dockerfile", false, "Generate a Dockerfile extending the base image")
+	cmd.Flags().StringVar(&dockerfile.Manifest, "manifest", "", "Brewfile to take packages from (default: packages tier Brewfile)")
+	cmd.Flags().BoolVar(&dockerfile.WithBrew, "with-brew", false, "Install formulas without a distro package via Homebrew")
 
 	return cmd
 }
@@ -413,6 +435,12 @@ func newDevcontainerServicesCmd() *cobra.Command {
 }
 
 func runDevcontainerInit(imageFlag, presetFlag, outputDir string, force, noVSExt bool, servicesFlag []string) error {
+	return initDevcontainer(imageFlag, presetFlag, outputDir, force, noVSExt, servicesFlag, nil)
+}
+
+// initDevcontainer generates the configuration; a non-nil dockerfile builds
+// from a generated Dockerfile instead of the prebuilt image
+func initDevcontainer(imageFlag, presetFlag, outputDir string, force, noVSExt bool, servicesFlag []string, dockerfile *devcontainerDockerfileOptions) error {
 	fmt.Println()
 	BoldCyan.Println("Blackdot Devcontainer Setup")
 	fmt.Println(strings.Repeat("═", 30))
@@ -523,6 +551,21 @@ func runDevcontainerInit(imageFlag, presetFlag, outputDir string, force, noVSExt
 		return fmt.Errorf("creating output directory: %w", err)
 	}
 
+	// Generate the Dockerfile first so manifest errors leave nothing behind
+	var build *DevcontainerBuild
+	if dockerfile != nil {
+		dockerfilePath := filepath.Join(outputDir, "Dockerfile")
+		content, err := generateDevcontainerDockerfile(selectedImage.Image, *dockerfile)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(dockerfilePath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("writing Dockerfile: %w", err)
+		}
+		Pass("Generated %s", dockerfilePath)
+		build = devcontainerBuildArgs()
+	}
+
 	// Generate configuration based on whether services are requested
 	var config DevcontainerConfig
 	if len(selectedServices) > 0 {
@@ -532,6 +575,9 @@ func runDevcontainerInit(imageFlag, presetFlag, outputDir string, force, noVSExt
 		// Generate docker-compose.yml
 		composePath := filepath.Join(outputDir, "docker-compose.yml")
 		composeContent := generateDockerCompose(selectedImage, selectedServices)
+		if build != nil {
+			composeContent = composeWithBuild(composeContent, selectedImage.Image, build)
+		}
 		if err := os.WriteFile(composePath, []byte(composeContent), 0644); err != nil {
 			return fmt.Errorf("writing docker-compose.yml: %w", err)
 		}
@@ -548,6 +594,9 @@ func runDevcontainerInit(imageFlag, presetFlag, outputDir string, force, noVSExt
 		// Generate simple image-based config
 		config = generateDevcontainerConfig(selectedImage, selectedPreset, noVSExt)
 	}
+	if build != nil {
+		useDockerfileBuild(&config, build)
+	}
 
 	// Write devcontainer.json
 	jsonData, err := json.MarshalIndent(config, "", "  ")
@@ -567,6 +616,9 @@ func runDevcontainerInit(imageFlag, presetFlag, outputDir string, force, noVSExt
 	// Summary
 	Dim.Println("Configuration:")
 	fmt.Printf("  Image:  %s\n", selectedImage.Image)
+	if build != nil {
+		fmt.Printf("  Build:  Dockerfile (UID %s, GID %s)\n", build.Args["USER_UID"], build.Args["USER_GID"])
+	}
 	fmt.Printf("  Preset: %s\n", selectedPreset)
 	fmt.Printf("  SSH agent forwarding: enabled\n")
 	if len(selectedImage.Extensions) > 0 && !noVSExt {
@@ -598,59 +650,36 @@ func selectImage() (DevcontainerImage, error) {
 	BoldCyan.Println("Select base image:")
 	fmt.Println()
 
+	options := make([]string, len(devcontainerImages))
 	for i, img := range devcontainerImages {
-		fmt.Printf("  %d. ", i+1)
-		Yellow.Print(img.Name)
-		Dim.Printf(" - %s\n", img.Description)
+		options[i] = fmt.Sprintf("%s - %s", img.Name, img.Description)
 	}
 
-	fmt.Println()
-	fmt.Print("Enter selection (1-", len(devcontainerImages), "): ")
-
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
+	choice, err := prompts.Select("Enter selection", options, -1)
 	if err != nil {
 		return DevcontainerImage{}, fmt.Errorf("reading input: %w", err)
 	}
 
-	input = strings.TrimSpace(input)
-	num, err := strconv.Atoi(input)
-	if err != nil || num < 1 || num > len(devcontainerImages) {
-		return DevcontainerImage{}, fmt.Errorf("invalid selection: %s", input)
-	}
-
 	fmt.Println()
-	return devcontainerImages[num-1], nil
+	return devcontainerImages[choice], nil
 }
 
 func selectPreset() (string, error) {
 	BoldCyan.Println("Select blackdot preset:")
 	fmt.Println()
 
+	options := make([]string, len(devcontainerPresets))
 	for i, preset := range devcontainerPresets {
-		fmt.Printf("  %d. ", i+1)
-		Yellow.Print(preset.Name)
-		fmt.Print(strings.Repeat(" ", 12-len(preset.Name)))
-		Dim.Printf("- %s\n", preset.Description)
+		options[i] = fmt.Sprintf("%-12s- %s", preset.Name, preset.Description)
 	}
 
-	fmt.Println()
-	fmt.Print("Enter selection (1-", len(devcontainerPresets), "): ")
-
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
+	choice, err := prompts.Select("Enter selection", options, -1)
 	if err != nil {
 		return "", fmt.Errorf("reading input: %w", err)
 	}
 
-	input = strings.TrimSpace(input)
-	num, err := strconv.Atoi(input)
-	if err != nil || num < 1 || num > len(devcontainerPresets) {
-		return "", fmt.Errorf("invalid selection: %s", input)
-	}
-
 	fmt.Println()
-	return devcontainerPresets[num-1].Name, nil
+	return devcontainerPresets[choice].Name, nil
 }
 
 func generateDevcontainerConfig(image DevcontainerImage, preset string, noVSExt bool) DevcontainerConfig {
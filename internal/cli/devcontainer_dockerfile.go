@@ -0,0 +1,388 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// devcontainerDockerfileOptions configures `devcontainer init --dockerfile`
+type devcontainerDockerfileOptions struct {
+	Manifest string // Brewfile to take packages from ("" = packages tier Brewfile)
+	WithBrew bool   // install formulas without a distro package via Homebrew
+}
+
+// brewToDistro maps Homebrew formulas to Debian/Ubuntu packages. An empty
+// value means the devcontainer base images already ship the tool.
+var brewToDistro = map[string]string{
+	"age":        "age",
+	"awscli":     "awscli",
+	"bash":       "",
+	"bat":        "bat",
+	"cmake":      "cmake",
+	"coreutils":  "",
+	"curl":       "",
+	"direnv":     "direnv",
+	"fd":         "fd-find",
+	"fzf":        "fzf",
+	"git":        "",
+	"gnupg":      "gnupg",
+	"grep":       "",
+	"htop":       "htop",
+	"httpie":     "httpie",
+	"jq":         "jq",
+	"make":       "make",
+	"ncdu":       "ncdu",
+	"neovim":     "neovim",
+	"openssh":    "openssh-client",
+	"pass":       "pass",
+	"pinentry":   "pinentry-curses",
+	"ripgrep":    "ripgrep",
+	"rsync":      "rsync",
+	"shellcheck": "shellcheck",
+	"sqlite":     "sqlite3",
+	"stow":       "stow",
+	"tmux":       "tmux",
+	"tree":       "tree",
+	"unzip":      "unzip",
+	"vim":        "vim",
+	"wget":       "wget",
+	"zoxide":     "zoxide",
+	"zsh":        "",
+}
+
+// alpineNames lists apk package names that differ from the Debian ones
+var alpineNames = map[string]string{
+	"fd-find":         "fd",
+	"pinentry-curses": "pinentry",
+	"sqlite3":         "sqlite",
+}
+
+// dockerfileMeta is recorded in the Dockerfile header so `devcontainer
+// update` can tell when the manifest changed and regenerate it
+type dockerfileMeta struct {
+	Base        string
+	Manifest    string
+	ManifestSHA string
+	WithBrew    bool
+}
+
+const (
+	dockerfileMetaBase     = "# blackdot-base: "
+	dockerfileMetaManifest = "# blackdot-manifest: "
+	dockerfileMetaSHA      = "# blackdot-manifest-sha256: "
+	dockerfileMetaWithBrew = "# blackdot-with-brew: "
+
+	// defaultManifest is recorded when the packages tier picks the Brewfile
+	defaultManifest = "packages-tier"
+)
+
+// loadDockerfileManifest returns the formulas from the manifest (with any
+// Brewfile overlays applied) and a checksum of its content
+func loadDockerfileManifest(manifest string) ([]string, string, error) {
+	path := expandPath(manifest)
+	if manifest == "" {
+		blackdotDir := BlackdotDir()
+		resolved, _, err := resolveBrewfile(blackdotDir, getPackageTier("", blackdotDir))
+		if err != nil {
+			return nil, "", err
+		}
+		path = resolved
+	}
+
+	layered, _, cleanup, err := layeredBrewfile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading manifest: %w", err)
+	}
+	defer cleanup()
+
+	data, err := os.ReadFile(layered)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading manifest: %w", err)
+	}
+	formulas, _, err := parseBrewfile(layered)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading manifest: %w", err)
+	}
+	return formulas, sha256Hex(data), nil
+}
+
+// splitFormulas sorts formulas into distro packages and formulas that
+// only Homebrew provides
+func splitFormulas(formulas []string, alpine bool) (distro, brewOnly []string) {
+	seen := make(map[string]bool)
+	for _, formula := range formulas {
+		// Tapped formulas ("owner/tap/name") map by their short name
+		short := formula[strings.LastIndex(formula, "/")+1:]
+		pkg, ok := brewToDistro[short]
+		switch {
+		case !ok:
+			brewOnly = append(brewOnly, formula)
+		case pkg == "":
+			// already in the base image
+		default:
+			if alpine && alpineNames[pkg] != "" {
+				pkg = alpineNames[pkg]
+			}
+			if !seen[pkg] {
+				seen[pkg] = true
+				distro = append(distro, pkg)
+			}
+		}
+	}
+	sort.Strings(distro)
+	sort.Strings(brewOnly)
+	return distro, brewOnly
+}
+
+// generateDevcontainerDockerfile renders a Dockerfile extending base with
+// the manifest packages, UID/GID mapping and the blackdot binary
+func generateDevcontainerDockerfile(base string, opts devcontainerDockerfileOptions) (string, error) {
+	formulas, sum, err := loadDockerfileManifest(opts.Manifest)
+	if err != nil {
+		return "", err
+	}
+	alpine := strings.Contains(base, "alpine")
+	distro, brewOnly := splitFormulas(formulas, alpine)
+	if alpine && opts.WithBrew {
+		Warn("Homebrew does not support Alpine; %d formulas will be skipped", len(brewOnly))
+		opts.WithBrew = false
+	}
+
+	// Record the manifest so update works from any directory
+	manifest := defaultManifest
+	if opts.Manifest != "" {
+		abs, err := filepath.Abs(expandPath(opts.Manifest))
+		if err != nil {
+			return "", err
+		}
+		manifest = tildePath(abs)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Generated by blackdot devcontainer init --dockerfile\n")
+	sb.WriteString("# Regenerate with 'blackdot devcontainer update' after changing the manifest\n")
+	sb.WriteString(dockerfileMetaBase + base + "\n")
+	sb.WriteString(dockerfileMetaManifest + manifest + "\n")
+	sb.WriteString(dockerfileMetaSHA + sum + "\n")
+	sb.WriteString(dockerfileMetaWithBrew + strconv.FormatBool(opts.WithBrew) + "\n\n")
+	sb.WriteString(fmt.Sprintf("FROM %s\n\n", base))
+
+	// Map the container user onto the host user so bind mounts stay writable
+	sb.WriteString("ARG USERNAME=vscode\n")
+	sb.WriteString("ARG USER_UID=1000\n")
+	sb.WriteString("ARG USER_GID=$USER_UID\n")
+	sb.WriteString("RUN if [ \"$(id -u $USERNAME)\" != \"$USER_UID\" ] || [ \"$(id -g $USERNAME)\" != \"$USER_GID\" ]; then \\\n")
+	sb.WriteString("        groupmod --gid $USER_GID $USERNAME \\\n")
+	sb.WriteString("        && usermod --uid $USER_UID --gid $USER_GID $USERNAME \\\n")
+	sb.WriteString("        && chown -R $USER_UID:$USER_GID /home/$USERNAME; \\\n")
+	sb.WriteString("    fi\n\n")
+
+	if len(distro) > 0 {
+		sb.WriteString("# Packages from the manifest\n")
+		if alpine {
+			sb.WriteString("RUN apk add --no-cache \\\n")
+		} else {
+			sb.WriteString("RUN apt-get update \\\n")
+			sb.WriteString("    && DEBIAN_FRONTEND=noninteractive apt-get install -y --no-install-recommends \\\n")
+		}
+		sb.WriteString("        " + strings.Join(distro, " \\\n        "))
+		if alpine {
+			sb.WriteString("\n\n")
+		} else {
+			sb.WriteString(" \\\n    && rm -rf /var/lib/apt/lists/*\n\n")
+		}
+	}
+
+	// The release checksums file lists "<sha256>  blackdot-linux-<arch>"
+	sb.WriteString("# blackdot binary, verified against the release checksums\n")
+	sb.WriteString("ARG BLACKDOT_VERSION=latest\n")
+	sb.WriteString("ARG TARGETARCH\n")
+	sb.WriteString("RUN set -eu; \\\n")
+	sb.WriteString("    arch=\"${TARGETARCH:-$(uname -m)}\"; \\\n")
+	sb.WriteString("    case \"$arch\" in x86_64) arch=amd64 ;; aarch64) arch=arm64 ;; esac; \\\n")
+	sb.WriteString("    asset=\"blackdot-linux-$arch\"; \\\n")
+	sb.WriteString("    url=\"https://github.com/blackwell-systems/blackdot/releases\"; \\\n")
+	sb.WriteString("    if [ \"$BLACKDOT_VERSION\" = latest ]; then url=\"$url/latest/download\"; else url=\"$url/download/$BLACKDOT_VERSION\"; fi; \\\n")
+	sb.WriteString("    curl -fsSL -o \"/tmp/$asset\" \"$url/$asset\"; \\\n")
+	sb.WriteString(fmt.Sprintf("    curl -fsSL \"$url/%s\" | grep \" $asset\\$\" > /tmp/blackdot.sha256; \\\n", checksumAsset))
+	sb.WriteString("    (cd /tmp && sha256sum -c blackdot.sha256); \\\n")
+	sb.WriteString("    install -m 0755 \"/tmp/$asset\" /usr/local/bin/blackdot; \\\n")
+	sb.WriteString("    rm -f \"/tmp/$asset\" /tmp/blackdot.sha256\n")
+
+	if len(brewOnly) > 0 {
+		sb.WriteString("\n")
+		if opts.WithBrew {
+			sb.WriteString("# Formulas without a distro package, via Homebrew on Linux\n")
+			sb.WriteString("USER $USERNAME\n")
+			sb.WriteString("RUN NONINTERACTIVE=1 /bin/bash -c \"$(curl -fsSL https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh)\" \\\n")
+			sb.WriteString(fmt.Sprintf("    && /home/linuxbrew/.linuxbrew/bin/brew install %s\n", strings.Join(brewOnly, " ")))
+			sb.WriteString("USER root\n")
+			sb.WriteString("ENV PATH=/home/linuxbrew/.linuxbrew/bin:$PATH\n")
+		} else {
+			sb.WriteString("# Not installed (no distro package; use --with-brew to install via Homebrew):\n")
+			for _, formula := range brewOnly {
+				sb.WriteString(fmt.Sprintf("#   %s\n", formula))
+			}
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// readDockerfileMeta parses the header written by generateDevcontainerDockerfile
+func readDockerfileMeta(path string) (dockerfileMeta, error) {
+	var meta dockerfileMeta
+	f, err := os.Open(path)
+	if err != nil {
+		return meta, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		switch {
+		case strings.HasPrefix(line, dockerfileMetaBase):
+			meta.Base = strings.TrimPrefix(line, dockerfileMetaBase)
+		case strings.HasPrefix(line, dockerfileMetaManifest):
+			meta.Manifest = strings.TrimPrefix(line, dockerfileMetaManifest)
+		case strings.HasPrefix(line, dockerfileMetaSHA):
+			meta.ManifestSHA = strings.TrimPrefix(line, dockerfileMetaSHA)
+		case strings.HasPrefix(line, dockerfileMetaWithBrew):
+			meta.WithBrew = strings.TrimPrefix(line, dockerfileMetaWithBrew) == "true"
+		}
+	}
+	if meta.Base == "" {
+		return meta, fmt.Errorf("%s was not generated by 'blackdot devcontainer init --dockerfile'", path)
+	}
+	if meta.Manifest == defaultManifest {
+		meta.Manifest = ""
+	}
+	return meta, scanner.Err()
+}
+
+// devcontainerBuildArgs builds from the generated Dockerfile with the
+// current user's UID and GID
+func devcontainerBuildArgs() *DevcontainerBuild {
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid <= 0 {
+		// Windows has no UIDs, and root cannot be remapped; keep the
+		// image default
+		uid, gid = 1000, 1000
+	}
+	return &DevcontainerBuild{
+		Dockerfile: "Dockerfile",
+		Args: map[string]string{
+			"USER_UID": strconv.Itoa(uid),
+			"USER_GID": strconv.Itoa(gid),
+		},
+	}
+}
+
+// useDockerfileBuild switches an image-based config to the Dockerfile. The
+// blackdot feature is dropped since the binary is baked into the image.
+func useDockerfileBuild(config *DevcontainerConfig, build *DevcontainerBuild) {
+	delete(config.Features, "ghcr.io/blackwell-systems/blackdot:1")
+	if config.DockerComposeFile == "" {
+		config.Image = ""
+		config.Build = build
+	}
+}
+
+// composeWithBuild replaces the app service image with a build of the
+// generated Dockerfile
+func composeWithBuild(compose, image string, build *DevcontainerBuild) string {
+	var sb strings.Builder
+	sb.WriteString("    build:\n")
+	sb.WriteString("      context: .\n")
+	sb.WriteString(fmt.Sprintf("      dockerfile: %s\n", build.Dockerfile))
+	sb.WriteString("      args:\n")
+	keys := make([]string, 0, len(build.Args))
+	for k := range build.Args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("        %s: \"%s\"\n", k, build.Args[k]))
+	}
+	return strings.Replace(compose, fmt.Sprintf("    image: %s\n", image), sb.String(), 1)
+}
+
+func newDevcontainerUpdateCmd() *cobra.Command {
+	var (
+		output string
+		check  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Regenerate the Dockerfile when the package manifest changed",
+		Long: `Regenerate a Dockerfile created by 'devcontainer init --dockerfile'.
+
+The Dockerfile records a checksum of the Brewfile it was generated from.
+When the Brewfile (or one of its overlays) has changed since, the package
+layer is regenerated; base image and options are kept. Use --force to
+regenerate regardless.
+
+Examples:
+  blackdot devcontainer update
+  blackdot devcontainer update --check     # Exit 1 when out of date (CI)`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDevcontainerUpdate(output, check)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", ".devcontainer", "Devcontainer directory")
+	cmd.Flags().BoolVar(&check, "check", false, "Only report whether the Dockerfile is out of date")
+
+	return cmd
+}
+
+func runDevcontainerUpdate(outputDir string, check bool) error {
+	dockerfilePath := filepath.Join(outputDir, "Dockerfile")
+	meta, err := readDockerfileMeta(dockerfilePath)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+
+	_, sum, err := loadDockerfileManifest(meta.Manifest)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+	if sum == meta.ManifestSHA && !force {
+		Pass("%s is up to date", dockerfilePath)
+		return nil
+	}
+	if check {
+		err := fmt.Errorf("%s is out of date (run 'blackdot devcontainer update')", dockerfilePath)
+		Fail("%v", err)
+		return err
+	}
+
+	content, err := generateDevcontainerDockerfile(meta.Base, devcontainerDockerfileOptions{
+		Manifest: meta.Manifest,
+		WithBrew: meta.WithBrew,
+	})
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+	if err := os.WriteFile(dockerfilePath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("writing Dockerfile: %w", err)
+	}
+	Pass("Regenerated %s", dockerfilePath)
+	Info("Rebuild the container to pick up the new packages")
+	return nil
+}
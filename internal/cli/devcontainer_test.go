@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -414,3 +415,78 @@ func TestDevcontainerServicesSubcommand(t *testing.T) {
 		t.Error("expected subcommand 'services' not found")
 	}
 }
+
+// TestSplitFormulas verifies formulas map to distro packages, tools the
+// base images ship are dropped, and the rest are left for Homebrew
+func TestSplitFormulas(t *testing.T) {
+	formulas := []string{"ripgrep", "fd", "git", "lazygit", "owner/tap/jq", "sqlite"}
+
+	distro, brewOnly := splitFormulas(formulas, false)
+	if strings.Join(distro, " ") != "fd-find jq ripgrep sqlite3" {
+		t.Errorf("distro = %v", distro)
+	}
+	if strings.Join(brewOnly, " ") != "lazygit" {
+		t.Errorf("brewOnly = %v", brewOnly)
+	}
+
+	distro, _ = splitFormulas(formulas, true)
+	if strings.Join(distro, " ") != "fd jq ripgrep sqlite" {
+		t.Errorf("alpine distro = %v", distro)
+	}
+}
+
+// TestRunDevcontainerInitDockerfile verifies --dockerfile writes a
+// Dockerfile build and that update regenerates it when the manifest changes
+func TestRunDevcontainerInitDockerfile(t *testing.T) {
+	setScheduleEnv(t, "")
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, ".devcontainer")
+	manifest := filepath.Join(tmpDir, "Brewfile")
+	os.WriteFile(manifest, []byte("brew \"ripgrep\"\nbrew \"lazygit\"\ncask \"iterm2\"\n"), 0644)
+
+	opts := &devcontainerDockerfileOptions{Manifest: manifest}
+	if err := initDevcontainer("debian", "minimal", outputDir, false, false, []string{"postgres"}, opts); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+
+	dockerfilePath := filepath.Join(outputDir, "Dockerfile")
+	data, _ := os.ReadFile(dockerfilePath)
+	dockerfile := string(data)
+	for _, want := range []string{"FROM mcr.microsoft.com/devcontainers/base:debian", "        ripgrep \\\n", "#   lazygit", "SHA256SUMS.txt"} {
+		if !strings.Contains(dockerfile, want) {
+			t.Errorf("Dockerfile missing %q", want)
+		}
+	}
+	if strings.Contains(dockerfile, "iterm2") {
+		t.Error("casks should not be installed in the container")
+	}
+
+	compose, _ := os.ReadFile(filepath.Join(outputDir, "docker-compose.yml"))
+	if !strings.Contains(string(compose), "      dockerfile: Dockerfile\n") || strings.Contains(string(compose), "image: mcr.microsoft.com") {
+		t.Errorf("app service should build the Dockerfile:\n%s", compose)
+	}
+	var config DevcontainerConfig
+	data, _ = os.ReadFile(filepath.Join(outputDir, "devcontainer.json"))
+	json.Unmarshal(data, &config)
+	if _, ok := config.Features["ghcr.io/blackwell-systems/blackdot:1"]; ok {
+		t.Error("blackdot feature should be dropped when the binary is baked in")
+	}
+
+	if err := runDevcontainerUpdate(outputDir, true); err != nil {
+		t.Errorf("unchanged manifest reported stale: %v", err)
+	}
+	os.WriteFile(manifest, []byte("brew \"ripgrep\"\nbrew \"jq\"\n"), 0644)
+	if err := runDevcontainerUpdate(outputDir, true); err == nil {
+		t.Error("--check should fail after the manifest changed")
+	}
+	if err := runDevcontainerUpdate(outputDir, false); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	data, _ = os.ReadFile(dockerfilePath)
+	if !strings.Contains(string(data), "        jq \\\n") || strings.Contains(string(data), "lazygit") {
+		t.Errorf("Dockerfile not regenerated:\n%s", data)
+	}
+	if err := runDevcontainerUpdate(outputDir, true); err != nil {
+		t.Errorf("regenerated Dockerfile reported stale: %v", err)
+	}
+}
@@ -1,14 +1,23 @@
 package cli
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
+	"github.com/blackwell-systems/blackdot/internal/links"
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/plan"
+	"github.com/blackwell-systems/blackdot/internal/vault"
+	"github.com/blackwell-systems/blackdot/internal/vault/keychain"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -24,6 +33,30 @@ type doctorState struct {
 	warnChecks   []string
 	warnFixes    []string
 
+	// Fixers: --fix runs them inside a transaction log (batch) that
+	// 'doctor undo-fixes' reverts; --dry-run only lists them
+	fixMode   bool
+	dryRun    bool
+	batch     *fixBatch
+	changes   *plan.Plan // --dry-run: what the fixers would change
+	fixable   int
+	fixed     int
+	fixErrors int
+
+	// --refresh: run every --version instead of using the cache
+	refreshVersions bool
+
+	// Current section, for progress events
+	phase   string
+	percent int
+
+	// Every check outcome, compared between runs by --watch
+	results []doctorResult
+
+	// Where the report is printed; a section run concurrently buffers
+	out io.Writer
+	buf *bytes.Buffer
+
 	// Colors
 	bold   func(a ...interface{}) string
 	dim    func(a ...interface{}) string
@@ -37,6 +70,12 @@ type doctorState struct {
 func newDoctorCmd() *cobra.Command {
 	var fixMode bool
 	var quickMode bool
+	var dryRun bool
+	var planFormat string
+	var watch bool
+	var refresh bool
+	var sections []string
+	watchOpts := doctorWatchOptions{}
 
 	cmd := &cobra.Command{
 		Use:     "doctor",
@@ -44,7 +83,19 @@ func newDoctorCmd() *cobra.Command {
 		Short:   "Comprehensive blackdot health check",
 		Long:    `Comprehensive blackdot health check`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDoctor(fixMode, quickMode)
+			only, err := parseDoctorSections(sections)
+			if err != nil {
+				return err
+			}
+			if watch {
+				if fixMode || dryRun {
+					return fmt.Errorf("--watch cannot be combined with --fix")
+				}
+				watchOpts.quick = quickMode
+				watchOpts.only = only
+				return runDoctorWatch(watchOpts)
+			}
+			return runDoctor(fixMode || dryRun, dryRun, quickMode, refresh, planFormat, only)
 		},
 	}
 
@@ -53,8 +104,19 @@ func newDoctorCmd() *cobra.Command {
 		printDoctorHelp()
 	})
 
-	cmd.Flags().BoolVarP(&fixMode, "fix", "f", false, "Auto-fix permission issues")
+	cmd.Flags().BoolVarP(&fixMode, "fix", "f", false, "Auto-fix issues (undo with 'doctor undo-fixes')")
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "With --fix, show the fixes without applying them")
 	cmd.Flags().BoolVarP(&quickMode, "quick", "q", false, "Run quick checks only (skip vault)")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Re-run --version on every command instead of using cached versions")
+	cmd.Flags().StringSliceVarP(&sections, "section", "s", nil, "Check only these sections (e.g. ssh,templates)")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Re-run checks periodically and on file changes, printing only changes")
+	cmd.Flags().DurationVar(&watchOpts.interval, "interval", 5*time.Minute, "Time between checks for --watch")
+	cmd.Flags().IntVar(&watchOpts.threshold, "threshold", 80, "Health score below which --watch notifies")
+	cmd.Flags().BoolVar(&watchOpts.notify, "notify", true, "Send a desktop notification when the score drops below --threshold")
+	cmd.Flags().BoolVar(&watchOpts.exit, "exit-below", false, "Exit with an error when the score drops below --threshold")
+	addPlanFormatFlag(cmd, &planFormat)
+
+	cmd.AddCommand(newDoctorUndoFixesCmd())
 
 	return cmd
 }
@@ -71,6 +133,14 @@ func printDoctorHelp() {
 	// Usage
 	Bold.Print("Usage:")
 	fmt.Println(" blackdot doctor [OPTIONS]")
+	fmt.Println("        blackdot doctor undo-fixes [--dry-run]")
+	fmt.Println()
+
+	// Commands
+	BoldCyan.Println("Commands:")
+	fmt.Print("  ")
+	Yellow.Printf("%-12s", "undo-fixes")
+	Dim.Println("Revert the changes made by the last --fix")
 	fmt.Println()
 
 	// Options
@@ -80,7 +150,13 @@ func printDoctorHelp() {
 	fmt.Print(", ")
 	Yellow.Print("-f")
 	fmt.Print("      ")
-	Dim.Println("Auto-fix permission issues")
+	Dim.Println("Auto-fix issues (permissions, links, dirs, templates)")
+	fmt.Print("  ")
+	Yellow.Print("--dry-run")
+	fmt.Print(", ")
+	Yellow.Print("-n")
+	fmt.Print("  ")
+	Dim.Println("With --fix, show fixes without applying them")
 	fmt.Print("  ")
 	Yellow.Print("--quick")
 	fmt.Print(", ")
@@ -88,6 +164,28 @@ func printDoctorHelp() {
 	fmt.Print("    ")
 	Dim.Println("Run quick checks only (skip vault)")
 	fmt.Print("  ")
+	Yellow.Print("--section")
+	fmt.Print(", ")
+	Yellow.Print("-s")
+	fmt.Print("  ")
+	Dim.Println("Check only these sections, e.g. ssh,templates")
+	Dim.Println("                (" + strings.Join(doctorSectionKeys(), ", ") + ")")
+	fmt.Print("  ")
+	Yellow.Print("--refresh")
+	fmt.Print("      ")
+	Dim.Println("Re-run --version on every command instead of using the cache")
+	fmt.Print("  ")
+	Yellow.Print("--watch")
+	fmt.Print(", ")
+	Yellow.Print("-w")
+	fmt.Print("    ")
+	Dim.Println("Re-check every --interval (5m) and when ~/.ssh etc. change;")
+	Dim.Println("                print only changes, notify below --threshold (80)")
+	fmt.Print("  ")
+	Yellow.Print("--exit-below")
+	fmt.Print("   ")
+	Dim.Println("With --watch, exit non-zero when the score drops below --threshold")
+	fmt.Print("  ")
 	Yellow.Print("--help")
 	fmt.Print(", ")
 	Yellow.Print("-h")
@@ -117,30 +215,76 @@ func printDoctorHelp() {
 	fmt.Print("  ")
 	Yellow.Print("blackdot doctor --fix")
 	fmt.Print("    ")
-	Dim.Println("# Auto-fix permissions")
+	Dim.Println("# Auto-fix issues")
 	fmt.Print("  ")
 	Yellow.Print("blackdot doctor --quick")
 	fmt.Print("  ")
 	Dim.Println("# Fast checks only")
+	fmt.Print("  ")
+	Yellow.Print("blackdot doctor -s ssh,templates")
+	fmt.Print(" ")
+	Dim.Println("# Only these sections")
+	fmt.Print("  ")
+	Yellow.Print("blackdot doctor undo-fixes")
+	fmt.Print(" ")
+	Dim.Println("# Revert the last --fix")
+	fmt.Print("  ")
+	Yellow.Print("blackdot doctor --watch")
+	fmt.Print("  ")
+	Dim.Println("# Monitor, printing only changes")
 	fmt.Println()
 }
 
-func runDoctor(fixMode, quickMode bool) error {
-	// Initialize state
-	state := &doctorState{
-		bold:   color.New(color.Bold).SprintFunc(),
-		dim:    color.New(color.Faint).SprintFunc(),
-		red:    color.New(color.FgRed).SprintFunc(),
-		green:  color.New(color.FgGreen).SprintFunc(),
-		yellow: color.New(color.FgYellow).SprintFunc(),
-		blue:   color.New(color.FgBlue).SprintFunc(),
-		cyan:   color.New(color.FgCyan).SprintFunc(),
+// runDoctor runs the health check. With only set, just those sections are
+// checked: the banner is left out and the score is not saved, as a
+// partial score would skew the history.
+func runDoctor(fixMode, dryRun, quickMode, refresh bool, planFormat string, only map[string]bool) error {
+	changes, printPlan, err := startChangePlan("doctor --fix", dryRun, planFormat)
+	if err != nil {
+		return err
 	}
 
+	// Initialize state
+	state := newDoctorState()
+	state.changes = changes
+	state.fixMode = fixMode
+	state.dryRun = dryRun
+	state.refreshVersions = refresh
+
 	home, _ := os.UserHomeDir()
 	blackdotDir := getBlackdotDir()
 
 	// Banner
+	if len(only) == 0 {
+		printDoctorBanner(state)
+	} else {
+		fmt.Println()
+	}
+
+	runDoctorChecks(state, home, blackdotDir, quickMode, only)
+
+	if state.batch != nil {
+		state.batch.finish()
+	}
+
+	// Summary
+	printSummary(state)
+	printPlan()
+
+	// Save metrics
+	if len(only) == 0 {
+		saveMetrics(state, blackdotDir, home)
+	}
+
+	// Exit code
+	if state.checksFailed > 0 {
+		return fmt.Errorf("health check failed with %d error(s)", state.checksFailed)
+	}
+	return nil
+}
+
+// printDoctorBanner prints the full report's heading
+func printDoctorBanner(state *doctorState) {
 	fmt.Println()
 	boldCyan := color.New(color.Bold, color.FgCyan).SprintFunc()
 	fmt.Println(boldCyan(`    ____  __           __       __      __        ____             __
@@ -151,59 +295,152 @@ func runDoctor(fixMode, quickMode bool) error {
 	fmt.Println()
 	fmt.Println(state.dim("⚫ Comprehensive blackdot health check"))
 	fmt.Println()
+}
 
+// newDoctorState returns an empty report
+func newDoctorState() *doctorState {
+	return &doctorState{
+		bold:   color.New(color.Bold).SprintFunc(),
+		dim:    color.New(color.Faint).SprintFunc(),
+		red:    color.New(color.FgRed).SprintFunc(),
+		green:  color.New(color.FgGreen).SprintFunc(),
+		yellow: color.New(color.FgYellow).SprintFunc(),
+		blue:   color.New(color.FgBlue).SprintFunc(),
+		cyan:   color.New(color.FgCyan).SprintFunc(),
+	}
+}
+
+// doctorSections are the report's sections in order. A section's checks
+// only touch the doctorState they are given, so sections run concurrently;
+// applies, when set, leaves the section out where it does not apply.
+var doctorSections = []doctorSection{
 	// Section 1: Version & Updates
-	state.section("Version & Updates")
-	checkVersionAndUpdates(state, blackdotDir)
+	{name: "Version & Updates", key: "version", run: func(state *doctorState, d doctorRun) {
+		checkVersionAndUpdates(state, d.blackdotDir)
+	}},
 
 	// Section 2: Core Components
-	state.section("Core Components")
-	checkCoreComponents(state, home, blackdotDir)
+	{name: "Core Components", key: "core", run: func(state *doctorState, d doctorRun) {
+		checkCoreComponents(state, d.home, d.blackdotDir)
+	}},
 
 	// Section 3: Required Commands
-	state.section("Required Commands")
-	checkRequiredCommands(state)
+	{name: "Required Commands", key: "commands", run: func(state *doctorState, d doctorRun) {
+		checkRequiredCommands(state)
+	}},
 
 	// Section 4: SSH Configuration
-	state.section("SSH Configuration")
-	checkSSHConfiguration(state, home, fixMode)
+	{name: "SSH Configuration", key: "ssh", run: func(state *doctorState, d doctorRun) {
+		checkSSHConfiguration(state, d.home)
+	}},
 
 	// Section 5: AWS Configuration (if present)
-	if _, err := os.Stat(filepath.Join(home, ".aws")); err == nil {
-		state.section("AWS Configuration")
-		checkAWSConfiguration(state, home, fixMode)
-	}
-
-	// Section 6: Vault Status (unless quick mode)
-	if !quickMode {
+	{name: "AWS Configuration", key: "aws", applies: func(d doctorRun) bool {
+		_, err := os.Stat(filepath.Join(d.home, ".aws"))
+		return err == nil
+	}, run: func(state *doctorState, d doctorRun) {
+		checkAWSConfiguration(state, d.home)
+	}},
+
+	// Section 6: GPG Configuration (if gpg installed)
+	{name: "GPG Configuration", key: "gpg", applies: func(d doctorRun) bool {
+		_, err := gpgBinary()
+		return err == nil
+	}, run: func(state *doctorState, d doctorRun) {
+		checkGPGConfiguration(state)
+	}},
+
+	// Section 7: Kubernetes (if a kubeconfig exists)
+	{name: "Kubernetes", key: "k8s", applies: func(d doctorRun) bool {
+		_, _, err := loadKubeconfigs()
+		return err == nil
+	}, run: func(state *doctorState, d doctorRun) {
+		checkK8sConfiguration(state, d.quick)
+	}},
+
+	// Section 8: File Permissions
+	{name: "File Permissions", key: "permissions", run: func(state *doctorState, d doctorRun) {
+		checkFilePermissions(state, d.home)
+	}},
+
+	// Section 9: Leaked Secrets
+	{name: "Leaked Secrets", key: "secrets", run: func(state *doctorState, d doctorRun) {
+		checkLeakedSecrets(state, d.home, d.blackdotDir)
+	}},
+
+	// Section 10: Vault Status (unless quick mode); the heading names the
+	// backend, so the checks print it
+	{name: "Vault Status", key: "vault", applies: func(d doctorRun) bool {
+		return !d.quick
+	}, untitled: true, skipped: "Skipped (--quick)", run: func(state *doctorState, d doctorRun) {
 		checkVaultStatus(state)
-	}
-
-	// Section 7: Shell Configuration
-	state.section("Shell Configuration")
-	checkShellConfiguration(state, home, blackdotDir)
+		checkVaultSessionFile(state)
+	}},
+
+	// Section 11: Shell Configuration
+	{name: "Shell Configuration", key: "shell", run: func(state *doctorState, d doctorRun) {
+		checkShellConfiguration(state, d.home, d.blackdotDir)
+		checkCompletions(state)
+	}},
+
+	// Section 12: Claude Code (optional)
+	{name: "Claude Code", key: "claude", applies: func(d doctorRun) bool {
+		_, err := exec.LookPath("claude")
+		return err == nil
+	}, run: func(state *doctorState, d doctorRun) {
+		checkClaudeCode(state, d.home)
+	}},
+
+	// Section 13: Template System
+	{name: "Template System", key: "templates", run: func(state *doctorState, d doctorRun) {
+		checkTemplateSystem(state, d.blackdotDir)
+	}},
+
+	// Section 14: Overlay Repos (if any are configured)
+	{name: "Overlay Repos", key: "repos", applies: func(d doctorRun) bool {
+		return len(paths.Overlays()) > 0
+	}, run: func(state *doctorState, d doctorRun) {
+		checkOverlayRepos(state, paths.Overlays())
+	}},
+
+	// Section 15: Login Items (if any are configured or installed)
+	{name: "Login Items", key: "login", applies: func(d doctorRun) bool {
+		entries, err := autostartEntries()
+		return err != nil || len(entries) > 0
+	}, run: func(state *doctorState, d doctorRun) {
+		entries, err := autostartEntries()
+		checkAutostart(state, entries, err)
+	}},
+}
 
-	// Section 8: Claude Code (optional)
-	if _, err := exec.LookPath("claude"); err == nil {
-		state.section("Claude Code")
-		checkClaudeCode(state, home)
+// checkOverlayRepos reports each overlay's git state from local refs; it
+// does not fetch, so "behind" is as of the last fetch
+func checkOverlayRepos(state *doctorState, overlays []paths.Root) {
+	for _, r := range overlays {
+		if _, err := os.Stat(r.Path); err != nil {
+			state.fail(fmt.Sprintf("%s: %s is missing", r.Name, tildePath(r.Path)), "blackdot repos remove "+r.Name)
+			continue
+		}
+		st := repoGitState(r.Path)
+		switch {
+		case !st.IsRepo:
+			state.info(fmt.Sprintf("%s: not a git repo (%s)", r.Name, tildePath(r.Path)))
+			continue
+		case st.Behind > 0:
+			state.warn(fmt.Sprintf("%s: behind %s by %d commit(s)", r.Name, st.Upstream, st.Behind), "blackdot repos update "+r.Name)
+		case st.Dirty == 0 && st.Ahead == 0:
+			state.pass(fmt.Sprintf("%s: clean %s", r.Name, describeRepoGit(st)))
+		}
+		if st.Dirty > 0 {
+			state.warn(fmt.Sprintf("%s: %d uncommitted change(s)", r.Name, st.Dirty), "git -C "+shellQuote(r.Path)+" status")
+		}
+		if st.Ahead > 0 {
+			state.info(fmt.Sprintf("%s: %d unpushed commit(s) on %s", r.Name, st.Ahead, st.Branch))
+		}
 	}
-
-	// Section 9: Template System
-	state.section("Template System")
-	checkTemplateSystem(state, blackdotDir)
-
-	// Summary
-	printSummary(state, fixMode)
-
-	// Save metrics
-	saveMetrics(state, blackdotDir, home)
-
-	// Exit code
-	if state.checksFailed > 0 {
-		return fmt.Errorf("health check failed with %d error(s)", state.checksFailed)
+	if conflicts := repoConflicts(paths.Roots(getBlackdotDir())); len(conflicts) > 0 {
+		state.info(fmt.Sprintf("%d file(s) overridden across roots (blackdot repos list)", len(conflicts)))
 	}
-	return nil
 }
 
 func getBlackdotDir() string {
@@ -220,32 +457,90 @@ func getBlackdotDir() string {
 	return ""
 }
 
+// writer is where the report is printed, stdout unless out is set
+func (s *doctorState) writer() io.Writer {
+	if s.out == nil {
+		return os.Stdout
+	}
+	return s.out
+}
+
+// section starts a heading in the report; the progress percentage is set
+// by the section runner
 func (s *doctorState) section(name string) {
-	fmt.Println()
-	fmt.Printf("%s%s── %s ──%s\n", "\033[1m", "\033[36m", name, "\033[0m")
+	if s.phase != "" {
+		traceEndPhase(s.phase)
+	}
+	s.phase = name
+	emitProgress(progressEvent{Phase: name, Percent: s.percent})
+
+	fmt.Fprintln(s.writer())
+	fmt.Fprintf(s.writer(), "%s%s── %s ──%s\n", "\033[1m", "\033[36m", name, "\033[0m")
+}
+
+// checkEvent records a check result and reports it as a progress event
+func (s *doctorState) checkEvent(status, msg string) {
+	s.results = append(s.results, doctorResult{Section: s.phase, Status: status, Message: msg})
+	emitProgress(progressEvent{Phase: s.phase, Percent: s.percent, Status: status, Message: msg})
 }
 
 func (s *doctorState) pass(msg string) {
-	fmt.Printf("%s %s\n", s.green("✓"), msg)
+	s.checkEvent("pass", msg)
+	fmt.Fprintf(s.writer(), "%s %s\n", s.green("✓"), msg)
 	s.checksPassed++
 }
 
 func (s *doctorState) fail(msg, fix string) {
-	fmt.Printf("%s %s\n", s.red("✗"), msg)
+	s.checkEvent("fail", msg)
+	fmt.Fprintf(s.writer(), "%s %s\n", s.red("✗"), msg)
 	s.failedChecks = append(s.failedChecks, msg)
 	s.failedFixes = append(s.failedFixes, fix)
 	s.checksFailed++
 }
 
+// repair runs a check's fixer under --fix and reports whether the issue
+// is fixed; if not, the caller records the failure as usual. Without
+// --fix, or with --dry-run, the fixer is only counted or listed.
+func (s *doctorState) repair(desc string, fix func(b *fixBatch) error) bool {
+	if !s.fixMode || s.dryRun {
+		if s.dryRun {
+			fmt.Fprintf(s.writer(), "%s Would %s\n", s.cyan("[DRY-RUN]"), desc)
+			if s.changes != nil {
+				plannedFix(s.changes, desc, fix)
+			}
+		}
+		s.fixable++
+		return false
+	}
+	if s.batch == nil {
+		b, err := newFixBatch()
+		if err != nil {
+			fmt.Fprintf(s.writer(), "  %s\n", s.dim(fmt.Sprintf("Cannot record fixes, not applying them: %v", err)))
+			s.fixMode = false
+			s.fixable++
+			return false
+		}
+		s.batch = b
+	}
+	if err := s.batch.run(desc, fix); err != nil {
+		fmt.Fprintf(s.writer(), "  %s\n", s.dim(fmt.Sprintf("Could not %s: %v", desc, err)))
+		s.fixErrors++
+		return false
+	}
+	s.fixed++
+	return true
+}
+
 func (s *doctorState) warn(msg, fix string) {
-	fmt.Printf("%s %s\n", s.yellow("!"), msg)
+	s.checkEvent("warn", msg)
+	fmt.Fprintf(s.writer(), "%s %s\n", s.yellow("!"), msg)
 	s.warnChecks = append(s.warnChecks, msg)
 	s.warnFixes = append(s.warnFixes, fix)
 	s.checksWarned++
 }
 
 func (s *doctorState) info(msg string) {
-	fmt.Printf("%s %s\n", s.blue("ℹ"), msg)
+	fmt.Fprintf(s.writer(), "%s %s\n", s.blue("ℹ"), msg)
 }
 
 func checkVersionAndUpdates(state *doctorState, blackdotDir string) {
@@ -301,92 +596,123 @@ func checkVersionAndUpdates(state *doctorState, blackdotDir string) {
 }
 
 func checkCoreComponents(state *doctorState, home, blackdotDir string) {
-	// Check symlinks
-	checkSymlink := func(name, link, target string) {
-		info, err := os.Lstat(link)
-		if err != nil {
-			state.fail(fmt.Sprintf("%s symlink missing", name), fmt.Sprintf("ln -sf \"$BLACKDOT_DIR/%s\" \"%s\"", target, link))
-			return
-		}
-
-		if info.Mode()&os.ModeSymlink != 0 {
-			actualTarget, _ := os.Readlink(link)
-			expectedTarget := target
-			expectedFullPath := filepath.Join(blackdotDir, target)
-
-			if actualTarget == expectedTarget || actualTarget == expectedFullPath {
+	// Check every link declared in links.yaml
+	resolved, err := declaredLinks()
+	if err != nil {
+		state.fail(fmt.Sprintf("links.yaml: %v", err), "Fix the syntax in links.yaml")
+	}
+	for _, r := range resolved {
+		name := tildePath(r.Target)
+		st, actual, mechanism := links.Inspect(r)
+		if st == links.StateOK {
+			switch mechanism {
+			case links.MechanismSymlink:
 				state.pass(fmt.Sprintf("%s symlink OK", name))
-			} else {
-				state.fail(fmt.Sprintf("%s points to wrong target: %s", name, actualTarget),
-					fmt.Sprintf("rm \"%s\" && ln -sf \"$BLACKDOT_DIR/%s\" \"%s\"", link, target, link))
+			case links.MechanismCopy:
+				// A copy does not follow repo updates
+				state.pass(fmt.Sprintf("%s linked (copy - re-run 'blackdot links apply' after updates)", name))
+			default:
+				state.pass(fmt.Sprintf("%s linked (%s)", name, mechanism))
+			}
+			continue
+		}
+		if st == links.StateSourceMissing && r.Optional {
+			continue
+		}
+		if st != links.StateSourceMissing {
+			var mechanism string
+			relink := func(b *fixBatch) error {
+				return b.link(r.Target, func() error {
+					res := links.Apply(r, false)
+					mechanism = res.Mechanism
+					return res.Err
+				})
+			}
+			if state.repair("relink "+name, relink) {
+				state.pass(fmt.Sprintf("%s link fixed (%s)", name, mechanism))
+				continue
 			}
+		}
+
+		msg := fmt.Sprintf("%s symlink missing", name)
+		switch st {
+		case links.StateWrongTarget:
+			msg = fmt.Sprintf("%s points to wrong target: %s", name, actual)
+		case links.StateBlocked:
+			msg = fmt.Sprintf("%s exists but is not a symlink", name)
+		case links.StateSourceMissing:
+			msg = fmt.Sprintf("%s source missing: %s", name, tildePath(r.Source))
+		}
+		if r.Optional || st == links.StateBlocked {
+			state.warn(msg, "blackdot links apply")
 		} else {
-			state.warn(fmt.Sprintf("%s exists but is not a symlink", name),
-				fmt.Sprintf("mv \"%s\" \"%s.backup\" && ln -sf \"$BLACKDOT_DIR/%s\" \"%s\"", link, link, target, link))
+			state.fail(msg, "blackdot links apply")
 		}
 	}
 
-	checkSymlink("~/.zshrc", filepath.Join(home, ".zshrc"), "zsh/zshrc")
-	checkSymlink("~/.p10k.zsh", filepath.Join(home, ".p10k.zsh"), "zsh/p10k.zsh")
+	// Check /workspace symlink
+	checkWorkspaceHealth(state, state.fixMode && !state.dryRun)
+}
 
-	// Check ~/.claude symlink (special case - not relative to blackdotDir)
-	workspaceTarget := filepath.Join(home, "workspace")
-	if wt := os.Getenv("WORKSPACE_TARGET"); wt != "" {
-		workspaceTarget = wt
+// checkWorkspaceHealth verifies the /workspace symlink resolves to the
+// configured target. --fix repairs it, allowing a sudo prompt.
+func checkWorkspaceHealth(state *doctorState, fixMode bool) {
+	if !initRegistry().Enabled("workspace_symlink") {
+		return
 	}
-	claudeTarget := filepath.Join(workspaceTarget, ".claude")
 
-	// Check claude symlink separately since it's not relative to BLACKDOT_DIR
-	claudeLink := filepath.Join(home, ".claude")
-	if info, err := os.Lstat(claudeLink); err != nil {
-		state.fail("~/.claude symlink missing", fmt.Sprintf("ln -sf \"%s\" \"%s\"", claudeTarget, claudeLink))
-	} else if info.Mode()&os.ModeSymlink != 0 {
-		actualTarget, _ := os.Readlink(claudeLink)
-		if actualTarget == claudeTarget {
-			state.pass("~/.claude symlink OK")
-		} else {
-			state.fail(fmt.Sprintf("~/.claude points to wrong target: %s", actualTarget),
-				fmt.Sprintf("rm \"%s\" && ln -sf \"%s\" \"%s\"", claudeLink, claudeTarget, claudeLink))
-		}
-	} else {
-		state.warn("~/.claude exists but is not a symlink",
-			fmt.Sprintf("mv \"%s\" \"%s.backup\" && ln -sf \"%s\" \"%s\"", claudeLink, claudeLink, claudeTarget, claudeLink))
+	policy := workspaceRepairPolicy()
+	if fixMode && policy == workspaceRepairCached {
+		policy = workspaceRepairPrompt
 	}
-
-	// Check /workspace symlink
-	if info, err := os.Lstat("/workspace"); err == nil && info.Mode()&os.ModeSymlink != 0 {
-		actualTarget, _ := os.Readlink("/workspace")
-		if actualTarget == workspaceTarget {
-			state.pass(fmt.Sprintf("/workspace symlink correct -> %s", workspaceTarget))
-		} else {
-			state.warn(fmt.Sprintf("/workspace -> %s (expected: %s)", actualTarget, workspaceTarget), "")
-		}
-	} else {
-		state.warn("/workspace symlink not configured (optional for multi-machine)", "")
+	before, after, repairErr := workspaceWatchdog(fixMode, policy, "doctor")
+
+	switch {
+	case before.Status == workspaceOK:
+		state.pass(fmt.Sprintf("%s symlink correct -> %s", before.Link, before.Expected))
+	case after.Status == workspaceOK:
+		state.pass(fmt.Sprintf("Repaired %s -> %s", after.Link, after.Expected))
+	case before.Status == workspaceMissing && repairErr == nil:
+		state.warn(before.describe()+" (optional for multi-machine)", "blackdot workspace check --repair")
+	default:
+		state.fail(before.describe(), "blackdot workspace check --repair")
 	}
 }
 
 func checkRequiredCommands(state *doctorState) {
-	checkCommand := func(cmd, pkg string) {
-		if path, err := exec.LookPath(cmd); err == nil {
-			// Get version
-			verCmd := exec.Command(path, "--version")
-			verOut, _ := verCmd.Output()
-			version := strings.Split(strings.TrimSpace(string(verOut)), "\n")[0]
-			if len(version) > 40 {
-				version = version[:40]
+	required := []struct{ cmd, pkg string }{
+		{"zsh", "zsh"},
+		{"git", "git"},
+		{"brew", "homebrew"},
+		{"jq", "jq"},
+	}
+	names := make([]string, len(required))
+	for i, r := range required {
+		names[i] = r.cmd
+	}
+	versions := commandVersions(names, state.refreshVersions)
+
+	for _, r := range required {
+		v := versions[r.cmd]
+		if v.Path == "" {
+			state.fail(fmt.Sprintf("%s not found", r.cmd), fmt.Sprintf("brew install %s", r.pkg))
+			continue
+		}
+		minimum := commandMinimumVersion(r.cmd)
+		switch {
+		case v.Line == "":
+			state.warn(fmt.Sprintf("%s (could not read its version)", r.cmd), "")
+		case minimum != "" && v.Version != "" && versionBelow(v.Version, minimum):
+			state.fail(fmt.Sprintf("%s %s is older than the required %s", r.cmd, v.Version, minimum), fmt.Sprintf("brew upgrade %s", r.pkg))
+		default:
+			line := v.Line
+			if len(line) > 40 {
+				line = line[:40]
 			}
-			state.pass(fmt.Sprintf("%s %s", cmd, state.dim(fmt.Sprintf("(%s)", version))))
-		} else {
-			state.fail(fmt.Sprintf("%s not found", cmd), fmt.Sprintf("brew install %s", pkg))
+			state.pass(fmt.Sprintf("%s %s", r.cmd, state.dim(fmt.Sprintf("(%s)", line))))
 		}
 	}
 
-	checkCommand("zsh", "zsh")
-	checkCommand("git", "git")
-	checkCommand("brew", "homebrew")
-	checkCommand("jq", "jq")
-
 	// Check vault CLIs (optional)
 	if _, err := exec.LookPath("bw"); err == nil {
 		state.pass("bw (Bitwarden CLI)")
@@ -399,12 +725,16 @@ func checkRequiredCommands(state *doctorState) {
 	}
 }
 
-func checkSSHConfiguration(state *doctorState, home string, fixMode bool) {
+func checkSSHConfiguration(state *doctorState, home string) {
 	sshDir := filepath.Join(home, ".ssh")
 
 	info, err := os.Stat(sshDir)
 	if err != nil {
-		state.warn("~/.ssh directory does not exist", "mkdir -p ~/.ssh && chmod 700 ~/.ssh")
+		if state.repair("create ~/.ssh (700)", func(b *fixBatch) error { return b.mkdirAll(sshDir, 0700) }) {
+			state.pass("Created ~/.ssh (700)")
+		} else {
+			state.warn("~/.ssh directory does not exist", "mkdir -p ~/.ssh && chmod 700 ~/.ssh")
+		}
 		return
 	}
 
@@ -413,8 +743,7 @@ func checkSSHConfiguration(state *doctorState, home string, fixMode bool) {
 	if perms == 0700 {
 		state.pass("~/.ssh directory permissions (700)")
 	} else {
-		if fixMode {
-			os.Chmod(sshDir, 0700)
+		if state.repair("chmod 700 ~/.ssh", func(b *fixBatch) error { return b.chmod(sshDir, 0700) }) {
 			state.pass("~/.ssh permissions fixed to 700")
 		} else {
 			state.fail(fmt.Sprintf("~/.ssh has permissions %04o (should be 700)", perms), "chmod 700 ~/.ssh")
@@ -434,8 +763,7 @@ func checkSSHConfiguration(state *doctorState, home string, fixMode bool) {
 			keyInfo, _ := os.Stat(keyPath)
 			keyPerms := keyInfo.Mode().Perm()
 			if keyPerms != 0600 {
-				if fixMode {
-					os.Chmod(keyPath, 0600)
+				if state.repair("chmod 600 ~/.ssh/"+name, func(b *fixBatch) error { return b.chmod(keyPath, 0600) }) {
 					state.pass(fmt.Sprintf("Fixed permissions on %s", name))
 				} else {
 					state.fail(fmt.Sprintf("%s has permissions %04o (should be 600)", name, keyPerms),
@@ -450,9 +778,42 @@ func checkSSHConfiguration(state *doctorState, home string, fixMode bool) {
 	} else {
 		state.warn("No SSH keys found in ~/.ssh", "ssh-keygen -t ed25519 -C \"your_email@example.com\"")
 	}
+
+	checkSSHAgent(state)
+}
+
+// checkSSHAgent checks that an agent answers. On Windows it is the
+// OpenSSH service behind a named pipe, which --fix can enable and start.
+func checkSSHAgent(state *doctorState) {
+	status := detectSSHAgent()
+	if status.Reachable {
+		state.pass("SSH agent reachable (" + status.Address + ")")
+		return
+	}
+
+	if svc := status.Service; svc != nil {
+		switch {
+		case !svc.Installed:
+			state.warn("Windows ssh-agent service not installed",
+				"Add-WindowsCapability -Online -Name OpenSSH.Client~~~~0.0.1.0")
+		case state.repair("enable and start the ssh-agent service", func(b *fixBatch) error { return startWindowsSSHAgent(*svc) }):
+			state.pass("Started the Windows ssh-agent service")
+		default:
+			state.warn(fmt.Sprintf("Windows ssh-agent service is %s (start type %s)",
+				strings.ToLower(valueOr(svc.State, "unknown")), strings.ToLower(valueOr(svc.StartType, "unknown"))),
+				"blackdot tools ssh agent --start")
+		}
+		return
+	}
+
+	if status.Address == "" {
+		state.info("SSH agent not running (SSH_AUTH_SOCK not set)")
+		return
+	}
+	state.warn(fmt.Sprintf("SSH_AUTH_SOCK points at an agent that does not answer (%s)", status.Address), agentFixHint())
 }
 
-func checkAWSConfiguration(state *doctorState, home string, fixMode bool) {
+func checkAWSConfiguration(state *doctorState, home string) {
 	awsDir := filepath.Join(home, ".aws")
 
 	// Check config
@@ -469,8 +830,7 @@ func checkAWSConfiguration(state *doctorState, home string, fixMode bool) {
 		if perms == 0600 {
 			state.pass("~/.aws/credentials permissions (600)")
 		} else {
-			if fixMode {
-				os.Chmod(credsPath, 0600)
+			if state.repair("chmod 600 ~/.aws/credentials", func(b *fixBatch) error { return b.chmod(credsPath, 0600) }) {
 				state.pass("Fixed ~/.aws/credentials permissions")
 			} else {
 				state.fail(fmt.Sprintf("~/.aws/credentials has permissions %04o (should be 600)", perms),
@@ -482,7 +842,149 @@ func checkAWSConfiguration(state *doctorState, home string, fixMode bool) {
 	}
 }
 
+// checkGPGConfiguration checks the GnuPG home, agent, and pinentry. The pass
+// vault backend and signed commits both need a working agent and pinentry.
+func checkGPGConfiguration(state *doctorState) {
+	gnupgHome := gpgHomeDir()
+	if info, err := os.Stat(gnupgHome); err == nil {
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+			if state.repair("chmod 700 "+tildePath(gnupgHome), func(b *fixBatch) error { return b.chmod(gnupgHome, 0700) }) {
+				state.pass("Fixed GnuPG home permissions")
+			} else {
+				state.warn(fmt.Sprintf("GnuPG home has permissions %04o (should be 700)", info.Mode().Perm()),
+					"chmod 700 "+gnupgHome)
+			}
+		} else {
+			state.pass("GnuPG home exists")
+		}
+	} else {
+		state.info("GnuPG home not initialized (no keys yet)")
+		return
+	}
+
+	if gpgAgentRunning() {
+		state.pass("gpg-agent is running")
+	} else {
+		state.warn("gpg-agent is not running", "gpgconf --launch gpg-agent")
+	}
+
+	if pinentry := gpgPinentryProgram(); pinentry != "" {
+		if _, err := os.Stat(pinentry); err == nil {
+			state.pass(fmt.Sprintf("pinentry-program: %s", pinentry))
+		} else {
+			state.fail(fmt.Sprintf("pinentry-program not found: %s", pinentry),
+				"Fix pinentry-program in "+filepath.Join(gnupgHome, "gpg-agent.conf")+", then: gpgconf --kill gpg-agent")
+		}
+	} else if gpgDefaultPinentryAvailable() {
+		state.pass("pinentry available")
+	} else {
+		fix := "Install pinentry (e.g. apt install pinentry-curses)"
+		if runtime.GOOS == "darwin" {
+			fix = "brew install pinentry-mac"
+		}
+		state.warn("No pinentry program found (passphrase prompts will fail)", fix)
+	}
+
+	if getVaultBackend() == "pass" {
+		if keys, err := listGPGKeys(); err == nil {
+			secret := 0
+			for _, k := range keys {
+				if k.Secret {
+					secret++
+				}
+			}
+			if secret > 0 {
+				state.pass(fmt.Sprintf("%d secret key(s) available for pass", secret))
+			} else {
+				state.fail("No GPG secret keys (required by the pass backend)", "blackdot tools gpg restore")
+			}
+		}
+	}
+}
+
+// checkK8sConfiguration checks kubeconfig permissions and, unless in quick
+// mode, whether the current context's API server is reachable
+func checkK8sConfiguration(state *doctorState, quickMode bool) {
+	for _, path := range kubeconfigPaths() {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+			if state.repair("chmod 600 "+tildePath(path), func(b *fixBatch) error { return b.chmod(path, 0600) }) {
+				state.pass("Fixed kubeconfig permissions: " + path)
+			} else {
+				state.fail(fmt.Sprintf("kubeconfig has permissions %04o (should be 600): %s", info.Mode().Perm(), path),
+					"chmod 600 "+path)
+			}
+		} else {
+			state.pass("kubeconfig permissions OK: " + path)
+		}
+	}
+
+	current, contexts, err := loadKubeconfigs()
+	if err != nil {
+		state.fail("kubeconfig could not be parsed", err.Error())
+		return
+	}
+	ctx := findK8sContext(contexts, current)
+	if ctx == nil {
+		state.info("No current context set")
+		return
+	}
+	state.pass("Current context: " + ctx.Name)
+
+	if quickMode || ctx.Server == "" {
+		return
+	}
+	if err := checkK8sReachable(ctx.Server, 3*time.Second); err != nil {
+		state.warn(fmt.Sprintf("API server for '%s' unreachable: %v", ctx.Name, err),
+			"Check VPN/network, or switch context: blackdot tools k8s use <context>")
+	} else {
+		state.pass("API server reachable: " + ctx.Server)
+	}
+}
+
+// checkFilePermissions audits blackdot-managed files against the permission policy
+func checkFilePermissions(state *doctorState, home string) {
+	loose := auditPermissions(permAuditEntries(home))
+	if len(loose) == 0 {
+		state.pass("Blackdot-managed files have restricted permissions")
+		return
+	}
+
+	for _, e := range loose {
+		allowed := filePolicies[e.class].File
+		if e.dir {
+			allowed = filePolicies[e.class].Dir
+		}
+		path := e.path
+		if state.repair(fmt.Sprintf("chmod %o %s", allowed, tildePath(path)), func(b *fixBatch) error { return b.tighten(path, allowed) }) {
+			state.pass(fmt.Sprintf("Fixed permissions on %s", e.label))
+			continue
+		}
+		state.fail(describePerm(e), fmt.Sprintf("chmod %o %s", allowed, e.path))
+	}
+}
+
 func checkVaultStatus(state *doctorState) {
+	// The OS keychain backend needs no CLI login
+	if getVaultBackend() == keychain.BackendType {
+		state.section("Vault Status (keychain)")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := keychain.Available(ctx); err != nil {
+			state.fail(fmt.Sprintf("%s unavailable: %v", keychain.StoreName(), err), keychainHint())
+		} else {
+			state.pass(keychain.StoreName() + " available")
+		}
+		return
+	}
+	if backendType := getVaultBackend(); isCloudBackend(backendType) {
+		checkCloudVaultStatus(state, backendType)
+		return
+	}
+
 	// Check Bitwarden
 	if _, err := exec.LookPath("bw"); err == nil {
 		state.section("Vault Status (Bitwarden)")
@@ -529,6 +1031,56 @@ func checkVaultStatus(state *doctorState) {
 	}
 }
 
+// checkVaultSessionFile finds a cached vault session that can never work:
+// an empty file is sent as the session and fails every vault command
+// until it is removed and 'vault unlock' writes a fresh one
+func checkVaultSessionFile(state *doctorState) {
+	sessionFile := getSessionFile()
+	data, err := os.ReadFile(sessionFile)
+	if err != nil {
+		return
+	}
+	if strings.TrimSpace(string(data)) != "" {
+		checkVaultSessionEncrypted(state, data)
+		return
+	}
+	clear := func(b *fixBatch) error {
+		return b.remove(sessionFile)
+	}
+	if state.repair("remove empty vault session "+tildePath(sessionFile), clear) {
+		state.pass("Removed empty vault session (run 'blackdot vault unlock' to create a new one)")
+	} else {
+		state.warn("Vault session file is empty", "rm "+shellQuote(sessionFile)+" && blackdot vault unlock")
+	}
+}
+
+// checkVaultSessionEncrypted flags a session token cached in plaintext
+// while vault.session_encryption is on. The next vault command encrypts
+// it too; --fix does it now.
+func checkVaultSessionEncrypted(state *doctorState, data []byte) {
+	if source, sealed := vault.SessionSource(data); sealed {
+		state.pass("Vault session encrypted (" + source + " key)")
+		return
+	}
+	if sessionEncryptionMode() == sessionEncryptOff {
+		return
+	}
+	encrypt := func(b *fixBatch) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		source, err := writeVaultSession(ctx, data)
+		if err == nil && source == "" {
+			err = fmt.Errorf("no keychain or machine id to encrypt with")
+		}
+		return err
+	}
+	if state.repair("encrypt the cached vault session", encrypt) {
+		state.pass("Encrypted the cached vault session")
+	} else {
+		state.warn("Vault session token cached in plaintext", "blackdot doctor --fix (or any vault command) encrypts it")
+	}
+}
+
 func checkShellConfiguration(state *doctorState, home, blackdotDir string) {
 	// Check default shell
 	shell := os.Getenv("SHELL")
@@ -560,34 +1112,93 @@ func checkShellConfiguration(state *doctorState, home, blackdotDir string) {
 	}
 }
 
-func checkClaudeCode(state *doctorState, home string) {
-	state.pass("Claude CLI installed")
-
-	// Check dotclaude
-	if _, err := exec.LookPath("dotclaude"); err == nil {
-		state.pass("dotclaude installed")
-
-		// Check active profile
-		profileCmd := exec.Command("dotclaude", "active")
-		if out, err := profileCmd.Output(); err == nil {
-			profile := strings.TrimSpace(string(out))
-			if profile != "" && profile != "none" {
-				state.pass(fmt.Sprintf("Active profile: %s", profile))
-			} else {
-				state.warn("No active profile", "dotclaude switch <profile>")
+// checkCompletions verifies blackdot's completions are installed for the
+// login shell, match this binary's commands, and are on the shell's path
+func checkCompletions(state *doctorState) {
+	shell := detectShell()
+	var script bytes.Buffer
+	if err := writeCompletion(rootCmd, shell, &script); err != nil {
+		return
+	}
+	write := func(target completionTarget) func(b *fixBatch) error {
+		return func(b *fixBatch) error {
+			if err := b.mkdirAll(filepath.Dir(target.Path), 0755); err != nil {
+				return err
 			}
+			return b.replace(target.Path, func() error { return writeFileAtomic(target.Path, script.Bytes(), 0644) })
 		}
+	}
+
+	target, ok := installedCompletion(shell)
+	if !ok {
+		target, err := resolveCompletionTarget(shell, "")
+		if err != nil {
+			return
+		}
+		if state.repair("install "+shell+" completions", write(target)) {
+			state.pass(fmt.Sprintf("Installed %s completions: %s", shell, tildePath(target.Path)))
+		} else {
+			state.warn(fmt.Sprintf("blackdot %s completions not installed", shell), "blackdot completion install")
+		}
+		return
+	}
 
-		// Check profiles.json
-		if _, err := os.Stat(filepath.Join(home, ".claude/profiles.json")); err == nil {
-			state.pass("profiles.json exists (vault syncable)")
+	if existing, err := os.ReadFile(target.Path); err == nil && bytes.Equal(existing, script.Bytes()) {
+		state.pass(fmt.Sprintf("%s completions current for blackdot %s", shell, versionStr))
+	} else if state.repair("regenerate "+tildePath(target.Path), write(target)) {
+		state.pass(fmt.Sprintf("Regenerated %s completions for blackdot %s", shell, versionStr))
+	} else {
+		state.warn(fmt.Sprintf("%s completions are out of date for blackdot %s (%s)", shell, versionStr, tildePath(target.Path)), "blackdot completion install")
+	}
+
+	if !completionWired(target) {
+		state.warn(fmt.Sprintf("%s does not load %s", tildePath(target.RC), tildePath(filepath.Dir(target.Path))), "blackdot completion install")
+	}
+}
+
+func checkClaudeCode(state *doctorState, home string) {
+	state.pass("Claude CLI installed")
+
+	// Check settings.json
+	settingsPath := filepath.Join(home, ".claude", "settings.json")
+	if data, err := os.ReadFile(settingsPath); err == nil {
+		if problems := validateClaudeSettings(data); len(problems) > 0 {
+			state.warn(fmt.Sprintf("~/.claude/settings.json: %s", strings.Join(problems, "; ")), "blackdot claude status")
 		} else {
-			state.info("profiles.json not found - run: dotclaude activate <profile>")
+			state.pass("~/.claude/settings.json is valid")
 		}
+	}
+
+	// Check profiles.json
+	if _, err := os.Stat(filepath.Join(home, ".claude", "profiles.json")); err != nil {
+		state.info("No Claude profiles - create one: blackdot claude profiles create <name>")
+	} else if p, err := loadClaudeProfiles(); err != nil {
+		state.fail(err.Error(), "blackdot claude sync --pull")
+	} else if p.Active == "" {
+		state.warn("No active Claude profile", "blackdot claude profiles use <profile>")
 	} else {
-		state.info("dotclaude not installed (optional)")
-		fmt.Println("     Manage Claude profiles across machines:")
-		fmt.Println("     See: github.com/blackwell-systems/dotclaude")
+		state.pass(fmt.Sprintf("Active Claude profile: %s (vault syncable)", p.Active))
+	}
+}
+
+func checkAutostart(state *doctorState, entries []autostartEntry, err error) {
+	if err != nil {
+		state.fail(fmt.Sprintf("Cannot read autostart.items: %v", err), "blackdot config edit")
+		return
+	}
+	for _, e := range entries {
+		switch e.State {
+		case autostartOK:
+			state.pass(fmt.Sprintf("Login item %s installed", e.Name))
+		case autostartDisabled:
+			state.info(fmt.Sprintf("Login item %s disabled", e.Name))
+		case autostartMissing:
+			state.warn(fmt.Sprintf("Login item %s not installed", e.Name), "blackdot tools autostart apply")
+		case autostartChanged:
+			state.warn(fmt.Sprintf("Login item %s differs from config", e.Name), "blackdot tools autostart apply")
+		case autostartOrphaned:
+			state.warn(fmt.Sprintf("Login item %s installed but not in config", e.Name), "blackdot tools autostart apply")
+		}
 	}
 }
 
@@ -612,7 +1223,7 @@ func checkTemplateSystem(state *doctorState, blackdotDir string) {
 				state.pass(fmt.Sprintf("Found %d generated config(s)", generatedCount))
 
 				// Check for stale templates
-				staleCount := 0
+				var stale []string
 				tmplDir := filepath.Join(templatesDir, "configs")
 				if tmplEntries, err := os.ReadDir(tmplDir); err == nil {
 					for _, te := range tmplEntries {
@@ -626,19 +1237,36 @@ func checkTemplateSystem(state *doctorState, blackdotDir string) {
 						tmplInfo, _ := os.Stat(tmplPath)
 						genInfo, err := os.Stat(genPath)
 						if err == nil && tmplInfo.ModTime().After(genInfo.ModTime()) {
-							staleCount++
+							stale = append(stale, te.Name())
 						}
 					}
 				}
 
-				if staleCount > 0 {
-					state.warn(fmt.Sprintf("%d template(s) need re-rendering", staleCount), "blackdot template render")
+				if len(stale) > 0 {
+					if state.repair(fmt.Sprintf("re-render %d stale template(s)", len(stale)), func(b *fixBatch) error {
+						return renderTemplatesForFix(b, generatedDir, stale)
+					}) {
+						state.pass(fmt.Sprintf("Re-rendered %d stale template(s)", len(stale)))
+					} else {
+						state.warn(fmt.Sprintf("%d template(s) need re-rendering", len(stale)), "blackdot template render")
+					}
 				} else {
 					state.pass("All generated configs up to date")
 				}
+			} else if state.repair("render templates", func(b *fixBatch) error {
+				return renderTemplatesForFix(b, generatedDir, nil)
+			}) {
+				state.pass("Rendered templates")
 			} else {
 				state.warn("No generated configs", "blackdot template render")
 			}
+		} else if state.repair("create generated/ and render templates", func(b *fixBatch) error {
+			if err := b.mkdirAll(generatedDir, 0755); err != nil {
+				return err
+			}
+			return renderTemplatesForFix(b, generatedDir, nil)
+		}) {
+			state.pass("Created generated/ and rendered templates")
 		} else {
 			state.warn("Generated directory missing", fmt.Sprintf("mkdir -p \"%s\" && blackdot template render", generatedDir))
 		}
@@ -648,7 +1276,20 @@ func checkTemplateSystem(state *doctorState, blackdotDir string) {
 	}
 }
 
-func printSummary(state *doctorState, fixMode bool) {
+// renderTemplatesForFix renders the named templates (all when empty) as
+// 'template render' would. generated/ and the render checksums are saved
+// first so the render can be undone.
+func renderTemplatesForFix(b *fixBatch, generatedDir string, names []string) error {
+	return b.replaceTree([]string{generatedDir, renderedStateDir()}, func() error {
+		cmd := &cobra.Command{}
+		cmd.Flags().Bool("stdout", false, "")
+		cmd.Flags().Bool("dry-run", false, "")
+		cmd.Flags().Bool("fold", false, "")
+		return runTemplateRender(cmd, names)
+	})
+}
+
+func printSummary(state *doctorState) {
 	fmt.Println()
 	fmt.Printf("%s═══════════════════════════════════════════════════════════%s\n", "\033[1m", "\033[0m")
 	fmt.Println()
@@ -766,18 +1407,15 @@ func printSummary(state *doctorState, fixMode bool) {
 		}
 
 		// Auto-fix suggestion
-		if !fixMode {
-			fixable := 0
-			for _, check := range state.failedChecks {
-				if strings.Contains(check, "permissions") {
-					fixable++
-				}
-			}
-			if fixable > 0 {
-				fmt.Printf("  %s\n", state.bold(fmt.Sprintf("Auto-fix available for %d issue(s):", fixable)))
+		if state.fixable > 0 {
+			fmt.Printf("  %s\n", state.bold(fmt.Sprintf("Auto-fix available for %d issue(s):", state.fixable)))
+			if state.dryRun {
+				fmt.Printf("    %s blackdot doctor --fix   %s\n", state.green("→"), state.dim("(apply the fixes listed above)"))
+			} else {
+				fmt.Printf("    %s blackdot doctor --fix --dry-run   %s\n", state.green("→"), state.dim("(preview)"))
 				fmt.Printf("    %s blackdot doctor --fix\n", state.green("→"))
-				fmt.Println()
 			}
+			fmt.Println()
 		}
 
 		// Estimated improvement
@@ -791,6 +1429,12 @@ func printSummary(state *doctorState, fixMode bool) {
 		fmt.Println()
 	}
 
+	if state.fixed > 0 {
+		fmt.Printf("  %s %s\n", state.bold(fmt.Sprintf("Applied %d fix(es).", state.fixed)),
+			state.dim("Revert them with: blackdot doctor undo-fixes"))
+		fmt.Println()
+	}
+
 	// Perfect score celebration
 	if healthScore == 100 {
 		fmt.Printf("  %s\n", state.green(state.bold("🎉 Perfect score! Your blackdot setup is healthy.")))
@@ -804,6 +1448,15 @@ func printSummary(state *doctorState, fixMode bool) {
 	_ = total
 }
 
+// doctorHealthScore is 100 less 10 points per failure and 5 per warning
+func doctorHealthScore(state *doctorState) int {
+	score := 100 - (state.checksFailed * 10) - (state.checksWarned * 5)
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
 func saveMetrics(state *doctorState, blackdotDir, home string) {
 	metricsFile := filepath.Join(home, ".blackdot-metrics.jsonl")
 
@@ -830,10 +1483,7 @@ func saveMetrics(state *doctorState, blackdotDir, home string) {
 	}
 
 	// Calculate health score for metrics
-	healthScore := 100 - (state.checksFailed * 10) - (state.checksWarned * 5)
-	if healthScore < 0 {
-		healthScore = 0
-	}
+	healthScore := doctorHealthScore(state)
 
 	// Create metrics entry
 	metrics := map[string]interface{}{
@@ -841,7 +1491,7 @@ func saveMetrics(state *doctorState, blackdotDir, home string) {
 		"health_score": healthScore,
 		"errors":       state.checksFailed,
 		"warnings":     state.checksWarned,
-		"fixed":        0,
+		"fixed":        state.fixed,
 		"git_branch":   gitBranch,
 		"hostname":     hostname,
 		"os":           osName,
@@ -849,10 +1499,6 @@ func saveMetrics(state *doctorState, blackdotDir, home string) {
 
 	// Write as JSON line
 	if data, err := json.Marshal(metrics); err == nil {
-		f, err := os.OpenFile(metricsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err == nil {
-			defer f.Close()
-			f.WriteString(string(data) + "\n")
-		}
+		appendFileWithPolicy(metricsFile, append(data, '\n'), fileClassPrivate)
 	}
 }
@@ -0,0 +1,409 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/plan"
+	"github.com/spf13/cobra"
+)
+
+// fixBatchesKept is how many doctor --fix batches stay undoable
+const fixBatchesKept = 10
+
+// fixOp is one reversible change made by a doctor fixer
+type fixOp struct {
+	Kind   string      `json:"kind"` // chmod, mkdir, or replace
+	Path   string      `json:"path"`
+	Mode   fs.FileMode `json:"mode,omitempty"`   // chmod: previous permissions; replace: previous file mode
+	Backup string      `json:"backup,omitempty"` // replace: saved copy of the previous file
+	Link   string      `json:"link,omitempty"`   // replace: previous symlink target
+	Absent bool        `json:"absent,omitempty"` // replace: the path did not exist
+}
+
+// fixBatch is the transaction log of one doctor --fix run. Every change is
+// journaled before the next one starts, so an interrupted run can still be
+// undone.
+type fixBatch struct {
+	ID      string   `json:"id"`
+	Started string   `json:"started"`
+	Fixes   []string `json:"fixes"`
+	Ops     []fixOp  `json:"ops"`
+	dir     string
+
+	// changes is set when the batch only plans: fixers run against it
+	// for --dry-run and their changes are recorded here, not made
+	changes *plan.Plan
+	desc    string
+}
+
+// plannedFix runs fix against a batch that records what it would change
+// in changes instead of changing it
+func plannedFix(changes *plan.Plan, desc string, fix func(b *fixBatch) error) error {
+	return fix(&fixBatch{changes: changes, desc: desc})
+}
+
+func fixBatchesDir() string {
+	return filepath.Join(paths.StateDir(), "doctor-fixes")
+}
+
+// newFixBatch starts a transaction log for this run
+func newFixBatch() (*fixBatch, error) {
+	now := time.Now().UTC()
+	id := now.Format("20060102T150405.000Z")
+	b := &fixBatch{ID: id, Started: now.Format(time.RFC3339), dir: filepath.Join(fixBatchesDir(), id)}
+	if err := os.MkdirAll(b.dir, 0700); err != nil {
+		return nil, err
+	}
+	return b, b.save()
+}
+
+func (b *fixBatch) save() error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileWithPolicy(filepath.Join(b.dir, "journal.json"), data, fileClassPrivate)
+}
+
+func (b *fixBatch) record(op fixOp) error {
+	b.Ops = append(b.Ops, op)
+	return b.save()
+}
+
+// run applies one fixer and logs its description when it succeeds
+func (b *fixBatch) run(desc string, fix func(b *fixBatch) error) error {
+	if err := fix(b); err != nil {
+		return err
+	}
+	b.Fixes = append(b.Fixes, desc)
+	return b.save()
+}
+
+// finish drops a batch that changed nothing and prunes old batches
+func (b *fixBatch) finish() {
+	if len(b.Ops) == 0 {
+		os.RemoveAll(b.dir)
+	}
+	batches, _ := listFixBatches()
+	for len(batches) > fixBatchesKept {
+		os.RemoveAll(batches[0].dir)
+		batches = batches[1:]
+	}
+}
+
+// chmod sets a path's permissions, recording the previous ones
+func (b *fixBatch) chmod(path string, perm fs.FileMode) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if b.changes != nil {
+		b.changes.Add(plan.Op{Kind: plan.Chmod, Target: path, Before: fmt.Sprintf("%04o", info.Mode().Perm()), After: fmt.Sprintf("%04o", perm)})
+		return nil
+	}
+	if err := b.record(fixOp{Kind: "chmod", Path: path, Mode: info.Mode().Perm()}); err != nil {
+		return err
+	}
+	return os.Chmod(path, perm)
+}
+
+// tighten removes permission bits outside allowed, like tightenPerm
+func (b *fixBatch) tighten(path string, allowed fs.FileMode) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if current := info.Mode().Perm(); current&^allowed != 0 {
+		return b.chmod(path, current&allowed)
+	}
+	return nil
+}
+
+// mkdirAll creates path and any missing parents, recording each directory
+// it created
+func (b *fixBatch) mkdirAll(path string, perm fs.FileMode) error {
+	var missing []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil || dir == filepath.Dir(dir) {
+			break
+		}
+		missing = append(missing, dir)
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		if b.changes != nil {
+			b.changes.Add(plan.Op{Kind: plan.Create, Target: missing[i], After: fmt.Sprintf("directory %04o", perm)})
+			continue
+		}
+		if err := os.Mkdir(missing[i], perm); err != nil && !os.IsExist(err) {
+			return err
+		}
+		if err := b.record(fixOp{Kind: "mkdir", Path: missing[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replace saves what is at path, then runs change, which may overwrite or
+// remove it. Directories are not saved; a fixer cannot replace one.
+func (b *fixBatch) replace(path string, change func() error) error {
+	if b.changes != nil {
+		kind := plan.Update
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			kind = plan.Create
+		}
+		b.changes.Add(plan.Op{Kind: kind, Target: path, Before: describeFileForPlan(path), Detail: b.desc})
+		return nil
+	}
+	op, err := b.snapshot(path)
+	if err != nil {
+		return err
+	}
+	if err := b.record(op); err != nil {
+		return err
+	}
+	return change()
+}
+
+// link is replace for a change that leaves a symlink at path
+func (b *fixBatch) link(path string, change func() error) error {
+	if b.changes != nil {
+		b.changes.Add(plan.Op{Kind: plan.Symlink, Target: path, Before: describeFileForPlan(path), Detail: b.desc})
+		return nil
+	}
+	return b.replace(path, change)
+}
+
+// remove deletes path, saving it first
+func (b *fixBatch) remove(path string) error {
+	if b.changes != nil {
+		b.changes.Add(plan.Op{Kind: plan.Delete, Target: path, Before: describeFileForPlan(path), Detail: b.desc})
+		return nil
+	}
+	return b.replace(path, func() error { return os.Remove(path) })
+}
+
+// replaceTree saves every file under dirs, then runs change. Files that
+// change creates are recorded so undo removes them.
+func (b *fixBatch) replaceTree(dirs []string, change func() error) error {
+	if b.changes != nil {
+		for _, dir := range dirs {
+			b.changes.Add(plan.Op{Kind: plan.Update, Target: dir, Detail: b.desc})
+		}
+		return nil
+	}
+	before := make(map[string]bool)
+	for _, dir := range dirs {
+		for _, path := range treeFiles(dir) {
+			op, err := b.snapshot(path)
+			if err != nil {
+				return err
+			}
+			if err := b.record(op); err != nil {
+				return err
+			}
+			before[path] = true
+		}
+	}
+	changeErr := change()
+	for _, dir := range dirs {
+		for _, path := range treeFiles(dir) {
+			if !before[path] {
+				b.record(fixOp{Kind: "replace", Path: path, Absent: true})
+			}
+		}
+	}
+	return changeErr
+}
+
+// treeFiles lists the files and symlinks under dir
+func treeFiles(dir string) []string {
+	var files []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files
+}
+
+func (b *fixBatch) snapshot(path string) (fixOp, error) {
+	op := fixOp{Kind: "replace", Path: path}
+	info, err := os.Lstat(path)
+	switch {
+	case os.IsNotExist(err):
+		op.Absent = true
+		return op, nil
+	case err != nil:
+		return op, err
+	case info.Mode()&os.ModeSymlink != 0:
+		op.Link, err = os.Readlink(path)
+		return op, err
+	case info.IsDir():
+		return op, fmt.Errorf("%s is a directory; fix it by hand", tildePath(path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return op, err
+	}
+	op.Mode = info.Mode().Perm()
+	op.Backup = fmt.Sprintf("%03d-%s", len(b.Ops), filepath.Base(path))
+	return op, writeFileWithPolicy(filepath.Join(b.dir, op.Backup), data, fileClassPrivate)
+}
+
+// undo reverts the batch's changes in reverse order and returns the ones
+// that could not be reverted
+func (b *fixBatch) undo(dryRun bool) []error {
+	var errs []error
+	for i := len(b.Ops) - 1; i >= 0; i-- {
+		op := b.Ops[i]
+		if dryRun {
+			fmt.Printf("  %s\n", describeFixUndo(op))
+			continue
+		}
+		if err := b.revert(op); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", tildePath(op.Path), err))
+		}
+	}
+	return errs
+}
+
+func (b *fixBatch) revert(op fixOp) error {
+	switch op.Kind {
+	case "chmod":
+		return os.Chmod(op.Path, op.Mode)
+	case "mkdir":
+		if err := os.Remove(op.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("directory not empty, left in place")
+		}
+		return nil
+	case "replace":
+		if info, err := os.Lstat(op.Path); err == nil && info.IsDir() && info.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("now a directory, left in place")
+		}
+		if err := os.Remove(op.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		switch {
+		case op.Link != "":
+			return os.Symlink(op.Link, op.Path)
+		case op.Backup != "":
+			data, err := os.ReadFile(filepath.Join(b.dir, op.Backup))
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(op.Path, data, op.Mode)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown change %q", op.Kind)
+}
+
+func describeFixUndo(op fixOp) string {
+	path := tildePath(op.Path)
+	switch {
+	case op.Kind == "chmod":
+		return fmt.Sprintf("chmod %04o %s", op.Mode, path)
+	case op.Kind == "mkdir":
+		return fmt.Sprintf("remove directory %s (if empty)", path)
+	case op.Link != "":
+		return fmt.Sprintf("restore symlink %s -> %s", path, op.Link)
+	case op.Backup != "":
+		return fmt.Sprintf("restore %s", path)
+	default:
+		return fmt.Sprintf("remove %s", path)
+	}
+}
+
+// listFixBatches returns the recorded batches, oldest first
+func listFixBatches() ([]*fixBatch, error) {
+	entries, err := os.ReadDir(fixBatchesDir())
+	if err != nil {
+		return nil, err
+	}
+	var batches []*fixBatch
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(fixBatchesDir(), e.Name())
+		data, err := os.ReadFile(filepath.Join(dir, "journal.json"))
+		if err != nil {
+			continue
+		}
+		b := &fixBatch{dir: dir}
+		if json.Unmarshal(data, b) != nil {
+			continue
+		}
+		batches = append(batches, b)
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].ID < batches[j].ID })
+	return batches, nil
+}
+
+func newDoctorUndoFixesCmd() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "undo-fixes",
+		Short: "Revert the last batch of doctor --fix changes",
+		Long: `Revert the changes made by the most recent 'blackdot doctor --fix':
+permissions are restored, replaced files and symlinks are put back, and
+directories it created are removed if still empty.
+
+Each run reverts one batch; run it again to go further back. The last
+` + fmt.Sprint(fixBatchesKept) + ` batches are kept.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctorUndoFixes(dryRun)
+		},
+	}
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be reverted")
+	return cmd
+}
+
+func runDoctorUndoFixes(dryRun bool) error {
+	batches, _ := listFixBatches()
+	if len(batches) == 0 {
+		Info("No doctor fixes to undo")
+		return nil
+	}
+	b := batches[len(batches)-1]
+	date, clock := parseTimestamp(b.Started)
+	clock = strings.TrimSuffix(clock, "Z")
+
+	if dryRun {
+		DryRun("Would revert doctor --fix from %s %s:", date, clock)
+		for _, fix := range b.Fixes {
+			fmt.Printf("  - %s\n", fix)
+		}
+		fmt.Println()
+		b.undo(true)
+		return nil
+	}
+
+	errs := b.undo(false)
+	for _, err := range errs {
+		Warn("%v", err)
+	}
+	if err := os.RemoveAll(b.dir); err != nil {
+		Fail("Cannot remove fix log %s: %v", tildePath(b.dir), err)
+		return err
+	}
+	for _, fix := range b.Fixes {
+		Pass("Reverted: %s", fix)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d change(s) could not be reverted", len(errs))
+	}
+	Info("Reverted doctor --fix from %s %s", date, clock)
+	return nil
+}
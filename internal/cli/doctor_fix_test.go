@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/plan"
+	"github.com/fatih/color"
+)
+
+// TestFixBatchUndo verifies every kind of fix change is journaled and
+// undo-fixes puts things back as they were
+func TestFixBatchUndo(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX permissions not enforced on Windows")
+	}
+	setScheduleEnv(t, "")
+	dir := t.TempDir()
+	key := filepath.Join(dir, "id_ed25519")
+	os.WriteFile(key, []byte("key"), 0644)
+	link := filepath.Join(dir, "gitconfig")
+	os.Symlink("/elsewhere/gitconfig", link)
+	config := filepath.Join(dir, "config")
+	os.WriteFile(config, []byte("original"), 0640)
+	created := filepath.Join(dir, "generated", "sub")
+	newFile := filepath.Join(dir, "new")
+
+	state := &doctorState{fixMode: true, dim: color.New().SprintFunc(), cyan: color.New().SprintFunc()}
+	fixes := []func(b *fixBatch) error{
+		func(b *fixBatch) error { return b.chmod(key, 0600) },
+		func(b *fixBatch) error {
+			return b.replace(link, func() error {
+				os.Remove(link)
+				return os.Symlink("/repo/gitconfig", link)
+			})
+		},
+		func(b *fixBatch) error {
+			return b.replaceTree([]string{dir}, func() error {
+				os.WriteFile(config, []byte("rendered"), 0644)
+				return os.WriteFile(newFile, []byte("x"), 0644)
+			})
+		},
+		func(b *fixBatch) error { return b.mkdirAll(created, 0755) },
+	}
+	for i, fix := range fixes {
+		if !state.repair("fix", fix) {
+			t.Fatalf("fix %d failed", i)
+		}
+	}
+	state.batch.finish()
+
+	if err := runDoctorUndoFixes(false); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(key); info.Mode().Perm() != 0644 {
+		t.Errorf("key mode = %04o, want 0644", info.Mode().Perm())
+	}
+	if target, _ := os.Readlink(link); target != "/elsewhere/gitconfig" {
+		t.Errorf("link = %q", target)
+	}
+	if data, _ := os.ReadFile(config); string(data) != "original" {
+		t.Errorf("config = %q", data)
+	}
+	if info, _ := os.Stat(config); info.Mode().Perm() != 0640 {
+		t.Errorf("config mode = %04o, want 0640", info.Mode().Perm())
+	}
+	for _, path := range []string{newFile, filepath.Join(dir, "generated")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s not removed", path)
+		}
+	}
+	if batches, _ := listFixBatches(); len(batches) != 0 {
+		t.Errorf("batch kept after undo: %d", len(batches))
+	}
+}
+
+// TestDoctorRepairDryRun verifies --dry-run lists fixes without running
+// them or recording a batch
+func TestDoctorRepairDryRun(t *testing.T) {
+	setScheduleEnv(t, "")
+	state := &doctorState{fixMode: true, dryRun: true, dim: color.New().SprintFunc(), cyan: color.New().SprintFunc()}
+	ran := false
+	if state.repair("fix", func(b *fixBatch) error { ran = true; return nil }) || ran {
+		t.Fatal("dry run applied a fix")
+	}
+	if state.fixable != 1 || state.batch != nil {
+		t.Errorf("fixable = %d, batch = %v", state.fixable, state.batch)
+	}
+	if batches, _ := listFixBatches(); len(batches) != 0 {
+		t.Errorf("dry run recorded %d batch(es)", len(batches))
+	}
+}
+
+// TestPlannedFix verifies fixers run against a planning batch record
+// their changes in the plan and leave the files alone
+func TestPlannedFix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX permissions not enforced on Windows")
+	}
+	dir := t.TempDir()
+	key := filepath.Join(dir, "id_ed25519")
+	os.WriteFile(key, []byte("key"), 0644)
+	session := filepath.Join(dir, "session")
+	os.WriteFile(session, nil, 0600)
+	created := filepath.Join(dir, "generated")
+
+	changes := plan.New("doctor --fix")
+	plannedFix(changes, "chmod 600 key", func(b *fixBatch) error { return b.chmod(key, 0600) })
+	plannedFix(changes, "remove empty session", func(b *fixBatch) error { return b.remove(session) })
+	plannedFix(changes, "render templates", func(b *fixBatch) error {
+		if err := b.mkdirAll(created, 0755); err != nil {
+			return err
+		}
+		return b.replaceTree([]string{created}, func() error {
+			t.Error("planning ran a change")
+			return nil
+		})
+	})
+
+	want := []plan.Op{
+		{Kind: plan.Chmod, Target: key, Before: "0644", After: "0600"},
+		{Kind: plan.Delete, Target: session, Before: "0 B", Detail: "remove empty session"},
+		{Kind: plan.Create, Target: created, After: "directory 0755"},
+		{Kind: plan.Update, Target: created, Detail: "render templates"},
+	}
+	if !reflect.DeepEqual(changes.Ops, want) {
+		t.Errorf("ops = %+v", changes.Ops)
+	}
+	if info, _ := os.Stat(key); info.Mode().Perm() != 0644 {
+		t.Error("key mode changed")
+	}
+	if _, err := os.Stat(session); err != nil {
+		t.Error("session removed")
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Error("directory created")
+	}
+}
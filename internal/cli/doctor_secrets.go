@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/blackwell-systems/blackdot/internal/scanners"
+)
+
+// Kinds of places credentials leak into
+const (
+	leakHistory   = "history"
+	leakGenerated = "generated"
+	leakEnv       = "env"
+)
+
+// leakHistoryTail is how much of a shell history file is scanned: the
+// most recent commands, which are the ones still worth cleaning up
+const leakHistoryTail = 2 << 20
+
+// leakFindingsShown caps the findings listed per file
+const leakFindingsShown = 5
+
+// leakTarget is a file or directory that should not hold credentials
+type leakTarget struct {
+	Kind string
+	Path string
+}
+
+// leakScanEnabled is scan.doctor (on unless "false"), from trusted layers
+// only so a repo config cannot hide a leak
+func leakScanEnabled() bool {
+	val, _ := trustedConfigLookup("scan.doctor")
+	return val != "false"
+}
+
+// leakTargets are the shell history files, the generated/ directory, and
+// shell env files where a credential is often expanded by accident
+func leakTargets(home, blackdotDir string) []leakTarget {
+	var targets []leakTarget
+	seen := map[string]bool{}
+	add := func(kind, path string) {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			targets = append(targets, leakTarget{Kind: kind, Path: path})
+		}
+	}
+	add(leakHistory, expandPath(os.Getenv("HISTFILE")))
+	for _, name := range []string{".zsh_history", ".bash_history", ".histfile"} {
+		add(leakHistory, filepath.Join(home, name))
+	}
+	if blackdotDir != "" {
+		add(leakGenerated, filepath.Join(blackdotDir, "generated"))
+	}
+	for _, name := range []string{".zshenv", ".zprofile", ".profile", ".bash_profile", ".env", ".envrc"} {
+		add(leakEnv, filepath.Join(home, name))
+	}
+	return targets
+}
+
+// scanLeakTarget returns the findings in one target. Generated files
+// named like credential files (netrc, .env...) are meant to hold secrets
+// and are skipped, as vault push skips them; generated names drop the
+// leading dot of their target.
+func scanLeakTarget(scanner *scanners.Scanner, t leakTarget) []scanners.Finding {
+	info, err := os.Stat(t.Path)
+	if err != nil {
+		return nil
+	}
+	if t.Kind == leakHistory {
+		return scanHistoryFile(scanner, t.Path)
+	}
+	if !info.IsDir() {
+		found, _ := scanner.ScanPath(t.Path)
+		return found
+	}
+
+	var findings []scanners.Finding
+	filepath.WalkDir(t.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || secretFileNames.MatchString("."+d.Name()) {
+			return nil
+		}
+		found, _ := scanner.ScanPath(path)
+		findings = append(findings, found...)
+		return nil
+	})
+	return findings
+}
+
+// scanHistoryFile scans the last leakHistoryTail bytes of a history file,
+// keeping line numbers relative to the whole file
+func scanHistoryFile(scanner *scanners.Scanner, path string) []scanners.Finding {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	offset := 0
+	if len(data) > leakHistoryTail {
+		start := len(data) - leakHistoryTail
+		if i := bytes.IndexByte(data[start:], '\n'); i >= 0 {
+			start += i + 1
+		}
+		offset = bytes.Count(data[:start], []byte("\n"))
+		data = data[start:]
+	}
+	findings := scanner.Scan(path, data)
+	for i := range findings {
+		findings[i].Line += offset
+	}
+	return findings
+}
+
+// leakFixHint is what to do about a credential found in a kind of place
+func leakFixHint(t leakTarget) string {
+	switch t.Kind {
+	case leakHistory:
+		return fmt.Sprintf("Rotate the credential, then delete the lines from %s (a command typed with a leading space is not saved under 'setopt HIST_IGNORE_SPACE')", tildePath(t.Path))
+	case leakGenerated:
+		return "Move the value into a vault item, reference it from the template, and run 'blackdot template render'"
+	}
+	return fmt.Sprintf("Load the value from the vault instead of writing it into %s, then rotate it", tildePath(t.Path))
+}
+
+// checkLeakedSecrets scans places that should never hold credentials.
+// Only redacted matches are printed. Nothing is changed: a doctor fix
+// keeps a backup of what it edits, which would keep the secret too.
+func checkLeakedSecrets(state *doctorState, home, blackdotDir string) {
+	if !leakScanEnabled() {
+		state.info("Skipped (scan.doctor is false)")
+		return
+	}
+
+	scanner := scanners.New()
+	clean := true
+	for _, t := range leakTargets(home, blackdotDir) {
+		findings := scanLeakTarget(scanner, t)
+		if len(findings) == 0 {
+			continue
+		}
+		clean = false
+		state.warn(fmt.Sprintf("%d potential credential(s) in %s", len(findings), tildePath(t.Path)), leakFixHint(t))
+		shown := findings
+		if len(shown) > leakFindingsShown {
+			shown = shown[:leakFindingsShown]
+		}
+		fprintScanFindings(state.writer(), shown)
+		if more := len(findings) - len(shown); more > 0 {
+			fmt.Fprintf(state.writer(), "    %s\n", state.dim(fmt.Sprintf("... and %d more", more)))
+		}
+	}
+	if clean {
+		state.pass("No credentials in shell history, generated configs, or env files")
+	}
+}
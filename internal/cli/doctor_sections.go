@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// doctorWorkers bounds how many sections are checked at once
+const doctorWorkers = 4
+
+// doctorSection is one section of the doctor report
+type doctorSection struct {
+	name     string // heading
+	key      string // name for --section
+	untitled bool   // run prints its own heading
+	applies  func(d doctorRun) bool
+	skipped  string // why a selected section that does not apply is skipped
+	run      func(state *doctorState, d doctorRun)
+}
+
+// doctorRun is what every section is checked against
+type doctorRun struct {
+	home        string
+	blackdotDir string
+	quick       bool
+}
+
+// doctorSectionKeys lists the names --section accepts
+func doctorSectionKeys() []string {
+	keys := make([]string, len(doctorSections))
+	for i, sec := range doctorSections {
+		keys[i] = sec.key
+	}
+	return keys
+}
+
+// parseDoctorSections resolves --section values (comma-separated keys or
+// headings, any case) to section keys; none selects every section
+func parseDoctorSections(values []string) (map[string]bool, error) {
+	selected := map[string]bool{}
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			found := false
+			for _, sec := range doctorSections {
+				heading := strings.ReplaceAll(strings.ToLower(sec.name), " ", "-")
+				if name == sec.key || name == heading {
+					selected[sec.key] = true
+					found = true
+				}
+			}
+			if !found {
+				keys := doctorSectionKeys()
+				sort.Strings(keys)
+				return nil, fmt.Errorf("unknown doctor section %q (sections: %s)", name, strings.Join(keys, ", "))
+			}
+		}
+	}
+	return selected, nil
+}
+
+// runDoctorChecks runs the report's sections, or the ones in only. Each
+// section writes to its own buffer and is flushed in report order as soon
+// as the sections before it are done. Fixes share one transaction log
+// and are listed in order, so --fix and --dry-run check one section at a
+// time.
+func runDoctorChecks(state *doctorState, home, blackdotDir string, quickMode bool, only map[string]bool) {
+	d := doctorRun{home: home, blackdotDir: blackdotDir, quick: quickMode}
+	// Loaded once here; sections read it concurrently
+	initRegistry()
+
+	type job struct {
+		index int
+		sec   doctorSection
+	}
+	var jobs []job
+	for i, sec := range doctorSections {
+		if len(only) == 0 || only[sec.key] {
+			jobs = append(jobs, job{i, sec})
+		}
+	}
+
+	check := func(parent *doctorState, j job) *doctorState {
+		child := parent.child()
+		child.percent = progressPercent(j.index, len(doctorSections))
+		switch {
+		case j.sec.applies != nil && !j.sec.applies(d):
+			if len(only) > 0 {
+				child.section(j.sec.name)
+				reason := j.sec.skipped
+				if reason == "" {
+					reason = "Skipped: not set up on this machine"
+				}
+				child.info(reason)
+			}
+		default:
+			if !j.sec.untitled {
+				child.section(j.sec.name)
+			}
+			j.sec.run(child, d)
+		}
+		if child.phase != "" {
+			traceEndPhase(child.phase)
+		}
+		return child
+	}
+
+	if state.fixMode || state.dryRun {
+		for _, j := range jobs {
+			child := check(state, j)
+			// A fix batch started, or --fix given up on, carries on
+			state.fixMode, state.batch = child.fixMode, child.batch
+			state.merge(child)
+		}
+		return
+	}
+
+	done := make([]chan *doctorState, len(jobs))
+	for i := range done {
+		done[i] = make(chan *doctorState, 1)
+	}
+	queue := make(chan int)
+	for w := 0; w < min(doctorWorkers, len(jobs)); w++ {
+		go func() {
+			for i := range queue {
+				done[i] <- check(state, jobs[i])
+			}
+		}()
+	}
+	go func() {
+		for i := range jobs {
+			queue <- i
+		}
+		close(queue)
+	}()
+	for i := range jobs {
+		state.merge(<-done[i])
+	}
+}
+
+// child returns an empty report for one section, buffering its output and
+// sharing the parent's fix settings
+func (s *doctorState) child() *doctorState {
+	c := newDoctorState()
+	c.buf = &bytes.Buffer{}
+	c.out = c.buf
+	c.fixMode, c.dryRun, c.batch, c.changes = s.fixMode, s.dryRun, s.batch, s.changes
+	c.refreshVersions = s.refreshVersions
+	return c
+}
+
+// merge prints a section's buffered output and adds its results
+func (s *doctorState) merge(c *doctorState) {
+	if c.buf != nil {
+		s.writer().Write(c.buf.Bytes())
+	}
+	s.checksPassed += c.checksPassed
+	s.checksFailed += c.checksFailed
+	s.checksWarned += c.checksWarned
+	s.failedChecks = append(s.failedChecks, c.failedChecks...)
+	s.failedFixes = append(s.failedFixes, c.failedFixes...)
+	s.warnChecks = append(s.warnChecks, c.warnChecks...)
+	s.warnFixes = append(s.warnFixes, c.warnFixes...)
+	s.results = append(s.results, c.results...)
+	s.fixable += c.fixable
+	s.fixed += c.fixed
+	s.fixErrors += c.fixErrors
+	if c.phase != "" {
+		s.phase = c.phase
+	}
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestParseDoctorSections verifies keys and headings are accepted in any
+// case and an unknown name is an error
+func TestParseDoctorSections(t *testing.T) {
+	only, err := parseDoctorSections([]string{"ssh,Templates", " file-permissions "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(only) != 3 || !only["ssh"] || !only["templates"] || !only["permissions"] {
+		t.Errorf("selected = %v", only)
+	}
+	if only, err := parseDoctorSections(nil); err != nil || len(only) != 0 {
+		t.Errorf("no sections = %v, %v", only, err)
+	}
+	if _, err := parseDoctorSections([]string{"ssh,nope"}); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("unknown section error = %v", err)
+	}
+}
+
+// TestRunDoctorChecksOrder verifies sections checked concurrently are
+// printed and recorded in report order, and a selection checks only its
+// sections
+func TestRunDoctorChecksOrder(t *testing.T) {
+	setScheduleEnv(t, "")
+	home := os.Getenv("HOME")
+	var out bytes.Buffer
+	state := newDoctorState()
+	state.out = &out
+	runDoctorChecks(state, home, "", true, nil)
+
+	last := -1
+	for _, sec := range doctorSections {
+		i := strings.Index(out.String(), "── "+sec.name+" ──")
+		if i < 0 {
+			continue
+		}
+		if i < last {
+			t.Errorf("section %q printed out of order", sec.name)
+		}
+		last = i
+	}
+	if last < 0 || len(state.results) != state.checksPassed+state.checksWarned+state.checksFailed {
+		t.Fatalf("%d results for %d/%d/%d checks\n%s", len(state.results), state.checksPassed, state.checksWarned, state.checksFailed, out.String())
+	}
+
+	out.Reset()
+	state = newDoctorState()
+	state.out = &out
+	runDoctorChecks(state, home, "", true, map[string]bool{"ssh": true, "vault": true})
+	for _, r := range state.results {
+		if r.Section != "SSH Configuration" {
+			t.Errorf("result from unselected section %q", r.Section)
+		}
+	}
+	if !strings.Contains(out.String(), "── Vault Status ──") || !strings.Contains(out.String(), "Skipped (--quick)") {
+		t.Errorf("selected section skipped by --quick not reported:\n%s", out.String())
+	}
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+)
+
+// commandVersionTimeout bounds each --version call
+const commandVersionTimeout = 5 * time.Second
+
+// commandVersionMinimums are the oldest versions doctor accepts.
+// doctor.min_versions.<command> in config raises or lowers them; "0"
+// turns a check off.
+var commandVersionMinimums = map[string]string{
+	"zsh": "5.0",
+	"git": "2.25",
+	"jq":  "1.5",
+}
+
+// commandVersionPattern finds the version number in a --version line:
+// "git version 2.43.0", "jq-1.7.1", "zsh 5.9 (arm64-apple-darwin23.0)"
+var commandVersionPattern = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// commandVersion is what doctor knows about one command
+type commandVersion struct {
+	Path    string // empty when the command is not installed
+	Line    string // first line of --version, empty when it could not be run
+	Version string // version number from Line
+	Cached  bool
+}
+
+// commandVersionEntry is a cached --version line. The binary is run again
+// once its modification time or size changes.
+type commandVersionEntry struct {
+	ModTime string `json:"mtime"`
+	Size    int64  `json:"size"`
+	Line    string `json:"line"`
+}
+
+func commandVersionCachePath() string {
+	return filepath.Join(paths.CacheDir(), "command-versions.json")
+}
+
+// commandVersions looks up each command's version. Binaries unchanged
+// since they were last run come from the cache; the rest are run
+// concurrently. refresh ignores the cache.
+func commandVersions(names []string, refresh bool) map[string]*commandVersion {
+	cache := make(map[string]commandVersionEntry)
+	if !refresh {
+		if data, err := os.ReadFile(commandVersionCachePath()); err == nil {
+			json.Unmarshal(data, &cache)
+		}
+	}
+
+	results := make(map[string]*commandVersion, len(names))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	changed := false
+	for _, name := range names {
+		result := &commandVersion{}
+		results[name] = result
+		path, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+		result.Path = path
+
+		// Keyed by the resolved binary, so an upgrade that repoints a
+		// symlink is noticed
+		key := path
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			key = real
+		}
+		info, err := os.Stat(key)
+		if err != nil {
+			continue
+		}
+		mtime := info.ModTime().UTC().Format(time.RFC3339Nano)
+		if entry, ok := cache[key]; ok && entry.ModTime == mtime && entry.Size == info.Size() {
+			result.Line, result.Cached = entry.Line, true
+			result.Version = commandVersionPattern.FindString(entry.Line)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			line := runVersionCommand(path)
+			result.Line = line
+			result.Version = commandVersionPattern.FindString(line)
+			if line == "" {
+				return
+			}
+			mu.Lock()
+			cache[key] = commandVersionEntry{ModTime: mtime, Size: info.Size(), Line: line}
+			changed = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if changed {
+		if data, err := json.MarshalIndent(cache, "", "  "); err == nil {
+			_ = writeFileWithPolicy(commandVersionCachePath(), data, fileClassPrivate)
+		}
+	}
+	return results
+}
+
+// runVersionCommand returns the first line of `path --version`, or ""
+// when it fails or takes longer than commandVersionTimeout
+func runVersionCommand(path string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), commandVersionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil && len(out) == 0 {
+		return ""
+	}
+	return strings.Split(strings.TrimSpace(string(out)), "\n")[0]
+}
+
+// commandMinimumVersion is the oldest accepted version of a command, or
+// "" for none
+func commandMinimumVersion(name string) string {
+	minimum := commandVersionMinimums[name]
+	if v := configLookup("doctor.min_versions." + name); v != "" {
+		minimum = v
+	}
+	if minimum == "0" {
+		return ""
+	}
+	return minimum
+}
+
+// versionBelow reports whether version is older than minimum. Versions
+// with more than three parts are compared on the first three.
+func versionBelow(version, minimum string) bool {
+	trim := func(v string) string {
+		parts := strings.Split(v, ".")
+		return strings.Join(parts[:min(len(parts), 3)], ".")
+	}
+	return compareVersions(trim(version), trim(minimum)) < 0
+}
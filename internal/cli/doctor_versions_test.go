@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeVersionCommand writes a command to dir that prints version and
+// records each run in dir/runs
+func fakeVersionCommand(t *testing.T, dir, name, version string) {
+	t.Helper()
+	script := "#!/bin/sh\necho " + name + " >> " + filepath.Join(dir, "runs") + "\necho '" + version + "'\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// versionRuns returns how often each fake command ran
+func versionRuns(dir string) string {
+	data, _ := os.ReadFile(filepath.Join(dir, "runs"))
+	return strings.Join(strings.Fields(string(data)), ",")
+}
+
+// TestCommandVersionsCache verifies versions are cached per binary and
+// run again after the binary changes or with refresh
+func TestCommandVersionsCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script commands")
+	}
+	setScheduleEnv(t, "")
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(os.Getenv("HOME"), ".cache"))
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	fakeVersionCommand(t, bin, "git", "git version 2.43.0")
+	fakeVersionCommand(t, bin, "jq", "jq-1.7.1")
+
+	got := commandVersions([]string{"git", "jq", "zsh"}, false)
+	if got["git"].Version != "2.43.0" || got["jq"].Version != "1.7.1" || got["zsh"].Path != "" {
+		t.Fatalf("versions = git %+v, jq %+v, zsh %+v", got["git"], got["jq"], got["zsh"])
+	}
+	if runs := versionRuns(bin); len(runs) != len("git,jq") {
+		t.Errorf("runs = %s, want git and jq once each", runs)
+	}
+
+	os.Remove(filepath.Join(bin, "runs"))
+	got = commandVersions([]string{"git", "jq"}, false)
+	if runs := versionRuns(bin); runs != "" || !got["git"].Cached || got["git"].Version != "2.43.0" {
+		t.Errorf("cached lookup ran %q, git = %+v", runs, got["git"])
+	}
+
+	// An upgraded binary is run again
+	fakeVersionCommand(t, bin, "git", "git version 2.44.1")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(bin, "git"), later, later)
+	got = commandVersions([]string{"git", "jq"}, false)
+	if runs := versionRuns(bin); runs != "git" || got["git"].Version != "2.44.1" {
+		t.Errorf("after upgrade ran %q, git = %+v", runs, got["git"])
+	}
+
+	os.Remove(filepath.Join(bin, "runs"))
+	commandVersions([]string{"git", "jq"}, true)
+	if runs := versionRuns(bin); len(runs) != len("git,jq") {
+		t.Errorf("refresh ran %q, want both", runs)
+	}
+}
+
+// TestRequiredCommandMinimums verifies a command older than its minimum
+// fails, and config raises or turns off the minimum
+func TestRequiredCommandMinimums(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script commands")
+	}
+	setScheduleEnv(t, `{"doctor": {"min_versions": {"git": "2.40", "jq": "0"}}}`)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(os.Getenv("HOME"), ".cache"))
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	fakeVersionCommand(t, bin, "zsh", "zsh 5.9 (x86_64-pc-linux-gnu)")
+	fakeVersionCommand(t, bin, "git", "git version 2.39.5")
+	fakeVersionCommand(t, bin, "brew", "Homebrew 4.2.0")
+	fakeVersionCommand(t, bin, "jq", "jq-1.4")
+
+	var out bytes.Buffer
+	state := newDoctorState()
+	state.out = &out
+	checkRequiredCommands(state)
+	if state.checksFailed != 1 || len(state.failedChecks) != 1 || state.failedChecks[0] != "git 2.39.5 is older than the required 2.40" {
+		t.Errorf("failed = %v\n%s", state.failedChecks, out.String())
+	}
+	if state.checksPassed != 3 {
+		t.Errorf("passed = %d, want zsh, brew and jq (minimum off)\n%s", state.checksPassed, out.String())
+	}
+}
+
+// TestVersionBelow verifies minimums compare numerically on up to three
+// parts
+func TestVersionBelow(t *testing.T) {
+	for _, tc := range []struct {
+		version, minimum string
+		below            bool
+	}{
+		{"2.39.5", "2.40", true},
+		{"2.40.0", "2.40", false},
+		{"2.9", "2.10", true},
+		{"1.2.3.4", "1.2.3", false},
+		{"5.9", "5.0", false},
+	} {
+		if got := versionBelow(tc.version, tc.minimum); got != tc.below {
+			t.Errorf("versionBelow(%s, %s) = %v", tc.version, tc.minimum, got)
+		}
+	}
+}
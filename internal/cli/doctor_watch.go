@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// doctorResult is one check outcome
+type doctorResult struct {
+	Section string
+	Status  string // pass, warn, fail
+	Message string
+}
+
+// doctorWatchOptions configure 'doctor --watch'
+type doctorWatchOptions struct {
+	interval  time.Duration
+	threshold int
+	notify    bool
+	exit      bool
+	quick     bool
+	only      map[string]bool // --section
+}
+
+// doctorWatchPoll is how often watched paths are checked for changes
+const doctorWatchPoll = 5 * time.Second
+
+// doctorChange is a check whose outcome changed between two runs
+type doctorChange struct {
+	doctorResult
+	Resolved bool // was a warning or failure, now passes or is gone
+}
+
+func runDoctorWatch(opts doctorWatchOptions) error {
+	if opts.interval < 10*time.Second {
+		return fmt.Errorf("--interval must be at least 10s")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	home, _ := os.UserHomeDir()
+	blackdotDir := getBlackdotDir()
+	watched := doctorWatchPaths(home, blackdotDir)
+	Info("Checking health every %s and when %d watched paths change (Ctrl+C to stop)", opts.interval, len(watched))
+
+	poll := time.NewTicker(doctorWatchPoll)
+	defer poll.Stop()
+
+	var prev []doctorResult
+	prevScore := -1
+	snapshot := snapshotDoctorPaths(watched)
+	reason := ""
+	for {
+		state := quietDoctorRun(home, blackdotDir, opts.quick, opts.only)
+		score := doctorHealthScore(state)
+		stamp := Dim.Sprint(time.Now().Format("15:04:05"))
+
+		if prevScore < 0 {
+			fmt.Printf("  %s Health %d/100 (%d passed, %d warnings, %d failed)\n", stamp, score, state.checksPassed, state.checksWarned, state.checksFailed)
+			for _, r := range state.results {
+				if r.Status != "pass" {
+					printDoctorChange(stamp, doctorChange{doctorResult: r})
+				}
+			}
+		} else {
+			for _, c := range diffDoctorResults(prev, state.results) {
+				printDoctorChange(stamp, c)
+			}
+			if score != prevScore {
+				fmt.Printf("  %s Health %d/100 (was %d) %s\n", stamp, score, prevScore, Dim.Sprint(reason))
+			}
+		}
+
+		// Act when the score crosses the threshold, not on every run below it
+		if score < opts.threshold && (prevScore < 0 || prevScore >= opts.threshold) {
+			msg := fmt.Sprintf("Health score %d is below %d", score, opts.threshold)
+			if opts.notify {
+				notifyDesktop("blackdot doctor", msg)
+			}
+			if opts.exit {
+				Fail("%s", msg)
+				return fmt.Errorf("health score %d is below %d", score, opts.threshold)
+			}
+		}
+		prev, prevScore = state.results, score
+
+		// Wait for the interval or a change to a watched path
+		deadline := time.Now().Add(opts.interval)
+		reason = ""
+		for reason == "" {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-poll.C:
+			}
+			current := snapshotDoctorPaths(watched)
+			if changed := changedDoctorPaths(snapshot, current); len(changed) > 0 {
+				reason = "(changed: " + tildePath(changed[0]) + ")"
+				if len(changed) > 1 {
+					reason = fmt.Sprintf("(changed: %s and %d more)", tildePath(changed[0]), len(changed)-1)
+				}
+			} else if !time.Now().Before(deadline) {
+				reason = "(scheduled)"
+			}
+			snapshot = current
+		}
+	}
+}
+
+// quietDoctorRun runs the checks without printing the report. Checks
+// print as they go, so stdout, stderr, and color output are discarded
+// for the run.
+func quietDoctorRun(home, blackdotDir string, quick bool, only map[string]bool) *doctorState {
+	state := newDoctorState()
+	state.out = io.Discard
+	devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err == nil {
+		stdout, stderr, colorOut := os.Stdout, os.Stderr, color.Output
+		os.Stdout, os.Stderr, color.Output = devnull, devnull, io.Discard
+		defer func() {
+			os.Stdout, os.Stderr, color.Output = stdout, stderr, colorOut
+			devnull.Close()
+		}()
+	}
+	runDoctorChecks(state, home, blackdotDir, quick, only)
+	return state
+}
+
+// diffDoctorResults returns the warnings and failures in cur that are new
+// or changed status since prev, and the ones in prev that are resolved.
+// A check is identified by its section and message.
+func diffDoctorResults(prev, cur []doctorResult) []doctorChange {
+	key := func(r doctorResult) string { return r.Section + "\x00" + r.Message }
+	before := map[string]string{}
+	for _, r := range prev {
+		before[key(r)] = r.Status
+	}
+	after := map[string]string{}
+	var changes []doctorChange
+	for _, r := range cur {
+		after[key(r)] = r.Status
+		if r.Status != "pass" && before[key(r)] != r.Status {
+			changes = append(changes, doctorChange{doctorResult: r})
+		}
+	}
+	for _, r := range prev {
+		if r.Status == "pass" {
+			continue
+		}
+		if status, ok := after[key(r)]; !ok || status == "pass" {
+			changes = append(changes, doctorChange{doctorResult: r, Resolved: true})
+		}
+	}
+	return changes
+}
+
+func printDoctorChange(stamp string, c doctorChange) {
+	mark := Red.Sprint("✗")
+	switch {
+	case c.Resolved:
+		mark = Green.Sprint("✓")
+	case c.Status == "warn":
+		mark = Yellow.Sprint("!")
+	}
+	suffix := ""
+	if c.Resolved {
+		suffix = " " + Dim.Sprint("(resolved)")
+	}
+	fmt.Printf("  %s %s %s %s%s\n", stamp, mark, Dim.Sprint("["+c.Section+"]"), c.Message, suffix)
+}
+
+// doctorWatchPaths are the files and directories whose changes trigger a
+// re-check: keys, credentials, shell startup files, and generated configs
+func doctorWatchPaths(home, blackdotDir string) []string {
+	paths := []string{
+		filepath.Join(home, ".ssh"),
+		filepath.Join(home, ".aws"),
+		filepath.Join(home, ".gnupg"),
+		filepath.Join(home, ".kube"),
+		filepath.Join(home, ".zshrc"),
+		filepath.Join(home, ".gitconfig"),
+	}
+	if blackdotDir != "" {
+		paths = append(paths, filepath.Join(blackdotDir, "generated"), filepath.Join(blackdotDir, "templates"))
+	}
+	return paths
+}
+
+// snapshotDoctorPaths records the mode and modification time of each path
+// and of the entries directly inside watched directories
+func snapshotDoctorPaths(paths []string) map[string]string {
+	snap := map[string]string{}
+	record := func(path string) os.FileInfo {
+		info, err := os.Lstat(path)
+		if err != nil {
+			return nil
+		}
+		snap[path] = fmt.Sprintf("%v %d %d", info.Mode(), info.Size(), info.ModTime().UnixNano())
+		return info
+	}
+	for _, path := range paths {
+		if info := record(path); info != nil && info.IsDir() {
+			entries, _ := os.ReadDir(path)
+			for _, e := range entries {
+				record(filepath.Join(path, e.Name()))
+			}
+		}
+	}
+	return snap
+}
+
+// changedDoctorPaths lists paths added, removed, or modified between two
+// snapshots
+func changedDoctorPaths(before, after map[string]string) []string {
+	var changed []string
+	for path, v := range after {
+		if before[path] != v {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
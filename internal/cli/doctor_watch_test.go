@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDiffDoctorResults verifies watch mode reports new and changed
+// problems and resolved ones, but not unchanged checks
+func TestDiffDoctorResults(t *testing.T) {
+	prev := []doctorResult{
+		{"SSH Configuration", "pass", "~/.ssh exists"},
+		{"SSH Configuration", "warn", "id_rsa is 0644"},
+		{"Vault Status", "warn", "Session expires soon"},
+		{"Template System", "fail", "3 stale configs"},
+	}
+	cur := []doctorResult{
+		{"SSH Configuration", "pass", "~/.ssh exists"},
+		{"SSH Configuration", "fail", "id_rsa is 0644"},
+		{"Vault Status", "warn", "Session expires soon"},
+		{"Template System", "pass", "All generated configs up to date"},
+		{"Shell Configuration", "warn", "~/.zshrc is not a symlink"},
+	}
+	changes := diffDoctorResults(prev, cur)
+	want := []doctorChange{
+		{doctorResult: doctorResult{"SSH Configuration", "fail", "id_rsa is 0644"}},
+		{doctorResult: doctorResult{"Shell Configuration", "warn", "~/.zshrc is not a symlink"}},
+		{doctorResult: doctorResult{"Template System", "fail", "3 stale configs"}, Resolved: true},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %+v", changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+}
+
+// TestDoctorWatchSnapshots verifies changes to and inside watched paths
+// are noticed
+func TestDoctorWatchSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	ssh := filepath.Join(dir, ".ssh")
+	os.MkdirAll(ssh, 0700)
+	key := filepath.Join(ssh, "id_ed25519")
+	os.WriteFile(key, []byte("key"), 0600)
+	paths := []string{ssh, filepath.Join(dir, ".zshrc")}
+
+	before := snapshotDoctorPaths(paths)
+	if changed := changedDoctorPaths(before, snapshotDoctorPaths(paths)); len(changed) != 0 {
+		t.Errorf("unchanged paths reported: %v", changed)
+	}
+	os.Chmod(key, 0644)
+	os.WriteFile(filepath.Join(dir, ".zshrc"), nil, 0644)
+	changed := changedDoctorPaths(before, snapshotDoctorPaths(paths))
+	if len(changed) != 2 || changed[0] != key || changed[1] != filepath.Join(dir, ".zshrc") {
+		t.Errorf("changed = %v", changed)
+	}
+}
+
+// TestQuietDoctorRun verifies checks are recorded without printing
+func TestQuietDoctorRun(t *testing.T) {
+	setScheduleEnv(t, "")
+	home := os.Getenv("HOME")
+	stdout := os.Stdout
+	state := quietDoctorRun(home, "", true, nil)
+	if os.Stdout != stdout {
+		t.Fatal("stdout not restored")
+	}
+	if len(state.results) != state.checksPassed+state.checksWarned+state.checksFailed || len(state.results) == 0 {
+		t.Errorf("%d results for %d/%d/%d checks", len(state.results), state.checksPassed, state.checksWarned, state.checksFailed)
+	}
+	if doctorHealthScore(&doctorState{checksFailed: 9, checksWarned: 3}) != 0 || doctorHealthScore(&doctorState{checksWarned: 2}) != 90 {
+		t.Error("health score")
+	}
+}
@@ -1,20 +1,30 @@
 package cli
 
 import (
+	"bufio"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/fatih/color"
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/vaultmux"
 	"github.com/spf13/cobra"
 )
 
-// Drift items to track (matches bash implementation)
+// Drift items to track when vault-items.json is missing (matches bash
+// implementation)
 var driftTrackedFiles = map[string]string{
 	"SSH-Config":          ".ssh/config",
 	"AWS-Config":          ".aws/config",
@@ -25,232 +35,577 @@ var driftTrackedFiles = map[string]string{
 	"Claude-Profiles":     ".claude/profiles.json",
 }
 
-// DriftState represents the cached vault state
-type DriftState struct {
-	Timestamp string                    `json:"timestamp"`
-	Hostname  string                    `json:"hostname"`
-	Files     map[string]DriftFileState `json:"files"`
+// Drift states for one item
+const (
+	driftInSync       = "in-sync"
+	driftLocalChanged = "local-changed" // local edited since the last pull
+	driftVaultChanged = "vault-changed" // vault updated since the last pull
+	driftBothChanged  = "both-changed"  // both sides changed since the last pull
+	driftDiffers      = "differs"       // local and vault differ, no baseline
+	driftLocalMissing = "local-missing"
+	driftVaultMissing = "vault-missing"
+	driftUnknown      = "unknown" // the vault could not be read
+)
+
+// driftResult is the outcome of checking one item
+type driftResult struct {
+	Item  string `json:"item"`
+	Path  string `json:"path"`
+	State string `json:"state"`
+	// From is the backend that answered, when it was not the primary
+	From  string `json:"from,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// driftReport is one drift check
+type driftReport struct {
+	Time     string        `json:"time"`
+	Mode     string        `json:"mode"` // "full" or "quick"
+	Baseline string        `json:"baseline,omitempty"`
+	Items    []driftResult `json:"items"`
+}
+
+// Drifted returns the items that are not in sync
+func (r *driftReport) Drifted() []driftResult {
+	var drifted []driftResult
+	for _, item := range r.Items {
+		if item.State != driftInSync && item.State != driftUnknown {
+			drifted = append(drifted, item)
+		}
+	}
+	return drifted
 }
 
-// DriftFileState represents state for a single file
-type DriftFileState struct {
-	Path     string `json:"path"`
-	Checksum string `json:"checksum"`
+// driftEvent records an item changing drift state
+type driftEvent struct {
+	Time     string `json:"time"`
+	Item     string `json:"item"`
+	State    string `json:"state"`
+	Previous string `json:"previous"`
+	Mode     string `json:"mode"`
+}
+
+// driftBaseline is the checksum state saved by vault pull and sync
+type driftBaseline struct {
+	Timestamp string `json:"timestamp"`
+	Items     map[string]struct {
+		Checksum string `json:"checksum"`
+	} `json:"items"`
+}
+
+type driftOptions struct {
+	quick    bool
+	jsonOut  bool
+	exitCode bool
+	quiet    bool
+	watch    bool
+	interval time.Duration
+	notify   bool
 }
 
 func newDriftCmd() *cobra.Command {
+	var opts driftOptions
+
 	cmd := &cobra.Command{
 		Use:   "drift",
 		Short: "Compare local files vs vault",
 		Long: `Compare local configuration files against vault.
 
 Modes:
-  (default)   Full check - connects to vault and compares
+  (default)   Full check - compares local files, the live vault and the
+              checksums saved at the last pull, so local edits and vault
+              updates are told apart
   --quick, -q Fast check against cached state (no vault access)
 
-The quick mode compares local files against the last vault pull.
-Full mode connects to vault and compares current vault contents.
+Every change in an item's drift state is recorded in the drift history
+(see 'blackdot drift history').
+
+Watch mode polls and notifies when new drift appears. The vault is only
+contacted while it is unlocked, otherwise the check falls back to quick
+mode.
 
 Examples:
-  blackdot drift          # Full check (connects to vault)
-  blackdot drift --quick  # Fast check against cached state`,
-		RunE: runDrift,
+  blackdot drift                       # Full check (connects to vault)
+  blackdot drift --quick               # Fast check against cached state
+  blackdot drift -q --exit-code -s     # Exit 1 on drift, for prompts
+  blackdot drift --watch --interval 10m
+  blackdot drift history`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.watch {
+				return runDriftWatch(opts)
+			}
+			return runDrift(opts)
+		},
 	}
 
-	cmd.Flags().BoolP("quick", "q", false, "Fast check against cached state (no vault access)")
+	cmd.Flags().BoolVarP(&opts.quick, "quick", "q", false, "Fast check against cached state (no vault access)")
+	cmd.Flags().BoolVar(&opts.jsonOut, "json", false, "Output as JSON")
+	cmd.Flags().BoolVar(&opts.exitCode, "exit-code", false, "Exit with status 1 when drift is found")
+	cmd.Flags().BoolVarP(&opts.quiet, "quiet", "s", false, "Print nothing (use with --exit-code)")
+	cmd.Flags().BoolVarP(&opts.watch, "watch", "w", false, "Poll and notify when drift appears")
+	cmd.Flags().DurationVar(&opts.interval, "interval", 5*time.Minute, "Polling interval for --watch")
+	cmd.Flags().BoolVar(&opts.notify, "notify", true, "Send a desktop notification on new drift (--watch)")
 
+	cmd.AddCommand(newDriftHistoryCmd())
 	return cmd
 }
 
-func runDrift(cmd *cobra.Command, args []string) error {
-	quickMode, _ := cmd.Flags().GetBool("quick")
-
-	home, err := os.UserHomeDir()
+func runDrift(opts driftOptions) error {
+	ctx := context.Background()
+	report, err := checkDrift(ctx, opts.quick, opts.quiet || opts.jsonOut)
 	if err != nil {
-		return fmt.Errorf("cannot determine home directory: %w", err)
+		if !opts.quiet {
+			Fail("%v", err)
+		}
+		return err
 	}
-
-	// Colors
-	green := color.New(color.FgGreen).SprintFunc()
-	yellow := color.New(color.FgYellow).SprintFunc()
-	cyan := color.New(color.FgCyan).SprintFunc()
-	dim := color.New(color.Faint).SprintFunc()
-
-	if quickMode {
-		return runDriftQuick(home, green, yellow, dim)
+	recordDriftReport(report)
+
+	switch {
+	case opts.jsonOut:
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+	case !opts.quiet:
+		printDriftReport(report)
 	}
 
-	return runDriftFull(home, green, yellow, cyan, dim)
+	if opts.exitCode && len(report.Drifted()) > 0 {
+		os.Exit(1)
+	}
+	return nil
 }
 
-// runDriftQuick performs a quick drift check against cached state
-func runDriftQuick(home string, green, yellow, dim func(a ...interface{}) string) error {
-	cacheDir := os.Getenv("XDG_CACHE_HOME")
-	if cacheDir == "" {
-		cacheDir = filepath.Join(home, ".cache")
+// driftItems returns the items to check and their local paths, from
+// vault-items.json or the built-in list
+func driftItems() map[string]string {
+	items := map[string]string{}
+	if vaultItems, err := loadVaultItems(); err == nil && len(vaultItems) > 0 {
+		for name, item := range vaultItems {
+			items[name] = expandPath(item.Path)
+		}
+		return items
 	}
-	stateFile := filepath.Join(cacheDir, "blackdot", "vault-state.json")
 
-	// Check if state file exists
-	if _, err := os.Stat(stateFile); os.IsNotExist(err) {
-		fmt.Println("No drift state available. Run 'blackdot vault pull' first.")
-		return nil
+	home, _ := os.UserHomeDir()
+	for name, rel := range driftTrackedFiles {
+		items[name] = filepath.Join(home, rel)
 	}
+	return items
+}
 
-	// Load state
-	state, err := loadDriftState(stateFile)
+// loadDriftBaseline loads the checksums saved at the last pull or sync
+func loadDriftBaseline(path string) (*driftBaseline, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("loading drift state: %w", err)
+		return nil, err
 	}
 
-	fmt.Println("Drift Check (local vs last vault pull)")
-	fmt.Println("=======================================")
-	fmt.Printf("Last sync: %s\n", state.Timestamp)
-	fmt.Println()
-
-	driftCount := 0
-	checkedCount := 0
-
-	for itemName, relPath := range driftTrackedFiles {
-		filePath := filepath.Join(home, relPath)
+	var state driftBaseline
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
 
-		// Get cached checksum
-		fileState, ok := state.Files[itemName]
-		if !ok {
-			continue
+// classifyDrift decides an item's state from its local, vault and baseline
+// checksums. vault is "" in quick mode; "MISSING" means absent.
+func classifyDrift(local, vault, baseline string, quick bool) string {
+	if local == "MISSING" {
+		return driftLocalMissing
+	}
+	if quick {
+		if baseline == "" || local == baseline {
+			return driftInSync
 		}
+		return driftLocalChanged
+	}
+	if vault == "MISSING" {
+		return driftVaultMissing
+	}
+	if local == vault {
+		return driftInSync
+	}
+	switch {
+	case baseline == "":
+		return driftDiffers
+	case vault == baseline:
+		return driftLocalChanged
+	case local == baseline:
+		return driftVaultChanged
+	default:
+		return driftBothChanged
+	}
+}
 
-		checkedCount++
-		currentChecksum := fileChecksum(filePath)
+// checkDrift compares every tracked item. A full check falls back to quick
+// mode when no backend is available.
+func checkDrift(ctx context.Context, quick, silent bool) (*driftReport, error) {
+	baseline, _ := loadDriftBaseline(getVaultDriftStatePath())
+	if quick && baseline == nil {
+		return nil, fmt.Errorf("no drift state available - run 'blackdot vault pull' first")
+	}
 
-		if fileState.Checksum == currentChecksum {
-			fmt.Printf("%s %s: in sync\n", green("✓"), itemName)
-		} else if currentChecksum == "MISSING" {
-			fmt.Printf("%s %s: file missing (was synced)\n", yellow("!"), itemName)
-			driftCount++
+	var reader *vaultReader
+	if !quick {
+		var err error
+		if reader, err = openVaultReader(ctx); err != nil {
+			if baseline == nil {
+				return nil, err
+			}
+			if !silent {
+				Warn("Vault unavailable, checking against last pull: %v", err)
+			}
+			quick = true
 		} else {
-			fmt.Printf("%s %s: CHANGED locally\n", yellow("✗"), itemName)
-			driftCount++
+			defer reader.Close()
 		}
 	}
 
-	fmt.Println()
-	if driftCount == 0 {
-		fmt.Printf("%s\n", green(fmt.Sprintf("All %d items in sync with last vault pull", checkedCount)))
-	} else {
-		fmt.Printf("%s\n", yellow(fmt.Sprintf("%d of %d items have local changes", driftCount, checkedCount)))
-		fmt.Println()
-		fmt.Println("Options:")
-		fmt.Println("  blackdot vault push --all  # Push local changes to vault")
-		fmt.Println("  blackdot vault pull        # Overwrite local with vault")
+	report := &driftReport{Time: time.Now().UTC().Format(time.RFC3339), Mode: "full"}
+	if quick {
+		report.Mode = "quick"
+	}
+	if baseline != nil {
+		report.Baseline = baseline.Timestamp
 	}
 
-	return nil
-}
+	items := driftItems()
+	if !quick {
+		expectVaultReads(len(items))
+	}
+	names := make([]string, 0, len(items))
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := items[name]
+		result := driftResult{Item: name, Path: path}
+		expected := ""
+		if baseline != nil {
+			expected = baseline.Items[name].Checksum
+		}
+		local := fileChecksum(path)
+
+		if quick {
+			// Without a baseline for an item there is nothing to compare
+			if expected == "" && local == "MISSING" {
+				continue
+			}
+			result.State = classifyDrift(local, "", expected, true)
+			report.Items = append(report.Items, result)
+			continue
+		}
 
-// runDriftFull performs a full drift check against vault
-func runDriftFull(home string, green, yellow, cyan, dim func(a ...interface{}) string) error {
-	blackdotDir := os.Getenv("BLACKDOT_DIR")
-	if blackdotDir == "" {
-		blackdotDir = filepath.Join(home, ".blackdot")
+		notes, from, err := reader.GetNotes(ctx, name)
+		vault := ""
+		switch {
+		case errors.Is(err, vaultmux.ErrNotFound):
+			if local == "MISSING" {
+				continue
+			}
+			vault = "MISSING"
+		case err != nil:
+			result.State = driftUnknown
+			result.Error = err.Error()
+			report.Items = append(report.Items, result)
+			continue
+		default:
+			vault = vaultContentChecksum(notes)
+			result.From = reader.servedBy(from)
+		}
+		result.State = classifyDrift(local, vault, expected, false)
+		report.Items = append(report.Items, result)
 	}
 
-	// For full mode, we'd need to connect to vault
-	// This requires the vault abstraction which isn't fully ported to Go yet
-	// For now, we'll show a message and suggest using quick mode or bash version
+	return report, nil
+}
 
-	fmt.Println()
-	fmt.Println(color.New(color.Bold).Sprint("Drift Detection"))
-	fmt.Println()
+// driftStateLabel describes a state for display
+func driftStateLabel(state string) string {
+	switch state {
+	case driftInSync:
+		return "in sync"
+	case driftLocalChanged:
+		return "CHANGED locally"
+	case driftVaultChanged:
+		return "updated in vault"
+	case driftBothChanged:
+		return "changed locally AND in vault"
+	case driftDiffers:
+		return "LOCAL DIFFERS from vault"
+	case driftLocalMissing:
+		return "local file missing"
+	case driftVaultMissing:
+		return "not in vault"
+	}
+	return state
+}
 
-	// Check for vault session
-	vaultSessionFile := filepath.Join(blackdotDir, "vault", ".bw-session")
-	bwSession := os.Getenv("BW_SESSION")
-	if bwSession == "" {
-		if data, err := os.ReadFile(vaultSessionFile); err == nil {
-			bwSession = string(data)
+func printDriftReport(report *driftReport) {
+	if report.Mode == "quick" {
+		PrintHeader("Drift Check (local vs last vault pull)")
+	} else {
+		PrintHeader("Drift Check (local vs vault)")
+	}
+	if report.Baseline != "" {
+		fmt.Printf("  %s\n\n", Dim.Sprint("Last pull: "+formatTimeAgo(report.Baseline)))
+	}
+
+	local, vault, both := 0, 0, 0
+	for _, item := range report.Items {
+		detail := driftStateLabel(item.State)
+		if item.From != "" {
+			detail += " (" + item.From + ")"
+		}
+		switch item.State {
+		case driftInSync:
+			fmt.Printf("  %s %s: %s\n", Green.Sprint("✓"), item.Item, Dim.Sprint(detail))
+		case driftUnknown:
+			fmt.Printf("  %s %s: %s\n", Yellow.Sprint("?"), item.Item, Dim.Sprint(item.Error))
+		default:
+			fmt.Printf("  %s %s: %s\n", Yellow.Sprint("✗"), item.Item, Yellow.Sprint(detail))
+		}
+		switch item.State {
+		case driftLocalChanged, driftVaultMissing:
+			local++
+		case driftVaultChanged, driftLocalMissing:
+			vault++
+		case driftBothChanged, driftDiffers:
+			both++
 		}
 	}
 
-	if bwSession == "" {
-		fmt.Printf("%s Vault not unlocked - cannot check drift\n", yellow("[WARN]"))
-		fmt.Println()
-		fmt.Printf("%s For quick local check: blackdot drift --quick\n", cyan("[INFO]"))
-		fmt.Printf("%s To unlock vault: export BW_SESSION=\"$(bw unlock --raw)\"\n", cyan("[INFO]"))
-		return nil
+	fmt.Println()
+	drifted := len(report.Drifted())
+	if drifted == 0 {
+		Pass("All %d items in sync", len(report.Items))
+		return
 	}
 
-	// Perform drift check against vault
-	fmt.Printf("%s Checking drift against vault...\n", cyan("[INFO]"))
+	Warn("%d of %d items have drifted", drifted, len(report.Items))
 	fmt.Println()
+	if local > 0 {
+		fmt.Printf("  %s blackdot vault push --all  %s\n", Green.Sprint("→"), Dim.Sprint("# Push local changes to vault"))
+	}
+	if vault > 0 {
+		fmt.Printf("  %s blackdot vault pull        %s\n", Green.Sprint("→"), Dim.Sprint("# Overwrite local with vault"))
+	}
+	if both > 0 {
+		fmt.Printf("  %s blackdot vault pull -i     %s\n", Green.Sprint("→"), Dim.Sprint("# Resolve conflicts item by item"))
+	}
+}
 
-	driftCount := 0
-	checkedCount := 0
+// ============================================================
+// History
+// ============================================================
 
-	for itemName, relPath := range driftTrackedFiles {
-		filePath := filepath.Join(home, relPath)
+func driftHistoryPath() string {
+	return filepath.Join(paths.CacheDir(), "drift-history.jsonl")
+}
 
-		// Check if local file exists
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			fmt.Printf("%s %s: local file not found (%s)\n", cyan("[INFO]"), itemName, filePath)
-			continue
-		}
+// driftHistoryMax is the number of events kept when the log is trimmed
+const driftHistoryMax = 500
+
+// recordDriftReport appends an event for every item whose state changed
+// since it was last recorded, and returns the new events. Items never
+// recorded are assumed to have been in sync.
+func recordDriftReport(report *driftReport) []driftEvent {
+	history, _ := loadDriftHistory()
+	last := map[string]string{}
+	for _, e := range history {
+		last[e.Item] = e.State
+	}
 
-		// Get vault content using bw CLI
-		vaultContent, err := getVaultNotes(itemName, bwSession)
-		if err != nil || vaultContent == "" {
-			fmt.Printf("%s %s: not found in vault\n", cyan("[INFO]"), itemName)
+	var events []driftEvent
+	for _, item := range report.Items {
+		if item.State == driftUnknown {
 			continue
 		}
-
-		checkedCount++
-
-		// Compare
-		localContent, err := os.ReadFile(filePath)
-		if err != nil {
-			fmt.Printf("%s %s: error reading file: %v\n", yellow("[WARN]"), itemName, err)
+		previous := last[item.Item]
+		if previous == "" {
+			previous = driftInSync
+		}
+		if item.State == previous {
 			continue
 		}
+		events = append(events, driftEvent{
+			Time:     report.Time,
+			Item:     item.Item,
+			State:    item.State,
+			Previous: previous,
+			Mode:     report.Mode,
+		})
+	}
+	if len(events) == 0 {
+		return nil
+	}
 
-		if string(localContent) == vaultContent {
-			fmt.Printf("%s %s: in sync\n", green("[OK]"), itemName)
-		} else {
-			fmt.Printf("%s %s: LOCAL DIFFERS from vault\n", yellow("[WARN]"), itemName)
-			driftCount++
+	history = append(history, events...)
+	if len(history) > driftHistoryMax {
+		history = history[len(history)-driftHistoryMax:]
+	}
+	var b strings.Builder
+	for _, e := range history {
+		line, _ := json.Marshal(e)
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	_ = writeFileWithPolicy(driftHistoryPath(), []byte(b.String()), fileClassPrivate)
+	return events
+}
+
+// loadDriftHistory reads the drift history, oldest first
+func loadDriftHistory() ([]driftEvent, error) {
+	f, err := os.Open(driftHistoryPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []driftEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e driftEvent
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			events = append(events, e)
 		}
 	}
+	return events, scanner.Err()
+}
 
-	fmt.Println()
-	fmt.Println("========================================")
-	if driftCount == 0 {
-		fmt.Printf("%s\n", green(fmt.Sprintf("All %d checked items are in sync", checkedCount)))
-	} else {
-		fmt.Printf("%s\n", yellow(fmt.Sprintf("%d of %d items have drifted", driftCount, checkedCount)))
-		fmt.Println()
-		fmt.Printf("%s To sync local changes to vault:\n", cyan("[INFO]"))
-		fmt.Println("  blackdot vault sync --all")
-		fmt.Println()
-		fmt.Printf("%s To restore from vault (overwrite local):\n", cyan("[INFO]"))
-		fmt.Println("  blackdot vault restore")
+func newDriftHistoryCmd() *cobra.Command {
+	var limit int
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show when items drifted and returned to sync",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			events, err := loadDriftHistory()
+			if err != nil {
+				Fail("Failed to read drift history: %v", err)
+				return err
+			}
+			if limit > 0 && len(events) > limit {
+				events = events[len(events)-limit:]
+			}
+
+			if jsonOut {
+				if events == nil {
+					events = []driftEvent{}
+				}
+				data, _ := json.MarshalIndent(events, "", "  ")
+				fmt.Println(string(data))
+				return nil
+			}
+
+			PrintHeader("Drift History")
+			if len(events) == 0 {
+				Info("No drift recorded")
+				return nil
+			}
+			for _, e := range events {
+				when := e.Time
+				if t, err := time.Parse(time.RFC3339, e.Time); err == nil {
+					when = t.Local().Format("2006-01-02 15:04")
+				}
+				mark := Yellow.Sprint("✗")
+				if e.State == driftInSync {
+					mark = Green.Sprint("✓")
+				}
+				fmt.Printf("  %s %s %s %s\n", Dim.Sprint(when), mark, e.Item, Dim.Sprint(driftStateLabel(e.State)))
+			}
+			return nil
+		},
 	}
-	fmt.Println("========================================")
 
-	return nil
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Number of events to show (0 for all)")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON")
+	return cmd
 }
 
-// loadDriftState loads the cached drift state
-func loadDriftState(path string) (*DriftState, error) {
-	data, err := os.ReadFile(path)
+// ============================================================
+// Watch
+// ============================================================
+
+// vaultUnlocked reports whether the primary backend can be used without
+// prompting
+func vaultUnlocked(ctx context.Context) bool {
+	backend, err := newVaultBackend()
 	if err != nil {
-		return nil, err
+		return false
+	}
+	defer backend.Close()
+	if err := backend.Init(ctx); err != nil {
+		return false
 	}
+	return backend.IsAuthenticated(ctx)
+}
 
-	var state DriftState
-	if err := json.Unmarshal(data, &state); err != nil {
-		return nil, err
+func runDriftWatch(opts driftOptions) error {
+	if opts.interval < 10*time.Second {
+		return fmt.Errorf("--interval must be at least 10s")
 	}
 
-	return &state, nil
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if !opts.quiet {
+		Info("Watching for drift every %s (Ctrl+C to stop)", opts.interval)
+	}
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	for {
+		quick := opts.quick || !vaultUnlocked(ctx)
+		report, err := checkDrift(ctx, quick, true)
+		if err != nil {
+			if !opts.quiet {
+				Warn("%v", err)
+			}
+		} else {
+			for _, e := range recordDriftReport(report) {
+				if !opts.quiet {
+					mark := Yellow.Sprint("✗")
+					if e.State == driftInSync {
+						mark = Green.Sprint("✓")
+					}
+					fmt.Printf("  %s %s %s %s\n", Dim.Sprint(time.Now().Format("15:04:05")), mark, e.Item, driftStateLabel(e.State))
+				}
+				if opts.notify && e.State != driftInSync {
+					notifyDesktop("blackdot drift", e.Item+": "+driftStateLabel(e.State))
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// notifyDesktop shows a desktop notification where a notifier is available
+func notifyDesktop(title, message string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		cmd = exec.Command("osascript", "-e", script)
+	case "linux":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return
+		}
+		cmd = exec.Command("notify-send", title, message)
+	default:
+		return
+	}
+	_ = cmd.Run()
 }
 
 // fileChecksum returns SHA256 checksum of a file, or "MISSING" if not found
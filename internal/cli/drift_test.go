@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestClassifyDrift verifies the baseline tells local edits from vault updates
+func TestClassifyDrift(t *testing.T) {
+	cases := []struct {
+		local, vault, baseline string
+		quick                  bool
+		want                   string
+	}{
+		{"a", "a", "a", false, driftInSync},
+		{"b", "a", "a", false, driftLocalChanged},
+		{"a", "b", "a", false, driftVaultChanged},
+		{"b", "c", "a", false, driftBothChanged},
+		{"b", "c", "", false, driftDiffers},
+		{"a", "MISSING", "a", false, driftVaultMissing},
+		{"MISSING", "a", "a", false, driftLocalMissing},
+		{"a", "", "a", true, driftInSync},
+		{"b", "", "a", true, driftLocalChanged},
+	}
+	for _, c := range cases {
+		if got := classifyDrift(c.local, c.vault, c.baseline, c.quick); got != c.want {
+			t.Errorf("classifyDrift(%q, %q, %q, %v) = %s, want %s", c.local, c.vault, c.baseline, c.quick, got, c.want)
+		}
+	}
+}
+
+// TestQuickDriftAndHistory verifies a quick check reads the saved baseline
+// and the history only records state changes
+func TestQuickDriftAndHistory(t *testing.T) {
+	setScheduleEnv(t, "")
+	home := os.Getenv("HOME")
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+
+	gitconfig := filepath.Join(home, ".gitconfig")
+	os.WriteFile(gitconfig, []byte("[user]\n"), 0644)
+	items := map[string]VaultItem{"Git-Config": {Path: gitconfig}}
+	os.MkdirAll(ConfigDir(), 0755)
+	os.WriteFile(filepath.Join(ConfigDir(), "vault-items.json"), []byte(`{"vault_items":{"Git-Config":{"path":"`+gitconfig+`"}}}`), 0644)
+	if err := saveVaultDriftState(items); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := checkDrift(context.Background(), true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Items) != 1 || report.Items[0].State != driftInSync {
+		t.Fatalf("report = %+v", report.Items)
+	}
+	if events := recordDriftReport(report); len(events) != 0 {
+		t.Errorf("in-sync item should not be recorded: %+v", events)
+	}
+
+	os.WriteFile(gitconfig, []byte("[user]\n\tname = me\n"), 0644)
+	report, _ = checkDrift(context.Background(), true, true)
+	if report.Items[0].State != driftLocalChanged {
+		t.Fatalf("state = %s", report.Items[0].State)
+	}
+	if events := recordDriftReport(report); len(events) != 1 || events[0].Previous != driftInSync {
+		t.Fatalf("events = %+v", events)
+	}
+	if events := recordDriftReport(report); len(events) != 0 {
+		t.Errorf("unchanged drift recorded twice: %+v", events)
+	}
+
+	os.WriteFile(gitconfig, []byte("[user]\n"), 0644)
+	report, _ = checkDrift(context.Background(), true, true)
+	recordDriftReport(report)
+
+	history, _ := loadDriftHistory()
+	if len(history) != 2 || history[1].State != driftInSync || history[1].Previous != driftLocalChanged {
+		t.Errorf("history = %+v", history)
+	}
+}
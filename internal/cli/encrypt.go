@@ -1,13 +1,13 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/blackwell-systems/blackdot/internal/prompts"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -98,8 +98,7 @@ func newEncryptCmd() *cobra.Command {
 }
 
 func getEncryptionDir() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".config", "blackdot")
+	return ConfigDir()
 }
 
 func getAgeKeyFile() string {
@@ -553,10 +552,8 @@ func runEncryptPushKey(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  cat %s\n", keyFile)
 
 		// Offer to show the key
-		fmt.Print("\nShow key contents now? [y/N] ")
-		reader := bufio.NewReader(os.Stdin)
-		response, _ := reader.ReadString('\n')
-		if strings.TrimSpace(strings.ToLower(response)) == "y" {
+		fmt.Println()
+		if show, _ := prompts.Confirm("Show key contents now?", false); show {
 			fmt.Println()
 			fmt.Println(string(keyContent))
 		}
@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// envSecretsItem is the vault item env.secrets is restored from
+const envSecretsItem = "Environment-Secrets"
+
+// Sources env secrets can be read from
+const (
+	envSourceAuto  = "auto" // env.secrets if present, else the vault
+	envSourceFile  = "file"
+	envSourceVault = "vault"
+)
+
+// envFormats renders variables for each export format
+var envFormats = map[string]func(w io.Writer, vars [][2]string) error{
+	"dotenv": writeEnvDotenv,
+	"sh":     writeEnvSh,
+	"fish":   writeEnvFish,
+	"pwsh":   writeEnvPwsh,
+	"json":   writeEnvJSON,
+}
+
+func newEnvCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Export or inject environment secrets",
+		Long: `Load Environment-Secrets and hand them to other tools without the bash
+loader.
+
+Secrets come from ~/.local/env.secrets when it exists, otherwise straight
+from the vault item Environment-Secrets. With --from vault nothing is read
+from or written to disk.
+
+Examples:
+  blackdot env export --format pwsh | Out-String | Invoke-Expression
+  blackdot env export --format dotenv > .env      # direnv: dotenv_if_exists
+  blackdot env exec -- terraform plan
+  blackdot env exec --from vault -- npm run deploy`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newEnvExportCmd(), newEnvExecCmd())
+	return cmd
+}
+
+func addEnvSourceFlags(cmd *cobra.Command, from, item *string) {
+	cmd.Flags().StringVar(from, "from", envSourceAuto, "Where to read secrets: auto, file, vault")
+	cmd.Flags().StringVar(item, "item", envSecretsItem, "Vault item to read with --from vault")
+}
+
+func newEnvExportCmd() *cobra.Command {
+	var format, from, item string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Print environment secrets for a shell or tool",
+		Long: `Print environment secrets in a format another shell or tool can load.
+
+Formats:
+  dotenv   KEY="value" lines (direnv's dotenv, most .env loaders)
+  sh       export KEY='value' (bash, zsh)
+  fish     set -gx KEY 'value'
+  pwsh     $env:KEY = 'value' (PowerShell)
+  json     {"KEY": "value"}`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			write, ok := envFormats[format]
+			if !ok {
+				return fmt.Errorf("unknown format %q (expected dotenv, sh, fish, pwsh, json)", format)
+			}
+			vars, err := loadEnvSecrets(from, item)
+			if err != nil {
+				return err
+			}
+			return write(os.Stdout, vars)
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "sh", "Output format: dotenv, sh, fish, pwsh, json")
+	addEnvSourceFlags(cmd, &from, &item)
+	cmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"dotenv", "sh", "fish", "pwsh", "json"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+func newEnvExecCmd() *cobra.Command {
+	var from, item string
+
+	cmd := &cobra.Command{
+		Use:   "exec [flags] -- <command> [args...]",
+		Short: "Run a command with environment secrets set",
+		Long: `Run a command with environment secrets added to its environment.
+
+The secrets only exist in the child process; nothing is written to disk.
+Variables already set in your environment are overridden. The command's
+exit code is passed through.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vars, err := loadEnvSecrets(from, item)
+			if err != nil {
+				return err
+			}
+			return runWithEnv(vars, args)
+		},
+	}
+
+	addEnvSourceFlags(cmd, &from, &item)
+	cmd.Flags().SetInterspersed(false)
+	return cmd
+}
+
+// loadEnvSecrets reads environment secrets from the chosen source
+func loadEnvSecrets(from, item string) ([][2]string, error) {
+	path := envSecretsPath()
+	switch from {
+	case envSourceAuto:
+		if _, err := os.Stat(path); err == nil {
+			return parseEnvSecrets(path)
+		}
+		return loadEnvSecretsFromVault(item)
+	case envSourceFile:
+		vars, err := parseEnvSecrets(path)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s not found (run 'blackdot vault restore' or use --from vault)", tildePath(path))
+		}
+		return vars, err
+	case envSourceVault:
+		return loadEnvSecretsFromVault(item)
+	}
+	return nil, fmt.Errorf("unknown source %q (expected auto, file, vault)", from)
+}
+
+// loadEnvSecretsFromVault reads the secrets item without writing it out
+func loadEnvSecretsFromVault(item string) ([][2]string, error) {
+	if isOfflineMode() {
+		return nil, errors.New("env.secrets not found and BLACKDOT_OFFLINE=1 prevents reading the vault")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	reader, err := openVaultReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("vault not available: %w", err)
+	}
+	defer reader.Close()
+
+	notes, _, err := reader.GetNotes(ctx, item)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s from vault: %w", item, err)
+	}
+	return parseEnvSecretsReader(strings.NewReader(notes))
+}
+
+// runWithEnv runs args with vars added to the environment and exits with
+// the command's status when it fails
+func runWithEnv(vars [][2]string, args []string) error {
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return err
+	}
+	c := exec.Command(path, args[1:]...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	c.Env = mergeEnv(os.Environ(), vars)
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		return err
+	}
+	return nil
+}
+
+// mergeEnv returns environ with vars set, replacing existing entries
+func mergeEnv(environ []string, vars [][2]string) []string {
+	set := make(map[string]bool, len(vars))
+	for _, kv := range vars {
+		set[kv[0]] = true
+	}
+	out := make([]string, 0, len(environ)+len(vars))
+	for _, entry := range environ {
+		if name, _, _ := strings.Cut(entry, "="); !set[name] {
+			out = append(out, entry)
+		}
+	}
+	for _, kv := range vars {
+		out = append(out, kv[0]+"="+kv[1])
+	}
+	return out
+}
+
+func writeEnvDotenv(w io.Writer, vars [][2]string) error {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "$", `\$`)
+	for _, kv := range vars {
+		if _, err := fmt.Fprintf(w, "%s=\"%s\"\n", kv[0], r.Replace(kv[1])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeEnvSh(w io.Writer, vars [][2]string) error {
+	for _, kv := range vars {
+		if _, err := fmt.Fprintf(w, "export %s=%s\n", kv[0], shellQuote(kv[1])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeEnvFish(w io.Writer, vars [][2]string) error {
+	for _, kv := range vars {
+		if _, err := fmt.Fprintf(w, "set -gx %s %s\n", kv[0], fishQuote(kv[1])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeEnvPwsh(w io.Writer, vars [][2]string) error {
+	for _, kv := range vars {
+		if _, err := fmt.Fprintf(w, "$env:%s = '%s'\n", kv[0], strings.ReplaceAll(kv[1], "'", "''")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeEnvJSON(w io.Writer, vars [][2]string) error {
+	obj := make(map[string]string, len(vars))
+	for _, kv := range vars {
+		obj[kv[0]] = kv[1]
+	}
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEnvExportFormats verifies quoting of awkward values in every format
+func TestEnvExportFormats(t *testing.T) {
+	vars := [][2]string{{"TOKEN", `it's "$x"`}, {"PLAIN", "abc"}}
+	want := map[string]string{
+		"dotenv": "TOKEN=\"it's \\\"\\$x\\\"\"\nPLAIN=\"abc\"\n",
+		"sh":     "export TOKEN='it'\\''s \"$x\"'\nexport PLAIN=abc\n",
+		"fish":   "set -gx TOKEN 'it\\'s \"$x\"'\nset -gx PLAIN 'abc'\n",
+		"pwsh":   "$env:TOKEN = 'it''s \"$x\"'\n$env:PLAIN = 'abc'\n",
+		"json":   "{\n  \"PLAIN\": \"abc\",\n  \"TOKEN\": \"it's \\\"$x\\\"\"\n}\n",
+	}
+	for format, expected := range want {
+		var buf bytes.Buffer
+		if err := envFormats[format](&buf, vars); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != expected {
+			t.Errorf("%s:\n%s\nwant:\n%s", format, buf.String(), expected)
+		}
+	}
+}
+
+// TestLoadEnvSecretsFile verifies the file source and that exec overrides
+// variables already in the environment
+func TestLoadEnvSecretsFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	if _, err := loadEnvSecrets(envSourceFile, envSecretsItem); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing file: %v", err)
+	}
+
+	os.MkdirAll(filepath.Join(home, ".local"), 0700)
+	os.WriteFile(envSecretsPath(), []byte("export API_KEY=\"one\"\nOTHER=two\n"), 0600)
+	vars, err := loadEnvSecrets(envSourceAuto, envSecretsItem)
+	if err != nil || len(vars) != 2 || vars[0] != [2]string{"API_KEY", "one"} {
+		t.Fatalf("vars = %v, %v", vars, err)
+	}
+
+	env := mergeEnv([]string{"API_KEY=old", "PATH=/bin"}, vars)
+	if strings.Join(env, " ") != "PATH=/bin API_KEY=one OTHER=two" {
+		t.Errorf("env = %v", env)
+	}
+}
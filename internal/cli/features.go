@@ -8,7 +8,9 @@ import (
 
 	"github.com/blackwell-systems/blackdot/internal/config"
 	"github.com/blackwell-systems/blackdot/internal/feature"
+	"github.com/blackwell-systems/blackdot/internal/paths"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 // Shared registry instance
@@ -22,6 +24,10 @@ func initRegistry() *feature.Registry {
 
 	registry = feature.NewRegistry()
 
+	// Defaults from the dotfiles repos (features.yaml), below the user's
+	repoFeatures = repoFeatureDefaults()
+	registry.LoadState(repoFeatures)
+
 	// Load persisted state from config file
 	cfg := config.DefaultManager()
 	userConfig, err := cfg.Load()
@@ -29,9 +35,45 @@ func initRegistry() *feature.Registry {
 		registry.LoadState(userConfig.Features)
 	}
 
+	// Toggles from the repository's .blackdot.yaml override the user's
+	if overlay := currentProjectOverlay(); overlay != nil && len(overlay.Features) > 0 {
+		projectFeatures = overlay.Features
+		registry.LoadState(overlay.Features)
+	}
+
 	return registry
 }
 
+// projectFeatures holds the toggles applied from the project overlay so
+// they are not written back to the user config
+var projectFeatures map[string]bool
+
+// repoFeatures holds the defaults merged from the repos' features.yaml,
+// kept out of the user config for the same reason
+var repoFeatures map[string]bool
+
+// repoFeatureDefaults merges features.yaml (feature: true|false) across the
+// base repo and its overlays; a later root wins, so an org repo listed
+// before a personal one sets defaults the personal repo can change
+func repoFeatureDefaults() map[string]bool {
+	merged := map[string]bool{}
+	for _, f := range paths.CollectLayered(paths.Roots(BlackdotDir()), "features.yaml") {
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			continue
+		}
+		var toggles map[string]bool
+		if err := yaml.Unmarshal(data, &toggles); err != nil {
+			Warn("Ignoring %s: %v", tildePath(f.Path), err)
+			continue
+		}
+		for name, enabled := range toggles {
+			merged[name] = enabled
+		}
+	}
+	return merged
+}
+
 func newFeaturesCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "features",
@@ -62,6 +104,7 @@ categories: core, optional, and integration.`,
 		newFeaturesCheckCmd(),
 		newFeaturesShowCmd(),
 		newFeaturesValidateCmd(),
+		newFeaturesExportCmd(),
 	)
 
 	return cmd
@@ -106,6 +149,9 @@ func printFeaturesHelp() {
 	printFeaturesCmd("validate", "Validate feature registry for circular dependencies")
 	Dim.Println("                      and conflicts. Returns exit code 0 if valid.")
 	fmt.Println()
+	printFeaturesCmd("export", "Cache feature state as a shell snippet")
+	Dim.Println("                      --format: zsh, bash, pwsh (default zsh)")
+	fmt.Println()
 	printFeaturesCmd("help", "Show this help")
 	fmt.Println()
 
@@ -656,9 +702,41 @@ func persistFeatureState(reg *feature.Registry) error {
 		userConfig = &config.Config{Version: 3}
 	}
 
-	userConfig.Features = reg.SaveState()
+	state := reg.SaveState()
+	// Repo defaults are inherited, not saved; a change away from one is
+	// saved even when it matches the built-in default
+	for name, repoVal := range repoFeatures {
+		f, ok := reg.Get(name)
+		if !ok {
+			continue
+		}
+		enabled, saved := state[name]
+		if !saved {
+			enabled = f.Default == feature.DefaultTrue
+		}
+		if _, own := userConfig.Features[name]; enabled == repoVal && !own {
+			delete(state, name)
+		} else {
+			state[name] = enabled
+		}
+	}
+	for name, projectVal := range projectFeatures {
+		if reg.Enabled(name) != projectVal {
+			continue // changed by this command, keep it
+		}
+		if userVal, ok := userConfig.Features[name]; ok {
+			state[name] = userVal
+		} else {
+			delete(state, name)
+		}
+	}
+	userConfig.Features = state
 
-	return cfg.Save(userConfig)
+	if err := cfg.Save(userConfig); err != nil {
+		return err
+	}
+	refreshFeatureSnippets()
+	return nil
 }
 
 // printShellReloadHint prints a hint to reload the shell after feature changes
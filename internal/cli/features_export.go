@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/config"
+	"github.com/blackwell-systems/blackdot/internal/feature"
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// featureSnippetFiles maps each export format to its cached snippet name
+var featureSnippetFiles = map[string]string{
+	"zsh":  "features.zsh",
+	"bash": "features.bash",
+	"pwsh": "features.ps1",
+}
+
+// featureSnippetPath is where the snippet for format is cached. It uses
+// the XDG cache like 00-init.zsh does, so the shell can find it without
+// asking the binary.
+func featureSnippetPath(format string) string {
+	return filepath.Join(paths.For(paths.StrategyXDG).Cache, featureSnippetFiles[format])
+}
+
+func newFeaturesExportCmd() *cobra.Command {
+	var format string
+	var stdout bool
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write enabled features as a cached shell snippet",
+		Long: `Write the feature state as shell variables so shell modules can gate
+themselves without running the binary:
+
+  BLACKDOT_FEATURE_VAULT=1
+  BLACKDOT_FEATURE_GPG_TOOLS=0
+  BLACKDOT_FEATURES="vault templates ..."
+
+and define feature_on (zsh, bash) or Test-FeatureOn (PowerShell) to
+read them:
+
+  feature_on vault || return 0
+
+The variables are not exported: an exported BLACKDOT_FEATURE_<NAME>
+overrides the config for every blackdot command run from the shell.
+PowerShell gets a $BlackdotFeatures hashtable instead.
+
+Snippets are cached in ~/.cache/blackdot/features.{zsh,bash,ps1}, written
+atomically, and regenerated whenever features are changed with
+'features enable|disable|preset --persist' or 'config set features.*'.
+Project (.blackdot.yaml) toggles are not included.
+
+Examples:
+  blackdot features export                  # Write the zsh snippet
+  blackdot features export --format pwsh
+  blackdot features export --stdout         # Print instead of caching`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format == "powershell" {
+				format = "pwsh"
+			}
+			if _, ok := featureSnippetFiles[format]; !ok {
+				Fail("Unknown format: %s (use zsh, bash, or pwsh)", format)
+				return fmt.Errorf("unknown format: %s", format)
+			}
+			if stdout {
+				fmt.Print(renderFeatureSnippet(configuredFeatureRegistry(), format))
+				return nil
+			}
+			path, err := writeFeatureSnippet(format)
+			if err != nil {
+				Fail("Failed to write snippet: %v", err)
+				return err
+			}
+			Pass("Wrote %s", tildePath(path))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "zsh", "Snippet format: zsh, bash, pwsh")
+	cmd.Flags().BoolVar(&stdout, "stdout", false, "Print the snippet instead of caching it")
+	return cmd
+}
+
+// configuredFeatureRegistry is the feature state from the repos'
+// features.yaml and the user config, without project overlay toggles,
+// which depend on the working directory
+func configuredFeatureRegistry() *feature.Registry {
+	reg := feature.NewRegistry()
+	reg.LoadState(repoFeatureDefaults())
+	if cfg, err := config.DefaultManager().Load(); err == nil && cfg.Features != nil {
+		reg.LoadState(cfg.Features)
+	}
+	return reg
+}
+
+// featureVarName is the shell variable holding a feature's state
+func featureVarName(name string) string {
+	return "BLACKDOT_FEATURE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// renderFeatureSnippet returns the snippet for one format
+func renderFeatureSnippet(reg *feature.Registry, format string) string {
+	names := reg.List("")
+	sort.Strings(names)
+	var enabled []string
+	for _, name := range names {
+		if reg.Enabled(name) {
+			enabled = append(enabled, name)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# blackdot feature flags\n# Generated by: blackdot features export --format %s\n# Regenerated when features change; do not edit.\n\n", format)
+
+	// Shell variables, not environment variables: an exported
+	// BLACKDOT_FEATURE_<NAME> overrides the config for every blackdot run
+	if format == "pwsh" {
+		b.WriteString("$global:BlackdotFeatures = @{\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "    '%s' = $%t\n", name, reg.Enabled(name))
+		}
+		b.WriteString(`}
+
+# Test-FeatureOn <name>: true when the feature was enabled at export time
+function Test-FeatureOn {
+    param([string]$Feature)
+    $global:BlackdotFeatures[$Feature] -eq $true
+}
+`)
+		return b.String()
+	}
+
+	for _, name := range names {
+		value := 0
+		if reg.Enabled(name) {
+			value = 1
+		}
+		fmt.Fprintf(&b, "%s=%d\n", featureVarName(name), value)
+	}
+	fmt.Fprintf(&b, "BLACKDOT_FEATURES=%q\n\n", strings.Join(enabled, " "))
+	b.WriteString("# feature_on <name>: true when the feature was enabled at export time\n")
+	if format == "zsh" {
+		b.WriteString(`feature_on() {
+    local var="BLACKDOT_FEATURE_${(U)1//-/_}"
+    [[ "${(P)var}" == 1 ]]
+}
+`)
+	} else {
+		b.WriteString(`feature_on() {
+    local var="${1//-/_}"
+    var="BLACKDOT_FEATURE_${var^^}"
+    [[ "${!var}" == 1 ]]
+}
+`)
+	}
+	return b.String()
+}
+
+// writeFeatureSnippet renders and caches the snippet for format
+func writeFeatureSnippet(format string) (string, error) {
+	path := featureSnippetPath(format)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return path, writeFileAtomic(path, []byte(renderFeatureSnippet(configuredFeatureRegistry(), format)), 0644)
+}
+
+// refreshFeatureSnippets regenerates the snippets a shell has already
+// cached, after the persisted feature state changed
+func refreshFeatureSnippets() {
+	for format := range featureSnippetFiles {
+		if _, err := os.Stat(featureSnippetPath(format)); err != nil {
+			continue
+		}
+		if _, err := writeFeatureSnippet(format); err != nil {
+			Debug("Failed to refresh %s feature snippet: %v", format, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFeatureSnippet verifies the snippet holds every feature's state as
+// unexported variables and that persisting features rewrites cached
+// snippets only
+func TestFeatureSnippet(t *testing.T) {
+	setScheduleEnv(t, `{"version": 3, "features": {"vault": true, "gpg_tools": false}}`)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(os.Getenv("HOME"), ".cache"))
+	t.Setenv("BLACKDOT_FEATURE_VAULT", "")
+	t.Setenv("BLACKDOT_FEATURE_GPG_TOOLS", "")
+
+	zsh := renderFeatureSnippet(configuredFeatureRegistry(), "zsh")
+	for _, want := range []string{"\nBLACKDOT_FEATURE_VAULT=1\n", "\nBLACKDOT_FEATURE_GPG_TOOLS=0\n", "feature_on() {"} {
+		if !strings.Contains(zsh, want) {
+			t.Errorf("zsh snippet missing %q:\n%s", want, zsh)
+		}
+	}
+	if strings.Contains(zsh, "\nexport ") {
+		t.Error("feature variables must not be exported")
+	}
+	if pwsh := renderFeatureSnippet(configuredFeatureRegistry(), "pwsh"); !strings.Contains(pwsh, "'vault' = $true") || !strings.Contains(pwsh, "'gpg_tools' = $false") {
+		t.Errorf("pwsh snippet:\n%s", pwsh)
+	}
+
+	if bash, err := exec.LookPath("bash"); err == nil {
+		script := renderFeatureSnippet(configuredFeatureRegistry(), "bash") + "feature_on vault && ! feature_on gpg_tools && ! feature_on gpg-tools\n"
+		if out, err := exec.Command(bash, "-c", script).CombinedOutput(); err != nil {
+			t.Errorf("bash feature_on: %v %s", err, out)
+		}
+	}
+
+	path, err := writeFeatureSnippet("zsh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := configuredFeatureRegistry()
+	reg.Enable("gpg_tools")
+	if err := persistFeatureState(reg); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "BLACKDOT_FEATURE_GPG_TOOLS=1") {
+		t.Errorf("cached snippet not refreshed:\n%s", data)
+	}
+	if _, err := os.Stat(featureSnippetPath("pwsh")); !os.IsNotExist(err) {
+		t.Error("refresh should not create snippets no shell uses")
+	}
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/safefile"
+)
+
+// fileClass categorizes files blackdot writes so they share one permission
+// policy instead of ad-hoc 0644/0755 literals at each call site.
+type fileClass string
+
+const (
+	// fileClassConfig is user-editable settings with nothing sensitive
+	fileClassConfig fileClass = "config"
+	// fileClassPrivate is metadata that reveals what the user has: vault
+	// inventory, drift state (paths and checksums), metrics, backups
+	fileClassPrivate fileClass = "private"
+	// fileClassSecret is secret material restored from the vault
+	fileClassSecret fileClass = "secret"
+	// fileClassScript is executable hooks and loader scripts
+	fileClassScript fileClass = "script"
+)
+
+// filePolicy is the most permissive mode allowed for a class. The process
+// umask is applied on top, so a stricter umask always wins.
+type filePolicy struct {
+	File os.FileMode
+	Dir  os.FileMode
+}
+
+var filePolicies = map[fileClass]filePolicy{
+	fileClassConfig:  {File: 0644, Dir: 0755},
+	fileClassPrivate: {File: 0600, Dir: 0700},
+	fileClassSecret:  {File: 0600, Dir: 0700},
+	fileClassScript:  {File: 0700, Dir: 0700},
+}
+
+// filePermFor returns the file mode for a class after applying the umask
+func filePermFor(class fileClass) os.FileMode {
+	return filePolicies[class].File &^ currentUmask()
+}
+
+// dirPermFor returns the directory mode for a class after applying the umask
+func dirPermFor(class fileClass) os.FileMode {
+	return filePolicies[class].Dir &^ currentUmask()
+}
+
+// writeFileWithPolicy atomically writes data, creating parent directories,
+// with the file mode of the class policy
+func writeFileWithPolicy(path string, data []byte, class fileClass) error {
+	if err := os.MkdirAll(filepath.Dir(path), dirPermFor(class)); err != nil {
+		return err
+	}
+	perm := filePermFor(class)
+	if err := writeFileAtomic(path, data, perm); err != nil {
+		return err
+	}
+	return tightenPerm(path, perm)
+}
+
+// writeFileAtomic writes data to a temp file in the same directory and
+// renames it over path, so readers never see a partially written file.
+// Concurrent blackdot writers to path wait for each other.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return safefile.Write(path, data, perm)
+}
+
+// appendFileWithPolicy appends data to path, creating it under the class policy
+func appendFileWithPolicy(path string, data []byte, class fileClass) error {
+	if err := os.MkdirAll(filepath.Dir(path), dirPermFor(class)); err != nil {
+		return err
+	}
+	perm := filePermFor(class)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return tightenPerm(path, perm)
+}
+
+// tightenPerm removes any bits beyond perm; it never loosens a mode
+func tightenPerm(path string, perm os.FileMode) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if current := info.Mode().Perm(); current&^perm != 0 {
+		return os.Chmod(path, current&perm)
+	}
+	return nil
+}
+
+// permAuditEntry is a file doctor checks against its class policy
+type permAuditEntry struct {
+	label string
+	path  string
+	class fileClass
+	dir   bool
+}
+
+// permAuditEntries lists blackdot-managed files whose permissions matter
+func permAuditEntries(home string) []permAuditEntry {
+	return []permAuditEntry{
+		{"vault-items.json", filepath.Join(ConfigDir(), "vault-items.json"), fileClassPrivate, false},
+		{"vault drift state", getVaultDriftStatePath(), fileClassPrivate, false},
+		{"vault item index", getVaultIndexPath(), fileClassPrivate, false},
+		{"vault session", getSessionFile(), fileClassSecret, false},
+		{"metrics", filepath.Join(home, ".blackdot-metrics.jsonl"), fileClassPrivate, false},
+		{"env.secrets", filepath.Join(home, ".local", "env.secrets"), fileClassSecret, false},
+		{"backups", getBackupConfig().backupDir, fileClassPrivate, true},
+	}
+}
+
+// auditPermissions returns the entries whose mode grants more than their
+// class allows. Missing files are ignored.
+func auditPermissions(entries []permAuditEntry) []permAuditEntry {
+	var loose []permAuditEntry
+	for _, e := range entries {
+		info, err := os.Stat(e.path)
+		if err != nil || info.IsDir() != e.dir {
+			continue
+		}
+		allowed := filePolicies[e.class].File
+		if e.dir {
+			allowed = filePolicies[e.class].Dir
+		}
+		if info.Mode().Perm()&^allowed != 0 {
+			loose = append(loose, e)
+		}
+	}
+	return loose
+}
+
+// describePerm formats an audit finding for doctor output
+func describePerm(e permAuditEntry) string {
+	info, err := os.Stat(e.path)
+	if err != nil {
+		return e.label
+	}
+	allowed := filePolicies[e.class].File
+	if e.dir {
+		allowed = filePolicies[e.class].Dir
+	}
+	home, _ := os.UserHomeDir()
+	path := e.path
+	if home != "" && strings.HasPrefix(path, home) {
+		path = "~" + strings.TrimPrefix(path, home)
+	}
+	return fmt.Sprintf("%s (%s) has permissions %04o (should be %04o)", e.label, path, info.Mode().Perm(), allowed)
+}
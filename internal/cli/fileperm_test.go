@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestWriteFileWithPolicyTightens verifies existing files lose extra permission bits
+func TestWriteFileWithPolicyTightens(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX permissions not enforced on Windows")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "vault-items.json")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(path, 0644)
+
+	if err := writeFileWithPolicy(path, []byte(`{"vault_items":{}}`), fileClassPrivate); err != nil {
+		t.Fatalf("writeFileWithPolicy failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm&^0600 != 0 {
+		t.Errorf("expected at most 0600, got %04o", perm)
+	}
+}
+
+// TestAuditPermissions verifies loose files are reported and missing files ignored
+func TestAuditPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX permissions not enforced on Windows")
+	}
+
+	dir := t.TempDir()
+	loosePath := filepath.Join(dir, "metrics.jsonl")
+	tightPath := filepath.Join(dir, "state.json")
+	os.WriteFile(loosePath, []byte("x"), 0600)
+	os.WriteFile(tightPath, []byte("x"), 0600)
+	os.Chmod(loosePath, 0644)
+
+	entries := []permAuditEntry{
+		{"metrics", loosePath, fileClassPrivate, false},
+		{"state", tightPath, fileClassPrivate, false},
+		{"missing", filepath.Join(dir, "missing"), fileClassSecret, false},
+		{"config", loosePath, fileClassConfig, false},
+	}
+
+	loose := auditPermissions(entries)
+	if len(loose) != 1 || loose[0].label != "metrics" {
+		t.Errorf("expected only metrics to be flagged, got %v", loose)
+	}
+}
+
+// TestWriteFileAtomic verifies replacement, mode, and no leftover temp files
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials")
+	os.WriteFile(path, []byte("old"), 0644)
+
+	if err := writeFileAtomic(path, []byte("new"), 0600); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("content = %q", data)
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("mode = %o", info.Mode().Perm())
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temp file left behind: %v", entries)
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "file"), []byte("x"), 0600); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package cli
+
+import (
+	"os"
+	"sync"
+	"syscall"
+)
+
+var (
+	umaskOnce sync.Once
+	umask     os.FileMode
+)
+
+// Read the umask at startup, before any goroutine creates files
+func init() { currentUmask() }
+
+// currentUmask returns the process umask. syscall.Umask can only be read
+// by setting it, and a file created while it is 0 would get the wrong
+// mode, so it is read once and cached.
+func currentUmask() os.FileMode {
+	umaskOnce.Do(func() {
+		mask := syscall.Umask(0)
+		syscall.Umask(mask)
+		umask = os.FileMode(mask)
+	})
+	return umask
+}
+
+// fileOwnerUID returns the uid that owns info
+func fileOwnerUID(info os.FileInfo) (int, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(st.Uid), true
+}
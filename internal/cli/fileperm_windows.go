@@ -0,0 +1,15 @@
+//go:build windows
+
+package cli
+
+import "os"
+
+// currentUmask returns 0 on Windows, where POSIX mode bits are advisory
+func currentUmask() os.FileMode {
+	return 0
+}
+
+// fileOwnerUID reports no owner on Windows, which has no uids
+func fileOwnerUID(info os.FileInfo) (int, bool) {
+	return 0, false
+}
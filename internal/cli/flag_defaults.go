@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// flagDefaultsPrefix is the config section holding per-command flag
+// defaults: defaults.<command path>.<flag>, e.g. defaults.doctor.quick
+const flagDefaultsPrefix = "defaults"
+
+// flagDefaultKey is the config key for a flag of cmd
+func flagDefaultKey(cmd *cobra.Command, flag string) string {
+	path := strings.Fields(cmd.CommandPath())
+	// Drop the root command name
+	return strings.Join(append([]string{flagDefaultsPrefix}, append(path[1:], flag)...), ".")
+}
+
+// applyFlagDefaults sets each flag not given on the command line to its
+// configured default. Runs before every command. Project files are
+// skipped: a cloned repository must not be able to turn on flags like
+// --force for the commands you run.
+func applyFlagDefaults(cmd *cobra.Command) error {
+	var errs []string
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed || f.Name == "help" {
+			return
+		}
+		key := flagDefaultKey(cmd, f.Name)
+		val, layer := trustedConfigLookup(key)
+		if val == "" {
+			return
+		}
+		if err := f.Value.Set(flagDefaultValue(f, val)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s (%s layer): invalid value %q for --%s: %v", key, layer, val, f.Name, err))
+		}
+	})
+	if len(errs) > 0 {
+		return fmt.Errorf("bad command default in config:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// flagDefaultValue converts a JSON array into the comma-separated form
+// slice flags accept
+func flagDefaultValue(f *pflag.Flag, val string) string {
+	if !strings.HasSuffix(f.Value.Type(), "Slice") && !strings.HasSuffix(f.Value.Type(), "Array") {
+		return val
+	}
+	var items []string
+	if json.Unmarshal([]byte(val), &items) == nil {
+		return strings.Join(items, ",")
+	}
+	return val
+}
+
+// configuredFlagDefault is one defaults.* entry found in config
+type configuredFlagDefault struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Layer   string `json:"layer"`
+	Command string `json:"command,omitempty"`
+	Problem string `json:"problem,omitempty"`
+}
+
+// collectFlagDefaults lists the defaults set in the machine and user
+// layers, checking each against the command tree
+func collectFlagDefaults(root *cobra.Command) []configuredFlagDefault {
+	seen := make(map[string]bool)
+	var out []configuredFlagDefault
+	for _, layer := range []struct{ name, path string }{{"machine", configLayerMachine}, {"user", configLayerUser}} {
+		data, err := os.ReadFile(layer.path)
+		if err != nil {
+			continue
+		}
+		var obj map[string]interface{}
+		if json.Unmarshal(data, &obj) != nil {
+			continue
+		}
+		section, ok := obj[flagDefaultsPrefix].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key := range flattenConfig(section, flagDefaultsPrefix) {
+			if seen[key] {
+				continue // shadowed by the machine layer
+			}
+			seen[key] = true
+			val, from := trustedConfigLookup(key)
+			entry := configuredFlagDefault{Key: key, Value: val, Layer: from}
+			entry.Command, entry.Problem = resolveFlagDefault(root, key, val)
+			out = append(out, entry)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// resolveFlagDefault finds the command and flag a defaults key names and
+// reports why it would not apply
+func resolveFlagDefault(root *cobra.Command, key, val string) (string, string) {
+	parts := strings.Split(strings.TrimPrefix(key, flagDefaultsPrefix+"."), ".")
+	if len(parts) < 2 {
+		return "", "expected defaults.<command>.<flag>"
+	}
+	cmd, rest, err := root.Find(parts[:len(parts)-1])
+	if err != nil || len(rest) > 0 || cmd == root {
+		return "", "unknown command " + strings.Join(parts[:len(parts)-1], " ")
+	}
+	// Find resolves aliases; the key must use the canonical path
+	if flagDefaultKey(cmd, parts[len(parts)-1]) != key {
+		return cmd.CommandPath(), "use " + flagDefaultKey(cmd, parts[len(parts)-1])
+	}
+	f := cmd.Flags().Lookup(parts[len(parts)-1])
+	if f == nil {
+		return cmd.CommandPath(), "unknown flag --" + parts[len(parts)-1]
+	}
+	if err := checkFlagValue(f, flagDefaultValue(f, val)); err != nil {
+		return cmd.CommandPath(), fmt.Sprintf("invalid value for --%s: %v", f.Name, err)
+	}
+	return cmd.CommandPath(), ""
+}
+
+// checkFlagValue parses val as f's type without touching the flag
+func checkFlagValue(f *pflag.Flag, val string) error {
+	var err error
+	switch f.Value.Type() {
+	case "bool":
+		_, err = strconv.ParseBool(val)
+	case "int":
+		_, err = strconv.Atoi(val)
+	case "duration":
+		_, err = time.ParseDuration(val)
+	}
+	return err
+}
+
+func newConfigDefaultsCmd() *cobra.Command {
+	var jsonOutput bool
+	cmd := &cobra.Command{
+		Use:   "defaults",
+		Short: "Show per-command flag defaults set in config",
+		Long: `Show the flag defaults configured under the defaults section.
+
+A flag not given on the command line takes its value from
+defaults.<command path>.<flag>, resolved from the environment
+(BLACKDOT_DEFAULTS_...), machine.json, then config.json. Project files
+cannot set command defaults. Flags on the command line always win.
+
+  "defaults": {
+    "doctor": { "quick": true },
+    "vault": { "restore": { "force": false } },
+    "template": { "render": { "dry-run": false } }
+  }
+
+Examples:
+  blackdot config set machine defaults.doctor.quick true
+  blackdot config defaults`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return configDefaults(cmd.Root(), jsonOutput)
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	return cmd
+}
+
+func configDefaults(root *cobra.Command, asJSON bool) error {
+	defaults := collectFlagDefaults(root)
+	if asJSON {
+		if defaults == nil {
+			defaults = []configuredFlagDefault{}
+		}
+		data, _ := json.MarshalIndent(defaults, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	PrintHeader("Command Defaults")
+	if len(defaults) == 0 {
+		Dim.Println("  No command defaults configured")
+		fmt.Println()
+		Info("Example: blackdot config set user defaults.doctor.quick true")
+		return nil
+	}
+	problems := 0
+	for _, d := range defaults {
+		mark := Green.Sprint("✓")
+		if d.Problem != "" {
+			mark = Yellow.Sprint("!")
+			problems++
+		}
+		fmt.Printf("  %s %s = %s %s\n", mark, d.Key, Bold.Sprint(d.Value), Dim.Sprintf("(%s)", d.Layer))
+		if d.Problem != "" {
+			Yellow.Printf("      %s\n", d.Problem)
+		}
+	}
+	fmt.Println()
+	if problems > 0 {
+		Warn("%d default(s) will not apply", problems)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// newFlagDefaultsTestRoot builds a small command tree with a few flag types
+func newFlagDefaultsTestRoot(got *struct {
+	quick bool
+	limit int
+	tags  []string
+}) *cobra.Command {
+	root := &cobra.Command{Use: "blackdot", PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return applyFlagDefaults(cmd)
+	}}
+	vault := &cobra.Command{Use: "vault"}
+	restore := &cobra.Command{Use: "restore", Aliases: []string{"pull"}, RunE: func(cmd *cobra.Command, args []string) error { return nil }}
+	restore.Flags().BoolVar(&got.quick, "quick", false, "")
+	restore.Flags().IntVarP(&got.limit, "limit", "n", 5, "")
+	restore.Flags().StringSliceVar(&got.tags, "tags", nil, "")
+	vault.AddCommand(restore)
+	root.AddCommand(vault)
+	return root
+}
+
+// TestApplyFlagDefaults verifies config defaults fill unset flags, the
+// command line wins, machine beats user, and project files are ignored
+func TestApplyFlagDefaults(t *testing.T) {
+	setScheduleEnv(t, `{"defaults": {"vault": {"restore": {"quick": true, "limit": 9, "tags": ["a", "b"]}}}}`)
+	os.WriteFile(configLayerMachine, []byte(`{"defaults": {"vault": {"restore": {"limit": 7}}}}`), 0600)
+	repo := t.TempDir()
+	os.MkdirAll(filepath.Join(repo, ".git"), 0755)
+	os.WriteFile(filepath.Join(repo, ".blackdot.json"), []byte(`{"defaults": {"vault": {"restore": {"limit": 1}}}}`), 0644)
+	t.Chdir(repo)
+
+	var got struct {
+		quick bool
+		limit int
+		tags  []string
+	}
+	root := newFlagDefaultsTestRoot(&got)
+	root.SetArgs([]string{"vault", "restore"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !got.quick || got.limit != 7 || len(got.tags) != 2 {
+		t.Errorf("defaults not applied: %+v", got)
+	}
+
+	root = newFlagDefaultsTestRoot(&got)
+	root.SetArgs([]string{"vault", "pull", "--quick=false", "-n", "3"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if got.quick || got.limit != 3 {
+		t.Errorf("command line should win: %+v", got)
+	}
+
+	t.Setenv("BLACKDOT_DEFAULTS_VAULT_RESTORE_LIMIT", "lots")
+	root = newFlagDefaultsTestRoot(&got)
+	root.SetArgs([]string{"vault", "restore"})
+	root.SilenceErrors, root.SilenceUsage = true, true
+	if err := root.Execute(); err == nil {
+		t.Error("invalid default should fail the command")
+	}
+}
+
+// TestCollectFlagDefaults verifies configured defaults are checked against
+// the command tree
+func TestCollectFlagDefaults(t *testing.T) {
+	setScheduleEnv(t, `{"defaults": {
+		"vault": {"restore": {"quick": "maybe", "limit": 2, "nope": true}, "pull": {"limit": 1}},
+		"missing": {"flag": true}
+	}}`)
+	var got struct {
+		quick bool
+		limit int
+		tags  []string
+	}
+	problems := make(map[string]string)
+	for _, d := range collectFlagDefaults(newFlagDefaultsTestRoot(&got)) {
+		problems[d.Key] = d.Problem
+	}
+	want := map[string]string{
+		"defaults.vault.restore.quick": "invalid value for --quick: strconv.ParseBool: parsing \"maybe\": invalid syntax",
+		"defaults.vault.restore.limit": "",
+		"defaults.vault.restore.nope":  "unknown flag --nope",
+		"defaults.vault.pull.limit":    "use defaults.vault.restore.limit",
+		"defaults.missing.flag":        "unknown command missing",
+	}
+	for key, problem := range want {
+		if problems[key] != problem {
+			t.Errorf("%s: problem = %q, want %q", key, problems[key], problem)
+		}
+	}
+}
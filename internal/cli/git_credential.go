@@ -0,0 +1,374 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/spf13/cobra"
+)
+
+// gitCredentialPrefix is the vault item prefix for git credentials.
+// Items are named Git-Credential-<host> (e.g. Git-Credential-github.com),
+// optionally suffixed with /<path> when credential.useHttpPath is enabled.
+const gitCredentialPrefix = "Git-Credential-"
+
+func newGitCredentialCmd() *cobra.Command {
+	var allowStore bool
+	var allowErase bool
+	var replace bool
+
+	cmd := &cobra.Command{
+		Use:   "git-credential <get|store|erase|install>",
+		Short: "Git credential helper backed by the vault",
+		Long: `Git credential helper backed by the vault.
+
+Implements the git credential helper protocol so HTTPS git authentication
+reads tokens from the vault instead of plaintext gitconfig or OS-specific
+helpers. The same helper config works on every platform.
+
+Vault items are looked up by host:
+  Git-Credential-<host>           e.g. Git-Credential-github.com
+  Git-Credential-<host>/<path>    when credential.useHttpPath is true
+
+Item content is either a bare token (used as the password) or
+key=value lines:
+  username=octocat
+  password=ghp_xxxxxxxxxxxx
+
+By default the helper is read-only. Pass --allow-store to save credentials
+git obtained elsewhere, and --allow-erase to delete items git rejects.
+
+Setup:
+  blackdot git-credential install
+  # asks before removing other global helpers, which git would ask
+  # first; --replace removes them without asking
+  # or manually:
+  git config --global credential.helper '!blackdot git-credential'`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"get", "store", "erase", "install"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch args[0] {
+			case "get":
+				return gitCredentialGet(os.Stdin, os.Stdout)
+			case "store":
+				if !allowStore {
+					return nil
+				}
+				return gitCredentialStore(os.Stdin)
+			case "erase":
+				if !allowErase {
+					return nil
+				}
+				return gitCredentialErase(os.Stdin)
+			case "install":
+				return gitCredentialInstall(allowStore, allowErase, replace)
+			}
+			// Per the protocol, unknown operations are silently ignored
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&allowStore, "allow-store", false, "Save credentials to the vault on 'store'")
+	cmd.Flags().BoolVar(&allowErase, "allow-erase", false, "Delete vault items on 'erase'")
+	cmd.Flags().BoolVar(&replace, "replace", false, "Remove other global credential helpers on 'install' without asking")
+
+	return cmd
+}
+
+// parseGitCredentialInput reads key=value attributes until a blank line or EOF
+func parseGitCredentialInput(r io.Reader) (map[string]string, error) {
+	attrs := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		attrs[key] = value
+	}
+	return attrs, scanner.Err()
+}
+
+// gitCredentialItemNames returns candidate vault item names, most specific first
+func gitCredentialItemNames(attrs map[string]string) []string {
+	host := attrs["host"]
+	if host == "" {
+		return nil
+	}
+
+	var names []string
+	if path := strings.Trim(attrs["path"], "/"); path != "" {
+		names = append(names, gitCredentialPrefix+host+"/"+path)
+	}
+	names = append(names, gitCredentialPrefix+host)
+	return names
+}
+
+// parseGitCredentialItem extracts username/password from vault item content.
+// A single line without '=' is treated as a bare token.
+func parseGitCredentialItem(content string) map[string]string {
+	result := make(map[string]string)
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return result
+	}
+
+	lines := strings.Split(content, "\n")
+	if len(lines) == 1 && !strings.Contains(lines[0], "=") {
+		result["password"] = strings.TrimSpace(lines[0])
+		return result
+	}
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		result[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return result
+}
+
+// formatGitCredentialItem serializes credentials for storage in the vault
+func formatGitCredentialItem(attrs map[string]string) string {
+	var b strings.Builder
+	for _, key := range []string{"username", "password"} {
+		if v := attrs[key]; v != "" {
+			fmt.Fprintf(&b, "%s=%s\n", key, v)
+		}
+	}
+	return b.String()
+}
+
+// writeGitCredentialOutput writes attributes in protocol format
+func writeGitCredentialOutput(w io.Writer, attrs map[string]string) {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s=%s\n", k, attrs[k])
+	}
+}
+
+// openGitCredentialBackend connects to the vault without interactive output.
+// Git expects helpers to stay quiet on stdout, so all diagnostics go to stderr.
+func openGitCredentialBackend(ctx context.Context) (vaultmux.Backend, vaultmux.Session, error) {
+	if isOfflineMode() {
+		return nil, nil, errors.New("offline mode enabled")
+	}
+
+	backend, err := newVaultBackend()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := backend.Init(ctx); err != nil {
+		backend.Close()
+		return nil, nil, err
+	}
+
+	session, err := backend.Authenticate(ctx)
+	if err != nil {
+		backend.Close()
+		return nil, nil, err
+	}
+
+	return backend, session, nil
+}
+
+func gitCredentialGet(in io.Reader, out io.Writer) error {
+	attrs, err := parseGitCredentialInput(in)
+	if err != nil {
+		return err
+	}
+
+	names := gitCredentialItemNames(attrs)
+	if len(names) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	backend, session, err := openGitCredentialBackend(ctx)
+	if err != nil {
+		// Let git fall through to the next helper or prompt
+		Debug("git-credential: vault unavailable: %v", err)
+		return nil
+	}
+	defer backend.Close()
+
+	for _, name := range names {
+		notes, err := backend.GetNotes(ctx, name, session)
+		if err != nil {
+			if !errors.Is(err, vaultmux.ErrNotFound) {
+				Debug("git-credential: %s: %v", name, err)
+			}
+			continue
+		}
+
+		creds := parseGitCredentialItem(notes)
+		if creds["password"] == "" {
+			continue
+		}
+		if creds["username"] == "" && attrs["username"] != "" {
+			creds["username"] = attrs["username"]
+		}
+
+		Debug("git-credential: served %s", name)
+		writeGitCredentialOutput(out, creds)
+		return nil
+	}
+
+	return nil
+}
+
+func gitCredentialStore(in io.Reader) error {
+	attrs, err := parseGitCredentialInput(in)
+	if err != nil {
+		return err
+	}
+
+	names := gitCredentialItemNames(attrs)
+	if len(names) == 0 || attrs["password"] == "" {
+		return nil
+	}
+	// Store under the most specific name git gave us
+	name := names[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	backend, session, err := openGitCredentialBackend(ctx)
+	if err != nil {
+		return nil
+	}
+	defer backend.Close()
+
+	content := formatGitCredentialItem(attrs)
+	existing, err := backend.GetNotes(ctx, name, session)
+	switch {
+	case errors.Is(err, vaultmux.ErrNotFound):
+		return backend.CreateItem(ctx, name, content, session)
+	case err != nil:
+		return err
+	case existing == content:
+		return nil
+	default:
+		return backend.UpdateItem(ctx, name, content, session)
+	}
+}
+
+func gitCredentialErase(in io.Reader) error {
+	attrs, err := parseGitCredentialInput(in)
+	if err != nil {
+		return err
+	}
+
+	names := gitCredentialItemNames(attrs)
+	if len(names) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	backend, session, err := openGitCredentialBackend(ctx)
+	if err != nil {
+		return nil
+	}
+	defer backend.Close()
+
+	for _, name := range names {
+		if err := backend.DeleteItem(ctx, name, session); err == nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// gitCredentialInstall registers blackdot as a global git credential
+// helper. Helpers the user already has are only removed with --replace or
+// after asking; otherwise blackdot is added after them.
+func gitCredentialInstall(allowStore, allowErase, replace bool) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		Fail("git not found in PATH")
+		return err
+	}
+
+	helper := "!blackdot git-credential"
+	if allowStore {
+		helper += " --allow-store"
+	}
+	if allowErase {
+		helper += " --allow-erase"
+	}
+
+	// A previous install is replaced, whatever its flags
+	exec.Command("git", "config", "--global", "--unset-all", "credential.helper", "^!blackdot git-credential").Run()
+
+	others := gitCredentialHelpers()
+	if len(others) > 0 {
+		Info("Other credential helpers are configured:")
+		for _, h := range others {
+			fmt.Printf("  %s\n", h)
+		}
+		if !replace && stdinIsTerminal() {
+			replace = Confirm("Remove them so blackdot is asked first?")
+		}
+		if replace {
+			if err := exec.Command("git", "config", "--global", "--unset-all", "credential.helper").Run(); err != nil {
+				Fail("Failed to remove credential helpers: %v", err)
+				return err
+			}
+		} else {
+			Warn("Keeping them; git asks them before blackdot (use --replace to remove them)")
+		}
+	}
+
+	if err := exec.Command("git", "config", "--global", "--add", "credential.helper", helper).Run(); err != nil {
+		Fail("Failed to set credential.helper: %v", err)
+		return err
+	}
+
+	Pass("Git credential helper installed: %s", helper)
+	fmt.Println()
+	fmt.Println("Create a vault item per host, for example:")
+	fmt.Printf("  blackdot vault create %sgithub.com \"ghp_xxxxxxxxxxxx\"\n", gitCredentialPrefix)
+	return nil
+}
+
+// gitCredentialHelpers lists the global credential helpers other than
+// blackdot's
+func gitCredentialHelpers() []string {
+	out, err := exec.Command("git", "config", "--global", "--get-all", "credential.helper").Output()
+	if err != nil {
+		return nil
+	}
+	var helpers []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line != "" && !strings.HasPrefix(line, "!blackdot git-credential") {
+			helpers = append(helpers, line)
+		}
+	}
+	return helpers
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseGitCredentialInput verifies protocol attribute parsing
+func TestParseGitCredentialInput(t *testing.T) {
+	input := "protocol=https\nhost=github.com\npath=org/repo.git\n\nignored=true\n"
+
+	attrs, err := parseGitCredentialInput(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if attrs["host"] != "github.com" {
+		t.Errorf("expected host 'github.com', got '%s'", attrs["host"])
+	}
+	if attrs["path"] != "org/repo.git" {
+		t.Errorf("expected path 'org/repo.git', got '%s'", attrs["path"])
+	}
+	if _, ok := attrs["ignored"]; ok {
+		t.Error("attributes after blank line should be ignored")
+	}
+}
+
+// TestGitCredentialItemNames verifies lookup order
+func TestGitCredentialItemNames(t *testing.T) {
+	names := gitCredentialItemNames(map[string]string{"host": "github.com", "path": "/org/repo.git"})
+	expected := []string{"Git-Credential-github.com/org/repo.git", "Git-Credential-github.com"}
+
+	if len(names) != len(expected) {
+		t.Fatalf("expected %d names, got %d: %v", len(expected), len(names), names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("name[%d]: expected '%s', got '%s'", i, expected[i], names[i])
+		}
+	}
+
+	if names := gitCredentialItemNames(map[string]string{}); names != nil {
+		t.Errorf("expected no names without host, got %v", names)
+	}
+}
+
+// TestParseGitCredentialItem verifies vault item content formats
+func TestParseGitCredentialItem(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		username string
+		password string
+	}{
+		{"bare token", "ghp_abc123\n", "", "ghp_abc123"},
+		{"key value", "username=octocat\npassword=secret\n", "octocat", "secret"},
+		{"comments", "# work account\nusername = me\npassword = p=w\n", "me", "p=w"},
+		{"empty", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds := parseGitCredentialItem(tt.content)
+			if creds["username"] != tt.username {
+				t.Errorf("expected username '%s', got '%s'", tt.username, creds["username"])
+			}
+			if creds["password"] != tt.password {
+				t.Errorf("expected password '%s', got '%s'", tt.password, creds["password"])
+			}
+		})
+	}
+}
+
+// TestGitCredentialRoundTrip verifies stored content parses back
+func TestGitCredentialRoundTrip(t *testing.T) {
+	content := formatGitCredentialItem(map[string]string{
+		"host":     "github.com",
+		"username": "octocat",
+		"password": "secret",
+	})
+
+	creds := parseGitCredentialItem(content)
+	if creds["username"] != "octocat" || creds["password"] != "secret" {
+		t.Errorf("round trip failed: %v", creds)
+	}
+	if _, ok := creds["host"]; ok {
+		t.Error("host should not be stored in item content")
+	}
+
+	var buf bytes.Buffer
+	writeGitCredentialOutput(&buf, creds)
+	if buf.String() != "password=secret\nusername=octocat\n" {
+		t.Errorf("unexpected protocol output: %q", buf.String())
+	}
+}
+
+// TestGitCredentialInstallKeepsHelpers verifies install adds blackdot after
+// existing helpers unless asked to replace them
+func TestGitCredentialInstallKeepsHelpers(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	helpers := func() []string {
+		out, _ := exec.Command("git", "config", "--global", "--get-all", "credential.helper").Output()
+		return strings.Fields(strings.ReplaceAll(string(out), " ", "_"))
+	}
+	exec.Command("git", "config", "--global", "credential.helper", "osxkeychain").Run()
+
+	if err := gitCredentialInstall(false, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := gitCredentialInstall(true, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := helpers(), []string{"osxkeychain", "!blackdot_git-credential_--allow-store"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("helpers = %v, want %v", got, want)
+	}
+
+	if err := gitCredentialInstall(false, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := helpers(), []string{"!blackdot_git-credential"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("helpers after --replace = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,754 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/scanners"
+	"github.com/spf13/cobra"
+)
+
+// githookMarker identifies hook scripts installed by blackdot
+const githookMarker = "# blackdot-githook"
+
+// githooksMaxDepth is how deep 'githooks status' and '--workspace' look for
+// repositories under the workspace directory
+const githooksMaxDepth = 3
+
+// Hook states of a repository
+const (
+	githookGlobal     = "global"     // core.hooksPath points at the template directory
+	githookInstalled  = "installed"  // every hook copied into .git/hooks
+	githookPartial    = "partial"    // some hooks copied
+	githookOverridden = "overridden" // the repo sets its own core.hooksPath
+	githookNone       = "none"
+)
+
+// githooksTemplateDir is the directory of hook scripts: $BLACKDOT_DIR/githooks
+func githooksTemplateDir() string {
+	if dir := os.Getenv("BLACKDOT_DIR"); dir != "" {
+		return filepath.Join(dir, "githooks")
+	}
+	return filepath.Join(BlackdotDir(), "githooks")
+}
+
+// githookNames returns the hooks in the template directory
+func githookNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".") && !strings.Contains(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func newGithooksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "githooks",
+		Short: "Manage git hooks for secret scanning and template checks",
+		Long: `Install the blackdot git hooks into your repositories.
+
+The hooks live in $BLACKDOT_DIR/githooks. Each hook runs the blackdot
+check for it and then the repository's own hook, if it has one:
+
+  pre-commit   Block commits whose staged files contain credentials;
+               warn when staged templates leave generated configs stale
+  pre-push     Block pushes of commits that add credentials
+  post-merge   Warn when a pull left rendered templates stale
+
+Lines marked "` + scanners.AllowMarker + `" are not reported. Disable the
+hooks everywhere with 'blackdot features disable git_hooks', or skip them
+once with git's --no-verify.
+
+Examples:
+  blackdot githooks install                  # set global core.hooksPath
+  blackdot githooks install --workspace      # copy into every workspace repo
+  blackdot githooks install --repo ~/code/app
+  blackdot githooks status`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(
+		newGithooksInstallCmd(),
+		newGithooksUninstallCmd(),
+		newGithooksStatusCmd(),
+		newGithooksRunCmd(),
+	)
+	return cmd
+}
+
+// githooksTargets are the repositories selected by --repo and --workspace
+func githooksTargets(repos []string, workspace bool) ([]string, error) {
+	var targets []string
+	for _, r := range repos {
+		top, err := gitToplevel(expandPath(r))
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a git repository", r)
+		}
+		targets = append(targets, top)
+	}
+	if workspace {
+		targets = append(targets, findWorkspaceRepos(workspaceTarget())...)
+	}
+	return targets, nil
+}
+
+func newGithooksInstallCmd() *cobra.Command {
+	var repos []string
+	var workspace, force, dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the git hooks globally or into repositories",
+		Long: `Install the git hooks.
+
+Without flags, the global core.hooksPath is pointed at the template
+directory so every repository uses the hooks. Repositories that set their
+own core.hooksPath (husky, lefthook) are not affected.
+
+With --repo or --workspace the hooks are copied into each repository's
+.git/hooks instead. A hook the repository already has is left alone unless
+--force is given; it is then kept as <hook>.local and still runs.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !initRegistry().Enabled("git_hooks") {
+				Warn("The git_hooks feature is disabled; installed hooks will do nothing")
+				PrintHint("blackdot features enable git_hooks")
+			}
+			if len(repos) == 0 && !workspace {
+				return githooksInstallGlobal(force, dryRun)
+			}
+			targets, err := githooksTargets(repos, workspace)
+			if err != nil {
+				Fail("%v", err)
+				return err
+			}
+			return githooksInstallRepos(targets, force, dryRun)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&repos, "repo", nil, "Copy hooks into this repository (repeatable)")
+	cmd.Flags().BoolVar(&workspace, "workspace", false, "Copy hooks into every repository under the workspace directory")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Replace existing hooks or a global core.hooksPath")
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would change")
+	return cmd
+}
+
+func newGithooksUninstallCmd() *cobra.Command {
+	var repos []string
+	var workspace bool
+
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the git hooks",
+		Long: `Remove the git hooks.
+
+Without flags, the global core.hooksPath is unset if it points at the
+template directory. With --repo or --workspace the copied hooks are
+removed and any <hook>.local kept by install --force is put back.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(repos) == 0 && !workspace {
+				return githooksUninstallGlobal()
+			}
+			targets, err := githooksTargets(repos, workspace)
+			if err != nil {
+				Fail("%v", err)
+				return err
+			}
+			for _, repo := range targets {
+				removed, err := uninstallRepoGithooks(repo)
+				if err != nil {
+					Fail("%s: %v", tildePath(repo), err)
+					return err
+				}
+				if removed > 0 {
+					Pass("%s: removed %d hook(s)", tildePath(repo), removed)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&repos, "repo", nil, "Remove hooks from this repository (repeatable)")
+	cmd.Flags().BoolVar(&workspace, "workspace", false, "Remove hooks from every repository under the workspace directory")
+	return cmd
+}
+
+func newGithooksStatusCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show which workspace repositories run the hooks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return githooksStatus(jsonOutput)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	return cmd
+}
+
+func newGithooksRunCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "run <hook> [args...]",
+		Short:  "Run the checks for a git hook (called by the hook scripts)",
+		Hidden: true,
+		Args:   cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !initRegistry().Enabled("git_hooks") {
+				return nil
+			}
+			cmd.SilenceUsage = true
+			switch args[0] {
+			case "pre-commit":
+				return githookPreCommit()
+			case "pre-push":
+				return githookPrePush(os.Stdin)
+			case "post-merge":
+				githookPostMerge()
+			default:
+				Debug("No checks for git hook %s", args[0])
+			}
+			return nil
+		},
+	}
+}
+
+func githooksInstallGlobal(force, dryRun bool) error {
+	dir := githooksTemplateDir()
+	if _, err := githookNames(dir); err != nil {
+		Fail("Hook templates not found in %s", tildePath(dir))
+		return err
+	}
+	current := gitConfigGet("", "--global", "core.hooksPath")
+	if current != "" && sameDir(expandPath(current), dir) {
+		Pass("Global core.hooksPath already set to %s", tildePath(dir))
+		return nil
+	}
+	if current != "" && !force {
+		Fail("Global core.hooksPath is already %s", current)
+		Info("Use --force to replace it, or --workspace to copy hooks into repositories")
+		return errors.New("core.hooksPath already set")
+	}
+	if dryRun {
+		fmt.Printf("%s git config --global core.hooksPath %s\n", Cyan.Sprint("[dry-run]"), dir)
+		return nil
+	}
+	if err := exec.Command("git", "config", "--global", "core.hooksPath", dir).Run(); err != nil {
+		Fail("Failed to set core.hooksPath: %v", err)
+		return err
+	}
+	Pass("Global core.hooksPath set to %s", tildePath(dir))
+	Dim.Println("  Repositories with their own .git/hooks still run them after the blackdot checks")
+	return nil
+}
+
+func githooksUninstallGlobal() error {
+	current := gitConfigGet("", "--global", "core.hooksPath")
+	if current == "" {
+		Info("Global core.hooksPath is not set")
+		return nil
+	}
+	if !sameDir(expandPath(current), githooksTemplateDir()) {
+		Info("Global core.hooksPath is %s, not the blackdot hooks; leaving it", current)
+		return nil
+	}
+	if err := exec.Command("git", "config", "--global", "--unset", "core.hooksPath").Run(); err != nil {
+		Fail("Failed to unset core.hooksPath: %v", err)
+		return err
+	}
+	Pass("Global core.hooksPath unset")
+	return nil
+}
+
+func githooksInstallRepos(repos []string, force, dryRun bool) error {
+	src := githooksTemplateDir()
+	names, err := githookNames(src)
+	if err != nil {
+		Fail("Hook templates not found in %s", tildePath(src))
+		return err
+	}
+	if len(repos) == 0 {
+		Info("No repositories found")
+		return nil
+	}
+
+	var failed int
+	for _, repo := range repos {
+		installed, skipped, err := installRepoGithooks(repo, src, names, force, dryRun)
+		switch {
+		case err != nil:
+			Fail("%s: %v", tildePath(repo), err)
+			failed++
+		case dryRun:
+			fmt.Printf("%s %s: install %s\n", Cyan.Sprint("[dry-run]"), tildePath(repo), strings.Join(installed, ", "))
+		case len(installed) > 0:
+			Pass("%s: %s", tildePath(repo), strings.Join(installed, ", "))
+		}
+		for _, name := range skipped {
+			Warn("%s: has its own %s hook (use --force to keep it as %s.local)", tildePath(repo), name, name)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d repositories failed", failed)
+	}
+	return nil
+}
+
+// installRepoGithooks copies the hooks into a repository's hooks directory.
+// Foreign hooks are skipped, or with force moved to <hook>.local.
+func installRepoGithooks(repo, src string, names []string, force, dryRun bool) (installed, skipped []string, err error) {
+	hooksDir, err := gitHooksDir(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range names {
+		dst := filepath.Join(hooksDir, name)
+		if existing, err := os.ReadFile(dst); err == nil && !isBlackdotGithook(existing) {
+			if !force {
+				skipped = append(skipped, name)
+				continue
+			}
+			if !dryRun {
+				if err := os.Rename(dst, dst+".local"); err != nil {
+					return installed, skipped, err
+				}
+			}
+		}
+		installed = append(installed, name)
+		if dryRun {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(src, name))
+		if err != nil {
+			return installed, skipped, err
+		}
+		if err := os.MkdirAll(hooksDir, 0755); err != nil {
+			return installed, skipped, err
+		}
+		if err := writeFileAtomic(dst, data, 0755); err != nil {
+			return installed, skipped, err
+		}
+	}
+	return installed, skipped, nil
+}
+
+// uninstallRepoGithooks removes blackdot hooks from a repository and puts
+// back hooks moved aside by install --force
+func uninstallRepoGithooks(repo string) (int, error) {
+	hooksDir, err := gitHooksDir(repo)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := os.ReadDir(hooksDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, e := range entries {
+		path := filepath.Join(hooksDir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil || !isBlackdotGithook(data) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed++
+		if _, err := os.Stat(path + ".local"); err == nil {
+			if err := os.Rename(path+".local", path); err != nil {
+				return removed, err
+			}
+		}
+	}
+	return removed, nil
+}
+
+func isBlackdotGithook(data []byte) bool {
+	return bytes.Contains(data, []byte(githookMarker))
+}
+
+// githookRepoStatus is the hook state of one repository
+type githookRepoStatus struct {
+	Path      string `json:"path"`
+	State     string `json:"state"`
+	HooksPath string `json:"hooks_path,omitempty"`
+}
+
+// repoGithookState works out whether a repository runs the blackdot hooks
+func repoGithookState(repo, globalHooksPath string, names []string) githookRepoStatus {
+	st := githookRepoStatus{Path: repo, State: githookNone}
+	if local := gitConfigGet(repo, "--local", "core.hooksPath"); local != "" {
+		st.HooksPath = local
+		if sameDir(expandPath(local), githooksTemplateDir()) {
+			st.State = githookGlobal
+		} else {
+			st.State = githookOverridden
+		}
+		return st
+	}
+	if globalHooksPath != "" && sameDir(expandPath(globalHooksPath), githooksTemplateDir()) {
+		st.State = githookGlobal
+		return st
+	}
+
+	hooksDir, err := gitHooksDir(repo)
+	if err != nil {
+		return st
+	}
+	count := 0
+	for _, name := range names {
+		if data, err := os.ReadFile(filepath.Join(hooksDir, name)); err == nil && isBlackdotGithook(data) {
+			count++
+		}
+	}
+	switch {
+	case count > 0 && count == len(names):
+		st.State = githookInstalled
+	case count > 0:
+		st.State = githookPartial
+	}
+	return st
+}
+
+func githooksStatus(jsonOutput bool) error {
+	names, _ := githookNames(githooksTemplateDir())
+	global := gitConfigGet("", "--global", "core.hooksPath")
+	workspace := workspaceTarget()
+
+	var repos []githookRepoStatus
+	for _, repo := range findWorkspaceRepos(workspace) {
+		repos = append(repos, repoGithookState(repo, global, names))
+	}
+
+	if jsonOutput {
+		out := struct {
+			Enabled         bool                `json:"enabled"`
+			TemplateDir     string              `json:"template_dir"`
+			GlobalHooksPath string              `json:"global_hooks_path"`
+			Workspace       string              `json:"workspace"`
+			Repos           []githookRepoStatus `json:"repos"`
+		}{initRegistry().Enabled("git_hooks"), githooksTemplateDir(), global, workspace, repos}
+		if out.Repos == nil {
+			out.Repos = []githookRepoStatus{}
+		}
+		data, _ := json.MarshalIndent(out, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	PrintHeader("Git Hooks")
+	if !initRegistry().Enabled("git_hooks") {
+		Warn("git_hooks feature is disabled; hooks exit without checking")
+	}
+	fmt.Printf("  Templates: %s (%s)\n", tildePath(githooksTemplateDir()), strings.Join(names, ", "))
+	if global == "" {
+		fmt.Printf("  Global:    %s\n", Dim.Sprint("core.hooksPath not set"))
+	} else {
+		fmt.Printf("  Global:    core.hooksPath = %s\n", global)
+	}
+	fmt.Println()
+
+	if len(repos) == 0 {
+		Info("No repositories under %s", tildePath(workspace))
+		return nil
+	}
+	covered := 0
+	for _, r := range repos {
+		var state string
+		switch r.State {
+		case githookGlobal, githookInstalled:
+			covered++
+			state = Green.Sprint(r.State)
+		case githookPartial:
+			state = Yellow.Sprint(r.State)
+		case githookOverridden:
+			state = Yellow.Sprint(r.State) + Dim.Sprint(" (core.hooksPath "+r.HooksPath+")")
+		default:
+			state = Dim.Sprint(r.State)
+		}
+		fmt.Printf("  %-40s %s\n", tildePath(r.Path), state)
+	}
+	fmt.Println()
+	Info("%d of %d repositories run the hooks", covered, len(repos))
+	if covered < len(repos) {
+		PrintHint("blackdot githooks install --workspace")
+	}
+	return nil
+}
+
+// findWorkspaceRepos returns the git repositories under dir, looking
+// githooksMaxDepth levels down and not descending into repositories
+func findWorkspaceRepos(dir string) []string {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	var repos []string
+	var walk func(path string, depth int)
+	walk = func(path string, depth int) {
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			repos = append(repos, path)
+			return
+		}
+		if depth >= githooksMaxDepth {
+			return
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			if !e.IsDir() || strings.HasPrefix(e.Name(), ".") || e.Name() == "node_modules" {
+				continue
+			}
+			walk(filepath.Join(path, e.Name()), depth+1)
+		}
+	}
+	walk(dir, 0)
+	sort.Strings(repos)
+	return repos
+}
+
+// gitConfigGet returns a git config value from scope (--global, --local),
+// or "" when unset. repo is the repository to read local config from.
+func gitConfigGet(repo, scope, key string) string {
+	args := []string{"config", scope, "--get", key}
+	if repo != "" {
+		args = append([]string{"-C", repo}, args...)
+	}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// gitToplevel returns the root of the work tree containing dir
+func gitToplevel(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Clean(strings.TrimSpace(string(out))), nil
+}
+
+// gitHooksDir returns .git/hooks of a repository, shared by its worktrees
+func gitHooksDir(repo string) (string, error) {
+	out, err := exec.Command("git", "-C", repo, "rev-parse", "--git-common-dir").Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository")
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repo, dir)
+	}
+	return filepath.Join(dir, "hooks"), nil
+}
+
+// githookScanPolicy is githooks.scan: abort (default), warn, or off. Project
+// files cannot change it, so a repository cannot turn its own scan off.
+func githookScanPolicy() string {
+	val, _ := trustedConfigLookup("githooks.scan")
+	switch policy := strings.ToLower(val); policy {
+	case scanPolicyWarn, scanPolicyOff:
+		return policy
+	}
+	return scanPolicyAbort
+}
+
+// githookBlob is a file version to scan
+type githookBlob struct {
+	Path string
+	Rev  string // "" for the index
+}
+
+// scanGithookBlobs scans file versions and applies githooks.scan
+func scanGithookBlobs(blobs []githookBlob, action string) error {
+	policy := githookScanPolicy()
+	if policy == scanPolicyOff {
+		return nil
+	}
+	scanner := scanners.New()
+	var findings []scanners.Finding
+	for _, b := range blobs {
+		data, err := exec.Command("git", "show", b.Rev+":"+b.Path).Output()
+		if err != nil {
+			continue
+		}
+		findings = append(findings, scanner.Scan(b.Path, data)...)
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	Warn("%d potential credential(s) in this %s", len(findings), action)
+	printScanFindings(findings)
+	if policy == scanPolicyWarn {
+		return nil
+	}
+	Info("Move them to the vault, or add \"%s\" to a line that is a false positive", scanners.AllowMarker)
+	Dim.Printf("  To %s anyway: git %s --no-verify\n", action, action)
+	return fmt.Errorf("%s blocked: %d potential credential(s)", action, len(findings))
+}
+
+// githookPreCommit scans the staged files and warns about templates whose
+// generated output the commit will leave stale
+func githookPreCommit() error {
+	out, err := exec.Command("git", "diff", "--cached", "--name-only", "-z", "--diff-filter=ACM").Output()
+	if err != nil {
+		return err
+	}
+	var blobs []githookBlob
+	var staged []string
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path != "" {
+			blobs = append(blobs, githookBlob{Path: path})
+			staged = append(staged, path)
+		}
+	}
+	warnStagedTemplates(staged)
+	return scanGithookBlobs(blobs, "commit")
+}
+
+// warnStagedTemplates warns when commits to the blackdot repository change
+// templates, since their rendered configs are not updated by the commit
+func warnStagedTemplates(staged []string) {
+	if !inBlackdotRepo() {
+		return
+	}
+	var changed []string
+	for _, path := range staged {
+		if strings.HasPrefix(path, "templates/configs/") && strings.HasSuffix(path, ".tmpl") {
+			changed = append(changed, strings.TrimSuffix(filepath.Base(path), ".tmpl"))
+		}
+	}
+	if len(changed) > 0 {
+		Warn("Templates changed: %s; generated configs are stale until rendered", strings.Join(changed, ", "))
+		PrintHint("blackdot template render")
+	}
+}
+
+// githookPrePush scans the files added or changed by the commits being
+// pushed. refs is the "<local ref> <local sha> <remote ref> <remote sha>"
+// list git passes on stdin.
+func githookPrePush(refs io.Reader) error {
+	seen := make(map[string]bool)
+	var blobs []githookBlob
+	scanner := bufio.NewScanner(refs)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 || strings.Trim(fields[1], "0") == "" {
+			continue // malformed, or a branch deletion
+		}
+		local, remote := fields[1], fields[3]
+		rangeArgs := []string{"rev-list", local, "--not", "--remotes"}
+		if strings.Trim(remote, "0") != "" {
+			rangeArgs = []string{"rev-list", remote + ".." + local}
+		}
+		out, err := exec.Command("git", rangeArgs...).Output()
+		if err != nil {
+			continue
+		}
+		for _, commit := range strings.Fields(string(out)) {
+			blobs = append(blobs, commitBlobs(commit, seen)...)
+		}
+	}
+	return scanGithookBlobs(blobs, "push")
+}
+
+// commitBlobs returns the files a commit added or modified, skipping blobs
+// already in seen
+func commitBlobs(commit string, seen map[string]bool) []githookBlob {
+	out, err := exec.Command("git", "diff-tree", "-r", "-z", "--no-commit-id", "--root", "--diff-filter=ACM", commit).Output()
+	if err != nil {
+		return nil
+	}
+	// -z output alternates ":<modes> <old> <new> <status>" and path
+	var blobs []githookBlob
+	parts := strings.Split(string(out), "\x00")
+	for i := 0; i+1 < len(parts); i += 2 {
+		fields := strings.Fields(parts[i])
+		if len(fields) < 4 {
+			continue
+		}
+		sha := fields[3]
+		if seen[sha] {
+			continue
+		}
+		seen[sha] = true
+		blobs = append(blobs, githookBlob{Path: parts[i+1], Rev: commit})
+	}
+	return blobs
+}
+
+// githookPostMerge warns about stale rendered templates after a pull into
+// the blackdot repository
+func githookPostMerge() {
+	if !inBlackdotRepo() {
+		return
+	}
+	cfg, err := getTemplateConfig()
+	if err != nil {
+		return
+	}
+	files, err := templateFiles(cfg)
+	if err != nil {
+		return
+	}
+	var stale []string
+	for _, f := range files {
+		if templateOutputState(cfg, f) == templateStale {
+			stale = append(stale, strings.TrimSuffix(filepath.Base(f.Path), ".tmpl"))
+		}
+	}
+	if len(stale) > 0 {
+		Warn("Templates updated: %s; rendered configs are stale", strings.Join(stale, ", "))
+		PrintHint("blackdot template render")
+	}
+}
+
+// inBlackdotRepo reports whether the current directory is the blackdot repo
+func inBlackdotRepo() bool {
+	top, err := gitToplevel(".")
+	if err != nil {
+		return false
+	}
+	cfg, err := getTemplateConfig()
+	if err != nil {
+		return false
+	}
+	return sameDir(top, cfg.blackdotDir)
+}
+
+// sameDir compares directories after resolving symlinks
+func sameDir(a, b string) bool {
+	if ra, err := filepath.EvalSymlinks(a); err == nil {
+		a = ra
+	}
+	if rb, err := filepath.EvalSymlinks(b); err == nil {
+		b = rb
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupGithooks points BLACKDOT_DIR at a copy of the repo's hook templates,
+// isolates git config, and returns the workspace directory
+func setupGithooks(t *testing.T) string {
+	t.Helper()
+	setScheduleEnv(t, "")
+	home := os.Getenv("HOME")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+
+	dir := filepath.Join(home, ".blackdot")
+	t.Setenv("BLACKDOT_DIR", dir)
+	os.MkdirAll(filepath.Join(dir, "githooks"), 0755)
+	names, err := githookNames(filepath.Join("..", "..", "githooks"))
+	if err != nil || len(names) == 0 {
+		t.Fatalf("hook templates: %v", err)
+	}
+	for _, name := range names {
+		data, _ := os.ReadFile(filepath.Join("..", "..", "githooks", name))
+		os.WriteFile(filepath.Join(dir, "githooks", name), data, 0755)
+	}
+
+	workspace := filepath.Join(home, "workspace")
+	t.Setenv("WORKSPACE_TARGET", workspace)
+	return workspace
+}
+
+func gitInit(t *testing.T, dir string) {
+	t.Helper()
+	os.MkdirAll(dir, 0755)
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+}
+
+// TestGithooksInstallRepo verifies copied hooks keep a repository's own hook
+// as <hook>.local with --force, and uninstall puts it back
+func TestGithooksInstallRepo(t *testing.T) {
+	workspace := setupGithooks(t)
+	repo := filepath.Join(workspace, "app")
+	gitInit(t, repo)
+	own := filepath.Join(repo, ".git", "hooks", "pre-commit")
+	os.WriteFile(own, []byte("#!/bin/sh\necho mine\n"), 0755)
+
+	names, _ := githookNames(githooksTemplateDir())
+	installed, skipped, err := installRepoGithooks(repo, githooksTemplateDir(), names, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 || skipped[0] != "pre-commit" {
+		t.Errorf("skipped = %v, want [pre-commit]", skipped)
+	}
+	if got := repoGithookState(repo, "", names).State; got != githookPartial {
+		t.Errorf("state = %s, want %s", got, githookPartial)
+	}
+
+	if _, _, err := installRepoGithooks(repo, githooksTemplateDir(), names, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(own + ".local"); !strings.Contains(string(data), "mine") {
+		t.Error("own hook not kept as pre-commit.local")
+	}
+	if got := repoGithookState(repo, "", names).State; got != githookInstalled {
+		t.Errorf("state = %s, want %s (installed %v)", got, githookInstalled, installed)
+	}
+
+	if _, err := uninstallRepoGithooks(repo); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(own); !strings.Contains(string(data), "mine") {
+		t.Error("own hook not restored by uninstall")
+	}
+	if got := repoGithookState(repo, "", names).State; got != githookNone {
+		t.Errorf("state after uninstall = %s, want %s", got, githookNone)
+	}
+}
+
+// TestGithooksStatusStates verifies workspace discovery and the global and
+// overridden states
+func TestGithooksStatusStates(t *testing.T) {
+	workspace := setupGithooks(t)
+	gitInit(t, filepath.Join(workspace, "a"))
+	gitInit(t, filepath.Join(workspace, "org", "b"))
+	gitInit(t, filepath.Join(workspace, "a", "vendored")) // inside a repo, not listed
+
+	repos := findWorkspaceRepos(workspace)
+	if len(repos) != 2 || filepath.Base(repos[0]) != "a" || filepath.Base(repos[1]) != "b" {
+		t.Fatalf("repos = %v", repos)
+	}
+
+	names, _ := githookNames(githooksTemplateDir())
+	if got := repoGithookState(repos[0], githooksTemplateDir(), names).State; got != githookGlobal {
+		t.Errorf("state = %s, want %s", got, githookGlobal)
+	}
+	exec.Command("git", "-C", repos[1], "config", "core.hooksPath", ".husky").Run()
+	st := repoGithookState(repos[1], githooksTemplateDir(), names)
+	if st.State != githookOverridden || st.HooksPath != ".husky" {
+		t.Errorf("state = %+v, want overridden .husky", st)
+	}
+}
+
+// TestGithookPreCommit verifies staged credentials block the commit unless
+// githooks.scan is warn or the line carries the allow marker
+func TestGithookPreCommit(t *testing.T) {
+	workspace := setupGithooks(t)
+	repo := filepath.Join(workspace, "app")
+	gitInit(t, repo)
+	t.Chdir(repo)
+
+	key := "AKIA" + "Z7Q2M4X8K1L9P3R5"
+	os.WriteFile("config.env", []byte("AWS_ACCESS_KEY_ID="+key+"\n"), 0644)
+	os.WriteFile("clean.txt", []byte("nothing here\n"), 0644)
+	exec.Command("git", "add", ".").Run()
+
+	if err := githookPreCommit(); err == nil {
+		t.Error("staged AWS key did not block the commit")
+	}
+
+	t.Setenv("BLACKDOT_GITHOOKS_SCAN", "warn")
+	if err := githookPreCommit(); err != nil {
+		t.Errorf("githooks.scan=warn: %v", err)
+	}
+	t.Setenv("BLACKDOT_GITHOOKS_SCAN", "")
+
+	os.WriteFile("config.env", []byte("AWS_ACCESS_KEY_ID="+key+" # blackdot:allow\n"), 0644)
+	exec.Command("git", "add", ".").Run()
+	if err := githookPreCommit(); err != nil {
+		t.Errorf("allow marker: %v", err)
+	}
+}
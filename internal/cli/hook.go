@@ -4,11 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 	"time"
 
+	"github.com/blackwell-systems/blackdot/internal/prompts"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -75,6 +74,7 @@ var hookCategories = []struct {
 			Name string
 			Desc string
 		}{
+			{"pre_setup", "Before the setup wizard starts"},
 			{"pre_setup_phase", "Before each wizard phase"},
 			{"post_setup_phase", "After each wizard phase"},
 			{"setup_complete", "After all phases done"},
@@ -115,14 +115,15 @@ type HookEntry struct {
 	Script   string `json:"script,omitempty"`
 	Function string `json:"function,omitempty"`
 	Enabled  *bool  `json:"enabled,omitempty"`
-	FailOk   bool   `json:"fail_ok,omitempty"`
+	FailOk   bool   `json:"fail_ok,omitempty" yaml:"fail_ok"`
 }
 
 // HookSettings represents hook system settings
 type HookSettings struct {
-	FailFast bool `json:"fail_fast"`
-	Verbose  bool `json:"verbose"`
-	Timeout  int  `json:"timeout"`
+	FailFast bool              `json:"fail_fast"`
+	Verbose  bool              `json:"verbose"`
+	Timeout  int               `json:"timeout"`
+	Policies map[string]string `json:"policies,omitempty"` // point or alias -> abort|warn
 }
 
 func newHookCmd() *cobra.Command {
@@ -153,15 +154,21 @@ func newHookCmd() *cobra.Command {
 		},
 	}
 
+	var runItems, runPaths []string
+	var runTimeout time.Duration
 	runCmd := &cobra.Command{
 		Use:   "run <point> [args]",
 		Short: "Manually trigger hooks for a point",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			verbose, _ := cmd.Flags().GetBool("verbose")
-			return runHookRun(args, verbose)
+			return runHookRunWith(args, hookContext{Items: runItems, Paths: runPaths},
+				hookRunOptions{Verbose: verbose, Timeout: runTimeout})
 		},
 	}
 	runCmd.Flags().BoolP("verbose", "v", false, "Show detailed output")
+	runCmd.Flags().StringSliceVar(&runItems, "item", nil, "Item name to pass in BLACKDOT_HOOK_ITEMS (repeatable)")
+	runCmd.Flags().StringSliceVar(&runPaths, "path", nil, "Path to pass in BLACKDOT_HOOK_PATHS (repeatable)")
+	runCmd.Flags().DurationVar(&runTimeout, "timeout", 0, "Per-hook timeout (default: settings.timeout or 30s)")
 
 	cmd.AddCommand(
 		listCmd,
@@ -201,13 +208,17 @@ func newHookCmd() *cobra.Command {
 }
 
 func getHooksDir() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".config", "blackdot", "hooks")
+	if dir := os.Getenv("BLACKDOT_HOOKS_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(ConfigDir(), "hooks")
 }
 
 func getHooksConfigPath() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".config", "blackdot", "hooks.json")
+	if path := os.Getenv("BLACKDOT_HOOKS_CONFIG"); path != "" {
+		return path
+	}
+	return filepath.Join(ConfigDir(), "hooks.json")
 }
 
 func isValidHookPoint(point string) bool {
@@ -263,86 +274,53 @@ func runHookList(args []string) error {
 	yellow := color.New(color.FgYellow).SprintFunc()
 	dim := color.New(color.Faint).SprintFunc()
 
-	hooksDir := getHooksDir()
 	config, _ := loadHooksConfig()
 
 	if len(args) > 0 {
 		// List hooks for specific point
-		point := args[0]
-		if !isValidHookPoint(point) {
-			fmt.Printf("%s Invalid hook point: %s\n", color.RedString("[FAIL]"), point)
+		point, ok := resolveHookPoint(args[0])
+		if !ok {
+			fmt.Printf("%s Invalid hook point: %s\n", color.RedString("[FAIL]"), args[0])
 			fmt.Println()
 			fmt.Println("Valid hook points:")
 			for _, p := range getAllHookPoints() {
 				fmt.Printf("  %s\n", p)
 			}
-			return fmt.Errorf("invalid hook point: %s", point)
+			return fmt.Errorf("invalid hook point: %s", args[0])
 		}
 
 		fmt.Printf("%s\n", bold(fmt.Sprintf("Hooks for: %s", cyan(point))))
 		fmt.Println("═══════════════════════════════════════════════════════════════")
 		fmt.Println()
 
-		hasHooks := false
-
-		// File-based hooks
-		pointDir := filepath.Join(hooksDir, point)
-		var allFiles []string
-
-		if shFiles, err := filepath.Glob(filepath.Join(pointDir, "*.sh")); err == nil {
-			allFiles = append(allFiles, shFiles...)
-		}
-		if zshFiles, err := filepath.Glob(filepath.Join(pointDir, "*.zsh")); err == nil {
-			allFiles = append(allFiles, zshFiles...)
-		}
-
-		if len(allFiles) > 0 {
-			fmt.Printf("%s %s\n", bold("File-based hooks:"), dim(fmt.Sprintf("(%s)", pointDir)))
-			hasHooks = true
-			for _, f := range allFiles {
-				name := filepath.Base(f)
-				info, err := os.Stat(f)
-				if err != nil {
-					continue
-				}
-				if info.Mode()&0111 != 0 {
-					fmt.Printf("  %s %s %s\n", green("●"), name, dim("(executable)"))
-				} else {
-					fmt.Printf("  %s %s %s\n", yellow("○"), name, dim("(not executable - will be skipped)"))
+		hooks := discoverHooks(point, config)
+		for i, h := range hooks {
+			kind := "file"
+			if h.Entry != nil {
+				kind = "json"
+				if h.Entry.Command != "" {
+					kind = "command"
+				} else if h.Entry.Script != "" {
+					kind = "script: " + h.Entry.Script
+				} else if h.Entry.Function != "" {
+					kind = "function: " + h.Entry.Function
 				}
 			}
-			fmt.Println()
-		}
-
-		// JSON configured hooks
-		if hooks, ok := config.Hooks[point]; ok && len(hooks) > 0 {
-			fmt.Printf("%s %s\n", bold("JSON configured:"), dim(fmt.Sprintf("(%s)", getHooksConfigPath())))
-			hasHooks = true
-			for _, hook := range hooks {
-				enabled := hook.Enabled == nil || *hook.Enabled
-				typeLabel := ""
-				if hook.Command != "" {
-					typeLabel = "command"
-				} else if hook.Script != "" {
-					typeLabel = "script: " + hook.Script
-				} else if hook.Function != "" {
-					typeLabel = "function: " + hook.Function
-				}
-
-				if enabled {
-					fmt.Printf("  %s %s %s\n", green("●"), hook.Name, dim(fmt.Sprintf("(%s)", typeLabel)))
-				} else {
-					fmt.Printf("  %s %s %s\n", dim("○"), hook.Name, dim(fmt.Sprintf("(disabled, %s)", typeLabel)))
-				}
+			if h.Runs {
+				fmt.Printf("  %2d. %s %s %s\n", i+1, green("●"), h.Name, dim(fmt.Sprintf("(%s, %s)", kind, h.Source)))
+			} else {
+				fmt.Printf("  %2d. %s %s %s\n", i+1, yellow("○"), h.Name, dim(fmt.Sprintf("(%s - will be skipped)", h.Skipped)))
 			}
-			fmt.Println()
 		}
 
-		if !hasHooks {
+		if len(hooks) > 0 {
+			fmt.Println()
+			fmt.Printf("%s %s\n", dim("On failure:"), hookFailurePolicy(point, config.Settings))
+		} else {
 			fmt.Printf("%s\n", dim("No hooks registered for this point."))
 			fmt.Println()
 			fmt.Println("Add hooks by:")
-			fmt.Printf("  1. Creating scripts in: %s/\n", pointDir)
+			fmt.Printf("  1. Creating scripts in: %s/\n", hookPointDirs(point)[0])
 			fmt.Printf("  2. Adding to JSON config: %s\n", getHooksConfigPath())
 		}
 	} else {
@@ -356,21 +334,7 @@ func runHookList(args []string) error {
 			fmt.Println("───────────────────────────────────────────────────────────────")
 
 			for _, p := range cat.Points {
-				count := 0
-
-				// Count file-based hooks
-				pointDir := filepath.Join(hooksDir, p.Name)
-				if shFiles, err := filepath.Glob(filepath.Join(pointDir, "*.sh")); err == nil {
-					count += len(shFiles)
-				}
-				if zshFiles, err := filepath.Glob(filepath.Join(pointDir, "*.zsh")); err == nil {
-					count += len(zshFiles)
-				}
-
-				// Count JSON hooks
-				if hooks, ok := config.Hooks[p.Name]; ok {
-					count += len(hooks)
-				}
+				count := len(discoverHooks(p.Name, config))
 
 				if count > 0 {
 					fmt.Printf("  %s %-25s %d hook(s)\n", green("●"), p.Name, count)
@@ -388,125 +352,29 @@ func runHookList(args []string) error {
 }
 
 func runHookRun(args []string, verbose bool) error {
+	return runHookRunWith(args, hookContext{}, hookRunOptions{Verbose: verbose})
+}
+
+// runHookRunWith runs hooks for a point on demand. Unlike lifecycle
+// triggers, any failure is reported as an error regardless of policy.
+func runHookRunWith(args []string, hctx hookContext, opts hookRunOptions) error {
 	if len(args) == 0 {
 		fmt.Println(color.RedString("[FAIL]") + " Hook point required")
 		fmt.Println("Usage: blackdot hook run [--verbose] <point> [args...]")
 		return fmt.Errorf("hook point required")
 	}
 
-	point := args[0]
-	hookArgs := args[1:]
-
-	if !isValidHookPoint(point) {
-		fmt.Printf("%s Invalid hook point: %s\n", color.RedString("[FAIL]"), point)
-		return fmt.Errorf("invalid hook point: %s", point)
+	point, ok := resolveHookPoint(args[0])
+	if !ok {
+		fmt.Printf("%s Invalid hook point: %s\n", color.RedString("[FAIL]"), args[0])
+		return fmt.Errorf("invalid hook point: %s", args[0])
 	}
+	hctx.Args = args[1:]
 
 	fmt.Printf("%s Running hooks for: %s\n", color.CyanString("[INFO]"), point)
 
-	failed := false
-	hooksDir := getHooksDir()
-	config, _ := loadHooksConfig()
-	timeout := 30
-	if config.Settings.Timeout > 0 {
-		timeout = config.Settings.Timeout
-	}
-
-	// Run file-based hooks
-	pointDir := filepath.Join(hooksDir, point)
-	patterns := []string{"*.sh", "*.zsh"}
-	for _, pattern := range patterns {
-		files, err := filepath.Glob(filepath.Join(pointDir, pattern))
-		if err != nil {
-			continue
-		}
-		for _, script := range files {
-			info, err := os.Stat(script)
-			if err != nil || info.Mode()&0111 == 0 {
-				if verbose {
-					fmt.Printf("  Skipping non-executable: %s\n", script)
-				}
-				continue
-			}
-
-			if verbose {
-				fmt.Printf("  Running: %s\n", script)
-			}
-
-			cmd := exec.Command(script, hookArgs...)
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-
-			done := make(chan error)
-			go func() {
-				done <- cmd.Run()
-			}()
-
-			select {
-			case err := <-done:
-				if err != nil {
-					fmt.Printf("  Hook failed: %s (%v)\n", script, err)
-					failed = true
-					if config.Settings.FailFast {
-						return fmt.Errorf("hook failed: %s", script)
-					}
-				}
-			case <-time.After(time.Duration(timeout) * time.Second):
-				if cmd.Process != nil {
-					cmd.Process.Kill()
-				}
-				fmt.Printf("  Hook timed out: %s\n", script)
-				failed = true
-			}
-		}
-	}
-
-	// Run JSON configured hooks
-	if hooks, ok := config.Hooks[point]; ok {
-		for _, hook := range hooks {
-			enabled := hook.Enabled == nil || *hook.Enabled
-			if !enabled {
-				if verbose {
-					fmt.Printf("  Skipping disabled: %s\n", hook.Name)
-				}
-				continue
-			}
-
-			var err error
-			if hook.Command != "" {
-				if verbose {
-					fmt.Printf("  Running command (%s): %s\n", hook.Name, hook.Command)
-				}
-				cmd := exec.Command("sh", "-c", hook.Command)
-				cmd.Stdout = os.Stdout
-				cmd.Stderr = os.Stderr
-				err = cmd.Run()
-			} else if hook.Script != "" {
-				script := hook.Script
-				if strings.HasPrefix(script, "~") {
-					home, _ := os.UserHomeDir()
-					script = filepath.Join(home, script[1:])
-				}
-				if verbose {
-					fmt.Printf("  Running script (%s): %s\n", hook.Name, script)
-				}
-				cmd := exec.Command(script, hookArgs...)
-				cmd.Stdout = os.Stdout
-				cmd.Stderr = os.Stderr
-				err = cmd.Run()
-			}
-
-			if err != nil && !hook.FailOk {
-				fmt.Printf("  Hook failed: %s (%v)\n", hook.Name, err)
-				failed = true
-				if config.Settings.FailFast {
-					return fmt.Errorf("hook failed: %s", hook.Name)
-				}
-			}
-		}
-	}
-
-	if failed {
+	failed, err := runHooks(point, hctx, opts)
+	if err != nil || len(failed) > 0 {
 		fmt.Println(color.RedString("[FAIL]") + " One or more hooks failed")
 		return fmt.Errorf("one or more hooks failed")
 	}
@@ -549,10 +417,7 @@ func runHookAdd(args []string) error {
 
 	if _, err := os.Stat(dest); err == nil {
 		fmt.Printf("%s Hook already exists: %s\n", color.YellowString("[WARN]"), dest)
-		fmt.Print("Overwrite? [y/N] ")
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
+		if overwrite, _ := prompts.Confirm("Overwrite?", false); !overwrite {
 			fmt.Println(color.CyanString("[INFO]") + " Cancelled")
 			return nil
 		}
@@ -640,11 +505,10 @@ func runHookTest(args []string) error {
 		return fmt.Errorf("hook point required")
 	}
 
-	point := args[0]
-
-	if !isValidHookPoint(point) {
-		fmt.Printf("%s Invalid hook point: %s\n", color.RedString("[FAIL]"), point)
-		return fmt.Errorf("invalid hook point: %s", point)
+	point, ok := resolveHookPoint(args[0])
+	if !ok {
+		fmt.Printf("%s Invalid hook point: %s\n", color.RedString("[FAIL]"), args[0])
+		return fmt.Errorf("invalid hook point: %s", args[0])
 	}
 
 	bold := color.New(color.Bold).SprintFunc()
@@ -658,11 +522,11 @@ func runHookTest(args []string) error {
 	runHookList([]string{point})
 
 	fmt.Println("───────────────────────────────────────────────────────────────")
-	fmt.Println(bold("Executing with --verbose:"))
+	fmt.Println(bold("Executing with --verbose (BLACKDOT_HOOK_TEST=1, BLACKDOT_HOOK_DRY_RUN=1):"))
 	fmt.Println()
 
-	// Run with verbose
-	err := runHookRun([]string{point}, true)
+	// Run with verbose; hooks can check BLACKDOT_HOOK_DRY_RUN to avoid side effects
+	err := runHookRunWith([]string{point}, hookContext{DryRun: true, Test: true}, hookRunOptions{Verbose: true})
 
 	fmt.Println()
 	if err != nil {
@@ -711,6 +575,13 @@ func printHookHelp() {
 		fmt.Println()
 	}
 
+	// Lifecycle aliases
+	BoldCyan.Println("Lifecycle Directories:")
+	Dim.Println("  pre-restore, post-restore, pre-push, post-push, pre-setup, post-setup,")
+	Dim.Println("  pre-render, post-render (aliases for the points above)")
+	Dim.Println("  Scripts run in file-name order; pre-* failures abort, others warn.")
+	fmt.Println()
+
 	// Configuration
 	BoldCyan.Println("Configuration:")
 	fmt.Print("  ")
@@ -735,7 +606,7 @@ func printHookHelp() {
 	fmt.Println("  blackdot hook run shell_init --verbose")
 	fmt.Println()
 	Dim.Println("  # Add a hook script")
-	fmt.Println("  blackdot hook add post_vault_pull ~/scripts/ssh-add-keys.sh")
+	fmt.Println("  blackdot hook add post-restore ~/scripts/ssh-add-keys.sh")
 	fmt.Println()
 
 	// Environment Variables
@@ -756,4 +627,24 @@ func printHookHelp() {
 	Yellow.Print("BLACKDOT_HOOKS_TIMEOUT")
 	fmt.Print("    ")
 	Dim.Println("Hook timeout in seconds (default: 30)")
+	fmt.Println()
+
+	// Hook environment
+	BoldCyan.Println("Passed to Hooks:")
+	fmt.Print("  ")
+	Yellow.Print("BLACKDOT_HOOK_POINT")
+	fmt.Print("       ")
+	Dim.Println("Hook point being run")
+	fmt.Print("  ")
+	Yellow.Print("BLACKDOT_HOOK_ITEMS")
+	fmt.Print("       ")
+	Dim.Println("Space-separated item names")
+	fmt.Print("  ")
+	Yellow.Print("BLACKDOT_HOOK_PATHS")
+	fmt.Print("       ")
+	Dim.Println("Affected paths (PATH-style list)")
+	fmt.Print("  ")
+	Yellow.Print("BLACKDOT_HOOK_DRY_RUN")
+	fmt.Print("     ")
+	Dim.Println("Set to 1 for dry runs and 'hook test'")
 }
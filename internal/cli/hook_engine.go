@@ -0,0 +1,348 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hookPointAliases maps lifecycle directory names to hook points, so scripts
+// in ~/.config/blackdot/hooks/pre-restore/ run alongside pre_vault_pull/
+var hookPointAliases = map[string]string{
+	"pre-restore":  "pre_vault_pull",
+	"post-restore": "post_vault_pull",
+	"pre-push":     "pre_vault_push",
+	"post-push":    "post_vault_push",
+	"pre-setup":    "pre_setup",
+	"post-setup":   "setup_complete",
+	"pre-render":   "pre_template_render",
+	"post-render":  "post_template_render",
+}
+
+// Hook failure policies
+const (
+	hookPolicyAbort = "abort" // failure stops the operation that triggered the hook
+	hookPolicyWarn  = "warn"  // failure is reported and the operation continues
+)
+
+// hookContext is the data passed to hooks as environment variables
+type hookContext struct {
+	Items  []string          // vault item or template names involved
+	Paths  []string          // local file paths involved
+	Args   []string          // positional arguments for scripts
+	Env    map[string]string // extra BLACKDOT_HOOK_* variables
+	DryRun bool
+	Test   bool // set by 'blackdot hook test'
+}
+
+// hookRunOptions controls output and limits for a hook run
+type hookRunOptions struct {
+	Verbose bool
+	Timeout time.Duration // 0 uses settings / BLACKDOT_HOOKS_TIMEOUT
+}
+
+// hookScript is a single runnable hook
+type hookScript struct {
+	Name    string
+	Path    string     // file-based hook
+	Entry   *HookEntry // hooks.json entry
+	Source  string     // directory or config file it came from
+	Runs    bool       // false when it will be skipped (not executable, disabled)
+	Skipped string     // reason when Runs is false
+}
+
+// hookContextForItems builds a context from item name -> local path pairs,
+// keeping items and paths in the same (sorted) order
+func hookContextForItems(paths map[string]string, dryRun bool) hookContext {
+	hctx := hookContext{DryRun: dryRun}
+	for name := range paths {
+		hctx.Items = append(hctx.Items, name)
+	}
+	sort.Strings(hctx.Items)
+	for _, name := range hctx.Items {
+		hctx.Paths = append(hctx.Paths, paths[name])
+	}
+	return hctx
+}
+
+// errHookAborted wraps a hook failure under the abort policy
+var errHookAborted = errors.New("hook failed")
+
+// resolveHookPoint accepts a hook point or a lifecycle alias (pre-restore)
+func resolveHookPoint(name string) (string, bool) {
+	if point, ok := hookPointAliases[name]; ok {
+		return point, true
+	}
+	return name, isValidHookPoint(name)
+}
+
+// hookPointDirs returns the directories scanned for a point: its own name
+// plus any lifecycle alias that maps to it
+func hookPointDirs(point string) []string {
+	hooksDir := getHooksDir()
+	dirs := []string{filepath.Join(hooksDir, point)}
+
+	var aliases []string
+	for alias, target := range hookPointAliases {
+		if target == point {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		dirs = append(dirs, filepath.Join(hooksDir, alias))
+	}
+	return dirs
+}
+
+// discoverHooks returns hooks for a point in execution order: file-based
+// hooks sorted by file name across all directories (use 10-, 20- prefixes
+// to order them), then hooks.json entries in the order they are listed,
+// then entries from the project overlay
+func discoverHooks(point string, config *HooksConfig) []hookScript {
+	var files []hookScript
+	for _, dir := range hookPointDirs(point) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || !(strings.HasSuffix(name, ".sh") || strings.HasSuffix(name, ".zsh")) {
+				continue
+			}
+			h := hookScript{Name: name, Path: filepath.Join(dir, name), Source: dir, Runs: true}
+			if info, err := os.Stat(h.Path); err != nil || info.Mode()&0111 == 0 {
+				h.Runs = false
+				h.Skipped = "not executable"
+			}
+			files = append(files, h)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	hooks := files
+	if config != nil {
+		for i := range config.Hooks[point] {
+			entry := &config.Hooks[point][i]
+			h := hookScript{Name: entry.Name, Entry: entry, Source: getHooksConfigPath(), Runs: true}
+			if entry.Enabled != nil && !*entry.Enabled {
+				h.Runs = false
+				h.Skipped = "disabled"
+			}
+			hooks = append(hooks, h)
+		}
+	}
+
+	// Hooks from the repository's .blackdot.yaml run last, and only when
+	// the user has opted in
+	if overlay := currentProjectOverlay(); overlay != nil && len(overlay.Hooks[point]) > 0 {
+		allowed := projectHooksAllowed()
+		for i := range overlay.Hooks[point] {
+			entry := &overlay.Hooks[point][i]
+			h := hookScript{Name: entry.Name, Entry: entry, Source: overlay.Path, Runs: true}
+			switch {
+			case entry.Enabled != nil && !*entry.Enabled:
+				h.Runs = false
+				h.Skipped = "disabled"
+			case !allowed:
+				h.Runs = false
+				h.Skipped = "project hooks need hooks.allow_project"
+			}
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks
+}
+
+// hookFailurePolicy returns the policy for a point. Pre-hooks abort by
+// default so they can act as guards; everything else warns. Override per
+// point (or alias) in hooks.json: "settings": {"policies": {"pre-push": "warn"}}
+func hookFailurePolicy(point string, settings HookSettings) string {
+	if policy, ok := settings.Policies[point]; ok {
+		return policy
+	}
+	for alias, target := range hookPointAliases {
+		if target == point {
+			if policy, ok := settings.Policies[alias]; ok {
+				return policy
+			}
+		}
+	}
+	if strings.HasPrefix(point, "pre_") {
+		return hookPolicyAbort
+	}
+	return hookPolicyWarn
+}
+
+// hooksDisabled reports whether hooks are turned off globally
+func hooksDisabled() bool {
+	if v := os.Getenv("BLACKDOT_HOOKS_DISABLED"); v == "true" || v == "1" {
+		return true
+	}
+	return !initRegistry().Enabled("hooks")
+}
+
+// applyHookEnvSettings layers BLACKDOT_HOOKS_* variables over hooks.json
+func applyHookEnvSettings(settings *HookSettings) {
+	if v := os.Getenv("BLACKDOT_HOOKS_FAIL_FAST"); v != "" {
+		settings.FailFast = v == "true" || v == "1"
+	}
+	if v := os.Getenv("BLACKDOT_HOOKS_VERBOSE"); v != "" {
+		settings.Verbose = v == "true" || v == "1"
+	}
+	if n, err := strconv.Atoi(os.Getenv("BLACKDOT_HOOKS_TIMEOUT")); err == nil && n > 0 {
+		settings.Timeout = n
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = 30
+	}
+}
+
+// hookEnv builds the environment injected into every hook
+func hookEnv(point string, hctx hookContext) []string {
+	env := append(os.Environ(),
+		"BLACKDOT_HOOK_POINT="+point,
+		"BLACKDOT_DIR="+BlackdotDir(),
+		"BLACKDOT_HOOK_ITEMS="+strings.Join(hctx.Items, " "),
+		"BLACKDOT_HOOK_PATHS="+strings.Join(hctx.Paths, string(os.PathListSeparator)),
+	)
+	if hctx.DryRun {
+		env = append(env, "BLACKDOT_HOOK_DRY_RUN=1")
+	}
+	if hctx.Test {
+		env = append(env, "BLACKDOT_HOOK_TEST=1")
+	}
+	keys := make([]string, 0, len(hctx.Env))
+	for k := range hctx.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+hctx.Env[k])
+	}
+	return env
+}
+
+// hookCommand builds the command for a hook
+func hookCommand(ctx context.Context, h hookScript, args []string) *exec.Cmd {
+	if h.Entry == nil {
+		return exec.CommandContext(ctx, h.Path, args...)
+	}
+	if h.Entry.Command != "" {
+		return exec.CommandContext(ctx, "sh", "-c", h.Entry.Command)
+	}
+	return exec.CommandContext(ctx, expandPath(h.Entry.Script), args...)
+}
+
+// runHooks runs all hooks for a point. It returns the names of failed hooks,
+// and a non-nil error only when the point's failure policy is "abort".
+func runHooks(point string, hctx hookContext, opts hookRunOptions) ([]string, error) {
+	config, err := loadHooksConfig()
+	if err != nil {
+		Warn("Failed to read %s: %v", getHooksConfigPath(), err)
+		config = &HooksConfig{Hooks: make(map[string][]HookEntry)}
+	}
+	settings := config.Settings
+	applyHookEnvSettings(&settings)
+	verbose := opts.Verbose || settings.Verbose
+
+	timeout := time.Duration(settings.Timeout) * time.Second
+	if opts.Timeout > 0 {
+		timeout = opts.Timeout
+	}
+
+	hooks := discoverHooks(point, config)
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+
+	policy := hookFailurePolicy(point, settings)
+	env := hookEnv(point, hctx)
+
+	var failed []string
+	for _, h := range hooks {
+		if !h.Runs {
+			if verbose {
+				fmt.Printf("  Skipping %s: %s (%s)\n", h.Skipped, h.Name, h.Source)
+			}
+			continue
+		}
+		if h.Entry != nil && h.Entry.Command == "" && h.Entry.Script == "" {
+			// function hooks only exist in the shell library
+			if verbose {
+				fmt.Printf("  Skipping shell function hook: %s\n", h.Name)
+			}
+			continue
+		}
+
+		if verbose {
+			fmt.Printf("  Running: %s\n", h.Name)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		cmd := hookCommand(ctx, h, hctx.Args)
+		cmd.Env = env
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Stdin = nil
+		cmd.WaitDelay = time.Second // don't hang on children holding stdout
+		runErr := cmd.Run()
+		timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+		cancel()
+
+		if runErr == nil {
+			continue
+		}
+
+		if timedOut {
+			Warn("Hook timed out after %s: %s", timeout, h.Name)
+		} else {
+			Warn("Hook failed: %s (%v)", h.Name, runErr)
+		}
+
+		if h.Entry != nil && h.Entry.FailOk {
+			continue
+		}
+		failed = append(failed, h.Name)
+
+		if policy == hookPolicyAbort {
+			return failed, fmt.Errorf("%w: %s (%s policy for %s)", errHookAborted, h.Name, policy, point)
+		}
+		if settings.FailFast {
+			break
+		}
+	}
+
+	return failed, nil
+}
+
+// triggerHooks runs lifecycle hooks from a command. Output is quiet unless
+// hooks exist; the returned error is non-nil only under the abort policy.
+func triggerHooks(point string, hctx hookContext) error {
+	if hooksDisabled() {
+		return nil
+	}
+	config, _ := loadHooksConfig()
+	if len(discoverHooks(point, config)) == 0 {
+		return nil
+	}
+
+	Info("Running %s hooks...", point)
+	failed, err := runHooks(point, hctx, hookRunOptions{})
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+	if len(failed) > 0 {
+		Warn("%d %s hook(s) failed (continuing)", len(failed), point)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupHooksDir points the hook engine at a temporary directory
+func setupHooksDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, key := range []string{"BLACKDOT_HOOKS_DIR", "BLACKDOT_HOOKS_CONFIG", "BLACKDOT_HOOKS_TIMEOUT"} {
+		orig, had := os.LookupEnv(key)
+		t.Cleanup(func() {
+			if had {
+				os.Setenv(key, orig)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+	os.Setenv("BLACKDOT_HOOKS_DIR", filepath.Join(dir, "hooks"))
+	os.Setenv("BLACKDOT_HOOKS_CONFIG", filepath.Join(dir, "hooks.json"))
+	os.Unsetenv("BLACKDOT_HOOKS_TIMEOUT")
+	return dir
+}
+
+func writeHookScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestResolveHookPoint verifies lifecycle aliases map to hook points
+func TestResolveHookPoint(t *testing.T) {
+	tests := []struct {
+		name  string
+		point string
+		ok    bool
+	}{
+		{"pre-restore", "pre_vault_pull", true},
+		{"post-push", "post_vault_push", true},
+		{"post-setup", "setup_complete", true},
+		{"post_vault_pull", "post_vault_pull", true},
+		{"pre_setup", "pre_setup", true},
+		{"nonsense", "nonsense", false},
+	}
+
+	for _, tt := range tests {
+		point, ok := resolveHookPoint(tt.name)
+		if point != tt.point || ok != tt.ok {
+			t.Errorf("resolveHookPoint(%q) = (%q, %v), want (%q, %v)", tt.name, point, ok, tt.point, tt.ok)
+		}
+	}
+}
+
+// TestHookFailurePolicy verifies pre-hooks abort by default and overrides apply
+func TestHookFailurePolicy(t *testing.T) {
+	settings := HookSettings{}
+	if got := hookFailurePolicy("pre_vault_push", settings); got != hookPolicyAbort {
+		t.Errorf("pre_vault_push default = %q, want abort", got)
+	}
+	if got := hookFailurePolicy("post_vault_push", settings); got != hookPolicyWarn {
+		t.Errorf("post_vault_push default = %q, want warn", got)
+	}
+
+	settings.Policies = map[string]string{"pre-push": "warn", "post_vault_pull": "abort"}
+	if got := hookFailurePolicy("pre_vault_push", settings); got != hookPolicyWarn {
+		t.Errorf("pre_vault_push with alias override = %q, want warn", got)
+	}
+	if got := hookFailurePolicy("post_vault_pull", settings); got != hookPolicyAbort {
+		t.Errorf("post_vault_pull with override = %q, want abort", got)
+	}
+}
+
+// TestDiscoverHooksOrder verifies files from point and alias directories are
+// merged and sorted by name, followed by hooks.json entries
+func TestDiscoverHooksOrder(t *testing.T) {
+	dir := setupHooksDir(t)
+	hooksDir := filepath.Join(dir, "hooks")
+
+	writeHookScript(t, filepath.Join(hooksDir, "pre_vault_push"), "20-second.sh", "true")
+	writeHookScript(t, filepath.Join(hooksDir, "pre-push"), "10-first.sh", "true")
+	writeHookScript(t, filepath.Join(hooksDir, "pre-push"), "30-third.zsh", "true")
+	if err := os.WriteFile(filepath.Join(hooksDir, "pre-push", "notes.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hooksDir, "pre-push", "40-noexec.sh"), []byte("true"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	disabled := false
+	config := &HooksConfig{Hooks: map[string][]HookEntry{
+		"pre_vault_push": {
+			{Name: "json-hook", Command: "true"},
+			{Name: "off", Command: "true", Enabled: &disabled},
+		},
+	}}
+
+	hooks := discoverHooks("pre_vault_push", config)
+	var names []string
+	for _, h := range hooks {
+		names = append(names, h.Name)
+	}
+	want := "10-first.sh,20-second.sh,30-third.zsh,40-noexec.sh,json-hook,off"
+	if got := strings.Join(names, ","); got != want {
+		t.Fatalf("order = %s, want %s", got, want)
+	}
+	if runtime.GOOS != "windows" && hooks[3].Runs {
+		t.Error("non-executable hook should be skipped")
+	}
+	if hooks[5].Runs {
+		t.Error("disabled JSON hook should be skipped")
+	}
+}
+
+// TestRunHooksPolicies verifies environment injection, abort vs warn, and timeouts
+func TestRunHooksPolicies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell hooks not supported on Windows")
+	}
+	dir := setupHooksDir(t)
+	hooksDir := filepath.Join(dir, "hooks")
+	out := filepath.Join(dir, "env.out")
+
+	writeHookScript(t, filepath.Join(hooksDir, "post-restore"), "10-env.sh",
+		`echo "$BLACKDOT_HOOK_POINT|$BLACKDOT_HOOK_ITEMS|$BLACKDOT_HOOK_PATHS" > "`+out+`"`)
+	writeHookScript(t, filepath.Join(hooksDir, "post-restore"), "20-fail.sh", "exit 1")
+
+	hctx := hookContextForItems(map[string]string{"b-item": "/tmp/b", "a-item": "/tmp/a"}, false)
+	failed, err := runHooks("post_vault_pull", hctx, hookRunOptions{})
+	if err != nil {
+		t.Fatalf("warn policy should not return error: %v", err)
+	}
+	if len(failed) != 1 || failed[0] != "20-fail.sh" {
+		t.Errorf("failed = %v, want [20-fail.sh]", failed)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("env hook did not run: %v", err)
+	}
+	want := "post_vault_pull|a-item b-item|/tmp/a" + string(os.PathListSeparator) + "/tmp/b"
+	if got := strings.TrimSpace(string(data)); got != want {
+		t.Errorf("hook env = %q, want %q", got, want)
+	}
+
+	// Pre-hooks abort, and later hooks don't run
+	marker := filepath.Join(dir, "after")
+	writeHookScript(t, filepath.Join(hooksDir, "pre-push"), "10-fail.sh", "exit 3")
+	writeHookScript(t, filepath.Join(hooksDir, "pre-push"), "20-after.sh", `touch "`+marker+`"`)
+	_, err = runHooks("pre_vault_push", hookContext{}, hookRunOptions{})
+	if !errors.Is(err, errHookAborted) {
+		t.Fatalf("abort policy error = %v, want errHookAborted", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("hooks after an aborting failure should not run")
+	}
+
+	// Timeouts count as failures
+	writeHookScript(t, filepath.Join(hooksDir, "post-render"), "10-slow.sh", "exec sleep 5")
+	start := time.Now()
+	failed, _ = runHooks("post_template_render", hookContext{}, hookRunOptions{Timeout: 200 * time.Millisecond})
+	if len(failed) != 1 {
+		t.Errorf("timed out hook should fail, got %v", failed)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("hook was not killed at its timeout")
+	}
+}
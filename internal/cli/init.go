@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/scaffold"
+	"github.com/spf13/cobra"
+)
+
+// initCommitMessage is the first commit of a repo created with --git
+const initCommitMessage = "Initial dotfiles from blackdot init"
+
+type initOptions struct {
+	git    bool
+	merge  bool
+	dryRun bool
+}
+
+func newInitCmd() *cobra.Command {
+	var opts initOptions
+	cmd := &cobra.Command{
+		Use:   "init [dir]",
+		Short: "Scaffold a new dotfiles repo",
+		Long: `Create a dotfiles repo with the layout blackdot expects, for setups that
+do not start from a fork of the blackdot repo:
+
+  zsh/zshrc, zsh/zsh.d/       modular shell config
+  templates/_variables.sh     template variable defaults
+  templates/configs/          config templates (a gitconfig to start)
+  vault/                      example vault-items.json
+  brew/Brewfile*              minimal, enhanced, and full package tiers
+  features.yaml               feature defaults for machines using the repo
+  links.yaml                  symlinks into your home directory
+
+The directory defaults to BLACKDOT_DIR (~/.blackdot). A directory that is
+not empty is refused; with --merge only the missing files are added and
+existing ones are left alone.`,
+		Example: `  blackdot init                       # Scaffold ~/.blackdot
+  blackdot init ~/dotfiles --git      # Scaffold and make the first commit
+  blackdot init --merge --dry-run     # Show what would be added to an existing repo`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := BlackdotDir()
+			if len(args) == 1 {
+				dir = args[0]
+			}
+			return runInit(dir, opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.git, "git", false, "Create a git repository and commit the scaffold")
+	cmd.Flags().BoolVar(&opts.merge, "merge", false, "Add missing files to a directory that is not empty")
+	cmd.Flags().BoolVarP(&opts.dryRun, "dry-run", "n", false, "Show what would be created")
+	return cmd
+}
+
+func runInit(dir string, opts initOptions) error {
+	dir, err := filepath.Abs(expandPath(dir))
+	if err != nil {
+		return err
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) > 0 && !opts.merge {
+		Fail("%s is not empty", tildePath(dir))
+		Info("Use --merge to add only the missing files")
+		return fmt.Errorf("%s is not empty", dir)
+	}
+
+	if opts.dryRun {
+		PrintHeader("Scaffold (dry run)")
+	} else {
+		PrintHeader("Scaffold")
+	}
+	created, kept, err := writeScaffold(dir, opts.dryRun)
+	if err != nil {
+		Fail("Failed to write the scaffold: %v", err)
+		return err
+	}
+	for _, rel := range created {
+		if opts.dryRun {
+			fmt.Printf("  %s %s\n", Green.Sprint("+"), rel)
+		} else {
+			Pass("Created %s", rel)
+		}
+	}
+	for _, rel := range kept {
+		fmt.Printf("  %s %s %s\n", Dim.Sprint("="), rel, Dim.Sprint("(exists, kept)"))
+	}
+	if opts.dryRun {
+		fmt.Println()
+		Info("%d file(s) would be created in %s", len(created), tildePath(dir))
+		if opts.git {
+			Info("Would create a git repository and commit the scaffold")
+		}
+		return nil
+	}
+
+	if opts.git {
+		if err := initGitRepo(dir); err != nil {
+			Warn("Scaffold written, but not committed: %v", err)
+		}
+	}
+
+	fmt.Println()
+	Info("Next steps:")
+	if filepath.Clean(dir) != filepath.Clean(BlackdotDir()) {
+		fmt.Printf("  export BLACKDOT_DIR=%s   %s\n", shellQuote(dir), Dim.Sprint("# add to your shell profile"))
+	}
+	fmt.Println("  blackdot template init && blackdot template render")
+	fmt.Println("  blackdot links apply")
+	fmt.Println("  blackdot doctor")
+	return nil
+}
+
+// writeScaffold copies the skeleton into dir, never replacing a file that
+// exists. It returns the slash-separated paths created and kept.
+func writeScaffold(dir string, dryRun bool) (created, kept []string, err error) {
+	files := scaffold.Files()
+	err = fs.WalkDir(files, ".", func(rel string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if _, statErr := os.Lstat(target); statErr == nil {
+			kept = append(kept, rel)
+			return nil
+		}
+		created = append(created, rel)
+		if dryRun {
+			return nil
+		}
+		data, err := fs.ReadFile(files, rel)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+	return created, kept, err
+}
+
+// initGitRepo creates a repository in dir (unless it is already one) and
+// commits everything in it
+func initGitRepo(dir string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git not found")
+	}
+	if !fileExists(filepath.Join(dir, ".git")) {
+		if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+			return fmt.Errorf("git init: %s", strings.TrimSpace(string(out)))
+		}
+		Pass("Created git repository")
+	}
+	if out, err := exec.Command("git", "-C", dir, "add", "-A").CombinedOutput(); err != nil {
+		return fmt.Errorf("git add: %s", strings.TrimSpace(string(out)))
+	}
+	if out, err := exec.Command("git", "-C", dir, "commit", "-q", "-m", initCommitMessage).CombinedOutput(); err != nil {
+		return fmt.Errorf("git commit: %s (set user.name and user.email, then commit by hand)", strings.TrimSpace(string(out)))
+	}
+	Pass("Committed: %s", initCommitMessage)
+	return nil
+}
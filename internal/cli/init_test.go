@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRunInitScaffold verifies the layout is written, a non-empty
+// directory is refused, and --merge only adds missing files
+func TestRunInitScaffold(t *testing.T) {
+	setScheduleEnv(t, "")
+	dir := filepath.Join(t.TempDir(), "dotfiles")
+
+	if err := runInit(dir, initOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range []string{
+		"zsh/zshrc", "zsh/zsh.d/00-env.zsh", "templates/_variables.sh",
+		"templates/configs/gitconfig.tmpl", "vault/vault-items.example.json",
+		"brew/Brewfile", "brew/Brewfile.minimal", "brew/Brewfile.enhanced",
+		"features.yaml", "links.yaml", ".gitignore",
+	} {
+		if !fileExists(filepath.Join(dir, filepath.FromSlash(rel))) {
+			t.Errorf("%s not created", rel)
+		}
+	}
+
+	if err := runInit(dir, initOptions{}); err == nil {
+		t.Error("a non-empty directory should be refused without --merge")
+	}
+
+	features := filepath.Join(dir, "features.yaml")
+	os.WriteFile(features, []byte("vault: false\n"), 0644)
+	os.Remove(filepath.Join(dir, "links.yaml"))
+	created, kept, err := writeScaffold(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || created[0] != "links.yaml" || len(kept) == 0 {
+		t.Errorf("merge created %v", created)
+	}
+	if data, _ := os.ReadFile(features); string(data) != "vault: false\n" {
+		t.Errorf("merge replaced an existing file: %q", data)
+	}
+}
+
+// TestRunInitGit verifies --git commits the scaffold
+func TestRunInitGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	setScheduleEnv(t, "")
+	t.Setenv("GIT_AUTHOR_NAME", "t")
+	t.Setenv("GIT_AUTHOR_EMAIL", "t@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "t")
+	t.Setenv("GIT_COMMITTER_EMAIL", "t@example.com")
+	dir := t.TempDir()
+
+	if err := runInit(dir, initOptions{git: true}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("git", "-C", dir, "log", "--format=%s").Output()
+	if err != nil || strings.TrimSpace(string(out)) != initCommitMessage {
+		t.Fatalf("git log = %q, %v", out, err)
+	}
+	out, _ = exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	if len(out) != 0 {
+		t.Errorf("scaffold left uncommitted files:\n%s", out)
+	}
+}
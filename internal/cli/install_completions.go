@@ -0,0 +1,365 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/safefile"
+	"github.com/spf13/cobra"
+)
+
+// completionShells are the shells install-completions supports
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// completionTarget is where a shell's completion script goes
+type completionTarget struct {
+	Shell string
+	Path  string
+	Hint  string // setup the shell needs to find Path, if any
+
+	// RC is the rc file that must load Path's directory, and RCLine
+	// the line that does it; empty when the shell finds Path by itself
+	RC     string
+	RCLine string
+}
+
+func newInstallCompletionsCmd() *cobra.Command {
+	return newCompletionInstallCmd("install-completions", "blackdot install-completions")
+}
+
+// newCompletionInstallCmd builds 'blackdot completion install' and its
+// top-level spelling, install-completions
+func newCompletionInstallCmd(use, invocation string) *cobra.Command {
+	var shell, dir string
+	var dryRun, noRC bool
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: "Install shell completions where your shell loads them",
+		Long: fmt.Sprintf(`Write the completion script for your shell into a directory it loads
+completions from, and wire that directory into your shell's rc file when
+the shell doesn't already look there.
+
+The shell is taken from $SHELL unless --shell is given. Locations:
+
+  zsh         $(brew --prefix)/share/zsh/site-functions/_blackdot when
+              Homebrew is installed and writable, else
+              ~/.local/share/zsh/site-functions/_blackdot (added to fpath
+              at the top of ~/.zshrc unless blackdot manages ~/.zshrc)
+  bash        $(brew --prefix)/etc/bash_completion.d/blackdot, else
+              ~/.local/share/bash-completion/completions/blackdot
+  fish        ~/.config/fish/completions/blackdot.fish
+  powershell  ~/.config/blackdot/completions/blackdot.ps1 (dot-sourced
+              from the CurrentUserAllHosts $PROFILE)
+
+Homebrew installs of blackdot already ship completions; run this after
+installing the binary any other way, or after upgrading. 'blackdot doctor'
+warns when the installed completions are missing or out of date.
+
+Examples:
+  %[1]s
+  %[1]s --shell fish
+  %[1]s --shell zsh --dir ~/.zfunc
+  %[1]s --no-rc      # Print the rc change instead`, invocation),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if shell == "" {
+				shell = detectShell()
+			}
+			target, err := resolveCompletionTarget(shell, dir)
+			if err != nil {
+				Fail("%v", err)
+				return err
+			}
+			return installCompletion(cmd.Root(), target, dryRun, !noRC)
+		},
+	}
+
+	cmd.Flags().StringVarP(&shell, "shell", "s", "", "Shell to install for: bash, zsh, fish, powershell")
+	cmd.Flags().StringVar(&dir, "dir", "", "Install into this directory instead")
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show where the script would be written")
+	cmd.Flags().BoolVar(&noRC, "no-rc", false, "Don't edit ~/.zshrc or $PROFILE; print the line to add")
+	cmd.RegisterFlagCompletionFunc("shell", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completionShells, cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+// detectShell returns the login shell from $SHELL, defaulting to
+// powershell on Windows and bash elsewhere
+func detectShell() string {
+	switch name := strings.TrimSuffix(filepath.Base(os.Getenv("SHELL")), ".exe"); name {
+	case "bash", "zsh", "fish":
+		return name
+	case "pwsh", "powershell":
+		return "powershell"
+	}
+	if runtime.GOOS == "windows" {
+		return "powershell"
+	}
+	return "bash"
+}
+
+// brewPrefix returns the Homebrew prefix, or "" without Homebrew
+func brewPrefix() string {
+	if prefix := os.Getenv("HOMEBREW_PREFIX"); prefix != "" {
+		return prefix
+	}
+	if _, err := exec.LookPath("brew"); err != nil {
+		return ""
+	}
+	out, err := exec.Command("brew", "--prefix").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// dirWritable reports whether dir exists and files can be created in it
+func dirWritable(dir string) bool {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	f, err := os.CreateTemp(dir, ".blackdot-write-test-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
+// completionLocations returns a shell's completion file name, the
+// Homebrew directory for it (empty without Homebrew), and the per-user
+// directory
+func completionLocations(shell string) (file, brewDir, userDir string, err error) {
+	home, _ := os.UserHomeDir()
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	switch shell {
+	case "zsh":
+		file = "_blackdot"
+		if prefix := brewPrefix(); prefix != "" {
+			brewDir = filepath.Join(prefix, "share", "zsh", "site-functions")
+		}
+		userDir = filepath.Join(dataHome, "zsh", "site-functions")
+	case "bash":
+		file = "blackdot"
+		if prefix := brewPrefix(); prefix != "" {
+			brewDir = filepath.Join(prefix, "etc", "bash_completion.d")
+		}
+		userDir = filepath.Join(dataHome, "bash-completion", "completions")
+		if d := os.Getenv("BASH_COMPLETION_USER_DIR"); d != "" {
+			userDir = filepath.Join(d, "completions")
+		}
+	case "fish":
+		file = "blackdot.fish"
+		userDir = filepath.Join(configHome, "fish", "completions")
+	case "powershell":
+		file = "blackdot.ps1"
+		userDir = filepath.Join(ConfigDir(), "completions")
+	default:
+		err = fmt.Errorf("unsupported shell %q (expected bash, zsh, fish, powershell)", shell)
+	}
+	return file, brewDir, userDir, err
+}
+
+// resolveCompletionTarget picks the completion file for shell. A non-empty
+// dir overrides the detected directory.
+func resolveCompletionTarget(shell, dir string) (completionTarget, error) {
+	file, brewDir, userDir, err := completionLocations(shell)
+	if err != nil {
+		return completionTarget{}, err
+	}
+
+	switch {
+	case dir != "":
+		return userCompletionTarget(shell, filepath.Join(expandPath(dir), file)), nil
+	case brewDir != "" && dirWritable(brewDir):
+		return completionTarget{Shell: shell, Path: filepath.Join(brewDir, file)}, nil
+	}
+	return userCompletionTarget(shell, filepath.Join(userDir, file)), nil
+}
+
+// userCompletionTarget fills in the rc wiring a completion file outside
+// the shell's default search path needs
+func userCompletionTarget(shell, path string) completionTarget {
+	target := completionTarget{Shell: shell, Path: path}
+	switch shell {
+	case "zsh":
+		home, _ := os.UserHomeDir()
+		target.RC = filepath.Join(home, ".zshrc")
+		target.RCLine = fmt.Sprintf("fpath=(%s $fpath)", tildePath(filepath.Dir(path)))
+		target.Hint = "Add to ~/.zshrc before compinit: " + target.RCLine
+	case "bash":
+		target.Hint = "Requires the bash-completion package (v2), which loads this directory on demand"
+	case "powershell":
+		target.RC = powershellProfile()
+		target.RCLine = fmt.Sprintf("if (Test-Path '%[1]s') { . '%[1]s' }", path)
+		target.Hint = "Add to $PROFILE: . " + path
+	}
+	return target
+}
+
+// installedCompletion finds the completion file already installed for
+// shell, in the Homebrew or per-user directory
+func installedCompletion(shell string) (completionTarget, bool) {
+	file, brewDir, userDir, err := completionLocations(shell)
+	if err != nil {
+		return completionTarget{}, false
+	}
+	if brewDir != "" {
+		if path := filepath.Join(brewDir, file); fileExists(path) {
+			return completionTarget{Shell: shell, Path: path}, true
+		}
+	}
+	target := userCompletionTarget(shell, filepath.Join(userDir, file))
+	return target, fileExists(target.Path)
+}
+
+// powershellProfile is the CurrentUserAllHosts profile, so completions
+// load in every PowerShell host
+func powershellProfile() string {
+	for _, pwsh := range []string{"pwsh", "powershell"} {
+		if _, err := exec.LookPath(pwsh); err != nil {
+			continue
+		}
+		out, err := exec.Command(pwsh, "-NoProfile", "-Command", "$PROFILE.CurrentUserAllHosts").Output()
+		if profile := strings.TrimSpace(string(out)); err == nil && profile != "" {
+			return profile
+		}
+	}
+	home, _ := os.UserHomeDir()
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "Documents", "PowerShell", "profile.ps1")
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "powershell", "profile.ps1")
+}
+
+// completionRCMarker precedes the line install adds to an rc file
+const completionRCMarker = "# Added by blackdot completion install"
+
+// completionWired reports whether target's rc file already loads its
+// directory: the line (or the directory) is in the rc, or the rc is
+// blackdot's own zshrc, which puts the per-user site-functions on fpath
+func completionWired(target completionTarget) bool {
+	if target.RC == "" {
+		return true
+	}
+	rc := target.RC
+	if resolved, err := filepath.EvalSymlinks(rc); err == nil {
+		rc = resolved
+	}
+	if target.Shell == "zsh" {
+		if zshrc, err := filepath.EvalSymlinks(filepath.Join(getBlackdotDir(), "zsh", "zshrc")); err == nil && zshrc == rc {
+			return true
+		}
+	}
+	data, err := os.ReadFile(rc)
+	if err != nil {
+		return false
+	}
+	content := string(data)
+	dir := filepath.Dir(target.Path)
+	return strings.Contains(content, target.RCLine) || strings.Contains(content, dir) || strings.Contains(content, tildePath(dir))
+}
+
+// wireCompletionRC adds target's RCLine to its rc file. zsh needs the
+// directory on fpath before compinit runs, so the line goes at the top;
+// PowerShell's dot-source is appended.
+func wireCompletionRC(target completionTarget) error {
+	rc := target.RC
+	if resolved, err := filepath.EvalSymlinks(rc); err == nil {
+		rc = resolved
+	}
+	if err := os.MkdirAll(filepath.Dir(rc), 0755); err != nil {
+		return err
+	}
+	return safefile.Update(rc, 0644, func(current []byte) ([]byte, error) {
+		block := completionRCMarker + "\n" + target.RCLine + "\n"
+		content := string(current)
+		if target.Shell == "zsh" {
+			if content != "" {
+				block += "\n"
+			}
+			return []byte(block + content), nil
+		}
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if content != "" {
+			content += "\n"
+		}
+		return []byte(content + block), nil
+	})
+}
+
+// installCompletion writes the completion script for target, leaving the
+// file alone when it is already current, and with wireRC adds the
+// target's directory to the shell's rc when it isn't loaded from there
+func installCompletion(root *cobra.Command, target completionTarget, dryRun, wireRC bool) error {
+	var buf bytes.Buffer
+	if err := writeCompletion(root, target.Shell, &buf); err != nil {
+		Fail("Failed to generate %s completions: %v", target.Shell, err)
+		return err
+	}
+
+	wired := completionWired(target)
+	if dryRun {
+		fmt.Printf("%s %s completions -> %s\n", Cyan.Sprint("[dry-run]"), target.Shell, tildePath(target.Path))
+		if !wired && wireRC {
+			fmt.Printf("%s add to %s: %s\n", Cyan.Sprint("[dry-run]"), tildePath(target.RC), target.RCLine)
+		}
+		return nil
+	}
+
+	changed := true
+	if existing, err := os.ReadFile(target.Path); err == nil && bytes.Equal(existing, buf.Bytes()) {
+		Pass("%s completions already up to date: %s", target.Shell, tildePath(target.Path))
+		changed = false
+	} else {
+		if err := os.MkdirAll(filepath.Dir(target.Path), 0755); err != nil {
+			Fail("Failed to create %s: %v", filepath.Dir(target.Path), err)
+			return err
+		}
+		if err := writeFileAtomic(target.Path, buf.Bytes(), 0644); err != nil {
+			Fail("Failed to write %s: %v", target.Path, err)
+			return err
+		}
+		Pass("Installed %s completions: %s", target.Shell, tildePath(target.Path))
+	}
+
+	switch {
+	case !wired && wireRC:
+		if err := wireCompletionRC(target); err != nil {
+			Fail("Failed to update %s: %v", tildePath(target.RC), err)
+			Dim.Println("  " + target.Hint)
+			return err
+		}
+		Pass("Added completions to %s", tildePath(target.RC))
+		changed = true
+	case !wired || (target.RC == "" && target.Hint != "" && changed):
+		Dim.Println("  " + target.Hint)
+	}
+	if changed {
+		Info("Open a new shell to load them")
+	}
+	return nil
+}
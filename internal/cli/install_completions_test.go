@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInstallCompletions verifies the per-shell user directories, --dir,
+// and that the written script is a completion for the detected shell
+func TestInstallCompletions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("HOMEBREW_PREFIX", filepath.Join(home, "no-brew"))
+	t.Setenv("BASH_COMPLETION_USER_DIR", "")
+
+	tests := []struct {
+		shell, dir, want string
+	}{
+		{"zsh", "", filepath.Join(home, "data", "zsh", "site-functions", "_blackdot")},
+		{"bash", "", filepath.Join(home, "data", "bash-completion", "completions", "blackdot")},
+		{"fish", "", filepath.Join(home, "config", "fish", "completions", "blackdot.fish")},
+		{"zsh", filepath.Join(home, "zfunc"), filepath.Join(home, "zfunc", "_blackdot")},
+	}
+	for _, tt := range tests {
+		target, err := resolveCompletionTarget(tt.shell, tt.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target.Path != tt.want {
+			t.Errorf("%s: path = %s, want %s", tt.shell, target.Path, tt.want)
+		}
+	}
+
+	brewDir := filepath.Join(home, "brew", "share", "zsh", "site-functions")
+	os.MkdirAll(brewDir, 0755)
+	t.Setenv("HOMEBREW_PREFIX", filepath.Join(home, "brew"))
+	target, _ := resolveCompletionTarget("zsh", "")
+	if target.Path != filepath.Join(brewDir, "_blackdot") {
+		t.Errorf("brew zsh path = %s", target.Path)
+	}
+	if err := installCompletion(rootCmd, target, false, true); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(target.Path)
+	if !strings.HasPrefix(string(data), "#compdef blackdot") {
+		t.Errorf("not a zsh completion: %.40q", data)
+	}
+
+	if _, err := resolveCompletionTarget("tcsh", ""); err == nil {
+		t.Error("unsupported shell accepted")
+	}
+}
+
+// TestCompletionInstallWiresRC verifies the per-user zsh directory is put
+// on fpath at the top of ~/.zshrc once, and that the install is then
+// reported current
+func TestCompletionInstallWiresRC(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	t.Setenv("HOMEBREW_PREFIX", filepath.Join(home, "no-brew"))
+	t.Setenv("BLACKDOT_DIR", filepath.Join(home, "blackdot"))
+	zshrc := filepath.Join(home, ".zshrc")
+	os.WriteFile(zshrc, []byte("autoload -Uz compinit && compinit\n"), 0644)
+
+	if _, ok := installedCompletion("zsh"); ok {
+		t.Fatal("completion reported installed before install")
+	}
+	target, _ := resolveCompletionTarget("zsh", "")
+	if completionWired(target) {
+		t.Fatal("plain ~/.zshrc reported as loading the completion dir")
+	}
+	for i := 0; i < 2; i++ {
+		if err := installCompletion(rootCmd, target, false, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, _ := os.ReadFile(zshrc)
+	content := string(data)
+	if strings.Count(content, completionRCMarker) != 1 {
+		t.Errorf("rc wired %d times:\n%s", strings.Count(content, completionRCMarker), content)
+	}
+	if strings.Index(content, "fpath=(") > strings.Index(content, "compinit") {
+		t.Errorf("fpath added after compinit:\n%s", content)
+	}
+
+	installed, ok := installedCompletion("zsh")
+	if !ok || installed.Path != target.Path || !completionWired(installed) {
+		t.Errorf("installed = %+v, %v", installed, ok)
+	}
+}
@@ -0,0 +1,310 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/links"
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/plan"
+	"github.com/spf13/cobra"
+)
+
+func newLinksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "links",
+		Short: "Manage the symlinks declared in links.yaml",
+		Long: `Manage the symlinks declared in links.yaml.
+
+Every root (the blackdot repo and any overlay repos) may ship a links.yaml
+mapping sources in the repo to targets in your home directory:
+
+  backup: rename
+  links:
+    - source: zsh/zshrc
+      target: ~/.zshrc
+      platforms: [unix]
+
+Fields per link:
+  source      Path relative to the root (globs allowed), or absolute
+  target      Destination; a trailing / links each source inside it
+  platforms   darwin, linux, windows, unix (default: all)
+  backup      rename, skip or overwrite an existing real file
+  optional    Report problems as warnings instead of failures
+  feature     Only link when this feature is enabled
+
+Targets may use ~, ${HOME}, ${BLACKDOT_DIR}, ${WORKSPACE_TARGET} and
+environment variables. A later root overrides an earlier one for the same
+target.
+
+On Windows without Developer Mode, directories are linked with junctions and
+files with hard links (or copies across drives).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLinksStatus(false)
+		},
+	}
+
+	var jsonOut bool
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the state of every declared link",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLinksStatus(jsonOut)
+		},
+	}
+	statusCmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON")
+
+	var applyDryRun bool
+	var applyPlanFormat string
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Create or repair declared links",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLinksApply(applyDryRun, applyPlanFormat)
+		},
+	}
+	applyCmd.Flags().BoolVarP(&applyDryRun, "dry-run", "n", false, "Show what would change")
+	addPlanFormatFlag(applyCmd, &applyPlanFormat)
+
+	var restore, removeDryRun bool
+	var removePlanFormat string
+	removeCmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Remove declared links that point into the repo",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLinksRemove(restore, removeDryRun, removePlanFormat)
+		},
+	}
+	removeCmd.Flags().BoolVar(&restore, "restore", false, "Move the latest .bak-* file back into place")
+	removeCmd.Flags().BoolVarP(&removeDryRun, "dry-run", "n", false, "Show what would change")
+	addPlanFormatFlag(removeCmd, &removePlanFormat)
+
+	cmd.AddCommand(statusCmd, applyCmd, removeCmd)
+	return cmd
+}
+
+// declaredLinks resolves links.yaml across the base repo and overlays for
+// this machine
+func declaredLinks() ([]links.Resolved, error) {
+	var roots []links.Root
+	for _, r := range paths.Roots(BlackdotDir()) {
+		roots = append(roots, links.Root{Name: r.Name, Path: r.Path})
+	}
+
+	home, _ := os.UserHomeDir()
+	reg := initRegistry()
+	return links.Resolve(roots, links.Options{
+		Vars: map[string]string{
+			"HOME":             home,
+			"BLACKDOT_DIR":     BlackdotDir(),
+			"WORKSPACE_TARGET": workspaceTarget(),
+		},
+		FeatureEnabled: reg.Enabled,
+	})
+}
+
+// mechanismSuffix labels links that are not plain symlinks, e.g. the
+// junctions and hard links used on Windows without Developer Mode
+func mechanismSuffix(mechanism string) string {
+	if mechanism == "" || mechanism == links.MechanismSymlink {
+		return ""
+	}
+	return Yellow.Sprint(" [" + mechanism + "]")
+}
+
+// tildePath shortens paths under the home directory for display
+func tildePath(p string) string {
+	home, _ := os.UserHomeDir()
+	if home != "" && (p == home || strings.HasPrefix(p, home+string(os.PathSeparator))) {
+		return "~" + p[len(home):]
+	}
+	return p
+}
+
+func runLinksStatus(jsonOut bool) error {
+	resolved, err := declaredLinks()
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+
+	if jsonOut {
+		type linkStatus struct {
+			links.Resolved
+			State     string `json:"state"`
+			Actual    string `json:"actual,omitempty"`
+			Mechanism string `json:"mechanism,omitempty"`
+		}
+		out := make([]linkStatus, 0, len(resolved))
+		for _, r := range resolved {
+			state, actual, mechanism := links.Inspect(r)
+			out = append(out, linkStatus{Resolved: r, State: state, Actual: actual, Mechanism: mechanism})
+		}
+		data, _ := json.MarshalIndent(out, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	PrintHeader("Links")
+	if len(resolved) == 0 {
+		Info("No links declared for this platform (see links.yaml)")
+		return nil
+	}
+
+	problems := 0
+	for _, r := range resolved {
+		state, actual, mechanism := links.Inspect(r)
+		source := tildePath(r.Source)
+		switch state {
+		case links.StateOK:
+			fmt.Printf("  %s %s %s%s\n", Green.Sprint("✓"), tildePath(r.Target), Dim.Sprint("→ "+source), mechanismSuffix(mechanism))
+			continue
+		case links.StateWrongTarget:
+			source = "points to " + tildePath(actual)
+		}
+		mark := Red.Sprint("✗")
+		if r.Optional {
+			mark = Yellow.Sprint("!")
+		} else {
+			problems++
+		}
+		fmt.Printf("  %s %s %s %s\n", mark, tildePath(r.Target), Yellow.Sprint(state), Dim.Sprint(source))
+	}
+
+	fmt.Println()
+	if problems > 0 {
+		Warn("%d link(s) need attention. Fix with: blackdot links apply", problems)
+	} else {
+		Pass("All required links in place")
+	}
+	return nil
+}
+
+func runLinksApply(dryRun bool, planFormat string) error {
+	changes, printPlan, err := startChangePlan("links apply", dryRun, planFormat)
+	if err != nil {
+		return err
+	}
+	resolved, err := declaredLinks()
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+
+	if dryRun {
+		PrintHeader("Links (dry run)")
+	} else {
+		PrintHeader("Applying Links")
+	}
+
+	failed := 0
+	changed := 0
+	for _, r := range resolved {
+		res := links.Apply(r, dryRun)
+		target := tildePath(r.Target)
+		switch res.Action {
+		case links.ActionNone:
+			continue
+		case links.ActionCreated, links.ActionReplaced:
+			changed++
+			if dryRun {
+				detail := res.Action
+				if res.Backup != "" {
+					detail += ", existing file moved to " + tildePath(res.Backup)
+				}
+				changes.Add(plan.Op{Kind: plan.Symlink, Target: r.Target, Before: describeFileForPlan(r.Target), After: "link → " + tildePath(r.Source), Detail: detail})
+				continue
+			}
+			fmt.Printf("  %s %s %s%s\n", Green.Sprint("✓"), target, Dim.Sprint(res.Action+" → "+tildePath(r.Source)), mechanismSuffix(res.Mechanism))
+			if res.Backup != "" {
+				fmt.Printf("      %s\n", Dim.Sprint("existing file moved to "+tildePath(res.Backup)))
+			}
+		case links.ActionSkipped:
+			if r.Optional && res.State == links.StateSourceMissing {
+				continue
+			}
+			if !r.Optional {
+				failed++
+			}
+			fmt.Printf("  %s %s %s\n", Yellow.Sprint("!"), target, Dim.Sprint(res.Err.Error()))
+		case links.ActionFailed:
+			failed++
+			fmt.Printf("  %s %s %s\n", Red.Sprint("✗"), target, res.Err)
+		}
+	}
+	printPlan()
+
+	fmt.Println()
+	if changed == 0 && failed == 0 {
+		Pass("All links already in place")
+		return nil
+	}
+	if failed > 0 {
+		Fail("%d link(s) could not be applied", failed)
+		return fmt.Errorf("%d link(s) failed", failed)
+	}
+	if dryRun {
+		Info("%d link(s) would change. Run without --dry-run to apply.", changed)
+	} else {
+		Pass("%d link(s) updated", changed)
+	}
+	return nil
+}
+
+func runLinksRemove(restore, dryRun bool, planFormat string) error {
+	changes, printPlan, err := startChangePlan("links remove", dryRun, planFormat)
+	if err != nil {
+		return err
+	}
+	resolved, err := declaredLinks()
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+
+	if dryRun {
+		PrintHeader("Remove Links (dry run)")
+	} else {
+		PrintHeader("Removing Links")
+	}
+
+	removed := 0
+	failed := 0
+	for _, r := range resolved {
+		res := links.Remove(r, restore, dryRun)
+		target := tildePath(r.Target)
+		switch res.Action {
+		case links.ActionRemoved, links.ActionRestored:
+			removed++
+			detail := "removed"
+			if res.Action == links.ActionRestored {
+				detail = "restored from " + tildePath(res.Backup)
+			}
+			if dryRun {
+				changes.Add(plan.Op{Kind: plan.Delete, Target: r.Target, Before: describeFileForPlan(r.Target), After: describeFileForPlan(res.Backup), Detail: detail})
+				continue
+			}
+			fmt.Printf("  %s %s %s\n", Green.Sprint("✓"), target, Dim.Sprint(detail))
+		case links.ActionFailed:
+			failed++
+			fmt.Printf("  %s %s %s\n", Red.Sprint("✗"), target, res.Err)
+		}
+	}
+	printPlan()
+
+	fmt.Println()
+	if failed > 0 {
+		Fail("%d link(s) could not be removed", failed)
+		return fmt.Errorf("%d link(s) failed", failed)
+	}
+	if removed == 0 {
+		Info("No declared links to remove")
+	} else if dryRun {
+		Info("%d link(s) would be removed", removed)
+	} else {
+		Pass("%d link(s) removed", removed)
+	}
+	return nil
+}
@@ -192,7 +192,7 @@ func runLint(cmd *cobra.Command, args []string) error {
 	}
 
 	// Also check config directory JSON files
-	configDir := filepath.Join(os.Getenv("HOME"), ".config", "blackdot")
+	configDir := ConfigDir()
 	if configJSON := filepath.Join(configDir, "config.json"); lintFileExists(configJSON) {
 		jsonFiles = append(jsonFiles, configJSON)
 	}
@@ -0,0 +1,433 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/vault"
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/spf13/cobra"
+)
+
+// machineItemPrefix names the vault item each machine's record is kept in
+const machineItemPrefix = "Machine-"
+
+// machineDefaultStaleAfter is machines.stale_after when unset
+const machineDefaultStaleAfter = "30d"
+
+// machineRecord is what a machine publishes about itself. Items are the
+// vault items present on the machine, so the fleet view shows which
+// devices hold which keys.
+type machineRecord struct {
+	ID       string   `json:"id"`
+	Hostname string   `json:"hostname"`
+	OS       string   `json:"os"`
+	Arch     string   `json:"arch"`
+	User     string   `json:"user,omitempty"`
+	Version  string   `json:"version"`
+	Backend  string   `json:"backend"`
+	Features []string `json:"features"`
+	Items    []string `json:"items"`
+	LastSeen string   `json:"last_seen"`
+	LastPull string   `json:"last_pull,omitempty"`
+	LastPush string   `json:"last_push,omitempty"`
+}
+
+var machineIDUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// currentMachineID is machine.identifier, or the short hostname
+func currentMachineID() string {
+	id := configLookup("machine.identifier")
+	if id == "" {
+		id, _ = os.Hostname()
+		id, _, _ = strings.Cut(id, ".")
+	}
+	id = strings.Trim(machineIDUnsafe.ReplaceAllString(id, "-"), "-")
+	if id == "" {
+		id = "unknown"
+	}
+	return id
+}
+
+// collectMachineRecord describes this machine
+func collectMachineRecord(now time.Time) *machineRecord {
+	hostname, _ := os.Hostname()
+	record := &machineRecord{
+		ID:       currentMachineID(),
+		Hostname: hostname,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		User:     os.Getenv("USER"),
+		Version:  versionStr,
+		Backend:  string(getVaultBackend()),
+		Features: collectStatusFeatures().Enabled,
+		Items:    []string{},
+		LastSeen: now.UTC().Format(time.RFC3339),
+		LastPull: configLookup("vault.last_pull"),
+		LastPush: configLookup("vault.last_push"),
+	}
+	for name, path := range driftItems() {
+		if fileExists(path) {
+			record.Items = append(record.Items, name)
+		}
+	}
+	sort.Strings(record.Items)
+	return record
+}
+
+// machineAutoRegister is machines.auto_register (default true): update
+// this machine's record after each vault pull and push
+func machineAutoRegister() bool {
+	return configLookup("machines.auto_register") != "false"
+}
+
+// machineStaleAfter is machines.stale_after as a duration
+func machineStaleAfter(flag string) (time.Duration, error) {
+	if flag == "" {
+		flag = configLookup("machines.stale_after")
+	}
+	if flag == "" {
+		flag = machineDefaultStaleAfter
+	}
+	return parseItemAge(flag)
+}
+
+// pushMachineRecord writes this machine's record to the primary backend.
+// It skips the pre-write sync: the item is only ever written by this
+// machine.
+func pushMachineRecord(ctx context.Context, svc *vault.Service) (*machineRecord, error) {
+	session, err := svc.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record := collectMachineRecord(time.Now())
+	data, _ := json.MarshalIndent(record, "", "  ")
+	name := machineItemPrefix + record.ID
+	backend := svc.Backend()
+	existing, err := backend.GetNotes(ctx, name, session)
+	if err != nil && !errors.Is(err, vaultmux.ErrNotFound) {
+		return nil, err
+	}
+	if existing != "" {
+		return record, backend.UpdateItem(ctx, name, string(data), session)
+	}
+	return record, backend.CreateItem(ctx, name, string(data), session)
+}
+
+// registerMachineAfterSync updates the record after a pull or push.
+// Failures only warn; the sync itself succeeded.
+func registerMachineAfterSync() {
+	if !machineAutoRegister() || isOfflineMode() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	svc, err := newVaultService()
+	if err != nil {
+		Warn("Could not update machine record: %v", err)
+		return
+	}
+	defer svc.Close()
+	if _, err := pushMachineRecord(ctx, svc); err != nil {
+		Warn("Could not update machine record: %v", err)
+		return
+	}
+	Debug("Updated %s%s", machineItemPrefix, currentMachineID())
+}
+
+// loadMachineRecords reads every machine record in the vault. Items that
+// do not parse are skipped with a warning.
+func loadMachineRecords(ctx context.Context, svc *vault.Service) ([]*machineRecord, error) {
+	items, err := svc.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	session, err := svc.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var records []*machineRecord
+	for _, item := range items {
+		if !strings.HasPrefix(item.Name, machineItemPrefix) {
+			continue
+		}
+		notes := item.Notes
+		if notes == "" {
+			if notes, err = svc.Backend().GetNotes(ctx, item.Name, session); err != nil {
+				Warn("%s: %v", item.Name, err)
+				continue
+			}
+		}
+		var record machineRecord
+		if err := json.Unmarshal([]byte(notes), &record); err != nil || record.ID == "" {
+			Warn("%s: not a machine record", item.Name)
+			continue
+		}
+		records = append(records, &record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
+
+// machineWarnings are the staleness problems of one record: not seen,
+// or not pulled secrets, within staleAfter
+func machineWarnings(r *machineRecord, staleAfter time.Duration, now time.Time) []string {
+	var warnings []string
+	days := func(ts string) (int, bool) {
+		t, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			return 0, false
+		}
+		return int(now.Sub(t).Hours() / 24), now.Sub(t) > staleAfter
+	}
+	if n, stale := days(r.LastSeen); stale {
+		warnings = append(warnings, fmt.Sprintf("%s hasn't been seen in %d days", r.ID, n))
+	}
+	if r.LastPull == "" {
+		warnings = append(warnings, fmt.Sprintf("%s has never pulled secrets", r.ID))
+	} else if n, stale := days(r.LastPull); stale {
+		warnings = append(warnings, fmt.Sprintf("%s hasn't pulled secrets in %d days", r.ID, n))
+	}
+	return warnings
+}
+
+func newMachinesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "machines",
+		Aliases: []string{"machine"},
+		Short:   "Inventory of machines using this vault",
+		Long: `Track which machines use your vault and what they hold.
+
+Each machine keeps a small record in the vault (` + machineItemPrefix + `<id>):
+hostname, OS, blackdot version, enabled features, the vault items
+present on it, and when it last ran, pulled, and pushed. The record is
+updated after every 'vault pull' and 'vault push'; turn that off with
+'blackdot config set user machines.auto_register false'.
+
+The id is machine.identifier from the machine config, or the short
+hostname.
+
+Commands:
+  list             Show every machine, with staleness warnings (default)
+  register         Update this machine's record now
+  show <id>        Show one machine's record
+  forget <id>      Delete a machine's record (e.g. a retired laptop)
+
+A machine is stale when it has not been seen, or has not pulled
+secrets, for machines.stale_after (default ` + machineDefaultStaleAfter + `).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return machinesList("", false)
+		},
+	}
+
+	var staleAfter string
+	var jsonOut bool
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "Show every machine",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return machinesList(staleAfter, jsonOut)
+		},
+	}
+	listCmd.Flags().StringVar(&staleAfter, "stale-after", "", "Warn about machines idle this long (default machines.stale_after or "+machineDefaultStaleAfter+")")
+	listCmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON")
+
+	var yes bool
+	forgetCmd := &cobra.Command{
+		Use:   "forget <id>",
+		Short: "Delete a machine's record",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return machinesForget(args[0], yes)
+		},
+	}
+	forgetCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation")
+
+	cmd.AddCommand(listCmd, &cobra.Command{
+		Use:   "register",
+		Short: "Update this machine's record in the vault",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return machinesRegister()
+		},
+	}, &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show one machine's record",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return machinesShow(args[0])
+		},
+	}, forgetCmd)
+	return cmd
+}
+
+// openMachinesService connects to the primary backend
+func openMachinesService() (*vault.Service, context.Context, context.CancelFunc, error) {
+	if isOfflineMode() {
+		err := errors.New("BLACKDOT_OFFLINE=1 prevents reading the vault")
+		Fail("%v", err)
+		return nil, nil, nil, err
+	}
+	svc, err := newVaultService()
+	if err != nil {
+		Fail("Failed to create backend: %v", err)
+		return nil, nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	return svc, ctx, cancel, nil
+}
+
+func machinesRegister() error {
+	svc, ctx, cancel, err := openMachinesService()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer svc.Close()
+
+	record, err := pushMachineRecord(ctx, svc)
+	if err != nil {
+		failVaultOp("Failed to update machine record", err)
+		return err
+	}
+	Pass("Registered %s (%s/%s, %d vault items)", record.ID, record.OS, record.Arch, len(record.Items))
+	return nil
+}
+
+func machinesList(staleFlag string, jsonOut bool) error {
+	staleAfter, err := machineStaleAfter(staleFlag)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+	svc, ctx, cancel, err := openMachinesService()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer svc.Close()
+
+	records, err := loadMachineRecords(ctx, svc)
+	if err != nil {
+		failVaultOp("Failed to list machines", err)
+		return err
+	}
+	if jsonOut {
+		if records == nil {
+			records = []*machineRecord{}
+		}
+		data, _ := json.MarshalIndent(records, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	PrintHeader("Machines")
+	if len(records) == 0 {
+		Info("No machines registered yet")
+		fmt.Println("Register this one with: blackdot machines register")
+		return nil
+	}
+
+	now := time.Now()
+	self := currentMachineID()
+	var warnings []string
+	for _, r := range records {
+		seen, _ := parseTimestamp(r.LastSeen)
+		pulled := "never"
+		if r.LastPull != "" {
+			pulled, _ = parseTimestamp(r.LastPull)
+		}
+		name := r.ID
+		if r.ID == self {
+			name += " *"
+		}
+		fmt.Printf("  %-22s %-14s %s  %s  %s\n", name, r.OS+"/"+r.Arch,
+			Dim.Sprintf("seen %s", seen), Dim.Sprintf("pulled %s", pulled), Dim.Sprintf("%d items", len(r.Items)))
+		warnings = append(warnings, machineWarnings(r, staleAfter, now)...)
+	}
+	fmt.Println()
+	for _, w := range warnings {
+		Warn("%s", w)
+	}
+	Info("%d machine(s); * is this one", len(records))
+	return nil
+}
+
+func machinesShow(id string) error {
+	svc, ctx, cancel, err := openMachinesService()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer svc.Close()
+
+	records, err := loadMachineRecords(ctx, svc)
+	if err != nil {
+		failVaultOp("Failed to list machines", err)
+		return err
+	}
+	for _, r := range records {
+		if r.ID != id {
+			continue
+		}
+		PrintHeader("Machine " + r.ID)
+		fmt.Printf("  Hostname:  %s\n", r.Hostname)
+		fmt.Printf("  Platform:  %s/%s\n", r.OS, r.Arch)
+		if r.User != "" {
+			fmt.Printf("  User:      %s\n", r.User)
+		}
+		fmt.Printf("  Version:   %s\n", r.Version)
+		fmt.Printf("  Backend:   %s\n", r.Backend)
+		fmt.Printf("  Last seen: %s\n", r.LastSeen)
+		fmt.Printf("  Last pull: %s\n", valueOr(r.LastPull, "never"))
+		fmt.Printf("  Last push: %s\n", valueOr(r.LastPush, "never"))
+		fmt.Printf("  Features:  %s\n", valueOr(strings.Join(r.Features, ", "), "none"))
+		fmt.Println()
+		fmt.Printf("  Vault items on this machine (%d):\n", len(r.Items))
+		for _, item := range r.Items {
+			fmt.Printf("    %s\n", item)
+		}
+		return nil
+	}
+	err = fmt.Errorf("no machine %q", id)
+	Fail("%v", err)
+	return err
+}
+
+func machinesForget(id string, yes bool) error {
+	svc, ctx, cancel, err := openMachinesService()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer svc.Close()
+
+	result := svc.DeleteItem(ctx, machineItemPrefix+id, confirmVaultDelete(yes))
+	switch result.Status {
+	case vault.DeleteDeleted:
+		Pass("Forgot %s", id)
+	case vault.DeleteNotFound:
+		Warn("No machine %q", id)
+	case vault.DeleteFailed:
+		failVaultOp("Failed to delete "+result.Name, result.Err)
+		return result.Err
+	}
+	return nil
+}
+
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestMachineWarnings verifies machines are flagged when not seen or not
+// pulled within the stale window
+func TestMachineWarnings(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(days int) string { return now.AddDate(0, 0, -days).Format(time.RFC3339) }
+
+	cases := []struct {
+		record machineRecord
+		want   []string
+	}{
+		{machineRecord{ID: "desk", LastSeen: ago(1), LastPull: ago(2)}, nil},
+		{machineRecord{ID: "laptop-x", LastSeen: ago(3), LastPull: ago(45)}, []string{"laptop-x hasn't pulled secrets in 45 days"}},
+		{machineRecord{ID: "old", LastSeen: ago(60)}, []string{"old hasn't been seen in 60 days", "old has never pulled secrets"}},
+	}
+	for _, c := range cases {
+		if got := machineWarnings(&c.record, 30*24*time.Hour, now); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %q, want %q", c.record.ID, got, c.want)
+		}
+	}
+}
+
+// TestCollectMachineRecord verifies the id comes from machine.identifier
+// and only vault items present locally are listed
+func TestCollectMachineRecord(t *testing.T) {
+	setScheduleEnv(t, `{"vault": {"last_pull": "2025-05-01T10:00:00Z"}}`)
+	home := os.Getenv("HOME")
+	dir := filepath.Join(home, ".blackdot")
+	t.Setenv("BLACKDOT_DIR", dir)
+	t.Cleanup(initConfig)
+	initConfig()
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(configLayerMachine, []byte(`{"machine": {"identifier": "work mac"}}`), 0600)
+	os.WriteFile(filepath.Join(dir, "vault-items.json"), []byte(`{"vault_items": {
+		"Git-Config": {"path": "~/.gitconfig", "required": true, "type": "file"},
+		"AWS-Config": {"path": "~/.aws/config", "required": false, "type": "file"}
+	}}`), 0644)
+	os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[user]\n"), 0644)
+
+	record := collectMachineRecord(time.Now())
+	if record.ID != "work-mac" {
+		t.Errorf("id = %q", record.ID)
+	}
+	if !reflect.DeepEqual(record.Items, []string{"Git-Config"}) {
+		t.Errorf("items = %v", record.Items)
+	}
+	if record.LastPull != "2025-05-01T10:00:00Z" {
+		t.Errorf("last pull = %q", record.LastPull)
+	}
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// manPageDate is the date printed in man pages. SOURCE_DATE_EPOCH makes
+// release builds reproducible.
+func manPageDate() time.Time {
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		if secs, err := strconv.ParseInt(epoch, 10, 64); err == nil {
+			return time.Unix(secs, 0).UTC()
+		}
+	}
+	return time.Now().UTC()
+}
+
+// manPageName is the man page of a command: "blackdot-vault-list"
+func manPageName(cmd *cobra.Command) string {
+	return strings.ReplaceAll(cmd.CommandPath(), " ", "-")
+}
+
+// genManPages writes a section 1 man page for every visible command under
+// root into dir and returns the files written
+func genManPages(root *cobra.Command, dir string, date time.Time) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var written []string
+	var walk func(cmd *cobra.Command) error
+	walk = func(cmd *cobra.Command) error {
+		if !cmd.IsAvailableCommand() && cmd != root {
+			return nil
+		}
+		path := filepath.Join(dir, manPageName(cmd)+".1")
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		writeManPage(f, cmd, date)
+		if err := f.Close(); err != nil {
+			return err
+		}
+		written = append(written, path)
+		for _, sub := range cmd.Commands() {
+			if err := walk(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return written, walk(root)
+}
+
+// writeManPage renders one command as roff
+func writeManPage(w io.Writer, cmd *cobra.Command, date time.Time) {
+	name := manPageName(cmd)
+	fmt.Fprintf(w, ".TH \"%s\" \"1\" \"%s\" \"blackdot %s\" \"Blackdot Manual\"\n",
+		strings.ToUpper(name), date.Format("Jan 2006"), roffEscape(versionStr))
+
+	fmt.Fprintf(w, ".SH NAME\n%s \\- %s\n", name, roffEscape(cmd.Short))
+
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B %s\n", roffEscape(cmd.CommandPath()))
+	if use := strings.TrimSpace(strings.TrimPrefix(cmd.UseLine(), cmd.CommandPath())); use != "" {
+		fmt.Fprintln(w, roffLine(use))
+	}
+
+	if long := strings.TrimSpace(cmd.Long); long != "" {
+		fmt.Fprintln(w, ".SH DESCRIPTION\n.nf")
+		for _, line := range strings.Split(long, "\n") {
+			fmt.Fprintln(w, roffLine(line))
+		}
+		fmt.Fprintln(w, ".fi")
+	} else if cmd.Short != "" {
+		fmt.Fprintf(w, ".SH DESCRIPTION\n%s\n", roffLine(cmd.Short))
+	}
+
+	writeManFlags(w, "OPTIONS", cmd.NonInheritedFlags())
+	writeManFlags(w, "GLOBAL OPTIONS", cmd.InheritedFlags())
+
+	var seeAlso []string
+	if parent := cmd.Parent(); parent != nil {
+		seeAlso = append(seeAlso, manPageName(parent))
+	}
+	for _, sub := range cmd.Commands() {
+		if sub.IsAvailableCommand() {
+			seeAlso = append(seeAlso, manPageName(sub))
+		}
+	}
+	if len(seeAlso) > 0 {
+		sort.Strings(seeAlso)
+		refs := make([]string, len(seeAlso))
+		for i, s := range seeAlso {
+			refs[i] = fmt.Sprintf("\\fB%s\\fP(1)", roffEscape(s))
+		}
+		fmt.Fprintf(w, ".SH SEE ALSO\n%s\n", strings.Join(refs, ", "))
+	}
+}
+
+func writeManFlags(w io.Writer, title string, flags *pflag.FlagSet) {
+	var entries []*pflag.Flag
+	flags.VisitAll(func(f *pflag.Flag) {
+		if !f.Hidden {
+			entries = append(entries, f)
+		}
+	})
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Fprintf(w, ".SH %s\n", title)
+	for _, f := range entries {
+		fmt.Fprintln(w, ".TP")
+		flag := "\\fB\\-\\-" + roffEscape(f.Name) + "\\fP"
+		if f.Shorthand != "" {
+			flag = "\\fB\\-" + f.Shorthand + "\\fP, " + flag
+		}
+		if f.Value.Type() != "bool" {
+			flag += " \\fI" + roffEscape(f.Value.Type()) + "\\fP"
+		}
+		fmt.Fprintln(w, flag)
+		usage := f.Usage
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "[]" && f.DefValue != "0" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(w, roffLine(usage))
+	}
+}
+
+// roffEscape escapes backslashes and hyphens for roff
+func roffEscape(s string) string {
+	return strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+}
+
+// roffLine escapes a line of text, guarding lines that roff would read as
+// a request
+func roffLine(s string) string {
+	s = roffEscape(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGenManPages verifies every visible command gets a page, hidden ones
+// do not, and flags are rendered with roff escaping
+func TestGenManPages(t *testing.T) {
+	dir := t.TempDir()
+	files, err := genManPages(rootCmd, dir, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 10 {
+		t.Fatalf("only %d man pages written", len(files))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "blackdot-release.1")); err == nil {
+		t.Error("hidden command got a man page")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "blackdot-env-export.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+	for _, want := range []string{
+		`.TH "BLACKDOT-ENV-EXPORT" "1" "Jan 2026"`,
+		`blackdot-env-export \- Print environment secrets`,
+		`\fB\-f\fP, \fB\-\-format\fP \fIstring\fP`,
+		`\fBblackdot\-env\fP(1)`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+}
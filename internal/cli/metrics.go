@@ -15,6 +15,7 @@ import (
 
 // MetricEntry represents a single health check metric
 type MetricEntry struct {
+	Kind        string `json:"kind,omitempty"` // empty for health checks
 	Timestamp   string `json:"timestamp"`
 	HealthScore int    `json:"health_score"`
 	Errors      int    `json:"errors"`
@@ -37,12 +38,17 @@ Modes:
   --graph, -g   ASCII bar chart of health scores (last 30)
   --all, -a     Show all metric entries
 
+'blackdot metrics report' summarizes command timings recorded with
+--timings or metrics.timings.
+
 Examples:
   blackdot metrics           # Summary view
   blackdot metrics --graph   # Health score trend
-  blackdot metrics --all     # All entries`,
+  blackdot metrics --all     # All entries
+  blackdot metrics report    # Slowest commands and phases`,
 		RunE: runMetrics,
 	}
+	cmd.AddCommand(newMetricsReportCmd())
 
 	cmd.Flags().BoolP("all", "a", false, "Show all metric entries")
 	cmd.Flags().BoolP("graph", "g", false, "Show health score graph (last 30)")
@@ -50,9 +56,14 @@ Examples:
 	return cmd
 }
 
-func runMetrics(cmd *cobra.Command, args []string) error {
+// metricsPath is the JSONL log doctor results and command timings go to
+func metricsPath() string {
 	home, _ := os.UserHomeDir()
-	metricsFile := filepath.Join(home, ".blackdot-metrics.jsonl")
+	return filepath.Join(home, ".blackdot-metrics.jsonl")
+}
+
+func runMetrics(cmd *cobra.Command, args []string) error {
+	metricsFile := metricsPath()
 
 	showAll, _ := cmd.Flags().GetBool("all")
 	showGraph, _ := cmd.Flags().GetBool("graph")
@@ -275,6 +286,10 @@ func loadMetrics(path string) ([]MetricEntry, error) {
 			// Skip malformed lines
 			continue
 		}
+		if entry.Kind != "" {
+			// Timing records are read by loadTimingRecords
+			continue
+		}
 		entries = append(entries, entry)
 	}
 
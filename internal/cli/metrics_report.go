@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// commandTimingStats summarizes the recorded runs of one command
+type commandTimingStats struct {
+	Command  string  `json:"command"`
+	Runs     int     `json:"runs"`
+	Failures int     `json:"failures"`
+	AvgMs    float64 `json:"avg_ms"`
+	P95Ms    float64 `json:"p95_ms"`
+	MaxMs    float64 `json:"max_ms"`
+	LastMs   float64 `json:"last_ms"`
+}
+
+// phaseTimingStats summarizes one phase across all recorded runs
+type phaseTimingStats struct {
+	Phase   string  `json:"phase"`
+	Calls   int     `json:"calls"`
+	TotalMs float64 `json:"total_ms"`
+	AvgMs   float64 `json:"avg_ms"` // per call
+	MaxMs   float64 `json:"max_ms"` // most spent in one run
+}
+
+// timingReport is the output of metrics report
+type timingReport struct {
+	Runs     int                  `json:"runs"`
+	From     string               `json:"from,omitempty"`
+	To       string               `json:"to,omitempty"`
+	Commands []commandTimingStats `json:"commands"`
+	Phases   []phaseTimingStats   `json:"phases"`
+	Slowest  []timingRecord       `json:"slowest"`
+}
+
+func newMetricsReportCmd() *cobra.Command {
+	var since, command string
+	var top int
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarize recorded command and phase timings",
+		Long: `Summarize the command timings in the metrics log: the slowest commands,
+where their time goes (vault backend calls, template renders), and the
+slowest individual runs.
+
+Timings are recorded for a single run with --timings, or for every run
+with:
+
+  blackdot config set user metrics.timings true
+
+Examples:
+  blackdot metrics report
+  blackdot metrics report --since 7d
+  blackdot metrics report --command "blackdot vault" --top 5
+  blackdot vault pull --timings      # Print one run's breakdown`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var cutoff time.Time
+			if since != "" {
+				d, err := parseScheduleInterval(since)
+				if err != nil {
+					return fmt.Errorf("--since: %w", err)
+				}
+				cutoff = time.Now().Add(-d)
+			}
+			records, err := loadTimingRecords(metricsPath())
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("loading metrics: %w", err)
+			}
+			report := buildTimingReport(filterTimingRecords(records, cutoff, command), top)
+			if jsonOutput {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			printTimingReport(report)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "Only runs within this window (e.g. 24h, 7d)")
+	cmd.Flags().StringVarP(&command, "command", "c", "", "Only commands starting with this (e.g. \"blackdot vault\")")
+	cmd.Flags().IntVarP(&top, "top", "n", 10, "Rows per table")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	return cmd
+}
+
+// loadTimingRecords reads the timing entries from the metrics log, oldest
+// first
+func loadTimingRecords(path string) ([]timingRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []timingRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec timingRecord
+		if json.Unmarshal(scanner.Bytes(), &rec) != nil || rec.Kind != timingKind {
+			continue
+		}
+		records = append(records, rec)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Timestamp < records[j].Timestamp })
+	return records, scanner.Err()
+}
+
+// filterTimingRecords keeps runs after cutoff (when set) whose command
+// starts with prefix
+func filterTimingRecords(records []timingRecord, cutoff time.Time, prefix string) []timingRecord {
+	var out []timingRecord
+	for _, rec := range records {
+		if !cutoff.IsZero() {
+			if t, err := time.Parse(time.RFC3339, rec.Timestamp); err != nil || t.Before(cutoff) {
+				continue
+			}
+		}
+		if prefix != "" && !strings.HasPrefix(rec.Command, prefix) {
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+// buildTimingReport aggregates records; top limits each table
+func buildTimingReport(records []timingRecord, top int) timingReport {
+	report := timingReport{Runs: len(records), Commands: []commandTimingStats{}, Phases: []phaseTimingStats{}, Slowest: []timingRecord{}}
+	if len(records) == 0 {
+		return report
+	}
+	report.From, report.To = records[0].Timestamp, records[len(records)-1].Timestamp
+
+	durations := make(map[string][]float64)
+	commands := make(map[string]*commandTimingStats)
+	phases := make(map[string]*phaseTimingStats)
+	for _, rec := range records {
+		c, ok := commands[rec.Command]
+		if !ok {
+			c = &commandTimingStats{Command: rec.Command}
+			commands[rec.Command] = c
+		}
+		c.Runs++
+		if !rec.OK {
+			c.Failures++
+		}
+		c.LastMs = rec.DurationMs
+		durations[rec.Command] = append(durations[rec.Command], rec.DurationMs)
+
+		for _, ph := range rec.Phases {
+			p, ok := phases[ph.Name]
+			if !ok {
+				p = &phaseTimingStats{Phase: ph.Name}
+				phases[ph.Name] = p
+			}
+			p.Calls += ph.Count
+			p.TotalMs += ph.DurationMs
+			if ph.DurationMs > p.MaxMs {
+				p.MaxMs = ph.DurationMs
+			}
+		}
+	}
+
+	for name, c := range commands {
+		ds := durations[name]
+		sort.Float64s(ds)
+		var sum float64
+		for _, d := range ds {
+			sum += d
+		}
+		c.AvgMs = sum / float64(len(ds))
+		c.P95Ms = ds[(len(ds)*95+99)/100-1]
+		c.MaxMs = ds[len(ds)-1]
+		report.Commands = append(report.Commands, *c)
+	}
+	sort.Slice(report.Commands, func(i, j int) bool { return report.Commands[i].AvgMs > report.Commands[j].AvgMs })
+
+	for _, p := range phases {
+		if p.Calls > 0 {
+			p.AvgMs = p.TotalMs / float64(p.Calls)
+		}
+		report.Phases = append(report.Phases, *p)
+	}
+	sort.Slice(report.Phases, func(i, j int) bool { return report.Phases[i].TotalMs > report.Phases[j].TotalMs })
+
+	report.Slowest = append(report.Slowest, records...)
+	sort.SliceStable(report.Slowest, func(i, j int) bool { return report.Slowest[i].DurationMs > report.Slowest[j].DurationMs })
+
+	if top > 0 {
+		report.Commands = report.Commands[:min(top, len(report.Commands))]
+		report.Phases = report.Phases[:min(top, len(report.Phases))]
+		report.Slowest = report.Slowest[:min(top, len(report.Slowest))]
+	}
+	return report
+}
+
+func printTimingReport(report timingReport) {
+	if report.Runs == 0 {
+		fmt.Println("No timings recorded. Run a command with --timings, or set metrics.timings to true.")
+		return
+	}
+
+	fmt.Println()
+	Bold.Println("=== Command Timings ===")
+	from, _ := parseTimestamp(report.From)
+	to, _ := parseTimestamp(report.To)
+	Dim.Printf("%d runs, %s to %s\n", report.Runs, from, to)
+
+	fmt.Println()
+	Bold.Println("Slowest commands (by average):")
+	fmt.Printf("  %-34s %5s %9s %9s %9s\n", "COMMAND", "RUNS", "AVG", "P95", "MAX")
+	for _, c := range report.Commands {
+		failed := ""
+		if c.Failures > 0 {
+			failed = Red.Sprintf(" %d failed", c.Failures)
+		}
+		fmt.Printf("  %-34s %5d %9s %9s %9s%s\n", c.Command, c.Runs, formatMs(c.AvgMs), formatMs(c.P95Ms), formatMs(c.MaxMs), failed)
+	}
+
+	if len(report.Phases) > 0 {
+		fmt.Println()
+		Bold.Println("Where the time goes (by total):")
+		fmt.Printf("  %-34s %5s %9s %9s %9s\n", "PHASE", "CALLS", "TOTAL", "AVG", "MAX/RUN")
+		for _, p := range report.Phases {
+			fmt.Printf("  %-34s %5d %9s %9s %9s\n", p.Phase, p.Calls, formatMs(p.TotalMs), formatMs(p.AvgMs), formatMs(p.MaxMs))
+		}
+	}
+
+	fmt.Println()
+	Bold.Println("Slowest runs:")
+	for _, rec := range report.Slowest {
+		date, clock := parseTimestamp(rec.Timestamp)
+		slowest := ""
+		if len(rec.Phases) > 0 {
+			p := rec.Phases[0]
+			for _, ph := range rec.Phases[1:] {
+				if ph.DurationMs > p.DurationMs {
+					p = ph
+				}
+			}
+			slowest = Dim.Sprintf("  (%s %s)", p.Name, formatMs(p.DurationMs))
+		}
+		fmt.Printf("  %s %-8s %-34s %9s%s\n", date, strings.TrimSuffix(clock, "Z"), rec.Command, formatMs(rec.DurationMs), slowest)
+	}
+	fmt.Println()
+}
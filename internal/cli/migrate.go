@@ -0,0 +1,626 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/links"
+	"github.com/blackwell-systems/blackdot/internal/scanners"
+	"github.com/spf13/cobra"
+)
+
+// Tools blackdot migrate reads
+const (
+	migrateFromChezmoi = "chezmoi"
+	migrateFromStow    = "stow"
+	migrateFromYadm    = "yadm"
+)
+
+// What becomes of a file from the other tool
+const (
+	migrateLink     = "link"     // copied into the repo and linked
+	migrateTemplate = "template" // converted to a blackdot template
+	migrateSecret   = "secret"   // left out of the repo, for the vault
+	migrateSkipped  = "skipped"  // nothing blackdot can do with it
+)
+
+// Files written in the repo besides the migrated files
+const (
+	migrateReportName     = "migration-report.md"
+	migrateVaultItemsName = "vault/vault-items.migrated.json"
+)
+
+type migrateOptions struct {
+	from   string
+	source string
+	config string
+	dryRun bool
+	report string
+}
+
+// migrateEntry is one file from the other tool and what becomes of it
+type migrateEntry struct {
+	Kind      string
+	From      string // path in the other tool's source state
+	Target    string // slash path relative to home
+	Repo      string // slash path written in the repo
+	Source    string // links.yaml source: Repo, generated/<name>, or absolute
+	Platforms []string
+	Mode      os.FileMode
+	Content   []byte
+	Note      string
+}
+
+// migration is what was read from the other tool
+type migration struct {
+	from      string
+	source    string
+	entries   []migrateEntry
+	variables map[string]string
+
+	scanner   *scanners.Scanner
+	templates map[string]bool // template names taken
+}
+
+func newMigration(from, source string) *migration {
+	return &migration{
+		from:      from,
+		source:    source,
+		variables: map[string]string{},
+		scanner:   scanners.New(),
+		templates: map[string]bool{},
+	}
+}
+
+func newMigrateCmd() *cobra.Command {
+	var opts migrateOptions
+	cmd := &cobra.Command{
+		Use:   "migrate --from chezmoi|stow|yadm",
+		Short: "Migrate from chezmoi, GNU stow or yadm",
+		Long: `Move dotfiles managed by another tool into the blackdot repo.
+
+The other tool's source state is read, not changed:
+
+  chezmoi   ~/.local/share/chezmoi: dot_/private_/executable_ names,
+            Go templates (converted to Handlebars), [data] in chezmoi.toml
+            (written to templates/_variables.local.sh), symlink_ entries
+  stow      ~/dotfiles or ~/.dotfiles: one directory per package,
+            dot- names, .stow-local-ignore
+  yadm      ~/.local/share/yadm/repo.git: tracked files, ##os alternates
+            (as link platforms), ##template files, the encrypt list
+
+Plain files are copied into the repo (stow packages keep their directory,
+chezmoi and yadm files go under home/) and linked from links.yaml;
+templates go to templates/configs/ and are linked from generated/. Files
+that hold credentials, or are encrypted by the other tool, are not copied:
+they are listed in vault/vault-items.migrated.json for the vault. Scripts,
+modify_ entries and alternates without a blackdot equivalent are skipped.
+
+Everything is written to a migration report, migration-report.md in the
+repo unless --report is given. Files already in the repo and links already
+declared are kept.`,
+		Example: `  blackdot migrate --from chezmoi --dry-run   # Show what would be migrated
+  blackdot migrate --from stow --source ~/dots
+  blackdot migrate --from yadm --report ~/yadm-migration.md`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigrate(opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.from, "from", "", "Tool to migrate from: chezmoi, stow, yadm")
+	cmd.Flags().StringVarP(&opts.source, "source", "s", "", "The tool's source directory (yadm: its git repo)")
+	cmd.Flags().StringVar(&opts.config, "config", "", "chezmoi config file (default ~/.config/chezmoi/chezmoi.toml)")
+	cmd.Flags().BoolVarP(&opts.dryRun, "dry-run", "n", false, "Show the migration without writing anything")
+	cmd.Flags().StringVar(&opts.report, "report", "", "Where to write the migration report")
+	cmd.MarkFlagRequired("from")
+	return cmd
+}
+
+func runMigrate(opts migrateOptions) error {
+	home, _ := os.UserHomeDir()
+	source, err := migrateSourceDir(opts.from, opts.source, home)
+	if err != nil {
+		return err
+	}
+
+	var m *migration
+	switch opts.from {
+	case migrateFromChezmoi:
+		config := opts.config
+		if config == "" {
+			config = filepath.Join(home, ".config", "chezmoi", "chezmoi.toml")
+		}
+		m, err = readChezmoiSource(source, expandPath(config))
+	case migrateFromStow:
+		m, err = readStowSource(source)
+	case migrateFromYadm:
+		m, err = readYadmSource(source, home)
+	}
+	if err != nil {
+		return err
+	}
+
+	blackdotDir := getBlackdotDir()
+	reportPath := filepath.Join(blackdotDir, migrateReportName)
+	if opts.report != "" {
+		reportPath = expandPath(opts.report)
+	}
+
+	if opts.dryRun {
+		PrintHeader("Migrate from " + m.from + " (dry run)")
+	} else {
+		PrintHeader("Migrate from " + m.from)
+	}
+	Info("Source: %s", tildePath(source))
+	Info("Repo:   %s", tildePath(blackdotDir))
+	fmt.Println()
+
+	res, err := m.apply(blackdotDir, opts.dryRun)
+	if err != nil {
+		Fail("Migration failed: %v", err)
+		return err
+	}
+	printMigration(m, res, opts.dryRun)
+
+	report := m.report(blackdotDir, res, time.Now())
+	fmt.Println()
+	if opts.dryRun {
+		Info("Would write the migration report to %s", tildePath(reportPath))
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(reportPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(reportPath, []byte(report), 0644); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	Pass("Report written to %s", tildePath(reportPath))
+
+	fmt.Println()
+	Info("Next steps:")
+	fmt.Println("  review " + tildePath(reportPath))
+	if m.count(migrateTemplate) > 0 {
+		fmt.Println("  blackdot template render")
+	}
+	fmt.Println("  blackdot links apply --dry-run && blackdot links apply")
+	if m.count(migrateSecret) > 0 {
+		fmt.Printf("  merge %s into %s, then: blackdot vault push --all\n",
+			migrateVaultItemsName, tildePath(filepath.Join(ConfigDir(), "vault-items.json")))
+	}
+	return nil
+}
+
+// migrateSourceDir is --source, or where the tool keeps its state by
+// default
+func migrateSourceDir(from, source, home string) (string, error) {
+	var candidates []string
+	switch from {
+	case migrateFromChezmoi:
+		candidates = []string{filepath.Join(home, ".local", "share", "chezmoi")}
+	case migrateFromStow:
+		candidates = []string{filepath.Join(home, "dotfiles"), filepath.Join(home, ".dotfiles")}
+	case migrateFromYadm:
+		candidates = []string{filepath.Join(home, ".local", "share", "yadm", "repo.git"), filepath.Join(home, ".config", "yadm", "repo.git")}
+	default:
+		return "", fmt.Errorf("unknown --from %q (use chezmoi, stow or yadm)", from)
+	}
+	if source != "" {
+		candidates = []string{expandPath(source)}
+	}
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return filepath.Abs(dir)
+		}
+	}
+	if source != "" {
+		return "", fmt.Errorf("%s source not found: %s", from, candidates[0])
+	}
+	return "", fmt.Errorf("no %s source found in %s; use --source", from, tildePath(candidates[0]))
+}
+
+// addFile adds a plain file: linked from the repo, or kept for the vault
+// when it looks like it holds a credential
+func (m *migration) addFile(from, target, repo string, data []byte, mode os.FileMode, platforms []string) {
+	if reason := m.secretReason(target, data); reason != "" {
+		m.addSecret(from, target, reason)
+		return
+	}
+	m.entries = append(m.entries, migrateEntry{
+		Kind: migrateLink, From: from, Target: target, Repo: repo, Source: repo,
+		Platforms: platforms, Mode: mode, Content: data,
+	})
+}
+
+// addTemplate adds a converted template, named after its target since
+// templates are rendered flat into generated/
+func (m *migration) addTemplate(from, target string, content []byte, platforms []string, note string) {
+	if reason := m.secretReason(target, content); reason != "" {
+		m.addSecret(from, target, reason)
+		return
+	}
+	name := m.templateName(target)
+	m.entries = append(m.entries, migrateEntry{
+		Kind: migrateTemplate, From: from, Target: target,
+		Repo: "templates/configs/" + name + ".tmpl", Source: "generated/" + name,
+		Platforms: platforms, Mode: 0644, Content: content, Note: note,
+	})
+}
+
+// addSymlink links target straight to an absolute path
+func (m *migration) addSymlink(from, target, dest string) {
+	m.entries = append(m.entries, migrateEntry{Kind: migrateLink, From: from, Target: target, Source: dest})
+}
+
+func (m *migration) addSecret(from, target, reason string) {
+	m.entries = append(m.entries, migrateEntry{Kind: migrateSecret, From: from, Target: target, Note: reason})
+}
+
+func (m *migration) skip(from, reason string) {
+	m.entries = append(m.entries, migrateEntry{Kind: migrateSkipped, From: from, Note: reason})
+}
+
+func (m *migration) count(kind string) int {
+	n := 0
+	for _, e := range m.entries {
+		if e.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// secretReason says why a file looks like it holds a credential, or ""
+func (m *migration) secretReason(target string, data []byte) string {
+	if secretFileNames.MatchString(target) {
+		return "named like a credential file"
+	}
+	if findings := m.scanner.Scan(target, data); len(findings) > 0 {
+		return fmt.Sprintf("%d potential credential(s), e.g. %s on line %d", len(findings), findings[0].Description, findings[0].Line)
+	}
+	return ""
+}
+
+// templateName flattens a target path to a free template name:
+// .config/git/config becomes config-git-config
+func (m *migration) templateName(target string) string {
+	parts := strings.Split(target, "/")
+	for i, p := range parts {
+		parts[i] = strings.TrimLeft(p, ".")
+	}
+	base := strings.Join(parts, "-")
+	name := base
+	for n := 2; m.templates[name]; n++ {
+		name = fmt.Sprintf("%s-%d", base, n)
+	}
+	m.templates[name] = true
+	return name
+}
+
+// migrateVaultItemName names the vault item for a secret's target:
+// .ssh/id_ed25519 becomes ssh-id_ed25519
+func migrateVaultItemName(target string) string {
+	parts := strings.Split(target, "/")
+	for i, p := range parts {
+		parts[i] = strings.TrimLeft(p, ".")
+	}
+	return strings.Join(parts, "-")
+}
+
+// migrateResult is what apply wrote, or would write
+type migrateResult struct {
+	written   []string // repo files
+	kept      []string // repo files that already existed
+	linked    int      // links added to links.yaml
+	declared  int      // links already in links.yaml
+	linksErr  error    // links.yaml could not be updated
+	variables []string // variables added
+}
+
+// apply writes the migrated files, links, variables and vault items into
+// the repo. Existing files and links are kept.
+func (m *migration) apply(blackdotDir string, dryRun bool) (*migrateResult, error) {
+	res := &migrateResult{}
+	for i := range m.entries {
+		e := &m.entries[i]
+		if (e.Kind != migrateLink && e.Kind != migrateTemplate) || e.Repo == "" {
+			continue
+		}
+		path := filepath.Join(blackdotDir, filepath.FromSlash(e.Repo))
+		if _, err := os.Lstat(path); err == nil {
+			res.kept = append(res.kept, e.Repo)
+			continue
+		}
+		res.written = append(res.written, e.Repo)
+		if dryRun {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, e.Content, e.Mode); err != nil {
+			return nil, err
+		}
+	}
+
+	res.linked, res.declared, res.linksErr = m.appendLinks(filepath.Join(blackdotDir, "links.yaml"), dryRun)
+
+	var err error
+	res.variables, err = m.appendVariables(filepath.Join(blackdotDir, "templates", "_variables.local.sh"), dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.count(migrateSecret) > 0 && !dryRun {
+		if err := m.writeVaultItems(filepath.Join(blackdotDir, filepath.FromSlash(migrateVaultItemsName))); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// appendLinks adds a links.yaml entry for every migrated file whose target
+// is not declared yet. The entries are appended, so the links list must be
+// the last key in the file.
+func (m *migration) appendLinks(path string, dryRun bool) (added, declared int, err error) {
+	have := map[string]bool{}
+	data, readErr := os.ReadFile(path)
+	if readErr == nil {
+		f, err := links.Load(path)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, l := range f.Links {
+			have[l.Target+"|"+strings.Join(l.Platforms, ",")] = true
+		}
+	}
+
+	var block strings.Builder
+	for _, e := range m.entries {
+		if (e.Kind != migrateLink && e.Kind != migrateTemplate) || e.Source == "" {
+			continue
+		}
+		target := "~/" + e.Target
+		// Alternates for other platforms share a target
+		key := target + "|" + strings.Join(e.Platforms, ",")
+		if have[key] {
+			declared++
+			continue
+		}
+		have[key] = true
+		added++
+		fmt.Fprintf(&block, "  - source: %s\n    target: %s\n", linksYAMLValue(e.Source), linksYAMLValue(target))
+		if len(e.Platforms) > 0 {
+			fmt.Fprintf(&block, "    platforms: [%s]\n", strings.Join(e.Platforms, ", "))
+		}
+		if e.Kind == migrateTemplate {
+			block.WriteString("    optional: true\n")
+		}
+	}
+	if added == 0 || dryRun {
+		return added, declared, nil
+	}
+
+	header := fmt.Sprintf("\n  # Migrated from %s (blackdot migrate)\n", m.from)
+	var out string
+	if readErr != nil {
+		out = "backup: rename\n\nlinks:\n" + strings.TrimPrefix(header, "\n") + block.String()
+	} else {
+		if !linksListIsLast(string(data)) {
+			return 0, declared, fmt.Errorf("links: is not the last key in %s; add the links from the report by hand", tildePath(path))
+		}
+		out = strings.TrimRight(string(data), "\n") + "\n" + header + block.String()
+	}
+	return added, declared, os.WriteFile(path, []byte(out), 0644)
+}
+
+// linksListIsLast reports whether the last top-level key in a links.yaml
+// is a block "links:" list that more entries can be appended to
+func linksListIsLast(content string) bool {
+	last := ""
+	for _, line := range strings.Split(content, "\n") {
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '-' {
+			continue
+		}
+		last = strings.TrimSpace(line)
+	}
+	return last == "links:"
+}
+
+// yamlPlain matches values safe to write unquoted in YAML
+var yamlPlain = regexp.MustCompile(`^[A-Za-z0-9_./~${}-]+$`)
+
+// linksYAMLValue quotes a value unless it is plainly safe in YAML
+func linksYAMLValue(s string) string {
+	if yamlPlain.MatchString(s) && !strings.HasPrefix(s, "-") {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// migrateVarLine matches a variable already set in a variables file
+var migrateVarLine = regexp.MustCompile(`(?m)^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)=`)
+
+// appendVariables adds the other tool's variables that the local variables
+// file does not set yet, and returns their names
+func (m *migration) appendVariables(path string, dryRun bool) ([]string, error) {
+	if len(m.variables) == 0 {
+		return nil, nil
+	}
+	data, _ := os.ReadFile(path)
+	set := map[string]bool{}
+	for _, match := range migrateVarLine.FindAllStringSubmatch(string(data), -1) {
+		set[match[1]] = true
+	}
+
+	var names []string
+	for name, value := range m.variables {
+		if !set[name] && !strings.ContainsAny(value, "\n\"") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 || dryRun {
+		return names, nil
+	}
+
+	var b strings.Builder
+	if len(data) == 0 {
+		b.WriteString("# Machine-specific template variables (not committed)\n")
+	} else {
+		b.WriteString(strings.TrimRight(string(data), "\n") + "\n")
+	}
+	fmt.Fprintf(&b, "\n# Migrated from %s (blackdot migrate)\n", m.from)
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s=%q\n", name, m.variables[name])
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return names, os.WriteFile(path, []byte(b.String()), 0600)
+}
+
+// migrateVaultItem is a vault-items.json entry for a migrated secret
+type migrateVaultItem struct {
+	Path     string `json:"path"`
+	Required bool   `json:"required"`
+	Type     string `json:"type"`
+}
+
+// writeVaultItems writes the secrets as vault-items.json entries to merge
+// into the user's own
+func (m *migration) writeVaultItems(path string) error {
+	items := map[string]migrateVaultItem{}
+	for _, e := range m.entries {
+		if e.Kind != migrateSecret || e.Target == "" {
+			continue
+		}
+		typ := "file"
+		if strings.HasPrefix(e.Target, ".ssh/id_") && !strings.HasSuffix(e.Target, ".pub") {
+			typ = "sshkey"
+		}
+		items[migrateVaultItemName(e.Target)] = migrateVaultItem{Path: "~/" + e.Target, Type: typ}
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"$comment":    fmt.Sprintf("Secrets found by 'blackdot migrate --from %s'. Merge into vault-items.json, then run 'blackdot vault push'.", m.from),
+		"vault_items": items,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// printMigration lists every entry by what becomes of it
+func printMigration(m *migration, res *migrateResult, dryRun bool) {
+	kept := map[string]bool{}
+	for _, rel := range res.kept {
+		kept[rel] = true
+	}
+	for _, e := range m.entries {
+		dest := e.Repo
+		if dest == "" {
+			dest = e.Source
+		}
+		switch e.Kind {
+		case migrateLink, migrateTemplate:
+			mark, suffix := Green.Sprint("+"), ""
+			if kept[e.Repo] {
+				mark, suffix = Dim.Sprint("="), Dim.Sprint(" (exists, kept)")
+			}
+			if e.Note != "" {
+				suffix += " " + Yellow.Sprint(e.Note)
+			}
+			fmt.Printf("  %s %s → ~/%s%s\n", mark, dest, e.Target, suffix)
+		case migrateSecret:
+			fmt.Printf("  %s ~/%s %s\n", Yellow.Sprint("!"), e.Target, Dim.Sprint("(vault: "+e.Note+")"))
+		case migrateSkipped:
+			fmt.Printf("  %s %s %s\n", Dim.Sprint("-"), e.From, Dim.Sprint("("+e.Note+")"))
+		}
+	}
+	fmt.Println()
+	Info("%d linked, %d template(s), %d secret(s) for the vault, %d skipped",
+		m.count(migrateLink), m.count(migrateTemplate), m.count(migrateSecret), m.count(migrateSkipped))
+	added := "added to"
+	if dryRun {
+		added = "would be added to"
+	}
+	if res.linked > 0 {
+		Info("%d link(s) %s links.yaml", res.linked, added)
+	}
+	if res.linksErr != nil {
+		Warn("links.yaml not updated: %v", res.linksErr)
+	}
+	if len(res.variables) > 0 {
+		Info("%d variable(s) %s templates/_variables.local.sh", len(res.variables), added)
+	}
+}
+
+// report is the migration report in markdown
+func (m *migration) report(blackdotDir string, res *migrateResult, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Migration from %s\n\n", m.from)
+	fmt.Fprintf(&b, "- Source: `%s`\n- Repo: `%s`\n- Date: %s\n\n", tildePath(m.source), tildePath(blackdotDir), now.Format(time.RFC3339))
+
+	section := func(title string, kind string, row func(e migrateEntry) string, columns string) {
+		n := m.count(kind)
+		if n == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "## %s (%d)\n\n%s\n", title, n, columns)
+		for _, e := range m.entries {
+			if e.Kind == kind {
+				b.WriteString(row(e) + "\n")
+			}
+		}
+		b.WriteString("\n")
+	}
+	platforms := func(e migrateEntry) string {
+		if len(e.Platforms) == 0 {
+			return "all"
+		}
+		return strings.Join(e.Platforms, ", ")
+	}
+	section("Linked files", migrateLink, func(e migrateEntry) string {
+		return fmt.Sprintf("| `%s` | `%s` | `~/%s` | %s |", e.From, e.Source, e.Target, platforms(e))
+	}, "| From | Source | Target | Platforms |\n|------|--------|--------|-----------|")
+	section("Templates", migrateTemplate, func(e migrateEntry) string {
+		return fmt.Sprintf("| `%s` | `%s` | `~/%s` | %s |", e.From, e.Repo, e.Target, e.Note)
+	}, "| From | Template | Target | Review |\n|------|----------|--------|--------|")
+	section("Secrets for the vault", migrateSecret, func(e migrateEntry) string {
+		return fmt.Sprintf("| `~/%s` | `%s` | %s |", e.Target, migrateVaultItemName(e.Target), e.Note)
+	}, "| File | Vault item | Why |\n|------|------------|-----|")
+	section("Not migrated", migrateSkipped, func(e migrateEntry) string {
+		return fmt.Sprintf("| `%s` | %s |", e.From, e.Note)
+	}, "| From | Why |\n|------|-----|")
+
+	if len(res.variables) > 0 {
+		fmt.Fprintf(&b, "## Variables (%d)\n\nAdded to `templates/_variables.local.sh`: %s\n\n", len(res.variables), "`"+strings.Join(res.variables, "`, `")+"`")
+	}
+	if len(res.kept) > 0 {
+		fmt.Fprintf(&b, "## Kept\n\nAlready in the repo, not replaced: %s\n\n", "`"+strings.Join(res.kept, "`, `")+"`")
+	}
+
+	b.WriteString("## Next steps\n\n")
+	if res.linksErr != nil {
+		fmt.Fprintf(&b, "1. Add the links above to `links.yaml` by hand (%v)\n", res.linksErr)
+	}
+	if m.count(migrateTemplate) > 0 {
+		b.WriteString("1. Review the templates, then run `blackdot template render`\n")
+	}
+	b.WriteString("1. Run `blackdot links apply --dry-run`, then `blackdot links apply`; existing files are renamed to `*.bak-<timestamp>`\n")
+	if m.count(migrateSecret) > 0 {
+		fmt.Fprintf(&b, "1. Merge `%s` into `%s` and run `blackdot vault push --all`\n", migrateVaultItemsName, tildePath(filepath.Join(ConfigDir(), "vault-items.json")))
+	}
+	fmt.Fprintf(&b, "1. Stop using %s once `blackdot doctor` is clean\n", m.from)
+	return b.String()
+}
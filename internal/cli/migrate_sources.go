@@ -0,0 +1,516 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// chezmoiAttributes are the source name prefixes chezmoi strips to get a
+// target name
+var chezmoiAttributes = []string{
+	"create_", "modify_", "remove_", "run_", "symlink_", "encrypted_", "private_",
+	"readonly_", "empty_", "executable_", "exact_", "once_", "onchange_",
+	"before_", "after_", "literal_",
+}
+
+// chezmoiSecretFuncs are chezmoi template functions that read a password
+// manager: the rendered file holds a secret
+var chezmoiSecretFuncs = regexp.MustCompile(`\{\{[^}]*\b(onepassword\w*|bitwarden\w*|pass|passFields|gopass\w*|keepassxc\w*|lastpass\w*|vault|secret\w*|keyring|awsSecretsManager\w*|azureKeyVault|doppler\w*|ejson\w*)\b`)
+
+// chezmoiLeftover is Go template syntax the converter does not handle
+var chezmoiLeftover = regexp.MustCompile(`\{\{-|-\}\}|\{\{\s*\.|\{\{\s*(include|template|output|lookPath|joinPath|fromJson|toToml)\b`)
+
+// parseChezmoiName strips chezmoi's attributes from a source path, giving
+// the slash target path, the attributes found, and whether it is a template
+func parseChezmoiName(rel string) (target string, attrs map[string]bool, isTemplate bool) {
+	attrs = map[string]bool{}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i, part := range parts {
+		for stripped := true; stripped; {
+			stripped = false
+			for _, prefix := range chezmoiAttributes {
+				if strings.HasPrefix(part, prefix) {
+					attrs[strings.TrimSuffix(prefix, "_")] = true
+					part = strings.TrimPrefix(part, prefix)
+					stripped = true
+				}
+			}
+		}
+		if strings.HasPrefix(part, "dot_") {
+			part = "." + strings.TrimPrefix(part, "dot_")
+		}
+		if i == len(parts)-1 {
+			if strings.HasSuffix(part, ".tmpl") {
+				isTemplate = true
+				part = strings.TrimSuffix(part, ".tmpl")
+			}
+			if attrs["encrypted"] {
+				part = strings.TrimSuffix(strings.TrimSuffix(part, ".age"), ".asc")
+			}
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "/"), attrs, isTemplate
+}
+
+// readChezmoiSource reads a chezmoi source directory and the [data] in its
+// config file
+func readChezmoiSource(source, configFile string) (*migration, error) {
+	importer := &chezmoiImporter{sourceDir: source, configFile: configFile, configData: map[string]interface{}{}}
+	if err := importer.loadConfig(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", configFile, err)
+	}
+	m := newMigration(migrateFromChezmoi, source)
+	flattenMigrateVariables(m.variables, "", importer.configData)
+	ignored := readChezmoiIgnore(filepath.Join(source, ".chezmoiignore"))
+
+	err := filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(source, p)
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		// Real dotfiles are named dot_*; a leading dot is chezmoi's own
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.Name() != ".git" && strings.HasPrefix(d.Name(), ".chezmoi") && d.Name() != ".chezmoiignore" {
+				m.skip(rel, "chezmoi's own file; port data and scripts by hand")
+			}
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		target, attrs, isTemplate := parseChezmoiName(rel)
+		if ignored(target) {
+			m.skip(rel, "ignored by .chezmoiignore")
+			return nil
+		}
+		switch {
+		case attrs["run"]:
+			m.skip(rel, "chezmoi script; port it to a blackdot hook")
+			return nil
+		case attrs["modify"]:
+			m.skip(rel, "modify_ script; no blackdot equivalent")
+			return nil
+		case attrs["remove"]:
+			m.skip(rel, "remove_ entry; delete the target by hand")
+			return nil
+		case attrs["encrypted"]:
+			m.addSecret(rel, target, "encrypted by chezmoi")
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if attrs["symlink"] {
+			dest := strings.TrimSpace(string(data))
+			if isTemplate || dest == "" {
+				m.skip(rel, "templated symlink; add it to links.yaml by hand")
+			} else {
+				m.addSymlink(rel, target, dest)
+			}
+			return nil
+		}
+		if isTemplate {
+			if match := chezmoiSecretFuncs.FindStringSubmatch(string(data)); match != nil {
+				m.addSecret(rel, target, "chezmoi template reads "+match[1])
+				return nil
+			}
+			converted := importer.convertGoTemplateToHandlebars(string(data))
+			converted = strings.ReplaceAll(converted, `(eq os "darwin")`, `(eq os "macos")`)
+			note := ""
+			if chezmoiLeftover.MatchString(converted) {
+				note = "Go template syntax left to convert by hand"
+			}
+			m.addTemplate(rel, target, []byte(converted), nil, note)
+			return nil
+		}
+		mode := os.FileMode(0644)
+		if attrs["private"] {
+			mode = 0600
+		}
+		if attrs["executable"] {
+			mode = 0755
+		}
+		m.addFile(rel, target, "home/"+target, data, mode, nil)
+		return nil
+	})
+	return m, err
+}
+
+// flattenMigrateVariables turns nested config data into template variables:
+// [data.git] email becomes git_email
+func flattenMigrateVariables(vars map[string]string, prefix string, data map[string]interface{}) {
+	for key, value := range data {
+		name := key
+		if prefix != "" {
+			name = prefix + "_" + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenMigrateVariables(vars, name, v)
+		case []interface{}:
+			// Lists have no scalar form
+		default:
+			vars[name] = fmt.Sprint(v)
+		}
+	}
+}
+
+// readChezmoiIgnore returns a matcher for .chezmoiignore's target patterns.
+// Templated lines are left out; they depend on the machine.
+func readChezmoiIgnore(file string) func(target string) bool {
+	var patterns []string
+	if data, err := os.ReadFile(file); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") || strings.Contains(line, "{{") || strings.HasPrefix(line, "!") {
+				continue
+			}
+			patterns = append(patterns, line)
+		}
+	}
+	return func(target string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, target); ok {
+				return true
+			}
+			if strings.HasPrefix(target, strings.TrimSuffix(pattern, "/**")+"/") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// stowDefaultIgnore is stow's built-in ignore list, used when a package
+// has no .stow-local-ignore
+var stowDefaultIgnore = []string{
+	`RCS`, `.+,v`, `CVS`, `\.\#.+`, `\.cvsignore`, `\.svn`, `_darcs`, `\.hg`,
+	`\.git`, `\.gitignore`, `\.gitmodules`, `.+~`, `\#.*\#`,
+	`^/README.*`, `^/LICENSE.*`, `^/COPYING`,
+}
+
+// readStowSource reads a stow directory: each directory in it is a package
+// mirroring the home directory. dot- names are read as stow --dotfiles
+// does.
+func readStowSource(source string) (*migration, error) {
+	packages, err := os.ReadDir(source)
+	if err != nil {
+		return nil, err
+	}
+	m := newMigration(migrateFromStow, source)
+	for _, pkg := range packages {
+		if !pkg.IsDir() || strings.HasPrefix(pkg.Name(), ".") {
+			continue
+		}
+		pkgDir := filepath.Join(source, pkg.Name())
+		ignored := readStowIgnore(pkgDir)
+		err := filepath.WalkDir(pkgDir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(pkgDir, p)
+			if rel == "." {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			from := pkg.Name() + "/" + rel
+			if d.Name() == ".stow-local-ignore" {
+				return nil
+			}
+			if ignored(rel) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			parts := strings.Split(rel, "/")
+			for i, part := range parts {
+				if strings.HasPrefix(part, "dot-") {
+					parts[i] = "." + strings.TrimPrefix(part, "dot-")
+				}
+			}
+			target := strings.Join(parts, "/")
+			if d.Type()&fs.ModeSymlink != 0 {
+				dest, err := os.Readlink(p)
+				if err != nil || !filepath.IsAbs(dest) {
+					m.skip(from, "relative symlink in a stow package; add it to links.yaml by hand")
+				} else {
+					m.addSymlink(from, target, dest)
+				}
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			m.addFile(from, target, from, data, info.Mode().Perm(), nil)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// readStowIgnore returns a matcher for a package's ignore list. Patterns
+// with a slash match the path from the package root (with a leading /),
+// others the file name, as stow does.
+func readStowIgnore(pkgDir string) func(rel string) bool {
+	lines := stowDefaultIgnore
+	if data, err := os.ReadFile(filepath.Join(pkgDir, ".stow-local-ignore")); err == nil {
+		lines = nil
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				lines = append(lines, line)
+			}
+		}
+	}
+	type pattern struct {
+		re       *regexp.Regexp
+		fullPath bool
+	}
+	var patterns []pattern
+	for _, line := range lines {
+		re, err := regexp.Compile(`^(?:` + strings.TrimPrefix(line, "^") + `)$`)
+		if err != nil {
+			continue
+		}
+		patterns = append(patterns, pattern{re, strings.Contains(line, "/")})
+	}
+	return func(rel string) bool {
+		for _, p := range patterns {
+			subject := path.Base(rel)
+			if p.fullPath {
+				subject = "/" + rel
+			}
+			if p.re.MatchString(subject) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// yadmAlternate is one file of a set of yadm alternates for a target
+type yadmAlternate struct {
+	rel        string
+	platform   string // "" for ##default or no os condition
+	isTemplate bool
+	processor  string
+	unsupport  string // a condition blackdot cannot express
+}
+
+// yadmPlatforms maps yadm's os values to links.yaml platforms
+var yadmPlatforms = map[string]string{"darwin": "darwin", "linux": "linux"}
+
+// parseYadmName splits a tracked path into its target and alternate
+// conditions (file##os.Darwin,template)
+func parseYadmName(rel string) (target string, alt yadmAlternate) {
+	alt.rel = rel
+	i := strings.Index(rel, "##")
+	if i < 0 {
+		return rel, alt
+	}
+	target = rel[:i]
+	if strings.Contains(rel[i:], "/") {
+		alt.unsupport = "alternate directory"
+		return target, alt
+	}
+	for _, cond := range strings.Split(rel[i+2:], ",") {
+		key, value, _ := strings.Cut(cond, ".")
+		switch key {
+		case "", "default":
+		case "o", "os":
+			if p, ok := yadmPlatforms[strings.ToLower(value)]; ok {
+				alt.platform = p
+			} else {
+				alt.unsupport = "os." + value
+			}
+		case "t", "template":
+			alt.isTemplate = true
+			alt.processor = value
+		case "e", "extension":
+		default:
+			alt.unsupport = cond
+		}
+	}
+	return target, alt
+}
+
+// yadmTemplateRules convert yadm's built-in template syntax
+var yadmTemplateRules = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`\{%-?\s*if\s+yadm\.os\s*==\s*"Darwin"\s*-?%\}`), `{{#if (eq os "macos")}}`},
+	{regexp.MustCompile(`\{%-?\s*if\s+yadm\.os\s*==\s*"Linux"\s*-?%\}`), `{{#if (eq os "linux")}}`},
+	{regexp.MustCompile(`\{%-?\s*if\s+yadm\.class\s*==\s*"([^"]*)"\s*-?%\}`), `{{#if (eq machine_type "$1")}}`},
+	{regexp.MustCompile(`\{%-?\s*if\s+yadm\.(\w+)\s*==\s*"([^"]*)"\s*-?%\}`), `{{#if (eq $1 "$2")}}`},
+	{regexp.MustCompile(`\{%-?\s*if\s+yadm\.(\w+)\s*!=\s*"([^"]*)"\s*-?%\}`), `{{#if (ne $1 "$2")}}`},
+	{regexp.MustCompile(`\{%-?\s*else\s*-?%\}`), `{{else}}`},
+	{regexp.MustCompile(`\{%-?\s*endif\s*-?%\}`), `{{/if}}`},
+	{regexp.MustCompile(`\{\{\s*yadm\.user\s*\}\}`), `{{ user }}`},
+	{regexp.MustCompile(`\{\{\s*yadm\.class\s*\}\}`), `{{ machine_type }}`},
+	{regexp.MustCompile(`\{\{\s*yadm\.(\w+)\s*\}\}`), `{{ $1 }}`},
+}
+
+// convertYadmTemplate converts a template for yadm's default processor;
+// others (j2, esh) are copied for review
+func convertYadmTemplate(content, processor string) (string, string) {
+	switch processor {
+	case "", "default":
+	default:
+		return content, processor + " template; convert to Handlebars by hand"
+	}
+	for _, rule := range yadmTemplateRules {
+		content = rule.re.ReplaceAllString(content, rule.repl)
+	}
+	if strings.Contains(content, "{%") || strings.Contains(content, "yadm.") {
+		return content, "yadm template syntax left to convert by hand"
+	}
+	return content, ""
+}
+
+// readYadmSource reads the files tracked in a yadm repository, whose work
+// tree is the home directory, and the files listed in yadm's encrypt list
+func readYadmSource(repo, home string) (*migration, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is needed to read a yadm repository")
+	}
+	out, err := exec.Command("git", "--git-dir", repo, "--work-tree", home, "ls-files", "-z").Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", repo, err)
+	}
+	m := newMigration(migrateFromYadm, repo)
+
+	// Group alternates by target, in a stable order
+	alternates := map[string][]yadmAlternate{}
+	var targets []string
+	for _, rel := range strings.Split(strings.TrimRight(string(out), "\x00"), "\x00") {
+		if rel == "" {
+			continue
+		}
+		if strings.HasPrefix(rel, ".config/yadm/") || strings.HasPrefix(rel, ".local/share/yadm/") {
+			m.skip(rel, "yadm's own file; port bootstrap scripts to a blackdot hook")
+			continue
+		}
+		target, alt := parseYadmName(rel)
+		if _, ok := alternates[target]; !ok {
+			targets = append(targets, target)
+		}
+		alternates[target] = append(alternates[target], alt)
+	}
+	sort.Strings(targets)
+
+	for _, target := range targets {
+		alts := alternates[target]
+		covered := map[string]bool{}
+		for _, alt := range alts {
+			if alt.platform != "" && alt.unsupport == "" {
+				covered[alt.platform] = true
+			}
+		}
+		for _, alt := range alts {
+			if alt.unsupport != "" {
+				m.skip(alt.rel, "yadm alternate for "+alt.unsupport+"; pick one by hand")
+				continue
+			}
+			var platforms []string
+			if alt.platform != "" {
+				platforms = []string{alt.platform}
+			} else if len(covered) > 0 {
+				// The default applies where no os alternate does
+				for _, p := range []string{"darwin", "linux", "windows"} {
+					if !covered[p] {
+						platforms = append(platforms, p)
+					}
+				}
+			}
+			data, err := os.ReadFile(filepath.Join(home, filepath.FromSlash(alt.rel)))
+			if err != nil {
+				m.skip(alt.rel, "not checked out in the home directory")
+				continue
+			}
+			if alt.isTemplate {
+				converted, note := convertYadmTemplate(string(data), alt.processor)
+				m.addTemplate(alt.rel, target, []byte(converted), platforms, note)
+				continue
+			}
+			info, _ := os.Stat(filepath.Join(home, filepath.FromSlash(alt.rel)))
+			m.addFile(alt.rel, target, "home/"+alt.rel, data, info.Mode().Perm(), platforms)
+		}
+	}
+
+	for _, target := range readYadmEncrypt(home) {
+		m.addSecret(".config/yadm/encrypt", target, "listed in yadm's encrypt file")
+	}
+	return m, nil
+}
+
+// readYadmEncrypt returns the files in the home directory matched by the
+// patterns in yadm's encrypt file
+func readYadmEncrypt(home string) []string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	data, err := os.ReadFile(filepath.Join(configHome, "yadm", "encrypt"))
+	if err != nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var targets []string
+	add := func(p string) {
+		rel, err := filepath.Rel(home, p)
+		if err == nil && !seen[rel] {
+			seen[rel] = true
+			targets = append(targets, filepath.ToSlash(rel))
+		}
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		matches, _ := filepath.Glob(filepath.Join(home, line))
+		for _, match := range matches {
+			filepath.WalkDir(match, func(p string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					add(p)
+				}
+				return nil
+			})
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
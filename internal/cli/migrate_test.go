@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/links"
+)
+
+// writeMigrateFiles writes files (slash paths to content) under dir
+func writeMigrateFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// migrateKinds maps each entry's From to its kind
+func migrateKinds(m *migration) map[string]string {
+	kinds := map[string]string{}
+	for _, e := range m.entries {
+		kinds[e.From] = e.Kind
+	}
+	return kinds
+}
+
+// TestMigrateFromChezmoi verifies names, templates, variables, secrets and
+// skipped entries are mapped, and a second run keeps what is there
+func TestMigrateFromChezmoi(t *testing.T) {
+	setScheduleEnv(t, "")
+	home := os.Getenv("HOME")
+	repo := filepath.Join(home, ".blackdot")
+	t.Setenv("BLACKDOT_DIR", repo)
+	source := filepath.Join(home, ".local", "share", "chezmoi")
+	writeMigrateFiles(t, source, map[string]string{
+		"dot_zshrc":                                 "export EDITOR=vim\n",
+		"private_dot_config/app/config.yml":         "theme: dark\n",
+		"dot_gitconfig.tmpl":                        "[user]\n  email = {{ .email }}\n{{ if eq .chezmoi.os \"darwin\" }}[credential]\n  helper = osxkeychain\n{{ end }}\n",
+		"dot_npmrc.tmpl":                            "//registry.npmjs.org/:_authToken={{ onepasswordRead \"op://npm\" }}\n",
+		"private_dot_netrc":                         "machine example.com login me password hunter2\n",
+		"encrypted_private_dot_aws/credentials.age": "age-encrypted",
+		"run_once_install.sh":                       "#!/bin/sh\n",
+		"symlink_dot_vimrc":                         "/opt/vim/vimrc\n",
+		"README.md":                                 "my dotfiles\n",
+		".chezmoiignore":                            "README.md\n",
+		".chezmoidata.yaml":                         "x: 1\n",
+	})
+	writeMigrateFiles(t, filepath.Join(home, ".config", "chezmoi"), map[string]string{
+		"chezmoi.toml": "[data]\n  email = \"me@example.com\"\n  [data.git]\n    name = \"Me\"\n",
+	})
+
+	if err := runMigrate(migrateOptions{from: migrateFromChezmoi, dryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if fileExists(repo) {
+		t.Fatal("--dry-run wrote to the repo")
+	}
+	if err := runMigrate(migrateOptions{from: migrateFromChezmoi}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := links.Load(filepath.Join(repo, "links.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, l := range f.Links {
+		got[l.Target] = l.Source
+	}
+	want := map[string]string{
+		"~/.zshrc":                 "home/.zshrc",
+		"~/.config/app/config.yml": "home/.config/app/config.yml",
+		"~/.gitconfig":             "generated/gitconfig",
+		"~/.vimrc":                 "/opt/vim/vimrc",
+	}
+	if len(got) != len(want) {
+		t.Errorf("links = %v", got)
+	}
+	for target, source := range want {
+		if got[target] != source {
+			t.Errorf("link %s = %q, want %q", target, got[target], source)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(repo, "home", ".config", "app", "config.yml")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("private file not copied 0600: %v", err)
+	}
+
+	tmpl, _ := os.ReadFile(filepath.Join(repo, "templates", "configs", "gitconfig.tmpl"))
+	if !strings.Contains(string(tmpl), "{{ email }}") || !strings.Contains(string(tmpl), `{{#if (eq os "macos")}}`) {
+		t.Errorf("template not converted:\n%s", tmpl)
+	}
+	vars, _ := os.ReadFile(filepath.Join(repo, "templates", "_variables.local.sh"))
+	if !strings.Contains(string(vars), `email="me@example.com"`) || !strings.Contains(string(vars), `git_name="Me"`) {
+		t.Errorf("variables = %s", vars)
+	}
+
+	var items struct {
+		VaultItems map[string]migrateVaultItem `json:"vault_items"`
+	}
+	data, _ := os.ReadFile(filepath.Join(repo, filepath.FromSlash(migrateVaultItemsName)))
+	json.Unmarshal(data, &items)
+	for _, name := range []string{"netrc", "aws-credentials", "npmrc"} {
+		if _, ok := items.VaultItems[name]; !ok {
+			t.Errorf("vault item %s missing: %s", name, data)
+		}
+	}
+	if fileExists(filepath.Join(repo, "home", ".netrc")) {
+		t.Error("a secret was copied into the repo")
+	}
+
+	report, _ := os.ReadFile(filepath.Join(repo, migrateReportName))
+	for _, s := range []string{"run_once_install.sh", "ignored by .chezmoiignore", ".chezmoidata.yaml", "reads onepasswordRead"} {
+		if !strings.Contains(string(report), s) {
+			t.Errorf("report missing %q:\n%s", s, report)
+		}
+	}
+
+	// A second run adds nothing
+	before, _ := os.ReadFile(filepath.Join(repo, "links.yaml"))
+	if err := runMigrate(migrateOptions{from: migrateFromChezmoi}); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.ReadFile(filepath.Join(repo, "links.yaml"))
+	if string(before) != string(after) {
+		t.Errorf("second run changed links.yaml:\n%s", after)
+	}
+}
+
+// TestMigrateFromStow verifies packages, dot- names and ignore lists
+func TestMigrateFromStow(t *testing.T) {
+	source := t.TempDir()
+	writeMigrateFiles(t, source, map[string]string{
+		"zsh/dot-zshrc":              "setopt autocd\n",
+		"zsh/README.md":              "notes\n",
+		"git/.gitconfig":             "[core]\n",
+		"git/.config/git/ignore":     "*.swp\n",
+		"nvim/.stow-local-ignore":    "^/notes\n",
+		"nvim/.config/nvim/init.lua": "vim.o.number = true\n",
+		"nvim/notes":                 "todo\n",
+		"aws/.aws/credentials":       "[default]\n",
+		"README.md":                  "top-level files are not packages\n",
+	})
+	m, err := readStowSource(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets := map[string]string{}
+	for _, e := range m.entries {
+		targets[e.From] = e.Target
+	}
+	want := map[string]string{
+		"zsh/dot-zshrc":              ".zshrc",
+		"git/.gitconfig":             ".gitconfig",
+		"git/.config/git/ignore":     ".config/git/ignore",
+		"nvim/.config/nvim/init.lua": ".config/nvim/init.lua",
+		"aws/.aws/credentials":       ".aws/credentials",
+	}
+	if len(targets) != len(want) {
+		t.Errorf("entries = %v", targets)
+	}
+	for from, target := range want {
+		if targets[from] != target {
+			t.Errorf("%s → %q, want %q", from, targets[from], target)
+		}
+	}
+	if kinds := migrateKinds(m); kinds["aws/.aws/credentials"] != migrateSecret || kinds["zsh/dot-zshrc"] != migrateLink {
+		t.Errorf("kinds = %v", kinds)
+	}
+}
+
+// TestMigrateFromYadm verifies alternates become link platforms, templates
+// are converted and the encrypt list goes to the vault
+func TestMigrateFromYadm(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	setScheduleEnv(t, "")
+	home := os.Getenv("HOME")
+	repo := filepath.Join(home, ".local", "share", "yadm", "repo.git")
+	writeMigrateFiles(t, home, map[string]string{
+		".bashrc":               "alias ll='ls -l'\n",
+		".tmux.conf##os.Darwin": "set -g mouse on\n",
+		".tmux.conf##default":   "set -g mouse off\n",
+		".profile##class.Work":  "export WORK=1\n",
+		".gitconfig##template":  "[user]\n  name = {{ yadm.user }}\n{% if yadm.os == \"Darwin\" %}\n  helper = osxkeychain\n{% endif %}\n",
+		".config/yadm/encrypt":  ".ssh/id_*\n",
+		".ssh/id_ed25519":       "key\n",
+	})
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"--git-dir", repo, "--work-tree", home}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	exec.Command("git", "init", "-q", "--bare", repo).Run()
+	git("add", ".bashrc", ".tmux.conf##os.Darwin", ".tmux.conf##default", ".profile##class.Work", ".gitconfig##template", ".config/yadm/encrypt")
+
+	m, err := readYadmSource(repo, home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byFrom := map[string]migrateEntry{}
+	for _, e := range m.entries {
+		byFrom[e.From] = e
+	}
+	if e := byFrom[".tmux.conf##os.Darwin"]; e.Target != ".tmux.conf" || strings.Join(e.Platforms, ",") != "darwin" {
+		t.Errorf("os alternate = %+v", e)
+	}
+	if e := byFrom[".tmux.conf##default"]; strings.Join(e.Platforms, ",") != "linux,windows" {
+		t.Errorf("default alternate platforms = %v", e.Platforms)
+	}
+	if e := byFrom[".profile##class.Work"]; e.Kind != migrateSkipped {
+		t.Errorf("class alternate = %+v", e)
+	}
+	if e := byFrom[".gitconfig##template"]; e.Kind != migrateTemplate || !strings.Contains(string(e.Content), "{{ user }}") ||
+		!strings.Contains(string(e.Content), `{{#if (eq os "macos")}}`) || e.Note != "" {
+		t.Errorf("template = %+v\n%s", e, e.Content)
+	}
+	if e := byFrom[".config/yadm/encrypt"]; e.Kind != migrateSecret || e.Target != ".ssh/id_ed25519" {
+		t.Errorf("encrypt list entry = %+v", e)
+	}
+}
@@ -3,11 +3,11 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/blackwell-systems/blackdot/internal/prompts"
 	"github.com/fatih/color"
 )
 
@@ -133,14 +133,8 @@ func Confirm(prompt string) bool {
 		prompt = "Continue?"
 	}
 
-	Yellow.Fprintf(os.Stderr, "%s ", prompt)
-	fmt.Fprint(os.Stderr, "[y/N] ")
-
-	reader := bufio.NewReader(os.Stdin)
-	response, _ := reader.ReadString('\n')
-	response = strings.TrimSpace(strings.ToLower(response))
-
-	return response == "y" || response == "yes"
+	ok, _ := prompts.Confirm(Yellow.Sprint(prompt), false)
+	return ok
 }
 
 // ============================================================
@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/blackwell-systems/blackdot/internal/paths"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -30,6 +31,9 @@ Modes:
   --install, -i Install missing packages
   --outdated, -o Show outdated packages
 
+Subcommands:
+  orphans       Packages installed but not declared, and declared but missing
+
 Tiers:
   minimal     ~18 packages  - Essentials only
   enhanced    ~43 packages  - Modern tools, no containers
@@ -39,7 +43,8 @@ Examples:
   blackdot packages                        # Status overview
   blackdot packages --check                # See what needs installing
   blackdot packages --install              # Install from saved tier
-  blackdot packages --install --tier minimal  # Install minimal tier`,
+  blackdot packages --install --tier minimal  # Install minimal tier
+  blackdot packages orphans --adopt        # Declare what you installed by hand`,
 		RunE: runPackages,
 	}
 
@@ -48,6 +53,7 @@ Examples:
 	cmd.Flags().BoolP("outdated", "o", false, "Show outdated packages")
 	cmd.Flags().StringP("tier", "t", "", "Use specific tier (minimal/enhanced/full)")
 
+	cmd.AddCommand(newPackagesOrphansCmd())
 	return cmd
 }
 
@@ -83,33 +89,29 @@ func runPackages(cmd *cobra.Command, args []string) error {
 	tier := getPackageTier(tierOverride, blackdotDir)
 
 	// Map tier to Brewfile
-	var brewfilePath string
-	switch tier {
-	case "minimal":
-		brewfilePath = filepath.Join(blackdotDir, "brew", "Brewfile.minimal")
-	case "enhanced":
-		brewfilePath = filepath.Join(blackdotDir, "brew", "Brewfile.enhanced")
-	default:
-		brewfilePath = filepath.Join(blackdotDir, "brew", "Brewfile")
-		tier = "full"
+	brewfilePath, resolvedTier, err := resolveBrewfile(blackdotDir, tier)
+	if err != nil {
+		return err
+	}
+	if resolvedTier != tier && tier != "full" && tier != "" {
+		fmt.Printf("%s Brewfile for '%s' tier not found, using full Brewfile\n", yellow("[WARN]"), tier)
 	}
+	tier = resolvedTier
 
-	// Check Brewfile exists, fall back to main if needed
-	if _, err := os.Stat(brewfilePath); os.IsNotExist(err) {
-		mainBrewfile := filepath.Join(blackdotDir, "brew", "Brewfile")
-		if _, err := os.Stat(mainBrewfile); err == nil {
-			fmt.Printf("%s Brewfile for '%s' tier not found, using full Brewfile\n", yellow("[WARN]"), tier)
-			brewfilePath = mainBrewfile
-			tier = "full"
-		} else {
-			return fmt.Errorf("no Brewfile found at %s", brewfilePath)
-		}
+	// Append Brewfiles from overlay repos
+	brewfilePath, overlays, cleanup, err := layeredBrewfile(brewfilePath)
+	if err != nil {
+		return err
 	}
+	defer cleanup()
 
 	fmt.Println()
 	fmt.Println(bold("Blackdot Package Manager"))
 	fmt.Println("========================")
 	fmt.Printf("%s\n", dim(fmt.Sprintf("Tier: %s (%s)", tier, filepath.Base(brewfilePath))))
+	if len(overlays) > 0 {
+		fmt.Printf("%s\n", dim("Overlays: "+strings.Join(overlays, ", ")))
+	}
 	fmt.Println()
 
 	// Outdated mode
@@ -202,6 +204,11 @@ func runPackages(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		fmt.Printf("%s Packages installed successfully (%s tier)\n", green("[OK]"), tier)
+
+		// New or upgraded tools bring new completions
+		if _, err := exec.LookPath("zsh"); err == nil {
+			regenZshCompletions(false, true)
+		}
 		return nil
 	}
 
@@ -218,16 +225,27 @@ func runPackages(cmd *cobra.Command, args []string) error {
 		fmt.Println(dim("Change tier with: blackdot packages --tier minimal|enhanced|full"))
 	}
 
-	// Suggest dotclaude for Claude users
-	if _, err := exec.LookPath("claude"); err == nil {
-		if _, err := exec.LookPath("dotclaude"); err != nil {
-			fmt.Println()
-			fmt.Printf("%s Claude Code detected without dotclaude\n", cyan("[INFO]"))
-			fmt.Println("     Manage profiles across machines with dotclaude:")
-			fmt.Println("     See: github.com/blackwell-systems/dotclaude")
+	// Runtimes pinned by the project in the current directory
+	if initRegistry().Enabled("tool_versions") {
+		if dir, err := os.Getwd(); err == nil {
+			if reqs, err := readToolVersions(dir); err == nil {
+				path := toolBasePath(os.Getenv("PATH"), os.Getenv("BLACKDOT_TOOL_PATHS"))
+				if missing := checkToolRequirements(reqs, path); missing > 0 {
+					fmt.Println()
+					fmt.Printf("%s %d runtime(s) pinned by this project are not installed\n", yellow("[WARN]"), missing)
+					fmt.Println("Run 'blackdot tools versions' for install hints")
+				}
+			}
 		}
 	}
 
+	// Suggest Claude profiles for Claude users
+	if _, err := exec.LookPath("claude"); err == nil && !fileExists(claudeProfilesPath()) {
+		fmt.Println()
+		fmt.Printf("%s Claude Code detected without profiles\n", cyan("[INFO]"))
+		fmt.Println("     Manage profiles across machines with: blackdot claude profiles")
+	}
+
 	return nil
 }
 
@@ -240,8 +258,7 @@ func getPackageTier(tierOverride, blackdotDir string) string {
 	}
 
 	// 2. Config file (packages.tier)
-	home, _ := os.UserHomeDir()
-	configPath := filepath.Join(home, ".config", "blackdot", "config.json")
+	configPath := filepath.Join(ConfigDir(), "config.json")
 	if data, err := os.ReadFile(configPath); err == nil {
 		var cfg map[string]interface{}
 		if json.Unmarshal(data, &cfg) == nil {
@@ -262,6 +279,71 @@ func getPackageTier(tierOverride, blackdotDir string) string {
 	return "full"
 }
 
+// resolveBrewfile maps a package tier to its Brewfile, falling back to the
+// full Brewfile when the tier has none. It returns the tier actually used.
+func resolveBrewfile(blackdotDir, tier string) (string, string, error) {
+	var brewfilePath string
+	switch tier {
+	case "minimal":
+		brewfilePath = filepath.Join(blackdotDir, "brew", "Brewfile.minimal")
+	case "enhanced":
+		brewfilePath = filepath.Join(blackdotDir, "brew", "Brewfile.enhanced")
+	default:
+		brewfilePath = filepath.Join(blackdotDir, "brew", "Brewfile")
+		tier = "full"
+	}
+
+	// Check Brewfile exists, fall back to main if needed
+	if _, err := os.Stat(brewfilePath); os.IsNotExist(err) {
+		mainBrewfile := filepath.Join(blackdotDir, "brew", "Brewfile")
+		if _, err := os.Stat(mainBrewfile); err != nil {
+			return "", "", fmt.Errorf("no Brewfile found at %s", brewfilePath)
+		}
+		return mainBrewfile, "full", nil
+	}
+	return brewfilePath, tier, nil
+}
+
+// layeredBrewfile appends the same-named Brewfile from each overlay repo to
+// the base one. When overlays contribute, the combined file is written to a
+// temp file for brew bundle; cleanup removes it.
+func layeredBrewfile(brewfilePath string) (string, []string, func(), error) {
+	noop := func() {}
+	rel := filepath.Join("brew", filepath.Base(brewfilePath))
+	files := paths.CollectLayered(paths.Overlays(), rel)
+	if len(files) == 0 {
+		return brewfilePath, nil, noop, nil
+	}
+
+	base, err := os.ReadFile(brewfilePath)
+	if err != nil {
+		return "", nil, noop, err
+	}
+	var combined strings.Builder
+	combined.Write(base)
+	var names []string
+	for _, f := range files {
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return "", nil, noop, err
+		}
+		fmt.Fprintf(&combined, "\n# --- overlay: %s ---\n", f.Root)
+		combined.Write(data)
+		names = append(names, f.Root)
+	}
+
+	tmp, err := os.CreateTemp("", "Brewfile.layered.*")
+	if err != nil {
+		return "", nil, noop, err
+	}
+	defer tmp.Close()
+	if _, err := tmp.WriteString(combined.String()); err != nil {
+		os.Remove(tmp.Name())
+		return "", nil, noop, err
+	}
+	return tmp.Name(), names, func() { os.Remove(tmp.Name()) }, nil
+}
+
 // parseBrewfile extracts formula and cask names from a Brewfile
 func parseBrewfile(path string) (formulas, casks []string, err error) {
 	file, err := os.Open(path)
@@ -0,0 +1,432 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/safefile"
+	"github.com/spf13/cobra"
+)
+
+// packageRef is one package in a manifest or installed on the machine.
+// Kind is "brew" or "cask" for Homebrew, "winget" for winget.
+type packageRef struct {
+	Kind string
+	Name string
+}
+
+func (p packageRef) String() string {
+	if p.Kind == "cask" {
+		return p.Name + " (cask)"
+	}
+	return p.Name
+}
+
+// key matches a declared package with an installed one. Homebrew lists
+// tap formulas by full name (owner/tap/name) while Brewfiles often use the
+// short name; winget ids are case-insensitive.
+func (p packageRef) key() string {
+	name := p.Name
+	if p.Kind != "winget" {
+		name = name[strings.LastIndex(name, "/")+1:]
+	}
+	return p.Kind + ":" + strings.ToLower(name)
+}
+
+// packageInventory is what a package manager reports against a manifest
+type packageInventory struct {
+	Manager  string
+	Manifest string       // file declared packages come from, and --adopt writes to
+	Declared []packageRef // everything in the manifest (with overlays)
+	Leaves   []packageRef // installed on purpose, not as a dependency
+	Present  []packageRef // everything installed
+}
+
+func newPackagesOrphansCmd() *cobra.Command {
+	var tier, manager string
+	var adopt, remove bool
+
+	cmd := &cobra.Command{
+		Use:   "orphans",
+		Short: "Show packages installed but not declared, and declared but missing",
+		Long: `Compare installed packages against the tier's manifest.
+
+Orphans are packages you installed that the manifest doesn't declare:
+Homebrew formulas from 'brew leaves' (dependencies are not counted) and
+casks, or the packages 'winget export' reports on Windows. Missing
+packages are declared but not installed.
+
+The manifest is the tier's Brewfile plus overlay repo Brewfiles, or
+winget.json (else powershell/packages.json) in the blackdot directory and
+each overlay repo.
+
+  --adopt    Append the orphans to the manifest (the tier's Brewfile)
+  --remove   Ask about each orphan and uninstall the ones you confirm
+
+Examples:
+  blackdot packages orphans
+  blackdot packages orphans --tier minimal
+  blackdot packages orphans --adopt
+  blackdot packages orphans --remove`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if adopt && remove {
+				return fmt.Errorf("--adopt and --remove cannot be combined")
+			}
+			if manager == "" {
+				manager = defaultPackageManager()
+			}
+
+			blackdotDir := getBlackdotDir()
+			var inv *packageInventory
+			var err error
+			switch manager {
+			case "brew":
+				inv, err = brewInventory(blackdotDir, getPackageTier(tier, blackdotDir))
+			case "winget":
+				inv, err = wingetInventory(blackdotDir)
+			default:
+				return fmt.Errorf("unknown package manager %q (expected brew or winget)", manager)
+			}
+			if err != nil {
+				Fail("%v", err)
+				return err
+			}
+			return runPackagesOrphans(inv, adopt, remove)
+		},
+	}
+
+	cmd.Flags().StringVarP(&tier, "tier", "t", "", "Use specific tier (minimal/enhanced/full)")
+	cmd.Flags().StringVar(&manager, "manager", "", "Package manager: brew or winget (default: detected)")
+	cmd.Flags().BoolVar(&adopt, "adopt", false, "Append orphans to the manifest")
+	cmd.Flags().BoolVar(&remove, "remove", false, "Uninstall orphans, asking for each")
+	return cmd
+}
+
+// defaultPackageManager is winget on Windows without Homebrew, else brew
+func defaultPackageManager() string {
+	if _, err := exec.LookPath("brew"); err != nil && runtime.GOOS == "windows" {
+		return "winget"
+	}
+	return "brew"
+}
+
+func runPackagesOrphans(inv *packageInventory, adopt, remove bool) error {
+	orphans, missing := diffPackages(inv.Declared, inv.Leaves, inv.Present)
+
+	PrintHeader("Package Orphans")
+	fmt.Println(Dim.Sprintf("Manifest: %s (%d packages, %s)", tildePath(inv.Manifest), len(inv.Declared), inv.Manager))
+	fmt.Println()
+
+	if len(orphans) == 0 {
+		Pass("Every installed package is declared")
+	} else {
+		Warn("Installed but not declared (%d):", len(orphans))
+		for _, p := range orphans {
+			fmt.Printf("  - %s\n", p)
+		}
+	}
+	if len(missing) == 0 {
+		Pass("Every declared package is installed")
+	} else {
+		Warn("Declared but missing (%d):", len(missing))
+		for _, p := range missing {
+			fmt.Printf("  - %s\n", p)
+		}
+	}
+
+	switch {
+	case len(orphans) == 0:
+	case adopt:
+		if err := adoptPackages(inv, orphans); err != nil {
+			Fail("Failed to update %s: %v", tildePath(inv.Manifest), err)
+			return err
+		}
+		fmt.Println()
+		Pass("Added %d package(s) to %s", len(orphans), tildePath(inv.Manifest))
+	case remove:
+		fmt.Println()
+		removed := 0
+		for _, p := range orphans {
+			if !Confirm(fmt.Sprintf("Uninstall %s?", p)) {
+				continue
+			}
+			if err := uninstallPackage(p); err != nil {
+				Fail("Failed to uninstall %s: %v", p, err)
+				continue
+			}
+			Pass("Uninstalled %s", p)
+			removed++
+		}
+		Info("Uninstalled %d of %d orphan(s)", removed, len(orphans))
+	default:
+		fmt.Println()
+		Info("Keep them with --adopt, or uninstall them with --remove")
+	}
+	if len(missing) > 0 && inv.Manager == "brew" {
+		Info("Install missing packages with: blackdot packages --install")
+	}
+	return nil
+}
+
+// diffPackages returns the leaves not in declared (orphans) and the
+// declared packages not in present (missing), each sorted
+func diffPackages(declared, leaves, present []packageRef) (orphans, missing []packageRef) {
+	declaredSet := map[string]bool{}
+	for _, p := range declared {
+		declaredSet[p.key()] = true
+	}
+	presentSet := map[string]bool{}
+	for _, p := range present {
+		presentSet[p.key()] = true
+	}
+
+	seen := map[string]bool{}
+	for _, p := range leaves {
+		if !declaredSet[p.key()] && !seen[p.key()] {
+			orphans = append(orphans, p)
+			seen[p.key()] = true
+		}
+	}
+	for _, p := range declared {
+		if !presentSet[p.key()] && !seen[p.key()] {
+			missing = append(missing, p)
+			seen[p.key()] = true
+		}
+	}
+	byName := func(list []packageRef) func(i, j int) bool {
+		return func(i, j int) bool { return list[i].String() < list[j].String() }
+	}
+	sort.Slice(orphans, byName(orphans))
+	sort.Slice(missing, byName(missing))
+	return orphans, missing
+}
+
+// brewInventory reads the tier's Brewfile (with overlays) and what
+// Homebrew has installed
+func brewInventory(blackdotDir, tier string) (*packageInventory, error) {
+	if _, err := exec.LookPath("brew"); err != nil {
+		return nil, fmt.Errorf("homebrew not installed")
+	}
+	brewfile, _, err := resolveBrewfile(blackdotDir, tier)
+	if err != nil {
+		return nil, err
+	}
+	layered, _, cleanup, err := layeredBrewfile(brewfile)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	formulas, casks, err := parseBrewfile(layered)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Brewfile: %w", err)
+	}
+
+	out, err := exec.Command("brew", "leaves").Output()
+	if err != nil {
+		return nil, fmt.Errorf("brew leaves: %w", err)
+	}
+	installedCasks := getInstalledCasks()
+	inv := &packageInventory{
+		Manager:  "brew",
+		Manifest: brewfile,
+		Declared: append(packageRefs("brew", formulas), packageRefs("cask", casks)...),
+		Leaves:   append(packageRefs("brew", strings.Fields(string(out))), packageRefs("cask", installedCasks)...),
+		Present:  append(packageRefs("brew", getInstalledFormulas()), packageRefs("cask", installedCasks)...),
+	}
+	return inv, nil
+}
+
+// wingetManifest is the winget package list: winget.json (a 'winget
+// export' file, as setup uses) or powershell/packages.json
+func wingetManifest(blackdotDir string) string {
+	if path := filepath.Join(blackdotDir, "winget.json"); fileExists(path) {
+		return path
+	}
+	return filepath.Join(blackdotDir, "powershell", "packages.json")
+}
+
+// wingetInventory reads the winget manifest and exports what winget
+// has installed
+func wingetInventory(blackdotDir string) (*packageInventory, error) {
+	if _, err := exec.LookPath("winget"); err != nil {
+		return nil, fmt.Errorf("winget not installed")
+	}
+	manifest := wingetManifest(blackdotDir)
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		return nil, err
+	}
+	declared, err := parseWingetManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", manifest, err)
+	}
+	// Overlay repos contribute their packages, like their Brewfiles
+	for _, r := range paths.Overlays() {
+		path := wingetManifest(r.Path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		ids, err := parseWingetManifest(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		declared = append(declared, ids...)
+	}
+
+	tmp, err := os.MkdirTemp("", "blackdot-winget-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	export := filepath.Join(tmp, "installed.json")
+	if out, err := exec.Command("winget", "export", "-o", export, "--accept-source-agreements", "--disable-interactivity").CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("winget export: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	data, err = os.ReadFile(export)
+	if err != nil {
+		return nil, err
+	}
+	installed, err := parseWingetManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("winget export: %w", err)
+	}
+
+	inv := &packageInventory{
+		Manager:  "winget",
+		Manifest: manifest,
+		Declared: packageRefs("winget", declared),
+		Leaves:   packageRefs("winget", installed),
+		Present:  packageRefs("winget", installed),
+	}
+	return inv, nil
+}
+
+// parseWingetManifest returns the package ids in a winget package list:
+// a 'winget export' file (Sources[].Packages[].PackageIdentifier) or
+// powershell/packages.json (sources[].packages[].id)
+func parseWingetManifest(data []byte) ([]string, error) {
+	var list struct {
+		Sources []map[string]json.RawMessage `json:"Sources"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, source := range list.Sources {
+		for _, key := range []string{"Packages", "packages"} {
+			raw, ok := source[key]
+			if !ok {
+				continue
+			}
+			var packages []map[string]interface{}
+			if err := json.Unmarshal(raw, &packages); err != nil {
+				return nil, err
+			}
+			for _, p := range packages {
+				for _, field := range []string{"PackageIdentifier", "id"} {
+					if id, ok := p[field].(string); ok && id != "" {
+						ids = append(ids, id)
+						break
+					}
+				}
+			}
+		}
+	}
+	return ids, nil
+}
+
+func packageRefs(kind string, names []string) []packageRef {
+	refs := make([]packageRef, len(names))
+	for i, name := range names {
+		refs[i] = packageRef{Kind: kind, Name: name}
+	}
+	return refs
+}
+
+// adoptPackages adds packages to the inventory's manifest
+func adoptPackages(inv *packageInventory, packages []packageRef) error {
+	if inv.Manager == "winget" {
+		return safefile.Update(inv.Manifest, 0644, func(current []byte) ([]byte, error) {
+			return adoptIntoWingetManifest(current, packages)
+		})
+	}
+	return safefile.Update(inv.Manifest, 0644, func(current []byte) ([]byte, error) {
+		return adoptIntoBrewfile(current, packages), nil
+	})
+}
+
+// adoptIntoBrewfile appends brew and cask lines for packages under a
+// comment saying where they came from
+func adoptIntoBrewfile(brewfile []byte, packages []packageRef) []byte {
+	content := string(brewfile)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += "\n# Adopted by blackdot packages orphans\n"
+	for _, p := range packages {
+		content += fmt.Sprintf("%s %q\n", p.Kind, p.Name)
+	}
+	return []byte(content)
+}
+
+// adoptIntoWingetManifest adds packages to the first source of a winget
+// package list, using the key its existing entries use
+func adoptIntoWingetManifest(data []byte, packages []packageRef) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	sourcesKey := "sources"
+	if _, ok := doc["Sources"]; ok {
+		sourcesKey = "Sources"
+	}
+	sources, _ := doc[sourcesKey].([]interface{})
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no package sources in manifest")
+	}
+	source, _ := sources[0].(map[string]interface{})
+	if source == nil {
+		return nil, fmt.Errorf("malformed package source")
+	}
+
+	listKey, idKey := "packages", "id"
+	if _, ok := source["Packages"]; ok {
+		listKey, idKey = "Packages", "PackageIdentifier"
+	}
+	list, _ := source[listKey].([]interface{})
+	for _, p := range packages {
+		list = append(list, map[string]interface{}{idKey: p.Name})
+	}
+	source[listKey] = list
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// uninstallPackage removes one package with its package manager
+func uninstallPackage(p packageRef) error {
+	var cmd *exec.Cmd
+	switch p.Kind {
+	case "cask":
+		cmd = exec.Command("brew", "uninstall", "--cask", p.Name)
+	case "winget":
+		cmd = exec.Command("winget", "uninstall", "--id", p.Name, "--exact", "--disable-interactivity")
+	default:
+		cmd = exec.Command("brew", "uninstall", p.Name)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
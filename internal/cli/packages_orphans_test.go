@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestDiffPackages verifies orphans come from leaves only, tap formulas
+// match their short Brewfile names, and dependencies count as present
+func TestDiffPackages(t *testing.T) {
+	declared := []packageRef{{"brew", "git"}, {"brew", "felixkratz/formulae/sketchybar"}, {"brew", "jq"}, {"cask", "ghostty"}}
+	leaves := []packageRef{{"brew", "git"}, {"brew", "sketchybar"}, {"brew", "htop"}, {"cask", "ghostty"}, {"cask", "slack"}}
+	present := append([]packageRef{{"brew", "pcre2"}, {"brew", "sketchybar"}}, leaves...)
+
+	orphans, missing := diffPackages(declared, leaves, present)
+	if want := []packageRef{{"brew", "htop"}, {"cask", "slack"}}; !reflect.DeepEqual(orphans, want) {
+		t.Errorf("orphans = %v, want %v", orphans, want)
+	}
+	if want := []packageRef{{"brew", "jq"}}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing = %v, want %v", missing, want)
+	}
+
+	orphans, _ = diffPackages([]packageRef{{"winget", "Git.Git"}}, []packageRef{{"winget", "git.git"}}, nil)
+	if len(orphans) != 0 {
+		t.Errorf("winget ids matched case-sensitively: %v", orphans)
+	}
+}
+
+func TestAdoptPackages(t *testing.T) {
+	brewfile := adoptIntoBrewfile([]byte(`brew "git"`), []packageRef{{"brew", "htop"}, {"cask", "slack"}})
+	if want := "brew \"git\"\n\n# Adopted by blackdot packages orphans\nbrew \"htop\"\ncask \"slack\"\n"; string(brewfile) != want {
+		t.Errorf("Brewfile = %q", brewfile)
+	}
+
+	// Both manifest shapes round-trip through adopt and parse
+	manifests := []string{
+		`{"sources": [{"packages": [{"id": "Git.Git", "comment": "Version control"}]}]}`,
+		`{"Sources": [{"Packages": [{"PackageIdentifier": "Git.Git"}], "SourceDetails": {"Name": "winget"}}]}`,
+	}
+	for _, manifest := range manifests {
+		out, err := adoptIntoWingetManifest([]byte(manifest), []packageRef{{"winget", "Slack.Slack"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids, err := parseWingetManifest(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"Git.Git", "Slack.Slack"}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("ids = %v, want %v", ids, want)
+		}
+		if !strings.Contains(string(out), "Version control") && strings.Contains(manifest, "comment") {
+			t.Errorf("existing entries not kept:\n%s", out)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package cli
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/config"
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// pairCodePrefix marks a pairing code and its format version
+const pairCodePrefix = "BD1"
+
+// pairPayload is the non-secret configuration carried by a pairing code.
+// Keys are short to keep the code small. Never add credentials, session
+// tokens or vault contents here: secrets come from the vault itself.
+type pairPayload struct {
+	Backend   string     `json:"b,omitempty"`
+	Fallback  []string   `json:"fb,omitempty"`
+	Server    string     `json:"s,omitempty"`
+	Location  string     `json:"l,omitempty"`
+	Namespace string     `json:"n,omitempty"`
+	Features  []string   `json:"f,omitempty"`
+	Repos     []pairRepo `json:"r,omitempty"`
+	Workspace string     `json:"w,omitempty"`
+}
+
+// pairRepo is an overlay repo; URL lets the new machine clone it
+type pairRepo struct {
+	Name string `json:"n"`
+	Path string `json:"p"`
+	URL  string `json:"u,omitempty"`
+}
+
+var pairEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func newPairCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pair",
+		Short: "Share bootstrap config with a new machine",
+		Long: `Share non-secret bootstrap configuration with a new machine.
+
+'pair export' encodes the vault backend, fallback order, server URL,
+location, enabled features, overlay repos and workspace target into a
+short code (and optionally a QR code). 'pair import' on the new machine
+writes them to config so 'blackdot setup' starts prefilled.
+
+No secrets are included: sign in to the vault on the new machine and run
+'blackdot vault pull' to restore them.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	var qr, jsonOut bool
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Print a pairing code for this machine's config",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPairExport(qr, jsonOut)
+		},
+	}
+	exportCmd.Flags().BoolVar(&qr, "qr", false, "Also print a QR code (requires qrencode)")
+	exportCmd.Flags().BoolVar(&jsonOut, "json", false, "Print the decoded payload instead of a code")
+
+	var dryRun, yes bool
+	importCmd := &cobra.Command{
+		Use:   "import <code>",
+		Short: "Apply a pairing code from another machine",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPairImport(args[0], dryRun, yes)
+		},
+	}
+	importCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be configured")
+	importCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation")
+
+	cmd.AddCommand(exportCmd, importCmd)
+	return cmd
+}
+
+// collectPairPayload gathers the shareable config on this machine
+func collectPairPayload() pairPayload {
+	var p pairPayload
+
+	cfg, err := config.DefaultManager().Load()
+	if err == nil {
+		p.Backend = cfg.Vault.Backend
+		p.Fallback = cfg.Vault.Fallback
+		p.Location = cfg.Vault.Location
+		p.Namespace = cfg.Vault.Namespace
+		p.Server = cfg.Vault.Server
+		for name, enabled := range cfg.Features {
+			if enabled {
+				p.Features = append(p.Features, name)
+			}
+		}
+		sort.Strings(p.Features)
+	}
+	if p.Backend == "none" {
+		p.Backend = ""
+	}
+
+	// Self-hosted Bitwarden servers are configured in the bw CLI itself
+	if p.Server == "" && p.Backend == "bitwarden" {
+		if out, err := exec.Command("bw", "config", "server").Output(); err == nil {
+			if server := strings.TrimSpace(string(out)); server != "" && !strings.Contains(server, "bitwarden.com") {
+				p.Server = server
+			}
+		}
+	}
+	p.Server = sanitizePairURL(p.Server)
+
+	for _, r := range paths.Overlays() {
+		repo := pairRepo{Name: r.Name, Path: tildePath(r.Path)}
+		if out, err := exec.Command("git", "-C", r.Path, "remote", "get-url", "origin").Output(); err == nil {
+			repo.URL = sanitizePairURL(strings.TrimSpace(string(out)))
+		}
+		p.Repos = append(p.Repos, repo)
+	}
+
+	if target := configLookup("paths.workspace_target"); target != "" {
+		p.Workspace = tildePath(target)
+	}
+	return p
+}
+
+// sanitizePairURL drops credentials and query strings from a URL so tokens
+// embedded in remotes or server URLs never end up in a code
+func sanitizePairURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		// scp-style git remotes (git@host:org/repo) carry no secrets
+		return raw
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+// encodePairCode compresses the payload and encodes it as dash-grouped
+// base32 (QR alphanumeric mode) with a checksum
+func encodePairCode(p pairPayload) (string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.BestCompression)
+	w.Write(data)
+	w.Close()
+
+	sum := make([]byte, 2)
+	binary.BigEndian.PutUint16(sum, uint16(crc32.ChecksumIEEE(buf.Bytes())))
+	encoded := pairEncoding.EncodeToString(append(buf.Bytes(), sum...))
+
+	groups := []string{pairCodePrefix}
+	for len(encoded) > 5 {
+		groups = append(groups, encoded[:5])
+		encoded = encoded[5:]
+	}
+	groups = append(groups, encoded)
+	return strings.Join(groups, "-"), nil
+}
+
+// decodePairCode reverses encodePairCode. Case, spaces and dashes are
+// ignored so codes survive being retyped.
+func decodePairCode(code string) (pairPayload, error) {
+	var p pairPayload
+
+	code = strings.ToUpper(strings.Join(strings.Fields(code), ""))
+	code = strings.ReplaceAll(code, "-", "")
+	if !strings.HasPrefix(code, pairCodePrefix) {
+		return p, fmt.Errorf("not a blackdot pairing code (expected %s-...)", pairCodePrefix)
+	}
+
+	raw, err := pairEncoding.DecodeString(strings.TrimPrefix(code, pairCodePrefix))
+	if err != nil || len(raw) < 3 {
+		return p, fmt.Errorf("invalid pairing code: check for typos")
+	}
+	body, sum := raw[:len(raw)-2], raw[len(raw)-2:]
+	if binary.BigEndian.Uint16(sum) != uint16(crc32.ChecksumIEEE(body)) {
+		return p, fmt.Errorf("pairing code checksum mismatch: check for typos")
+	}
+
+	data, err := io.ReadAll(flate.NewReader(bytes.NewReader(body)))
+	if err != nil {
+		return p, fmt.Errorf("invalid pairing code: %w", err)
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, fmt.Errorf("invalid pairing code: %w", err)
+	}
+	return p, nil
+}
+
+// printPairPayload lists what a code carries
+func printPairPayload(p pairPayload) {
+	row := func(label, value string) {
+		if value != "" {
+			fmt.Printf("  %-12s %s\n", label, value)
+		}
+	}
+	row("Backend", p.Backend)
+	row("Fallback", strings.Join(p.Fallback, ", "))
+	row("Server", p.Server)
+	row("Location", p.Location)
+	row("Namespace", p.Namespace)
+	row("Features", strings.Join(p.Features, ", "))
+	row("Workspace", p.Workspace)
+	for _, r := range p.Repos {
+		detail := r.Path
+		if r.URL != "" {
+			detail += Dim.Sprint(" (" + r.URL + ")")
+		}
+		row("Repo "+r.Name, detail)
+	}
+}
+
+func runPairExport(qr, jsonOut bool) error {
+	p := collectPairPayload()
+
+	if jsonOut {
+		data, _ := json.MarshalIndent(p, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	code, err := encodePairCode(p)
+	if err != nil {
+		return err
+	}
+
+	PrintHeader("Pairing Code")
+	printPairPayload(p)
+	if p.Backend == "" {
+		fmt.Println()
+		Warn("No vault backend configured - the code only carries features and paths")
+	}
+	fmt.Println()
+	fmt.Println(Cyan.Sprint(code))
+	fmt.Println()
+
+	if qr {
+		if _, err := exec.LookPath("qrencode"); err != nil {
+			Warn("qrencode not found - install it for QR output (brew install qrencode)")
+		} else {
+			c := exec.Command("qrencode", "-t", "ANSIUTF8", code)
+			c.Stdout = os.Stdout
+			c.Stderr = os.Stderr
+			if err := c.Run(); err != nil {
+				Warn("qrencode failed: %v", err)
+			}
+			fmt.Println()
+		}
+	}
+
+	fmt.Println("On the new machine:")
+	fmt.Printf("  blackdot pair import %s\n", code)
+	fmt.Println(Dim.Sprint("No secrets are included; they are restored from the vault."))
+	return nil
+}
+
+func runPairImport(code string, dryRun, yes bool) error {
+	p, err := decodePairCode(code)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+
+	PrintHeader("Pairing Import")
+	printPairPayload(p)
+	fmt.Println()
+
+	if p.Backend != "" {
+		if err := validateBackendName(p.Backend); err != nil {
+			return err
+		}
+	}
+	for _, b := range p.Fallback {
+		if err := validateBackendName(b); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		Info("Dry run - nothing written to %s", configLayerUser)
+		return nil
+	}
+	if !yes && !Confirm("Write this configuration?") {
+		Info("Cancelled")
+		return nil
+	}
+
+	cfg := config.DefaultManager()
+	settings := []struct{ key, value string }{
+		{"vault.backend", p.Backend},
+		{"vault.fallback", strings.Join(p.Fallback, ",")},
+		{"vault.server", p.Server},
+		{"vault.location", p.Location},
+		{"vault.namespace", p.Namespace},
+	}
+	for _, s := range settings {
+		if s.value == "" {
+			continue
+		}
+		if err := cfg.Set(s.key, s.value); err != nil {
+			Fail("Failed to save %s: %v", s.key, err)
+			return err
+		}
+	}
+
+	if len(p.Features) > 0 {
+		reg := initRegistry()
+		for _, name := range p.Features {
+			if err := reg.Enable(name); err != nil {
+				Warn("Feature %s: %v", name, err)
+			}
+		}
+		if err := persistFeatureState(reg); err != nil {
+			Fail("Failed to save features: %v", err)
+			return err
+		}
+	}
+
+	if p.Workspace != "" {
+		if err := setInJSONFile(configLayerUser, "paths.workspace_target", p.Workspace); err != nil {
+			Fail("Failed to save workspace target: %v", err)
+			return err
+		}
+	}
+
+	// Point the bw CLI at a self-hosted server
+	if p.Backend == "bitwarden" && p.Server != "" {
+		if _, err := exec.LookPath("bw"); err == nil {
+			if out, err := exec.Command("bw", "config", "server", p.Server).CombinedOutput(); err != nil {
+				Warn("bw config server failed (log out first?): %s", strings.TrimSpace(string(out)))
+			} else {
+				Pass("bw server set to %s", p.Server)
+			}
+		}
+	}
+
+	var missing []pairRepo
+	if len(p.Repos) > 0 {
+		overlays := paths.Overlays()
+		known := map[string]bool{}
+		for _, r := range overlays {
+			known[r.Name] = true
+		}
+		for _, r := range p.Repos {
+			if known[r.Name] {
+				continue
+			}
+			if _, err := os.Stat(expandPath(r.Path)); err != nil {
+				missing = append(missing, r)
+			}
+			// Registered either way; paths.Overlays expands ~ when read
+			overlays = append(overlays, paths.Root{Name: r.Name, Path: r.Path})
+		}
+		if err := saveRepoOverlays(overlays); err != nil {
+			Fail("Failed to save overlay repos: %v", err)
+			return err
+		}
+	}
+
+	Pass("Configuration written to %s", configLayerUser)
+	fmt.Println()
+	fmt.Println("Next steps:")
+	step := 1
+	for _, r := range missing {
+		if r.URL != "" {
+			fmt.Printf("  %d. git clone %s %s\n", step, r.URL, r.Path)
+		} else {
+			fmt.Printf("  %d. Create overlay repo '%s' at %s\n", step, r.Name, r.Path)
+		}
+		step++
+	}
+	fmt.Printf("  %d. blackdot setup          %s\n", step, Dim.Sprint("# vault backend is preselected"))
+	fmt.Printf("  %d. blackdot vault pull     %s\n", step+1, Dim.Sprint("# secrets come from the vault"))
+	return nil
+}
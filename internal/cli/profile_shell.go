@@ -0,0 +1,694 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// shellProfileKind marks shell startup profiles in the metrics log
+const shellProfileKind = "shell-profile"
+
+// shellProfileRun is what one instrumented 'zsh -i -c exit' wrote to its
+// log (see the BLACKDOT_PROFILE_SHELL block in zsh/zshrc)
+type shellProfileRun struct {
+	WallMs    float64
+	ZshrcMs   float64
+	Modules   []shellProfileModule
+	Gates     []shellProfileGateCheck
+	Functions []shellProfileFunc
+}
+
+// shellProfileModule is the time spent sourcing one zsh.d module
+type shellProfileModule struct {
+	Name  string  `json:"name"`
+	Path  string  `json:"path,omitempty"`
+	Ms    float64 `json:"ms"`
+	start float64
+	end   float64
+}
+
+// shellProfileGateCheck is one feature_on call during startup
+type shellProfileGateCheck struct {
+	Feature string
+	Enabled bool
+	Ms      float64
+	Module  string
+	start   float64
+}
+
+// shellProfileGate sums the checks of one feature and the modules that
+// made them
+type shellProfileGate struct {
+	Feature  string   `json:"feature"`
+	Enabled  bool     `json:"enabled"`
+	Checks   int      `json:"checks"`
+	Ms       float64  `json:"ms"`
+	Modules  []string `json:"modules"`
+	ModuleMs float64  `json:"module_ms"`
+}
+
+// shellProfileFunc is one row of zprof's summary table
+type shellProfileFunc struct {
+	Name    string  `json:"name"`
+	Calls   int     `json:"calls"`
+	TotalMs float64 `json:"total_ms"`
+	SelfMs  float64 `json:"self_ms"`
+}
+
+// shellProfile is the median of several runs, with suggestions
+type shellProfile struct {
+	Runs        int                  `json:"runs"`
+	WallMs      float64              `json:"wall_ms"`
+	ZshrcMs     float64              `json:"zshrc_ms"`
+	Modules     []shellProfileModule `json:"modules"`
+	Gates       []shellProfileGate   `json:"gates"`
+	Functions   []shellProfileFunc   `json:"functions"`
+	Suggestions []string             `json:"suggestions"`
+	Previous    *shellProfileRecord  `json:"previous,omitempty"`
+}
+
+// shellProfileRecord is a profile's entry in the metrics log, for trends
+type shellProfileRecord struct {
+	Kind      string               `json:"kind"`
+	Timestamp string               `json:"timestamp"`
+	WallMs    float64              `json:"wall_ms"`
+	ZshrcMs   float64              `json:"zshrc_ms"`
+	Modules   []shellProfileModule `json:"modules"`
+	Hostname  string               `json:"hostname"`
+	OS        string               `json:"os"`
+}
+
+func newProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Profile shell startup",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+	cmd.AddCommand(newProfileShellCmd())
+	return cmd
+}
+
+func newProfileShellCmd() *cobra.Command {
+	var runs, top int
+	var threshold time.Duration
+	var jsonOut, noRecord bool
+
+	cmd := &cobra.Command{
+		Use:   "shell",
+		Short: "Show which zsh.d modules and feature gates slow down shell startup",
+		Long: `Start 'zsh -i -c exit' with BLACKDOT_PROFILE_SHELL set, which makes
+blackdot's zshrc record when each zsh.d module starts and ends, every
+feature_on check, and zsh's own function profile (zprof).
+
+The report shows the median of --runs shells: time per module, the
+feature gates checked at startup and what the modules behind them cost,
+the functions with the most self time, and suggestions: lazy-load
+candidates, tool init evals worth caching, and modules that still cost
+time with their feature off.
+
+Each profile is appended to the metrics log (~/.blackdot-metrics.jsonl)
+and compared with the previous one. --no-record skips that.`,
+		Example: `  blackdot profile shell
+  blackdot profile shell --runs 5 --threshold 10ms
+  blackdot profile shell --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if runs < 1 {
+				return fmt.Errorf("--runs must be at least 1")
+			}
+			return runProfileShell(runs, top, threshold, jsonOut, noRecord)
+		},
+	}
+
+	cmd.Flags().IntVar(&runs, "runs", 3, "Number of shells to start (the median is reported)")
+	cmd.Flags().IntVar(&top, "top", 10, "Functions to list from zprof")
+	cmd.Flags().DurationVar(&threshold, "threshold", 20*time.Millisecond, "Modules and functions slower than this get suggestions")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON")
+	cmd.Flags().BoolVar(&noRecord, "no-record", false, "Don't append the profile to the metrics log")
+	return cmd
+}
+
+// profileShellRun starts one interactive zsh that logs to logPath and
+// returns how long it took. A variable so tests need no zsh.
+var profileShellRun = func(logPath string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	c := exec.CommandContext(ctx, "zsh", "-i", "-c", "exit")
+	c.Env = append(os.Environ(), "BLACKDOT_PROFILE_SHELL="+logPath)
+	var stderr strings.Builder
+	c.Stderr = &stderr
+	start := time.Now()
+	if err := c.Run(); err != nil {
+		msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n")
+		if msg != "" {
+			return 0, fmt.Errorf("zsh: %w: %s", err, msg)
+		}
+		return 0, fmt.Errorf("zsh: %w", err)
+	}
+	return time.Since(start), nil
+}
+
+func runProfileShell(runs, top int, threshold time.Duration, jsonOut, noRecord bool) error {
+	if _, err := exec.LookPath("zsh"); err != nil {
+		Fail("zsh is not installed")
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "blackdot-profile-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	var results []*shellProfileRun
+	for i := 0; i < runs; i++ {
+		if !jsonOut {
+			fmt.Fprintf(os.Stderr, "\rStarting shell %d/%d...", i+1, runs)
+		}
+		logPath := filepath.Join(dir, fmt.Sprintf("run-%d.log", i))
+		wall, err := profileShellRun(logPath)
+		if err != nil {
+			if !jsonOut {
+				fmt.Fprintln(os.Stderr)
+			}
+			Fail("Shell failed to start: %v", err)
+			return err
+		}
+		data, err := os.ReadFile(logPath)
+		if os.IsNotExist(err) {
+			if !jsonOut {
+				fmt.Fprintln(os.Stderr)
+			}
+			Fail("The shell did not load blackdot's zshrc")
+			fmt.Println("  Link it with: ln -sf " + filepath.Join(BlackdotDir(), "zsh", "zshrc") + " ~/.zshrc")
+			return fmt.Errorf("no profile written to %s", logPath)
+		} else if err != nil {
+			return err
+		}
+		run, err := parseShellProfileLog(string(data))
+		if err != nil {
+			return err
+		}
+		run.WallMs = durationMs(wall)
+		results = append(results, run)
+	}
+	if !jsonOut {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+
+	profile := summarizeShellProfile(results, top)
+	records, _ := loadShellProfileRecords(metricsPath())
+	if len(records) > 0 {
+		profile.Previous = &records[len(records)-1]
+	}
+	profile.Suggestions = shellProfileSuggestions(profile, results[len(results)-1].Functions, threshold)
+
+	if !noRecord {
+		if err := recordShellProfile(profile); err != nil {
+			Debug("recording shell profile: %v", err)
+		}
+		records = append(records, shellProfileRecordOf(profile))
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(profile)
+	}
+	printShellProfile(profile, records)
+	return nil
+}
+
+// parseShellProfileLog reads the start/module/gate/end lines and the zprof
+// table that follows the "zprof" line
+func parseShellProfileLog(data string) (*shellProfileRun, error) {
+	run := &shellProfileRun{}
+	var start, end float64
+	lines := strings.Split(data, "\n")
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "start", "end":
+			if len(fields) < 2 {
+				continue
+			}
+			t, _ := strconv.ParseFloat(fields[1], 64)
+			if fields[0] == "start" {
+				start = t
+			} else {
+				end = t
+			}
+		case "module":
+			parts := strings.SplitN(line, " ", 4)
+			if len(parts) < 4 {
+				continue
+			}
+			s, err1 := strconv.ParseFloat(parts[1], 64)
+			e, err2 := strconv.ParseFloat(parts[2], 64)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			run.Modules = append(run.Modules, shellProfileModule{
+				Name: filepath.Base(parts[3]), Path: parts[3], Ms: (e - s) * 1000, start: s, end: e,
+			})
+		case "gate":
+			if len(fields) < 5 {
+				continue
+			}
+			s, err1 := strconv.ParseFloat(fields[1], 64)
+			e, err2 := strconv.ParseFloat(fields[2], 64)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			run.Gates = append(run.Gates, shellProfileGateCheck{
+				Feature: fields[3], Enabled: fields[4] == "on", Ms: (e - s) * 1000, start: s,
+			})
+		case "zprof":
+			run.Functions = parseZprof(strings.Join(lines[i+1:], "\n"))
+			return run.finish(start, end)
+		}
+	}
+	return run.finish(start, end)
+}
+
+// finish sets the zshrc total and which module made each gate check
+func (r *shellProfileRun) finish(start, end float64) (*shellProfileRun, error) {
+	if start == 0 {
+		return nil, fmt.Errorf("shell profile log has no start time")
+	}
+	if end > start {
+		r.ZshrcMs = (end - start) * 1000
+	}
+	for i := range r.Gates {
+		for _, m := range r.Modules {
+			if r.Gates[i].start >= m.start && r.Gates[i].start <= m.end {
+				r.Gates[i].Module = m.Name
+				break
+			}
+		}
+	}
+	return r, nil
+}
+
+// zprofRowPattern matches a row of zprof's first table:
+// num) calls total avg pct% self avg pct% name
+var zprofRowPattern = regexp.MustCompile(`^\s*\d+\)\s+(\d+)\s+([\d.]+)\s+[\d.]+\s+[\d.]+%\s+([\d.]+)\s+[\d.]+\s+[\d.]+%\s+(\S+)`)
+
+// parseZprof reads the summary table at the top of zprof's output, which
+// ends at the first blank line
+func parseZprof(out string) []shellProfileFunc {
+	var funcs []shellProfileFunc
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(funcs) > 0 {
+				break
+			}
+			continue
+		}
+		m := zprofRowPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		calls, _ := strconv.Atoi(m[1])
+		total, _ := strconv.ParseFloat(m[2], 64)
+		self, _ := strconv.ParseFloat(m[3], 64)
+		if strings.HasPrefix(m[4], "_blackdot_profile") {
+			continue
+		}
+		funcs = append(funcs, shellProfileFunc{Name: m[4], Calls: calls, TotalMs: total, SelfMs: self})
+	}
+	return funcs
+}
+
+// summarizeShellProfile takes the median of each measurement across runs,
+// keeping the module order of the first run
+func summarizeShellProfile(runs []*shellProfileRun, top int) *shellProfile {
+	p := &shellProfile{Runs: len(runs), Modules: []shellProfileModule{}, Gates: []shellProfileGate{}, Functions: []shellProfileFunc{}}
+	var walls, zshrcs []float64
+	moduleMs := make(map[string][]float64)
+	var moduleOrder []string
+	gateMs := make(map[string][]float64)
+	gates := make(map[string]*shellProfileGate)
+	var gateOrder []string
+	funcTotal := make(map[string][]float64)
+	funcSelf := make(map[string][]float64)
+	funcs := make(map[string]*shellProfileFunc)
+
+	for _, run := range runs {
+		walls = append(walls, run.WallMs)
+		zshrcs = append(zshrcs, run.ZshrcMs)
+		for _, m := range run.Modules {
+			if _, ok := moduleMs[m.Path]; !ok {
+				moduleOrder = append(moduleOrder, m.Path)
+			}
+			moduleMs[m.Path] = append(moduleMs[m.Path], m.Ms)
+		}
+
+		perRun := make(map[string]float64)
+		for _, g := range run.Gates {
+			gate, ok := gates[g.Feature]
+			if !ok {
+				gate = &shellProfileGate{Feature: g.Feature}
+				gates[g.Feature] = gate
+				gateOrder = append(gateOrder, g.Feature)
+			}
+			perRun[g.Feature] += g.Ms
+			gate.Enabled = g.Enabled
+			if run == runs[0] {
+				gate.Checks++
+			}
+			if g.Module != "" && !containsString(gate.Modules, g.Module) {
+				gate.Modules = append(gate.Modules, g.Module)
+			}
+		}
+		for feature, ms := range perRun {
+			gateMs[feature] = append(gateMs[feature], ms)
+		}
+
+		for _, f := range run.Functions {
+			if _, ok := funcs[f.Name]; !ok {
+				funcs[f.Name] = &shellProfileFunc{Name: f.Name}
+			}
+			funcs[f.Name].Calls = f.Calls
+			funcTotal[f.Name] = append(funcTotal[f.Name], f.TotalMs)
+			funcSelf[f.Name] = append(funcSelf[f.Name], f.SelfMs)
+		}
+	}
+
+	p.WallMs = medianMs(walls)
+	p.ZshrcMs = medianMs(zshrcs)
+	byName := make(map[string]float64)
+	for _, path := range moduleOrder {
+		m := shellProfileModule{Name: filepath.Base(path), Path: path, Ms: medianMs(moduleMs[path])}
+		byName[m.Name] = m.Ms
+		p.Modules = append(p.Modules, m)
+	}
+	for _, feature := range gateOrder {
+		g := gates[feature]
+		g.Ms = medianMs(gateMs[feature])
+		for _, name := range g.Modules {
+			g.ModuleMs += byName[name]
+		}
+		p.Gates = append(p.Gates, *g)
+	}
+	sort.SliceStable(p.Gates, func(i, j int) bool { return p.Gates[i].ModuleMs > p.Gates[j].ModuleMs })
+	for name, f := range funcs {
+		f.TotalMs = medianMs(funcTotal[name])
+		f.SelfMs = medianMs(funcSelf[name])
+		p.Functions = append(p.Functions, *f)
+	}
+	sort.Slice(p.Functions, func(i, j int) bool {
+		if p.Functions[i].SelfMs != p.Functions[j].SelfMs {
+			return p.Functions[i].SelfMs > p.Functions[j].SelfMs
+		}
+		return p.Functions[i].Name < p.Functions[j].Name
+	})
+	if top > 0 && len(p.Functions) > top {
+		p.Functions = p.Functions[:top]
+	}
+	return p
+}
+
+// medianMs is the median of values, or 0 when there are none
+func medianMs(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// slowStartupPatterns are top-level module lines known to cost startup
+// time, with what to do about them
+var slowStartupPatterns = []struct {
+	pattern *regexp.Regexp
+	advice  string
+}{
+	{regexp.MustCompile(`(nvm\.sh|sdkman-init\.sh|conda\.sh|\b(pyenv|rbenv|nodenv|conda) (init|shell\.zsh hook))`), "lazy-load candidate: load it on first use, like the NVM and SDKMAN loaders in 90-integrations.zsh"},
+	{regexp.MustCompile(`\$\(\s*brew --prefix\s*\)`), "'brew --prefix' starts Ruby; use $HOMEBREW_PREFIX, set by 'brew shellenv'"},
+	{regexp.MustCompile(`^\s*compinit(\s|$)`), "compinit without -C audits fpath on every start; see 'blackdot shell compcache'"},
+	{regexp.MustCompile(`eval\s+"\$\(\s*([^)"]+)`), "runs a command on every start; cache its output to a file and source that"},
+}
+
+// shellProfileSuggestions looks for what could make startup faster:
+// known-slow lines in slow modules, slow functions, gate checks that call
+// the binary, modules that cost time with their feature off, and time
+// spent before the zshrc
+func shellProfileSuggestions(p *shellProfile, funcs []shellProfileFunc, threshold time.Duration) []string {
+	limit := durationMs(threshold)
+	var out []string
+
+	for _, m := range p.Modules {
+		if m.Ms < limit {
+			continue
+		}
+		hits := scanSlowStartupLines(m.Path)
+		for _, h := range hits {
+			out = append(out, fmt.Sprintf("%s (%s): %s", h, formatMs(m.Ms), h.advice))
+		}
+		if len(hits) == 0 {
+			out = append(out, fmt.Sprintf("%s takes %s; move work into functions that run on first use", m.Name, formatMs(m.Ms)))
+		}
+	}
+
+	for _, f := range p.Functions {
+		if f.SelfMs < limit {
+			continue
+		}
+		switch {
+		case f.Name == "compinit" || f.Name == "compdump":
+			out = append(out, fmt.Sprintf("%s: %s; run 'blackdot shell compcache status' to check the dump is reused", f.Name, formatMs(f.SelfMs)))
+		default:
+			out = append(out, fmt.Sprintf("function %s: %s self time at startup; lazy-load candidate", f.Name, formatMs(f.SelfMs)))
+		}
+	}
+
+	var gateMs float64
+	for _, g := range p.Gates {
+		gateMs += g.Ms
+		if !g.Enabled && g.ModuleMs >= limit {
+			out = append(out, fmt.Sprintf("feature %s is off but %s still take %s; gate them first with 'feature_on %s || return 0'",
+				g.Feature, strings.Join(g.Modules, ", "), formatMs(g.ModuleMs), g.Feature))
+		}
+	}
+	if gateMs >= 5 {
+		out = append(out, fmt.Sprintf("feature checks took %s; the cached feature snippet may be missing, run 'blackdot features export --format zsh'", formatMs(gateMs)))
+	}
+
+	if outside := p.WallMs - p.ZshrcMs; p.ZshrcMs > 0 && outside >= 100 {
+		out = append(out, fmt.Sprintf("%s is spent outside blackdot's zshrc (.zshenv, .zprofile, zsh itself)", formatMs(outside)))
+	}
+	return out
+}
+
+// slowStartupHit is a known-slow line in a module
+type slowStartupHit struct {
+	module string
+	line   int
+	advice string
+}
+
+func (h slowStartupHit) String() string { return fmt.Sprintf("%s:%d", h.module, h.line) }
+
+// scanSlowStartupLines finds known-slow lines that run when the module is
+// sourced, skipping the bodies of functions
+func scanSlowStartupLines(path string) []slowStartupHit {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var hits []slowStartupHit
+	inFunc := false
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if zshFuncOpen.MatchString(line) {
+			inFunc = true
+			continue
+		}
+		if inFunc {
+			if strings.HasPrefix(line, "}") {
+				inFunc = false
+			}
+			continue
+		}
+		for _, p := range slowStartupPatterns {
+			if p.pattern.MatchString(line) {
+				hits = append(hits, slowStartupHit{module: filepath.Base(path), line: i + 1, advice: p.advice})
+				break
+			}
+		}
+	}
+	return hits
+}
+
+// zshFuncOpen matches the first line of a multi-line function definition
+var zshFuncOpen = regexp.MustCompile(`^(function\s+[\w:.-]+(\s*\(\))?|[\w:.-]+\s*\(\))\s*\{\s*$`)
+
+func shellProfileRecordOf(p *shellProfile) shellProfileRecord {
+	rec := shellProfileRecord{
+		Kind:      shellProfileKind,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		WallMs:    p.WallMs,
+		ZshrcMs:   p.ZshrcMs,
+		OS:        runtime.GOOS,
+	}
+	for _, m := range p.Modules {
+		rec.Modules = append(rec.Modules, shellProfileModule{Name: m.Name, Ms: m.Ms})
+	}
+	rec.Hostname, _ = os.Hostname()
+	return rec
+}
+
+// recordShellProfile appends the profile to the metrics log
+func recordShellProfile(p *shellProfile) error {
+	data, err := json.Marshal(shellProfileRecordOf(p))
+	if err != nil {
+		return err
+	}
+	return appendFileWithPolicy(metricsPath(), append(data, '\n'), fileClassPrivate)
+}
+
+// loadShellProfileRecords reads the shell profiles from the metrics log,
+// oldest first
+func loadShellProfileRecords(path string) ([]shellProfileRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []shellProfileRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec shellProfileRecord
+		if json.Unmarshal(scanner.Bytes(), &rec) != nil || rec.Kind != shellProfileKind {
+			continue
+		}
+		records = append(records, rec)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Timestamp < records[j].Timestamp })
+	return records, scanner.Err()
+}
+
+// formatMsDelta renders a change in milliseconds, colored by direction
+func formatMsDelta(delta float64) string {
+	switch {
+	case delta >= 1:
+		return Red.Sprintf("+%s", formatMs(delta))
+	case delta <= -1:
+		return Green.Sprintf("-%s", formatMs(-delta))
+	}
+	return Dim.Sprint("±0")
+}
+
+func printShellProfile(p *shellProfile, history []shellProfileRecord) {
+	PrintHeader("Shell Startup Profile")
+	runs := "1 run"
+	if p.Runs > 1 {
+		runs = fmt.Sprintf("median of %d runs", p.Runs)
+	}
+	fmt.Printf("  zsh -i -c exit:   %s %s\n", Bold.Sprint(formatMs(p.WallMs)), Dim.Sprintf("(%s)", runs))
+	fmt.Printf("  blackdot zshrc:   %s\n", formatMs(p.ZshrcMs))
+	if p.Previous != nil {
+		fmt.Printf("  since last:       %s %s\n", formatMsDelta(p.WallMs-p.Previous.WallMs), Dim.Sprintf("(%s)", formatTimeAgo(p.Previous.Timestamp)))
+	}
+	fmt.Println()
+
+	previous := make(map[string]float64)
+	if p.Previous != nil {
+		for _, m := range p.Previous.Modules {
+			previous[m.Name] = m.Ms
+		}
+	}
+	PrintSubheader("Modules")
+	modules := append([]shellProfileModule(nil), p.Modules...)
+	sort.SliceStable(modules, func(i, j int) bool { return modules[i].Ms > modules[j].Ms })
+	for _, m := range modules {
+		pct := 0.0
+		if p.ZshrcMs > 0 {
+			pct = m.Ms / p.ZshrcMs * 100
+		}
+		bar := strings.Repeat("█", int(pct/5))
+		delta := ""
+		if prev, ok := previous[m.Name]; ok && (m.Ms-prev >= 5 || prev-m.Ms >= 5) {
+			delta = " " + formatMsDelta(m.Ms-prev)
+		}
+		fmt.Printf("  %-24s %8s %4.0f%% %s%s\n", m.Name, formatMs(m.Ms), pct, Cyan.Sprint(bar), delta)
+	}
+	fmt.Println()
+
+	if len(p.Gates) > 0 {
+		PrintSubheader("Feature Gates")
+		for _, g := range p.Gates {
+			fmt.Printf("  %s %-20s %8s check  %s\n", StatusIcon(g.Enabled), g.Feature, formatMs(g.Ms),
+				Dim.Sprintf("%s behind it in %s", formatMs(g.ModuleMs), strings.Join(g.Modules, ", ")))
+		}
+		fmt.Println()
+	}
+
+	if len(p.Functions) > 0 {
+		PrintSubheader("Functions (zprof, by self time)")
+		for _, f := range p.Functions {
+			fmt.Printf("  %-32s %8s self %8s total  %s\n", f.Name, formatMs(f.SelfMs), formatMs(f.TotalMs), Dim.Sprintf("%d call(s)", f.Calls))
+		}
+		fmt.Println()
+	}
+
+	if len(history) > 1 {
+		PrintSubheader("Trend")
+		start := max(0, len(history)-10)
+		for _, rec := range history[start:] {
+			date, clock := parseTimestamp(rec.Timestamp)
+			fmt.Printf("  %s %s %8s %s\n", date, clock, formatMs(rec.WallMs), Cyan.Sprint(strings.Repeat("▪", int(rec.WallMs/25))))
+		}
+		fmt.Println()
+	}
+
+	if len(p.Suggestions) == 0 {
+		Pass("Nothing over the threshold")
+		return
+	}
+	PrintSubheader("Suggestions")
+	for _, s := range p.Suggestions {
+		fmt.Printf("  %s %s\n", Yellow.Sprint("→"), s)
+	}
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleZprof = `num  calls                time                       self            name
+-----------------------------------------------------------------------------------
+ 1)    1          41.20    41.20   52.10%     38.90    38.90   49.19%  compinit
+ 2)    2           9.10     4.55   11.51%      9.10     4.55   11.51%  _blackdot_profile_feature_on
+ 3)    1           6.30     6.30    7.97%      6.30     6.30    7.97%  nvm_lazy
+
+-----------------------------------------------------------------------------------
+
+ 1)    1          41.20    41.20   52.10%     38.90    38.90   49.19%  compinit
+`
+
+// TestParseShellProfileLog verifies module times, gate attribution and the
+// zprof summary table are read from a profile log
+func TestParseShellProfileLog(t *testing.T) {
+	log := strings.Join([]string{
+		"start 1700000000.000000",
+		"module 1700000000.001000 1700000000.011000 /home/u/.blackdot/zsh/zsh.d/00-init.zsh",
+		"gate 1700000000.012000 1700000000.012500 docker_tools off",
+		"module 1700000000.011000 1700000000.041000 /home/u/.blackdot/zsh/zsh.d/66-docker.zsh",
+		"end 1700000000.050000",
+		"zprof",
+		sampleZprof,
+	}, "\n")
+
+	run, err := parseShellProfileLog(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.ZshrcMs < 49.9 || run.ZshrcMs > 50.1 {
+		t.Errorf("ZshrcMs = %v", run.ZshrcMs)
+	}
+	if len(run.Modules) != 2 || run.Modules[1].Name != "66-docker.zsh" || run.Modules[1].Ms < 29.9 || run.Modules[1].Ms > 30.1 {
+		t.Errorf("Modules = %+v", run.Modules)
+	}
+	if len(run.Gates) != 1 || run.Gates[0].Module != "66-docker.zsh" || run.Gates[0].Enabled {
+		t.Errorf("Gates = %+v", run.Gates)
+	}
+	if len(run.Functions) != 2 || run.Functions[0].Name != "compinit" || run.Functions[0].SelfMs != 38.9 || run.Functions[1].Name != "nvm_lazy" {
+		t.Errorf("Functions = %+v", run.Functions)
+	}
+
+	if _, err := parseShellProfileLog("zprof\n"); err == nil {
+		t.Error("a log without a start line should be an error")
+	}
+}
+
+// TestSummarizeShellProfile verifies runs are combined by median and gates
+// carry the cost of the modules that check them
+func TestSummarizeShellProfile(t *testing.T) {
+	run := func(wall, docker float64) *shellProfileRun {
+		return &shellProfileRun{
+			WallMs:  wall,
+			ZshrcMs: wall - 10,
+			Modules: []shellProfileModule{
+				{Name: "00-init.zsh", Path: "/d/00-init.zsh", Ms: 5},
+				{Name: "66-docker.zsh", Path: "/d/66-docker.zsh", Ms: docker},
+			},
+			Gates:     []shellProfileGateCheck{{Feature: "docker_tools", Ms: 0.5, Module: "66-docker.zsh"}},
+			Functions: []shellProfileFunc{{Name: "compinit", Calls: 1, SelfMs: docker}},
+		}
+	}
+	p := summarizeShellProfile([]*shellProfileRun{run(100, 30), run(300, 90), run(120, 40)}, 10)
+
+	if p.Runs != 3 || p.WallMs != 120 || p.ZshrcMs != 110 {
+		t.Errorf("totals = %d runs, %v wall, %v zshrc", p.Runs, p.WallMs, p.ZshrcMs)
+	}
+	if p.Modules[1].Ms != 40 {
+		t.Errorf("docker module = %v, want the median 40", p.Modules[1].Ms)
+	}
+	if len(p.Gates) != 1 || p.Gates[0].Checks != 1 || p.Gates[0].ModuleMs != 40 {
+		t.Errorf("Gates = %+v", p.Gates)
+	}
+	if medianMs([]float64{1, 3}) != 2 || medianMs(nil) != 0 {
+		t.Error("medianMs")
+	}
+}
+
+// TestShellProfileSuggestions verifies slow eval lines outside functions,
+// slow functions and disabled-but-costly gates are suggested
+func TestShellProfileSuggestions(t *testing.T) {
+	dir := t.TempDir()
+	module := filepath.Join(dir, "90-integrations.zsh")
+	os.WriteFile(module, []byte(`# eval "$(pyenv init -)" in a comment
+eval "$(pyenv init -)"
+nvm() {
+  source "$NVM_DIR/nvm.sh"
+}
+`), 0644)
+
+	p := &shellProfile{
+		WallMs:    400,
+		ZshrcMs:   250,
+		Modules:   []shellProfileModule{{Name: "90-integrations.zsh", Path: module, Ms: 80}, {Name: "20-env.zsh", Path: filepath.Join(dir, "20-env.zsh"), Ms: 2}},
+		Gates:     []shellProfileGate{{Feature: "docker_tools", Modules: []string{"66-docker.zsh"}, ModuleMs: 35, Ms: 1}},
+		Functions: []shellProfileFunc{{Name: "compinit", SelfMs: 40}, {Name: "fast", SelfMs: 1}},
+	}
+	got := strings.Join(shellProfileSuggestions(p, p.Functions, 20*time.Millisecond), "\n")
+
+	for _, want := range []string{
+		"90-integrations.zsh:2 (80ms): lazy-load candidate",
+		"compinit: 40ms",
+		"feature docker_tools is off but 66-docker.zsh still take 35ms",
+		"150ms is spent outside",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("suggestions missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, ":4 ") || strings.Contains(got, "20-env") || strings.Contains(got, "fast") {
+		t.Errorf("unexpected suggestion:\n%s", got)
+	}
+}
+
+// TestShellProfileRecords verifies profiles round-trip through the metrics
+// log alongside other entries
+func TestShellProfileRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.jsonl")
+	os.WriteFile(path, []byte(`{"timestamp":"2026-01-01T00:00:00Z","health_score":90}
+{"kind":"shell-profile","timestamp":"2026-01-03T00:00:00Z","wall_ms":210,"modules":[{"name":"10-plugins.zsh","ms":90}]}
+{"kind":"timing","timestamp":"2026-01-02T00:00:00Z","command":"doctor"}
+{"kind":"shell-profile","timestamp":"2026-01-02T00:00:00Z","wall_ms":250}
+`), 0600)
+
+	records, err := loadShellProfileRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].WallMs != 250 || records[1].Modules[0].Ms != 90 {
+		t.Errorf("records = %+v", records)
+	}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressBar renders a single redrawn status line on a terminal:
+//
+//	[=========>          ] 12/30  SSH-GitHub  ETA 8s
+//
+// It is safe for concurrent use. When the output is not a terminal it
+// draws nothing, so callers can use it unconditionally.
+type progressBar struct {
+	mu      sync.Mutex
+	out     io.Writer
+	enabled bool
+	phase   string // progress event phase
+	label   string
+	total   int
+	done    int
+	current string
+	start   time.Time
+}
+
+// newProgressBar returns a bar writing to stderr when it is a terminal
+func newProgressBar(label string, total int) *progressBar {
+	enabled := false
+	if info, err := os.Stderr.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		enabled = os.Getenv("TERM") != "dumb" && os.Getenv("CI") == ""
+	}
+	// With --progress json the bar's steps are reported as events instead
+	if progressEventsEnabled() {
+		enabled = false
+	}
+	return &progressBar{out: os.Stderr, enabled: enabled, phase: strings.ToLower(label), label: label, total: total, start: time.Now()}
+}
+
+// Start marks an item as in progress
+func (p *progressBar) Start(item string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = item
+	p.draw()
+	emitProgress(progressEvent{Phase: p.phase, Item: item, Percent: progressPercent(p.done, p.total)})
+}
+
+// Done marks an item as finished
+func (p *progressBar) Done(item string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if p.current == item {
+		p.current = ""
+	}
+	p.draw()
+	emitProgress(progressEvent{Phase: p.phase, Item: item, Percent: progressPercent(p.done, p.total), Status: "done"})
+}
+
+// Finish clears the progress line
+func (p *progressBar) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.enabled {
+		fmt.Fprint(p.out, "\r\033[K")
+	}
+}
+
+// eta estimates the remaining time from the average time per item
+func (p *progressBar) eta() time.Duration {
+	if p.done == 0 || p.done >= p.total {
+		return 0
+	}
+	perItem := time.Since(p.start) / time.Duration(p.done)
+	return (perItem * time.Duration(p.total-p.done)).Round(time.Second)
+}
+
+func (p *progressBar) draw() {
+	if !p.enabled || p.total == 0 {
+		return
+	}
+	const width = 20
+	filled := width * p.done / p.total
+	bar := strings.Repeat("=", filled)
+	if filled < width {
+		bar += ">" + strings.Repeat(" ", width-filled-1)
+	}
+	line := fmt.Sprintf("%s [%s] %d/%d", p.label, bar, p.done, p.total)
+	if p.current != "" {
+		line += "  " + p.current
+	}
+	if eta := p.eta(); eta > 0 {
+		line += "  ETA " + eta.String()
+	}
+	fmt.Fprintf(p.out, "\r\033[K%s", line)
+}
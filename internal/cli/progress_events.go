@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// progressFormat is the --progress flag: json emits progress events
+var progressFormat string
+
+// progressEvent is one line of --progress json output. Status is set when
+// a step finishes: ok, skipped, or failed for phases, the report status
+// (restored, unchanged, skipped, failed) for restored items, done for
+// fetches, and pass, warn, or fail for doctor checks.
+type progressEvent struct {
+	Time    string `json:"time"`
+	Command string `json:"command"`
+	Phase   string `json:"phase"`
+	Item    string `json:"item,omitempty"`
+	Percent int    `json:"percent"`
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// progressEmitter writes progress events for the running command
+type progressEmitter struct {
+	mu      sync.Mutex
+	out     io.Writer // nil when --progress is off
+	command string
+}
+
+var progressEvents = &progressEmitter{}
+
+// startProgressEvents enables --progress json for cmd. Events own stderr,
+// so everything else written to stderr (status lines, warnings, prompts)
+// moves to stdout with the rest of the human output.
+func startProgressEvents(cmd *cobra.Command) error {
+	switch progressFormat {
+	case "", "none":
+		return nil
+	case "json":
+	default:
+		return fmt.Errorf("invalid --progress %q (use json)", progressFormat)
+	}
+	progressEvents.mu.Lock()
+	defer progressEvents.mu.Unlock()
+	progressEvents.out = os.Stderr
+	progressEvents.command = cmd.CommandPath()
+	os.Stderr = os.Stdout
+	return nil
+}
+
+// progressEventsEnabled reports whether --progress json is on
+func progressEventsEnabled() bool {
+	progressEvents.mu.Lock()
+	defer progressEvents.mu.Unlock()
+	return progressEvents.out != nil
+}
+
+// emitProgress writes ev as one JSON line and adds it to the trace. It
+// costs nothing when --progress and tracing are off.
+func emitProgress(ev progressEvent) {
+	traceProgress(ev)
+	progressEvents.mu.Lock()
+	defer progressEvents.mu.Unlock()
+	if progressEvents.out == nil {
+		return
+	}
+	ev.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	ev.Command = progressEvents.command
+	enc := json.NewEncoder(progressEvents.out)
+	enc.SetEscapeHTML(false)
+	enc.Encode(ev)
+}
+
+// progressPercent is done out of total as a whole percentage
+func progressPercent(done, total int) int {
+	if total <= 0 {
+		return 0
+	}
+	return min(100, done*100/total)
+}
+
+// finishProgressEvents emits the final "done" event with the command's
+// outcome
+func finishProgressEvents(err error) {
+	ev := progressEvent{Phase: "done", Percent: 100, Status: "ok"}
+	if err != nil {
+		ev.Status, ev.Message = "failed", err.Error()
+	}
+	emitProgress(ev)
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestEmitProgress verifies events are one JSON object per line, restore
+// outcomes carry the running percentage, and the final event reports the
+// command's error
+func TestEmitProgress(t *testing.T) {
+	var buf bytes.Buffer
+	progressEvents.out, progressEvents.command = &buf, "blackdot vault restore"
+	t.Cleanup(func() { progressEvents.out = nil })
+
+	bar := newProgressBar("Fetching", 2)
+	bar.Start("Git-Config")
+	bar.Done("Git-Config")
+	report := newVaultReport("restore", "bitwarden", false)
+	report.expect(4)
+	report.add("Git-Config", "/home/me/.gitconfig", reportStatusRestored, "")
+	report.add("AWS-Config", "/home/me/.aws/config", reportStatusFailed, "not found")
+	finishProgressEvents(errors.New("1 items failed to restore"))
+
+	var events []progressEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var ev progressEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("bad line %q: %v", line, err)
+		}
+		events = append(events, ev)
+	}
+	want := []progressEvent{
+		{Phase: "fetching", Item: "Git-Config", Percent: 0},
+		{Phase: "fetching", Item: "Git-Config", Percent: 50, Status: "done"},
+		{Phase: "restore", Item: "Git-Config", Percent: 25, Status: "restored"},
+		{Phase: "restore", Item: "AWS-Config", Percent: 50, Status: "failed", Message: "not found"},
+		{Phase: "done", Percent: 100, Status: "failed", Message: "1 items failed to restore"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events: %s", len(events), buf.String())
+	}
+	for i, ev := range events {
+		if ev.Command != "blackdot vault restore" || ev.Time == "" {
+			t.Errorf("event %d missing command or time: %+v", i, ev)
+		}
+		ev.Command, ev.Time = "", ""
+		if ev != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, ev, want[i])
+		}
+	}
+}
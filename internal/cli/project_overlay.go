@@ -0,0 +1,385 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/config"
+	"github.com/blackwell-systems/blackdot/internal/feature"
+	"gopkg.in/yaml.v3"
+)
+
+// projectOverlayFiles are looked up in the root of the current git
+// repository. The overlay is part of the project layer and can override config keys, feature
+// toggles, template variables, required vault items, vault item definitions
+// and hooks (the last two only when the user opts in from a trusted layer):
+//
+//	config:
+//	  shell:
+//	    theme: minimal
+//	features:
+//	  aws_helpers: true
+//	template:
+//	  variables:
+//	    git_email: me@work.example
+//	vault:
+//	  required: [Work-SSH-Key]
+//	  items:
+//	    Work-SSH-Key: {path: ~/.ssh/id_work, type: sshkey}
+//	hooks:
+//	  post_vault_pull:
+//	    - name: fix-perms
+//	      command: chmod 600 ~/.ssh/id_work
+var projectOverlayFiles = []string{".blackdot.yaml", ".blackdot.yml"}
+
+// projectOverlay is the parsed .blackdot.yaml
+type projectOverlay struct {
+	Path     string                 `yaml:"-"`
+	Config   map[string]interface{} `yaml:"config"`
+	Features map[string]bool        `yaml:"features"`
+	Template struct {
+		Variables map[string]interface{} `yaml:"variables"`
+	} `yaml:"template"`
+	Vault struct {
+		Required []string             `yaml:"required"`
+		Items    map[string]VaultItem `yaml:"items"`
+	} `yaml:"vault"`
+	Hooks map[string][]HookEntry `yaml:"hooks"`
+}
+
+// findProjectRoot walks up from the working directory to the nearest
+// directory containing .git and returns it, or "" outside a repository
+func findProjectRoot() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// findProjectOverlay returns the path of .blackdot.yaml in the current
+// repository root, or ""
+func findProjectOverlay() string {
+	root := findProjectRoot()
+	if root == "" {
+		return ""
+	}
+	for _, name := range projectOverlayFiles {
+		path := filepath.Join(root, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// loadProjectOverlay parses the current repository's overlay. It returns
+// nil without error when there is none.
+func loadProjectOverlay() (*projectOverlay, error) {
+	path := findProjectOverlay()
+	if path == "" {
+		return nil, nil
+	}
+	return parseProjectOverlay(path)
+}
+
+func parseProjectOverlay(path string) (*projectOverlay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var overlay projectOverlay
+	if err := yaml.Unmarshal(data, &overlay); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	overlay.Path = path
+
+	// Round-trip config through JSON so nested maps and numbers look the
+	// same as values read from the .json layers
+	if overlay.Config != nil {
+		raw, err := json.Marshal(overlay.Config)
+		if err != nil {
+			return nil, fmt.Errorf("%s: config: %w", path, err)
+		}
+		overlay.Config = nil
+		json.Unmarshal(raw, &overlay.Config)
+	}
+
+	// Accept hook point aliases (post-restore) as well as canonical names
+	for point, entries := range overlay.Hooks {
+		if target, ok := hookPointAliases[point]; ok {
+			delete(overlay.Hooks, point)
+			overlay.Hooks[target] = append(overlay.Hooks[target], entries...)
+		}
+	}
+	return &overlay, nil
+}
+
+// currentProjectOverlay loads the overlay and warns once if it is invalid
+func currentProjectOverlay() *projectOverlay {
+	overlay, err := loadProjectOverlay()
+	if err != nil {
+		if !overlayWarned {
+			Warn("Ignoring project overlay: %v", err)
+			overlayWarned = true
+		}
+		return nil
+	}
+	return overlay
+}
+
+var overlayWarned bool
+
+// projectLayerValue resolves key in the project layer: .blackdot.json
+// first, then the config section of .blackdot.yaml. It returns the value
+// and the file it came from.
+func projectLayerValue(key string) (string, string) {
+	if projectConfig := findProjectConfig(); projectConfig != "" {
+		if val := getFromJSONFile(projectConfig, key); val != "" {
+			return val, projectConfig
+		}
+	}
+	if overlay := currentProjectOverlay(); overlay != nil {
+		if val := lookupNested(overlay.Config, key); val != "" {
+			return val, overlay.Path
+		}
+	}
+	return "", ""
+}
+
+// applyOverlayVaultItems adds the overlay's vault items and marks its
+// required items. The items themselves are only taken when the user has
+// opted in with vault.allow_project_items.
+func applyOverlayVaultItems(items map[string]VaultItem, overlay *projectOverlay) map[string]VaultItem {
+	if overlay == nil {
+		return items
+	}
+	if items == nil {
+		items = make(map[string]VaultItem)
+	}
+	if len(overlay.Vault.Items) > 0 {
+		if projectVaultItemsAllowed() {
+			for name, item := range overlay.Vault.Items {
+				items[name] = item
+			}
+		} else if !overlayItemsWarned {
+			Warn("Ignoring vault items in %s: project vault items need vault.allow_project_items", tildePath(overlay.Path))
+			overlayItemsWarned = true
+		}
+	}
+	for _, name := range overlay.Vault.Required {
+		if item, ok := items[name]; ok {
+			item.Required = true
+			items[name] = item
+		} else {
+			Warn("%s requires vault item %q, which is not defined", tildePath(overlay.Path), name)
+		}
+	}
+	return items
+}
+
+// projectHooksAllowed reports whether hooks defined in a repository's
+// overlay may run. A cloned repository must not be able to run commands
+// on its own, so this is only read from the environment and the machine
+// and user layers, never from the project itself.
+func projectHooksAllowed() bool {
+	if val := os.Getenv("BLACKDOT_HOOKS_ALLOW_PROJECT"); val != "" {
+		return val == "true" || val == "1"
+	}
+	if val := getFromJSONFile(configLayerMachine, "hooks.allow_project"); val != "" {
+		return val == "true"
+	}
+	return getFromJSONFile(configLayerUser, "hooks.allow_project") == "true"
+}
+
+var overlayItemsWarned bool
+
+// projectVaultItemsAllowed reports whether a repository's overlay may add
+// vault items or change their paths. Restoring an item writes a secret to
+// its path, so like projectHooksAllowed this is never read from the
+// project itself.
+func projectVaultItemsAllowed() bool {
+	val, _ := trustedConfigLookup("vault.allow_project_items")
+	return val == "true" || val == "1"
+}
+
+// effectiveValue is one resolved setting with the layer it came from
+type effectiveValue struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	Layer string      `json:"layer"`
+	Path  string      `json:"path,omitempty"`
+}
+
+// effectiveConfig is the merged view printed by `config effective`
+type effectiveConfig struct {
+	Overlay   string           `json:"overlay,omitempty"`
+	Config    []effectiveValue `json:"config"`
+	Features  []effectiveValue `json:"features"`
+	Variables []effectiveValue `json:"template_variables"`
+	Vault     []effectiveValue `json:"vault_required"`
+	Hooks     []effectiveValue `json:"hooks"`
+}
+
+// collectEffectiveConfig merges every layer and records each value's source
+func collectEffectiveConfig() *effectiveConfig {
+	overlay := currentProjectOverlay()
+	result := &effectiveConfig{}
+	if overlay != nil {
+		result.Overlay = overlay.Path
+	}
+
+	// Config keys: lowest layer first so higher layers replace entries
+	type layer struct {
+		name, path string
+		obj        map[string]interface{}
+	}
+	var layers []layer
+	for _, l := range []struct{ name, path string }{
+		{"user", configLayerUser},
+		{"machine", configLayerMachine},
+	} {
+		obj := make(map[string]interface{})
+		loadJSONInto(l.path, obj)
+		layers = append(layers, layer{l.name, l.path, obj})
+	}
+	if overlay != nil {
+		layers = append(layers, layer{"project", overlay.Path, overlay.Config})
+	}
+	if projectConfig := findProjectConfig(); projectConfig != "" {
+		obj := make(map[string]interface{})
+		loadJSONInto(projectConfig, obj)
+		layers = append(layers, layer{"project", projectConfig, obj})
+	}
+
+	values := make(map[string]effectiveValue)
+	for _, l := range layers {
+		for key, val := range flattenConfig(l.obj, "") {
+			if key == "features" || strings.HasPrefix(key, "features.") {
+				continue
+			}
+			values[key] = effectiveValue{Key: key, Value: val, Layer: l.name, Path: l.path}
+		}
+	}
+	for key := range values {
+		envKey := config.EnvKey(key)
+		if val := os.Getenv(envKey); val != "" {
+			values[key] = effectiveValue{Key: key, Value: val, Layer: "env", Path: envKey}
+		}
+	}
+	result.Config = sortedEffective(values)
+
+	// Features: report every non-default or explicitly set toggle
+	reg := initRegistry()
+	userFeatures := make(map[string]interface{})
+	if obj := layers[0].obj; obj != nil {
+		if f, ok := obj["features"].(map[string]interface{}); ok {
+			userFeatures = f
+		}
+	}
+	for _, f := range reg.All() {
+		if f.Category == feature.CategoryCore {
+			continue
+		}
+		v := effectiveValue{Key: f.Name, Value: reg.Enabled(f.Name), Layer: "default"}
+		if overlay != nil {
+			if _, ok := overlay.Features[f.Name]; ok {
+				v.Layer, v.Path = "project", overlay.Path
+			}
+		}
+		if v.Layer == "default" {
+			if _, ok := userFeatures[f.Name]; ok {
+				v.Layer, v.Path = "user", configLayerUser
+			} else if os.Getenv("BLACKDOT_FEATURE_"+strings.ToUpper(f.Name)) != "" {
+				v.Layer = "env"
+			}
+		}
+		result.Features = append(result.Features, v)
+	}
+
+	if overlay == nil {
+		return result
+	}
+
+	// Template variables set by the overlay; BLACKDOT_TMPL_* still wins
+	vars := make(map[string]effectiveValue)
+	for name, val := range overlay.Template.Variables {
+		v := effectiveValue{Key: name, Value: val, Layer: "project", Path: overlay.Path}
+		envName := "BLACKDOT_TMPL_" + strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+		if env := os.Getenv(envName); env != "" {
+			v = effectiveValue{Key: name, Value: env, Layer: "env", Path: envName}
+		}
+		vars[name] = v
+	}
+	result.Variables = sortedEffective(vars)
+
+	required := append([]string(nil), overlay.Vault.Required...)
+	sort.Strings(required)
+	for _, name := range required {
+		result.Vault = append(result.Vault, effectiveValue{Key: name, Value: true, Layer: "project", Path: overlay.Path})
+	}
+
+	points := make([]string, 0, len(overlay.Hooks))
+	for point := range overlay.Hooks {
+		points = append(points, point)
+	}
+	sort.Strings(points)
+	for _, point := range points {
+		for _, entry := range overlay.Hooks[point] {
+			result.Hooks = append(result.Hooks, effectiveValue{Key: point + "/" + entry.Name, Value: hookEntryCommand(entry), Layer: "project", Path: overlay.Path})
+		}
+	}
+	return result
+}
+
+func hookEntryCommand(entry HookEntry) string {
+	switch {
+	case entry.Command != "":
+		return entry.Command
+	case entry.Script != "":
+		return entry.Script
+	default:
+		return entry.Function
+	}
+}
+
+func sortedEffective(values map[string]effectiveValue) []effectiveValue {
+	out := make([]effectiveValue, 0, len(values))
+	for _, v := range values {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// flattenConfig turns nested maps into dot-notation keys
+func flattenConfig(obj map[string]interface{}, prefix string) map[string]interface{} {
+	out := make(map[string]interface{})
+	for k, v := range obj {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok && len(nested) > 0 {
+			for nk, nv := range flattenConfig(nested, key) {
+				out[nk] = nv
+			}
+			continue
+		}
+		out[key] = v
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testOverlay = `config:
+  shell:
+    theme: minimal
+  vault:
+    backend: pass
+template:
+  variables:
+    git_email: me@work.example
+vault:
+  required: [Work-SSH-Key]
+  items:
+    Work-SSH-Key: {path: ~/.ssh/id_work, type: sshkey}
+hooks:
+  post-restore:
+    - name: fix-perms
+      command: chmod 600 ~/.ssh/id_work
+`
+
+// setupProjectOverlay creates a repository with a .blackdot.yaml and
+// changes into a subdirectory of it
+func setupProjectOverlay(t *testing.T, userConfig string) string {
+	t.Helper()
+	setScheduleEnv(t, userConfig)
+	t.Setenv("BLACKDOT_HOOKS_ALLOW_PROJECT", "")
+	t.Setenv("BLACKDOT_VAULT_ALLOW_PROJECT_ITEMS", "")
+	repo := t.TempDir()
+	os.MkdirAll(filepath.Join(repo, ".git"), 0755)
+	os.MkdirAll(filepath.Join(repo, "src", "pkg"), 0755)
+	os.WriteFile(filepath.Join(repo, ".blackdot.yaml"), []byte(testOverlay), 0644)
+	t.Chdir(filepath.Join(repo, "src", "pkg"))
+	return repo
+}
+
+// TestProjectOverlayConfigLayer verifies overlay config keys sit above the
+// machine and user layers and below the environment
+func TestProjectOverlayConfigLayer(t *testing.T) {
+	setupProjectOverlay(t, `{"vault": {"backend": "bitwarden", "timeout": 30}}`)
+
+	if got := configLookup("vault.backend"); got != "pass" {
+		t.Errorf("vault.backend = %q, want project value", got)
+	}
+	if got := configLookup("vault.timeout"); got != "30" {
+		t.Errorf("vault.timeout = %q, want user value", got)
+	}
+	t.Setenv("BLACKDOT_VAULT_BACKEND", "1password")
+	if got := configLookup("vault.backend"); got != "1password" {
+		t.Errorf("env should win, got %q", got)
+	}
+}
+
+// TestProjectOverlayVaultAndHooks verifies required items are marked,
+// hook aliases resolve, and project items and hooks only apply after
+// opting in
+func TestProjectOverlayVaultAndHooks(t *testing.T) {
+	repo := setupProjectOverlay(t, "")
+
+	items := applyOverlayVaultItems(map[string]VaultItem{"Git-Config": {Path: "~/.gitconfig"}}, currentProjectOverlay())
+	if _, ok := items["Work-SSH-Key"]; ok {
+		t.Error("project vault items must not apply without vault.allow_project_items")
+	}
+	t.Setenv("BLACKDOT_VAULT_ALLOW_PROJECT_ITEMS", "true")
+	items = applyOverlayVaultItems(map[string]VaultItem{"Git-Config": {Path: "~/.gitconfig"}}, currentProjectOverlay())
+	if !items["Work-SSH-Key"].Required || items["Git-Config"].Required {
+		t.Errorf("items = %+v", items)
+	}
+
+	hooks := discoverHooks("post_vault_pull", nil)
+	if len(hooks) != 1 || hooks[0].Source != filepath.Join(repo, ".blackdot.yaml") {
+		t.Fatalf("hooks = %+v", hooks)
+	}
+	if hooks[0].Runs {
+		t.Error("project hooks must not run without hooks.allow_project")
+	}
+
+	os.MkdirAll(filepath.Dir(configLayerUser), 0700)
+	os.WriteFile(configLayerUser, []byte(`{"hooks": {"allow_project": true}}`), 0600)
+	if hooks := discoverHooks("post_vault_pull", nil); !hooks[0].Runs {
+		t.Errorf("opted-in hook skipped: %s", hooks[0].Skipped)
+	}
+}
+
+// TestCollectEffectiveConfig verifies merged values report their layer
+func TestCollectEffectiveConfig(t *testing.T) {
+	repo := setupProjectOverlay(t, `{"shell": {"theme": "full", "editor": "vim"}}`)
+	t.Setenv("BLACKDOT_TMPL_GIT_EMAIL", "")
+
+	eff := collectEffectiveConfig()
+	layers := make(map[string]string)
+	for _, v := range eff.Config {
+		layers[v.Key] = v.Layer
+	}
+	if layers["shell.theme"] != "project" || layers["shell.editor"] != "user" {
+		t.Errorf("config layers = %v", layers)
+	}
+	if len(eff.Variables) != 1 || eff.Variables[0].Path != filepath.Join(repo, ".blackdot.yaml") {
+		t.Errorf("variables = %+v", eff.Variables)
+	}
+	if len(eff.Vault) != 1 || len(eff.Hooks) != 1 || eff.Hooks[0].Key != "post_vault_pull/fix-perms" {
+		t.Errorf("vault = %+v, hooks = %+v", eff.Vault, eff.Hooks)
+	}
+}
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// promptStatusBudget is how long prompt-status may take by default.
+// Prompts run it on every render, so it only reads files other commands
+// leave behind and never runs a backend CLI.
+const promptStatusBudget = 50 * time.Millisecond
+
+// promptHealthThreshold is the doctor score below which the health token
+// is shown
+const promptHealthThreshold = 80
+
+// promptMetricsTail is how much of the end of the metrics log is read
+// for the last doctor score
+const promptMetricsTail = 64 * 1024
+
+// promptStatus is what prompt-status reports. Fields a source could not
+// provide within the budget are left empty and the source is listed in
+// Timeout.
+type promptStatus struct {
+	Vault       string   `json:"vault,omitempty"` // locked or unlocked
+	Backend     string   `json:"backend,omitempty"`
+	SessionAge  int64    `json:"session_age_s,omitempty"`
+	Drift       int      `json:"drift"`
+	DriftItems  []string `json:"drift_items,omitempty"`
+	Health      int      `json:"health,omitempty"`
+	HealthAt    string   `json:"health_at,omitempty"`
+	Timeout     []string `json:"timeout,omitempty"`
+	ElapsedMs   float64  `json:"elapsed_ms"`
+	healthKnown bool
+}
+
+func newPromptStatusCmd() *cobra.Command {
+	var jsonOut, all bool
+	var budget time.Duration
+	cmd := &cobra.Command{
+		Use:   "prompt-status",
+		Short: "Compact status for shell prompt segments",
+		Long: `Print a compact status line for a prompt segment, such as
+
+  vault:locked drift:2 health:72
+
+Only tokens that need attention are printed, so the output is empty when
+all is well; --all prints every token. Nothing is checked live: the
+vault token comes from the age of the cached session, drift from the
+last drift check, and health from the last doctor run. No backend CLI is
+run, and sources not read within --budget are left out.
+
+Powerlevel10k (~/.p10k.zsh):
+
+  function prompt_blackdot() {
+    local s=$(blackdot prompt-status)
+    [[ -n $s ]] && p10k segment -f 208 -t "$s"
+  }
+  # then add blackdot to POWERLEVEL9K_RIGHT_PROMPT_ELEMENTS
+
+Starship (starship.toml):
+
+  [custom.blackdot]
+  command = "blackdot prompt-status"
+  when = true
+  shell = ["sh"]
+
+--json prints every field for scripts.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status := collectPromptStatus(budget)
+			if jsonOut {
+				data, _ := json.Marshal(status)
+				fmt.Println(string(data))
+				return nil
+			}
+			if line := status.tokens(all); line != "" {
+				fmt.Println(line)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print every field as JSON")
+	cmd.Flags().BoolVar(&all, "all", false, "Print every token, not only those that need attention")
+	cmd.Flags().DurationVar(&budget, "budget", promptStatusBudget, "Leave out sources not read within this time")
+	return cmd
+}
+
+// collectPromptStatus reads each source concurrently and returns what
+// was read within budget
+func collectPromptStatus(budget time.Duration) *promptStatus {
+	start := time.Now()
+	status := &promptStatus{}
+	var mu sync.Mutex
+	sources := map[string]func(*promptStatus){
+		"vault":  promptVaultStatus,
+		"drift":  promptDriftStatus,
+		"health": promptHealthStatus,
+	}
+	done := make(chan string, len(sources))
+	for name, read := range sources {
+		go func(name string, read func(*promptStatus)) {
+			var part promptStatus
+			read(&part)
+			mu.Lock()
+			status.merge(&part)
+			mu.Unlock()
+			done <- name
+		}(name, read)
+	}
+
+	pending := make(map[string]bool, len(sources))
+	for name := range sources {
+		pending[name] = true
+	}
+	timeout := time.After(budget)
+wait:
+	for len(pending) > 0 {
+		select {
+		case name := <-done:
+			delete(pending, name)
+		case <-timeout:
+			break wait
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	result := *status
+	result.DriftItems = append([]string(nil), status.DriftItems...)
+	result.Timeout = sortedKeys(pending)
+	result.ElapsedMs = durationMs(time.Since(start))
+	return &result
+}
+
+// merge copies the fields a source set
+func (s *promptStatus) merge(part *promptStatus) {
+	if part.Vault != "" {
+		s.Vault, s.Backend, s.SessionAge = part.Vault, part.Backend, part.SessionAge
+	}
+	if part.DriftItems != nil {
+		s.Drift, s.DriftItems = part.Drift, part.DriftItems
+	}
+	if part.healthKnown {
+		s.Health, s.HealthAt, s.healthKnown = part.Health, part.HealthAt, true
+	}
+}
+
+// tokens formats the status for a prompt
+func (s *promptStatus) tokens(all bool) string {
+	var tokens []string
+	if s.Vault != "" && (all || s.Vault == "locked") {
+		tokens = append(tokens, "vault:"+s.Vault)
+	}
+	if s.Drift > 0 || (all && s.DriftItems != nil) {
+		tokens = append(tokens, fmt.Sprintf("drift:%d", s.Drift))
+	}
+	if s.healthKnown && (all || s.Health < promptHealthThreshold) {
+		tokens = append(tokens, fmt.Sprintf("health:%d", s.Health))
+	}
+	return strings.Join(tokens, " ")
+}
+
+// promptVaultStatus judges the vault session by the age of the session
+// file. Backends that do not cache a session have no token.
+func promptVaultStatus(s *promptStatus) {
+	backend := getVaultBackend()
+	if !sessionBackends[backend] {
+		return
+	}
+	s.Backend = string(backend)
+	s.Vault = "locked"
+	info, err := os.Stat(getSessionFile())
+	if err != nil || info.Size() == 0 {
+		return
+	}
+	age := time.Since(info.ModTime())
+	s.SessionAge = int64(age.Seconds())
+	if age < vaultSessionTTL*time.Second {
+		s.Vault = "unlocked"
+	}
+}
+
+// promptDriftStatus counts the items whose last recorded drift state is
+// a real difference, not in sync or unreadable
+func promptDriftStatus(s *promptStatus) {
+	events, err := loadDriftHistory()
+	if err != nil {
+		return
+	}
+	last := make(map[string]string)
+	for _, e := range events {
+		last[e.Item] = e.State
+	}
+	s.DriftItems = []string{}
+	for _, item := range sortedKeys(last) {
+		if state := last[item]; state != driftInSync && state != driftUnknown {
+			s.DriftItems = append(s.DriftItems, item)
+		}
+	}
+	s.Drift = len(s.DriftItems)
+}
+
+// promptHealthStatus finds the last doctor score near the end of the
+// metrics log
+func promptHealthStatus(s *promptStatus) {
+	entry, ok := lastHealthEntry(metricsPath())
+	if !ok {
+		return
+	}
+	s.Health, s.HealthAt, s.healthKnown = entry.HealthScore, entry.Timestamp, true
+}
+
+// lastHealthEntry returns the newest doctor record in the last
+// promptMetricsTail bytes of the metrics log
+func lastHealthEntry(path string) (MetricEntry, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return MetricEntry{}, false
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() > promptMetricsTail {
+		f.Seek(-promptMetricsTail, io.SeekEnd)
+	}
+	data, _ := io.ReadAll(f)
+	lines := bytes.Split(data, []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		var entry MetricEntry
+		if json.Unmarshal(lines[i], &entry) == nil && entry.Kind == "" && entry.Timestamp != "" {
+			return entry, true
+		}
+	}
+	return MetricEntry{}, false
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// usePromptEnv isolates the files prompt-status reads
+func usePromptEnv(t *testing.T, backend string) string {
+	t.Helper()
+	setScheduleEnv(t, "")
+	home := os.Getenv("HOME")
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+	t.Setenv("BLACKDOT_VAULT_BACKEND", backend)
+	t.Setenv("VAULT_SESSION_FILE", filepath.Join(home, ".vault-session"))
+	return home
+}
+
+// TestPromptStatus verifies the tokens come from the cached session,
+// drift history, and last doctor score
+func TestPromptStatus(t *testing.T) {
+	home := usePromptEnv(t, "bitwarden")
+
+	if got := collectPromptStatus(time.Second).tokens(false); got != "vault:locked" {
+		t.Errorf("no state: tokens = %q, want vault:locked", got)
+	}
+
+	os.WriteFile(filepath.Join(home, ".vault-session"), []byte("token"), 0600)
+	os.MkdirAll(filepath.Dir(driftHistoryPath()), 0700)
+	os.WriteFile(driftHistoryPath(), []byte(strings.Join([]string{
+		`{"time":"2026-01-01T00:00:00Z","item":"SSH-Config","state":"modified"}`,
+		`{"time":"2026-01-01T00:00:00Z","item":"Git-Config","state":"modified"}`,
+		`{"time":"2026-01-01T00:00:00Z","item":"AWS-Config","state":"unknown"}`,
+		`{"time":"2026-01-02T00:00:00Z","item":"Git-Config","state":"in-sync"}`,
+		`{"time":"2026-01-02T00:00:00Z","item":"Env-Secrets","state":"missing-local"}`,
+	}, "\n")+"\n"), 0600)
+	os.WriteFile(metricsPath(), []byte(
+		`{"timestamp":"2026-01-01T00:00:00Z","health_score":95}`+"\n"+
+			`{"timestamp":"2026-01-02T00:00:00Z","health_score":64}`+"\n"+
+			`{"kind":"vault","timestamp":"2026-01-03T00:00:00Z"}`+"\n"), 0600)
+
+	status := collectPromptStatus(time.Second)
+	if got := status.tokens(false); got != "drift:2 health:64" {
+		t.Errorf("tokens = %q, want drift:2 health:64", got)
+	}
+	if got := status.tokens(true); got != "vault:unlocked drift:2 health:64" {
+		t.Errorf("--all tokens = %q", got)
+	}
+	if fmt.Sprint(status.DriftItems) != "[Env-Secrets SSH-Config]" || len(status.Timeout) != 0 {
+		t.Errorf("status = %+v", status)
+	}
+
+	old := time.Now().Add(-(vaultSessionTTL + 60) * time.Second)
+	os.Chtimes(filepath.Join(home, ".vault-session"), old, old)
+	if got := collectPromptStatus(time.Second).Vault; got != "locked" {
+		t.Errorf("expired session: vault = %q, want locked", got)
+	}
+}
+
+// TestPromptStatusBackendWithoutSession verifies backends that keep no
+// session have no vault token
+func TestPromptStatusBackendWithoutSession(t *testing.T) {
+	usePromptEnv(t, "pass")
+	if got := collectPromptStatus(time.Second).tokens(true); got != "" {
+		t.Errorf("tokens = %q, want none", got)
+	}
+}
+
+// TestLastHealthEntryTail verifies only the end of a large metrics log
+// is read
+func TestLastHealthEntryTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.jsonl")
+	line := `{"timestamp":"2026-01-01T00:00:00Z","health_score":50}` + "\n"
+	data := strings.Repeat(line, 2*promptMetricsTail/len(line)) +
+		`{"timestamp":"2026-01-02T00:00:00Z","health_score":88}` + "\n"
+	os.WriteFile(path, []byte(data), 0600)
+
+	entry, ok := lastHealthEntry(path)
+	if !ok || entry.HealthScore != 88 {
+		t.Errorf("entry = %+v, %v", entry, ok)
+	}
+}
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultHomebrewTap is the tap the release formula is published to
+const defaultHomebrewTap = "blackwell-systems/homebrew-tap"
+
+// completionFiles maps each shell to the file name its completions ship as
+// in release archives
+var completionFiles = map[string]string{
+	"bash":       "blackdot.bash",
+	"zsh":        "_blackdot",
+	"fish":       "blackdot.fish",
+	"powershell": "blackdot.ps1",
+}
+
+func newReleaseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "release",
+		Short: "Release tooling: goreleaser config, man pages, completions",
+		Long: `Tooling for building blackdot releases from this repository.
+
+'release scaffold' writes .goreleaser.yaml. Its build hooks run
+'release completions' and 'release manpages', so release archives and the
+Homebrew formula carry completions for every shell and a man page per
+command.`,
+		Hidden: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newReleaseScaffoldCmd(), newReleaseManpagesCmd(), newReleaseCompletionsCmd())
+	return cmd
+}
+
+func newReleaseScaffoldCmd() *cobra.Command {
+	var tap, output string
+	var force, stdout bool
+
+	cmd := &cobra.Command{
+		Use:   "scaffold",
+		Short: "Generate .goreleaser.yaml with a Homebrew formula",
+		Long: `Generate a goreleaser config that builds blackdot for Linux, macOS and
+Windows, packages completions and man pages with each archive, and
+publishes a Homebrew formula to the tap (HOMEBREW_TAP_GITHUB_TOKEN must
+allow pushing to it).
+
+Run from the repository root. The module path in go.mod sets the
+homepage.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			owner, name, ok := strings.Cut(tap, "/")
+			if !ok || owner == "" || name == "" {
+				return fmt.Errorf("--tap must be owner/repo, got %q", tap)
+			}
+			module, err := readModulePath("go.mod")
+			if err != nil {
+				Fail("Run from the repository root: %v", err)
+				return err
+			}
+			config := goreleaserConfig(module, owner, name)
+			if stdout {
+				fmt.Print(config)
+				return nil
+			}
+			if _, err := os.Stat(output); err == nil && !force {
+				Fail("%s exists (use --force to overwrite)", output)
+				return fmt.Errorf("%s exists", output)
+			}
+			if err := writeFileAtomic(output, []byte(config), 0644); err != nil {
+				Fail("Failed to write %s: %v", output, err)
+				return err
+			}
+			Pass("Wrote %s (formula: %s)", output, tap)
+			Info("Check it with: goreleaser check && goreleaser release --snapshot --clean")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tap, "tap", defaultHomebrewTap, "Homebrew tap repository (owner/repo)")
+	cmd.Flags().StringVarP(&output, "output", "o", ".goreleaser.yaml", "File to write")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite an existing file")
+	cmd.Flags().BoolVar(&stdout, "stdout", false, "Print instead of writing")
+	return cmd
+}
+
+func newReleaseManpagesCmd() *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:   "manpages",
+		Short: "Generate a man page for every command",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := genManPages(cmd.Root(), dir, manPageDate())
+			if err != nil {
+				Fail("Failed to generate man pages: %v", err)
+				return err
+			}
+			Pass("Wrote %d man pages to %s", len(files), dir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", "manpages", "Output directory")
+	return cmd
+}
+
+func newReleaseCompletionsCmd() *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:   "completions",
+		Short: "Generate completion scripts for every shell",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			for _, shell := range completionShells {
+				f, err := os.Create(filepath.Join(dir, completionFiles[shell]))
+				if err != nil {
+					return err
+				}
+				err = writeCompletion(cmd.Root(), shell, f)
+				if cerr := f.Close(); err == nil {
+					err = cerr
+				}
+				if err != nil {
+					Fail("Failed to generate %s completions: %v", shell, err)
+					return err
+				}
+			}
+			Pass("Wrote completions for %s to %s", strings.Join(completionShells, ", "), dir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", "completions", "Output directory")
+	return cmd
+}
+
+// readModulePath returns the module path declared in a go.mod file
+func readModulePath(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("no module line in %s", path)
+}
+
+// goreleaserConfig renders .goreleaser.yaml. Braces in the result are
+// goreleaser templates, so values are substituted with a replacer rather
+// than text/template.
+func goreleaserConfig(module, tapOwner, tapName string) string {
+	return strings.NewReplacer(
+		"@HOMEPAGE@", "https://"+module,
+		"@TAP_OWNER@", tapOwner,
+		"@TAP_NAME@", tapName,
+		"@BASH@", completionFiles["bash"],
+		"@ZSH@", completionFiles["zsh"],
+		"@FISH@", completionFiles["fish"],
+	).Replace(goreleaserTemplate)
+}
+
+const goreleaserTemplate = `# Generated by 'blackdot release scaffold'. Regenerate instead of editing:
+#   go run ./cmd/blackdot release scaffold --force
+version: 2
+
+project_name: blackdot
+
+before:
+  hooks:
+    - go run -ldflags "-X main.version={{ .Version }}" ./cmd/blackdot release completions --dir completions
+    - go run -ldflags "-X main.version={{ .Version }}" ./cmd/blackdot release manpages --dir manpages
+
+builds:
+  - id: blackdot
+    main: ./cmd/blackdot
+    binary: blackdot
+    env:
+      - CGO_ENABLED=0
+    goos: [linux, darwin, windows]
+    goarch: [amd64, arm64]
+    ldflags:
+      - -s -w -X main.version={{ .Version }} -X main.commit={{ .ShortCommit }} -X main.date={{ .Date }}
+
+archives:
+  - id: blackdot
+    formats: [tar.gz]
+    format_overrides:
+      - goos: windows
+        formats: [zip]
+    name_template: "blackdot_{{ .Version }}_{{ .Os }}_{{ .Arch }}"
+    files:
+      - LICENSE
+      - README.md
+      - completions/*
+      - manpages/*
+
+checksum:
+  name_template: SHA256SUMS.txt
+
+brews:
+  - name: blackdot
+    repository:
+      owner: @TAP_OWNER@
+      name: @TAP_NAME@
+      token: "{{ .Env.HOMEBREW_TAP_GITHUB_TOKEN }}"
+    directory: Formula
+    homepage: @HOMEPAGE@
+    description: Dotfiles manager with vault-backed secrets, templates, and health checks
+    license: Apache-2.0
+    install: |
+      bin.install "blackdot"
+      bash_completion.install "completions/@BASH@" => "blackdot"
+      zsh_completion.install "completions/@ZSH@"
+      fish_completion.install "completions/@FISH@"
+      man1.install Dir["manpages/*.1"]
+    test: |
+      system "#{bin}/blackdot", "version"
+`
@@ -0,0 +1,373 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+func newReposCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "repos",
+		Aliases: []string{"overlay", "overlays"},
+		Short:   "Manage overlay repositories layered on top of blackdot",
+		Long: `Manage overlay repositories layered on top of the base blackdot repo.
+
+An overlay is another dotfiles repo (for example a private work repo) with
+the same layout. Roots are merged in order, base first:
+
+  zsh/zsh.d/*.zsh            loaded by name; a later root replaces the file
+  templates/configs/*.tmpl   rendered by name; a later root replaces the file
+  brew/Brewfile*             concatenated; every root contributes packages
+  winget.json                concatenated; every root contributes packages
+  features.yaml              feature defaults; a later root wins, the user's
+                             own toggles win over all of them
+
+List an org repo before a personal one so the personal repo takes
+precedence. When two roots define the same zsh module or template, the last one wins
+and the override is reported as a conflict.
+
+Overlays are stored in config.json under "repos".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReposList()
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List source roots and conflicts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReposList()
+		},
+	}
+
+	var name string
+	addCmd := &cobra.Command{
+		Use:   "add <path>",
+		Short: "Add an overlay repo (merged after existing roots)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReposAdd(args[0], name)
+		},
+	}
+	addCmd.Flags().StringVar(&name, "name", "", "Name for the overlay (default: directory name)")
+
+	removeCmd := &cobra.Command{
+		Use:     "remove <name>",
+		Aliases: []string{"rm"},
+		Short:   "Remove an overlay repo (files are not deleted)",
+		Args:    cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			var names []string
+			for _, r := range paths.Overlays() {
+				names = append(names, r.Name)
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReposRemove(args[0])
+		},
+	}
+
+	// Used by zshrc to source the merged zsh.d without parsing JSON in shell
+	filesCmd := &cobra.Command{
+		Use:    "files <dir>",
+		Short:  "Print the merged files for a layered directory",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, d := range paths.LayeredDirs {
+				if d.Dir == args[0] {
+					files, _ := paths.ResolveLayered(paths.Roots(BlackdotDir()), d.Dir, d.Pattern)
+					for _, f := range files {
+						fmt.Println(f.Path)
+					}
+					return nil
+				}
+			}
+			return fmt.Errorf("not a layered directory: %s", args[0])
+		},
+	}
+
+	updateCmd := &cobra.Command{
+		Use:   "update [name...]",
+		Short: "Pull overlay repos (all of them by default)",
+		Long: `Pull overlay repos that are git checkouts. Uncommitted changes are
+stashed and restored, and a failed rebase is aborted, as with 'blackdot
+upgrade'. The base repo is updated by 'blackdot upgrade'.`,
+		ValidArgsFunction: removeCmd.ValidArgsFunction,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReposUpdate(args)
+		},
+	}
+
+	cmd.AddCommand(listCmd, addCmd, removeCmd, updateCmd, filesCmd)
+	return cmd
+}
+
+// repoConflicts returns the overrides across all non-additive layered dirs
+func repoConflicts(roots []paths.Root) []paths.Conflict {
+	var conflicts []paths.Conflict
+	for _, d := range paths.LayeredDirs {
+		if d.Additive {
+			continue
+		}
+		_, c := paths.ResolveLayered(roots, d.Dir, d.Pattern)
+		conflicts = append(conflicts, c...)
+	}
+	return conflicts
+}
+
+func printRepoConflicts(conflicts []paths.Conflict) {
+	if len(conflicts) == 0 {
+		return
+	}
+	fmt.Println()
+	Warn("%d file(s) defined in more than one root:", len(conflicts))
+	for _, c := range conflicts {
+		fmt.Printf("  %s  %s %s\n", c.Rel, Green.Sprint("← "+c.Winner), Dim.Sprint("(overrides "+strings.Join(c.Shadow, ", ")+")"))
+	}
+}
+
+func runReposList() error {
+	roots := paths.Roots(BlackdotDir())
+
+	PrintHeader("Source Roots")
+	for i, r := range roots {
+		state := ""
+		if _, err := os.Stat(r.Path); err != nil {
+			state = Yellow.Sprint(" (missing)")
+		}
+		if state == "" && i > 0 {
+			state = " " + Dim.Sprint(describeRepoGit(repoGitState(r.Path)))
+		}
+		fmt.Printf("  %d. %-12s %s%s\n", i+1, r.Name, r.Path, state)
+	}
+	if len(roots) == 1 {
+		fmt.Println()
+		Info("No overlays configured. Add one with: blackdot repos add <path>")
+		return nil
+	}
+
+	fmt.Println()
+	for _, d := range paths.LayeredDirs {
+		files, _ := paths.ResolveLayered(roots, d.Dir, d.Pattern)
+		counts := map[string]int{}
+		for _, f := range files {
+			counts[f.Root]++
+		}
+		var parts []string
+		for _, r := range roots {
+			if counts[r.Name] > 0 {
+				parts = append(parts, fmt.Sprintf("%s %d", r.Name, counts[r.Name]))
+			}
+		}
+		mode := ""
+		if d.Additive {
+			mode = " (concatenated)"
+		}
+		fmt.Printf("  %-20s %s%s\n", d.Dir, strings.Join(parts, ", "), Dim.Sprint(mode))
+	}
+
+	printRepoConflicts(repoConflicts(roots))
+	return nil
+}
+
+func runReposAdd(path, name string) error {
+	abs, err := filepath.Abs(expandPath(path))
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(abs)
+	if err != nil || !info.IsDir() {
+		Fail("Not a directory: %s", abs)
+		return fmt.Errorf("not a directory: %s", abs)
+	}
+	if name == "" {
+		name = filepath.Base(abs)
+	}
+
+	roots := paths.Roots(BlackdotDir())
+	for _, r := range roots {
+		if r.Name == name {
+			Fail("A root named '%s' already exists", name)
+			return fmt.Errorf("root %q already exists", name)
+		}
+		if filepath.Clean(r.Path) == abs {
+			Fail("%s is already a root (%s)", abs, r.Name)
+			return fmt.Errorf("%s is already a root", abs)
+		}
+	}
+
+	found := false
+	for _, d := range paths.LayeredDirs {
+		if _, err := os.Stat(filepath.Join(abs, d.Dir)); err == nil {
+			found = true
+		}
+	}
+	if !found {
+		Warn("%s has no zsh/zsh.d, templates/configs, or brew directory yet", abs)
+	}
+
+	overlays := append(paths.Overlays(), paths.Root{Name: name, Path: abs})
+	if err := saveRepoOverlays(overlays); err != nil {
+		Fail("Failed to save config: %v", err)
+		return err
+	}
+	Pass("Added overlay '%s' → %s", name, abs)
+
+	printRepoConflicts(repoConflicts(paths.Roots(BlackdotDir())))
+	return nil
+}
+
+func runReposRemove(name string) error {
+	overlays := paths.Overlays()
+	kept := overlays[:0]
+	for _, r := range overlays {
+		if r.Name != name {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == len(overlays) {
+		Fail("No overlay named '%s'", name)
+		return fmt.Errorf("no overlay named %q", name)
+	}
+	if err := saveRepoOverlays(kept); err != nil {
+		Fail("Failed to save config: %v", err)
+		return err
+	}
+	Pass("Removed overlay '%s'", name)
+	return nil
+}
+
+func runReposUpdate(names []string) error {
+	overlays := paths.Overlays()
+	if len(overlays) == 0 {
+		Info("No overlays configured. Add one with: blackdot repos add <path>")
+		return nil
+	}
+	selected := overlays
+	if len(names) > 0 {
+		byName := map[string]paths.Root{}
+		for _, r := range overlays {
+			byName[r.Name] = r
+		}
+		selected = nil
+		for _, name := range names {
+			r, ok := byName[name]
+			if !ok {
+				Fail("No overlay named '%s'", name)
+				return fmt.Errorf("no overlay named %q", name)
+			}
+			selected = append(selected, r)
+		}
+	}
+
+	failed := 0
+	for _, r := range selected {
+		PrintHeader(r.Name)
+		if _, err := os.Stat(r.Path); err != nil {
+			Warn("%s is missing", tildePath(r.Path))
+			failed++
+			continue
+		}
+		if err := upgradeRepo(r.Path); err != nil {
+			failed++
+		}
+	}
+	printRepoConflicts(repoConflicts(paths.Roots(BlackdotDir())))
+	if failed > 0 {
+		return fmt.Errorf("%d overlay(s) not updated", failed)
+	}
+	return nil
+}
+
+// repoGit is the git state of a repo, from local refs only (no fetch)
+type repoGit struct {
+	IsRepo   bool
+	Branch   string // empty when detached
+	Upstream string // empty when the branch tracks nothing
+	Ahead    int
+	Behind   int
+	Dirty    int // changed or untracked files
+}
+
+func repoGitState(dir string) repoGit {
+	git := func(args ...string) (string, error) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	if _, err := git("rev-parse", "--git-dir"); err != nil {
+		return repoGit{}
+	}
+	st := repoGit{IsRepo: true}
+	if branch, err := git("symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+		st.Branch = branch
+	}
+	if status, err := git("status", "--porcelain"); err == nil && status != "" {
+		st.Dirty = len(strings.Split(status, "\n"))
+	}
+	if upstream, err := git("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err == nil {
+		st.Upstream = upstream
+		if counts, err := git("rev-list", "--left-right", "--count", "HEAD...@{upstream}"); err == nil {
+			fmt.Sscanf(counts, "%d %d", &st.Ahead, &st.Behind)
+		}
+	}
+	return st
+}
+
+// describeRepoGit summarizes a repo's git state in a few words
+func describeRepoGit(st repoGit) string {
+	if !st.IsRepo {
+		return "(not a git repo)"
+	}
+	branch := st.Branch
+	if branch == "" {
+		branch = "detached"
+	}
+	parts := []string{branch}
+	if st.Upstream == "" && st.Branch != "" {
+		parts = append(parts, "no upstream")
+	}
+	if st.Ahead > 0 {
+		parts = append(parts, fmt.Sprintf("%d ahead", st.Ahead))
+	}
+	if st.Behind > 0 {
+		parts = append(parts, fmt.Sprintf("%d behind", st.Behind))
+	}
+	if st.Dirty > 0 {
+		parts = append(parts, fmt.Sprintf("%d uncommitted", st.Dirty))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// saveRepoOverlays writes the repos key, preserving the rest of config.json
+func saveRepoOverlays(overlays []paths.Root) error {
+	configPath := filepath.Join(ConfigDir(), "config.json")
+	cfg := map[string]interface{}{}
+	if data, err := os.ReadFile(configPath); err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return fmt.Errorf("parsing %s: %w", configPath, err)
+		}
+	}
+	if len(overlays) == 0 {
+		delete(cfg, "repos")
+	} else {
+		cfg["repos"] = overlays
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return err
+	}
+	return writeFileWithPolicy(configPath, append(data, '\n'), fileClassConfig)
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+)
+
+// TestReposAddRemove verifies overlays are saved without clobbering config
+func TestReposAddRemove(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("BLACKDOT_PATHS_STRATEGY", "")
+	paths.ResetStrategy()
+	t.Cleanup(paths.ResetStrategy)
+
+	configDir := filepath.Join(home, ".config", "blackdot")
+	os.MkdirAll(configDir, 0700)
+	configPath := filepath.Join(configDir, "config.json")
+	os.WriteFile(configPath, []byte(`{"vault":{"backend":"pass"}}`), 0600)
+
+	overlay := filepath.Join(home, "work-dotfiles")
+	os.MkdirAll(filepath.Join(overlay, "zsh", "zsh.d"), 0755)
+
+	if err := runReposAdd(overlay, "work"); err != nil {
+		t.Fatalf("runReposAdd: %v", err)
+	}
+	if err := runReposAdd(overlay, "again"); err == nil {
+		t.Error("adding the same path twice should fail")
+	}
+	if err := runReposAdd(filepath.Join(home, "missing"), ""); err == nil {
+		t.Error("adding a missing directory should fail")
+	}
+
+	if got := paths.Overlays(); len(got) != 1 || got[0].Name != "work" || got[0].Path != overlay {
+		t.Errorf("overlays = %+v", got)
+	}
+	data, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(data), `"backend": "pass"`) {
+		t.Errorf("existing config lost:\n%s", data)
+	}
+
+	if err := runReposRemove("work"); err != nil {
+		t.Fatalf("runReposRemove: %v", err)
+	}
+	if err := runReposRemove("work"); err == nil {
+		t.Error("removing an unknown overlay should fail")
+	}
+	data, _ = os.ReadFile(configPath)
+	if strings.Contains(string(data), "repos") {
+		t.Errorf("repos key should be removed when empty:\n%s", data)
+	}
+}
+
+// TestRepoFeatureDefaults verifies features.yaml merges across roots with
+// later roots winning, and that only departures from the merged defaults
+// are saved to the user config
+func TestRepoFeatureDefaults(t *testing.T) {
+	setScheduleEnv(t, "")
+	home := os.Getenv("HOME")
+	base := filepath.Join(home, ".blackdot")
+	savedDir := blackdotDir
+	blackdotDir = base
+	t.Cleanup(func() { blackdotDir = savedDir })
+	org, personal := filepath.Join(home, "org"), filepath.Join(home, "personal")
+	for dir, content := range map[string]string{
+		base:     "vault: true\nk8s_tools: false\n",
+		org:      "vault: false\ntemplates: true\n",
+		personal: "vault: true\n",
+	} {
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "features.yaml"), []byte(content), 0644)
+	}
+	if err := saveRepoOverlays([]paths.Root{{Name: "org", Path: org}, {Name: "personal", Path: personal}}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := repoFeatureDefaults()
+	if len(got) != 3 || !got["vault"] || got["k8s_tools"] || !got["templates"] {
+		t.Errorf("repoFeatureDefaults = %v", got)
+	}
+
+	saved := registry
+	registry = nil
+	t.Cleanup(func() { registry = saved })
+	reg := initRegistry()
+	if !reg.Enabled("templates") || reg.Enabled("k8s_tools") {
+		t.Fatalf("repo defaults not applied: templates=%v k8s_tools=%v", reg.Enabled("templates"), reg.Enabled("k8s_tools"))
+	}
+	if err := reg.Disable("templates"); err != nil {
+		t.Fatal(err)
+	}
+	if err := persistFeatureState(reg); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(ConfigDir(), "config.json"))
+	if !strings.Contains(string(data), `"templates": false`) {
+		t.Errorf("disabling a repo default should be saved:\n%s", data)
+	}
+	for _, inherited := range []string{`"vault": true`, `"k8s_tools"`} {
+		if strings.Contains(string(data), inherited) {
+			t.Errorf("inherited default %s should not be saved:\n%s", inherited, data)
+		}
+	}
+}
+
+// TestRepoGitState verifies branch, upstream, ahead, and uncommitted
+// counts come from local refs
+func TestRepoGitState(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "t")
+	t.Setenv("GIT_AUTHOR_EMAIL", "t@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "t")
+	t.Setenv("GIT_COMMITTER_EMAIL", "t@example.com")
+	dir := t.TempDir()
+	origin, clone := filepath.Join(dir, "origin"), filepath.Join(dir, "clone")
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main", origin},
+		{"-C", origin, "commit", "-q", "--allow-empty", "-m", "init"},
+		{"clone", "-q", origin, clone},
+		{"-C", clone, "commit", "-q", "--allow-empty", "-m", "local"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	os.WriteFile(filepath.Join(clone, "new.zsh"), []byte("x\n"), 0644)
+
+	st := repoGitState(clone)
+	if !st.IsRepo || st.Branch != "main" || st.Upstream != "origin/main" || st.Ahead != 1 || st.Behind != 0 || st.Dirty != 1 {
+		t.Errorf("repoGitState = %+v", st)
+	}
+	if got := describeRepoGit(st); got != "(main, 1 ahead, 1 uncommitted)" {
+		t.Errorf("describeRepoGit = %q", got)
+	}
+	if st := repoGitState(dir); st.IsRepo {
+		t.Errorf("a plain directory is not a repo: %+v", st)
+	}
+}
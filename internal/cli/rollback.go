@@ -1,13 +1,9 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"path/filepath"
-	"sort"
-	"strings"
 
+	"github.com/blackwell-systems/blackdot/internal/prompts"
 	"github.com/spf13/cobra"
 )
 
@@ -126,23 +122,9 @@ func rollbackList() error {
 	fmt.Println("================================")
 	fmt.Println()
 
-	entries, err := os.ReadDir(cfg.backupDir)
+	backups, err := listBackups(cfg)
 	if err != nil {
-		if os.IsNotExist(err) {
-			Warn("No backups found")
-			Info("Create one with: blackdot backup")
-			return nil
-		}
-		return fmt.Errorf("reading backup directory: %w", err)
-	}
-
-	var backups []os.DirEntry
-	for _, e := range entries {
-		// Support both naming conventions: backup- (bash) and backup_ (legacy Go)
-		if (strings.HasPrefix(e.Name(), "backup-") || strings.HasPrefix(e.Name(), "backup_")) &&
-			(strings.HasSuffix(e.Name(), ".tar.gz") || strings.HasSuffix(e.Name(), ".tar")) {
-			backups = append(backups, e)
-		}
+		return err
 	}
 
 	if len(backups) == 0 {
@@ -151,11 +133,6 @@ func rollbackList() error {
 		return nil
 	}
 
-	// Sort by name (which includes timestamp) - newest first
-	sort.Slice(backups, func(i, j int) bool {
-		return backups[i].Name() > backups[j].Name()
-	})
-
 	// Show top 5 for rollback
 	limit := 5
 	if len(backups) < limit {
@@ -163,20 +140,8 @@ func rollbackList() error {
 	}
 
 	for i, b := range backups[:limit] {
-		info, _ := b.Info()
-		name := b.Name()
-
-		// Extract backup ID from name
-		backupID := strings.TrimPrefix(name, "backup-")
-		backupID = strings.TrimPrefix(backupID, "backup_")
-		backupID = strings.TrimSuffix(backupID, ".tar.gz")
-		backupID = strings.TrimSuffix(backupID, ".tar")
-
-		// Format size
-		size := "?"
-		if info != nil {
-			size = formatSize(info.Size())
-		}
+		backupID := b.ID
+		size := formatSize(b.Size)
 
 		marker := " "
 		if i == 0 {
@@ -209,82 +174,27 @@ func rollbackRestore(specificBackup string, skipConfirm bool, dryRun bool) error
 	cfg := getBackupConfig()
 
 	// Find backup to restore
-	var backupPath string
-	var backupID string
-
-	if specificBackup != "" {
-		// Specific backup requested - try multiple naming patterns
-		patterns := []string{
-			filepath.Join(cfg.backupDir, fmt.Sprintf("backup-%s.tar.gz", specificBackup)),
-			filepath.Join(cfg.backupDir, fmt.Sprintf("backup_%s.tar.gz", specificBackup)),
-			filepath.Join(cfg.backupDir, fmt.Sprintf("%s.tar.gz", specificBackup)),
-			filepath.Join(cfg.backupDir, fmt.Sprintf("backup-%s.tar", specificBackup)),
-			filepath.Join(cfg.backupDir, fmt.Sprintf("backup_%s.tar", specificBackup)),
-			filepath.Join(cfg.backupDir, specificBackup),
-		}
-		for _, p := range patterns {
-			if _, err := os.Stat(p); err == nil {
-				backupPath = p
-				break
-			}
-		}
-		if backupPath == "" {
+	archive, err := findBackup(cfg, specificBackup)
+	if err != nil {
+		if specificBackup != "" {
 			Fail("Backup not found: %s", specificBackup)
 			fmt.Println()
 			fmt.Println("Available backups:")
 			rollbackList()
-			return fmt.Errorf("backup not found: %s", specificBackup)
-		}
-		backupID = specificBackup
-	} else {
-		// Find latest backup by name (contains timestamp)
-		entries, err := os.ReadDir(cfg.backupDir)
-		if err != nil {
-			if os.IsNotExist(err) {
-				Fail("No backups found")
-				Info("Create one with: blackdot backup")
-				return fmt.Errorf("no backups found")
-			}
-			return fmt.Errorf("reading backup directory: %w", err)
-		}
-
-		var backups []string
-		for _, e := range entries {
-			// Support both naming conventions
-			if (strings.HasPrefix(e.Name(), "backup-") || strings.HasPrefix(e.Name(), "backup_")) &&
-				(strings.HasSuffix(e.Name(), ".tar.gz") || strings.HasSuffix(e.Name(), ".tar")) {
-				backups = append(backups, e.Name())
-			}
-		}
-
-		if len(backups) == 0 {
+		} else {
 			Fail("No backups found")
 			Info("Create one with: blackdot backup")
-			return fmt.Errorf("no backups found")
 		}
-
-		// Sort newest first (name contains timestamp)
-		sort.Sort(sort.Reverse(sort.StringSlice(backups)))
-		backupPath = filepath.Join(cfg.backupDir, backups[0])
-
-		// Extract ID for display
-		backupID = strings.TrimPrefix(backups[0], "backup-")
-		backupID = strings.TrimPrefix(backupID, "backup_")
-		backupID = strings.TrimSuffix(backupID, ".tar.gz")
-		backupID = strings.TrimSuffix(backupID, ".tar")
-	}
-
-	// Confirm we have a valid path
-	if _, err := os.Stat(backupPath); err != nil {
-		return fmt.Errorf("backup not found: %s", backupPath)
+		return err
 	}
+	backupID := archive.ID
 
 	// In dry-run mode, skip the warning and confirmation
 	if dryRun {
 		Info("Preview rollback to: %s", backupID)
 		fmt.Println()
 		// Use the backup restore logic with dry-run
-		return runBackupRestoreWithDryRun(nil, []string{backupID}, true)
+		return runBackupRestoreImpl(nil, []string{archive.Path}, true)
 	}
 
 	fmt.Println()
@@ -294,14 +204,11 @@ func rollbackRestore(specificBackup string, skipConfirm bool, dryRun bool) error
 
 	// Ask for confirmation unless -y flag is set
 	if !skipConfirm {
-		fmt.Print("Proceed with rollback? [y/N] ")
-		reader := bufio.NewReader(os.Stdin)
-		response, err := reader.ReadString('\n')
+		ok, err := prompts.Confirm("Proceed with rollback?", false)
 		if err != nil {
-			return fmt.Errorf("reading input: %w", err)
+			return err
 		}
-		response = strings.TrimSpace(strings.ToLower(response))
-		if response != "y" && response != "yes" {
+		if !ok {
 			Info("Rollback cancelled")
 			return nil
 		}
@@ -311,5 +218,5 @@ func rollbackRestore(specificBackup string, skipConfirm bool, dryRun bool) error
 	fmt.Println()
 
 	// Use the backup restore logic
-	return runBackupRestore(nil, []string{backupID})
+	return runBackupRestoreImpl(nil, []string{archive.Path}, false)
 }
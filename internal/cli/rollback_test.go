@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestRollbackCommandExists verifies rollback command is registered
@@ -199,6 +200,25 @@ func TestGetBackupConfig(t *testing.T) {
 	}
 }
 
+// TestGetBackupConfigIgnoresProjectLocation verifies a repository can
+// tune retention but not move or disable backups
+func TestGetBackupConfigIgnoresProjectLocation(t *testing.T) {
+	setScheduleEnv(t, "")
+	repo := t.TempDir()
+	os.MkdirAll(filepath.Join(repo, ".git"), 0755)
+	overlay := "config:\n  backup:\n    location: " + filepath.Join(repo, "backups") + "\n    enabled: false\n    max_snapshots: 3\n"
+	os.WriteFile(filepath.Join(repo, ".blackdot.yaml"), []byte(overlay), 0644)
+	t.Chdir(repo)
+
+	cfg := getBackupConfig()
+	if strings.HasPrefix(cfg.backupDir, repo) || !cfg.enabled {
+		t.Errorf("project layer set backupDir=%s enabled=%v", cfg.backupDir, cfg.enabled)
+	}
+	if cfg.maxBackups != 3 {
+		t.Errorf("maxBackups = %d, want project value 3", cfg.maxBackups)
+	}
+}
+
 // TestFormatSize verifies size formatting
 func TestFormatSize(t *testing.T) {
 	tests := []struct {
@@ -222,3 +242,121 @@ func TestFormatSize(t *testing.T) {
 		})
 	}
 }
+
+// TestBackupIDFromName verifies IDs are extracted from all archive formats
+func TestBackupIDFromName(t *testing.T) {
+	tests := map[string]string{
+		"backup-20250101-120000.tar.zst":             "20250101-120000",
+		"backup-20250101-120000-pre-restore.tar.zst": "20250101-120000-pre-restore",
+		"backup-20250101-120000.tar.gz":              "20250101-120000",
+		"backup_20250101_120000.tar":                 "20250101_120000",
+	}
+
+	for name, expected := range tests {
+		if !isBackupArchiveName(name) {
+			t.Errorf("isBackupArchiveName(%q) = false", name)
+		}
+		if got := backupIDFromName(name); got != expected {
+			t.Errorf("backupIDFromName(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+
+	if isBackupArchiveName("backup-20250101-120000.tar.zst.tmp") {
+		t.Error("temp files should not be treated as backups")
+	}
+}
+
+// TestCreateAndRestoreBackup verifies a tar.zst snapshot round-trips
+func TestCreateAndRestoreBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalHome := os.Getenv("HOME")
+	originalData := os.Getenv("XDG_DATA_HOME")
+	os.Setenv("HOME", tmpDir)
+	os.Setenv("XDG_DATA_HOME", filepath.Join(tmpDir, "data"))
+	defer os.Setenv("HOME", originalHome)
+	defer os.Setenv("XDG_DATA_HOME", originalData)
+
+	gitconfig := filepath.Join(tmpDir, ".gitconfig")
+	if err := os.WriteFile(gitconfig, []byte("[user]\n\tname = test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := getBackupConfig()
+	archive, count, err := createBackup(cfg, "pre-restore", true)
+	if err != nil {
+		t.Fatalf("createBackup failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 file backed up, got %d", count)
+	}
+	if !strings.HasSuffix(archive.Name, "-pre-restore.tar.zst") {
+		t.Errorf("unexpected archive name: %s", archive.Name)
+	}
+	if filepath.Dir(archive.Path) != filepath.Join(tmpDir, "data", "blackdot", "backups") {
+		t.Errorf("unexpected archive location: %s", archive.Path)
+	}
+
+	if err := os.WriteFile(gitconfig, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runBackupRestoreImpl(nil, []string{archive.ID}, false); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	data, err := os.ReadFile(gitconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "name = test") {
+		t.Errorf("file not restored, got: %s", data)
+	}
+}
+
+// TestPruneBackups verifies the retention policy keeps the newest backups
+func TestPruneBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &backupConfig{
+		backupDir:     tmpDir,
+		legacyDir:     tmpDir,
+		maxBackups:    2,
+		retentionDays: 30,
+	}
+
+	now := time.Now()
+	ages := map[string]time.Duration{
+		"backup-20250104-120000.tar.zst": 0,
+		"backup-20250103-120000.tar.zst": time.Hour,
+		"backup-20250102-120000.tar.gz":  2 * time.Hour,
+		"backup-20250101-120000.tar.gz":  3 * time.Hour,
+	}
+	for name, age := range ages {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, now.Add(-age), now.Add(-age))
+	}
+
+	removed := pruneBackups(cfg, true)
+	if len(removed) != 2 {
+		t.Fatalf("dry-run should report 2 removals, got %d", len(removed))
+	}
+	if backups, _ := listBackups(cfg); len(backups) != 4 {
+		t.Errorf("dry-run should not delete, %d backups left", len(backups))
+	}
+
+	pruneBackups(cfg, false)
+	backups, _ := listBackups(cfg)
+	if len(backups) != 2 || backups[0].ID != "20250104-120000" {
+		t.Errorf("expected newest 2 backups to remain, got %v", backups)
+	}
+
+	// Age limit removes everything but the newest
+	os.Chtimes(backups[1].Path, now.AddDate(0, 0, -60), now.AddDate(0, 0, -60))
+	cfg.maxBackups = 10
+	pruneBackups(cfg, false)
+	if backups, _ := listBackups(cfg); len(backups) != 1 {
+		t.Errorf("expected expired backup to be pruned, %d left", len(backups))
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/blackwell-systems/blackdot/internal/paths"
 	"github.com/spf13/cobra"
 )
 
@@ -40,6 +41,16 @@ customize the available functionality.
 Run 'blackdot help' for detailed command information.`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	// Flags not given on the command line take defaults.<command>.<flag>
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		startTimings(cmd)
+		startTracing(cmd)
+		if err := startProgressEvents(cmd); err != nil {
+			return err
+		}
+		migrateUserConfig()
+		return applyFlagDefaults(cmd)
+	},
 	// Show help when called without subcommand
 	Run: func(cmd *cobra.Command, args []string) {
 		customHelpFunc(cmd, args)
@@ -56,6 +67,10 @@ func SetVersionInfo(version, commit, date string) {
 // Execute runs the root command
 func Execute() error {
 	err := rootCmd.Execute()
+	finishTimings(err)
+	finishProgressEvents(err)
+	finishTracing(err)
+	finishVaultSessions()
 	if err != nil {
 		// Check if it's an unknown command error vs execution error
 		errStr := err.Error()
@@ -84,6 +99,8 @@ func init() {
 	// Global flags available to all commands
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolVar(&force, "force", false, "bypass feature checks")
+	rootCmd.PersistentFlags().BoolVar(&showTimings, "timings", false, "print and record per-phase timings")
+	rootCmd.PersistentFlags().StringVar(&progressFormat, "progress", "", "emit progress events on stderr (json) for setup, vault restore, and doctor")
 
 	// Add subcommands
 	rootCmd.AddCommand(
@@ -115,10 +132,55 @@ func init() {
 		newMacOSCmd(),
 		// Import from other dotfile managers
 		newImportCmd(),
+		// Scaffold a new dotfiles repo
+		newInitCmd(),
+		// Migrate from chezmoi, stow or yadm
+		newMigrateCmd(),
 		// Shell initialization (outputs feature check functions)
 		newShellInitCmd(),
 		// Devcontainer support
 		newDevcontainerCmd(),
+		// Git credential helper backed by vault
+		newGitCredentialCmd(),
+		// /workspace symlink watchdog
+		newWorkspaceCmd(),
+		// Overlay repositories (multi-repo layering)
+		newReposCmd(),
+		// Declarative scheduled tasks
+		newScheduleCmd(),
+		// Declarative symlinks (links.yaml)
+		newLinksCmd(),
+		// Share bootstrap config with a new machine
+		newPairCmd(),
+		// One-command onboarding of a remote host over SSH
+		newBootstrapCmd(),
+		// Test the setup in a throwaway container
+		newSandboxCmd(),
+		// Fleet inventory kept in the vault
+		newMachinesCmd(),
+		// Non-secret state shared through a git repo
+		newStateCmd(),
+		// Diagnostics for bug reports
+		newSupportCmd(),
+		// Plaintext credential scanning
+		newScanCmd(),
+		// Self-update from GitHub releases
+		newUpgradeCmd(),
+		// Environment secrets for other shells and child processes
+		newEnvCmd(),
+		// Git hooks for secret scanning and template staleness
+		newGithooksCmd(),
+		// Shell completion install and release tooling
+		newInstallCompletionsCmd(),
+		newReleaseCmd(),
+		// Shell integration maintenance
+		newShellCmd(),
+		// Shell startup profiling
+		newProfileCmd(),
+		// Cached status for prompt segments
+		newPromptStatusCmd(),
+		// Claude Code profiles, settings and CLAUDE.md
+		newClaudeCmd(),
 		// Note: migrate command dropped - one-time v2→v3 migration handled by bash
 	)
 }
@@ -146,12 +208,8 @@ func BlackdotDir() string {
 	return blackdotDir
 }
 
-// ConfigDir returns the config directory (~/.config/blackdot)
+// ConfigDir returns the config directory (~/.config/blackdot, or the
+// platform-native location when paths.strategy is platform-native)
 func ConfigDir() string {
-	configHome := os.Getenv("XDG_CONFIG_HOME")
-	if configHome == "" {
-		home, _ := os.UserHomeDir()
-		configHome = filepath.Join(home, ".config")
-	}
-	return filepath.Join(configHome, "blackdot")
+	return paths.ConfigDir()
 }
@@ -0,0 +1,356 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Paths inside a sandbox container
+const (
+	sandboxRepoMount = "/sandbox/repo"
+	sandboxBinary    = "/usr/local/bin/blackdot"
+	sandboxHome      = "/root"
+)
+
+// sandboxPrepare installs what setup and doctor expect on a bare image,
+// with whichever package manager it has. Failures are left to doctor.
+const sandboxPrepare = `if command -v apt-get >/dev/null 2>&1; then
+  export DEBIAN_FRONTEND=noninteractive
+  apt-get update -qq && apt-get install -y -qq git zsh ca-certificates >/dev/null
+elif command -v dnf >/dev/null 2>&1; then dnf install -y -q git zsh
+elif command -v yum >/dev/null 2>&1; then yum install -y -q git zsh
+elif command -v apk >/dev/null 2>&1; then apk add --quiet git zsh bash
+elif command -v pacman >/dev/null 2>&1; then pacman -Sy --noconfirm --quiet git zsh >/dev/null
+elif command -v zypper >/dev/null 2>&1; then zypper -q -n install git zsh
+fi
+true`
+
+type sandboxOptions struct {
+	images     []string
+	platform   string
+	version    string
+	noPackages bool
+	keep       bool
+	timeout    time.Duration
+	report     string
+}
+
+// sandboxStep is one command run in the container
+type sandboxStep struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"` // ok, failed, skipped
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// sandboxResult is the outcome of a sandbox run on one image
+type sandboxResult struct {
+	Image     string        `json:"image"`
+	Container string        `json:"container"`
+	Steps     []sandboxStep `json:"steps"`
+	Passed    int           `json:"passed"`
+	Warnings  int           `json:"warnings"`
+	Failed    int           `json:"failed"`
+	Problems  []string      `json:"problems,omitempty"` // doctor warnings and failures
+}
+
+// ok reports whether every step ran and doctor found no failures
+func (r *sandboxResult) ok() bool {
+	for _, s := range r.Steps {
+		if s.Status == "failed" {
+			return false
+		}
+	}
+	return r.Failed == 0
+}
+
+// sandboxDocker runs docker; tests replace it
+var sandboxDocker = func(ctx context.Context, stdout, stderr io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	return cmd.Run()
+}
+
+func newSandboxCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sandbox",
+		Short: "Test your dotfiles in a throwaway container",
+		Long: `Run your setup in a throwaway Docker container, as an integration test of
+your config on another distro that leaves this machine alone.`,
+	}
+
+	var opts sandboxOptions
+	runCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run setup and doctor in a throwaway container",
+		Long: `Start a container from each --image, run setup and doctor in it, and
+report the results.
+
+The dotfiles repo is mounted read-only and copied into the container's
+home directory, so setup and template rendering can write there. The
+blackdot binary is this one when the container platform matches, or the
+release for the container's platform otherwise (downloaded and
+checksum-verified, as bootstrap does). Setup runs non-interactively
+(BLACKDOT_NONINTERACTIVE=1), so every prompt takes its default. Nothing
+else from this machine is mounted: no vault session, SSH keys or config.
+
+Steps:
+  prepare   install git and zsh with the image's package manager
+            (apt, dnf, yum, apk, pacman, zypper); skip with --no-packages
+  copy      copy the repo to ~/.blackdot
+  setup     blackdot setup
+  doctor    blackdot doctor
+
+The container is removed afterwards unless --keep is given. The command
+fails when a step fails or doctor reports a failed check.`,
+		Example: `  blackdot sandbox run                          # ubuntu:24.04
+  blackdot sandbox run --image fedora --image alpine
+  blackdot sandbox run --image debian --keep    # Inspect it afterwards
+  blackdot sandbox run --report sandbox.json    # Write the results as JSON`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			return runSandbox(ctx, opts)
+		},
+	}
+	runCmd.Flags().StringSliceVarP(&opts.images, "image", "i", []string{"ubuntu:24.04"}, "Image to test on (repeatable)")
+	runCmd.Flags().StringVar(&opts.platform, "platform", "", "Container platform, e.g. linux/arm64 (default: the Docker server's)")
+	runCmd.Flags().StringVar(&opts.version, "version", "", "Install this release in the container instead of this binary")
+	runCmd.Flags().BoolVar(&opts.keep, "keep", false, "Keep the container for inspection")
+	runCmd.Flags().DurationVar(&opts.timeout, "timeout", 20*time.Minute, "Give up on an image after this long")
+	runCmd.Flags().StringVar(&opts.report, "report", "", "Write the results as JSON to this file")
+	runCmd.Flags().BoolVar(&opts.noPackages, "no-packages", false, "Don't install git and zsh in the container first")
+
+	cmd.AddCommand(runCmd)
+	return cmd
+}
+
+func runSandbox(ctx context.Context, opts sandboxOptions) error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return fmt.Errorf("docker not found; the sandbox needs Docker")
+	}
+	if err := checkDockerRunning(); err != nil {
+		return err
+	}
+	repo, err := filepath.Abs(getBlackdotDir())
+	if err != nil || !fileExists(repo) {
+		return fmt.Errorf("dotfiles repo not found: %s", repo)
+	}
+
+	platform, err := sandboxPlatform(ctx, opts.platform)
+	if err != nil {
+		return err
+	}
+	binary, cleanup, err := bootstrapBinary(ctx, platform, bootstrapOptions{
+		LocalBinary: opts.version == "" && platform.GOOS == runtime.GOOS && platform.GOARCH == runtime.GOARCH,
+		Version:     opts.version,
+	})
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var results []*sandboxResult
+	for _, image := range opts.images {
+		PrintHeader("Sandbox: " + image)
+		imageCtx, cancel := context.WithTimeout(ctx, opts.timeout)
+		result := runSandboxImage(imageCtx, image, repo, binary, platform, opts)
+		cancel()
+		results = append(results, result)
+		printSandboxResult(result, opts.keep)
+	}
+
+	if opts.report != "" {
+		data, _ := json.MarshalIndent(results, "", "  ")
+		if err := os.WriteFile(expandPath(opts.report), append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+		Info("Results written to %s", opts.report)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if !r.ok() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("sandbox failed on %d of %d image(s)", failed, len(results))
+	}
+	return nil
+}
+
+// sandboxPlatform is --platform, or the Docker server's platform
+func sandboxPlatform(ctx context.Context, flag string) (remotePlatform, error) {
+	value := flag
+	if value == "" {
+		var out bytes.Buffer
+		if err := sandboxDocker(ctx, &out, io.Discard, "version", "--format", "{{.Server.Os}}/{{.Server.Arch}}"); err != nil {
+			return remotePlatform{}, fmt.Errorf("cannot read the Docker server platform: %w", err)
+		}
+		value = strings.TrimSpace(out.String())
+	}
+	goos, goarch, ok := strings.Cut(value, "/")
+	if !ok || goos != "linux" || (goarch != "amd64" && goarch != "arm64") {
+		return remotePlatform{}, fmt.Errorf("unsupported container platform %q (linux/amd64 and linux/arm64 are supported)", value)
+	}
+	return remotePlatform{GOOS: goos, GOARCH: goarch}, nil
+}
+
+// runSandboxImage starts a container from image and runs each step in it.
+// Doctor runs even when setup fails, to show what was left undone; only a
+// failed copy stops the run.
+func runSandboxImage(ctx context.Context, image, repo, binary string, platform remotePlatform, opts sandboxOptions) *sandboxResult {
+	result := &sandboxResult{Image: image, Container: sandboxContainerName()}
+
+	args := []string{"run", "-d", "--name", result.Container, "--label", "blackdot.sandbox=1",
+		"--platform", platform.GOOS + "/" + platform.GOARCH,
+		"-v", repo + ":" + sandboxRepoMount + ":ro",
+		"-v", binary + ":" + sandboxBinary + ":ro",
+		"-e", "HOME=" + sandboxHome,
+		"-e", "BLACKDOT_DIR=" + sandboxHome + "/.blackdot",
+		"-e", "BLACKDOT_NONINTERACTIVE=1",
+		"-e", "TERM=dumb",
+		"--entrypoint", "sh", image, "-c", "while :; do sleep 3600; done"}
+	var stderr bytes.Buffer
+	start := time.Now()
+	if err := sandboxDocker(ctx, io.Discard, &stderr, args...); err != nil {
+		result.Steps = append(result.Steps, sandboxStep{Name: "start", Status: "failed",
+			Duration: sandboxDuration(start), Error: firstLine(stderr.String(), err)})
+		return result
+	}
+	result.Steps = append(result.Steps, sandboxStep{Name: "start", Status: "ok", Duration: sandboxDuration(start)})
+	if !opts.keep {
+		defer sandboxDocker(context.Background(), io.Discard, io.Discard, "rm", "-f", result.Container)
+	}
+
+	type step struct {
+		name    string
+		command string
+		skip    bool
+	}
+	steps := []step{
+		{"prepare", sandboxPrepare, opts.noPackages},
+		{"copy", "cp -a " + sandboxRepoMount + " \"$HOME/.blackdot\"", false},
+		{"setup", "blackdot setup", false},
+		{"doctor", "blackdot doctor --progress json", false},
+	}
+	copied := true
+	for _, s := range steps {
+		if s.skip || !copied {
+			result.Steps = append(result.Steps, sandboxStep{Name: s.name, Status: "skipped"})
+			continue
+		}
+		Info("%s...", s.name)
+		var stdout, stderr bytes.Buffer
+		out := io.Writer(&stdout)
+		if verbose {
+			out = io.MultiWriter(&stdout, os.Stdout)
+		}
+		start := time.Now()
+		err := sandboxDocker(ctx, out, &stderr, "exec", result.Container, "sh", "-c", s.command)
+		step := sandboxStep{Name: s.name, Status: "ok", Duration: sandboxDuration(start)}
+		if s.name == "doctor" {
+			parseSandboxDoctor(result, stderr.Bytes())
+			// A failed check is counted, not a failed step
+			if err != nil && result.Failed > 0 {
+				err = nil
+			}
+		}
+		if err != nil {
+			step.Status = "failed"
+			step.Error = firstLine(lastLines(strings.TrimSpace(stderr.String()+stdout.String()), 1), err)
+			if s.name == "copy" {
+				copied = false
+			}
+		}
+		result.Steps = append(result.Steps, step)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return result
+}
+
+// parseSandboxDoctor counts doctor's check events and keeps its warnings
+// and failures
+func parseSandboxDoctor(result *sandboxResult, events []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(events))
+	for scanner.Scan() {
+		var ev progressEvent
+		if json.Unmarshal(scanner.Bytes(), &ev) != nil || ev.Message == "" {
+			continue
+		}
+		switch ev.Status {
+		case "pass":
+			result.Passed++
+		case "warn":
+			result.Warnings++
+			result.Problems = append(result.Problems, "warn: "+ev.Phase+": "+ev.Message)
+		case "fail":
+			result.Failed++
+			result.Problems = append(result.Problems, "fail: "+ev.Phase+": "+ev.Message)
+		}
+	}
+}
+
+func printSandboxResult(r *sandboxResult, keep bool) {
+	for _, s := range r.Steps {
+		switch s.Status {
+		case "ok":
+			Pass("%-8s %s", s.Name, Dim.Sprint(s.Duration))
+		case "skipped":
+			fmt.Printf("  %s %-8s %s\n", Dim.Sprint("-"), s.Name, Dim.Sprint("skipped"))
+		default:
+			Fail("%-8s %s", s.Name, s.Error)
+		}
+	}
+	if r.Passed+r.Warnings+r.Failed > 0 {
+		Info("doctor: %d passed, %d warnings, %d failed", r.Passed, r.Warnings, r.Failed)
+		for _, p := range r.Problems {
+			fmt.Printf("    %s\n", p)
+		}
+	}
+	if keep {
+		Info("Container kept: docker exec -it %s sh   (docker rm -f %s when done)", r.Container, r.Container)
+	}
+	fmt.Println()
+}
+
+// sandboxContainerName is a unique name for a sandbox container
+func sandboxContainerName() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return "blackdot-sandbox-" + hex.EncodeToString(b)
+}
+
+func sandboxDuration(start time.Time) string {
+	return time.Since(start).Round(100 * time.Millisecond).String()
+}
+
+// firstLine is the first non-empty line of output, or err's message
+func firstLine(output string, err error) string {
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return err.Error()
+}
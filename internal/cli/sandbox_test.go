@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeSandboxDocker replaces sandboxDocker, recording each call and
+// answering docker exec with the output for the command it runs
+func fakeSandboxDocker(t *testing.T, exec func(command string, stdout, stderr io.Writer) error) *[]string {
+	t.Helper()
+	var calls []string
+	orig := sandboxDocker
+	sandboxDocker = func(ctx context.Context, stdout, stderr io.Writer, args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		if args[0] == "exec" {
+			return exec(args[len(args)-1], stdout, stderr)
+		}
+		return nil
+	}
+	t.Cleanup(func() { sandboxDocker = orig })
+	return &calls
+}
+
+// TestRunSandboxImage verifies the steps run in order, doctor's events are
+// counted, and the container is removed
+func TestRunSandboxImage(t *testing.T) {
+	calls := fakeSandboxDocker(t, func(command string, stdout, stderr io.Writer) error {
+		if strings.HasPrefix(command, "blackdot doctor") {
+			fmt.Fprintln(stderr, `{"phase":"SSH Configuration","percent":10}`)
+			fmt.Fprintln(stderr, `{"phase":"SSH Configuration","percent":10,"status":"pass","message":"~/.ssh exists"}`)
+			fmt.Fprintln(stderr, `{"phase":"Shell","percent":20,"status":"warn","message":"zsh is not the login shell"}`)
+			fmt.Fprintln(stderr, `{"phase":"Shell","percent":20,"status":"fail","message":"~/.zshrc missing"}`)
+			return errors.New("exit status 1")
+		}
+		return nil
+	})
+
+	r := runSandboxImage(context.Background(), "alpine", "/repo", "/bin/blackdot", remotePlatform{"linux", "amd64"}, sandboxOptions{})
+	var names []string
+	for _, s := range r.Steps {
+		names = append(names, s.Name+"="+s.Status)
+	}
+	if got := strings.Join(names, " "); got != "start=ok prepare=ok copy=ok setup=ok doctor=ok" {
+		t.Errorf("steps = %s", got)
+	}
+	if r.Passed != 1 || r.Warnings != 1 || r.Failed != 1 || len(r.Problems) != 2 {
+		t.Errorf("doctor = %+v", r)
+	}
+	if r.ok() {
+		t.Error("a failed check should fail the run")
+	}
+	start := (*calls)[0]
+	if !strings.Contains(start, "/repo:"+sandboxRepoMount+":ro") || !strings.Contains(start, "BLACKDOT_NONINTERACTIVE=1") || !strings.Contains(start, " alpine ") {
+		t.Errorf("docker run = %s", start)
+	}
+	if last := (*calls)[len(*calls)-1]; last != "rm -f "+r.Container {
+		t.Errorf("container not removed: %s", last)
+	}
+}
+
+// TestRunSandboxImageSetupFails verifies a failed setup still runs doctor
+// and --keep leaves the container
+func TestRunSandboxImageSetupFails(t *testing.T) {
+	calls := fakeSandboxDocker(t, func(command string, stdout, stderr io.Writer) error {
+		if command == "blackdot setup" {
+			fmt.Fprint(stderr, "\n[FAIL] links.yaml: invalid\n\n")
+			return errors.New("exit status 1")
+		}
+		return nil
+	})
+
+	r := runSandboxImage(context.Background(), "debian", "/repo", "/bin/blackdot", remotePlatform{"linux", "arm64"}, sandboxOptions{noPackages: true, keep: true})
+	status := map[string]sandboxStep{}
+	for _, s := range r.Steps {
+		status[s.Name] = s
+	}
+	if status["prepare"].Status != "skipped" || status["setup"].Status != "failed" || status["doctor"].Status != "ok" {
+		t.Errorf("steps = %+v", r.Steps)
+	}
+	if status["setup"].Error != "[FAIL] links.yaml: invalid" {
+		t.Errorf("setup error = %q", status["setup"].Error)
+	}
+	if r.ok() {
+		t.Error("a failed setup should fail the run")
+	}
+	for _, c := range *calls {
+		if strings.HasPrefix(c, "rm ") {
+			t.Error("--keep container was removed")
+		}
+	}
+}
+
+// TestSandboxPlatform verifies the Docker server's platform is used unless
+// --platform is given, and only Linux is accepted
+func TestSandboxPlatform(t *testing.T) {
+	orig := sandboxDocker
+	t.Cleanup(func() { sandboxDocker = orig })
+	sandboxDocker = func(ctx context.Context, stdout, stderr io.Writer, args ...string) error {
+		io.WriteString(stdout, "linux/arm64\n")
+		return nil
+	}
+	if p, err := sandboxPlatform(context.Background(), ""); err != nil || p.GOARCH != "arm64" {
+		t.Errorf("server platform = %+v, %v", p, err)
+	}
+	if p, err := sandboxPlatform(context.Background(), "linux/amd64"); err != nil || p.GOARCH != "amd64" {
+		t.Errorf("--platform = %+v, %v", p, err)
+	}
+	if _, err := sandboxPlatform(context.Background(), "windows/amd64"); err == nil {
+		t.Error("windows containers should be rejected")
+	}
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/scanners"
+	"github.com/spf13/cobra"
+)
+
+// Scan policies for content written to non-secret destinations
+const (
+	scanPolicyWarn  = "warn"
+	scanPolicyAbort = "abort"
+	scanPolicyOff   = "off"
+)
+
+func newScanCmd() *cobra.Command {
+	var jsonOut, noEntropy bool
+
+	cmd := &cobra.Command{
+		Use:   "scan [path...]",
+		Short: "Scan files for plaintext credentials",
+		Long: `Scan files or directories for plaintext credentials such as AWS keys,
+GitHub tokens, private keys and high-entropy values assigned to
+secret-looking keys (password, token, api_key...).
+
+With no paths, the blackdot directory is scanned. Matches are redacted in
+the output. Add "blackdot:allow" to a line to silence it.
+
+The same scanner runs before 'vault push' (items that are not secret
+files) and before 'template render' writes to generated/. Configure what
+happens on a finding with scan.policy:
+
+  warn   Print the findings and continue (default)
+  abort  Stop before writing
+  off    Skip the check
+
+Exits with status 1 when anything is found.
+
+Examples:
+  blackdot scan
+  blackdot scan ~/.gitconfig ~/.ssh/config
+  blackdot config set scan.policy abort`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScan(args, jsonOut, noEntropy)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON")
+	cmd.Flags().BoolVar(&noEntropy, "no-entropy", false, "Only report known token patterns")
+	return cmd
+}
+
+func runScan(targets []string, jsonOut, noEntropy bool) error {
+	if len(targets) == 0 {
+		targets = []string{BlackdotDir()}
+	}
+
+	scanner := scanners.New()
+	scanner.Entropy = !noEntropy
+
+	var findings []scanners.Finding
+	for _, target := range targets {
+		found, err := scanner.ScanPath(expandPath(target))
+		if err != nil {
+			Fail("Cannot scan %s: %v", target, err)
+			return err
+		}
+		findings = append(findings, found...)
+	}
+
+	if jsonOut {
+		if findings == nil {
+			findings = []scanners.Finding{}
+		}
+		data, _ := json.MarshalIndent(findings, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		PrintHeader("Secret Scan")
+		if len(findings) == 0 {
+			Pass("No credentials found")
+			return nil
+		}
+		printScanFindings(findings)
+		fmt.Println()
+		Warn("%d potential credential(s) found", len(findings))
+		Info("Move them to the vault, or add \"%s\" to a line that is a false positive", scanners.AllowMarker)
+	}
+
+	if len(findings) > 0 {
+		return fmt.Errorf("%d potential credential(s) found", len(findings))
+	}
+	return nil
+}
+
+// printScanFindings lists findings with their redacted match
+func printScanFindings(findings []scanners.Finding) {
+	fprintScanFindings(os.Stdout, findings)
+}
+
+// fprintScanFindings is printScanFindings writing to w
+func fprintScanFindings(w io.Writer, findings []scanners.Finding) {
+	for _, f := range findings {
+		fmt.Fprintf(w, "  %s %s:%d %s %s\n", Red.Sprint("✗"), tildePath(f.Path), f.Line, f.Description, Dim.Sprint(f.Match))
+	}
+}
+
+// scanPolicy returns scan.policy, defaulting to warn. Project files cannot
+// change it, so a repository cannot turn off the scan of what it receives.
+func scanPolicy() string {
+	val, _ := trustedConfigLookup("scan.policy")
+	switch policy := strings.ToLower(val); policy {
+	case scanPolicyAbort, scanPolicyOff:
+		return policy
+	}
+	return scanPolicyWarn
+}
+
+// checkContentForSecrets scans content bound for a non-secret destination
+// and applies scan.policy. It returns an error only when the policy is
+// abort and something was found.
+func checkContentForSecrets(name string, data []byte, destination string) error {
+	policy := scanPolicy()
+	if policy == scanPolicyOff {
+		return nil
+	}
+
+	findings := scanners.New().Scan(name, data)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	Warn("%s: %d potential credential(s) headed for %s", name, len(findings), destination)
+	printScanFindings(findings)
+	if policy == scanPolicyAbort {
+		Info("Move them to a secret item, or set scan.policy to warn")
+		return fmt.Errorf("%s contains plaintext credentials (scan.policy=abort)", name)
+	}
+	return nil
+}
+
+// secretFileNames are local files expected to hold credentials; pushing
+// them is the point, so they are not scanned
+var secretFileNames = regexp.MustCompile(`(?i)(credentials|secrets?|\.env|\.netrc|\.pgpass|\.pem|\.key|\.p12|id_[a-z0-9]+|token)$`)
+
+// isSecretDestination reports whether a vault item holds secret material
+func isSecretDestination(itemType, path string) bool {
+	if itemType == "sshkey" || itemType == "kubeconfig" || itemType == "binary" {
+		return true
+	}
+	return secretFileNames.MatchString(filepath.Base(path))
+}
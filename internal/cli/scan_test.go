@@ -0,0 +1,41 @@
+package cli
+
+import "testing"
+
+// TestCheckContentForSecretsPolicy verifies warn continues, abort stops and
+// off skips the scan
+func TestCheckContentForSecretsPolicy(t *testing.T) {
+	content := []byte("[credential]\n\ttoken = ghp_" + "aB3dE5fG7hJ9kL1mN3pQ5rS7tU9vW1xY3zA5\n")
+
+	for policy, wantErr := range map[string]bool{"warn": false, "abort": true, "off": false, "": false} {
+		t.Setenv("BLACKDOT_SCAN_POLICY", policy)
+		if err := checkContentForSecrets("gitconfig", content, "generated/gitconfig"); (err != nil) != wantErr {
+			t.Errorf("policy %q: err = %v", policy, err)
+		}
+	}
+
+	t.Setenv("BLACKDOT_SCAN_POLICY", "abort")
+	if err := checkContentForSecrets("gitconfig", []byte("[user]\n\tname = me\n"), "generated/gitconfig"); err != nil {
+		t.Errorf("clean content: %v", err)
+	}
+}
+
+// TestIsSecretDestination verifies credential files are exempt from the
+// push-time scan and ordinary config files are not
+func TestIsSecretDestination(t *testing.T) {
+	for path, want := range map[string]bool{
+		"~/.aws/credentials":   true,
+		"~/.local/env.secrets": true,
+		"~/.ssh/id_ed25519":    true,
+		"~/.gitconfig":         false,
+		"~/.ssh/config":        false,
+		"~/.aws/config":        false,
+	} {
+		if got := isSecretDestination("file", path); got != want {
+			t.Errorf("isSecretDestination(%q) = %v, want %v", path, got, want)
+		}
+	}
+	if !isSecretDestination("sshkey", "~/.ssh/github") {
+		t.Error("sshkey items are secret")
+	}
+}
@@ -0,0 +1,1105 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// scheduleTick is how often the OS scheduler invokes 'schedule run'.
+// Task intervals shorter than this are effectively rounded up to it.
+const scheduleTick = 15 * time.Minute
+
+// scheduleDefaultTimeout bounds a task run unless the task sets timeout
+const scheduleDefaultTimeout = 30 * time.Minute
+
+// scheduleSyncTask is the built-in task enabled by vault.auto_sync
+const scheduleSyncTask = "vault-sync"
+
+// Run statuses recorded in the history
+const (
+	scheduleOK      = "ok"
+	scheduleFailed  = "failed"
+	scheduleTimeout = "timeout"
+	scheduleSkipped = "skipped"
+)
+
+// scheduleTask is a named task from schedule.tasks in config.json
+type scheduleTask struct {
+	Name     string   `json:"-"`
+	Command  string   `json:"command"`
+	Interval string   `json:"interval"`
+	When     []string `json:"when,omitempty"`
+	Timeout  string   `json:"timeout,omitempty"`
+	Disabled bool     `json:"disabled,omitempty"`
+	Builtin  bool     `json:"-"`
+}
+
+// scheduleRun is one line of the run history
+type scheduleRun struct {
+	Task       string `json:"task"`
+	Start      string `json:"start"`
+	DurationMs int64  `json:"duration_ms"`
+	Status     string `json:"status"`
+	ExitCode   int    `json:"exit_code,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Output     string `json:"output,omitempty"`
+	Trigger    string `json:"trigger"`
+}
+
+// scheduleConditions are the checks usable in a task's when list. Prefix a
+// name with ! to negate it.
+var scheduleConditions = map[string]func() (bool, string){
+	"ac_power": conditionACPower,
+	"vpn":      conditionVPN,
+	"online":   conditionOnline,
+}
+
+func newScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run declared maintenance tasks on a schedule",
+		Long: `Run declared maintenance tasks on a schedule.
+
+Tasks live under schedule.tasks in config.json:
+
+  "schedule": {
+    "tasks": {
+      "brew-cleanup": { "command": "brew cleanup", "interval": "weekly", "when": ["ac_power"] },
+      "repo-fetch":   { "command": "git -C ~/code fetch --all", "interval": "2h", "when": ["online", "!vpn"] }
+    }
+  }
+
+Intervals: Go durations (30m, 6h), days (2d), or hourly/daily/weekly.
+Conditions: ac_power, vpn, online; prefix with ! to negate. A due task whose
+conditions fail is skipped and retried on the next tick.
+
+When vault.auto_sync is true, a built-in vault-sync task runs 'blackdot sync'
+every schedule.sync_interval (default 1h).
+
+'schedule install' registers 'blackdot schedule run' with launchd, a systemd
+user timer, cron, or Task Scheduler to run every 15 minutes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleList(false)
+		},
+	}
+
+	var listJSON bool
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List tasks with last run and next due time",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleList(listJSON)
+		},
+	}
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "Output as JSON")
+
+	var force, dryRun, quiet bool
+	runCmd := &cobra.Command{
+		Use:   "run [task...]",
+		Short: "Run due tasks (or the named tasks now)",
+		Long: `Run due tasks. Named tasks run now regardless of their interval, but
+their conditions still apply unless --force is given.`,
+		ValidArgsFunction: completeScheduleTasks,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleRun(args, force, dryRun, quiet)
+		},
+	}
+	runCmd.Flags().BoolVarP(&force, "force", "f", false, "Ignore conditions")
+	runCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would run")
+	runCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "No output (used by the OS scheduler)")
+
+	var historyLimit int
+	var historyJSON bool
+	historyCmd := &cobra.Command{
+		Use:               "history [task]",
+		Short:             "Show task run history",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeScheduleTasks,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			task := ""
+			if len(args) == 1 {
+				task = args[0]
+			}
+			return runScheduleHistory(task, historyLimit, historyJSON)
+		},
+	}
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of runs to show")
+	historyCmd.Flags().BoolVar(&historyJSON, "json", false, "Output as JSON")
+
+	var task scheduleTask
+	addCmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add or replace a task in config.json",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleAdd(args[0], task)
+		},
+	}
+	addCmd.Flags().StringVar(&task.Command, "command", "", "Shell command to run (required)")
+	addCmd.Flags().StringVar(&task.Interval, "interval", "daily", "Run interval (30m, 6h, 2d, hourly, daily, weekly)")
+	addCmd.Flags().StringArrayVar(&task.When, "when", nil, "Condition: ac_power, vpn, online, or !name (repeatable)")
+	addCmd.Flags().StringVar(&task.Timeout, "timeout", "", "Kill the task after this long (default 30m)")
+	addCmd.MarkFlagRequired("command")
+
+	removeCmd := &cobra.Command{
+		Use:               "remove <name>",
+		Aliases:           []string{"rm"},
+		Short:             "Remove a task from config.json",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeScheduleTasks,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			removed, err := deleteFromJSONFile(filepath.Join(ConfigDir(), "config.json"), "schedule.tasks."+args[0])
+			if err != nil {
+				return err
+			}
+			if !removed {
+				Fail("No task named '%s' in config.json", args[0])
+				return fmt.Errorf("no task named %q", args[0])
+			}
+			Pass("Removed task '%s'", args[0])
+			return nil
+		},
+	}
+
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Run the scheduler every 15 minutes via the OS scheduler",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleInstall()
+		},
+	}
+	uninstallCmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the OS scheduler entry",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleUninstall()
+		},
+	}
+
+	cmd.AddCommand(listCmd, runCmd, historyCmd, addCmd, removeCmd, installCmd, uninstallCmd)
+	return cmd
+}
+
+func completeScheduleTasks(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	tasks, _ := loadScheduleTasks()
+	names := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		names = append(names, t.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// ============================================================
+// Tasks
+// ============================================================
+
+// loadScheduleTasks returns the configured tasks plus the built-in sync
+// task when vault.auto_sync is on, sorted by name
+func loadScheduleTasks() ([]scheduleTask, error) {
+	var cfg struct {
+		Schedule struct {
+			Tasks map[string]scheduleTask `json:"tasks"`
+		} `json:"schedule"`
+	}
+	configPath := filepath.Join(ConfigDir(), "config.json")
+	if data, err := os.ReadFile(configPath); err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("parsing schedule.tasks in %s: %w", configPath, err)
+		}
+	}
+
+	tasks := make([]scheduleTask, 0, len(cfg.Schedule.Tasks)+1)
+	for name, t := range cfg.Schedule.Tasks {
+		t.Name = name
+		tasks = append(tasks, t)
+	}
+
+	if _, overridden := cfg.Schedule.Tasks[scheduleSyncTask]; !overridden && configLookup("vault.auto_sync") == "true" {
+		interval := configLookup("schedule.sync_interval")
+		if interval == "" {
+			interval = "1h"
+		}
+		tasks = append(tasks, scheduleTask{
+			Name:     scheduleSyncTask,
+			Command:  shellQuote(blackdotExecutable()) + " sync",
+			Interval: interval,
+			When:     []string{"online"},
+			Builtin:  true,
+		})
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	return tasks, nil
+}
+
+// validate checks the interval, timeout, and condition names
+func (t scheduleTask) validate() error {
+	if strings.TrimSpace(t.Command) == "" {
+		return fmt.Errorf("task %s: command is empty", t.Name)
+	}
+	if _, err := parseScheduleInterval(t.Interval); err != nil {
+		return fmt.Errorf("task %s: %w", t.Name, err)
+	}
+	if t.Timeout != "" {
+		if _, err := time.ParseDuration(t.Timeout); err != nil {
+			return fmt.Errorf("task %s: invalid timeout %q", t.Name, t.Timeout)
+		}
+	}
+	for _, c := range t.When {
+		if _, ok := scheduleConditions[strings.TrimPrefix(c, "!")]; !ok {
+			return fmt.Errorf("task %s: unknown condition %q", t.Name, c)
+		}
+	}
+	return nil
+}
+
+func (t scheduleTask) timeout() time.Duration {
+	if d, err := time.ParseDuration(t.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return scheduleDefaultTimeout
+}
+
+// parseScheduleInterval accepts Go durations, Nd, and hourly/daily/weekly
+func parseScheduleInterval(s string) (time.Duration, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "hourly":
+		return time.Hour, nil
+	case "daily", "":
+		return 24 * time.Hour, nil
+	case "weekly":
+		return 7 * 24 * time.Hour, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 1 {
+			return 0, fmt.Errorf("invalid interval %q", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q", s)
+	}
+	if d < time.Minute {
+		return 0, fmt.Errorf("interval %q is shorter than 1m", s)
+	}
+	return d, nil
+}
+
+// checkScheduleConditions returns whether all conditions hold, and the
+// first failing one otherwise
+func checkScheduleConditions(when []string) (bool, string) {
+	for _, c := range when {
+		negate := strings.HasPrefix(c, "!")
+		check, ok := scheduleConditions[strings.TrimPrefix(c, "!")]
+		if !ok {
+			return false, "unknown condition " + c
+		}
+		held, detail := check()
+		if held == negate {
+			if negate {
+				return false, fmt.Sprintf("%s (%s)", c, detail)
+			}
+			return false, fmt.Sprintf("%s not met (%s)", c, detail)
+		}
+	}
+	return true, ""
+}
+
+// nextDue returns when the task is next due given its last run. A task
+// that never ran is due now.
+func nextDue(t scheduleTask, last *scheduleRun, now time.Time) time.Time {
+	if last == nil {
+		return now
+	}
+	start, err := time.Parse(time.RFC3339, last.Start)
+	if err != nil {
+		return now
+	}
+	interval, err := parseScheduleInterval(t.Interval)
+	if err != nil {
+		return now
+	}
+	return start.Add(interval)
+}
+
+// blackdotExecutable returns the path of the running binary
+func blackdotExecutable() string {
+	if exe, err := os.Executable(); err == nil {
+		return exe
+	}
+	return "blackdot"
+}
+
+// ============================================================
+// Conditions
+// ============================================================
+
+func conditionACPower() (bool, string) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("pmset", "-g", "batt").Output()
+		if err != nil {
+			return true, "power source unknown"
+		}
+		if strings.Contains(string(out), "AC Power") {
+			return true, "on AC power"
+		}
+		return false, "on battery"
+	case "linux":
+		supplies, _ := filepath.Glob("/sys/class/power_supply/*")
+		hasBattery := false
+		for _, s := range supplies {
+			kind, _ := os.ReadFile(filepath.Join(s, "type"))
+			switch strings.TrimSpace(string(kind)) {
+			case "Mains", "USB":
+				online, _ := os.ReadFile(filepath.Join(s, "online"))
+				if strings.TrimSpace(string(online)) == "1" {
+					return true, "on AC power"
+				}
+			case "Battery":
+				hasBattery = true
+			}
+		}
+		if hasBattery {
+			return false, "on battery"
+		}
+		return true, "no battery"
+	}
+	return true, "power source unknown"
+}
+
+// vpnInterfacePrefixes are interface names used by common VPN clients
+var vpnInterfacePrefixes = []string{"utun", "tun", "tap", "wg", "ppp", "ipsec", "tailscale", "gpd", "nordlynx", "proton"}
+
+func conditionVPN() (bool, string) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return false, err.Error()
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		for _, prefix := range vpnInterfacePrefixes {
+			if !strings.HasPrefix(iface.Name, prefix) {
+				continue
+			}
+			// macOS keeps utun interfaces with only link-local IPv6 for
+			// system services; require a routable address
+			addrs, _ := iface.Addrs()
+			for _, addr := range addrs {
+				if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLinkLocalUnicast() && !ipnet.IP.IsLoopback() {
+					return true, "vpn up on " + iface.Name
+				}
+			}
+		}
+	}
+	return false, "no vpn interface"
+}
+
+func conditionOnline() (bool, string) {
+	conn, err := net.DialTimeout("tcp", "1.1.1.1:443", 3*time.Second)
+	if err != nil {
+		return false, "offline"
+	}
+	conn.Close()
+	return true, "online"
+}
+
+// ============================================================
+// History
+// ============================================================
+
+func scheduleHistoryPath() string {
+	return filepath.Join(paths.StateDir(), "schedule-history.jsonl")
+}
+
+// scheduleHistoryMax is the number of runs kept when the log is trimmed
+const scheduleHistoryMax = 1000
+
+func appendScheduleRun(run scheduleRun) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	path := scheduleHistoryPath()
+	if err := appendFileWithPolicy(path, append(data, '\n'), fileClassPrivate); err != nil {
+		return err
+	}
+
+	// Trim occasionally rather than on every append
+	if info, err := os.Stat(path); err == nil && info.Size() > 1<<20 {
+		runs, _ := loadScheduleHistory()
+		if len(runs) > scheduleHistoryMax {
+			runs = runs[len(runs)-scheduleHistoryMax:]
+		}
+		var b strings.Builder
+		for _, r := range runs {
+			line, _ := json.Marshal(r)
+			b.Write(line)
+			b.WriteByte('\n')
+		}
+		return writeFileWithPolicy(path, []byte(b.String()), fileClassPrivate)
+	}
+	return nil
+}
+
+func loadScheduleHistory() ([]scheduleRun, error) {
+	f, err := os.Open(scheduleHistoryPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []scheduleRun
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var r scheduleRun
+		if json.Unmarshal(scanner.Bytes(), &r) == nil {
+			runs = append(runs, r)
+		}
+	}
+	return runs, scanner.Err()
+}
+
+// lastScheduleRuns returns the most recent executed run and the most recent
+// history entry (which may be a skip) for each task
+func lastScheduleRuns(runs []scheduleRun) (executed, latest map[string]*scheduleRun) {
+	executed = map[string]*scheduleRun{}
+	latest = map[string]*scheduleRun{}
+	for i := range runs {
+		r := &runs[i]
+		latest[r.Task] = r
+		if r.Status != scheduleSkipped {
+			executed[r.Task] = r
+		}
+	}
+	return executed, latest
+}
+
+// ============================================================
+// Run
+// ============================================================
+
+// tailWriter keeps the last max bytes written to it
+type tailWriter struct {
+	buf []byte
+	max int
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > w.max {
+		w.buf = w.buf[len(w.buf)-w.max:]
+	}
+	return len(p), nil
+}
+
+// executeScheduleTask runs the task's command through the shell
+func executeScheduleTask(t scheduleTask, trigger string) scheduleRun {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout())
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", t.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", t.Command)
+	}
+	home, _ := os.UserHomeDir()
+	cmd.Dir = home
+	cmd.Env = append(os.Environ(), "BLACKDOT_SCHEDULED=1", "BLACKDOT_TASK="+t.Name)
+	output := &tailWriter{max: 2000}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err := cmd.Run()
+	run := scheduleRun{
+		Task:       t.Name,
+		Start:      start.UTC().Format(time.RFC3339),
+		DurationMs: time.Since(start).Milliseconds(),
+		Status:     scheduleOK,
+		Output:     strings.TrimSpace(string(output.buf)),
+		Trigger:    trigger,
+	}
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		run.Status = scheduleTimeout
+		run.Reason = "exceeded " + t.timeout().String()
+	case err != nil:
+		run.Status = scheduleFailed
+		run.Reason = err.Error()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			run.ExitCode = exitErr.ExitCode()
+		}
+	}
+	return run
+}
+
+// acquireScheduleLock prevents overlapping scheduler runs. A lock older
+// than the longest reasonable run is treated as stale.
+func acquireScheduleLock() (func(), error) {
+	lockPath := filepath.Join(paths.StateDir(), "schedule.lock")
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0700); err != nil {
+		return nil, err
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		info, statErr := os.Stat(lockPath)
+		if statErr != nil || time.Since(info.ModTime()) < 2*scheduleDefaultTimeout {
+			return nil, fmt.Errorf("another scheduler run is in progress (%s)", lockPath)
+		}
+		os.Remove(lockPath)
+	}
+	return nil, fmt.Errorf("could not acquire %s", lockPath)
+}
+
+func runScheduleRun(names []string, force, dryRun, quiet bool) error {
+	tasks, err := loadScheduleTasks()
+	if err != nil {
+		return err
+	}
+	history, _ := loadScheduleHistory()
+	executed, latest := lastScheduleRuns(history)
+	now := time.Now()
+
+	say := func(format string, args ...interface{}) {
+		if !quiet {
+			fmt.Printf(format+"\n", args...)
+		}
+	}
+
+	// Pick tasks: named ones always, otherwise those due
+	var selected []scheduleTask
+	trigger := "scheduler"
+	if len(names) > 0 {
+		trigger = "manual"
+		for _, name := range names {
+			found := false
+			for _, t := range tasks {
+				if t.Name == name {
+					selected = append(selected, t)
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("unknown task: %s", name)
+			}
+		}
+	} else {
+		for _, t := range tasks {
+			if !t.Disabled && !nextDue(t, executed[t.Name], now).After(now) {
+				selected = append(selected, t)
+			}
+		}
+	}
+
+	if len(selected) == 0 {
+		say("No tasks due")
+		return nil
+	}
+
+	if !dryRun {
+		release, err := acquireScheduleLock()
+		if err != nil {
+			if quiet {
+				return nil
+			}
+			return err
+		}
+		defer release()
+	}
+
+	failed := 0
+	for _, t := range selected {
+		if err := t.validate(); err != nil {
+			say("%s %v", Red.Sprint("✗"), err)
+			failed++
+			continue
+		}
+
+		if !force {
+			if ok, reason := checkScheduleConditions(t.When); !ok {
+				say("%s %s skipped: %s", Yellow.Sprint("○"), t.Name, reason)
+				// Record a skip once, not on every tick while it persists
+				if prev := latest[t.Name]; !dryRun && (prev == nil || prev.Status != scheduleSkipped || prev.Reason != reason) {
+					appendScheduleRun(scheduleRun{Task: t.Name, Start: now.UTC().Format(time.RFC3339), Status: scheduleSkipped, Reason: reason, Trigger: trigger})
+				}
+				continue
+			}
+		}
+
+		if dryRun {
+			say("%s would run %s: %s", Cyan.Sprint("→"), t.Name, t.Command)
+			continue
+		}
+
+		say("%s running %s...", Cyan.Sprint("→"), t.Name)
+		run := executeScheduleTask(t, trigger)
+		if err := appendScheduleRun(run); err != nil {
+			say("%s could not record history: %v", Yellow.Sprint("!"), err)
+		}
+		if run.Status == scheduleOK {
+			say("%s %s (%s)", Green.Sprint("✓"), t.Name, formatRunDuration(run.DurationMs))
+		} else {
+			failed++
+			say("%s %s %s: %s", Red.Sprint("✗"), t.Name, run.Status, run.Reason)
+			if run.Output != "" && !quiet {
+				fmt.Println(Dim.Sprint(indentLines(lastLines(run.Output, 10), "    ")))
+			}
+		}
+	}
+
+	if failed > 0 && !quiet {
+		return fmt.Errorf("%d task(s) failed", failed)
+	}
+	return nil
+}
+
+func formatRunDuration(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	if d < time.Second {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func indentLines(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+// ============================================================
+// List / History / Add
+// ============================================================
+
+func runScheduleList(asJSON bool) error {
+	tasks, err := loadScheduleTasks()
+	if err != nil {
+		return err
+	}
+	history, _ := loadScheduleHistory()
+	executed, latest := lastScheduleRuns(history)
+	now := time.Now()
+
+	if asJSON {
+		type entry struct {
+			scheduleTask
+			Name    string       `json:"name"`
+			Builtin bool         `json:"builtin,omitempty"`
+			LastRun *scheduleRun `json:"last_run,omitempty"`
+			NextDue string       `json:"next_due"`
+		}
+		out := make([]entry, 0, len(tasks))
+		for _, t := range tasks {
+			out = append(out, entry{t, t.Name, t.Builtin, latest[t.Name], nextDue(t, executed[t.Name], now).UTC().Format(time.RFC3339)})
+		}
+		data, _ := json.MarshalIndent(out, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	PrintHeader("Scheduled Tasks")
+	if len(tasks) == 0 {
+		fmt.Println("No tasks defined.")
+		fmt.Println()
+		fmt.Println("Add one with:")
+		fmt.Println("  blackdot schedule add brew-cleanup --command 'brew cleanup' --interval weekly --when ac_power")
+	}
+	for _, t := range tasks {
+		icon := Green.Sprint("●")
+		state := ""
+		if t.Disabled {
+			icon = Dim.Sprint("○")
+			state = Dim.Sprint(" (disabled)")
+		} else if err := t.validate(); err != nil {
+			icon = Red.Sprint("✗")
+			state = Red.Sprint(" " + err.Error())
+		}
+		if t.Builtin {
+			state += Dim.Sprint(" (built-in)")
+		}
+
+		fmt.Printf("%s %s%s\n", icon, t.Name, state)
+		fmt.Printf("    %s %s\n", Dim.Sprint("command:"), t.Command)
+		when := ""
+		if len(t.When) > 0 {
+			when = "  when " + strings.Join(t.When, ", ")
+		}
+		fmt.Printf("    %s %s%s\n", Dim.Sprint("every:  "), t.Interval, when)
+
+		last := "never"
+		if r := latest[t.Name]; r != nil {
+			last = fmt.Sprintf("%s %s", formatRunAge(r.Start, now), r.Status)
+			if r.Status != scheduleOK && r.Reason != "" {
+				last += " (" + r.Reason + ")"
+			}
+		}
+		due := nextDue(t, executed[t.Name], now)
+		next := "now"
+		if due.After(now) {
+			next = "in " + due.Sub(now).Round(time.Minute).String()
+		}
+		fmt.Printf("    %s %s, next %s\n", Dim.Sprint("last:   "), last, next)
+	}
+
+	fmt.Println()
+	if mech := scheduleInstalled(); mech != "" {
+		Pass("Scheduler installed (%s)", mech)
+	} else {
+		Warn("Scheduler not installed. Run: blackdot schedule install")
+	}
+	return nil
+}
+
+func formatRunAge(start string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return start
+	}
+	age := now.Sub(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+	}
+}
+
+func runScheduleHistory(task string, limit int, asJSON bool) error {
+	runs, err := loadScheduleHistory()
+	if err != nil {
+		return err
+	}
+	if task != "" {
+		filtered := runs[:0]
+		for _, r := range runs {
+			if r.Task == task {
+				filtered = append(filtered, r)
+			}
+		}
+		runs = filtered
+	}
+	if limit > 0 && len(runs) > limit {
+		runs = runs[len(runs)-limit:]
+	}
+
+	if asJSON {
+		if runs == nil {
+			runs = []scheduleRun{}
+		}
+		data, _ := json.MarshalIndent(runs, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	PrintHeader("Schedule History")
+	if len(runs) == 0 {
+		fmt.Println("No runs recorded.")
+		return nil
+	}
+	for _, r := range runs {
+		icon := Green.Sprint("✓")
+		switch r.Status {
+		case scheduleFailed, scheduleTimeout:
+			icon = Red.Sprint("✗")
+		case scheduleSkipped:
+			icon = Yellow.Sprint("○")
+		}
+		start := r.Start
+		if t, err := time.Parse(time.RFC3339, r.Start); err == nil {
+			start = t.Local().Format("2006-01-02 15:04")
+		}
+		detail := r.Status
+		if r.Status != scheduleSkipped {
+			detail += " " + formatRunDuration(r.DurationMs)
+		}
+		if r.Reason != "" {
+			detail += ": " + r.Reason
+		}
+		fmt.Printf("%s %s  %-20s %s %s\n", icon, start, r.Task, detail, Dim.Sprint("["+r.Trigger+"]"))
+	}
+	return nil
+}
+
+func runScheduleAdd(name string, t scheduleTask) error {
+	t.Name = name
+	if err := t.validate(); err != nil {
+		Fail("%v", err)
+		return err
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if err := setInJSONFile(filepath.Join(ConfigDir(), "config.json"), "schedule.tasks."+name, string(data)); err != nil {
+		Fail("Failed to save task: %v", err)
+		return err
+	}
+	Pass("Saved task '%s' (every %s)", name, t.Interval)
+	if scheduleInstalled() == "" {
+		Info("Run 'blackdot schedule install' to run tasks automatically")
+	}
+	return nil
+}
+
+// ============================================================
+// OS scheduler integration
+// ============================================================
+
+const (
+	scheduleLaunchdLabel = "com.blackwell-systems.blackdot.schedule"
+	scheduleUnitName     = "blackdot-schedule"
+	scheduleCronMarker   = "# blackdot-schedule"
+)
+
+func launchdPlistPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "LaunchAgents", scheduleLaunchdLabel+".plist")
+}
+
+func systemdUserDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "systemd", "user")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "systemd", "user")
+}
+
+func systemdUserAvailable() bool {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return false
+	}
+	return exec.Command("systemctl", "--user", "show-environment").Run() == nil
+}
+
+func userCrontab() string {
+	out, err := exec.Command("crontab", "-l").Output()
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// scheduleInstalled reports which OS scheduler runs blackdot, if any
+func scheduleInstalled() string {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := os.Stat(launchdPlistPath()); err == nil {
+			return "launchd"
+		}
+	case "windows":
+		if exec.Command("schtasks", "/Query", "/TN", scheduleUnitName).Run() == nil {
+			return "Task Scheduler"
+		}
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(systemdUserDir(), scheduleUnitName+".timer")); err == nil {
+		return "systemd user timer"
+	}
+	if strings.Contains(userCrontab(), scheduleCronMarker) {
+		return "cron"
+	}
+	return ""
+}
+
+func runScheduleInstall() error {
+	exe := blackdotExecutable()
+	minutes := int(scheduleTick.Minutes())
+	logPath := filepath.Join(paths.StateDir(), "schedule.log")
+
+	switch {
+	case runtime.GOOS == "darwin":
+		plist := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+  <key>Label</key>
+  <string>%s</string>
+  <key>ProgramArguments</key>
+  <array>
+    <string>%s</string>
+    <string>schedule</string>
+    <string>run</string>
+    <string>--quiet</string>
+  </array>
+  <key>StartInterval</key>
+  <integer>%d</integer>
+  <key>RunAtLoad</key>
+  <true/>
+  <key>StandardOutPath</key>
+  <string>%s</string>
+  <key>StandardErrorPath</key>
+  <string>%s</string>
+</dict>
+</plist>
+`, scheduleLaunchdLabel, exe, minutes*60, logPath, logPath)
+		path := launchdPlistPath()
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := writeFileWithPolicy(path, []byte(plist), fileClassConfig); err != nil {
+			Fail("Failed to write %s: %v", path, err)
+			return err
+		}
+		exec.Command("launchctl", "unload", path).Run()
+		if out, err := exec.Command("launchctl", "load", "-w", path).CombinedOutput(); err != nil {
+			Fail("launchctl load failed: %s", strings.TrimSpace(string(out)))
+			return err
+		}
+		Pass("Installed launchd agent %s (every %dm)", scheduleLaunchdLabel, minutes)
+
+	case runtime.GOOS == "windows":
+		tr := fmt.Sprintf(`"%s" schedule run --quiet`, exe)
+		if out, err := exec.Command("schtasks", "/Create", "/F", "/SC", "MINUTE", "/MO", strconv.Itoa(minutes), "/TN", scheduleUnitName, "/TR", tr).CombinedOutput(); err != nil {
+			Fail("schtasks failed: %s", strings.TrimSpace(string(out)))
+			return err
+		}
+		Pass("Installed scheduled task %s (every %dm)", scheduleUnitName, minutes)
+
+	case systemdUserAvailable():
+		dir := systemdUserDir()
+		os.MkdirAll(dir, 0755)
+		service := fmt.Sprintf(`[Unit]
+Description=blackdot scheduled tasks
+
+[Service]
+Type=oneshot
+ExecStart=%s schedule run --quiet
+`, exe)
+		timer := fmt.Sprintf(`[Unit]
+Description=Run blackdot scheduled tasks every %d minutes
+
+[Timer]
+OnBootSec=5min
+OnUnitActiveSec=%dmin
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`, minutes, minutes)
+		if err := writeFileWithPolicy(filepath.Join(dir, scheduleUnitName+".service"), []byte(service), fileClassConfig); err != nil {
+			return err
+		}
+		if err := writeFileWithPolicy(filepath.Join(dir, scheduleUnitName+".timer"), []byte(timer), fileClassConfig); err != nil {
+			return err
+		}
+		exec.Command("systemctl", "--user", "daemon-reload").Run()
+		if out, err := exec.Command("systemctl", "--user", "enable", "--now", scheduleUnitName+".timer").CombinedOutput(); err != nil {
+			Fail("systemctl enable failed: %s", strings.TrimSpace(string(out)))
+			return err
+		}
+		Pass("Installed systemd user timer %s.timer (every %dm)", scheduleUnitName, minutes)
+
+	default:
+		if _, err := exec.LookPath("crontab"); err != nil {
+			Fail("No supported scheduler found (launchd, systemd, cron)")
+			return fmt.Errorf("no supported scheduler")
+		}
+		current := userCrontab()
+		if strings.Contains(current, scheduleCronMarker) {
+			Pass("Already installed in crontab")
+			return nil
+		}
+		line := fmt.Sprintf("*/%d * * * * %s schedule run --quiet >> %s 2>&1 %s\n", minutes, shellQuote(exe), shellQuote(logPath), scheduleCronMarker)
+		if current != "" && !strings.HasSuffix(current, "\n") {
+			current += "\n"
+		}
+		install := exec.Command("crontab", "-")
+		install.Stdin = strings.NewReader(current + line)
+		if out, err := install.CombinedOutput(); err != nil {
+			Fail("crontab update failed: %s", strings.TrimSpace(string(out)))
+			return err
+		}
+		Pass("Installed crontab entry (every %dm)", minutes)
+	}
+
+	Info("Run history: blackdot schedule history")
+	return nil
+}
+
+func runScheduleUninstall() error {
+	removed := false
+
+	if path := launchdPlistPath(); runtime.GOOS == "darwin" {
+		if _, err := os.Stat(path); err == nil {
+			exec.Command("launchctl", "unload", "-w", path).Run()
+			os.Remove(path)
+			Pass("Removed launchd agent")
+			removed = true
+		}
+	}
+	if runtime.GOOS == "windows" {
+		if exec.Command("schtasks", "/Delete", "/F", "/TN", scheduleUnitName).Run() == nil {
+			Pass("Removed scheduled task")
+			removed = true
+		}
+	}
+
+	timer := filepath.Join(systemdUserDir(), scheduleUnitName+".timer")
+	if _, err := os.Stat(timer); err == nil {
+		exec.Command("systemctl", "--user", "disable", "--now", scheduleUnitName+".timer").Run()
+		os.Remove(timer)
+		os.Remove(filepath.Join(systemdUserDir(), scheduleUnitName+".service"))
+		exec.Command("systemctl", "--user", "daemon-reload").Run()
+		Pass("Removed systemd user timer")
+		removed = true
+	}
+
+	if current := userCrontab(); strings.Contains(current, scheduleCronMarker) {
+		var kept []string
+		for _, line := range strings.Split(strings.TrimRight(current, "\n"), "\n") {
+			if !strings.Contains(line, scheduleCronMarker) {
+				kept = append(kept, line)
+			}
+		}
+		install := exec.Command("crontab", "-")
+		install.Stdin = strings.NewReader(strings.Join(kept, "\n") + "\n")
+		if err := install.Run(); err != nil {
+			Fail("crontab update failed: %v", err)
+			return err
+		}
+		Pass("Removed crontab entry")
+		removed = true
+	}
+
+	if !removed {
+		Info("Scheduler was not installed")
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+)
+
+// setScheduleEnv isolates config and state directories for scheduler tests
+func setScheduleEnv(t *testing.T, config string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".state"))
+	t.Setenv("BLACKDOT_PATHS_STRATEGY", "")
+	paths.ResetStrategy()
+	t.Cleanup(paths.ResetStrategy)
+	t.Setenv("BLACKDOT_VAULT_AUTO_SYNC", "")
+	dir := filepath.Join(home, ".config", "blackdot")
+	origUser, origMachine := configLayerUser, configLayerMachine
+	configLayerUser = filepath.Join(dir, "config.json")
+	configLayerMachine = filepath.Join(dir, "machine.json")
+	t.Cleanup(func() { configLayerUser, configLayerMachine = origUser, origMachine })
+	if config != "" {
+		os.MkdirAll(dir, 0700)
+		os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600)
+	}
+}
+
+// TestParseScheduleInterval verifies durations, days, and named intervals
+func TestParseScheduleInterval(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"30m":    30 * time.Minute,
+		"6h":     6 * time.Hour,
+		"2d":     48 * time.Hour,
+		"hourly": time.Hour,
+		"daily":  24 * time.Hour,
+		"weekly": 7 * 24 * time.Hour,
+	} {
+		got, err := parseScheduleInterval(input)
+		if err != nil || got != want {
+			t.Errorf("parseScheduleInterval(%q) = %v, %v", input, got, err)
+		}
+	}
+	for _, bad := range []string{"10s", "0d", "soon"} {
+		if _, err := parseScheduleInterval(bad); err == nil {
+			t.Errorf("parseScheduleInterval(%q) should fail", bad)
+		}
+	}
+}
+
+// TestScheduleConditions verifies negation and failure reasons
+func TestScheduleConditions(t *testing.T) {
+	orig := scheduleConditions
+	t.Cleanup(func() { scheduleConditions = orig })
+	scheduleConditions = map[string]func() (bool, string){
+		"yes": func() (bool, string) { return true, "held" },
+		"no":  func() (bool, string) { return false, "not held" },
+	}
+
+	if ok, _ := checkScheduleConditions([]string{"yes", "!no"}); !ok {
+		t.Error("yes + !no should pass")
+	}
+	if ok, reason := checkScheduleConditions([]string{"yes", "no"}); ok || reason != "no not met (not held)" {
+		t.Errorf("no: ok=%v reason=%q", ok, reason)
+	}
+	if ok, _ := checkScheduleConditions([]string{"!yes"}); ok {
+		t.Error("!yes should fail")
+	}
+	if err := (scheduleTask{Name: "x", Command: "true", Interval: "1h", When: []string{"maybe"}}).validate(); err == nil {
+		t.Error("unknown condition should fail validation")
+	}
+}
+
+// TestLoadScheduleTasks verifies config tasks and the built-in sync task
+func TestLoadScheduleTasks(t *testing.T) {
+	setScheduleEnv(t, `{"vault":{"auto_sync":true},"schedule":{"sync_interval":"3h","tasks":{"cleanup":{"command":"brew cleanup","interval":"weekly","when":["ac_power"]}}}}`)
+
+	tasks, err := loadScheduleTasks()
+	if err != nil {
+		t.Fatalf("loadScheduleTasks: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Name != "cleanup" || tasks[1].Name != scheduleSyncTask {
+		t.Fatalf("tasks = %+v", tasks)
+	}
+	if !tasks[1].Builtin || tasks[1].Interval != "3h" {
+		t.Errorf("sync task = %+v", tasks[1])
+	}
+}
+
+// TestScheduleRunAndHistory verifies due selection, history, and skips
+func TestScheduleRunAndHistory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	setScheduleEnv(t, `{"schedule":{"tasks":{
+  "ok":   {"command":"echo hello","interval":"1h"},
+  "bad":  {"command":"echo boom; exit 3","interval":"1h"},
+  "cond": {"command":"true","interval":"1h","when":["never"]}
+}}}`)
+	orig := scheduleConditions
+	t.Cleanup(func() { scheduleConditions = orig })
+	scheduleConditions = map[string]func() (bool, string){
+		"never": func() (bool, string) { return false, "test" },
+	}
+
+	if err := runScheduleRun(nil, false, false, true); err != nil {
+		t.Fatalf("quiet run should not return task failures: %v", err)
+	}
+	runs, _ := loadScheduleHistory()
+	executed, latest := lastScheduleRuns(runs)
+	if r := executed["ok"]; r == nil || r.Status != scheduleOK || r.Output != "hello" {
+		t.Errorf("ok run = %+v", r)
+	}
+	if r := executed["bad"]; r == nil || r.Status != scheduleFailed || r.ExitCode != 3 {
+		t.Errorf("bad run = %+v", r)
+	}
+	if r := latest["cond"]; r == nil || r.Status != scheduleSkipped {
+		t.Errorf("cond should be skipped, got %+v", r)
+	}
+
+	// Nothing is due again within the interval; repeated skips are not logged
+	before := len(runs)
+	runScheduleRun(nil, false, false, true)
+	runs, _ = loadScheduleHistory()
+	if len(runs) != before {
+		t.Errorf("history grew from %d to %d on a tick with nothing due", before, len(runs))
+	}
+
+	// Named tasks run regardless of interval; --force ignores conditions
+	if err := runScheduleRun([]string{"cond"}, true, false, false); err != nil {
+		t.Fatalf("forced run: %v", err)
+	}
+	runs, _ = loadScheduleHistory()
+	if last := runs[len(runs)-1]; last.Task != "cond" || last.Status != scheduleOK || last.Trigger != "manual" {
+		t.Errorf("forced run = %+v", last)
+	}
+
+	if err := runScheduleRun([]string{"missing"}, false, false, true); err == nil {
+		t.Error("unknown task should fail")
+	}
+}
+
+// TestScheduleAddRemove verifies tasks round-trip through config.json
+func TestScheduleAddRemove(t *testing.T) {
+	setScheduleEnv(t, `{"vault":{"backend":"pass"}}`)
+
+	if err := runScheduleAdd("fetch", scheduleTask{Command: "git fetch", Interval: "2h", When: []string{"online"}}); err != nil {
+		t.Fatalf("runScheduleAdd: %v", err)
+	}
+	if err := runScheduleAdd("bad", scheduleTask{Command: "x", Interval: "nope"}); err == nil {
+		t.Error("invalid interval should be rejected")
+	}
+
+	tasks, _ := loadScheduleTasks()
+	if len(tasks) != 1 || tasks[0].Command != "git fetch" || tasks[0].When[0] != "online" {
+		t.Fatalf("tasks = %+v", tasks)
+	}
+	if configLookup("vault.backend") != "pass" {
+		t.Error("existing config lost")
+	}
+
+	removed, err := deleteFromJSONFile(filepath.Join(ConfigDir(), "config.json"), "schedule.tasks.fetch")
+	if err != nil || !removed {
+		t.Fatalf("delete: %v %v", removed, err)
+	}
+	if tasks, _ := loadScheduleTasks(); len(tasks) != 0 {
+		t.Errorf("task not removed: %+v", tasks)
+	}
+}
@@ -1,14 +1,17 @@
 package cli
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+	"github.com/blackwell-systems/blackdot/internal/vault/keychain"
+	"github.com/blackwell-systems/vaultmux"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -149,8 +152,7 @@ func runSetup(reset, statusOnly bool) error {
 
 	// Handle --reset flag
 	if reset {
-		fmt.Print("Reset all setup progress? [y/N]: ")
-		if confirm := readInput(); strings.EqualFold(confirm, "y") {
+		if setupConfirm("Reset all setup progress?", false) {
 			cfg.Setup.Completed = []string{}
 			if err := saveSetupConfig(cfg); err != nil {
 				return fmt.Errorf("failed to reset state: %w", err)
@@ -185,6 +187,10 @@ func runSetup(reset, statusOnly bool) error {
 		return nil
 	}
 
+	if err := triggerHooks("pre_setup", hookContext{}); err != nil {
+		return err
+	}
+
 	fmt.Println("Let's complete your setup...")
 	fmt.Println()
 
@@ -223,7 +229,7 @@ func runSetup(reset, statusOnly bool) error {
 	fmt.Printf("     %s\n", dim("Auto-discover and sync to vault"))
 	fmt.Println()
 	fmt.Printf("  %s %s       - AI assistant integration\n", cyan("6."), bold("Claude Code"))
-	fmt.Printf("     %s\n", dim("Optional: dotclaude + portable sessions"))
+	fmt.Printf("     %s\n", dim("Optional: Claude profiles + portable sessions"))
 	fmt.Println()
 	fmt.Printf("  %s %s         - Machine-specific configs\n", cyan("7."), bold("Templates"))
 	fmt.Printf("     %s\n", dim("Optional: work vs personal configs"))
@@ -234,8 +240,7 @@ func runSetup(reset, statusOnly bool) error {
 	fmt.Println(cyan("═══════════════════════════════════════════════════════════════"))
 	fmt.Println()
 
-	fmt.Print("Press Enter to begin setup...")
-	readInput()
+	setupInput("Press Enter to begin setup", "")
 	fmt.Println()
 
 	// Run each phase
@@ -249,18 +254,35 @@ func runSetup(reset, statusOnly bool) error {
 		"template":  phaseTemplate,
 	}
 
-	for _, phase := range setupPhases {
-		if !isPhaseCompleted(cfg, phase) {
-			if fn, ok := phaseFuncs[phase]; ok {
-				if err := fn(cfg); err != nil {
-					fmt.Printf("%s Phase %s failed: %v\n", yellow("!"), phase, err)
-					// Continue even if phase fails
-				}
-				// Save config after each phase
-				if err := saveSetupConfig(cfg); err != nil {
-					fmt.Printf("%s Failed to save config: %v\n", yellow("!"), err)
-				}
+	phaseDescs := getPhaseDescriptions()
+	for i, phase := range setupPhases {
+		done := progressEvent{Phase: phase, Percent: progressPercent(i+1, len(setupPhases)), Status: "ok"}
+		if isPhaseCompleted(cfg, phase) {
+			done.Status, done.Message = "skipped", "already completed"
+			emitProgress(done)
+			continue
+		}
+		if fn, ok := phaseFuncs[phase]; ok {
+			emitProgress(progressEvent{Phase: phase, Percent: progressPercent(i, len(setupPhases)), Message: phaseDescs[phase]})
+			phaseCtx := hookContext{Env: map[string]string{"BLACKDOT_HOOK_PHASE": phase}}
+			if err := triggerHooks("pre_setup_phase", phaseCtx); err != nil {
+				fmt.Printf("%s Phase %s skipped by hook\n", yellow("!"), phase)
+				done.Status, done.Message = "skipped", err.Error()
+				emitProgress(done)
+				continue
+			}
+			if err := fn(cfg); err != nil {
+				fmt.Printf("%s Phase %s failed: %v\n", yellow("!"), phase, err)
+				done.Status, done.Message = "failed", err.Error()
+				// Continue even if phase fails
 			}
+			emitProgress(done)
+			triggerHooks("post_setup_phase", phaseCtx)
+			// Save config after each phase
+			if err := saveSetupConfig(cfg); err != nil {
+				fmt.Printf("%s Failed to save config: %v\n", yellow("!"), err)
+			}
+			syncStateAfterSetup()
 		}
 	}
 
@@ -278,6 +300,8 @@ func runSetup(reset, statusOnly bool) error {
 		// Offer feature preset selection
 		showPresetSelection(cfg)
 		showNextSteps(cfg)
+
+		triggerHooks("setup_complete", hookContext{})
 	} else {
 		fmt.Printf("%s Some steps were skipped or failed.\n", yellow("!"))
 		fmt.Println("Run 'blackdot setup' again to continue.")
@@ -318,14 +342,45 @@ func saveSetupConfig(cfg *SetupConfig) error {
 	}
 
 	configPath := filepath.Join(configDir, "config.json")
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	data, err := json.Marshal(cfg)
 	if err != nil {
 		return err
 	}
 
+	// Merge over the existing file so keys setup doesn't model
+	// (paths.strategy, vault.verified_at, ...) are kept
+	var merged, updates map[string]interface{}
+	if existing, err := os.ReadFile(configPath); err == nil {
+		json.Unmarshal(existing, &merged)
+	}
+	if merged == nil {
+		merged = make(map[string]interface{})
+	}
+	if err := json.Unmarshal(data, &updates); err != nil {
+		return err
+	}
+	mergeJSONObjects(merged, updates)
+
+	data, err = json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return err
+	}
 	return os.WriteFile(configPath, data, 0644)
 }
 
+// mergeJSONObjects deep-merges src into dst, with src winning
+func mergeJSONObjects(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcObj, srcIsObj := value.(map[string]interface{})
+		dstObj, dstIsObj := dst[key].(map[string]interface{})
+		if srcIsObj && dstIsObj {
+			mergeJSONObjects(dstObj, srcObj)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
 // isPhaseCompleted checks if a phase is in the completed list
 func isPhaseCompleted(cfg *SetupConfig, phase string) bool {
 	for _, p := range cfg.Setup.Completed {
@@ -416,9 +471,9 @@ func inferState(cfg *SetupConfig) {
 		}
 	}
 
-	// Infer claude: if dotclaude is installed or claude not available
+	// Infer claude: if profiles exist or claude not available
 	if !isPhaseCompleted(cfg, "claude") {
-		if _, err := exec.LookPath("dotclaude"); err == nil {
+		if fileExists(claudeProfilesPath()) {
 			markPhaseComplete(cfg, "claude")
 		} else if _, err := exec.LookPath("claude"); err != nil {
 			// Claude not installed, skip this phase
@@ -510,11 +565,17 @@ func showProgress(current, total int, stepName string) {
 	fmt.Println()
 }
 
-// readInput reads a line of input from stdin
-func readInput() string {
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	return strings.TrimSpace(input)
+// setupConfirm asks a yes/no question. An empty answer, or a
+// non-interactive run, picks def.
+func setupConfirm(question string, def bool) bool {
+	ok, _ := prompts.Confirm(question, def)
+	return ok
+}
+
+// setupInput asks for a line of text, returning def for an empty answer
+func setupInput(question, def string) string {
+	answer, _ := prompts.Input(question, def)
+	return answer
 }
 
 // ============================================================
@@ -529,8 +590,7 @@ func phaseWorkspace(cfg *SetupConfig) error {
 
 	if isPhaseCompleted(cfg, "workspace") {
 		fmt.Printf("%s Workspace already configured: %s\n", green("✓"), cfg.Paths.WorkspaceTarget)
-		fmt.Print("Reconfigure workspace target? [y/N]: ")
-		if !strings.EqualFold(readInput(), "y") {
+		if !setupConfirm("Reconfigure workspace target?", false) {
 			return nil
 		}
 	}
@@ -560,13 +620,7 @@ func phaseWorkspace(cfg *SetupConfig) error {
 		fmt.Println("  ~/projects")
 	}
 	fmt.Println()
-	fmt.Printf("Workspace directory [%s]: ", defaultTarget)
-	userTarget := readInput()
-
-	finalTarget := defaultTarget
-	if userTarget != "" {
-		finalTarget = userTarget
-	}
+	finalTarget := setupInput("Workspace directory", defaultTarget)
 	// Expand ~ if present (Unix) or %USERPROFILE% (Windows)
 	if strings.HasPrefix(finalTarget, "~/") {
 		finalTarget = filepath.Join(home, finalTarget[2:])
@@ -579,8 +633,7 @@ func phaseWorkspace(cfg *SetupConfig) error {
 	// Create directory if needed
 	if _, err := os.Stat(finalTarget); os.IsNotExist(err) {
 		fmt.Println()
-		fmt.Print("Directory doesn't exist. Create it? [Y/n]: ")
-		if input := readInput(); input == "" || strings.EqualFold(input, "y") {
+		if setupConfirm("Directory doesn't exist. Create it?", true) {
 			if err := os.MkdirAll(finalTarget, 0755); err != nil {
 				return fmt.Errorf("failed to create directory: %w", err)
 			}
@@ -594,8 +647,7 @@ func phaseWorkspace(cfg *SetupConfig) error {
 		if currentLink != finalTarget {
 			fmt.Println()
 			fmt.Printf("Current %s → %s\n", symlinkPath, currentLink)
-			fmt.Printf("Update symlink to → %s? [Y/n]: ", finalTarget)
-			if input := readInput(); input == "" || strings.EqualFold(input, "y") {
+			if setupConfirm(fmt.Sprintf("Update symlink to → %s?", finalTarget), true) {
 				if err := createWorkspaceSymlink(symlinkPath, finalTarget); err != nil {
 					fmt.Printf("%s Failed to update symlink: %v\n", yellow("!"), err)
 				} else {
@@ -607,8 +659,7 @@ func phaseWorkspace(cfg *SetupConfig) error {
 		}
 	} else if _, err := os.Stat(symlinkPath); os.IsNotExist(err) {
 		fmt.Println()
-		fmt.Printf("Create %s symlink to %s? [Y/n]: ", symlinkPath, finalTarget)
-		if input := readInput(); input == "" || strings.EqualFold(input, "y") {
+		if setupConfirm(fmt.Sprintf("Create %s symlink to %s?", symlinkPath, finalTarget), true) {
 			if err := createWorkspaceSymlink(symlinkPath, finalTarget); err != nil {
 				fmt.Printf("%s Failed to create symlink: %v\n", yellow("!"), err)
 				if isWindows() {
@@ -661,48 +712,30 @@ func phaseSymlinks(cfg *SetupConfig) error {
 		return nil
 	}
 
-	blackdotDir := BlackdotDir()
-	home, _ := os.UserHomeDir()
 	fmt.Println("This will link your shell configuration files.")
 	fmt.Println()
 	fmt.Println("Files to link:")
 
-	if isWindows() {
-		// Windows: PowerShell profile
-		psProfileDir := filepath.Join(home, "Documents", "PowerShell")
-		fmt.Printf("  %s\\profile.ps1 → %s\\powershell\\profile.ps1\n", psProfileDir, blackdotDir)
-	} else {
-		// Unix: ZSH config
-		fmt.Printf("  ~/.zshrc     → %s/zsh/zshrc\n", blackdotDir)
-		fmt.Printf("  ~/.p10k.zsh  → %s/zsh/p10k.zsh\n", blackdotDir)
+	// Everything declared in links.yaml for this platform
+	resolved, err := declaredLinks()
+	if err != nil {
+		return err
+	}
+	for _, r := range resolved {
+		fmt.Printf("  %s → %s\n", tildePath(r.Target), tildePath(r.Source))
 	}
 	fmt.Println()
 
-	fmt.Print("Create symlinks? [Y/n]: ")
-	if input := readInput(); strings.EqualFold(input, "n") {
+	if !setupConfirm("Create symlinks?", true) {
 		fmt.Printf("%s Skipped symlinks\n", yellow("!"))
 		return nil
 	}
 
-	if isWindows() {
-		// Windows: Create PowerShell profile symlink
-		if err := createWindowsSymlinks(blackdotDir, home); err != nil {
-			fmt.Printf("%s Failed to create symlinks: %v\n", yellow("!"), err)
-			return err
-		}
-	} else {
-		// Unix: Run bootstrap script
-		bootstrapScript := filepath.Join(blackdotDir, "bootstrap", "bootstrap-blackdot.sh")
-		if _, err := os.Stat(bootstrapScript); err != nil {
-			return fmt.Errorf("bootstrap-blackdot.sh not found")
-		}
-
-		cmd := exec.Command("bash", bootstrapScript)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("bootstrap failed: %w", err)
-		}
+	// On Windows without Developer Mode, links fall back to junctions,
+	// hard links or copies
+	if err := runLinksApply(false, ""); err != nil {
+		fmt.Printf("%s Failed to create symlinks: %v\n", yellow("!"), err)
+		return err
 	}
 
 	markPhaseComplete(cfg, "symlinks")
@@ -710,51 +743,6 @@ func phaseSymlinks(cfg *SetupConfig) error {
 	return nil
 }
 
-// createWindowsSymlinks creates shell config symlinks on Windows
-func createWindowsSymlinks(blackdotDir, home string) error {
-	green := color.New(color.FgGreen).SprintFunc()
-
-	// Create PowerShell profile directory if needed
-	psProfileDir := filepath.Join(home, "Documents", "PowerShell")
-	if err := os.MkdirAll(psProfileDir, 0755); err != nil {
-		return fmt.Errorf("failed to create PowerShell directory: %w", err)
-	}
-
-	// Source and target for profile
-	source := filepath.Join(blackdotDir, "powershell", "profile.ps1")
-	target := filepath.Join(psProfileDir, "profile.ps1")
-
-	// Check if source exists
-	if _, err := os.Stat(source); os.IsNotExist(err) {
-		fmt.Printf("  PowerShell profile source not found: %s\n", source)
-		fmt.Println("  Skipping PowerShell profile (you can create it later)")
-		return nil
-	}
-
-	// Remove existing target if it exists
-	if _, err := os.Lstat(target); err == nil {
-		os.Remove(target)
-	}
-
-	// Create symlink (try mklink first, fall back to copy)
-	cmd := exec.Command("cmd", "/c", "mklink", target, source)
-	if err := cmd.Run(); err != nil {
-		// Symlink failed, try copying instead
-		data, err := os.ReadFile(source)
-		if err != nil {
-			return fmt.Errorf("failed to read source: %w", err)
-		}
-		if err := os.WriteFile(target, data, 0644); err != nil {
-			return fmt.Errorf("failed to copy profile: %w", err)
-		}
-		fmt.Printf("%s Copied profile.ps1 (symlink requires admin)\n", green("✓"))
-	} else {
-		fmt.Printf("%s Created symlink: profile.ps1\n", green("✓"))
-	}
-
-	return nil
-}
-
 func phasePackages(cfg *SetupConfig) error {
 	showProgress(3, 7, "Packages")
 
@@ -827,11 +815,7 @@ func phasePackages(cfg *SetupConfig) error {
 		fmt.Println()
 		fmt.Printf("%s\n", dim("Tip: You can always add more packages later with 'brew install <package>'"))
 		fmt.Println()
-		fmt.Print("Your choice [2]: ")
-		choice := readInput()
-		if choice == "" {
-			choice = "2"
-		}
+		choice := setupInput("Your choice", "2")
 
 		switch choice {
 		case "1":
@@ -878,8 +862,7 @@ func phasePackages(cfg *SetupConfig) error {
 	}
 
 	fmt.Printf("This will install %d packages (%s).\n", packageCount, timeEstimate)
-	fmt.Print("Install packages? [Y/n]: ")
-	if input := readInput(); strings.EqualFold(input, "n") {
+	if !setupConfirm("Install packages?", true) {
 		fmt.Printf("%s Skipped packages\n", yellow("!"))
 		return nil
 	}
@@ -926,8 +909,7 @@ func phasePackagesWindows(cfg *SetupConfig, blackdotDir string, green, yellow, b
 		return nil
 	}
 
-	fmt.Print("Install packages from winget.json? [Y/n]: ")
-	if input := readInput(); strings.EqualFold(input, "n") {
+	if !setupConfirm("Install packages from winget.json?", true) {
 		fmt.Printf("%s Skipped packages\n", yellow("!"))
 		return nil
 	}
@@ -964,8 +946,7 @@ func phaseVault(cfg *SetupConfig) error {
 		}
 
 		fmt.Println()
-		fmt.Print("Reconfigure vault? [y/N]: ")
-		if !strings.EqualFold(readInput(), "y") {
+		if !setupConfirm("Reconfigure vault?", false) {
 			if cfg.Vault.Backend == "none" {
 				fmt.Println("Run 'blackdot vault init' anytime to configure vault")
 			}
@@ -984,6 +965,15 @@ func phaseVault(cfg *SetupConfig) error {
 	if _, err := exec.LookPath("pass"); err == nil {
 		available = append(available, "pass")
 	}
+	if keychainAvailable() {
+		available = append(available, "keychain")
+	}
+	if dopplerAvailable() {
+		available = append(available, "doppler")
+	}
+	if awsSecretsAvailable() {
+		available = append(available, "awssecrets")
+	}
 
 	if len(available) == 0 {
 		fmt.Println("No vault CLI detected. Vault features are optional.")
@@ -993,8 +983,7 @@ func phaseVault(cfg *SetupConfig) error {
 		fmt.Println("  • 1Password:  brew install 1password-cli")
 		fmt.Println("  • pass:       brew install pass")
 		fmt.Println()
-		fmt.Print("Skip vault setup? [Y/n]: ")
-		if input := readInput(); input == "" || strings.EqualFold(input, "y") {
+		if setupConfirm("Skip vault setup?", true) {
 			fmt.Printf("%s Skipped vault setup\n", yellow("!"))
 			cfg.Vault.Backend = "none"
 			markPhaseComplete(cfg, "vault")
@@ -1006,17 +995,21 @@ func phaseVault(cfg *SetupConfig) error {
 		return fmt.Errorf("no vault CLI available")
 	}
 
+	// A backend already in config (e.g. from 'blackdot pair import') is
+	// the default choice
+	defaultChoice := 1
 	fmt.Println("Available vault backends:")
 	for i, backend := range available {
+		if backend == cfg.Vault.Backend {
+			defaultChoice = i + 1
+			fmt.Printf("  %d) %s (configured)\n", i+1, backend)
+			continue
+		}
 		fmt.Printf("  %d) %s\n", i+1, backend)
 	}
 	fmt.Printf("  %d) Skip (configure secrets manually)\n", len(available)+1)
 
-	fmt.Print("Select vault backend [1]: ")
-	choice := readInput()
-	if choice == "" {
-		choice = "1"
-	}
+	choice := setupInput("Select vault backend", fmt.Sprint(defaultChoice))
 
 	var choiceNum int
 	fmt.Sscanf(choice, "%d", &choiceNum)
@@ -1068,8 +1061,7 @@ func phaseVault(cfg *SetupConfig) error {
 			fmt.Println("Please customize your vault items configuration.")
 			fmt.Println("Edit the file to match your vault item names and paths.")
 			fmt.Println()
-			fmt.Print("Open editor now? [Y/n]: ")
-			if input := readInput(); input == "" || strings.EqualFold(input, "y") {
+			if setupConfirm("Open editor now?", true) {
 				editor := os.Getenv("EDITOR")
 				if editor == "" {
 					editor = "vim"
@@ -1099,6 +1091,11 @@ func phaseVault(cfg *SetupConfig) error {
 		fmt.Println("  eval $(op signin)")
 	case "pass":
 		fmt.Println("Ensure GPG agent is running for pass")
+	case "keychain":
+		fmt.Printf("Secrets are stored in %s, unlocked with your login\n", keychain.StoreName())
+	case "doppler", "awssecrets":
+		fmt.Println("To authenticate:")
+		fmt.Println("  " + cloudBackendHint(vaultmux.BackendType(selected)))
 	}
 
 	markPhaseComplete(cfg, "vault")
@@ -1134,31 +1131,22 @@ func phaseSecrets(cfg *SetupConfig) error {
 	fmt.Println("  3) Pull secrets from vault")
 	fmt.Println("  4) Skip for now")
 	fmt.Println()
-	fmt.Print("Select action [4]: ")
-	choice := readInput()
-	if choice == "" {
-		choice = "4"
-	}
+	choice := setupInput("Select action", "4")
 
 	switch choice {
 	case "1":
 		// Run drift check using Go CLI
-		home, _ := os.UserHomeDir()
-		green := color.New(color.FgGreen).SprintFunc()
-		yellow := color.New(color.FgYellow).SprintFunc()
-		cyan := color.New(color.FgCyan).SprintFunc()
-		dim := color.New(color.Faint).SprintFunc()
-		runDriftFull(home, green, yellow, cyan, dim)
+		runDrift(driftOptions{})
 	case "2":
 		// Push to vault using Go implementation
 		fmt.Println("Pushing secrets to vault...")
-		if err := vaultPush(nil, false, false, true); err != nil {
+		if err := vaultPush(vaultPushOptions{All: true}); err != nil {
 			fmt.Printf("%s Push failed: %v\n", yellow("!"), err)
 		}
 	case "3":
 		// Pull from vault using Go implementation
 		fmt.Println("Restoring secrets from vault...")
-		if err := vaultRestore(true, false); err != nil {
+		if err := vaultRestore(vaultRestoreOptions{Force: true}); err != nil {
 			fmt.Printf("%s Restore failed: %v\n", yellow("!"), err)
 		}
 	default:
@@ -1189,33 +1177,36 @@ func phaseClaude(cfg *SetupConfig) error {
 		return nil
 	}
 
-	// Check if dotclaude is installed
-	if _, err := exec.LookPath("dotclaude"); err == nil {
-		fmt.Printf("%s dotclaude already installed\n", green("✓"))
-		markPhaseComplete(cfg, "claude")
-		cfg.Features["claude_integration"] = true
-		cfg.Features["dotclaude"] = true
-		return nil
-	}
-
-	fmt.Println("Claude Code detected. dotclaude helps manage profiles across machines.")
-	fmt.Print("Install dotclaude? [Y/n]: ")
+	cfg.Features["claude_integration"] = true
+	fmt.Printf("%s Claude Code detected\n", green("✓"))
 
-	if input := readInput(); input == "" || strings.EqualFold(input, "y") {
-		fmt.Println("Installing dotclaude...")
-		cmd := exec.Command("bash", "-c", "curl -fsSL https://raw.githubusercontent.com/blackwell-systems/dotclaude/main/install.sh | bash")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("%s dotclaude installation failed - continuing\n", yellow("!"))
+	// Profiles come from the vault when it has them; otherwise offer to
+	// create the first one
+	if !fileExists(claudeProfilesPath()) && cfg.Vault.Backend != "" && cfg.Vault.Backend != "none" {
+		if setupConfirm("Restore Claude profiles from the vault ("+claudeProfilesItem+")?", true) {
+			if err := runClaudeSync(false, true, false); err != nil {
+				fmt.Printf("%s Claude profiles not restored - continuing\n", yellow("!"))
+			}
+		}
+	}
+	if !fileExists(claudeProfilesPath()) {
+		fmt.Println("Claude profiles switch backend and environment per context (work, personal).")
+		if setupConfirm("Create a Claude profile now?", false) {
+			backends := []string{"max", "bedrock"}
+			name, _ := prompts.Input("Profile name", "personal")
+			backend, _ := prompts.Select("Backend", backends, 0)
+			raw, _ := json.Marshal(claudeProfile{Backend: backends[backend], Created: time.Now().Format("2006-01-02")})
+			p := &claudeProfiles{Active: name, Profiles: map[string]json.RawMessage{name: raw}}
+			if !claudeProfileName.MatchString(name) {
+				fmt.Printf("%s Invalid profile name %q - skipped\n", yellow("!"), name)
+			} else if err := saveClaudeProfiles(p); err != nil {
+				fmt.Printf("%s Could not save profile: %v\n", yellow("!"), err)
+			} else {
+				fmt.Printf("%s Created Claude profile %s\n", green("✓"), name)
+			}
 		} else {
-			fmt.Printf("%s dotclaude installed\n", green("✓"))
-			cfg.Features["claude_integration"] = true
-			cfg.Features["dotclaude"] = true
+			fmt.Println("Create one later with: blackdot claude profiles create <name>")
 		}
-	} else {
-		fmt.Println("Skipped dotclaude installation")
-		fmt.Println("You can install later with: curl -fsSL https://dotclaude.sh | bash")
 	}
 
 	markPhaseComplete(cfg, "claude")
@@ -1238,9 +1229,7 @@ func phaseTemplate(cfg *SetupConfig) error {
 	fmt.Println("  • Work vs personal git email")
 	fmt.Println("  • Different SSH keys per machine")
 	fmt.Println("  • Machine-specific environment variables")
-	fmt.Print("Setup machine-specific config templates? [y/N]: ")
-
-	if strings.EqualFold(readInput(), "y") {
+	if setupConfirm("Setup machine-specific config templates?", false) {
 		fmt.Println("Initializing template system...")
 		fmt.Println()
 
@@ -1283,11 +1272,7 @@ func showPresetSelection(cfg *SetupConfig) {
 	fmt.Println("  4) full       - All features enabled")
 	fmt.Println("  5) Skip       - Configure features manually later")
 	fmt.Println()
-	fmt.Print("Select a preset [3]: ")
-	choice := readInput()
-	if choice == "" {
-		choice = "3"
-	}
+	choice := setupInput("Select a preset", "3")
 
 	presets := map[string][]string{
 		"minimal":   {"shell_basics", "aliases", "completions"},
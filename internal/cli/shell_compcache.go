@@ -0,0 +1,458 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// zshCompletionGenerators are the tools whose zsh completions blackdot
+// generates, and the arguments that print them. blackdot's own completion
+// ships in zsh/completions.
+var zshCompletionGenerators = map[string][]string{
+	"docker":        {"completion", "zsh"},
+	"gh":            {"completion", "-s", "zsh"},
+	"golangci-lint": {"completion", "zsh"},
+	"helm":          {"completion", "zsh"},
+	"k9s":           {"completion", "zsh"},
+	"kind":          {"completion", "zsh"},
+	"kubectl":       {"completion", "zsh"},
+	"poetry":        {"completions", "zsh"},
+	"rustup":        {"completions", "zsh"},
+	"uv":            {"generate-shell-completion", "zsh"},
+}
+
+// zshCompletionManifest records the binary each generated completion came
+// from, so regeneration only runs tools that changed
+type zshCompletionManifest map[string]zshCompletionSource
+
+type zshCompletionSource struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// compDirIssue is an fpath directory compinit would refuse as insecure
+type compDirIssue struct {
+	Dir     string
+	Reason  string
+	Fixable bool // owned by the current user, so chmod can fix it
+}
+
+// zcompdumpPath is where compinit keeps its dump. 10-plugins.zsh exports
+// BLACKDOT_ZCOMPDUMP; the default matches the one it uses.
+func zcompdumpPath() string {
+	if p := os.Getenv("BLACKDOT_ZCOMPDUMP"); p != "" {
+		return p
+	}
+	return filepath.Join(paths.For(paths.StrategyXDG).Cache, "zcompdump")
+}
+
+// zshCompletionsDir holds completions generated for installed tools
+func zshCompletionsDir() string {
+	if p := os.Getenv("BLACKDOT_ZSH_COMPLETIONS"); p != "" {
+		return p
+	}
+	return filepath.Join(paths.For(paths.StrategyXDG).Data, "zsh-completions")
+}
+
+func newShellCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "shell",
+		Short: "Shell integration maintenance",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+	cmd.AddCommand(newCompcacheCmd(), newShellStartupCmd())
+	return cmd
+}
+
+func newCompcacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compcache",
+		Short: "Manage the zsh completion cache",
+		Long: `Manage zsh's completion dump and the completions blackdot generates.
+
+blackdot keeps the dump at ~/.cache/blackdot/zcompdump and runs compinit
+with -C (no security audit, no rescan) when the dump is less than a day
+old, which is most of the startup cost of the completion system. The
+dump is compiled to .zwc in the background.
+
+Commands:
+  status   Dump location, age, and generated completions (default)
+  reset    Delete the dump so the next shell rebuilds it
+  audit    Find fpath directories compinit reports as insecure (--fix)
+  regen    Regenerate completions for installed tools (kubectl, gh, ...)
+
+'blackdot packages --install' runs regen automatically.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCompcacheStatus()
+		},
+	}
+
+	var legacy bool
+	resetCmd := &cobra.Command{
+		Use:     "reset",
+		Aliases: []string{"clear"},
+		Short:   "Delete the completion dump so the next shell rebuilds it",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			removed, err := resetZcompdump(legacy)
+			if err != nil {
+				Fail("%v", err)
+				return err
+			}
+			if len(removed) == 0 {
+				Info("No completion dump to remove")
+				return nil
+			}
+			for _, p := range removed {
+				Pass("Removed %s", tildePath(p))
+			}
+			Info("Open a new shell to rebuild it")
+			return nil
+		},
+	}
+	resetCmd.Flags().BoolVar(&legacy, "legacy", false, "Also remove ~/.zcompdump* files from before blackdot managed the dump")
+
+	var fix bool
+	var fpathDirs []string
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Find insecure completion directories (compaudit)",
+		Long: `Check fpath the way compaudit does: a directory, or its parent, that is
+group- or world-writable or owned by someone other than you or root
+makes compinit warn on every new shell.
+
+fpath is read from an interactive zsh unless --fpath is given. With
+--fix, write permission for group and others is removed from
+directories you own; others are listed with the command to fix them.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCompcacheAudit(fpathDirs, fix)
+		},
+	}
+	auditCmd.Flags().BoolVar(&fix, "fix", false, "chmod g-w,o-w directories you own")
+	auditCmd.Flags().StringArrayVar(&fpathDirs, "fpath", nil, "Directory to check instead of zsh's fpath (repeatable)")
+
+	var regenForce bool
+	regenCmd := &cobra.Command{
+		Use:   "regen",
+		Short: "Regenerate completions for installed tools",
+		Long: `Generate zsh completions for installed tools that print their own
+(` + strings.Join(sortedKeys(zshCompletionGenerators), ", ") + `)
+into ~/.local/share/blackdot/zsh-completions. Only tools whose binary
+changed since the last run are regenerated unless --force is given;
+completions for tools no longer installed are removed. The dump is reset
+when anything changed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := regenZshCompletions(regenForce, false)
+			return err
+		},
+	}
+	regenCmd.Flags().BoolVarP(&regenForce, "force", "f", false, "Regenerate every tool")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show the dump and generated completions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCompcacheStatus()
+		},
+	}, resetCmd, auditCmd, regenCmd)
+	return cmd
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func runCompcacheStatus() error {
+	PrintHeader("Zsh Completion Cache")
+
+	dump := zcompdumpPath()
+	if info, err := os.Stat(dump); err == nil {
+		detail := fmt.Sprintf("%s, built %s", formatSize(info.Size()), formatTimeAgo(info.ModTime().UTC().Format(time.RFC3339)))
+		if zwc, err := os.Stat(dump + ".zwc"); err == nil && !zwc.ModTime().Before(info.ModTime()) {
+			detail += ", compiled"
+		}
+		Pass("Dump: %s (%s)", tildePath(dump), detail)
+		if time.Since(info.ModTime()) > 24*time.Hour {
+			Dim.Println("  Older than a day: the next shell runs a full compinit and refreshes it")
+		}
+	} else {
+		Warn("Dump: %s not built yet (open a new zsh)", tildePath(dump))
+	}
+
+	manifest := loadZshCompletionManifest()
+	if len(manifest) > 0 {
+		Pass("Generated completions: %s (%s)", tildePath(zshCompletionsDir()), strings.Join(sortedKeys(manifest), ", "))
+	} else {
+		Info("No generated completions (run: blackdot shell compcache regen)")
+	}
+
+	if legacy := legacyZcompdumps(); len(legacy) > 0 {
+		Warn("%d stale ~/.zcompdump file(s) from before blackdot managed the dump", len(legacy))
+		Dim.Println("  Remove them with: blackdot shell compcache reset --legacy")
+	}
+	fmt.Println()
+	Dim.Println("Check for insecure completion directories: blackdot shell compcache audit")
+	return nil
+}
+
+// legacyZcompdumps are dumps compinit wrote to their default location
+func legacyZcompdumps() []string {
+	home, _ := os.UserHomeDir()
+	matches, _ := filepath.Glob(filepath.Join(home, ".zcompdump*"))
+	return matches
+}
+
+// resetZcompdump removes the dump and its compiled form, and the legacy
+// dumps when asked
+func resetZcompdump(legacy bool) ([]string, error) {
+	candidates := []string{zcompdumpPath(), zcompdumpPath() + ".zwc"}
+	if legacy {
+		candidates = append(candidates, legacyZcompdumps()...)
+	}
+	var removed []string
+	for _, p := range candidates {
+		if err := os.Remove(p); err == nil {
+			removed = append(removed, p)
+		} else if !os.IsNotExist(err) {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+func runCompcacheAudit(dirs []string, fix bool) error {
+	if len(dirs) == 0 {
+		var err error
+		if dirs, err = zshFpath(); err != nil {
+			Fail("Cannot read fpath from zsh: %v", err)
+			Info("Pass the directories with --fpath")
+			return err
+		}
+	}
+
+	issues := auditCompletionDirs(dirs, os.Geteuid())
+	if len(issues) == 0 {
+		Pass("No insecure completion directories (%d checked)", len(dirs))
+		return nil
+	}
+
+	var unfixed []compDirIssue
+	for _, issue := range issues {
+		if fix && issue.Fixable {
+			if err := fixCompletionDir(issue.Dir); err != nil {
+				Fail("%s: %v", tildePath(issue.Dir), err)
+				unfixed = append(unfixed, issue)
+				continue
+			}
+			Pass("Fixed %s (%s)", tildePath(issue.Dir), issue.Reason)
+			continue
+		}
+		Warn("%s: %s", tildePath(issue.Dir), issue.Reason)
+		unfixed = append(unfixed, issue)
+	}
+	if len(unfixed) == 0 {
+		resetZcompdump(false)
+		Info("Open a new shell; compinit will no longer warn")
+		return nil
+	}
+
+	fmt.Println()
+	for _, issue := range unfixed {
+		if issue.Fixable {
+			fmt.Printf("  chmod g-w,o-w %s\n", shellQuote(issue.Dir))
+		} else {
+			fmt.Printf("  sudo chown root %s && sudo chmod g-w,o-w %s\n", shellQuote(issue.Dir), shellQuote(issue.Dir))
+		}
+	}
+	if !fix {
+		Dim.Println("Run with --fix to fix the directories you own")
+	}
+	return fmt.Errorf("%d insecure completion director(ies)", len(unfixed))
+}
+
+// zshFpath reads fpath from an interactive zsh, so rc-file additions are
+// included. A marker separates it from anything the rc files print.
+func zshFpath() ([]string, error) {
+	if _, err := exec.LookPath("zsh"); err != nil {
+		return nil, fmt.Errorf("zsh not found")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	const marker = "--blackdot-fpath--"
+	out, err := exec.CommandContext(ctx, "zsh", "-i", "-c", "print -r -- "+marker+"; print -rl -- $fpath").Output()
+	if err != nil {
+		return nil, err
+	}
+	_, list, ok := bytes.Cut(out, []byte(marker+"\n"))
+	if !ok {
+		return nil, fmt.Errorf("unexpected zsh output")
+	}
+	return strings.Fields(string(list)), nil
+}
+
+// auditCompletionDirs applies compaudit's rules to dirs and their parents:
+// each must be owned by root or uid and not writable by group or others
+func auditCompletionDirs(dirs []string, uid int) []compDirIssue {
+	var issues []compDirIssue
+	seen := make(map[string]bool)
+	check := func(dir string) {
+		if seen[dir] {
+			return
+		}
+		seen[dir] = true
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			return
+		}
+		owner, hasOwner := fileOwnerUID(info)
+		var reasons []string
+		if hasOwner && owner != 0 && owner != uid {
+			reasons = append(reasons, fmt.Sprintf("owned by uid %d", owner))
+		}
+		switch mode := info.Mode().Perm(); {
+		case mode&0022 == 0022:
+			reasons = append(reasons, "group and world writable")
+		case mode&0002 != 0:
+			reasons = append(reasons, "world writable")
+		case mode&0020 != 0:
+			reasons = append(reasons, "group writable")
+		}
+		if len(reasons) > 0 {
+			issues = append(issues, compDirIssue{
+				Dir:     dir,
+				Reason:  strings.Join(reasons, ", "),
+				Fixable: !hasOwner || owner == uid,
+			})
+		}
+	}
+	for _, dir := range dirs {
+		check(dir)
+		check(filepath.Dir(dir))
+	}
+	return issues
+}
+
+func fixCompletionDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dir, info.Mode().Perm()&^0022)
+}
+
+func loadZshCompletionManifest() zshCompletionManifest {
+	manifest := make(zshCompletionManifest)
+	data, err := os.ReadFile(filepath.Join(zshCompletionsDir(), ".manifest.json"))
+	if err == nil {
+		json.Unmarshal(data, &manifest)
+	}
+	return manifest
+}
+
+// regenZshCompletions regenerates completions for installed tools whose
+// binary changed and resets the dump when any changed. quiet reports only
+// changes, for callers like packages --install.
+func regenZshCompletions(force, quiet bool) (changed int, err error) {
+	dir := zshCompletionsDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		Fail("Cannot create %s: %v", tildePath(dir), err)
+		return 0, err
+	}
+	manifest := loadZshCompletionManifest()
+	next := make(zshCompletionManifest)
+
+	for _, tool := range sortedKeys(zshCompletionGenerators) {
+		target := filepath.Join(dir, "_"+tool)
+		bin, err := exec.LookPath(tool)
+		if err != nil {
+			if _, had := manifest[tool]; had {
+				os.Remove(target)
+				changed++
+				if !quiet {
+					Info("Removed %s completions (no longer installed)", tool)
+				}
+			}
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(bin); err == nil {
+			bin = resolved
+		}
+		info, err := os.Stat(bin)
+		if err != nil {
+			continue
+		}
+		src := zshCompletionSource{Path: bin, Size: info.Size(), ModTime: info.ModTime().UTC()}
+		if prev, ok := manifest[tool]; ok && !force && prev == src {
+			if _, err := os.Stat(target); err == nil {
+				next[tool] = src
+				continue
+			}
+		}
+
+		script, err := generateZshCompletion(tool)
+		if err != nil {
+			Warn("%s: %v", tool, err)
+			continue
+		}
+		if err := writeFileAtomic(target, script, 0644); err != nil {
+			Fail("Failed to write %s: %v", tildePath(target), err)
+			return changed, err
+		}
+		next[tool] = src
+		changed++
+		Pass("Generated %s completions", tool)
+	}
+
+	data, _ := json.MarshalIndent(next, "", "  ")
+	if err := writeFileAtomic(filepath.Join(dir, ".manifest.json"), data, 0644); err != nil {
+		return changed, err
+	}
+	if changed > 0 {
+		resetZcompdump(false)
+		Info("Completion dump reset; new shells pick up the changes")
+	} else if !quiet {
+		Pass("Completions up to date (%d tool(s))", len(next))
+	}
+	return changed, nil
+}
+
+// generateZshCompletion runs a tool's completion generator and checks it
+// printed a zsh completion
+func generateZshCompletion(tool string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, tool, zshCompletionGenerators[tool]...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("completion generator failed: %w", err)
+	}
+	head := out
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	if !bytes.Contains(head, []byte("#compdef")) {
+		return nil, fmt.Errorf("generator did not print a zsh completion")
+	}
+	return out, nil
+}
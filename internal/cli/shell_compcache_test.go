@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestAuditCompletionDirs verifies writable directories and writable
+// parents are reported, and --fix removes the write bits
+func TestAuditCompletionDirs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX permissions not enforced on Windows")
+	}
+	base := t.TempDir()
+	os.Chmod(base, 0755)
+	secure := filepath.Join(base, "secure")
+	open := filepath.Join(base, "open")
+	parent := filepath.Join(base, "shared")
+	child := filepath.Join(parent, "functions")
+	for _, dir := range []string{secure, open, child} {
+		os.MkdirAll(dir, 0755)
+	}
+	os.Chmod(open, 0777)
+	os.Chmod(parent, 0775)
+
+	issues := auditCompletionDirs([]string{secure, open, child, filepath.Join(base, "missing")}, os.Geteuid())
+	got := make(map[string]string)
+	for _, issue := range issues {
+		got[issue.Dir] = issue.Reason
+		if !issue.Fixable {
+			t.Errorf("%s should be fixable", issue.Dir)
+		}
+	}
+	if len(got) != 2 || got[open] != "group and world writable" || got[parent] != "group writable" {
+		t.Fatalf("issues = %v", got)
+	}
+
+	if err := runCompcacheAudit([]string{open, child}, true); err != nil {
+		t.Fatal(err)
+	}
+	if issues := auditCompletionDirs([]string{open, child}, os.Geteuid()); len(issues) != 0 {
+		t.Errorf("still insecure after --fix: %v", issues)
+	}
+}
+
+// TestRegenZshCompletions verifies completions are generated for tools on
+// PATH, skipped when unchanged, removed when the tool is gone, and that
+// changes reset the dump
+func TestRegenZshCompletions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script stands in for the tool")
+	}
+	setScheduleEnv(t, "")
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	t.Setenv("BLACKDOT_ZSH_COMPLETIONS", filepath.Join(t.TempDir(), "completions"))
+	dump := filepath.Join(t.TempDir(), "zcompdump")
+	t.Setenv("BLACKDOT_ZCOMPDUMP", dump)
+
+	tool := filepath.Join(bin, "kubectl")
+	os.WriteFile(tool, []byte("#!/bin/sh\necho '#compdef kubectl'\necho \"args: $*\"\n"), 0755)
+	os.WriteFile(filepath.Join(bin, "helm"), []byte("#!/bin/sh\necho 'not a completion'\n"), 0755)
+	os.WriteFile(dump, []byte("dump"), 0644)
+
+	changed, err := regenZshCompletions(false, true)
+	if err != nil || changed != 1 {
+		t.Fatalf("regen = %d, %v", changed, err)
+	}
+	data, err := os.ReadFile(filepath.Join(zshCompletionsDir(), "_kubectl"))
+	if err != nil || !strings.Contains(string(data), "args: completion zsh") {
+		t.Fatalf("_kubectl = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(zshCompletionsDir(), "_helm")); !os.IsNotExist(err) {
+		t.Error("invalid helm output written")
+	}
+	if _, err := os.Stat(dump); !os.IsNotExist(err) {
+		t.Error("dump not reset after completions changed")
+	}
+
+	if changed, _ := regenZshCompletions(false, true); changed != 0 {
+		t.Errorf("unchanged tool regenerated (%d)", changed)
+	}
+	if changed, _ := regenZshCompletions(true, true); changed != 1 {
+		t.Errorf("--force regenerated %d tools", changed)
+	}
+
+	os.Remove(tool)
+	if changed, _ := regenZshCompletions(false, true); changed != 1 {
+		t.Errorf("removal counted %d changes", changed)
+	}
+	if _, err := os.Stat(filepath.Join(zshCompletionsDir(), "_kubectl")); !os.IsNotExist(err) {
+		t.Error("completion for removed tool kept")
+	}
+}
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Fish does not read load-env.sh, so blackdot mirrors the managed
+// environment into fish universal variables instead. Universal variables
+// persist across sessions, which means stale ones must be erased: the names
+// blackdot exported last time are kept in __blackdot_exported.
+
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// fishEnv is the environment blackdot manages for fish
+type fishEnv struct {
+	Vars     [][2]string // env.secrets, in file order
+	Paths    []string    // directories for fish_user_paths
+	Features []string    // enabled features, sorted
+}
+
+// envSecretsPath returns ~/.local/env.secrets
+func envSecretsPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "env.secrets")
+}
+
+// parseEnvSecrets reads KEY=value lines as load-env.sh does. An "export "
+// prefix and matching surrounding quotes are removed.
+func parseEnvSecrets(path string) ([][2]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseEnvSecretsReader(f)
+}
+
+// parseEnvSecretsReader parses env.secrets content
+func parseEnvSecretsReader(r io.Reader) ([][2]string, error) {
+	var vars [][2]string
+	seen := map[string]int{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !envVarName.MatchString(name) {
+			continue
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		// Later assignments win, as they would when sourced
+		if i, dup := seen[name]; dup {
+			vars[i][1] = value
+			continue
+		}
+		seen[name] = len(vars)
+		vars = append(vars, [2]string{name, value})
+	}
+	return vars, scanner.Err()
+}
+
+// collectFishEnv gathers env.secrets, blackdot's bin directory and the
+// enabled features. A missing env.secrets is not an error.
+func collectFishEnv() fishEnv {
+	var env fishEnv
+
+	if vars, err := parseEnvSecrets(envSecretsPath()); err == nil {
+		env.Vars = vars
+	}
+
+	if info, err := os.Stat(filepath.Join(BlackdotDir(), "bin")); err == nil && info.IsDir() {
+		env.Paths = append(env.Paths, filepath.Join(BlackdotDir(), "bin"))
+	}
+
+	reg := initRegistry()
+	for _, f := range reg.All() {
+		if reg.Enabled(f.Name) {
+			env.Features = append(env.Features, f.Name)
+		}
+	}
+	sort.Strings(env.Features)
+
+	return env
+}
+
+// fishQuote single-quotes a value for fish, which only treats \\ and \'
+// specially inside single quotes
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+// fishUniversalScript renders fish commands that set env as universal
+// variables, erase variables exported previously but no longer present,
+// and record a stamp of the exported state
+func fishUniversalScript(env fishEnv) string {
+	names := make([]string, 0, len(env.Vars))
+	for _, kv := range env.Vars {
+		names = append(names, kv[0])
+	}
+
+	var b strings.Builder
+	b.WriteString("for _blackdot_var in $__blackdot_exported\n")
+	if len(names) > 0 {
+		fmt.Fprintf(&b, "    contains -- $_blackdot_var %s; or set -eU $_blackdot_var\n", strings.Join(names, " "))
+	} else {
+		b.WriteString("    set -eU $_blackdot_var\n")
+	}
+	b.WriteString("end\nset -e _blackdot_var\n")
+
+	for _, kv := range env.Vars {
+		fmt.Fprintf(&b, "set -Ux %s %s\n", kv[0], fishQuote(kv[1]))
+	}
+	writeFishList(&b, "__blackdot_exported", names)
+
+	for _, p := range env.Paths {
+		fmt.Fprintf(&b, "contains -- %s $fish_user_paths; or set -Ua fish_user_paths %s\n", fishQuote(p), fishQuote(p))
+	}
+	writeFishList(&b, "blackdot_features", env.Features)
+
+	fmt.Fprintf(&b, "set -U __blackdot_env_stamp %s\n", fishEnvStamp(env))
+	return b.String()
+}
+
+func writeFishList(b *strings.Builder, name string, values []string) {
+	if len(values) == 0 {
+		fmt.Fprintf(b, "set -eU %s\n", name)
+		return
+	}
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fishQuote(v)
+	}
+	fmt.Fprintf(b, "set -U %s %s\n", name, strings.Join(quoted, " "))
+}
+
+// fishEnvStamp identifies an exported state so shell init can skip the
+// export when nothing changed
+func fishEnvStamp(env fishEnv) string {
+	h := sha256.New()
+	for _, kv := range env.Vars {
+		fmt.Fprintf(h, "v\x00%s\x00%s\x00", kv[0], kv[1])
+	}
+	for _, p := range env.Paths {
+		fmt.Fprintf(h, "p\x00%s\x00", p)
+	}
+	for _, f := range env.Features {
+		fmt.Fprintf(h, "f\x00%s\x00", f)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// fishUniversalEnabled reports whether the fish export is on. It is on by
+// default and disabled with shell.fish_universal=false.
+func fishUniversalEnabled() bool {
+	switch strings.ToLower(configLookup("shell.fish_universal")) {
+	case "false", "0", "off", "no":
+		return false
+	}
+	return true
+}
+
+// refreshFishUniversal updates fish universal variables after blackdot
+// changes the managed environment, so running fish sessions pick up the
+// change without restarting. It does nothing when fish is not installed.
+func refreshFishUniversal() {
+	if !fishUniversalEnabled() {
+		return
+	}
+	fishBin, err := exec.LookPath("fish")
+	if err != nil {
+		return
+	}
+
+	// The script goes through stdin so secrets never appear in argv
+	cmd := exec.Command(fishBin, "--no-config")
+	cmd.Stdin = strings.NewReader(fishUniversalScript(collectFishEnv()))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		Warn("Could not update fish universal variables: %v %s", err, strings.TrimSpace(string(out)))
+	}
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseEnvSecrets verifies comments, export prefixes, quotes and
+// duplicate assignments are handled as load-env.sh would
+func TestParseEnvSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env.secrets")
+	os.WriteFile(path, []byte("# comment\n\nexport API_TOKEN=\"abc def\"\nDB_URL=postgres://x\nbad-name=1\nAPI_TOKEN='override'\n"), 0600)
+
+	vars, err := parseEnvSecrets(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]string{{"API_TOKEN", "override"}, {"DB_URL", "postgres://x"}}
+	if len(vars) != len(want) {
+		t.Fatalf("vars = %v", vars)
+	}
+	for i := range want {
+		if vars[i] != want[i] {
+			t.Errorf("vars[%d] = %v, want %v", i, vars[i], want[i])
+		}
+	}
+}
+
+// TestFishUniversalScript verifies values are quoted for fish, stale
+// exports are erased and the stamp tracks the state
+func TestFishUniversalScript(t *testing.T) {
+	env := fishEnv{
+		Vars:     [][2]string{{"TOKEN", `it's a \ test`}},
+		Paths:    []string{"/opt/blackdot/bin"},
+		Features: []string{"vault"},
+	}
+	script := fishUniversalScript(env)
+
+	for _, want := range []string{
+		`set -Ux TOKEN 'it\'s a \\ test'`,
+		"contains -- $_blackdot_var TOKEN; or set -eU $_blackdot_var",
+		"set -U __blackdot_exported 'TOKEN'",
+		"or set -Ua fish_user_paths '/opt/blackdot/bin'",
+		"set -U blackdot_features 'vault'",
+		"set -U __blackdot_env_stamp " + fishEnvStamp(env),
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+
+	empty := fishUniversalScript(fishEnv{})
+	if !strings.Contains(empty, "    set -eU $_blackdot_var") || !strings.Contains(empty, "set -eU __blackdot_exported") {
+		t.Errorf("empty env should erase previous exports:\n%s", empty)
+	}
+
+	changed := env
+	changed.Vars = [][2]string{{"TOKEN", "rotated"}}
+	if fishEnvStamp(changed) == fishEnvStamp(env) {
+		t.Error("stamp should change with values")
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
 func newShellInitCmd() *cobra.Command {
+	var universal bool
+
 	cmd := &cobra.Command{
 		Use:   "shell-init [shell]",
 		Short: "Output shell initialization code",
@@ -30,6 +33,16 @@ Usage:
   # In config.fish
   blackdot shell-init fish | source
 
+Fish does not read ~/.local/load-env.sh. Instead, the fish init exports
+env.secrets, blackdot's bin directory (via fish_user_paths) and the list
+of enabled features (blackdot_features) as fish universal variables.
+They are refreshed when the managed state changes and after 'vault
+restore' and 'sync' pull env.secrets. Variables removed from env.secrets
+are erased. Disable with: blackdot config set shell.fish_universal false
+
+  # Print only the universal variable export
+  blackdot shell-init fish --universal
+
   # In PowerShell $PROFILE
   Invoke-Expression (blackdot shell-init powershell)`,
 		Args: cobra.MaximumNArgs(1),
@@ -43,6 +56,10 @@ Usage:
 			case "zsh", "bash":
 				return outputPosixInit()
 			case "fish":
+				if universal {
+					fmt.Print(fishUniversalScript(collectFishEnv()))
+					return nil
+				}
 				return outputFishInit()
 			case "powershell", "pwsh":
 				return outputPowerShellInit()
@@ -52,6 +69,7 @@ Usage:
 		},
 	}
 
+	cmd.Flags().BoolVar(&universal, "universal", false, "fish: print only the universal variable export")
 	return cmd
 }
 
@@ -194,6 +212,17 @@ function feature_exists --description "Check if a feature exists"
 end
 `, binaryPath)
 
+	// Mirror the managed environment into universal variables, skipping
+	// the work when the stamp shows nothing changed
+	if fishUniversalEnabled() {
+		env := collectFishEnv()
+		script += fmt.Sprintf(`
+# Managed environment (env.secrets, PATH, features) as universal variables
+if test "$__blackdot_env_stamp" != "%s"
+%send
+`, fishEnvStamp(env), indentLines(strings.TrimSuffix(fishUniversalScript(env), "\n"), "    ")+"\n")
+	}
+
 	fmt.Print(script)
 	return nil
 }
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// shellStartupKey names the single-flight result shared by new shells
+const shellStartupKey = "shell-startup"
+
+// shellStartupSummary is what a new shell reports: local drift against
+// the last vault pull, templates whose output is out of date, and how
+// many tool completions were regenerated
+type shellStartupSummary struct {
+	Drifted        []string `json:"drifted"`
+	StaleTemplates []string `json:"stale_templates"`
+	Completions    int      `json:"completions_regenerated"`
+}
+
+func newShellStartupCmd() *cobra.Command {
+	var maxAge time.Duration
+	var refresh, jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "startup",
+		Short: "Startup checks for new shells, shared between terminals",
+		Long: `Run the checks a new shell makes: a quick drift check (no vault
+access), out-of-date templates, and regeneration of tool completions.
+
+Opening many terminals at once does the work only once. One process
+refreshes the result under a lock in ~/.cache/blackdot/singleflight;
+the others print the previous result straight away, or wait for the
+first one when there is none yet. A result younger than --max-age is
+reused. 'vault pull' and 'sync' drop it.
+
+90-integrations.zsh runs this on every new shell. Set
+BLACKDOT_SKIP_DRIFT_CHECK=1 to skip it.
+
+Examples:
+  blackdot shell startup
+  blackdot shell startup --refresh     # Ignore the cached result
+  blackdot shell startup --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if refresh {
+				maxAge = 0
+			}
+			entry, err := singleFlight(shellStartupKey, maxAge, func() (interface{}, error) {
+				return collectShellStartup(), nil
+			})
+			if err != nil {
+				return err
+			}
+			if jsonOut {
+				fmt.Println(string(entry.Data))
+				return nil
+			}
+			var summary shellStartupSummary
+			if err := json.Unmarshal(entry.Data, &summary); err != nil {
+				return err
+			}
+			printShellStartup(&summary)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&maxAge, "max-age", 5*time.Minute, "Reuse a result up to this old")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Refresh even when the cached result is recent")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON")
+	return cmd
+}
+
+func collectShellStartup() *shellStartupSummary {
+	summary := &shellStartupSummary{Drifted: []string{}, StaleTemplates: []string{}}
+	if drift := collectStatusDrift(); drift != nil {
+		summary.Drifted = drift.Drifted
+	}
+	if templates := collectStatusTemplates(); templates != nil {
+		summary.StaleTemplates = templates.Stale
+	}
+	summary.Completions, _ = regenZshCompletions(false, true)
+	return summary
+}
+
+// printShellStartup prints only what needs attention, so a clean setup
+// starts silently
+func printShellStartup(summary *shellStartupSummary) {
+	if n := len(summary.Drifted); n > 0 {
+		Warn("%d item(s) changed since the last vault pull (%s): blackdot drift",
+			n, strings.Join(summary.Drifted, ", "))
+	}
+	if n := len(summary.StaleTemplates); n > 0 {
+		Warn("%d template(s) out of date (%s): blackdot template render",
+			n, strings.Join(summary.StaleTemplates, ", "))
+	}
+}
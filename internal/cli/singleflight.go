@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+)
+
+// Shell startup runs the same checks in every new terminal. When several
+// open at once, one process refreshes a result under a lock and the rest
+// read the cached copy instead of repeating the work.
+const (
+	// singleFlightStaleLock is how long a lock may be held before it is
+	// assumed to belong to a process that died
+	singleFlightStaleLock = 2 * time.Minute
+	// singleFlightWait is how long a process without any cached result
+	// waits for the refresh in progress
+	singleFlightWait = 5 * time.Second
+)
+
+// singleFlightEntry is the shared result file
+type singleFlightEntry struct {
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+func singleFlightDir() string {
+	return filepath.Join(paths.CacheDir(), "singleflight")
+}
+
+// singleFlight returns the cached result of refresh when it is younger
+// than maxAge. Otherwise one process runs refresh under a lock and caches
+// its result. Processes that find the lock taken return the previous
+// result, however old, or wait briefly for the first one. Only when
+// there is nothing to share does a process run refresh itself.
+func singleFlight(name string, maxAge time.Duration, refresh func() (interface{}, error)) (*singleFlightEntry, error) {
+	cachePath := filepath.Join(singleFlightDir(), name+".json")
+	lockPath := filepath.Join(singleFlightDir(), name+".lock")
+
+	cached := readSingleFlight(cachePath)
+	if cached != nil && time.Since(cached.Time) < maxAge {
+		return cached, nil
+	}
+
+	if !acquireSingleFlightLock(lockPath) {
+		if cached != nil {
+			return cached, nil
+		}
+		deadline := time.Now().Add(singleFlightWait)
+		for time.Now().Before(deadline) {
+			time.Sleep(50 * time.Millisecond)
+			if cached = readSingleFlight(cachePath); cached != nil {
+				return cached, nil
+			}
+		}
+		return runSingleFlight(refresh)
+	}
+	defer os.Remove(lockPath)
+
+	// Another process may have finished between the read and the lock
+	if latest := readSingleFlight(cachePath); latest != nil && time.Since(latest.Time) < maxAge {
+		return latest, nil
+	}
+	entry, err := runSingleFlight(refresh)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(entry)
+	if err := writeFileAtomic(cachePath, data, 0600); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func runSingleFlight(refresh func() (interface{}, error)) (*singleFlightEntry, error) {
+	result, err := refresh()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return &singleFlightEntry{Time: time.Now(), Data: data}, nil
+}
+
+func readSingleFlight(path string) *singleFlightEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entry singleFlightEntry
+	if json.Unmarshal(data, &entry) != nil || entry.Data == nil {
+		return nil
+	}
+	return &entry
+}
+
+// acquireSingleFlightLock creates the lock file, breaking a stale one
+func acquireSingleFlightLock(path string) bool {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return false
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return true
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return false
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue // released in the meantime
+		}
+		if time.Since(info.ModTime()) < singleFlightStaleLock {
+			return false
+		}
+		os.Remove(path)
+	}
+	return false
+}
+
+// clearSingleFlight drops a cached result so the next caller refreshes
+func clearSingleFlight(name string) {
+	os.Remove(filepath.Join(singleFlightDir(), name+".json"))
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestSingleFlight verifies concurrent callers share one refresh, a held
+// lock serves the previous result, and a stale lock is broken
+func TestSingleFlight(t *testing.T) {
+	setScheduleEnv(t, "")
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(os.Getenv("HOME"), ".cache"))
+
+	var runs int32
+	refresh := func() (interface{}, error) {
+		n := atomic.AddInt32(&runs, 1)
+		time.Sleep(100 * time.Millisecond)
+		return n, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entry, err := singleFlight("test", time.Minute, refresh)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results[i] = string(entry.Data)
+		}(i)
+	}
+	wg.Wait()
+	if runs != 1 {
+		t.Fatalf("refresh ran %d times", runs)
+	}
+	for i, r := range results {
+		if r != "1" {
+			t.Errorf("caller %d got %q", i, r)
+		}
+	}
+
+	// Expired, but another process holds the lock: the old result is
+	// returned without waiting
+	lock := filepath.Join(singleFlightDir(), "test.lock")
+	os.WriteFile(lock, []byte("1\n"), 0600)
+	entry, err := singleFlight("test", 0, refresh)
+	if err != nil || string(entry.Data) != "1" || runs != 1 {
+		t.Errorf("with lock held got %v %v after %d runs", entry, err, runs)
+	}
+
+	// A lock left by a dead process is taken over
+	old := time.Now().Add(-2 * singleFlightStaleLock)
+	os.Chtimes(lock, old, old)
+	entry, err = singleFlight("test", 0, refresh)
+	if err != nil || string(entry.Data) != "2" {
+		t.Errorf("with stale lock got %v %v", entry, err)
+	}
+	if _, err := os.Stat(lock); !os.IsNotExist(err) {
+		t.Error("lock not released")
+	}
+}
@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// windowsAgentPipe is the named pipe the Windows OpenSSH agent service
+// listens on. Windows ssh-add uses it when SSH_AUTH_SOCK is unset.
+const windowsAgentPipe = `\\.\pipe\openssh-ssh-agent`
+
+// windowsAgentService is the name of the Windows OpenSSH agent service
+const windowsAgentService = "ssh-agent"
+
+// errSSHAgentNotRunning is returned when there is no agent to talk to
+var errSSHAgentNotRunning = errors.New("ssh agent not running")
+
+// defaultSSHKeys are loaded by 'ssh load' without arguments, like ssh-add
+var defaultSSHKeys = []string{"id_rsa", "id_ecdsa", "id_ed25519"}
+
+// windowsServiceStatus is what sc.exe reports about a service
+type windowsServiceStatus struct {
+	Installed bool
+	State     string // RUNNING, STOPPED, START_PENDING...
+	StartType string // AUTO_START, DEMAND_START, DISABLED...
+}
+
+// sshAgentStatus describes how the agent is reached and whether it answers
+type sshAgentStatus struct {
+	Address   string // socket path or named pipe; empty when there is none
+	Reachable bool
+	Err       error
+	Service   *windowsServiceStatus // the Windows agent service; nil elsewhere
+}
+
+// sshAgentAddress is where the agent listens: SSH_AUTH_SOCK, or the
+// service's named pipe on Windows
+func sshAgentAddress() string {
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		return sock
+	}
+	if runtime.GOOS == "windows" {
+		return windowsAgentPipe
+	}
+	return ""
+}
+
+// dialSSHAgent connects to the agent over its socket or named pipe
+func dialSSHAgent() (io.ReadWriteCloser, error) {
+	addr := sshAgentAddress()
+	if addr == "" {
+		return nil, errSSHAgentNotRunning
+	}
+	if strings.HasPrefix(addr, `\\.\pipe\`) {
+		// A named pipe opens like a file; reads and writes are synchronous,
+		// which is all the agent protocol needs
+		f, err := os.OpenFile(addr, os.O_RDWR, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errSSHAgentNotRunning, err)
+		}
+		return f, nil
+	}
+	conn, err := net.Dial("unix", addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errSSHAgentNotRunning, err)
+	}
+	return conn, nil
+}
+
+// openSSHAgent returns an agent client; close releases the connection
+func openSSHAgent() (client agent.ExtendedAgent, close func(), err error) {
+	conn, err := dialSSHAgent()
+	if err != nil {
+		return nil, nil, err
+	}
+	return agent.NewClient(conn), func() { conn.Close() }, nil
+}
+
+// detectSSHAgent checks the agent, and on Windows the service behind it
+func detectSSHAgent() sshAgentStatus {
+	status := sshAgentStatus{Address: sshAgentAddress()}
+	if runtime.GOOS == "windows" {
+		svc := queryWindowsService(windowsAgentService)
+		status.Service = &svc
+	}
+	client, closeAgent, err := openSSHAgent()
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	defer closeAgent()
+	if _, err := client.List(); err != nil {
+		status.Err = err
+		return status
+	}
+	status.Reachable = true
+	return status
+}
+
+// queryWindowsService asks sc.exe for a service's state and start type
+func queryWindowsService(name string) windowsServiceStatus {
+	out, err := exec.Command("sc.exe", "query", name).CombinedOutput()
+	if err != nil {
+		// 1060: the service is not installed
+		return windowsServiceStatus{}
+	}
+	status := windowsServiceStatus{Installed: true, State: parseSCField(string(out), "STATE")}
+	if out, err := exec.Command("sc.exe", "qc", name).CombinedOutput(); err == nil {
+		status.StartType = parseSCField(string(out), "START_TYPE")
+	}
+	return status
+}
+
+// parseSCField reads a "FIELD : 4  DISABLED" line from sc.exe output and
+// returns the symbolic value
+func parseSCField(output, field string) string {
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) != field {
+			continue
+		}
+		if parts := strings.Fields(value); len(parts) >= 2 {
+			return parts[1]
+		}
+	}
+	return ""
+}
+
+// startWindowsSSHAgent enables the agent service if it is disabled and
+// starts it. Both need an elevated prompt.
+func startWindowsSSHAgent(svc windowsServiceStatus) error {
+	if !svc.Installed {
+		return fmt.Errorf("the %s service is not installed (Settings > Optional features > OpenSSH Client)", windowsAgentService)
+	}
+	steps := [][]string{}
+	if svc.StartType == "DISABLED" {
+		steps = append(steps, []string{"config", windowsAgentService, "start=", "auto"})
+	}
+	if svc.State != "RUNNING" {
+		steps = append(steps, []string{"start", windowsAgentService})
+	}
+	for _, args := range steps {
+		out, err := exec.Command("sc.exe", args...).CombinedOutput()
+		if err == nil {
+			continue
+		}
+		msg := strings.TrimSpace(string(out))
+		if strings.Contains(msg, "FAILED 5") || strings.Contains(strings.ToLower(msg), "access is denied") {
+			return fmt.Errorf("sc.exe %s: access denied (run from an Administrator prompt)", args[0])
+		}
+		return fmt.Errorf("sc.exe %s: %v: %s", args[0], err, msg)
+	}
+	return nil
+}
+
+// agentFixHint is how to get an agent running on this platform
+func agentFixHint() string {
+	if runtime.GOOS == "windows" {
+		return "blackdot tools ssh agent --start"
+	}
+	return `eval "$(ssh-agent -s)"`
+}
+
+// resolveSSHKeyPath finds a key given as a path, a file in ~/.ssh, or the
+// suffix of id_ed25519_<name> / id_rsa_<name>
+func resolveSSHKeyPath(key string) (string, bool) {
+	home, _ := os.UserHomeDir()
+	sshDir := filepath.Join(home, ".ssh")
+	for _, candidate := range []string{
+		expandPath(key),
+		filepath.Join(sshDir, key),
+		filepath.Join(sshDir, "id_ed25519_"+key),
+		filepath.Join(sshDir, "id_rsa_"+key),
+	} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// addSSHKeyToAgent loads a private key file into the agent, asking for
+// its passphrase on a terminal, with its certificate when one sits next
+// to it (<key>-cert.pub)
+func addSSHKeyToAgent(client agent.Agent, keyPath string) error {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return err
+	}
+	key, err := ssh.ParseRawPrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		if !stdinIsTerminal() {
+			return fmt.Errorf("%s needs a passphrase and there is no terminal to ask on", tildePath(keyPath))
+		}
+		passphrase, perr := prompts.Secret("Passphrase for " + tildePath(keyPath))
+		if perr != nil {
+			return perr
+		}
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(data, []byte(passphrase))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", tildePath(keyPath), err)
+	}
+
+	added := agent.AddedKey{PrivateKey: key, Comment: tildePath(keyPath)}
+	if pub, err := os.ReadFile(keyPath + ".pub"); err == nil {
+		if _, comment, _, _, err := ssh.ParseAuthorizedKey(pub); err == nil && comment != "" {
+			added.Comment = comment
+		}
+	}
+	if certData, err := os.ReadFile(keyPath + "-cert.pub"); err == nil {
+		if pub, _, _, _, err := ssh.ParseAuthorizedKey(certData); err == nil {
+			if cert, ok := pub.(*ssh.Certificate); ok {
+				added.Certificate = cert
+			}
+		}
+	}
+	if err := client.Add(added); err != nil {
+		return fmt.Errorf("adding %s: %w", tildePath(keyPath), err)
+	}
+	return nil
+}
+
+// sshKeyPublic returns the public half of a key file: from <key>.pub, or
+// from the private key (OpenSSH-format keys carry it unencrypted)
+func sshKeyPublic(keyPath string) (ssh.PublicKey, error) {
+	pubPath := keyPath
+	if !strings.HasSuffix(pubPath, ".pub") {
+		pubPath += ".pub"
+	}
+	if data, err := os.ReadFile(pubPath); err == nil {
+		pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+		return pub, err
+	}
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) && missing.PublicKey != nil {
+		return missing.PublicKey, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return signer.PublicKey(), nil
+}
+
+// printAgentKeys lists the agent's keys like ssh-add -l
+func printAgentKeys(client agent.Agent) error {
+	keys, err := client.List()
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		fmt.Println("  (no keys loaded)")
+		return nil
+	}
+	for _, k := range keys {
+		kind := strings.ToUpper(strings.TrimSuffix(strings.TrimPrefix(k.Type(), "ssh-"), "-v01@openssh.com"))
+		fmt.Printf("  %d %s %s (%s)\n", getKeyBits(k), ssh.FingerprintSHA256(k), k.Comment, kind)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestParseSCField(t *testing.T) {
+	query := `
+SERVICE_NAME: ssh-agent
+        TYPE               : 10  WIN32_OWN_PROCESS
+        STATE              : 1  STOPPED
+        WIN32_EXIT_CODE    : 1077  (0x435)
+`
+	qc := `
+[SC] QueryServiceConfig SUCCESS
+
+SERVICE_NAME: ssh-agent
+        TYPE               : 10  WIN32_OWN_PROCESS
+        START_TYPE         : 4   DISABLED
+        BINARY_PATH_NAME   : C:\Windows\System32\OpenSSH\ssh-agent.exe
+`
+	if got := parseSCField(query, "STATE"); got != "STOPPED" {
+		t.Errorf("STATE = %q", got)
+	}
+	if got := parseSCField(qc, "START_TYPE"); got != "DISABLED" {
+		t.Errorf("START_TYPE = %q", got)
+	}
+	if got := parseSCField(qc, "STATE"); got != "" {
+		t.Errorf("missing field = %q", got)
+	}
+}
+
+// serveTestAgent runs an in-memory agent on a unix socket and points
+// SSH_AUTH_SOCK at it
+func serveTestAgent(t *testing.T) agent.Agent {
+	t.Helper()
+	keyring := agent.NewKeyring()
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				agent.ServeAgent(keyring, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+	return keyring
+}
+
+// writeTestKey writes an unencrypted ed25519 key pair to ~/.ssh/<name>
+func writeTestKey(t *testing.T, home, name string) ssh.PublicKey {
+	t.Helper()
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(priv, "test@"+name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPub, _ := ssh.NewPublicKey(pub)
+	dir := filepath.Join(home, ".ssh")
+	os.MkdirAll(dir, 0700)
+	os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0600)
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub))) + " test@" + name + "\n"
+	os.WriteFile(filepath.Join(dir, name+".pub"), []byte(line), 0644)
+	return sshPub
+}
+
+// TestSSHAgentKeyManagement verifies load, unload and clear go through
+// the agent protocol rather than ssh-add
+func TestSSHAgentKeyManagement(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", "")
+	keyring := serveTestAgent(t)
+	github := writeTestKey(t, home, "id_ed25519_github")
+	writeTestKey(t, home, "id_ed25519")
+
+	if status := detectSSHAgent(); !status.Reachable {
+		t.Fatalf("agent not detected: %v", status.Err)
+	}
+
+	if err := sshLoadKey("github"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sshLoadDefault(); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ := keyring.List()
+	if len(keys) != 2 || keys[0].Comment != "test@id_ed25519_github" {
+		t.Fatalf("agent keys = %v", keys)
+	}
+
+	if err := sshUnloadKey("github"); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ = keyring.List()
+	for _, k := range keys {
+		if string(k.Marshal()) == string(github.Marshal()) {
+			t.Error("unloaded key still in the agent")
+		}
+	}
+
+	if err := sshClearAgent(); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ = keyring.List(); len(keys) != 0 {
+		t.Errorf("agent not cleared: %v", keys)
+	}
+}
+
+func TestDetectSSHAgentUnreachable(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", filepath.Join(t.TempDir(), "gone.sock"))
+	status := detectSSHAgent()
+	if status.Reachable || status.Err == nil {
+		t.Errorf("dangling socket reported reachable: %+v", status)
+	}
+	if err := sshClearAgent(); err == nil {
+		t.Error("clear succeeded without an agent")
+	}
+}
@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/template"
+)
+
+// sshIncludeMaxDepth matches the nesting limit of ssh itself
+const sshIncludeMaxDepth = 16
+
+// sshConfigHost is a Host alias and the file that declares it
+type sshConfigHost struct {
+	Name   string
+	Source string
+	Line   int
+}
+
+// sshConfigScan is the result of reading an ssh config and its includes
+type sshConfigScan struct {
+	Hosts    []sshConfigHost
+	Files    []string // every file read, in order
+	Problems []string // includes that could not be followed
+}
+
+// userSSHDir is ~/.ssh, which relative Include paths in the user config
+// are resolved against
+func userSSHDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ssh")
+}
+
+// scanSSHConfig reads an ssh config, following Include directives
+// recursively. Wildcard Host patterns are skipped. A file already being
+// read is not read again, so include cycles end instead of looping.
+func scanSSHConfig(path string) (*sshConfigScan, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	scan := &sshConfigScan{}
+	scan.read(path, userSSHDir(), make(map[string]bool), 0)
+	return scan, nil
+}
+
+func (s *sshConfigScan) read(path, baseDir string, reading map[string]bool, depth int) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	if reading[abs] {
+		s.Problems = append(s.Problems, fmt.Sprintf("%s: include cycle, skipped", tildePath(abs)))
+		return
+	}
+	if depth > sshIncludeMaxDepth {
+		s.Problems = append(s.Problems, fmt.Sprintf("%s: includes nested too deeply", tildePath(abs)))
+		return
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		s.Problems = append(s.Problems, fmt.Sprintf("%s: %v", tildePath(abs), err))
+		return
+	}
+	defer f.Close()
+
+	reading[abs] = true
+	defer delete(reading, abs)
+	s.Files = append(s.Files, abs)
+
+	lineNo := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNo++
+		keyword, args := splitSSHConfigLine(scanner.Text())
+		switch strings.ToLower(keyword) {
+		case "host":
+			for _, h := range args {
+				if !strings.ContainsAny(h, "*?!") {
+					s.Hosts = append(s.Hosts, sshConfigHost{Name: h, Source: abs, Line: lineNo})
+				}
+			}
+		case "include":
+			for _, pattern := range args {
+				for _, inc := range expandSSHInclude(pattern, baseDir) {
+					s.read(inc, baseDir, reading, depth+1)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		s.Problems = append(s.Problems, fmt.Sprintf("%s: %v", tildePath(abs), err))
+	}
+}
+
+// splitSSHConfigLine returns the keyword and arguments of a config line,
+// accepting both "Keyword value" and "Keyword=value"
+func splitSSHConfigLine(line string) (string, []string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil
+	}
+	i := strings.IndexAny(line, " \t=")
+	if i < 0 {
+		return line, nil
+	}
+	rest := strings.TrimPrefix(strings.TrimSpace(line[i:]), "=")
+	return line[:i], strings.Fields(rest)
+}
+
+// expandSSHInclude resolves an Include argument to the files it names.
+// Relative paths are relative to baseDir; globs are expanded and sorted
+// the way ssh does.
+func expandSSHInclude(pattern, baseDir string) []string {
+	pattern = expandPath(pattern)
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(baseDir, pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil
+	}
+	sort.Strings(matches)
+	var files []string
+	for _, m := range matches {
+		if info, err := os.Stat(m); err == nil && !info.IsDir() {
+			files = append(files, m)
+		}
+	}
+	return files
+}
+
+// uniqueHosts returns each host once, keeping the first declaration as ssh
+// does
+func (s *sshConfigScan) uniqueHosts() []sshConfigHost {
+	seen := make(map[string]bool)
+	var out []sshConfigHost
+	for _, h := range s.Hosts {
+		if !seen[h.Name] {
+			seen[h.Name] = true
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// sshHostsIncludeDir is where template render writes one ssh config file
+// per ssh_hosts category, included from the rendered ssh-config
+const sshHostsIncludeDir = "ssh-config.d"
+
+// sshHostsByCategory reads ssh_hosts (from _arrays.local.json or SSH_HOSTS
+// in the variables files), grouped by each entry's category ("hosts" when
+// unset)
+func sshHostsByCategory(cfg *templateConfig) (map[string][]map[string]interface{}, error) {
+	arrays, err := loadTemplateArrays(cfg)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string][]map[string]interface{})
+	for _, a := range arrays {
+		if a.Name != "ssh_hosts" {
+			continue
+		}
+		for _, host := range a.Items {
+			category, _ := host["category"].(string)
+			category = sanitizeSSHCategory(category)
+			groups[category] = append(groups[category], host)
+		}
+	}
+	return groups, nil
+}
+
+// sanitizeSSHCategory makes a category usable as a file name
+func sanitizeSSHCategory(category string) string {
+	category = strings.ToLower(strings.TrimSpace(category))
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '-'
+	}, category)
+	if strings.Trim(clean, "-") == "" {
+		return "hosts"
+	}
+	return clean
+}
+
+// renderSSHHostsFile renders the Host blocks of one category
+func renderSSHHostsFile(category string, hosts []map[string]interface{}) string {
+	field := func(h map[string]interface{}, key string) string {
+		if v, ok := h[key]; ok && v != nil {
+			return strings.TrimSpace(fmt.Sprint(v))
+		}
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# ssh_hosts category: %s\n", category)
+	b.WriteString("# Generated by blackdot template render from ssh_hosts - DO NOT EDIT\n")
+	for _, h := range hosts {
+		name := field(h, "name")
+		if name == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\nHost %s\n", name)
+		for _, kv := range [][2]string{{"HostName", "hostname"}, {"User", "user"}, {"Port", "port"}, {"IdentityFile", "identity"}} {
+			if v := field(h, kv[1]); v != "" {
+				fmt.Fprintf(&b, "    %s %s\n", kv[0], v)
+			}
+		}
+		if extra := field(h, "extra"); extra != "" {
+			fmt.Fprintf(&b, "    %s\n", extra)
+		}
+	}
+	return b.String()
+}
+
+// renderSSHHostIncludes writes one file per ssh_hosts category to
+// generated/ssh-config.d and points ssh-config.tmpl at them through the
+// ssh_include_dir variable. Files of categories that no longer exist are
+// removed.
+func renderSSHHostIncludes(engine *template.RaymondEngine, cfg *templateConfig, writes, dryRun bool) error {
+	groups, err := sshHostsByCategory(cfg)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(cfg.generatedDir, sshHostsIncludeDir)
+	if len(groups) > 0 {
+		engine.SetVar("ssh_include_dir", dir)
+	}
+
+	categories := make([]string, 0, len(groups))
+	for c := range groups {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+
+	if dryRun {
+		for _, c := range categories {
+			fmt.Printf("%s ssh_hosts -> %s/%s.conf (%d hosts)\n", Cyan.Sprint("[dry-run]"), sshHostsIncludeDir, c, len(groups[c]))
+		}
+		return nil
+	}
+	if !writes {
+		return nil
+	}
+
+	keep := make(map[string]bool)
+	if len(groups) > 0 {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	for _, c := range categories {
+		path := filepath.Join(dir, c+".conf")
+		keep[path] = true
+		if err := writeFileAtomic(path, []byte(renderSSHHostsFile(c, groups[c])), 0600); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+		fmt.Printf("%s ssh_hosts -> %s/%s.conf (%d hosts)\n", Green.Sprint("✓"), sshHostsIncludeDir, c, len(groups[c]))
+	}
+	stale, _ := filepath.Glob(filepath.Join(dir, "*.conf"))
+	for _, path := range stale {
+		if !keep[path] {
+			os.Remove(path)
+		}
+	}
+	return nil
+}
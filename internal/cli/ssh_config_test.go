@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestScanSSHConfigIncludes verifies Include is followed through globs and
+// nested files, hosts keep their source, and cycles end
+func TestScanSSHConfigIncludes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	sshDir := filepath.Join(home, ".ssh")
+	os.MkdirAll(filepath.Join(sshDir, "config.d"), 0700)
+
+	write := func(name, content string) {
+		os.WriteFile(filepath.Join(sshDir, name), []byte(content), 0600)
+	}
+	write("config", "Include config.d/*\nHost main *.internal\n  HostName main.example.com\n")
+	write("config.d/a-work", "Host=work1 work2\nInclude ~/.ssh/nested\n")
+	write("config.d/b-home", "Host nas\n")
+	write("nested", "Host deep\nInclude ~/.ssh/config\n")
+
+	scan, err := scanSSHConfig(filepath.Join(sshDir, "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, h := range scan.uniqueHosts() {
+		got = append(got, h.Name+"@"+filepath.Base(h.Source))
+	}
+	want := "work1@a-work work2@a-work deep@nested nas@b-home main@config"
+	if strings.Join(got, " ") != want {
+		t.Errorf("hosts = %v, want %s", got, want)
+	}
+	if len(scan.Problems) != 1 || !strings.Contains(scan.Problems[0], "cycle") {
+		t.Errorf("problems = %v", scan.Problems)
+	}
+}
+
+// TestRenderSSHHostIncludes verifies ssh_hosts render one file per category
+// and stale category files are removed
+func TestRenderSSHHostIncludes(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &templateConfig{variablesDir: dir, generatedDir: filepath.Join(dir, "generated")}
+	os.WriteFile(filepath.Join(dir, "_arrays.local.json"), []byte(`{"ssh_hosts": [
+		{"name": "web", "hostname": "web.example.com", "user": "deploy", "category": "Work"},
+		{"name": "nas", "hostname": "192.168.1.2", "port": 2222}
+	]}`), 0600)
+	includeDir := filepath.Join(cfg.generatedDir, sshHostsIncludeDir)
+	os.MkdirAll(includeDir, 0755)
+	os.WriteFile(filepath.Join(includeDir, "old.conf"), []byte("Host old\n"), 0600)
+
+	engine := newTemplateEngine(cfg)
+	if err := renderSSHHostIncludes(engine, cfg, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := engine.GetVar("ssh_include_dir"); v != includeDir {
+		t.Errorf("ssh_include_dir = %v", v)
+	}
+	work, _ := os.ReadFile(filepath.Join(includeDir, "work.conf"))
+	if !strings.Contains(string(work), "Host web\n    HostName web.example.com\n    User deploy\n") {
+		t.Errorf("work.conf:\n%s", work)
+	}
+	hosts, _ := os.ReadFile(filepath.Join(includeDir, "hosts.conf"))
+	if !strings.Contains(string(hosts), "Port 2222") {
+		t.Errorf("hosts.conf:\n%s", hosts)
+	}
+	if _, err := os.Stat(filepath.Join(includeDir, "old.conf")); !os.IsNotExist(err) {
+		t.Error("stale category file was kept")
+	}
+}
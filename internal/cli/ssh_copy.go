@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+	"golang.org/x/crypto/ssh"
+)
+
+// Built-in key copy for `tools ssh copy` where ssh-copy-id is not
+// installed (Windows, minimal distros). It connects with the same client
+// as tunnel and socks and edits authorized_keys with a small sh script.
+
+// sshCopyScript appends the key read from stdin to ~/.ssh/authorized_keys
+// unless a line already has the same key type and data, creating the
+// directory and file with the permissions sshd requires. It runs under
+// sh whatever the login shell is, so it must not contain single quotes.
+const sshCopyScript = `set -f
+umask 077
+key=$(cat)
+set -- $key
+mkdir -p ~/.ssh && chmod 700 ~/.ssh || exit 1
+f=~/.ssh/authorized_keys
+touch "$f" && chmod 600 "$f" || exit 1
+if grep -qF -- "$1 $2" "$f"; then echo exists; exit 0; fi
+if [ -s "$f" ] && [ -n "$(tail -c 1 "$f")" ]; then echo >> "$f"; fi
+printf "%s\n" "$key" >> "$f" && echo added`
+
+// runNativeSSHCopy copies the public key of keyPath (default: the first
+// of ~/.ssh/id_ed25519, id_ecdsa, id_rsa) to host
+func runNativeSSHCopy(host, keyPath string, acceptNew bool) error {
+	line, keyFile, err := sshCopyKeyLine(keyPath)
+	if err != nil {
+		return err
+	}
+
+	addr, login, hostCfg := sshDestination(host)
+	if hostCfg.Proxy != "" && hostCfg.Proxy != "none" {
+		return fmt.Errorf("%s is reached through %s, which the built-in client does not support (install ssh-copy-id)", host, hostCfg.Proxy)
+	}
+	config, err := sshClientConfig(addr, login, hostCfg, acceptNew)
+	if err != nil {
+		return err
+	}
+	// The key is usually not authorized yet, so allow a password
+	if stdinIsTerminal() {
+		hostname, _, _ := net.SplitHostPort(addr)
+		password := func() (string, error) {
+			return prompts.Secret(fmt.Sprintf("%s@%s's password", login, hostname))
+		}
+		config.Auth = append(config.Auth,
+			ssh.PasswordCallback(password),
+			ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range questions {
+					answer, err := password()
+					if err != nil {
+						return nil, err
+					}
+					answers[i] = answer
+				}
+				return answers, nil
+			}))
+	}
+
+	client, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", host, err)
+	}
+	defer client.Close()
+
+	added, err := sshInstallAuthorizedKey(client, line)
+	if err != nil {
+		return fmt.Errorf("%s: %w", host, err)
+	}
+	if added {
+		Pass("Added %s to %s@%s:~/.ssh/authorized_keys", tildePath(keyFile), login, host)
+	} else {
+		Info("%s is already in %s@%s:~/.ssh/authorized_keys", tildePath(keyFile), login, host)
+	}
+	return nil
+}
+
+// sshCopyKeyLine returns the authorized_keys line for a key and the file
+// it came from. keyPath may name the private or public half.
+func sshCopyKeyLine(keyPath string) (string, string, error) {
+	if keyPath == "" {
+		for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+			candidate := filepath.Join(userSSHDir(), name+".pub")
+			if fileExists(candidate) {
+				keyPath = candidate
+				break
+			}
+		}
+		if keyPath == "" {
+			return "", "", fmt.Errorf("no public key in ~/.ssh (generate one with: blackdot tools ssh gen)")
+		}
+	} else if resolved, ok := resolveSSHKeyPath(keyPath); ok {
+		keyPath = resolved
+	} else {
+		return "", "", fmt.Errorf("key not found: %s", keyPath)
+	}
+
+	pub, err := sshKeyPublic(keyPath)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", tildePath(keyPath), err)
+	}
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+	pubPath := strings.TrimSuffix(keyPath, ".pub") + ".pub"
+	if data, err := os.ReadFile(pubPath); err == nil {
+		if _, comment, _, _, err := ssh.ParseAuthorizedKey(data); err == nil && comment != "" {
+			line += " " + comment
+		}
+	}
+	return line, keyPath, nil
+}
+
+// sshInstallAuthorizedKey adds line to the remote authorized_keys and
+// reports whether it was added (false: the key was already there)
+func sshInstallAuthorizedKey(client *ssh.Client, line string) (bool, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return false, err
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdin = strings.NewReader(line + "\n")
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if err := session.Run("exec sh -c '" + sshCopyScript + "'"); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return false, fmt.Errorf("updating authorized_keys: %s", msg)
+		}
+		return false, fmt.Errorf("updating authorized_keys: %w", err)
+	}
+	switch result := strings.TrimSpace(stdout.String()); result {
+	case "added":
+		return true, nil
+	case "exists":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected reply from remote shell: %q", result)
+	}
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// serveSession runs a session's exec request with sh, with HOME set to
+// execHome
+func (s *testSSHServer) serveSession(nch ssh.NewChannel) {
+	ch, reqs, err := nch.Accept()
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+	for req := range reqs {
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+		var payload struct{ Command string }
+		ssh.Unmarshal(req.Payload, &payload)
+		req.Reply(true, nil)
+
+		s.mu.Lock()
+		home := s.execHome
+		s.mu.Unlock()
+		cmd := exec.Command("sh", "-c", payload.Command)
+		cmd.Env = append(os.Environ(), "HOME="+home)
+		cmd.Stdin = ch
+		cmd.Stdout = ch
+		cmd.Stderr = ch.Stderr()
+		status := uint32(0)
+		if err := cmd.Run(); err != nil {
+			status = 1
+		}
+		ch.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, status))
+		return
+	}
+}
+
+// TestNativeSSHCopy verifies the built-in copy creates ~/.ssh and
+// authorized_keys with sshd's permissions, appends the key once, and
+// keeps existing keys
+func TestNativeSSHCopy(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not installed")
+	}
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("USERPROFILE", dir)
+	t.Setenv("SSH_AUTH_SOCK", "")
+	sshDir := filepath.Join(dir, ".ssh")
+	os.MkdirAll(sshDir, 0700)
+
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(sshDir, "id_ed25519"), pem.EncodeToMemory(block), 0600)
+	sshPub, _ := ssh.NewPublicKey(pub)
+	keyLine := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub)))
+	os.WriteFile(filepath.Join(sshDir, "id_ed25519.pub"), []byte(keyLine+" me@laptop\n"), 0644)
+
+	server := startTestSSHServer(t, sshPub)
+	remoteHome := t.TempDir()
+	server.execHome = remoteHome
+	_, port, _ := net.SplitHostPort(server.addr)
+	os.WriteFile(filepath.Join(sshDir, "config"), []byte(fmt.Sprintf("Host test\n  HostName 127.0.0.1\n  Port %s\n  User tester\n", port)), 0600)
+	os.WriteFile(filepath.Join(sshDir, "known_hosts"), []byte(knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, server.hostKey)+"\n"), 0600)
+
+	if err := runNativeSSHCopy("test", "", false); err != nil {
+		t.Fatal(err)
+	}
+	authorized := filepath.Join(remoteHome, ".ssh", "authorized_keys")
+	data, err := os.ReadFile(authorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != keyLine+" me@laptop\n" {
+		t.Errorf("authorized_keys = %q", data)
+	}
+	for path, want := range map[string]os.FileMode{filepath.Dir(authorized): 0700, authorized: 0600} {
+		if info, _ := os.Stat(path); info.Mode().Perm() != want {
+			t.Errorf("%s mode = %v, want %v", path, info.Mode().Perm(), want)
+		}
+	}
+
+	// An existing file without a trailing newline, already holding the key
+	// under another comment
+	other := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOtherKeyOtherKeyOtherKeyOtherKeyOtherKey other"
+	os.WriteFile(authorized, []byte(other+"\n"+keyLine+" old-comment"), 0644)
+	if err := runNativeSSHCopy("test", "", false); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(authorized); bytes.Count(data, []byte(keyLine)) != 1 {
+		t.Errorf("key added twice:\n%s", data)
+	}
+
+	os.WriteFile(authorized, []byte(other), 0644)
+	client, err := ssh.Dial("tcp", server.addr, &ssh.ClientConfig{
+		User:            "tester",
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(mustSigner(t, priv))},
+		HostKeyCallback: ssh.FixedHostKey(server.hostKey),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	added, err := sshInstallAuthorizedKey(client, keyLine)
+	if err != nil || !added {
+		t.Fatalf("added = %v, %v", added, err)
+	}
+	if data, _ := os.ReadFile(authorized); string(data) != other+"\n"+keyLine+"\n" {
+		t.Errorf("authorized_keys = %q", data)
+	}
+}
+
+func mustSigner(t *testing.T, key interface{}) ssh.Signer {
+	t.Helper()
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
@@ -0,0 +1,669 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// The built-in ssh client behind 'tools ssh tunnel' and 'tools ssh socks'.
+// It reads the same ~/.ssh/config, agent, keys, and known_hosts as ssh, so
+// forwards work where no ssh binary is installed.
+
+// sshForwardOptions are the flags shared by tunnel and socks
+type sshForwardOptions struct {
+	OpenSSH   bool          // run the ssh binary instead
+	Keepalive time.Duration // interval between keepalive requests
+	AcceptNew bool          // add unknown host keys to known_hosts
+}
+
+// sshHostConfig is what ~/.ssh/config says about a host
+type sshHostConfig struct {
+	HostName       string
+	User           string
+	Port           string
+	IdentityFiles  []string
+	KnownHostsFile []string
+	Proxy          string // ProxyJump or ProxyCommand, which only ssh can run
+}
+
+// lookupSSHHostConfig applies the Host blocks of an ssh config matching
+// alias. As in ssh, the first value found for each keyword wins, and
+// IdentityFile accumulates. Match blocks are not evaluated and are
+// skipped.
+func lookupSSHHostConfig(configPath, alias string) sshHostConfig {
+	var cfg sshHostConfig
+	active := true
+	for _, line := range readSSHConfigLines(configPath, userSSHDir(), make(map[string]bool), 0) {
+		keyword, args := splitSSHConfigLine(line)
+		if len(args) == 0 {
+			continue
+		}
+		switch strings.ToLower(keyword) {
+		case "host":
+			active = sshHostMatches(args, alias)
+		case "match":
+			active = false
+		case "hostname":
+			if active && cfg.HostName == "" {
+				cfg.HostName = args[0]
+			}
+		case "user":
+			if active && cfg.User == "" {
+				cfg.User = args[0]
+			}
+		case "port":
+			if active && cfg.Port == "" {
+				cfg.Port = args[0]
+			}
+		case "identityfile":
+			if active {
+				cfg.IdentityFiles = append(cfg.IdentityFiles, args[0])
+			}
+		case "proxyjump", "proxycommand":
+			if active && cfg.Proxy == "" {
+				cfg.Proxy = keyword
+				if strings.EqualFold(args[0], "none") {
+					cfg.Proxy = "none"
+				}
+			}
+		case "userknownhostsfile":
+			if active && cfg.KnownHostsFile == nil {
+				cfg.KnownHostsFile = args
+			}
+		}
+	}
+	return cfg
+}
+
+// readSSHConfigLines returns the lines of an ssh config with Include
+// directives replaced by the lines of the files they name
+func readSSHConfigLines(configPath, baseDir string, reading map[string]bool, depth int) []string {
+	if reading[configPath] || depth > sshIncludeMaxDepth {
+		return nil
+	}
+	f, err := os.Open(configPath)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	reading[configPath] = true
+	defer delete(reading, configPath)
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		keyword, args := splitSSHConfigLine(scanner.Text())
+		if !strings.EqualFold(keyword, "include") {
+			lines = append(lines, scanner.Text())
+			continue
+		}
+		for _, pattern := range args {
+			for _, inc := range expandSSHInclude(pattern, baseDir) {
+				lines = append(lines, readSSHConfigLines(inc, baseDir, reading, depth+1)...)
+			}
+		}
+	}
+	return lines
+}
+
+// sshHostMatches reports whether alias matches a Host line's patterns: at
+// least one pattern matches and no negated (!) pattern does
+func sshHostMatches(patterns []string, alias string) bool {
+	matched := false
+	for _, p := range patterns {
+		negated := strings.HasPrefix(p, "!")
+		ok, _ := path.Match(strings.TrimPrefix(p, "!"), alias)
+		if ok && negated {
+			return false
+		}
+		matched = matched || ok
+	}
+	return matched
+}
+
+// sshDestination resolves user@host to the address to dial and the user
+// to log in as, following ~/.ssh/config
+func sshDestination(dest string) (addr, login string, cfg sshHostConfig) {
+	alias := dest
+	if i := strings.LastIndex(dest, "@"); i >= 0 {
+		login, alias = dest[:i], dest[i+1:]
+	}
+	cfg = lookupSSHHostConfig(filepath.Join(userSSHDir(), "config"), alias)
+	host := valueOr(cfg.HostName, alias)
+	host = strings.ReplaceAll(host, "%h", alias)
+	if login == "" {
+		login = valueOr(cfg.User, localUsername())
+	}
+	return net.JoinHostPort(host, valueOr(cfg.Port, "22")), login, cfg
+}
+
+// localUsername is the user ssh logs in as by default
+func localUsername() string {
+	if u, err := user.Current(); err == nil {
+		// Windows returns DOMAIN\user
+		name := u.Username
+		if i := strings.LastIndex(name, `\`); i >= 0 {
+			name = name[i+1:]
+		}
+		return name
+	}
+	return valueOr(os.Getenv("USER"), os.Getenv("USERNAME"))
+}
+
+// sshSigners returns the keys to offer: the agent's first, then identity
+// files. A passphrase-protected file is skipped when the agent holds the
+// same key, and otherwise prompted for on a terminal.
+func sshSigners(identityFiles []string, host, login string) ([]ssh.Signer, error) {
+	var signers []ssh.Signer
+	inAgent := make(map[string]bool)
+	if client, _, err := openSSHAgent(); err == nil {
+		// The connection stays open: agent signers sign through it
+		if agentSigners, err := client.Signers(); err == nil {
+			for _, s := range agentSigners {
+				inAgent[string(s.PublicKey().Marshal())] = true
+			}
+			signers = append(signers, agentSigners...)
+		}
+	}
+
+	if len(identityFiles) == 0 {
+		identityFiles = []string{"~/.ssh/id_rsa", "~/.ssh/id_ecdsa", "~/.ssh/id_ed25519"}
+	}
+	home, _ := os.UserHomeDir()
+	tokens := strings.NewReplacer("%d", home, "%h", host, "%r", login, "%u", localUsername(), "%%", "%")
+	for _, file := range identityFiles {
+		keyPath := expandPath(tokens.Replace(file))
+		data, err := os.ReadFile(keyPath)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			if missing.PublicKey != nil && inAgent[string(missing.PublicKey.Marshal())] {
+				continue
+			}
+			if !stdinIsTerminal() {
+				Debug("Skipping %s: passphrase needed and no terminal to ask on", tildePath(keyPath))
+				continue
+			}
+			passphrase, perr := prompts.Secret("Passphrase for " + tildePath(keyPath))
+			if perr != nil {
+				return nil, perr
+			}
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+		}
+		if err != nil {
+			Debug("Skipping %s: %v", tildePath(keyPath), err)
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("no ssh keys available (start ssh-agent or add an IdentityFile)")
+	}
+	return signers, nil
+}
+
+// sshHostKeyError is a host key that failed verification. Reconnecting
+// cannot fix it, so forwards stop instead of retrying.
+type sshHostKeyError struct {
+	msg string
+}
+
+func (e *sshHostKeyError) Error() string { return e.msg }
+
+// sshKnownHosts checks host keys against the known_hosts files that
+// exist. With none, every host is unknown.
+func sshKnownHosts(files []string) (ssh.HostKeyCallback, error) {
+	var existing []string
+	for _, f := range files {
+		if _, err := os.Stat(f); err == nil {
+			existing = append(existing, f)
+		}
+	}
+	if len(existing) == 0 {
+		return func(string, net.Addr, ssh.PublicKey) error { return &knownhosts.KeyError{} }, nil
+	}
+	return knownhosts.New(existing...)
+}
+
+// sshVerifyHostKey wraps a known_hosts check. A changed key is always
+// refused. An unknown host is refused unless acceptNew is set, in which
+// case its key is appended to addTo, as with StrictHostKeyChecking=accept-new.
+func sshVerifyHostKey(check ssh.HostKeyCallback, addTo string, acceptNew bool) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := check(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if err == nil || !errors.As(err, &keyErr) {
+			return err
+		}
+		if len(keyErr.Want) > 0 {
+			return &sshHostKeyError{fmt.Sprintf("host key for %s has CHANGED (now %s, known_hosts has %s at %s:%d); someone may be intercepting the connection. Remove the old key with 'ssh-keygen -R %s' only if you know why it changed",
+				hostname, ssh.FingerprintSHA256(key), ssh.FingerprintSHA256(keyErr.Want[0].Key), tildePath(keyErr.Want[0].Filename), keyErr.Want[0].Line, knownhosts.Normalize(hostname))}
+		}
+		if !acceptNew {
+			return &sshHostKeyError{fmt.Sprintf("%s is not in known_hosts (%s key %s); connect once with ssh to verify it, or rerun with --accept-new",
+				hostname, key.Type(), ssh.FingerprintSHA256(key))}
+		}
+		line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key) + "\n"
+		if err := os.MkdirAll(filepath.Dir(addTo), 0700); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(addTo, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := f.WriteString(line); err != nil {
+			return err
+		}
+		Warn("Permanently added %s (%s) to %s", hostname, ssh.FingerprintSHA256(key), tildePath(addTo))
+		return nil
+	}
+}
+
+// sshKnownHostAlgorithms lists the host key algorithms known_hosts has
+// keys for, so the server is asked for a key that can be checked instead
+// of its preferred one. check must be the unwrapped known_hosts check.
+func sshKnownHostAlgorithms(check ssh.HostKeyCallback, addr string) []string {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil
+	}
+	probe, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil
+	}
+	var keyErr *knownhosts.KeyError
+	if !errors.As(check(addr, &net.TCPAddr{}, probe), &keyErr) {
+		return nil
+	}
+	var algos []string
+	for _, known := range keyErr.Want {
+		switch t := known.Key.Type(); t {
+		case ssh.KeyAlgoRSA:
+			algos = append(algos, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA)
+		default:
+			algos = append(algos, t)
+		}
+	}
+	return algos
+}
+
+// sshForwarder keeps one ssh connection up for forwarding: it reconnects
+// with backoff when the connection drops, and keepalives notice a dead
+// connection that TCP has not.
+type sshForwarder struct {
+	dest      string
+	addr      string
+	config    *ssh.ClientConfig
+	keepalive time.Duration
+
+	mu      sync.Mutex
+	client  *ssh.Client
+	err     error         // set when the forwarder has given up
+	changed chan struct{} // closed when client or err changes
+}
+
+// newSSHForwarder resolves dest and prepares its client config
+func newSSHForwarder(dest string, opts sshForwardOptions) (*sshForwarder, error) {
+	addr, login, hostCfg := sshDestination(dest)
+	if hostCfg.Proxy != "" && hostCfg.Proxy != "none" {
+		return nil, fmt.Errorf("%s is reached through %s, which the built-in client does not support (use --openssh)", dest, hostCfg.Proxy)
+	}
+	config, err := sshClientConfig(addr, login, hostCfg, opts.AcceptNew)
+	if err != nil {
+		return nil, err
+	}
+	keepalive := opts.Keepalive
+	if keepalive <= 0 {
+		keepalive = 30 * time.Second
+	}
+	return &sshForwarder{
+		dest:      dest,
+		addr:      addr,
+		config:    config,
+		keepalive: keepalive,
+		changed:   make(chan struct{}),
+	}, nil
+}
+
+// sshClientConfig prepares the client config for a resolved destination:
+// its keys, and host key checks against its known_hosts files
+func sshClientConfig(addr, login string, hostCfg sshHostConfig, acceptNew bool) (*ssh.ClientConfig, error) {
+	knownHosts := []string{filepath.Join(userSSHDir(), "known_hosts")}
+	if len(hostCfg.KnownHostsFile) > 0 {
+		knownHosts = nil
+		for _, f := range hostCfg.KnownHostsFile {
+			knownHosts = append(knownHosts, expandPath(f))
+		}
+	}
+	if _, err := os.Stat("/etc/ssh/ssh_known_hosts"); err == nil {
+		knownHosts = append(knownHosts, "/etc/ssh/ssh_known_hosts")
+	}
+	check, err := sshKnownHosts(knownHosts)
+	if err != nil {
+		return nil, err
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	signers, err := sshSigners(hostCfg.IdentityFiles, host, login)
+	if err != nil {
+		return nil, err
+	}
+	return &ssh.ClientConfig{
+		User:              login,
+		Auth:              []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback:   sshVerifyHostKey(check, knownHosts[0], acceptNew),
+		HostKeyAlgorithms: sshKnownHostAlgorithms(check, addr),
+		Timeout:           15 * time.Second,
+	}, nil
+}
+
+func (f *sshForwarder) set(client *ssh.Client, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.client, f.err = client, err
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// connect opens the ssh connection
+func (f *sshForwarder) connect() (*ssh.Client, error) {
+	return ssh.Dial("tcp", f.addr, f.config)
+}
+
+// run keeps the connection up until ctx is done. It returns early only
+// for a host key failure.
+func (f *sshForwarder) run(ctx context.Context, client *ssh.Client) error {
+	backoff := time.Second
+	for {
+		f.set(client, nil)
+		done := make(chan struct{})
+		go func() {
+			client.Wait()
+			close(done)
+		}()
+		go f.keepAlive(client, done)
+
+		select {
+		case <-ctx.Done():
+			client.Close()
+			return nil
+		case <-done:
+		}
+		f.set(nil, nil)
+		Warn("Connection to %s dropped; reconnecting", f.dest)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(backoff):
+			}
+			var err error
+			if client, err = f.connect(); err == nil {
+				Pass("Reconnected to %s", f.dest)
+				backoff = time.Second
+				break
+			}
+			var hostKeyErr *sshHostKeyError
+			if errors.As(err, &hostKeyErr) {
+				f.set(nil, err)
+				return err
+			}
+			backoff = min(backoff*2, 30*time.Second)
+			Warn("Reconnect to %s failed: %v (retrying in %s)", f.dest, err, backoff)
+		}
+	}
+}
+
+// keepAlive sends keepalive requests until done, closing the connection
+// when the server stops answering
+func (f *sshForwarder) keepAlive(client *ssh.Client, done chan struct{}) {
+	ticker := time.NewTicker(f.keepalive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		reply := make(chan error, 1)
+		go func() {
+			_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+			reply <- err
+		}()
+		select {
+		case err := <-reply:
+			if err == nil {
+				continue
+			}
+		case <-time.After(f.keepalive):
+		}
+		client.Close()
+		return
+	}
+}
+
+// dial opens a connection to addr through the server, waiting for a
+// reconnect in progress
+func (f *sshForwarder) dial(addr string) (net.Conn, error) {
+	timeout := time.After(f.config.Timeout * 2)
+	for {
+		f.mu.Lock()
+		client, err, changed := f.client, f.err, f.changed
+		f.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		if client != nil {
+			return client.Dial("tcp", addr)
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			return nil, fmt.Errorf("not connected to %s", f.dest)
+		}
+	}
+}
+
+// serve accepts local connections until ctx is done or the forwarder gives
+// up, handing each to handle
+func (f *sshForwarder) serve(ctx context.Context, ln net.Listener, client *ssh.Client, handle func(net.Conn)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- f.run(ctx, client)
+		cancel()
+	}()
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return <-runErr
+			}
+			return err
+		}
+		go handle(conn)
+	}
+}
+
+// pipeConns copies between a and b until either side closes
+func pipeConns(a, b net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(a, b)
+		a.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(b, a)
+		b.Close()
+	}()
+	wg.Wait()
+}
+
+// startSSHForward connects to dest, listens on localAddr, and serves
+// connections with handle until interrupted
+func startSSHForward(dest, localAddr string, opts sshForwardOptions, handle func(f *sshForwarder, conn net.Conn)) error {
+	f, err := newSSHForwarder(dest, opts)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+	client, err := f.connect()
+	if err != nil {
+		Fail("Cannot connect to %s: %v", dest, err)
+		return err
+	}
+	ln, err := net.Listen("tcp", localAddr)
+	if err != nil {
+		client.Close()
+		Fail("Cannot listen on %s: %v", localAddr, err)
+		return err
+	}
+	Pass("Connected to %s (%s)", dest, f.addr)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return f.serve(ctx, ln, client, func(conn net.Conn) { handle(f, conn) })
+}
+
+// runNativeSSHTunnel forwards localhost:localPort to remoteAddr as seen
+// from the server
+func runNativeSSHTunnel(dest, localPort, remoteAddr string, opts sshForwardOptions) error {
+	return startSSHForward(dest, net.JoinHostPort("127.0.0.1", localPort), opts, func(f *sshForwarder, conn net.Conn) {
+		remote, err := f.dial(remoteAddr)
+		if err != nil {
+			Warn("Forward to %s failed: %v", remoteAddr, err)
+			conn.Close()
+			return
+		}
+		pipeConns(conn, remote)
+	})
+}
+
+// runNativeSSHSocks serves SOCKS5 on localhost:port, connecting through
+// the server
+func runNativeSSHSocks(dest, port string, opts sshForwardOptions) error {
+	return startSSHForward(dest, net.JoinHostPort("127.0.0.1", port), opts, func(f *sshForwarder, conn net.Conn) {
+		serveSOCKS5(conn, f.dial)
+	})
+}
+
+// SOCKS5 reply codes (RFC 1928)
+const (
+	socksSucceeded          = 0x00
+	socksGeneralFailure     = 0x01
+	socksCommandUnsupported = 0x07
+	socksAddressUnsupported = 0x08
+)
+
+// serveSOCKS5 handles one SOCKS5 client: no authentication, CONNECT only,
+// with targets dialed through dial
+func serveSOCKS5(conn net.Conn, dial func(addr string) (net.Conn, error)) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	// Greeting: version, then the offered auth methods
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil || header[0] != 5 {
+		return
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(r, methods); err != nil {
+		return
+	}
+	noAuth := false
+	for _, m := range methods {
+		noAuth = noAuth || m == 0
+	}
+	if !noAuth {
+		conn.Write([]byte{5, 0xff})
+		return
+	}
+	conn.Write([]byte{5, 0})
+
+	// Request: version, command, reserved, address type, address, port
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(r, req); err != nil || req[0] != 5 {
+		return
+	}
+	reply := func(code byte) {
+		conn.Write([]byte{5, code, 0, 1, 0, 0, 0, 0, 0, 0})
+	}
+	if req[1] != 1 {
+		reply(socksCommandUnsupported)
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1, 4:
+		ip := make(net.IP, map[byte]int{1: 4, 4: 16}[req[3]])
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return
+		}
+		host = ip.String()
+	case 3:
+		n, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		name := make([]byte, n)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return
+		}
+		host = string(name)
+	default:
+		reply(socksAddressUnsupported)
+		return
+	}
+	portBytes := make([]byte, 2)
+	if _, err := io.ReadFull(r, portBytes); err != nil {
+		return
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBytes))))
+
+	remote, err := dial(addr)
+	if err != nil {
+		Debug("SOCKS connect to %s failed: %v", addr, err)
+		reply(socksGeneralFailure)
+		return
+	}
+	reply(socksSucceeded)
+	// Anything the client sent after the request is already buffered
+	if n := r.Buffered(); n > 0 {
+		buffered, _ := r.Peek(n)
+		remote.Write(buffered)
+	}
+	pipeConns(conn, remote)
+}
@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// TestLookupSSHHostConfig verifies first-match-wins values, wildcard and
+// negated Host patterns, Include, and accumulated IdentityFile
+func TestLookupSSHHostConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("USERPROFILE", dir)
+	sshDir := filepath.Join(dir, ".ssh")
+	os.MkdirAll(filepath.Join(sshDir, "conf.d"), 0700)
+	os.WriteFile(filepath.Join(sshDir, "conf.d", "work"), []byte("Host bastion\n  HostName 10.0.0.5\n  Port 2222\n"), 0600)
+	config := filepath.Join(sshDir, "config")
+	os.WriteFile(config, []byte(`Include conf.d/*
+Host bastion
+  HostName ignored.example.com
+  User ops
+  IdentityFile ~/.ssh/id_work
+Host * !bastion
+  User me
+  ProxyJump bastion
+Host *
+  IdentityFile ~/.ssh/id_default
+  Port 22
+`), 0600)
+
+	got := lookupSSHHostConfig(config, "bastion")
+	if got.HostName != "10.0.0.5" || got.Port != "2222" || got.User != "ops" {
+		t.Errorf("bastion = %+v", got)
+	}
+	if got.Proxy != "" {
+		t.Errorf("bastion proxy = %q", got.Proxy)
+	}
+	if strings.Join(got.IdentityFiles, ",") != "~/.ssh/id_work,~/.ssh/id_default" {
+		t.Errorf("identity files = %v", got.IdentityFiles)
+	}
+	if other := lookupSSHHostConfig(config, "web"); other.User != "me" || other.HostName != "" || other.Port != "22" || other.Proxy != "ProxyJump" {
+		t.Errorf("web = %+v", other)
+	}
+}
+
+// TestSSHVerifyHostKey verifies unknown hosts are refused unless accepted,
+// accepted keys are recorded, and changed keys are always refused
+func TestSSHVerifyHostKey(t *testing.T) {
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	key := newTestSSHSigner(t).PublicKey()
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 5), Port: 22}
+
+	check, _ := sshKnownHosts([]string{knownHostsFile})
+	var hostKeyErr *sshHostKeyError
+	if err := sshVerifyHostKey(check, knownHostsFile, false)("server:22", addr, key); !errors.As(err, &hostKeyErr) {
+		t.Fatalf("unknown host accepted: %v", err)
+	}
+	if err := sshVerifyHostKey(check, knownHostsFile, true)("server:22", addr, key); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := sshKnownHosts([]string{knownHostsFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sshVerifyHostKey(check, knownHostsFile, false)("server:22", addr, key); err != nil {
+		t.Errorf("recorded key refused: %v", err)
+	}
+	other := newTestSSHSigner(t).PublicKey()
+	err = sshVerifyHostKey(check, knownHostsFile, true)("server:22", addr, other)
+	if !errors.As(err, &hostKeyErr) || !strings.Contains(err.Error(), "CHANGED") {
+		t.Errorf("changed key: %v", err)
+	}
+	if algos := sshKnownHostAlgorithms(check, "server:22"); len(algos) != 1 || algos[0] != ssh.KeyAlgoED25519 {
+		t.Errorf("algorithms = %v", algos)
+	}
+}
+
+// TestSSHForwarderSOCKS verifies SOCKS5 connections are carried over the
+// built-in client, and that it reconnects after the server drops it
+func TestSSHForwarderSOCKS(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("USERPROFILE", dir)
+	t.Setenv("SSH_AUTH_SOCK", "")
+	sshDir := filepath.Join(dir, ".ssh")
+	os.MkdirAll(sshDir, 0700)
+
+	// Client key and an ssh server that accepts it and serves direct-tcpip
+	clientPub, clientPriv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(sshDir, "id_test"), pem.EncodeToMemory(block), 0600)
+	authorized, _ := ssh.NewPublicKey(clientPub)
+	server := startTestSSHServer(t, authorized)
+
+	_, port, _ := net.SplitHostPort(server.addr)
+	os.WriteFile(filepath.Join(sshDir, "config"), []byte(fmt.Sprintf("Host test\n  HostName 127.0.0.1\n  Port %s\n  User tester\n  IdentityFile ~/.ssh/id_test\n", port)), 0600)
+	os.WriteFile(filepath.Join(sshDir, "known_hosts"), []byte(knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, server.hostKey)+"\n"), 0600)
+
+	echo, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() { io.Copy(conn, conn); conn.Close() }()
+		}
+	}()
+
+	f, err := newSSHForwarder("test", sshForwardOptions{Keepalive: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := f.connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- f.serve(ctx, ln, client, func(conn net.Conn) { serveSOCKS5(conn, f.dial) })
+	}()
+
+	roundTrip := func(msg string) {
+		t.Helper()
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		// Greeting (no auth), then CONNECT localhost:<echo port>, then data
+		req := append([]byte{5, 1, 0, 5, 1, 0, 3, 9}, "localhost"...)
+		req = binary.BigEndian.AppendUint16(req, uint16(echo.Addr().(*net.TCPAddr).Port))
+		conn.Write(append(req, msg...))
+		reply := make([]byte, 2+10+len(msg))
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			t.Fatalf("reading reply: %v", err)
+		}
+		if reply[0] != 5 || reply[1] != 0 || reply[3] != socksSucceeded || string(reply[12:]) != msg {
+			t.Fatalf("reply = %v", reply)
+		}
+	}
+	roundTrip("hello")
+
+	server.dropAll()
+	deadline := time.Now().Add(10 * time.Second)
+	for server.connections() < 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	roundTrip("again")
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("serve: %v", err)
+	}
+}
+
+func newTestSSHSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// testSSHServer accepts one authorized key and opens direct-tcpip
+// channels to their targets. Sessions run exec requests with sh in
+// execHome.
+type testSSHServer struct {
+	addr     string
+	hostKey  ssh.PublicKey
+	mu       sync.Mutex
+	conns    []net.Conn
+	total    int
+	execHome string
+}
+
+func startTestSSHServer(t *testing.T, authorized ssh.PublicKey) *testSSHServer {
+	t.Helper()
+	hostSigner := newTestSSHSigner(t)
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if meta.User() == "tester" && string(key.Marshal()) == string(authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unauthorized")
+		},
+	}
+	config.AddHostKey(hostSigner)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &testSSHServer{addr: ln.Addr().String(), hostKey: hostSigner.PublicKey()}
+
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, nc)
+			s.total++
+			s.mu.Unlock()
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(nc, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for nch := range chans {
+					if nch.ChannelType() == "session" {
+						go s.serveSession(nch)
+						continue
+					}
+					var target struct {
+						Host     string
+						Port     uint32
+						OrigHost string
+						OrigPort uint32
+					}
+					if nch.ChannelType() != "direct-tcpip" || ssh.Unmarshal(nch.ExtraData(), &target) != nil {
+						nch.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					remote, err := net.Dial("tcp", net.JoinHostPort(target.Host, fmt.Sprint(target.Port)))
+					if err != nil {
+						nch.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					ch, chReqs, err := nch.Accept()
+					if err != nil {
+						remote.Close()
+						continue
+					}
+					go ssh.DiscardRequests(chReqs)
+					go func() {
+						go func() { io.Copy(ch, remote); ch.Close() }()
+						io.Copy(remote, ch)
+						remote.Close()
+					}()
+				}
+			}()
+		}
+	}()
+	return s
+}
+
+// dropAll closes every connection, as a network failure would
+func (s *testSSHServer) dropAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.conns = nil
+}
+
+func (s *testSSHServer) connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.total
+}
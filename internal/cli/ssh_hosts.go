@@ -0,0 +1,691 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/template"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// sshHostsItem is the vault item hosts.yaml is synced to
+const sshHostsItem = "SSH-Hosts"
+
+// Markers around the generated part of ~/.ssh/config. Everything outside
+// them is left as written.
+const (
+	sshHostsBegin = "# >>> blackdot ssh hosts >>>"
+	sshHostsEnd   = "# <<< blackdot ssh hosts <<<"
+)
+
+// sshHostDef is one host in hosts.yaml
+type sshHostDef struct {
+	Host     string            `yaml:"host" json:"host"`
+	HostName string            `yaml:"hostname,omitempty" json:"hostname,omitempty"`
+	User     string            `yaml:"user,omitempty" json:"user,omitempty"`
+	Port     int               `yaml:"port,omitempty" json:"port,omitempty"`
+	Identity string            `yaml:"identity,omitempty" json:"identity,omitempty"`
+	Jump     string            `yaml:"jump,omitempty" json:"jump,omitempty"`
+	Tags     []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Options  map[string]string `yaml:"options,omitempty" json:"options,omitempty"`
+}
+
+// sshHostsFile is the structure of hosts.yaml
+type sshHostsFile struct {
+	Hosts []sshHostDef `yaml:"hosts"`
+}
+
+// sshHostsTemplate renders the managed section. Values are validated
+// before rendering, so the triple braces only skip HTML escaping.
+const sshHostsTemplate = `{{#each hosts}}
+Host {{{host}}}
+{{#if hostname}}
+    HostName {{{hostname}}}
+{{/if}}
+{{#if user}}
+    User {{{user}}}
+{{/if}}
+{{#if port}}
+    Port {{{port}}}
+{{/if}}
+{{#if identity}}
+    IdentityFile {{{identity}}}
+    IdentitiesOnly yes
+{{/if}}
+{{#if jump}}
+    ProxyJump {{{jump}}}
+{{/if}}
+{{#each options}}
+    {{{key}}} {{{value}}}
+{{/each}}
+
+{{/each}}`
+
+// sshHostsPath is hosts.yaml in the blackdot config directory
+func sshHostsPath() string {
+	return filepath.Join(ConfigDir(), "ssh", "hosts.yaml")
+}
+
+// loadSSHHosts reads hosts.yaml; a missing file is an empty list
+func loadSSHHosts() (*sshHostsFile, error) {
+	data, err := os.ReadFile(sshHostsPath())
+	if os.IsNotExist(err) {
+		return &sshHostsFile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseSSHHosts(data)
+}
+
+// parseSSHHosts parses and validates hosts.yaml content
+func parseSSHHosts(data []byte) (*sshHostsFile, error) {
+	var f sshHostsFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("hosts.yaml: %w", err)
+	}
+	seen := make(map[string]bool)
+	for _, h := range f.Hosts {
+		if err := h.validate(); err != nil {
+			return nil, fmt.Errorf("hosts.yaml: %w", err)
+		}
+		if seen[h.Host] {
+			return nil, fmt.Errorf("hosts.yaml: host %q defined twice", h.Host)
+		}
+		seen[h.Host] = true
+	}
+	return &f, nil
+}
+
+func saveSSHHosts(f *sshHostsFile) error {
+	sort.Slice(f.Hosts, func(i, j int) bool { return f.Hosts[i].Host < f.Hosts[j].Host })
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+	path := sshHostsPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+func (f *sshHostsFile) find(name string) int {
+	for i, h := range f.Hosts {
+		if h.Host == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// validate rejects values that would break out of their line in the
+// generated config
+func (h sshHostDef) validate() error {
+	if h.Host == "" || strings.ContainsAny(h.Host, " \t\r\n*?!") {
+		return fmt.Errorf("invalid host name %q (no spaces or patterns)", h.Host)
+	}
+	fields := map[string]string{"hostname": h.HostName, "user": h.User, "identity": h.Identity, "jump": h.Jump}
+	for k, v := range h.Options {
+		if k == "" || strings.ContainsAny(k, " \t\r\n=") {
+			return fmt.Errorf("%s: invalid option name %q", h.Host, k)
+		}
+		fields["option "+k] = v
+	}
+	for name, v := range fields {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("%s: %s contains a line break", h.Host, name)
+		}
+	}
+	if strings.ContainsAny(h.HostName+h.User+h.Jump, " \t") {
+		return fmt.Errorf("%s: hostname, user and jump cannot contain spaces", h.Host)
+	}
+	if h.Port < 0 || h.Port > 65535 {
+		return fmt.Errorf("%s: invalid port %d", h.Host, h.Port)
+	}
+	return nil
+}
+
+func (h sshHostDef) hasTag(tag string) bool {
+	for _, t := range h.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderSSHHosts renders the managed section, markers included
+func renderSSHHosts(f *sshHostsFile) (string, error) {
+	items := make([]map[string]interface{}, 0, len(f.Hosts))
+	for _, h := range f.Hosts {
+		item := map[string]interface{}{
+			"host":     h.Host,
+			"hostname": h.HostName,
+			"user":     h.User,
+			"identity": h.Identity,
+			"jump":     h.Jump,
+		}
+		// Sorted so the output is stable
+		keys := make([]string, 0, len(h.Options))
+		for k := range h.Options {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		options := make([]map[string]interface{}, 0, len(keys))
+		for _, k := range keys {
+			options = append(options, map[string]interface{}{"key": k, "value": h.Options[k]})
+		}
+		item["options"] = options
+		if h.Port != 0 && h.Port != 22 {
+			item["port"] = strconv.Itoa(h.Port)
+		}
+		items = append(items, item)
+	}
+	engine := template.NewRaymondEngine("")
+	engine.SetArray("hosts", items)
+	body, err := engine.Render(sshHostsTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(sshHostsBegin + "\n")
+	fmt.Fprintf(&b, "# Generated from %s by 'blackdot tools ssh hosts'.\n", tildePath(sshHostsPath()))
+	b.WriteString("# Edit hosts with 'blackdot tools ssh hosts edit'; changes here are overwritten.\n")
+	b.WriteString(strings.TrimRight(body, "\n") + "\n")
+	b.WriteString(sshHostsEnd + "\n")
+	return b.String(), nil
+}
+
+// replaceSSHHostsSection puts section into an ssh config in place of the
+// previous generated section. The first time, it goes before the first
+// Host or Match block so its values win over wildcard blocks, without
+// capturing top-level options.
+func replaceSSHHostsSection(config, section string) (string, error) {
+	begin := strings.Index(config, sshHostsBegin)
+	end := strings.Index(config, sshHostsEnd)
+	switch {
+	case begin >= 0 && end > begin:
+		end += len(sshHostsEnd)
+		if end < len(config) && config[end] == '\n' {
+			end++
+		}
+		return config[:begin] + section + config[end:], nil
+	case begin >= 0 || end >= 0:
+		return "", errors.New("ssh config has an unmatched blackdot ssh hosts marker; fix it by hand")
+	}
+
+	lines := strings.SplitAfter(config, "\n")
+	offset := 0
+	for _, line := range lines {
+		keyword, _ := splitSSHConfigLine(line)
+		if k := strings.ToLower(keyword); k == "host" || k == "match" {
+			return config[:offset] + section + "\n" + config[offset:], nil
+		}
+		offset += len(line)
+	}
+	if config != "" && !strings.HasSuffix(config, "\n") {
+		config += "\n"
+	}
+	if config != "" {
+		config += "\n"
+	}
+	return config + section, nil
+}
+
+// applySSHHosts regenerates the managed section of ~/.ssh/config
+func applySSHHosts(f *sshHostsFile, dryRun bool) error {
+	section, err := renderSSHHosts(f)
+	if err != nil {
+		return fmt.Errorf("rendering hosts: %w", err)
+	}
+
+	configPath := filepath.Join(userSSHDir(), "config")
+	// Write through a symlinked config rather than replacing the link
+	if resolved, err := filepath.EvalSymlinks(configPath); err == nil {
+		configPath = resolved
+	}
+	existing, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	updated, err := replaceSSHHostsSection(string(existing), section)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		if updated == string(existing) {
+			Pass("%s is up to date", tildePath(configPath))
+			return nil
+		}
+		printUnifiedDiff(os.Stdout, tildePath(configPath), string(existing), tildePath(configPath)+" (generated)", updated)
+		return nil
+	}
+	if updated == string(existing) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(configPath, []byte(updated), 0600); err != nil {
+		return err
+	}
+	Pass("Updated %s (%d hosts)", tildePath(configPath), len(f.Hosts))
+	if cfg, err := getTemplateConfig(); err == nil && strings.HasPrefix(configPath, cfg.generatedDir+string(filepath.Separator)) {
+		Warn("%s is rendered from a template; run 'blackdot tools ssh hosts apply' again after 'blackdot template render'", tildePath(configPath))
+	}
+	return nil
+}
+
+func newSSHHostsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hosts",
+		Short: "Manage SSH hosts from a structured hosts file",
+		Long: `Keep SSH hosts in ~/.config/blackdot/ssh/hosts.yaml and generate
+~/.ssh/config from it.
+
+The generated hosts go between "` + sshHostsBegin + `" and
+"` + sshHostsEnd + `" markers in ~/.ssh/config; hand-written entries
+outside the markers are never touched. add, edit and remove regenerate the
+section; apply does it on demand.
+
+hosts.yaml:
+  hosts:
+    - host: web1
+      hostname: 10.0.0.5
+      user: deploy
+      port: 2222
+      identity: ~/.ssh/id_ed25519_work
+      jump: bastion
+      tags: [work, prod]
+      options:
+        ForwardAgent: "no"
+
+Sync it between machines with 'hosts push' and 'hosts pull' (vault item
+` + sshHostsItem + `).
+
+Examples:
+  blackdot tools ssh hosts add web1 --hostname 10.0.0.5 --user deploy --tag work
+  blackdot tools ssh hosts edit web1 --jump bastion
+  blackdot tools ssh hosts list --tag work
+  blackdot tools ssh hosts remove web1`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(
+		newSSHHostsListCmd(),
+		newSSHHostsAddCmd(),
+		newSSHHostsEditCmd(),
+		newSSHHostsRemoveCmd(),
+		newSSHHostsApplyCmd(),
+		newSSHHostsPushCmd(),
+		newSSHHostsPullCmd(),
+	)
+	return cmd
+}
+
+// completeSSHHostDefs completes host names from hosts.yaml
+func completeSSHHostDefs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	f, err := loadSSHHosts()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, h := range f.Hosts {
+		if strings.HasPrefix(h.Host, toComplete) {
+			names = append(names, h.Host)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func newSSHHostsListCmd() *cobra.Command {
+	var tag string
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List hosts in hosts.yaml",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := loadSSHHosts()
+			if err != nil {
+				return err
+			}
+			hosts := []sshHostDef{}
+			for _, h := range f.Hosts {
+				if tag == "" || h.hasTag(tag) {
+					hosts = append(hosts, h)
+				}
+			}
+			if jsonOutput {
+				data, _ := json.MarshalIndent(hosts, "", "  ")
+				fmt.Println(string(data))
+				return nil
+			}
+			if len(hosts) == 0 {
+				if tag != "" {
+					Info("No hosts tagged %s in %s", tag, tildePath(sshHostsPath()))
+				} else {
+					Info("No hosts in %s", tildePath(sshHostsPath()))
+				}
+				return nil
+			}
+			for _, h := range hosts {
+				target := h.HostName
+				if h.User != "" {
+					target = h.User + "@" + target
+				}
+				if h.Port != 0 && h.Port != 22 {
+					target += ":" + strconv.Itoa(h.Port)
+				}
+				extra := ""
+				if h.Jump != "" {
+					extra += " via " + h.Jump
+				}
+				if len(h.Tags) > 0 {
+					extra += " [" + strings.Join(h.Tags, ", ") + "]"
+				}
+				fmt.Printf("  %-20s %s%s\n", h.Host, target, Dim.Sprint(extra))
+			}
+			fmt.Printf("\nTotal: %d hosts\n", len(hosts))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&tag, "tag", "t", "", "Only hosts with this tag")
+	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	return cmd
+}
+
+// sshHostFlags are the host fields settable from add and edit
+type sshHostFlags struct {
+	hostname, user, identity, jump string
+	port                           int
+	tags, options                  []string
+}
+
+func (fl *sshHostFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&fl.hostname, "hostname", "", "Hostname or IP address")
+	cmd.Flags().StringVarP(&fl.user, "user", "u", "", "Username")
+	cmd.Flags().IntVarP(&fl.port, "port", "p", 0, "Port number")
+	cmd.Flags().StringVarP(&fl.identity, "identity", "i", "", "Identity file path")
+	cmd.Flags().StringVarP(&fl.jump, "jump", "J", "", "Jump host (ProxyJump)")
+	cmd.Flags().StringSliceVarP(&fl.tags, "tag", "t", nil, "Tag (repeatable; replaces existing tags on edit)")
+	cmd.Flags().StringArrayVarP(&fl.options, "option", "o", nil, "Extra ssh option as Key=Value (repeatable; empty value removes)")
+	cmd.RegisterFlagCompletionFunc("jump", completeSSHHostDefs)
+}
+
+// apply copies the flags that were set onto h
+func (fl *sshHostFlags) apply(cmd *cobra.Command, h *sshHostDef) error {
+	changed := cmd.Flags().Changed
+	if changed("hostname") {
+		h.HostName = fl.hostname
+	}
+	if changed("user") {
+		h.User = fl.user
+	}
+	if changed("port") {
+		h.Port = fl.port
+	}
+	if changed("identity") {
+		h.Identity = fl.identity
+	}
+	if changed("jump") {
+		h.Jump = fl.jump
+	}
+	if changed("tag") {
+		h.Tags = fl.tags
+	}
+	for _, opt := range fl.options {
+		key, value, ok := strings.Cut(opt, "=")
+		if !ok {
+			return fmt.Errorf("--option %q: expected Key=Value", opt)
+		}
+		if value == "" {
+			delete(h.Options, key)
+			continue
+		}
+		if h.Options == nil {
+			h.Options = make(map[string]string)
+		}
+		h.Options[key] = value
+	}
+	return h.validate()
+}
+
+func newSSHHostsAddCmd() *cobra.Command {
+	var fl sshHostFlags
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "add <host>",
+		Short: "Add a host and regenerate ~/.ssh/config",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h := sshHostDef{Host: args[0]}
+			if err := fl.apply(cmd, &h); err != nil {
+				return err
+			}
+			return addSSHHostDef(h, dryRun)
+		},
+	}
+
+	fl.register(cmd)
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show the config change without saving")
+	cmd.MarkFlagRequired("hostname")
+	return cmd
+}
+
+// addSSHHostDef stores a new host and regenerates the config
+func addSSHHostDef(h sshHostDef, dryRun bool) error {
+	f, err := loadSSHHosts()
+	if err != nil {
+		return err
+	}
+	if f.find(h.Host) >= 0 {
+		Fail("Host %s already exists", h.Host)
+		Info("Change it with: blackdot tools ssh hosts edit %s", h.Host)
+		return fmt.Errorf("host %s exists", h.Host)
+	}
+	if scan, err := scanSSHConfig(filepath.Join(userSSHDir(), "config")); err == nil {
+		for _, existing := range scan.Hosts {
+			if existing.Name == h.Host {
+				Warn("%s is also declared in %s:%d; the first declaration wins", h.Host, tildePath(existing.Source), existing.Line)
+				break
+			}
+		}
+	}
+	f.Hosts = append(f.Hosts, h)
+	return saveAndApplySSHHosts(f, dryRun, fmt.Sprintf("Added %s", h.Host))
+}
+
+func newSSHHostsEditCmd() *cobra.Command {
+	var fl sshHostFlags
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:               "edit <host>",
+		Short:             "Change a host's settings",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeSSHHostDefs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := loadSSHHosts()
+			if err != nil {
+				return err
+			}
+			i := f.find(args[0])
+			if i < 0 {
+				Fail("Host %s not found in %s", args[0], tildePath(sshHostsPath()))
+				return fmt.Errorf("host %s not found", args[0])
+			}
+			if err := fl.apply(cmd, &f.Hosts[i]); err != nil {
+				return err
+			}
+			return saveAndApplySSHHosts(f, dryRun, fmt.Sprintf("Updated %s", args[0]))
+		},
+	}
+
+	fl.register(cmd)
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show the config change without saving")
+	return cmd
+}
+
+func newSSHHostsRemoveCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:               "remove <host>",
+		Aliases:           []string{"rm"},
+		Short:             "Remove a host and regenerate ~/.ssh/config",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeSSHHostDefs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := loadSSHHosts()
+			if err != nil {
+				return err
+			}
+			i := f.find(args[0])
+			if i < 0 {
+				Fail("Host %s not found in %s", args[0], tildePath(sshHostsPath()))
+				return fmt.Errorf("host %s not found", args[0])
+			}
+			f.Hosts = append(f.Hosts[:i], f.Hosts[i+1:]...)
+			return saveAndApplySSHHosts(f, dryRun, fmt.Sprintf("Removed %s", args[0]))
+		},
+	}
+
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show the config change without saving")
+	return cmd
+}
+
+func newSSHHostsApplyCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Regenerate the hosts section of ~/.ssh/config",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := loadSSHHosts()
+			if err != nil {
+				return err
+			}
+			if err := applySSHHosts(f, dryRun); err != nil {
+				Fail("%v", err)
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show the change without writing")
+	return cmd
+}
+
+// saveAndApplySSHHosts saves hosts.yaml and regenerates the config; a dry
+// run only shows the config change
+func saveAndApplySSHHosts(f *sshHostsFile, dryRun bool, done string) error {
+	if dryRun {
+		return applySSHHosts(f, true)
+	}
+	if err := saveSSHHosts(f); err != nil {
+		Fail("Failed to save %s: %v", tildePath(sshHostsPath()), err)
+		return err
+	}
+	Pass("%s in %s", done, tildePath(sshHostsPath()))
+	if err := applySSHHosts(f, false); err != nil {
+		Fail("Failed to update ssh config: %v", err)
+		return err
+	}
+	return nil
+}
+
+func newSSHHostsPushCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "push",
+		Short: "Store hosts.yaml in the vault (" + sshHostsItem + ")",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(sshHostsPath())
+			if err != nil {
+				Fail("Cannot read %s: %v", tildePath(sshHostsPath()), err)
+				return err
+			}
+			if _, err := parseSSHHosts(data); err != nil {
+				Fail("%v", err)
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+			defer cancel()
+			svc, err := newVaultService()
+			if err != nil {
+				Fail("Failed to create backend: %v", err)
+				return err
+			}
+			defer svc.Close()
+			result, err := svc.Create(ctx, sshHostsItem, string(data), true)
+			if err != nil {
+				failVaultOp("Failed to push "+sshHostsItem, err)
+				return err
+			}
+			if result.Updated {
+				Pass("Updated %s in vault", sshHostsItem)
+			} else {
+				Pass("Created %s in vault", sshHostsItem)
+			}
+			return nil
+		},
+	}
+}
+
+func newSSHHostsPullCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "pull",
+		Short: "Restore hosts.yaml from the vault and regenerate ~/.ssh/config",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			reader, err := openVaultReader(ctx)
+			if err != nil {
+				Fail("Vault not available: %v", err)
+				return err
+			}
+			defer reader.Close()
+			notes, _, err := reader.GetNotes(ctx, sshHostsItem)
+			if err != nil {
+				Fail("Failed to read %s: %v", sshHostsItem, err)
+				return err
+			}
+			f, err := parseSSHHosts([]byte(notes))
+			if err != nil {
+				Fail("%s: %v", sshHostsItem, err)
+				return err
+			}
+			return saveAndApplySSHHosts(f, dryRun, fmt.Sprintf("Restored %d hosts", len(f.Hosts)))
+		},
+	}
+
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show the config change without writing")
+	return cmd
+}
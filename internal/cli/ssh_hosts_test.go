@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestReplaceSSHHostsSection verifies the generated section goes before
+// the first Host block, replaces itself in place later, and leaves
+// hand-written entries alone
+func TestReplaceSSHHostsSection(t *testing.T) {
+	section := sshHostsBegin + "\nHost web\n    HostName 10.0.0.5\n" + sshHostsEnd + "\n"
+	config := "AddKeysToAgent yes\n\nHost *\n    User me\n"
+
+	got, err := replaceSSHHostsSection(config, section)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "AddKeysToAgent yes\n\n" + section + "\nHost *\n    User me\n"; got != want {
+		t.Errorf("first insert:\n%s\nwant:\n%s", got, want)
+	}
+
+	again, err := replaceSSHHostsSection(got+"Host mine\n", strings.Replace(section, "10.0.0.5", "10.0.0.6", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(again, "10.0.0.5") || !strings.Contains(again, "10.0.0.6") || !strings.HasSuffix(again, "Host mine\n") {
+		t.Errorf("replace:\n%s", again)
+	}
+	if strings.Count(again, sshHostsBegin) != 1 {
+		t.Errorf("section duplicated:\n%s", again)
+	}
+
+	if _, err := replaceSSHHostsSection(sshHostsBegin+"\nHost x\n", section); err == nil {
+		t.Error("unmatched marker accepted")
+	}
+}
+
+// TestSSHHostsCommands verifies add, edit and remove keep hosts.yaml and
+// the generated part of ~/.ssh/config in step, and reject injected lines
+func TestSSHHostsCommands(t *testing.T) {
+	setScheduleEnv(t, "")
+	home := os.Getenv("HOME")
+	configPath := filepath.Join(home, ".ssh", "config")
+	os.MkdirAll(filepath.Dir(configPath), 0700)
+	os.WriteFile(configPath, []byte("Host old\n    HostName old.example.com\n"), 0600)
+
+	run := func(args ...string) error {
+		cmd := newSSHHostsCmd()
+		cmd.SetArgs(args)
+		cmd.SilenceUsage, cmd.SilenceErrors = true, true
+		return cmd.Execute()
+	}
+
+	if err := run("add", "web", "--hostname", "10.0.0.5", "--user", "deploy", "--port", "2222", "--tag", "work", "-o", "ForwardAgent=no"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("add", "db", "--hostname", "10.0.0.9", "--jump", "web"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("add", "web", "--hostname", "dup"); err == nil {
+		t.Error("duplicate host accepted")
+	}
+	if err := run("add", "evil", "--hostname", "x\nProxyCommand sh"); err == nil {
+		t.Error("line break in hostname accepted")
+	}
+
+	data, _ := os.ReadFile(configPath)
+	config := string(data)
+	for _, want := range []string{"Host web\n    HostName 10.0.0.5\n    User deploy\n    Port 2222\n", "ForwardAgent no", "Host db\n    HostName 10.0.0.9\n    ProxyJump web\n", "Host old\n"} {
+		if !strings.Contains(config, want) {
+			t.Errorf("config missing %q:\n%s", want, config)
+		}
+	}
+	if strings.Index(config, "Host db") > strings.Index(config, "Host old") {
+		t.Errorf("generated hosts not before hand-written ones:\n%s", config)
+	}
+
+	if err := run("edit", "web", "--port", "22", "-o", "ForwardAgent="); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("remove", "db"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(configPath)
+	config = string(data)
+	if strings.Contains(config, "Port 2222") || strings.Contains(config, "ForwardAgent") || strings.Contains(config, "Host db") {
+		t.Errorf("edit/remove not applied:\n%s", config)
+	}
+
+	f, err := loadSSHHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Hosts) != 1 || f.Hosts[0].Host != "web" || !f.Hosts[0].hasTag("WORK") {
+		t.Errorf("hosts.yaml = %+v", f.Hosts)
+	}
+}
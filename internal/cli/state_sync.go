@@ -0,0 +1,774 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/links"
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/safefile"
+	"github.com/spf13/cobra"
+)
+
+// Fleet state: non-secret settings shared between machines through a
+// private git repo (state.repo). Each sync commits this machine's state
+// to a local clone, merges what other machines pushed, and applies the
+// result here. Secrets never go in the repo; they stay in the vault.
+//
+// Repo layout:
+//
+//	shared.json          config.json keys in stateSharedKeys
+//	links.yaml           the blackdot repo's links.yaml
+//	machines/<id>.json   each machine's inventory record
+
+const (
+	stateSharedFile    = "shared.json"
+	stateMachinesDir   = "machines"
+	stateDefaultBranch = "main"
+)
+
+// stateSharedKeys are the config.json keys every machine shares
+var stateSharedKeys = []string{"features", "packages.tier"}
+
+// errStateNotConfigured means state.repo is unset
+var errStateNotConfigured = errors.New("no state repo configured (blackdot config set user state.repo <git url>)")
+
+func newStateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: "Sync non-secret state across machines through a git repo",
+		Long: `Share feature toggles, the package tier, links.yaml and the machine
+inventory between machines through a private git repository. Secrets are
+never written to it.
+
+Set the repo once per machine (it is cloned on first use):
+
+  blackdot config set user state.repo git@github.com:you/blackdot-state.git
+
+Each sync commits this machine's state, merges what other machines
+pushed, and applies the result. JSON files are merged key by key, so
+machines changing different settings never conflict. When two machines
+changed the same setting, or links.yaml, choose a side with --prefer.
+
+After each setup phase the state is pushed automatically; turn that off
+with 'blackdot config set user state.auto_sync false'.
+
+Commands:
+  status    Show the repo, unpushed changes and the fleet (default)
+  pull      Merge the fleet's state and apply it here
+  push      Pull, then publish this machine's state`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return stateStatus(false)
+		},
+	}
+
+	var noFetch bool
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the repo, unpushed changes and the fleet",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return stateStatus(noFetch)
+		},
+	}
+	statusCmd.Flags().BoolVar(&noFetch, "no-fetch", false, "Compare with the last fetched state instead of fetching")
+
+	var prefer string
+	pullCmd := &cobra.Command{
+		Use:   "pull",
+		Short: "Merge the fleet's state and apply it here",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStateSync(false, prefer)
+		},
+	}
+	pushCmd := &cobra.Command{
+		Use:   "push",
+		Short: "Pull, then publish this machine's state",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStateSync(true, prefer)
+		},
+	}
+	for _, c := range []*cobra.Command{pullCmd, pushCmd} {
+		c.Flags().StringVar(&prefer, "prefer", "", "Resolve conflicts with this machine's (ours) or the fleet's (theirs) version")
+	}
+
+	cmd.AddCommand(statusCmd, pullCmd, pushCmd)
+	return cmd
+}
+
+// stateRepoURL is state.repo. Only trusted layers are read so a project
+// file cannot send machine state elsewhere.
+func stateRepoURL() string {
+	url, _ := trustedConfigLookup("state.repo")
+	return url
+}
+
+// stateBranch is state.branch, or main
+func stateBranch() string {
+	if branch, _ := trustedConfigLookup("state.branch"); branch != "" {
+		return branch
+	}
+	return stateDefaultBranch
+}
+
+// stateAutoSync is state.auto_sync (default true): push after setup phases
+func stateAutoSync() bool {
+	val, _ := trustedConfigLookup("state.auto_sync")
+	return val != "false"
+}
+
+// stateRepoDir is the local clone of the state repo
+func stateRepoDir() string {
+	return filepath.Join(paths.DataDir(), "state-repo")
+}
+
+// stateGit runs git in the state repo. Commits made without a configured
+// identity are attributed to blackdot on this machine.
+func stateGit(args ...string) (string, error) {
+	full := []string{"-C", stateRepoDir()}
+	if args[0] == "commit" || args[0] == "merge" {
+		if out, _ := exec.Command("git", "-C", stateRepoDir(), "config", "user.email").Output(); len(out) == 0 {
+			full = append(full, "-c", "user.name=blackdot", "-c", "user.email=blackdot@"+currentMachineID())
+		}
+	}
+	cmd := exec.Command("git", append(full, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// openStateRepo clones state.repo on first use and keeps origin pointing
+// at it. fresh reports a clone made now, whose content this machine has
+// not seen yet.
+func openStateRepo() (fresh bool, err error) {
+	url := stateRepoURL()
+	if url == "" {
+		return false, errStateNotConfigured
+	}
+	dir := stateRepoDir()
+	if fileExists(filepath.Join(dir, ".git")) {
+		if current, _ := stateGit("remote", "get-url", "origin"); current != url {
+			if _, err := stateGit("remote", "set-url", "origin", url); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0700); err != nil {
+		return false, err
+	}
+	cmd := exec.Command("git", "clone", "-q", url, dir)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return false, fmt.Errorf("cloning %s: %s", url, strings.TrimSpace(string(out)))
+	}
+	branch := stateBranch()
+	if _, err := stateGit("rev-parse", "--verify", "-q", "origin/"+branch); err == nil {
+		_, err = stateGit("checkout", "-q", "-B", branch, "origin/"+branch)
+		return true, err
+	}
+	// An empty repo: the first commit goes on the configured branch
+	_, err = stateGit("symbolic-ref", "HEAD", "refs/heads/"+branch)
+	return true, err
+}
+
+// localStateFiles is the repo content this machine's state calls for.
+// On a fresh clone the fleet's values win and this machine only fills in
+// what the repo lacks. touch refreshes the inventory record's last_seen;
+// otherwise an unchanged record keeps its file.
+func localStateFiles(fresh, touch bool) (map[string][]byte, error) {
+	dir := stateRepoDir()
+	files := make(map[string][]byte)
+
+	var local map[string]interface{}
+	if data, err := os.ReadFile(configLayerUser); err == nil {
+		if err := json.Unmarshal(data, &local); err != nil {
+			return nil, fmt.Errorf("%s: %w", configLayerUser, err)
+		}
+	}
+	shared := make(map[string]interface{})
+	for _, key := range stateSharedKeys {
+		if v, ok := jsonPathGet(local, key); ok {
+			jsonPathSet(shared, key, v)
+		}
+	}
+	if fresh {
+		var repo map[string]interface{}
+		if data, err := os.ReadFile(filepath.Join(dir, stateSharedFile)); err == nil && json.Unmarshal(data, &repo) == nil {
+			mergeMissingJSON(repo, shared)
+			shared = repo
+		}
+	}
+	files[stateSharedFile] = marshalStateJSON(shared)
+
+	linksPath := filepath.Join(BlackdotDir(), links.FileName)
+	if data, err := os.ReadFile(linksPath); err == nil {
+		if repoLinks, err := os.ReadFile(filepath.Join(dir, links.FileName)); fresh && err == nil {
+			data = repoLinks
+		}
+		files[links.FileName] = data
+	}
+
+	record := collectMachineRecord(time.Now())
+	rel := filepath.Join(stateMachinesDir, record.ID+".json")
+	if !touch {
+		var existing machineRecord
+		if data, err := os.ReadFile(filepath.Join(dir, rel)); err == nil && json.Unmarshal(data, &existing) == nil {
+			seen := existing.LastSeen
+			existing.LastSeen = record.LastSeen
+			if reflect.DeepEqual(&existing, record) {
+				record.LastSeen = seen
+			}
+		}
+	}
+	data, _ := json.MarshalIndent(record, "", "  ")
+	files[rel] = append(data, '\n')
+	return files, nil
+}
+
+// marshalStateJSON formats a state file so diffs and merges are by line
+func marshalStateJSON(v interface{}) []byte {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	return append(data, '\n')
+}
+
+// commitLocalState writes this machine's state into the clone and
+// commits it. It reports whether there was anything to commit.
+func commitLocalState(fresh, touch bool) (bool, error) {
+	files, err := localStateFiles(fresh, touch)
+	if err != nil {
+		return false, err
+	}
+	for rel, data := range files {
+		path := filepath.Join(stateRepoDir(), rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return false, err
+		}
+	}
+	if _, err := stateGit("add", "-A"); err != nil {
+		return false, err
+	}
+	if _, err := stateGit("diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+	_, err = stateGit("commit", "-q", "-m", "state: "+currentMachineID())
+	return err == nil, err
+}
+
+// mergeFleetState fetches and merges what other machines pushed. JSON
+// files are merged key by key; a setting both sides changed, or any other
+// file changed on both sides, is a conflict unless prefer is ours or
+// theirs. It returns the conflicts prefer settled.
+func mergeFleetState(prefer string) (int, []string, error) {
+	branch := stateBranch()
+	if _, err := stateGit("fetch", "-q", "origin"); err != nil {
+		return 0, nil, err
+	}
+	remote := "origin/" + branch
+	if _, err := stateGit("rev-parse", "--verify", "-q", remote); err != nil {
+		return 0, nil, nil // nothing pushed yet
+	}
+	incoming := 0
+	if _, err := stateGit("rev-parse", "--verify", "-q", "HEAD"); err != nil {
+		_, err = stateGit("checkout", "-q", "-B", branch, remote)
+		return 1, nil, err
+	}
+	if out, err := stateGit("rev-list", "--count", "HEAD.."+remote); err == nil {
+		incoming, _ = strconv.Atoi(out)
+	}
+	if incoming == 0 {
+		return 0, nil, nil
+	}
+	if _, err := stateGit("merge", "-q", "--no-edit", remote); err == nil {
+		return incoming, nil, nil
+	}
+
+	out, err := stateGit("diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		stateGit("merge", "--abort")
+		return 0, nil, err
+	}
+	var settled, unresolved []string
+	for _, rel := range strings.Fields(out) {
+		if strings.HasSuffix(rel, ".json") {
+			var sides [3]map[string]interface{}
+			for i, stage := range []string{":1:", ":2:", ":3:"} {
+				if data, err := stateGit("show", stage+rel); err == nil {
+					json.Unmarshal([]byte(data), &sides[i])
+				}
+			}
+			var conflicts []string
+			merged := mergeJSON3(sides[0], sides[1], sides[2], prefer == "theirs", "", &conflicts)
+			if len(conflicts) > 0 && prefer == "" {
+				for _, c := range conflicts {
+					unresolved = append(unresolved, rel+": "+c)
+				}
+				continue
+			}
+			for _, c := range conflicts {
+				settled = append(settled, rel+": "+c)
+			}
+			if err := os.WriteFile(filepath.Join(stateRepoDir(), rel), marshalStateJSON(merged), 0600); err != nil {
+				stateGit("merge", "--abort")
+				return 0, nil, err
+			}
+		} else {
+			if prefer == "" {
+				unresolved = append(unresolved, rel)
+				continue
+			}
+			if _, err := stateGit("checkout", "--"+prefer, "--", rel); err != nil {
+				stateGit("merge", "--abort")
+				return 0, nil, err
+			}
+			settled = append(settled, rel)
+		}
+		if _, err := stateGit("add", "--", rel); err != nil {
+			stateGit("merge", "--abort")
+			return 0, nil, err
+		}
+	}
+	if len(unresolved) > 0 {
+		stateGit("merge", "--abort")
+		return 0, nil, &stateConflictError{unresolved}
+	}
+	_, err = stateGit("commit", "-q", "--no-edit")
+	return incoming, settled, err
+}
+
+// stateConflictError lists what both this machine and the fleet changed
+type stateConflictError struct {
+	Paths []string
+}
+
+func (e *stateConflictError) Error() string {
+	return "changed here and by another machine: " + strings.Join(e.Paths, ", ")
+}
+
+// mergeJSON3 merges two edits of base key by key. A key only one side
+// changed takes that side's value; objects both sides changed are merged
+// recursively; anything else is a conflict, resolved to theirs or ours
+// and recorded in conflicts.
+func mergeJSON3(base, ours, theirs map[string]interface{}, preferTheirs bool, path string, conflicts *[]string) map[string]interface{} {
+	keys := make(map[string]bool)
+	for _, m := range []map[string]interface{}{base, ours, theirs} {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+	merged := make(map[string]interface{})
+	for _, k := range sortedKeys(keys) {
+		b, inBase := base[k]
+		o, inOurs := ours[k]
+		t, inTheirs := theirs[k]
+		pick := func(v interface{}, present bool) {
+			if present {
+				merged[k] = v
+			}
+		}
+		switch {
+		case inOurs == inTheirs && reflect.DeepEqual(o, t):
+			pick(o, inOurs)
+		case inOurs == inBase && reflect.DeepEqual(o, b):
+			pick(t, inTheirs)
+		case inTheirs == inBase && reflect.DeepEqual(t, b):
+			pick(o, inOurs)
+		default:
+			om, oursIsObj := o.(map[string]interface{})
+			tm, theirsIsObj := t.(map[string]interface{})
+			if oursIsObj && theirsIsObj {
+				bm, _ := b.(map[string]interface{})
+				merged[k] = mergeJSON3(bm, om, tm, preferTheirs, joinStatePath(path, k), conflicts)
+				continue
+			}
+			*conflicts = append(*conflicts, joinStatePath(path, k))
+			if preferTheirs {
+				pick(t, inTheirs)
+			} else {
+				pick(o, inOurs)
+			}
+		}
+	}
+	return merged
+}
+
+func joinStatePath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// mergeMissingJSON copies into dst what src has and dst lacks
+func mergeMissingJSON(dst, src map[string]interface{}) {
+	for k, v := range src {
+		existing, ok := dst[k]
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		if dm, ok := existing.(map[string]interface{}); ok {
+			if sm, ok := v.(map[string]interface{}); ok {
+				mergeMissingJSON(dm, sm)
+			}
+		}
+	}
+}
+
+// jsonPathGet returns the value at a dotted key
+func jsonPathGet(doc map[string]interface{}, key string) (interface{}, bool) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := doc[part].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		doc = next
+	}
+	v, ok := doc[parts[len(parts)-1]]
+	return v, ok
+}
+
+// jsonPathSet sets a dotted key, creating objects on the way
+func jsonPathSet(doc map[string]interface{}, key string, v interface{}) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := doc[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			doc[part] = next
+		}
+		doc = next
+	}
+	doc[parts[len(parts)-1]] = v
+}
+
+// jsonPathDelete removes a dotted key
+func jsonPathDelete(doc map[string]interface{}, key string) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := doc[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		doc = next
+	}
+	delete(doc, parts[len(parts)-1])
+}
+
+// applyFleetState writes the merged shared settings and links.yaml to this
+// machine and returns what changed
+func applyFleetState() ([]string, error) {
+	dir := stateRepoDir()
+	var changed []string
+
+	var shared map[string]interface{}
+	if data, err := os.ReadFile(filepath.Join(dir, stateSharedFile)); err == nil {
+		if err := json.Unmarshal(data, &shared); err != nil {
+			return nil, fmt.Errorf("%s: %w", stateSharedFile, err)
+		}
+	}
+	if shared != nil {
+		os.MkdirAll(filepath.Dir(configLayerUser), 0755)
+		err := safefile.Update(configLayerUser, 0644, func(data []byte) ([]byte, error) {
+			doc := make(map[string]interface{})
+			if data != nil {
+				if err := json.Unmarshal(data, &doc); err != nil {
+					return nil, fmt.Errorf("%s: %w", configLayerUser, err)
+				}
+			}
+			for _, key := range stateSharedKeys {
+				want, inRepo := jsonPathGet(shared, key)
+				have, inLocal := jsonPathGet(doc, key)
+				if inRepo == inLocal && reflect.DeepEqual(want, have) {
+					continue
+				}
+				if inRepo {
+					jsonPathSet(doc, key, want)
+				} else {
+					jsonPathDelete(doc, key)
+				}
+				changed = append(changed, key)
+			}
+			if len(changed) == 0 {
+				return nil, nil
+			}
+			return json.MarshalIndent(doc, "", "  ")
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, links.FileName)); err == nil {
+		target := filepath.Join(BlackdotDir(), links.FileName)
+		if current, _ := os.ReadFile(target); string(current) != string(data) {
+			if err := writeFileAtomic(target, data, 0644); err != nil {
+				return changed, err
+			}
+			changed = append(changed, links.FileName)
+		}
+	}
+
+	for _, key := range changed {
+		if key == "features" {
+			registry = nil
+			refreshFeatureSnippets()
+		}
+	}
+	return changed, nil
+}
+
+// stateSyncResult is what one pull or push did
+type stateSyncResult struct {
+	Committed bool
+	Incoming  int
+	Settled   []string
+	Applied   []string
+	Pushed    bool
+}
+
+// syncFleetState commits local state, merges the fleet's, applies the
+// result, and with push publishes it
+func syncFleetState(push bool, prefer string) (*stateSyncResult, error) {
+	if prefer != "" && prefer != "ours" && prefer != "theirs" {
+		return nil, fmt.Errorf("--prefer must be ours or theirs, not %q", prefer)
+	}
+	fresh, err := openStateRepo()
+	if err != nil {
+		return nil, err
+	}
+	result := &stateSyncResult{}
+	if result.Committed, err = commitLocalState(fresh, push); err != nil {
+		return nil, err
+	}
+	if result.Incoming, result.Settled, err = mergeFleetState(prefer); err != nil {
+		return result, err
+	}
+	if result.Applied, err = applyFleetState(); err != nil {
+		return result, err
+	}
+	if push {
+		if _, err := stateGit("rev-parse", "--verify", "-q", "HEAD"); err != nil {
+			return result, nil // nothing to publish
+		}
+		ahead := "1"
+		if _, err := stateGit("rev-parse", "--verify", "-q", "origin/"+stateBranch()); err == nil {
+			ahead, _ = stateGit("rev-list", "--count", "origin/"+stateBranch()+"..HEAD")
+		}
+		if ahead != "0" {
+			if _, err := stateGit("push", "-q", "origin", "HEAD:refs/heads/"+stateBranch()); err != nil {
+				return result, err
+			}
+			result.Pushed = true
+		}
+	}
+	return result, nil
+}
+
+func runStateSync(push bool, prefer string) error {
+	title := "Pull Fleet State"
+	if push {
+		title = "Push Fleet State"
+	}
+	PrintHeader(title)
+
+	result, err := syncFleetState(push, prefer)
+	var conflict *stateConflictError
+	switch {
+	case errors.As(err, &conflict):
+		Fail("Both this machine and the fleet changed:")
+		for _, p := range conflict.Paths {
+			fmt.Printf("    %s\n", p)
+		}
+		fmt.Println()
+		fmt.Println("Keep this machine's values:  blackdot state pull --prefer ours")
+		fmt.Println("Take the fleet's values:     blackdot state pull --prefer theirs")
+		return err
+	case err != nil:
+		Fail("%v", err)
+		return err
+	}
+
+	if result.Committed {
+		Pass("Committed this machine's state")
+	}
+	if result.Incoming > 0 {
+		Pass("Merged %d change(s) from other machines", result.Incoming)
+	}
+	for _, s := range result.Settled {
+		Warn("Conflict settled with --prefer %s: %s", prefer, s)
+	}
+	for _, key := range result.Applied {
+		Pass("Updated %s", key)
+	}
+	if result.Pushed {
+		Pass("Pushed to %s (%s)", stateRepoURL(), stateBranch())
+	}
+	if !result.Committed && result.Incoming == 0 && len(result.Applied) == 0 && !result.Pushed {
+		Pass("Already in sync")
+	}
+	return nil
+}
+
+// syncStateAfterSetup pushes state after a setup phase when a state repo
+// is configured. Failures only warn; setup carries on.
+func syncStateAfterSetup() {
+	if stateRepoURL() == "" || !stateAutoSync() || isOfflineMode() {
+		return
+	}
+	if _, err := syncFleetState(true, ""); err != nil {
+		Warn("Could not sync fleet state: %v", err)
+		fmt.Println("  Run 'blackdot state push' to retry")
+		return
+	}
+	Debug("Synced fleet state to %s", stateRepoURL())
+}
+
+func stateStatus(noFetch bool) error {
+	PrintHeader("Fleet State")
+
+	url := stateRepoURL()
+	if url == "" {
+		Info("No state repo configured")
+		fmt.Println("Share state between machines with:")
+		fmt.Println("  blackdot config set user state.repo <private git url>")
+		fmt.Println("  blackdot state push")
+		return nil
+	}
+	dir := stateRepoDir()
+	fmt.Printf("  Repo:    %s (%s)\n", url, stateBranch())
+	fmt.Printf("  Clone:   %s\n", tildePath(dir))
+	if !fileExists(filepath.Join(dir, ".git")) {
+		fmt.Println()
+		Info("Not cloned yet - run 'blackdot state pull'")
+		return nil
+	}
+	if when, err := stateGit("log", "-1", "--format=%cI"); err == nil && when != "" {
+		fmt.Printf("  Synced:  %s\n", formatTimeAgo(when))
+	}
+	fmt.Println()
+
+	remote := "origin/" + stateBranch()
+	if !noFetch {
+		if _, err := stateGit("fetch", "-q", "origin"); err != nil {
+			Warn("Could not fetch: %v", err)
+		}
+	}
+	if counts, err := stateGit("rev-list", "--left-right", "--count", "HEAD..."+remote); err == nil {
+		var ahead, behind int
+		fmt.Sscan(counts, &ahead, &behind)
+		switch {
+		case ahead == 0 && behind == 0:
+			Pass("Up to date with %s", remote)
+		default:
+			if ahead > 0 {
+				Warn("%d commit(s) not pushed", ahead)
+			}
+			if behind > 0 {
+				Warn("%d change(s) from other machines not pulled", behind)
+			}
+		}
+	}
+
+	pending, err := pendingStateChanges()
+	if err != nil {
+		Warn("%v", err)
+	} else if len(pending) > 0 {
+		Warn("Changed here since the last sync: %s", strings.Join(pending, ", "))
+	} else {
+		Pass("No local changes since the last sync")
+	}
+
+	records := loadStateMachines()
+	if len(records) > 0 {
+		fmt.Println()
+		BoldCyan.Println("Machines:")
+		self := currentMachineID()
+		for _, r := range records {
+			name := r.ID
+			if r.ID == self {
+				name += " *"
+			}
+			seen, _ := parseTimestamp(r.LastSeen)
+			fmt.Printf("  %-22s %-14s %s  %s\n", name, r.OS+"/"+r.Arch, Dim.Sprintf("seen %s", seen), Dim.Sprintf("%d features", len(r.Features)))
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// pendingStateChanges names the shared settings and files that differ
+// between this machine and the clone
+func pendingStateChanges() ([]string, error) {
+	files, err := localStateFiles(false, false)
+	if err != nil {
+		return nil, err
+	}
+	dir := stateRepoDir()
+	var pending []string
+	for _, rel := range sortedKeys(files) {
+		current, _ := os.ReadFile(filepath.Join(dir, rel))
+		if string(current) == string(files[rel]) {
+			continue
+		}
+		if rel != stateSharedFile {
+			pending = append(pending, rel)
+			continue
+		}
+		var want, have map[string]interface{}
+		json.Unmarshal(files[rel], &want)
+		json.Unmarshal(current, &have)
+		for _, key := range stateSharedKeys {
+			w, inWant := jsonPathGet(want, key)
+			h, inHave := jsonPathGet(have, key)
+			if inWant != inHave || !reflect.DeepEqual(w, h) {
+				pending = append(pending, key)
+			}
+		}
+	}
+	return pending, nil
+}
+
+// loadStateMachines reads the inventory records in the clone
+func loadStateMachines() []*machineRecord {
+	entries, _ := os.ReadDir(filepath.Join(stateRepoDir(), stateMachinesDir))
+	var records []*machineRecord
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(stateRepoDir(), stateMachinesDir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var r machineRecord
+		if json.Unmarshal(data, &r) == nil && r.ID != "" {
+			records = append(records, &r)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// stateMachine is a machine with its own home, config and blackdot
+// directory, syncing through a shared remote
+type stateMachine struct {
+	id, home string
+}
+
+// useStateMachines isolates config for a fleet test and returns a
+// function that switches to a machine
+func useStateMachines(t *testing.T, remote string) func(stateMachine) {
+	t.Helper()
+	setScheduleEnv(t, "")
+	saved := blackdotDir
+	t.Cleanup(func() { blackdotDir = saved })
+	t.Setenv("BLACKDOT_STATE_REPO", remote)
+	return func(m stateMachine) {
+		t.Setenv("HOME", m.home)
+		t.Setenv("XDG_CONFIG_HOME", filepath.Join(m.home, ".config"))
+		t.Setenv("XDG_DATA_HOME", filepath.Join(m.home, ".data"))
+		t.Setenv("XDG_STATE_HOME", filepath.Join(m.home, ".state"))
+		t.Setenv("BLACKDOT_MACHINE_IDENTIFIER", m.id)
+		configLayerUser = filepath.Join(m.home, ".config", "blackdot", "config.json")
+		configLayerMachine = filepath.Join(m.home, ".config", "blackdot", "machine.json")
+		blackdotDir = filepath.Join(m.home, ".blackdot")
+		os.MkdirAll(blackdotDir, 0755)
+		os.MkdirAll(filepath.Dir(configLayerUser), 0755)
+	}
+}
+
+// stateFeatures reads the features section of the current user config
+func stateFeatures(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	data, _ := os.ReadFile(configLayerUser)
+	json.Unmarshal(data, &doc)
+	features, _ := doc["features"].(map[string]interface{})
+	return features
+}
+
+// TestFleetStateSync verifies machines share settings through the repo,
+// a new machine keeps its own settings the fleet lacks, changes to
+// different keys merge, and the same key changed twice needs --prefer
+func TestFleetStateSync(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	remote := filepath.Join(t.TempDir(), "state.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %s", out)
+	}
+	use := useStateMachines(t, remote)
+	laptop := stateMachine{"laptop", t.TempDir()}
+	desktop := stateMachine{"desktop", t.TempDir()}
+
+	use(laptop)
+	os.WriteFile(configLayerUser, []byte(`{"features": {"vault": true}, "packages": {"tier": "full"}}`), 0644)
+	os.WriteFile(filepath.Join(blackdotDir, "links.yaml"), []byte("links: []\n"), 0644)
+	if _, err := syncFleetState(true, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	use(desktop)
+	os.WriteFile(configLayerUser, []byte(`{"features": {"docker_tools": true}}`), 0644)
+	result, err := syncFleetState(true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"vault": true, "docker_tools": true}; !reflect.DeepEqual(stateFeatures(t), want) {
+		t.Errorf("desktop features = %v, want %v", stateFeatures(t), want)
+	}
+	if data, _ := os.ReadFile(filepath.Join(blackdotDir, "links.yaml")); string(data) != "links: []\n" {
+		t.Errorf("links.yaml not applied: %q", data)
+	}
+	if len(result.Applied) == 0 || !result.Pushed {
+		t.Errorf("result = %+v", result)
+	}
+	if got := loadStateMachines(); len(got) != 2 || got[0].ID != "desktop" || got[1].ID != "laptop" {
+		t.Errorf("machines = %+v", got)
+	}
+
+	// Different keys changed on each machine merge cleanly
+	setInJSONFile(configLayerUser, "features.vault", "false")
+	if _, err := syncFleetState(true, ""); err != nil {
+		t.Fatal(err)
+	}
+	use(laptop)
+	setInJSONFile(configLayerUser, "features.ai_tools", "true")
+	if _, err := syncFleetState(true, ""); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"vault": false, "docker_tools": true, "ai_tools": true}; !reflect.DeepEqual(stateFeatures(t), want) {
+		t.Errorf("laptop features = %v, want %v", stateFeatures(t), want)
+	}
+
+	// The same key changed on both is a conflict until a side is chosen
+	setInJSONFile(configLayerUser, "packages.tier", "minimal")
+	if _, err := syncFleetState(true, ""); err != nil {
+		t.Fatal(err)
+	}
+	use(desktop)
+	setInJSONFile(configLayerUser, "packages.tier", "enhanced")
+
+	_, err = syncFleetState(false, "")
+	var conflict *stateConflictError
+	if !errors.As(err, &conflict) || len(conflict.Paths) != 1 || conflict.Paths[0] != "shared.json: packages.tier" {
+		t.Fatalf("err = %v, want a packages.tier conflict", err)
+	}
+	if got := getFromJSONFile(configLayerUser, "packages.tier"); got != "enhanced" {
+		t.Errorf("packages.tier = %s after a conflict, want it unchanged", got)
+	}
+	if result, err := syncFleetState(false, "theirs"); err != nil || len(result.Settled) != 1 {
+		t.Fatalf("prefer theirs: %+v, %v", result, err)
+	}
+	if got := getFromJSONFile(configLayerUser, "packages.tier"); got != "minimal" {
+		t.Errorf("packages.tier = %s, want the fleet's minimal", got)
+	}
+	if got := getFromJSONFile(configLayerUser, "features.ai_tools"); got != "true" {
+		t.Errorf("features.ai_tools = %q, want true merged from the laptop", got)
+	}
+}
+
+// TestMergeJSON3 verifies key-level three-way merges
+func TestMergeJSON3(t *testing.T) {
+	base := map[string]interface{}{"features": map[string]interface{}{"a": true, "b": true}, "tier": "full"}
+	ours := map[string]interface{}{"features": map[string]interface{}{"a": false, "b": true}, "tier": "minimal"}
+	theirs := map[string]interface{}{"features": map[string]interface{}{"a": true, "c": true}, "tier": "enhanced"}
+
+	var conflicts []string
+	got := mergeJSON3(base, ours, theirs, false, "", &conflicts)
+	want := map[string]interface{}{"features": map[string]interface{}{"a": false, "c": true}, "tier": "minimal"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("merged = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(conflicts, []string{"tier"}) {
+		t.Errorf("conflicts = %v", conflicts)
+	}
+
+	conflicts = nil
+	if got := mergeJSON3(base, ours, theirs, true, "", &conflicts); got["tier"] != "enhanced" {
+		t.Errorf("prefer theirs: tier = %v", got["tier"])
+	}
+}
@@ -1,25 +1,83 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
+// statusItem is one line of the dashboard. Highlight colors the detail
+// green when ok and red when not; otherwise it is dimmed.
 type statusItem struct {
-	name string
-	ok   bool
-	info string
-	fix  string
-	skip bool // Don't show this item
+	Name      string `json:"name"`
+	OK        bool   `json:"ok"`
+	Detail    string `json:"detail"`
+	Fix       string `json:"fix,omitempty"`
+	highlight bool
+}
+
+// statusReport is everything 'blackdot status' shows
+type statusReport struct {
+	Items     []statusItem     `json:"items"`
+	Features  statusFeatures   `json:"features"`
+	Drift     *statusDrift     `json:"drift,omitempty"`
+	Health    *statusHealth    `json:"health,omitempty"`
+	Templates *statusTemplates `json:"templates,omitempty"`
+	Updates   statusUpdates    `json:"updates"`
+	Fixes     []string         `json:"fixes"`
+}
+
+type statusFeatures struct {
+	Enabled []string `json:"enabled"`
+	Total   int      `json:"total"`
+}
+
+// statusDrift counts items by drift state, from a local (quick) check
+// against the last vault pull
+type statusDrift struct {
+	Baseline string         `json:"baseline,omitempty"`
+	InSync   int            `json:"in_sync"`
+	Drifted  []string       `json:"drifted"`
+	States   map[string]int `json:"states"`
+}
+
+// statusHealth is the last 'blackdot doctor' run
+type statusHealth struct {
+	Score    int    `json:"score"`
+	Errors   int    `json:"errors"`
+	Warnings int    `json:"warnings"`
+	Time     string `json:"time"`
+}
+
+type statusTemplates struct {
+	Rendered    int      `json:"rendered"`
+	Stale       []string `json:"stale"`
+	Edited      []string `json:"edited"`
+	NotRendered int      `json:"not_rendered"`
+}
+
+// statusUpdates is what is known without touching the network: commits
+// on the upstream branch as of the last fetch, and the last release seen
+// by 'blackdot upgrade'
+type statusUpdates struct {
+	Branch        string `json:"branch,omitempty"`
+	Behind        int    `json:"behind"`
+	Version       string `json:"version"`
+	LatestRelease string `json:"latest_release,omitempty"`
+	ReleaseNewer  bool   `json:"release_newer"`
 }
 
 func newStatusCmd() *cobra.Command {
+	var jsonOut bool
+
 	cmd := &cobra.Command{
 		Use:     "status",
 		Aliases: []string{"s"},
@@ -29,140 +87,247 @@ func newStatusCmd() *cobra.Command {
 Shows:
   - Symlink status (zshrc, claude, /workspace)
   - SSH keys loaded
-  - AWS authentication status
+  - Vault backend and AWS authentication status
   - Lima VM status (macOS only)
-  - Claude profile (if dotclaude available)
+  - Active Claude profile (if ~/.claude/profiles.json exists)
+  - Enabled features
+  - Drift summary (local files vs last vault pull, no vault access)
+  - Health score from the last 'blackdot doctor' run
+  - Stale or unrendered templates
+  - Pending updates (commits behind upstream as of the last fetch,
+    newer release seen by 'blackdot upgrade')
 
 Examples:
-  blackdot status    # Quick visual dashboard
-  blackdot s         # Short alias`,
-		RunE: runStatus,
+  blackdot status          # Quick visual dashboard
+  blackdot s               # Short alias
+  blackdot status --json   # Machine-readable`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report := collectStatus()
+			if jsonOut {
+				data, _ := json.MarshalIndent(report, "", "  ")
+				fmt.Println(string(data))
+				return nil
+			}
+			printStatus(report)
+			return nil
+		},
 	}
 
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON")
 	return cmd
 }
 
-func runStatus(cmd *cobra.Command, args []string) error {
+// collectStatus gathers the dashboard. Every check is local or a quick
+// CLI call; nothing prompts or unlocks the vault.
+func collectStatus() *statusReport {
 	home, _ := os.UserHomeDir()
-
-	// Colors
-	green := color.New(color.FgGreen).SprintFunc()
-	red := color.New(color.FgRed).SprintFunc()
-	dim := color.New(color.Faint).SprintFunc()
-
-	var items []statusItem
-	var fixes []string
+	report := &statusReport{}
+	add := func(item statusItem) {
+		report.Items = append(report.Items, item)
+		if !item.OK && item.Fix != "" {
+			report.Fixes = append(report.Fixes, item.Fix)
+		}
+	}
 
 	// Check zshrc symlink
-	zshrcPath := filepath.Join(home, ".zshrc")
-	zshrcItem := statusItem{name: "zshrc"}
-	if isSymlink(zshrcPath) {
-		zshrcItem.ok = true
-		zshrcItem.info = dim("→ blackdot/zsh/zshrc")
+	if isSymlink(filepath.Join(home, ".zshrc")) {
+		add(statusItem{Name: "zshrc", OK: true, Detail: "→ blackdot/zsh/zshrc"})
 	} else {
-		zshrcItem.ok = false
-		zshrcItem.info = "not linked"
-		zshrcItem.fix = "zshrc: run bootstrap"
-		fixes = append(fixes, zshrcItem.fix)
+		add(statusItem{Name: "zshrc", Detail: "not linked", Fix: "zshrc: blackdot links apply"})
 	}
-	items = append(items, zshrcItem)
 
 	// Check claude symlink
-	claudePath := filepath.Join(home, ".claude")
-	claudeItem := statusItem{name: "claude"}
-	if isSymlink(claudePath) {
-		claudeItem.ok = true
-		claudeItem.info = dim("→ workspace/.claude")
+	if isSymlink(filepath.Join(home, ".claude")) {
+		add(statusItem{Name: "claude", OK: true, Detail: "→ workspace/.claude"})
 	} else {
-		claudeItem.ok = false
-		claudeItem.info = "not linked"
-		claudeItem.fix = "claude: bootstrap-blackdot.sh"
-		fixes = append(fixes, claudeItem.fix)
+		add(statusItem{Name: "claude", Detail: "not linked", Fix: "claude: blackdot links apply"})
 	}
-	items = append(items, claudeItem)
 
 	// Check /workspace symlink
-	workspaceItem := statusItem{name: "/workspace"}
-	if isSymlink("/workspace") {
-		target, _ := os.Readlink("/workspace")
-		workspaceItem.ok = true
-		workspaceItem.info = dim("→ " + target)
+	if health := checkWorkspaceSymlink(workspaceSymlinkPath(), workspaceTarget()); health.Status == workspaceOK {
+		add(statusItem{Name: "/workspace", OK: true, Detail: "→ " + health.Expected})
 	} else {
-		workspaceItem.ok = false
-		workspaceItem.info = "missing"
-		workspaceItem.fix = "/workspace: sudo ln -sfn $HOME/workspace /workspace"
-		fixes = append(fixes, workspaceItem.fix)
+		add(statusItem{Name: "/workspace", Detail: health.Status, Fix: "/workspace: blackdot workspace check --repair"})
 	}
-	items = append(items, workspaceItem)
 
 	// Check SSH keys
-	sshItem := statusItem{name: "ssh"}
-	sshCount := countSSHKeys()
-	if sshCount > 0 {
-		sshItem.ok = true
-		sshItem.info = green(fmt.Sprintf("%d keys loaded", sshCount))
+	if sshCount := countSSHKeys(); sshCount > 0 {
+		add(statusItem{Name: "ssh", OK: true, Detail: fmt.Sprintf("%d keys loaded", sshCount), highlight: true})
 	} else {
-		sshItem.ok = false
-		sshItem.info = red("no keys")
-		sshItem.fix = "ssh: blackdot vault restore"
-		fixes = append(fixes, sshItem.fix)
+		add(statusItem{Name: "ssh", Detail: "no keys", Fix: "ssh: blackdot vault restore", highlight: true})
+	}
+
+	// Check vault backend: configured vs verified working
+	if backendName := configuredVaultBackend(); backendName != "" && backendName != "none" {
+		if verified, _ := vaultVerifiedState(); verified {
+			add(statusItem{Name: "vault", OK: true, Detail: backendName + " verified", highlight: true})
+		} else {
+			add(statusItem{Name: "vault", Detail: backendName + " configured, not verified", Fix: "vault: blackdot vault init"})
+		}
 	}
-	items = append(items, sshItem)
 
 	// Check AWS authentication
-	awsItem := statusItem{name: "aws"}
 	awsProfile := os.Getenv("_CLAUDE_BEDROCK_PROFILE")
 	if checkAWSAuth(awsProfile) {
-		awsItem.ok = true
-		awsItem.info = green("authenticated")
+		add(statusItem{Name: "aws", OK: true, Detail: "authenticated", highlight: true})
 	} else {
-		awsItem.ok = false
-		awsItem.info = dim("not authenticated")
+		item := statusItem{Name: "aws", Detail: "not authenticated"}
 		if awsProfile != "" {
-			awsItem.fix = fmt.Sprintf("aws: aws sso login --profile %s", awsProfile)
-			fixes = append(fixes, awsItem.fix)
+			item.Fix = fmt.Sprintf("aws: aws sso login --profile %s", awsProfile)
 		}
+		add(item)
 	}
-	items = append(items, awsItem)
 
 	// Check Lima (macOS only)
-	limaItem := statusItem{name: "lima", skip: true}
 	if isMacOS() {
 		if _, err := exec.LookPath("limactl"); err == nil {
-			limaItem.skip = false
 			if checkLimaRunning() {
-				limaItem.ok = true
-				limaItem.info = green("running")
+				add(statusItem{Name: "lima", OK: true, Detail: "running", highlight: true})
 			} else {
-				limaItem.ok = false
-				limaItem.info = dim("stopped")
-				limaItem.fix = "lima: limactl start"
-				fixes = append(fixes, limaItem.fix)
+				add(statusItem{Name: "lima", Detail: "stopped", Fix: "lima: limactl start"})
 			}
 		}
 	}
-	items = append(items, limaItem)
 
 	// Check Claude profile
-	profileItem := statusItem{name: "profile", skip: true}
-	if _, err := exec.LookPath("dotclaude"); err == nil {
-		profileItem.skip = false
-		profile := getClaudeProfile()
-		if profile != "" && profile != "none" {
-			profileItem.ok = true
-			profileItem.info = green(profile)
+	if fileExists(claudeProfilesPath()) {
+		if profile := activeClaudeProfile(); profile != "" {
+			add(statusItem{Name: "profile", OK: true, Detail: profile, highlight: true})
 		} else {
-			profileItem.ok = false
-			profileItem.info = dim("no active profile")
-			profileItem.fix = "profile: dotclaude switch <profile>"
-			fixes = append(fixes, profileItem.fix)
+			add(statusItem{Name: "profile", Detail: "no active profile", Fix: "profile: blackdot claude profiles use <profile>"})
 		}
 	} else if _, err := exec.LookPath("claude"); err == nil {
-		profileItem.skip = false
-		profileItem.ok = false
-		profileItem.info = dim("try: dotclaude")
+		add(statusItem{Name: "profile", Detail: "try: blackdot claude profiles"})
+	}
+
+	report.Features = collectStatusFeatures()
+
+	report.Drift = collectStatusDrift()
+	if report.Drift != nil && len(report.Drift.Drifted) > 0 {
+		report.Fixes = append(report.Fixes, "drift: blackdot drift")
+	}
+
+	report.Health = collectStatusHealth(home)
+	if report.Health == nil {
+		report.Fixes = append(report.Fixes, "health: blackdot doctor")
+	} else if report.Health.Score < 80 {
+		report.Fixes = append(report.Fixes, "health: blackdot doctor --fix")
+	}
+
+	report.Templates = collectStatusTemplates()
+	if t := report.Templates; t != nil && len(t.Edited) > 0 {
+		report.Fixes = append(report.Fixes, "templates: blackdot template render --fold")
+	} else if t != nil && (len(t.Stale) > 0 || t.NotRendered > 0) {
+		report.Fixes = append(report.Fixes, "templates: blackdot template render")
+	}
+
+	report.Updates = collectStatusUpdates(BlackdotDir())
+	if report.Updates.Behind > 0 || report.Updates.ReleaseNewer {
+		report.Fixes = append(report.Fixes, "updates: blackdot upgrade")
+	}
+
+	if report.Fixes == nil {
+		report.Fixes = []string{}
+	}
+	return report
+}
+
+func collectStatusFeatures() statusFeatures {
+	reg := initRegistry()
+	features := statusFeatures{Enabled: []string{}}
+	for _, f := range reg.All() {
+		features.Total++
+		if reg.Enabled(f.Name) {
+			features.Enabled = append(features.Enabled, f.Name)
+		}
+	}
+	return features
+}
+
+// collectStatusDrift runs a quick drift check; nil when nothing has been
+// pulled from the vault yet
+func collectStatusDrift() *statusDrift {
+	report, err := checkDrift(context.Background(), true, true)
+	if err != nil {
+		return nil
+	}
+	drift := &statusDrift{Baseline: report.Baseline, Drifted: []string{}, States: map[string]int{}}
+	for _, item := range report.Items {
+		drift.States[item.State]++
+		if item.State == driftInSync {
+			drift.InSync++
+		}
+	}
+	for _, item := range report.Drifted() {
+		drift.Drifted = append(drift.Drifted, item.Item)
+	}
+	return drift
+}
+
+// collectStatusHealth returns the last doctor run from the metrics log
+func collectStatusHealth(home string) *statusHealth {
+	entries, err := loadMetrics(filepath.Join(home, ".blackdot-metrics.jsonl"))
+	if err != nil || len(entries) == 0 {
+		return nil
 	}
-	items = append(items, profileItem)
+	last := entries[len(entries)-1]
+	return &statusHealth{Score: last.HealthScore, Errors: last.Errors, Warnings: last.Warnings, Time: last.Timestamp}
+}
+
+// collectStatusTemplates counts generated outputs; nil when the template
+// system has no templates
+func collectStatusTemplates() *statusTemplates {
+	cfg, err := getTemplateConfig()
+	if err != nil {
+		return nil
+	}
+	files, err := templateFiles(cfg)
+	if err != nil || len(files) == 0 {
+		return nil
+	}
+	templates := &statusTemplates{Stale: []string{}, Edited: []string{}}
+	for _, f := range files {
+		switch templateOutputState(cfg, f) {
+		case templateRendered:
+			templates.Rendered++
+		case templateStale:
+			templates.Stale = append(templates.Stale, strings.TrimSuffix(filepath.Base(f.Path), ".tmpl"))
+		case templateEdited:
+			templates.Edited = append(templates.Edited, strings.TrimSuffix(filepath.Base(f.Path), ".tmpl"))
+		default:
+			templates.NotRendered++
+		}
+	}
+	return templates
+}
+
+func collectStatusUpdates(dir string) statusUpdates {
+	updates := statusUpdates{Version: versionStr}
+
+	git := func(args ...string) string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	}
+	if upstream := git("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); upstream != "" {
+		updates.Branch = upstream
+		updates.Behind, _ = strconv.Atoi(git("rev-list", "--count", "HEAD..@{upstream}"))
+	}
+
+	if check := loadUpgradeCheck(); check != nil {
+		updates.LatestRelease = check.Latest
+		updates.ReleaseNewer = compareVersions(check.Latest, versionStr) > 0 && versionStr != "dev"
+	}
+	return updates
+}
+
+func printStatus(report *statusReport) {
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	dim := color.New(color.Faint).SprintFunc()
 
 	// Print city skyline ASCII art with blackdot branding
 	fmt.Println()
@@ -180,34 +345,92 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	fmt.Println(dim("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~"))
 	fmt.Println()
 
+	line := func(name string, ok bool, detail string) {
+		symbol := red("◇")
+		if ok {
+			symbol = green("◆")
+		}
+		fmt.Printf("  %-10s %s  %s\n", name, symbol, detail)
+	}
+
 	// Print status items
-	for _, item := range items {
-		if item.skip {
-			continue
+	for _, item := range report.Items {
+		detail := dim(item.Detail)
+		if item.highlight {
+			if item.OK {
+				detail = green(item.Detail)
+			} else {
+				detail = red(item.Detail)
+			}
 		}
+		line(item.Name, item.OK, detail)
+	}
+	fmt.Println()
 
-		var symbol string
-		if item.ok {
-			symbol = green("◆")
+	line("features", true, fmt.Sprintf("%d/%d enabled", len(report.Features.Enabled), report.Features.Total))
+
+	if d := report.Drift; d != nil {
+		if len(d.Drifted) == 0 {
+			line("drift", true, green(fmt.Sprintf("%d in sync", d.InSync))+dim(" vs last pull"))
 		} else {
-			symbol = red("◇")
+			line("drift", false, yellow(fmt.Sprintf("%d drifted", len(d.Drifted)))+dim(fmt.Sprintf(" (%s), %d in sync", strings.Join(d.Drifted, ", "), d.InSync)))
+		}
+	} else {
+		line("drift", false, dim("no vault pull yet"))
+	}
+
+	if h := report.Health; h != nil {
+		detail := fmt.Sprintf("%d/100", h.Score)
+		switch {
+		case h.Score >= 80:
+			detail = green(detail)
+		case h.Score >= 40:
+			detail = yellow(detail)
+		default:
+			detail = red(detail)
+		}
+		line("health", h.Score >= 80, detail+dim(" doctor "+formatTimeAgo(h.Time)))
+	} else {
+		line("health", false, dim("doctor has not run"))
+	}
+
+	if t := report.Templates; t != nil {
+		switch {
+		case len(t.Edited) > 0:
+			line("templates", false, yellow(fmt.Sprintf("%d edited by hand", len(t.Edited)))+dim(fmt.Sprintf(" (%s), %d rendered", strings.Join(t.Edited, ", "), t.Rendered)))
+		case len(t.Stale) > 0:
+			line("templates", false, yellow(fmt.Sprintf("%d stale", len(t.Stale)))+dim(fmt.Sprintf(" (%s), %d rendered", strings.Join(t.Stale, ", "), t.Rendered)))
+		case t.NotRendered > 0:
+			line("templates", false, yellow(fmt.Sprintf("%d not rendered", t.NotRendered))+dim(fmt.Sprintf(", %d rendered", t.Rendered)))
+		default:
+			line("templates", true, green(fmt.Sprintf("%d rendered", t.Rendered)))
 		}
+	}
 
-		fmt.Printf("  %-10s %s  %s\n", item.name, symbol, item.info)
+	u := report.Updates
+	var pending []string
+	if u.Behind > 0 {
+		pending = append(pending, fmt.Sprintf("%d commit(s) behind %s", u.Behind, u.Branch))
+	}
+	if u.ReleaseNewer {
+		pending = append(pending, u.LatestRelease+" available")
+	}
+	if len(pending) > 0 {
+		line("updates", false, yellow(strings.Join(pending, ", ")))
+	} else {
+		line("updates", true, dim("up to date "+u.Version))
 	}
 	fmt.Println()
 
 	// Print fixes if needed
-	if len(fixes) > 0 {
+	if len(report.Fixes) > 0 {
 		fmt.Println(dim("  ┌─ fixes ────────────────────────────────"))
-		for _, fix := range fixes {
+		for _, fix := range report.Fixes {
 			fmt.Printf("  %s %s\n", dim("│"), fix)
 		}
 		fmt.Println(dim("  └─────────────────────────────────────────"))
 		fmt.Println()
 	}
-
-	return nil
 }
 
 // isSymlink checks if a path is a symbolic link
@@ -264,13 +487,3 @@ func checkLimaRunning() bool {
 	}
 	return strings.Contains(string(output), "Running")
 }
-
-// getClaudeProfile returns the active Claude profile
-func getClaudeProfile() string {
-	cmd := exec.Command("dotclaude", "active")
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	return strings.TrimSpace(string(output))
-}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+)
+
+// TestTemplateOutputState verifies rendered, stale and missing outputs
+func TestTemplateOutputState(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &templateConfig{templateDir: dir, generatedDir: filepath.Join(dir, "generated")}
+	os.MkdirAll(cfg.generatedDir, 0755)
+
+	tmpl := func(name string) paths.LayeredFile {
+		path := filepath.Join(dir, name+".tmpl")
+		os.WriteFile(path, []byte("x"), 0644)
+		return paths.LayeredFile{Rel: name + ".tmpl", Path: path, Root: paths.BaseRootName}
+	}
+	old := time.Now().Add(-time.Hour)
+
+	fresh := tmpl("gitconfig")
+	os.Chtimes(fresh.Path, old, old)
+	os.WriteFile(filepath.Join(cfg.generatedDir, "gitconfig"), []byte("x"), 0644)
+
+	stale := tmpl("ssh-config")
+	os.WriteFile(filepath.Join(cfg.generatedDir, "ssh-config"), []byte("x"), 0644)
+	os.Chtimes(filepath.Join(cfg.generatedDir, "ssh-config"), old, old)
+
+	missing := tmpl("99-local.zsh")
+
+	for f, want := range map[*paths.LayeredFile]string{&fresh: templateRendered, &stale: templateStale, &missing: templateNotRendered} {
+		if got := templateOutputState(cfg, *f); got != want {
+			t.Errorf("%s: %s, want %s", f.Rel, got, want)
+		}
+	}
+}
+
+// TestCollectStatusUpdates verifies commits behind upstream and the cached
+// release check are reported without fetching
+func TestCollectStatusUpdates(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	setScheduleEnv(t, "")
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(os.Getenv("HOME"), ".cache"))
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	root := t.TempDir()
+	upstream, clone := filepath.Join(root, "upstream"), filepath.Join(root, "clone")
+	git := func(dir string, args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	os.MkdirAll(upstream, 0755)
+	git(upstream, "init", "-q", "-b", "main")
+	git(upstream, "commit", "-q", "--allow-empty", "-m", "one")
+	git(root, "clone", "-q", upstream, clone)
+	git(upstream, "commit", "-q", "--allow-empty", "-m", "two")
+	git(upstream, "commit", "-q", "--allow-empty", "-m", "three")
+	git(clone, "fetch", "-q")
+
+	origVersion := versionStr
+	versionStr = "v1.0.0"
+	defer func() { versionStr = origVersion }()
+	saveUpgradeCheck("v1.1.0")
+
+	updates := collectStatusUpdates(clone)
+	if updates.Behind != 2 || updates.Branch != "origin/main" {
+		t.Errorf("behind = %d on %q", updates.Behind, updates.Branch)
+	}
+	if !updates.ReleaseNewer || updates.LatestRelease != "v1.1.0" {
+		t.Errorf("release = %+v", updates)
+	}
+}
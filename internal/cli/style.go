@@ -36,9 +36,12 @@ func printRootHelp() {
 	// Setup & Health (always visible)
 	BoldCyan.Println("Setup & Health:")
 	printCmd("setup", "Interactive setup wizard (recommended)")
+	printCmd("init", "Scaffold a new dotfiles repo")
+	printCmd("migrate", "Migrate from chezmoi, stow or yadm")
 	printCmdAlias("status", "s", "Quick visual dashboard")
 	printCmdAlias("doctor", "health", "Run comprehensive health check")
 	printCmd("lint", "Validate shell config syntax")
+	printCmd("sandbox run", "Test setup in a throwaway container")
 	printCmdAlias("packages", "pkg", "Check/install Brewfile packages")
 	fmt.Println()
 
@@ -53,6 +56,7 @@ func printRootHelp() {
 	printCmd("drift", "Compare local files vs vault")
 	printCmd("sync", "Bidirectional vault sync (smart push/pull)")
 	printCmd("diff", "Preview changes before sync/restore")
+	printCmd("git-credential", "Git credential helper backed by vault")
 	fmt.Println()
 
 	// Backup & Safety
@@ -100,6 +104,7 @@ func printRootHelp() {
 	printCmd("tools aws", "AWS CLI helpers (profiles, SSO)")
 	printCmd("tools docker", "Docker management shortcuts")
 	printCmd("tools claude", "Claude Code backend configuration")
+	printCmd("claude", "Claude Code profiles, settings and CLAUDE.md")
 	fmt.Println()
 
 	// macOS Settings (only show on Darwin)
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/links"
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// supportHistoryLines is how much of each history log a bundle includes
+const supportHistoryLines = 50
+
+func newSupportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "support",
+		Short: "Collect diagnostics for bug reports",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	var output string
+	bundleCmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Write a diagnostics archive to attach to an issue",
+		Long: `Write a tar.gz of diagnostics to attach to a bug report:
+
+  system.txt           blackdot version, OS, architecture, paths
+  config.json          Your config with tokens redacted
+  vault-errors.jsonl   Recent failed bw/op/pass invocations
+  vault-last-*.json    What the last vault restore and push did
+  links.json           State of every declared link
+  schedule-history     Last scheduled task runs
+
+No vault items, secrets or restored files are included. Review the archive
+before sharing it.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSupportBundle(output)
+		},
+	}
+	bundleCmd.Flags().StringVarP(&output, "output", "o", "", "Archive path (default: ./blackdot-support-<timestamp>.tar.gz)")
+
+	cmd.AddCommand(bundleCmd)
+	return cmd
+}
+
+func runSupportBundle(output string) error {
+	if output == "" {
+		output = fmt.Sprintf("blackdot-support-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+
+	files := supportBundleFiles()
+	if err := writeSupportBundle(output, files); err != nil {
+		Fail("Failed to write support bundle: %v", err)
+		return err
+	}
+
+	Pass("Support bundle written to %s", output)
+	for _, f := range files {
+		fmt.Printf("    %s\n", Dim.Sprint(f.name))
+	}
+	Info("Review the contents before attaching it to an issue")
+	return nil
+}
+
+type supportFile struct {
+	name string
+	data []byte
+}
+
+// supportBundleFiles gathers the bundle contents. Missing sources are
+// skipped rather than failing the bundle.
+func supportBundleFiles() []supportFile {
+	var files []supportFile
+
+	var sys strings.Builder
+	fmt.Fprintf(&sys, "blackdot:     %s (commit %s, built %s)\n", versionStr, commitStr, dateStr)
+	fmt.Fprintf(&sys, "go:           %s\n", runtime.Version())
+	fmt.Fprintf(&sys, "os/arch:      %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&sys, "shell:        %s\n", os.Getenv("SHELL"))
+	fmt.Fprintf(&sys, "blackdot_dir: %s\n", BlackdotDir())
+	fmt.Fprintf(&sys, "config_dir:   %s\n", ConfigDir())
+	fmt.Fprintf(&sys, "state_dir:    %s\n", paths.StateDir())
+	fmt.Fprintf(&sys, "vault:        %s", getVaultBackend())
+	if fallbacks := vaultFallbackBackends(); len(fallbacks) > 0 {
+		fmt.Fprintf(&sys, " (fallback: %s)", joinBackendTypes(fallbacks))
+	}
+	sys.WriteString("\n")
+	files = append(files, supportFile{"system.txt", []byte(sys.String())})
+
+	if data, err := os.ReadFile(configLayerUser); err == nil {
+		files = append(files, supportFile{"config.json", []byte(sanitizeVaultOutput(string(data)))})
+	}
+
+	if data, err := os.ReadFile(vaultFailureLogPath()); err == nil {
+		files = append(files, supportFile{"vault-errors.jsonl", data})
+	}
+	for _, op := range vaultLastRunOps {
+		if data, err := os.ReadFile(vaultLastRunPath(op)); err == nil {
+			files = append(files, supportFile{"vault-last-" + op + ".json", data})
+		}
+	}
+
+	if resolved, err := declaredLinks(); err == nil {
+		type linkState struct {
+			Source    string `json:"source"`
+			Target    string `json:"target"`
+			State     string `json:"state"`
+			Mechanism string `json:"mechanism,omitempty"`
+		}
+		out := make([]linkState, 0, len(resolved))
+		for _, r := range resolved {
+			state, _, mechanism := links.Inspect(r)
+			out = append(out, linkState{Source: tildePath(r.Source), Target: tildePath(r.Target), State: state, Mechanism: mechanism})
+		}
+		data, _ := json.MarshalIndent(out, "", "  ")
+		files = append(files, supportFile{"links.json", data})
+	}
+
+	if data := tailFile(scheduleHistoryPath(), supportHistoryLines); data != nil {
+		files = append(files, supportFile{"schedule-history.jsonl", data})
+	}
+
+	return files
+}
+
+// tailFile returns the last n lines of a file, or nil if it can't be read
+func tailFile(path string, n int) []byte {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+func writeSupportBundle(path string, files []supportFile) error {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	prefix := strings.TrimSuffix(filepath.Base(path), ".tar.gz")
+	now := time.Now()
+
+	for _, f := range files {
+		header := &tar.Header{
+			Name:    prefix + "/" + f.name,
+			Mode:    0600,
+			Size:    int64(len(f.data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return writeFileWithPolicy(path, buf.Bytes(), fileClassPrivate)
+}
@@ -177,10 +177,7 @@ func runSync(args []string, dryRun, forceLocal, forceVault, verbose, all bool) e
 	failed := 0
 
 	// Get drift state for baseline checksums
-	driftStateFile := filepath.Join(os.Getenv("XDG_CACHE_HOME"), "blackdot", "vault-state.json")
-	if driftStateFile == "/blackdot/vault-state.json" {
-		driftStateFile = filepath.Join(home, ".cache", "blackdot", "vault-state.json")
-	}
+	driftStateFile := getVaultDriftStatePath()
 
 	// Process each item
 	for _, itemName := range itemsToSync {
@@ -258,6 +255,12 @@ func runSync(args []string, dryRun, forceLocal, forceVault, verbose, all bool) e
 		saveDriftState(itemsToSync, session, driftStateFile)
 	}
 
+	// Keep a generated setup document in step with the configuration
+	if !dryRun && failed == 0 {
+		refreshConfigDoc()
+		clearSingleFlight(shellStartupKey)
+	}
+
 	// Provide guidance for conflicts
 	if conflicts > 0 {
 		fmt.Println()
@@ -450,6 +453,9 @@ func pullFromVault(itemName, localPath, session string, dryRun bool, green, red
 	}
 
 	fmt.Printf("    %s Pulled %s to %s\n", green("✓"), itemName, localPath)
+	if itemName == "Environment-Secrets" {
+		refreshFishUniversal()
+	}
 	return nil
 }
 
@@ -564,10 +570,6 @@ func updateSyncTimestamps(timestamp string, pushed, pulled bool) {
 }
 
 func saveDriftState(items []string, session, driftStateFile string) {
-	// Create cache directory
-	cacheDir := filepath.Dir(driftStateFile)
-	os.MkdirAll(cacheDir, 0755)
-
 	// Build state
 	state := map[string]interface{}{
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
@@ -606,5 +608,5 @@ func saveDriftState(items []string, session, driftStateFile string) {
 	if err != nil {
 		return
 	}
-	os.WriteFile(driftStateFile, data, 0644)
+	writeFileWithPolicy(driftStateFile, data, fileClassPrivate)
 }
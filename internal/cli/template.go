@@ -2,16 +2,17 @@ package cli
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/blackwell-systems/blackdot/internal/paths"
 	"github.com/blackwell-systems/blackdot/internal/template"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -43,6 +44,74 @@ func getTemplateConfig() (*templateConfig, error) {
 	}, nil
 }
 
+// newTemplateEngine creates an engine with the configured render limits:
+// template.render_timeout (a duration) and template.max_output (bytes, or
+// with a KB/MB suffix)
+func newTemplateEngine(cfg *templateConfig) *template.RaymondEngine {
+	engine := template.NewRaymondEngine(cfg.templateDir)
+
+	timeout := template.DefaultRenderTimeout
+	if value := configLookup("template.render_timeout"); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			timeout = d
+		} else {
+			Warn("Ignoring invalid template.render_timeout %q", value)
+		}
+	}
+	maxOutput := template.DefaultMaxOutput
+	if value := configLookup("template.max_output"); value != "" {
+		if n, err := parseByteSize(value); err == nil {
+			maxOutput = n
+		} else {
+			Warn("Ignoring invalid template.max_output %q", value)
+		}
+	}
+
+	engine.SetLimits(timeout, maxOutput)
+	if timings.active() {
+		engine.SetRenderObserver(func(name string, elapsed time.Duration) {
+			if name == "" {
+				name = "(inline)"
+			}
+			recordPhase("template.render:"+name, elapsed)
+		})
+	}
+	return engine
+}
+
+// parseByteSize parses "1048576", "512KB" or "10MB"
+func parseByteSize(value string) (int, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	multiplier := 1
+	for suffix, m := range map[string]int{"KB": 1 << 10, "MB": 1 << 20, "K": 1 << 10, "M": 1 << 20} {
+		if strings.HasSuffix(value, suffix) {
+			trimmed := strings.TrimSpace(strings.TrimSuffix(value, suffix))
+			if _, err := strconv.Atoi(trimmed); err == nil {
+				value, multiplier = trimmed, m
+				break
+			}
+		}
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(value, "B"))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return n * multiplier, nil
+}
+
+// templateFiles returns the .tmpl files merged across the base repo and any
+// overlay repos, sorted by name. Overlays win when both define a template.
+func templateFiles(cfg *templateConfig) ([]paths.LayeredFile, error) {
+	roots := paths.Roots(cfg.blackdotDir)
+	if len(roots) == 1 {
+		if _, err := os.Stat(cfg.templateDir); err != nil {
+			return nil, fmt.Errorf("reading template directory: %w", err)
+		}
+	}
+	files, _ := paths.ResolveLayered(roots, filepath.Join("templates", "configs"), "*.tmpl")
+	return files, nil
+}
+
 func newTemplateCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "template",
@@ -78,11 +147,22 @@ Output goes to the generated/ directory.
 Examples:
   blackdot template render                    # Render all templates
   blackdot template render gitconfig.tmpl     # Render specific template
-  blackdot template render --stdout file.tmpl # Output to stdout`,
+  blackdot template render --stdout file.tmpl # Output to stdout
+
+Generated files edited by hand are detected by checksum. Render stops and
+shows the change unless --fold merges it into the template or --force
+discards it; on a terminal you are asked instead. Set
+template.protect_generated to write generated files read-only.
+
+Values of variables marked "secret" in _variables.schema.json are shown
+as <secret:name> in --stdout output and diffs unless --show-secrets is
+given. Files written to generated/ always hold the real values.`,
 		RunE: runTemplateRender,
 	}
 	renderCmd.Flags().Bool("stdout", false, "Output to stdout instead of file")
 	renderCmd.Flags().Bool("dry-run", false, "Show what would be rendered without writing")
+	renderCmd.Flags().Bool("fold", false, "Merge hand edits in generated/ back into their templates")
+	renderCmd.Flags().Bool("show-secrets", false, "Show secret variable values in --stdout output and diffs")
 
 	// Vars command
 	varsCmd := &cobra.Command{
@@ -94,9 +174,26 @@ Variables are loaded from:
   1. Environment (BLACKDOT_TMPL_* prefix, highest priority)
   2. templates/_variables.local.sh (machine-specific)
   3. templates/_variables.sh (defaults)
-  4. Auto-detected values (hostname, os, user, etc.)`,
+  4. Auto-detected values (hostname, os, user, etc.)
+
+Variables marked "secret" in _variables.schema.json are hidden unless
+--show-secrets is given.`,
 		RunE: runTemplateVars,
 	}
+	varsCmd.Flags().Bool("show-secrets", false, "Show secret variable values")
+
+	// Diff command
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show differences from rendered",
+		Long: `List generated files that differ from what their templates render now.
+With --verbose, show each difference as a unified diff.
+
+Values of variables marked "secret" in _variables.schema.json are shown
+as <secret:name> unless --show-secrets is given.`,
+		RunE: runTemplateDiff,
+	}
+	diffCmd.Flags().Bool("show-secrets", false, "Show secret variable values in the diff")
 
 	// List command
 	listCmd := &cobra.Command{
@@ -126,15 +223,6 @@ Variables are loaded from:
 		RunE:  runTemplateEdit,
 	}
 
-	// Arrays command
-	arraysCmd := &cobra.Command{
-		Use:   "arrays",
-		Short: "Manage JSON/shell arrays for {{#each}} loops",
-		RunE:  runTemplateArrays,
-	}
-	arraysCmd.Flags().BoolP("export-json", "e", false, "Export shell arrays to JSON format")
-	arraysCmd.Flags().Bool("validate", false, "Validate JSON arrays file syntax")
-
 	// Vault command
 	vaultCmd := &cobra.Command{
 		Use:   "vault [command]",
@@ -184,23 +272,23 @@ Commands:
 		checkCmd,
 		filtersCmd,
 		editCmd,
-		arraysCmd,
+		newTemplateArraysCmd(),
 		vaultCmd,
 		&cobra.Command{
 			Use:   "init",
 			Short: "Interactive setup (creates _variables.local.sh)",
 			RunE:  runTemplateInit,
 		},
+		newTemplateConfigureCmd(),
 		&cobra.Command{
-			Use:   "link",
-			Short: "Create symlinks from generated/ to destinations",
+			Use:   "link [file...]",
+			Short: "Deploy generated/ files to their targets (link or copy)",
 			RunE:  runTemplateLink,
 		},
-		&cobra.Command{
-			Use:   "diff",
-			Short: "Show differences from rendered",
-			RunE:  runTemplateDiff,
-		},
+		newTemplateApplyCmd(),
+		newTemplateAdoptCmd(),
+		newTemplateTestCmd(),
+		diffCmd,
 	)
 
 	return cmd
@@ -215,34 +303,32 @@ func runTemplateRender(cmd *cobra.Command, args []string) error {
 
 	toStdout, _ := cmd.Flags().GetBool("stdout")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	fold, _ := cmd.Flags().GetBool("fold")
+	showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+	if watch, _ := cmd.Flags().GetBool("watch"); watch {
+		if toStdout || dryRun {
+			return fmt.Errorf("--watch cannot be combined with --stdout or --dry-run")
+		}
+		opts := templateWatchOptions{}
+		opts.link, _ = cmd.Flags().GetBool("link")
+		opts.exec, _ = cmd.Flags().GetString("exec")
+		opts.debounce, _ = cmd.Flags().GetDuration("debounce")
+		opts.showSecrets = showSecrets
+		return runTemplateWatch(cfg, args, opts)
+	}
+	rendered := loadRenderedState()
 
 	// Create engine and load variables
-	engine := template.NewRaymondEngine(cfg.templateDir)
+	engine := newTemplateEngine(cfg)
 	if err := loadTemplateVariables(engine, cfg); err != nil {
 		return fmt.Errorf("loading variables: %w", err)
 	}
+	mask := templateSecretMask(cfg, engine, showSecrets)
 
 	// Determine which templates to render
-	var templates []string
-	if len(args) > 0 {
-		for _, arg := range args {
-			tmplPath := arg
-			if !filepath.IsAbs(arg) {
-				tmplPath = filepath.Join(cfg.templateDir, arg)
-			}
-			templates = append(templates, tmplPath)
-		}
-	} else {
-		// Find all .tmpl files
-		entries, err := os.ReadDir(cfg.templateDir)
-		if err != nil {
-			return fmt.Errorf("reading template directory: %w", err)
-		}
-		for _, entry := range entries {
-			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tmpl") {
-				templates = append(templates, filepath.Join(cfg.templateDir, entry.Name()))
-			}
-		}
+	templates, err := resolveTemplatePaths(cfg, args)
+	if err != nil {
+		return err
 	}
 
 	if len(templates) == 0 {
@@ -250,13 +336,35 @@ func runTemplateRender(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Lifecycle hooks only wrap renders that write files
+	writes := !toStdout && !dryRun
+	renderPaths := make(map[string]string, len(templates))
+	for _, tmplPath := range templates {
+		outputName := strings.TrimSuffix(filepath.Base(tmplPath), ".tmpl")
+		renderPaths[outputName] = filepath.Join(cfg.generatedDir, outputName)
+	}
+	hctx := hookContextForItems(renderPaths, dryRun)
+	if writes {
+		if err := triggerHooks("pre_template_render", hctx); err != nil {
+			return err
+		}
+	}
+
 	// Ensure generated directory exists
-	if !toStdout && !dryRun {
+	if writes {
 		if err := os.MkdirAll(cfg.generatedDir, 0755); err != nil {
 			return fmt.Errorf("creating generated directory: %w", err)
 		}
 	}
 
+	// ssh_hosts are written per category and included by ssh-config
+	if _, ok := renderPaths["ssh-config"]; ok {
+		if err := renderSSHHostIncludes(engine, cfg, writes, dryRun); err != nil {
+			Fail("ssh_hosts: %v", err)
+			return err
+		}
+	}
+
 	green := color.New(color.FgGreen).SprintFunc()
 	cyan := color.New(color.FgCyan).SprintFunc()
 
@@ -267,32 +375,103 @@ func runTemplateRender(cmd *cobra.Command, args []string) error {
 
 		result, err := engine.RenderFile(tmplPath)
 		if err != nil {
-			return fmt.Errorf("rendering %s: %w", baseName, err)
+			Fail("%v", err)
+			return err
+		}
+
+		// Hand edits to generated files would be lost by this render
+		outputPath := filepath.Join(cfg.generatedDir, outputName)
+		if edited, ok := rendered.editedByHand(outputName, outputPath); ok && !toStdout {
+			if dryRun {
+				fmt.Printf("%s %s was edited by hand (render will stop)\n", cyan("[dry-run]"), outputName)
+				continue
+			}
+			base, _ := rendered.lastRender(outputName)
+			switch resolveManualEdit(os.Stdout, outputName, mask(base), mask(edited), fold) {
+			case manualEditRefuse:
+				Fail("generated/%s was edited by hand; rendering would discard the change", outputName)
+				Info("Move the change into %s, or re-run with --fold to merge it or --force to discard it", tildePath(tmplPath))
+				return fmt.Errorf("generated/%s has manual changes", outputName)
+			case manualEditKeep:
+				Warn("Skipped %s (manual changes kept)", outputName)
+				continue
+			case manualEditFold:
+				if err := foldManualEdit(tmplPath, base, edited); err != nil {
+					Fail("Cannot fold generated/%s into %s: %v", outputName, tildePath(tmplPath), err)
+					Info("Edit the template by hand, or re-run with --force to discard the change")
+					return err
+				}
+				Pass("Merged manual change into %s", tildePath(tmplPath))
+				if result, err = engine.RenderFile(tmplPath); err != nil {
+					Fail("%v", err)
+					return err
+				}
+			}
+		}
+
+		if !toStdout {
+			if err := checkContentForSecrets(outputName, []byte(result), "generated/"+outputName); err != nil {
+				return err
+			}
 		}
 
 		if toStdout {
 			fmt.Printf("=== %s ===\n", baseName)
-			fmt.Print(result)
+			fmt.Print(mask(result))
 			fmt.Println()
 		} else if dryRun {
 			fmt.Printf("%s %s -> %s (%d bytes)\n",
 				cyan("[dry-run]"), baseName, outputName, len(result))
 		} else {
-			outputPath := filepath.Join(cfg.generatedDir, outputName)
-			if err := os.WriteFile(outputPath, []byte(result), 0644); err != nil {
+			if err := writeFileAtomic(outputPath, []byte(result), generatedFileMode()); err != nil {
 				return fmt.Errorf("writing %s: %w", outputPath, err)
 			}
+			if err := rendered.record(outputName, tmplPath, result); err != nil {
+				Warn("Failed to record checksum for %s: %v", outputName, err)
+			}
 			fmt.Printf("%s %s -> %s\n", green("✓"), baseName, outputName)
 		}
 	}
 
-	if !toStdout && !dryRun {
+	if writes {
 		fmt.Printf("\nRendered %d template(s) to %s\n", len(templates), cfg.generatedDir)
+		if err := triggerHooks("post_template_render", hctx); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// resolveTemplatePaths maps template arguments to files, searching overlay
+// repos; no arguments means every template
+func resolveTemplatePaths(cfg *templateConfig, args []string) ([]string, error) {
+	var templates []string
+	if len(args) > 0 {
+		for _, arg := range args {
+			tmplPath := arg
+			if !filepath.IsAbs(arg) {
+				tmplPath = filepath.Join(cfg.templateDir, arg)
+				if layered := paths.FindLayered(paths.Roots(cfg.blackdotDir), filepath.Join("templates", "configs", arg)); layered != "" {
+					tmplPath = layered
+				}
+			}
+			templates = append(templates, tmplPath)
+		}
+		return templates, nil
+	}
+
+	// Find all .tmpl files
+	files, err := templateFiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		templates = append(templates, f.Path)
+	}
+	return templates, nil
+}
+
 // runTemplateVars shows all template variables
 func runTemplateVars(cmd *cobra.Command, args []string) error {
 	cfg, err := getTemplateConfig()
@@ -300,13 +479,15 @@ func runTemplateVars(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	engine := template.NewRaymondEngine(cfg.templateDir)
+	engine := newTemplateEngine(cfg)
 	if err := loadTemplateVariables(engine, cfg); err != nil {
 		return fmt.Errorf("loading variables: %w", err)
 	}
 
 	// Get all variables from the engine
 	vars := getEngineVars(engine)
+	showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+	secrets := templateSecretNames(cfg)
 
 	// Sort variable names
 	var names []string
@@ -326,7 +507,9 @@ func runTemplateVars(cmd *cobra.Command, args []string) error {
 		value := vars[name]
 		// Truncate long values
 		display := value
-		if len(display) > 60 {
+		if secrets[name] && !showSecrets {
+			display = Dim.Sprint("<secret>")
+		} else if len(display) > 60 {
 			display = display[:57] + "..."
 		}
 		// Check if from environment
@@ -349,9 +532,9 @@ func runTemplateList(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	entries, err := os.ReadDir(cfg.templateDir)
+	files, err := templateFiles(cfg)
 	if err != nil {
-		return fmt.Errorf("reading template directory: %w", err)
+		return err
 	}
 
 	green := color.New(color.FgGreen).SprintFunc()
@@ -362,31 +545,20 @@ func runTemplateList(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	var count int
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tmpl") {
-			continue
-		}
-
+	for _, f := range files {
 		count++
-		baseName := entry.Name()
+		baseName := filepath.Base(f.Path)
+		if f.Root != paths.BaseRootName {
+			baseName += " [" + f.Root + "]"
+		}
 		outputName := strings.TrimSuffix(baseName, ".tmpl")
-		outputPath := filepath.Join(cfg.generatedDir, outputName)
 
-		// Check if generated file exists
-		if _, err := os.Stat(outputPath); err == nil {
-			info, _ := entry.Info()
-			genInfo, _ := os.Stat(outputPath)
-
-			if info != nil && genInfo != nil {
-				if genInfo.ModTime().After(info.ModTime()) {
-					fmt.Printf("  %s %s -> %s\n", green("✓"), baseName, outputName)
-				} else {
-					fmt.Printf("  %s %s -> %s (stale)\n", yellow("⚠"), baseName, outputName)
-				}
-			} else {
-				fmt.Printf("  %s %s -> %s\n", green("✓"), baseName, outputName)
-			}
-		} else {
+		switch templateOutputState(cfg, f) {
+		case templateRendered:
+			fmt.Printf("  %s %s -> %s\n", green("✓"), baseName, outputName)
+		case templateStale:
+			fmt.Printf("  %s %s -> %s (stale)\n", yellow("⚠"), baseName, outputName)
+		default:
 			fmt.Printf("  - %s (not rendered)\n", baseName)
 		}
 	}
@@ -395,10 +567,41 @@ func runTemplateList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// Generated output states
+const (
+	templateRendered    = "rendered"
+	templateStale       = "stale"
+	templateNotRendered = "not-rendered"
+	templateEdited      = "edited"
+)
+
+// templateOutputState compares a template with its generated output. The
+// output is stale when the template was modified after it was rendered,
+// and edited when its checksum no longer matches the last render.
+func templateOutputState(cfg *templateConfig, f paths.LayeredFile) string {
+	name := strings.TrimSuffix(filepath.Base(f.Path), ".tmpl")
+	outputPath := filepath.Join(cfg.generatedDir, name)
+	genInfo, err := os.Stat(outputPath)
+	if err != nil {
+		return templateNotRendered
+	}
+	if _, edited := loadRenderedState().editedByHand(name, outputPath); edited {
+		return templateEdited
+	}
+	info, err := os.Stat(f.Path)
+	if err == nil && !genInfo.ModTime().After(info.ModTime()) {
+		return templateStale
+	}
+	return templateRendered
+}
+
 // loadTemplateVariables loads all variable sources into the engine
 func loadTemplateVariables(engine *template.RaymondEngine, cfg *templateConfig) error {
 	// 1. Load auto-detected variables (lowest priority)
 	engine.LoadAutoDetectedVars()
+	if profile := activeClaudeProfile(); profile != "" {
+		engine.SetVar("claude_profile", profile)
+	}
 
 	// 2. Load default variables file
 	defaultsFile := filepath.Join(cfg.variablesDir, "_variables.sh")
@@ -417,7 +620,22 @@ func loadTemplateVariables(engine *template.RaymondEngine, cfg *templateConfig)
 		}
 	}
 
-	// 4. Environment variables override everything (handled in engine.buildContext)
+	// 4. Project overlay (.blackdot.yaml in the current repository)
+	if overlay := currentProjectOverlay(); overlay != nil {
+		for name, val := range overlay.Template.Variables {
+			engine.SetVar(name, val)
+		}
+	}
+
+	// 5. Arrays for {{#each}}: shell arrays in the variables files, replaced
+	// by _arrays.local.json
+	arrays, err := loadTemplateArrays(cfg)
+	if err != nil {
+		return fmt.Errorf("loading arrays: %w", err)
+	}
+	setTemplateArrays(engine, arrays)
+
+	// 6. Environment variables override everything (handled in engine.buildContext)
 
 	return nil
 }
@@ -458,12 +676,12 @@ func runTemplateCheck(cmd *cobra.Command, args []string) error {
 
 	PrintHeader("Template Syntax Check")
 
-	entries, err := os.ReadDir(cfg.templateDir)
+	files, err := templateFiles(cfg)
 	if err != nil {
-		return fmt.Errorf("reading template directory: %w", err)
+		return err
 	}
 
-	engine := template.NewRaymondEngine(cfg.templateDir)
+	engine := newTemplateEngine(cfg)
 	if err := loadTemplateVariables(engine, cfg); err != nil {
 		return fmt.Errorf("loading variables: %w", err)
 	}
@@ -471,21 +689,18 @@ func runTemplateCheck(cmd *cobra.Command, args []string) error {
 	errors := 0
 	checked := 0
 
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tmpl") {
-			continue
-		}
-
+	for _, f := range files {
 		checked++
-		tmplPath := filepath.Join(cfg.templateDir, entry.Name())
+		tmplPath := f.Path
+		name := filepath.Base(f.Path)
 
 		// Try to render the template
 		_, err := engine.RenderFile(tmplPath)
 		if err != nil {
-			Fail("%s: %v", entry.Name(), err)
+			Fail("%v", err)
 			errors++
 		} else {
-			Pass("%s", entry.Name())
+			Pass("%s", name)
 		}
 	}
 
@@ -575,94 +790,6 @@ func runTemplateEdit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// runTemplateArrays manages JSON/shell arrays
-func runTemplateArrays(cmd *cobra.Command, args []string) error {
-	cfg, err := getTemplateConfig()
-	if err != nil {
-		return err
-	}
-
-	exportJSON, _ := cmd.Flags().GetBool("export-json")
-	validate, _ := cmd.Flags().GetBool("validate")
-
-	jsonFile := filepath.Join(cfg.variablesDir, "_arrays.local.json")
-
-	if validate {
-		// Validate JSON arrays file
-		if _, err := os.Stat(jsonFile); os.IsNotExist(err) {
-			Info("No JSON arrays file found: %s", jsonFile)
-			return nil
-		}
-
-		data, err := os.ReadFile(jsonFile)
-		if err != nil {
-			Fail("Failed to read arrays file: %v", err)
-			return err
-		}
-
-		var arrays map[string]interface{}
-		if err := json.Unmarshal(data, &arrays); err != nil {
-			Fail("Invalid JSON: %v", err)
-			return err
-		}
-
-		Pass("Valid JSON: %s", jsonFile)
-		for key, val := range arrays {
-			if arr, ok := val.([]interface{}); ok {
-				fmt.Printf("  • %s: %d items\n", key, len(arr))
-			}
-		}
-		return nil
-	}
-
-	if exportJSON {
-		// Export shell arrays to JSON format
-		Info("Shell array export not implemented in Go CLI")
-		fmt.Println("Use: blackdot template arrays --export-json (bash version)")
-		return nil
-	}
-
-	// Default: list arrays
-	PrintHeader("Template Arrays")
-
-	if _, err := os.Stat(jsonFile); os.IsNotExist(err) {
-		Info("No JSON arrays file found")
-		fmt.Println()
-		fmt.Println("Create one at:")
-		fmt.Printf("  %s\n", jsonFile)
-		return nil
-	}
-
-	data, err := os.ReadFile(jsonFile)
-	if err != nil {
-		return err
-	}
-
-	var arrays map[string]interface{}
-	if err := json.Unmarshal(data, &arrays); err != nil {
-		Fail("Invalid JSON: %v", err)
-		return err
-	}
-
-	fmt.Printf("Source: %s\n\n", jsonFile)
-	for key, val := range arrays {
-		if arr, ok := val.([]interface{}); ok {
-			fmt.Printf("%s (%d items):\n", key, len(arr))
-			for i, item := range arr {
-				if i >= 5 {
-					fmt.Printf("  ... and %d more\n", len(arr)-5)
-					break
-				}
-				itemJSON, _ := json.Marshal(item)
-				fmt.Printf("  [%d] %s\n", i, string(itemJSON))
-			}
-			fmt.Println()
-		}
-	}
-
-	return nil
-}
-
 // runTemplateInit runs interactive template setup
 func runTemplateInit(cmd *cobra.Command, args []string) error {
 	cfg, err := getTemplateConfig()
@@ -747,7 +874,7 @@ TMPL_AUTO[machine_type]="unknown"
 `, time.Now().Format("2006-01-02 15:04:05"), gitName, gitEmail)
 
 	os.MkdirAll(cfg.variablesDir, 0755)
-	if err := os.WriteFile(localFile, []byte(content), 0644); err != nil {
+	if err := writeFileAtomic(localFile, []byte(content), 0644); err != nil {
 		Fail("Failed to create local variables file: %v", err)
 		return err
 	}
@@ -755,70 +882,9 @@ TMPL_AUTO[machine_type]="unknown"
 	Pass("Created: %s", localFile)
 	fmt.Println()
 	fmt.Println("Next steps:")
-	fmt.Println("  1. blackdot template edit    - Customize variables")
-	fmt.Println("  2. blackdot template vars    - Review all variables")
-	fmt.Println("  3. blackdot template render  - Generate config files")
-
-	return nil
-}
-
-// runTemplateLink creates symlinks from generated files to destinations
-func runTemplateLink(cmd *cobra.Command, args []string) error {
-	cfg, err := getTemplateConfig()
-	if err != nil {
-		return err
-	}
-
-	PrintHeader("Linking Generated Files")
-
-	// Define destinations for generated files
-	home := os.Getenv("HOME")
-	linkMap := map[string]string{
-		"gitconfig":    filepath.Join(home, ".gitconfig"),
-		"99-local.zsh": filepath.Join(cfg.blackdotDir, "zsh", "zsh.d", "99-local.zsh"),
-		"ssh-config":   filepath.Join(home, ".ssh", "config"),
-		"claude.local": filepath.Join(home, ".claude.local"),
-	}
-
-	linked := 0
-	for file, dest := range linkMap {
-		src := filepath.Join(cfg.generatedDir, file)
-
-		if _, err := os.Stat(src); os.IsNotExist(err) {
-			continue
-		}
-
-		// Create parent directory
-		os.MkdirAll(filepath.Dir(dest), 0755)
-
-		// Check existing file
-		if info, err := os.Lstat(dest); err == nil {
-			if info.Mode()&os.ModeSymlink != 0 {
-				// Remove existing symlink
-				os.Remove(dest)
-			} else {
-				// Backup existing file
-				backup := dest + ".backup." + time.Now().Format("20060102150405")
-				os.Rename(dest, backup)
-				Info("Backed up: %s", backup)
-			}
-		}
-
-		// Create symlink
-		if err := os.Symlink(src, dest); err != nil {
-			Fail("Failed to link %s: %v", file, err)
-			continue
-		}
-
-		Pass("Linked: %s → %s", file, dest)
-		linked++
-	}
-
-	if linked == 0 {
-		Info("No files to link (run 'blackdot template render' first)")
-	} else {
-		fmt.Printf("\nLinked %d file(s)\n", linked)
-	}
+	fmt.Println("  1. blackdot template configure - Fill in variables from the schema")
+	fmt.Println("  2. blackdot template vars      - Review all variables")
+	fmt.Println("  3. blackdot template render    - Generate config files")
 
 	return nil
 }
@@ -832,30 +898,31 @@ func runTemplateDiff(cmd *cobra.Command, args []string) error {
 
 	PrintHeader("Template Differences")
 
-	entries, err := os.ReadDir(cfg.templateDir)
+	files, err := templateFiles(cfg)
 	if err != nil {
-		return fmt.Errorf("reading template directory: %w", err)
+		return err
 	}
 
-	engine := template.NewRaymondEngine(cfg.templateDir)
+	engine := newTemplateEngine(cfg)
 	if err := loadTemplateVariables(engine, cfg); err != nil {
 		return fmt.Errorf("loading variables: %w", err)
 	}
 
-	hasDiff := false
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tmpl") {
-			continue
-		}
+	showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+	mask := templateSecretMask(cfg, engine, showSecrets)
 
-		tmplPath := filepath.Join(cfg.templateDir, entry.Name())
-		outputName := strings.TrimSuffix(entry.Name(), ".tmpl")
+	rendered := loadRenderedState()
+	hasDiff := false
+	for _, f := range files {
+		tmplPath := f.Path
+		name := filepath.Base(f.Path)
+		outputName := strings.TrimSuffix(name, ".tmpl")
 		outputPath := filepath.Join(cfg.generatedDir, outputName)
 
 		// Render template
 		newContent, err := engine.RenderFile(tmplPath)
 		if err != nil {
-			Warn("%s: render failed: %v", entry.Name(), err)
+			Warn("render failed: %v", err)
 			continue
 		}
 
@@ -873,9 +940,17 @@ func runTemplateDiff(cmd *cobra.Command, args []string) error {
 			continue
 		}
 
-		if string(existingContent) != newContent {
+		if _, edited := rendered.editedByHand(outputName, outputPath); edited {
+			fmt.Printf("  %s: edited by hand since last render\n", outputName)
+		} else if string(existingContent) != newContent {
 			fmt.Printf("  %s: differs from template\n", outputName)
-			hasDiff = true
+		} else {
+			continue
+		}
+		hasDiff = true
+		if verbose {
+			printUnifiedDiff(os.Stdout, "generated/"+outputName, mask(string(existingContent)), "rendered "+name, mask(newContent))
+			fmt.Println()
 		}
 	}
 
@@ -1006,16 +1081,19 @@ func runTemplateVaultPull(cmd *cobra.Command, args []string) error {
 
 	localFile := filepath.Join(cfg.variablesDir, "_variables.local.sh")
 
-	// Backup if exists
-	if _, err := os.Stat(localFile); err == nil {
+	// Backup if exists; the original stays in place until replaced
+	if current, err := os.ReadFile(localFile); err == nil {
 		backup := localFile + ".backup." + time.Now().Format("20060102150405")
-		os.Rename(localFile, backup)
+		if err := writeFileAtomic(backup, current, 0600); err != nil {
+			Fail("Failed to back up local file: %v", err)
+			return err
+		}
 		Info("Backed up: %s", backup)
 	}
 
 	// Write vault content
 	os.MkdirAll(cfg.variablesDir, 0755)
-	if err := os.WriteFile(localFile, []byte(vaultContent), 0600); err != nil {
+	if err := writeFileAtomic(localFile, []byte(vaultContent), 0600); err != nil {
 		Fail("Failed to write local file: %v", err)
 		return err
 	}
@@ -0,0 +1,356 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+	"github.com/spf13/cobra"
+)
+
+// adoptSkipVars are variables whose values are too generic to substitute
+var adoptSkipVars = map[string]bool{"os": true, "shell": true}
+
+// adoptCandidate is a variable whose value adopt looks for
+type adoptCandidate struct {
+	Name  string
+	Value string
+	New   bool // not defined yet; adopting it adds it to _variables.local.sh
+}
+
+// adoptMatch is one occurrence of a candidate value
+type adoptMatch struct {
+	Start, End int
+	Var        string
+}
+
+func newTemplateAdoptCmd() *cobra.Command {
+	var name string
+	var skip []string
+	var yes, dryRun, force bool
+
+	cmd := &cobra.Command{
+		Use:   "adopt <file>",
+		Short: "Turn an existing config file into a template",
+		Long: `Create a template from a config file you already have.
+
+Values of known variables (git email and name, hostname, user, home and
+workspace paths, and anything in _variables*.sh) are found in the file
+and, after asking about each one, replaced with placeholders. Variables
+that are not defined yet are added to templates/_variables.local.sh.
+The template is then rendered and compared with the original, so you can
+see it reproduces the file before deploying it.
+
+The template is named after the file without its leading dot
+(~/.gitconfig becomes gitconfig.tmpl) and declares the file as its
+target, unless the stock template of that name already deploys there.
+
+Examples:
+  blackdot template adopt ~/.gitconfig
+  blackdot template adopt ~/.config/starship.toml --yes
+  blackdot template adopt ~/.npmrc --skip user --dry-run`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTemplateAdopt(args[0], name, skip, yes, dryRun, force)
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Template name (default: file name without leading dot)")
+	cmd.Flags().StringArrayVar(&skip, "skip", nil, "Never substitute this variable (repeatable)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Substitute every match without asking")
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Print the template and render check without writing")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite an existing template")
+	return cmd
+}
+
+func runTemplateAdopt(file, name string, skip []string, yes, dryRun, force bool) error {
+	cfg, err := getTemplateConfig()
+	if err != nil {
+		return err
+	}
+
+	source := expandPath(file)
+	if resolved, err := filepath.EvalSymlinks(source); err == nil && strings.HasPrefix(resolved, cfg.generatedDir+string(filepath.Separator)) {
+		Fail("%s is already generated from a template (%s)", tildePath(source), tildePath(resolved))
+		return fmt.Errorf("%s is already templated", file)
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		Fail("Cannot read %s: %v", tildePath(source), err)
+		return err
+	}
+	original := string(data)
+
+	if name == "" {
+		name = strings.TrimPrefix(filepath.Base(source), ".")
+	}
+	name = strings.TrimSuffix(name, ".tmpl")
+	tmplPath := filepath.Join(cfg.templateDir, name+".tmpl")
+	if _, err := os.Stat(tmplPath); err == nil && !force && !dryRun {
+		Fail("%s already exists (pick another --name, or --force to replace it)", tildePath(tmplPath))
+		return fmt.Errorf("%s exists", name+".tmpl")
+	}
+
+	engine := newTemplateEngine(cfg)
+	if err := loadTemplateVariables(engine, cfg); err != nil {
+		return fmt.Errorf("loading variables: %w", err)
+	}
+
+	skipped := make(map[string]bool)
+	for _, s := range skip {
+		skipped[s] = true
+	}
+	candidates := adoptCandidates(engine.Vars(), adoptExtraVars(), skipped)
+	matches := findAdoptMatches(original, candidates)
+
+	byName := make(map[string]adoptCandidate, len(candidates))
+	for _, c := range candidates {
+		byName[c.Name] = c
+	}
+	counts := make(map[string]int)
+	var order []string
+	for _, m := range matches {
+		if counts[m.Var] == 0 {
+			order = append(order, m.Var)
+		}
+		counts[m.Var]++
+	}
+
+	accepted := make(map[string]bool)
+	if len(order) == 0 {
+		Info("No known variable values found in %s; the template will be a plain copy", tildePath(source))
+	}
+	for _, v := range order {
+		c := byName[v]
+		if yes {
+			accepted[v] = true
+			continue
+		}
+		question := fmt.Sprintf("Replace %q (%d occurrence(s)) with %s?", c.Value, counts[v], adoptPlaceholder(c))
+		if c.New {
+			question = fmt.Sprintf("Replace %q (%d occurrence(s)) with %s and define %s?", c.Value, counts[v], adoptPlaceholder(c), v)
+		}
+		ok, err := prompts.Confirm(question, true)
+		if err != nil {
+			return err
+		}
+		accepted[v] = ok
+	}
+
+	header := ""
+	if legacyTemplateLinks(cfg)[name] != source {
+		header = fmt.Sprintf("{{!-- target: %s --}}\n", tildePath(source))
+	}
+	tmpl := header + buildAdoptedTemplate(original, matches, accepted, byName)
+
+	// Render with the new variables defined to prove the template
+	// reproduces the file
+	var newVars [][2]string
+	for _, v := range order {
+		if c := byName[v]; accepted[v] && c.New {
+			newVars = append(newVars, [2]string{v, c.Value})
+			engine.SetVar(v, c.Value)
+		}
+	}
+	rendered, err := engine.Render(tmpl)
+	if err != nil {
+		Fail("Adopted template does not render: %v", err)
+		return err
+	}
+	if err := checkContentForSecrets(name+".tmpl", []byte(tmpl), "templates/configs/"); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("=== %s ===\n%s", name+".tmpl", tmpl)
+		if !strings.HasSuffix(tmpl, "\n") {
+			fmt.Println()
+		}
+		for _, kv := range newVars {
+			fmt.Printf("%s would add %s=%q to _variables.local.sh\n", Cyan.Sprint("[dry-run]"), kv[0], kv[1])
+		}
+	}
+
+	if rendered == original {
+		Pass("Rendered template matches %s", tildePath(source))
+	} else {
+		Warn("Rendered template differs from %s:", tildePath(source))
+		printUnifiedDiff(os.Stdout, tildePath(source), original, "rendered "+name+".tmpl", rendered)
+	}
+	if dryRun {
+		return nil
+	}
+
+	if err := os.MkdirAll(cfg.templateDir, 0755); err != nil {
+		return fmt.Errorf("creating template directory: %w", err)
+	}
+	if err := writeFileAtomic(tmplPath, []byte(tmpl), 0644); err != nil {
+		Fail("Failed to write %s: %v", tildePath(tmplPath), err)
+		return err
+	}
+	Pass("Created %s", tildePath(tmplPath))
+
+	if len(newVars) > 0 {
+		localFile := filepath.Join(cfg.variablesDir, "_variables.local.sh")
+		if err := appendTemplateVariables(localFile, newVars); err != nil {
+			Fail("Failed to update %s: %v", tildePath(localFile), err)
+			return err
+		}
+		names := make([]string, len(newVars))
+		for i, kv := range newVars {
+			names[i] = kv[0]
+		}
+		Pass("Defined %s in %s", strings.Join(names, ", "), tildePath(localFile))
+	}
+
+	fmt.Println()
+	fmt.Println("Next steps:")
+	fmt.Printf("  blackdot template apply --dry-run %s.tmpl   # review the deployment\n", name)
+	fmt.Printf("  blackdot template apply %s.tmpl             # replace %s with the rendered file\n", name, tildePath(source))
+	return nil
+}
+
+// adoptExtraVars are values adopt recognizes even when no variable holds
+// them yet: the git identity and the workspace path
+func adoptExtraVars() map[string]string {
+	extra := map[string]string{"workspace": workspaceTarget()}
+	for name, key := range map[string]string{"git_name": "user.name", "git_email": "user.email"} {
+		if out, err := exec.Command("git", "config", "--global", key).Output(); err == nil {
+			extra[name] = strings.TrimSpace(string(out))
+		}
+	}
+	return extra
+}
+
+// adoptCandidates merges defined variables with the extra values, drops
+// values too short or generic to substitute safely, and orders longer
+// values first so /home/me/workspace wins over /home/me
+func adoptCandidates(vars, extra map[string]string, skip map[string]bool) []adoptCandidate {
+	var out []adoptCandidate
+	add := func(name, value string, isNew bool) {
+		if skip[name] || adoptSkipVars[name] || !isTemplateVarName(name) || len(value) < 3 {
+			return
+		}
+		switch strings.ToLower(value) {
+		case "true", "false", "yes", "no", "unknown":
+			return
+		}
+		if strings.ContainsAny(value, "\n\"{}") {
+			return
+		}
+		out = append(out, adoptCandidate{Name: name, Value: value, New: isNew})
+	}
+	for name, value := range vars {
+		add(name, value, false)
+	}
+	for name, value := range extra {
+		if v, ok := vars[name]; !ok || v == "" {
+			add(name, value, true)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].Value) != len(out[j].Value) {
+			return len(out[i].Value) > len(out[j].Value)
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// isTemplateVarName reports whether name can be used as {{ name }}
+func isTemplateVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// findAdoptMatches finds candidate values in text, longest first, that do
+// not continue a longer word (so user "al" never matches inside "also")
+func findAdoptMatches(text string, candidates []adoptCandidate) []adoptMatch {
+	var matches []adoptMatch
+	for i := 0; i < len(text); {
+		matched := false
+		for _, c := range candidates {
+			end := i + len(c.Value)
+			if !strings.HasPrefix(text[i:], c.Value) {
+				continue
+			}
+			if isWordByte(c.Value[0]) && i > 0 && isWordByte(text[i-1]) {
+				continue
+			}
+			if isWordByte(c.Value[len(c.Value)-1]) && end < len(text) && isWordByte(text[end]) {
+				continue
+			}
+			matches = append(matches, adoptMatch{Start: i, End: end, Var: c.Name})
+			i = end
+			matched = true
+			break
+		}
+		if !matched {
+			i++
+		}
+	}
+	return matches
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b == '-' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+// adoptPlaceholder is the expression for a candidate. Values Handlebars
+// would HTML-escape use triple braces.
+func adoptPlaceholder(c adoptCandidate) string {
+	if strings.ContainsAny(c.Value, "&<>'`=") {
+		return "{{{ " + c.Name + " }}}"
+	}
+	return "{{ " + c.Name + " }}"
+}
+
+// buildAdoptedTemplate replaces accepted matches with placeholders and
+// escapes any literal {{ in the rest of the file
+func buildAdoptedTemplate(text string, matches []adoptMatch, accepted map[string]bool, candidates map[string]adoptCandidate) string {
+	escape := func(s string) string { return strings.ReplaceAll(s, "{{", `\{{`) }
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if !accepted[m.Var] {
+			continue
+		}
+		b.WriteString(escape(text[last:m.Start]))
+		b.WriteString(adoptPlaceholder(candidates[m.Var]))
+		last = m.End
+	}
+	b.WriteString(escape(text[last:]))
+	return b.String()
+}
+
+// appendTemplateVariables adds name="value" lines to a variables file
+func appendTemplateVariables(path string, vars [][2]string) error {
+	var b strings.Builder
+	if existing, err := os.ReadFile(path); err == nil {
+		b.Write(existing)
+		if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("# Added by blackdot template adopt\n")
+	for _, kv := range vars {
+		fmt.Fprintf(&b, "%s=\"%s\"\n", kv[0], kv[1])
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, []byte(b.String()), 0644)
+}
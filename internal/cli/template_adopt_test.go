@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+)
+
+// TestFindAdoptMatches verifies longer values win and values never match
+// inside a longer word
+func TestFindAdoptMatches(t *testing.T) {
+	candidates := adoptCandidates(
+		map[string]string{"home": "/home/al", "user": "al", "editor": "nvim", "os": "linux"},
+		map[string]string{"workspace": "/home/al/work", "user": "ignored"},
+		nil,
+	)
+	for _, c := range candidates {
+		if c.Name == "os" || c.Name == "user" {
+			t.Errorf("candidate %s should be skipped", c.Name)
+		}
+	}
+
+	text := "root = /home/al/work/src\nconfig = /home/al/.config also nvim-qt\n{{ literal }}\n"
+	matches := findAdoptMatches(text, candidates)
+	var got []string
+	for _, m := range matches {
+		got = append(got, m.Var)
+	}
+	if strings.Join(got, ",") != "workspace,home" {
+		t.Errorf("matches = %v, want [workspace home]", got)
+	}
+
+	byName := make(map[string]adoptCandidate)
+	for _, c := range candidates {
+		byName[c.Name] = c
+	}
+	tmpl := buildAdoptedTemplate(text, matches, map[string]bool{"workspace": true, "home": true}, byName)
+	want := "root = {{ workspace }}/src\nconfig = {{ home }}/.config also nvim-qt\n\\{{ literal }}\n"
+	if tmpl != want {
+		t.Errorf("template =\n%s\nwant\n%s", tmpl, want)
+	}
+}
+
+// TestTemplateAdopt verifies adopt writes a template with a target that
+// renders back to the original, and defines variables it introduced
+func TestTemplateAdopt(t *testing.T) {
+	setScheduleEnv(t, "")
+	home := os.Getenv("HOME")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig-global"))
+	t.Setenv("WORKSPACE_TARGET", filepath.Join(home, "code"))
+	dir := filepath.Join(home, ".blackdot")
+	t.Setenv("BLACKDOT_DIR", dir)
+	os.MkdirAll(filepath.Join(dir, "templates", "configs"), 0755)
+	os.WriteFile(filepath.Join(dir, "templates", "_variables.local.sh"), []byte("git_email=\"me@example.com\"\n"), 0644)
+
+	source := filepath.Join(home, ".npmrc")
+	original := "email=me@example.com\ncache=" + filepath.Join(home, "code", ".npm") + "\nprefix=" + filepath.Join(home, ".npm-global") + "\n"
+	os.WriteFile(source, []byte(original), 0644)
+
+	// Decline the first question (git_email), accept the rest
+	restore := prompts.SetDefault(prompts.New(strings.NewReader("n\n"), &strings.Builder{}))
+	defer restore()
+	if err := runTemplateAdopt(source, "", nil, false, false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "templates", "configs", "npmrc.tmpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := string(data)
+	for _, want := range []string{"{{!-- target: ~/.npmrc --}}", "email=me@example.com", "cache={{ workspace }}/.npm", "prefix={{ home }}/.npm-global"} {
+		if !strings.Contains(tmpl, want) {
+			t.Errorf("template missing %q:\n%s", want, tmpl)
+		}
+	}
+	vars, _ := os.ReadFile(filepath.Join(dir, "templates", "_variables.local.sh"))
+	if !strings.Contains(string(vars), "workspace=\""+filepath.Join(home, "code")+"\"") {
+		t.Errorf("workspace not defined:\n%s", vars)
+	}
+
+	cfg, _ := getTemplateConfig()
+	engine := newTemplateEngine(cfg)
+	loadTemplateVariables(engine, cfg)
+	if rendered, err := engine.RenderFile(filepath.Join(cfg.templateDir, "npmrc.tmpl")); err != nil || rendered != original {
+		t.Errorf("render = %q, %v; want %q", rendered, err, original)
+	}
+
+	if err := runTemplateAdopt(source, "", nil, true, false, false); err == nil {
+		t.Error("existing template overwritten without --force")
+	}
+}
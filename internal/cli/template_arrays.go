@@ -0,0 +1,387 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/template"
+	"github.com/spf13/cobra"
+)
+
+// templateArraysFile holds arrays as JSON; it wins over shell arrays of
+// the same name in _variables*.sh
+const templateArraysFile = "_arrays.local.json"
+
+// templateArray is one array for {{#each}} loops and the file it came from
+type templateArray struct {
+	Name   string
+	Spec   *template.ArraySpec // nil for a JSON array without a declaration
+	Items  []map[string]interface{}
+	Source string
+}
+
+func newTemplateArraysCmd() *cobra.Command {
+	var exportJSON, exportShell, validate, force, stdout bool
+
+	cmd := &cobra.Command{
+		Use:   "arrays",
+		Short: "Manage JSON/shell arrays for {{#each}} loops",
+		Long: `List, validate and convert the arrays templates loop over with {{#each}}.
+
+An array can be a shell array in _variables.sh or _variables.local.sh,
+one element per entry with the fields joined by '|':
+
+  SSH_HOSTS=(
+      "github|github.com|git|~/.ssh/id_ed25519"
+  )
+
+or a list of objects in _arrays.local.json. When both define an array,
+the JSON one is used. Templates see it under both names ({{#each
+ssh_hosts}} and {{#each SSH_HOSTS}}).
+
+Fields are declared in _variables.schema.json under "arrays" (ssh_hosts
+is built in); --validate checks every element against them.`,
+		Example: `  blackdot template arrays                  # List arrays and where they come from
+  blackdot template arrays --validate
+  blackdot template arrays --export-json    # Shell arrays -> _arrays.local.json
+  blackdot template arrays --export-shell   # JSON arrays -> _variables.local.sh
+  blackdot template arrays --export-shell --stdout`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := getTemplateConfig()
+			if err != nil {
+				return err
+			}
+			switch {
+			case exportJSON && exportShell:
+				return fmt.Errorf("--export-json and --export-shell are exclusive")
+			case exportJSON:
+				return exportTemplateArraysJSON(cfg, force, stdout)
+			case exportShell:
+				return exportTemplateArraysShell(cfg, stdout)
+			case validate:
+				return validateTemplateArrays(cfg)
+			}
+			return listTemplateArrays(cfg)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&exportJSON, "export-json", "e", false, "Export shell arrays to _arrays.local.json")
+	cmd.Flags().BoolVar(&exportShell, "export-shell", false, "Export JSON arrays to _variables.local.sh")
+	cmd.Flags().BoolVar(&validate, "validate", false, "Validate arrays against their field declarations")
+	cmd.Flags().BoolVar(&force, "force", false, "With --export-json, replace arrays already in the JSON file")
+	cmd.Flags().BoolVar(&stdout, "stdout", false, "Print the exported arrays instead of writing them")
+	return cmd
+}
+
+// templateArraySpecs returns the declared arrays: the builtins plus
+// "arrays" in _variables.schema.json
+func templateArraySpecs(cfg *templateConfig) ([]template.ArraySpec, error) {
+	schemaPath := filepath.Join(cfg.variablesDir, templateSchemaFile)
+	if cfg.blackdotDir != "" {
+		schemaPath = paths.FindLayered(paths.Roots(cfg.blackdotDir), filepath.Join("templates", templateSchemaFile))
+	}
+	if schemaPath == "" || !fileExists(schemaPath) {
+		return (*template.VariableSchema)(nil).ArraySpecs(), nil
+	}
+	schema, err := template.LoadVariableSchema(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+	return schema.ArraySpecs(), nil
+}
+
+// loadShellTemplateArrays reads the declared arrays from _variables.sh and
+// _variables.local.sh, the local file winning
+func loadShellTemplateArrays(cfg *templateConfig, specs []template.ArraySpec) (map[string]templateArray, error) {
+	arrays := make(map[string]templateArray)
+	for _, name := range []string{"_variables.sh", "_variables.local.sh"} {
+		path := filepath.Join(cfg.variablesDir, name)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		shell := template.ParseShellArrays(string(data))
+		for i := range specs {
+			elements, ok := shell[specs[i].ShellName()]
+			if !ok {
+				continue
+			}
+			items, err := specs[i].ArrayFromShell(elements)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			arrays[specs[i].Name] = templateArray{Name: specs[i].Name, Spec: &specs[i], Items: items, Source: path}
+		}
+	}
+	return arrays, nil
+}
+
+// loadTemplateArrays returns every array, sorted by name: shell arrays,
+// replaced by JSON arrays of the same name
+func loadTemplateArrays(cfg *templateConfig) ([]templateArray, error) {
+	specs, err := templateArraySpecs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	arrays, err := loadShellTemplateArrays(cfg, specs)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonPath := filepath.Join(cfg.variablesDir, templateArraysFile)
+	fromJSON, err := template.LoadArraysFile(jsonPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for name, items := range fromJSON {
+		a := templateArray{Name: name, Items: items, Source: jsonPath}
+		for i := range specs {
+			if specs[i].Name == name {
+				a.Spec = &specs[i]
+			}
+		}
+		arrays[name] = a
+	}
+
+	list := make([]templateArray, 0, len(arrays))
+	for _, a := range arrays {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// setTemplateArrays gives the engine each array under its name and, for
+// declared arrays, its shell name
+func setTemplateArrays(engine *template.RaymondEngine, arrays []templateArray) {
+	for _, a := range arrays {
+		engine.SetArray(a.Name, a.Items)
+		if a.Spec != nil && a.Spec.ShellName() != a.Name {
+			engine.SetArray(a.Spec.ShellName(), a.Items)
+		}
+	}
+}
+
+func listTemplateArrays(cfg *templateConfig) error {
+	arrays, err := loadTemplateArrays(cfg)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+
+	PrintHeader("Template Arrays")
+	if len(arrays) == 0 {
+		Info("No arrays defined")
+		fmt.Println()
+		fmt.Println("Define them in:")
+		fmt.Printf("  %s\n", tildePath(filepath.Join(cfg.variablesDir, templateArraysFile)))
+		fmt.Printf("  %s (e.g. SSH_HOSTS=(...))\n", tildePath(filepath.Join(cfg.variablesDir, "_variables.local.sh")))
+		return nil
+	}
+
+	for _, a := range arrays {
+		fmt.Printf("%s (%d items) %s\n", Bold.Sprint(a.Name), len(a.Items), Dim.Sprintf("from %s", tildePath(a.Source)))
+		if a.Spec != nil {
+			fmt.Printf("  %s\n", Dim.Sprintf("fields: %s", strings.Join(a.Spec.FieldNames(), "|")))
+		}
+		for i, item := range a.Items {
+			if i >= 5 {
+				fmt.Printf("  ... and %d more\n", len(a.Items)-5)
+				break
+			}
+			itemJSON, _ := json.Marshal(item)
+			fmt.Printf("  [%d] %s\n", i, string(itemJSON))
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func validateTemplateArrays(cfg *templateConfig) error {
+	arrays, err := loadTemplateArrays(cfg)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+	if len(arrays) == 0 {
+		Info("No arrays defined")
+		return nil
+	}
+
+	problems := 0
+	for _, a := range arrays {
+		if a.Spec == nil {
+			Warn("%s: %d items, not declared in %s (not checked)", a.Name, len(a.Items), templateSchemaFile)
+			continue
+		}
+		found := a.Spec.Check(a.Items)
+		if len(found) == 0 {
+			Pass("%s: %d items %s", a.Name, len(a.Items), Dim.Sprintf("(%s)", tildePath(a.Source)))
+			continue
+		}
+		Fail("%s: %d problem(s) %s", a.Name, len(found), Dim.Sprintf("(%s)", tildePath(a.Source)))
+		for _, p := range found {
+			fmt.Printf("    %s\n", p)
+		}
+		problems += len(found)
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d array problem(s)", problems)
+	}
+	return nil
+}
+
+// exportTemplateArraysJSON writes the shell arrays to _arrays.local.json,
+// keeping the arrays already there unless force is set
+func exportTemplateArraysJSON(cfg *templateConfig, force, stdout bool) error {
+	specs, err := templateArraySpecs(cfg)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+	shell, err := loadShellTemplateArrays(cfg, specs)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+	if len(shell) == 0 {
+		Info("No shell arrays found in _variables.sh or _variables.local.sh")
+		return nil
+	}
+
+	jsonPath := filepath.Join(cfg.variablesDir, templateArraysFile)
+	existing := make(map[string]json.RawMessage)
+	if data, err := os.ReadFile(jsonPath); err == nil {
+		if err := json.Unmarshal(data, &existing); err != nil {
+			Fail("Invalid JSON in %s: %v", tildePath(jsonPath), err)
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(shell))
+	for name := range shell {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var exported []string
+	for _, name := range names {
+		a := shell[name]
+		if _, ok := existing[name]; ok && !force {
+			Warn("%s is already in %s; skipped (use --force to replace it)", name, templateArraysFile)
+			continue
+		}
+		if problems := a.Spec.Check(a.Items); len(problems) > 0 {
+			Fail("%s has %d problem(s), first: %s", a.Spec.ShellName(), len(problems), problems[0])
+			return fmt.Errorf("invalid array %s", name)
+		}
+		data, err := json.Marshal(a.Items)
+		if err != nil {
+			return err
+		}
+		existing[name] = data
+		exported = append(exported, fmt.Sprintf("%s (%d items)", name, len(a.Items)))
+	}
+	if len(exported) == 0 {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(existing, "", "  ")
+	if err != nil {
+		return err
+	}
+	if stdout {
+		fmt.Println(string(data))
+		return nil
+	}
+	if err := writeFileAtomic(jsonPath, append(data, '\n'), 0600); err != nil {
+		Fail("Failed to write %s: %v", tildePath(jsonPath), err)
+		return err
+	}
+	Pass("Exported %s to %s", strings.Join(exported, ", "), tildePath(jsonPath))
+	return nil
+}
+
+// exportTemplateArraysShell writes the declared JSON arrays to
+// _variables.local.sh, replacing assignments already there
+func exportTemplateArraysShell(cfg *templateConfig, stdout bool) error {
+	arrays, err := loadTemplateArrays(cfg)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+
+	var blocks []templateArray
+	for _, a := range arrays {
+		if filepath.Base(a.Source) != templateArraysFile {
+			continue
+		}
+		if a.Spec == nil {
+			Warn("%s is not declared in %s; its fields have no shell order, skipped", a.Name, templateSchemaFile)
+			continue
+		}
+		if problems := a.Spec.Check(a.Items); len(problems) > 0 {
+			Fail("%s has %d problem(s), first: %s", a.Name, len(problems), problems[0])
+			return fmt.Errorf("invalid array %s", a.Name)
+		}
+		blocks = append(blocks, a)
+	}
+	if len(blocks) == 0 {
+		Info("No JSON arrays to export")
+		return nil
+	}
+
+	if stdout {
+		for _, a := range blocks {
+			fmt.Print(a.Spec.ArrayToShell(a.Items))
+		}
+		return nil
+	}
+
+	localFile := filepath.Join(cfg.variablesDir, "_variables.local.sh")
+	content := "#!/usr/bin/env zsh\n# Machine-specific template variables (see _variables.sh)\n"
+	if data, err := os.ReadFile(localFile); err == nil {
+		content = string(data)
+	}
+	for _, a := range blocks {
+		content = replaceShellArray(content, *a.Spec, a.Spec.ArrayToShell(a.Items))
+	}
+	if err := writeFileAtomic(localFile, []byte(content), 0644); err != nil {
+		Fail("Failed to write %s: %v", tildePath(localFile), err)
+		return err
+	}
+	for _, a := range blocks {
+		Pass("Exported %s (%d items) as %s", a.Name, len(a.Items), a.Spec.ShellName())
+	}
+	fmt.Printf("  to %s\n", tildePath(localFile))
+	return nil
+}
+
+// replaceShellArray swaps the spec's array assignment in a variables file
+// for block, along with the field comment a previous export wrote, or
+// appends block when the file has none
+func replaceShellArray(content string, spec template.ArraySpec, block string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	for _, a := range template.FindShellArrays(content) {
+		if a.Name != spec.ShellName() {
+			continue
+		}
+		from := a.StartLine
+		if from > 0 && strings.HasPrefix(lines[from-1], "# "+spec.Name+": ") {
+			from--
+		}
+		out := append([]string{}, lines[:from]...)
+		out = append(out, strings.Split(strings.TrimRight(block, "\n"), "\n")...)
+		out = append(out, lines[min(a.EndLine+1, len(lines)):]...)
+		return strings.Join(out, "\n") + "\n"
+	}
+	return strings.Join(lines, "\n") + "\n\n" + block
+}
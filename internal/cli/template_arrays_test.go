@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/template"
+)
+
+// TestLoadTemplateArrays verifies shell arrays are read from the variables
+// files, JSON arrays replace them, and templates see both names
+func TestLoadTemplateArrays(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &templateConfig{variablesDir: dir}
+	os.WriteFile(filepath.Join(dir, "_variables.sh"), []byte("typeset -ga SSH_HOSTS=(\n)\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "_variables.local.sh"), []byte(`SSH_HOSTS=(
+    "github|github.com|git|~/.ssh/id_ed25519"
+    "work|server.co|deploy|||work"
+)
+`), 0644)
+
+	arrays, err := loadTemplateArrays(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(arrays) != 1 || len(arrays[0].Items) != 2 || filepath.Base(arrays[0].Source) != "_variables.local.sh" {
+		t.Fatalf("arrays = %+v", arrays)
+	}
+
+	engine := template.NewRaymondEngine("")
+	setTemplateArrays(engine, arrays)
+	out, err := engine.Render("{{#each SSH_HOSTS}}{{name}} {{/each}}/ {{#each ssh_hosts}}{{hostname}} {{/each}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "github work / github.com server.co " {
+		t.Errorf("render = %q", out)
+	}
+
+	groups, err := sshHostsByCategory(cfg)
+	if err != nil || len(groups["work"]) != 1 || len(groups["hosts"]) != 1 {
+		t.Errorf("sshHostsByCategory = %v, %v", groups, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, templateArraysFile), []byte(`{"ssh_hosts": [{"name": "json"}], "extra": [{"k": "v"}]}`), 0600)
+	arrays, err = loadTemplateArrays(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(arrays) != 2 || arrays[0].Name != "extra" || arrays[0].Spec != nil || arrays[1].Items[0]["name"] != "json" {
+		t.Errorf("with JSON arrays = %+v", arrays)
+	}
+}
+
+// TestReplaceShellArray verifies an export replaces the previous array and
+// its field comment, and appends when there is none
+func TestReplaceShellArray(t *testing.T) {
+	spec := template.BuiltinArrays[0]
+	block := spec.ArrayToShell([]map[string]interface{}{{"name": "new", "hostname": "new.example.com"}})
+
+	content := "git_name=\"Me\"\n# ssh_hosts: old fields\nSSH_HOSTS=(\n    \"old|old.example.com\"\n)\ngit_email=\"me@example.com\"\n"
+	got := replaceShellArray(content, spec, block)
+	want := "git_name=\"Me\"\n" + block + "git_email=\"me@example.com\"\n"
+	if got != want {
+		t.Errorf("replace:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = replaceShellArray("git_name=\"Me\"\n", spec, block)
+	if got != "git_name=\"Me\"\n\n"+block || strings.Count(got, "SSH_HOSTS=(") != 1 {
+		t.Errorf("append:\n%s", got)
+	}
+}
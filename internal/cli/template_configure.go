@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+	"github.com/blackwell-systems/blackdot/internal/template"
+	"github.com/spf13/cobra"
+)
+
+// templateSchemaFile declares the variables templates use, next to
+// _variables.sh. An overlay repo's schema replaces the base one.
+const templateSchemaFile = "_variables.schema.json"
+
+// configureAttempts is how often an invalid answer is asked again
+const configureAttempts = 3
+
+func newTemplateConfigureCmd() *cobra.Command {
+	var all, check bool
+
+	cmd := &cobra.Command{
+		Use:   "configure",
+		Short: "Prompt for missing or invalid template variables",
+		Long: `Ask for the template variables declared in templates/_variables.schema.json
+that are not set yet or whose values do not validate, and write the
+answers to templates/_variables.local.sh.
+
+Each schema entry has a name, a type (string, bool, int, email, path),
+a description, an optional default and enum, and whether it is required
+or secret. Secret values are read without echo and never shown.
+Press Enter to accept the default shown; an optional variable left empty
+is recorded as empty and not asked again.
+
+Examples:
+  blackdot template configure            # Fill in what is missing
+  blackdot template configure --all      # Review every variable
+  blackdot template configure --check    # Report problems, don't ask`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTemplateConfigure(all, check)
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Ask for every variable, not only missing or invalid ones")
+	cmd.Flags().BoolVar(&check, "check", false, "Only report missing or invalid variables")
+	return cmd
+}
+
+func runTemplateConfigure(all, check bool) error {
+	cfg, err := getTemplateConfig()
+	if err != nil {
+		return err
+	}
+
+	schemaPath := paths.FindLayered(paths.Roots(cfg.blackdotDir), filepath.Join("templates", templateSchemaFile))
+	if schemaPath == "" {
+		Fail("No variable schema found (%s)", tildePath(filepath.Join(cfg.variablesDir, templateSchemaFile)))
+		return fmt.Errorf("no template variable schema")
+	}
+	schema, err := template.LoadVariableSchema(schemaPath)
+	if err != nil {
+		Fail("Invalid schema: %v", err)
+		return err
+	}
+
+	engine := newTemplateEngine(cfg)
+	if err := loadTemplateVariables(engine, cfg); err != nil {
+		return fmt.Errorf("loading variables: %w", err)
+	}
+	vars := engine.Vars()
+
+	if check {
+		problems := schema.Check(vars)
+		for _, p := range problems {
+			if p.Value == "" || p.Spec.Secret {
+				Fail("%s: %v", p.Spec.Name, p.Err)
+			} else {
+				Fail("%s = %q: %v", p.Spec.Name, p.Value, p.Err)
+			}
+		}
+		if len(problems) > 0 {
+			Info("Fix with: blackdot template configure")
+			return fmt.Errorf("%d template variable(s) missing or invalid", len(problems))
+		}
+		Pass("All %d template variables are valid", len(schema.Variables))
+		return nil
+	}
+
+	PrintHeader("Configure Template Variables")
+	answers, err := askTemplateVariables(schema, vars, all, prompts.Default())
+	if err != nil {
+		return err
+	}
+	if len(answers) == 0 {
+		Pass("All template variables are set")
+		return nil
+	}
+
+	localFile := filepath.Join(cfg.variablesDir, "_variables.local.sh")
+	if err := setTemplateVariables(localFile, answers); err != nil {
+		Fail("Failed to update %s: %v", tildePath(localFile), err)
+		return err
+	}
+	fmt.Println()
+	Pass("Saved %d variable(s) to %s", len(answers), tildePath(localFile))
+	Info("Run 'blackdot template render' to apply them")
+	return nil
+}
+
+// askTemplateVariables prompts for the schema variables that are undefined
+// or invalid in vars (every variable with all) and returns the answers in
+// schema order. Environment overrides are shown but not asked for.
+func askTemplateVariables(schema *template.VariableSchema, vars map[string]string, all bool, p *prompts.Prompter) ([][2]string, error) {
+	invalid := map[string]bool{}
+	for _, problem := range schema.Check(vars) {
+		invalid[problem.Spec.Name] = true
+	}
+
+	var answers [][2]string
+	for _, spec := range schema.Variables {
+		current, defined := vars[spec.Name]
+		if !all && defined && !invalid[spec.Name] {
+			continue
+		}
+		if os.Getenv(templateEnvName(spec.Name)) != "" {
+			Info("%s is set by %s; skipped", spec.Name, templateEnvName(spec.Name))
+			continue
+		}
+
+		def := current
+		if def == "" || invalid[spec.Name] {
+			def = spec.Default
+		}
+		fmt.Fprintln(p.Out)
+		fmt.Fprintf(p.Out, "%s %s\n", Cyan.Sprint(spec.Name), Dim.Sprint(describeTemplateVariable(spec)))
+		if invalid[spec.Name] && current != "" && !spec.Secret {
+			fmt.Fprintf(p.Out, "  current value %q is invalid\n", current)
+		}
+
+		value, err := askTemplateVariable(spec, def, p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", spec.Name, err)
+		}
+		if !defined || value != current {
+			answers = append(answers, [2]string{spec.Name, value})
+		}
+	}
+	return answers, nil
+}
+
+// askTemplateVariable asks for one value until it validates
+func askTemplateVariable(spec template.VariableSpec, def string, p *prompts.Prompter) (string, error) {
+	question := spec.Description
+	if question == "" {
+		question = spec.Name
+	}
+
+	switch {
+	case len(spec.Enum) > 0:
+		index := -1
+		for i, option := range spec.Enum {
+			if option == def {
+				index = i
+			}
+		}
+		i, err := p.Select(question, spec.Enum, index)
+		if err != nil {
+			return "", err
+		}
+		return spec.Enum[i], nil
+	case spec.Type == template.VarBool:
+		yes, err := p.Confirm(question+"?", def == "true")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(yes), nil
+	}
+
+	// Secrets are read without echo, and never shown as the default
+	read := func() (string, error) { return p.Input(question, def) }
+	if spec.Secret {
+		read = func() (string, error) {
+			q := question
+			if def != "" {
+				q += " (Enter keeps the current value)"
+			}
+			value, err := p.Secret(q)
+			if value == "" {
+				value = def
+			}
+			return value, err
+		}
+	}
+
+	for attempt := 0; attempt < configureAttempts; attempt++ {
+		value, err := read()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case value == "" && spec.Required:
+			fmt.Fprintln(p.Out, "  a value is required")
+		case value == "":
+			return "", nil
+		default:
+			if err := spec.Validate(value); err != nil {
+				fmt.Fprintf(p.Out, "  %v\n", err)
+				continue
+			}
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("no valid value after %d attempts", configureAttempts)
+}
+
+// describeTemplateVariable is the hint printed after a variable's name:
+// (email, required) or (bool, default: true)
+func describeTemplateVariable(spec template.VariableSpec) string {
+	kind := spec.Type
+	if kind == "" {
+		kind = template.VarString
+	}
+	parts := []string{kind}
+	if spec.Required {
+		parts = append(parts, "required")
+	}
+	if spec.Default != "" {
+		parts = append(parts, "default: "+spec.Default)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// templateEnvName is the environment variable that overrides a template
+// variable
+func templateEnvName(name string) string {
+	return "BLACKDOT_TMPL_" + strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+}
+
+// templateAssignment matches a name="value" line in a variables file
+var templateAssignment = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_.]*)=`)
+
+// setTemplateVariables writes name="value" lines to a variables file,
+// replacing existing assignments in place and appending the rest
+func setTemplateVariables(path string, vars [][2]string) error {
+	pending := map[string]string{}
+	for _, kv := range vars {
+		pending[kv[0]] = kv[1]
+	}
+
+	var lines []string
+	if existing, err := os.ReadFile(path); err == nil {
+		lines = strings.Split(strings.TrimRight(string(existing), "\n"), "\n")
+	} else {
+		lines = []string{"#!/usr/bin/env zsh", "# Machine-specific template variables (see _variables.sh)"}
+	}
+	for i, line := range lines {
+		if m := templateAssignment.FindStringSubmatch(line); m != nil {
+			if value, ok := pending[m[1]]; ok {
+				lines[i] = fmt.Sprintf("%s=\"%s\"", m[1], value)
+				delete(pending, m[1])
+			}
+		}
+	}
+
+	var added []string
+	for _, kv := range vars {
+		if value, ok := pending[kv[0]]; ok {
+			added = append(added, fmt.Sprintf("%s=\"%s\"", kv[0], value))
+		}
+	}
+	if len(added) > 0 {
+		lines = append(lines, "", "# Added by blackdot template configure")
+		lines = append(lines, added...)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
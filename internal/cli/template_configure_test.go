@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+	"github.com/blackwell-systems/blackdot/internal/template"
+)
+
+// TestAskTemplateVariables verifies only missing or invalid variables are
+// asked for, invalid answers are asked again, and defaults apply on Enter
+func TestAskTemplateVariables(t *testing.T) {
+	schema := &template.VariableSchema{Variables: []template.VariableSpec{
+		{Name: "git_name", Required: true},
+		{Name: "git_email", Type: template.VarEmail, Required: true},
+		{Name: "machine_type", Default: "unknown", Enum: []string{"work", "personal", "unknown"}},
+		{Name: "enable_nvm", Type: template.VarBool, Default: "true"},
+		{Name: "editor", Default: "nvim"},
+	}}
+	vars := map[string]string{"git_name": "Ann", "git_email": "ann@", "editor": "vim"}
+	// git_email: invalid then valid; machine_type: "2"; enable_nvm: Enter
+	in := strings.NewReader("still-bad\nann@example.com\n2\n\n")
+	var out bytes.Buffer
+
+	answers, err := askTemplateVariables(schema, vars, false, prompts.New(in, &out))
+	if err != nil {
+		t.Fatalf("ask: %v\n%s", err, out.String())
+	}
+	want := [][2]string{{"git_email", "ann@example.com"}, {"machine_type", "personal"}, {"enable_nvm", "true"}}
+	if len(answers) != len(want) {
+		t.Fatalf("answers = %v", answers)
+	}
+	for i := range want {
+		if answers[i] != want[i] {
+			t.Errorf("answer %d = %v, want %v", i, answers[i], want[i])
+		}
+	}
+	if !strings.Contains(out.String(), "must be an email address") {
+		t.Errorf("invalid answer not reported:\n%s", out.String())
+	}
+}
+
+// TestSetTemplateVariables verifies assignments are replaced in place and
+// new ones appended
+func TestSetTemplateVariables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "_variables.local.sh")
+	os.WriteFile(path, []byte("# mine\ngit_name=\"Old\"\nexport editor=vim\n"), 0644)
+
+	if err := setTemplateVariables(path, [][2]string{{"editor", "nvim"}, {"git_email", "a@b.c"}, {"git_name", "Ann"}}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	want := "# mine\ngit_name=\"Ann\"\neditor=\"nvim\"\n\n# Added by blackdot template configure\ngit_email=\"a@b.c\"\n"
+	if string(data) != want {
+		t.Errorf("file =\n%s\nwant\n%s", data, want)
+	}
+}
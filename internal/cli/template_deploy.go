@@ -0,0 +1,341 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/plan"
+	"github.com/blackwell-systems/blackdot/internal/safefile"
+	"github.com/blackwell-systems/blackdot/internal/template"
+	"github.com/spf13/cobra"
+)
+
+// templateTarget is where a rendered template is deployed
+type templateTarget struct {
+	Name     string // output name in generated/
+	Template string
+	Output   string // path in generated/
+	Target   string // expanded destination
+	Mode     os.FileMode
+	Strategy string
+}
+
+// legacyTemplateLinks are the destinations used for the stock templates
+// when they declare no front-matter target
+func legacyTemplateLinks(cfg *templateConfig) map[string]string {
+	home := os.Getenv("HOME")
+	return map[string]string{
+		"gitconfig":    filepath.Join(home, ".gitconfig"),
+		"99-local.zsh": filepath.Join(cfg.blackdotDir, "zsh", "zsh.d", "99-local.zsh"),
+		"ssh-config":   filepath.Join(home, ".ssh", "config"),
+		"claude.local": filepath.Join(home, ".claude.local"),
+	}
+}
+
+// templateTargets returns the deployment of each template that has one:
+// its front-matter, or the legacy link for the stock templates
+func templateTargets(cfg *templateConfig, templates []string) ([]templateTarget, error) {
+	legacy := legacyTemplateLinks(cfg)
+	var targets []templateTarget
+	for _, tmplPath := range templates {
+		name := strings.TrimSuffix(filepath.Base(tmplPath), ".tmpl")
+		t := templateTarget{
+			Name:     name,
+			Template: tmplPath,
+			Output:   filepath.Join(cfg.generatedDir, name),
+			Strategy: template.StrategyLink,
+		}
+		fm, err := template.ParseFrontMatterFile(tmplPath)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case fm != nil:
+			t.Target, t.Mode, t.Strategy = expandPath(fm.Target), fm.Mode, fm.Strategy
+		case legacy[name] != "":
+			t.Target = legacy[name]
+		default:
+			continue
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// deployTemplateTarget links or copies a rendered output to its target.
+// Files blackdot did not put there are backed up first.
+func deployTemplateTarget(t templateTarget, rendered *renderedState) error {
+	os.MkdirAll(filepath.Dir(t.Target), 0755)
+	info, statErr := os.Lstat(t.Target)
+	isLink := statErr == nil && info.Mode()&os.ModeSymlink != 0
+
+	if t.Strategy == template.StrategyCopy {
+		content, err := os.ReadFile(t.Output)
+		if err != nil {
+			return err
+		}
+		if isLink {
+			os.Remove(t.Target)
+		} else if current, err := os.ReadFile(t.Target); err == nil && !bytes.Equal(current, content) {
+			if rendered.Deployed[t.Target] != sha256Hex(current) {
+				if err := backupFile(t.Target); err != nil {
+					return err
+				}
+				Info("Backed up: %s", tildePath(t.Target))
+			}
+		}
+		mode := t.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := writeFileAtomic(t.Target, content, mode); err != nil {
+			return err
+		}
+		return rendered.recordDeployed(t.Target, string(content))
+	}
+
+	if t.Mode != 0 {
+		if err := os.Chmod(t.Output, t.Mode); err != nil {
+			return err
+		}
+	}
+	if isLink {
+		if dest, _ := os.Readlink(t.Target); dest == t.Output {
+			return nil
+		}
+	} else if statErr == nil {
+		backup := t.Target + ".backup." + time.Now().Format("20060102150405")
+		if info.IsDir() {
+			if err := os.Rename(t.Target, backup); err != nil {
+				return err
+			}
+		} else {
+			// Copy rather than move, so the target stays in place until
+			// the link replaces it
+			content, err := os.ReadFile(t.Target)
+			if err != nil {
+				return err
+			}
+			if err := writeFileAtomic(backup, content, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+		Info("Backed up: %s", backup)
+	}
+	// The new link replaces the target in one rename, so the target is
+	// never missing
+	return safefile.Symlink(t.Output, t.Target)
+}
+
+// runTemplateLink deploys generated files to their targets
+func runTemplateLink(cmd *cobra.Command, args []string) error {
+	cfg, err := getTemplateConfig()
+	if err != nil {
+		return err
+	}
+
+	PrintHeader("Linking Generated Files")
+
+	templates, err := resolveTemplatePaths(cfg, args)
+	if err != nil {
+		return err
+	}
+	return deployTemplateTargets(cfg, templates)
+}
+
+func deployTemplateTargets(cfg *templateConfig, templates []string) error {
+	targets, err := templateTargets(cfg, templates)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+
+	rendered := loadRenderedState()
+	deployed := 0
+	var failed []string
+	for _, t := range targets {
+		if _, err := os.Stat(t.Output); os.IsNotExist(err) {
+			continue
+		}
+		if err := deployTemplateTarget(t, rendered); err != nil {
+			Fail("Failed to %s %s: %v", t.Strategy, t.Name, err)
+			failed = append(failed, t.Name)
+			continue
+		}
+		if t.Strategy == template.StrategyCopy {
+			Pass("Copied: %s → %s", t.Name, tildePath(t.Target))
+		} else {
+			Pass("Linked: %s → %s", t.Name, tildePath(t.Target))
+		}
+		deployed++
+	}
+
+	if deployed == 0 && len(failed) == 0 {
+		Info("No files to link (run 'blackdot template render' first)")
+	} else if deployed > 0 {
+		fmt.Printf("\nDeployed %d file(s)\n", deployed)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to deploy: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func newTemplateApplyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply [file...]",
+		Short: "Render templates and deploy them to their targets",
+		Long: `Render templates and deploy each one to the target its front-matter
+declares, in one step.
+
+A template declares its deployment in a leading comment:
+
+  {{!-- target: ~/.gitconfig mode: 0644 copy --}}
+
+  target    Destination path (~ is expanded)
+  mode      Octal file mode for the deployed file
+  link      Symlink the target to generated/ (default)
+  copy      Write a copy to the target
+
+The stock templates without front-matter keep their usual destinations.
+With --dry-run, each target is compared with what would be deployed and
+the differences are shown, followed by the change plan (--plan-format json
+for scripts); nothing is written.
+
+Examples:
+  blackdot template apply                 # Render and deploy everything
+  blackdot template apply gitconfig.tmpl
+  blackdot template apply --dry-run       # Per-file diffs, no changes`,
+		RunE: runTemplateApply,
+	}
+	cmd.Flags().BoolP("dry-run", "n", false, "Show per-file diffs without writing")
+	cmd.Flags().String("plan-format", "table", "With --dry-run, print the change plan as: table, json")
+	cmd.Flags().Bool("fold", false, "Merge hand edits in generated/ back into their templates")
+	return cmd
+}
+
+func runTemplateApply(cmd *cobra.Command, args []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if dryRun {
+		planFormat, _ := cmd.Flags().GetString("plan-format")
+		return planTemplateApply(args, planFormat)
+	}
+	if err := runTemplateRender(cmd, args); err != nil {
+		return err
+	}
+
+	cfg, err := getTemplateConfig()
+	if err != nil {
+		return err
+	}
+	templates, err := resolveTemplatePaths(cfg, args)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	return deployTemplateTargets(cfg, templates)
+}
+
+// planTemplateApply renders in memory and diffs each target against what
+// apply would leave there
+func planTemplateApply(args []string, planFormat string) error {
+	changesPlan, printPlan, err := startChangePlan("template apply", true, planFormat)
+	if err != nil {
+		return err
+	}
+	cfg, err := getTemplateConfig()
+	if err != nil {
+		return err
+	}
+	templates, err := resolveTemplatePaths(cfg, args)
+	if err != nil {
+		return err
+	}
+	targets, err := templateTargets(cfg, templates)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+	engine := newTemplateEngine(cfg)
+	if err := loadTemplateVariables(engine, cfg); err != nil {
+		return fmt.Errorf("loading variables: %w", err)
+	}
+	if err := renderSSHHostIncludes(engine, cfg, false, false); err != nil {
+		return err
+	}
+
+	defer printPlan()
+
+	PrintHeader("Template Apply (dry run)")
+	changes := 0
+	for _, t := range targets {
+		content, err := engine.RenderFile(t.Template)
+		if err != nil {
+			Fail("%v", err)
+			return err
+		}
+
+		op := plan.Op{Target: t.Target, Before: describeFileForPlan(t.Target), After: formatSize(int64(len(content))), Detail: t.Strategy}
+		linkOp := plan.Op{Kind: plan.Symlink, Target: t.Target, Before: op.Before, After: "link → generated/" + t.Name}
+		info, statErr := os.Lstat(t.Target)
+		switch {
+		case statErr != nil && t.Strategy == template.StrategyCopy:
+			op.Kind = plan.Create
+		case statErr != nil:
+			op = linkOp
+		case info.Mode()&os.ModeSymlink != 0:
+			if dest, _ := os.Readlink(t.Target); t.Strategy == template.StrategyCopy {
+				op.Kind, op.Detail = plan.Update, "copy replaces the link"
+			} else if dest != t.Output {
+				op = linkOp
+			}
+		case t.Strategy == template.StrategyLink:
+			op = linkOp
+			op.Detail = "existing file backed up"
+		}
+
+		current, _ := os.ReadFile(t.Target)
+		differs := string(current) != content
+		if op.Kind == "" && differs {
+			op.Kind = plan.Update
+		}
+		changed := op.Kind != ""
+		if changed {
+			changesPlan.Add(op)
+		}
+		if statErr == nil && info.Mode().IsRegular() && t.Strategy == template.StrategyCopy {
+			mode := t.Mode
+			if mode == 0 {
+				mode = 0644
+			}
+			if perm := info.Mode().Perm(); perm != mode.Perm() {
+				changed = true
+				changesPlan.Add(plan.Op{Kind: plan.Chmod, Target: t.Target, Before: fmt.Sprintf("%04o", perm), After: fmt.Sprintf("%04o", mode.Perm())})
+			}
+		}
+		if changed {
+			changes++
+		}
+		if differs {
+			printUnifiedDiff(os.Stdout, tildePath(t.Target), string(current), "generated/"+t.Name, content)
+			fmt.Println()
+		} else if !changed {
+			fmt.Printf("  %s %s → %s %s\n", Green.Sprint("✓"), t.Name, tildePath(t.Target), Dim.Sprint("(up to date)"))
+		}
+	}
+
+	if len(targets) == 0 {
+		Info("No templates declare a target")
+		return nil
+	}
+	if changes == 0 {
+		Pass("All targets are up to date")
+	} else {
+		Info("%d target(s) would change; run without --dry-run to apply", changes)
+	}
+	return nil
+}
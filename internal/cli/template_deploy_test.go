@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupTemplateDeploy creates a blackdot dir with a copy and a link
+// template and returns the home directory
+func setupTemplateDeploy(t *testing.T) string {
+	t.Helper()
+	setScheduleEnv(t, "")
+	home := os.Getenv("HOME")
+	dir := filepath.Join(home, ".blackdot")
+	t.Setenv("BLACKDOT_DIR", dir)
+	configs := filepath.Join(dir, "templates", "configs")
+	os.MkdirAll(configs, 0755)
+	os.WriteFile(filepath.Join(configs, "netrc.tmpl"), []byte("{{!-- target: ~/.netrc mode: 0600 copy --}}\nmachine example.com\n"), 0644)
+	os.WriteFile(filepath.Join(configs, "tool.toml.tmpl"), []byte("{{!-- target: ~/.config/tool/tool.toml --}}\ncolor = true\n"), 0644)
+	os.WriteFile(filepath.Join(configs, "notes.tmpl"), []byte("no target\n"), 0644)
+	return home
+}
+
+// TestTemplateApply verifies apply renders and deploys by front-matter,
+// copies with the declared mode, links by default, and backs up files it
+// did not write
+func TestTemplateApply(t *testing.T) {
+	home := setupTemplateDeploy(t)
+	netrc := filepath.Join(home, ".netrc")
+	os.WriteFile(netrc, []byte("mine\n"), 0600)
+
+	cmd := newTemplateApplyCmd()
+	cmd.SetArgs(nil)
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(netrc)
+	info, _ := os.Lstat(netrc)
+	if string(data) != "machine example.com\n" || info.Mode().Perm() != 0600 || info.Mode()&os.ModeSymlink != 0 {
+		t.Errorf(".netrc = %q, mode %v", data, info.Mode())
+	}
+	backups, _ := filepath.Glob(netrc + ".bak-*")
+	if len(backups) != 1 {
+		t.Errorf("existing .netrc should be backed up once, got %v", backups)
+	}
+
+	toml := filepath.Join(home, ".config", "tool", "tool.toml")
+	if dest, err := os.Readlink(toml); err != nil || !strings.HasSuffix(dest, filepath.Join("generated", "tool.toml")) {
+		t.Errorf("tool.toml link = %q, %v", dest, err)
+	}
+	if _, err := os.Lstat(filepath.Join(home, "notes")); !os.IsNotExist(err) {
+		t.Error("templates without a target must not be deployed")
+	}
+
+	// Re-applying over our own copy must not create another backup
+	if err := newTemplateApplyCmd().Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := filepath.Glob(netrc + ".bak-*"); len(backups) != 1 {
+		t.Errorf("re-apply backed up its own file: %v", backups)
+	}
+}
@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// templateFixture is a templates/tests/*.yaml file: cases that render one
+// template with fixed variables and check the output
+type templateFixture struct {
+	Template string                `yaml:"template"` // default: <fixture name>.tmpl
+	Cases    []templateFixtureCase `yaml:"cases"`
+}
+
+// templateFixtureCase is one set of inputs and expectations
+type templateFixtureCase struct {
+	Name        string                              `yaml:"name"`
+	Vars        map[string]string                   `yaml:"vars"`
+	Arrays      map[string][]map[string]interface{} `yaml:"arrays"`
+	Contains    []string                            `yaml:"contains"`
+	NotContains []string                            `yaml:"not_contains"`
+	Golden      string                              `yaml:"golden"` // relative to the fixture file
+}
+
+// templateCaseResult is the outcome of one case
+type templateCaseResult struct {
+	Name     string
+	Failures []string
+	Updated  bool
+	// Golden mismatch, shown as a diff
+	GoldenPath string
+	Want, Got  string
+}
+
+func newTemplateTestCmd() *cobra.Command {
+	var update bool
+	var run string
+
+	cmd := &cobra.Command{
+		Use:   "test [fixture|template...]",
+		Short: "Test templates against fixture variables",
+		Long: `Render templates with the variables in templates/tests/*.yaml and check
+the output, so a template change cannot silently break a config.
+
+Each fixture names a template and lists cases:
+
+  template: gitconfig.tmpl        # default: <fixture name>.tmpl
+  cases:
+    - name: work laptop
+      vars:
+        git_name: Jane Doe
+        git_email: jane@corp.example
+      arrays:                     # for {{#each}}
+        hosts: [{name: bastion}]
+      contains: ["email = jane@corp.example"]
+      not_contains: ["signingkey"]
+      golden: golden/gitconfig-work   # expected output, next to the fixture
+
+Cases see only templates/_variables.sh and their own vars: machine-local
+variables, auto-detected values, and BLACKDOT_TMPL_* are ignored, so
+results are the same on every machine. A golden mismatch prints a diff;
+--update writes the rendered output to the golden files instead.
+
+Examples:
+  blackdot template test                  # All fixtures
+  blackdot template test gitconfig        # One fixture or template
+  blackdot template test --run work       # Cases whose name contains "work"
+  blackdot template test --update         # Accept current output`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTemplateTest(args, run, update)
+		},
+	}
+
+	cmd.Flags().BoolVar(&update, "update", false, "Write rendered output to golden files")
+	cmd.Flags().StringVar(&run, "run", "", "Only run cases whose name contains this text")
+	return cmd
+}
+
+func runTemplateTest(filter []string, run string, update bool) error {
+	cfg, err := getTemplateConfig()
+	if err != nil {
+		return err
+	}
+	roots := paths.Roots(cfg.blackdotDir)
+	files, _ := paths.ResolveLayered(roots, filepath.Join("templates", "tests"), "*.yaml")
+
+	PrintHeader("Template Tests")
+
+	passed, failed, updated := 0, 0, 0
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f.Path), ".yaml")
+		fixture, err := loadTemplateFixture(f.Path)
+		if err != nil {
+			Fail("%s: %v", name, err)
+			failed++
+			continue
+		}
+		if fixture.Template == "" {
+			fixture.Template = name + ".tmpl"
+		}
+		if !matchesTemplateFilter(filter, name, fixture.Template) {
+			continue
+		}
+
+		tmplPath := filepath.Join(cfg.templateDir, fixture.Template)
+		if layered := paths.FindLayered(roots, filepath.Join("templates", "configs", fixture.Template)); layered != "" {
+			tmplPath = layered
+		}
+
+		for i, c := range fixture.Cases {
+			if c.Name == "" {
+				c.Name = fmt.Sprintf("case %d", i+1)
+			}
+			if run != "" && !strings.Contains(c.Name, run) {
+				continue
+			}
+			r := runTemplateCase(cfg, tmplPath, filepath.Dir(f.Path), c, update)
+			label := name + ": " + r.Name
+			switch {
+			case len(r.Failures) > 0:
+				failed++
+				Fail("%s", label)
+				for _, msg := range r.Failures {
+					fmt.Printf("    %s\n", msg)
+				}
+				if r.GoldenPath != "" {
+					printUnifiedDiff(os.Stdout, tildePath(r.GoldenPath), r.Want, "rendered", r.Got)
+				}
+			case r.Updated:
+				updated++
+				Pass("%s %s", label, Dim.Sprintf("(updated %s)", tildePath(r.GoldenPath)))
+			default:
+				passed++
+				Pass("%s", label)
+			}
+		}
+	}
+
+	total := passed + failed + updated
+	fmt.Println()
+	if total == 0 {
+		Info("No template tests found in templates/tests/")
+		return nil
+	}
+	if failed > 0 {
+		Fail("%d of %d template test(s) failed", failed, total)
+		return fmt.Errorf("%d template test(s) failed", failed)
+	}
+	if updated > 0 {
+		Pass("%d passed, %d golden file(s) updated", passed, updated)
+		return nil
+	}
+	Pass("All %d template test(s) passed", total)
+	return nil
+}
+
+// loadTemplateFixture reads one fixture file
+func loadTemplateFixture(path string) (*templateFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture templateFixture
+	if err := yaml.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("parsing: %w", err)
+	}
+	if len(fixture.Cases) == 0 {
+		return nil, fmt.Errorf("no cases")
+	}
+	return &fixture, nil
+}
+
+// matchesTemplateFilter reports whether a fixture is selected by the
+// command's arguments: its own name or its template's, with or without .tmpl
+func matchesTemplateFilter(filter []string, fixture, template string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, arg := range filter {
+		arg = strings.TrimSuffix(strings.TrimSuffix(arg, ".yaml"), ".tmpl")
+		if arg == fixture || arg == strings.TrimSuffix(template, ".tmpl") {
+			return true
+		}
+	}
+	return false
+}
+
+// runTemplateCase renders the template with the case's variables and
+// checks the result
+func runTemplateCase(cfg *templateConfig, tmplPath, fixtureDir string, c templateFixtureCase, update bool) templateCaseResult {
+	r := templateCaseResult{Name: c.Name}
+
+	engine := newTemplateEngine(cfg)
+	engine.IgnoreEnvironment()
+	defaults := filepath.Join(cfg.variablesDir, "_variables.sh")
+	if _, err := os.Stat(defaults); err == nil {
+		if err := engine.LoadVariablesFile(defaults); err != nil {
+			r.Failures = append(r.Failures, fmt.Sprintf("loading %s: %v", tildePath(defaults), err))
+			return r
+		}
+	}
+	for name, value := range c.Vars {
+		engine.SetVar(name, value)
+	}
+	for name, items := range c.Arrays {
+		engine.SetArray(name, items)
+	}
+
+	got, err := engine.RenderFile(tmplPath)
+	if err != nil {
+		r.Failures = append(r.Failures, err.Error())
+		return r
+	}
+
+	for _, want := range c.Contains {
+		if !strings.Contains(got, want) {
+			r.Failures = append(r.Failures, fmt.Sprintf("missing %q", want))
+		}
+	}
+	for _, unwanted := range c.NotContains {
+		if strings.Contains(got, unwanted) {
+			r.Failures = append(r.Failures, fmt.Sprintf("unexpected %q", unwanted))
+		}
+	}
+
+	if c.Golden == "" {
+		return r
+	}
+	golden := c.Golden
+	if !filepath.IsAbs(golden) {
+		golden = filepath.Join(fixtureDir, golden)
+	}
+	if update {
+		if len(r.Failures) > 0 {
+			return r
+		}
+		err := os.MkdirAll(filepath.Dir(golden), 0755)
+		if err == nil {
+			err = os.WriteFile(golden, []byte(got), 0644)
+		}
+		if err != nil {
+			r.Failures = append(r.Failures, fmt.Sprintf("writing golden file: %v", err))
+			return r
+		}
+		r.Updated, r.GoldenPath = true, golden
+		return r
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		r.Failures = append(r.Failures, fmt.Sprintf("golden file %s: %v (create it with --update)", tildePath(golden), err))
+		return r
+	}
+	if string(want) != got {
+		r.Failures = append(r.Failures, "output differs from golden file")
+		r.GoldenPath, r.Want, r.Got = golden, string(want), got
+	}
+	return r
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestTemplateTest verifies fixture cases check snippets and golden files,
+// ignore BLACKDOT_TMPL_* overrides, and --update accepts the output
+func TestTemplateTest(t *testing.T) {
+	setScheduleEnv(t, "")
+	dir := filepath.Join(os.Getenv("HOME"), ".blackdot")
+	t.Setenv("BLACKDOT_DIR", dir)
+	t.Setenv("BLACKDOT_TMPL_GIT_EMAIL", "env@example.com")
+	configs := filepath.Join(dir, "templates", "configs")
+	tests := filepath.Join(dir, "templates", "tests")
+	os.MkdirAll(configs, 0755)
+	os.MkdirAll(tests, 0755)
+	os.WriteFile(filepath.Join(configs, "gitconfig.tmpl"), []byte("email = {{ git_email }}\n{{#each hosts}}host {{ name }}\n{{/each}}"), 0644)
+	os.WriteFile(filepath.Join(tests, "gitconfig.yaml"), []byte(`cases:
+  - name: snippets
+    vars: {git_email: me@example.com}
+    arrays:
+      hosts: [{name: bastion}, {name: db}]
+    contains: ["email = me@example.com", "host db"]
+    not_contains: ["env@example.com"]
+  - name: golden
+    vars: {git_email: me@example.com}
+    golden: golden/gitconfig
+`), 0644)
+
+	if err := runTemplateTest(nil, "", false); err == nil {
+		t.Fatal("missing golden file should fail")
+	}
+	if err := runTemplateTest(nil, "golden", true); err != nil {
+		t.Fatalf("--update: %v", err)
+	}
+	golden, err := os.ReadFile(filepath.Join(tests, "golden", "gitconfig"))
+	if err != nil || string(golden) != "email = me@example.com\n" {
+		t.Fatalf("golden = %q, %v", golden, err)
+	}
+	if err := runTemplateTest([]string{"gitconfig.tmpl"}, "", false); err != nil {
+		t.Errorf("passing fixture: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(configs, "gitconfig.tmpl"), []byte("mail = {{ git_email }}\n"), 0644)
+	r := runTemplateCase(&templateConfig{templateDir: configs, variablesDir: filepath.Dir(configs)},
+		filepath.Join(configs, "gitconfig.tmpl"), tests,
+		templateFixtureCase{Name: "golden", Vars: map[string]string{"git_email": "me@example.com"}, Contains: []string{"email ="}, Golden: "golden/gitconfig"}, false)
+	if len(r.Failures) != 2 || !strings.Contains(r.Failures[0], `missing "email ="`) || r.Want == r.Got {
+		t.Errorf("result = %+v", r)
+	}
+	if err := runTemplateTest([]string{"other"}, "", false); err != nil {
+		t.Errorf("filter matching nothing: %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+)
+
+// renderedFile records what template render last wrote for one output
+type renderedFile struct {
+	Template   string `json:"template"`
+	SHA256     string `json:"sha256"`
+	RenderedAt string `json:"rendered_at"`
+}
+
+// renderedState tracks the outputs template render wrote, keyed by output
+// name. A copy of each output is kept next to it so hand edits can be
+// shown as a diff and merged back into the template.
+type renderedState struct {
+	dir   string
+	Files map[string]renderedFile `json:"files"`
+	// Deployed holds the checksum of each file template apply copied to
+	// its target, keyed by target path
+	Deployed map[string]string `json:"deployed,omitempty"`
+}
+
+func renderedStateDir() string {
+	return filepath.Join(paths.StateDir(), "rendered")
+}
+
+func loadRenderedState() *renderedState {
+	state := &renderedState{dir: renderedStateDir(), Files: make(map[string]renderedFile)}
+	data, err := os.ReadFile(filepath.Join(state.dir, "checksums.json"))
+	if err == nil {
+		json.Unmarshal(data, state)
+		if state.Files == nil {
+			state.Files = make(map[string]renderedFile)
+		}
+	}
+	return state
+}
+
+// record stores the checksum and a copy of a freshly written output
+func (s *renderedState) record(name, tmplPath, content string) error {
+	if err := writeFileWithPolicy(filepath.Join(s.dir, name), []byte(content), fileClassPrivate); err != nil {
+		return err
+	}
+	s.Files[name] = renderedFile{Template: tmplPath, SHA256: sha256Hex([]byte(content)), RenderedAt: time.Now().UTC().Format(time.RFC3339)}
+	return s.save()
+}
+
+// recordDeployed stores the checksum of content copied to target
+func (s *renderedState) recordDeployed(target, content string) error {
+	if s.Deployed == nil {
+		s.Deployed = make(map[string]string)
+	}
+	s.Deployed[target] = sha256Hex([]byte(content))
+	return s.save()
+}
+
+func (s *renderedState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileWithPolicy(filepath.Join(s.dir, "checksums.json"), data, fileClassPrivate)
+}
+
+// lastRender returns the content render last wrote for name
+func (s *renderedState) lastRender(name string) (string, bool) {
+	if _, ok := s.Files[name]; !ok {
+		return "", false
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// editedByHand returns the current content of a generated file when it no
+// longer matches what render last wrote
+func (s *renderedState) editedByHand(name, outputPath string) (string, bool) {
+	entry, ok := s.Files[name]
+	if !ok {
+		return "", false
+	}
+	current, err := os.ReadFile(outputPath)
+	if err != nil {
+		return "", false
+	}
+	if sha256Hex(current) == entry.SHA256 {
+		return "", false
+	}
+	return string(current), true
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// generatedFileMode is 0444 when template.protect_generated is set, so
+// editors warn before changing a file the next render would overwrite
+func generatedFileMode() os.FileMode {
+	if configLookup("template.protect_generated") == "true" {
+		return 0444
+	}
+	return 0644
+}
+
+// Ways to handle a generated file that was edited by hand
+const (
+	manualEditRefuse    = "refuse"
+	manualEditOverwrite = "overwrite"
+	manualEditFold      = "fold"
+	manualEditKeep      = "keep"
+)
+
+// resolveManualEdit decides what to do with a hand-edited output. --force
+// overwrites and --fold merges; otherwise an interactive user is asked
+// and anyone else gets the diff and a refusal.
+func resolveManualEdit(out io.Writer, name, base, edited string, fold bool) string {
+	switch {
+	case force:
+		return manualEditOverwrite
+	case fold:
+		return manualEditFold
+	case !stdinIsTerminal():
+		printUnifiedDiff(out, "rendered/"+name, base, "generated/"+name, edited)
+		return manualEditRefuse
+	}
+
+	Warn("generated/%s was edited by hand since the last render", name)
+	options := []string{
+		"fold - merge the change into the template",
+		"overwrite - discard the change",
+		"keep - leave this file alone for now",
+		"diff - show the change",
+	}
+	for {
+		choice, err := prompts.Select("Action", options, 0)
+		if err != nil {
+			return manualEditRefuse
+		}
+		switch choice {
+		case 0:
+			return manualEditFold
+		case 1:
+			return manualEditOverwrite
+		case 2:
+			return manualEditKeep
+		default:
+			printUnifiedDiff(out, "rendered/"+name, base, "generated/"+name, edited)
+		}
+	}
+}
+
+// errFoldConflict means the hand edit touches lines produced by template
+// expressions and cannot be merged automatically
+var errFoldConflict = errors.New("the change overlaps template expressions")
+
+// foldManualEdit applies the difference between the last render and the
+// edited output to the template with a three-way merge. The template is
+// only rewritten when the merge is clean.
+func foldManualEdit(tmplPath, base, edited string) error {
+	dir, err := os.MkdirTemp("", "blackdot-fold-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	basePath, editedPath := filepath.Join(dir, "base"), filepath.Join(dir, "edited")
+	os.WriteFile(basePath, []byte(base), 0600)
+	os.WriteFile(editedPath, []byte(edited), 0600)
+
+	cmd := exec.Command("git", "merge-file", "-p", "-L", "template", "-L", "last render", "-L", "generated",
+		tmplPath, basePath, editedPath)
+	merged, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && exitErr.ExitCode() < 128 {
+			return errFoldConflict
+		}
+		return fmt.Errorf("git merge-file: %w", err)
+	}
+
+	info, err := os.Stat(tmplPath)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(tmplPath, merged, info.Mode().Perm())
+}
+
+// printUnifiedDiff prints a colored unified diff of a and b
+func printUnifiedDiff(out io.Writer, aLabel, a, bLabel, b string) {
+	dir, err := os.MkdirTemp("", "blackdot-diff-*")
+	if err != nil {
+		fmt.Fprintf(out, "  cannot create temp dir: %v\n", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	aPath, bPath := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	os.WriteFile(aPath, []byte(a), 0600)
+	os.WriteFile(bPath, []byte(b), 0600)
+
+	output, err := exec.Command("diff", "-u", "--label", aLabel, "--label", bLabel, aPath, bPath).Output()
+	if len(output) == 0 && err != nil {
+		// diff unavailable: fall back to showing both versions
+		fmt.Fprintln(out, Red.Sprint("--- "+aLabel))
+		fmt.Fprintln(out, a)
+		fmt.Fprintln(out, Green.Sprint("+++ "+bLabel))
+		fmt.Fprintln(out, b)
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			fmt.Fprintln(out, Green.Sprint(line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Fprintln(out, Red.Sprint(line))
+		case strings.HasPrefix(line, "@@"):
+			fmt.Fprintln(out, Cyan.Sprint(line))
+		default:
+			fmt.Fprintln(out, line)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestRenderedStateDetectsEdits verifies outputs changed after render are
+// reported with their current content
+func TestRenderedStateDetectsEdits(t *testing.T) {
+	setScheduleEnv(t, "")
+	output := filepath.Join(t.TempDir(), "gitconfig")
+	os.WriteFile(output, []byte("rendered\n"), 0644)
+
+	state := loadRenderedState()
+	if err := state.record("gitconfig", "gitconfig.tmpl", "rendered\n"); err != nil {
+		t.Fatal(err)
+	}
+	state = loadRenderedState()
+	if _, edited := state.editedByHand("gitconfig", output); edited {
+		t.Error("untouched output reported as edited")
+	}
+
+	os.WriteFile(output, []byte("edited\n"), 0644)
+	if content, edited := state.editedByHand("gitconfig", output); !edited || content != "edited\n" {
+		t.Errorf("edited = %v, content = %q", edited, content)
+	}
+	if base, ok := state.lastRender("gitconfig"); !ok || base != "rendered\n" {
+		t.Errorf("lastRender = %q, %v", base, ok)
+	}
+	if _, edited := state.editedByHand("unknown", output); edited {
+		t.Error("outputs without a recorded render cannot be judged")
+	}
+}
+
+// TestFoldManualEdit verifies edits to static lines merge into the
+// template and edits to rendered expressions are refused
+func TestFoldManualEdit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	tmpl := filepath.Join(t.TempDir(), "app.conf.tmpl")
+	source := "name = {{ name }}\n\n[ui]\ncolor = auto\n"
+	base := "name = alice\n\n[ui]\ncolor = auto\n"
+	os.WriteFile(tmpl, []byte(source), 0644)
+
+	if err := foldManualEdit(tmpl, base, "name = alice\n\n[ui]\ncolor = always\n"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(tmpl); string(data) != "name = {{ name }}\n\n[ui]\ncolor = always\n" {
+		t.Errorf("template = %q", data)
+	}
+
+	err := foldManualEdit(tmpl, "name = alice\n\n[ui]\ncolor = always\n", "name = bob\n\n[ui]\ncolor = always\n")
+	if !errors.Is(err, errFoldConflict) {
+		t.Errorf("err = %v, want conflict", err)
+	}
+	if data, _ := os.ReadFile(tmpl); string(data) != "name = {{ name }}\n\n[ui]\ncolor = always\n" {
+		t.Errorf("conflicting fold changed the template: %q", data)
+	}
+}
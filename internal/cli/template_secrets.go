@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/template"
+)
+
+// templateSecretMinLen is the shortest value masked: replacing every
+// occurrence of a one- to three-character string would garble the output
+const templateSecretMinLen = 4
+
+// templateSecretNames returns the variables _variables.schema.json marks
+// secret; none when there is no schema
+func templateSecretNames(cfg *templateConfig) map[string]bool {
+	names := map[string]bool{}
+	schemaPath := paths.FindLayered(paths.Roots(cfg.blackdotDir), filepath.Join("templates", templateSchemaFile))
+	if schemaPath == "" {
+		return names
+	}
+	schema, err := template.LoadVariableSchema(schemaPath)
+	if err != nil {
+		Debug("Secret variables unknown: %v", err)
+		return names
+	}
+	for _, name := range schema.SecretNames() {
+		names[name] = true
+	}
+	return names
+}
+
+// templateSecretMask returns a function replacing the current values of
+// secret variables with <secret:name>, for output that may be read over a
+// shoulder. show turns masking off.
+func templateSecretMask(cfg *templateConfig, engine *template.RaymondEngine, show bool) func(string) string {
+	if show {
+		return func(s string) string { return s }
+	}
+	values := map[string]string{}
+	for name := range templateSecretNames(cfg) {
+		if val, ok := engine.GetVar(name); ok {
+			if s, ok := val.(string); ok {
+				values[name] = s
+			}
+		}
+	}
+	return maskTemplateSecrets(values)
+}
+
+// maskTemplateSecrets builds the replacer for name -> value. Longer values
+// are replaced first so a value containing another is masked whole.
+func maskTemplateSecrets(values map[string]string) func(string) string {
+	names := make([]string, 0, len(values))
+	for name, value := range values {
+		if len(value) < templateSecretMinLen {
+			if value != "" {
+				Debug("Secret variable %s is too short to mask", name)
+			}
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return func(s string) string { return s }
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(values[names[i]]) != len(values[names[j]]) {
+			return len(values[names[i]]) > len(values[names[j]])
+		}
+		return names[i] < names[j]
+	})
+	var pairs []string
+	for _, name := range names {
+		pairs = append(pairs, values[name], "<secret:"+name+">")
+	}
+	return strings.NewReplacer(pairs...).Replace
+}
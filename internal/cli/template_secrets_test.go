@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+	"github.com/blackwell-systems/blackdot/internal/template"
+)
+
+// TestMaskTemplateSecrets verifies values are masked whole, longest
+// first, and values too short to mask safely are left alone
+func TestMaskTemplateSecrets(t *testing.T) {
+	mask := maskTemplateSecrets(map[string]string{
+		"npm_token":  "npm_abcd1234",
+		"npm_suffix": "1234",
+		"pin":        "42",
+	})
+	got := mask("//registry/:_authToken=npm_abcd1234\nsuffix=1234 pin=42\n")
+	want := "//registry/:_authToken=<secret:npm_token>\nsuffix=<secret:npm_suffix> pin=42\n"
+	if got != want {
+		t.Errorf("mask = %q, want %q", got, want)
+	}
+}
+
+// TestTemplateSecretMask verifies the schema's secret variables are
+// masked in rendered output unless shown
+func TestTemplateSecretMask(t *testing.T) {
+	setupTemplateDeploy(t)
+	cfg, err := getTemplateConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := `{"variables": [{"name": "npm_token", "secret": true}, {"name": "git_name"}]}`
+	os.WriteFile(filepath.Join(cfg.blackdotDir, "templates", templateSchemaFile), []byte(schema), 0644)
+	tmpl := filepath.Join(cfg.templateDir, "npmrc.tmpl")
+	os.WriteFile(tmpl, []byte("//registry.npmjs.org/:_authToken={{ npm_token }}\n"), 0644)
+
+	engine := newTemplateEngine(cfg)
+	if err := loadTemplateVariables(engine, cfg); err != nil {
+		t.Fatal(err)
+	}
+	engine.SetVar("npm_token", "tok-0123456789")
+	result, err := engine.RenderFile(tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := templateSecretMask(cfg, engine, false)(result); strings.Contains(got, "tok-0123456789") || !strings.Contains(got, "<secret:npm_token>") {
+		t.Errorf("masked = %q", got)
+	}
+	if got := templateSecretMask(cfg, engine, true)(result); got != result {
+		t.Errorf("--show-secrets changed the output: %q", got)
+	}
+}
+
+// TestAskSecretTemplateVariable verifies a secret's current value is kept
+// on Enter and never printed
+func TestAskSecretTemplateVariable(t *testing.T) {
+	schema := &template.VariableSchema{Variables: []template.VariableSpec{
+		{Name: "npm_token", Description: "npm auth token", Secret: true},
+	}}
+	var out bytes.Buffer
+	answers, err := askTemplateVariables(schema, map[string]string{"npm_token": "tok-0123456789"}, true, prompts.New(strings.NewReader("\n"), &out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answers) != 0 || strings.Contains(out.String(), "tok-0123456789") {
+		t.Errorf("answers = %v, output:\n%s", answers, out.String())
+	}
+}
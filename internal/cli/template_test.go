@@ -0,0 +1,21 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestNewTemplateEngineLimits verifies the engine honours
+// template.max_output from config
+func TestNewTemplateEngineLimits(t *testing.T) {
+	setScheduleEnv(t, `{"template": {"max_output": "8"}}`)
+	engine := newTemplateEngine(&templateConfig{templateDir: t.TempDir()})
+	engine.SetVar("name", "alice")
+
+	if out, err := engine.Render("hi {{ name }}"); err != nil || out != "hi alice" {
+		t.Fatalf("Render = %q, %v", out, err)
+	}
+	if _, err := engine.Render(strings.Repeat("x", 32)); err == nil {
+		t.Error("output over template.max_output should fail")
+	}
+}
@@ -0,0 +1,287 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/fsnotify/fsnotify"
+)
+
+// templateWatchOptions configure 'template render --watch'
+type templateWatchOptions struct {
+	link        bool          // re-deploy changed outputs to their targets
+	exec        string        // shell command run after outputs change
+	debounce    time.Duration // quiet period before re-rendering
+	showSecrets bool          // don't mask secret variables in diffs
+}
+
+// runTemplateWatch renders once, then re-renders whenever a template or a
+// variables file changes, until interrupted
+func runTemplateWatch(cfg *templateConfig, args []string, opts templateWatchOptions) error {
+	if opts.debounce < 0 {
+		return fmt.Errorf("--debounce must not be negative")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := os.MkdirAll(cfg.generatedDir, 0755); err != nil {
+		return fmt.Errorf("creating generated directory: %w", err)
+	}
+	rendered := loadRenderedState()
+
+	templates, err := resolveTemplatePaths(cfg, args)
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("starting file watcher: %w", err)
+	}
+	defer watcher.Close()
+	watched := newTemplateWatchSet(watcher, templateWatchPaths(cfg, templates))
+
+	Info("Watching %d template(s) and the variables files (Ctrl+C to stop)", len(templates))
+	if changed := renderWatchedTemplates(cfg, templates, rendered, opts); len(changed) > 0 {
+		runTemplateWatchExec(opts.exec, cfg, changed)
+	}
+
+	var pending []string
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			Warn("Watching templates: %v", err)
+			continue
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod || !watched.covers(event.Name) {
+				continue
+			}
+			// Editors save in several steps; wait until the files settle
+			pending = append(pending, event.Name)
+			settled = time.After(opts.debounce)
+			continue
+		case <-settled:
+			settled = nil
+		}
+
+		// Templates may have been added or removed since the last pass
+		if templates, err = resolveTemplatePaths(cfg, args); err != nil {
+			Fail("%v", err)
+			pending = nil
+			continue
+		}
+		watched.add(templateWatchPaths(cfg, templates))
+
+		affected := templatesAffected(pending, templates)
+		pending = nil
+		if len(affected) == 0 {
+			continue
+		}
+		changed := renderWatchedTemplates(cfg, affected, rendered, opts)
+		if len(changed) == 0 {
+			fmt.Printf("  %s no output changed\n", Dim.Sprint(time.Now().Format("15:04:05")))
+			continue
+		}
+		runTemplateWatchExec(opts.exec, cfg, changed)
+	}
+}
+
+// templateWatchSet tracks what the watcher is registered for. Files are
+// watched through their directory, because editors often save by renaming
+// a new file over the old one, which ends a watch on the file itself.
+type templateWatchSet struct {
+	watcher *fsnotify.Watcher
+	dirs    map[string]bool // watched for every entry
+	files   map[string]bool // watched through their parent directory
+	added   map[string]bool // directories registered with the watcher
+}
+
+func newTemplateWatchSet(watcher *fsnotify.Watcher, targets []string) *templateWatchSet {
+	w := &templateWatchSet{watcher: watcher, dirs: map[string]bool{}, files: map[string]bool{}, added: map[string]bool{}}
+	w.add(targets)
+	return w
+}
+
+// add registers targets not watched yet. Directories that don't exist, such
+// as an empty layered root, are skipped.
+func (w *templateWatchSet) add(targets []string) {
+	for _, path := range targets {
+		dir := path
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			w.files[path] = true
+			dir = filepath.Dir(path)
+		} else {
+			w.dirs[path] = true
+		}
+		if w.added[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err == nil {
+			w.added[dir] = true
+		}
+	}
+}
+
+// covers reports whether an event for path concerns a watched template
+// or variables file
+func (w *templateWatchSet) covers(path string) bool {
+	return w.files[path] || w.dirs[filepath.Dir(path)]
+}
+
+// templateWatchPaths are the directories holding templates in every
+// layered root, the variables directory, and templates given by path
+func templateWatchPaths(cfg *templateConfig, templates []string) []string {
+	seen := map[string]bool{}
+	var watched []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			watched = append(watched, path)
+		}
+	}
+	add(cfg.variablesDir)
+	add(cfg.templateDir)
+	for _, root := range paths.Roots(cfg.blackdotDir) {
+		add(filepath.Join(root.Path, "templates", "configs"))
+	}
+	for _, tmplPath := range templates {
+		if !seen[filepath.Dir(tmplPath)] {
+			add(tmplPath)
+		}
+	}
+	return watched
+}
+
+// templatesAffected maps changed paths to the templates to re-render: a
+// changed variables or arrays file (_variables*.sh, _arrays*.json) affects
+// every template, a changed .tmpl only itself
+func templatesAffected(changed, templates []string) []string {
+	byPath := map[string]bool{}
+	for _, path := range changed {
+		if strings.HasPrefix(filepath.Base(path), "_") {
+			return templates
+		}
+		byPath[path] = true
+	}
+	var affected []string
+	for _, tmplPath := range templates {
+		if byPath[tmplPath] {
+			affected = append(affected, tmplPath)
+		}
+	}
+	return affected
+}
+
+// renderWatchedTemplates renders templates with freshly loaded variables
+// and writes the outputs that changed, printing a diff of each. Errors are
+// reported and skipped so a half-typed template doesn't end the watch.
+// Returns the names of the outputs written.
+func renderWatchedTemplates(cfg *templateConfig, templates []string, rendered *renderedState, opts templateWatchOptions) []string {
+	stamp := Dim.Sprint(time.Now().Format("15:04:05"))
+	engine := newTemplateEngine(cfg)
+	if err := loadTemplateVariables(engine, cfg); err != nil {
+		fmt.Printf("  %s %s loading variables: %v\n", stamp, Red.Sprint("✗"), err)
+		return nil
+	}
+	mask := templateSecretMask(cfg, engine, opts.showSecrets)
+
+	var changed []string
+	outputs := map[string]string{}
+	for _, tmplPath := range templates {
+		name := strings.TrimSuffix(filepath.Base(tmplPath), ".tmpl")
+		outputPath := filepath.Join(cfg.generatedDir, name)
+
+		result, err := engine.RenderFile(tmplPath)
+		if err != nil {
+			fmt.Printf("  %s %s %s: %v\n", stamp, Red.Sprint("✗"), name, err)
+			continue
+		}
+		if _, edited := rendered.editedByHand(name, outputPath); edited {
+			fmt.Printf("  %s %s generated/%s was edited by hand; skipped (render --fold to merge it)\n", stamp, Yellow.Sprint("!"), name)
+			continue
+		}
+		previous, _ := os.ReadFile(outputPath)
+		if string(previous) == result {
+			continue
+		}
+		if err := checkContentForSecrets(name, []byte(result), "generated/"+name); err != nil {
+			continue
+		}
+
+		fmt.Printf("  %s %s %s\n", stamp, Green.Sprint("✓"), name)
+		printUnifiedDiff(os.Stdout, "generated/"+name, mask(string(previous)), "generated/"+name, mask(result))
+		if err := writeFileAtomic(outputPath, []byte(result), generatedFileMode()); err != nil {
+			fmt.Printf("  %s %s writing %s: %v\n", stamp, Red.Sprint("✗"), name, err)
+			continue
+		}
+		if err := rendered.record(name, tmplPath, result); err != nil {
+			Warn("Failed to record checksum for %s: %v", name, err)
+		}
+		changed = append(changed, name)
+		outputs[name] = outputPath
+
+		if opts.link {
+			targets, err := templateTargets(cfg, []string{tmplPath})
+			if err != nil {
+				Warn("%s: %v", name, err)
+			}
+			for _, t := range targets {
+				if err := deployTemplateTarget(t, rendered); err != nil {
+					Warn("Deploying %s: %v", tildePath(t.Target), err)
+					continue
+				}
+				fmt.Printf("  %s %s %s -> %s\n", stamp, Green.Sprint("✓"), name, tildePath(t.Target))
+			}
+		}
+	}
+
+	if len(changed) > 0 {
+		if err := triggerHooks("post_template_render", hookContextForItems(outputs, false)); err != nil {
+			Warn("post_template_render: %v", err)
+		}
+	}
+	return changed
+}
+
+// runTemplateWatchExec runs the --exec hook through the shell, with the
+// changed outputs in BLACKDOT_RENDERED (space separated) so one hook can
+// reload only what is affected
+func runTemplateWatchExec(command string, cfg *templateConfig, changed []string) {
+	if command == "" {
+		return
+	}
+	sort.Strings(changed)
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(),
+		"BLACKDOT_RENDERED="+strings.Join(changed, " "),
+		"BLACKDOT_GENERATED_DIR="+cfg.generatedDir,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		Warn("--exec %q: %v", command, err)
+	}
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// TestTemplatesAffected verifies a variables file change re-renders every
+// template and a template change only itself
+func TestTemplatesAffected(t *testing.T) {
+	templates := []string{"/t/configs/a.tmpl", "/t/configs/b.tmpl"}
+	cases := []struct {
+		changed []string
+		want    []string
+	}{
+		{[]string{"/t/configs/b.tmpl"}, []string{"/t/configs/b.tmpl"}},
+		{[]string{"/t/_variables.local.sh"}, templates},
+		{[]string{"/t/configs/b.tmpl", "/t/_arrays.local.json"}, templates},
+		{[]string{"/t/configs", "/t/configs/new.tmpl"}, nil},
+	}
+	for _, c := range cases {
+		if got := templatesAffected(c.changed, templates); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("templatesAffected(%v) = %v, want %v", c.changed, got, c.want)
+		}
+	}
+}
+
+// TestTemplateWatchSet verifies events in watched directories and for
+// templates given by path are covered, including a save by rename
+func TestTemplateWatchSet(t *testing.T) {
+	dir := t.TempDir()
+	configs, other := filepath.Join(dir, "configs"), filepath.Join(dir, "other")
+	os.MkdirAll(configs, 0755)
+	os.MkdirAll(other, 0755)
+	single := filepath.Join(other, "single.tmpl")
+	os.WriteFile(single, []byte("a"), 0644)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+	set := newTemplateWatchSet(watcher, []string{configs, single, filepath.Join(dir, "missing")})
+
+	if !set.covers(filepath.Join(configs, "new.tmpl")) || !set.covers(single) || set.covers(filepath.Join(other, "unrelated")) {
+		t.Errorf("covers: dirs %v files %v", set.dirs, set.files)
+	}
+
+	tmp := filepath.Join(other, ".single.tmpl.swp")
+	os.WriteFile(tmp, []byte("b"), 0644)
+	os.Rename(tmp, single)
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-watcher.Events:
+			if set.covers(event.Name) {
+				return
+			}
+		case err := <-watcher.Errors:
+			t.Fatal(err)
+		case <-timeout:
+			t.Fatal("no event for the renamed template")
+		}
+	}
+}
+
+// TestRenderWatchedTemplates verifies only outputs whose content changed
+// are written, hand-edited outputs are left alone, and --link deploys
+func TestRenderWatchedTemplates(t *testing.T) {
+	home := setupTemplateDeploy(t)
+	cfg, err := getTemplateConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(cfg.generatedDir, 0755)
+	templates, err := resolveTemplatePaths(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered := loadRenderedState()
+
+	if changed := renderWatchedTemplates(cfg, templates, rendered, templateWatchOptions{link: true}); len(changed) != 3 {
+		t.Fatalf("first pass wrote %v, want all three outputs", changed)
+	}
+	if dest, err := os.Readlink(filepath.Join(home, ".config", "tool", "tool.toml")); err != nil || !strings.HasSuffix(dest, "tool.toml") {
+		t.Errorf("--link did not deploy tool.toml: %q, %v", dest, err)
+	}
+	if changed := renderWatchedTemplates(cfg, templates, rendered, templateWatchOptions{}); len(changed) != 0 {
+		t.Errorf("unchanged templates rewrote %v", changed)
+	}
+
+	tool := filepath.Join(cfg.templateDir, "tool.toml.tmpl")
+	os.WriteFile(tool, []byte("{{!-- target: ~/.config/tool/tool.toml --}}\ncolor = false\n"), 0644)
+	os.WriteFile(filepath.Join(cfg.generatedDir, "notes"), []byte("edited\n"), 0644)
+	os.WriteFile(filepath.Join(cfg.templateDir, "notes.tmpl"), []byte("changed\n"), 0644)
+	changed := renderWatchedTemplates(cfg, templates, rendered, templateWatchOptions{})
+	if !reflect.DeepEqual(changed, []string{"tool.toml"}) {
+		t.Errorf("changed = %v, want [tool.toml]", changed)
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.generatedDir, "notes")); string(data) != "edited\n" {
+		t.Errorf("hand-edited output was overwritten: %q", data)
+	}
+}
+
+// TestRunTemplateWatchExec verifies the hook sees the changed outputs
+func TestRunTemplateWatchExec(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	cfg := &templateConfig{generatedDir: dir}
+	runTemplateWatchExec(`printf '%s' "$BLACKDOT_RENDERED" > "$BLACKDOT_GENERATED_DIR/out"`, cfg, []string{"ssh-config", "gitconfig"})
+	if data, _ := os.ReadFile(out); string(data) != "gitconfig ssh-config" {
+		t.Errorf("BLACKDOT_RENDERED = %q", data)
+	}
+}
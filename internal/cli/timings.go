@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/spf13/cobra"
+)
+
+// showTimings is the --timings flag: print a breakdown and record it
+var showTimings bool
+
+// timingRecord is one command's timing entry in the metrics log. Kind
+// separates it from doctor's health entries.
+type timingRecord struct {
+	Kind       string        `json:"kind"`
+	Timestamp  string        `json:"timestamp"`
+	Command    string        `json:"command"`
+	DurationMs float64       `json:"duration_ms"`
+	OK         bool          `json:"ok"`
+	Phases     []timingPhase `json:"phases,omitempty"`
+	Hostname   string        `json:"hostname"`
+	OS         string        `json:"os"`
+}
+
+// timingPhase is the total time spent in one named phase of a command
+type timingPhase struct {
+	Name       string  `json:"name"`
+	Count      int     `json:"count"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+const timingKind = "timing"
+
+// timingRecorder collects phase durations for the running command
+type timingRecorder struct {
+	mu      sync.Mutex
+	enabled bool
+	command string
+	start   time.Time
+	phases  map[string]*timingPhase
+	order   []string
+}
+
+var timings = &timingRecorder{}
+
+// timingsEnabled reports whether this run records timings: --timings, or
+// metrics.timings set to true
+func timingsEnabled() bool {
+	return showTimings || configLookup("metrics.timings") == "true"
+}
+
+// startTimings begins recording for cmd. Completion requests are never
+// recorded; they run on every tab press.
+func startTimings(cmd *cobra.Command) {
+	if !timingsEnabled() {
+		return
+	}
+	switch cmd.Name() {
+	case cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return
+	}
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+	timings.enabled = true
+	timings.command = cmd.CommandPath()
+	timings.start = time.Now()
+	timings.phases = make(map[string]*timingPhase)
+	timings.order = nil
+}
+
+// timePhase starts timing a phase; call the returned function when it ends.
+// It costs nothing when timings are off.
+//
+//	defer timePhase("vault.sync")()
+func timePhase(name string) func() {
+	if !timings.active() {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { recordPhase(name, time.Since(start)) }
+}
+
+// recordPhase adds a measured duration to a phase
+func recordPhase(name string, elapsed time.Duration) {
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+	if !timings.enabled {
+		return
+	}
+	p, ok := timings.phases[name]
+	if !ok {
+		p = &timingPhase{Name: name}
+		timings.phases[name] = p
+		timings.order = append(timings.order, name)
+	}
+	p.Count++
+	p.DurationMs += durationMs(elapsed)
+}
+
+func (r *timingRecorder) active() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enabled
+}
+
+// finishTimings appends the command's record to the metrics log and, with
+// --timings, prints the breakdown to stderr
+func finishTimings(cmdErr error) {
+	timings.mu.Lock()
+	if !timings.enabled {
+		timings.mu.Unlock()
+		return
+	}
+	timings.enabled = false
+	rec := timingRecord{
+		Kind:       timingKind,
+		Timestamp:  timings.start.UTC().Format(time.RFC3339),
+		Command:    timings.command,
+		DurationMs: durationMs(time.Since(timings.start)),
+		OK:         cmdErr == nil,
+		OS:         runtime.GOOS,
+	}
+	for _, name := range timings.order {
+		rec.Phases = append(rec.Phases, *timings.phases[name])
+	}
+	timings.mu.Unlock()
+	rec.Hostname, _ = os.Hostname()
+
+	if data, err := json.Marshal(rec); err == nil {
+		if err := appendFileWithPolicy(metricsPath(), append(data, '\n'), fileClassPrivate); err != nil {
+			Debug("recording timings: %v", err)
+		}
+	}
+	if showTimings {
+		printTimingRecord(rec)
+	}
+}
+
+// printTimingRecord prints one command's phases, slowest first
+func printTimingRecord(rec timingRecord) {
+	phases := append([]timingPhase(nil), rec.Phases...)
+	sort.SliceStable(phases, func(i, j int) bool { return phases[i].DurationMs > phases[j].DurationMs })
+
+	fmt.Fprintln(os.Stderr)
+	Bold.Fprintf(os.Stderr, "Timings: %s  %s\n", rec.Command, formatMs(rec.DurationMs))
+	for _, p := range phases {
+		count := ""
+		if p.Count > 1 {
+			count = Dim.Sprintf(" (%d calls)", p.Count)
+		}
+		fmt.Fprintf(os.Stderr, "  %-36s %9s%s\n", p.Name, formatMs(p.DurationMs), count)
+	}
+	if len(phases) == 0 {
+		Dim.Fprintln(os.Stderr, "  no instrumented phases ran")
+	}
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// formatMs renders milliseconds with a unit suited to the size
+func formatMs(ms float64) string {
+	switch {
+	case ms >= 1000:
+		return fmt.Sprintf("%.2fs", ms/1000)
+	case ms >= 10:
+		return fmt.Sprintf("%.0fms", ms)
+	default:
+		return fmt.Sprintf("%.1fms", ms)
+	}
+}
+
+// timedBackend records the duration of every backend call as a
+// vault.<backend>.<op> phase
+type timedBackend struct {
+	vaultmux.Backend
+	prefix string
+}
+
+func newTimedBackend(b vaultmux.Backend, backendType vaultmux.BackendType) vaultmux.Backend {
+	return &timedBackend{Backend: b, prefix: "vault." + string(backendType) + "."}
+}
+
+func (b *timedBackend) time(op string) func() { return timePhase(b.prefix + op) }
+
+func (b *timedBackend) Init(ctx context.Context) error {
+	defer b.time("init")()
+	return b.Backend.Init(ctx)
+}
+
+func (b *timedBackend) Authenticate(ctx context.Context) (vaultmux.Session, error) {
+	defer b.time("authenticate")()
+	return b.Backend.Authenticate(ctx)
+}
+
+func (b *timedBackend) Sync(ctx context.Context, session vaultmux.Session) error {
+	defer b.time("sync")()
+	return b.Backend.Sync(ctx, session)
+}
+
+func (b *timedBackend) GetItem(ctx context.Context, name string, session vaultmux.Session) (*vaultmux.Item, error) {
+	defer b.time("get")()
+	return b.Backend.GetItem(ctx, name, session)
+}
+
+func (b *timedBackend) GetNotes(ctx context.Context, name string, session vaultmux.Session) (string, error) {
+	defer b.time("get")()
+	return b.Backend.GetNotes(ctx, name, session)
+}
+
+func (b *timedBackend) ItemExists(ctx context.Context, name string, session vaultmux.Session) (bool, error) {
+	defer b.time("exists")()
+	return b.Backend.ItemExists(ctx, name, session)
+}
+
+func (b *timedBackend) ListItems(ctx context.Context, session vaultmux.Session) ([]*vaultmux.Item, error) {
+	defer b.time("list")()
+	return b.Backend.ListItems(ctx, session)
+}
+
+func (b *timedBackend) CreateItem(ctx context.Context, name, content string, session vaultmux.Session) error {
+	defer b.time("create")()
+	return b.Backend.CreateItem(ctx, name, content, session)
+}
+
+func (b *timedBackend) UpdateItem(ctx context.Context, name, content string, session vaultmux.Session) error {
+	defer b.time("update")()
+	return b.Backend.UpdateItem(ctx, name, content, session)
+}
+
+func (b *timedBackend) DeleteItem(ctx context.Context, name string, session vaultmux.Session) error {
+	defer b.time("delete")()
+	return b.Backend.DeleteItem(ctx, name, session)
+}
+
+func (b *timedBackend) ListItemsInLocation(ctx context.Context, locType, locValue string, session vaultmux.Session) ([]*vaultmux.Item, error) {
+	defer b.time("list")()
+	return b.Backend.ListItemsInLocation(ctx, locType, locValue, session)
+}
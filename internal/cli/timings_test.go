@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux/mock"
+	"github.com/spf13/cobra"
+)
+
+// TestTimingsRecordPhases verifies backend calls and template renders are
+// recorded as phases, written to the metrics log, and kept out of the
+// health metrics
+func TestTimingsRecordPhases(t *testing.T) {
+	setScheduleEnv(t, "")
+	t.Setenv("BLACKDOT_METRICS_TIMINGS", "true")
+
+	root := &cobra.Command{Use: "blackdot"}
+	sub := &cobra.Command{Use: "pull"}
+	root.AddCommand(sub)
+	startTimings(sub)
+
+	m := mock.New()
+	m.SetItem("Git-Config", "notes")
+	backend := newTimedBackend(m, "pass")
+	for i := 0; i < 2; i++ {
+		if _, err := backend.GetNotes(context.Background(), "Git-Config", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, _ := getTemplateConfig()
+	if _, err := newTemplateEngine(cfg).Render("{{ user }}"); err != nil {
+		t.Fatal(err)
+	}
+	finishTimings(nil)
+
+	timePhase("after")() // recording has ended; must not record
+
+	records, err := loadTimingRecords(metricsPath())
+	if err != nil || len(records) != 1 {
+		t.Fatalf("records = %v, %v", records, err)
+	}
+	rec := records[0]
+	if rec.Command != "blackdot pull" || !rec.OK {
+		t.Errorf("record = %+v", rec)
+	}
+	phases := make(map[string]int)
+	for _, p := range rec.Phases {
+		phases[p.Name] = p.Count
+	}
+	if phases["vault.pass.get"] != 2 || phases["template.render:(inline)"] != 1 || len(phases) != 2 {
+		t.Errorf("phases = %v", phases)
+	}
+
+	if entries, _ := loadMetrics(metricsPath()); len(entries) != 0 {
+		t.Errorf("timing records read as health checks: %v", entries)
+	}
+}
+
+// TestBuildTimingReport verifies per-command percentiles, phase totals,
+// and the --since and --command filters
+func TestBuildTimingReport(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+	records := []timingRecord{
+		{Kind: timingKind, Timestamp: at(10 * 24 * time.Hour), Command: "blackdot vault pull", DurationMs: 5000, OK: true},
+		{Kind: timingKind, Timestamp: at(2 * time.Hour), Command: "blackdot vault pull", DurationMs: 1000, OK: true,
+			Phases: []timingPhase{{Name: "vault.bitwarden.get", Count: 4, DurationMs: 800}}},
+		{Kind: timingKind, Timestamp: at(time.Hour), Command: "blackdot vault pull", DurationMs: 3000, OK: false,
+			Phases: []timingPhase{{Name: "vault.bitwarden.get", Count: 2, DurationMs: 2000}}},
+		{Kind: timingKind, Timestamp: at(time.Minute), Command: "blackdot template render", DurationMs: 50, OK: true},
+	}
+
+	report := buildTimingReport(filterTimingRecords(records, now.Add(-7*24*time.Hour), ""), 10)
+	if report.Runs != 3 || len(report.Commands) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	pull := report.Commands[0]
+	if pull.Command != "blackdot vault pull" || pull.Runs != 2 || pull.AvgMs != 2000 || pull.P95Ms != 3000 || pull.Failures != 1 {
+		t.Errorf("pull stats = %+v", pull)
+	}
+	get := report.Phases[0]
+	if get.Calls != 6 || get.TotalMs != 2800 || get.MaxMs != 2000 {
+		t.Errorf("phase stats = %+v", get)
+	}
+	if report.Slowest[0].DurationMs != 3000 {
+		t.Errorf("slowest = %+v", report.Slowest[0])
+	}
+
+	if got := filterTimingRecords(records, time.Time{}, "blackdot template"); len(got) != 1 {
+		t.Errorf("--command filter kept %d records", len(got))
+	}
+}
@@ -10,14 +10,17 @@ import (
 
 // Tool feature mappings (matches ZSH feature names)
 var toolFeatureMap = map[string]string{
-	"ssh":    "ssh_tools",
-	"aws":    "aws_helpers",
-	"cdk":    "cdk_tools",
-	"go":     "go_tools",
-	"rust":   "rust_tools",
-	"python": "python_tools",
-	"docker": "docker_tools",
-	"claude": "claude_integration",
+	"ssh":      "ssh_tools",
+	"gpg":      "gpg_tools",
+	"aws":      "aws_helpers",
+	"k8s":      "k8s_tools",
+	"cdk":      "cdk_tools",
+	"go":       "go_tools",
+	"rust":     "rust_tools",
+	"python":   "python_tools",
+	"docker":   "docker_tools",
+	"claude":   "claude_integration",
+	"versions": "tool_versions",
 }
 
 // checkToolFeature verifies a tool's feature is enabled
@@ -95,13 +98,17 @@ func newToolsCmd() *cobra.Command {
 	// Add tool subcommands with feature checks
 	cmd.AddCommand(
 		wrapWithFeatureCheck("ssh", newToolsSSHCmd()),
+		wrapWithFeatureCheck("gpg", newToolsGPGCmd()),
 		wrapWithFeatureCheck("aws", newToolsAWSCmd()),
+		wrapWithFeatureCheck("k8s", newToolsK8sCmd()),
 		wrapWithFeatureCheck("cdk", newToolsCDKCmd()),
 		wrapWithFeatureCheck("go", newToolsGoCmd()),
 		wrapWithFeatureCheck("rust", newToolsRustCmd()),
 		wrapWithFeatureCheck("python", newToolsPythonCmd()),
 		wrapWithFeatureCheck("docker", newDockerToolsCmd()),
 		wrapWithFeatureCheck("claude", newToolsClaudeCmd()),
+		wrapWithFeatureCheck("versions", newToolsVersionsCmd()),
+		newToolsAutostartCmd(),
 	)
 
 	return cmd
@@ -124,20 +131,25 @@ func printToolsHelp() {
 	// Categories
 	BoldCyan.Println("Categories:")
 	printToolsCmd("ssh", "SSH key and connection management")
+	printToolsCmd("gpg", "GPG key management and vault backup")
 	printToolsCmd("aws", "AWS profile and authentication")
+	printToolsCmd("k8s", "Kubernetes contexts and kubeconfig merging")
 	printToolsCmd("cdk", "AWS CDK development helpers")
 	printToolsCmd("go", "Go development helpers")
 	printToolsCmd("rust", "Rust/Cargo development helpers")
 	printToolsCmd("python", "Python/uv development helpers")
 	printToolsCmd("docker", "Docker container management")
 	printToolsCmd("claude", "Claude Code configuration")
+	printToolsCmd("versions", "Runtimes pinned by .tool-versions and mise.toml")
+	printToolsCmd("autostart", "Login items from config (LaunchAgents, XDG, Run keys)")
 	fmt.Println()
 
 	// Feature flags
 	BoldCyan.Println("Feature Flags:")
 	Dim.Println("  Each category respects its feature flag:")
-	Dim.Println("  ssh_tools, aws_helpers, cdk_tools, go_tools,")
-	Dim.Println("  rust_tools, python_tools, docker_tools, claude_integration")
+	Dim.Println("  ssh_tools, gpg_tools, aws_helpers, k8s_tools, cdk_tools,")
+	Dim.Println("  go_tools, rust_tools, python_tools, docker_tools, claude_integration,")
+	Dim.Println("  tool_versions")
 	fmt.Println()
 
 	// Examples
@@ -0,0 +1,581 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// Login items are managed per OS under these names so blackdot only ever
+// touches entries it created
+const (
+	autostartLaunchdPrefix = "com.blackwell-systems.blackdot.autostart."
+	autostartFilePrefix    = "blackdot-"
+	autostartRunKey        = `HKCU\Software\Microsoft\Windows\CurrentVersion\Run`
+)
+
+// Login item states reported by status and doctor
+const (
+	autostartOK       = "ok"
+	autostartMissing  = "missing"
+	autostartChanged  = "changed"
+	autostartOrphaned = "orphaned"
+	autostartDisabled = "disabled"
+)
+
+// autostartItem is a login item from autostart.items in config.json. An
+// empty command uses the preset of the same name.
+type autostartItem struct {
+	Name     string `json:"-"`
+	Command  string `json:"command,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// autostartPresets are commands for common login items, used when an item
+// has no command of its own
+var autostartPresets = map[string]func() string{
+	"ssh-agent": func() string {
+		return `ssh-agent -D -a "${XDG_RUNTIME_DIR:-$HOME/.ssh}/agent.sock"`
+	},
+	"syncthing": func() string {
+		return "syncthing serve --no-browser --no-restart"
+	},
+	"scheduler": func() string {
+		return shellQuote(blackdotExecutable()) + " schedule run --quiet"
+	},
+}
+
+// autostartEntry is the state of one managed login item
+type autostartEntry struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	State   string `json:"state"`
+	Path    string `json:"path,omitempty"`
+}
+
+// newToolsAutostartCmd creates the autostart tools subcommand
+func newToolsAutostartCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "autostart",
+		Short: "Manage login items declaratively",
+		Long: `Manage OS login items from config.json.
+
+Items live under autostart.items and are installed as macOS LaunchAgents,
+XDG autostart .desktop entries on Linux, or Run keys on Windows:
+
+  "autostart": {
+    "items": {
+      "syncthing": {},
+      "scheduler": {},
+      "notes-sync": { "command": "~/bin/notes-sync --watch" }
+    }
+  }
+
+Items without a command use a preset: ssh-agent, syncthing, or scheduler
+(runs 'blackdot schedule run' at login). Set "disabled": true to keep an
+item in config but remove it from the OS.
+
+Commands:
+  status    - Show configured and installed login items (default)
+  apply     - Install, update, and remove items to match config
+  add       - Add an item to config and install it
+  remove    - Remove an item from config and the OS
+  clear     - Remove every login item blackdot installed`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAutostartStatus(false)
+		},
+	}
+
+	var statusJSON bool
+	statusCmd := &cobra.Command{
+		Use:     "status",
+		Aliases: []string{"list", "ls"},
+		Short:   "Show configured and installed login items",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAutostartStatus(statusJSON)
+		},
+	}
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Output as JSON")
+
+	var dryRun bool
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Install, update, and remove login items to match config",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAutostartApply(dryRun)
+		},
+	}
+	applyCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would change")
+
+	var item autostartItem
+	addCmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add a login item to config.json and install it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAutostartAdd(args[0], item)
+		},
+	}
+	addCmd.Flags().StringVar(&item.Command, "command", "", "Command to run at login (default: preset of the same name)")
+
+	removeCmd := &cobra.Command{
+		Use:               "remove <name>",
+		Aliases:           []string{"rm"},
+		Short:             "Remove a login item from config.json and the OS",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeAutostartItems,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAutostartRemove(args[0])
+		},
+	}
+
+	clearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove every login item blackdot installed (config is kept)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return removeAllAutostart(false)
+		},
+	}
+
+	cmd.AddCommand(statusCmd, applyCmd, addCmd, removeCmd, clearCmd)
+	return cmd
+}
+
+func completeAutostartItems(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	items, _ := loadAutostartItems()
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// loadAutostartItems returns autostart.items from config.json sorted by name
+func loadAutostartItems() ([]autostartItem, error) {
+	var cfg struct {
+		Autostart struct {
+			Items map[string]autostartItem `json:"items"`
+		} `json:"autostart"`
+	}
+	configPath := filepath.Join(ConfigDir(), "config.json")
+	if data, err := os.ReadFile(configPath); err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("parsing autostart.items in %s: %w", configPath, err)
+		}
+	}
+
+	items := make([]autostartItem, 0, len(cfg.Autostart.Items))
+	for name, item := range cfg.Autostart.Items {
+		item.Name = name
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	return items, nil
+}
+
+// command returns the item's command, falling back to its preset
+func (item autostartItem) command() (string, error) {
+	if item.Command != "" {
+		return item.Command, nil
+	}
+	if preset, ok := autostartPresets[item.Name]; ok {
+		return preset(), nil
+	}
+	return "", fmt.Errorf("autostart item '%s' has no command and is not a preset (ssh-agent, syncthing, scheduler)", item.Name)
+}
+
+// ============================================================
+// OS integration
+// ============================================================
+
+func autostartDir() string {
+	switch runtime.GOOS {
+	case "darwin":
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, "Library", "LaunchAgents")
+	case "windows":
+		return ""
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "autostart")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "autostart")
+}
+
+// autostartPath is the file holding a login item; empty on Windows
+func autostartPath(name string) string {
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(autostartDir(), autostartLaunchdPrefix+name+".plist")
+	case "windows":
+		return ""
+	}
+	return filepath.Join(autostartDir(), autostartFilePrefix+name+".desktop")
+}
+
+// renderAutostartEntry returns the file content (or Run key value on
+// Windows) for a login item
+func renderAutostartEntry(goos, name, command string) string {
+	switch goos {
+	case "darwin":
+		logPath := filepath.Join(paths.StateDir(), "autostart-"+name+".log")
+		return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+  <key>Label</key>
+  <string>%s</string>
+  <key>ProgramArguments</key>
+  <array>
+    <string>/bin/sh</string>
+    <string>-c</string>
+    <string>%s</string>
+  </array>
+  <key>RunAtLoad</key>
+  <true/>
+  <key>StandardOutPath</key>
+  <string>%s</string>
+  <key>StandardErrorPath</key>
+  <string>%s</string>
+</dict>
+</plist>
+`, autostartLaunchdPrefix+name, xmlEscape(command), logPath, logPath)
+	case "windows":
+		return command
+	}
+	return fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=blackdot: %s
+Exec=sh -c "%s"
+X-GNOME-Autostart-enabled=true
+NoDisplay=true
+X-Blackdot-Managed=true
+`, name, desktopExecEscape(command))
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// desktopExecEscape quotes a command for a double-quoted Exec argument
+// per the desktop entry spec
+func desktopExecEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$", `\$`).Replace(s)
+	// The spec applies string escaping on top, so backslashes double again
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// installedAutostart returns the current content of each login item
+// blackdot installed, keyed by name
+func installedAutostart() (map[string]string, error) {
+	installed := make(map[string]string)
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", autostartRunKey).Output()
+		if err != nil {
+			return installed, nil
+		}
+		scanner := bufio.NewScanner(strings.NewReader(string(out)))
+		for scanner.Scan() {
+			// "    blackdot-name    REG_SZ    command"
+			fields := strings.SplitN(strings.TrimSpace(scanner.Text()), "    ", 3)
+			if len(fields) == 3 && strings.HasPrefix(fields[0], autostartFilePrefix) {
+				installed[strings.TrimPrefix(fields[0], autostartFilePrefix)] = strings.TrimSpace(fields[2])
+			}
+		}
+		return installed, nil
+	}
+
+	prefix, suffix := autostartFilePrefix, ".desktop"
+	if runtime.GOOS == "darwin" {
+		prefix, suffix = autostartLaunchdPrefix, ".plist"
+	}
+	entries, err := os.ReadDir(autostartDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), prefix) || !strings.HasSuffix(e.Name(), suffix) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(autostartDir(), e.Name()))
+		if err != nil {
+			continue
+		}
+		// Only claim .desktop files blackdot wrote
+		if suffix == ".desktop" && !strings.Contains(string(data), "X-Blackdot-Managed=true") {
+			continue
+		}
+		installed[strings.TrimSuffix(strings.TrimPrefix(e.Name(), prefix), suffix)] = string(data)
+	}
+	return installed, nil
+}
+
+func installAutostart(name, command string) error {
+	content := renderAutostartEntry(runtime.GOOS, name, command)
+	if runtime.GOOS == "windows" {
+		if out, err := exec.Command("reg", "add", autostartRunKey, "/v", autostartFilePrefix+name, "/t", "REG_SZ", "/d", content, "/f").CombinedOutput(); err != nil {
+			return fmt.Errorf("reg add: %s", strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+
+	path := autostartPath(name)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := writeFileWithPolicy(path, []byte(content), fileClassConfig); err != nil {
+		return err
+	}
+	if runtime.GOOS == "darwin" {
+		exec.Command("launchctl", "unload", path).Run()
+		if out, err := exec.Command("launchctl", "load", "-w", path).CombinedOutput(); err != nil {
+			return fmt.Errorf("launchctl load: %s", strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+func uninstallAutostart(name string) error {
+	if runtime.GOOS == "windows" {
+		if out, err := exec.Command("reg", "delete", autostartRunKey, "/v", autostartFilePrefix+name, "/f").CombinedOutput(); err != nil {
+			return fmt.Errorf("reg delete: %s", strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+
+	path := autostartPath(name)
+	if runtime.GOOS == "darwin" {
+		exec.Command("launchctl", "unload", "-w", path).Run()
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// autostartEntries compares configured items with what is installed
+func autostartEntries() ([]autostartEntry, error) {
+	items, err := loadAutostartItems()
+	if err != nil {
+		return nil, err
+	}
+	installed, err := installedAutostart()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []autostartEntry
+	for _, item := range items {
+		command, err := item.command()
+		if err != nil {
+			return nil, err
+		}
+		entry := autostartEntry{Name: item.Name, Command: command, Path: autostartPath(item.Name)}
+		current, ok := installed[item.Name]
+		delete(installed, item.Name)
+		switch {
+		case item.Disabled && ok:
+			entry.State = autostartOrphaned
+		case item.Disabled:
+			entry.State = autostartDisabled
+		case !ok:
+			entry.State = autostartMissing
+		case current != renderAutostartEntry(runtime.GOOS, item.Name, command):
+			entry.State = autostartChanged
+		default:
+			entry.State = autostartOK
+		}
+		entries = append(entries, entry)
+	}
+
+	// Installed by blackdot but no longer in config
+	var orphans []string
+	for name := range installed {
+		orphans = append(orphans, name)
+	}
+	sort.Strings(orphans)
+	for _, name := range orphans {
+		entries = append(entries, autostartEntry{Name: name, State: autostartOrphaned, Path: autostartPath(name)})
+	}
+	return entries, nil
+}
+
+// ============================================================
+// Commands
+// ============================================================
+
+func runAutostartStatus(asJSON bool) error {
+	entries, err := autostartEntries()
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+
+	if asJSON {
+		if entries == nil {
+			entries = []autostartEntry{}
+		}
+		data, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	PrintHeader("Login Items")
+	if len(entries) == 0 {
+		Dim.Println("  No login items configured")
+		fmt.Println()
+		Info("Add one with: blackdot tools autostart add syncthing")
+		return nil
+	}
+
+	pending := 0
+	for _, e := range entries {
+		var mark string
+		switch e.State {
+		case autostartOK:
+			mark = Green.Sprint("✓")
+		case autostartDisabled:
+			mark = Dim.Sprint("-")
+		default:
+			mark = Yellow.Sprint("!")
+			pending++
+		}
+		fmt.Printf("  %s %-16s %s\n", mark, e.Name, Dim.Sprint(e.State))
+		if e.Command != "" && verbose {
+			Dim.Printf("      %s\n", e.Command)
+		}
+	}
+	fmt.Println()
+	if pending > 0 {
+		Info("Run 'blackdot tools autostart apply' to sync %d item(s)", pending)
+	}
+	return nil
+}
+
+func runAutostartApply(dryRun bool) error {
+	entries, err := autostartEntries()
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+
+	changed := 0
+	var failed []string
+	for _, e := range entries {
+		var action, done string
+		apply := func() error { return installAutostart(e.Name, e.Command) }
+		switch e.State {
+		case autostartMissing:
+			action, done = "install", "Installed"
+		case autostartChanged:
+			action, done = "update", "Updated"
+		case autostartOrphaned:
+			action, done = "remove", "Removed"
+			apply = func() error { return uninstallAutostart(e.Name) }
+		default:
+			continue
+		}
+		changed++
+		if dryRun {
+			Info("Would %s %s", action, e.Name)
+			continue
+		}
+		if err := apply(); err != nil {
+			Fail("Failed to %s %s: %v", action, e.Name, err)
+			failed = append(failed, e.Name)
+			continue
+		}
+		Pass("%s %s", done, e.Name)
+	}
+
+	if changed == 0 {
+		Pass("Login items match config")
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to apply: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func runAutostartAdd(name string, item autostartItem) error {
+	item.Name = name
+	command, err := item.command()
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if err := setInJSONFile(filepath.Join(ConfigDir(), "config.json"), "autostart.items."+name, string(data)); err != nil {
+		Fail("Failed to save login item: %v", err)
+		return err
+	}
+	if err := installAutostart(name, command); err != nil {
+		Fail("Saved '%s' but could not install it: %v", name, err)
+		return err
+	}
+	Pass("Added login item '%s'", name)
+	return nil
+}
+
+func runAutostartRemove(name string) error {
+	removed, err := deleteFromJSONFile(filepath.Join(ConfigDir(), "config.json"), "autostart.items."+name)
+	if err != nil {
+		return err
+	}
+	installed, _ := installedAutostart()
+	if _, ok := installed[name]; ok {
+		if err := uninstallAutostart(name); err != nil {
+			Fail("Failed to remove login item: %v", err)
+			return err
+		}
+		removed = true
+	}
+	if !removed {
+		Fail("No login item named '%s'", name)
+		return fmt.Errorf("no login item named %q", name)
+	}
+	Pass("Removed login item '%s'", name)
+	return nil
+}
+
+// removeAllAutostart removes every login item blackdot installed; used by
+// 'autostart clear' and uninstall
+func removeAllAutostart(dryRun bool) error {
+	installed, err := installedAutostart()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(installed))
+	for name := range installed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if dryRun {
+			Info("Would remove login item %s", name)
+			continue
+		}
+		if err := uninstallAutostart(name); err != nil {
+			Fail("Failed to remove login item %s: %v", name, err)
+			return err
+		}
+		Pass("Removed login item %s", name)
+	}
+	if len(names) == 0 && !dryRun {
+		Pass("No login items installed")
+	}
+	return nil
+}
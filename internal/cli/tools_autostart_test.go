@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestRenderAutostartEntry verifies shell metacharacters survive the
+// desktop entry and plist encodings
+func TestRenderAutostartEntry(t *testing.T) {
+	command := `notes-sync --dir "$HOME/notes" --fmt 100% && echo <ok>`
+
+	desktop := renderAutostartEntry("linux", "notes", command)
+	if !strings.Contains(desktop, `Exec=sh -c "notes-sync --dir \\"\\$HOME/notes\\" --fmt 100%% && echo <ok>"`) {
+		t.Errorf("desktop entry:\n%s", desktop)
+	}
+	if !strings.Contains(desktop, "X-Blackdot-Managed=true") {
+		t.Error("desktop entry must be marked as managed")
+	}
+
+	plist := renderAutostartEntry("darwin", "notes", command)
+	if !strings.Contains(plist, "<string>com.blackwell-systems.blackdot.autostart.notes</string>") ||
+		!strings.Contains(plist, "&amp;&amp; echo &lt;ok&gt;") {
+		t.Errorf("plist:\n%s", plist)
+	}
+}
+
+// TestAutostartApply verifies apply installs configured items, rewrites
+// changed ones, and removes disabled and unconfigured entries while leaving
+// autostart files it did not write alone
+func TestAutostartApply(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("exercises XDG autostart entries")
+	}
+	setScheduleEnv(t, `{"autostart": {"items": {
+		"syncthing": {},
+		"notes": {"command": "notes-sync --watch"},
+		"old": {"command": "old-daemon", "disabled": true}
+	}}}`)
+	dir := autostartDir()
+	os.MkdirAll(dir, 0755)
+	foreign := filepath.Join(dir, "blackdot-foreign.desktop")
+	os.WriteFile(foreign, []byte("[Desktop Entry]\nExec=foreign\n"), 0644)
+	os.WriteFile(autostartPath("old"), []byte(renderAutostartEntry("linux", "old", "old-daemon")), 0644)
+	os.WriteFile(autostartPath("stale"), []byte(renderAutostartEntry("linux", "stale", "x")), 0644)
+
+	states := func() map[string]string {
+		entries, err := autostartEntries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := make(map[string]string)
+		for _, e := range entries {
+			m[e.Name] = e.State
+		}
+		return m
+	}
+	if got := states(); got["syncthing"] != autostartMissing || got["old"] != autostartOrphaned || got["stale"] != autostartOrphaned || got["foreign"] != "" {
+		t.Fatalf("before apply: %v", got)
+	}
+
+	if err := runAutostartApply(false); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"syncthing": autostartOK, "notes": autostartOK, "old": autostartDisabled}
+	if got := states(); len(got) != len(want) || got["syncthing"] != want["syncthing"] || got["notes"] != want["notes"] || got["old"] != want["old"] {
+		t.Errorf("after apply: %v", got)
+	}
+	if _, err := os.Stat(foreign); err != nil {
+		t.Error("apply removed an entry blackdot did not write")
+	}
+
+	os.WriteFile(autostartPath("notes"), []byte(renderAutostartEntry("linux", "notes", "notes-sync")), 0644)
+	if got := states(); got["notes"] != autostartChanged {
+		t.Errorf("edited entry state = %q", got["notes"])
+	}
+
+	if err := removeAllAutostart(false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(autostartPath("syncthing")); !os.IsNotExist(err) {
+		t.Error("clear left a managed entry behind")
+	}
+}
@@ -3,11 +3,17 @@ package cli
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -25,10 +31,12 @@ and cross-account role assumption.
 
 Commands:
   profiles  - List all configured AWS profiles
-  who       - Show current AWS identity
-  login     - SSO login to AWS profile
-  switch    - Set AWS_PROFILE environment variable (prints export command)
+  who       - Show current AWS identity (alias: whoami)
+  login     - SSO login to AWS profile and verify via STS
+  expiry    - Show when SSO tokens and temporary credentials expire
+  switch    - Set AWS_PROFILE/AWS_REGION (prints export commands)
   assume    - Assume IAM role for cross-account access
+  credential-process - Serve vault-stored keys to AWS credential_process
   clear     - Clear temporary credentials`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Show status banner when called without subcommand
@@ -40,8 +48,10 @@ Commands:
 		newAWSProfilesCmd(),
 		newAWSWhoCmd(),
 		newAWSLoginCmd(),
+		newAWSExpiryCmd(),
 		newAWSSwitchCmd(),
 		newAWSAssumeCmd(),
+		newAWSCredentialProcessCmd(),
 		newAWSClearCmd(),
 		newAWSStatusCmd(),
 	)
@@ -49,38 +59,206 @@ Commands:
 	return cmd
 }
 
+// awsProfile is a profile parsed from ~/.aws/config and ~/.aws/credentials
+type awsProfile struct {
+	Name       string            `json:"name"`
+	Kind       string            `json:"kind"` // sso, role, process, static
+	Region     string            `json:"region,omitempty"`
+	AccountID  string            `json:"account_id,omitempty"`
+	RoleName   string            `json:"role_name,omitempty"`
+	SSOSession string            `json:"sso_session,omitempty"`
+	StartURL   string            `json:"sso_start_url,omitempty"`
+	Settings   map[string]string `json:"-"`
+}
+
+// awsConfigPath returns the AWS config file (AWS_CONFIG_FILE honored)
+func awsConfigPath() string {
+	if p := os.Getenv("AWS_CONFIG_FILE"); p != "" {
+		return p
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "config")
+}
+
+// awsCredentialsPath returns the AWS credentials file
+func awsCredentialsPath() string {
+	if p := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); p != "" {
+		return p
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "credentials")
+}
+
+// parseAWSINI parses an AWS-style INI file into section -> key -> value
+func parseAWSINI(data []byte) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	var current map[string]string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			current = make(map[string]string)
+			sections[name] = current
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			current[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return sections
+}
+
+// loadAWSProfiles reads profiles from the config and credentials files,
+// resolving sso-session sections so each SSO profile knows its start URL
+func loadAWSProfiles() ([]awsProfile, error) {
+	configData, configErr := os.ReadFile(awsConfigPath())
+	credsData, credsErr := os.ReadFile(awsCredentialsPath())
+	if configErr != nil && credsErr != nil {
+		return nil, fmt.Errorf("no AWS config found at %s", awsConfigPath())
+	}
+	return buildAWSProfiles(parseAWSINI(configData), parseAWSINI(credsData)), nil
+}
+
+func buildAWSProfiles(config, creds map[string]map[string]string) []awsProfile {
+	byName := make(map[string]*awsProfile)
+	get := func(name string) *awsProfile {
+		if p, ok := byName[name]; ok {
+			return p
+		}
+		p := &awsProfile{Name: name, Kind: "static", Settings: make(map[string]string)}
+		byName[name] = p
+		return p
+	}
+
+	for section, settings := range config {
+		name := section
+		switch {
+		case section == "default":
+		case strings.HasPrefix(section, "profile "):
+			name = strings.TrimPrefix(section, "profile ")
+		default:
+			continue // sso-session, services, ...
+		}
+		p := get(name)
+		for k, v := range settings {
+			p.Settings[k] = v
+		}
+	}
+	for name := range creds {
+		get(name)
+	}
+
+	profiles := make([]awsProfile, 0, len(byName))
+	for _, p := range byName {
+		p.Region = p.Settings["region"]
+		p.AccountID = p.Settings["sso_account_id"]
+		p.RoleName = p.Settings["sso_role_name"]
+		p.SSOSession = p.Settings["sso_session"]
+		p.StartURL = p.Settings["sso_start_url"]
+		if session, ok := config["sso-session "+p.SSOSession]; ok && p.SSOSession != "" {
+			if p.StartURL == "" {
+				p.StartURL = session["sso_start_url"]
+			}
+		}
+		switch {
+		case p.StartURL != "" || p.SSOSession != "":
+			p.Kind = "sso"
+		case p.Settings["role_arn"] != "":
+			p.Kind = "role"
+			if p.RoleName == "" {
+				p.RoleName = p.Settings["role_arn"]
+			}
+		case p.Settings["credential_process"] != "":
+			p.Kind = "process"
+		}
+		profiles = append(profiles, *p)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles
+}
+
+// findAWSProfile returns a profile by name
+func findAWSProfile(name string) (*awsProfile, error) {
+	profiles, err := loadAWSProfiles()
+	if err != nil {
+		return nil, err
+	}
+	for i := range profiles {
+		if profiles[i].Name == name {
+			return &profiles[i], nil
+		}
+	}
+	return nil, fmt.Errorf("profile '%s' not found in %s", name, awsConfigPath())
+}
+
+// currentAWSProfile returns AWS_PROFILE or "default"
+func currentAWSProfile() string {
+	if p := os.Getenv("AWS_PROFILE"); p != "" {
+		return p
+	}
+	return "default"
+}
+
 // newAWSProfilesCmd lists AWS profiles
 func newAWSProfilesCmd() *cobra.Command {
-	return &cobra.Command{
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
 		Use:   "profiles",
 		Short: "List all configured AWS profiles",
-		Long:  `List all AWS profiles from ~/.aws/config with active profile marked.`,
+		Long: `List all AWS profiles from ~/.aws/config and ~/.aws/credentials with
+the active profile marked. Shows the profile type (sso, role, process,
+static), region, and SSO account/role.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runAWSProfiles()
+			return runAWSProfiles(jsonOutput)
 		},
 	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	return cmd
 }
 
-func runAWSProfiles() error {
-	// Get profiles using aws cli
-	out, err := exec.Command("aws", "configure", "list-profiles").Output()
+func runAWSProfiles(jsonOutput bool) error {
+	profiles, err := loadAWSProfiles()
 	if err != nil {
-		return fmt.Errorf("failed to list profiles (is AWS CLI installed?): %w", err)
+		return err
+	}
+
+	if jsonOutput {
+		data, _ := json.MarshalIndent(profiles, "", "  ")
+		fmt.Println(string(data))
+		return nil
 	}
 
 	currentProfile := os.Getenv("AWS_PROFILE")
+	dim := color.New(color.Faint)
+	green := color.New(color.FgGreen)
 
 	fmt.Println("Available AWS profiles:")
-	scanner := bufio.NewScanner(strings.NewReader(string(out)))
-	for scanner.Scan() {
-		profile := strings.TrimSpace(scanner.Text())
-		if profile == "" {
-			continue
+	for _, p := range profiles {
+		detail := p.Kind
+		if p.Region != "" {
+			detail += ", " + p.Region
 		}
-		if profile == currentProfile {
-			fmt.Printf("  * %s (active)\n", profile)
+		if p.AccountID != "" {
+			detail += ", " + p.AccountID
+			if p.RoleName != "" {
+				detail += "/" + p.RoleName
+			}
+		}
+		if p.Name == currentProfile {
+			fmt.Printf("  %s %-24s %s\n", green.Sprint("*"), p.Name, dim.Sprintf("(%s) active", detail))
 		} else {
-			fmt.Printf("    %s\n", profile)
+			fmt.Printf("    %-24s %s\n", p.Name, dim.Sprintf("(%s)", detail))
 		}
 	}
 
@@ -90,31 +268,67 @@ func runAWSProfiles() error {
 // newAWSWhoCmd shows current AWS identity
 func newAWSWhoCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "who",
-		Short: "Show current AWS identity",
-		Long:  `Display the current AWS identity (account, user, ARN).`,
+		Use:     "who [profile]",
+		Aliases: []string{"whoami"},
+		Short:   "Show current AWS identity",
+		Long:    `Display the current AWS identity (account, user, ARN) and credential expiry.`,
+		Args:    cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runAWSWho()
+			profile := currentAWSProfile()
+			if len(args) > 0 {
+				profile = args[0]
+			}
+			return runAWSWho(profile)
 		},
 	}
 }
 
-func runAWSWho() error {
-	profile := os.Getenv("AWS_PROFILE")
-	if profile == "" {
-		profile = "default"
+// awsCallerIdentity is the output of sts get-caller-identity
+type awsCallerIdentity struct {
+	Account string `json:"Account"`
+	UserID  string `json:"UserId"`
+	Arn     string `json:"Arn"`
+}
+
+// awsIdentity verifies credentials for a profile via STS
+func awsIdentity(profile string) (*awsCallerIdentity, error) {
+	args := []string{"sts", "get-caller-identity", "--output", "json"}
+	if profile != "" && os.Getenv("AWS_ACCESS_KEY_ID") == "" {
+		args = append(args, "--profile", profile)
 	}
-	fmt.Printf("Profile: %s\n", profile)
+	var stderr bytes.Buffer
+	cmd := exec.Command("aws", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, err
+	}
+	var id awsCallerIdentity
+	if err := json.Unmarshal(out, &id); err != nil {
+		return nil, fmt.Errorf("failed to parse STS response: %w", err)
+	}
+	return &id, nil
+}
 
-	cmd := exec.Command("aws", "sts", "get-caller-identity", "--output", "table")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+func runAWSWho(profile string) error {
+	fmt.Printf("Profile: %s\n", profile)
 
-	if err := cmd.Run(); err != nil {
+	id, err := awsIdentity(profile)
+	if err != nil {
 		fmt.Printf("Not authenticated. Run: blackdot tools aws login %s\n", profile)
 		return nil
 	}
 
+	fmt.Printf("Account: %s\n", id.Account)
+	fmt.Printf("User:    %s\n", id.UserID)
+	fmt.Printf("ARN:     %s\n", id.Arn)
+	if exp, source := awsCredentialExpiry(profile); !exp.IsZero() {
+		fmt.Printf("Expires: %s (%s)\n", formatAWSExpiry(exp), source)
+	}
+
 	return nil
 }
 
@@ -142,65 +356,283 @@ If no profile is specified, uses AWS_PROFILE or defaults to 'default'.`,
 }
 
 func runAWSLogin(profile string) error {
-	fmt.Printf("Logging in to AWS SSO profile: %s\n", profile)
+	if p, err := findAWSProfile(profile); err == nil && p.Kind != "sso" {
+		Warn("Profile '%s' is not an SSO profile (%s); checking its credentials instead", profile, p.Kind)
+	} else {
+		fmt.Printf("Logging in to AWS SSO profile: %s\n", profile)
 
-	cmd := exec.Command("aws", "sso", "login", "--profile", profile)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+		cmd := exec.Command("aws", "sso", "login", "--profile", profile)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("SSO login failed: %w", err)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("SSO login failed: %w", err)
+		}
+		fmt.Println()
+	}
+
+	// Verify the credentials actually work before telling the user to use them
+	id, err := awsIdentity(profile)
+	if err != nil {
+		Fail("Credential check failed for %s: %v", profile, err)
+		return err
+	}
+	Pass("Authenticated as %s (account %s)", id.Arn, id.Account)
+	if exp, _ := awsCredentialExpiry(profile); !exp.IsZero() {
+		Info("Session expires %s", formatAWSExpiry(exp))
 	}
 
 	fmt.Println()
-	fmt.Printf("Logged in successfully. To use this profile:\n")
-	fmt.Printf("  export AWS_PROFILE=%s\n", profile)
+	fmt.Printf("To use this profile:\n")
+	fmt.Printf("  eval \"$(blackdot tools aws switch %s)\"\n", profile)
 
 	return nil
 }
 
+// newAWSExpiryCmd shows credential expiry
+func newAWSExpiryCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "expiry [profile]",
+		Short: "Show when credentials expire",
+		Long: `Show when the SSO token or temporary credentials for a profile expire.
+
+Checks AWS_CREDENTIAL_EXPIRATION for exported credentials, the SSO token
+cache (~/.aws/sso/cache) for SSO profiles, and the CLI cache
+(~/.aws/cli/cache) for assumed roles.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile := currentAWSProfile()
+			if len(args) > 0 {
+				profile = args[0]
+			}
+			exp, source := awsCredentialExpiry(profile)
+			if exp.IsZero() {
+				fmt.Printf("%s: no expiring credentials found (static keys or not logged in)\n", profile)
+				return nil
+			}
+			fmt.Printf("%s: %s (%s)\n", profile, formatAWSExpiry(exp), source)
+			return nil
+		},
+	}
+}
+
+// awsCredentialExpiry finds when the credentials for a profile expire.
+// The returned source describes where the expiry came from.
+func awsCredentialExpiry(profile string) (time.Time, string) {
+	for _, env := range []string{"AWS_CREDENTIAL_EXPIRATION", "AWS_SESSION_EXPIRATION"} {
+		if v := os.Getenv(env); v != "" && os.Getenv("AWS_ACCESS_KEY_ID") != "" {
+			if t, err := parseAWSTime(v); err == nil {
+				return t, env
+			}
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	p, err := findAWSProfile(profile)
+	if err != nil {
+		return time.Time{}, ""
+	}
+
+	if p.Kind == "sso" {
+		key := p.SSOSession
+		if key == "" {
+			key = p.StartURL
+		}
+		if t, ok := awsSSOCacheExpiry(filepath.Join(home, ".aws", "sso", "cache"), key); ok {
+			return t, "SSO token"
+		}
+	}
+
+	if p.Kind == "role" {
+		if t, ok := awsCLICacheExpiry(filepath.Join(home, ".aws", "cli", "cache"), p.Settings["role_arn"]); ok {
+			return t, "assumed role"
+		}
+	}
+	return time.Time{}, ""
+}
+
+// awsSSOCacheExpiry reads the token cache file the AWS CLI names after the
+// SHA-1 of the sso-session name (or start URL for legacy profiles)
+func awsSSOCacheExpiry(cacheDir, key string) (time.Time, bool) {
+	if key == "" {
+		return time.Time{}, false
+	}
+	sum := sha1.Sum([]byte(key))
+	data, err := os.ReadFile(filepath.Join(cacheDir, hex.EncodeToString(sum[:])+".json"))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var token struct {
+		ExpiresAt string `json:"expiresAt"`
+	}
+	if json.Unmarshal(data, &token) != nil {
+		return time.Time{}, false
+	}
+	t, err := parseAWSTime(token.ExpiresAt)
+	return t, err == nil
+}
+
+// awsCLICacheExpiry returns the latest expiry among cached assume-role
+// credentials for a role ARN
+func awsCLICacheExpiry(cacheDir, roleArn string) (time.Time, bool) {
+	files, _ := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	var latest time.Time
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var cached struct {
+			Credentials struct {
+				Expiration string `json:"Expiration"`
+			} `json:"Credentials"`
+			AssumedRoleUser struct {
+				Arn string `json:"Arn"`
+			} `json:"AssumedRoleUser"`
+		}
+		if json.Unmarshal(data, &cached) != nil {
+			continue
+		}
+		if roleArn != "" && !awsAssumedRoleMatches(cached.AssumedRoleUser.Arn, roleArn) {
+			continue
+		}
+		if t, err := parseAWSTime(cached.Credentials.Expiration); err == nil && t.After(latest) {
+			latest = t
+		}
+	}
+	return latest, !latest.IsZero()
+}
+
+// awsAssumedRoleMatches compares an assumed-role ARN
+// (arn:aws:sts::123:assumed-role/Name/session) with a role ARN
+// (arn:aws:iam::123:role/Name)
+func awsAssumedRoleMatches(assumed, roleArn string) bool {
+	a := strings.Split(assumed, ":")
+	r := strings.Split(roleArn, ":")
+	if len(a) < 6 || len(r) < 6 || a[4] != r[4] {
+		return false
+	}
+	aParts := strings.Split(a[5], "/")
+	rParts := strings.Split(r[5], "/")
+	return len(aParts) >= 2 && len(rParts) >= 2 && aParts[1] == rParts[len(rParts)-1]
+}
+
+// parseAWSTime parses the timestamp formats used in AWS caches
+func parseAWSTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05UTC", "2006-01-02T15:04:05Z0700"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time: %s", s)
+}
+
+// formatAWSExpiry renders an expiry relative to now
+func formatAWSExpiry(t time.Time) string {
+	remaining := time.Until(t)
+	local := t.Local().Format("2006-01-02 15:04")
+	if remaining <= 0 {
+		return fmt.Sprintf("expired at %s", local)
+	}
+	return fmt.Sprintf("in %s, at %s", remaining.Round(time.Minute), local)
+}
+
 // newAWSSwitchCmd sets AWS profile
 func newAWSSwitchCmd() *cobra.Command {
-	return &cobra.Command{
+	var region, shell string
+
+	cmd := &cobra.Command{
 		Use:   "switch <profile>",
-		Short: "Set AWS_PROFILE (prints export command)",
-		Long: `Print the export command to set AWS_PROFILE.
+		Short: "Set AWS_PROFILE/AWS_REGION (prints export commands)",
+		Long: `Print the commands to set AWS_PROFILE and the profile's region.
 
 Since Go cannot modify the parent shell's environment,
-this command prints the export command to execute.
+this command prints the commands to execute.
 
 Usage:
   eval "$(blackdot tools aws switch myprofile)"
-
-Or copy and paste the output.`,
+  eval "$(blackdot tools aws switch myprofile --region eu-west-1)"
+  blackdot tools aws switch myprofile --shell fish | source
+  blackdot tools aws switch myprofile --shell powershell | Invoke-Expression`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			profile := args[0]
+			return runAWSSwitch(args[0], region, shell)
+		},
+	}
 
-			// Verify profile exists
-			out, err := exec.Command("aws", "configure", "list-profiles").Output()
-			if err != nil {
-				return fmt.Errorf("failed to list profiles: %w", err)
-			}
+	cmd.Flags().StringVarP(&region, "region", "r", "", "Region to export (default: the profile's region)")
+	cmd.Flags().StringVar(&shell, "shell", "posix", "Output syntax: posix, fish, powershell")
 
-			found := false
-			for _, p := range strings.Split(string(out), "\n") {
-				if strings.TrimSpace(p) == profile {
-					found = true
-					break
-				}
-			}
+	return cmd
+}
 
-			if !found {
-				return fmt.Errorf("profile '%s' not found", profile)
-			}
+func runAWSSwitch(profile, region, shell string) error {
+	p, err := findAWSProfile(profile)
+	if err != nil {
+		return err
+	}
+	if region == "" {
+		region = p.Region
+	}
 
-			// Print export command
-			fmt.Printf("export AWS_PROFILE=%s\n", profile)
-			return nil
-		},
+	vars := [][2]string{{"AWS_PROFILE", profile}}
+	if region != "" {
+		vars = append(vars, [2]string{"AWS_REGION", region}, [2]string{"AWS_DEFAULT_REGION", region})
+	}
+
+	// Switching profiles should drop credentials exported by 'assume'
+	unset := []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_CREDENTIAL_EXPIRATION"}
+
+	lines, err := formatShellEnv(shell, vars, unset)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// formatShellEnv renders export/unset commands for a shell
+func formatShellEnv(shell string, set [][2]string, unset []string) ([]string, error) {
+	var lines []string
+	switch shell {
+	case "", "posix", "bash", "zsh", "sh":
+		for _, name := range unset {
+			lines = append(lines, "unset "+name)
+		}
+		for _, kv := range set {
+			lines = append(lines, fmt.Sprintf("export %s=%s", kv[0], shellQuote(kv[1])))
+		}
+	case "fish":
+		for _, name := range unset {
+			lines = append(lines, "set -e "+name)
+		}
+		for _, kv := range set {
+			lines = append(lines, fmt.Sprintf("set -gx %s %s", kv[0], shellQuote(kv[1])))
+		}
+	case "powershell", "pwsh":
+		for _, name := range unset {
+			lines = append(lines, fmt.Sprintf("Remove-Item Env:%s -ErrorAction SilentlyContinue", name))
+		}
+		for _, kv := range set {
+			lines = append(lines, fmt.Sprintf("$env:%s = '%s'", kv[0], strings.ReplaceAll(kv[1], "'", "''")))
+		}
+	default:
+		return nil, fmt.Errorf("unknown shell: %s (use posix, fish, or powershell)", shell)
 	}
+	return lines, nil
+}
+
+// shellQuote single-quotes a value when it contains shell metacharacters
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:@", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 // newAWSAssumeCmd assumes IAM role
@@ -230,6 +662,7 @@ type stsCredentials struct {
 		AccessKeyId     string `json:"AccessKeyId"`
 		SecretAccessKey string `json:"SecretAccessKey"`
 		SessionToken    string `json:"SessionToken"`
+		Expiration      string `json:"Expiration"`
 	} `json:"Credentials"`
 }
 
@@ -328,10 +761,16 @@ func runAWSStatus() error {
 	} else {
 		fmt.Printf("    %s   %s\n", dim.Sprint("Profile"), dim.Sprint("<not set>"))
 	}
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		fmt.Printf("    %s    %s\n", dim.Sprint("Region"), region)
+	}
 
 	// Session status
 	if isAuthenticated {
 		fmt.Printf("    %s   %s\n", dim.Sprint("Session"), green.Sprint("✓ authenticated"))
+		if exp, _ := awsCredentialExpiry(currentAWSProfile()); !exp.IsZero() {
+			fmt.Printf("    %s   %s\n", dim.Sprint("Expires"), formatAWSExpiry(exp))
+		}
 	} else {
 		fmt.Printf("    %s   %s %s\n", dim.Sprint("Session"), red.Sprint("✗ not authenticated"), dim.Sprint("(run awslogin)"))
 	}
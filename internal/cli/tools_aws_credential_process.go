@@ -0,0 +1,371 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// awsCredentialRefreshMargin is how long before expiry cached credentials
+// are treated as stale, so callers never receive credentials about to lapse
+const awsCredentialRefreshMargin = 5 * time.Minute
+
+// awsVaultCredentials are AWS keys read from a vault item
+type awsVaultCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+	RoleArn         string
+	ExternalID      string
+	Region          string
+}
+
+// awsProcessCredentials is the credential_process output contract
+type awsProcessCredentials struct {
+	Version         int    `json:"Version"`
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken,omitempty"`
+	Expiration      string `json:"Expiration,omitempty"`
+}
+
+// awsCredentialProcessOptions configures one credential-process call
+type awsCredentialProcessOptions struct {
+	Item        string
+	Section     string
+	RoleArn     string
+	SessionName string
+	ExternalID  string
+	Region      string
+	Duration    int
+	NoCache     bool
+}
+
+func newAWSCredentialProcessCmd() *cobra.Command {
+	var opts awsCredentialProcessOptions
+
+	cmd := &cobra.Command{
+		Use:   "credential-process",
+		Short: "Serve vault-stored credentials to the AWS credential_process hook",
+		Long: `Print credentials from a vault item in the JSON format AWS SDKs and
+the AWS CLI expect from credential_process, so ~/.aws/credentials never
+holds plaintext keys.
+
+The item holds AWS keys as key = value lines, either flat or in
+credentials-file [sections] (pick one with --section, default "default"):
+
+  aws_access_key_id = AKIA...
+  aws_secret_access_key = ...
+  aws_session_token = ...
+  expiration = 2026-01-01T00:00:00Z
+  role_arn = arn:aws:iam::123456789012:role/Deploy
+
+aws_session_token and expiration are only set for short-lived keys;
+role_arn, external_id and region are optional.
+
+With a role ARN (from the item or --role-arn) the keys are long-term keys
+used only to call sts assume-role, and the temporary credentials are
+returned instead. Assumed-role credentials are cached until five minutes
+before they expire so the vault is not unlocked on every AWS call;
+long-term keys are never cached.
+
+Configure a profile in ~/.aws/config:
+
+  [profile work]
+  credential_process = blackdot tools aws credential-process --item AWS-Credentials-Work
+
+Examples:
+  blackdot tools aws credential-process --item AWS-Credentials-Work
+  blackdot tools aws credential-process --item AWS-Keys --role-arn arn:aws:iam::123456789012:role/Admin`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			creds, err := runAWSCredentialProcess(opts)
+			if err != nil {
+				Fail("%v", err)
+				return err
+			}
+			return json.NewEncoder(os.Stdout).Encode(creds)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Item, "item", "", "Vault item holding the AWS keys (required)")
+	cmd.Flags().StringVar(&opts.Section, "section", "", "Section of the item to read (default \"default\")")
+	cmd.Flags().StringVar(&opts.RoleArn, "role-arn", "", "Assume this role with the vault keys")
+	cmd.Flags().StringVar(&opts.SessionName, "session-name", "blackdot", "Role session name")
+	cmd.Flags().StringVar(&opts.ExternalID, "external-id", "", "External ID for the role trust policy")
+	cmd.Flags().StringVar(&opts.Region, "region", "", "Region for the STS call")
+	cmd.Flags().IntVar(&opts.Duration, "duration", 0, "Assumed-role session length in seconds")
+	cmd.Flags().BoolVar(&opts.NoCache, "no-cache", false, "Always read the vault and assume the role again")
+	cmd.MarkFlagRequired("item")
+	cmd.RegisterFlagCompletionFunc("item", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeVaultItems(toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+// runAWSCredentialProcess resolves credentials for opts, preferring a
+// fresh cached assumed-role session
+func runAWSCredentialProcess(opts awsCredentialProcessOptions) (*awsProcessCredentials, error) {
+	cachePath := awsCredentialCachePath(opts)
+	if !opts.NoCache {
+		if cached, ok := loadCachedAWSCredentials(cachePath, time.Now()); ok {
+			return cached, nil
+		}
+	}
+
+	notes, err := readAWSCredentialItem(opts.Item)
+	if err != nil {
+		return nil, err
+	}
+	vc, err := parseAWSVaultCredentials(notes, opts.Section)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opts.Item, err)
+	}
+
+	roleArn := vc.RoleArn
+	if opts.RoleArn != "" {
+		roleArn = opts.RoleArn
+	}
+	if roleArn == "" {
+		if !vc.Expiration.IsZero() && time.Now().After(vc.Expiration) {
+			return nil, fmt.Errorf("%s: credentials expired at %s", opts.Item, vc.Expiration.Format(time.RFC3339))
+		}
+		return vc.processCredentials(), nil
+	}
+
+	if opts.ExternalID == "" {
+		opts.ExternalID = vc.ExternalID
+	}
+	if opts.Region == "" {
+		opts.Region = vc.Region
+	}
+	creds, err := assumeRoleWithKeys(vc, roleArn, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.NoCache {
+		if err := saveCachedAWSCredentials(cachePath, creds); err != nil {
+			Debug("aws credential cache: %v", err)
+		}
+	}
+	return creds, nil
+}
+
+// readAWSCredentialItem fetches the item's notes without writing them out
+func readAWSCredentialItem(item string) (string, error) {
+	if isOfflineMode() {
+		return "", errors.New("BLACKDOT_OFFLINE=1 prevents reading the vault")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	reader, err := openVaultReader(ctx)
+	if err != nil {
+		return "", fmt.Errorf("vault not available: %w", err)
+	}
+	defer reader.Close()
+
+	notes, _, err := reader.GetNotes(ctx, item)
+	if err != nil {
+		return "", fmt.Errorf("reading %s from vault: %w", item, err)
+	}
+	return notes, nil
+}
+
+// parseAWSVaultCredentials reads keys from item notes. Notes with [sections]
+// are read like a credentials file; otherwise every key = value line counts.
+// Environment-style names (AWS_ACCESS_KEY_ID, export ...) are accepted too.
+func parseAWSVaultCredentials(notes, section string) (*awsVaultCredentials, error) {
+	var kv map[string]string
+	if sections := parseAWSINI([]byte(notes)); len(sections) > 0 {
+		if section == "" {
+			section = "default"
+			if len(sections) == 1 {
+				for name := range sections {
+					section = name
+				}
+			}
+		}
+		var ok bool
+		if kv, ok = sections[section]; !ok {
+			return nil, fmt.Errorf("no [%s] section", section)
+		}
+	} else {
+		if section != "" {
+			return nil, fmt.Errorf("no [%s] section", section)
+		}
+		kv = make(map[string]string)
+		scanner := bufio.NewScanner(strings.NewReader(notes))
+		for scanner.Scan() {
+			line := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "export "))
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if key, value, ok := strings.Cut(line, "="); ok {
+				kv[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+			}
+		}
+	}
+
+	get := func(key string) string {
+		for k, v := range kv {
+			if strings.EqualFold(k, key) {
+				return v
+			}
+		}
+		return ""
+	}
+	vc := &awsVaultCredentials{
+		AccessKeyID:     get("aws_access_key_id"),
+		SecretAccessKey: get("aws_secret_access_key"),
+		SessionToken:    get("aws_session_token"),
+		RoleArn:         get("role_arn"),
+		ExternalID:      get("external_id"),
+		Region:          get("region"),
+	}
+	if vc.AccessKeyID == "" || vc.SecretAccessKey == "" {
+		return nil, errors.New("aws_access_key_id and aws_secret_access_key are required")
+	}
+	for _, key := range []string{"expiration", "aws_expiration", "aws_credential_expiration"} {
+		if s := get(key); s != "" {
+			t, err := parseAWSTime(s)
+			if err != nil {
+				return nil, err
+			}
+			vc.Expiration = t
+			break
+		}
+	}
+	return vc, nil
+}
+
+// processCredentials converts vault keys to the credential_process format
+func (vc *awsVaultCredentials) processCredentials() *awsProcessCredentials {
+	creds := &awsProcessCredentials{
+		Version:         1,
+		AccessKeyID:     vc.AccessKeyID,
+		SecretAccessKey: vc.SecretAccessKey,
+		SessionToken:    vc.SessionToken,
+	}
+	if !vc.Expiration.IsZero() {
+		creds.Expiration = vc.Expiration.UTC().Format(time.RFC3339)
+	}
+	return creds
+}
+
+// assumeRoleWithKeys calls sts assume-role authenticated as vc. The keys
+// reach the aws CLI only through its environment, and profile variables
+// are cleared so the call cannot recurse into this credential_process.
+func assumeRoleWithKeys(vc *awsVaultCredentials, roleArn string, opts awsCredentialProcessOptions) (*awsProcessCredentials, error) {
+	if _, err := exec.LookPath("aws"); err != nil {
+		return nil, errors.New("assuming a role requires the aws CLI")
+	}
+	args := []string{"sts", "assume-role",
+		"--role-arn", roleArn,
+		"--role-session-name", opts.SessionName,
+		"--output", "json"}
+	if opts.ExternalID != "" {
+		args = append(args, "--external-id", opts.ExternalID)
+	}
+	if opts.Duration > 0 {
+		args = append(args, "--duration-seconds", strconv.Itoa(opts.Duration))
+	}
+	if opts.Region != "" {
+		args = append(args, "--region", opts.Region)
+	}
+
+	var environ []string
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		switch name {
+		case "AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN":
+			continue
+		}
+		environ = append(environ, entry)
+	}
+	environ = append(environ,
+		"AWS_ACCESS_KEY_ID="+vc.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY="+vc.SecretAccessKey)
+	if vc.SessionToken != "" {
+		environ = append(environ, "AWS_SESSION_TOKEN="+vc.SessionToken)
+	}
+
+	cmd := exec.Command("aws", args...)
+	cmd.Env = environ
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("assume-role %s: %s", roleArn, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("assume-role %s: %w", roleArn, err)
+	}
+
+	var sts stsCredentials
+	if err := json.Unmarshal(out, &sts); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials: %w", err)
+	}
+	return &awsProcessCredentials{
+		Version:         1,
+		AccessKeyID:     sts.Credentials.AccessKeyId,
+		SecretAccessKey: sts.Credentials.SecretAccessKey,
+		SessionToken:    sts.Credentials.SessionToken,
+		Expiration:      sts.Credentials.Expiration,
+	}, nil
+}
+
+// awsCredentialCachePath is the cache file for one item/section/role
+// combination
+func awsCredentialCachePath(opts awsCredentialProcessOptions) string {
+	key := strings.Join([]string{opts.Item, opts.Section, opts.RoleArn, opts.SessionName, opts.ExternalID, strconv.Itoa(opts.Duration)}, "\x00")
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(paths.CacheDir(), "aws-credentials", hex.EncodeToString(sum[:])+".json")
+}
+
+// loadCachedAWSCredentials returns cached credentials that stay valid past
+// the refresh margin
+func loadCachedAWSCredentials(path string, now time.Time) (*awsProcessCredentials, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var creds awsProcessCredentials
+	if json.Unmarshal(data, &creds) != nil || creds.Expiration == "" {
+		return nil, false
+	}
+	exp, err := parseAWSTime(creds.Expiration)
+	if err != nil || now.Add(awsCredentialRefreshMargin).After(exp) {
+		return nil, false
+	}
+	return &creds, true
+}
+
+// saveCachedAWSCredentials caches temporary credentials readable only by
+// the user. Credentials without an expiry are long-term and never cached.
+func saveCachedAWSCredentials(path string, creds *awsProcessCredentials) error {
+	if creds.Expiration == "" || creds.SessionToken == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
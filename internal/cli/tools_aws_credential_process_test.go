@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseAWSVaultCredentials verifies flat, environment-style and
+// sectioned items
+func TestParseAWSVaultCredentials(t *testing.T) {
+	flat := "aws_access_key_id = AKIAFLAT\naws_secret_access_key = s3cret\nexpiration = 2030-01-01T00:00:00Z\n"
+	vc, err := parseAWSVaultCredentials(flat, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vc.AccessKeyID != "AKIAFLAT" || vc.Expiration.Year() != 2030 {
+		t.Errorf("flat = %+v", vc)
+	}
+	out := vc.processCredentials()
+	if out.Version != 1 || out.Expiration != "2030-01-01T00:00:00Z" {
+		t.Errorf("process credentials = %+v", out)
+	}
+
+	env := "export AWS_ACCESS_KEY_ID=AKIAENV\nexport AWS_SECRET_ACCESS_KEY='s3cret'\n"
+	if vc, err := parseAWSVaultCredentials(env, ""); err != nil || vc.SecretAccessKey != "s3cret" {
+		t.Errorf("env style = %+v, %v", vc, err)
+	}
+
+	sectioned := "[default]\naws_access_key_id = AKIADEF\naws_secret_access_key = a\n\n[work]\naws_access_key_id = AKIAWORK\naws_secret_access_key = b\nrole_arn = arn:aws:iam::123456789012:role/Deploy\n"
+	if vc, err := parseAWSVaultCredentials(sectioned, ""); err != nil || vc.AccessKeyID != "AKIADEF" {
+		t.Errorf("default section = %+v, %v", vc, err)
+	}
+	if vc, err := parseAWSVaultCredentials(sectioned, "work"); err != nil || vc.RoleArn == "" {
+		t.Errorf("work section = %+v, %v", vc, err)
+	}
+	if _, err := parseAWSVaultCredentials(sectioned, "missing"); err == nil {
+		t.Error("missing section accepted")
+	}
+	if _, err := parseAWSVaultCredentials("aws_access_key_id = AKIAONLY\n", ""); err == nil {
+		t.Error("item without a secret key accepted")
+	}
+}
+
+// TestAssumeRoleWithKeys verifies the vault keys reach sts only through the
+// environment, profiles are cleared, and only temporary credentials are
+// cached
+func TestAssumeRoleWithKeys(t *testing.T) {
+	setScheduleEnv(t, "")
+	bin := t.TempDir()
+	script := `#!/bin/sh
+[ "$AWS_ACCESS_KEY_ID" = AKIAVAULT ] || { echo "wrong key" >&2; exit 1; }
+[ -z "$AWS_PROFILE" ] || { echo "profile leaked" >&2; exit 1; }
+case "$*" in *--external-id\ ext*) ;; *) echo "no external id" >&2; exit 1;; esac
+echo '{"Credentials":{"AccessKeyId":"ASIATEMP","SecretAccessKey":"tmp","SessionToken":"tok","Expiration":"2030-01-01T00:00:00Z"}}'
+`
+	os.WriteFile(filepath.Join(bin, "aws"), []byte(script), 0755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("AWS_PROFILE", "work")
+
+	vc := &awsVaultCredentials{AccessKeyID: "AKIAVAULT", SecretAccessKey: "long"}
+	opts := awsCredentialProcessOptions{Item: "AWS-Keys", SessionName: "blackdot", ExternalID: "ext"}
+	creds, err := assumeRoleWithKeys(vc, "arn:aws:iam::123456789012:role/Deploy", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyID != "ASIATEMP" || creds.Expiration == "" {
+		t.Fatalf("creds = %+v", creds)
+	}
+
+	path := awsCredentialCachePath(opts)
+	if err := saveCachedAWSCredentials(path, creds); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("cache file: %v %v", info, err)
+	}
+	if _, ok := loadCachedAWSCredentials(path, time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC)); !ok {
+		t.Error("fresh cached credentials not used")
+	}
+	if _, ok := loadCachedAWSCredentials(path, time.Date(2029, 12, 31, 23, 58, 0, 0, time.UTC)); ok {
+		t.Error("credentials inside the refresh margin were used")
+	}
+
+	longTerm := vc.processCredentials()
+	other := filepath.Join(filepath.Dir(path), "long.json")
+	saveCachedAWSCredentials(other, longTerm)
+	if _, err := os.Stat(other); err == nil {
+		t.Error("long-term keys were cached")
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "long") {
+		t.Error("cache holds the long-term secret")
+	}
+}
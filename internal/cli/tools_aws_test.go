@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestLoadAWSProfiles verifies profile kinds, regions, and sso-session resolution
+func TestLoadAWSProfiles(t *testing.T) {
+	dir := t.TempDir()
+	config := `[default]
+region = us-east-1
+
+[profile work]
+sso_session = corp
+sso_account_id = 111111111111
+sso_role_name = Admin
+region = eu-west-1
+
+[profile legacy-sso]
+sso_start_url = https://legacy.awsapps.com/start
+
+[profile deploy]
+role_arn = arn:aws:iam::222222222222:role/Deploy
+source_profile = default
+
+[sso-session corp]
+sso_start_url = https://corp.awsapps.com/start
+`
+	creds := `[default]
+aws_access_key_id = AKIAEXAMPLE
+
+[personal]
+aws_access_key_id = AKIAOTHER
+`
+	os.WriteFile(filepath.Join(dir, "config"), []byte(config), 0600)
+	os.WriteFile(filepath.Join(dir, "credentials"), []byte(creds), 0600)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+
+	profiles, err := loadAWSProfiles()
+	if err != nil {
+		t.Fatalf("loadAWSProfiles: %v", err)
+	}
+
+	want := map[string]string{"default": "static", "deploy": "role", "legacy-sso": "sso", "personal": "static", "work": "sso"}
+	if len(profiles) != len(want) {
+		t.Fatalf("got %d profiles, want %d: %+v", len(profiles), len(want), profiles)
+	}
+	for _, p := range profiles {
+		if want[p.Name] != p.Kind {
+			t.Errorf("%s kind = %s, want %s", p.Name, p.Kind, want[p.Name])
+		}
+	}
+
+	work, err := findAWSProfile("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work.StartURL != "https://corp.awsapps.com/start" || work.Region != "eu-west-1" || work.AccountID != "111111111111" {
+		t.Errorf("work profile = %+v", work)
+	}
+	if _, err := findAWSProfile("missing"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+// TestAWSSSOCacheExpiry verifies the token cache is found by SHA-1 of the session key
+func TestAWSSSOCacheExpiry(t *testing.T) {
+	dir := t.TempDir()
+	sum := sha1.Sum([]byte("corp"))
+	os.WriteFile(filepath.Join(dir, hex.EncodeToString(sum[:])+".json"),
+		[]byte(`{"expiresAt": "2030-01-02T03:04:05Z"}`), 0600)
+
+	got, ok := awsSSOCacheExpiry(dir, "corp")
+	if !ok || !got.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("awsSSOCacheExpiry = %v, %v", got, ok)
+	}
+	if _, ok := awsSSOCacheExpiry(dir, "other"); ok {
+		t.Error("expected no expiry for an uncached session")
+	}
+
+	// Legacy CLI caches use a UTC suffix instead of Z
+	if _, err := parseAWSTime("2030-01-02T03:04:05UTC"); err != nil {
+		t.Errorf("parseAWSTime legacy format: %v", err)
+	}
+}
+
+// TestAWSCLICacheExpiry verifies assumed-role credentials are matched to the role ARN
+func TestAWSCLICacheExpiry(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{
+		"Credentials": {"Expiration": "2030-01-01T00:00:00Z"},
+		"AssumedRoleUser": {"Arn": "arn:aws:sts::222222222222:assumed-role/Deploy/botocore-session-1"}
+	}`), 0600)
+	os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{
+		"Credentials": {"Expiration": "2031-01-01T00:00:00Z"},
+		"AssumedRoleUser": {"Arn": "arn:aws:sts::333333333333:assumed-role/Deploy/botocore-session-2"}
+	}`), 0600)
+
+	got, ok := awsCLICacheExpiry(dir, "arn:aws:iam::222222222222:role/Deploy")
+	if !ok || got.Year() != 2030 {
+		t.Errorf("awsCLICacheExpiry = %v, %v", got, ok)
+	}
+}
+
+// TestFormatShellEnv verifies export syntax for each supported shell
+func TestFormatShellEnv(t *testing.T) {
+	set := [][2]string{{"AWS_PROFILE", "work"}, {"AWS_REGION", "it's"}}
+
+	posix, _ := formatShellEnv("posix", set, []string{"AWS_SESSION_TOKEN"})
+	if posix[0] != "unset AWS_SESSION_TOKEN" || posix[1] != "export AWS_PROFILE=work" || posix[2] != `export AWS_REGION='it'\''s'` {
+		t.Errorf("posix = %q", posix)
+	}
+
+	fish, _ := formatShellEnv("fish", set, nil)
+	if fish[0] != "set -gx AWS_PROFILE work" {
+		t.Errorf("fish = %q", fish)
+	}
+
+	pwsh, _ := formatShellEnv("powershell", set, nil)
+	if pwsh[1] != "$env:AWS_REGION = 'it''s'" {
+		t.Errorf("powershell = %q", pwsh)
+	}
+
+	if _, err := formatShellEnv("tcsh", set, nil); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+}
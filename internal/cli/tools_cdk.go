@@ -2,12 +2,13 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
+	"github.com/blackwell-systems/blackdot/internal/prompts"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -424,12 +425,8 @@ func cdkCheck(stacks []string) error {
 	diffCmd.Run() // Don't fail on diff
 
 	// Prompt for deploy
-	fmt.Print("\nDeploy these changes? [y/N] ")
-	reader := bufio.NewReader(os.Stdin)
-	response, _ := reader.ReadString('\n')
-	response = strings.TrimSpace(strings.ToLower(response))
-
-	if response == "y" || response == "yes" {
+	fmt.Println()
+	if deploy, _ := prompts.Confirm("Deploy these changes?", false); deploy {
 		deployArgs := []string{"deploy"}
 		if len(stacks) > 0 {
 			deployArgs = append(deployArgs, stacks...)
@@ -648,14 +645,8 @@ func runSSHStatus() error {
 
 	// Count hosts and keys
 	hostCount := 0
-	if file, err := os.Open(os.Getenv("HOME") + "/.ssh/config"); err == nil {
-		defer file.Close()
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			if strings.HasPrefix(strings.TrimSpace(scanner.Text()), "Host ") {
-				hostCount++
-			}
-		}
+	if scan, err := scanSSHConfig(filepath.Join(userSSHDir(), "config")); err == nil {
+		hostCount = len(scan.uniqueHosts())
 	}
 	fmt.Printf("    %s     %s\n", dim.Sprint("Hosts"), cyan.Sprintf("%d configured", hostCount))
 
@@ -2,7 +2,6 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,6 +9,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/blackwell-systems/blackdot/internal/prompts"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -144,12 +144,8 @@ func runClaudeStatus() error {
 		fmt.Printf("  Claude CLI: %s\n", yellow("not found"))
 	}
 
-	if _, err := exec.LookPath("dotclaude"); err == nil {
-		fmt.Printf("  dotclaude:  %s\n", green("installed"))
-	} else {
-		fmt.Printf("  dotclaude:  %s\n", dim("not installed"))
-	}
-
+	fmt.Println()
+	printClaudeProfileStatus()
 	return nil
 }
 
@@ -192,13 +188,7 @@ func runClaudeBedrock(evalMode bool) error {
 		return fmt.Errorf("SSO authentication required")
 	}
 
-	exports := []string{
-		fmt.Sprintf("export AWS_PROFILE='%s'", cfg.BedrockProfile),
-		fmt.Sprintf("export AWS_REGION='%s'", cfg.BedrockRegion),
-		"export CLAUDE_CODE_USE_BEDROCK=1",
-		fmt.Sprintf("export ANTHROPIC_MODEL='%s'", cfg.BedrockModel),
-		fmt.Sprintf("export ANTHROPIC_SMALL_FAST_MODEL='%s'", cfg.BedrockFastModel),
-	}
+	exports := claudeBedrockExports(cfg)
 
 	if evalMode {
 		// Just print the exports for eval
@@ -218,6 +208,18 @@ func runClaudeBedrock(evalMode bool) error {
 	return nil
 }
 
+// claudeBedrockExports are the shell commands that point Claude Code at
+// AWS Bedrock
+func claudeBedrockExports(cfg claudeConfig) []string {
+	return []string{
+		fmt.Sprintf("export AWS_PROFILE='%s'", cfg.BedrockProfile),
+		fmt.Sprintf("export AWS_REGION='%s'", cfg.BedrockRegion),
+		"export CLAUDE_CODE_USE_BEDROCK=1",
+		fmt.Sprintf("export ANTHROPIC_MODEL='%s'", cfg.BedrockModel),
+		fmt.Sprintf("export ANTHROPIC_SMALL_FAST_MODEL='%s'", cfg.BedrockFastModel),
+	}
+}
+
 // newClaudeMaxCmd prints Max export commands
 func newClaudeMaxCmd() *cobra.Command {
 	var evalMode bool
@@ -245,20 +247,24 @@ Or use --eval flag:
 	return cmd
 }
 
+// claudeMaxUnsets clear the Bedrock and API settings so Claude Code uses
+// the logged-in Anthropic Max session
+var claudeMaxUnsets = []string{
+	"unset CLAUDE_CODE_USE_BEDROCK",
+	"unset AWS_PROFILE",
+	"unset AWS_REGION",
+	"unset AWS_ACCESS_KEY_ID",
+	"unset AWS_SECRET_ACCESS_KEY",
+	"unset AWS_SESSION_TOKEN",
+	"unset ANTHROPIC_API_KEY",
+	"unset ANTHROPIC_BASE_URL",
+	"unset ANTHROPIC_AUTH_TOKEN",
+	"unset ANTHROPIC_MODEL",
+	"unset ANTHROPIC_SMALL_FAST_MODEL",
+}
+
 func runClaudeMax(evalMode bool) error {
-	unsets := []string{
-		"unset CLAUDE_CODE_USE_BEDROCK",
-		"unset AWS_PROFILE",
-		"unset AWS_REGION",
-		"unset AWS_ACCESS_KEY_ID",
-		"unset AWS_SECRET_ACCESS_KEY",
-		"unset AWS_SESSION_TOKEN",
-		"unset ANTHROPIC_API_KEY",
-		"unset ANTHROPIC_BASE_URL",
-		"unset ANTHROPIC_AUTH_TOKEN",
-		"unset ANTHROPIC_MODEL",
-		"unset ANTHROPIC_SMALL_FAST_MODEL",
-	}
+	unsets := claudeMaxUnsets
 
 	if evalMode {
 		for _, u := range unsets {
@@ -314,28 +320,19 @@ func runClaudeSwitchInteractive() error {
 	fmt.Println("Claude Code Backend Selection")
 	fmt.Println("=============================")
 	fmt.Println()
-	fmt.Println("1) bedrock - AWS Bedrock" + func() string {
-		if cfg.BedrockProfile != "" {
-			return fmt.Sprintf(" (profile: %s)", cfg.BedrockProfile)
-		}
-		return " (not configured)"
-	}())
-	fmt.Println("2) max     - Anthropic Max subscription")
-	fmt.Println()
-	fmt.Print("Select backend [1-2]: ")
-
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	input = strings.TrimSpace(input)
+	bedrock := "bedrock - AWS Bedrock (not configured)"
+	if cfg.BedrockProfile != "" {
+		bedrock = fmt.Sprintf("bedrock - AWS Bedrock (profile: %s)", cfg.BedrockProfile)
+	}
 
-	switch input {
-	case "1", "bedrock":
+	choice, err := prompts.Select("Select backend", []string{bedrock, "max     - Anthropic Max subscription"}, -1)
+	if err != nil {
+		return err
+	}
+	if choice == 0 {
 		return runClaudeBedrock(false)
-	case "2", "max":
-		return runClaudeMax(false)
-	default:
-		return fmt.Errorf("invalid selection: %s", input)
 	}
+	return runClaudeMax(false)
 }
 
 // newClaudeInitCmd initializes Claude Code hooks/commands
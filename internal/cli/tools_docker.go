@@ -339,99 +339,6 @@ func dockerNetInspect(network string) error {
 	return cmd.Run()
 }
 
-// newDockerCleanCmd removes stopped containers and dangling images
-func newDockerCleanCmd() *cobra.Command {
-	var dryRun bool
-
-	cmd := &cobra.Command{
-		Use:   "clean",
-		Short: "Remove stopped containers and dangling images",
-		Long: `Clean up Docker by removing:
-  - Stopped containers
-  - Dangling images
-
-This is a safe cleanup operation that won't remove running containers
-or images that are in use.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return dockerClean(dryRun)
-		},
-	}
-
-	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be removed")
-
-	return cmd
-}
-
-func dockerClean(dryRun bool) error {
-	if err := checkDockerRunning(); err != nil {
-		return err
-	}
-
-	PrintHeader("Docker Cleanup")
-
-	if dryRun {
-		fmt.Println("(DRY RUN - no changes will be made)")
-		fmt.Println()
-	}
-
-	// Count stopped containers
-	cmd := exec.Command("docker", "ps", "-aq", "-f", "status=exited")
-	output, _ := cmd.Output()
-	stoppedContainers := strings.Split(strings.TrimSpace(string(output)), "\n")
-	stoppedCount := 0
-	for _, c := range stoppedContainers {
-		if c != "" {
-			stoppedCount++
-		}
-	}
-
-	// Count dangling images
-	cmd = exec.Command("docker", "images", "-f", "dangling=true", "-q")
-	output, _ = cmd.Output()
-	danglingImages := strings.Split(strings.TrimSpace(string(output)), "\n")
-	danglingCount := 0
-	for _, i := range danglingImages {
-		if i != "" {
-			danglingCount++
-		}
-	}
-
-	if stoppedCount > 0 {
-		fmt.Printf("Stopped containers: %d\n", stoppedCount)
-		if !dryRun {
-			Info("Removing stopped containers...")
-			cmd = exec.Command("docker", "container", "prune", "-f")
-			cmd.Stdout = os.Stdout
-			cmd.Run()
-		} else {
-			fmt.Println("Would remove stopped containers")
-		}
-	} else {
-		fmt.Println("No stopped containers to remove")
-	}
-
-	fmt.Println()
-
-	if danglingCount > 0 {
-		fmt.Printf("Dangling images: %d\n", danglingCount)
-		if !dryRun {
-			Info("Removing dangling images...")
-			cmd = exec.Command("docker", "image", "prune", "-f")
-			cmd.Stdout = os.Stdout
-			cmd.Run()
-		} else {
-			fmt.Println("Would remove dangling images")
-		}
-	} else {
-		fmt.Println("No dangling images to remove")
-	}
-
-	fmt.Println()
-	Pass("Done!")
-
-	return nil
-}
-
 // newDockerPruneCmd does system prune
 func newDockerPruneCmd() *cobra.Command {
 	var all bool
@@ -1116,266 +1023,6 @@ func dockerTag(source, target string) error {
 	return cmd.Run()
 }
 
-// =============================================================================
-// Compose Commands
-// =============================================================================
-
-// newDockerComposeCmd provides compose subcommands
-func newDockerComposeCmd() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "compose",
-		Short: "Docker Compose commands",
-		Long:  `Docker Compose management commands.`,
-	}
-
-	cmd.AddCommand(newComposeUpCmd())
-	cmd.AddCommand(newComposeDownCmd())
-	cmd.AddCommand(newComposeLogsCmd())
-	cmd.AddCommand(newComposePsCmd())
-	cmd.AddCommand(newComposeBuildCmd())
-	cmd.AddCommand(newComposeRestartCmd())
-	cmd.AddCommand(newComposeExecCmd())
-	cmd.AddCommand(newComposePullCmd())
-
-	return cmd
-}
-
-func newComposeUpCmd() *cobra.Command {
-	var detach bool
-	var build bool
-
-	cmd := &cobra.Command{
-		Use:   "up [services...]",
-		Short: "Start compose services",
-		Long:  `Create and start containers.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return composeUp(args, detach, build)
-		},
-	}
-
-	cmd.Flags().BoolVarP(&detach, "detach", "d", false, "Run in background")
-	cmd.Flags().BoolVar(&build, "build", false, "Build images before starting")
-
-	return cmd
-}
-
-func composeUp(services []string, detach, build bool) error {
-	if err := checkDockerRunning(); err != nil {
-		return err
-	}
-
-	args := []string{"compose", "up"}
-	if detach {
-		args = append(args, "-d")
-	}
-	if build {
-		args = append(args, "--build")
-	}
-	args = append(args, services...)
-
-	cmd := exec.Command("docker", args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
-func newComposeDownCmd() *cobra.Command {
-	var volumes bool
-
-	cmd := &cobra.Command{
-		Use:   "down",
-		Short: "Stop compose services",
-		Long:  `Stop and remove containers, networks.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return composeDown(volumes)
-		},
-	}
-
-	cmd.Flags().BoolVarP(&volumes, "volumes", "v", false, "Remove volumes too")
-
-	return cmd
-}
-
-func composeDown(volumes bool) error {
-	if err := checkDockerRunning(); err != nil {
-		return err
-	}
-
-	args := []string{"compose", "down"}
-	if volumes {
-		args = append(args, "-v")
-	}
-
-	cmd := exec.Command("docker", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
-func newComposeLogsCmd() *cobra.Command {
-	var follow bool
-
-	cmd := &cobra.Command{
-		Use:   "logs [services...]",
-		Short: "View compose logs",
-		Long:  `View output from containers.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return composeLogs(args, follow)
-		},
-	}
-
-	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Follow log output")
-
-	return cmd
-}
-
-func composeLogs(services []string, follow bool) error {
-	if err := checkDockerRunning(); err != nil {
-		return err
-	}
-
-	args := []string{"compose", "logs"}
-	if follow {
-		args = append(args, "-f")
-	}
-	args = append(args, services...)
-
-	cmd := exec.Command("docker", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
-func newComposePsCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "ps",
-		Short: "List compose containers",
-		Long:  `List containers for compose project.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return composePs()
-		},
-	}
-}
-
-func composePs() error {
-	if err := checkDockerRunning(); err != nil {
-		return err
-	}
-
-	cmd := exec.Command("docker", "compose", "ps")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
-func newComposeBuildCmd() *cobra.Command {
-	var noCache bool
-
-	cmd := &cobra.Command{
-		Use:   "build [services...]",
-		Short: "Build compose images",
-		Long:  `Build or rebuild services.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return composeBuild(args, noCache)
-		},
-	}
-
-	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Build without cache")
-
-	return cmd
-}
-
-func composeBuild(services []string, noCache bool) error {
-	if err := checkDockerRunning(); err != nil {
-		return err
-	}
-
-	args := []string{"compose", "build"}
-	if noCache {
-		args = append(args, "--no-cache")
-	}
-	args = append(args, services...)
-
-	cmd := exec.Command("docker", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
-func newComposeRestartCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "restart [services...]",
-		Short: "Restart compose services",
-		Long:  `Restart service containers.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return composeRestart(args)
-		},
-	}
-}
-
-func composeRestart(services []string) error {
-	if err := checkDockerRunning(); err != nil {
-		return err
-	}
-
-	args := append([]string{"compose", "restart"}, services...)
-	cmd := exec.Command("docker", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
-func newComposeExecCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "exec <service> <command> [args...]",
-		Short: "Execute in compose service",
-		Long:  `Execute a command in a running service container.`,
-		Args:  cobra.MinimumNArgs(2),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return composeExec(args[0], args[1:])
-		},
-	}
-}
-
-func composeExec(service string, command []string) error {
-	if err := checkDockerRunning(); err != nil {
-		return err
-	}
-
-	args := []string{"compose", "exec", service}
-	args = append(args, command...)
-
-	cmd := exec.Command("docker", args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
-func newComposePullCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "pull [services...]",
-		Short: "Pull compose images",
-		Long:  `Pull service images.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return composePull(args)
-		},
-	}
-}
-
-func composePull(services []string) error {
-	if err := checkDockerRunning(); err != nil {
-		return err
-	}
-
-	args := append([]string{"compose", "pull"}, services...)
-	cmd := exec.Command("docker", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
 // buildHere builds Docker image with current directory name as tag
 func buildHere(noCache bool) error {
 	if err := checkDockerRunning(); err != nil {
@@ -0,0 +1,416 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+	"github.com/spf13/cobra"
+)
+
+// dockerCleanOptions are the flags of 'tools docker clean'
+type dockerCleanOptions struct {
+	DryRun      bool
+	OlderThan   string // minimum time a container has been stopped
+	Volumes     bool   // also remove unused volumes
+	Interactive bool
+}
+
+// newDockerCleanCmd removes reclaimable containers, images, and volumes
+func newDockerCleanCmd() *cobra.Command {
+	var opts dockerCleanOptions
+
+	cmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove stopped containers, dangling images, and unused volumes",
+		Long: `Show reclaimable Docker space by category, then remove it:
+  - Dangling images (untagged, not used by any container)
+  - Stopped containers, optionally only those stopped for --older-than
+  - Unused volumes: not mounted by any container and not owned by a
+    compose project (only removed with --volumes)
+
+Sizes come from 'docker system df' and count only what deleting frees:
+an image's layers shared with other images are not included.
+
+With --interactive, choose per category whether to remove everything,
+pick items one by one, or skip it.`,
+		Example: `  blackdot tools docker clean --dry-run
+  blackdot tools docker clean --older-than 7d
+  blackdot tools docker clean --volumes -i`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return dockerClean(opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.DryRun, "dry-run", "n", false, "Show what would be removed and the space it frees")
+	cmd.Flags().StringVar(&opts.OlderThan, "older-than", "", "Only containers stopped at least this long (e.g. 7d, 2w, 12h)")
+	cmd.Flags().BoolVar(&opts.Volumes, "volumes", false, "Also remove unused volumes")
+	cmd.Flags().BoolVarP(&opts.Interactive, "interactive", "i", false, "Choose what to remove per category")
+
+	return cmd
+}
+
+// dockerReclaimCategory is one kind of reclaimable resource
+type dockerReclaimCategory struct {
+	Key   string // images, containers, volumes
+	Label string
+	Items []dockerReclaimItem
+}
+
+// dockerReclaimItem is a resource clean can remove, with the bytes
+// removing it frees
+type dockerReclaimItem struct {
+	ID     string // what docker rm/rmi/volume rm is given
+	Name   string
+	Size   int64
+	Age    time.Duration // since the container stopped or the item was created
+	Detail string
+}
+
+func (c dockerReclaimCategory) size() int64 {
+	var total int64
+	for _, item := range c.Items {
+		total += item.Size
+	}
+	return total
+}
+
+// dockerDiskUsage is 'docker system df -v --format {{json .}}'. Docker
+// formats sizes for humans there, so they are parsed back to bytes.
+type dockerDiskUsage struct {
+	Images []struct {
+		ID         string
+		Repository string
+		Tag        string
+		Containers string
+		CreatedAt  string
+		UniqueSize string
+	}
+	Containers []struct {
+		ID        string
+		Names     string
+		Image     string
+		State     string
+		CreatedAt string
+		Size      string
+	}
+	Volumes []struct {
+		Name   string
+		Labels string
+		Links  string
+		Size   string
+	}
+}
+
+func dockerClean(opts dockerCleanOptions) error {
+	olderThan, err := parseItemAge(opts.OlderThan)
+	if err != nil {
+		return err
+	}
+	if err := checkDockerRunning(); err != nil {
+		return err
+	}
+
+	out, err := exec.Command("docker", "system", "df", "-v", "--format", "{{json .}}").Output()
+	if err != nil {
+		Fail("Failed to read Docker disk usage: %v", err)
+		return err
+	}
+	var usage dockerDiskUsage
+	if err := json.Unmarshal(out, &usage); err != nil {
+		Fail("Failed to parse docker system df output: %v", err)
+		return err
+	}
+	categories := dockerReclaimable(usage, dockerStoppedTimes(usage), olderThan, time.Now())
+
+	PrintHeader("Docker Cleanup")
+	if opts.DryRun {
+		fmt.Println("(DRY RUN - no changes will be made)")
+		fmt.Println()
+	}
+	printDockerReclaimable(categories, opts)
+
+	var freed int64
+	failed := 0
+	for _, c := range categories {
+		if len(c.Items) == 0 || (c.Key == "volumes" && !opts.Volumes) {
+			continue
+		}
+		items := c.Items
+		if opts.Interactive && !opts.DryRun {
+			if items, err = pickDockerReclaimItems(c); err != nil {
+				return err
+			}
+		}
+		if len(items) == 0 || opts.DryRun {
+			continue
+		}
+		Info("Removing %s...", strings.ToLower(c.Label))
+		for _, item := range items {
+			if err := removeDockerItem(c.Key, item.ID); err != nil {
+				Warn("Failed to remove %s: %v", item.Name, err)
+				failed++
+				continue
+			}
+			freed += item.Size
+		}
+	}
+
+	fmt.Println()
+	if opts.DryRun {
+		return nil
+	}
+	if failed > 0 {
+		Warn("Freed %s; %d item(s) could not be removed", formatSize(freed), failed)
+		return nil
+	}
+	Pass("Freed %s", formatSize(freed))
+	return nil
+}
+
+// dockerStoppedTimes returns when each stopped container finished, from
+// docker inspect, keyed by container ID. Containers that never ran have no
+// entry.
+func dockerStoppedTimes(usage dockerDiskUsage) map[string]time.Time {
+	args := []string{"inspect", "--format", "{{.Id}} {{.State.FinishedAt}}"}
+	for _, c := range usage.Containers {
+		if c.State != "running" && c.State != "paused" && c.State != "restarting" {
+			args = append(args, c.ID)
+		}
+	}
+	times := make(map[string]time.Time)
+	if len(args) == 3 {
+		return times
+	}
+	out, _ := exec.Command("docker", args...).Output()
+	for _, line := range strings.Split(string(out), "\n") {
+		id, finished, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339Nano, finished); err == nil && t.Year() > 1 {
+			times[id] = t
+		}
+	}
+	return times
+}
+
+// dockerReclaimable sorts disk usage into the categories clean removes,
+// largest items first
+func dockerReclaimable(usage dockerDiskUsage, stopped map[string]time.Time, olderThan time.Duration, now time.Time) []dockerReclaimCategory {
+	images := dockerReclaimCategory{Key: "images", Label: "Dangling images"}
+	for _, img := range usage.Images {
+		if img.Repository != "<none>" || img.Tag != "<none>" || img.Containers != "0" {
+			continue
+		}
+		images.Items = append(images.Items, dockerReclaimItem{
+			ID:   img.ID,
+			Name: shortDockerID(img.ID),
+			Size: parseDockerSize(img.UniqueSize),
+			Age:  sinceDockerTime(img.CreatedAt, now),
+		})
+	}
+
+	containers := dockerReclaimCategory{Key: "containers", Label: "Stopped containers"}
+	for _, c := range usage.Containers {
+		if c.State != "exited" && c.State != "created" && c.State != "dead" {
+			continue
+		}
+		age := sinceDockerTime(c.CreatedAt, now)
+		for id, finished := range stopped {
+			if strings.HasPrefix(id, c.ID) { // df may print short IDs
+				age = now.Sub(finished)
+			}
+		}
+		if age < olderThan {
+			continue
+		}
+		containers.Items = append(containers.Items, dockerReclaimItem{
+			ID:     c.ID,
+			Name:   c.Names,
+			Size:   parseDockerSize(c.Size),
+			Age:    age,
+			Detail: c.Image,
+		})
+	}
+
+	volumes := dockerReclaimCategory{Key: "volumes", Label: "Unused volumes"}
+	for _, v := range usage.Volumes {
+		if v.Links != "0" {
+			continue
+		}
+		if dockerLabel(v.Labels, "com.docker.compose.project") != "" {
+			continue
+		}
+		volumes.Items = append(volumes.Items, dockerReclaimItem{
+			ID:   v.Name,
+			Name: v.Name,
+			Size: parseDockerSize(v.Size),
+		})
+	}
+
+	categories := []dockerReclaimCategory{images, containers, volumes}
+	for _, c := range categories {
+		sort.SliceStable(c.Items, func(i, j int) bool { return c.Items[i].Size > c.Items[j].Size })
+	}
+	return categories
+}
+
+// printDockerReclaimable prints a summary line per category followed by
+// its items
+func printDockerReclaimable(categories []dockerReclaimCategory, opts dockerCleanOptions) {
+	var total int64
+	for _, c := range categories {
+		note := ""
+		switch {
+		case c.Key == "containers" && opts.OlderThan != "":
+			note = fmt.Sprintf("stopped for %s or more", opts.OlderThan)
+		case c.Key == "volumes" && !opts.Volumes && len(c.Items) > 0:
+			note = "kept; use --volumes to remove"
+		}
+		fmt.Printf("%-20s %4d  %10s", c.Label, len(c.Items), formatSize(c.size()))
+		if note != "" {
+			fmt.Print("  " + Dim.Sprintf("(%s)", note))
+		}
+		fmt.Println()
+		if c.Key != "volumes" || opts.Volumes {
+			total += c.size()
+		}
+	}
+	fmt.Printf("%-20s %4s  %10s\n", "Reclaimable", "", formatSize(total))
+
+	for _, c := range categories {
+		if len(c.Items) == 0 {
+			continue
+		}
+		fmt.Println()
+		fmt.Println(c.Label + ":")
+		for _, item := range c.Items {
+			line := fmt.Sprintf("  %-30s %10s", item.Name, formatSize(item.Size))
+			if item.Age > 0 {
+				line += fmt.Sprintf("  %6s", formatDockerAge(item.Age))
+			}
+			if item.Detail != "" {
+				line += "  " + Dim.Sprint(item.Detail)
+			}
+			fmt.Println(line)
+		}
+	}
+	fmt.Println()
+}
+
+// pickDockerReclaimItems asks whether to remove all of a category, pick
+// items one by one, or skip it
+func pickDockerReclaimItems(c dockerReclaimCategory) ([]dockerReclaimItem, error) {
+	question := fmt.Sprintf("%s (%d, %s)", c.Label, len(c.Items), formatSize(c.size()))
+	choice, err := prompts.Select(question, []string{"all", "pick one by one", "skip"}, 2)
+	if err != nil {
+		return nil, err
+	}
+	switch choice {
+	case 0:
+		return c.Items, nil
+	case 2:
+		return nil, nil
+	}
+	var picked []dockerReclaimItem
+	for _, item := range c.Items {
+		ok, err := prompts.Confirm(fmt.Sprintf("Remove %s (%s)?", item.Name, formatSize(item.Size)), false)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			picked = append(picked, item)
+		}
+	}
+	return picked, nil
+}
+
+// removeDockerItem deletes one container, image, or volume
+func removeDockerItem(category, id string) error {
+	var args []string
+	switch category {
+	case "containers":
+		args = []string{"rm", id}
+	case "images":
+		args = []string{"rmi", id}
+	case "volumes":
+		args = []string{"volume", "rm", id}
+	}
+	out, err := exec.Command("docker", args...).CombinedOutput()
+	if err != nil && len(out) > 0 {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return err
+}
+
+// parseDockerSize converts a size docker printed for humans ("1.23GB",
+// "512kB", "0B") back to bytes. Docker uses powers of 1000. Unparseable
+// sizes count as 0.
+func parseDockerSize(s string) int64 {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, " ("); i >= 0 { // "12kB (virtual 1GB)"
+		s = s[:i]
+	}
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i <= 0 {
+		return 0
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0
+	}
+	exp, ok := map[byte]int{'b': 0, 'k': 1, 'm': 2, 'g': 3, 't': 4, 'p': 5}[s[i]|0x20]
+	if !ok {
+		return 0
+	}
+	for ; exp > 0; exp-- {
+		n *= 1000
+	}
+	return int64(n)
+}
+
+// sinceDockerTime is how long ago a docker CreatedAt timestamp
+// ("2024-03-01 10:00:00 +0000 UTC") was, or 0 if it cannot be parsed
+func sinceDockerTime(s string, now time.Time) time.Duration {
+	t, err := time.Parse("2006-01-02 15:04:05 -0700 MST", s)
+	if err != nil {
+		return 0
+	}
+	return now.Sub(t)
+}
+
+// dockerLabel returns one value from docker's "k=v,k=v" label list
+func dockerLabel(labels, key string) string {
+	for _, kv := range strings.Split(labels, ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// shortDockerID is the 12-character form docker prints for an ID
+func shortDockerID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// formatDockerAge prints an age in its largest whole unit: 3d, 5h, 10m
+func formatDockerAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dm", int(d/time.Minute))
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestParseDockerSize verifies docker's human sizes parse back to bytes
+func TestParseDockerSize(t *testing.T) {
+	for in, want := range map[string]int64{
+		"0B":                 0,
+		"512B":               512,
+		"1.5kB":              1500,
+		"12.3MB":             12300000,
+		"1.23GB":             1230000000,
+		"8kB (virtual 72MB)": 8000,
+		"N/A":                0,
+		"":                   0,
+	} {
+		if got := parseDockerSize(in); got != want {
+			t.Errorf("parseDockerSize(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+// TestDockerReclaimable verifies which images, containers, and volumes
+// are offered for removal
+func TestDockerReclaimable(t *testing.T) {
+	var usage dockerDiskUsage
+	err := json.Unmarshal([]byte(`{
+		"Images": [
+			{"ID": "sha256:aaaaaaaaaaaaaaaa", "Repository": "<none>", "Tag": "<none>", "Containers": "0", "CreatedAt": "2026-10-01 10:00:00 +0000 UTC", "UniqueSize": "150MB"},
+			{"ID": "sha256:bbbbbbbbbbbbbbbb", "Repository": "<none>", "Tag": "<none>", "Containers": "1", "UniqueSize": "20MB"},
+			{"ID": "sha256:cccccccccccccccc", "Repository": "nginx", "Tag": "latest", "Containers": "0", "UniqueSize": "180MB"}
+		],
+		"Containers": [
+			{"ID": "old", "Names": "old-build", "State": "exited", "Size": "2MB"},
+			{"ID": "new", "Names": "new-build", "State": "exited", "Size": "4MB"},
+			{"ID": "web", "Names": "web", "State": "running", "Size": "1MB"}
+		],
+		"Volumes": [
+			{"Name": "scratch", "Links": "0", "Size": "1GB"},
+			{"Name": "app_db", "Labels": "com.docker.compose.project=app,com.docker.compose.volume=db", "Links": "0", "Size": "3GB"},
+			{"Name": "mounted", "Links": "1", "Size": "5GB"}
+		]
+	}`), &usage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	stopped := map[string]time.Time{
+		"old0123": now.Add(-10 * 24 * time.Hour),
+		"new0123": now.Add(-time.Hour),
+	}
+
+	got := dockerReclaimable(usage, stopped, 7*24*time.Hour, now)
+	names := func(c dockerReclaimCategory) []string {
+		var out []string
+		for _, item := range c.Items {
+			out = append(out, item.Name)
+		}
+		return out
+	}
+	if n := names(got[0]); len(n) != 1 || n[0] != "aaaaaaaaaaaa" || got[0].size() != 150000000 {
+		t.Errorf("images = %v (%d bytes)", n, got[0].size())
+	}
+	if got[0].Items[0].Age != 15*24*time.Hour {
+		t.Errorf("image age = %v", got[0].Items[0].Age)
+	}
+	if n := names(got[1]); len(n) != 1 || n[0] != "old-build" || got[1].Items[0].Age != 10*24*time.Hour {
+		t.Errorf("containers = %v", got[1].Items)
+	}
+	if n := names(got[2]); len(n) != 1 || n[0] != "scratch" {
+		t.Errorf("volumes = %v", n)
+	}
+
+	if all := dockerReclaimable(usage, stopped, 0, now); len(all[1].Items) != 2 || all[1].Items[0].Name != "new-build" {
+		t.Errorf("without --older-than, containers = %v", all[1].Items)
+	}
+}
@@ -0,0 +1,809 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// composeFileNames are looked up in each directory, in Docker's order
+var composeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// composeVarRef is a ${VAR} or $VAR reference in a compose file. Group 2
+// is set when the reference has a default (${VAR:-x}, ${VAR-x}).
+var composeVarRef = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)(:?[-+?][^}]*)?\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// composeProject is the compose file for the current project
+type composeProject struct {
+	File     string
+	Dir      string
+	Services []string
+	EnvFiles map[string][]string // service -> env_file paths, relative to Dir
+	Vars     []string            // variables referenced without a default
+}
+
+// composeRun runs docker with the terminal attached; tests replace it
+var composeRun = func(args ...string) error {
+	cmd := exec.Command("docker", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// composeOutput runs docker and returns its output; tests replace it
+var composeOutput = func(args ...string) ([]byte, error) {
+	return exec.Command("docker", args...).Output()
+}
+
+// newDockerComposeCmd provides compose subcommands
+func newDockerComposeCmd() *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "compose",
+		Short: "Docker Compose commands",
+		Long: `Docker Compose management commands for the current project.
+
+The compose file is found by walking up from the working directory to the
+repository root, looking for compose.yaml, compose.yml, docker-compose.yaml
+or docker-compose.yml, then .devcontainer/docker-compose.yml (written by
+'blackdot devcontainer init'). --file picks one explicitly.
+
+Services can be named or matched with globs ('db*'), and unknown names are
+rejected before docker runs. ps shows each service's state and health
+check, and env checks .env against .env.example next to the compose file.`,
+		Example: `  blackdot tools docker compose up -d 'db*'
+  blackdot tools docker compose logs -f api --tail 50
+  blackdot tools docker compose ps
+  blackdot tools docker compose env --init`,
+	}
+	cmd.PersistentFlags().StringVar(&file, "file", "", "Compose file (default: found from the working directory)")
+
+	project := func() (*composeProject, error) {
+		if err := checkDockerRunning(); err != nil {
+			return nil, err
+		}
+		return loadComposeProject(file)
+	}
+
+	cmd.AddCommand(newComposeUpCmd(project))
+	cmd.AddCommand(newComposeDownCmd(project))
+	cmd.AddCommand(newComposeLogsCmd(project))
+	cmd.AddCommand(newComposePsCmd(project))
+	cmd.AddCommand(newComposeEnvCmd(&file))
+	cmd.AddCommand(newComposePassthroughCmd(project, "build [services...]", "Build compose images", "no-cache", "Build without cache"))
+	cmd.AddCommand(newComposePassthroughCmd(project, "restart [services...]", "Restart compose services", "", ""))
+	cmd.AddCommand(newComposePassthroughCmd(project, "pull [services...]", "Pull compose images", "", ""))
+	cmd.AddCommand(newComposeExecCmd(project))
+
+	return cmd
+}
+
+func newComposeUpCmd(project func() (*composeProject, error)) *cobra.Command {
+	var detach, build bool
+
+	cmd := &cobra.Command{
+		Use:   "up [services...]",
+		Short: "Start compose services",
+		Long: `Create and start containers. .env is checked against .env.example
+first; problems are reported but do not stop the services starting. With
+--detach the service health summary is printed once they are up.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := project()
+			if err != nil {
+				return err
+			}
+			services, err := p.resolveServices(args)
+			if err != nil {
+				return err
+			}
+			if problems := checkComposeEnv(p); len(problems) > 0 {
+				for _, problem := range problems {
+					Warn("%s", problem)
+				}
+				Info("Run 'blackdot tools docker compose env' for details")
+			}
+
+			upArgs := []string{}
+			if detach {
+				upArgs = append(upArgs, "-d")
+			}
+			if build {
+				upArgs = append(upArgs, "--build")
+			}
+			if err := composeRun(p.args("up", append(upArgs, services...)...)...); err != nil {
+				return err
+			}
+			if detach {
+				fmt.Println()
+				return composeStatus(p, services)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&detach, "detach", "d", false, "Run in background")
+	cmd.Flags().BoolVar(&build, "build", false, "Build images before starting")
+
+	return cmd
+}
+
+func newComposeDownCmd(project func() (*composeProject, error)) *cobra.Command {
+	var volumes bool
+
+	cmd := &cobra.Command{
+		Use:   "down [services...]",
+		Short: "Stop compose services",
+		Long:  `Stop and remove containers and networks, or only the given services.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := project()
+			if err != nil {
+				return err
+			}
+			services, err := p.resolveServices(args)
+			if err != nil {
+				return err
+			}
+			downArgs := []string{}
+			if volumes {
+				downArgs = append(downArgs, "-v")
+			}
+			return composeRun(p.args("down", append(downArgs, services...)...)...)
+		},
+	}
+
+	cmd.Flags().BoolVar(&volumes, "volumes", false, "Remove volumes too")
+
+	return cmd
+}
+
+func newComposeLogsCmd(project func() (*composeProject, error)) *cobra.Command {
+	var follow bool
+	var tail, since string
+
+	cmd := &cobra.Command{
+		Use:   "logs [services...]",
+		Short: "View compose logs",
+		Long:  `View output from containers, for all services or the matching ones.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := project()
+			if err != nil {
+				return err
+			}
+			services, err := p.resolveServices(args)
+			if err != nil {
+				return err
+			}
+			logArgs := []string{}
+			if follow {
+				logArgs = append(logArgs, "-f")
+			}
+			if tail != "" {
+				logArgs = append(logArgs, "--tail", tail)
+			}
+			if since != "" {
+				logArgs = append(logArgs, "--since", since)
+			}
+			return composeRun(p.args("logs", append(logArgs, services...)...)...)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Follow log output")
+	cmd.Flags().StringVar(&tail, "tail", "", "Number of lines from the end of each log")
+	cmd.Flags().StringVar(&since, "since", "", "Only logs since this time or duration (e.g. 10m)")
+
+	return cmd
+}
+
+func newComposePsCmd(project func() (*composeProject, error)) *cobra.Command {
+	var raw bool
+
+	cmd := &cobra.Command{
+		Use:     "ps [services...]",
+		Aliases: []string{"status"},
+		Short:   "Show compose services with their health",
+		Long: `Show each service's state and health check result, with a summary.
+Services defined in the compose file but without a container are listed
+as not created. --raw prints 'docker compose ps' instead.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := project()
+			if err != nil {
+				return err
+			}
+			services, err := p.resolveServices(args)
+			if err != nil {
+				return err
+			}
+			if raw {
+				return composeRun(p.args("ps", append([]string{"--all"}, services...)...)...)
+			}
+			return composeStatus(p, services)
+		},
+	}
+
+	cmd.Flags().BoolVar(&raw, "raw", false, "Print docker compose ps output")
+
+	return cmd
+}
+
+// newComposePassthroughCmd wraps a compose subcommand that takes services
+// and at most one boolean flag
+func newComposePassthroughCmd(project func() (*composeProject, error), use, short, flag, flagUsage string) *cobra.Command {
+	var set bool
+	sub := strings.Fields(use)[0]
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := project()
+			if err != nil {
+				return err
+			}
+			services, err := p.resolveServices(args)
+			if err != nil {
+				return err
+			}
+			var extra []string
+			if set {
+				extra = append(extra, "--"+flag)
+			}
+			return composeRun(p.args(sub, append(extra, services...)...)...)
+		},
+	}
+	if flag != "" {
+		cmd.Flags().BoolVar(&set, flag, false, flagUsage)
+	}
+	return cmd
+}
+
+func newComposeExecCmd(project func() (*composeProject, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "exec <service> <command> [args...]",
+		Short: "Execute in compose service",
+		Long:  `Execute a command in a running service container.`,
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := project()
+			if err != nil {
+				return err
+			}
+			if _, err := p.resolveServices(args[:1]); err != nil {
+				return err
+			}
+			return composeRun(p.args("exec", args...)...)
+		},
+	}
+}
+
+func newComposeEnvCmd(file *string) *cobra.Command {
+	var initEnv bool
+
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Check .env against .env.example",
+		Long: `Check the .env next to the compose file against .env.example (as
+written by 'blackdot devcontainer init'):
+
+  - keys in .env.example missing from .env
+  - keys left empty in .env
+  - keys in .env that .env.example does not list
+  - env_file entries of services that do not exist
+  - variables the compose file uses without a default that neither .env
+    nor the environment sets
+
+--init creates .env from .env.example, or appends the missing keys with
+their example values.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := loadComposeProject(*file)
+			if err != nil {
+				return err
+			}
+			if initEnv {
+				if err := initComposeEnv(p.Dir); err != nil {
+					return err
+				}
+			}
+			return printComposeEnv(p)
+		},
+	}
+
+	cmd.Flags().BoolVar(&initEnv, "init", false, "Create .env or add missing keys from .env.example")
+
+	return cmd
+}
+
+// findComposeFile walks up from dir to the repository root (or the
+// filesystem root outside one) and returns the first compose file
+func findComposeFile(dir string) string {
+	stop := ""
+	if root := findProjectRoot(); root != "" && strings.HasPrefix(dir, root) {
+		stop = root
+	}
+	for {
+		for _, name := range composeFileNames {
+			if path := filepath.Join(dir, name); fileExists(path) {
+				return path
+			}
+		}
+		if path := filepath.Join(dir, ".devcontainer", "docker-compose.yml"); fileExists(path) {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if dir == stop || parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadComposeProject finds and parses the project's compose file, or file
+// when given
+func loadComposeProject(file string) (*composeProject, error) {
+	if file == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		if file = findComposeFile(cwd); file == "" {
+			return nil, fmt.Errorf("no compose file found (looked for %s from %s up)", strings.Join(composeFileNames, ", "), tildePath(cwd))
+		}
+	}
+	file, err := filepath.Abs(expandPath(file))
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p, err := parseComposeProject(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", tildePath(file), err)
+	}
+	p.File, p.Dir = file, filepath.Dir(file)
+	return p, nil
+}
+
+// parseComposeProject reads the services, their env files and the
+// variables the compose file needs
+func parseComposeProject(data []byte) (*composeProject, error) {
+	var doc struct {
+		Services map[string]struct {
+			EnvFile interface{} `yaml:"env_file"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	p := &composeProject{EnvFiles: make(map[string][]string)}
+	for name, svc := range doc.Services {
+		p.Services = append(p.Services, name)
+		switch v := svc.EnvFile.(type) {
+		case string:
+			p.EnvFiles[name] = []string{v}
+		case []interface{}:
+			for _, entry := range v {
+				switch e := entry.(type) {
+				case string:
+					p.EnvFiles[name] = append(p.EnvFiles[name], e)
+				case map[string]interface{}:
+					// {path: x, required: false} entries may be absent
+					if path, _ := e["path"].(string); path != "" && e["required"] != false {
+						p.EnvFiles[name] = append(p.EnvFiles[name], path)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(p.Services)
+
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		line = strings.ReplaceAll(line, "$$", "") // escaped dollar
+		for _, m := range composeVarRef.FindAllStringSubmatch(line, -1) {
+			name := m[1] + m[3]
+			if m[2] == "" && !seen[name] {
+				seen[name] = true
+				p.Vars = append(p.Vars, name)
+			}
+		}
+	}
+	sort.Strings(p.Vars)
+	return p, nil
+}
+
+// args builds a docker compose command line for this project
+func (p *composeProject) args(sub string, rest ...string) []string {
+	return append([]string{"compose", "-f", p.File, "--project-directory", p.Dir, sub}, rest...)
+}
+
+// resolveServices expands service names and globs against the compose
+// file, in compose file order
+func (p *composeProject) resolveServices(patterns []string) ([]string, error) {
+	var services []string
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		matched := false
+		for _, name := range p.Services {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				matched = true
+				if !seen[name] {
+					seen[name] = true
+					services = append(services, name)
+				}
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no service matches %q (services: %s)", pattern, strings.Join(p.Services, ", "))
+		}
+	}
+	return services, nil
+}
+
+// composeContainer is one entry of 'docker compose ps --format json'
+type composeContainer struct {
+	Name       string
+	Service    string
+	State      string
+	Health     string
+	ExitCode   int
+	Publishers []struct {
+		URL           string
+		TargetPort    int
+		PublishedPort int
+		Protocol      string
+	}
+}
+
+// parseComposePs reads 'docker compose ps --format json', which is one
+// object per line in newer Compose releases and an array in older ones
+func parseComposePs(data []byte) ([]composeContainer, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var containers []composeContainer
+	if data[0] == '[' {
+		err := json.Unmarshal(data, &containers)
+		return containers, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var c composeContainer
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return nil, err
+		}
+		containers = append(containers, c)
+	}
+	return containers, scanner.Err()
+}
+
+// composeStatus prints each service's state and health, and a summary
+func composeStatus(p *composeProject, services []string) error {
+	out, err := composeOutput(p.args("ps", "--all", "--format", "json")...)
+	if err != nil {
+		return fmt.Errorf("docker compose ps: %w", err)
+	}
+	containers, err := parseComposePs(out)
+	if err != nil {
+		return fmt.Errorf("reading docker compose ps: %w", err)
+	}
+	if len(services) == 0 {
+		services = p.Services
+	}
+
+	PrintHeader("Compose: " + tildePath(p.File))
+	for _, line := range composeStatusLines(services, containers) {
+		fmt.Println(line)
+	}
+	fmt.Println()
+	fmt.Println(composeStatusSummary(services, containers))
+	return nil
+}
+
+// composeStatusLines renders one line per container, and one per service
+// that has none
+func composeStatusLines(services []string, containers []composeContainer) []string {
+	byService := make(map[string][]composeContainer)
+	for _, c := range containers {
+		byService[c.Service] = append(byService[c.Service], c)
+	}
+	var lines []string
+	for _, service := range services {
+		if len(byService[service]) == 0 {
+			lines = append(lines, fmt.Sprintf("  %s %-20s %s", Dim.Sprint("-"), service, Dim.Sprint("not created")))
+			continue
+		}
+		for _, c := range byService[service] {
+			var mark, state string
+			switch {
+			case c.State != "running":
+				mark, state = Red.Sprint("✗"), fmt.Sprintf("%s (%d)", c.State, c.ExitCode)
+			case c.Health == "unhealthy":
+				mark, state = Red.Sprint("✗"), "running, unhealthy"
+			case c.Health == "starting":
+				mark, state = Yellow.Sprint("!"), "running, starting"
+			case c.Health == "healthy":
+				mark, state = Green.Sprint("✓"), "running, healthy"
+			default:
+				mark, state = Green.Sprint("✓"), "running"
+			}
+			var ports []string
+			for _, pub := range c.Publishers {
+				if pub.PublishedPort > 0 {
+					ports = append(ports, fmt.Sprintf("%d→%d/%s", pub.PublishedPort, pub.TargetPort, pub.Protocol))
+				}
+			}
+			lines = append(lines, strings.TrimRight(fmt.Sprintf("  %s %-20s %-22s %s", mark, service, state, Dim.Sprint(strings.Join(ports, " "))), " "))
+		}
+	}
+	return lines
+}
+
+// composeStatusSummary counts containers by state and health
+func composeStatusSummary(services []string, containers []composeContainer) string {
+	wanted := make(map[string]bool)
+	for _, s := range services {
+		wanted[s] = true
+	}
+	created := make(map[string]bool)
+	var running, healthy, unhealthy, starting, stopped int
+	for _, c := range containers {
+		if !wanted[c.Service] {
+			continue
+		}
+		created[c.Service] = true
+		if c.State != "running" {
+			stopped++
+			continue
+		}
+		running++
+		switch c.Health {
+		case "healthy":
+			healthy++
+		case "unhealthy":
+			unhealthy++
+		case "starting":
+			starting++
+		}
+	}
+
+	summary := fmt.Sprintf("%d running", running)
+	var health []string
+	for _, h := range []struct {
+		n     int
+		label string
+	}{{healthy, "healthy"}, {unhealthy, "unhealthy"}, {starting, "starting"}} {
+		if h.n > 0 {
+			health = append(health, fmt.Sprintf("%d %s", h.n, h.label))
+		}
+	}
+	if len(health) > 0 {
+		summary += " (" + strings.Join(health, ", ") + ")"
+	}
+	if stopped > 0 {
+		summary += fmt.Sprintf(", %d stopped", stopped)
+	}
+	if missing := len(services) - len(created); missing > 0 {
+		summary += fmt.Sprintf(", %d not created", missing)
+	}
+	return summary
+}
+
+// parseEnvFile reads KEY=value lines, ignoring comments and "export"
+func parseEnvFile(path string) (map[string]string, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make(map[string]string)
+	var keys []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if _, dup := values[key]; !dup {
+			keys = append(keys, key)
+		}
+		values[key] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return values, keys, nil
+}
+
+// composeEnvReport is the result of checking a project's env files
+type composeEnvReport struct {
+	Missing  []string // in .env.example, not in .env
+	Empty    []string // set to "" in .env
+	Extra    []string // in .env, not in .env.example
+	NoEnv    bool     // .env.example exists but .env does not
+	EnvFiles []string // env_file entries that do not exist, as "service: path"
+	Unset    []string // compose variables nobody sets
+}
+
+func (r *composeEnvReport) problems() []string {
+	var problems []string
+	if r.NoEnv {
+		problems = append(problems, "no .env; copy .env.example or run 'compose env --init'")
+	}
+	if len(r.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf(".env is missing %s from .env.example", strings.Join(r.Missing, ", ")))
+	}
+	for _, f := range r.EnvFiles {
+		problems = append(problems, "env_file not found: "+f)
+	}
+	if len(r.Unset) > 0 {
+		problems = append(problems, fmt.Sprintf("compose file uses unset variables: %s", strings.Join(r.Unset, ", ")))
+	}
+	return problems
+}
+
+// checkComposeEnvFiles compares .env with .env.example in the compose
+// directory and looks for env files and variables the project needs
+func checkComposeEnvFiles(p *composeProject) *composeEnvReport {
+	r := &composeEnvReport{}
+	env, envKeys, envErr := parseEnvFile(filepath.Join(p.Dir, ".env"))
+	example, exampleKeys, exampleErr := parseEnvFile(filepath.Join(p.Dir, ".env.example"))
+
+	if exampleErr == nil {
+		if envErr != nil {
+			r.NoEnv = true
+		} else {
+			for _, key := range exampleKeys {
+				if _, ok := env[key]; !ok {
+					r.Missing = append(r.Missing, key)
+				}
+			}
+			for _, key := range envKeys {
+				if _, ok := example[key]; !ok {
+					r.Extra = append(r.Extra, key)
+				}
+			}
+		}
+	}
+	for _, key := range envKeys {
+		if env[key] == "" {
+			r.Empty = append(r.Empty, key)
+		}
+	}
+
+	for _, service := range p.Services {
+		for _, f := range p.EnvFiles[service] {
+			path := f
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(p.Dir, f)
+			}
+			if !fileExists(path) {
+				r.EnvFiles = append(r.EnvFiles, service+": "+f)
+			}
+		}
+	}
+
+	for _, name := range p.Vars {
+		if _, ok := env[name]; ok {
+			continue
+		}
+		if _, ok := os.LookupEnv(name); !ok {
+			r.Unset = append(r.Unset, name)
+		}
+	}
+	return r
+}
+
+// checkComposeEnv returns the problems that would break 'compose up'
+func checkComposeEnv(p *composeProject) []string {
+	return checkComposeEnvFiles(p).problems()
+}
+
+func printComposeEnv(p *composeProject) error {
+	r := checkComposeEnvFiles(p)
+	PrintHeader("Compose Environment")
+	fmt.Println(Dim.Sprintf("Compose file: %s", tildePath(p.File)))
+	fmt.Println()
+
+	hasExample := fileExists(filepath.Join(p.Dir, ".env.example"))
+	switch {
+	case r.NoEnv:
+		Fail(".env not found (run with --init to create it from .env.example)")
+	case len(r.Missing) > 0:
+		Fail(".env is missing: %s", strings.Join(r.Missing, ", "))
+	case hasExample:
+		Pass(".env has every key in .env.example")
+	default:
+		Info("No .env.example to check .env against")
+	}
+	if len(r.Empty) > 0 {
+		Warn("Empty in .env: %s", strings.Join(r.Empty, ", "))
+	}
+	if len(r.Extra) > 0 {
+		Info("Not in .env.example: %s", strings.Join(r.Extra, ", "))
+	}
+	for _, f := range r.EnvFiles {
+		Fail("env_file not found: %s", f)
+	}
+	if len(r.Unset) > 0 {
+		Fail("Unset variables used by the compose file: %s", strings.Join(r.Unset, ", "))
+	} else if len(p.Vars) > 0 {
+		Pass("All %d variables used by the compose file are set", len(p.Vars))
+	}
+
+	if problems := r.problems(); len(problems) > 0 {
+		return fmt.Errorf("%d environment problem(s)", len(problems))
+	}
+	return nil
+}
+
+// initComposeEnv creates .env from .env.example in dir, or appends the
+// keys .env lacks with their example values
+func initComposeEnv(dir string) error {
+	examplePath, envPath := filepath.Join(dir, ".env.example"), filepath.Join(dir, ".env")
+	example, exampleKeys, err := parseEnvFile(examplePath)
+	if err != nil {
+		return fmt.Errorf("no .env.example in %s", tildePath(dir))
+	}
+
+	env, _, err := parseEnvFile(envPath)
+	if err != nil {
+		data, err := os.ReadFile(examplePath)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(envPath, data, 0600); err != nil {
+			return err
+		}
+		Pass("Created %s from .env.example", tildePath(envPath))
+		return nil
+	}
+
+	var missing []string
+	for _, key := range exampleKeys {
+		if _, ok := env[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(envPath)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.Write(data)
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		b.WriteString("\n")
+	}
+	b.WriteString("\n# Added from .env.example by blackdot\n")
+	for _, key := range missing {
+		fmt.Fprintf(&b, "%s=%s\n", key, example[key])
+	}
+	info, err := os.Stat(envPath)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(envPath, []byte(b.String()), info.Mode().Perm()); err != nil {
+		return err
+	}
+	Pass("Added %s to %s", strings.Join(missing, ", "), tildePath(envPath))
+	return nil
+}
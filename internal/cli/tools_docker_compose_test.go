@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseComposeProject verifies services, env files and variables
+// without defaults are read from the compose file
+func TestParseComposeProject(t *testing.T) {
+	p, err := parseComposeProject([]byte(`services:
+  web:
+    image: "nginx:${NGINX_TAG:-latest}"
+    env_file: .env
+    ports: ["${WEB_PORT}:80"]
+  db:
+    image: postgres
+    environment:
+      POSTGRES_PASSWORD: $DB_PASSWORD
+      LITERAL: "$$HOME"
+    env_file:
+      - db.env
+      - path: optional.env
+        required: false
+  # cache uses ${COMMENTED}
+  cache:
+    image: redis
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(p.Services, " "); got != "cache db web" {
+		t.Errorf("services = %s", got)
+	}
+	if got := strings.Join(p.EnvFiles["db"], " "); got != "db.env" {
+		t.Errorf("db env files = %s", got)
+	}
+	if got := strings.Join(p.Vars, " "); got != "DB_PASSWORD WEB_PORT" {
+		t.Errorf("vars = %s", got)
+	}
+}
+
+// TestResolveComposeServices verifies names and globs are expanded and
+// unknown services are rejected
+func TestResolveComposeServices(t *testing.T) {
+	p := &composeProject{Services: []string{"api", "db-primary", "db-replica"}}
+	got, err := p.resolveServices([]string{"db*", "api", "db-primary"})
+	if err != nil || strings.Join(got, " ") != "db-primary db-replica api" {
+		t.Errorf("resolveServices = %v, %v", got, err)
+	}
+	if _, err := p.resolveServices([]string{"web"}); err == nil || !strings.Contains(err.Error(), "api, db-primary, db-replica") {
+		t.Errorf("unknown service error = %v", err)
+	}
+}
+
+// TestFindComposeFile verifies the compose file is found from a
+// subdirectory, falling back to the devcontainer one
+func TestFindComposeFile(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "src", "pkg")
+	os.MkdirAll(sub, 0755)
+	os.MkdirAll(filepath.Join(root, ".devcontainer"), 0755)
+	os.WriteFile(filepath.Join(root, ".devcontainer", "docker-compose.yml"), []byte("services: {}\n"), 0644)
+
+	if got := findComposeFile(sub); got != filepath.Join(root, ".devcontainer", "docker-compose.yml") {
+		t.Errorf("devcontainer fallback = %s", got)
+	}
+	os.WriteFile(filepath.Join(root, "compose.yaml"), []byte("services: {}\n"), 0644)
+	if got := findComposeFile(sub); got != filepath.Join(root, "compose.yaml") {
+		t.Errorf("project compose file = %s", got)
+	}
+}
+
+// TestComposeStatus verifies both ps output formats are read and health
+// is summarised per service
+func TestComposeStatus(t *testing.T) {
+	lines := `{"Name":"app-api-1","Service":"api","State":"running","Health":"healthy","Publishers":[{"URL":"0.0.0.0","TargetPort":80,"PublishedPort":8080,"Protocol":"tcp"}]}
+{"Name":"app-db-1","Service":"db","State":"running","Health":"unhealthy"}
+{"Name":"app-worker-1","Service":"worker","State":"exited","ExitCode":1}`
+	containers, err := parseComposePs([]byte(lines))
+	if err != nil || len(containers) != 3 {
+		t.Fatalf("parseComposePs = %d containers, %v", len(containers), err)
+	}
+	array, err := parseComposePs([]byte(`[{"Service":"api","State":"running"}]`))
+	if err != nil || len(array) != 1 || array[0].Service != "api" {
+		t.Errorf("array format = %v, %v", array, err)
+	}
+
+	services := []string{"api", "cache", "db", "worker"}
+	if got := composeStatusSummary(services, containers); got != "2 running (1 healthy, 1 unhealthy), 1 stopped, 1 not created" {
+		t.Errorf("summary = %s", got)
+	}
+	out := strings.Join(composeStatusLines(services, containers), "\n")
+	for _, want := range []string{"running, healthy", "8080→80/tcp", "not created", "running, unhealthy", "exited (1)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("status lines missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestComposeEnv verifies .env is checked against .env.example and --init
+// adds the missing keys without touching existing values
+func TestComposeEnv(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env.example"), []byte("# Database\nDB_PASSWORD=changeme\nAPI_KEY=\nWEB_PORT=8080\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("DB_PASSWORD=secret\nAPI_KEY=\nLOCAL_ONLY=1"), 0600)
+	t.Setenv("WEB_PORT", "")
+	os.Unsetenv("WEB_PORT")
+	p := &composeProject{
+		Dir:      dir,
+		Services: []string{"db"},
+		EnvFiles: map[string][]string{"db": {"db.env"}},
+		Vars:     []string{"DB_PASSWORD", "WEB_PORT"},
+	}
+
+	r := checkComposeEnvFiles(p)
+	if strings.Join(r.Missing, " ") != "WEB_PORT" || strings.Join(r.Empty, " ") != "API_KEY" || strings.Join(r.Extra, " ") != "LOCAL_ONLY" {
+		t.Errorf("missing %v, empty %v, extra %v", r.Missing, r.Empty, r.Extra)
+	}
+	if strings.Join(r.EnvFiles, " ") != "db: db.env" || strings.Join(r.Unset, " ") != "WEB_PORT" {
+		t.Errorf("env files %v, unset %v", r.EnvFiles, r.Unset)
+	}
+
+	if err := initComposeEnv(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, ".env"))
+	if !strings.HasPrefix(string(data), "DB_PASSWORD=secret\nAPI_KEY=\nLOCAL_ONLY=1\n") || !strings.HasSuffix(string(data), "WEB_PORT=8080\n") {
+		t.Errorf(".env after init:\n%s", data)
+	}
+	os.WriteFile(filepath.Join(dir, "db.env"), nil, 0600)
+	if problems := checkComposeEnv(p); len(problems) != 0 {
+		t.Errorf("problems after init: %v", problems)
+	}
+}
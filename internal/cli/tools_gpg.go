@@ -0,0 +1,727 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// gpgDefaultVaultItem is the vault item holding the armored secret keyring
+const gpgDefaultVaultItem = "GPG-Secret-Keys"
+
+// gpgKey is a key parsed from gpg --with-colons output
+type gpgKey struct {
+	KeyID       string
+	Fingerprint string
+	Algo        string
+	Bits        int
+	Created     time.Time
+	Expires     time.Time // zero when the key never expires
+	UIDs        []string
+	Secret      bool
+	Validity    string // gpg validity field: u=ultimate, f=full, r=revoked, e=expired
+}
+
+// newToolsGPGCmd creates the gpg tools subcommand
+func newToolsGPGCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gpg",
+		Short: "GPG key management",
+		Long: `GPG key management tools.
+
+Wraps gpg for day-to-day key management and keeps the secret keyring
+in the vault so it can be restored on a new machine.
+
+Commands:
+  keys      - List keys with expiry and secret-key status
+  gen       - Generate a new key (ed25519 by default)
+  export    - Export a public key (armored)
+  import    - Import public keys from a file or stdin
+  push      - Store the secret keyring in the vault
+  restore   - Import the secret keyring from the vault
+  status    - Show gpg, agent, and pinentry status`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGPGStatus()
+		},
+	}
+
+	cmd.AddCommand(
+		newGPGKeysCmd(),
+		newGPGGenCmd(),
+		newGPGExportCmd(),
+		newGPGImportCmd(),
+		newGPGPushCmd(),
+		newGPGRestoreCmd(),
+		newGPGStatusCmd(),
+	)
+
+	return cmd
+}
+
+// newGPGKeysCmd lists keys
+func newGPGKeysCmd() *cobra.Command {
+	var secretOnly bool
+
+	cmd := &cobra.Command{
+		Use:     "keys",
+		Aliases: []string{"list", "ls"},
+		Short:   "List GPG keys",
+		Long: `List GPG keys with key ID, algorithm, expiry, and user IDs.
+
+Keys with a secret part on this machine are marked with "sec".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGPGKeys(secretOnly)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&secretOnly, "secret", "s", false, "Only show keys with a local secret key")
+
+	return cmd
+}
+
+// newGPGGenCmd generates a new key
+func newGPGGenCmd() *cobra.Command {
+	var algo, expire string
+	var noPassphrase bool
+
+	cmd := &cobra.Command{
+		Use:   "gen <name> <email>",
+		Short: "Generate a new GPG key",
+		Long: `Generate a new GPG key with gpg --quick-generate-key.
+
+Examples:
+  blackdot tools gpg gen "Jane Doe" jane@example.com
+  blackdot tools gpg gen "CI Signer" ci@example.com --expire 1y --no-passphrase`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGPGGen(args[0], args[1], algo, expire, noPassphrase)
+		},
+	}
+
+	cmd.Flags().StringVar(&algo, "algo", "ed25519", "Key algorithm (ed25519, rsa4096, ...)")
+	cmd.Flags().StringVar(&expire, "expire", "2y", "Expiry (e.g. 1y, 6m, never)")
+	cmd.Flags().BoolVar(&noPassphrase, "no-passphrase", false, "Generate key without passphrase")
+
+	return cmd
+}
+
+// newGPGExportCmd exports a public key
+func newGPGExportCmd() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "export <key-id|email>",
+		Short: "Export a public key (armored)",
+		Long: `Export a public key in ASCII-armored form, e.g. to paste into GitHub.
+
+Writes to stdout unless --output is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGPGExport(args[0], output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write to file instead of stdout")
+
+	return cmd
+}
+
+// newGPGImportCmd imports public keys
+func newGPGImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import public keys",
+		Long: `Import public keys from a file, or from stdin when no file (or "-") is given.
+
+Examples:
+  blackdot tools gpg import colleague.asc
+  curl -s https://github.com/user.gpg | blackdot tools gpg import`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file := "-"
+			if len(args) > 0 {
+				file = args[0]
+			}
+			return runGPGImport(file)
+		},
+	}
+}
+
+// newGPGPushCmd stores the secret keyring in the vault
+func newGPGPushCmd() *cobra.Command {
+	var item string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "push [key-id...]",
+		Short: "Store secret keys in the vault",
+		Long: `Export secret keys (armored) and owner trust, and store them as a
+single vault item. With no key IDs, all local secret keys are exported.
+
+The default item name is ` + gpgDefaultVaultItem + `.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGPGPush(item, args, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&item, "item", gpgDefaultVaultItem, "Vault item name")
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be stored")
+
+	return cmd
+}
+
+// newGPGRestoreCmd imports the secret keyring from the vault
+func newGPGRestoreCmd() *cobra.Command {
+	var item string
+
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Import secret keys from the vault",
+		Long: `Import the armored secret keyring and owner trust stored by
+'blackdot tools gpg push'. Existing keys are merged, not replaced.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGPGRestore(item)
+		},
+	}
+
+	cmd.Flags().StringVar(&item, "item", gpgDefaultVaultItem, "Vault item name")
+
+	return cmd
+}
+
+// newGPGStatusCmd shows gpg status
+func newGPGStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show gpg, agent, and pinentry status",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGPGStatus()
+		},
+	}
+}
+
+// gpgBinary returns the gpg executable (gpg2 on older systems)
+func gpgBinary() (string, error) {
+	for _, name := range []string{"gpg", "gpg2"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("gpg not found in PATH")
+}
+
+// gpgHomeDir returns the GnuPG home directory
+func gpgHomeDir() string {
+	if dir := os.Getenv("GNUPGHOME"); dir != "" {
+		return dir
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gnupg")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".gnupg")
+}
+
+// runGPGOutput runs gpg and returns stdout
+func runGPGOutput(args ...string) ([]byte, error) {
+	gpg, err := gpgBinary()
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(gpg, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return out, fmt.Errorf("gpg %s: %s", args[0], msg)
+		}
+		return out, fmt.Errorf("gpg %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// parseGPGColons parses gpg --with-colons key listings. Secret listings
+// (sec records) mark keys as Secret.
+func parseGPGColons(r io.Reader) []gpgKey {
+	var keys []gpgKey
+	var current *gpgKey
+	expectFpr := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 10 {
+			continue
+		}
+		switch fields[0] {
+		case "pub", "sec":
+			keys = append(keys, gpgKey{
+				KeyID:    fields[4],
+				Algo:     gpgAlgoName(fields[3]),
+				Validity: fields[1],
+				Secret:   fields[0] == "sec",
+				Created:  parseGPGTime(fields[5]),
+				Expires:  parseGPGTime(fields[6]),
+			})
+			current = &keys[len(keys)-1]
+			current.Bits, _ = strconv.Atoi(fields[2])
+			if len(fields) > 16 && fields[16] != "" {
+				current.Algo = fields[16]
+			}
+			expectFpr = true
+		case "sub", "ssb":
+			expectFpr = false
+		case "fpr":
+			if current != nil && expectFpr {
+				current.Fingerprint = fields[9]
+				expectFpr = false
+			}
+		case "uid":
+			if current != nil {
+				current.UIDs = append(current.UIDs, fields[9])
+			}
+		}
+	}
+	return keys
+}
+
+// parseGPGTime parses an epoch (or ISO 8601) timestamp field
+func parseGPGTime(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0)
+	}
+	if t, err := time.Parse("20060102T150405", s); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// gpgAlgoName maps OpenPGP public key algorithm IDs to names
+func gpgAlgoName(id string) string {
+	switch id {
+	case "1", "2", "3":
+		return "rsa"
+	case "17":
+		return "dsa"
+	case "18":
+		return "ecdh"
+	case "19":
+		return "ecdsa"
+	case "22":
+		return "eddsa"
+	default:
+		return id
+	}
+}
+
+// listGPGKeys returns public keys, marking those with a local secret key
+func listGPGKeys() ([]gpgKey, error) {
+	pubOut, err := runGPGOutput("--list-keys", "--with-colons", "--fixed-list-mode")
+	if err != nil {
+		return nil, err
+	}
+	keys := parseGPGColons(bytes.NewReader(pubOut))
+
+	if secOut, err := runGPGOutput("--list-secret-keys", "--with-colons", "--fixed-list-mode"); err == nil {
+		secret := make(map[string]bool)
+		for _, k := range parseGPGColons(bytes.NewReader(secOut)) {
+			secret[k.Fingerprint] = true
+		}
+		for i := range keys {
+			keys[i].Secret = secret[keys[i].Fingerprint]
+		}
+	}
+	return keys, nil
+}
+
+func runGPGKeys(secretOnly bool) error {
+	keys, err := listGPGKeys()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("GPG Keys in %s:\n", gpgHomeDir())
+	fmt.Println("──────────────────────────────────────")
+
+	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
+	red := color.New(color.FgRed)
+	dim := color.New(color.Faint)
+
+	shown := 0
+	for _, k := range keys {
+		if secretOnly && !k.Secret {
+			continue
+		}
+		shown++
+
+		marker := "pub"
+		if k.Secret {
+			marker = green.Sprint("sec")
+		}
+
+		expiry := dim.Sprint("never expires")
+		switch {
+		case k.Validity == "r":
+			expiry = red.Sprint("revoked")
+		case !k.Expires.IsZero() && k.Expires.Before(time.Now()):
+			expiry = red.Sprintf("expired %s", k.Expires.Format("2006-01-02"))
+		case !k.Expires.IsZero() && time.Until(k.Expires) < 30*24*time.Hour:
+			expiry = yellow.Sprintf("expires %s", k.Expires.Format("2006-01-02"))
+		case !k.Expires.IsZero():
+			expiry = dim.Sprintf("expires %s", k.Expires.Format("2006-01-02"))
+		}
+
+		uid := ""
+		if len(k.UIDs) > 0 {
+			uid = k.UIDs[0]
+		}
+		fmt.Printf("  %s %s %-8s %s\n", marker, k.KeyID, k.Algo, expiry)
+		fmt.Printf("      %s\n", uid)
+	}
+
+	if shown == 0 {
+		fmt.Println("  No GPG keys found")
+	}
+	fmt.Println()
+	return nil
+}
+
+func runGPGGen(name, email, algo, expire string, noPassphrase bool) error {
+	gpg, err := gpgBinary()
+	if err != nil {
+		return err
+	}
+
+	uid := fmt.Sprintf("%s <%s>", name, email)
+	if expire == "never" {
+		expire = "0"
+	}
+
+	args := []string{}
+	if noPassphrase {
+		args = append(args, "--batch", "--pinentry-mode", "loopback", "--passphrase", "")
+	}
+	args = append(args, "--quick-generate-key", uid, algo, "default", expire)
+
+	fmt.Printf("Generating %s key: %s\n", algo, uid)
+	cmd := exec.Command(gpg, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("gpg key generation failed: %w", err)
+	}
+
+	fmt.Println()
+	fmt.Println("Key generated successfully!")
+	fmt.Println("Store it in the vault with: blackdot tools gpg push")
+	return nil
+}
+
+func runGPGExport(keyID, output string) error {
+	out, err := runGPGOutput("--armor", "--export", keyID)
+	if err != nil {
+		return err
+	}
+	if len(out) == 0 {
+		return fmt.Errorf("no public key found for %s", keyID)
+	}
+
+	if output == "" {
+		fmt.Print(string(out))
+		return nil
+	}
+	if err := writeFileWithPolicy(expandPath(output), out, fileClassConfig); err != nil {
+		return fmt.Errorf("writing %s: %w", output, err)
+	}
+	Pass("Exported public key for %s to %s", keyID, output)
+	return nil
+}
+
+func runGPGImport(file string) error {
+	gpg, err := gpgBinary()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(gpg, "--import")
+	if file == "-" {
+		cmd.Stdin = os.Stdin
+	} else {
+		f, err := os.Open(expandPath(file))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		cmd.Stdin = f
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("gpg import failed: %w", err)
+	}
+	return nil
+}
+
+// gpgKeyringBundle joins the armored secret keys and ownertrust into one
+// vault item. The trust section follows a marker line so restore can split
+// them again.
+const gpgTrustMarker = "# blackdot:ownertrust"
+
+func gpgKeyringBundle(secretKeys, ownerTrust []byte) string {
+	var b strings.Builder
+	b.Write(bytes.TrimSpace(secretKeys))
+	b.WriteString("\n")
+	if trust := bytes.TrimSpace(ownerTrust); len(trust) > 0 {
+		b.WriteString(gpgTrustMarker + "\n")
+		b.Write(trust)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// splitGPGKeyringBundle returns the armored keys and ownertrust sections
+func splitGPGKeyringBundle(content string) (keys, trust string) {
+	if i := strings.Index(content, gpgTrustMarker+"\n"); i >= 0 {
+		return content[:i], content[i+len(gpgTrustMarker)+1:]
+	}
+	return content, ""
+}
+
+func runGPGPush(item string, keyIDs []string, dryRun bool) error {
+	args := append([]string{"--armor", "--export-secret-keys"}, keyIDs...)
+	secretKeys, err := runGPGOutput(args...)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(secretKeys)) == 0 {
+		return errors.New("no secret keys to export")
+	}
+	ownerTrust, err := runGPGOutput("--export-ownertrust")
+	if err != nil {
+		Warn("Could not export owner trust: %v", err)
+	}
+	content := gpgKeyringBundle(secretKeys, ownerTrust)
+
+	if dryRun {
+		Info("Would store %d bytes of armored secret keys in '%s'", len(content), item)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	backend, session, err := openGPGVaultBackend(ctx)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	existing, err := backend.GetNotes(ctx, item, session)
+	switch {
+	case errors.Is(err, vaultmux.ErrNotFound):
+		if err := backend.CreateItem(ctx, item, content, session); err != nil {
+			Fail("Failed to create '%s': %v", item, err)
+			return err
+		}
+		Pass("Created '%s' in vault", item)
+	case err != nil:
+		Fail("Failed to read '%s': %v", item, err)
+		return err
+	case existing == content:
+		Pass("Already in sync: %s", item)
+	default:
+		if err := backend.UpdateItem(ctx, item, content, session); err != nil {
+			Fail("Failed to update '%s': %v", item, err)
+			return err
+		}
+		Pass("Updated '%s' in vault", item)
+	}
+	return nil
+}
+
+func runGPGRestore(item string) error {
+	gpg, err := gpgBinary()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	backend, session, err := openGPGVaultBackend(ctx)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	content, err := backend.GetNotes(ctx, item, session)
+	if err != nil {
+		if errors.Is(err, vaultmux.ErrNotFound) {
+			Fail("Vault item not found: %s", item)
+			fmt.Println("Store your keys first with: blackdot tools gpg push")
+		} else {
+			Fail("Failed to read '%s': %v", item, err)
+		}
+		return err
+	}
+
+	keys, trust := splitGPGKeyringBundle(content)
+
+	cmd := exec.Command(gpg, "--batch", "--import")
+	cmd.Stdin = strings.NewReader(keys)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		Fail("gpg import failed: %v", err)
+		return err
+	}
+
+	if strings.TrimSpace(trust) != "" {
+		cmd := exec.Command(gpg, "--import-ownertrust")
+		cmd.Stdin = strings.NewReader(trust)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			Warn("Failed to import owner trust: %v", err)
+		}
+	}
+
+	Pass("Restored GPG keys from '%s'", item)
+	return nil
+}
+
+// openGPGVaultBackend connects to the vault for keyring push/restore
+func openGPGVaultBackend(ctx context.Context) (vaultmux.Backend, vaultmux.Session, error) {
+	backend, err := newVaultBackend()
+	if err != nil {
+		Fail("Failed to create backend: %v", err)
+		return nil, nil, err
+	}
+	if err := backend.Init(ctx); err != nil {
+		backend.Close()
+		Fail("Backend not available: %v", err)
+		return nil, nil, err
+	}
+	session, err := backend.Authenticate(ctx)
+	if err != nil {
+		backend.Close()
+		Fail("Authentication required: %v", err)
+		return nil, nil, err
+	}
+	return backend, session, nil
+}
+
+// gpgAgentRunning reports whether gpg-agent answers on its socket
+func gpgAgentRunning() bool {
+	if _, err := exec.LookPath("gpg-connect-agent"); err != nil {
+		return false
+	}
+	cmd := exec.Command("gpg-connect-agent", "--no-autostart", "/bye")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return false
+	}
+	return !strings.Contains(stderr.String(), "no gpg-agent running")
+}
+
+// gpgPinentryProgram returns the pinentry-program configured in
+// gpg-agent.conf, or "" when gpg-agent uses its default
+func gpgPinentryProgram() string {
+	data, err := os.ReadFile(filepath.Join(gpgHomeDir(), "gpg-agent.conf"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "pinentry-program" {
+			return strings.Join(fields[1:], " ")
+		}
+	}
+	return ""
+}
+
+// gpgDefaultPinentryAvailable reports whether any pinentry is on PATH
+func gpgDefaultPinentryAvailable() bool {
+	candidates := []string{"pinentry", "pinentry-mac", "pinentry-curses", "pinentry-tty", "pinentry-gnome3", "pinentry-qt"}
+	for _, name := range candidates {
+		if _, err := exec.LookPath(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func runGPGStatus() error {
+	bold := color.New(color.Bold)
+	dim := color.New(color.Faint)
+	green := color.New(color.FgGreen)
+	red := color.New(color.FgRed)
+	yellow := color.New(color.FgYellow)
+
+	fmt.Println()
+	bold.Println("  GPG Status")
+	dim.Println("  ───────────────────────────────────────")
+
+	gpg, err := gpgBinary()
+	if err != nil {
+		fmt.Printf("    %s       %s\n", dim.Sprint("gpg"), red.Sprint("✗ not installed"))
+		fmt.Println()
+		return nil
+	}
+	version := ""
+	if out, err := exec.Command(gpg, "--version").Output(); err == nil {
+		version = strings.SplitN(string(out), "\n", 2)[0]
+	}
+	fmt.Printf("    %s       %s %s\n", dim.Sprint("gpg"), green.Sprint("●"), version)
+	fmt.Printf("    %s      %s\n", dim.Sprint("Home"), gpgHomeDir())
+
+	if gpgAgentRunning() {
+		fmt.Printf("    %s     %s\n", dim.Sprint("Agent"), green.Sprint("● running"))
+	} else {
+		fmt.Printf("    %s     %s %s\n", dim.Sprint("Agent"), yellow.Sprint("○ not running"), dim.Sprint("(gpgconf --launch gpg-agent)"))
+	}
+
+	if pinentry := gpgPinentryProgram(); pinentry != "" {
+		if _, err := os.Stat(pinentry); err == nil {
+			fmt.Printf("    %s  %s\n", dim.Sprint("Pinentry"), pinentry)
+		} else {
+			fmt.Printf("    %s  %s %s\n", dim.Sprint("Pinentry"), red.Sprint("✗ missing"), dim.Sprint(pinentry))
+		}
+	} else if gpgDefaultPinentryAvailable() {
+		fmt.Printf("    %s  %s\n", dim.Sprint("Pinentry"), dim.Sprint("default"))
+	} else {
+		fmt.Printf("    %s  %s\n", dim.Sprint("Pinentry"), yellow.Sprint("○ none found"))
+	}
+
+	if keys, err := listGPGKeys(); err == nil {
+		secret := 0
+		for _, k := range keys {
+			if k.Secret {
+				secret++
+			}
+		}
+		fmt.Printf("    %s      %d public, %d secret\n", dim.Sprint("Keys"), len(keys), secret)
+	}
+	fmt.Println()
+	return nil
+}
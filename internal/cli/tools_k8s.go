@@ -0,0 +1,741 @@
+// Package cli implements the blackdot command-line interface using Cobra.
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// newToolsK8sCmd creates the k8s tools subcommand
+func newToolsK8sCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "k8s",
+		Aliases: []string{"kube"},
+		Short:   "Kubernetes kubeconfig management",
+		Long: `Kubernetes kubeconfig management tools.
+
+Reads and edits kubeconfig files directly, so kubectl is not required.
+KUBECONFIG is honored; edits go to the file that defines the entry, or the
+first file in the list.
+
+Commands:
+  contexts  - List contexts with cluster, user, and namespace
+  use       - Switch the current context
+  ns        - Show or set the namespace of a context
+  merge     - Merge other kubeconfig files into yours
+  status    - Show kubeconfig status banner
+
+Vault:
+  Add ~/.kube/config to vault-items.json with "type": "kubeconfig".
+  On push, certificate/key file paths are inlined and cached
+  auth-provider tokens are stripped, so the item restores anywhere.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runK8sStatus()
+		},
+	}
+
+	cmd.AddCommand(
+		newK8sContextsCmd(),
+		newK8sUseCmd(),
+		newK8sNsCmd(),
+		newK8sMergeCmd(),
+		newK8sStatusCmd(),
+	)
+
+	return cmd
+}
+
+// kubeconfig is the subset of a kubeconfig file blackdot reads
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server   string `yaml:"server"`
+			CA       string `yaml:"certificate-authority"`
+			CAData   string `yaml:"certificate-authority-data"`
+			Insecure bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+	} `yaml:"users"`
+}
+
+// k8sContext is a context resolved across all kubeconfig files
+type k8sContext struct {
+	Name      string
+	Cluster   string
+	Server    string
+	User      string
+	Namespace string
+	File      string
+}
+
+// kubeconfigPaths returns the kubeconfig files in KUBECONFIG order, or
+// ~/.kube/config
+func kubeconfigPaths() []string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		var files []string
+		for _, p := range filepath.SplitList(env) {
+			if p != "" {
+				files = append(files, expandPath(p))
+			}
+		}
+		if len(files) > 0 {
+			return files
+		}
+	}
+	home, _ := os.UserHomeDir()
+	return []string{filepath.Join(home, ".kube", "config")}
+}
+
+// loadKubeconfigs reads every kubeconfig file with kubectl merge semantics:
+// the first current-context wins and the first definition of a name wins.
+func loadKubeconfigs() (current string, contexts []k8sContext, err error) {
+	servers := make(map[string]string)
+	seen := make(map[string]bool)
+	found := false
+
+	for _, path := range kubeconfigPaths() {
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			continue
+		}
+		found = true
+
+		var kc kubeconfig
+		if err := yaml.Unmarshal(data, &kc); err != nil {
+			return "", nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if current == "" {
+			current = kc.CurrentContext
+		}
+		for _, c := range kc.Clusters {
+			if _, ok := servers[c.Name]; !ok {
+				servers[c.Name] = c.Cluster.Server
+			}
+		}
+		for _, c := range kc.Contexts {
+			if seen[c.Name] {
+				continue
+			}
+			seen[c.Name] = true
+			contexts = append(contexts, k8sContext{
+				Name:      c.Name,
+				Cluster:   c.Context.Cluster,
+				User:      c.Context.User,
+				Namespace: c.Context.Namespace,
+				File:      path,
+			})
+		}
+	}
+
+	if !found {
+		return "", nil, fmt.Errorf("no kubeconfig found at %s", strings.Join(kubeconfigPaths(), string(os.PathListSeparator)))
+	}
+	for i := range contexts {
+		contexts[i].Server = servers[contexts[i].Cluster]
+	}
+	return current, contexts, nil
+}
+
+// findK8sContext returns a context by name
+func findK8sContext(contexts []k8sContext, name string) *k8sContext {
+	for i := range contexts {
+		if contexts[i].Name == name {
+			return &contexts[i]
+		}
+	}
+	return nil
+}
+
+// completeK8sContexts completes context names
+func completeK8sContexts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	_, contexts, err := loadKubeconfigs()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, c := range contexts {
+		if strings.HasPrefix(c.Name, toComplete) {
+			names = append(names, c.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// newK8sContextsCmd lists contexts
+func newK8sContextsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "contexts",
+		Aliases: []string{"ctx", "ls"},
+		Short:   "List contexts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runK8sContexts()
+		},
+	}
+}
+
+func runK8sContexts() error {
+	current, contexts, err := loadKubeconfigs()
+	if err != nil {
+		return err
+	}
+
+	dim := color.New(color.Faint)
+	green := color.New(color.FgGreen)
+
+	if len(contexts) == 0 {
+		fmt.Println("No contexts defined")
+		return nil
+	}
+
+	fmt.Println("Kubernetes contexts:")
+	for _, c := range contexts {
+		ns := c.Namespace
+		if ns == "" {
+			ns = "default"
+		}
+		detail := dim.Sprintf("%s, ns=%s", c.Server, ns)
+		if c.Name == current {
+			fmt.Printf("  %s %-28s %s\n", green.Sprint("*"), c.Name, detail)
+		} else {
+			fmt.Printf("    %-28s %s\n", c.Name, detail)
+		}
+	}
+	return nil
+}
+
+// newK8sUseCmd switches context
+func newK8sUseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "use <context>",
+		Aliases:           []string{"switch"},
+		Short:             "Switch the current context",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeK8sContexts,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runK8sUse(args[0])
+		},
+	}
+}
+
+func runK8sUse(name string) error {
+	_, contexts, err := loadKubeconfigs()
+	if err != nil {
+		return err
+	}
+	if findK8sContext(contexts, name) == nil {
+		Fail("Context '%s' not found", name)
+		return fmt.Errorf("context not found: %s", name)
+	}
+
+	// kubectl writes current-context to the first file in KUBECONFIG
+	path := kubeconfigPaths()[0]
+	if err := editKubeconfig(path, func(root *yaml.Node) error {
+		setYAMLScalar(root, "current-context", name)
+		return nil
+	}); err != nil {
+		Fail("Failed to update %s: %v", path, err)
+		return err
+	}
+
+	Pass("Switched to context '%s'", name)
+	return nil
+}
+
+// newK8sNsCmd shows or sets a context's namespace
+func newK8sNsCmd() *cobra.Command {
+	var contextName string
+
+	cmd := &cobra.Command{
+		Use:     "ns [namespace]",
+		Aliases: []string{"namespace"},
+		Short:   "Show or set the namespace of a context",
+		Long: `Show the namespace of the current context, or set it.
+
+Examples:
+  blackdot tools k8s ns                  # Show namespace
+  blackdot tools k8s ns kube-system      # Set namespace on current context
+  blackdot tools k8s ns web --context prod`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace := ""
+			if len(args) > 0 {
+				namespace = args[0]
+			}
+			return runK8sNs(namespace, contextName)
+		},
+	}
+
+	cmd.Flags().StringVar(&contextName, "context", "", "Context to change (default: current)")
+	cmd.RegisterFlagCompletionFunc("context", completeK8sContexts)
+
+	return cmd
+}
+
+func runK8sNs(namespace, contextName string) error {
+	current, contexts, err := loadKubeconfigs()
+	if err != nil {
+		return err
+	}
+	if contextName == "" {
+		contextName = current
+	}
+	if contextName == "" {
+		Fail("No current context (use --context or 'blackdot tools k8s use')")
+		return fmt.Errorf("no current context")
+	}
+	ctx := findK8sContext(contexts, contextName)
+	if ctx == nil {
+		Fail("Context '%s' not found", contextName)
+		return fmt.Errorf("context not found: %s", contextName)
+	}
+
+	if namespace == "" {
+		ns := ctx.Namespace
+		if ns == "" {
+			ns = "default"
+		}
+		fmt.Println(ns)
+		return nil
+	}
+
+	if err := editKubeconfig(ctx.File, func(root *yaml.Node) error {
+		for _, item := range yamlSeqItems(root, "contexts") {
+			if yamlScalar(item, "name") != contextName {
+				continue
+			}
+			body := yamlMappingValue(item, "context")
+			if body == nil {
+				return fmt.Errorf("context '%s' has no body", contextName)
+			}
+			setYAMLScalar(body, "namespace", namespace)
+			return nil
+		}
+		return fmt.Errorf("context '%s' not found in %s", contextName, ctx.File)
+	}); err != nil {
+		Fail("Failed to update namespace: %v", err)
+		return err
+	}
+
+	Pass("Namespace for '%s' set to '%s'", contextName, namespace)
+	return nil
+}
+
+// newK8sMergeCmd merges kubeconfig files
+func newK8sMergeCmd() *cobra.Command {
+	var dryRun, overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "merge <file>...",
+		Short: "Merge kubeconfig files into yours",
+		Long: `Merge clusters, users, and contexts from other kubeconfig files into
+the first kubeconfig file (usually ~/.kube/config).
+
+Entries whose name already exists are kept unless --overwrite is given.
+The original file is backed up before writing.
+
+Examples:
+  blackdot tools k8s merge ~/Downloads/eks-prod.yaml
+  blackdot tools k8s merge a.yaml b.yaml --dry-run`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runK8sMerge(args, overwrite, dryRun)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be merged")
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace entries that already exist")
+
+	return cmd
+}
+
+func runK8sMerge(files []string, overwrite, dryRun bool) error {
+	target := kubeconfigPaths()[0]
+
+	dst, err := readKubeconfigNode(target)
+	if os.IsNotExist(err) {
+		dst = newKubeconfigNode()
+	} else if err != nil {
+		Fail("Failed to read %s: %v", target, err)
+		return err
+	}
+
+	added, replaced, skipped := 0, 0, 0
+	for _, file := range files {
+		src, err := readKubeconfigNode(expandPath(file))
+		if err != nil {
+			Fail("Failed to read %s: %v", file, err)
+			return err
+		}
+		a, r, s := mergeKubeconfigNodes(dst.Content[0], src.Content[0], overwrite, func(kind, name, action string) {
+			fmt.Printf("  %-8s %-8s %s\n", action, kind, name)
+		})
+		added, replaced, skipped = added+a, replaced+r, skipped+s
+	}
+
+	// Adopt a current-context when the target had none
+	if yamlScalar(dst.Content[0], "current-context") == "" {
+		for _, file := range files {
+			if src, err := readKubeconfigNode(expandPath(file)); err == nil {
+				if cc := yamlScalar(src.Content[0], "current-context"); cc != "" {
+					setYAMLScalar(dst.Content[0], "current-context", cc)
+					break
+				}
+			}
+		}
+	}
+
+	fmt.Println()
+	if dryRun {
+		Info("Would add %d, replace %d, skip %d entries in %s", added, replaced, skipped, target)
+		return nil
+	}
+	if added+replaced == 0 {
+		Info("Nothing to merge")
+		return nil
+	}
+
+	if err := backupFile(target); err != nil {
+		Warn("Could not back up %s: %v", target, err)
+	}
+	if err := writeKubeconfigNode(target, dst); err != nil {
+		Fail("Failed to write %s: %v", target, err)
+		return err
+	}
+	Pass("Merged into %s (added %d, replaced %d, skipped %d)", target, added, replaced, skipped)
+	return nil
+}
+
+// mergeKubeconfigNodes copies clusters, users, and contexts from src into
+// dst by name. report is called for every entry with the action taken.
+func mergeKubeconfigNodes(dst, src *yaml.Node, overwrite bool, report func(kind, name, action string)) (added, replaced, skipped int) {
+	for _, section := range []string{"clusters", "users", "contexts"} {
+		kind := strings.TrimSuffix(section, "s")
+		dstSeq := yamlMappingValue(dst, section)
+		if dstSeq == nil || dstSeq.Kind != yaml.SequenceNode {
+			dstSeq = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			setYAMLNode(dst, section, dstSeq)
+		}
+
+		for _, item := range yamlSeqItems(src, section) {
+			name := yamlScalar(item, "name")
+			existing := -1
+			for i, d := range dstSeq.Content {
+				if yamlScalar(d, "name") == name {
+					existing = i
+					break
+				}
+			}
+			switch {
+			case existing < 0:
+				dstSeq.Content = append(dstSeq.Content, item)
+				report(kind, name, "add")
+				added++
+			case overwrite:
+				dstSeq.Content[existing] = item
+				report(kind, name, "replace")
+				replaced++
+			default:
+				report(kind, name, "skip")
+				skipped++
+			}
+		}
+	}
+	return added, replaced, skipped
+}
+
+// newK8sStatusCmd shows status banner
+func newK8sStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show kubeconfig status",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runK8sStatus()
+		},
+	}
+}
+
+func runK8sStatus() error {
+	dim := color.New(color.Faint)
+	cyan := color.New(color.FgCyan)
+	green := color.New(color.FgGreen)
+	red := color.New(color.FgRed)
+
+	fmt.Println()
+	cyan.Println("  ⎈  Kubernetes")
+	fmt.Println()
+
+	current, contexts, err := loadKubeconfigs()
+	if err != nil {
+		fmt.Printf("    %s  %s\n", dim.Sprint("Config"), red.Sprint(err.Error()))
+		fmt.Println()
+		return nil
+	}
+
+	fmt.Printf("    %s  %s\n", dim.Sprint("Config"), strings.Join(kubeconfigPaths(), string(os.PathListSeparator)))
+	fmt.Printf("    %s  %d\n", dim.Sprint("Contexts"), len(contexts))
+
+	if ctx := findK8sContext(contexts, current); ctx != nil {
+		ns := ctx.Namespace
+		if ns == "" {
+			ns = "default"
+		}
+		fmt.Printf("    %s %s\n", dim.Sprint("Current"), green.Sprint(ctx.Name))
+		fmt.Printf("    %s  %s\n", dim.Sprint("Server"), ctx.Server)
+		fmt.Printf("    %s  %s\n", dim.Sprint("Namespace"), ns)
+	} else {
+		fmt.Printf("    %s %s\n", dim.Sprint("Current"), dim.Sprint("<not set>"))
+	}
+	fmt.Println()
+	return nil
+}
+
+// checkK8sReachable dials a cluster's API server
+func checkK8sReachable(server string, timeout time.Duration) error {
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid server URL %q", server)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	conn, err := net.DialTimeout("tcp", host, timeout)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
+
+// kubeconfigForVault prepares a kubeconfig for storage in the vault.
+// Certificate and key file references are inlined as *-data fields,
+// relative to baseDir, so the item restores on machines without those
+// files. Cached auth-provider tokens are stripped: they are short-lived,
+// would be stale on restore, and churn the item on every refresh. Notes
+// describe each change and any cluster that will not verify its server.
+func kubeconfigForVault(data []byte, baseDir string) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil, nil
+	}
+	root := doc.Content[0]
+	var notes []string
+	changed := false
+
+	inline := func(body *yaml.Node, owner, pathKey, dataKey string) {
+		ref := yamlScalar(body, pathKey)
+		if ref == "" {
+			return
+		}
+		if yamlScalar(body, dataKey) != "" {
+			deleteYAMLKey(body, pathKey)
+			changed = true
+			return
+		}
+		p := expandPath(ref)
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(baseDir, p)
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("%s: %s %s not readable; left as a path", owner, pathKey, ref))
+			return
+		}
+		setYAMLScalar(body, dataKey, base64.StdEncoding.EncodeToString(content))
+		deleteYAMLKey(body, pathKey)
+		notes = append(notes, fmt.Sprintf("%s: inlined %s", owner, pathKey))
+		changed = true
+	}
+
+	for _, item := range yamlSeqItems(root, "clusters") {
+		name := "cluster " + yamlScalar(item, "name")
+		body := yamlMappingValue(item, "cluster")
+		if body == nil {
+			continue
+		}
+		inline(body, name, "certificate-authority", "certificate-authority-data")
+		if yamlScalar(body, "certificate-authority-data") == "" {
+			if yamlScalar(body, "insecure-skip-tls-verify") == "true" {
+				notes = append(notes, fmt.Sprintf("%s: insecure-skip-tls-verify with no CA", name))
+			} else if strings.HasPrefix(yamlScalar(body, "server"), "https://") {
+				notes = append(notes, fmt.Sprintf("%s: no CA; relies on system trust store", name))
+			}
+		}
+	}
+
+	for _, item := range yamlSeqItems(root, "users") {
+		name := "user " + yamlScalar(item, "name")
+		body := yamlMappingValue(item, "user")
+		if body == nil {
+			continue
+		}
+		inline(body, name, "client-certificate", "client-certificate-data")
+		inline(body, name, "client-key", "client-key-data")
+
+		if provider := yamlMappingValue(body, "auth-provider"); provider != nil {
+			if cfg := yamlMappingValue(provider, "config"); cfg != nil {
+				for _, key := range []string{"access-token", "expiry", "expires-in", "expires-on"} {
+					if deleteYAMLKey(cfg, key) {
+						notes = append(notes, fmt.Sprintf("%s: removed cached %s", name, key))
+						changed = true
+					}
+				}
+			}
+		}
+	}
+
+	if !changed {
+		return data, notes, nil
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, err
+	}
+	enc.Close()
+	return buf.Bytes(), notes, nil
+}
+
+// ============================================================
+// kubeconfig YAML node helpers
+// ============================================================
+
+func newKubeconfigNode() *yaml.Node {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setYAMLScalar(root, "apiVersion", "v1")
+	setYAMLScalar(root, "kind", "Config")
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+}
+
+func readKubeconfigNode(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return newKubeconfigNode(), nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s is not a kubeconfig", path)
+	}
+	return &doc, nil
+}
+
+func writeKubeconfigNode(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	enc.Close()
+	return writeFileWithPolicy(path, buf.Bytes(), fileClassSecret)
+}
+
+// editKubeconfig applies fn to a kubeconfig's root mapping and writes it back
+func editKubeconfig(path string, fn func(root *yaml.Node) error) error {
+	doc, err := readKubeconfigNode(path)
+	if os.IsNotExist(err) {
+		doc = newKubeconfigNode()
+	} else if err != nil {
+		return err
+	}
+	if err := fn(doc.Content[0]); err != nil {
+		return err
+	}
+	return writeKubeconfigNode(path, doc)
+}
+
+// yamlMappingValue returns the value node for key in a mapping
+func yamlMappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlScalar returns the scalar value for key, or ""
+func yamlScalar(m *yaml.Node, key string) string {
+	if v := yamlMappingValue(m, key); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+	return ""
+}
+
+// yamlSeqItems returns the items of the sequence at key
+func yamlSeqItems(m *yaml.Node, key string) []*yaml.Node {
+	if v := yamlMappingValue(m, key); v != nil && v.Kind == yaml.SequenceNode {
+		return v.Content
+	}
+	return nil
+}
+
+// setYAMLNode sets key to value in a mapping, appending when missing
+func setYAMLNode(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// setYAMLScalar sets key to a string scalar in a mapping
+func setYAMLScalar(m *yaml.Node, key, value string) {
+	setYAMLNode(m, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+}
+
+// deleteYAMLKey removes key from a mapping, reporting whether it existed
+func deleteYAMLKey(m *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testKubeconfigA = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev-cluster
+  cluster:
+    server: https://dev.example.com:6443
+contexts:
+- name: dev
+  context:
+    cluster: dev-cluster
+    user: dev-user
+users:
+- name: dev-user
+  user:
+    token: abc
+`
+
+const testKubeconfigB = `apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: prod
+  context:
+    cluster: prod-cluster
+    user: prod-user
+    namespace: web
+- name: dev
+  context:
+    cluster: prod-cluster
+    user: prod-user
+users:
+- name: prod-user
+  user:
+    token: xyz
+`
+
+// writeTestKubeconfigs writes two kubeconfigs and points KUBECONFIG at them
+func writeTestKubeconfigs(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yaml")
+	b := filepath.Join(dir, "b.yaml")
+	os.WriteFile(a, []byte(testKubeconfigA), 0600)
+	os.WriteFile(b, []byte(testKubeconfigB), 0600)
+	t.Setenv("KUBECONFIG", a+string(os.PathListSeparator)+b)
+	return a, b
+}
+
+// TestLoadKubeconfigs verifies KUBECONFIG merge semantics
+func TestLoadKubeconfigs(t *testing.T) {
+	a, b := writeTestKubeconfigs(t)
+
+	current, contexts, err := loadKubeconfigs()
+	if err != nil {
+		t.Fatalf("loadKubeconfigs: %v", err)
+	}
+	if current != "dev" {
+		t.Errorf("current = %q, want first file's current-context", current)
+	}
+	if len(contexts) != 2 {
+		t.Fatalf("expected 2 contexts (first definition wins), got %+v", contexts)
+	}
+	dev := findK8sContext(contexts, "dev")
+	if dev.File != a || dev.Server != "https://dev.example.com:6443" {
+		t.Errorf("dev = %+v", dev)
+	}
+	prod := findK8sContext(contexts, "prod")
+	if prod.File != b || prod.Namespace != "web" {
+		t.Errorf("prod = %+v", prod)
+	}
+}
+
+// TestK8sUseAndNs verifies context and namespace edits go to the right file
+func TestK8sUseAndNs(t *testing.T) {
+	a, b := writeTestKubeconfigs(t)
+
+	if err := runK8sUse("prod"); err != nil {
+		t.Fatalf("runK8sUse: %v", err)
+	}
+	if err := runK8sUse("missing"); err == nil {
+		t.Error("expected error for unknown context")
+	}
+	if err := runK8sNs("api", "prod"); err != nil {
+		t.Fatalf("runK8sNs: %v", err)
+	}
+
+	current, contexts, _ := loadKubeconfigs()
+	if current != "prod" {
+		t.Errorf("current = %q after use", current)
+	}
+	if ns := findK8sContext(contexts, "prod").Namespace; ns != "api" {
+		t.Errorf("prod namespace = %q", ns)
+	}
+
+	// Namespace edit must land in the file defining the context
+	data, _ := os.ReadFile(b)
+	if !strings.Contains(string(data), "namespace: api") {
+		t.Errorf("namespace not written to %s:\n%s", b, data)
+	}
+	if info, _ := os.Stat(a); info.Mode().Perm() != 0600 {
+		t.Errorf("kubeconfig written with %o", info.Mode().Perm())
+	}
+}
+
+// TestMergeKubeconfigNodes verifies add, skip, and overwrite by name
+func TestMergeKubeconfigNodes(t *testing.T) {
+	parse := func(s string) *yaml.Node {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(s), &doc); err != nil {
+			t.Fatal(err)
+		}
+		return doc.Content[0]
+	}
+	noop := func(kind, name, action string) {}
+
+	dst := parse(testKubeconfigA)
+	added, replaced, skipped := mergeKubeconfigNodes(dst, parse(testKubeconfigB), false, noop)
+	if added != 3 || replaced != 0 || skipped != 1 {
+		t.Errorf("merge = %d added, %d replaced, %d skipped", added, replaced, skipped)
+	}
+	if got := len(yamlSeqItems(dst, "contexts")); got != 2 {
+		t.Errorf("contexts after merge = %d", got)
+	}
+
+	dst = parse(testKubeconfigA)
+	_, replaced, _ = mergeKubeconfigNodes(dst, parse(testKubeconfigB), true, noop)
+	if replaced != 1 {
+		t.Errorf("overwrite replaced = %d", replaced)
+	}
+	dev := yamlMappingValue(yamlSeqItems(dst, "contexts")[0], "context")
+	if yamlScalar(dev, "cluster") != "prod-cluster" {
+		t.Error("overwrite should replace the existing dev context")
+	}
+}
+
+// TestKubeconfigForVault verifies CA inlining and token stripping
+func TestKubeconfigForVault(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "ca.crt"), []byte("CA-PEM"), 0600)
+
+	in := `apiVersion: v1
+clusters:
+- name: c1
+  cluster:
+    server: https://c1.example.com
+    certificate-authority: ca.crt
+- name: c2
+  cluster:
+    server: https://c2.example.com
+    insecure-skip-tls-verify: true
+users:
+- name: u1
+  user:
+    auth-provider:
+      name: gcp
+      config:
+        access-token: secret-token
+        expiry: "2030-01-01T00:00:00Z"
+        cmd-path: gcloud
+`
+	out, notes, err := kubeconfigForVault([]byte(in), dir)
+	if err != nil {
+		t.Fatalf("kubeconfigForVault: %v", err)
+	}
+	s := string(out)
+	if !strings.Contains(s, "certificate-authority-data: "+base64.StdEncoding.EncodeToString([]byte("CA-PEM"))) {
+		t.Errorf("CA not inlined:\n%s", s)
+	}
+	if strings.Contains(s, "certificate-authority: ") {
+		t.Errorf("CA path should be removed:\n%s", s)
+	}
+	if strings.Contains(s, "secret-token") || strings.Contains(s, "expiry") {
+		t.Errorf("cached token not stripped:\n%s", s)
+	}
+	if !strings.Contains(s, "cmd-path: gcloud") {
+		t.Errorf("auth-provider settings should be kept:\n%s", s)
+	}
+
+	foundInsecure := false
+	for _, n := range notes {
+		if strings.Contains(n, "c2") && strings.Contains(n, "insecure") {
+			foundInsecure = true
+		}
+	}
+	if !foundInsecure {
+		t.Errorf("expected insecure cluster note, got %v", notes)
+	}
+
+	// Unchanged configs are returned byte-for-byte
+	plain := []byte(testKubeconfigA)
+	out, _, _ = kubeconfigForVault(plain, dir)
+	if string(out) != testKubeconfigA {
+		t.Error("kubeconfig without file refs or cached tokens should be unchanged")
+	}
+}
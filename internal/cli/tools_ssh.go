@@ -2,7 +2,6 @@
 package cli
 
 import (
-	"bufio"
 	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/pem"
@@ -10,10 +9,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -44,7 +43,8 @@ Commands:
   unload    - Remove key from SSH agent
   clear     - Remove all keys from agent
   tunnels   - List active SSH connections
-  add-host  - Add new host to SSH config`,
+  add-host  - Add new host to SSH config
+  hosts     - Manage hosts.yaml and the generated ~/.ssh/config section`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runSSHStatusLocal()
 		},
@@ -66,6 +66,7 @@ Commands:
 		newSSHClearCmd(),
 		newSSHTunnelsCmd(),
 		newSSHAddHostCmd(),
+		newSSHHostsCmd(),
 	)
 
 	return cmd
@@ -380,7 +381,9 @@ func newSSHListCmd() *cobra.Command {
 		Short: "List configured SSH hosts",
 		Long: `List all hosts configured in ~/.ssh/config.
 
-Shows host aliases that can be used with ssh command.`,
+Shows host aliases that can be used with ssh command. Include directives
+are followed (recursively, with globs), and hosts are grouped by the file
+that declares them.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runSSHList()
 		},
@@ -390,129 +393,140 @@ Shows host aliases that can be used with ssh command.`,
 }
 
 func runSSHList() error {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("cannot determine home directory: %w", err)
-	}
-
-	configPath := filepath.Join(home, ".ssh", "config")
-
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+	configPath := filepath.Join(userSSHDir(), "config")
+	scan, err := scanSSHConfig(configPath)
+	if os.IsNotExist(err) {
 		fmt.Println("No SSH config found at ~/.ssh/config")
 		return nil
 	}
-
-	file, err := os.Open(configPath)
 	if err != nil {
-		return fmt.Errorf("cannot open SSH config: %w", err)
+		return fmt.Errorf("cannot read SSH config: %w", err)
 	}
-	defer file.Close()
 
 	fmt.Println("SSH Hosts:")
 	fmt.Println("──────────────────────────────────────")
 
-	hostRegex := regexp.MustCompile(`(?i)^Host\s+(.+)$`)
-	var hosts []string
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if matches := hostRegex.FindStringSubmatch(line); matches != nil {
-			// Split on whitespace to handle multiple hosts on one line
-			hostnames := strings.Fields(matches[1])
-			for _, h := range hostnames {
-				// Skip wildcards
-				if !strings.Contains(h, "*") && !strings.Contains(h, "?") {
-					hosts = append(hosts, h)
-				}
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading SSH config: %w", err)
-	}
-
-	// Sort and deduplicate
-	sort.Strings(hosts)
-	seen := make(map[string]bool)
+	// Group by declaring file, in the order ssh reads them
+	hosts := scan.uniqueHosts()
+	bySource := make(map[string][]string)
 	for _, h := range hosts {
-		if !seen[h] {
-			seen[h] = true
+		bySource[h.Source] = append(bySource[h.Source], h.Name)
+	}
+	for _, file := range scan.Files {
+		names := bySource[file]
+		if len(names) == 0 {
+			continue
+		}
+		if len(scan.Files) > 1 {
+			Dim.Printf("  %s\n", tildePath(file))
+		}
+		sort.Strings(names)
+		for _, h := range names {
 			fmt.Printf("  %s\n", h)
 		}
+		if len(scan.Files) > 1 {
+			fmt.Println()
+		}
+	}
+	for _, p := range scan.Problems {
+		Warn("%s", p)
 	}
 
 	fmt.Println()
-	fmt.Printf("Total: %d hosts\n", len(seen))
+	fmt.Printf("Total: %d hosts", len(hosts))
+	if len(scan.Files) > 1 {
+		fmt.Printf(" in %d files", len(scan.Files))
+	}
+	fmt.Println()
 
 	return nil
 }
 
 // newSSHAgentCmd shows SSH agent status
 func newSSHAgentCmd() *cobra.Command {
+	var start bool
+
 	cmd := &cobra.Command{
 		Use:   "agent",
 		Short: "Show SSH agent status",
 		Long: `Show SSH agent status and currently loaded keys.
 
-Displays agent PID, socket path, and lists all loaded keys.`,
+Displays where the agent listens (SSH_AUTH_SOCK, or on Windows the
+OpenSSH service's named pipe) and lists all loaded keys. On Windows the
+ssh-agent service's state and start type are shown too; --start enables
+the service if it is disabled and starts it (needs an Administrator
+prompt).
+
+Examples:
+  blackdot tools ssh agent
+  blackdot tools ssh agent --start   # Windows: enable and start the service`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSSHAgent()
+			return runSSHAgent(start)
 		},
 	}
 
+	cmd.Flags().BoolVar(&start, "start", false, "Enable and start the Windows ssh-agent service")
 	return cmd
 }
 
-func runSSHAgent() error {
+func runSSHAgent(start bool) error {
+	status := detectSSHAgent()
+	if start && !status.Reachable {
+		if status.Service == nil {
+			return fmt.Errorf("--start manages the Windows service; start an agent in your shell with: %s", agentFixHint())
+		}
+		if err := startWindowsSSHAgent(*status.Service); err != nil {
+			Fail("%v", err)
+			return err
+		}
+		Pass("Started the %s service", windowsAgentService)
+		status = detectSSHAgent()
+	}
+
 	fmt.Println("SSH Agent Status:")
 	fmt.Println("──────────────────────────────────────")
 
-	// Check SSH_AUTH_SOCK
-	authSock := os.Getenv("SSH_AUTH_SOCK")
-	if authSock == "" {
+	if svc := status.Service; svc != nil {
+		switch {
+		case !svc.Installed:
+			fmt.Println("  Service: not installed (OpenSSH Client optional feature)")
+		default:
+			fmt.Printf("  Service: %s (start type %s)\n", strings.ToLower(svc.State), strings.ToLower(valueOr(svc.StartType, "unknown")))
+		}
+	}
+
+	if !status.Reachable {
 		fmt.Println("  Status: ○ not running")
-		fmt.Println("  Socket: not set")
-		fmt.Println()
-		fmt.Println("Start the agent with:")
-		if runtime.GOOS == "windows" {
-			fmt.Println("  Start-Service ssh-agent")
+		if status.Address == "" {
+			fmt.Println("  Socket: not set")
 		} else {
-			fmt.Println("  eval \"$(ssh-agent -s)\"")
+			fmt.Printf("  Socket: %s (%v)\n", status.Address, status.Err)
 		}
+		fmt.Println()
+		fmt.Println("Start the agent with:")
+		fmt.Printf("  %s\n", agentFixHint())
 		return nil
 	}
 
-	// Try to list keys
-	cmd := exec.Command("ssh-add", "-l")
-	output, err := cmd.Output()
-
 	agentPID := os.Getenv("SSH_AGENT_PID")
 	if agentPID == "" {
 		agentPID = "unknown"
 	}
-
-	fmt.Printf("  PID:    %s\n", agentPID)
-	fmt.Printf("  Socket: %s\n", authSock)
+	if status.Service == nil || os.Getenv("SSH_AUTH_SOCK") != "" {
+		fmt.Printf("  PID:    %s\n", agentPID)
+	}
+	fmt.Printf("  Socket: %s\n", status.Address)
 	fmt.Println()
 	fmt.Println("Loaded keys:")
 
+	client, closeAgent, err := openSSHAgent()
 	if err != nil {
-		// Check if it's "no identities" message
-		if strings.Contains(string(output), "no identities") || cmd.ProcessState.ExitCode() == 1 {
-			fmt.Println("  (no keys loaded)")
-		} else {
-			fmt.Printf("  (error listing keys: %v)\n", err)
-		}
-	} else {
-		// Parse and display keys
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-		for _, line := range lines {
-			if line != "" {
-				fmt.Printf("  %s\n", line)
-			}
-		}
+		fmt.Printf("  (error listing keys: %v)\n", err)
+		return nil
+	}
+	defer closeAgent()
+	if err := printAgentKeys(client); err != nil {
+		fmt.Printf("  (error listing keys: %v)\n", err)
 	}
 
 	fmt.Println()
@@ -640,29 +654,44 @@ func runSSHFingerprint(keyName string) error {
 // newSSHCopyCmd copies public key to remote host
 func newSSHCopyCmd() *cobra.Command {
 	var keyPath string
+	var native, acceptNew bool
 
 	cmd := &cobra.Command{
 		Use:   "copy <host>",
 		Short: "Copy public key to remote host",
 		Long: `Copy SSH public key to remote host's authorized_keys.
 
-Uses ssh-copy-id under the hood.
+Uses ssh-copy-id when it is installed. Without it (Windows, minimal
+distros) blackdot connects itself, using ~/.ssh/config, ssh-agent or key
+files, or a password, and appends the key to ~/.ssh/authorized_keys,
+creating ~/.ssh with the permissions sshd requires. A key that is already
+authorized is not added again. The remote host needs a POSIX shell.
 
 Examples:
   blackdot tools ssh copy myserver
-  blackdot tools ssh copy user@host --key ~/.ssh/id_ed25519_work.pub`,
+  blackdot tools ssh copy user@host --key ~/.ssh/id_ed25519_work.pub
+  blackdot tools ssh copy myserver --native    # Skip ssh-copy-id`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSSHCopy(args[0], keyPath)
+			return runSSHCopy(args[0], keyPath, native, acceptNew)
 		},
 	}
 
 	cmd.Flags().StringVarP(&keyPath, "key", "k", "", "Specific key to copy")
+	cmd.Flags().BoolVar(&native, "native", false, "Use the built-in client even if ssh-copy-id is installed")
+	cmd.Flags().BoolVar(&acceptNew, "accept-new", false, "Add an unknown host's key to known_hosts (built-in client)")
 
 	return cmd
 }
 
-func runSSHCopy(host, keyPath string) error {
+func runSSHCopy(host, keyPath string, native, acceptNew bool) error {
+	if _, err := exec.LookPath("ssh-copy-id"); err != nil || native {
+		if !native {
+			Info("ssh-copy-id not found; copying with the built-in client")
+		}
+		return runNativeSSHCopy(host, keyPath, acceptNew)
+	}
+
 	args := []string{}
 	if keyPath != "" {
 		args = append(args, "-i", keyPath)
@@ -677,19 +706,41 @@ func runSSHCopy(host, keyPath string) error {
 	return cmd.Run()
 }
 
+// addSSHForwardFlags registers the flags shared by tunnel and socks
+func addSSHForwardFlags(cmd *cobra.Command, opts *sshForwardOptions) {
+	cmd.Flags().BoolVar(&opts.OpenSSH, "openssh", false, "Run the ssh binary instead of the built-in client")
+	cmd.Flags().DurationVar(&opts.Keepalive, "keepalive", 30*time.Second, "Interval between keepalive requests")
+	cmd.Flags().BoolVar(&opts.AcceptNew, "accept-new", false, "Add an unknown host's key to known_hosts")
+}
+
+// sshForwardHelp describes the built-in client in tunnel and socks help
+const sshForwardHelp = `The connection is made by blackdot itself, so no ssh binary is needed. It
+uses ~/.ssh/config (HostName, User, Port, IdentityFile, UserKnownHostsFile),
+keys from ssh-agent and identity files, and verifies the host against
+known_hosts. An unknown host is refused unless --accept-new is given; a
+changed host key is always refused. A dropped connection is re-established
+automatically while the local port stays open. Use --openssh for anything
+the built-in client does not support, such as ProxyJump.`
+
 // newSSHTunnelCmd creates port forward tunnel
 func newSSHTunnelCmd() *cobra.Command {
+	var opts sshForwardOptions
+
 	cmd := &cobra.Command{
 		Use:   "tunnel <host> <local_port> [remote_port]",
 		Short: "Create SSH port forward tunnel",
 		Long: `Create an SSH port forwarding tunnel.
 
 Forwards localhost:local_port to host:remote_port.
-If remote_port is not specified, uses the same as local_port.
+If remote_port is not specified, uses the same as local_port. It may also
+be target:port, a host the server can reach.
+
+` + sshForwardHelp + `
 
 Examples:
   blackdot tools ssh tunnel myserver 8080 80
-  blackdot tools ssh tunnel db-server 5432`,
+  blackdot tools ssh tunnel db-server 5432
+  blackdot tools ssh tunnel bastion 5432 db.internal:5432`,
 		Args: cobra.RangeArgs(2, 3),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			host := args[0]
@@ -698,19 +749,27 @@ Examples:
 			if len(args) > 2 {
 				remotePort = args[2]
 			}
-			return runSSHTunnel(host, localPort, remotePort)
+			return runSSHTunnel(host, localPort, remotePort, opts)
 		},
 	}
+	addSSHForwardFlags(cmd, &opts)
 
 	return cmd
 }
 
-func runSSHTunnel(host, localPort, remotePort string) error {
-	fmt.Printf("Creating tunnel: localhost:%s -> %s:%s\n", localPort, host, remotePort)
+func runSSHTunnel(host, localPort, remotePort string, opts sshForwardOptions) error {
+	remoteAddr := remotePort
+	if !strings.Contains(remotePort, ":") {
+		remoteAddr = "localhost:" + remotePort
+	}
+	fmt.Printf("Creating tunnel: localhost:%s -> %s (via %s)\n", localPort, remoteAddr, host)
 	fmt.Println("Press Ctrl+C to close tunnel")
+	if !opts.OpenSSH {
+		return runNativeSSHTunnel(host, localPort, remoteAddr, opts)
+	}
 
-	tunnelSpec := fmt.Sprintf("%s:localhost:%s", localPort, remotePort)
-	cmd := exec.Command("ssh", "-N", "-L", tunnelSpec, host)
+	tunnelSpec := fmt.Sprintf("%s:%s", localPort, remoteAddr)
+	cmd := exec.Command("ssh", "-N", "-o", fmt.Sprintf("ServerAliveInterval=%d", int(opts.Keepalive.Seconds())), "-L", tunnelSpec, host)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -721,6 +780,7 @@ func runSSHTunnel(host, localPort, remotePort string) error {
 // newSSHSocksCmd creates SOCKS5 proxy
 func newSSHSocksCmd() *cobra.Command {
 	var port string
+	var opts sshForwardOptions
 
 	cmd := &cobra.Command{
 		Use:   "socks <host>",
@@ -729,26 +789,32 @@ func newSSHSocksCmd() *cobra.Command {
 
 Configure browser/apps to use socks5://localhost:<port>
 
+` + sshForwardHelp + `
+
 Examples:
   blackdot tools ssh socks myserver
   blackdot tools ssh socks myserver --port 9050`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSSHSocks(args[0], port)
+			return runSSHSocks(args[0], port, opts)
 		},
 	}
 
 	cmd.Flags().StringVarP(&port, "port", "p", "1080", "Local SOCKS5 port")
+	addSSHForwardFlags(cmd, &opts)
 
 	return cmd
 }
 
-func runSSHSocks(host, port string) error {
+func runSSHSocks(host, port string, opts sshForwardOptions) error {
 	fmt.Printf("Creating SOCKS5 proxy on localhost:%s through %s\n", port, host)
 	fmt.Printf("Configure apps to use: socks5://localhost:%s\n", port)
 	fmt.Println("Press Ctrl+C to close proxy")
+	if !opts.OpenSSH {
+		return runNativeSSHSocks(host, port, opts)
+	}
 
-	cmd := exec.Command("ssh", "-N", "-D", port, host)
+	cmd := exec.Command("ssh", "-N", "-o", fmt.Sprintf("ServerAliveInterval=%d", int(opts.Keepalive.Seconds())), "-D", port, host)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -772,19 +838,14 @@ func runSSHStatusLocal() error {
 	sshDir := filepath.Join(home, ".ssh")
 
 	// Check agent status
-	authSock := os.Getenv("SSH_AUTH_SOCK")
-	agentRunning := authSock != ""
+	agentRunning := false
 	keysLoaded := 0
-
-	if agentRunning {
-		if out, err := exec.Command("ssh-add", "-l").Output(); err == nil {
-			lines := strings.Split(strings.TrimSpace(string(out)), "\n")
-			for _, l := range lines {
-				if l != "" && !strings.Contains(l, "no identities") {
-					keysLoaded++
-				}
-			}
+	if client, closeAgent, err := openSSHAgent(); err == nil {
+		if keys, err := client.List(); err == nil {
+			agentRunning = true
+			keysLoaded = len(keys)
 		}
+		closeAgent()
 	}
 
 	// Choose color
@@ -833,15 +894,8 @@ func runSSHStatusLocal() error {
 
 	// Count hosts
 	hostCount := 0
-	configPath := filepath.Join(sshDir, "config")
-	if file, err := os.Open(configPath); err == nil {
-		defer file.Close()
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			if strings.HasPrefix(strings.TrimSpace(scanner.Text()), "Host ") {
-				hostCount++
-			}
-		}
+	if scan, err := scanSSHConfig(filepath.Join(sshDir, "config")); err == nil {
+		hostCount = len(scan.uniqueHosts())
 	}
 	fmt.Printf("    %s     %s\n", dim.Sprint("Hosts"), cyan.Sprintf("%d configured", hostCount))
 
@@ -881,57 +935,46 @@ Examples:
 }
 
 func sshLoadDefault() error {
-	fmt.Println("Loading default SSH keys...")
-	cmd := exec.Command("ssh-add")
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to load default keys: %w", err)
+	home, _ := os.UserHomeDir()
+	var keys []string
+	for _, name := range defaultSSHKeys {
+		if path := filepath.Join(home, ".ssh", name); fileExists(path) {
+			keys = append(keys, path)
+		}
 	}
-
-	fmt.Println("\nCurrently loaded keys:")
-	listCmd := exec.Command("ssh-add", "-l")
-	listCmd.Stdout = os.Stdout
-	listCmd.Stderr = os.Stderr
-	return listCmd.Run()
+	if len(keys) == 0 {
+		return fmt.Errorf("no default keys (%s) in ~/.ssh", strings.Join(defaultSSHKeys, ", "))
+	}
+	fmt.Println("Loading default SSH keys...")
+	return sshLoadKeys(keys)
 }
 
 func sshLoadKey(key string) error {
-	home, _ := os.UserHomeDir()
-	sshDir := filepath.Join(home, ".ssh")
+	keyPath, ok := resolveSSHKeyPath(key)
+	if !ok {
+		home, _ := os.UserHomeDir()
+		return fmt.Errorf("key not found: %s\n\nAvailable keys:\n%s", key, listAvailableKeys(filepath.Join(home, ".ssh")))
+	}
+	return sshLoadKeys([]string{strings.TrimSuffix(keyPath, ".pub")})
+}
 
-	// Try to find the key
-	keyPath := key
-	if _, err := os.Stat(keyPath); os.IsNotExist(err) {
-		// Try in .ssh directory
-		keyPath = filepath.Join(sshDir, key)
-		if _, err := os.Stat(keyPath); os.IsNotExist(err) {
-			// Try with id_ed25519_ prefix
-			keyPath = filepath.Join(sshDir, "id_ed25519_"+key)
-			if _, err := os.Stat(keyPath); os.IsNotExist(err) {
-				// Try with id_rsa_ prefix
-				keyPath = filepath.Join(sshDir, "id_rsa_"+key)
-				if _, err := os.Stat(keyPath); os.IsNotExist(err) {
-					return fmt.Errorf("key not found: %s\n\nAvailable keys:\n%s", key, listAvailableKeys(sshDir))
-				}
-			}
-		}
+// sshLoadKeys adds key files to the agent and lists what it then holds
+func sshLoadKeys(keyPaths []string) error {
+	client, closeAgent, err := openSSHAgent()
+	if err != nil {
+		return fmt.Errorf("%w (start it with: %s)", err, agentFixHint())
 	}
+	defer closeAgent()
 
-	cmd := exec.Command("ssh-add", keyPath)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to add key: %w", err)
+	for _, keyPath := range keyPaths {
+		if err := addSSHKeyToAgent(client, keyPath); err != nil {
+			return fmt.Errorf("failed to add key: %w", err)
+		}
+		fmt.Printf("Identity added: %s\n", tildePath(keyPath))
 	}
 
 	fmt.Println("\nCurrently loaded keys:")
-	listCmd := exec.Command("ssh-add", "-l")
-	listCmd.Stdout = os.Stdout
-	listCmd.Stderr = os.Stderr
-	return listCmd.Run()
+	return printAgentKeys(client)
 }
 
 func listAvailableKeys(sshDir string) string {
@@ -966,33 +1009,22 @@ Examples:
 }
 
 func sshUnloadKey(key string) error {
-	home, _ := os.UserHomeDir()
-	sshDir := filepath.Join(home, ".ssh")
-
-	// Try to find the key
-	keyPath := key
-	if _, err := os.Stat(keyPath); os.IsNotExist(err) {
-		keyPath = filepath.Join(sshDir, key)
-		if _, err := os.Stat(keyPath); os.IsNotExist(err) {
-			keyPath = filepath.Join(sshDir, "id_ed25519_"+key)
-			if _, err := os.Stat(keyPath); os.IsNotExist(err) {
-				keyPath = filepath.Join(sshDir, "id_rsa_"+key)
-			}
-		}
+	keyPath, ok := resolveSSHKeyPath(key)
+	if !ok {
+		return fmt.Errorf("key not found: %s", key)
+	}
+	pub, err := sshKeyPublic(keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %w", err)
 	}
 
-	// Try removing the private key
-	cmd := exec.Command("ssh-add", "-d", keyPath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		// Try with .pub extension
-		cmd = exec.Command("ssh-add", "-d", keyPath+".pub")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to remove key: %w", err)
-		}
+	client, closeAgent, err := openSSHAgent()
+	if err != nil {
+		return fmt.Errorf("%w (start it with: %s)", err, agentFixHint())
+	}
+	defer closeAgent()
+	if err := client.Remove(pub); err != nil {
+		return fmt.Errorf("failed to remove key: %w", err)
 	}
 
 	fmt.Println("Removed key from agent")
@@ -1013,10 +1045,12 @@ func newSSHClearCmd() *cobra.Command {
 
 func sshClearAgent() error {
 	fmt.Println("Removing all keys from SSH agent...")
-	cmd := exec.Command("ssh-add", "-D")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	client, closeAgent, err := openSSHAgent()
+	if err != nil {
+		return fmt.Errorf("%w (start it with: %s)", err, agentFixHint())
+	}
+	defer closeAgent()
+	if err := client.RemoveAll(); err != nil {
 		return fmt.Errorf("failed to clear agent: %w", err)
 	}
 	fmt.Println("Done. No keys loaded.")
@@ -1079,12 +1113,17 @@ func sshListTunnels() error {
 
 // newSSHAddHostCmd adds a new host to SSH config
 func newSSHAddHostCmd() *cobra.Command {
-	var hostname, user, port, identity string
+	var hostname, user, identity string
+	var port int
 
 	cmd := &cobra.Command{
 		Use:   "add-host <name>",
 		Short: "Add new host to SSH config",
-		Long: `Add a new host entry to ~/.ssh/config.
+		Long: `Add a new host to hosts.yaml and regenerate ~/.ssh/config.
+
+Shorthand for 'blackdot tools ssh hosts add' that defaults the user to
+the current user. See 'blackdot tools ssh hosts --help' for tags, jump
+hosts and extra options.
 
 Example:
   blackdot tools ssh add-host myserver --hostname 192.168.1.100 --user admin`,
@@ -1096,17 +1135,14 @@ Example:
 
 	cmd.Flags().StringVar(&hostname, "hostname", "", "Hostname or IP address (required)")
 	cmd.Flags().StringVarP(&user, "user", "u", "", "Username (defaults to current user)")
-	cmd.Flags().StringVarP(&port, "port", "p", "22", "Port number")
+	cmd.Flags().IntVarP(&port, "port", "p", 22, "Port number")
 	cmd.Flags().StringVarP(&identity, "identity", "i", "", "Identity file path")
 	cmd.MarkFlagRequired("hostname")
 
 	return cmd
 }
 
-func sshAddHost(name, hostname, user, port, identity string) error {
-	home, _ := os.UserHomeDir()
-	configPath := filepath.Join(home, ".ssh", "config")
-
+func sshAddHost(name, hostname, user string, port int, identity string) error {
 	// Default user to current user
 	if user == "" {
 		user = os.Getenv("USER")
@@ -1115,37 +1151,13 @@ func sshAddHost(name, hostname, user, port, identity string) error {
 		}
 	}
 
-	// Build host entry
-	var entry strings.Builder
-	entry.WriteString("\n")
-	entry.WriteString(fmt.Sprintf("Host %s\n", name))
-	entry.WriteString(fmt.Sprintf("    HostName %s\n", hostname))
-	entry.WriteString(fmt.Sprintf("    User %s\n", user))
-	if port != "22" {
-		entry.WriteString(fmt.Sprintf("    Port %s\n", port))
-	}
-	if identity != "" {
-		entry.WriteString(fmt.Sprintf("    IdentityFile %s\n", identity))
-	}
-
-	// Ensure .ssh directory exists
-	sshDir := filepath.Join(home, ".ssh")
-	if err := os.MkdirAll(sshDir, 0700); err != nil {
-		return fmt.Errorf("failed to create .ssh directory: %w", err)
-	}
-
-	// Append to config
-	f, err := os.OpenFile(configPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return fmt.Errorf("failed to open SSH config: %w", err)
+	h := sshHostDef{Host: name, HostName: hostname, User: user, Port: port, Identity: identity}
+	if err := h.validate(); err != nil {
+		return err
 	}
-	defer f.Close()
-
-	if _, err := f.WriteString(entry.String()); err != nil {
-		return fmt.Errorf("failed to write to SSH config: %w", err)
+	if err := addSSHHostDef(h, false); err != nil {
+		return err
 	}
-
-	fmt.Printf("Added host '%s' to %s\n", name, configPath)
 	fmt.Printf("Connect with: ssh %s\n", name)
 	return nil
 }
@@ -0,0 +1,638 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+)
+
+// toolVersionMiseFiles pin runtimes in their [tools] table. In a directory
+// they win over .tool-versions, which wins over the legacy files, as in mise.
+var toolVersionMiseFiles = []string{".mise.toml", "mise.toml"}
+
+// toolVersionLegacyFiles each pin one runtime
+var toolVersionLegacyFiles = map[string]string{
+	".nvmrc":          "node",
+	".node-version":   "node",
+	".python-version": "python",
+	".ruby-version":   "ruby",
+	".go-version":     "go",
+	".java-version":   "java",
+}
+
+// toolAliases maps asdf plugin names to the names mise uses
+var toolAliases = map[string]string{
+	"nodejs": "node",
+	"golang": "go",
+}
+
+// toolBinaries is the command that prints a runtime's version, when it is
+// not the tool name with --version
+var toolBinaries = map[string][]string{
+	"python":    {"python3", "--version"},
+	"go":        {"go", "version"},
+	"java":      {"java", "-version"},
+	"rust":      {"rustc", "--version"},
+	"terraform": {"terraform", "version"},
+}
+
+// toolFormulas is the Homebrew formula for a runtime, when it differs from
+// the tool name
+var toolFormulas = map[string]string{
+	"java":    "openjdk",
+	"kubectl": "kubernetes-cli",
+}
+
+var toolVersionRe = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// toolRequirement is a runtime pinned by a version file
+type toolRequirement struct {
+	Tool    string `json:"tool"`
+	Version string `json:"version"`
+	Source  string `json:"source"`          // version file that pins it
+	Status  string `json:"status"`          // installed, missing
+	Found   string `json:"found,omitempty"` // installed version, or the wrong one on PATH
+	BinDir  string `json:"bin_dir,omitempty"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+func newToolsVersionsCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "versions [dir]",
+		Short: "Check the runtimes a project pins",
+		Long: `Check the runtimes pinned by the project in the current directory.
+
+Version files are read from the directory up to /, and the nearest pin of
+each tool wins:
+
+  .mise.toml, mise.toml   [tools] table
+  .tool-versions          asdf and mise
+  .nvmrc, .node-version, .python-version, .ruby-version, .go-version,
+  .java-version
+
+A runtime is installed when mise, asdf, nvm, pyenv or rbenv has a matching
+version, or the one on PATH matches. Missing runtimes get an install hint:
+mise or asdf when available, otherwise Homebrew, pointing at
+'blackdot packages --install' when the formula is already in the Brewfile.
+
+Commands:
+  check  - List pinned runtimes and whether they are installed (default)
+  hook   - Shell code for the cd hook`,
+		Example: `  blackdot tools versions                 # Check the current project
+  blackdot tools versions ~/src/api --json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runToolVersionsCheck(args, jsonOutput)
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	checkCmd := &cobra.Command{
+		Use:   "check [dir]",
+		Short: "List pinned runtimes and whether they are installed",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runToolVersionsCheck(args, jsonOutput)
+		},
+	}
+	checkCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	hookCmd := &cobra.Command{
+		Use:   "hook [zsh|bash]",
+		Short: "Shell code for the cd hook",
+		Long: `Print shell code to eval after changing directory.
+
+Pinned runtimes found in mise, asdf, nvm, pyenv or rbenv install dirs are
+put first on PATH, replacing the ones added for the previous project
+(tracked in BLACKDOT_TOOL_PATHS). PATH is left alone when mise or asdf
+already manage it. Missing runtimes are reported on stderr with an install
+hint. 90-integrations.zsh runs this when the tool_versions feature is on:
+
+  eval "$(blackdot tools versions hook zsh)"`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"zsh", "bash"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 && args[0] != "zsh" && args[0] != "bash" {
+				return fmt.Errorf("unsupported shell: %s (supported: zsh, bash)", args[0])
+			}
+			dir, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			fmt.Print(runToolVersionsHook(dir, os.Getenv("PATH"), os.Getenv("BLACKDOT_TOOL_PATHS")))
+			return nil
+		},
+	}
+
+	cmd.AddCommand(checkCmd, hookCmd)
+	return cmd
+}
+
+func runToolVersionsCheck(args []string, jsonOutput bool) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = expandPath(args[0])
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	reqs, err := readToolVersions(dir)
+	if err != nil {
+		return err
+	}
+	path := toolBasePath(os.Getenv("PATH"), os.Getenv("BLACKDOT_TOOL_PATHS"))
+	missing := checkToolRequirements(reqs, path)
+
+	if jsonOutput {
+		if reqs == nil {
+			reqs = []*toolRequirement{}
+		}
+		data, _ := json.MarshalIndent(reqs, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		if len(reqs) == 0 {
+			Info("No .tool-versions, mise.toml or version files above %s", tildePath(dir))
+			return nil
+		}
+		PrintHeader("Tool Versions")
+		for _, r := range reqs {
+			source := Dim.Sprint("(" + tildePath(r.Source) + ")")
+			if r.Status == "installed" {
+				Pass("%s %s %s", r.Tool, r.Found, source)
+				continue
+			}
+			found := ""
+			if r.Found != "" {
+				found = fmt.Sprintf(", %s on PATH", r.Found)
+			}
+			Fail("%s %s not installed%s %s", r.Tool, r.Version, found, source)
+			fmt.Printf("       %s\n", r.Hint)
+		}
+	}
+
+	if missing > 0 {
+		return fmt.Errorf("%d pinned runtime(s) not installed", missing)
+	}
+	return nil
+}
+
+// runToolVersionsHook is the shell code the cd hook evals for dir. path is
+// the shell's PATH and added the bin dirs a previous run put on it.
+func runToolVersionsHook(dir, path, added string) string {
+	reqs, err := readToolVersions(dir)
+	if err != nil {
+		return fmt.Sprintf("echo %s >&2\n", shellQuote("blackdot: "+err.Error()))
+	}
+	base := toolBasePath(path, added)
+	checkToolRequirements(reqs, base)
+	return toolVersionsHook(reqs, path, base, added, toolManagerActive(base))
+}
+
+// toolVersionsHook renders the hook output for checked requirements. PATH
+// becomes base with the bin dirs of installed runtimes in front, unless a
+// version manager already handles PATH.
+func toolVersionsHook(reqs []*toolRequirement, path, base, added string, managed bool) string {
+	var bins []string
+	if !managed {
+		seen := make(map[string]bool)
+		for _, r := range reqs {
+			if r.BinDir != "" && !seen[r.BinDir] {
+				seen[r.BinDir] = true
+				bins = append(bins, r.BinDir)
+			}
+		}
+	}
+
+	var b strings.Builder
+	newPath := strings.Join(append(append([]string{}, bins...), filepath.SplitList(base)...), string(os.PathListSeparator))
+	if newPath != path {
+		fmt.Fprintf(&b, "export PATH=%s\n", shellQuote(newPath))
+	}
+	newAdded := strings.Join(bins, string(os.PathListSeparator))
+	switch {
+	case newAdded == added:
+	case newAdded == "":
+		b.WriteString("unset BLACKDOT_TOOL_PATHS\n")
+	default:
+		fmt.Fprintf(&b, "export BLACKDOT_TOOL_PATHS=%s\n", shellQuote(newAdded))
+	}
+	for _, r := range reqs {
+		if r.Status == "missing" {
+			msg := fmt.Sprintf("blackdot: %s %s (%s) is not installed: %s", r.Tool, r.Version, filepath.Base(r.Source), r.Hint)
+			fmt.Fprintf(&b, "echo %s >&2\n", shellQuote(msg))
+		}
+	}
+	return b.String()
+}
+
+// toolBasePath is path without the bin dirs the hook added
+func toolBasePath(path, added string) string {
+	if added == "" {
+		return path
+	}
+	drop := make(map[string]bool)
+	for _, dir := range filepath.SplitList(added) {
+		drop[dir] = true
+	}
+	var kept []string
+	for _, dir := range filepath.SplitList(path) {
+		if !drop[dir] {
+			kept = append(kept, dir)
+		}
+	}
+	return strings.Join(kept, string(os.PathListSeparator))
+}
+
+// toolManagerActive reports whether mise or asdf already put the pinned
+// runtimes on PATH for this shell
+func toolManagerActive(path string) bool {
+	if os.Getenv("MISE_SHELL") != "" {
+		return true
+	}
+	shims := filepath.Join(asdfDataDir(), "shims")
+	for _, dir := range filepath.SplitList(path) {
+		if dir == shims {
+			return true
+		}
+	}
+	return false
+}
+
+// readToolVersions collects the runtimes pinned for dir, reading version
+// files from dir up to the filesystem root. The nearest pin of each tool
+// wins, as in asdf and mise.
+func readToolVersions(dir string) ([]*toolRequirement, error) {
+	seen := make(map[string]bool)
+	var reqs []*toolRequirement
+	for {
+		found, err := toolVersionsIn(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range found {
+			if !seen[r.Tool] {
+				seen[r.Tool] = true
+				reqs = append(reqs, r)
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return reqs, nil
+		}
+		dir = parent
+	}
+}
+
+// toolVersionsIn reads the version files in one directory, highest
+// precedence first
+func toolVersionsIn(dir string) ([]*toolRequirement, error) {
+	var reqs []*toolRequirement
+	for _, name := range toolVersionMiseFiles {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		pins, err := parseMiseTools(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tildePath(path), err)
+		}
+		reqs = append(reqs, toolPins(pins, path)...)
+	}
+
+	path := filepath.Join(dir, ".tool-versions")
+	if data, err := os.ReadFile(path); err == nil {
+		reqs = append(reqs, toolPins(parseToolVersions(data), path)...)
+	}
+
+	names := make([]string, 0, len(toolVersionLegacyFiles))
+	for name := range toolVersionLegacyFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if version := firstToolVersionLine(data); version != "" {
+			reqs = append(reqs, &toolRequirement{Tool: toolVersionLegacyFiles[name], Version: version, Source: path})
+		}
+	}
+	return reqs, nil
+}
+
+// toolPins turns tool/version pairs from one file into requirements
+func toolPins(pins [][2]string, source string) []*toolRequirement {
+	var reqs []*toolRequirement
+	for _, p := range pins {
+		reqs = append(reqs, &toolRequirement{Tool: p[0], Version: p[1], Source: source})
+	}
+	return reqs
+}
+
+// parseToolVersions reads .tool-versions lines ("nodejs 20.11.0 18.19.0
+// # comment"). The first version listed is the one in use.
+func parseToolVersions(data []byte) [][2]string {
+	var pins [][2]string
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pins = append(pins, [2]string{normalizeToolName(fields[0]), fields[1]})
+	}
+	return pins
+}
+
+// parseMiseTools reads the [tools] table of a mise config. A value is a
+// version, a list whose first entry is used, or a table with a version.
+// Tools from other backends (npm:, cargo:, ...) are skipped.
+func parseMiseTools(data []byte) ([][2]string, error) {
+	var cfg struct {
+		Tools map[string]interface{} `toml:"tools"`
+	}
+	if _, err := toml.Decode(string(data), &cfg); err != nil {
+		return nil, err
+	}
+	var pins [][2]string
+	for name, value := range cfg.Tools {
+		name = strings.TrimPrefix(name, "core:")
+		if strings.Contains(name, ":") {
+			continue
+		}
+		var version string
+		switch v := value.(type) {
+		case string:
+			version = v
+		case []interface{}:
+			if len(v) > 0 {
+				version, _ = v[0].(string)
+			}
+		case map[string]interface{}:
+			version, _ = v["version"].(string)
+		}
+		if version != "" {
+			pins = append(pins, [2]string{normalizeToolName(name), version})
+		}
+	}
+	sort.Slice(pins, func(i, j int) bool { return pins[i][0] < pins[j][0] })
+	return pins, nil
+}
+
+// firstToolVersionLine is the first non-comment line of a legacy version file
+func firstToolVersionLine(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+func normalizeToolName(name string) string {
+	if alias, ok := toolAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// toolVersionMatches reports whether have satisfies the pinned want. A
+// version matches on component boundaries, so 3.12 matches 3.12.4 but not
+// 3.120. Pins without digits (latest, lts/*, system) match any version.
+func toolVersionMatches(want, have string) bool {
+	want = strings.TrimPrefix(strings.TrimPrefix(want, "prefix:"), "v")
+	have = strings.TrimPrefix(have, "v")
+	if !strings.ContainsAny(want, "0123456789") {
+		return true
+	}
+	return have == want || strings.HasPrefix(have, want+".")
+}
+
+// checkToolRequirements fills in each requirement's status, looking for
+// binaries on path. It returns how many are missing.
+func checkToolRequirements(reqs []*toolRequirement, path string) int {
+	missing := 0
+	var brewfile map[string]bool
+	for _, r := range reqs {
+		checkToolRequirement(r, path)
+		if r.Status == "missing" {
+			if brewfile == nil {
+				brewfile = brewfileFormulaSet()
+			}
+			r.Hint = toolInstallHint(r, brewfile)
+			missing++
+		}
+	}
+	return missing
+}
+
+// checkToolRequirement marks r installed when a version manager has a
+// matching install, or the binary on path reports a matching version
+func checkToolRequirement(r *toolRequirement, path string) {
+	if r.Version != "system" {
+		if version, bin := findToolInstall(r.Tool, r.Version); bin != "" {
+			r.Status, r.Found, r.BinDir = "installed", version, bin
+			return
+		}
+	}
+	have := toolVersionOnPath(r.Tool, path)
+	if have != "" && toolVersionMatches(r.Version, have) {
+		r.Status, r.Found = "installed", have
+		return
+	}
+	r.Status, r.Found = "missing", have
+}
+
+// toolInstallDirs are the directories holding one subdirectory per
+// installed version of tool
+func toolInstallDirs(tool string) []string {
+	home, _ := os.UserHomeDir()
+	miseData := os.Getenv("MISE_DATA_DIR")
+	if miseData == "" {
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+		miseData = filepath.Join(dataHome, "mise")
+	}
+	asdfName := tool
+	for alias, name := range toolAliases {
+		if name == tool {
+			asdfName = alias
+		}
+	}
+	dirs := []string{
+		filepath.Join(miseData, "installs", tool),
+		filepath.Join(asdfDataDir(), "installs", asdfName),
+	}
+
+	switch tool {
+	case "node":
+		nvmDir := os.Getenv("NVM_DIR")
+		if nvmDir == "" {
+			nvmDir = filepath.Join(home, ".nvm")
+		}
+		dirs = append(dirs, filepath.Join(nvmDir, "versions", "node"))
+	case "python":
+		pyenvRoot := os.Getenv("PYENV_ROOT")
+		if pyenvRoot == "" {
+			pyenvRoot = filepath.Join(home, ".pyenv")
+		}
+		dirs = append(dirs, filepath.Join(pyenvRoot, "versions"))
+	case "ruby":
+		dirs = append(dirs, filepath.Join(home, ".rbenv", "versions"))
+	}
+	return dirs
+}
+
+func asdfDataDir() string {
+	if dir := os.Getenv("ASDF_DATA_DIR"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".asdf")
+}
+
+// findToolInstall returns the newest installed version of tool matching
+// want, and its bin directory
+func findToolInstall(tool, want string) (string, string) {
+	for _, dir := range toolInstallDirs(tool) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		best := ""
+		for _, e := range entries {
+			name := e.Name()
+			if name == want || toolVersionMatches(want, name) && (best == "" || compareVersions(name, best) > 0) {
+				best = name
+				if name == want {
+					break
+				}
+			}
+		}
+		if best == "" {
+			continue
+		}
+		root := filepath.Join(dir, best)
+		// asdf's golang plugin nests the toolchain in go/
+		for _, bin := range []string{filepath.Join(root, "bin"), filepath.Join(root, "go", "bin")} {
+			if info, err := os.Stat(bin); err == nil && info.IsDir() {
+				return strings.TrimPrefix(best, "v"), bin
+			}
+		}
+	}
+	return "", ""
+}
+
+// toolVersionOnPath runs tool's version command as found on path and
+// returns the version it prints, or ""
+func toolVersionOnPath(tool, path string) string {
+	command, ok := toolBinaries[tool]
+	if !ok {
+		command = []string{tool, "--version"}
+	}
+	bin := lookPathIn(command[0], path)
+	if bin == "" {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	out, _ := exec.CommandContext(ctx, bin, command[1:]...).CombinedOutput()
+	return toolVersionRe.FindString(string(out))
+}
+
+// lookPathIn is exec.LookPath against a PATH other than the process's
+func lookPathIn(name, path string) string {
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	for _, dir := range filepath.SplitList(path) {
+		candidate := filepath.Join(dir, name)
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() && (runtime.GOOS == "windows" || info.Mode()&0111 != 0) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// toolInstallHint suggests how to install a missing runtime: with the
+// version manager in use, else with Homebrew
+func toolInstallHint(r *toolRequirement, brewfile map[string]bool) string {
+	isMiseFile := strings.Contains(filepath.Base(r.Source), "mise")
+	switch {
+	case commandExists("mise"):
+		return fmt.Sprintf("mise install %s@%s", r.Tool, r.Version)
+	case commandExists("asdf") && !isMiseFile:
+		name := r.Tool
+		for alias, tool := range toolAliases {
+			if tool == r.Tool {
+				name = alias
+			}
+		}
+		return fmt.Sprintf("asdf install %s %s", name, r.Version)
+	case commandExists("brew"):
+		formula := toolFormula(r.Tool, r.Version)
+		if brewfile[formula] {
+			return fmt.Sprintf("blackdot packages --install (%s is in the Brewfile)", formula)
+		}
+		return "brew install " + formula
+	}
+	return "install mise (https://mise.jdx.dev), then: mise install"
+}
+
+// toolFormula is the Homebrew formula for a runtime. Python and Node get
+// their versioned formula when the pin names one.
+func toolFormula(tool, version string) string {
+	if formula, ok := toolFormulas[tool]; ok {
+		return formula
+	}
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	switch {
+	case tool == "python" && len(parts) >= 2 && toolVersionRe.MatchString(parts[0]+"."+parts[1]):
+		return "python@" + parts[0] + "." + parts[1]
+	case tool == "node" && strings.Trim(parts[0], "0123456789") == "" && parts[0] != "":
+		return "node@" + parts[0]
+	}
+	return tool
+}
+
+// brewfileFormulaSet is the formulas in the Brewfile for the saved package
+// tier, including overlays
+func brewfileFormulaSet() map[string]bool {
+	set := make(map[string]bool)
+	blackdotDir := BlackdotDir()
+	brewfilePath, _, err := resolveBrewfile(blackdotDir, getPackageTier("", blackdotDir))
+	if err != nil {
+		return set
+	}
+	layered, _, cleanup, err := layeredBrewfile(brewfilePath)
+	if err != nil {
+		return set
+	}
+	defer cleanup()
+	formulas, _, _ := parseBrewfile(layered)
+	for _, f := range formulas {
+		set[f] = true
+	}
+	return set
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestReadToolVersions verifies pins are read from the directory up, the
+// nearest pin of a tool wins, and mise.toml wins over .tool-versions
+func TestReadToolVersions(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "project")
+	sub := filepath.Join(project, "sub")
+	os.MkdirAll(sub, 0755)
+	os.WriteFile(filepath.Join(root, ".tool-versions"), []byte("nodejs 18.19.0\nterraform 1.7.0\n"), 0644)
+	os.WriteFile(filepath.Join(project, ".tool-versions"), []byte("# runtimes\ngolang 1.22.1 1.21.0 # pinned\npython 3.11\n"), 0644)
+	os.WriteFile(filepath.Join(project, "mise.toml"), []byte(`[tools]
+python = ["3.12", "3.11"]
+node = { version = "20" }
+"npm:prettier" = "3"
+`), 0644)
+	os.WriteFile(filepath.Join(sub, ".ruby-version"), []byte("\n3.3.0\n"), 0644)
+
+	reqs, err := readToolVersions(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range reqs {
+		got = append(got, r.Tool+"@"+r.Version+"="+filepath.Base(r.Source))
+	}
+	want := "ruby@3.3.0=.ruby-version node@20=mise.toml python@3.12=mise.toml go@1.22.1=.tool-versions terraform@1.7.0=.tool-versions"
+	if strings.Join(got, " ") != want {
+		t.Errorf("pins = %s\nwant   %s", strings.Join(got, " "), want)
+	}
+
+	os.WriteFile(filepath.Join(project, "mise.toml"), []byte("[tools\n"), 0644)
+	if _, err := readToolVersions(sub); err == nil {
+		t.Error("a malformed mise.toml should be an error")
+	}
+}
+
+// TestToolVersionMatches verifies versions match on component boundaries
+// and aliases match anything
+func TestToolVersionMatches(t *testing.T) {
+	tests := []struct {
+		want, have string
+		match      bool
+	}{
+		{"3.12", "3.12.4", true},
+		{"3.12", "3.120.1", false},
+		{"20", "v20.11.0", true},
+		{"v20.11.0", "20.11.0", true},
+		{"1.22.1", "1.22.10", false},
+		{"lts/*", "18.19.0", true},
+		{"latest", "1.0.0", true},
+	}
+	for _, tt := range tests {
+		if got := toolVersionMatches(tt.want, tt.have); got != tt.match {
+			t.Errorf("toolVersionMatches(%q, %q) = %v", tt.want, tt.have, got)
+		}
+	}
+}
+
+// TestToolVersionsHook verifies installed runtimes replace the previous
+// project's PATH entries and missing ones are reported
+func TestToolVersionsHook(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv("MISE_DATA_DIR", data)
+	t.Setenv("ASDF_DATA_DIR", filepath.Join(data, "asdf"))
+	t.Setenv("MISE_SHELL", "")
+	for _, v := range []string{"20.9.0", "20.11.1", "21.0.0"} {
+		os.MkdirAll(filepath.Join(data, "installs", "node", v, "bin"), 0755)
+	}
+	project := t.TempDir()
+	os.WriteFile(filepath.Join(project, ".tool-versions"), []byte("nodejs 20\nnonexistent-tool 1.0\n"), 0644)
+
+	path := "/old/node/bin:/usr/bin"
+	out := runToolVersionsHook(project, path, "/old/node/bin")
+	bin := filepath.Join(data, "installs", "node", "20.11.1", "bin")
+	if !strings.Contains(out, "export PATH="+shellQuote(bin+":/usr/bin")+"\n") {
+		t.Errorf("PATH not updated:\n%s", out)
+	}
+	if !strings.Contains(out, "export BLACKDOT_TOOL_PATHS="+shellQuote(bin)+"\n") {
+		t.Errorf("added dirs not tracked:\n%s", out)
+	}
+	if !strings.Contains(out, "nonexistent-tool 1.0 (.tool-versions) is not installed") || !strings.Contains(out, ">&2") {
+		t.Errorf("missing tool not reported:\n%s", out)
+	}
+
+	// Leaving the project drops what was added
+	out = runToolVersionsHook(t.TempDir(), bin+":/usr/bin", bin)
+	if out != "export PATH=/usr/bin\nunset BLACKDOT_TOOL_PATHS\n" {
+		t.Errorf("leaving the project:\n%s", out)
+	}
+}
+
+// TestToolFormula verifies versioned Homebrew formulas for pinned runtimes
+func TestToolFormula(t *testing.T) {
+	for _, tt := range [][3]string{
+		{"python", "3.12.4", "python@3.12"},
+		{"node", "20", "node@20"},
+		{"node", "lts/*", "node"},
+		{"java", "21", "openjdk"},
+		{"go", "1.22.1", "go"},
+	} {
+		if got := toolFormula(tt[0], tt[1]); got != tt[2] {
+			t.Errorf("toolFormula(%s, %s) = %s, want %s", tt[0], tt[1], got, tt[2])
+		}
+	}
+}
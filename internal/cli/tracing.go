@@ -0,0 +1,418 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/scanners"
+	"github.com/spf13/cobra"
+)
+
+// tracedCommands are the long-running commands exported as traces when
+// telemetry.otlp_endpoint is set
+var tracedCommands = map[string]bool{
+	"blackdot setup":           true,
+	"blackdot doctor":          true,
+	"blackdot vault restore":   true,
+	"blackdot secrets restore": true,
+}
+
+// traceExportTimeout bounds the export at the end of a command
+const traceExportTimeout = 5 * time.Second
+
+// traceSpan is one span of the running command's trace
+type traceSpan struct {
+	id      [8]byte
+	parent  [8]byte // zero for a root without TRACEPARENT
+	name    string
+	start   time.Time
+	end     time.Time
+	attrs   map[string]string
+	events  []traceSpanEvent
+	failed  bool
+	message string
+}
+
+// traceSpanEvent is a point in time within a span, such as a doctor check
+type traceSpanEvent struct {
+	name  string
+	time  time.Time
+	attrs map[string]string
+}
+
+// tracer builds a trace from the progress events of the running command:
+// phases and items become spans under one root span per command
+type tracer struct {
+	mu       sync.Mutex
+	enabled  bool
+	endpoint string
+	headers  map[string]string
+	traceID  [16]byte
+	root     *traceSpan
+	phases   []*traceSpan          // open phase spans, innermost last
+	items    map[string]*traceSpan // by phase and item name
+	spans    []*traceSpan          // every span, in start order
+}
+
+var tracing = &tracer{}
+
+// startTracing begins a trace for cmd when it is a traced command and an
+// OTLP endpoint is configured. The endpoint is read from trusted layers
+// only, so a repository's project file cannot send telemetry elsewhere.
+func startTracing(cmd *cobra.Command) {
+	if !tracedCommands[cmd.CommandPath()] {
+		return
+	}
+	endpoint, _ := trustedConfigLookup("telemetry.otlp_endpoint")
+	if endpoint == "" {
+		return
+	}
+	headers, _ := trustedConfigLookup("telemetry.otlp_headers")
+
+	tracing.mu.Lock()
+	defer tracing.mu.Unlock()
+	tracing.begin(cmd.CommandPath(), traceEndpointURL(endpoint), parseTraceHeaders(headers), os.Getenv("TRACEPARENT"), time.Now())
+}
+
+// begin resets the tracer and opens the root span. A valid W3C traceparent
+// makes the run part of the caller's trace.
+func (t *tracer) begin(command, endpoint string, headers map[string]string, traceparent string, now time.Time) {
+	t.enabled = true
+	t.endpoint = endpoint
+	t.headers = headers
+	t.phases = nil
+	t.items = make(map[string]*traceSpan)
+	t.spans = nil
+	t.root = nil
+
+	var parent [8]byte
+	if id, span, ok := parseTraceparent(traceparent); ok {
+		t.traceID, parent = id, span
+	} else {
+		rand.Read(t.traceID[:])
+	}
+	t.root = t.newSpan(command, parent, now)
+	t.root.attrs["blackdot.command"] = command
+	t.root.attrs["blackdot.version"] = versionStr
+}
+
+func (t *tracer) newSpan(name string, parent [8]byte, now time.Time) *traceSpan {
+	s := &traceSpan{parent: parent, name: name, start: now, attrs: make(map[string]string)}
+	rand.Read(s.id[:])
+	t.spans = append(t.spans, s)
+	return s
+}
+
+// traceProgress adds a progress event to the trace. It costs nothing when
+// tracing is off.
+func traceProgress(ev progressEvent) {
+	tracing.mu.Lock()
+	defer tracing.mu.Unlock()
+	if !tracing.enabled {
+		return
+	}
+	tracing.observe(ev, time.Now())
+}
+
+// traceEndPhase closes a phase whose progress events have no end event,
+// such as a doctor section
+func traceEndPhase(name string) {
+	tracing.mu.Lock()
+	defer tracing.mu.Unlock()
+	if !tracing.enabled {
+		return
+	}
+	tracing.endPhase(name, "", "", time.Now())
+}
+
+// observe maps one progress event onto spans. An event without a status
+// opens a phase or item span and one with a status closes it; doctor's
+// pass/warn/fail results become events on the current phase.
+func (t *tracer) observe(ev progressEvent, now time.Time) {
+	if ev.Phase == "done" {
+		return
+	}
+
+	if ev.Item != "" {
+		key := ev.Phase + "\x00" + ev.Item
+		span := t.items[key]
+		if ev.Status == "" {
+			if span == nil || !span.end.IsZero() {
+				span = t.newSpan(ev.Item, t.phaseFor(ev.Phase, now).id, now)
+				span.attrs["blackdot.phase"] = ev.Phase
+				span.attrs["blackdot.item"] = ev.Item
+				t.items[key] = span
+			}
+			return
+		}
+		if span == nil {
+			span = t.newSpan(ev.Item, t.phaseFor(ev.Phase, now).id, now)
+			span.attrs["blackdot.phase"] = ev.Phase
+			span.attrs["blackdot.item"] = ev.Item
+			t.items[key] = span
+		}
+		// An item may be reported twice, e.g. fetched then restored
+		if span.end.IsZero() || ev.Status == reportStatusFailed {
+			span.finish(ev.Status, ev.Message, now)
+		}
+		return
+	}
+
+	switch ev.Status {
+	case "":
+		if top := t.top(); top != nil && top.name == ev.Phase {
+			return
+		}
+		span := t.newSpan(ev.Phase, t.current().id, now)
+		if ev.Message != "" {
+			span.attrs["blackdot.description"] = ev.Message
+		}
+		t.phases = append(t.phases, span)
+	case "pass", "warn", "fail":
+		span := t.current()
+		event := traceSpanEvent{name: "check", time: now, attrs: map[string]string{"blackdot.status": ev.Status, "blackdot.check": ev.Message}}
+		span.events = append(span.events, event)
+		if ev.Status == "fail" && span != t.root {
+			span.failed = true
+			span.message = "check failed: " + ev.Message
+		}
+	default:
+		if !t.endPhase(ev.Phase, ev.Status, ev.Message, now) {
+			// A phase reported only once it finished, e.g. skipped
+			span := t.newSpan(ev.Phase, t.current().id, now)
+			span.finish(ev.Status, ev.Message, now)
+		}
+	}
+}
+
+// endPhase closes the innermost open phase named name and any phases
+// opened inside it. It reports whether such a phase was open.
+func (t *tracer) endPhase(name, status, message string, now time.Time) bool {
+	for i := len(t.phases) - 1; i >= 0; i-- {
+		if t.phases[i].name != name {
+			continue
+		}
+		for _, inner := range t.phases[i+1:] {
+			inner.finish("", "", now)
+		}
+		t.phases[i].finish(status, message, now)
+		t.phases = t.phases[:i]
+		return true
+	}
+	return false
+}
+
+// phaseFor returns the open phase named name, opening it when items
+// arrive without a phase start
+func (t *tracer) phaseFor(name string, now time.Time) *traceSpan {
+	for i := len(t.phases) - 1; i >= 0; i-- {
+		if t.phases[i].name == name {
+			return t.phases[i]
+		}
+	}
+	span := t.newSpan(name, t.current().id, now)
+	t.phases = append(t.phases, span)
+	return span
+}
+
+func (t *tracer) top() *traceSpan {
+	if len(t.phases) == 0 {
+		return nil
+	}
+	return t.phases[len(t.phases)-1]
+}
+
+// current is the span new spans and events belong to
+func (t *tracer) current() *traceSpan {
+	if top := t.top(); top != nil {
+		return top
+	}
+	return t.root
+}
+
+// finish ends the span. Status is the progress status; failed marks the
+// span as an error.
+func (s *traceSpan) finish(status, message string, now time.Time) {
+	if s.end.IsZero() {
+		s.end = now
+	}
+	if status != "" {
+		s.attrs["blackdot.status"] = status
+	}
+	if status == reportStatusFailed {
+		s.failed = true
+		s.message = message
+	} else if message != "" {
+		s.attrs["blackdot.message"] = message
+	}
+}
+
+// finishTracing ends every open span and exports the trace. Export
+// failures never fail the command; --verbose shows them.
+func finishTracing(cmdErr error) {
+	tracing.mu.Lock()
+	if !tracing.enabled {
+		tracing.mu.Unlock()
+		return
+	}
+	tracing.enabled = false
+	now := time.Now()
+	for _, s := range tracing.spans {
+		if s.end.IsZero() && s != tracing.root {
+			s.finish("", "", now)
+		}
+	}
+	tracing.root.finish("", "", now)
+	if cmdErr != nil {
+		tracing.root.failed = true
+		tracing.root.message = cmdErr.Error()
+	}
+	payload := tracing.otlpPayload()
+	endpoint, headers := tracing.endpoint, tracing.headers
+	tracing.mu.Unlock()
+
+	if err := exportTrace(endpoint, headers, payload); err != nil {
+		Debug("exporting trace: %v", err)
+	}
+}
+
+// exportTrace posts an OTLP/HTTP JSON request
+func exportTrace(endpoint string, headers map[string]string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), traceExportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: %s", endpoint, resp.Status)
+	}
+	return nil
+}
+
+// otlpPayload is the trace as an OTLP ExportTraceServiceRequest in its JSON
+// encoding. Every string attribute is passed through the secret scanner.
+func (t *tracer) otlpPayload() map[string]any {
+	scrub := scanners.New()
+	attrs := func(m map[string]string) []map[string]any {
+		out := []map[string]any{}
+		for _, k := range sortedKeys(m) {
+			out = append(out, map[string]any{"key": k, "value": map[string]any{"stringValue": scrub.Mask(m[k])}})
+		}
+		return out
+	}
+	nanos := func(ts time.Time) string { return strconv.FormatInt(ts.UnixNano(), 10) }
+
+	traceID := hex.EncodeToString(t.traceID[:])
+	spans := []map[string]any{}
+	for _, s := range t.spans {
+		span := map[string]any{
+			"traceId":           traceID,
+			"spanId":            hex.EncodeToString(s.id[:]),
+			"name":              s.name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": nanos(s.start),
+			"endTimeUnixNano":   nanos(s.end),
+			"attributes":        attrs(s.attrs),
+			"status":            map[string]any{"code": 1}, // STATUS_CODE_OK
+		}
+		if s.parent != [8]byte{} {
+			span["parentSpanId"] = hex.EncodeToString(s.parent[:])
+		}
+		if s.failed {
+			span["status"] = map[string]any{"code": 2, "message": scrub.Mask(s.message)} // STATUS_CODE_ERROR
+		}
+		var events []map[string]any
+		for _, e := range s.events {
+			events = append(events, map[string]any{"name": e.name, "timeUnixNano": nanos(e.time), "attributes": attrs(e.attrs)})
+		}
+		if events != nil {
+			span["events"] = events
+		}
+		spans = append(spans, span)
+	}
+
+	hostname, _ := os.Hostname()
+	resource := map[string]string{
+		"service.name":    "blackdot",
+		"service.version": versionStr,
+		"host.name":       hostname,
+		"os.type":         runtime.GOOS,
+	}
+	return map[string]any{
+		"resourceSpans": []map[string]any{{
+			"resource": map[string]any{"attributes": attrs(resource)},
+			"scopeSpans": []map[string]any{{
+				"scope": map[string]any{"name": "blackdot", "version": versionStr},
+				"spans": spans,
+			}},
+		}},
+	}
+}
+
+// traceEndpointURL adds the OTLP traces path to a collector base URL
+func traceEndpointURL(endpoint string) string {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if u, err := url.Parse(endpoint); err == nil && (u.Path == "" || u.Path == "/") {
+		return endpoint + "/v1/traces"
+	}
+	return endpoint
+}
+
+// parseTraceHeaders reads "key=value,key=value", the format of
+// OTEL_EXPORTER_OTLP_HEADERS
+func parseTraceHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			v = unescaped
+		}
+		headers[strings.TrimSpace(k)] = v
+	}
+	return headers
+}
+
+// parseTraceparent reads a W3C traceparent ("00-<trace id>-<span id>-<flags>")
+func parseTraceparent(s string) (traceID [16]byte, spanID [8]byte, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return traceID, spanID, false
+	}
+	tid, err1 := hex.DecodeString(parts[1])
+	sid, err2 := hex.DecodeString(parts[2])
+	if err1 != nil || err2 != nil || len(tid) != 16 || len(sid) != 8 {
+		return traceID, spanID, false
+	}
+	copy(traceID[:], tid)
+	copy(spanID[:], sid)
+	return traceID, spanID, traceID != [16]byte{} && spanID != [8]byte{}
+}
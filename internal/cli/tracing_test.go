@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// otlpTestSpan is the part of an exported span the tests inspect
+type otlpTestSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Attributes   []struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+		} `json:"value"`
+	} `json:"attributes"`
+	Events []struct {
+		Name string `json:"name"`
+	} `json:"events"`
+	Status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+func (s otlpTestSpan) attr(key string) string {
+	for _, a := range s.Attributes {
+		if a.Key == key {
+			return a.Value.StringValue
+		}
+	}
+	return ""
+}
+
+// TestTraceExport verifies progress events become nested phase and item
+// spans, the trace is posted as OTLP JSON with the configured headers, and
+// secrets in messages are redacted
+func TestTraceExport(t *testing.T) {
+	var body []byte
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("posted to %s", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	t.Setenv("BLACKDOT_TELEMETRY_OTLP_ENDPOINT", server.URL)
+	t.Setenv("BLACKDOT_TELEMETRY_OTLP_HEADERS", "Authorization=Bearer%20abc")
+	t.Setenv("TRACEPARENT", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	cmd, _, err := rootCmd.Find([]string{"setup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	startTracing(cmd)
+
+	token := "ghp_" + "aB3dE5fG7hJ9kL1mN3pQ5rS7tU9vW1xY3zA5"
+	emitProgress(progressEvent{Phase: "workspace", Message: "Configure workspace"})
+	emitProgress(progressEvent{Phase: "workspace", Status: "ok"})
+	emitProgress(progressEvent{Phase: "symlinks", Status: "skipped", Message: "already completed"})
+	emitProgress(progressEvent{Phase: "secrets"})
+	emitProgress(progressEvent{Phase: "sync"})
+	emitProgress(progressEvent{Phase: "sync", Status: "ok"})
+	emitProgress(progressEvent{Phase: "restore", Item: "Git-Config"})
+	emitProgress(progressEvent{Phase: "restore", Item: "Git-Config", Status: reportStatusRestored})
+	emitProgress(progressEvent{Phase: "restore", Item: "GitHub-Token", Status: reportStatusFailed, Message: "bad token " + token})
+	emitProgress(progressEvent{Phase: "secrets", Status: "ok"})
+	finishProgressEvents(errors.New("1 items failed to restore"))
+	finishTracing(errors.New("1 items failed to restore"))
+
+	if auth != "Bearer abc" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if strings.Contains(string(body), token) {
+		t.Errorf("token exported unredacted")
+	}
+	var req struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []otlpTestSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("bad payload %q: %v", body, err)
+	}
+	spans := map[string]otlpTestSpan{}
+	for _, s := range req.ResourceSpans[0].ScopeSpans[0].Spans {
+		if s.TraceID != "0af7651916cd43dd8448eb211c80319c" {
+			t.Errorf("%s: trace id %s", s.Name, s.TraceID)
+		}
+		spans[s.Name] = s
+	}
+
+	parents := map[string]string{
+		"blackdot setup": "b7ad6b7169203331",
+		"workspace":      spans["blackdot setup"].SpanID,
+		"symlinks":       spans["blackdot setup"].SpanID,
+		"secrets":        spans["blackdot setup"].SpanID,
+		"sync":           spans["secrets"].SpanID,
+		"restore":        spans["secrets"].SpanID,
+		"Git-Config":     spans["restore"].SpanID,
+		"GitHub-Token":   spans["restore"].SpanID,
+	}
+	if len(spans) != len(parents) {
+		t.Errorf("got %d spans, want %d", len(spans), len(parents))
+	}
+	for name, parent := range parents {
+		if got := spans[name].ParentSpanID; got != parent {
+			t.Errorf("%s: parent %q, want %q", name, got, parent)
+		}
+	}
+
+	if s := spans["symlinks"]; s.attr("blackdot.status") != "skipped" || s.Status.Code != 1 {
+		t.Errorf("symlinks = %+v", s)
+	}
+	if s := spans["GitHub-Token"]; s.Status.Code != 2 || !strings.HasPrefix(s.Status.Message, "bad token ghp_") {
+		t.Errorf("GitHub-Token status = %+v", s.Status)
+	}
+	if s := spans["blackdot setup"]; s.Status.Code != 2 || s.attr("blackdot.command") != "blackdot setup" {
+		t.Errorf("root = %+v", s)
+	}
+}
+
+// TestTraceDoctorSections verifies each doctor section is its own span with
+// checks as events, and a failed check marks its section as an error
+func TestTraceDoctorSections(t *testing.T) {
+	tr := &tracer{}
+	now := time.Now()
+	tr.begin("blackdot doctor", "", nil, "", now)
+
+	state := &doctorState{}
+	tracing = tr
+	t.Cleanup(func() { tracing = &tracer{} })
+	state.section("Core Components")
+	state.checkEvent("pass", "blackdot installed")
+	state.section("SSH Configuration")
+	state.checkEvent("fail", "~/.ssh is world-readable")
+
+	if len(tr.spans) != 3 {
+		t.Fatalf("got %d spans", len(tr.spans))
+	}
+	core, ssh := tr.spans[1], tr.spans[2]
+	if core.end.IsZero() || core.parent != tr.root.id || len(core.events) != 1 || core.failed {
+		t.Errorf("core = %+v", core)
+	}
+	if ssh.parent != tr.root.id || !ssh.failed || len(ssh.events) != 1 {
+		t.Errorf("ssh = %+v", ssh)
+	}
+}
+
+// TestTraceHelpers verifies endpoint, header, and traceparent parsing
+func TestTraceHelpers(t *testing.T) {
+	for in, want := range map[string]string{
+		"http://localhost:4318":              "http://localhost:4318/v1/traces",
+		"http://localhost:4318/":             "http://localhost:4318/v1/traces",
+		"https://otel.example.com/v1/traces": "https://otel.example.com/v1/traces",
+		"https://gw.example.com/otlp/traces": "https://gw.example.com/otlp/traces",
+	} {
+		if got := traceEndpointURL(in); got != want {
+			t.Errorf("traceEndpointURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	headers := parseTraceHeaders("api-key=abc, x-team = infra ,bogus")
+	if len(headers) != 2 || headers["api-key"] != "abc" || headers["x-team"] != "infra" {
+		t.Errorf("headers = %v", headers)
+	}
+
+	tid, sid, ok := parseTraceparent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	if !ok || hex.EncodeToString(tid[:]) != "0af7651916cd43dd8448eb211c80319c" || hex.EncodeToString(sid[:]) != "b7ad6b7169203331" {
+		t.Errorf("parseTraceparent = %x %x %v", tid, sid, ok)
+	}
+	for _, bad := range []string{"", "01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "00-00000000000000000000000000000000-b7ad6b7169203331-01", "00-xyz-b7ad6b7169203331-01"} {
+		if _, _, ok := parseTraceparent(bad); ok {
+			t.Errorf("parseTraceparent(%q) accepted", bad)
+		}
+	}
+}
@@ -1,12 +1,11 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
+	"github.com/blackwell-systems/blackdot/internal/prompts"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -97,6 +96,11 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 	// Also check /workspace symlink
 	removeItem("/workspace", "symlink", dryRun, green, yellow)
 
+	// Remove login items
+	fmt.Println()
+	fmt.Println(blue("Removing login items..."))
+	removeAllAutostart(dryRun)
+
 	// Remove config files
 	fmt.Println()
 	fmt.Println(blue("Removing config files..."))
@@ -114,12 +118,7 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 		fmt.Println(yellow("WARNING: This will delete SSH keys, AWS credentials, etc."))
 
 		if !dryRun {
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Print("Are you sure? (yes/no): ")
-			confirm, _ := reader.ReadString('\n')
-			confirm = strings.TrimSpace(confirm)
-
-			if confirm != "yes" {
+			if ok, _ := prompts.ConfirmText("Are you sure? (yes/no):", "yes"); !ok {
 				fmt.Println("Keeping secrets.")
 				keepSecrets = true
 			}
@@ -140,12 +139,7 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 		if dryRun {
 			fmt.Printf("  %s: %s (repository)\n", yellow("Would remove"), blackdotDir)
 		} else {
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Print("Remove blackdot repository? (yes/no): ")
-			confirm, _ := reader.ReadString('\n')
-			confirm = strings.TrimSpace(confirm)
-
-			if confirm == "yes" {
+			if ok, _ := prompts.ConfirmText("Remove blackdot repository? (yes/no):", "yes"); ok {
 				if err := os.RemoveAll(blackdotDir); err != nil {
 					red.Printf("  Failed to remove: %s: %v\n", blackdotDir, err)
 				} else {
@@ -0,0 +1,587 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// releaseAPIURL is the GitHub releases API for blackdot. BLACKDOT_RELEASE_API
+// overrides it for mirrors and tests.
+const releaseAPIURL = "https://api.github.com/repos/blackwell-systems/blackdot/releases"
+
+// checksumAsset lists the SHA256 of every release file; checksumSigAsset is
+// an optional detached ed25519 signature over it
+const (
+	checksumAsset    = "SHA256SUMS.txt"
+	checksumSigAsset = "SHA256SUMS.txt.sig"
+)
+
+type upgradeOptions struct {
+	Check        bool
+	Version      string
+	BinaryOnly   bool
+	RepoOnly     bool
+	Force        bool
+	SkipChecksum bool
+}
+
+type githubRelease struct {
+	TagName    string         `json:"tag_name"`
+	Prerelease bool           `json:"prerelease"`
+	Assets     []releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+func (r *githubRelease) asset(name string) *releaseAsset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+func newUpgradeCmd() *cobra.Command {
+	var opts upgradeOptions
+
+	cmd := &cobra.Command{
+		Use:     "upgrade",
+		Aliases: []string{"update"},
+		Short:   "Update the blackdot binary and repository",
+		Long: `Update blackdot to the latest release.
+
+The binary is updated from GitHub releases:
+  1. Look up the latest (or --version) release
+  2. Download the binary for this platform
+  3. Verify it against SHA256SUMS.txt, and against SHA256SUMS.txt.sig
+     when upgrade.public_key is configured
+  4. Replace the running binary atomically
+
+The blackdot repository is then updated with 'git pull --rebase'. Local
+changes are stashed first and restored afterwards; if the rebase fails it
+is aborted and the repository is left as it was.
+
+Runs the pre_upgrade and post_upgrade hooks.
+
+Examples:
+  blackdot upgrade                  # Binary and repository
+  blackdot upgrade --check          # Only report whether an update exists
+  blackdot upgrade --version v3.2.0 # Install a specific release
+  blackdot upgrade --repo-only      # Only git pull the repository`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// --force is the global flag: reinstall even when up to date
+			opts.Force = force
+			return runUpgrade(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Check, "check", false, "Check for an update without installing")
+	cmd.Flags().StringVar(&opts.Version, "version", "", "Release tag to install (default: latest)")
+	cmd.Flags().BoolVar(&opts.BinaryOnly, "binary-only", false, "Only update the binary")
+	cmd.Flags().BoolVar(&opts.RepoOnly, "repo-only", false, "Only update the repository")
+	cmd.Flags().BoolVar(&opts.SkipChecksum, "skip-checksum", false, "Do not verify the download (not recommended)")
+	return cmd
+}
+
+func runUpgrade(ctx context.Context, opts upgradeOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts.BinaryOnly && opts.RepoOnly {
+		return fmt.Errorf("--binary-only and --repo-only are mutually exclusive")
+	}
+
+	PrintHeader("blackdot upgrade")
+
+	if !opts.Check {
+		if err := triggerHooks("pre_upgrade", hookContext{}); err != nil {
+			return err
+		}
+	}
+
+	if !opts.RepoOnly {
+		if err := upgradeBinary(ctx, opts); err != nil {
+			return err
+		}
+	}
+
+	if !opts.BinaryOnly && !opts.Check {
+		fmt.Println()
+		if err := upgradeRepo(BlackdotDir()); err != nil {
+			return err
+		}
+	}
+
+	if opts.Check {
+		return nil
+	}
+	return triggerHooks("post_upgrade", hookContext{})
+}
+
+// upgradeBinary replaces the running binary with the release build
+func upgradeBinary(ctx context.Context, opts upgradeOptions) error {
+	release, err := fetchRelease(ctx, opts.Version)
+	if err != nil {
+		Fail("Cannot look up release: %v", err)
+		return err
+	}
+
+	current := versionStr
+	latest := release.TagName
+	saveUpgradeCheck(latest)
+	newer := compareVersions(latest, current) > 0
+	if current == "dev" {
+		Info("Current: development build")
+	} else {
+		Info("Current: %s", current)
+	}
+	Info("Release: %s", latest)
+
+	if opts.Check {
+		if newer || current == "dev" {
+			Warn("Update available: %s → %s", current, latest)
+			Info("Run: blackdot upgrade")
+		} else {
+			Pass("Up to date")
+		}
+		return nil
+	}
+
+	if !newer && !opts.Force && opts.Version == "" {
+		if current == "dev" {
+			Info("Development build; use --force to replace it with %s", latest)
+		} else {
+			Pass("Binary is up to date")
+		}
+		return nil
+	}
+
+	name := releaseAssetName(runtime.GOOS, runtime.GOARCH)
+	asset := release.asset(name)
+	if asset == nil {
+		err := fmt.Errorf("release %s has no %s", latest, name)
+		Fail("%v", err)
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		Fail("Cannot locate the running binary: %v", err)
+		return err
+	}
+	cleanupOldBinary(exe)
+
+	var expected string
+	if !opts.SkipChecksum {
+		expected, err = releaseChecksum(ctx, release, name)
+		if err != nil {
+			Fail("Cannot verify release: %v", err)
+			return err
+		}
+	} else {
+		Warn("Skipping checksum verification")
+	}
+
+	Info("Downloading %s...", name)
+	tmp, err := downloadVerified(ctx, asset.URL, filepath.Dir(exe), expected)
+	if err != nil {
+		Fail("Download failed: %v", err)
+		return err
+	}
+	defer os.Remove(tmp)
+	if expected != "" {
+		Pass("Checksum verified")
+	}
+
+	// Refuse a binary that cannot start, before it replaces a working one
+	if out, err := exec.CommandContext(ctx, tmp, "version").CombinedOutput(); err != nil {
+		err = fmt.Errorf("downloaded binary does not run: %v %s", err, strings.TrimSpace(string(out)))
+		Fail("%v", err)
+		return err
+	}
+
+	if err := replaceExecutable(exe, tmp); err != nil {
+		Fail("Cannot replace %s: %v", exe, err)
+		return err
+	}
+	Pass("Updated %s → %s (%s)", current, latest, tildePath(exe))
+	return nil
+}
+
+// upgradeCheck is the last release lookup, shown by 'blackdot status'
+type upgradeCheck struct {
+	Checked string `json:"checked"`
+	Latest  string `json:"latest"`
+}
+
+func upgradeCheckPath() string {
+	return filepath.Join(paths.CacheDir(), "upgrade-check.json")
+}
+
+func saveUpgradeCheck(latest string) {
+	data, _ := json.Marshal(upgradeCheck{Checked: time.Now().UTC().Format(time.RFC3339), Latest: latest})
+	_ = writeFileWithPolicy(upgradeCheckPath(), data, fileClassPrivate)
+}
+
+// loadUpgradeCheck returns the last release lookup, or nil
+func loadUpgradeCheck() *upgradeCheck {
+	data, err := os.ReadFile(upgradeCheckPath())
+	if err != nil {
+		return nil
+	}
+	var check upgradeCheck
+	if json.Unmarshal(data, &check) != nil || check.Latest == "" {
+		return nil
+	}
+	return &check
+}
+
+// releaseAssetName is the binary name published for a platform
+func releaseAssetName(goos, goarch string) string {
+	name := fmt.Sprintf("blackdot-%s-%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+func releaseAPI() string {
+	if url := os.Getenv("BLACKDOT_RELEASE_API"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return releaseAPIURL
+}
+
+var upgradeHTTPClient = &http.Client{Timeout: 5 * time.Minute}
+
+func httpGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "blackdot/"+versionStr)
+	if strings.Contains(url, "api.github.com") {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	resp, err := upgradeHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// fetchRelease returns the latest release, or the release tagged version
+func fetchRelease(ctx context.Context, version string) (*githubRelease, error) {
+	url := releaseAPI() + "/latest"
+	if version != "" {
+		if !strings.HasPrefix(version, "v") {
+			version = "v" + version
+		}
+		url = releaseAPI() + "/tags/" + version
+	}
+
+	resp, err := httpGet(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("invalid release response: %w", err)
+	}
+	if release.TagName == "" {
+		return nil, fmt.Errorf("release response has no tag")
+	}
+	return &release, nil
+}
+
+// releaseChecksum downloads SHA256SUMS.txt, verifies its signature when a
+// public key is configured, and returns the checksum for name
+func releaseChecksum(ctx context.Context, release *githubRelease, name string) (string, error) {
+	sums := release.asset(checksumAsset)
+	if sums == nil {
+		return "", fmt.Errorf("release %s has no %s (use --skip-checksum to override)", release.TagName, checksumAsset)
+	}
+	data, err := downloadBytes(ctx, sums.URL)
+	if err != nil {
+		return "", err
+	}
+
+	if key := configLookup("upgrade.public_key"); key != "" {
+		sig := release.asset(checksumSigAsset)
+		if sig == nil {
+			return "", fmt.Errorf("upgrade.public_key is set but release %s is not signed", release.TagName)
+		}
+		sigData, err := downloadBytes(ctx, sig.URL)
+		if err != nil {
+			return "", err
+		}
+		if err := verifyChecksumSignature(key, data, sigData); err != nil {
+			return "", err
+		}
+		Pass("Signature verified")
+	}
+
+	sum := parseChecksums(data)[name]
+	if sum == "" {
+		return "", fmt.Errorf("%s has no entry for %s", checksumAsset, name)
+	}
+	return sum, nil
+}
+
+// parseChecksums reads sha256sum output ("<hex>  <name>"), skipping
+// comments and blank lines
+func parseChecksums(data []byte) map[string]string {
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return sums
+}
+
+// verifyChecksumSignature checks a detached ed25519 signature. Both the key
+// and the signature are base64 encoded.
+func verifyChecksumSignature(publicKey string, data, signature []byte) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("upgrade.public_key is not a base64 ed25519 public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return fmt.Errorf("%s signature does not match upgrade.public_key", checksumAsset)
+	}
+	return nil
+}
+
+func downloadBytes(ctx context.Context, url string) ([]byte, error) {
+	resp, err := httpGet(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// downloadVerified streams url into a temp file in dir, hashing as it goes.
+// The file is removed unless its checksum matches expected (when set).
+func downloadVerified(ctx context.Context, url, dir, expected string) (string, error) {
+	resp, err := httpGet(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	f, err := os.CreateTemp(dir, ".blackdot-upgrade-*")
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0755)
+	}
+	if err == nil && expected != "" {
+		if got := hex.EncodeToString(h.Sum(nil)); got != strings.ToLower(expected) {
+			err = fmt.Errorf("checksum mismatch: got %s, want %s", got, expected)
+		}
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// replaceExecutable moves newPath over exe. Windows cannot overwrite a
+// running executable but can rename it, so the old binary is moved aside
+// first and restored if the swap fails.
+func replaceExecutable(exe, newPath string) error {
+	if runtime.GOOS != "windows" {
+		return os.Rename(newPath, exe)
+	}
+
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(newPath, exe); err != nil {
+		os.Rename(old, exe)
+		return err
+	}
+	// Fails while the old binary is still running; cleanupOldBinary
+	// retries on the next upgrade
+	os.Remove(old)
+	return nil
+}
+
+// cleanupOldBinary removes the binary a previous Windows upgrade moved aside
+func cleanupOldBinary(exe string) {
+	os.Remove(exe + ".old")
+}
+
+// compareVersions compares dotted versions with an optional "v" prefix and
+// pre-release suffix. A release sorts after its pre-releases. Unparseable
+// versions (such as "dev") sort first.
+func compareVersions(a, b string) int {
+	pa, preA, okA := parseVersion(a)
+	pb, preB, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := 0; i < 3; i++ {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	case preA < preB:
+		return -1
+	}
+	return 1
+}
+
+func parseVersion(v string) ([3]int, string, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, pre, _ := strings.Cut(v, "-")
+	v, _, _ = strings.Cut(v, "+")
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, "", false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, "", false
+		}
+		parts[i] = n
+	}
+	return parts, pre, true
+}
+
+// upgradeRepo pulls the blackdot repository. Uncommitted changes are
+// stashed and restored; a failed rebase is aborted so the working tree is
+// left as it was.
+func upgradeRepo(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		Info("%s is not a git repository, skipping pull", tildePath(dir))
+		return nil
+	}
+
+	git := func(args ...string) (string, error) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		return strings.TrimSpace(string(out)), err
+	}
+
+	branch, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil || branch == "HEAD" {
+		Warn("Repository is not on a branch, skipping pull")
+		return nil
+	}
+	before, _ := git("rev-parse", "HEAD")
+
+	status, err := git("status", "--porcelain")
+	if err != nil {
+		Fail("git status failed: %s", status)
+		return err
+	}
+	stashed := false
+	if status != "" {
+		msg := "blackdot upgrade " + time.Now().Format(time.RFC3339)
+		if out, err := git("stash", "push", "--include-untracked", "-m", msg); err != nil {
+			Fail("Cannot stash local changes: %s", out)
+			return err
+		}
+		stashed = true
+		Info("Stashed local changes")
+	}
+
+	Info("Pulling %s...", branch)
+	if out, err := git("pull", "--rebase"); err != nil {
+		git("rebase", "--abort")
+		if stashed {
+			git("stash", "pop")
+		}
+		Fail("git pull failed, repository unchanged:\n%s", out)
+		return fmt.Errorf("git pull failed")
+	}
+
+	if stashed {
+		if out, err := git("stash", "pop"); err != nil {
+			Warn("Local changes conflict with the update and were kept in the stash:\n%s", out)
+			Info("Resolve with: git -C %s stash show -p | git apply", tildePath(dir))
+		} else {
+			Pass("Restored local changes")
+		}
+	}
+
+	after, _ := git("rev-parse", "HEAD")
+	if before == after {
+		Pass("Repository is up to date")
+		return nil
+	}
+	count, _ := git("rev-list", "--count", before+".."+after)
+	Pass("Pulled %s commit(s)", count)
+	Info("Run 'blackdot doctor' to check the updated setup")
+	return nil
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCompareVersions verifies numeric ordering, v prefixes, pre-releases
+// and dev builds
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"v3.2.0", "v3.1.9", 1},
+		{"3.10.0", "v3.9.0", 1},
+		{"v3.2", "v3.2.0", 0},
+		{"v3.2.0-rc.1", "v3.2.0", -1},
+		{"v3.2.0", "dev", 1},
+		{"dev", "dev", 0},
+	} {
+		if got := compareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+// TestReleaseChecksumAndDownload verifies the checksum file is parsed, a
+// configured signature is enforced and a tampered download is rejected
+func TestReleaseChecksumAndDownload(t *testing.T) {
+	t.Setenv("BLACKDOT_UPGRADE_PUBLIC_KEY", "")
+	binary := []byte("#!/bin/sh\necho ok\n")
+	sum := sha256.Sum256(binary)
+	name := releaseAssetName("linux", "amd64")
+	sums := []byte("# Go Binaries\n" + hex.EncodeToString(sum[:]) + "  " + name + "\n")
+
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, sums))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sums":
+			w.Write(sums)
+		case "/sig":
+			w.Write([]byte(sig))
+		case "/bin":
+			w.Write(binary)
+		case "/bad":
+			w.Write([]byte("tampered"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	release := &githubRelease{TagName: "v9.0.0", Assets: []releaseAsset{
+		{Name: checksumAsset, URL: srv.URL + "/sums"},
+		{Name: checksumSigAsset, URL: srv.URL + "/sig"},
+	}}
+	ctx := context.Background()
+
+	got, err := releaseChecksum(ctx, release, name)
+	if err != nil || got != hex.EncodeToString(sum[:]) {
+		t.Fatalf("releaseChecksum = %q, %v", got, err)
+	}
+
+	t.Setenv("BLACKDOT_UPGRADE_PUBLIC_KEY", base64.StdEncoding.EncodeToString(pub))
+	if _, err := releaseChecksum(ctx, release, name); err != nil {
+		t.Errorf("valid signature: %v", err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	t.Setenv("BLACKDOT_UPGRADE_PUBLIC_KEY", base64.StdEncoding.EncodeToString(otherPub))
+	if _, err := releaseChecksum(ctx, release, name); err == nil {
+		t.Error("signature from another key should fail")
+	}
+
+	dir := t.TempDir()
+	path, err := downloadVerified(ctx, srv.URL+"/bin", dir, got)
+	if err != nil {
+		t.Fatalf("downloadVerified: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(binary) {
+		t.Errorf("downloaded %q", data)
+	}
+	if _, err := downloadVerified(ctx, srv.URL+"/bad", dir, got); err == nil {
+		t.Error("tampered download should fail")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("failed download left files behind: %v", entries)
+	}
+}
+
+// TestReplaceExecutable verifies the new binary takes the old one's place
+func TestReplaceExecutable(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "blackdot")
+	next := filepath.Join(dir, "next")
+	os.WriteFile(exe, []byte("old"), 0755)
+	os.WriteFile(next, []byte("new"), 0755)
+
+	if err := replaceExecutable(exe, next); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "new" {
+		t.Errorf("exe = %q", data)
+	}
+	if _, err := os.Stat(next); !os.IsNotExist(err) {
+		t.Error("new binary should have been moved")
+	}
+}
+
+// TestUpgradeRepoStashesChanges verifies local changes survive a pull
+func TestUpgradeRepoStashesChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	root := t.TempDir()
+	upstream := filepath.Join(root, "upstream")
+	clone := filepath.Join(root, "clone")
+	git := func(dir string, args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	os.MkdirAll(upstream, 0755)
+	git(upstream, "init", "-q", "-b", "main")
+	os.WriteFile(filepath.Join(upstream, "a.txt"), []byte("one\n"), 0644)
+	git(upstream, "add", ".")
+	git(upstream, "commit", "-qm", "one")
+	git(root, "clone", "-q", upstream, clone)
+
+	os.WriteFile(filepath.Join(upstream, "b.txt"), []byte("two\n"), 0644)
+	git(upstream, "add", ".")
+	git(upstream, "commit", "-qm", "two")
+
+	os.WriteFile(filepath.Join(clone, "local.txt"), []byte("mine\n"), 0644)
+
+	if err := upgradeRepo(clone); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(clone, "b.txt")); err != nil {
+		t.Error("upstream commit not pulled")
+	}
+	if data, err := os.ReadFile(filepath.Join(clone, "local.txt")); err != nil || !strings.Contains(string(data), "mine") {
+		t.Error("local change not restored")
+	}
+}
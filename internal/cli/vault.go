@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"bufio"
 	"context"
 	"crypto/sha256"
 	"encoding/json"
@@ -12,11 +11,22 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/blackwell-systems/blackdot/internal/config"
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/plan"
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+	"github.com/blackwell-systems/blackdot/internal/vault"
+	"github.com/blackwell-systems/blackdot/internal/vault/doppler"
+	"github.com/blackwell-systems/blackdot/internal/vault/keychain"
 	"github.com/blackwell-systems/vaultmux"
+	_ "github.com/blackwell-systems/vaultmux/backends/awssecrets"
 	_ "github.com/blackwell-systems/vaultmux/backends/bitwarden"
 	_ "github.com/blackwell-systems/vaultmux/backends/onepassword"
 	_ "github.com/blackwell-systems/vaultmux/backends/pass"
@@ -40,6 +50,29 @@ func getVaultBackend() vaultmux.BackendType {
 	return vaultmux.BackendBitwarden
 }
 
+// keychainAvailable reports whether the OS credential store answers. The
+// Secret Service can hang without a D-Bus session, so the probe is bounded.
+func keychainAvailable() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return keychain.Available(ctx) == nil
+}
+
+// keychainHint says how to make the OS credential store usable
+func keychainHint() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "security unlock-keychain"
+	case "windows":
+		return "Credential Manager is driven through powershell.exe; make sure it is on PATH"
+	default:
+		return "Install libsecret-tools (secret-tool) and run an unlocked Secret Service such as gnome-keyring or KWallet"
+	}
+}
+
+// vaultSessionTTL is how long a cached vault session is used, in seconds
+const vaultSessionTTL = 1800
+
 // getSessionFile returns the session cache file path
 func getSessionFile() string {
 	if file := os.Getenv("VAULT_SESSION_FILE"); file != "" {
@@ -50,16 +83,83 @@ func getSessionFile() string {
 
 // newVaultBackend creates a new vault backend with config
 func newVaultBackend() (vaultmux.Backend, error) {
-	backendType := getVaultBackend()
+	return newVaultBackendFor(getVaultBackend())
+}
 
+// newVaultBackendFor creates a backend of the given type with the shared
+// session settings
+func newVaultBackendFor(backendType vaultmux.BackendType) (vaultmux.Backend, error) {
+	sessionFile, sessionCopy := openVaultSessionCopy(backendType)
 	cfg := vaultmux.Config{
 		Backend:     backendType,
-		SessionFile: getSessionFile(),
-		SessionTTL:  1800, // 30 minutes
+		SessionFile: sessionFile,
+		SessionTTL:  vaultSessionTTL,
 		Prefix:      "blackdot",
+		Options:     vaultBackendOptions(backendType),
+	}
+
+	backend, err := vaultmux.New(cfg)
+	if err != nil {
+		if sessionCopy != nil {
+			sessionCopy.close()
+		}
+		return nil, err
+	}
+	if sessionCopy != nil {
+		backend = &sessionBackend{Backend: backend, copy: sessionCopy}
 	}
+	backend = newRetryBackend(backend, backendType)
+	if timings.active() {
+		backend = newTimedBackend(backend, backendType)
+	}
+	backend = newBulkBackend(backend, backendType)
+	backend = &recordingBackend{Backend: backend, backendType: backendType}
+	if vaultAuditEnabled() {
+		backend = &auditBackend{Backend: backend, backendType: backendType}
+	}
+	return vault.WithHistory(backend, vaultHistoryKeep()), nil
+}
+
+// cliVaultReporter prints vault service progress
+type cliVaultReporter struct{}
+
+func (cliVaultReporter) Info(format string, a ...interface{}) { Info(format, a...) }
+func (cliVaultReporter) Warn(format string, a ...interface{}) { Warn(format, a...) }
+
+// newVaultService wraps the configured backend in a vault.Service
+func newVaultService() (*vault.Service, error) {
+	backend, err := newVaultBackend()
+	if err != nil {
+		return nil, err
+	}
+	return vault.New(backend, cliVaultReporter{}), nil
+}
+
+// failVaultOp prints a service error: the connect stage that failed, or
+// the operation's own error after what
+func failVaultOp(what string, err error) {
+	var stage *vault.StageError
+	switch {
+	case errors.As(err, &stage) && stage.Stage == vault.StageInit:
+		Fail("Backend not available: %v", stage.Err)
+	case stage != nil:
+		Fail("Authentication required: %v", stage.Err)
+	default:
+		Fail("%s: %v", what, err)
+	}
+}
 
-	return vaultmux.New(cfg)
+// wrapVaultConnectError describes a connect failure for commands that
+// return it rather than print it; other errors give nil
+func wrapVaultConnectError(err error) error {
+	var stage *vault.StageError
+	if !errors.As(err, &stage) {
+		return nil
+	}
+	if stage.Stage == vault.StageInit {
+		return fmt.Errorf("backend not available: %w", stage.Err)
+	}
+	return fmt.Errorf("authentication failed: %w", stage.Err)
 }
 
 func newVaultCmd() *cobra.Command {
@@ -88,6 +188,7 @@ func newVaultCmd() *cobra.Command {
 		newVaultUnlockCmd(),
 		newVaultLockCmd(),
 		newVaultListCmd(),
+		newVaultGrepCmd(),
 		newVaultBackendCmd(),
 		newVaultSyncCmd(),
 		newVaultGetCmd(),
@@ -100,7 +201,15 @@ func newVaultCmd() *cobra.Command {
 		newVaultValidateCmd(),
 		newVaultInitCmd(),
 		newVaultCreateCmd(),
+		newVaultTemplatesCmd(),
 		newVaultDeleteCmd(),
+		newVaultHistoryCmd(),
+		newVaultLastErrorCmd(),
+		newVaultLastRunCmd(),
+		newVaultAuditCmd(),
+		newVaultVerifyCmd(),
+		newVaultRotateCmd(),
+		newVaultReencryptCmd(),
 	)
 
 	return cmd
@@ -150,25 +259,52 @@ func newVaultLockCmd() *cobra.Command {
 }
 
 func newVaultListCmd() *cobra.Command {
-	var jsonOutput bool
-	var location string
+	var opts vaultListOptions
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List vault items",
-		Long:  `List all items in the vault or in a specific location/folder.`,
+		Long: `List all items in the vault or in a specific location/folder.
+
+Filters combine; an item must match all of them.
+  --filter name~REGEX     Match a column against a regular expression
+  --filter location=NAME  Match a column exactly
+  --filter REGEX          Same as name~REGEX
+  --type TYPE             securenote, login, sshkey, identity, card
+  --modified-since WHEN   A date (2024-01-31) or an age (30d, 12h).
+                          Items the backend reports no time for are
+                          left out.
+
+Columns are name, location, type, modified, size, and id. Sizes and
+modification times are shown only when the backend returns them in
+listings; otherwise the column shows "-".
+
+Examples:
+  blackdot vault list --filter '^SSH-'
+  blackdot vault list --type sshkey --columns name,modified --sort modified
+  blackdot vault list --modified-since 30d
+  blackdot vault list --tree`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return vaultList(jsonOutput, location)
+			return vaultList(opts)
 		},
 	}
 
-	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "output as JSON")
-	cmd.Flags().StringVarP(&location, "location", "l", "", "filter by location/folder")
+	cmd.Flags().BoolVarP(&opts.JSON, "json", "j", false, "output as JSON")
+	cmd.Flags().StringVarP(&opts.Location, "location", "l", "", "filter by location/folder")
+	cmd.Flags().StringArrayVarP(&opts.Filters, "filter", "f", nil, "column=value or column~regex (repeatable)")
+	cmd.Flags().StringVarP(&opts.Type, "type", "t", "", "only items of this type")
+	cmd.Flags().StringVar(&opts.ModifiedSince, "modified-since", "", "only items modified since a date or age (30d)")
+	cmd.Flags().StringVarP(&opts.Sort, "sort", "s", "name", "sort by name, modified, location, or size")
+	cmd.Flags().BoolVarP(&opts.Reverse, "reverse", "r", false, "reverse the sort order")
+	cmd.Flags().StringVarP(&opts.Columns, "columns", "c", "", "comma-separated columns (default name,location)")
+	cmd.Flags().BoolVar(&opts.Tree, "tree", false, "group items by folder")
 
 	return cmd
 }
 
 func newVaultBackendCmd() *cobra.Command {
+	var fallback string
+
 	cmd := &cobra.Command{
 		Use:   "backend [name]",
 		Short: "Show or set vault backend",
@@ -177,14 +313,29 @@ func newVaultBackendCmd() *cobra.Command {
 Available backends:
   bitwarden  - Bitwarden CLI (bw)
   1password  - 1Password CLI (op)
-  pass       - pass (GPG-based password manager)`,
+  pass       - pass (GPG-based password manager)
+
+Reads (restore, get) can fall back to other backends, in order, when the
+primary's CLI is missing or its service is down. Writes always go to the
+primary.
+
+  blackdot vault backend 1password --fallback pass,bitwarden`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("fallback") {
+				if err := setBackendFallback(fallback); err != nil {
+					return err
+				}
+				if len(args) == 0 {
+					return nil
+				}
+			}
 			if len(args) == 0 {
 				return showBackend()
 			}
 			return setBackend(args[0])
 		},
 	}
+	cmd.Flags().StringVar(&fallback, "fallback", "", "Comma-separated read fallback backends (\"\" to clear)")
 
 	return cmd
 }
@@ -201,32 +352,75 @@ func newVaultSyncCmd() *cobra.Command {
 }
 
 func newVaultGetCmd() *cobra.Command {
-	var outputNotes bool
+	var outputNotes, fuzzy bool
 
 	cmd := &cobra.Command{
 		Use:   "get <item-name>",
 		Short: "Get a vault item",
-		Long:  `Retrieve an item from the vault by name.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Retrieve an item from the vault by name.
+
+If the name is not found, close matches from the cached item index are
+suggested. With --fuzzy, a single close match is used automatically.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return vaultGet(args[0], outputNotes)
+			return vaultGet(args[0], outputNotes, fuzzy)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeVaultItems(toComplete), cobra.ShellCompDirectiveNoFileComp
 		},
 	}
 
 	cmd.Flags().BoolVarP(&outputNotes, "notes", "n", false, "output only the notes field")
+	cmd.Flags().BoolVar(&fuzzy, "fuzzy", false, "use the closest match when exactly one item is close")
 
 	return cmd
 }
 
 func newVaultHealthCmd() *cobra.Command {
-	return &cobra.Command{
+	var probes []string
+	var timeout time.Duration
+	var quiet, jsonOut bool
+
+	cmd := &cobra.Command{
 		Use:   "health",
 		Short: "Run vault health check",
-		Long:  `Check vault backend availability and authentication status.`,
+		Long: `Check vault backend availability and authentication status.
+
+With --probe (or --quiet/--json) it runs readiness probes instead, never
+prompts, and exits with a code that says what failed, for CI:
+
+  cli           backend CLI installed and working
+  auth          logged in and unlocked
+  item:<name>   the item exists and is not empty
+
+Exit codes: 0 ready, 3 CLI missing or backend not configured,
+4 not authenticated, 5 item missing or empty, 6 item could not be read,
+7 timed out. The first failed probe decides the code.
+
+Examples:
+  blackdot vault health
+  blackdot vault health --probe auth --probe item:SSH-Config --timeout 10s --quiet
+  blackdot vault health --probe cli --probe auth --json`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return vaultHealth()
+			if len(probes) == 0 && !quiet && !jsonOut {
+				return vaultHealth(timeout)
+			}
+			if len(probes) == 0 {
+				probes = []string{vault.ProbeCLI, vault.ProbeAuth}
+			}
+			return runVaultProbes(probes, timeout, quiet, jsonOut)
 		},
 	}
+
+	cmd.Flags().StringArrayVar(&probes, "probe", nil, "Probe to run: cli, auth, or item:<name> (repeatable)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Give up after this long")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Print nothing; report through the exit code")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print probe results as JSON")
+	return cmd
 }
 
 func newVaultQuickCmd() *cobra.Command {
@@ -244,11 +438,11 @@ and other detailed checks.`,
 }
 
 func newVaultRestoreCmd() *cobra.Command {
-	var force bool
-	var dryRun bool
+	var opts vaultRestoreOptions
+	var version int
 
 	cmd := &cobra.Command{
-		Use:     "restore",
+		Use:     "restore [item --version N]",
 		Aliases: []string{"pull"},
 		Short:   "Restore secrets from vault to local",
 		Long: `Restore secrets from vault to local machine.
@@ -260,23 +454,52 @@ Restores:
   - Environment secrets
 
 Options:
-  --force, -f    Skip drift check and overwrite local changes
-  --dry-run, -n  Show what would be restored without making changes`,
+  --force, -f        Skip drift check and overwrite local changes
+  --interactive, -i  Resolve drifted items one by one (default on a terminal)
+  --dry-run, -n      Show what would be restored without making changes
+  --concurrency, -j  Items fetched from the vault in parallel (default 4)
+  --resume           Continue an interrupted restore
+  --report <path>    Write a structured report (use - for stdout)
+  --report-format    Report format: json, markdown (default: from extension)
+  --plan-format      With --dry-run, print the change plan as table or json
+
+Items are fetched in parallel and each file is written atomically. Failures
+are collected and listed together at the end.
+
+Progress is recorded after every item. If a restore is interrupted or some
+items fail, --resume skips the items already restored whose files are
+unchanged since, and fetches only the rest.
+
+With an item and --version N, the item is rolled back in the vault to a
+saved version instead (see 'blackdot vault history'); --dry-run shows the
+diff.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return vaultRestore(force, dryRun)
+			if len(args) == 1 || version != 0 {
+				if len(args) == 0 || version <= 0 {
+					return fmt.Errorf("restoring an item from history needs both the item and --version N")
+				}
+				return vaultRestoreVersion(args[0], version, opts.DryRun)
+			}
+			return vaultRestore(opts)
 		},
 	}
 
-	cmd.Flags().BoolVarP(&force, "force", "f", false, "Skip drift check and overwrite local changes")
-	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be restored")
+	cmd.Flags().BoolVarP(&opts.Force, "force", "f", false, "Skip drift check and overwrite local changes")
+	cmd.Flags().BoolVarP(&opts.DryRun, "dry-run", "n", false, "Show what would be restored")
+	cmd.Flags().BoolVarP(&opts.Interactive, "interactive", "i", false, "Resolve drifted items one by one")
+	cmd.Flags().IntVarP(&opts.Concurrency, "concurrency", "j", vaultDefaultConcurrency, "Items to fetch from the vault in parallel")
+	cmd.Flags().BoolVar(&opts.Resume, "resume", false, "Skip items an interrupted restore already wrote")
+	cmd.Flags().StringVar(&opts.Report, "report", "", "Write a structured report to path (- for stdout)")
+	cmd.Flags().StringVar(&opts.ReportFormat, "report-format", "", "Report format: json, markdown")
+	addPlanFormatFlag(cmd, &opts.PlanFormat)
+	cmd.Flags().IntVar(&version, "version", 0, "Roll the item back to this saved version")
 
 	return cmd
 }
 
 func newVaultPushCmd() *cobra.Command {
-	var force bool
-	var dryRun bool
-	var all bool
+	var opts vaultPushOptions
 
 	cmd := &cobra.Command{
 		Use:   "push [items...]",
@@ -286,24 +509,38 @@ func newVaultPushCmd() *cobra.Command {
 Items:
   SSH-Config, AWS-Config, AWS-Credentials, Git-Config, Environment-Secrets
 
+Before an existing item is overwritten, push shows the lines added and
+removed, with the first few changed lines (values masked for secret
+items), and asks for confirmation. It warns when the local file is empty
+or drops most of the vault copy. Without a terminal the item is skipped
+unless --force or BLACKDOT_ASSUME_YES=1 is given.
+
 Options:
-  --force, -f    Overwrite vault content without confirmation
-  --dry-run, -n  Show what would be pushed without making changes
-  --all, -a      Push all items`,
+  --force, -f        Overwrite vault content without confirmation
+  --dry-run, -n      Show what would be pushed without making changes
+  --all, -a          Push all items
+  --report <path>    Write a structured report (use - for stdout)
+  --report-format    Report format: json, markdown (default: from extension)
+  --plan-format      With --dry-run, print the change plan as table or json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return vaultPush(args, force, dryRun, all)
+			opts.Items = args
+			return vaultPush(opts)
 		},
 	}
 
-	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite vault without confirmation")
-	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be pushed")
-	cmd.Flags().BoolVarP(&all, "all", "a", false, "Push all items")
+	cmd.Flags().BoolVarP(&opts.Force, "force", "f", false, "Overwrite vault without confirmation")
+	cmd.Flags().BoolVarP(&opts.DryRun, "dry-run", "n", false, "Show what would be pushed")
+	cmd.Flags().BoolVarP(&opts.All, "all", "a", false, "Push all items")
+	cmd.Flags().StringVar(&opts.Report, "report", "", "Write a structured report to path (- for stdout)")
+	cmd.Flags().StringVar(&opts.ReportFormat, "report-format", "", "Report format: json, markdown")
+	addPlanFormatFlag(cmd, &opts.PlanFormat)
 
 	return cmd
 }
 
 func newVaultScanCmd() *cobra.Command {
-	return &cobra.Command{
+	var rulesPath string
+	cmd := &cobra.Command{
 		Use:   "scan",
 		Short: "Scan for local secrets to add to vault",
 		Long: `Scan for local secrets that could be added to vault.
@@ -312,11 +549,20 @@ Discovers:
   - SSH keys in ~/.ssh/
   - AWS credentials in ~/.aws/
   - Git configuration
-  - Environment files`,
+  - Environment files
+
+More locations come from discovery rules in vault-discovery.json in the
+config directory: path globs, content matchers, item name templates,
+item types and exclusions. See docs/vault-README.md.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return vaultScan()
+			if rulesPath == "" {
+				rulesPath = vaultDiscoveryPath()
+			}
+			return vaultScan(rulesPath)
 		},
 	}
+	cmd.Flags().StringVar(&rulesPath, "rules", "", "Discovery rules file (default: vault-discovery.json in the config directory)")
+	return cmd
 }
 
 func newVaultCheckCmd() *cobra.Command {
@@ -357,7 +603,7 @@ func newVaultInitCmd() *cobra.Command {
 		Long: `Interactive vault setup wizard.
 
 Steps:
-  1. Select vault backend (bitwarden, 1password, pass)
+  1. Select vault backend (bitwarden, 1password, pass, keychain)
   2. Verify CLI tool is installed
   3. Test authentication
   4. Create vault-items.json if missing`,
@@ -371,6 +617,8 @@ func newVaultCreateCmd() *cobra.Command {
 	var dryRun bool
 	var force bool
 	var fromFile string
+	var templateName string
+	var sets []string
 
 	cmd := &cobra.Command{
 		Use:   "create <item-name> [content]",
@@ -381,21 +629,40 @@ Content can be provided as:
   - An argument: blackdot vault create My-Item "content here"
   - From a file: blackdot vault create My-Item --file ~/path/to/file
   - From stdin: echo "content" | blackdot vault create My-Item
+  - From a template: blackdot vault create --template api-key MyService
+
+Templates prompt for each placeholder and produce the same structured note
+on every backend. See 'blackdot vault templates' for the built-in ones;
+add your own under item_templates in vault-items.json.
 
 Options:
-  --dry-run, -n  Show what would be created without making changes
-  --force, -f    Overwrite if item already exists
-  --file         Read content from file
+  --dry-run, -n     Show what would be created without making changes
+  --force, -f       Overwrite if item already exists
+  --file            Read content from file
+  --template, -t    Build content from an item template
+  --set key=value   Template value (skips the prompt; repeatable)
 
 Examples:
   blackdot vault create API-Key "sk-1234567890"
   blackdot vault create SSH-Config --file ~/.ssh/config
-  blackdot vault create --dry-run Git-Config --file ~/.gitconfig`,
+  blackdot vault create --dry-run Git-Config --file ~/.gitconfig
+  blackdot vault create --template api-key MyService
+  blackdot vault create -t database Prod-DB --set host=db.internal --set username=app`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
 			var content string
 
+			if templateName != "" {
+				if fromFile != "" || len(args) > 1 {
+					return fmt.Errorf("--template cannot be combined with --file or content")
+				}
+				return vaultCreateFromTemplate(templateName, name, sets, dryRun, force)
+			}
+			if len(sets) > 0 {
+				return fmt.Errorf("--set requires --template")
+			}
+
 			if fromFile != "" {
 				// Read from file
 				data, err := os.ReadFile(fromFile)
@@ -427,6 +694,16 @@ Examples:
 	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be created")
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite if item exists")
 	cmd.Flags().StringVar(&fromFile, "file", "", "Read content from file")
+	cmd.Flags().StringVarP(&templateName, "template", "t", "", "Build content from an item template")
+	cmd.Flags().StringArrayVar(&sets, "set", nil, "Template value as key=value (repeatable)")
+	cmd.RegisterFlagCompletionFunc("template", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		templates, _ := loadVaultTemplates()
+		names := make([]string, 0, len(templates))
+		for name, t := range templates {
+			names = append(names, name+"\t"+t.Description)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
 
 	return cmd
 }
@@ -500,17 +777,30 @@ func printVaultHelp() {
 	// Items section
 	BoldCyan.Println("Items:")
 	printCmd("list", "List vault items")
+	printCmd("grep", "Search item contents (values masked)")
 	printCmd("create", "Create a new vault item")
+	printCmd("templates", "List item templates for create --template")
 	printCmd("delete", "Delete vault item(s)")
+	printCmd("history", "List saved versions of an item")
 	printCmd("scan", "Re-scan for new secrets (updates config)")
 	printCmd("check", "Check required vault items exist")
 	fmt.Println()
 
+	// Batch section
+	BoldCyan.Println("Batch:")
+	printCmd("verify --all", "Check every item's integrity and format")
+	printCmd("rotate --ssh", "Replace old SSH keys (--older-than 1y)")
+	printCmd("re-encrypt", "Re-encrypt age items after a key change")
+	fmt.Println()
+
 	// Config section
 	BoldCyan.Println("Config:")
 	printCmd("validate", "Validate vault-items.json schema")
 	printCmd("backend", "Show or set vault backend")
 	printCmd("init", "Initialize vault setup")
+	printCmd("last-error", "Show the last failed vault CLI call")
+	printCmd("last-run", "Show what the last restore or push did")
+	printCmd("audit", "Show or verify the log of secret reads and writes")
 	fmt.Println()
 
 	// Examples
@@ -573,6 +863,11 @@ func vaultStatus() error {
 	}
 
 	Pass("Backend: %s", backend.Name())
+	if verified, at := vaultVerifiedState(); verified {
+		Pass("Verified working: %s", at)
+	} else {
+		Warn("Configured but not verified (run 'blackdot vault init' to test read/write)")
+	}
 
 	// Check authentication
 	authenticated := backend.IsAuthenticated(ctx)
@@ -677,6 +972,7 @@ func vaultStatus() error {
 			checkedCount := 0
 			var driftedItems []string
 
+			expectVaultReads(len(vaultItems))
 			for name, item := range vaultItems {
 				localPath := expandPath(item.Path)
 
@@ -833,9 +1129,7 @@ func vaultUnlock() error {
 		Warn("Session file not created by vaultmux, saving manually...")
 		token := session.Token()
 		if token != "" {
-			if err := os.MkdirAll(filepath.Dir(sessionFile), 0700); err != nil {
-				Warn("Failed to create session directory: %v", err)
-			} else if err := os.WriteFile(sessionFile, []byte(token), 0600); err != nil {
+			if _, err := writeVaultSession(ctx, []byte(token)); err != nil {
 				Warn("Failed to save session: %v", err)
 			} else {
 				Info("Session saved manually")
@@ -870,41 +1164,43 @@ func vaultLock() error {
 	return nil
 }
 
-func vaultList(jsonOutput bool, location string) error {
+func vaultList(opts vaultListOptions) error {
+	columns, err := parseVaultListColumns(opts.Columns)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	backend, err := newVaultBackend()
+	svc, err := newVaultService()
 	if err != nil {
 		Fail("Failed to create backend: %v", err)
 		return err
 	}
-	defer backend.Close()
-
-	if err := backend.Init(ctx); err != nil {
-		Fail("Backend not available: %v", err)
-		return err
-	}
+	defer svc.Close()
 
-	session, err := backend.Authenticate(ctx)
+	items, err := svc.List(ctx, opts.Location)
 	if err != nil {
-		Fail("Authentication required: %v", err)
+		failVaultOp("Failed to list items", err)
 		return err
 	}
 
-	var items []*vaultmux.Item
-	if location != "" {
-		items, err = backend.ListItemsInLocation(ctx, "folder", location, session)
-	} else {
-		items, err = backend.ListItems(ctx, session)
+	if opts.Location == "" {
+		if err := saveVaultIndex(items); err != nil {
+			Debug("Failed to update item index: %v", err)
+		}
 	}
 
+	total := len(items)
+	items, err = selectVaultListItems(items, opts, time.Now())
 	if err != nil {
-		Fail("Failed to list items: %v", err)
+		Fail("%v", err)
 		return err
 	}
 
-	if jsonOutput {
+	if opts.JSON {
 		data, _ := json.MarshalIndent(items, "", "  ")
 		fmt.Println(string(data))
 		return nil
@@ -917,16 +1213,18 @@ func vaultList(jsonOutput bool, location string) error {
 
 	PrintHeader("Vault Items")
 
-	for _, item := range items {
-		loc := item.Location
-		if loc == "" {
-			loc = "(root)"
-		}
-		fmt.Printf("  %-30s %s\n", item.Name, Dim.Sprintf("[%s]", loc))
+	if opts.Tree {
+		printVaultTree(buildVaultTree(items), columns, "  ")
+	} else {
+		printVaultListTable(items, columns)
 	}
 
 	fmt.Println()
-	Info("Total: %d items", len(items))
+	if len(items) < total {
+		Info("Showing %d of %d items", len(items), total)
+	} else {
+		Info("Total: %d items", len(items))
+	}
 
 	return nil
 }
@@ -935,6 +1233,9 @@ func showBackend() error {
 	backendType := getVaultBackend()
 
 	fmt.Printf("Current backend: %s\n", backendType)
+	if fallbacks := vaultFallbackBackends(); len(fallbacks) > 0 {
+		fmt.Printf("Read fallback:   %s\n", joinBackendTypes(fallbacks))
+	}
 	fmt.Println()
 	fmt.Println("Available backends:")
 	fmt.Println("  bitwarden  - Bitwarden CLI (bw)")
@@ -945,15 +1246,8 @@ func showBackend() error {
 }
 
 func setBackend(name string) error {
-	// Validate backend name
-	switch vaultmux.BackendType(name) {
-	case vaultmux.BackendBitwarden, vaultmux.BackendOnePassword, vaultmux.BackendPass:
-		// Valid
-	default:
-		Fail("Unknown backend: %s", name)
-		fmt.Println()
-		fmt.Println("Available backends: bitwarden, 1password, pass")
-		return fmt.Errorf("unknown backend: %s", name)
+	if err := validateBackendName(name); err != nil {
+		return err
 	}
 
 	// Save to config
@@ -967,31 +1261,64 @@ func setBackend(name string) error {
 	return nil
 }
 
+// validateBackendName rejects backends blackdot does not support
+func validateBackendName(name string) error {
+	switch vaultmux.BackendType(name) {
+	case vaultmux.BackendBitwarden, vaultmux.BackendOnePassword, vaultmux.BackendPass, keychain.BackendType,
+		doppler.BackendType, vaultmux.BackendAWSSecretsManager:
+		return nil
+	}
+	Fail("Unknown backend: %s", name)
+	fmt.Println()
+	fmt.Println("Available backends: bitwarden, 1password, pass, keychain, doppler, awssecrets")
+	return fmt.Errorf("unknown backend: %s", name)
+}
+
+// setBackendFallback saves the ordered read fallback list
+func setBackendFallback(list string) error {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if err := validateBackendName(name); err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+
+	cfg := config.DefaultManager()
+	if err := cfg.Set("vault.fallback", strings.Join(names, ",")); err != nil {
+		Fail("Failed to save config: %v", err)
+		return err
+	}
+	if len(names) == 0 {
+		Pass("Read fallback cleared")
+	} else {
+		Pass("Read fallback set to: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
 func vaultSync() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	backend, err := newVaultBackend()
+	svc, err := newVaultService()
 	if err != nil {
 		Fail("Failed to create backend: %v", err)
 		return err
 	}
-	defer backend.Close()
-
-	if err := backend.Init(ctx); err != nil {
-		Fail("Backend not available: %v", err)
-		return err
-	}
+	defer svc.Close()
 
-	session, err := backend.Authenticate(ctx)
-	if err != nil {
-		Fail("Authentication required: %v", err)
+	if _, err := svc.Connect(ctx); err != nil {
+		failVaultOp("", err)
 		return err
 	}
 
-	Info("Syncing %s vault...", backend.Name())
+	Info("Syncing %s vault...", svc.Backend().Name())
 
-	if err := backend.Sync(ctx, session); err != nil {
+	if err := svc.Sync(ctx); err != nil {
 		Fail("Sync failed: %v", err)
 		return err
 	}
@@ -1000,59 +1327,113 @@ func vaultSync() error {
 	return nil
 }
 
-func vaultGet(name string, notesOnly bool) error {
+func vaultGet(name string, notesOnly, fuzzy bool) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	backend, err := newVaultBackend()
+	reader, err := openVaultReader(ctx)
 	if err != nil {
-		Fail("Failed to create backend: %v", err)
-		return err
-	}
-	defer backend.Close()
-
-	if err := backend.Init(ctx); err != nil {
 		Fail("Backend not available: %v", err)
 		return err
 	}
+	defer reader.Close()
 
-	session, err := backend.Authenticate(ctx)
-	if err != nil {
-		Fail("Authentication required: %v", err)
-		return err
+	// retryTypo looks for a close match in the backend that answered
+	retryTypo := func(from vaultmux.BackendType) (string, bool) {
+		backend, session, ok := reader.backendFor(from)
+		if !ok {
+			return "", false
+		}
+		return resolveItemTypo(ctx, backend, session, name, fuzzy)
 	}
 
 	if notesOnly {
-		notes, err := backend.GetNotes(ctx, name, session)
-		if err != nil {
-			if errors.Is(err, vaultmux.ErrNotFound) {
-				Fail("Item not found: %s", name)
+		notes, from, err := reader.GetNotes(ctx, name)
+		if errors.Is(err, vaultmux.ErrNotFound) {
+			if match, ok := retryTypo(from); ok {
+				notes, from, err = reader.GetNotes(ctx, match)
+			} else {
 				return err
 			}
+		}
+		if err != nil {
 			Fail("Failed to get item: %v", err)
 			return err
 		}
+		if label := reader.servedBy(from); label != "" {
+			Warn("%s unavailable - served %s", reader.Primary(), label)
+		}
 		fmt.Println(notes)
 		return nil
 	}
 
-	item, err := backend.GetItem(ctx, name, session)
-	if err != nil {
-		if errors.Is(err, vaultmux.ErrNotFound) {
-			Fail("Item not found: %s", name)
+	item, from, err := reader.GetItem(ctx, name)
+	if errors.Is(err, vaultmux.ErrNotFound) {
+		if match, ok := retryTypo(from); ok {
+			item, from, err = reader.GetItem(ctx, match)
+		} else {
 			return err
 		}
+	}
+	if err != nil {
 		Fail("Failed to get item: %v", err)
 		return err
 	}
+	if label := reader.servedBy(from); label != "" {
+		Warn("%s unavailable - served %s", reader.Primary(), label)
+	}
 
 	data, _ := json.MarshalIndent(item, "", "  ")
 	fmt.Println(string(data))
 	return nil
 }
 
-func vaultHealth() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// resolveItemTypo handles a missing item name. It suggests close matches from
+// the item index (refreshing it from the backend when stale) and, with
+// fuzzy, returns the match to use when exactly one is close.
+func resolveItemTypo(ctx context.Context, backend vaultmux.Backend, session vaultmux.Session, name string, fuzzy bool) (string, bool) {
+	index := loadVaultIndex()
+	if index.stale() {
+		if items, err := backend.ListItems(ctx, session); err == nil {
+			if err := saveVaultIndex(items); err != nil {
+				Debug("Failed to update item index: %v", err)
+			}
+			index = loadVaultIndex()
+		}
+	}
+
+	var matches []string
+	if index != nil {
+		matches = fuzzyMatchItems(name, index.Names)
+	}
+
+	if fuzzy {
+		if match, ok := singleCloseMatch(name, matches); ok {
+			Info("Using closest match: %s", match)
+			return match, true
+		}
+	}
+
+	Fail("Item not found: %s", name)
+	if len(matches) > 0 {
+		if len(matches) > 3 {
+			matches = matches[:3]
+		}
+		fmt.Println()
+		fmt.Println("Did you mean:")
+		for _, m := range matches {
+			fmt.Printf("  %s\n", m)
+		}
+		if !fuzzy && len(matches) == 1 {
+			fmt.Println()
+			Dim.Println("Use --fuzzy to select the closest match automatically.")
+		}
+	}
+	return "", false
+}
+
+func vaultHealth(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	PrintHeader("Vault Health Check")
@@ -1060,33 +1441,31 @@ func vaultHealth() error {
 	backendType := getVaultBackend()
 	fmt.Printf("Backend: %s\n\n", backendType)
 
-	backend, err := newVaultBackend()
+	svc, err := newVaultService()
 	if err != nil {
 		Fail("Failed to create backend: %v", err)
 		return err
 	}
-	defer backend.Close()
+	defer svc.Close()
 
-	// Check init
-	if err := backend.Init(ctx); err != nil {
-		Fail("Backend CLI not available: %v", err)
+	sessionFile := getSessionFile()
+	health := svc.Health(ctx, sessionFile)
+	if !health.Available {
+		Fail("Backend CLI not available: %v", health.InitErr)
 		PrintHint("Install the CLI tool for %s", backendType)
-		return err
+		return health.InitErr
 	}
-	Pass("Backend CLI available: %s", backend.Name())
+	Pass("Backend CLI available: %s", health.Backend)
 
-	// Check auth
-	if backend.IsAuthenticated(ctx) {
+	if health.Authenticated {
 		Pass("Authenticated")
 	} else {
 		Warn("Not authenticated")
 		PrintHint("Run 'blackdot vault unlock' to authenticate")
 	}
 
-	// Check session file
-	sessionFile := getSessionFile()
-	if _, err := os.Stat(sessionFile); err == nil {
-		Pass("Session file exists: %s", sessionFile)
+	if health.SessionCached {
+		Pass("Session file exists: %s (%s)", sessionFile, describeSessionStorage())
 	} else {
 		Info("No cached session")
 	}
@@ -1121,6 +1500,12 @@ func vaultQuick() error {
 	defer backend.Close()
 
 	if err := backend.Init(ctx); err != nil {
+		if isCloudBackend(backendType) {
+			fmt.Println("  Status:     Not connected")
+			fmt.Println()
+			Info("%s", cloudBackendHint(backendType))
+			return err
+		}
 		fmt.Println("  CLI:        Not installed")
 		fmt.Println()
 		switch backendType {
@@ -1130,6 +1515,8 @@ func vaultQuick() error {
 			Info("Install with: brew install --cask 1password-cli")
 		case vaultmux.BackendPass:
 			Info("Install with: brew install pass")
+		case keychain.BackendType:
+			Info("%s", keychainHint())
 		}
 		return err
 	}
@@ -1149,11 +1536,124 @@ func vaultQuick() error {
 	return fmt.Errorf("vault not authenticated")
 }
 
+// vaultRestoreOptions holds flags for vault restore
+type vaultRestoreOptions struct {
+	Force        bool
+	DryRun       bool
+	Interactive  bool
+	Concurrency  int
+	Resume       bool
+	Report       string
+	ReportFormat string
+	PlanFormat   string
+}
+
+// vaultDefaultConcurrency is how many items are fetched at once. Vault CLIs
+// are slow per call but tolerate a few parallel invocations.
+const vaultDefaultConcurrency = 4
+
+// vaultItemTimeout bounds a single item fetch or update
+const vaultItemTimeout = 60 * time.Second
+
+// vaultFetchResult is the outcome of fetching one item's notes
+type vaultFetchResult struct {
+	Notes    string
+	Backend  vaultmux.BackendType // which backend served the item
+	Err      error
+	Duration time.Duration
+}
+
+// fetchVaultNotes fetches the notes for names with bounded concurrency,
+// reporting each item to progress. Every name gets a result.
+func fetchVaultNotes(reader *vaultReader, names []string, concurrency int, progress *progressBar) map[string]vaultFetchResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make(map[string]vaultFetchResult, len(names))
+	expectVaultReads(len(names))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for _, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			progress.Start(name)
+
+			ctx, cancel := context.WithTimeout(context.Background(), vaultItemTimeout)
+			start := time.Now()
+			notes, from, err := reader.GetNotes(ctx, name)
+			cancel()
+
+			mu.Lock()
+			results[name] = vaultFetchResult{Notes: notes, Backend: from, Err: err, Duration: time.Since(start)}
+			mu.Unlock()
+			progress.Done(name)
+		}(name)
+	}
+	wg.Wait()
+	progress.Finish()
+	return results
+}
+
+// sortedVaultItemNames returns item names in a stable order
+func sortedVaultItemNames(items map[string]VaultItem) []string {
+	names := make([]string, 0, len(items))
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// firstUnmet returns the first of deps in unmet
+func firstUnmet(deps []string, unmet map[string]bool) string {
+	for _, dep := range deps {
+		if unmet[dep] {
+			return dep
+		}
+	}
+	return ""
+}
+
 // vaultRestore restores secrets from vault to local machine
-func vaultRestore(force, dryRun bool) error {
+func vaultRestore(opts vaultRestoreOptions) (err error) {
+	force, dryRun := opts.Force, opts.DryRun
+
+	// Covers init, auth, and sync; item fetches have their own timeouts
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
 	defer cancel()
 
+	changes, printPlan, err := startChangePlan("vault restore", dryRun, opts.PlanFormat)
+	if err != nil {
+		return err
+	}
+	if changes != nil && opts.PlanFormat == "json" && opts.Report == "-" {
+		return fmt.Errorf("--report - and --plan-format json both write to stdout")
+	}
+	defer printPlan()
+
+	report := newVaultReport("restore", string(getVaultBackend()), dryRun)
+	if opts.Report != "" {
+		if _, err := resolveVaultReportFormat(opts.Report, opts.ReportFormat); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		report.finish(err)
+		saveVaultLastRun(report)
+		if opts.Report == "" {
+			return
+		}
+		if werr := writeVaultReport(report, opts.Report, opts.ReportFormat); werr != nil {
+			Warn("Failed to write report: %v", werr)
+		}
+	}()
+	defer redirectStdoutForReport(opts.Report)()
+
 	PrintHeader("Vault Restore")
 
 	// Check offline mode
@@ -1169,33 +1669,29 @@ func vaultRestore(force, dryRun bool) error {
 	}
 	fmt.Println()
 
-	backendType := getVaultBackend()
-	fmt.Printf("Backend: %s\n", backendType)
-
-	backend, err := newVaultBackend()
-	if err != nil {
-		Fail("Failed to create backend: %v", err)
-		return err
-	}
-	defer backend.Close()
-
-	if err := backend.Init(ctx); err != nil {
-		Fail("Backend not available: %v", err)
-		return err
-	}
-
-	session, err := backend.Authenticate(ctx)
-	if err != nil {
-		Fail("Authentication required: %v", err)
-		return err
+	reader := newVaultReader(vaultBackendOrder(), connectVaultBackend)
+	defer reader.Close()
+	if fallbacks := reader.Fallbacks(); len(fallbacks) > 0 {
+		fmt.Printf("Backend: %s (fallback: %s)\n", reader.Primary(), joinBackendTypes(fallbacks))
+	} else {
+		fmt.Printf("Backend: %s\n", reader.Primary())
 	}
 
-	// Sync with remote
+	// Sync with remote (connects the first available backend)
 	Info("Syncing vault...")
-	if err := backend.Sync(ctx, session); err != nil {
+	emitProgress(progressEvent{Phase: "sync"})
+	if err := reader.Sync(ctx); err != nil {
+		if len(reader.Unavailable()) == len(reader.members) {
+			reader.reportFallback()
+			Fail("No vault backend available")
+			emitProgress(progressEvent{Phase: "sync", Status: "failed", Message: err.Error()})
+			return err
+		}
 		Warn("Sync warning: %v", err)
 	}
+	reader.reportFallback()
 	Pass("Vault synced")
+	emitProgress(progressEvent{Phase: "sync", Percent: 100, Status: "ok"})
 	fmt.Println()
 
 	// Load vault items configuration
@@ -1205,55 +1701,130 @@ func vaultRestore(force, dryRun bool) error {
 		return err
 	}
 
-	// Pre-restore drift check (unless --force)
-	if !force && !dryRun {
-		Info("Checking for local changes before restore...")
-		driftedItems := []string{}
-
-		for name, item := range vaultItems {
-			path := expandPath(item.Path)
+	// Items listed in another's "after" are restored first
+	names, err := vault.RestoreOrder(vaultItems)
+	if err != nil {
+		Fail("vault-items.json: %v", err)
+		return err
+	}
+	report.expect(len(names))
 
-			// Get vault content to compare
-			notes, err := backend.GetNotes(ctx, name, session)
-			if err != nil {
+	// Items for features this machine has disabled are not fetched
+	names, featureOff := skipVaultItemsForFeatures(names, vaultItems)
+	for _, name := range sortedKeys(featureOff) {
+		detail := featureDisabledDetail(featureOff[name])
+		Info("%s: %s", name, detail)
+		report.add(name, expandPath(vaultItems[name].Path), reportStatusSkipped, detail)
+	}
+	if len(featureOff) > 0 {
+		fmt.Println()
+	}
+
+	// Resume: items an earlier run restored, whose files are unchanged
+	// since, are neither fetched nor written again
+	var progress *restoreProgress
+	resumed := 0
+	if opts.Resume {
+		if progress = loadRestoreProgress(); progress == nil {
+			Info("No interrupted restore to resume - restoring all items")
+		} else {
+			pending := names[:0:0]
+			for _, name := range names {
+				path := expandPath(vaultItems[name].Path)
+				if progress.verified(name, path) {
+					report.add(name, path, reportStatusUnchanged, "already restored")
+					resumed++
+					continue
+				}
+				pending = append(pending, name)
+			}
+			date, clock := parseTimestamp(progress.Started)
+			Pass("Resuming restore from %s %s: %d of %d items already restored", date, strings.TrimSuffix(clock, "Z"), resumed, len(names))
+			names = pending
+		}
+		fmt.Println()
+	}
+	if progress == nil {
+		progress = newRestoreProgress(string(reader.Primary()))
+	}
+
+	// Fetch every item once, in parallel; the drift check and the restore
+	// both use these results
+	var fetched map[string]vaultFetchResult
+	if !dryRun {
+		concurrency := opts.Concurrency
+		if concurrency < 1 {
+			concurrency = vaultDefaultConcurrency
+		}
+		Info("Fetching %d items (%d at a time)...", len(names), concurrency)
+		start := time.Now()
+		fetched = fetchVaultNotes(reader, names, concurrency, newProgressBar("Fetching", len(names)))
+		Pass("Fetched %d items in %s", len(names), time.Since(start).Round(100*time.Millisecond))
+		fmt.Println()
+	}
+
+	// Pre-restore drift check (unless --force)
+	var resolutions map[string]restoreResolution
+	if !force && !dryRun {
+		Info("Checking for local changes before restore...")
+		var conflicts []restoreConflict
+
+		for _, name := range names {
+			path := expandPath(vaultItems[name].Path)
+
+			result := fetched[name]
+			if result.Err != nil {
 				continue // Can't check drift if vault item doesn't exist
 			}
 
-			driftStatus := checkItemDrift(path, notes)
+			driftStatus := checkItemDrift(path, result.Notes)
 			if driftStatus == 1 { // Drifted
-				driftedItems = append(driftedItems, name)
+				conflicts = append(conflicts, restoreConflict{Name: name, Path: path, Vault: result.Notes})
 			}
 		}
 
-		if len(driftedItems) > 0 {
+		if len(conflicts) > 0 {
 			Warn("Local files have changed since last vault sync:")
-			for _, item := range driftedItems {
-				fmt.Printf("  - %s\n", item)
+			for _, c := range conflicts {
+				fmt.Printf("  - %s\n", c.Name)
 			}
 			fmt.Println()
-			fmt.Println("Options:")
-			fmt.Println("  1. Run 'blackdot vault push' first to save local changes")
-			fmt.Println("  2. Run restore with --force to overwrite local changes")
-			fmt.Println("  3. Run 'blackdot drift' to see detailed differences")
-			fmt.Println()
-			Fail("Restore aborted to prevent data loss")
-			return fmt.Errorf("local drift detected - use --force to overwrite")
+
+			if !opts.Interactive && !stdinIsTerminal() {
+				fmt.Println("Options:")
+				fmt.Println("  1. Run 'blackdot vault push' first to save local changes")
+				fmt.Println("  2. Run restore with --force to overwrite local changes")
+				fmt.Println("  3. Run restore with --interactive to resolve each item")
+				fmt.Println("  4. Run 'blackdot drift' to see detailed differences")
+				fmt.Println()
+				Fail("Restore aborted to prevent data loss")
+				return fmt.Errorf("local drift detected - use --force to overwrite")
+			}
+
+			resolutions, err = resolveRestoreConflicts(conflicts, os.Stdin, os.Stderr)
+			if err != nil {
+				fmt.Println()
+				Fail("Restore aborted - no changes made")
+				return err
+			}
+		} else {
+			Pass("No local drift detected - safe to restore")
 		}
-		Pass("No local drift detected - safe to restore")
 		fmt.Println()
 	}
 
+	restorePaths := make(map[string]string, len(vaultItems))
+	for name, item := range vaultItems {
+		restorePaths[name] = expandPath(item.Path)
+	}
+	hctx := hookContextForItems(restorePaths, dryRun)
+
 	// Auto-backup before restore (if not dry-run)
 	if !dryRun {
-		Info("Creating backup before restore...")
-		backupCmd := exec.Command(filepath.Join(BlackdotDir(), "bin", "blackdot"), "backup", "create")
-		backupCmd.Stdout = os.Stdout
-		backupCmd.Stderr = os.Stderr
-		if err := backupCmd.Run(); err != nil {
-			Warn("Backup failed (continuing anyway): %v", err)
-		} else {
-			Pass("Backup created")
+		if err := triggerHooks("pre_vault_pull", hctx); err != nil {
+			return err
 		}
+		snapshotBeforeWrite("restore")
 		fmt.Println()
 	}
 
@@ -1264,45 +1835,114 @@ func vaultRestore(force, dryRun bool) error {
 
 	// Restore each item
 	restored := 0
-	skipped := 0
+	skipped := len(featureOff)
 	failed := 0
+	var failures []string
 
-	for name, item := range vaultItems {
+	// unmet holds items that were not restored, so items that must come
+	// after them are skipped too
+	unmet := make(map[string]bool)
+	fail := func(name, path, reason string) {
+		Fail("%s: %s", name, reason)
+		report.add(name, path, reportStatusFailed, reason)
+		failures = append(failures, fmt.Sprintf("%s: %s", name, reason))
+		unmet[name] = true
+		failed++
+	}
+
+	for _, name := range names {
+		item := vaultItems[name]
 		path := expandPath(item.Path)
+		emitProgress(progressEvent{Phase: "restore", Item: name, Percent: progressPercent(len(report.Items), len(names)+resumed)})
+		report.begin(name, string(fetched[name].Backend), fetched[name].Duration)
+
+		if dep := firstUnmet(item.After, unmet); dep != "" {
+			Warn("%s: skipped (restored after %s, which was not restored)", name, dep)
+			report.add(name, path, reportStatusSkipped, "dependency "+dep+" not restored")
+			unmet[name] = true
+			skipped++
+			continue
+		}
 
 		if dryRun {
 			if _, err := os.Stat(path); err == nil {
-				fmt.Printf("  %s → %s (exists, would overwrite)\n", name, path)
+				changes.Add(plan.Op{Kind: plan.Update, Target: path, Before: describeFileForPlan(path), Detail: "from vault:" + name})
+				report.add(name, path, reportStatusPlanned, "exists, would overwrite")
 			} else {
-				fmt.Printf("  %s → %s (new)\n", name, path)
+				changes.Add(plan.Op{Kind: plan.Create, Target: path, Detail: "from vault:" + name})
+				report.add(name, path, reportStatusPlanned, "new")
 			}
 			restored++
 			continue
 		}
 
-		// Get item from vault
-		notes, err := backend.GetNotes(ctx, name, session)
+		// Apply conflict resolution choices
+		switch resolution, ok := resolutions[name]; {
+		case ok && resolution == resolveSkip:
+			Info("%s: skipped (local and vault left unchanged)", name)
+			report.add(name, path, reportStatusSkipped, "conflict skipped")
+			skipped++
+			continue
+		case ok && resolution == resolveKeepLocal && item.Type == "sshkey":
+			// SSH key items hold private and public keys together, so a
+			// single local file can't be written back as-is
+			Warn("%s: keep local not supported for SSH key items - left unchanged", name)
+			report.add(name, path, reportStatusSkipped, "kept local (SSH key item not updated)")
+			skipped++
+			continue
+		case ok && resolution == resolveKeepLocal:
+			localContent, err := os.ReadFile(path)
+			if err == nil {
+				// Writes always go to the primary, never a fallback
+				itemCtx, itemCancel := context.WithTimeout(context.Background(), vaultItemTimeout)
+				var backend vaultmux.Backend
+				var session vaultmux.Session
+				if backend, session, err = reader.Writer(itemCtx); err == nil {
+					if item.Type == "binary" {
+						_, err = pushBinaryItem(itemCtx, reader.Primary(), backend, session, name, localContent, true)
+					} else {
+						err = backend.UpdateItem(itemCtx, name, string(localContent), session)
+					}
+				}
+				itemCancel()
+			}
+			if err != nil {
+				fail(name, path, fmt.Sprintf("failed to update vault from local: %v", err))
+				continue
+			}
+			Pass("%s: kept local, vault updated", name)
+			report.add(name, path, reportStatusUpdated, "kept local, vault updated")
+			progress.record(name, path)
+			restored++
+			continue
+		}
+
+		// Item fetched from the vault earlier
+		notes, err := fetched[name].Notes, fetched[name].Err
+		viaDetail := reader.servedBy(fetched[name].Backend)
+		via := ""
+		if viaDetail != "" {
+			via = " (" + viaDetail + ")"
+		}
 		if err != nil {
 			if errors.Is(err, vaultmux.ErrNotFound) {
 				if item.Required {
-					Fail("%s: not found in vault (required)", name)
-					failed++
+					fail(name, path, "not found in vault (required)")
 				} else {
 					Warn("%s: not found in vault (optional)", name)
+					report.add(name, path, reportStatusSkipped, "not found in vault (optional)")
 					skipped++
 				}
 				continue
 			}
-			Fail("%s: failed to get from vault: %v", name, err)
-			failed++
+			fail(name, path, fmt.Sprintf("failed to get from vault: %v", err))
 			continue
 		}
 
 		// Create parent directory
 		dir := filepath.Dir(path)
 		if err := os.MkdirAll(dir, 0755); err != nil {
-			Fail("%s: failed to create directory: %v", name, err)
-			failed++
+			fail(name, path, fmt.Sprintf("failed to create directory: %v", err))
 			continue
 		}
 
@@ -1311,13 +1951,32 @@ func vaultRestore(force, dryRun bool) error {
 			Warn("%s: backup failed: %v", name, err)
 		}
 
+		// Binary items: decode the notes or fetch the attachment
+		if item.Type == "binary" {
+			itemCtx, itemCancel := context.WithTimeout(context.Background(), vaultItemTimeout)
+			data, err := readBinaryItem(itemCtx, reader, fetched[name].Backend, name, notes)
+			itemCancel()
+			if err != nil {
+				fail(name, path, err.Error())
+				continue
+			}
+			if err := writeFileAtomic(path, data, 0600); err != nil {
+				fail(name, path, fmt.Sprintf("failed to write file: %v", err))
+				continue
+			}
+			Pass("%s → %s (%s)%s", name, path, formatSize(int64(len(data))), via)
+			report.add(name, path, reportStatusRestored, viaDetail)
+			progress.record(name, path)
+			restored++
+			continue
+		}
+
 		// Handle SSH keys specially - extract private and public keys
 		if item.Type == "sshkey" {
 			// Extract and write private key
 			privateKey := extractSSHPrivateKey(notes)
 			if privateKey == "" {
-				Fail("%s: no private key found in vault item", name)
-				failed++
+				fail(name, path, "no private key found in vault item")
 				continue
 			}
 
@@ -1326,9 +1985,8 @@ func vaultRestore(force, dryRun bool) error {
 				privateKey += "\n"
 			}
 
-			if err := os.WriteFile(path, []byte(privateKey), 0600); err != nil {
-				Fail("%s: failed to write private key: %v", name, err)
-				failed++
+			if err := writeFileAtomic(path, []byte(privateKey), 0600); err != nil {
+				fail(name, path, fmt.Sprintf("failed to write private key: %v", err))
 				continue
 			}
 
@@ -1340,23 +1998,24 @@ func vaultRestore(force, dryRun bool) error {
 				if !strings.HasSuffix(publicKey, "\n") {
 					publicKey += "\n"
 				}
-				if err := os.WriteFile(pubPath, []byte(publicKey), 0644); err != nil {
+				if err := writeFileAtomic(pubPath, []byte(publicKey), 0644); err != nil {
 					Warn("%s: failed to write public key: %v", name, err)
 				} else {
-					Pass("%s → %s (+ .pub)", name, path)
+					Pass("%s → %s (+ .pub)%s", name, path, via)
 				}
 			} else {
-				Pass("%s → %s", name, path)
+				Pass("%s → %s%s", name, path, via)
 			}
+			report.add(name, path, reportStatusRestored, viaDetail)
+			progress.record(name, path)
 			restored++
 			continue
 		}
 
 		// Handle environment secrets specially - create loader script
 		if name == "Environment-Secrets" || strings.HasSuffix(path, "env.secrets") {
-			if err := os.WriteFile(path, []byte(notes), 0600); err != nil {
-				Fail("%s: failed to write file: %v", name, err)
-				failed++
+			if err := writeFileAtomic(path, []byte(notes), 0600); err != nil {
+				fail(name, path, fmt.Sprintf("failed to write file: %v", err))
 				continue
 			}
 
@@ -1364,25 +2023,29 @@ func vaultRestore(force, dryRun bool) error {
 			if err := createEnvLoader(path); err != nil {
 				Warn("%s: failed to create loader script: %v", name, err)
 			} else {
-				Pass("%s → %s (+ load-env.sh)", name, path)
+				Pass("%s → %s (+ load-env.sh)%s", name, path, via)
 			}
+			refreshFishUniversal()
+			report.add(name, path, reportStatusRestored, viaDetail)
+			progress.record(name, path)
 			restored++
 			continue
 		}
 
 		// Standard file restoration
 		perm := os.FileMode(0644)
-		if strings.Contains(path, ".aws/") || strings.Contains(path, ".ssh/") {
+		if strings.Contains(path, ".aws/") || strings.Contains(path, ".ssh/") || item.Type == "kubeconfig" {
 			perm = 0600
 		}
 
-		if err := os.WriteFile(path, []byte(notes), perm); err != nil {
-			Fail("%s: failed to write file: %v", name, err)
-			failed++
+		if err := writeFileAtomic(path, []byte(notes), perm); err != nil {
+			fail(name, path, fmt.Sprintf("failed to write file: %v", err))
 			continue
 		}
 
-		Pass("%s → %s", name, path)
+		Pass("%s → %s%s", name, path, via)
+		report.add(name, path, reportStatusRestored, viaDetail)
+		progress.record(name, path)
 		restored++
 	}
 
@@ -1393,18 +2056,33 @@ func vaultRestore(force, dryRun bool) error {
 	} else {
 		fmt.Printf("Restored: %d\n", restored)
 	}
+	if resumed > 0 {
+		fmt.Printf("Already restored: %d\n", resumed)
+	}
 	fmt.Printf("Skipped: %d\n", skipped)
 	if failed > 0 {
 		Fail("Failed: %d", failed)
+		fmt.Println()
+		fmt.Println("Failures:")
+		for _, f := range failures {
+			fmt.Printf("  - %s\n", f)
+		}
+		if !dryRun {
+			fmt.Println()
+			fmt.Println("Retry only what is missing: blackdot vault restore --resume")
+		}
 		return fmt.Errorf("%d items failed to restore", failed)
 	}
 	fmt.Println("========================================")
 
 	// Save timestamp and drift state (if not dry-run)
 	if !dryRun && failed == 0 {
+		clearRestoreProgress()
+		clearSingleFlight(shellStartupKey)
 		if err := saveVaultTimestamp("vault.last_pull"); err != nil {
 			Warn("Failed to save timestamp: %v", err)
 		}
+		registerMachineAfterSync()
 
 		Info("Saving drift state for startup checks...")
 		if err := saveVaultDriftState(vaultItems); err != nil {
@@ -1412,16 +2090,60 @@ func vaultRestore(force, dryRun bool) error {
 		} else {
 			Pass("Drift state saved to %s", getVaultDriftStatePath())
 		}
+
+		if err := triggerHooks("post_vault_pull", hctx); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// vaultPushOptions holds flags for vault push
+type vaultPushOptions struct {
+	Items        []string
+	Force        bool
+	DryRun       bool
+	All          bool
+	Report       string
+	ReportFormat string
+	PlanFormat   string
+}
+
 // vaultPush pushes local secrets to vault
-func vaultPush(items []string, force, dryRun, all bool) error {
+func vaultPush(opts vaultPushOptions) (err error) {
+	items, dryRun, all := opts.Items, opts.DryRun, opts.All
+
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
 	defer cancel()
 
+	changes, printPlan, err := startChangePlan("vault push", dryRun, opts.PlanFormat)
+	if err != nil {
+		return err
+	}
+	if changes != nil && opts.PlanFormat == "json" && opts.Report == "-" {
+		return fmt.Errorf("--report - and --plan-format json both write to stdout")
+	}
+	defer printPlan()
+
+	report := newVaultReport("push", string(getVaultBackend()), dryRun)
+	if opts.Report != "" {
+		if _, err := resolveVaultReportFormat(opts.Report, opts.ReportFormat); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		report.finish(err)
+		saveVaultLastRun(report)
+		if opts.Report == "" {
+			return
+		}
+		if werr := writeVaultReport(report, opts.Report, opts.ReportFormat); werr != nil {
+			Warn("Failed to write report: %v", werr)
+		}
+	}()
+	defer redirectStdoutForReport(opts.Report)()
+
 	PrintHeader("Push to Vault")
 
 	// Check offline mode
@@ -1506,62 +2228,158 @@ func vaultPush(items []string, force, dryRun, all bool) error {
 		fmt.Println()
 	}
 
+	pushPaths := make(map[string]string, len(itemsToSync))
+	for name, pathTemplate := range itemsToSync {
+		pushPaths[name] = expandPath(pathTemplate)
+	}
+	hctx := hookContextForItems(pushPaths, dryRun)
+
+	if !dryRun {
+		if err := triggerHooks("pre_vault_push", hctx); err != nil {
+			return err
+		}
+		// Snapshot local state so the pushed content can be recovered
+		snapshotBeforeWrite("push")
+		fmt.Println()
+	}
+
+	// Item types decide how local content is prepared for the vault
+	itemTypes := make(map[string]string)
+	if vaultItems, err := loadVaultItems(); err == nil {
+		for name, item := range vaultItems {
+			itemTypes[name] = item.Type
+		}
+	}
+
 	// Push each item
 	synced := 0
 	skipped := 0
 	failed := 0
 
+	expectVaultReads(len(itemsToSync))
 	for name, pathTemplate := range itemsToSync {
 		path := expandPath(pathTemplate)
 
 		fmt.Printf("--- %s ---\n", name)
+		report.begin(name, "", 0)
 
 		// Check if local file exists
 		localContent, err := os.ReadFile(path)
 		if err != nil {
 			Warn("Local file not found: %s", path)
+			report.add(name, path, reportStatusSkipped, "local file not found")
 			skipped++
 			continue
 		}
 
+		if itemTypes[name] == "kubeconfig" {
+			prepared, notes, err := kubeconfigForVault(localContent, filepath.Dir(path))
+			if err != nil {
+				Fail("Invalid kubeconfig %s: %v", path, err)
+				report.add(name, path, reportStatusFailed, err.Error())
+				failed++
+				continue
+			}
+			for _, note := range notes {
+				Info("  %s", note)
+			}
+			localContent = prepared
+		}
+
 		// Get current vault content
 		vaultContent, err := backend.GetNotes(ctx, name, session)
 		if err != nil && !errors.Is(err, vaultmux.ErrNotFound) {
 			Fail("Failed to get vault item: %v", err)
+			report.add(name, path, reportStatusFailed, err.Error())
 			failed++
 			continue
 		}
 
+		// Binary items are compared by the manifest checksum
+		binary := itemTypes[name] == "binary"
+		if binary && isBinaryNote(vaultContent) && vaultContentChecksum(vaultContent) == calculateChecksum(localContent) {
+			vaultContent = string(localContent)
+		}
+
 		// Compare
 		if string(localContent) == vaultContent {
 			Pass("Already in sync: %s", path)
+			report.add(name, path, reportStatusUnchanged, "")
 			skipped++
 			continue
 		}
 
+		// Credentials don't belong in items restored to ordinary config files
+		if !isSecretDestination(itemTypes[name], path) {
+			if err := checkContentForSecrets(tildePath(path), localContent, "non-secret item "+name); err != nil {
+				Fail("%v", err)
+				report.add(name, path, reportStatusFailed, err.Error())
+				failed++
+				continue
+			}
+		}
+
+		// Show what an update would change, and ask before overwriting
+		secret := isSecretDestination(itemTypes[name], path)
+		if dryRun && vaultContent != "" && !binary {
+			printVaultPushDiff(diffVaultPush(vaultContent, string(localContent), secret), vaultContent, string(localContent))
+		}
+		if !dryRun && vaultContent != "" && !opts.Force {
+			if !confirmVaultPush(name, vaultContent, string(localContent), binary, secret) {
+				Info("Skipped '%s' (use --force to overwrite without confirmation)", name)
+				report.add(name, path, reportStatusSkipped, "not confirmed")
+				skipped++
+				fmt.Println()
+				continue
+			}
+		}
+
 		if dryRun {
-			fmt.Printf("Would update '%s' from %s\n", name, path)
+			op := plan.Op{Kind: plan.Create, Target: "vault:" + name, After: formatSize(int64(len(localContent))), Detail: "from " + tildePath(path)}
+			if vaultContent != "" {
+				op.Kind, op.Before = plan.Update, formatSize(int64(len(vaultContent)))
+			}
+			changes.Add(op)
+			report.add(name, path, reportStatusPlanned, "")
 			synced++
 			continue
 		}
 
 		// Update vault
-		if vaultContent == "" {
+		if binary {
+			storage, err := pushBinaryItem(ctx, backendType, backend, session, name, localContent, vaultContent != "")
+			if err != nil {
+				Fail("Failed to push '%s': %v", name, err)
+				report.add(name, path, reportStatusFailed, err.Error())
+				failed++
+				continue
+			}
+			Pass("Pushed '%s' from %s (%s, %s)", name, path, formatSize(int64(len(localContent))), storage)
+			status := reportStatusUpdated
+			if vaultContent == "" {
+				status = reportStatusCreated
+			}
+			report.add(name, path, status, storage)
+		} else if vaultContent == "" {
 			// Create new item
 			if err := backend.CreateItem(ctx, name, string(localContent), session); err != nil {
 				Fail("Failed to create '%s': %v", name, err)
+				report.add(name, path, reportStatusFailed, err.Error())
 				failed++
 				continue
 			}
 			Pass("Created '%s' from %s", name, path)
+			report.add(name, path, reportStatusCreated, "")
 		} else {
 			// Update existing item
 			if err := backend.UpdateItem(ctx, name, string(localContent), session); err != nil {
 				Fail("Failed to update '%s': %v", name, err)
+				report.add(name, path, reportStatusFailed, err.Error())
 				failed++
 				continue
 			}
 			Pass("Updated '%s' from %s", name, path)
+			report.add(name, path, reportStatusUpdated, "")
 		}
 		synced++
 		fmt.Println()
@@ -1588,133 +2406,52 @@ func vaultPush(items []string, force, dryRun, all bool) error {
 		}
 	}
 
+	if !dryRun && failed == 0 {
+		registerMachineAfterSync()
+		if err := triggerHooks("post_vault_push", hctx); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // vaultScan scans for local secrets to add to vault
-func vaultScan() error {
+func vaultScan(rulesPath string) error {
 	PrintHeader("Secret Discovery")
 
 	fmt.Println("Scanning for secrets in standard locations...")
 	fmt.Println()
 
-	type discoveredItem struct {
-		Name     string
-		Path     string
-		Type     string
-		Required bool
+	rules, err := loadDiscoveryRules(rulesPath)
+	if err != nil {
+		Fail("Invalid discovery rules: %v", err)
+		return err
 	}
 
 	var discovered []discoveredItem
-
-	homeDir, _ := os.UserHomeDir()
-
-	// Scan SSH keys
-	Info("Scanning ~/.ssh/ for SSH keys...")
-	sshDir := filepath.Join(homeDir, ".ssh")
-	if entries, err := os.ReadDir(sshDir); err == nil {
-		for _, entry := range entries {
-			if entry.IsDir() {
-				continue
+	claimed := make(map[string]bool)
+	byName := make(map[string]string)
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Description != "" && (i == 0 || rules[i-1].Description != rule.Description) {
+			if i > 0 {
+				fmt.Println()
 			}
-			name := entry.Name()
-			// Skip known non-key files
-			if name == "known_hosts" || name == "config" || name == "authorized_keys" ||
-				strings.HasSuffix(name, ".pub") {
+			Info("%s", rule.Description)
+		}
+		for _, item := range rule.discover(claimed) {
+			if !discoveryItemName.MatchString(item.Name) {
+				Warn("  Skipped: %s (%q is not a valid item name)", item.Path, item.Name)
 				continue
 			}
-
-			// Check if it looks like a private key
-			keyPath := filepath.Join(sshDir, name)
-			content, err := os.ReadFile(keyPath)
-			if err != nil {
+			if prev, ok := byName[item.Name]; ok {
+				Warn("  Skipped: %s (%s is already %s)", item.Path, item.Name, prev)
 				continue
 			}
-			if strings.HasPrefix(string(content), "-----BEGIN") &&
-				strings.Contains(string(content), "PRIVATE KEY") {
-				// Generate vault name from filename
-				vaultName := normalizeSSHKeyName(name)
-				Pass("  Found: %s → %s", name, vaultName)
-				discovered = append(discovered, discoveredItem{
-					Name:     vaultName,
-					Path:     "~/.ssh/" + name,
-					Type:     "sshkey",
-					Required: true,
-				})
-			}
-		}
-	}
-	fmt.Println()
-
-	// Scan AWS configs
-	Info("Checking for AWS configs...")
-	awsDir := filepath.Join(homeDir, ".aws")
-	if _, err := os.Stat(filepath.Join(awsDir, "credentials")); err == nil {
-		Pass("  Found: ~/.aws/credentials")
-		discovered = append(discovered, discoveredItem{
-			Name:     "AWS-Credentials",
-			Path:     "~/.aws/credentials",
-			Type:     "file",
-			Required: true,
-		})
-	}
-	if _, err := os.Stat(filepath.Join(awsDir, "config")); err == nil {
-		Pass("  Found: ~/.aws/config")
-		discovered = append(discovered, discoveredItem{
-			Name:     "AWS-Config",
-			Path:     "~/.aws/config",
-			Type:     "file",
-			Required: true,
-		})
-	}
-	fmt.Println()
-
-	// Scan Git config
-	Info("Checking for Git config...")
-	if _, err := os.Stat(filepath.Join(homeDir, ".gitconfig")); err == nil {
-		Pass("  Found: ~/.gitconfig")
-		discovered = append(discovered, discoveredItem{
-			Name:     "Git-Config",
-			Path:     "~/.gitconfig",
-			Type:     "file",
-			Required: true,
-		})
-	}
-	fmt.Println()
-
-	// Scan SSH config
-	Info("Checking for SSH config...")
-	if _, err := os.Stat(filepath.Join(homeDir, ".ssh", "config")); err == nil {
-		Pass("  Found: ~/.ssh/config")
-		discovered = append(discovered, discoveredItem{
-			Name:     "SSH-Config",
-			Path:     "~/.ssh/config",
-			Type:     "file",
-			Required: true,
-		})
-	}
-	fmt.Println()
-
-	// Scan other common secrets
-	Info("Checking for other secrets...")
-	otherSecrets := map[string]string{
-		"Claude-Profiles":     filepath.Join(homeDir, ".claude", "profiles.json"),
-		"NPM-Config":          filepath.Join(homeDir, ".npmrc"),
-		"PyPI-Config":         filepath.Join(homeDir, ".pypirc"),
-		"Docker-Config":       filepath.Join(homeDir, ".docker", "config.json"),
-		"Environment-Secrets": filepath.Join(homeDir, ".local", "env.secrets"),
-		"Template-Variables":  filepath.Join(homeDir, ".config", "blackdot", "template-variables.sh"),
-	}
-	for name, path := range otherSecrets {
-		if _, err := os.Stat(path); err == nil {
-			shortPath := strings.Replace(path, homeDir, "~", 1)
-			Pass("  Found: %s", shortPath)
-			discovered = append(discovered, discoveredItem{
-				Name:     name,
-				Path:     shortPath,
-				Type:     "file",
-				Required: false,
-			})
+			byName[item.Name] = item.Path
+			Pass("  Found: %s → %s", item.Path, item.Name)
+			discovered = append(discovered, item)
 		}
 	}
 	fmt.Println()
@@ -1746,6 +2483,9 @@ func vaultScan() error {
 			"type":     item.Type,
 			"required": item.Required,
 		}
+		if item.RequiresFeature != "" {
+			vaultItems[item.Name]["requires_feature"] = item.RequiresFeature
+		}
 
 		if item.Type == "sshkey" {
 			sshKeys[item.Name] = item.Path
@@ -1764,11 +2504,7 @@ func vaultScan() error {
 	fmt.Println(string(jsonBytes))
 	fmt.Println()
 
-	configDir := os.Getenv("XDG_CONFIG_HOME")
-	if configDir == "" {
-		configDir = filepath.Join(homeDir, ".config")
-	}
-	vaultItemsPath := filepath.Join(configDir, "blackdot", "vault-items.json")
+	vaultItemsPath := filepath.Join(ConfigDir(), "vault-items.json")
 
 	// Check if file already exists
 	existingConfig := false
@@ -1779,23 +2515,19 @@ func vaultScan() error {
 	// Prompt user for action
 	fmt.Println("What would you like to do?")
 	fmt.Println()
+	actions := []string{"Save - Create new config file", "Preview only - Don't save (copy JSON above)"}
 	if existingConfig {
-		fmt.Println("  1) Merge - Add new items to existing config")
-		fmt.Println("  2) Replace - Overwrite with new config (backup created)")
-		fmt.Println("  3) Preview only - Don't save (copy JSON above)")
-	} else {
-		fmt.Println("  1) Save - Create new config file")
-		fmt.Println("  2) Preview only - Don't save (copy JSON above)")
+		actions = []string{
+			"Merge - Add new items to existing config",
+			"Replace - Overwrite with new config (backup created)",
+			"Preview only - Don't save (copy JSON above)",
+		}
 	}
-	fmt.Println()
-	fmt.Print("Select action [1]: ")
-
-	reader := bufio.NewReader(os.Stdin)
-	choice, _ := reader.ReadString('\n')
-	choice = strings.TrimSpace(choice)
-	if choice == "" {
-		choice = "1"
+	index, err := prompts.Select("Select action", actions, 0)
+	if err != nil {
+		return err
 	}
+	choice := strconv.Itoa(index + 1)
 
 	if existingConfig {
 		switch choice {
@@ -1844,9 +2576,16 @@ func vaultScan() error {
 				vaultItemsJSON["syncable_items"] = existingSyncable
 			}
 
+			// Keep sections scan doesn't manage (item_templates, aws_expected_profiles, ...)
+			for key, value := range existingJSON {
+				if _, managed := vaultItemsJSON[key]; !managed {
+					vaultItemsJSON[key] = value
+				}
+			}
+
 			// Write merged config
 			mergedBytes, _ := json.MarshalIndent(vaultItemsJSON, "", "  ")
-			if err := os.WriteFile(vaultItemsPath, mergedBytes, 0644); err != nil {
+			if err := writeFileWithPolicy(vaultItemsPath, mergedBytes, fileClassPrivate); err != nil {
 				Fail("Failed to write config: %v", err)
 				return err
 			}
@@ -1861,7 +2600,7 @@ func vaultScan() error {
 			}
 			Info("Backed up to: %s", backupPath)
 
-			if err := os.WriteFile(vaultItemsPath, jsonBytes, 0644); err != nil {
+			if err := writeFileWithPolicy(vaultItemsPath, jsonBytes, fileClassPrivate); err != nil {
 				Fail("Failed to write config: %v", err)
 				return err
 			}
@@ -1874,13 +2613,7 @@ func vaultScan() error {
 	} else {
 		switch choice {
 		case "1":
-			// Create directory if needed
-			if err := os.MkdirAll(filepath.Dir(vaultItemsPath), 0755); err != nil {
-				Fail("Failed to create config directory: %v", err)
-				return err
-			}
-
-			if err := os.WriteFile(vaultItemsPath, jsonBytes, 0644); err != nil {
+			if err := writeFileWithPolicy(vaultItemsPath, jsonBytes, fileClassPrivate); err != nil {
 				Fail("Failed to write config: %v", err)
 				return err
 			}
@@ -1953,11 +2686,15 @@ func vaultCheck() error {
 		vaultItemNames[item.Name] = true
 	}
 
+	// Items for features this machine has disabled are neither required
+	// nor expected
+	_, featureOff := skipVaultItemsForFeatures(sortedKeys(vaultItems), vaultItems)
+
 	fmt.Println()
 	fmt.Println("=== Required Items ===")
 	missing := 0
 	for name, item := range vaultItems {
-		if !item.Required {
+		if !item.Required || featureOff[name] != "" {
 			continue
 		}
 		if vaultItemNames[name] {
@@ -1971,7 +2708,7 @@ func vaultCheck() error {
 	fmt.Println()
 	fmt.Println("=== Optional Items ===")
 	for name, item := range vaultItems {
-		if item.Required {
+		if item.Required || featureOff[name] != "" {
 			continue
 		}
 		if vaultItemNames[name] {
@@ -1981,6 +2718,14 @@ func vaultCheck() error {
 		}
 	}
 
+	if len(featureOff) > 0 {
+		fmt.Println()
+		fmt.Println("=== Skipped Items ===")
+		for _, name := range sortedKeys(featureOff) {
+			Info("%s: %s", name, featureDisabledDetail(featureOff[name]))
+		}
+	}
+
 	fmt.Println()
 	fmt.Println("========================================")
 	if missing == 0 {
@@ -2002,12 +2747,8 @@ func vaultValidate() error {
 	fmt.Println()
 
 	// Find vault-items.json
-	configDir := os.Getenv("XDG_CONFIG_HOME")
-	if configDir == "" {
-		configDir = filepath.Join(os.Getenv("HOME"), ".config")
-	}
 
-	vaultItemsPath := filepath.Join(configDir, "blackdot", "vault-items.json")
+	vaultItemsPath := filepath.Join(ConfigDir(), "vault-items.json")
 
 	// Check if file exists
 	if _, err := os.Stat(vaultItemsPath); os.IsNotExist(err) {
@@ -2057,7 +2798,7 @@ func vaultValidate() error {
 
 			// Validate type if present
 			if itemType, ok := item["type"].(string); ok {
-				validTypes := []string{"file", "sshkey", "env", "directory"}
+				validTypes := []string{"file", "sshkey", "kubeconfig", "env", "directory", "binary"}
 				isValid := false
 				for _, t := range validTypes {
 					if t == itemType {
@@ -2069,6 +2810,37 @@ func vaultValidate() error {
 					Warn("  %s: unknown type '%s'", name, itemType)
 				}
 			}
+
+			// after must be a list of item names
+			if after, ok := item["after"]; ok {
+				list, isList := after.([]interface{})
+				for _, dep := range list {
+					if _, isName := dep.(string); !isName {
+						isList = false
+					}
+				}
+				if !isList {
+					Fail("  %s: 'after' must be a list of item names", name)
+					errors++
+				}
+			}
+
+			// requires_feature must name a known feature
+			if f, ok := item["requires_feature"]; ok {
+				if fname, isName := f.(string); !isName || !initRegistry().Exists(fname) {
+					Fail("  %s: 'requires_feature' must name a feature (see 'blackdot features list')", name)
+					errors++
+				}
+			}
+		}
+
+		if errors == 0 {
+			if items, err := vault.LoadItemsFile(vaultItemsPath); err == nil {
+				if _, err := vault.RestoreOrder(items.VaultItems); err != nil {
+					Fail("  %v", err)
+					errors++
+				}
+			}
 		}
 	} else {
 		Warn("vault_items section not found")
@@ -2094,39 +2866,89 @@ func vaultValidate() error {
 	return nil
 }
 
+// vaultCanaryPrefix names the temporary items created by vaultCanaryCheck
+const vaultCanaryPrefix = "blackdot-canary-"
+
+// vaultCanaryCheck proves a backend works end to end by creating a
+// temporary item, reading it back, and deleting it. This catches problems
+// authentication alone does not, such as read-only folders or a wrong
+// server URL.
+func vaultCanaryCheck(ctx context.Context, backend vaultmux.Backend, session vaultmux.Session) error {
+	name := vaultCanaryPrefix + time.Now().Format("20060102150405")
+	content := fmt.Sprintf("blackdot canary %d", time.Now().UnixNano())
+
+	if err := backend.CreateItem(ctx, name, content, session); err != nil {
+		return fmt.Errorf("could not create test item: %w", err)
+	}
+
+	got, readErr := backend.GetNotes(ctx, name, session)
+	deleteErr := backend.DeleteItem(ctx, name, session)
+
+	switch {
+	case readErr != nil:
+		return fmt.Errorf("created test item but could not read it back: %w", readErr)
+	case got != content:
+		return fmt.Errorf("test item read back with different content")
+	case deleteErr != nil:
+		return fmt.Errorf("could not delete test item '%s' (remove it manually): %w", name, deleteErr)
+	}
+	return nil
+}
+
+// recordVaultVerified stores when the backend last passed vaultCanaryCheck
+func recordVaultVerified(backendName string) error {
+	cfg := config.DefaultManager()
+	if err := cfg.Set("vault.verified_at", time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	return cfg.Set("vault.verified_backend", backendName)
+}
+
+// vaultVerifiedState returns whether the configured backend is verified
+// working and when it was last verified
+func vaultVerifiedState() (bool, string) {
+	cfg, err := config.DefaultManager().Load()
+	if err != nil {
+		return false, ""
+	}
+	return cfg.Vault.Verified(), cfg.Vault.VerifiedAt
+}
+
+// configuredVaultBackend returns the backend chosen via env or config, or
+// "" when none was chosen (getVaultBackend falls back to bitwarden)
+func configuredVaultBackend() string {
+	if backend := os.Getenv("BLACKDOT_VAULT_BACKEND"); backend != "" {
+		return backend
+	}
+	val, _ := config.DefaultManager().Get("vault.backend")
+	return val
+}
+
 // vaultInit initializes vault setup
 func vaultInit() error {
 	PrintHeader("Vault Setup Wizard")
 
-	reader := bufio.NewReader(os.Stdin)
-
 	// Check for existing config
-	configDir := os.Getenv("XDG_CONFIG_HOME")
-	if configDir == "" {
-		configDir = filepath.Join(os.Getenv("HOME"), ".config")
-	}
-	vaultConfigPath := filepath.Join(configDir, "blackdot", "vault-items.json")
+	vaultConfigPath := filepath.Join(ConfigDir(), "vault-items.json")
 
 	if _, err := os.Stat(vaultConfigPath); err == nil {
 		Info("Existing configuration found: %s", vaultConfigPath)
 		fmt.Println()
 		fmt.Println("What would you like to do?")
-		fmt.Println("  1) Add new items (keep existing, scan for more)")
-		fmt.Println("  2) Reconfigure (backup current, start fresh)")
-		fmt.Println("  3) Cancel (keep current config)")
-		fmt.Println()
-		fmt.Print("Your choice [1]: ")
-		choice, _ := reader.ReadString('\n')
-		choice = strings.TrimSpace(choice)
-		if choice == "" {
-			choice = "1"
+		choice, err := prompts.Select("Your choice", []string{
+			"Add new items (keep existing, scan for more)",
+			"Reconfigure (backup current, start fresh)",
+			"Cancel (keep current config)",
+		}, 0)
+		if err != nil {
+			return err
 		}
 
 		switch choice {
-		case "1":
+		case 0:
 			// Run scan with merge
-			return vaultScan()
-		case "2":
+			return vaultScan(vaultDiscoveryPath())
+		case 1:
 			// Backup and continue
 			backup := vaultConfigPath + ".backup." + time.Now().Format("20060102150405")
 			if err := os.Rename(vaultConfigPath, backup); err != nil {
@@ -2151,6 +2973,9 @@ func vaultInit() error {
 	fmt.Println("  • bitwarden  - Bitwarden CLI (bw)")
 	fmt.Println("  • 1password  - 1Password CLI (op)")
 	fmt.Println("  • pass       - pass (GPG-based password manager)")
+	fmt.Println("  • keychain   - OS keychain (macOS Keychain, Windows Credential Manager, libsecret)")
+	fmt.Println("  • doppler    - Doppler (API token)")
+	fmt.Println("  • awssecrets - AWS Secrets Manager (AWS credentials or SSO)")
 	fmt.Println()
 
 	// Step 1: Select backend
@@ -2161,9 +2986,12 @@ func vaultInit() error {
 	// Detect available backends
 	available := []string{}
 	backendNames := map[string]string{
-		"bitwarden": "Bitwarden",
-		"1password": "1Password",
-		"pass":      "pass (GPG-based)",
+		"bitwarden":  "Bitwarden",
+		"1password":  "1Password",
+		"pass":       "pass (GPG-based)",
+		"keychain":   "OS keychain (" + keychain.StoreName() + ")",
+		"doppler":    "Doppler",
+		"awssecrets": "AWS Secrets Manager",
 	}
 
 	if _, err := exec.LookPath("bw"); err == nil {
@@ -2175,6 +3003,15 @@ func vaultInit() error {
 	if _, err := exec.LookPath("pass"); err == nil {
 		available = append(available, "pass")
 	}
+	if keychainAvailable() {
+		available = append(available, "keychain")
+	}
+	if dopplerAvailable() {
+		available = append(available, "doppler")
+	}
+	if awsSecretsAvailable() {
+		available = append(available, "awssecrets")
+	}
 
 	if len(available) == 0 {
 		Warn("No vault CLI detected.")
@@ -2187,34 +3024,25 @@ func vaultInit() error {
 	}
 
 	fmt.Println("Available backends:")
-	for i, backend := range available {
-		fmt.Printf("  %d) %s\n", i+1, backendNames[backend])
+	options := make([]string, 0, len(available)+1)
+	for _, backend := range available {
+		options = append(options, backendNames[backend])
 	}
-	fmt.Printf("  %d) Skip (configure later)\n", len(available)+1)
-	fmt.Println()
+	options = append(options, "Skip (configure later)")
 
-	fmt.Print("Select backend [1]: ")
-	choiceStr, _ := reader.ReadString('\n')
-	choiceStr = strings.TrimSpace(choiceStr)
-	if choiceStr == "" {
-		choiceStr = "1"
+	choice, err := prompts.Select("Select backend", options, 0)
+	if err != nil {
+		Fail("Invalid selection")
+		return err
 	}
 
-	var selectedBackend string
-	choice := 0
-	fmt.Sscanf(choiceStr, "%d", &choice)
-
-	if choice == len(available)+1 {
+	if choice == len(available) {
 		cfg := config.DefaultManager()
 		cfg.Set("vault.backend", "none")
 		Info("Vault setup skipped. Run 'blackdot vault init' anytime.")
 		return nil
-	} else if choice >= 1 && choice <= len(available) {
-		selectedBackend = available[choice-1]
-	} else {
-		Fail("Invalid selection")
-		return fmt.Errorf("invalid selection")
 	}
+	selectedBackend := available[choice]
 
 	// Save backend
 	cfg := config.DefaultManager()
@@ -2257,6 +3085,10 @@ func vaultInit() error {
 			fmt.Println("  op signin")
 		case "pass":
 			fmt.Println("  pass init <gpg-id>")
+		case "keychain":
+			fmt.Println("  " + keychainHint())
+		case "doppler", "awssecrets":
+			fmt.Println("  " + cloudBackendHint(vaultmux.BackendType(selectedBackend)))
 		}
 		fmt.Println()
 		fmt.Println("Then run setup again:")
@@ -2267,30 +3099,54 @@ func vaultInit() error {
 	Pass("Authenticated to %s", backend.Name())
 	fmt.Println()
 
-	// Step 3: Setup type
-	fmt.Println("Step 3: Setup Type")
+	// Step 3: End-to-end check
+	fmt.Println("Step 3: Verify Read/Write Access")
+	fmt.Println("────────────────────────────────")
+	fmt.Println()
+
+	session, err := backend.Authenticate(ctx)
+	if err != nil {
+		Fail("Authentication failed: %v", err)
+		return err
+	}
+	if err := vaultCanaryCheck(ctx, backend, session); err != nil {
+		Fail("%v", err)
+		fmt.Println()
+		fmt.Println("The backend is configured but not usable. Common causes:")
+		fmt.Println("  • No write access to the vault, folder, or collection")
+		fmt.Println("  • Wrong server URL (bw config server <url>)")
+		fmt.Println("  • Locked session (blackdot vault unlock)")
+		return err
+	}
+	if err := recordVaultVerified(selectedBackend); err != nil {
+		Warn("Failed to record verified state: %v", err)
+	}
+	Pass("Created, read back, and deleted a test item")
+	fmt.Println()
+
+	// Step 4: Setup type
+	fmt.Println("Step 4: Setup Type")
 	fmt.Println("──────────────────")
 	fmt.Println()
 	fmt.Println("How would you like to set up vault integration?")
 	fmt.Println()
-	fmt.Println("  1) Fresh - Scan local files, create new items")
-	fmt.Println("  2) Manual - Create template config, edit manually")
-	fmt.Println()
-	fmt.Print("Your choice [1]: ")
-
-	setupChoice, _ := reader.ReadString('\n')
-	setupChoice = strings.TrimSpace(setupChoice)
-	if setupChoice == "" || setupChoice == "1" {
+	setupChoice, err := prompts.Select("Your choice", []string{
+		"Fresh - Scan local files, create new items",
+		"Manual - Create template config, edit manually",
+	}, 0)
+	if err != nil {
+		return err
+	}
+	if setupChoice == 0 {
 		// Run discovery
-		return vaultScan()
+		return vaultScan(vaultDiscoveryPath())
 	}
 
 	// Manual setup - copy example file
 	exampleFile := filepath.Join(BlackdotDir(), "vault", "vault-items.example.json")
 	if _, err := os.Stat(exampleFile); err == nil {
-		os.MkdirAll(filepath.Dir(vaultConfigPath), 0755)
 		data, _ := os.ReadFile(exampleFile)
-		os.WriteFile(vaultConfigPath, data, 0644)
+		writeFileWithPolicy(vaultConfigPath, data, fileClassPrivate)
 		Pass("Created config from template")
 	} else {
 		// Create minimal config
@@ -2302,8 +3158,7 @@ func vaultInit() error {
   "syncable_items": {}
 }
 `
-		os.MkdirAll(filepath.Dir(vaultConfigPath), 0755)
-		os.WriteFile(vaultConfigPath, []byte(minimalConfig), 0644)
+		writeFileWithPolicy(vaultConfigPath, []byte(minimalConfig), fileClassPrivate)
 		Pass("Created minimal config")
 	}
 
@@ -2326,11 +3181,7 @@ func vaultInit() error {
 // ============================================================
 
 // VaultItem represents an item in vault-items.json
-type VaultItem struct {
-	Path     string `json:"path"`
-	Type     string `json:"type"`
-	Required bool   `json:"required"`
-}
+type VaultItem = vault.Item
 
 // isOfflineMode checks if running in offline mode
 func isOfflineMode() bool {
@@ -2357,7 +3208,7 @@ func backupFile(path string) error {
 		return fmt.Errorf("failed to read file for backup: %w", err)
 	}
 
-	if err := os.WriteFile(backupPath, content, 0600); err != nil {
+	if err := writeFileAtomic(backupPath, content, 0600); err != nil {
 		return fmt.Errorf("failed to write backup: %w", err)
 	}
 
@@ -2418,6 +3269,12 @@ func checkItemDrift(localPath, vaultContent string) int {
 		return 0 // Can't check, assume no drift
 	}
 
+	if isBinaryNote(vaultContent) {
+		if calculateChecksum(localContent) != vaultContentChecksum(vaultContent) {
+			return 1
+		}
+		return 0
+	}
 	if string(localContent) != vaultContent {
 		return 1 // Drifted
 	}
@@ -2426,23 +3283,13 @@ func checkItemDrift(localPath, vaultContent string) int {
 
 // getVaultDriftStatePath returns the path to the vault drift state file
 func getVaultDriftStatePath() string {
-	cacheDir := os.Getenv("XDG_CACHE_HOME")
-	if cacheDir == "" {
-		home, _ := os.UserHomeDir()
-		cacheDir = filepath.Join(home, ".cache")
-	}
-	return filepath.Join(cacheDir, "blackdot", "vault-state.json")
+	return filepath.Join(paths.CacheDir(), "vault-state.json")
 }
 
 // saveVaultDriftState saves the current vault drift state after restore
 func saveVaultDriftState(items map[string]VaultItem) error {
 	statePath := getVaultDriftStatePath()
 
-	// Create directory
-	if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
-		return err
-	}
-
 	state := map[string]interface{}{
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 		"items":     make(map[string]interface{}),
@@ -2475,7 +3322,7 @@ func saveVaultDriftState(items map[string]VaultItem) error {
 		return err
 	}
 
-	return os.WriteFile(statePath, data, 0644)
+	return writeFileWithPolicy(statePath, data, fileClassPrivate)
 }
 
 // saveVaultTimestamp saves a timestamp to config
@@ -2504,68 +3351,30 @@ if [[ -f "$ENV_FILE" ]]; then
     done < "$ENV_FILE"
 fi
 `
-	return os.WriteFile(loaderPath, []byte(loaderContent), 0700)
+	return writeFileAtomic(loaderPath, []byte(loaderContent), 0700)
 }
 
 // loadVaultItems loads the vault_items section from vault-items.json
 func loadVaultItems() (map[string]VaultItem, error) {
-	configDir := os.Getenv("XDG_CONFIG_HOME")
-	if configDir == "" {
-		configDir = filepath.Join(os.Getenv("HOME"), ".config")
-	}
-	vaultItemsPath := filepath.Join(configDir, "blackdot", "vault-items.json")
+	overlay := currentProjectOverlay()
 
-	data, err := os.ReadFile(vaultItemsPath)
+	items, err := vault.LoadItemsFile(filepath.Join(ConfigDir(), "vault-items.json"))
 	if err != nil {
+		if os.IsNotExist(err) && overlay != nil && len(overlay.Vault.Items) > 0 && projectVaultItemsAllowed() {
+			return applyOverlayVaultItems(nil, overlay), nil
+		}
 		return nil, err
 	}
-
-	var config struct {
-		VaultItems map[string]VaultItem `json:"vault_items"`
-	}
-
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
-	}
-
-	return config.VaultItems, nil
+	return applyOverlayVaultItems(items.VaultItems, overlay), nil
 }
 
 // loadSyncableItems loads the syncable_items section from vault-items.json
 func loadSyncableItems() (map[string]string, error) {
-	configDir := os.Getenv("XDG_CONFIG_HOME")
-	if configDir == "" {
-		configDir = filepath.Join(os.Getenv("HOME"), ".config")
-	}
-	vaultItemsPath := filepath.Join(configDir, "blackdot", "vault-items.json")
-
-	data, err := os.ReadFile(vaultItemsPath)
+	items, err := vault.LoadItemsFile(filepath.Join(ConfigDir(), "vault-items.json"))
 	if err != nil {
 		return nil, err
 	}
-
-	var config struct {
-		SyncableItems map[string]string `json:"syncable_items"`
-		VaultItems    map[string]struct {
-			Path string `json:"path"`
-		} `json:"vault_items"`
-	}
-
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
-	}
-
-	// If syncable_items exists, use it; otherwise derive from vault_items
-	if len(config.SyncableItems) > 0 {
-		return config.SyncableItems, nil
-	}
-
-	// Derive from vault_items
-	result := make(map[string]string)
-	for name, item := range config.VaultItems {
-		result[name] = item.Path
-	}
-	return result, nil
+	return items.Syncable(), nil
 }
 
 // expandPath expands ~ to home directory in a path
@@ -2636,52 +3445,35 @@ func vaultCreate(name, content string, dryRun, force bool) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	backend, err := newVaultBackend()
+	svc, err := newVaultService()
 	if err != nil {
 		return fmt.Errorf("failed to create backend: %w", err)
 	}
-	defer backend.Close()
+	defer svc.Close()
 
-	if err := backend.Init(ctx); err != nil {
-		return fmt.Errorf("backend not available: %w", err)
+	result, err := svc.Create(ctx, name, content, force)
+	if result.ExistingSize > 0 {
+		Warn("Item '%s' already exists (%d chars)", name, result.ExistingSize)
 	}
-
-	session, err := backend.Authenticate(ctx)
-	if err != nil {
-		return fmt.Errorf("authentication failed: %w", err)
-	}
-
-	// Sync first
-	Info("Syncing vault...")
-	if err := backend.Sync(ctx, session); err != nil {
-		Warn("Sync failed: %v", err)
+	if errors.Is(err, vault.ErrItemExists) {
+		Fail("Use --force to overwrite")
+		return err
 	}
-
-	// Check if item exists
-	existing, _ := backend.GetNotes(ctx, name, session)
-	if existing != "" {
-		Warn("Item '%s' already exists (%d chars)", name, len(existing))
-		if !force {
-			Fail("Use --force to overwrite")
-			return fmt.Errorf("item exists, use --force to overwrite")
+	if err != nil {
+		if wrapped := wrapVaultConnectError(err); wrapped != nil {
+			return wrapped
 		}
-		Info("Will overwrite existing item (--force)")
+		if result.Updated {
+			return fmt.Errorf("failed to update item: %w", err)
+		}
+		return fmt.Errorf("failed to create item: %w", err)
 	}
 
 	fmt.Printf("Item name: %s\n", name)
-	fmt.Printf("Content size: %d bytes\n", len(content))
-
-	if existing != "" {
-		// Update existing
-		if err := backend.UpdateItem(ctx, name, content, session); err != nil {
-			return fmt.Errorf("failed to update item: %w", err)
-		}
+	fmt.Printf("Content size: %d bytes\n", result.Size)
+	if result.Updated {
 		Pass("Updated '%s'", name)
 	} else {
-		// Create new
-		if err := backend.CreateItem(ctx, name, content, session); err != nil {
-			return fmt.Errorf("failed to create item: %w", err)
-		}
 		Pass("Created '%s'", name)
 	}
 
@@ -2691,19 +3483,6 @@ func vaultCreate(name, content string, dryRun, force bool) error {
 	return nil
 }
 
-// isProtectedItem checks if an item is a protected blackdot item
-func isProtectedItem(name string) bool {
-	protected := []string{
-		"SSH-", "AWS-", "Git-Config", "Environment-Secrets",
-	}
-	for _, prefix := range protected {
-		if strings.HasPrefix(name, prefix) || name == prefix {
-			return true
-		}
-	}
-	return false
-}
-
 // vaultDelete deletes vault items
 func vaultDelete(names []string, dryRun, force bool) error {
 	PrintHeader("Delete from Vault")
@@ -2715,7 +3494,7 @@ func vaultDelete(names []string, dryRun, force bool) error {
 		for _, name := range names {
 			fmt.Printf("--- %s ---\n", name)
 			fmt.Printf("Would delete '%s'\n", name)
-			if isProtectedItem(name) {
+			if vault.IsProtected(name) {
 				Warn("Protected item - would require confirmation to delete")
 			}
 			fmt.Println()
@@ -2727,25 +3506,14 @@ func vaultDelete(names []string, dryRun, force bool) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	backend, err := newVaultBackend()
+	svc, err := newVaultService()
 	if err != nil {
 		return fmt.Errorf("failed to create backend: %w", err)
 	}
-	defer backend.Close()
+	defer svc.Close()
 
-	if err := backend.Init(ctx); err != nil {
-		return fmt.Errorf("backend not available: %w", err)
-	}
-
-	session, err := backend.Authenticate(ctx)
-	if err != nil {
-		return fmt.Errorf("authentication failed: %w", err)
-	}
-
-	// Sync first
-	Info("Syncing vault...")
-	if err := backend.Sync(ctx, session); err != nil {
-		Warn("Sync failed: %v", err)
+	if err := svc.PrepareDelete(ctx); err != nil {
+		return wrapVaultConnectError(err)
 	}
 
 	var deleted, skipped, failed int
@@ -2753,56 +3521,17 @@ func vaultDelete(names []string, dryRun, force bool) error {
 	for _, name := range names {
 		fmt.Printf("--- %s ---\n", name)
 
-		// Check if item exists
-		existing, _ := backend.GetNotes(ctx, name, session)
-		if existing == "" {
+		result := svc.DeleteItem(ctx, name, confirmVaultDelete(force))
+		switch result.Status {
+		case vault.DeleteNotFound:
 			Warn("Item '%s' not found", name)
 			skipped++
-			fmt.Println()
-			continue
-		}
-
-		fmt.Printf("  Size: %d chars\n", len(existing))
-
-		// Check if protected
-		if isProtectedItem(name) {
-			fmt.Println()
-			Warn("⚠ This is a protected blackdot item!")
-			fmt.Println("Deleting this will break your blackdot restore.")
-			fmt.Println()
-
-			// Always require confirmation for protected items
-			fmt.Print("Type the item name to confirm deletion: ")
-			reader := bufio.NewReader(os.Stdin)
-			confirm, _ := reader.ReadString('\n')
-			confirm = strings.TrimSpace(confirm)
-			if confirm != name {
-				Warn("Confirmation failed - skipping")
-				skipped++
-				fmt.Println()
-				continue
-			}
-		} else {
-			// Non-protected: respect --force
-			if !force {
-				fmt.Printf("Delete '%s'? [y/N] ", name)
-				reader := bufio.NewReader(os.Stdin)
-				confirm, _ := reader.ReadString('\n')
-				confirm = strings.TrimSpace(confirm)
-				if !strings.EqualFold(confirm, "y") {
-					Warn("Cancelled")
-					skipped++
-					fmt.Println()
-					continue
-				}
-			}
-		}
-
-		// Perform deletion
-		if err := backend.DeleteItem(ctx, name, session); err != nil {
-			Fail("Failed to delete '%s': %v", name, err)
+		case vault.DeleteDeclined:
+			skipped++
+		case vault.DeleteFailed:
+			Fail("Failed to delete '%s': %v", name, result.Err)
 			failed++
-		} else {
+		case vault.DeleteDeleted:
 			Pass("Deleted '%s'", name)
 			deleted++
 		}
@@ -2811,13 +3540,8 @@ func vaultDelete(names []string, dryRun, force bool) error {
 
 	// Summary
 	fmt.Println("========================================")
-	if dryRun {
-		fmt.Println("DRY RUN SUMMARY:")
-		fmt.Printf("  Would delete: %d\n", deleted)
-	} else {
-		fmt.Println("SUMMARY:")
-		fmt.Printf("  Deleted: %d\n", deleted)
-	}
+	fmt.Println("SUMMARY:")
+	fmt.Printf("  Deleted: %d\n", deleted)
 	fmt.Printf("  Skipped: %d\n", skipped)
 	if failed > 0 {
 		Fail("Failed: %d", failed)
@@ -2829,3 +3553,29 @@ func vaultDelete(names []string, dryRun, force bool) error {
 	}
 	return nil
 }
+
+// confirmVaultDelete prompts before each deletion. Protected items always
+// need the item name typed back; others are skipped with force.
+func confirmVaultDelete(force bool) vault.ConfirmDelete {
+	return func(r vault.DeleteResult) bool {
+		fmt.Printf("  Size: %d chars\n", r.Size)
+		if r.Protected {
+			fmt.Println()
+			Warn("⚠ This is a protected blackdot item!")
+			fmt.Println("Deleting this will break your blackdot restore.")
+			fmt.Println()
+			if ok, _ := prompts.ConfirmText("Type the item name to confirm deletion:", r.Name); !ok {
+				Warn("Confirmation failed - skipping")
+				return false
+			}
+			return true
+		}
+		if !force {
+			if ok, _ := prompts.Confirm(fmt.Sprintf("Delete '%s'?", r.Name), false); !ok {
+				Warn("Cancelled")
+				return false
+			}
+		}
+		return true
+	}
+}
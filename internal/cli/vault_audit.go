@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/vault"
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/spf13/cobra"
+)
+
+// Audited operations
+const (
+	auditRead   = "read"
+	auditCreate = "create"
+	auditUpdate = "update"
+	auditDelete = "delete"
+)
+
+func vaultAuditPath() string {
+	return filepath.Join(paths.StateDir(), "vault-audit.jsonl")
+}
+
+// vaultAuditEnabled is vault.audit (on unless "false"), from trusted
+// layers only so a repo config cannot turn the log off
+func vaultAuditEnabled() bool {
+	val, _ := trustedConfigLookup("vault.audit")
+	return val != "false"
+}
+
+// vaultAuditWarned limits append failures to one warning per command
+var vaultAuditWarned sync.Once
+
+// recordVaultAccess appends a secret read or write to the audit log.
+// Failing to log warns once but never fails the operation.
+func recordVaultAccess(backend vaultmux.BackendType, op, item string, err error) {
+	result := vault.AuditOK
+	switch {
+	case errors.Is(err, vaultmux.ErrNotFound):
+		result = vault.AuditNotFound
+	case err != nil:
+		result = vault.AuditError
+	}
+	entry := vault.AuditEntry{
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Backend: string(backend),
+		Op:      op,
+		Item:    item,
+		Result:  result,
+	}
+	if err := vault.AppendAudit(vaultAuditPath(), entry); err != nil {
+		vaultAuditWarned.Do(func() { Warn("Vault audit log not written: %v", err) })
+	}
+}
+
+// auditBackend logs every call that reads or writes secret content. It
+// sits under the history wrapper, so saving a previous version is logged
+// as the read and create it is.
+type auditBackend struct {
+	vaultmux.Backend
+	backendType vaultmux.BackendType
+}
+
+func (b *auditBackend) GetItem(ctx context.Context, name string, session vaultmux.Session) (*vaultmux.Item, error) {
+	item, err := b.Backend.GetItem(ctx, name, session)
+	recordVaultAccess(b.backendType, auditRead, name, err)
+	return item, err
+}
+
+func (b *auditBackend) GetNotes(ctx context.Context, name string, session vaultmux.Session) (string, error) {
+	notes, err := b.Backend.GetNotes(ctx, name, session)
+	recordVaultAccess(b.backendType, auditRead, name, err)
+	return notes, err
+}
+
+func (b *auditBackend) CreateItem(ctx context.Context, name, content string, session vaultmux.Session) error {
+	err := b.Backend.CreateItem(ctx, name, content, session)
+	recordVaultAccess(b.backendType, auditCreate, name, err)
+	return err
+}
+
+func (b *auditBackend) UpdateItem(ctx context.Context, name, content string, session vaultmux.Session) error {
+	err := b.Backend.UpdateItem(ctx, name, content, session)
+	recordVaultAccess(b.backendType, auditUpdate, name, err)
+	return err
+}
+
+func (b *auditBackend) DeleteItem(ctx context.Context, name string, session vaultmux.Session) error {
+	err := b.Backend.DeleteItem(ctx, name, session)
+	recordVaultAccess(b.backendType, auditDelete, name, err)
+	return err
+}
+
+func newVaultAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show and verify the local log of secret reads and writes",
+		Long: `Every vault item read, create, update, and delete this CLI performs is
+logged with the item name, operation, backend, result, and time. Secret
+content is never logged.
+
+Each entry carries the hash of the one before it, so 'audit verify' detects
+an entry that was edited, removed, or reordered, and a log cut short (the
+last entry is also kept in vault-audit.jsonl.head). The log is
+tamper-evident, not tamper-proof: someone who can write both files can
+rewrite the whole chain.
+
+The log is vault-audit.jsonl in the state directory. Set vault.audit to
+false to stop logging.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVaultAuditShow("", 20, false)
+		},
+	}
+
+	var item string
+	var limit int
+	var jsonOut bool
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show recent entries",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVaultAuditShow(item, limit, jsonOut)
+		},
+	}
+	showCmd.Flags().StringVar(&item, "item", "", "Only entries for this item")
+	showCmd.Flags().IntVarP(&limit, "limit", "n", 20, "Number of entries to show (0 for all)")
+	showCmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON")
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check the log's hash chain",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVaultAuditVerify()
+		},
+	}
+
+	cmd.AddCommand(showCmd, verifyCmd)
+	return cmd
+}
+
+func runVaultAuditShow(item string, limit int, jsonOut bool) error {
+	entries, err := vault.ReadAudit(vaultAuditPath())
+	if err != nil {
+		Fail("Failed to read the audit log: %v", err)
+		return err
+	}
+	if item != "" {
+		matched := entries[:0:0]
+		for _, e := range entries {
+			if e.Item == item {
+				matched = append(matched, e)
+			}
+		}
+		entries = matched
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	if jsonOut {
+		if entries == nil {
+			entries = []vault.AuditEntry{}
+		}
+		data, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+	if len(entries) == 0 {
+		Info("No vault access recorded")
+		if !vaultAuditEnabled() {
+			Info("Logging is off (vault.audit is false)")
+		}
+		return nil
+	}
+
+	PrintHeader("Vault Access")
+	for _, e := range entries {
+		when := e.Time
+		if t, err := time.Parse(time.RFC3339, e.Time); err == nil {
+			when = t.Local().Format("2006-01-02 15:04:05")
+		}
+		result := Green.Sprint(e.Result)
+		if e.Result == vault.AuditError {
+			result = Red.Sprint(e.Result)
+		} else if e.Result == vault.AuditNotFound {
+			result = Yellow.Sprint(e.Result)
+		}
+		fmt.Printf("  %s %6d  %-6s %-30s %-10s %s\n", Dim.Sprint(when), e.Seq, e.Op, e.Item, Cyan.Sprint(e.Backend), result)
+	}
+	return nil
+}
+
+func runVaultAuditVerify() error {
+	path := vaultAuditPath()
+	entries, err := vault.ReadAudit(path)
+	if err == nil {
+		head, _ := os.ReadFile(vault.AuditHeadPath(path))
+		err = vault.VerifyAudit(entries, string(head))
+	}
+	var brk *vault.AuditBreak
+	if errors.As(err, &brk) {
+		Fail("Audit log chain is broken at %v", brk)
+		Info("Entries before %d verify; the log at %s was changed outside blackdot", brk.Seq, tildePath(path))
+		return fmt.Errorf("audit log chain broken at entry %d", brk.Seq)
+	}
+	if err != nil {
+		Fail("Failed to read the audit log: %v", err)
+		return err
+	}
+	if len(entries) == 0 {
+		Info("Audit log is empty")
+		return nil
+	}
+	Pass("Audit log intact: %d entries, %s to %s", len(entries), entries[0].Time, entries[len(entries)-1].Time)
+	return nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/vault"
+	"github.com/blackwell-systems/vaultmux/mock"
+)
+
+// TestAuditBackendLogsAccess verifies reads and writes are logged with
+// their result, content is not, and the log verifies until edited
+func TestAuditBackendLogsAccess(t *testing.T) {
+	setScheduleEnv(t, "")
+	ctx := context.Background()
+	m := mock.New()
+	m.SetItem("Git-Config", "secret-content")
+	backend := vault.WithHistory(&auditBackend{Backend: m, backendType: "pass"}, 2)
+	session, _ := backend.Authenticate(ctx)
+
+	backend.GetNotes(ctx, "Git-Config", session)
+	backend.GetNotes(ctx, "Missing", session)
+	backend.UpdateItem(ctx, "Git-Config", "new-content", session)
+	backend.ListItems(ctx, session)
+
+	entries, err := vault.ReadAudit(vaultAuditPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Op+" "+e.Item+" "+e.Result)
+	}
+	// The update saves the previous content as a version first
+	want := []string{
+		"read Git-Config ok",
+		"read Missing not_found",
+		"read Git-Config ok",
+		"create Git-Config.v1 ok",
+		"update Git-Config ok",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("entries = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i+1, got[i], want[i])
+		}
+	}
+
+	data, _ := os.ReadFile(vaultAuditPath())
+	if len(data) == 0 || strings.Contains(string(data), "secret-content") || strings.Contains(string(data), "new-content") {
+		t.Errorf("log must not hold content:\n%s", data)
+	}
+	if err := runVaultAuditVerify(); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+	os.WriteFile(vaultAuditPath(), data[:len(data)/2], 0600)
+	if err := runVaultAuditVerify(); err == nil {
+		t.Error("verify should fail on a damaged log")
+	}
+}
+
+// TestVaultAuditDisabled verifies vault.audit=false turns logging off
+func TestVaultAuditDisabled(t *testing.T) {
+	setScheduleEnv(t, "")
+	if !vaultAuditEnabled() {
+		t.Fatal("audit should default to on")
+	}
+	t.Setenv("BLACKDOT_VAULT_AUDIT", "false")
+	if vaultAuditEnabled() {
+		t.Error("vault.audit=false should turn logging off")
+	}
+}
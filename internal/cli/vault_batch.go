@@ -0,0 +1,686 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+// vaultBatchOptions holds flags shared by verify, rotate and re-encrypt
+type vaultBatchOptions struct {
+	All          bool
+	Concurrency  int
+	Resume       bool
+	DryRun       bool
+	Report       string
+	ReportFormat string
+}
+
+func addVaultBatchFlags(cmd *cobra.Command, opts *vaultBatchOptions) {
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Process every item in vault-items.json")
+	cmd.Flags().IntVarP(&opts.Concurrency, "concurrency", "j", vaultDefaultConcurrency, "Items to process in parallel")
+	cmd.Flags().BoolVar(&opts.Resume, "resume", false, "Skip items finished by an interrupted run")
+	cmd.Flags().StringVar(&opts.Report, "report", "", "Write a JSON or markdown report to a file (- for stdout)")
+	cmd.Flags().StringVar(&opts.ReportFormat, "report-format", "", "Report format: json, markdown (default: from extension)")
+}
+
+func newVaultVerifyCmd() *cobra.Command {
+	var opts vaultBatchOptions
+	cmd := &cobra.Command{
+		Use:   "verify [items...]",
+		Short: "Check vault items for integrity and format problems",
+		Long: `Fetch vault items and check that each one is intact and well-formed
+for its type: SSH private keys parse and match their public key,
+kubeconfigs are valid YAML, env files are KEY=value lines and age blobs
+are complete. Items whose local copy differs are noted.
+
+Examples:
+  blackdot vault verify --all
+  blackdot vault verify SSH-GitHub AWS-Config
+  blackdot vault verify --all --report verify.md`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return vaultVerify(args, opts)
+		},
+	}
+	addVaultBatchFlags(cmd, &opts)
+	return cmd
+}
+
+func newVaultRotateCmd() *cobra.Command {
+	var opts vaultBatchOptions
+	var sshKeys bool
+	var olderThan string
+	cmd := &cobra.Command{
+		Use:   "rotate --ssh [items...]",
+		Short: "Replace old SSH keys with new ones in the vault",
+		Long: `Generate new ed25519 keys for SSH key items, store them in the vault
+and write them locally. The old private key is backed up. Install the
+new public keys on your servers and forges afterwards; the report lists
+each new fingerprint.
+
+Examples:
+  blackdot vault rotate --ssh --all --older-than 1y --dry-run
+  blackdot vault rotate --ssh SSH-Work`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !sshKeys {
+				Fail("Nothing to rotate: pass --ssh (SSH keys are the only rotatable type)")
+				return fmt.Errorf("no key type selected")
+			}
+			maxAge, err := parseItemAge(olderThan)
+			if err != nil {
+				Fail("%v", err)
+				return err
+			}
+			return vaultRotateSSH(args, maxAge, opts)
+		},
+	}
+	addVaultBatchFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&sshKeys, "ssh", false, "Rotate SSH key items")
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Only rotate items not modified for this long (e.g. 90d, 1y)")
+	cmd.Flags().BoolVarP(&opts.DryRun, "dry-run", "n", false, "Show which keys would be rotated")
+	return cmd
+}
+
+func newVaultReencryptCmd() *cobra.Command {
+	var opts vaultBatchOptions
+	var identities []string
+	cmd := &cobra.Command{
+		Use:   "re-encrypt [items...]",
+		Short: "Re-encrypt age-encrypted items to the current recipients",
+		Long: `Decrypt items stored as age blobs and encrypt them again to the
+recipients in age-recipients.txt. Run this after changing encryption
+keys; pass the old key with --identity if it is no longer in
+age-key.txt. Items that are not age-encrypted are skipped.
+
+Examples:
+  blackdot vault re-encrypt --all --identity ~/old-age-key.txt`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return vaultReencrypt(args, identities, opts)
+		},
+	}
+	addVaultBatchFlags(cmd, &opts)
+	cmd.Flags().StringArrayVarP(&identities, "identity", "i", nil, "Additional age identity file to decrypt with (repeatable)")
+	cmd.Flags().BoolVarP(&opts.DryRun, "dry-run", "n", false, "Check items decrypt without writing")
+	return cmd
+}
+
+// Extra item outcome statuses for batch operations
+const (
+	reportStatusVerified = "verified"
+	reportStatusRotated  = "rotated"
+)
+
+// vaultBatchTask processes one item and returns its report status and detail
+type vaultBatchTask func(ctx context.Context, name string) (status, detail string)
+
+// vaultBatchCheckpoint records the items a batch run has finished, so an
+// interrupted run can continue with --resume. Failed items are not
+// recorded and are retried.
+type vaultBatchCheckpoint struct {
+	Operation string            `json:"operation"`
+	StartedAt time.Time         `json:"started_at"`
+	Done      map[string]string `json:"done"` // item -> status
+}
+
+func vaultBatchCheckpointPath(op string) string {
+	return filepath.Join(paths.StateDir(), "vault-batch-"+op+".json")
+}
+
+func loadVaultBatchCheckpoint(op string) *vaultBatchCheckpoint {
+	data, err := os.ReadFile(vaultBatchCheckpointPath(op))
+	if err != nil {
+		return nil
+	}
+	var cp vaultBatchCheckpoint
+	if json.Unmarshal(data, &cp) != nil || cp.Done == nil {
+		return nil
+	}
+	return &cp
+}
+
+func (cp *vaultBatchCheckpoint) save() error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileWithPolicy(vaultBatchCheckpointPath(cp.Operation), data, fileClassPrivate)
+}
+
+// runVaultBatch runs task over names with bounded concurrency, recording
+// progress in a checkpoint and every outcome in a report. The run stops
+// early on Ctrl-C; finished items stay in the checkpoint.
+func runVaultBatch(op string, names []string, opts vaultBatchOptions, task vaultBatchTask) (*vaultReport, error) {
+	report := newVaultReport(op, string(getVaultBackend()), opts.DryRun)
+
+	cp := &vaultBatchCheckpoint{Operation: op, StartedAt: time.Now().UTC(), Done: make(map[string]string)}
+	if prev := loadVaultBatchCheckpoint(op); prev != nil {
+		if opts.Resume {
+			cp = prev
+			Info("Resuming %s started %s (%d items done)", op, formatTimeAgo(prev.StartedAt.Format(time.RFC3339)), len(prev.Done))
+		} else {
+			Warn("A previous %s run did not finish; starting over (use --resume to continue it)", op)
+		}
+	}
+
+	var todo []string
+	for _, name := range names {
+		if status, ok := cp.Done[name]; ok {
+			report.add(name, "", status, "done in previous run")
+			continue
+		}
+		todo = append(todo, name)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	progress := newProgressBar(op, len(todo))
+	expectVaultReads(len(todo))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for _, name := range todo {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			progress.Start(name)
+
+			itemCtx, cancel := context.WithTimeout(ctx, vaultItemTimeout)
+			status, detail := task(itemCtx, name)
+			cancel()
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.add(name, "", status, detail)
+			if status != reportStatusFailed && !opts.DryRun {
+				cp.Done[name] = status
+				if err := cp.save(); err != nil {
+					Warn("Failed to save checkpoint: %v", err)
+				}
+			}
+			progress.Done(name)
+		}(name)
+	}
+	wg.Wait()
+	progress.Finish()
+
+	var err error
+	switch {
+	case ctx.Err() != nil:
+		err = fmt.Errorf("%s interrupted", op)
+	case report.Summary[reportStatusFailed] > 0:
+		err = fmt.Errorf("%d of %d items failed", report.Summary[reportStatusFailed], len(names))
+	case !opts.DryRun:
+		os.Remove(vaultBatchCheckpointPath(op))
+	}
+	report.finish(err)
+	return report, err
+}
+
+// printVaultBatchSummary prints per-status counts and the failed items
+func printVaultBatchSummary(report *vaultReport, err error) {
+	fmt.Println()
+	BoldCyan.Println("Summary:")
+	for _, item := range report.Items {
+		switch item.Status {
+		case reportStatusFailed:
+			Fail("%s: %s", item.Name, item.Detail)
+		case reportStatusSkipped:
+			if verbose {
+				fmt.Printf("  %s %s: %s\n", Dim.Sprint("-"), item.Name, item.Detail)
+			}
+		default:
+			if item.Detail != "" && item.Detail != "done in previous run" {
+				fmt.Printf("  %s %s: %s\n", Green.Sprint("✓"), item.Name, item.Detail)
+			}
+		}
+	}
+	var counts []string
+	for _, status := range []string{reportStatusVerified, reportStatusRotated, reportStatusUpdated, reportStatusPlanned, reportStatusSkipped, reportStatusFailed} {
+		if n := report.Summary[status]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, status))
+		}
+	}
+	fmt.Printf("  %d items: %s\n", len(report.Items), strings.Join(counts, ", "))
+	if err != nil && report.Summary[reportStatusFailed] > 0 && !report.DryRun {
+		Info("Fix the failures and re-run with --resume to retry only those items")
+	} else if err != nil {
+		Info("Re-run with --resume to continue")
+	}
+	fmt.Println()
+}
+
+// finishVaultBatch prints the summary and writes the report if requested
+func finishVaultBatch(report *vaultReport, err error, opts vaultBatchOptions) error {
+	printVaultBatchSummary(report, err)
+	if opts.Report != "" {
+		if werr := writeVaultReport(report, opts.Report, opts.ReportFormat); werr != nil {
+			Warn("Failed to write report: %v", werr)
+		}
+	}
+	return err
+}
+
+// selectBatchItems picks the items to process: the named ones, or all
+// items from vault-items.json matching filter
+func selectBatchItems(args []string, all bool, filter func(VaultItem) bool) (map[string]VaultItem, []string, error) {
+	if len(args) == 0 && !all {
+		Fail("Name the items to process or pass --all")
+		return nil, nil, fmt.Errorf("no items selected")
+	}
+	items, err := loadVaultItems()
+	if err != nil && (all || !os.IsNotExist(err)) {
+		Fail("Failed to load vault-items.json: %v", err)
+		return nil, nil, err
+	}
+	if items == nil {
+		items = make(map[string]VaultItem)
+	}
+
+	var names []string
+	if all {
+		for _, name := range sortedVaultItemNames(items) {
+			if filter == nil || filter(items[name]) {
+				names = append(names, name)
+			}
+		}
+	} else {
+		for _, name := range args {
+			if item, ok := items[name]; ok && filter != nil && !filter(item) {
+				Warn("%s is a %s item, skipping", name, item.Type)
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		Info("No matching items")
+	}
+	return items, names, nil
+}
+
+// startVaultBatch validates report flags and redirects stdout for "--report -"
+func startVaultBatch(title string, opts vaultBatchOptions) (restore func(), err error) {
+	if opts.Report != "" {
+		if _, err := resolveVaultReportFormat(opts.Report, opts.ReportFormat); err != nil {
+			return nil, err
+		}
+	}
+	restore = redirectStdoutForReport(opts.Report)
+	PrintHeader(title)
+	if opts.DryRun {
+		fmt.Println("(DRY RUN - no changes will be made)")
+	}
+	if isOfflineMode() {
+		restore()
+		Warn("Offline mode enabled (BLACKDOT_OFFLINE=1) - skipping vault operation")
+		return nil, nil
+	}
+	return restore, nil
+}
+
+// ============================================================
+// verify
+// ============================================================
+
+func vaultVerify(args []string, opts vaultBatchOptions) error {
+	restore, err := startVaultBatch("Verify Vault Items", opts)
+	if restore == nil {
+		return err
+	}
+	defer restore()
+
+	items, names, err := selectBatchItems(args, opts.All, nil)
+	if err != nil || len(names) == 0 {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	reader, err := openVaultReader(ctx)
+	cancel()
+	if err != nil {
+		Fail("Vault not available: %v", err)
+		return err
+	}
+	defer reader.Close()
+
+	report, err := runVaultBatch("verify", names, opts, func(ctx context.Context, name string) (string, string) {
+		notes, _, err := reader.GetNotes(ctx, name)
+		if err != nil {
+			return reportStatusFailed, err.Error()
+		}
+		item := items[name]
+		if problem := lintVaultItem(item.Type, notes); problem != "" {
+			return reportStatusFailed, problem
+		}
+		if item.Path != "" && item.Type != "directory" {
+			want := notes
+			if item.Type == "sshkey" {
+				want = extractSSHPrivateKey(notes)
+			}
+			if item.Type == "binary" {
+				if checkItemDrift(expandPath(item.Path), notes) == 1 {
+					return reportStatusVerified, "local copy differs"
+				}
+			} else if local, err := os.ReadFile(expandPath(item.Path)); err == nil && strings.TrimSpace(string(local)) != strings.TrimSpace(want) {
+				return reportStatusVerified, "local copy differs"
+			}
+		}
+		return reportStatusVerified, ""
+	})
+	return finishVaultBatch(report, err, opts)
+}
+
+const (
+	ageArmorBegin = "-----BEGIN AGE ENCRYPTED FILE-----"
+	ageArmorEnd   = "-----END AGE ENCRYPTED FILE-----"
+)
+
+// lintVaultItem checks that notes are well-formed for the item type and
+// returns a description of the first problem, or ""
+func lintVaultItem(itemType, notes string) string {
+	if strings.TrimSpace(notes) == "" {
+		return "item is empty"
+	}
+	if strings.Contains(notes, ageArmorBegin) {
+		if !strings.Contains(notes, ageArmorEnd) {
+			return "age armor is truncated (no END line)"
+		}
+		return ""
+	}
+
+	switch itemType {
+	case "binary":
+		if _, err := parseBinaryNote(notes); err != nil {
+			return err.Error()
+		}
+	case "sshkey":
+		return lintSSHKeyNotes(notes)
+	case "kubeconfig":
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal([]byte(notes), &doc); err != nil {
+			return "invalid kubeconfig YAML: " + err.Error()
+		}
+		if _, ok := doc["clusters"]; !ok {
+			return "kubeconfig has no clusters"
+		}
+	case "env":
+		for i, line := range strings.Split(notes, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			line = strings.TrimPrefix(line, "export ")
+			if key, _, ok := strings.Cut(line, "="); !ok || key == "" || strings.ContainsAny(key, " \t") {
+				return fmt.Sprintf("line %d is not KEY=value", i+1)
+			}
+		}
+	}
+	return ""
+}
+
+// lintSSHKeyNotes checks the private key block parses and, when the notes
+// include a public key, that it belongs to the private key
+func lintSSHKeyNotes(notes string) string {
+	private := extractSSHPrivateKey(notes)
+	if private == "" {
+		return "no private key block"
+	}
+	if !strings.Contains(private, "-----END ") {
+		return "private key is truncated (no END line)"
+	}
+	key, err := ssh.ParseRawPrivateKey([]byte(private + "\n"))
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return "" // encrypted key: structure parsed, contents can't be checked
+		}
+		return "private key does not parse: " + err.Error()
+	}
+	pubLine := extractSSHPublicKey(notes)
+	if pubLine == "" {
+		return ""
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return "unsupported private key: " + err.Error()
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pubLine))
+	if err != nil {
+		return "public key does not parse: " + err.Error()
+	}
+	if !bytes.Equal(pub.Marshal(), signer.PublicKey().Marshal()) {
+		return "public key does not match the private key"
+	}
+	return ""
+}
+
+// ============================================================
+// rotate
+// ============================================================
+
+// parseItemAge accepts Nd, Nw, Ny and Go durations; "" means any age
+func parseItemAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour}
+	if unit, ok := units[s[len(s)-1:]]; ok {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid age %q (use e.g. 90d, 12w, 1y)", s)
+		}
+		return time.Duration(n) * unit, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 90d, 12w, 1y)", s)
+	}
+	return d, nil
+}
+
+func vaultRotateSSH(args []string, maxAge time.Duration, opts vaultBatchOptions) error {
+	restore, err := startVaultBatch("Rotate SSH Keys", opts)
+	if restore == nil {
+		return err
+	}
+	defer restore()
+
+	items, names, err := selectBatchItems(args, opts.All, func(item VaultItem) bool { return item.Type == "sshkey" })
+	if err != nil || len(names) == 0 {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	backend, session, err := connectVaultBackend(ctx, getVaultBackend())
+	cancel()
+	if err != nil {
+		Fail("Vault not available: %v", err)
+		return err
+	}
+	defer backend.Close()
+
+	report, err := runVaultBatch("rotate", names, opts, func(ctx context.Context, name string) (string, string) {
+		item, err := backend.GetItem(ctx, name, session)
+		if err != nil {
+			return reportStatusFailed, err.Error()
+		}
+		if maxAge > 0 {
+			if item.Modified.IsZero() {
+				if !force {
+					return reportStatusSkipped, "backend does not report modification time (use --force)"
+				}
+			} else if age := time.Since(item.Modified); age < maxAge {
+				return reportStatusSkipped, fmt.Sprintf("modified %d days ago", int(age.Hours()/24))
+			}
+		}
+
+		comment := name
+		if fields := strings.Fields(extractSSHPublicKey(item.Notes)); len(fields) > 2 {
+			comment = strings.Join(fields[2:], " ")
+		}
+		if opts.DryRun {
+			return reportStatusPlanned, "would generate a new ed25519 key"
+		}
+
+		private, public, fingerprint, err := generateSSHKey(comment)
+		if err != nil {
+			return reportStatusFailed, err.Error()
+		}
+		if err := backend.UpdateItem(ctx, name, private+public, session); err != nil {
+			return reportStatusFailed, "vault update failed: " + err.Error()
+		}
+
+		detail := "new key " + fingerprint
+		if path := items[name].Path; path != "" {
+			path = expandPath(path)
+			if err := backupFile(path); err != nil {
+				Warn("%s: backup failed: %v", name, err)
+			}
+			if err := writeFileAtomic(path, []byte(private), 0600); err != nil {
+				return reportStatusFailed, "stored in vault but local write failed: " + err.Error()
+			}
+			if err := writeFileAtomic(path+".pub", []byte(public), 0644); err != nil {
+				Warn("%s: failed to write public key: %v", name, err)
+			}
+			detail += " → " + tildePath(path)
+		}
+		return reportStatusRotated, detail
+	})
+	if report.Summary[reportStatusRotated] > 0 {
+		Info("Install the new public keys wherever the old ones were authorized")
+	}
+	return finishVaultBatch(report, err, opts)
+}
+
+// generateSSHKey returns a new ed25519 key as an OpenSSH private key, an
+// authorized_keys line, and its fingerprint
+func generateSSHKey(comment string) (private, public, fingerprint string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", "", err
+	}
+	block, err := ssh.MarshalPrivateKey(priv, comment)
+	if err != nil {
+		return "", "", "", err
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return "", "", "", err
+	}
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub)))
+	if comment != "" {
+		line += " " + comment
+	}
+	return string(pem.EncodeToMemory(block)), line + "\n", ssh.FingerprintSHA256(sshPub), nil
+}
+
+// ============================================================
+// re-encrypt
+// ============================================================
+
+func vaultReencrypt(args, identities []string, opts vaultBatchOptions) error {
+	restore, err := startVaultBatch("Re-encrypt Vault Items", opts)
+	if restore == nil {
+		return err
+	}
+	defer restore()
+
+	if !isAgeInstalled() {
+		Fail("'age' is not installed")
+		return fmt.Errorf("age not installed")
+	}
+	if !isEncryptionInitialized() {
+		Fail("Encryption not initialized")
+		fmt.Println("Run: blackdot encrypt init")
+		return fmt.Errorf("encryption not initialized")
+	}
+	identityArgs := []string{"-d", "-i", getAgeKeyFile()}
+	for _, id := range identities {
+		identityArgs = append(identityArgs, "-i", expandPath(id))
+	}
+
+	_, names, err := selectBatchItems(args, opts.All, nil)
+	if err != nil || len(names) == 0 {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	backend, session, err := connectVaultBackend(ctx, getVaultBackend())
+	cancel()
+	if err != nil {
+		Fail("Vault not available: %v", err)
+		return err
+	}
+	defer backend.Close()
+
+	report, err := runVaultBatch("re-encrypt", names, opts, func(ctx context.Context, name string) (string, string) {
+		notes, err := backend.GetNotes(ctx, name, session)
+		if err != nil {
+			return reportStatusFailed, err.Error()
+		}
+		if !strings.Contains(notes, ageArmorBegin) {
+			return reportStatusSkipped, "not age-encrypted"
+		}
+		plain, err := runAge(ctx, notes, identityArgs...)
+		if err != nil {
+			return reportStatusFailed, "decrypt failed (pass the old key with --identity): " + err.Error()
+		}
+		if opts.DryRun {
+			return reportStatusPlanned, "decrypts with the given identities"
+		}
+		cipher, err := runAge(ctx, plain, "-a", "-R", getAgeRecipientsFile())
+		if err != nil {
+			return reportStatusFailed, "encrypt failed: " + err.Error()
+		}
+		if err := backend.UpdateItem(ctx, name, cipher, session); err != nil {
+			return reportStatusFailed, "vault update failed: " + err.Error()
+		}
+		return reportStatusUpdated, ""
+	})
+	return finishVaultBatch(report, err, opts)
+}
+
+// runAge pipes input through the age CLI
+func runAge(ctx context.Context, input string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "age", args...)
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
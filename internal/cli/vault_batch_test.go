@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestLintVaultItem verifies per-type format checks, including SSH keys
+// whose public half does not match
+func TestLintVaultItem(t *testing.T) {
+	private, public, _, err := generateSSHKey("me@host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPublic, _, _ := generateSSHKey("other")
+
+	for _, tc := range []struct {
+		name, itemType, notes string
+		ok                    bool
+	}{
+		{"ssh key", "sshkey", private + public, true},
+		{"ssh key without pub", "sshkey", private, true},
+		{"mismatched pub", "sshkey", private + otherPublic, false},
+		{"truncated key", "sshkey", strings.SplitN(private, "\n", 3)[0] + "\n" + strings.SplitN(private, "\n", 3)[1], false},
+		{"empty", "file", "  \n", false},
+		{"kubeconfig", "kubeconfig", "apiVersion: v1\nclusters: []\n", true},
+		{"kubeconfig without clusters", "kubeconfig", "apiVersion: v1\n", false},
+		{"env", "env", "# comment\nexport A=1\nB=two\n", true},
+		{"bad env", "env", "A=1\nnot a pair\n", false},
+		{"age", "file", ageArmorBegin + "\nabc\n" + ageArmorEnd + "\n", true},
+		{"truncated age", "file", ageArmorBegin + "\nabc\n", false},
+	} {
+		if problem := lintVaultItem(tc.itemType, tc.notes); (problem == "") != tc.ok {
+			t.Errorf("%s: problem = %q", tc.name, problem)
+		}
+	}
+}
+
+// TestParseItemAge verifies day, week and year suffixes and Go durations
+func TestParseItemAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"":    0,
+		"90d": 90 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"1y":  365 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+	} {
+		if got, err := parseItemAge(in); err != nil || got != want {
+			t.Errorf("parseItemAge(%q) = %v, %v", in, got, err)
+		}
+	}
+	for _, bad := range []string{"y", "-1d", "soon"} {
+		if _, err := parseItemAge(bad); err == nil {
+			t.Errorf("parseItemAge(%q) should fail", bad)
+		}
+	}
+}
+
+// TestRunVaultBatchResume verifies finished items are checkpointed, a
+// resumed run only retries failures, and a clean run drops the checkpoint
+func TestRunVaultBatchResume(t *testing.T) {
+	setScheduleEnv(t, "")
+	names := []string{"a", "b", "c"}
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	failB := true
+	task := func(ctx context.Context, name string) (string, string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[name]++
+		if name == "b" && failB {
+			return reportStatusFailed, "boom"
+		}
+		return reportStatusVerified, ""
+	}
+
+	report, err := runVaultBatch("verify", names, vaultBatchOptions{Concurrency: 2}, task)
+	if err == nil || report.Summary[reportStatusFailed] != 1 {
+		t.Fatalf("first run: err = %v, summary = %v", err, report.Summary)
+	}
+	if cp := loadVaultBatchCheckpoint("verify"); cp == nil || len(cp.Done) != 2 {
+		t.Fatalf("checkpoint = %+v", cp)
+	}
+
+	failB = false
+	report, err = runVaultBatch("verify", names, vaultBatchOptions{Concurrency: 2, Resume: true}, task)
+	if err != nil || report.Summary[reportStatusVerified] != 3 {
+		t.Fatalf("resume: err = %v, summary = %v", err, report.Summary)
+	}
+	if calls["a"] != 1 || calls["b"] != 2 {
+		t.Errorf("calls = %v", calls)
+	}
+	if _, err := os.Stat(vaultBatchCheckpointPath("verify")); !os.IsNotExist(err) {
+		t.Error("checkpoint should be removed after a clean run")
+	}
+}
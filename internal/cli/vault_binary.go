@@ -0,0 +1,396 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// Binary items (keystores, .p12 certificates, wireguard configs) don't fit
+// a note's text field. The item's notes hold a manifest naming where the
+// content lives and its checksum:
+//
+//	blackdot-binary: v1
+//	sha256: 9f86d0...
+//	size: 2048
+//	storage: attachment
+//
+// Bitwarden keeps the content as an attachment of the item, 1Password as a
+// document, and pass as a gpg-encrypted file of its own. Other backends,
+// or a failed upload, store it base64-encoded in the notes after the
+// manifest.
+const binaryNoteHeader = "blackdot-binary: v1"
+
+// binaryStorageBase64 is the storage that works with every backend
+const binaryStorageBase64 = "base64"
+
+// binaryManifest describes a binary item's content
+type binaryManifest struct {
+	SHA256  string
+	Size    int
+	Storage string
+	Data    []byte // decoded content, base64 storage only
+}
+
+// isBinaryNote reports whether notes hold a binary item manifest
+func isBinaryNote(notes string) bool {
+	return strings.HasPrefix(notes, binaryNoteHeader+"\n")
+}
+
+// encodeBinaryNote returns the notes for content kept in storage
+func encodeBinaryNote(data []byte, storage string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\nsha256: %s\nsize: %d\nstorage: %s\n", binaryNoteHeader, calculateChecksum(data), len(data), storage)
+	if storage == binaryStorageBase64 {
+		b.WriteString("\n")
+		encoded := base64.StdEncoding.EncodeToString(data)
+		for len(encoded) > 76 {
+			b.WriteString(encoded[:76] + "\n")
+			encoded = encoded[76:]
+		}
+		if encoded != "" {
+			b.WriteString(encoded + "\n")
+		}
+	}
+	return b.String()
+}
+
+// parseBinaryNote reads a manifest, decoding base64 content
+func parseBinaryNote(notes string) (*binaryManifest, error) {
+	if !isBinaryNote(notes) {
+		return nil, fmt.Errorf("not a binary item (no %q header); push it again", binaryNoteHeader)
+	}
+	header, body, _ := strings.Cut(strings.ReplaceAll(notes, "\r\n", "\n"), "\n\n")
+	m := &binaryManifest{Size: -1}
+	for _, line := range strings.Split(header, "\n")[1:] {
+		key, value, _ := strings.Cut(line, ":")
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "sha256":
+			m.SHA256 = value
+		case "size":
+			if n, err := strconv.Atoi(value); err == nil {
+				m.Size = n
+			}
+		case "storage":
+			m.Storage = value
+		}
+	}
+	if m.SHA256 == "" || m.Size < 0 || m.Storage == "" {
+		return nil, fmt.Errorf("binary item manifest is incomplete")
+	}
+	if m.Storage == binaryStorageBase64 {
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 content: %w", err)
+		}
+		if err := m.verify(data); err != nil {
+			return nil, err
+		}
+		m.Data = data
+	}
+	return m, nil
+}
+
+// verify checks content against the manifest
+func (m *binaryManifest) verify(data []byte) error {
+	if len(data) != m.Size || calculateChecksum(data) != m.SHA256 {
+		return fmt.Errorf("content does not match the manifest checksum (%d bytes, expected %d)", len(data), m.Size)
+	}
+	return nil
+}
+
+// vaultContentChecksum is the checksum of the file an item restores to:
+// the manifest checksum for binary items, the notes' own otherwise
+func vaultContentChecksum(notes string) string {
+	if m, err := parseBinaryNote(notes); err == nil {
+		return m.SHA256
+	}
+	return calcChecksum(notes)
+}
+
+// binaryStore keeps binary content outside the notes field
+type binaryStore interface {
+	Storage() string
+	Put(ctx context.Context, name string, data []byte) error
+	Get(ctx context.Context, name string) ([]byte, error)
+}
+
+// binaryStoreFor returns the native store for a backend, or nil when the
+// content must go in the notes
+func binaryStoreFor(backendType vaultmux.BackendType, backend vaultmux.Backend, session vaultmux.Session) binaryStore {
+	switch backendType {
+	case vaultmux.BackendBitwarden:
+		return &bitwardenAttachments{backend: backend, session: session}
+	case vaultmux.BackendOnePassword:
+		return &onePasswordDocuments{session: session}
+	case vaultmux.BackendPass:
+		return passBinaryFiles{}
+	}
+	return nil
+}
+
+// pushBinaryItem writes data to the vault item name, which exists when
+// update is set, and returns the storage used. The manifest is written
+// first so Bitwarden has an item to attach to; if the native upload fails
+// the manifest is rewritten with the content in base64.
+// vault.binary_storage=base64 skips native stores.
+func pushBinaryItem(ctx context.Context, backendType vaultmux.BackendType, backend vaultmux.Backend, session vaultmux.Session, name string, data []byte, update bool) (string, error) {
+	write := func(notes string) error {
+		if update {
+			return backend.UpdateItem(ctx, name, notes, session)
+		}
+		update = true
+		return backend.CreateItem(ctx, name, notes, session)
+	}
+
+	store := binaryStoreFor(backendType, backend, session)
+	if configLookup("vault.binary_storage") == binaryStorageBase64 {
+		store = nil
+	}
+	if store != nil {
+		if err := write(encodeBinaryNote(data, store.Storage())); err != nil {
+			return "", err
+		}
+		err := store.Put(ctx, name, data)
+		if err == nil {
+			return store.Storage(), nil
+		}
+		Warn("%s: %s upload failed, storing base64 in notes: %v", name, store.Storage(), err)
+	}
+	return binaryStorageBase64, write(encodeBinaryNote(data, binaryStorageBase64))
+}
+
+// readBinaryItem returns the content a binary item's notes describe,
+// fetching it from the backend that served the notes when it is not inline
+func readBinaryItem(ctx context.Context, reader *vaultReader, from vaultmux.BackendType, name, notes string) ([]byte, error) {
+	m, err := parseBinaryNote(notes)
+	if err != nil {
+		return nil, err
+	}
+	if m.Storage == binaryStorageBase64 {
+		return m.Data, nil
+	}
+
+	member, err := reader.memberNamed(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	store := binaryStoreFor(from, member.backend, member.session)
+	if store == nil || store.Storage() != m.Storage {
+		return nil, fmt.Errorf("content is stored as %s, which %s cannot read", m.Storage, from)
+	}
+	data, err := store.Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", m.Storage, err)
+	}
+	if err := m.verify(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// memberNamed returns the connected reader member for a backend type
+func (r *vaultReader) memberNamed(ctx context.Context, name vaultmux.BackendType) (*vaultReaderMember, error) {
+	for i := range r.members {
+		if r.members[i].name == name {
+			return r.member(ctx, i)
+		}
+	}
+	return nil, fmt.Errorf("backend %s is not configured", name)
+}
+
+// looksBinary reports whether file content can't be kept as note text
+func looksBinary(data []byte) bool {
+	return !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0
+}
+
+// binaryFileExtensions are secrets that are binary regardless of content
+var binaryFileExtensions = map[string]bool{
+	".p12": true, ".pfx": true, ".jks": true, ".keystore": true, ".der": true, ".kdbx": true,
+}
+
+// isBinarySecretFile reports whether a discovered file should be a binary
+// item
+func isBinarySecretFile(path string) bool {
+	if binaryFileExtensions[strings.ToLower(filepath.Ext(path))] {
+		return true
+	}
+	data, err := os.ReadFile(path)
+	return err == nil && looksBinary(data)
+}
+
+// binaryFileName is the file name binary content is uploaded as
+const binaryFileName = "blackdot.bin"
+
+// bitwardenAttachments stores content as an attachment of the item
+type bitwardenAttachments struct {
+	backend vaultmux.Backend
+	session vaultmux.Session
+}
+
+func (bitwardenAttachments) Storage() string { return "attachment" }
+
+func (s *bitwardenAttachments) bw(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "bw", args...)
+	cmd.Env = append(os.Environ(), "BW_SESSION="+s.session.Token())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("bw %s: %w", args[0]+" "+args[1], err)
+	}
+	return out, nil
+}
+
+// attachments lists the item's id and its blackdot attachments' ids
+func (s *bitwardenAttachments) attachments(ctx context.Context, name string) (string, []string, error) {
+	item, err := s.backend.GetItem(ctx, name, s.session)
+	if err != nil {
+		return "", nil, err
+	}
+	out, err := s.bw(ctx, "get", "item", item.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	var full struct {
+		Attachments []struct {
+			ID       string `json:"id"`
+			FileName string `json:"fileName"`
+		} `json:"attachments"`
+	}
+	if err := json.Unmarshal(out, &full); err != nil {
+		return "", nil, fmt.Errorf("parse bw item: %w", err)
+	}
+	var ids []string
+	for _, a := range full.Attachments {
+		if a.FileName == binaryFileName {
+			ids = append(ids, a.ID)
+		}
+	}
+	return item.ID, ids, nil
+}
+
+func (s *bitwardenAttachments) Put(ctx context.Context, name string, data []byte) error {
+	itemID, existing, err := s.attachments(ctx, name)
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "blackdot-bin-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, binaryFileName)
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return err
+	}
+	if _, err := s.bw(ctx, "create", "attachment", "--file", file, "--itemid", itemID); err != nil {
+		return err
+	}
+	// Replace, not accumulate: old copies go once the new one is attached
+	for _, id := range existing {
+		if _, err := s.bw(ctx, "delete", "attachment", id, "--itemid", itemID); err != nil {
+			Debug("Failed to delete old attachment %s of %s: %v", id, name, err)
+		}
+	}
+	return nil
+}
+
+func (s *bitwardenAttachments) Get(ctx context.Context, name string) ([]byte, error) {
+	itemID, existing, err := s.attachments(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) == 0 {
+		return nil, fmt.Errorf("item has no %s attachment", binaryFileName)
+	}
+	return s.bw(ctx, "get", "attachment", existing[len(existing)-1], "--itemid", itemID, "--raw")
+}
+
+// onePasswordDocuments stores content as a document titled "<name>.bin"
+type onePasswordDocuments struct {
+	session vaultmux.Session
+}
+
+func (onePasswordDocuments) Storage() string { return "document" }
+
+func (s *onePasswordDocuments) op(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "op", args...)
+	cmd.Env = append(os.Environ(), "OP_SESSION_my="+s.session.Token())
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("op %s: %w", args[0]+" "+args[1], err)
+	}
+	return out, nil
+}
+
+func (s *onePasswordDocuments) Put(ctx context.Context, name string, data []byte) error {
+	title := name + ".bin"
+	// "-" reads the document from stdin, so the content never touches disk
+	if _, err := s.op(ctx, data, "document", "edit", title, "-", "--file-name", binaryFileName); err == nil {
+		return nil
+	}
+	_, err := s.op(ctx, data, "document", "create", "-", "--title", title, "--file-name", binaryFileName)
+	return err
+}
+
+func (s *onePasswordDocuments) Get(ctx context.Context, name string) ([]byte, error) {
+	return s.op(ctx, nil, "document", "get", name+".bin")
+}
+
+// passBinaryFiles stores content as its own pass entry, "<name>.bin" under
+// the blackdot prefix. pass encrypts stdin as-is, so binary content
+// round-trips.
+type passBinaryFiles struct{}
+
+func (passBinaryFiles) Storage() string { return "pass" }
+
+func (passBinaryFiles) Put(ctx context.Context, name string, data []byte) error {
+	cmd := exec.CommandContext(ctx, "pass", "insert", "-m", "-f", "blackdot/"+name+".bin")
+	cmd.Stdin = bytes.NewReader(data)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("pass insert: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (passBinaryFiles) Get(ctx context.Context, name string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, "pass", "show", "blackdot/"+name+".bin").Output()
+	if err != nil {
+		return nil, fmt.Errorf("pass show: %w", err)
+	}
+	return out, nil
+}
+
+// binarySecretDirs are searched by 'vault scan' for binary secrets
+func binarySecretDirs(home string) []string {
+	return []string{
+		filepath.Join(home, ".ssh"),
+		filepath.Join(home, ".aws"),
+		filepath.Join(home, ".certs"),
+		filepath.Join(home, ".android"),
+		filepath.Join(home, ".config", "wireguard"),
+	}
+}
+
+// binaryItemNameChars are dropped from generated item names
+var binaryItemNameChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// binaryItemName generates a vault name from a file name:
+// release.keystore → Binary-Release, wg0.conf → Binary-Wg0
+func binaryItemName(filename string) string {
+	return "Binary-" + titleItemName(strings.TrimSuffix(filename, filepath.Ext(filename)), "File")
+}
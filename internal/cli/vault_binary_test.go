@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/blackwell-systems/vaultmux/mock"
+)
+
+// TestBinaryNoteRoundTrip verifies base64 notes decode to the original
+// bytes and a corrupted body is rejected
+func TestBinaryNoteRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte{0x00, 0xff, 0x30, 0x82}, 100)
+	notes := encodeBinaryNote(data, binaryStorageBase64)
+	if !isBinaryNote(notes) {
+		t.Fatalf("notes have no header:\n%s", notes)
+	}
+	for _, line := range strings.Split(notes, "\n") {
+		if len(line) > 76 {
+			t.Errorf("line not wrapped: %q", line)
+		}
+	}
+
+	m, err := parseBinaryNote(notes)
+	if err != nil || !bytes.Equal(m.Data, data) || m.Size != len(data) {
+		t.Fatalf("parse = %+v, %v", m, err)
+	}
+	if got := vaultContentChecksum(notes); got != calculateChecksum(data) {
+		t.Errorf("checksum = %s", got)
+	}
+
+	corrupt := strings.Replace(notes, "AP8w", "AP8x", 1)
+	if _, err := parseBinaryNote(corrupt); err == nil {
+		t.Error("corrupted content was accepted")
+	}
+	if _, err := parseBinaryNote("plain text"); err == nil {
+		t.Error("text notes were accepted")
+	}
+
+	// Natively stored content has only the manifest
+	m, err = parseBinaryNote(encodeBinaryNote(data, "attachment"))
+	if err != nil || m.Storage != "attachment" || m.Data != nil {
+		t.Errorf("attachment manifest = %+v, %v", m, err)
+	}
+}
+
+// TestBinaryItemPushAndRestore verifies a backend without native file
+// storage keeps content in the notes and it reads back intact
+func TestBinaryItemPushAndRestore(t *testing.T) {
+	setScheduleEnv(t, `{}`)
+	backend := mock.New()
+	reader := newVaultReader([]vaultmux.BackendType{"mock"}, func(ctx context.Context, _ vaultmux.BackendType) (vaultmux.Backend, vaultmux.Session, error) {
+		session, err := backend.Authenticate(ctx)
+		return backend, session, err
+	})
+	ctx := context.Background()
+	session, _ := backend.Authenticate(ctx)
+
+	data := []byte{0x30, 0x82, 0x00, 0x01, 0xfe}
+	storage, err := pushBinaryItem(ctx, "mock", backend, session, "Keystore", data, false)
+	if err != nil || storage != binaryStorageBase64 {
+		t.Fatalf("push = %s, %v", storage, err)
+	}
+
+	notes, from, err := reader.GetNotes(ctx, "Keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readBinaryItem(ctx, reader, from, "Keystore", notes)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("restore = %x, %v", got, err)
+	}
+
+	// The manifest names a store this backend doesn't have
+	if _, err := readBinaryItem(ctx, reader, from, "Keystore", encodeBinaryNote(data, "attachment")); err == nil {
+		t.Error("unreadable storage was accepted")
+	}
+}
+
+// TestCheckItemDriftBinary verifies binary items are compared by checksum
+func TestCheckItemDriftBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "release.keystore")
+	data := []byte{0x00, 0x01, 0x02}
+	os.WriteFile(path, data, 0600)
+
+	if got := checkItemDrift(path, encodeBinaryNote(data, "attachment")); got != 0 {
+		t.Errorf("same content drift = %d", got)
+	}
+	if got := checkItemDrift(path, encodeBinaryNote([]byte{0x09}, binaryStorageBase64)); got != 1 {
+		t.Errorf("changed content drift = %d", got)
+	}
+}
+
+// TestBinaryScanHelpers verifies discovered files are classified and named
+func TestBinaryScanHelpers(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"cert.p12":  []byte("text but a certificate bundle"),
+		"blob":      {0x00, 0xff},
+		"notes.txt": []byte("plain text"),
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), content, 0600)
+	}
+	for name, want := range map[string]bool{"cert.p12": true, "blob": true, "notes.txt": false} {
+		if got := isBinarySecretFile(filepath.Join(dir, name)); got != want {
+			t.Errorf("isBinarySecretFile(%s) = %v", name, got)
+		}
+	}
+
+	for in, want := range map[string]string{
+		"release.keystore": "Binary-Release",
+		"wg0.conf":         "Binary-Wg0",
+		"my cert.p12":      "Binary-My-cert",
+		".p12":             "Binary-File",
+	} {
+		if got := binaryItemName(in); got != want {
+			t.Errorf("binaryItemName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// defaultVaultBulkThreshold is how many item reads an operation must
+// expect before items are fetched in one listing (vault.batch_threshold)
+const defaultVaultBulkThreshold = 5
+
+// vaultBulkFetcher fetches every item, with its notes, in one call
+type vaultBulkFetcher func(ctx context.Context, b vaultmux.Backend, session vaultmux.Session) ([]*vaultmux.Item, error)
+
+// vaultBulkFetchers are the backends whose CLIs cost a process per call and
+// can list item contents at once. `bw list items` includes notes; `op item
+// list` does not, so its output is piped back into `op item get -`.
+var vaultBulkFetchers = map[vaultmux.BackendType]vaultBulkFetcher{
+	vaultmux.BackendBitwarden: func(ctx context.Context, b vaultmux.Backend, session vaultmux.Session) ([]*vaultmux.Item, error) {
+		return b.ListItems(ctx, session)
+	},
+	vaultmux.BackendOnePassword: fetchOnePasswordItems,
+}
+
+// vaultReadsExpected is the number of item reads the running operation
+// announced with expectVaultReads
+var vaultReadsExpected struct {
+	mu sync.Mutex
+	n  int
+}
+
+// expectVaultReads announces that an operation is about to read n items,
+// so backends can serve them from one bulk fetch
+func expectVaultReads(n int) {
+	vaultReadsExpected.mu.Lock()
+	defer vaultReadsExpected.mu.Unlock()
+	vaultReadsExpected.n = n
+}
+
+// vaultBulkThreshold reads vault.batch_threshold; 0 turns bulk fetches off
+func vaultBulkThreshold() int {
+	if value := configLookup("vault.batch_threshold"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			return n
+		}
+		Warn("Ignoring invalid vault.batch_threshold %q", value)
+	}
+	return defaultVaultBulkThreshold
+}
+
+// bulkBackend serves notes and existence checks from a single listing once
+// an operation expects enough reads. GetItem is not cached: listings lack
+// dates. Names the listing does not contain exactly are still looked up
+// directly, and a failed listing falls back to per-item calls.
+type bulkBackend struct {
+	vaultmux.Backend
+	backendType vaultmux.BackendType
+	fetch       vaultBulkFetcher
+
+	mu     sync.Mutex
+	loaded bool
+	failed bool                      // listing failed; don't retry this run
+	items  map[string]*vaultmux.Item // nil for a name used by several items
+}
+
+// newBulkBackend wraps b when its backend type supports bulk fetches
+func newBulkBackend(b vaultmux.Backend, backendType vaultmux.BackendType) vaultmux.Backend {
+	fetch, ok := vaultBulkFetchers[backendType]
+	if !ok {
+		return b
+	}
+	return &bulkBackend{Backend: b, backendType: backendType, fetch: fetch}
+}
+
+// cached returns the listed item named name, fetching the listing first
+// if the operation expects enough reads
+func (b *bulkBackend) cached(ctx context.Context, name string, session vaultmux.Session) (*vaultmux.Item, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.loaded && !b.failed {
+		vaultReadsExpected.mu.Lock()
+		expected := vaultReadsExpected.n
+		vaultReadsExpected.mu.Unlock()
+		threshold := vaultBulkThreshold()
+		if threshold == 0 || expected < threshold {
+			return nil, false
+		}
+		items, err := b.fetch(ctx, b.Backend, session)
+		if err != nil {
+			Debug("bulk fetch from %s failed, reading items one by one: %v", b.backendType, err)
+			b.failed = true
+			return nil, false
+		}
+		b.items = indexVaultItems(items)
+		b.loaded = true
+	}
+	item := b.items[name]
+	return item, item != nil
+}
+
+// indexVaultItems maps items by name. A name shared by several items maps
+// to nil so it is looked up directly, as the CLI would resolve it.
+func indexVaultItems(items []*vaultmux.Item) map[string]*vaultmux.Item {
+	index := make(map[string]*vaultmux.Item, len(items))
+	for _, item := range items {
+		if _, dup := index[item.Name]; dup {
+			index[item.Name] = nil
+			continue
+		}
+		index[item.Name] = item
+	}
+	return index
+}
+
+// invalidate drops the listing, e.g. after a sync
+func (b *bulkBackend) invalidate() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.loaded, b.items = false, nil
+}
+
+// remember updates a listed item after a successful write
+func (b *bulkBackend) remember(name, content string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.loaded {
+		return
+	}
+	if item, ok := b.items[name]; ok && item != nil {
+		updated := *item
+		updated.Notes = content
+		b.items[name] = &updated
+		return
+	}
+	if _, dup := b.items[name]; !dup {
+		b.items[name] = &vaultmux.Item{Name: name, Type: vaultmux.ItemTypeSecureNote, Notes: content}
+	}
+}
+
+func (b *bulkBackend) Sync(ctx context.Context, session vaultmux.Session) error {
+	defer b.invalidate()
+	return b.Backend.Sync(ctx, session)
+}
+
+func (b *bulkBackend) GetNotes(ctx context.Context, name string, session vaultmux.Session) (string, error) {
+	if item, ok := b.cached(ctx, name, session); ok {
+		return item.Notes, nil
+	}
+	return b.Backend.GetNotes(ctx, name, session)
+}
+
+func (b *bulkBackend) ItemExists(ctx context.Context, name string, session vaultmux.Session) (bool, error) {
+	if _, ok := b.cached(ctx, name, session); ok {
+		return true, nil
+	}
+	return b.Backend.ItemExists(ctx, name, session)
+}
+
+func (b *bulkBackend) CreateItem(ctx context.Context, name, content string, session vaultmux.Session) error {
+	err := b.Backend.CreateItem(ctx, name, content, session)
+	if err == nil {
+		b.remember(name, content)
+	}
+	return err
+}
+
+func (b *bulkBackend) UpdateItem(ctx context.Context, name, content string, session vaultmux.Session) error {
+	err := b.Backend.UpdateItem(ctx, name, content, session)
+	if err == nil {
+		b.remember(name, content)
+	}
+	return err
+}
+
+func (b *bulkBackend) DeleteItem(ctx context.Context, name string, session vaultmux.Session) error {
+	err := b.Backend.DeleteItem(ctx, name, session)
+	if err == nil {
+		b.mu.Lock()
+		delete(b.items, name)
+		b.mu.Unlock()
+	}
+	return err
+}
+
+// onePasswordItem is the part of `op item get --format json` bulk fetches
+// read
+type onePasswordItem struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Vault struct {
+		Name string `json:"name"`
+	} `json:"vault"`
+	Fields []struct {
+		Type  string `json:"type"`
+		Label string `json:"label"`
+		Value string `json:"value"`
+	} `json:"fields"`
+}
+
+// fetchOnePasswordItems runs `op item list | op item get -`: two processes
+// instead of one per item
+func fetchOnePasswordItems(ctx context.Context, _ vaultmux.Backend, session vaultmux.Session) ([]*vaultmux.Item, error) {
+	defer timePhase("vault.1password.bulk")()
+	env := append(os.Environ(), "OP_SESSION_my="+session.Token())
+
+	list := exec.CommandContext(ctx, "op", "item", "list", "--format", "json")
+	list.Env = env
+	listed, err := list.Output()
+	if err != nil {
+		return nil, fmt.Errorf("op item list: %w", err)
+	}
+
+	get := exec.CommandContext(ctx, "op", "item", "get", "-", "--format", "json")
+	get.Env = env
+	get.Stdin = bytes.NewReader(listed)
+	out, err := get.Output()
+	if err != nil {
+		return nil, fmt.Errorf("op item get: %w", err)
+	}
+	return parseOnePasswordItems(out)
+}
+
+// parseOnePasswordItems reads the stream of JSON objects `op item get -`
+// prints, taking notes the way the 1password backend does
+func parseOnePasswordItems(data []byte) ([]*vaultmux.Item, error) {
+	var items []*vaultmux.Item
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var op onePasswordItem
+		if err := dec.Decode(&op); err == io.EOF {
+			return items, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("parsing op output: %w", err)
+		}
+		item := &vaultmux.Item{ID: op.ID, Name: op.Title, Type: vaultmux.ItemTypeSecureNote, Location: op.Vault.Name}
+		for _, field := range op.Fields {
+			if field.Label == "notesPlain" || field.Type == "TEXT" {
+				item.Notes = field.Value
+				break
+			}
+		}
+		items = append(items, item)
+	}
+}
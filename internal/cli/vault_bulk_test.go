@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/blackwell-systems/vaultmux/mock"
+)
+
+// countingBackend counts per-item reads and listings
+type countingBackend struct {
+	*mock.Backend
+	gets, lists int
+}
+
+func (b *countingBackend) GetNotes(ctx context.Context, name string, s vaultmux.Session) (string, error) {
+	b.gets++
+	return b.Backend.GetNotes(ctx, name, s)
+}
+
+func (b *countingBackend) ListItems(ctx context.Context, s vaultmux.Session) ([]*vaultmux.Item, error) {
+	b.lists++
+	return b.Backend.ListItems(ctx, s)
+}
+
+// TestBulkBackend verifies reads come from one listing only when enough
+// are expected, names missing from it are looked up directly, writes update
+// it, and a sync drops it
+func TestBulkBackend(t *testing.T) {
+	setScheduleEnv(t, "")
+	t.Cleanup(func() { expectVaultReads(0) })
+	inner := &countingBackend{Backend: mock.New()}
+	for i := 0; i < 6; i++ {
+		inner.SetItem(fmt.Sprintf("Item-%d", i), fmt.Sprintf("notes %d", i))
+	}
+	b := newBulkBackend(inner, vaultmux.BackendBitwarden)
+	ctx := context.Background()
+	session, _ := inner.Authenticate(ctx)
+
+	expectVaultReads(2)
+	b.GetNotes(ctx, "Item-0", session)
+	if inner.lists != 0 || inner.gets != 1 {
+		t.Fatalf("below threshold: %d lists, %d gets", inner.lists, inner.gets)
+	}
+
+	expectVaultReads(6)
+	for i := 0; i < 6; i++ {
+		notes, err := b.GetNotes(ctx, fmt.Sprintf("Item-%d", i), session)
+		if err != nil || notes != fmt.Sprintf("notes %d", i) {
+			t.Errorf("Item-%d = %q, %v", i, notes, err)
+		}
+	}
+	if inner.lists != 1 || inner.gets != 1 {
+		t.Errorf("bulk: %d lists, %d gets", inner.lists, inner.gets)
+	}
+
+	if _, err := b.GetNotes(ctx, "Missing", session); !errors.Is(err, vaultmux.ErrNotFound) || inner.gets != 2 {
+		t.Errorf("missing item: %v after %d gets", err, inner.gets)
+	}
+
+	b.UpdateItem(ctx, "Item-1", "changed", session)
+	if notes, _ := b.GetNotes(ctx, "Item-1", session); notes != "changed" || inner.gets != 2 {
+		t.Errorf("after update: %q, %d gets", notes, inner.gets)
+	}
+
+	b.Sync(ctx, session)
+	b.GetNotes(ctx, "Item-2", session)
+	if inner.lists != 2 {
+		t.Errorf("sync should drop the listing: %d lists", inner.lists)
+	}
+
+	if other := newBulkBackend(inner, vaultmux.BackendPass); other != vaultmux.Backend(inner) {
+		t.Error("pass should not be wrapped")
+	}
+}
+
+// TestIndexVaultItemsDuplicates verifies a shared name is not served from
+// the listing
+func TestIndexVaultItemsDuplicates(t *testing.T) {
+	index := indexVaultItems([]*vaultmux.Item{{Name: "A"}, {Name: "B"}, {Name: "A"}})
+	if item, ok := index["A"]; !ok || item != nil {
+		t.Errorf("duplicate A = %v, %v", item, ok)
+	}
+	if index["B"] == nil {
+		t.Error("B missing")
+	}
+}
+
+// TestParseOnePasswordItems verifies the object stream from `op item get -`
+func TestParseOnePasswordItems(t *testing.T) {
+	out := `{"id":"1","title":"Git-Config","vault":{"name":"Private"},"fields":[{"type":"STRING","label":"username","value":"me"},{"type":"STRING","label":"notesPlain","value":"[user]"}]}
+{"id":"2","title":"Empty","vault":{"name":"Private"},"fields":[]}
+`
+	items, err := parseOnePasswordItems([]byte(out))
+	if err != nil || len(items) != 2 {
+		t.Fatalf("items = %v, %v", items, err)
+	}
+	if items[0].Name != "Git-Config" || items[0].Notes != "[user]" || items[0].Location != "Private" || items[1].Notes != "" {
+		t.Errorf("items = %+v, %+v", items[0], items[1])
+	}
+	if _, err := parseOnePasswordItems([]byte("{bad")); err == nil {
+		t.Error("bad JSON should fail")
+	}
+}
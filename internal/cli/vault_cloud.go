@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/vault/doppler"
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// Cloud secret managers: Doppler, authenticated with an API token, and AWS
+// Secrets Manager, using the SDK's credential chain (environment, shared
+// profiles, SSO, instance roles). Their settings come from trusted config
+// layers only, so a project file cannot redirect where secrets are written.
+
+// awsSecretsPrefix is the default secret name prefix in AWS Secrets Manager
+const awsSecretsPrefix = "blackdot/"
+
+// vaultBackendOptions returns the backend-specific options for backendType
+func vaultBackendOptions(backendType vaultmux.BackendType) map[string]string {
+	switch backendType {
+	case doppler.BackendType:
+		return dopplerOptions()
+	case vaultmux.BackendAWSSecretsManager:
+		return awsSecretsOptions()
+	}
+	return nil
+}
+
+// dopplerOptions resolves the token, project and config: vault.doppler.*,
+// then DOPPLER_* variables, then what the Doppler CLI has configured
+func dopplerOptions() map[string]string {
+	opts := map[string]string{"prefix": doppler.DefaultPrefix}
+	for key, env := range map[string]string{"token": "DOPPLER_TOKEN", "project": "DOPPLER_PROJECT", "config": "DOPPLER_CONFIG"} {
+		if key != "token" {
+			if val, _ := trustedConfigLookup("vault.doppler." + key); val != "" {
+				opts[key] = val
+				continue
+			}
+		}
+		if val := os.Getenv(env); val != "" {
+			opts[key] = val
+			continue
+		}
+		opts[key] = dopplerCLIValue(key)
+	}
+	if val, _ := trustedConfigLookup("vault.doppler.prefix"); val != "" {
+		opts["prefix"] = val
+	}
+	if val, _ := trustedConfigLookup("vault.doppler.api_url"); val != "" {
+		opts["api_url"] = val
+	}
+	return opts
+}
+
+// dopplerCLIValue reads a setting saved by 'doppler login' or 'doppler
+// setup' for the current directory, or "" without the Doppler CLI
+var dopplerCLIValue = func(key string) string {
+	if !commandExists("doppler") {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "doppler", "configure", "get", key, "--plain").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// awsSecretsOptions resolves the region and prefix. A configured profile
+// is applied through AWS_PROFILE, which the SDK reads, including SSO
+// profiles logged in with 'aws sso login'.
+func awsSecretsOptions() map[string]string {
+	if profile, _ := trustedConfigLookup("vault.aws.profile"); profile != "" {
+		os.Setenv("AWS_PROFILE", profile)
+	}
+	opts := map[string]string{"region": awsSecretsRegion(), "prefix": awsSecretsPrefix}
+	if val, _ := trustedConfigLookup("vault.aws.prefix"); val != "" {
+		opts["prefix"] = val
+	}
+	if val, _ := trustedConfigLookup("vault.aws.endpoint"); val != "" {
+		opts["endpoint"] = val
+	}
+	return opts
+}
+
+// awsSecretsRegion is vault.aws.region, the AWS region variables, the
+// profile's region, or us-east-1
+func awsSecretsRegion() string {
+	if val, _ := trustedConfigLookup("vault.aws.region"); val != "" {
+		return val
+	}
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if val := os.Getenv(env); val != "" {
+			return val
+		}
+	}
+	if p, err := findAWSProfile(currentAWSProfile()); err == nil && p.Region != "" {
+		return p.Region
+	}
+	return "us-east-1"
+}
+
+// dopplerAvailable reports whether a Doppler token can be found
+func dopplerAvailable() bool {
+	return os.Getenv("DOPPLER_TOKEN") != "" || commandExists("doppler")
+}
+
+// awsSecretsAvailable reports whether AWS credentials are configured
+// anywhere the SDK looks. It does not call AWS.
+func awsSecretsAvailable() bool {
+	if os.Getenv("AWS_ACCESS_KEY_ID") != "" || os.Getenv("AWS_PROFILE") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" {
+		return true
+	}
+	return fileExists(awsConfigPath()) || fileExists(awsCredentialsPath())
+}
+
+// cloudBackendHint says how to authenticate to a cloud secret manager
+func cloudBackendHint(backendType vaultmux.BackendType) string {
+	switch backendType {
+	case doppler.BackendType:
+		if commandExists("doppler") {
+			return "doppler login (or export DOPPLER_TOKEN)"
+		}
+		return "export DOPPLER_TOKEN=<service or personal token>"
+	case vaultmux.BackendAWSSecretsManager:
+		profile := currentAWSProfile()
+		if p, err := findAWSProfile(profile); err == nil && p.Kind == "sso" {
+			return "blackdot tools aws login " + profile
+		}
+		return "aws configure --profile " + profile + " (or set vault.aws.profile)"
+	}
+	return ""
+}
+
+// isCloudBackend reports whether backendType is a cloud secret manager
+func isCloudBackend(backendType vaultmux.BackendType) bool {
+	return backendType == doppler.BackendType || backendType == vaultmux.BackendAWSSecretsManager
+}
+
+// checkCloudVaultStatus is the doctor section for cloud secret managers
+func checkCloudVaultStatus(state *doctorState, backendType vaultmux.BackendType) {
+	label := map[vaultmux.BackendType]string{doppler.BackendType: "Doppler", vaultmux.BackendAWSSecretsManager: "AWS Secrets Manager"}[backendType]
+	state.section("Vault Status (" + label + ")")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	backend, err := newVaultBackend()
+	if err != nil {
+		state.fail(err.Error(), "")
+		return
+	}
+	defer backend.Close()
+	if err := backend.Init(ctx); err != nil {
+		state.fail(vaultErrorSummary(err), cloudBackendHint(backendType))
+		return
+	}
+	if !backend.IsAuthenticated(ctx) {
+		state.fail("Not authenticated to "+label, cloudBackendHint(backendType))
+		return
+	}
+	opts := vaultBackendOptions(backendType)
+	switch backendType {
+	case doppler.BackendType:
+		target := "token's project and config"
+		if opts["project"] != "" {
+			target = opts["project"] + "/" + opts["config"]
+		}
+		state.pass("Authenticated, using " + target + " (prefix " + opts["prefix"] + ")")
+	default:
+		state.pass("Authenticated in " + opts["region"] + " (prefix " + opts["prefix"] + ")")
+	}
+}
+
+// vaultErrorSummary is the first line of a backend error
+func vaultErrorSummary(err error) string {
+	msg, _, _ := strings.Cut(err.Error(), "\n")
+	return msg
+}
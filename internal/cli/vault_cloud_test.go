@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/vault/doppler"
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// TestDopplerOptions verifies config wins over DOPPLER_* variables, which
+// win over the Doppler CLI, and the token never comes from config
+func TestDopplerOptions(t *testing.T) {
+	setScheduleEnv(t, `{"vault":{"doppler":{"project":"dotfiles","token":"from-config","prefix":"DEV_"}}}`)
+	t.Setenv("DOPPLER_TOKEN", "")
+	t.Setenv("DOPPLER_PROJECT", "env-project")
+	t.Setenv("DOPPLER_CONFIG", "")
+	orig := dopplerCLIValue
+	dopplerCLIValue = func(key string) string { return "cli-" + key }
+	defer func() { dopplerCLIValue = orig }()
+
+	opts := vaultBackendOptions(doppler.BackendType)
+	want := map[string]string{"token": "cli-token", "project": "dotfiles", "config": "cli-config", "prefix": "DEV_"}
+	for key, val := range want {
+		if opts[key] != val {
+			t.Errorf("%s = %q, want %q", key, opts[key], val)
+		}
+	}
+
+	t.Setenv("DOPPLER_TOKEN", "dp.st.dev")
+	if got := vaultBackendOptions(doppler.BackendType)["token"]; got != "dp.st.dev" {
+		t.Errorf("token = %q, want DOPPLER_TOKEN", got)
+	}
+}
+
+// TestAWSSecretsOptions verifies the configured profile is applied and the
+// region falls back to the profile's
+func TestAWSSecretsOptions(t *testing.T) {
+	setScheduleEnv(t, `{"vault":{"aws":{"profile":"work-sso"}}}`)
+	home, _ := os.UserHomeDir()
+	os.MkdirAll(filepath.Join(home, ".aws"), 0700)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte("[profile work-sso]\nsso_session = corp\nregion = eu-west-1\n"), 0600)
+	t.Setenv("AWS_CONFIG_FILE", "")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+
+	opts := vaultBackendOptions(vaultmux.BackendAWSSecretsManager)
+	if os.Getenv("AWS_PROFILE") != "work-sso" {
+		t.Errorf("AWS_PROFILE = %q", os.Getenv("AWS_PROFILE"))
+	}
+	if opts["region"] != "eu-west-1" || opts["prefix"] != awsSecretsPrefix {
+		t.Errorf("options = %v", opts)
+	}
+	if hint := cloudBackendHint(vaultmux.BackendAWSSecretsManager); hint != "blackdot tools aws login work-sso" {
+		t.Errorf("hint = %q", hint)
+	}
+
+	t.Setenv("BLACKDOT_VAULT_AWS_REGION", "us-west-2")
+	if got := awsSecretsRegion(); got != "us-west-2" {
+		t.Errorf("region = %q", got)
+	}
+}
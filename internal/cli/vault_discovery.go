@@ -0,0 +1,291 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/scanners"
+)
+
+// Discovery rules tell 'vault scan' where secrets live. The built-in
+// rules cover ~/.ssh, ~/.aws, kubeconfig and common dotfiles; more come
+// from vault-discovery.json in the config directory:
+//
+//	{
+//	  "exclude": ["*.bak"],
+//	  "rules": [
+//	    {"paths": ["~/.config/gh/hosts.yml"], "name": "GitHub-CLI"},
+//	    {"paths": ["~/.acme/*.key"], "contains": "BEGIN.*PRIVATE KEY", "name": "Acme-{Stem}"}
+//	  ]
+//	}
+//
+// Rules from the file are tried before the built-in ones, and a file is
+// claimed by the first rule that matches it.
+
+// discoveryRules is the parsed vault-discovery.json
+type discoveryRules struct {
+	// Defaults includes the built-in rules after these (default true)
+	Defaults *bool `json:"defaults,omitempty"`
+	// Exclude applies to every rule, built-in ones included
+	Exclude []string        `json:"exclude,omitempty"`
+	Rules   []discoveryRule `json:"rules"`
+}
+
+// discoveryRule matches files to turn into vault items
+type discoveryRule struct {
+	// Description is printed above the rule's findings
+	Description string `json:"description,omitempty"`
+	// Paths are globs; ~/ is the home directory
+	Paths []string `json:"paths"`
+	// Exclude are globs matched against the file name or the full path
+	Exclude []string `json:"exclude,omitempty"`
+	// Contains is a regexp the content must match
+	Contains string `json:"contains,omitempty"`
+	// Binary only matches files that look binary
+	Binary bool `json:"binary,omitempty"`
+	// Name is the item name, with placeholders filled from the file
+	Name            string `json:"name"`
+	Type            string `json:"type,omitempty"`
+	Required        bool   `json:"required,omitempty"`
+	RequiresFeature string `json:"requires_feature,omitempty"`
+
+	contains *regexp.Regexp
+}
+
+// discoveredItem is a local file 'vault scan' proposes as a vault item
+type discoveredItem struct {
+	Name            string
+	Path            string
+	Type            string
+	Required        bool
+	RequiresFeature string
+}
+
+// discoveryItemTypes are the item types a rule may produce
+var discoveryItemTypes = map[string]bool{"file": true, "sshkey": true, "kubeconfig": true, "binary": true}
+
+// discoveryPlaceholder matches {name} placeholders in item name templates
+var discoveryPlaceholder = regexp.MustCompile(`\{([A-Za-z]+|[1-9])\}`)
+
+// discoveryItemName is the form vault-items.json requires of item names
+var discoveryItemName = regexp.MustCompile(`^[A-Z][A-Za-z0-9_-]*$`)
+
+// vaultDiscoveryPath is the user's discovery rules file
+func vaultDiscoveryPath() string {
+	return filepath.Join(ConfigDir(), "vault-discovery.json")
+}
+
+// defaultDiscoveryRules are the locations 'vault scan' always checks
+func defaultDiscoveryRules() []discoveryRule {
+	home, _ := os.UserHomeDir()
+	const other = "Checking for other secrets..."
+	rules := []discoveryRule{
+		{
+			Description: "Scanning ~/.ssh/ for SSH keys...",
+			Paths:       []string{"~/.ssh/*"},
+			Exclude:     []string{"known_hosts", "config", "authorized_keys", "*.pub"},
+			Contains:    `\A-----BEGIN.*PRIVATE KEY`,
+			Name:        "{sshkey}",
+			Type:        "sshkey",
+			Required:    true,
+		},
+		{Description: "Checking for AWS configs...", Paths: []string{"~/.aws/credentials"}, Name: "AWS-Credentials", Required: true},
+		{Description: "Checking for AWS configs...", Paths: []string{"~/.aws/config"}, Name: "AWS-Config", Required: true},
+		{Description: "Checking for kubeconfig...", Paths: []string{"~/.kube/config"}, Name: "Kube-Config", Type: "kubeconfig"},
+		{Description: "Checking for Git config...", Paths: []string{"~/.gitconfig"}, Name: "Git-Config", Required: true},
+		{Description: "Checking for SSH config...", Paths: []string{"~/.ssh/config"}, Name: "SSH-Config", Required: true},
+		{Description: other, Paths: []string{"~/.claude/profiles.json"}, Name: "Claude-Profiles", RequiresFeature: "claude_integration"},
+		{Description: other, Paths: []string{"~/.npmrc"}, Name: "NPM-Config"},
+		{Description: other, Paths: []string{"~/.pypirc"}, Name: "PyPI-Config"},
+		{Description: other, Paths: []string{"~/.docker/config.json"}, Name: "Docker-Config"},
+		{Description: other, Paths: []string{"~/.local/env.secrets"}, Name: "Environment-Secrets"},
+		{Description: other, Paths: []string{tildePath(filepath.Join(ConfigDir(), "template-variables.sh"))}, Name: "Template-Variables"},
+		{Description: other, Paths: []string{tildePath(sshHostsPath())}, Name: "SSH-Hosts"},
+	}
+	// Keystores, certificates and wireguard configs
+	for _, dir := range binarySecretDirs(home) {
+		rules = append(rules, discoveryRule{
+			Description: "Checking for binary secrets...",
+			Paths:       []string{tildePath(filepath.Join(dir, "*"))},
+			Binary:      filepath.Base(dir) != "wireguard",
+			Name:        "Binary-{Stem}",
+			Type:        "binary",
+		})
+	}
+	return rules
+}
+
+// loadDiscoveryRules returns the rules from path followed by the built-in
+// ones. A missing file means the built-in rules alone.
+func loadDiscoveryRules(path string) ([]discoveryRule, error) {
+	var file discoveryRules
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("%s: %w", tildePath(path), err)
+		}
+	}
+
+	rules := file.Rules
+	for i := range rules {
+		if rules[i].Description == "" {
+			rules[i].Description = fmt.Sprintf("Checking %s...", strings.Join(rules[i].Paths, ", "))
+		}
+	}
+	if file.Defaults == nil || *file.Defaults {
+		rules = append(rules, defaultDiscoveryRules()...)
+	}
+	for i := range rules {
+		rules[i].Exclude = append(rules[i].Exclude, file.Exclude...)
+		if err := rules[i].compile(); err != nil {
+			if i < len(file.Rules) {
+				return nil, fmt.Errorf("%s: rule %d: %w", tildePath(path), i+1, err)
+			}
+			return nil, fmt.Errorf("%s: exclude: %w", tildePath(path), err)
+		}
+	}
+	return rules, nil
+}
+
+// compile checks a rule and prepares its content matcher
+func (r *discoveryRule) compile() error {
+	if len(r.Paths) == 0 {
+		return fmt.Errorf("no paths")
+	}
+	if r.Name == "" {
+		return fmt.Errorf("no name")
+	}
+	if r.Type == "" {
+		r.Type = "file"
+	}
+	if !discoveryItemTypes[r.Type] {
+		return fmt.Errorf("unknown type %q (want file, sshkey, kubeconfig or binary)", r.Type)
+	}
+	for _, pattern := range append(append([]string{}, r.Paths...), r.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad pattern %q", pattern)
+		}
+	}
+	groups := 0
+	if r.Contains != "" {
+		re, err := regexp.Compile(r.Contains)
+		if err != nil {
+			return fmt.Errorf("contains: %w", err)
+		}
+		r.contains = re
+		groups = re.NumSubexp()
+	}
+	for _, m := range discoveryPlaceholder.FindAllStringSubmatch(r.Name, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			if n > groups {
+				return fmt.Errorf("name %s: contains has no group %d", r.Name, n)
+			}
+		} else if _, ok := discoveryNameParts("x", "")[m[1]]; !ok {
+			return fmt.Errorf("name %s: unknown placeholder {%s}", r.Name, m[1])
+		}
+	}
+	return nil
+}
+
+// discover returns the items the rule finds, skipping paths already in
+// claimed and adding its own
+func (r *discoveryRule) discover(claimed map[string]bool) []discoveredItem {
+	var items []discoveredItem
+	for _, pattern := range r.Paths {
+		matches, _ := filepath.Glob(expandPath(pattern))
+		for _, path := range matches {
+			if claimed[path] {
+				continue
+			}
+			if item, ok := r.match(path); ok {
+				claimed[path] = true
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// match tests one file against the rule
+func (r *discoveryRule) match(path string) (discoveredItem, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return discoveredItem{}, false
+	}
+	base := filepath.Base(path)
+	for _, pattern := range r.Exclude {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return discoveredItem{}, false
+		}
+		if ok, _ := filepath.Match(expandPath(pattern), path); ok {
+			return discoveredItem{}, false
+		}
+	}
+	if r.Binary && !isBinarySecretFile(path) {
+		return discoveredItem{}, false
+	}
+	var groups []string
+	if r.contains != nil {
+		f, err := os.Open(path)
+		if err != nil {
+			return discoveredItem{}, false
+		}
+		data, _ := io.ReadAll(io.LimitReader(f, scanners.MaxFileSize))
+		f.Close()
+		m := r.contains.FindSubmatch(data)
+		if m == nil {
+			return discoveredItem{}, false
+		}
+		for _, g := range m {
+			groups = append(groups, string(g))
+		}
+	}
+
+	parts := discoveryNameParts(base, filepath.Base(filepath.Dir(path)))
+	name := discoveryPlaceholder.ReplaceAllStringFunc(r.Name, func(p string) string {
+		key := p[1 : len(p)-1]
+		if n, err := strconv.Atoi(key); err == nil {
+			return groups[n]
+		}
+		return parts[key]
+	})
+	return discoveredItem{
+		Name:            name,
+		Path:            tildePath(path),
+		Type:            r.Type,
+		Required:        r.Required,
+		RequiresFeature: r.RequiresFeature,
+	}, true
+}
+
+// discoveryNameParts are the placeholders item names can use
+func discoveryNameParts(file, dir string) map[string]string {
+	stem := strings.TrimSuffix(file, filepath.Ext(file))
+	return map[string]string{
+		"file":   file,
+		"stem":   stem,
+		"Stem":   titleItemName(stem, "File"),
+		"dir":    dir,
+		"Dir":    titleItemName(dir, "Dir"),
+		"sshkey": normalizeSSHKeyName(file),
+	}
+}
+
+// titleItemName turns a file or directory name into an item name part:
+// "my cert" → My-cert, ".aws" → Aws
+func titleItemName(s, fallback string) string {
+	s = strings.Trim(binaryItemNameChars.ReplaceAllString(s, "-"), "-")
+	if s == "" {
+		s = fallback
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
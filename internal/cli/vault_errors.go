@@ -0,0 +1,363 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/spf13/cobra"
+)
+
+// vaultFailure is one failed backend invocation, kept locally so a failure
+// can be diagnosed after the fact. Nothing here leaves the machine unless
+// the user shares a support bundle.
+type vaultFailure struct {
+	Time     string `json:"time"`
+	Backend  string `json:"backend"`
+	Op       string `json:"op"`
+	Item     string `json:"item,omitempty"`
+	Command  string `json:"command,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	Error    string `json:"error"`
+}
+
+// vaultFailuresMax is the number of failures kept in the log
+const vaultFailuresMax = 20
+
+// vaultFailuresMu serializes log rewrites from concurrent fetches
+var vaultFailuresMu sync.Mutex
+
+// vaultStderrMax caps the captured stderr per failure
+const vaultStderrMax = 4096
+
+func vaultFailureLogPath() string {
+	return filepath.Join(paths.StateDir(), "vault-errors.jsonl")
+}
+
+// vaultCommands maps a backend operation to the CLI invocation vaultmux runs
+// for it. %s is the item name. Encoded payloads are never recorded.
+var vaultCommands = map[string]map[string]string{
+	"bitwarden": {
+		"authenticate":  "bw unlock --raw",
+		"sync":          "bw sync",
+		"get":           "bw get item %s",
+		"list":          "bw list items",
+		"create":        "bw create item <encoded>",
+		"encode":        "bw encode",
+		"update":        "bw edit item <id> <encoded>",
+		"delete":        "bw delete item <id>",
+		"list-folders":  "bw list folders",
+		"create-folder": "bw create folder <encoded>",
+	},
+	"1password": {
+		"authenticate":        "op signin --raw",
+		"get":                 "op item get %s --format json",
+		"list":                "op item list --format json",
+		"create":              "op item create --title %s",
+		"update":              "op item edit %s",
+		"delete":              "op item delete %s",
+		"list-vaults":         "op vault list --format json",
+		"create-vault":        "op vault create %s",
+		"list-items-in-vault": "op item list --vault %s --format json",
+	},
+	"pass": {
+		"sync":   "pass git pull",
+		"get":    "pass show %s",
+		"list":   "pass ls",
+		"create": "pass insert -m %s",
+		"update": "pass insert -m -f %s",
+		"delete": "pass rm -f %s",
+	},
+}
+
+// vaultCommandLine reconstructs the command line for a failed operation
+func vaultCommandLine(backend, op, item string) string {
+	tmpl, ok := vaultCommands[backend][op]
+	if !ok {
+		return ""
+	}
+	if strings.Contains(tmpl, "%s") {
+		return fmt.Sprintf(tmpl, item)
+	}
+	return tmpl
+}
+
+var (
+	// Session tokens in env assignments and flags
+	vaultTokenAssign = regexp.MustCompile(`(?i)\b((?:BW_SESSION|OP_SESSION_\w+|BW_CLIENTSECRET|BW_PASSWORD)=)\S+`)
+	vaultTokenFlag   = regexp.MustCompile(`(?i)(--session[= ])\S+`)
+	// Long base64-ish runs are almost always tokens or encoded payloads
+	vaultTokenBlob = regexp.MustCompile(`[A-Za-z0-9+/_=-]{40,}`)
+)
+
+// sanitizeVaultOutput removes session tokens and encoded payloads
+func sanitizeVaultOutput(s string) string {
+	s = vaultTokenAssign.ReplaceAllString(s, "${1}<redacted>")
+	s = vaultTokenFlag.ReplaceAllString(s, "${1}<redacted>")
+	s = vaultTokenBlob.ReplaceAllString(s, "<redacted>")
+	return s
+}
+
+// newVaultFailure extracts what is known about a failed backend call
+func newVaultFailure(backend vaultmux.BackendType, op, item string, err error) vaultFailure {
+	f := vaultFailure{
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Backend: string(backend),
+		Op:      op,
+		Item:    item,
+	}
+
+	var be *vaultmux.BackendError
+	if errors.As(err, &be) {
+		if be.Op != "" {
+			f.Op = be.Op
+		}
+		if be.Item != "" {
+			f.Item = be.Item
+		}
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		f.ExitCode = exitErr.ExitCode()
+		stderr := strings.TrimSpace(string(exitErr.Stderr))
+		if len(stderr) > vaultStderrMax {
+			stderr = stderr[len(stderr)-vaultStderrMax:]
+		}
+		f.Stderr = sanitizeVaultOutput(stderr)
+	}
+
+	f.Command = sanitizeVaultOutput(vaultCommandLine(f.Backend, f.Op, f.Item))
+	f.Error = sanitizeVaultOutput(err.Error())
+	return f
+}
+
+// recordVaultFailure appends a failure to the local log, keeping only the
+// most recent entries. Logging problems never affect the caller.
+func recordVaultFailure(backend vaultmux.BackendType, op, item string, err error) {
+	if err == nil || errors.Is(err, vaultmux.ErrNotFound) || errors.Is(err, context.Canceled) {
+		return
+	}
+	vaultFailuresMu.Lock()
+	defer vaultFailuresMu.Unlock()
+	failures, _ := loadVaultFailures()
+	failures = append(failures, newVaultFailure(backend, op, item, err))
+	if len(failures) > vaultFailuresMax {
+		failures = failures[len(failures)-vaultFailuresMax:]
+	}
+	_ = writeVaultFailures(failures)
+}
+
+func writeVaultFailures(failures []vaultFailure) error {
+	var b strings.Builder
+	for _, f := range failures {
+		line, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	return writeFileWithPolicy(vaultFailureLogPath(), []byte(b.String()), fileClassPrivate)
+}
+
+// loadVaultFailures reads the failure log, oldest first
+func loadVaultFailures() ([]vaultFailure, error) {
+	f, err := os.Open(vaultFailureLogPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var failures []vaultFailure
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var vf vaultFailure
+		if json.Unmarshal(scanner.Bytes(), &vf) == nil {
+			failures = append(failures, vf)
+		}
+	}
+	return failures, scanner.Err()
+}
+
+// recordingBackend wraps a backend and records every failed call
+type recordingBackend struct {
+	vaultmux.Backend
+	backendType vaultmux.BackendType
+}
+
+func (b *recordingBackend) record(op, item string, err error) error {
+	recordVaultFailure(b.backendType, op, item, err)
+	return err
+}
+
+func (b *recordingBackend) Init(ctx context.Context) error {
+	return b.record("init", "", b.Backend.Init(ctx))
+}
+
+func (b *recordingBackend) Authenticate(ctx context.Context) (vaultmux.Session, error) {
+	session, err := b.Backend.Authenticate(ctx)
+	return session, b.record("authenticate", "", err)
+}
+
+func (b *recordingBackend) Sync(ctx context.Context, session vaultmux.Session) error {
+	return b.record("sync", "", b.Backend.Sync(ctx, session))
+}
+
+func (b *recordingBackend) GetItem(ctx context.Context, name string, session vaultmux.Session) (*vaultmux.Item, error) {
+	item, err := b.Backend.GetItem(ctx, name, session)
+	return item, b.record("get", name, err)
+}
+
+func (b *recordingBackend) GetNotes(ctx context.Context, name string, session vaultmux.Session) (string, error) {
+	notes, err := b.Backend.GetNotes(ctx, name, session)
+	return notes, b.record("get", name, err)
+}
+
+func (b *recordingBackend) ItemExists(ctx context.Context, name string, session vaultmux.Session) (bool, error) {
+	exists, err := b.Backend.ItemExists(ctx, name, session)
+	return exists, b.record("get", name, err)
+}
+
+func (b *recordingBackend) ListItems(ctx context.Context, session vaultmux.Session) ([]*vaultmux.Item, error) {
+	items, err := b.Backend.ListItems(ctx, session)
+	return items, b.record("list", "", err)
+}
+
+func (b *recordingBackend) CreateItem(ctx context.Context, name, content string, session vaultmux.Session) error {
+	return b.record("create", name, b.Backend.CreateItem(ctx, name, content, session))
+}
+
+func (b *recordingBackend) UpdateItem(ctx context.Context, name, content string, session vaultmux.Session) error {
+	return b.record("update", name, b.Backend.UpdateItem(ctx, name, content, session))
+}
+
+func (b *recordingBackend) DeleteItem(ctx context.Context, name string, session vaultmux.Session) error {
+	return b.record("delete", name, b.Backend.DeleteItem(ctx, name, session))
+}
+
+func (b *recordingBackend) ListLocations(ctx context.Context, session vaultmux.Session) ([]string, error) {
+	locations, err := b.Backend.ListLocations(ctx, session)
+	return locations, b.record("list-locations", "", err)
+}
+
+func (b *recordingBackend) CreateLocation(ctx context.Context, name string, session vaultmux.Session) error {
+	return b.record("create-location", name, b.Backend.CreateLocation(ctx, name, session))
+}
+
+func (b *recordingBackend) ListItemsInLocation(ctx context.Context, locType, locValue string, session vaultmux.Session) ([]*vaultmux.Item, error) {
+	items, err := b.Backend.ListItemsInLocation(ctx, locType, locValue, session)
+	return items, b.record("list-items-in-location", locValue, err)
+}
+
+func newVaultLastErrorCmd() *cobra.Command {
+	var jsonOut, all, clear bool
+
+	cmd := &cobra.Command{
+		Use:   "last-error",
+		Short: "Show the last failed vault CLI invocation",
+		Long: `Show details of the most recent failed bw/op/pass invocation: the
+operation, the command line (with session tokens removed), the exit code and
+the CLI's stderr.
+
+The last 20 failures are kept in the state directory (vault-errors.jsonl)
+and included in 'blackdot support bundle'.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if clear {
+				if err := os.Remove(vaultFailureLogPath()); err != nil && !os.IsNotExist(err) {
+					Fail("Failed to clear failure log: %v", err)
+					return err
+				}
+				Pass("Vault failure log cleared")
+				return nil
+			}
+			return runVaultLastError(jsonOut, all)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON")
+	cmd.Flags().BoolVar(&all, "all", false, "Show every recorded failure, oldest first")
+	cmd.Flags().BoolVar(&clear, "clear", false, "Delete the failure log")
+	return cmd
+}
+
+func runVaultLastError(jsonOut, all bool) error {
+	failures, err := loadVaultFailures()
+	if err != nil {
+		Fail("Failed to read failure log: %v", err)
+		return err
+	}
+	if !all && len(failures) > 0 {
+		failures = failures[len(failures)-1:]
+	}
+
+	if jsonOut {
+		var data []byte
+		if all {
+			if failures == nil {
+				failures = []vaultFailure{}
+			}
+			data, _ = json.MarshalIndent(failures, "", "  ")
+		} else if len(failures) == 1 {
+			data, _ = json.MarshalIndent(failures[0], "", "  ")
+		} else {
+			data = []byte("null")
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(failures) == 0 {
+		Pass("No vault CLI failures recorded")
+		return nil
+	}
+
+	PrintHeader("Vault CLI Failures")
+	for i, f := range failures {
+		if i > 0 {
+			fmt.Println()
+		}
+		printVaultFailure(f)
+	}
+	return nil
+}
+
+func printVaultFailure(f vaultFailure) {
+	when := f.Time
+	if t, err := time.Parse(time.RFC3339, f.Time); err == nil {
+		when = t.Local().Format("2006-01-02 15:04:05")
+	}
+	fmt.Printf("  %s %s %s\n", Red.Sprint("✗"), Cyan.Sprint(f.Backend), Dim.Sprint(when))
+	fmt.Printf("    Operation: %s\n", f.Op)
+	if f.Item != "" {
+		fmt.Printf("    Item:      %s\n", f.Item)
+	}
+	if f.Command != "" {
+		fmt.Printf("    Command:   %s\n", f.Command)
+	}
+	if f.ExitCode != 0 {
+		fmt.Printf("    Exit code: %d\n", f.ExitCode)
+	}
+	fmt.Printf("    Error:     %s\n", f.Error)
+	if f.Stderr != "" {
+		fmt.Println("    Stderr:")
+		for _, line := range strings.Split(f.Stderr, "\n") {
+			fmt.Printf("      %s\n", Dim.Sprint(line))
+		}
+	}
+}
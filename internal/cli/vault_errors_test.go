@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/blackwell-systems/vaultmux/mock"
+)
+
+// TestRecordingBackendCapturesFailure verifies a failed CLI call is logged
+// with its exit code, stderr and command line, with tokens redacted
+func TestRecordingBackendCapturesFailure(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	token := strings.Repeat("aB3", 20)
+	_, cliErr := exec.Command("sh", "-c", "echo 'mac failed, BW_SESSION="+token+"' >&2; exit 3").Output()
+	if cliErr == nil {
+		t.Skip("sh not available")
+	}
+
+	m := mock.New()
+	m.GetError = vaultmux.WrapError("bitwarden", "get", "SSH-Config", cliErr)
+	backend := &recordingBackend{Backend: m, backendType: "bitwarden"}
+	session, _ := backend.Authenticate(context.Background())
+
+	if _, err := backend.GetNotes(context.Background(), "SSH-Config", session); !errors.Is(err, cliErr) {
+		t.Fatalf("error should pass through unchanged, got %v", err)
+	}
+
+	failures, err := loadVaultFailures()
+	if err != nil || len(failures) != 1 {
+		t.Fatalf("failures = %+v, %v", failures, err)
+	}
+	f := failures[0]
+	if f.Backend != "bitwarden" || f.Op != "get" || f.Item != "SSH-Config" || f.ExitCode != 3 {
+		t.Errorf("failure = %+v", f)
+	}
+	if f.Command != "bw get item SSH-Config" {
+		t.Errorf("command = %q", f.Command)
+	}
+	if !strings.Contains(f.Stderr, "mac failed") || strings.Contains(f.Stderr, token) {
+		t.Errorf("stderr not captured or not redacted: %q", f.Stderr)
+	}
+}
+
+// TestRecordVaultFailureSkipsNotFoundAndTrims verifies missing items are not
+// failures and the log keeps only the most recent entries
+func TestRecordVaultFailureSkipsNotFoundAndTrims(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	recordVaultFailure("pass", "get", "x", vaultmux.ErrNotFound)
+	if failures, _ := loadVaultFailures(); len(failures) != 0 {
+		t.Fatalf("not found should not be recorded: %+v", failures)
+	}
+
+	for i := 0; i < vaultFailuresMax+5; i++ {
+		recordVaultFailure("pass", "sync", "", errors.New("boom"))
+	}
+	recordVaultFailure("1password", "list", "", errors.New("last"))
+
+	failures, _ := loadVaultFailures()
+	if len(failures) != vaultFailuresMax {
+		t.Fatalf("kept %d failures, want %d", len(failures), vaultFailuresMax)
+	}
+	if last := failures[len(failures)-1]; last.Backend != "1password" || last.Command != "op item list --format json" {
+		t.Errorf("last failure = %+v", last)
+	}
+}
+
+// TestSanitizeVaultOutput verifies session flags and tokens are redacted
+func TestSanitizeVaultOutput(t *testing.T) {
+	in := "bw sync --session abc123 OP_SESSION_my=xyz"
+	out := sanitizeVaultOutput(in)
+	if strings.Contains(out, "abc123") || strings.Contains(out, "xyz") {
+		t.Errorf("sanitizeVaultOutput(%q) = %q", in, out)
+	}
+}
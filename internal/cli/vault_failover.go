@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/blackwell-systems/blackdot/internal/config"
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// vaultFallbackBackends returns the backends reads fall back to, in order:
+// BLACKDOT_VAULT_FALLBACK (comma-separated) or vault.fallback in config.
+func vaultFallbackBackends() []vaultmux.BackendType {
+	value := os.Getenv("BLACKDOT_VAULT_FALLBACK")
+	if value == "" {
+		value, _ = config.DefaultManager().Get("vault.fallback")
+	}
+
+	var backends []vaultmux.BackendType
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			backends = append(backends, vaultmux.BackendType(name))
+		}
+	}
+	return backends
+}
+
+// vaultBackendOrder returns the primary backend followed by the fallbacks,
+// without duplicates
+func vaultBackendOrder() []vaultmux.BackendType {
+	order := []vaultmux.BackendType{getVaultBackend()}
+	seen := map[vaultmux.BackendType]bool{order[0]: true}
+	for _, b := range vaultFallbackBackends() {
+		if !seen[b] {
+			seen[b] = true
+			order = append(order, b)
+		}
+	}
+	return order
+}
+
+// connectVaultBackend creates, initializes and authenticates one backend
+func connectVaultBackend(ctx context.Context, backendType vaultmux.BackendType) (vaultmux.Backend, vaultmux.Session, error) {
+	backend, err := newVaultBackendFor(backendType)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := backend.Init(ctx); err != nil {
+		backend.Close()
+		return nil, nil, fmt.Errorf("not available: %w", err)
+	}
+	session, err := backend.Authenticate(ctx)
+	if err != nil {
+		backend.Close()
+		return nil, nil, fmt.Errorf("authentication failed: %w", err)
+	}
+	return backend, session, nil
+}
+
+// vaultReaderMember is one backend in a vaultReader
+type vaultReaderMember struct {
+	name      vaultmux.BackendType
+	backend   vaultmux.Backend
+	session   vaultmux.Session
+	connected bool
+	err       error // set once the backend is known to be unavailable
+}
+
+// vaultReader reads items from the first available backend in the
+// configured order. Backends after the primary are only connected when
+// needed, and a backend that fails with anything other than "not found" is
+// skipped for the rest of the run. Writes never fall back.
+type vaultReader struct {
+	mu      sync.Mutex
+	members []*vaultReaderMember
+	connect func(context.Context, vaultmux.BackendType) (vaultmux.Backend, vaultmux.Session, error)
+}
+
+// newVaultReader returns a reader over order using connect to open each backend
+func newVaultReader(order []vaultmux.BackendType, connect func(context.Context, vaultmux.BackendType) (vaultmux.Backend, vaultmux.Session, error)) *vaultReader {
+	r := &vaultReader{connect: connect}
+	for _, name := range order {
+		r.members = append(r.members, &vaultReaderMember{name: name})
+	}
+	return r
+}
+
+// openVaultReader connects to the first available configured backend
+func openVaultReader(ctx context.Context) (*vaultReader, error) {
+	r := newVaultReader(vaultBackendOrder(), connectVaultBackend)
+	if _, err := r.first(ctx); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// member returns the i-th backend, connecting it on first use
+func (r *vaultReader) member(ctx context.Context, i int) (*vaultReaderMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := r.members[i]
+	if m.err != nil {
+		return nil, m.err
+	}
+	if !m.connected {
+		backend, session, err := r.connect(ctx, m.name)
+		if err != nil {
+			m.err = err
+			return nil, err
+		}
+		m.backend, m.session, m.connected = backend, session, true
+	}
+	return m, nil
+}
+
+// markDown records that a connected backend stopped working
+func (r *vaultReader) markDown(m *vaultReaderMember, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+// first returns the first backend that connects
+func (r *vaultReader) first(ctx context.Context) (*vaultReaderMember, error) {
+	var errs []string
+	for i := range r.members {
+		m, err := r.member(ctx, i)
+		if err == nil {
+			return m, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", r.members[i].name, err))
+	}
+	return nil, fmt.Errorf("no vault backend available (%s)", strings.Join(errs, "; "))
+}
+
+// Primary is the backend writes go to
+func (r *vaultReader) Primary() vaultmux.BackendType {
+	return r.members[0].name
+}
+
+// Fallbacks are the backends after the primary
+func (r *vaultReader) Fallbacks() []vaultmux.BackendType {
+	var names []vaultmux.BackendType
+	for _, m := range r.members[1:] {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// Unavailable returns the backends that could not be used and why
+func (r *vaultReader) Unavailable() map[vaultmux.BackendType]error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	down := map[vaultmux.BackendType]error{}
+	for _, m := range r.members {
+		if m.err != nil {
+			down[m.name] = m.err
+		}
+	}
+	return down
+}
+
+// Writer returns the primary backend for writes. It never falls back.
+func (r *vaultReader) Writer(ctx context.Context) (vaultmux.Backend, vaultmux.Session, error) {
+	m, err := r.member(ctx, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("primary backend %s unavailable: %w", r.Primary(), err)
+	}
+	return m.backend, m.session, nil
+}
+
+// Sync syncs the first available backend
+func (r *vaultReader) Sync(ctx context.Context) error {
+	m, err := r.first(ctx)
+	if err != nil {
+		return err
+	}
+	return m.backend.Sync(ctx, m.session)
+}
+
+// read runs fn against each backend in order until one answers. A "not
+// found" answer is authoritative and does not fall back.
+func (r *vaultReader) read(ctx context.Context, fn func(vaultmux.Backend, vaultmux.Session) error) (vaultmux.BackendType, error) {
+	var lastErr error
+	for i := range r.members {
+		m, err := r.member(ctx, i)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		err = fn(m.backend, m.session)
+		if err == nil || errors.Is(err, vaultmux.ErrNotFound) {
+			return m.name, err
+		}
+		if ctx.Err() != nil {
+			return m.name, err
+		}
+		r.markDown(m, err)
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// GetNotes returns an item's notes and the backend that served them
+func (r *vaultReader) GetNotes(ctx context.Context, name string) (string, vaultmux.BackendType, error) {
+	var notes string
+	from, err := r.read(ctx, func(b vaultmux.Backend, s vaultmux.Session) error {
+		var err error
+		notes, err = b.GetNotes(ctx, name, s)
+		return err
+	})
+	return notes, from, err
+}
+
+// GetItem returns an item and the backend that served it
+func (r *vaultReader) GetItem(ctx context.Context, name string) (*vaultmux.Item, vaultmux.BackendType, error) {
+	var item *vaultmux.Item
+	from, err := r.read(ctx, func(b vaultmux.Backend, s vaultmux.Session) error {
+		var err error
+		item, err = b.GetItem(ctx, name, s)
+		return err
+	})
+	return item, from, err
+}
+
+// backendFor returns a connected backend by name, for follow-up calls
+// against the backend that answered a read
+func (r *vaultReader) backendFor(name vaultmux.BackendType) (vaultmux.Backend, vaultmux.Session, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.members {
+		if m.name == name && m.connected {
+			return m.backend, m.session, true
+		}
+	}
+	return nil, nil, false
+}
+
+// Close closes every connected backend
+func (r *vaultReader) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.members {
+		if m.connected {
+			m.backend.Close()
+		}
+	}
+}
+
+// reportFallback warns about each backend that was skipped
+func (r *vaultReader) reportFallback() {
+	down := r.Unavailable()
+	for _, m := range r.members {
+		if err, ok := down[m.name]; ok {
+			Warn("%s unavailable: %v", m.name, err)
+		}
+	}
+}
+
+// servedBy labels an item that did not come from the primary, e.g.
+// "from pass"
+func (r *vaultReader) servedBy(from vaultmux.BackendType) string {
+	if from == "" || from == r.Primary() {
+		return ""
+	}
+	return "from " + string(from)
+}
+
+// joinBackendTypes formats backend names for display
+func joinBackendTypes(types []vaultmux.BackendType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
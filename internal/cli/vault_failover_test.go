@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/blackwell-systems/vaultmux/mock"
+)
+
+// mockVaultReader builds a reader over named mock backends. A missing
+// entry behaves like a backend whose CLI is not installed.
+func mockVaultReader(order []vaultmux.BackendType, backends map[vaultmux.BackendType]*mock.Backend) *vaultReader {
+	return newVaultReader(order, func(ctx context.Context, name vaultmux.BackendType) (vaultmux.Backend, vaultmux.Session, error) {
+		b, ok := backends[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s CLI not found", name)
+		}
+		session, err := b.Authenticate(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return b, session, nil
+	})
+}
+
+// TestVaultReaderFallsBackWhenPrimaryMissing verifies reads use the next
+// backend when the primary cannot connect, and writes do not
+func TestVaultReaderFallsBackWhenPrimaryMissing(t *testing.T) {
+	pass := mock.New()
+	pass.SetItem("Git-Config", "from pass")
+	reader := mockVaultReader([]vaultmux.BackendType{"1password", "pass"}, map[vaultmux.BackendType]*mock.Backend{"pass": pass})
+
+	notes, from, err := reader.GetNotes(context.Background(), "Git-Config")
+	if err != nil || notes != "from pass" || from != "pass" {
+		t.Fatalf("GetNotes = %q, %q, %v", notes, from, err)
+	}
+	if reader.servedBy(from) != "from pass" {
+		t.Errorf("servedBy = %q", reader.servedBy(from))
+	}
+	if _, ok := reader.Unavailable()["1password"]; !ok {
+		t.Error("1password should be reported unavailable")
+	}
+	if _, _, err := reader.Writer(context.Background()); err == nil {
+		t.Error("Writer should not fall back to pass")
+	}
+}
+
+// TestVaultReaderFallsBackWhenServiceDown verifies a connected primary that
+// starts failing is skipped, while "not found" stays authoritative
+func TestVaultReaderFallsBackWhenServiceDown(t *testing.T) {
+	primary := mock.New()
+	primary.SetItem("Only-Primary", "primary")
+	fallback := mock.New()
+	fallback.SetItem("Only-Fallback", "fallback")
+	reader := mockVaultReader([]vaultmux.BackendType{"bitwarden", "pass"}, map[vaultmux.BackendType]*mock.Backend{"bitwarden": primary, "pass": fallback})
+	ctx := context.Background()
+
+	if _, from, err := reader.GetNotes(ctx, "Only-Fallback"); !errors.Is(err, vaultmux.ErrNotFound) || from != "bitwarden" {
+		t.Fatalf("not found on primary should not fall back: from=%q err=%v", from, err)
+	}
+	if notes, from, _ := reader.GetNotes(ctx, "Only-Primary"); notes != "primary" || reader.servedBy(from) != "" {
+		t.Errorf("primary read = %q from %q", notes, from)
+	}
+
+	primary.GetError = errors.New("service unavailable")
+	notes, from, err := reader.GetNotes(ctx, "Only-Fallback")
+	if err != nil || notes != "fallback" || from != "pass" {
+		t.Fatalf("GetNotes = %q, %q, %v", notes, from, err)
+	}
+
+	// The primary stays skipped for the rest of the run
+	primary.GetError = nil
+	if _, from, _ := reader.GetNotes(ctx, "Only-Primary"); from != "pass" {
+		t.Errorf("expected primary to stay down, served by %q", from)
+	}
+}
+
+// TestVaultBackendOrder verifies fallbacks follow the primary without duplicates
+func TestVaultBackendOrder(t *testing.T) {
+	t.Setenv("BLACKDOT_VAULT_BACKEND", "1password")
+	t.Setenv("BLACKDOT_VAULT_FALLBACK", "pass, 1password,bitwarden")
+
+	got := joinBackendTypes(vaultBackendOrder())
+	if got != "1password, pass, bitwarden" {
+		t.Errorf("order = %q", got)
+	}
+}
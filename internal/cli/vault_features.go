@@ -0,0 +1,32 @@
+package cli
+
+import "fmt"
+
+// vaultItemFeatureOff returns the feature an item requires when that
+// feature is disabled on this machine, or "" when the item applies
+func vaultItemFeatureOff(item VaultItem) string {
+	if item.RequiresFeature == "" || initRegistry().Enabled(item.RequiresFeature) {
+		return ""
+	}
+	return item.RequiresFeature
+}
+
+// skipVaultItemsForFeatures drops the items whose feature is off, keeping
+// the order of names, and returns the feature each dropped item needs
+func skipVaultItemsForFeatures(names []string, items map[string]VaultItem) ([]string, map[string]string) {
+	kept := names[:0:0]
+	skipped := make(map[string]string)
+	for _, name := range names {
+		if f := vaultItemFeatureOff(items[name]); f != "" {
+			skipped[name] = f
+			continue
+		}
+		kept = append(kept, name)
+	}
+	return kept, skipped
+}
+
+// featureDisabledDetail is how an item skipped for its feature is reported
+func featureDisabledDetail(feature string) string {
+	return fmt.Sprintf("skipped (feature %s disabled)", feature)
+}
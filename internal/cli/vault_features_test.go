@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/feature"
+)
+
+// TestSkipVaultItemsForFeatures verifies items are dropped only while the
+// feature they require is disabled, keeping restore order
+func TestSkipVaultItemsForFeatures(t *testing.T) {
+	saved := registry
+	t.Cleanup(func() { registry = saved })
+	registry = feature.NewRegistry()
+	registry.LoadState(map[string]bool{"claude_integration": false, "aws_helpers": true})
+
+	items := map[string]VaultItem{
+		"SSH-Config":      {Path: "~/.ssh/config", Required: true},
+		"Claude-Profiles": {Path: "~/.claude/profiles.json", Required: true, RequiresFeature: "claude_integration"},
+		"AWS-Config":      {Path: "~/.aws/config", Required: true, RequiresFeature: "aws_helpers"},
+	}
+	kept, skipped := skipVaultItemsForFeatures([]string{"SSH-Config", "Claude-Profiles", "AWS-Config"}, items)
+
+	if len(kept) != 2 || kept[0] != "SSH-Config" || kept[1] != "AWS-Config" {
+		t.Errorf("kept = %v", kept)
+	}
+	if len(skipped) != 1 || skipped["Claude-Profiles"] != "claude_integration" {
+		t.Errorf("skipped = %v", skipped)
+	}
+	if got := featureDisabledDetail("claude_integration"); got != "skipped (feature claude_integration disabled)" {
+		t.Errorf("detail = %q", got)
+	}
+
+	registry.LoadState(map[string]bool{"claude_integration": true})
+	if _, skipped := skipVaultItemsForFeatures([]string{"Claude-Profiles"}, items); len(skipped) != 0 {
+		t.Errorf("enabled feature should not skip: %v", skipped)
+	}
+}
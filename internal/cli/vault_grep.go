@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/scanners"
+	"github.com/spf13/cobra"
+)
+
+// vaultGrepOptions select the items 'vault grep' searches and shape its
+// output
+type vaultGrepOptions struct {
+	IgnoreCase  bool
+	FilesOnly   bool
+	ShowSecrets bool
+	JSON        bool
+	Concurrency int
+	List        vaultListOptions // --filter, --type, --location
+}
+
+// vaultGrepMatch is one matching line
+type vaultGrepMatch struct {
+	Item string `json:"item"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// vaultAssignment is a KEY=value or key: value line; the value is masked
+var vaultAssignment = regexp.MustCompile(`^(\s*(?:export\s+)?[A-Za-z0-9_.-]+\s*[=:]\s*)(\S.*)$`)
+
+func newVaultGrepCmd() *cobra.Command {
+	var opts vaultGrepOptions
+
+	cmd := &cobra.Command{
+		Use:   "grep PATTERN [items...]",
+		Short: "Search the contents of vault items",
+		Long: `Search the notes of vault items with a regular expression and print
+each matching line as item:line: text.
+
+Without item names every item in the vault is searched; --filter,
+--type and --location narrow the set the same way as 'vault list'.
+
+Secret values in matching lines are masked: the value of KEY=value and
+key: value lines, private key bodies, and anything the secret scanner
+recognizes. Pass --show-secrets to print lines as stored.
+
+Examples:
+  blackdot vault grep AKIA                       # Which item holds the AWS key?
+  blackdot vault grep -i 'host github' --filter '^SSH-'
+  blackdot vault grep -l token                   # Item names only`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return vaultGrep(args[0], args[1:], opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.IgnoreCase, "ignore-case", "i", false, "Case-insensitive match")
+	cmd.Flags().BoolVarP(&opts.FilesOnly, "files-with-matches", "l", false, "Only print the names of matching items")
+	cmd.Flags().BoolVar(&opts.ShowSecrets, "show-secrets", false, "Print matching lines unmasked")
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "Output matches as JSON")
+	cmd.Flags().IntVarP(&opts.Concurrency, "concurrency", "j", vaultDefaultConcurrency, "Items to fetch in parallel")
+	cmd.Flags().StringArrayVarP(&opts.List.Filters, "filter", "f", nil, "column=value or column~regex (repeatable)")
+	cmd.Flags().StringVarP(&opts.List.Type, "type", "t", "", "only items of this type")
+	cmd.Flags().StringVar(&opts.List.Location, "location", "", "only items in this location/folder")
+	return cmd
+}
+
+func vaultGrep(pattern string, names []string, opts vaultGrepOptions) error {
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		Fail("Invalid pattern: %v", err)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	reader, err := openVaultReader(ctx)
+	if err != nil {
+		failVaultOp("Failed to connect to vault", err)
+		return err
+	}
+	defer reader.Close()
+
+	if len(names) == 0 {
+		names, err = vaultGrepItemNames(ctx, reader, opts.List)
+		if err != nil {
+			failVaultOp("Failed to list items", err)
+			return err
+		}
+	}
+	if len(names) == 0 {
+		Info("No items to search")
+		return nil
+	}
+
+	results := fetchVaultNotes(reader, names, opts.Concurrency, newProgressBar("Searching", len(names)))
+	sc := scanners.New()
+	var matches []vaultGrepMatch
+	failed := 0
+	for _, name := range names {
+		r := results[name]
+		if r.Err != nil {
+			Warn("%s: %v", name, r.Err)
+			failed++
+			continue
+		}
+		matches = append(matches, grepVaultNotes(re, name, r.Notes, opts.ShowSecrets, sc)...)
+	}
+
+	switch {
+	case opts.JSON:
+		if matches == nil {
+			matches = []vaultGrepMatch{}
+		}
+		data, _ := json.MarshalIndent(matches, "", "  ")
+		fmt.Println(string(data))
+	case opts.FilesOnly:
+		last := ""
+		for _, m := range matches {
+			if m.Item != last {
+				fmt.Println(m.Item)
+				last = m.Item
+			}
+		}
+	default:
+		for _, m := range matches {
+			fmt.Printf("%s%s%s%s %s\n", Cyan.Sprint(m.Item), Dim.Sprint(":"), Dim.Sprint(m.Line), Dim.Sprint(":"), m.Text)
+		}
+		if len(matches) == 0 {
+			Info("No matches in %d item(s)", len(names)-failed)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d item(s) could not be read", failed)
+	}
+	return nil
+}
+
+// vaultGrepItemNames lists the vault and returns the names selected by the
+// list filters, in name order
+func vaultGrepItemNames(ctx context.Context, reader *vaultReader, opts vaultListOptions) ([]string, error) {
+	m, err := reader.first(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, err := m.backend.ListItems(ctx, m.session)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Location != "" {
+		opts.Filters = append(opts.Filters, "location="+opts.Location)
+	}
+	items, err = selectVaultListItems(items, opts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(items))
+	seen := map[string]bool{}
+	for _, item := range items {
+		if !seen[item.Name] {
+			seen[item.Name] = true
+			names = append(names, item.Name)
+		}
+	}
+	return names, nil
+}
+
+// grepVaultNotes returns the lines of one item's notes that match re,
+// masked unless show is set
+func grepVaultNotes(re *regexp.Regexp, item, notes string, show bool, sc *scanners.Scanner) []vaultGrepMatch {
+	var matches []vaultGrepMatch
+	inKey := false
+	for i, line := range strings.Split(notes, "\n") {
+		line = strings.TrimRight(line, "\r")
+		keyBody := inKey && !strings.HasPrefix(line, "-----END")
+		switch {
+		case strings.HasPrefix(line, "-----BEGIN") && strings.Contains(line, "PRIVATE KEY"):
+			inKey = true
+		case strings.HasPrefix(line, "-----END"):
+			inKey = false
+		}
+		if !re.MatchString(line) {
+			continue
+		}
+		text := line
+		if !show {
+			text = maskVaultLine(line, keyBody, sc)
+		}
+		matches = append(matches, vaultGrepMatch{Item: item, Line: i + 1, Text: text})
+	}
+	return matches
+}
+
+// maskVaultLine hides the secret in a line of vault notes. Vault contents
+// are secrets by definition, so assignment values are masked even when
+// they do not look like credentials.
+func maskVaultLine(line string, keyBody bool, sc *scanners.Scanner) string {
+	if keyBody {
+		return scanners.Redact(strings.TrimSpace(line))
+	}
+	if strings.HasPrefix(line, "-----") { // PEM armor says what the item holds, not the key
+		return line
+	}
+	if m := vaultAssignment.FindStringSubmatch(line); m != nil {
+		return m[1] + scanners.Redact(m[2])
+	}
+	return sc.Mask(line)
+}
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// vaultHistoryKeep is vault.history_keep: previous versions kept per item,
+// 0 to turn history off. Project files cannot change it.
+func vaultHistoryKeep() int {
+	val, _ := trustedConfigLookup("vault.history_keep")
+	if val == "" {
+		return vault.DefaultHistoryKeep
+	}
+	keep, err := strconv.Atoi(val)
+	if err != nil || keep < 0 {
+		Debug("Ignoring invalid vault.history_keep %q", val)
+		return vault.DefaultHistoryKeep
+	}
+	return keep
+}
+
+func newVaultHistoryCmd() *cobra.Command {
+	var jsonOutput, prune bool
+
+	cmd := &cobra.Command{
+		Use:   "history <item>",
+		Short: "List saved versions of a vault item",
+		Long: `List the previous versions of a vault item.
+
+Before an item is overwritten (by push, create --force, rotate, and the
+other commands that update items), its current content is saved as a
+separate item named <item>.v<N>. The newest vault.history_keep versions
+are kept (default 5); set it to 0 to turn history off. Version items are
+hidden from 'vault list'.
+
+Options:
+  --json     Output as JSON
+  --prune    Delete versions beyond vault.history_keep now
+
+Examples:
+  blackdot vault history Git-Config
+  blackdot vault restore Git-Config --version 3
+  blackdot config set user vault.history_keep 10`,
+		Args: cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeVaultItems(toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if prune {
+				return vaultPruneHistory(args[0])
+			}
+			return vaultHistory(args[0], jsonOutput)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	cmd.Flags().BoolVar(&prune, "prune", false, "Delete versions beyond vault.history_keep")
+
+	return cmd
+}
+
+func vaultHistory(name string, jsonOutput bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	svc, err := newVaultService()
+	if err != nil {
+		Fail("Failed to create backend: %v", err)
+		return err
+	}
+	defer svc.Close()
+
+	versions, err := svc.History(ctx, name)
+	if err != nil {
+		failVaultOp("Failed to list versions", err)
+		return err
+	}
+
+	if jsonOutput {
+		if versions == nil {
+			versions = []vault.Version{}
+		}
+		data, _ := json.MarshalIndent(versions, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	PrintHeader("History: " + name)
+	if len(versions) == 0 {
+		Info("No saved versions")
+		if vaultHistoryKeep() == 0 {
+			Dim.Println("  History is off (vault.history_keep is 0)")
+		}
+		return nil
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		when := ""
+		if !v.Modified.IsZero() {
+			when = v.Modified.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("  %-6s %-16s %s\n", "v"+strconv.Itoa(v.Number), when, Dim.Sprint(v.Item))
+	}
+	fmt.Println()
+	Info("Restore with: blackdot vault restore %s --version N", name)
+	return nil
+}
+
+func vaultPruneHistory(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	svc, err := newVaultService()
+	if err != nil {
+		Fail("Failed to create backend: %v", err)
+		return err
+	}
+	defer svc.Close()
+
+	keep := vaultHistoryKeep()
+	removed, err := svc.PruneHistory(ctx, name, keep)
+	for _, v := range removed {
+		Pass("Deleted %s", v.Item)
+	}
+	if err != nil {
+		failVaultOp("Failed to prune history", err)
+		return err
+	}
+	if len(removed) == 0 {
+		Info("Nothing to prune (keeping %d)", keep)
+	}
+	return nil
+}
+
+// vaultRestoreVersion makes a saved version the current content of an item
+func vaultRestoreVersion(name string, version int, dryRun bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	svc, err := newVaultService()
+	if err != nil {
+		Fail("Failed to create backend: %v", err)
+		return err
+	}
+	defer svc.Close()
+
+	content, err := svc.GetVersion(ctx, name, version)
+	if err != nil {
+		failVaultOp(fmt.Sprintf("Version %d of %s not found", version, name), err)
+		return err
+	}
+
+	if dryRun {
+		session, _ := svc.Connect(ctx)
+		current, _ := svc.Backend().GetNotes(ctx, name, session)
+		PrintHeader(fmt.Sprintf("Restore %s to v%d (dry run)", name, version))
+		if current == content {
+			Pass("Current content already matches v%d", version)
+			return nil
+		}
+		printUnifiedDiff(os.Stdout, name, current, vault.VersionName(name, version), content)
+		return nil
+	}
+
+	if err := svc.RestoreVersion(ctx, name, version); err != nil {
+		Fail("Failed to restore %s: %v", name, err)
+		return err
+	}
+	Pass("Restored %s to v%d", name, version)
+	if vaultHistoryKeep() > 0 {
+		Dim.Println("  The replaced content was saved as the newest version")
+	}
+	Info("Run 'blackdot vault restore' to update the local file")
+	return nil
+}
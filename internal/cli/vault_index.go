@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// vaultIndexMaxAge is how long the cached item index is trusted before a
+// miss triggers a fresh listing from the backend
+const vaultIndexMaxAge = 24 * time.Hour
+
+// vaultItemIndex is a cached list of vault item names, used for completion
+// and did-you-mean suggestions without a round trip to the backend
+type vaultItemIndex struct {
+	Backend   string    `json:"backend"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Names     []string  `json:"names"`
+}
+
+// getVaultIndexPath returns the path to the cached item index
+func getVaultIndexPath() string {
+	return filepath.Join(paths.CacheDir(), "vault-index.json")
+}
+
+// loadVaultIndex returns the cached index for the current backend, or nil
+func loadVaultIndex() *vaultItemIndex {
+	data, err := os.ReadFile(getVaultIndexPath())
+	if err != nil {
+		return nil
+	}
+	var index vaultItemIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil
+	}
+	if index.Backend != string(getVaultBackend()) {
+		return nil
+	}
+	return &index
+}
+
+// saveVaultIndex caches the names of a full item listing
+func saveVaultIndex(items []*vaultmux.Item) error {
+	index := vaultItemIndex{
+		Backend:   string(getVaultBackend()),
+		UpdatedAt: time.Now().UTC(),
+	}
+	for _, item := range items {
+		index.Names = append(index.Names, item.Name)
+	}
+	sort.Strings(index.Names)
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileWithPolicy(getVaultIndexPath(), data, fileClassPrivate)
+}
+
+// stale reports whether the index should be refreshed before trusting a miss
+func (idx *vaultItemIndex) stale() bool {
+	return idx == nil || time.Since(idx.UpdatedAt) > vaultIndexMaxAge
+}
+
+// normalizeItemName folds case and separators so "ssh-github", "SSH_GitHub"
+// and "ssh github" compare equal
+func normalizeItemName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '_', ' ', '.':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// itemNameDistance is the optimal string alignment distance between two
+// names: insertions, deletions, substitutions, and adjacent transpositions
+// ("Githbu" -> "Github") each cost 1
+func itemNameDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// fuzzyMatchItems returns names close to query, best first. Normalized
+// equality ranks first, then substring/prefix matches, then names within an
+// edit distance of roughly a third of the query length.
+func fuzzyMatchItems(query string, names []string) []string {
+	q := normalizeItemName(query)
+	if q == "" {
+		return nil
+	}
+	maxDist := max(1, len([]rune(q))/3)
+
+	type match struct {
+		name  string
+		score int
+	}
+	var matches []match
+	for _, name := range names {
+		n := normalizeItemName(name)
+		switch {
+		case n == q:
+			matches = append(matches, match{name, 0})
+		case strings.Contains(n, q) || (len(n) > 2 && strings.Contains(q, n)):
+			matches = append(matches, match{name, 1})
+		default:
+			if dist := itemNameDistance(q, n); dist <= maxDist {
+				matches = append(matches, match{name, 1 + dist})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.name
+	}
+	return result
+}
+
+// singleCloseMatch picks the match --fuzzy may use: the only match, or the
+// one strictly closer to the query than every other match
+func singleCloseMatch(query string, matches []string) (string, bool) {
+	if len(matches) == 1 {
+		return matches[0], true
+	}
+	q := normalizeItemName(query)
+	best, bestDist, tied := "", -1, false
+	for _, m := range matches {
+		dist := itemNameDistance(q, normalizeItemName(m))
+		switch {
+		case bestDist < 0 || dist < bestDist:
+			best, bestDist, tied = m, dist, false
+		case dist == bestDist:
+			tied = true
+		}
+	}
+	return best, best != "" && !tied
+}
+
+// completeVaultItems completes item names from the cached index only, so
+// tab completion never blocks on the backend or prompts for a password
+func completeVaultItems(toComplete string) []string {
+	index := loadVaultIndex()
+	if index == nil {
+		return nil
+	}
+	prefix := strings.ToLower(toComplete)
+	var prefixed, partial []string
+	for _, name := range index.Names {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, prefix) {
+			prefixed = append(prefixed, name)
+		} else if strings.Contains(lower, prefix) {
+			partial = append(partial, name)
+		}
+	}
+	if len(prefixed) > 0 {
+		return prefixed
+	}
+	return partial
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// vaultLastRunOps are the operations whose last report is kept
+var vaultLastRunOps = []string{"restore", "push"}
+
+func vaultLastRunPath(op string) string {
+	return filepath.Join(paths.StateDir(), "vault-last-"+op+".json")
+}
+
+// saveVaultLastRun keeps the report of a restore or push for 'vault
+// last-run'. Dry runs and runs that did nothing don't replace the last
+// real one. Failing to save never affects the run.
+func saveVaultLastRun(r *vaultReport) {
+	if r == nil || r.DryRun || (len(r.Items) == 0 && r.Error == "") {
+		return
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := writeFileWithPolicy(vaultLastRunPath(r.Operation), append(data, '\n'), fileClassPrivate); err != nil {
+		Warn("Run report not saved: %v", err)
+	}
+}
+
+// loadVaultLastRun reads the last report for op, or the most recent of
+// restore and push when op is empty. It returns nil when there is none.
+func loadVaultLastRun(op string) (*vaultReport, error) {
+	ops := vaultLastRunOps
+	if op != "" {
+		ops = []string{op}
+	}
+	var latest *vaultReport
+	for _, op := range ops {
+		data, err := os.ReadFile(vaultLastRunPath(op))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		var r vaultReport
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("%s: %w", tildePath(vaultLastRunPath(op)), err)
+		}
+		if latest == nil || r.FinishedAt.After(latest.FinishedAt) {
+			latest = &r
+		}
+	}
+	return latest, nil
+}
+
+func newVaultLastRunCmd() *cobra.Command {
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:       "last-run [restore|push]",
+		Short:     "Show what the last vault restore or push did",
+		ValidArgs: vaultLastRunOps,
+		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+		Long: `Show the report of the most recent vault restore or push: each item's
+outcome, the backend that served it, how long it took, and the SHA-256 of
+the local file afterwards. Files changed since the run are flagged.
+
+Every restore and push (except dry runs) saves its report to the state
+directory as vault-last-restore.json or vault-last-push.json.`,
+		Example: `  blackdot vault last-run            # Most recent restore or push
+  blackdot vault last-run restore    # Last restore, even if a push came after
+  blackdot vault last-run --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			op := ""
+			if len(args) > 0 {
+				op = args[0]
+			}
+			return runVaultLastRun(op, jsonOut)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output the saved report as JSON")
+	return cmd
+}
+
+func runVaultLastRun(op string, jsonOut bool) error {
+	r, err := loadVaultLastRun(op)
+	if err != nil {
+		Fail("Failed to read run report: %v", err)
+		return err
+	}
+
+	if jsonOut {
+		if r == nil {
+			fmt.Println("null")
+			return nil
+		}
+		return writeVaultReportJSON(os.Stdout, r)
+	}
+
+	if r == nil {
+		what := "restore or push"
+		if op != "" {
+			what = op
+		}
+		Info("No vault %s recorded yet", what)
+		return nil
+	}
+
+	printVaultLastRun(r)
+	return nil
+}
+
+func printVaultLastRun(r *vaultReport) {
+	PrintHeader("Last Vault " + strings.ToUpper(r.Operation[:1]) + r.Operation[1:])
+	fmt.Printf("  Backend:  %s\n", r.Backend)
+	fmt.Printf("  Started:  %s (%s)\n", r.StartedAt.Local().Format("2006-01-02 15:04:05"), formatTimeAgo(r.StartedAt.Format(time.RFC3339)))
+	fmt.Printf("  Duration: %s\n", r.FinishedAt.Sub(r.StartedAt).Round(100*time.Millisecond))
+	if r.Error != "" {
+		fmt.Printf("  Error:    %s\n", Red.Sprint(r.Error))
+	}
+	fmt.Println()
+
+	changed := 0
+	for _, item := range r.Items {
+		mark := Green.Sprint("✓")
+		switch item.Status {
+		case reportStatusFailed:
+			mark = Red.Sprint("✗")
+		case reportStatusSkipped:
+			mark = Yellow.Sprint("!")
+		case reportStatusUnchanged:
+			mark = Dim.Sprint("=")
+		}
+		fmt.Printf("  %s %-24s %-10s %s\n", mark, item.Name, item.Status, Dim.Sprint(tildePath(item.Path)))
+
+		var details []string
+		if item.Detail != "" {
+			details = append(details, item.Detail)
+		}
+		if item.Backend != "" && item.Backend != r.Backend {
+			details = append(details, "via "+item.Backend)
+		}
+		if item.DurationMs > 0 {
+			details = append(details, formatReportDuration(item.DurationMs))
+		}
+		if item.SHA256 != "" {
+			details = append(details, "sha256 "+shortChecksum(item.SHA256))
+		}
+		if len(details) > 0 {
+			fmt.Printf("    %s\n", Dim.Sprint(strings.Join(details, ", ")))
+		}
+		if note := vaultLastRunFileChange(item); note != "" {
+			fmt.Printf("    %s\n", Yellow.Sprint(note))
+			changed++
+		}
+	}
+
+	fmt.Println()
+	var counts []string
+	for _, status := range []string{reportStatusRestored, reportStatusCreated, reportStatusUpdated, reportStatusUnchanged, reportStatusSkipped, reportStatusFailed} {
+		if n := r.Summary[status]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, status))
+		}
+	}
+	fmt.Println("  " + strings.Join(counts, ", "))
+	if changed > 0 {
+		Warn("%d file(s) changed since this %s", changed, r.Operation)
+	}
+}
+
+// vaultLastRunFileChange says whether an item's file no longer matches the
+// checksum recorded for it
+func vaultLastRunFileChange(item vaultReportItem) string {
+	if item.SHA256 == "" {
+		return ""
+	}
+	data, err := os.ReadFile(item.Path)
+	switch {
+	case os.IsNotExist(err):
+		return "file removed since"
+	case err != nil:
+		return ""
+	case calculateChecksum(data) != item.SHA256:
+		return "file changed since (now sha256 " + shortChecksum(calculateChecksum(data)) + ")"
+	}
+	return ""
+}
+
+// shortChecksum abbreviates a hex checksum for display
+func shortChecksum(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
+
+// formatReportDuration formats an item duration, or "" when none was
+// recorded
+func formatReportDuration(ms int64) string {
+	if ms <= 0 {
+		return ""
+	}
+	return (time.Duration(ms) * time.Millisecond).String()
+}
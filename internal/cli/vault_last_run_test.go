@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestVaultLastRun verifies reports are saved per operation with item
+// checksums, durations and backends, and the most recent one is loaded
+func TestVaultLastRun(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config")
+	os.WriteFile(config, []byte("Host *\n"), 0600)
+
+	restore := newVaultReport("restore", "bitwarden", false)
+	restore.begin("SSH-Config", "pass", 300*time.Millisecond)
+	restore.add("SSH-Config", config, reportStatusRestored, "from pass")
+	restore.add("AWS-Config", filepath.Join(dir, "missing"), reportStatusFailed, "not found in vault (required)")
+	restore.finish(nil)
+	saveVaultLastRun(restore)
+
+	// Dry runs don't replace the last real run
+	dry := newVaultReport("push", "bitwarden", true)
+	dry.add("SSH-Config", config, reportStatusPlanned, "")
+	dry.finish(nil)
+	saveVaultLastRun(dry)
+
+	r, err := loadVaultLastRun("")
+	if err != nil || r == nil {
+		t.Fatalf("loadVaultLastRun = %v, %v", r, err)
+	}
+	item := r.Items[1]
+	if r.Operation != "restore" || item.Name != "SSH-Config" {
+		t.Fatalf("loaded %s %+v", r.Operation, r.Items)
+	}
+	if item.Backend != "pass" || item.DurationMs < 300 || item.SHA256 != calculateChecksum([]byte("Host *\n")) {
+		t.Errorf("item = %+v", item)
+	}
+	if r.Items[0].SHA256 != "" || r.Items[0].Backend != "bitwarden" {
+		t.Errorf("failed item = %+v", r.Items[0])
+	}
+	if note := vaultLastRunFileChange(item); note != "" {
+		t.Errorf("unchanged file reported: %s", note)
+	}
+	os.WriteFile(config, []byte("Host work\n"), 0600)
+	if note := vaultLastRunFileChange(item); !strings.HasPrefix(note, "file changed since") {
+		t.Errorf("changed file = %q", note)
+	}
+
+	push := newVaultReport("push", "bitwarden", false)
+	push.add("SSH-Config", config, reportStatusUpdated, "")
+	push.finish(nil)
+	saveVaultLastRun(push)
+	if r, _ := loadVaultLastRun(""); r.Operation != "push" {
+		t.Errorf("latest = %s, want push", r.Operation)
+	}
+	if r, _ := loadVaultLastRun("restore"); r.Operation != "restore" {
+		t.Errorf("restore = %s", r.Operation)
+	}
+}
@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// vaultListOptions narrow, order, and shape 'vault list' output
+type vaultListOptions struct {
+	JSON          bool
+	Location      string
+	Filters       []string
+	Type          string
+	ModifiedSince string
+	Sort          string
+	Reverse       bool
+	Columns       string
+	Tree          bool
+}
+
+// vaultListColumns are the columns --columns accepts
+var vaultListColumns = []string{"name", "location", "type", "modified", "size", "id"}
+
+// vaultListFilter is one --filter: field=value or field~regex
+type vaultListFilter struct {
+	field string
+	exact string
+	re    *regexp.Regexp
+}
+
+// parseVaultListFilter parses field=value or field~regex. A bare
+// expression is a name regex.
+func parseVaultListFilter(s string) (vaultListFilter, error) {
+	field, op, value := "name", "~", s
+	if i := strings.IndexAny(s, "=~"); i > 0 && isVaultListField(s[:i]) {
+		field, op, value = s[:i], s[i:i+1], s[i+1:]
+	}
+	f := vaultListFilter{field: field}
+	if op == "=" {
+		f.exact = value
+		return f, nil
+	}
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return f, fmt.Errorf("invalid filter %q: %w", s, err)
+	}
+	f.re = re
+	return f, nil
+}
+
+func isVaultListField(name string) bool {
+	for _, c := range vaultListColumns {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (f vaultListFilter) match(item *vaultmux.Item) bool {
+	value := vaultListCell(item, f.field)
+	if f.re != nil {
+		return f.re.MatchString(value)
+	}
+	return value == f.exact
+}
+
+// vaultItemTypeName is the lower-case type name used by --type and the
+// type column (securenote, login, sshkey, identity, card)
+func vaultItemTypeName(t vaultmux.ItemType) string {
+	return strings.ToLower(t.String())
+}
+
+// vaultItemSize is the size of the item's notes, or -1 when the backend
+// does not return contents in listings
+func vaultItemSize(item *vaultmux.Item) int {
+	if item.Notes == "" && len(item.Fields) == 0 {
+		return -1
+	}
+	size := len(item.Notes)
+	for _, v := range item.Fields {
+		size += len(v)
+	}
+	return size
+}
+
+func vaultListCell(item *vaultmux.Item, column string) string {
+	switch column {
+	case "name":
+		return item.Name
+	case "location":
+		if item.Location == "" {
+			return "(root)"
+		}
+		return item.Location
+	case "type":
+		return vaultItemTypeName(item.Type)
+	case "modified":
+		if item.Modified.IsZero() {
+			return "-"
+		}
+		return item.Modified.Local().Format("2006-01-02 15:04")
+	case "size":
+		if size := vaultItemSize(item); size >= 0 {
+			return formatSize(int64(size))
+		}
+		return "-"
+	case "id":
+		return item.ID
+	}
+	return ""
+}
+
+// selectVaultListItems applies the filters and sort order in opts
+func selectVaultListItems(items []*vaultmux.Item, opts vaultListOptions, now time.Time) ([]*vaultmux.Item, error) {
+	var filters []vaultListFilter
+	for _, s := range opts.Filters {
+		f, err := parseVaultListFilter(s)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	var since time.Time
+	if opts.ModifiedSince != "" {
+		if t, err := time.ParseInLocation("2006-01-02", opts.ModifiedSince, time.Local); err == nil {
+			since = t
+		} else {
+			age, err := parseItemAge(opts.ModifiedSince)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --modified-since %q (use a date like 2024-01-31 or an age like 30d)", opts.ModifiedSince)
+			}
+			since = now.Add(-age)
+		}
+	}
+	wantType := strings.ToLower(opts.Type)
+
+	selected := []*vaultmux.Item{}
+	for _, item := range items {
+		if wantType != "" && vaultItemTypeName(item.Type) != wantType {
+			continue
+		}
+		// Items without a modification time are left out: the backend
+		// did not say when they changed
+		if !since.IsZero() && (item.Modified.IsZero() || item.Modified.Before(since)) {
+			continue
+		}
+		matched := true
+		for _, f := range filters {
+			if !f.match(item) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			selected = append(selected, item)
+		}
+	}
+
+	var less func(a, b *vaultmux.Item) bool
+	switch opts.Sort {
+	case "", "name":
+		less = func(a, b *vaultmux.Item) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case "modified":
+		// newest first
+		less = func(a, b *vaultmux.Item) bool { return a.Modified.After(b.Modified) }
+	case "location":
+		less = func(a, b *vaultmux.Item) bool { return a.Location < b.Location }
+	case "size":
+		less = func(a, b *vaultmux.Item) bool { return vaultItemSize(a) > vaultItemSize(b) }
+	default:
+		return nil, fmt.Errorf("invalid --sort %q (name, modified, location, size)", opts.Sort)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		if opts.Reverse {
+			return less(selected[j], selected[i])
+		}
+		return less(selected[i], selected[j])
+	})
+	return selected, nil
+}
+
+// parseVaultListColumns validates --columns
+func parseVaultListColumns(s string) ([]string, error) {
+	if s == "" {
+		return []string{"name", "location"}, nil
+	}
+	var columns []string
+	for _, c := range strings.Split(s, ",") {
+		c = strings.TrimSpace(strings.ToLower(c))
+		if !isVaultListField(c) {
+			return nil, fmt.Errorf("unknown column %q (%s)", c, strings.Join(vaultListColumns, ", "))
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// printVaultListTable prints items in aligned columns, names padded to
+// at least 30 characters as the plain listing always was. Columns other
+// than the name are dimmed; the location is shown in brackets.
+func printVaultListTable(items []*vaultmux.Item, columns []string) {
+	widths := make([]int, len(columns))
+	for j, c := range columns {
+		if c == "name" {
+			widths[j] = 30
+		}
+	}
+	rows := make([][]string, len(items))
+	for i, item := range items {
+		rows[i] = make([]string, len(columns))
+		for j, c := range columns {
+			cell := vaultListCell(item, c)
+			if c == "location" {
+				cell = "[" + cell + "]"
+			}
+			rows[i][j] = cell
+			widths[j] = max(widths[j], len(cell))
+		}
+	}
+	for _, row := range rows {
+		line := "  "
+		for j, cell := range row {
+			if j < len(row)-1 {
+				cell = fmt.Sprintf("%-*s ", widths[j], cell)
+			}
+			if columns[j] != "name" {
+				cell = Dim.Sprint(cell)
+			}
+			line += cell
+		}
+		fmt.Println(line)
+	}
+}
+
+// vaultTreeNode is a folder in the --tree view
+type vaultTreeNode struct {
+	children map[string]*vaultTreeNode
+	items    []*vaultmux.Item
+}
+
+// buildVaultTree groups items by location, splitting folder paths on "/"
+func buildVaultTree(items []*vaultmux.Item) *vaultTreeNode {
+	root := &vaultTreeNode{children: map[string]*vaultTreeNode{}}
+	for _, item := range items {
+		node := root
+		for _, part := range strings.Split(item.Location, "/") {
+			if part == "" {
+				continue
+			}
+			child, ok := node.children[part]
+			if !ok {
+				child = &vaultTreeNode{children: map[string]*vaultTreeNode{}}
+				node.children[part] = child
+			}
+			node = child
+		}
+		node.items = append(node.items, item)
+	}
+	return root
+}
+
+// printVaultTree prints folders before items, each level indented. Item
+// order within a folder follows --sort.
+func printVaultTree(node *vaultTreeNode, columns []string, indent string) {
+	for _, name := range sortedKeys(node.children) {
+		child := node.children[name]
+		fmt.Printf("%s%s %s\n", indent, Cyan.Sprint(name+"/"), Dim.Sprintf("(%d)", child.count()))
+		printVaultTree(child, columns, indent+"  ")
+	}
+	for _, item := range node.items {
+		fmt.Printf("%s%s", indent, item.Name)
+		for _, c := range columns {
+			if c != "name" && c != "location" {
+				fmt.Printf("  %s", Dim.Sprint(vaultListCell(item, c)))
+			}
+		}
+		fmt.Println()
+	}
+}
+
+func (n *vaultTreeNode) count() int {
+	total := len(n.items)
+	for _, child := range n.children {
+		total += child.count()
+	}
+	return total
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// TestSelectVaultListItems verifies filters, --type, --modified-since,
+// and sorting
+func TestSelectVaultListItems(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	items := []*vaultmux.Item{
+		{Name: "SSH-Work", Type: vaultmux.ItemTypeSSHKey, Location: "ssh", Modified: now.Add(-48 * time.Hour)},
+		{Name: "AWS-Config", Location: "cloud/aws", Modified: now.Add(-90 * 24 * time.Hour), Notes: "0123456789"},
+		{Name: "ssh-personal", Type: vaultmux.ItemTypeSSHKey, Location: "ssh"},
+		{Name: "Git-Config", Notes: "x", Modified: now.Add(-time.Hour)},
+	}
+	names := func(opts vaultListOptions) []string {
+		t.Helper()
+		selected, err := selectVaultListItems(items, opts, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, item := range selected {
+			out = append(out, item.Name)
+		}
+		return out
+	}
+	check := func(got []string, want ...string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("got %v, want %v", got, want)
+			return
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("got %v, want %v", got, want)
+				return
+			}
+		}
+	}
+
+	check(names(vaultListOptions{}), "AWS-Config", "Git-Config", "ssh-personal", "SSH-Work")
+	check(names(vaultListOptions{Filters: []string{"(?i)^ssh-"}}), "ssh-personal", "SSH-Work")
+	check(names(vaultListOptions{Filters: []string{"location=ssh", "name~Work"}}), "SSH-Work")
+	check(names(vaultListOptions{Filters: []string{"location~^cloud/"}}), "AWS-Config")
+	check(names(vaultListOptions{Type: "SSHKey", Sort: "name", Reverse: true}), "SSH-Work", "ssh-personal")
+	check(names(vaultListOptions{ModifiedSince: "7d", Sort: "modified"}), "Git-Config", "SSH-Work")
+	check(names(vaultListOptions{ModifiedSince: "2025-01-01", Sort: "size"}), "AWS-Config", "Git-Config", "SSH-Work")
+
+	for _, bad := range []vaultListOptions{{Sort: "color"}, {Filters: []string{"name~("}}, {ModifiedSince: "soon"}} {
+		if _, err := selectVaultListItems(items, bad, now); err == nil {
+			t.Errorf("%+v should fail", bad)
+		}
+	}
+	if _, err := parseVaultListColumns("name,owner"); err == nil {
+		t.Error("unknown column accepted")
+	}
+
+	tree := buildVaultTree(items)
+	if tree.count() != 4 || len(tree.items) != 1 || tree.children["cloud"].children["aws"].count() != 1 || tree.children["ssh"].count() != 2 {
+		t.Errorf("unexpected tree grouping")
+	}
+}
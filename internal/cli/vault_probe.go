@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/vault"
+)
+
+// vaultProbeReport is the --json output of 'vault health --probe'
+type vaultProbeReport struct {
+	Backend  string              `json:"backend"`
+	OK       bool                `json:"ok"`
+	ExitCode int                 `json:"exit_code"`
+	Probes   []vault.ProbeResult `json:"probes"`
+}
+
+// runVaultProbes runs readiness probes, prints them, and exits with the
+// first failure's code
+func runVaultProbes(specs []string, timeout time.Duration, quiet, jsonOut bool) error {
+	probes := make([]vault.Probe, 0, len(specs))
+	for _, spec := range specs {
+		p, err := vault.ParseProbe(spec)
+		if err != nil {
+			return err
+		}
+		probes = append(probes, p)
+	}
+
+	report := probeVault(probes, timeout)
+	switch {
+	case jsonOut:
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+	case !quiet:
+		printVaultProbes(report)
+	}
+	if !report.OK {
+		os.Exit(report.ExitCode)
+	}
+	return nil
+}
+
+// probeVault runs probes against the configured backend. A backend that
+// can't be created fails every probe as a missing CLI.
+func probeVault(probes []vault.Probe, timeout time.Duration) vaultProbeReport {
+	report := vaultProbeReport{Backend: string(getVaultBackend())}
+
+	svc, err := newVaultService()
+	if err != nil {
+		for _, p := range probes {
+			report.Probes = append(report.Probes, vault.ProbeResult{
+				Probe: p.String(), Status: vault.ProbeFail, Code: vault.ExitCLIMissing, Error: err.Error(),
+			})
+		}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		report.Probes = svc.RunProbes(ctx, probes)
+		cancel()
+		svc.Close()
+	}
+
+	report.ExitCode = vault.ProbeExitCode(report.Probes)
+	report.OK = report.ExitCode == vault.ExitOK
+	return report
+}
+
+func printVaultProbes(report vaultProbeReport) {
+	fmt.Printf("Backend: %s\n\n", valueOr(report.Backend, "not configured"))
+	for _, r := range report.Probes {
+		if r.Status == vault.ProbePass {
+			Pass("%s %s", r.Probe, Dim.Sprintf("(%dms)", r.DurationMS))
+		} else {
+			Fail("%s: %s %s", r.Probe, r.Error, Dim.Sprintf("(exit %d)", r.Code))
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/prompts"
+	"github.com/blackwell-systems/blackdot/internal/scanners"
+)
+
+// vaultPushPreviewLines is how many changed lines the push preview shows
+const vaultPushPreviewLines = 6
+
+// vaultPushDiffLimit caps the line-diff table (vault lines × local lines);
+// larger items are summarised by counting lines instead
+const vaultPushDiffLimit = 4_000_000
+
+// vaultPushDiff summarises what pushing local content over the vault copy
+// would change
+type vaultPushDiff struct {
+	Added   int
+	Removed int
+	Lines   []string // first changed lines, masked, prefixed with + or -
+	More    int      // changed lines not in Lines
+}
+
+// diffVaultPush compares the vault copy with local content line by line.
+// Secret items have every value masked; other items only mask what looks
+// like a credential.
+func diffVaultPush(vault, local string, secret bool) vaultPushDiff {
+	a, b := splitVaultLines(vault), splitVaultLines(local)
+	sc := scanners.New()
+	maskedA, maskedB := maskVaultLines(a, secret, sc), maskVaultLines(b, secret, sc)
+
+	var d vaultPushDiff
+	add := func(prefix string, line string) {
+		if prefix == "+" {
+			d.Added++
+		} else {
+			d.Removed++
+		}
+		if len(d.Lines) < vaultPushPreviewLines {
+			d.Lines = append(d.Lines, prefix+" "+line)
+		} else {
+			d.More++
+		}
+	}
+
+	if len(a)*len(b) > vaultPushDiffLimit {
+		// Too big for a table: count lines only in one side
+		counts := make(map[string]int)
+		for _, line := range a {
+			counts[line]++
+		}
+		for i, line := range b {
+			if counts[line] > 0 {
+				counts[line]--
+			} else {
+				add("+", maskedB[i])
+			}
+		}
+		for i, line := range a {
+			if counts[line] > 0 {
+				counts[line]--
+				add("-", maskedA[i])
+			}
+		}
+		return d
+	}
+
+	// Longest common subsequence, walked front to back
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			add("+", maskedB[j])
+			j++
+		default:
+			add("-", maskedA[i])
+			i++
+		}
+	}
+	return d
+}
+
+func splitVaultLines(content string) []string {
+	content = strings.TrimRight(content, "\n")
+	if content == "" {
+		return nil
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, "\r")
+	}
+	return lines
+}
+
+// maskVaultLines masks each line for display, tracking private key bodies
+// as vault grep does
+func maskVaultLines(lines []string, secret bool, sc *scanners.Scanner) []string {
+	masked := make([]string, len(lines))
+	inKey := false
+	for i, line := range lines {
+		keyBody := inKey && !strings.HasPrefix(line, "-----END")
+		switch {
+		case strings.HasPrefix(line, "-----BEGIN") && strings.Contains(line, "PRIVATE KEY"):
+			inKey = true
+		case strings.HasPrefix(line, "-----END"):
+			inKey = false
+		}
+		switch {
+		case secret || keyBody:
+			masked[i] = maskVaultLine(line, keyBody, sc)
+		default:
+			masked[i] = sc.Mask(line)
+		}
+	}
+	return masked
+}
+
+// printVaultPushDiff shows the change an update would make, warning when
+// the local file looks truncated
+func printVaultPushDiff(d vaultPushDiff, vault, local string) {
+	fmt.Printf("  %s, %s (%s → %s)\n",
+		Green.Sprintf("+%d lines", d.Added), Red.Sprintf("-%d lines", d.Removed),
+		formatSize(int64(len(vault))), formatSize(int64(len(local))))
+	for _, line := range d.Lines {
+		if strings.HasPrefix(line, "+") {
+			fmt.Println("    " + Green.Sprint(line))
+		} else {
+			fmt.Println("    " + Red.Sprint(line))
+		}
+	}
+	if d.More > 0 {
+		fmt.Println("    " + Dim.Sprintf("... %d more changed lines", d.More))
+	}
+
+	vaultLines := len(splitVaultLines(vault))
+	switch {
+	case strings.TrimSpace(local) == "":
+		Warn("The local file is empty")
+	case vaultLines >= 4 && d.Removed*2 > vaultLines && d.Removed > d.Added:
+		Warn("The local file drops %d of the vault copy's %d lines", d.Removed, vaultLines)
+	}
+}
+
+// confirmVaultPush shows how pushing local would change an existing vault
+// item and asks before overwriting it. Binary items show their sizes only.
+func confirmVaultPush(name, vault, local string, binary, secret bool) bool {
+	if binary {
+		before := "unknown size"
+		if m, err := parseBinaryNote(vault); err == nil {
+			before = formatSize(int64(m.Size))
+		}
+		fmt.Printf("  binary content changed (%s → %s)\n", before, formatSize(int64(len(local))))
+	} else {
+		printVaultPushDiff(diffVaultPush(vault, local, secret), vault, local)
+	}
+	ok, err := prompts.Confirm(fmt.Sprintf("Overwrite '%s' in vault?", name), false)
+	return err == nil && ok
+}
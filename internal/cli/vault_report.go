@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Item outcome statuses recorded in vault reports
+const (
+	reportStatusRestored  = "restored"
+	reportStatusCreated   = "created"
+	reportStatusUpdated   = "updated"
+	reportStatusUnchanged = "unchanged"
+	reportStatusSkipped   = "skipped"
+	reportStatusFailed    = "failed"
+	reportStatusPlanned   = "planned" // dry-run: would be changed
+)
+
+// vaultReport is a structured record of a restore/push run, written with
+// --report so provisioning pipelines don't have to scrape human output.
+type vaultReport struct {
+	Operation  string            `json:"operation"`
+	Backend    string            `json:"backend"`
+	DryRun     bool              `json:"dry_run"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Items      []vaultReportItem `json:"items"`
+	Summary    map[string]int    `json:"summary"`
+	Error      string            `json:"error,omitempty"`
+
+	total   int                          // items expected, for progress events
+	started map[string]vaultReportTiming // items begun but not yet recorded
+}
+
+// vaultReportTiming is when an item was begun, and time already spent on it
+// elsewhere (such as a parallel fetch)
+type vaultReportTiming struct {
+	at      time.Time
+	spent   time.Duration
+	backend string
+}
+
+// vaultReportItem records the outcome for a single vault item
+type vaultReportItem struct {
+	Name       string `json:"name"`
+	Path       string `json:"path,omitempty"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Backend    string `json:"backend,omitempty"`
+	SHA256     string `json:"sha256,omitempty"` // local file after the run
+	DurationMs int64  `json:"duration_ms,omitempty"`
+}
+
+// vaultReportWriter renders a report in a specific format
+type vaultReportWriter func(w io.Writer, r *vaultReport) error
+
+// vaultReportFormats maps --report-format values to writers
+var vaultReportFormats = map[string]vaultReportWriter{
+	"json":     writeVaultReportJSON,
+	"markdown": writeVaultReportMarkdown,
+	"md":       writeVaultReportMarkdown,
+}
+
+func newVaultReport(operation, backend string, dryRun bool) *vaultReport {
+	return &vaultReport{
+		Operation: operation,
+		Backend:   backend,
+		DryRun:    dryRun,
+		StartedAt: time.Now().UTC(),
+		Summary:   make(map[string]int),
+		started:   make(map[string]vaultReportTiming),
+	}
+}
+
+// begin marks the start of work on an item, so its outcome records how
+// long it took. spent is time already spent on it, and backend is the
+// backend serving it when that is not the report's.
+func (r *vaultReport) begin(name, backend string, spent time.Duration) {
+	if r == nil {
+		return
+	}
+	r.started[name] = vaultReportTiming{at: time.Now(), spent: spent, backend: backend}
+}
+
+// expect sets how many items the run covers. Each outcome recorded after
+// that is also emitted as a progress event.
+func (r *vaultReport) expect(total int) {
+	r.total = total
+}
+
+// add records an item outcome
+func (r *vaultReport) add(name, path, status, detail string) {
+	if r == nil {
+		return
+	}
+	item := vaultReportItem{
+		Name:    name,
+		Path:    path,
+		Status:  status,
+		Detail:  detail,
+		Backend: r.Backend,
+	}
+	if timing, ok := r.started[name]; ok {
+		item.DurationMs = (time.Since(timing.at) + timing.spent).Milliseconds()
+		if timing.backend != "" {
+			item.Backend = timing.backend
+		}
+		delete(r.started, name)
+	}
+	// Record what the file holds now, to tell later whether it changed
+	switch status {
+	case reportStatusRestored, reportStatusCreated, reportStatusUpdated, reportStatusUnchanged:
+		if data, err := os.ReadFile(path); err == nil {
+			item.SHA256 = calculateChecksum(data)
+		}
+	}
+	r.Items = append(r.Items, item)
+	r.Summary[status]++
+	if r.total > 0 {
+		emitProgress(progressEvent{Phase: r.Operation, Item: name, Percent: progressPercent(len(r.Items), r.total), Status: status, Message: detail})
+	}
+}
+
+// finish stamps the end time, sorts items, and records a top-level error
+func (r *vaultReport) finish(err error) {
+	if r == nil {
+		return
+	}
+	r.FinishedAt = time.Now().UTC()
+	sort.Slice(r.Items, func(i, j int) bool {
+		return r.Items[i].Name < r.Items[j].Name
+	})
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// resolveVaultReportFormat picks the format from the flag or file extension
+func resolveVaultReportFormat(dest, format string) (vaultReportWriter, error) {
+	if format == "" {
+		format = "json"
+		if ext := strings.ToLower(filepath.Ext(dest)); ext == ".md" || ext == ".markdown" {
+			format = "markdown"
+		}
+	}
+
+	writer, ok := vaultReportFormats[format]
+	if !ok {
+		names := make([]string, 0, len(vaultReportFormats))
+		for name := range vaultReportFormats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown report format: %s (available: %s)", format, strings.Join(names, ", "))
+	}
+	return writer, nil
+}
+
+// writeVaultReport writes the report to dest ("-" for stdout)
+func writeVaultReport(r *vaultReport, dest, format string) error {
+	writer, err := resolveVaultReportFormat(dest, format)
+	if err != nil {
+		return err
+	}
+
+	if dest == "-" {
+		return writer(os.Stdout, r)
+	}
+
+	dest = expandPath(dest)
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writer(f, r)
+}
+
+// redirectStdoutForReport sends human-readable output to stderr while the
+// report owns stdout, so "--report -" can be piped straight into a parser.
+// The returned func restores the original writers.
+func redirectStdoutForReport(dest string) func() {
+	if dest != "-" {
+		return func() {}
+	}
+	origStdout, origColor := os.Stdout, color.Output
+	os.Stdout, color.Output = os.Stderr, os.Stderr
+	return func() {
+		os.Stdout, color.Output = origStdout, origColor
+	}
+}
+
+func writeVaultReportJSON(w io.Writer, r *vaultReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func writeVaultReportMarkdown(w io.Writer, r *vaultReport) error {
+	title := strings.ToUpper(r.Operation[:1]) + r.Operation[1:]
+	fmt.Fprintf(w, "# Vault %s Report\n\n", title)
+	fmt.Fprintf(w, "- **Backend:** %s\n", r.Backend)
+	fmt.Fprintf(w, "- **Started:** %s\n", r.StartedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "- **Finished:** %s\n", r.FinishedAt.Format(time.RFC3339))
+	if r.DryRun {
+		fmt.Fprintln(w, "- **Dry run:** yes")
+	}
+	if r.Error != "" {
+		fmt.Fprintf(w, "- **Error:** %s\n", r.Error)
+	}
+	fmt.Fprintln(w)
+
+	if len(r.Summary) > 0 {
+		fmt.Fprintln(w, "## Summary")
+		fmt.Fprintln(w)
+		statuses := make([]string, 0, len(r.Summary))
+		for status := range r.Summary {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(w, "- %s: %d\n", status, r.Summary[status])
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(r.Items) > 0 {
+		fmt.Fprintln(w, "## Items")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| Item | Path | Status | Detail | SHA-256 | Duration |")
+		fmt.Fprintln(w, "|------|------|--------|--------|---------|----------|")
+		for _, item := range r.Items {
+			fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s |\n",
+				item.Name, item.Path, item.Status, strings.ReplaceAll(item.Detail, "|", "\\|"),
+				shortChecksum(item.SHA256), formatReportDuration(item.DurationMs))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// restoreResolution is the user's choice for a drifted item during restore
+type restoreResolution int
+
+const (
+	resolveTakeVault restoreResolution = iota // overwrite local with vault content
+	resolveKeepLocal                          // keep local and update the vault to match
+	resolveSkip                               // leave both sides unchanged
+)
+
+// errRestoreAborted is returned when the user quits conflict resolution
+var errRestoreAborted = errors.New("restore aborted during conflict resolution")
+
+// restoreConflict is a vault item whose local file differs from the vault
+type restoreConflict struct {
+	Name  string
+	Path  string
+	Vault string
+}
+
+const restoreConflictHelp = `t - take vault version (overwrite local file)
+k - keep local version (update vault to match)
+d - view diff (vault → local)
+s - skip this item (leave both unchanged)
+a - take vault version for this and all remaining items
+q - quit (abort restore, nothing written)
+? - print help`
+
+// resolveRestoreConflicts prompts for each conflict, similar to
+// 'git checkout -p'. Prompts go to out; answers are read from in.
+func resolveRestoreConflicts(conflicts []restoreConflict, in io.Reader, out io.Writer) (map[string]restoreResolution, error) {
+	resolutions := make(map[string]restoreResolution, len(conflicts))
+	reader := bufio.NewReader(in)
+
+	for i, c := range conflicts {
+		fmt.Fprintf(out, "\n%s %s → %s\n", Cyan.Sprintf("[%d/%d]", i+1, len(conflicts)), Bold.Sprint(c.Name), c.Path)
+
+		for {
+			fmt.Fprint(out, Yellow.Sprint("Local differs from vault. Resolve [t,k,d,s,a,q,?]? "))
+			line, err := reader.ReadString('\n')
+			answer := strings.TrimSpace(strings.ToLower(line))
+			if err != nil && answer == "" {
+				// EOF without an answer: treat like quit so nothing is overwritten
+				return nil, errRestoreAborted
+			}
+
+			switch answer {
+			case "t":
+				resolutions[c.Name] = resolveTakeVault
+			case "k":
+				resolutions[c.Name] = resolveKeepLocal
+			case "s":
+				resolutions[c.Name] = resolveSkip
+			case "a":
+				for _, rest := range conflicts[i:] {
+					resolutions[rest.Name] = resolveTakeVault
+				}
+				return resolutions, nil
+			case "q":
+				return nil, errRestoreAborted
+			case "d":
+				showRestoreConflictDiff(out, c)
+				continue
+			default:
+				fmt.Fprintln(out, restoreConflictHelp)
+				continue
+			}
+			break
+		}
+	}
+
+	return resolutions, nil
+}
+
+// showRestoreConflictDiff prints a unified diff of vault vs local content
+func showRestoreConflictDiff(out io.Writer, c restoreConflict) {
+	local, err := os.ReadFile(c.Path)
+	if err != nil {
+		fmt.Fprintf(out, "  cannot read %s: %v\n", c.Path, err)
+		return
+	}
+	printUnifiedDiff(out, "vault/"+c.Name, c.Vault, "local/"+c.Path, string(local))
+}
+
+// stdinIsTerminal reports whether prompts can be answered interactively
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
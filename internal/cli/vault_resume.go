@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+)
+
+// restoreProgress records which items a vault restore has written, so an
+// interrupted restore can be resumed with --resume. It is removed when a
+// restore completes without failures.
+type restoreProgress struct {
+	Started string                  `json:"started"`
+	Backend string                  `json:"backend"`
+	Items   map[string]restoredItem `json:"items"`
+}
+
+// restoredItem is one item written by the restore, with the checksum of
+// the file as written
+type restoredItem struct {
+	Path     string `json:"path"`
+	Checksum string `json:"checksum"`
+	Restored string `json:"restored"`
+}
+
+func restoreProgressPath() string {
+	return filepath.Join(paths.StateDir(), "vault-restore.json")
+}
+
+func newRestoreProgress(backend string) *restoreProgress {
+	return &restoreProgress{
+		Started: time.Now().UTC().Format(time.RFC3339),
+		Backend: backend,
+		Items:   make(map[string]restoredItem),
+	}
+}
+
+// loadRestoreProgress returns the state of an interrupted restore, or nil
+func loadRestoreProgress() *restoreProgress {
+	data, err := os.ReadFile(restoreProgressPath())
+	if err != nil {
+		return nil
+	}
+	var p restoreProgress
+	if json.Unmarshal(data, &p) != nil {
+		return nil
+	}
+	if p.Items == nil {
+		p.Items = make(map[string]restoredItem)
+	}
+	return &p
+}
+
+func (p *restoreProgress) save() error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileWithPolicy(restoreProgressPath(), data, fileClassPrivate)
+}
+
+// record marks name as restored to path. The state is saved after every
+// item so a restore killed at any point keeps its progress.
+func (p *restoreProgress) record(name, path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	p.Items[name] = restoredItem{
+		Path:     path,
+		Checksum: calculateChecksum(content),
+		Restored: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := p.save(); err != nil {
+		Warn("Failed to save restore progress: %v", err)
+	}
+}
+
+// verified reports whether name was restored to path and the file is
+// still exactly what was written
+func (p *restoreProgress) verified(name, path string) bool {
+	item, ok := p.Items[name]
+	if !ok || item.Path != path {
+		return false
+	}
+	content, err := os.ReadFile(path)
+	return err == nil && calculateChecksum(content) == item.Checksum
+}
+
+func clearRestoreProgress() {
+	os.Remove(restoreProgressPath())
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRestoreProgress verifies recorded items survive a reload and only
+// count as restored while their files are unchanged and at the same path
+func TestRestoreProgress(t *testing.T) {
+	setScheduleEnv(t, "")
+	dir := t.TempDir()
+	gitconfig := filepath.Join(dir, "gitconfig")
+	sshConfig := filepath.Join(dir, "ssh_config")
+	os.WriteFile(gitconfig, []byte("[user]\n"), 0644)
+	os.WriteFile(sshConfig, []byte("Host *\n"), 0600)
+
+	if loadRestoreProgress() != nil {
+		t.Fatal("progress without a restore")
+	}
+	p := newRestoreProgress("pass")
+	p.record("Git-Config", gitconfig)
+	p.record("SSH-Config", sshConfig)
+	p.record("Missing", filepath.Join(dir, "missing"))
+
+	// a later run picks up where this one stopped
+	p = loadRestoreProgress()
+	if p == nil || p.Backend != "pass" || len(p.Items) != 2 {
+		t.Fatalf("reloaded progress = %+v", p)
+	}
+	if !p.verified("Git-Config", gitconfig) {
+		t.Error("unchanged file not verified")
+	}
+	os.WriteFile(sshConfig, []byte("Host * edited\n"), 0600)
+	if p.verified("SSH-Config", sshConfig) {
+		t.Error("edited file verified")
+	}
+	if p.verified("Git-Config", sshConfig) {
+		t.Error("item verified at a different path")
+	}
+
+	clearRestoreProgress()
+	if loadRestoreProgress() != nil {
+		t.Error("progress kept after a completed restore")
+	}
+}
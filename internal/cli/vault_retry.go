@@ -0,0 +1,314 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// Retry defaults: vault.retries extra attempts, starting vault.retry_delay
+// apart and doubling up to vaultRetryMaxDelay
+const (
+	defaultVaultRetries    = 3
+	defaultVaultRetryDelay = 500 * time.Millisecond
+	vaultRetryMaxDelay     = 8 * time.Second
+)
+
+// vaultTransientPattern matches CLI output for failures worth retrying:
+// rate limits, gateway errors, and network trouble
+var vaultTransientPattern = regexp.MustCompile(`(?i)\b(429|502|503|504)\b|too many requests|rate.?limit|timed? ?out|temporar(y|ily)|connection (reset|refused|closed)|econnreset|etimedout|eai_again|service unavailable|bad gateway|network`)
+
+// vaultPermanentErrors are never retried: another attempt gives the same
+// answer, or needs the user
+var vaultPermanentErrors = []error{
+	vaultmux.ErrNotFound,
+	vaultmux.ErrAlreadyExists,
+	vaultmux.ErrNotAuthenticated,
+	vaultmux.ErrSessionExpired,
+	vaultmux.ErrBackendNotInstalled,
+	vaultmux.ErrBackendLocked,
+	vaultmux.ErrPermissionDenied,
+	vaultmux.ErrNotSupported,
+	vaultmux.ErrInvalidItemName,
+	context.Canceled,
+	context.DeadlineExceeded,
+}
+
+// isTransientVaultError reports whether err looks like a failure that may
+// succeed on another attempt
+func isTransientVaultError(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, permanent := range vaultPermanentErrors {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+	text := err.Error()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		text += "\n" + string(exitErr.Stderr)
+	}
+	return vaultTransientPattern.MatchString(text)
+}
+
+// vaultRetryPolicy is how many times and how far apart calls are retried
+type vaultRetryPolicy struct {
+	retries int
+	delay   time.Duration
+}
+
+// vaultRetryConfig reads vault.retries (0 turns retries off) and
+// vault.retry_delay
+func vaultRetryConfig() vaultRetryPolicy {
+	policy := vaultRetryPolicy{retries: defaultVaultRetries, delay: defaultVaultRetryDelay}
+	if val := configLookup("vault.retries"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+			policy.retries = n
+		} else {
+			Debug("Ignoring invalid vault.retries %q", val)
+		}
+	}
+	if val := configLookup("vault.retry_delay"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			policy.delay = d
+		} else {
+			Debug("Ignoring invalid vault.retry_delay %q", val)
+		}
+	}
+	return policy
+}
+
+// backoff is the wait before retry n (from 1): the delay doubled per
+// retry, capped, with jitter so parallel fetches don't retry in step
+func (p vaultRetryPolicy) backoff(n int) time.Duration {
+	d := p.delay << (n - 1)
+	if d > vaultRetryMaxDelay || d <= 0 {
+		d = vaultRetryMaxDelay
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// vaultRetrySleep waits between attempts; tests replace it
+var vaultRetrySleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// vaultRetryError is a call that failed on every attempt. It unwraps to
+// each attempt's error, newest first.
+type vaultRetryError struct {
+	Op       string
+	Item     string
+	Attempts []error
+}
+
+func (e *vaultRetryError) Error() string {
+	what := e.Op
+	if e.Item != "" {
+		what += " " + strconv.Quote(e.Item)
+	}
+	last := e.Attempts[len(e.Attempts)-1]
+	msg := fmt.Sprintf("%s failed after %d attempts: %v", what, len(e.Attempts), last)
+	var earlier []string
+	seen := map[string]bool{last.Error(): true}
+	for _, err := range e.Attempts[:len(e.Attempts)-1] {
+		if !seen[err.Error()] {
+			seen[err.Error()] = true
+			earlier = append(earlier, err.Error())
+		}
+	}
+	if len(earlier) > 0 {
+		msg += " (earlier: " + strings.Join(earlier, "; ") + ")"
+	}
+	return msg
+}
+
+func (e *vaultRetryError) Unwrap() []error {
+	errs := make([]error, len(e.Attempts))
+	for i, err := range e.Attempts {
+		errs[len(errs)-1-i] = err
+	}
+	return errs
+}
+
+// retryBackend retries backend calls that fail transiently. Init and
+// Authenticate are not retried: they can prompt for a password.
+type retryBackend struct {
+	vaultmux.Backend
+	backendType vaultmux.BackendType
+	policy      vaultRetryPolicy
+}
+
+// newRetryBackend wraps b unless retries are turned off
+func newRetryBackend(b vaultmux.Backend, backendType vaultmux.BackendType) vaultmux.Backend {
+	policy := vaultRetryConfig()
+	if policy.retries == 0 {
+		return b
+	}
+	return &retryBackend{Backend: b, backendType: backendType, policy: policy}
+}
+
+// do runs call until it succeeds, fails permanently, or runs out of
+// retries. landed, when set, is asked before a retry whether a failed
+// attempt took effect anyway (a write whose response was lost).
+func (b *retryBackend) do(ctx context.Context, op, item string, landed func() bool, call func() error) error {
+	what := op
+	if item != "" {
+		what += " " + item
+	}
+	var attempts []error
+	for {
+		err := call()
+		if err == nil {
+			if len(attempts) > 0 {
+				Debug("vault %s: %s succeeded after %d retries", b.backendType, what, len(attempts))
+			}
+			return nil
+		}
+		attempts = append(attempts, err)
+		if !isTransientVaultError(err) {
+			if len(attempts) == 1 {
+				return err
+			}
+			return &vaultRetryError{Op: op, Item: item, Attempts: attempts}
+		}
+		if len(attempts) > b.policy.retries {
+			return &vaultRetryError{Op: op, Item: item, Attempts: attempts}
+		}
+		wait := b.policy.backoff(len(attempts))
+		Debug("vault %s: %s failed (attempt %d of %d), retrying in %s: %v",
+			b.backendType, what, len(attempts), b.policy.retries+1, wait.Round(time.Millisecond), err)
+		if sleepErr := vaultRetrySleep(ctx, wait); sleepErr != nil {
+			return &vaultRetryError{Op: op, Item: item, Attempts: append(attempts, sleepErr)}
+		}
+		if landed != nil && landed() {
+			Debug("vault %s: %s took effect despite the error", b.backendType, what)
+			return nil
+		}
+	}
+}
+
+func (b *retryBackend) Sync(ctx context.Context, session vaultmux.Session) error {
+	return b.do(ctx, "sync", "", nil, func() error {
+		return b.Backend.Sync(ctx, session)
+	})
+}
+
+func (b *retryBackend) GetItem(ctx context.Context, name string, session vaultmux.Session) (*vaultmux.Item, error) {
+	var item *vaultmux.Item
+	err := b.do(ctx, "get", name, nil, func() (err error) {
+		item, err = b.Backend.GetItem(ctx, name, session)
+		return err
+	})
+	return item, err
+}
+
+func (b *retryBackend) GetNotes(ctx context.Context, name string, session vaultmux.Session) (string, error) {
+	var notes string
+	err := b.do(ctx, "get", name, nil, func() (err error) {
+		notes, err = b.Backend.GetNotes(ctx, name, session)
+		return err
+	})
+	return notes, err
+}
+
+func (b *retryBackend) ItemExists(ctx context.Context, name string, session vaultmux.Session) (bool, error) {
+	var exists bool
+	err := b.do(ctx, "exists", name, nil, func() (err error) {
+		exists, err = b.Backend.ItemExists(ctx, name, session)
+		return err
+	})
+	return exists, err
+}
+
+func (b *retryBackend) ListItems(ctx context.Context, session vaultmux.Session) ([]*vaultmux.Item, error) {
+	var items []*vaultmux.Item
+	err := b.do(ctx, "list", "", nil, func() (err error) {
+		items, err = b.Backend.ListItems(ctx, session)
+		return err
+	})
+	return items, err
+}
+
+// CreateItem checks before a retry whether the failed create made the
+// item, so a lost response does not create a duplicate
+func (b *retryBackend) CreateItem(ctx context.Context, name, content string, session vaultmux.Session) error {
+	landed := func() bool {
+		exists, err := b.Backend.ItemExists(ctx, name, session)
+		return err == nil && exists
+	}
+	return b.do(ctx, "create", name, landed, func() error {
+		return b.Backend.CreateItem(ctx, name, content, session)
+	})
+}
+
+func (b *retryBackend) UpdateItem(ctx context.Context, name, content string, session vaultmux.Session) error {
+	return b.do(ctx, "update", name, nil, func() error {
+		return b.Backend.UpdateItem(ctx, name, content, session)
+	})
+}
+
+// DeleteItem checks before a retry whether the failed delete removed the
+// item, so the retry does not fail with not found
+func (b *retryBackend) DeleteItem(ctx context.Context, name string, session vaultmux.Session) error {
+	landed := func() bool {
+		exists, err := b.Backend.ItemExists(ctx, name, session)
+		return err == nil && !exists
+	}
+	return b.do(ctx, "delete", name, landed, func() error {
+		return b.Backend.DeleteItem(ctx, name, session)
+	})
+}
+
+func (b *retryBackend) ListLocations(ctx context.Context, session vaultmux.Session) ([]string, error) {
+	var locations []string
+	err := b.do(ctx, "list-locations", "", nil, func() (err error) {
+		locations, err = b.Backend.ListLocations(ctx, session)
+		return err
+	})
+	return locations, err
+}
+
+func (b *retryBackend) LocationExists(ctx context.Context, name string, session vaultmux.Session) (bool, error) {
+	var exists bool
+	err := b.do(ctx, "location-exists", name, nil, func() (err error) {
+		exists, err = b.Backend.LocationExists(ctx, name, session)
+		return err
+	})
+	return exists, err
+}
+
+func (b *retryBackend) CreateLocation(ctx context.Context, name string, session vaultmux.Session) error {
+	landed := func() bool {
+		exists, err := b.Backend.LocationExists(ctx, name, session)
+		return err == nil && exists
+	}
+	return b.do(ctx, "create-location", name, landed, func() error {
+		return b.Backend.CreateLocation(ctx, name, session)
+	})
+}
+
+func (b *retryBackend) ListItemsInLocation(ctx context.Context, locType, locValue string, session vaultmux.Session) ([]*vaultmux.Item, error) {
+	var items []*vaultmux.Item
+	err := b.do(ctx, "list-items-in-location", locValue, nil, func() (err error) {
+		items, err = b.Backend.ListItemsInLocation(ctx, locType, locValue, session)
+		return err
+	})
+	return items, err
+}
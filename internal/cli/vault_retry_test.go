@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/blackwell-systems/vaultmux/mock"
+)
+
+// flakyBackend fails its first calls with the given errors
+type flakyBackend struct {
+	*mock.Backend
+	errs  []error
+	calls int
+}
+
+func (b *flakyBackend) fail() error {
+	b.calls++
+	if len(b.errs) == 0 {
+		return nil
+	}
+	err := b.errs[0]
+	b.errs = b.errs[1:]
+	return err
+}
+
+func (b *flakyBackend) GetNotes(ctx context.Context, name string, session vaultmux.Session) (string, error) {
+	if err := b.fail(); err != nil {
+		return "", err
+	}
+	return b.Backend.GetNotes(ctx, name, session)
+}
+
+func (b *flakyBackend) CreateItem(ctx context.Context, name, content string, session vaultmux.Session) error {
+	err := b.Backend.CreateItem(ctx, name, content, session)
+	if failed := b.fail(); failed != nil {
+		return failed // the write landed, the response was lost
+	}
+	return err
+}
+
+func noRetrySleep(t *testing.T) *[]time.Duration {
+	var waits []time.Duration
+	orig := vaultRetrySleep
+	vaultRetrySleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	t.Cleanup(func() { vaultRetrySleep = orig })
+	return &waits
+}
+
+// TestRetryBackendRetriesTransient verifies a rate-limited read is retried
+// with growing waits, while a permanent error fails at once
+func TestRetryBackendRetriesTransient(t *testing.T) {
+	waits := noRetrySleep(t)
+	m := mock.New()
+	m.SetItem("Git-Config", "content")
+	rateLimited := errors.New("bw get item: 429 Too Many Requests")
+	flaky := &flakyBackend{Backend: m, errs: []error{rateLimited, rateLimited}}
+	b := &retryBackend{Backend: flaky, backendType: "bitwarden", policy: vaultRetryPolicy{retries: 3, delay: 100 * time.Millisecond}}
+
+	notes, err := b.GetNotes(context.Background(), "Git-Config", nil)
+	if err != nil || notes != "content" || flaky.calls != 3 {
+		t.Fatalf("GetNotes = %q, %v after %d calls", notes, err, flaky.calls)
+	}
+	if len(*waits) != 2 || (*waits)[0] < 50*time.Millisecond || (*waits)[0] > 100*time.Millisecond ||
+		(*waits)[1] < 100*time.Millisecond || (*waits)[1] > 200*time.Millisecond {
+		t.Errorf("waits = %v", *waits)
+	}
+
+	flaky.calls, flaky.errs = 0, []error{vaultmux.WrapError("bitwarden", "get", "Git-Config", vaultmux.ErrNotFound)}
+	if _, err := b.GetNotes(context.Background(), "Git-Config", nil); !errors.Is(err, vaultmux.ErrNotFound) || flaky.calls != 1 {
+		t.Errorf("not found: %v after %d calls", err, flaky.calls)
+	}
+}
+
+// TestRetryBackendAggregatesErrors verifies exhausted retries report every
+// distinct error and still match the last one
+func TestRetryBackendAggregatesErrors(t *testing.T) {
+	noRetrySleep(t)
+	timeout := errors.New("request timed out")
+	unavailable := vaultmux.WrapError("bitwarden", "get", "Git-Config", errors.New("503 Service Unavailable"))
+	flaky := &flakyBackend{Backend: mock.New(), errs: []error{timeout, timeout, unavailable}}
+	b := &retryBackend{Backend: flaky, backendType: "bitwarden", policy: vaultRetryPolicy{retries: 2, delay: time.Millisecond}}
+
+	_, err := b.GetNotes(context.Background(), "Git-Config", nil)
+	var retryErr *vaultRetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 3 {
+		t.Fatalf("err = %v", err)
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "after 3 attempts") || !strings.Contains(msg, "503") || strings.Count(msg, "timed out") != 1 {
+		t.Errorf("message = %q", msg)
+	}
+	var be *vaultmux.BackendError
+	if !errors.As(err, &be) || be.Item != "Git-Config" {
+		t.Errorf("should unwrap to the last attempt's BackendError")
+	}
+}
+
+// TestRetryBackendCreateLanded verifies a create whose response was lost
+// is not repeated
+func TestRetryBackendCreateLanded(t *testing.T) {
+	noRetrySleep(t)
+	flaky := &flakyBackend{Backend: mock.New(), errs: []error{errors.New("connection reset by peer")}}
+	b := &retryBackend{Backend: flaky, backendType: "bitwarden", policy: vaultRetryPolicy{retries: 3, delay: time.Millisecond}}
+
+	if err := b.CreateItem(context.Background(), "New-Item", "content", nil); err != nil || flaky.calls != 1 {
+		t.Errorf("CreateItem = %v after %d calls", err, flaky.calls)
+	}
+}
+
+// TestVaultRetryConfig verifies the config keys and that 0 turns retries off
+func TestVaultRetryConfig(t *testing.T) {
+	setScheduleEnv(t, "")
+	if p := vaultRetryConfig(); p.retries != defaultVaultRetries || p.delay != defaultVaultRetryDelay {
+		t.Errorf("defaults = %+v", p)
+	}
+	t.Setenv("BLACKDOT_VAULT_RETRIES", "0")
+	if _, ok := newRetryBackend(mock.New(), "pass").(*retryBackend); ok {
+		t.Error("vault.retries=0 should not wrap the backend")
+	}
+	t.Setenv("BLACKDOT_VAULT_RETRIES", "5")
+	t.Setenv("BLACKDOT_VAULT_RETRY_DELAY", "2s")
+	if p := vaultRetryConfig(); p.retries != 5 || p.delay != 2*time.Second {
+		t.Errorf("configured = %+v", p)
+	}
+}
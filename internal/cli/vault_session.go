@@ -0,0 +1,385 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/vault"
+	"github.com/blackwell-systems/blackdot/internal/vault/keychain"
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// Session encryption modes (vault.session_encryption)
+const (
+	sessionEncryptAuto     = "auto"     // keychain when it works, else machine
+	sessionEncryptKeychain = "keychain" // random key in the OS credential store
+	sessionEncryptMachine  = "machine"  // key derived from the machine id
+	sessionEncryptOff      = "off"      // plaintext, as before
+)
+
+// The keychain session key lives under its own service, apart from the
+// items of a keychain vault backend
+const (
+	sessionKeyService = "blackdot-session"
+	sessionKeyItem    = "Vault-Session-Key"
+)
+
+// sessionBackends are the backends that cache a session token in the
+// session file
+var sessionBackends = map[vaultmux.BackendType]bool{
+	vaultmux.BackendBitwarden:   true,
+	vaultmux.BackendOnePassword: true,
+}
+
+// readMachineID is replaced in tests
+var readMachineID = machineID
+
+// sessionEncryptionMode is vault.session_encryption, from trusted layers
+// only so a repo config cannot turn encryption off
+func sessionEncryptionMode() string {
+	val, _ := trustedConfigLookup("vault.session_encryption")
+	switch val {
+	case sessionEncryptKeychain, sessionEncryptMachine, sessionEncryptOff:
+		return val
+	case "", sessionEncryptAuto:
+	default:
+		Debug("Ignoring invalid vault.session_encryption %q", val)
+	}
+	return sessionEncryptAuto
+}
+
+// sessionKey returns the key for a source. The keychain key is created on
+// first use when create is set.
+func sessionKey(ctx context.Context, source string, create bool) ([]byte, error) {
+	switch source {
+	case sessionEncryptMachine:
+		id, err := readMachineID()
+		if err != nil {
+			return nil, err
+		}
+		name := ""
+		if u, err := user.Current(); err == nil {
+			name = u.Uid + ":" + u.Username
+		}
+		return vault.MachineSessionKey(id, name)
+	case sessionEncryptKeychain:
+		store, err := vaultmux.New(vaultmux.Config{Backend: keychain.BackendType, Prefix: sessionKeyService})
+		if err != nil {
+			return nil, err
+		}
+		if err := store.Init(ctx); err != nil {
+			return nil, err
+		}
+		encoded, err := store.GetNotes(ctx, sessionKeyItem, nil)
+		if errors.Is(err, vaultmux.ErrNotFound) && create {
+			key := make([]byte, vault.SessionKeySize)
+			if _, err := rand.Read(key); err != nil {
+				return nil, err
+			}
+			if err := store.CreateItem(ctx, sessionKeyItem, base64.StdEncoding.EncodeToString(key), nil); err != nil {
+				return nil, err
+			}
+			return key, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(encoded)
+	}
+	return nil, fmt.Errorf("unknown session key source %q", source)
+}
+
+// sessionSource picks where the key for a newly written session comes
+// from; "" means the session is stored in plaintext
+func sessionSource(ctx context.Context) string {
+	switch mode := sessionEncryptionMode(); mode {
+	case sessionEncryptOff:
+		return ""
+	case sessionEncryptKeychain, sessionEncryptMachine:
+		return mode
+	}
+	if keychain.Available(ctx) == nil {
+		return sessionEncryptKeychain
+	}
+	if _, err := readMachineID(); err == nil {
+		return sessionEncryptMachine
+	}
+	Debug("No keychain or machine id; the vault session is cached in plaintext")
+	return ""
+}
+
+// readVaultSession returns the cached session (nil when there is none)
+// and the source of the key it was sealed with ("" for plaintext)
+func readVaultSession(ctx context.Context) ([]byte, string, error) {
+	data, err := os.ReadFile(getSessionFile())
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	source, sealed := vault.SessionSource(data)
+	if !sealed {
+		return data, "", nil
+	}
+	key, err := sessionKey(ctx, source, false)
+	if err != nil {
+		return nil, source, fmt.Errorf("session key (%s): %w", source, err)
+	}
+	plaintext, err := vault.OpenSession(key, data)
+	return plaintext, source, err
+}
+
+// writeVaultSession stores a session, sealed unless encryption is off or
+// no key is available. Empty data removes the cached session.
+func writeVaultSession(ctx context.Context, plaintext []byte) (string, error) {
+	path := getSessionFile()
+	if len(plaintext) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		return "", nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+
+	data := plaintext
+	source := sessionSource(ctx)
+	if source != "" {
+		key, err := sessionKey(ctx, source, true)
+		if err == nil {
+			data, err = vault.SealSession(key, source, plaintext)
+		}
+		if err != nil {
+			Debug("Caching the vault session in plaintext: %s key: %v", source, err)
+			data, source = plaintext, ""
+		}
+	}
+	return source, writeFileAtomic(path, data, 0600)
+}
+
+// vaultSessionCopies tracks the plaintext copies this process handed to
+// vaultmux, so copies of backends that were never closed are removed at
+// exit
+var vaultSessionCopies struct {
+	mu    sync.Mutex
+	n     int
+	paths map[string]bool
+}
+
+// vaultSessionCopy is a private plaintext copy of the cached session that
+// one backend reads and writes. vaultmux only knows plain files, so the
+// sealed session is opened into the copy and changes are sealed back.
+type vaultSessionCopy struct {
+	path    string
+	initial []byte
+	mu      sync.Mutex
+}
+
+// sessionRuntimeDir holds the copies: $XDG_RUNTIME_DIR (a per-user tmpfs)
+// when there is one, otherwise the state directory
+func sessionRuntimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "blackdot")
+	}
+	return filepath.Join(paths.StateDir(), "run")
+}
+
+// openVaultSessionCopy returns the session file to give a backend of this
+// type, and a copy to sync back (nil when the backend uses the session
+// file directly: no session, or encryption off and nothing sealed)
+func openVaultSessionCopy(backendType vaultmux.BackendType) (string, *vaultSessionCopy) {
+	path := getSessionFile()
+	if !sessionBackends[backendType] {
+		return path, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	plaintext, source, err := readVaultSession(ctx)
+	if err != nil {
+		// A session that no longer opens is as good as none: the next
+		// unlock replaces it
+		Debug("Ignoring cached vault session: %v", err)
+		plaintext = nil
+	}
+	if sessionEncryptionMode() == sessionEncryptOff {
+		if source != "" && len(plaintext) > 0 {
+			// Encryption was turned off: store the session in plaintext again
+			writeVaultSession(ctx, plaintext)
+		}
+		return path, nil
+	}
+	if len(plaintext) > 0 && source == "" {
+		migrateVaultSession(ctx, plaintext)
+	}
+
+	dir := sessionRuntimeDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		// Never hand vaultmux the sealed file: it deletes what it can't parse
+		Debug("No runtime directory for the vault session: %v", err)
+		dir = filepath.Dir(path)
+	}
+	sweepVaultSessionCopies(dir)
+	vaultSessionCopies.mu.Lock()
+	vaultSessionCopies.n++
+	copyPath := filepath.Join(dir, fmt.Sprintf("vault-session-%d-%d", os.Getpid(), vaultSessionCopies.n))
+	if vaultSessionCopies.paths == nil {
+		vaultSessionCopies.paths = map[string]bool{}
+	}
+	vaultSessionCopies.paths[copyPath] = true
+	vaultSessionCopies.mu.Unlock()
+
+	c := &vaultSessionCopy{path: copyPath, initial: plaintext}
+	if len(plaintext) > 0 {
+		if err := os.WriteFile(copyPath, plaintext, 0600); err != nil {
+			Debug("Writing the vault session copy: %v", err)
+		}
+	}
+	return copyPath, c
+}
+
+// migrateVaultSession seals a plaintext session left from before
+// encryption (or from encryption being off), unless it is still off
+func migrateVaultSession(ctx context.Context, plaintext []byte) {
+	if sessionSource(ctx) == "" {
+		return
+	}
+	if source, err := writeVaultSession(ctx, plaintext); err != nil {
+		Debug("Encrypting the cached vault session: %v", err)
+	} else if source != "" {
+		Debug("Encrypted the cached vault session (%s key)", source)
+	}
+}
+
+// sync seals what the backend changed in the copy back into the session
+// file: a new session is sealed, a removed one (lock, expiry) removed
+func (c *vaultSessionCopy) sync() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	current, err := os.ReadFile(c.path)
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	if string(current) == string(c.initial) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := writeVaultSession(ctx, current); err != nil {
+		Warn("Failed to save the vault session: %v", err)
+		return
+	}
+	c.initial = current
+}
+
+// close syncs and removes the copy
+func (c *vaultSessionCopy) close() {
+	c.sync()
+	os.Remove(c.path)
+	vaultSessionCopies.mu.Lock()
+	delete(vaultSessionCopies.paths, c.path)
+	vaultSessionCopies.mu.Unlock()
+}
+
+// staleSessionCopy is the age after which a copy is assumed left behind
+// by a process that crashed; sessions themselves last 30 minutes
+const staleSessionCopy = 24 * time.Hour
+
+// sweepVaultSessionCopies removes stale copies in dir
+func sweepVaultSessionCopies(dir string) {
+	matches, _ := filepath.Glob(filepath.Join(dir, "vault-session-*"))
+	for _, path := range matches {
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleSessionCopy {
+			os.Remove(path)
+		}
+	}
+}
+
+// finishVaultSessions removes the copies of backends that were never
+// closed, once the command is done
+func finishVaultSessions() {
+	vaultSessionCopies.mu.Lock()
+	defer vaultSessionCopies.mu.Unlock()
+	for path := range vaultSessionCopies.paths {
+		os.Remove(path)
+	}
+	vaultSessionCopies.paths = nil
+}
+
+// sessionBackend keeps the sealed session in step with the backend's copy:
+// after authenticating, when vaultmux caches a new session, and on Close
+type sessionBackend struct {
+	vaultmux.Backend
+	copy *vaultSessionCopy
+}
+
+func (b *sessionBackend) Authenticate(ctx context.Context) (vaultmux.Session, error) {
+	session, err := b.Backend.Authenticate(ctx)
+	b.copy.sync()
+	return session, err
+}
+
+func (b *sessionBackend) Close() error {
+	err := b.Backend.Close()
+	b.copy.close()
+	return err
+}
+
+// describeSessionStorage says how the cached session is stored
+func describeSessionStorage() string {
+	data, err := os.ReadFile(getSessionFile())
+	if err != nil {
+		return "none"
+	}
+	if source, sealed := vault.SessionSource(data); sealed {
+		return "encrypted, " + source + " key"
+	}
+	return "plaintext"
+}
+
+// machineID reads the operating system's stable machine identifier
+func machineID() (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+		if err != nil {
+			return "", err
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if key, value, ok := strings.Cut(line, "="); ok && strings.Contains(key, "IOPlatformUUID") {
+				return strings.Trim(strings.TrimSpace(value), `"`), nil
+			}
+		}
+	case "windows":
+		out, err := exec.Command("reg", "query", `HKLM\SOFTWARE\Microsoft\Cryptography`, "/v", "MachineGuid").Output()
+		if err != nil {
+			return "", err
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if fields := strings.Fields(line); len(fields) == 3 && fields[0] == "MachineGuid" {
+				return fields[2], nil
+			}
+		}
+	default:
+		for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+			if data, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(data)) != "" {
+				return strings.TrimSpace(string(data)), nil
+			}
+		}
+	}
+	return "", errors.New("no machine id found")
+}
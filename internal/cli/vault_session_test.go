@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/vault"
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// setSessionEnv uses the machine key with a fixed machine id
+func setSessionEnv(t *testing.T, mode string) {
+	t.Helper()
+	setScheduleEnv(t, "")
+	home := os.Getenv("HOME")
+	t.Setenv("BLACKDOT_DIR", filepath.Join(home, ".blackdot"))
+	// The session file lives under blackdotDir, which is unset in tests
+	saved := blackdotDir
+	blackdotDir = filepath.Join(home, ".blackdot")
+	t.Cleanup(func() { blackdotDir = saved })
+	t.Setenv("VAULT_SESSION_FILE", "")
+	t.Setenv("XDG_RUNTIME_DIR", filepath.Join(home, "run"))
+	t.Setenv("BLACKDOT_VAULT_SESSION_ENCRYPTION", mode)
+	orig := readMachineID
+	readMachineID = func() (string, error) { return "test-machine", nil }
+	t.Cleanup(func() { readMachineID = orig })
+}
+
+// TestVaultSessionCopy verifies a plaintext session is migrated, handed to
+// the backend as a private copy, and sealed back when the copy changes
+func TestVaultSessionCopy(t *testing.T) {
+	setSessionEnv(t, sessionEncryptMachine)
+	legacy := `{"token": "old-token", "backend": "bitwarden"}`
+	os.MkdirAll(filepath.Dir(getSessionFile()), 0700)
+	os.WriteFile(getSessionFile(), []byte(legacy), 0600)
+
+	path, c := openVaultSessionCopy(vaultmux.BackendBitwarden)
+	if c == nil || path == getSessionFile() {
+		t.Fatalf("backend given the session file itself: %s", path)
+	}
+	if data, _ := os.ReadFile(path); string(data) != legacy {
+		t.Errorf("copy = %q", data)
+	}
+	stored, _ := os.ReadFile(getSessionFile())
+	if source, sealed := vault.SessionSource(stored); !sealed || source != sessionEncryptMachine || strings.Contains(string(stored), "old-token") {
+		t.Fatalf("plaintext session not migrated:\n%s", stored)
+	}
+
+	// vaultmux caches a new session in the copy
+	os.WriteFile(path, []byte(`{"token": "new-token"}`), 0600)
+	c.close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("copy left behind after close")
+	}
+	plaintext, source, err := readVaultSession(t.Context())
+	if err != nil || source != sessionEncryptMachine || string(plaintext) != `{"token": "new-token"}` {
+		t.Errorf("session = %q (%s), %v", plaintext, source, err)
+	}
+
+	// vaultmux removes an expired session from the copy
+	path, c = openVaultSessionCopy(vaultmux.BackendBitwarden)
+	os.Remove(path)
+	c.close()
+	if _, err := os.Stat(getSessionFile()); !os.IsNotExist(err) {
+		t.Error("expired session not removed")
+	}
+
+	// Backends without sessions read the file directly
+	if path, c := openVaultSessionCopy(vaultmux.BackendPass); c != nil || path != getSessionFile() {
+		t.Errorf("pass backend got a copy: %s", path)
+	}
+}
+
+// TestVaultSessionEncryptionOff verifies turning encryption off stores a
+// sealed session in plaintext again
+func TestVaultSessionEncryptionOff(t *testing.T) {
+	setSessionEnv(t, sessionEncryptMachine)
+	if source, err := writeVaultSession(t.Context(), []byte("token")); err != nil || source != sessionEncryptMachine {
+		t.Fatalf("write: %s, %v", source, err)
+	}
+
+	t.Setenv("BLACKDOT_VAULT_SESSION_ENCRYPTION", sessionEncryptOff)
+	path, c := openVaultSessionCopy(vaultmux.BackendOnePassword)
+	if c != nil || path != getSessionFile() {
+		t.Fatalf("copy made with encryption off: %s", path)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "token" {
+		t.Errorf("session file = %q", data)
+	}
+}
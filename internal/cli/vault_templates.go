@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// VaultItemTemplate describes the structure of a vault note. Body contains
+// {{field}} placeholders; {{item}} expands to the item name.
+type VaultItemTemplate struct {
+	Description string               `json:"description,omitempty"`
+	Fields      []VaultTemplateField `json:"fields"`
+	Body        string               `json:"body"`
+}
+
+// VaultTemplateField is one placeholder prompted for during create
+type VaultTemplateField struct {
+	Name     string `json:"name"`
+	Prompt   string `json:"prompt,omitempty"`
+	Default  string `json:"default,omitempty"`
+	Secret   bool   `json:"secret,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+var vaultTemplatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// builtinVaultTemplates are available without any configuration.
+// Templates in vault-items.json with the same name replace them.
+var builtinVaultTemplates = map[string]VaultItemTemplate{
+	"api-key": {
+		Description: "API key with endpoint and notes",
+		Fields: []VaultTemplateField{
+			{Name: "api_key", Prompt: "API key", Secret: true},
+			{Name: "endpoint", Prompt: "Endpoint URL"},
+			{Name: "notes", Prompt: "Notes", Optional: true},
+		},
+		Body: "# {{item}}\nAPI_KEY={{api_key}}\nENDPOINT={{endpoint}}\nNOTES={{notes}}\n",
+	},
+	"database": {
+		Description: "Database connection credentials",
+		Fields: []VaultTemplateField{
+			{Name: "host", Prompt: "Host"},
+			{Name: "port", Prompt: "Port", Default: "5432"},
+			{Name: "database", Prompt: "Database name"},
+			{Name: "username", Prompt: "Username"},
+			{Name: "password", Prompt: "Password", Secret: true},
+		},
+		Body: "# {{item}}\nDB_HOST={{host}}\nDB_PORT={{port}}\nDB_NAME={{database}}\nDB_USER={{username}}\nDB_PASSWORD={{password}}\n",
+	},
+	"oauth-client": {
+		Description: "OAuth client ID and secret",
+		Fields: []VaultTemplateField{
+			{Name: "client_id", Prompt: "Client ID"},
+			{Name: "client_secret", Prompt: "Client secret", Secret: true},
+			{Name: "redirect_uri", Prompt: "Redirect URI", Optional: true},
+		},
+		Body: "# {{item}}\nCLIENT_ID={{client_id}}\nCLIENT_SECRET={{client_secret}}\nREDIRECT_URI={{redirect_uri}}\n",
+	},
+}
+
+// loadVaultTemplates returns the built-in templates merged with the
+// item_templates section of vault-items.json
+func loadVaultTemplates() (map[string]VaultItemTemplate, error) {
+	templates := make(map[string]VaultItemTemplate, len(builtinVaultTemplates))
+	for name, t := range builtinVaultTemplates {
+		templates[name] = t
+	}
+
+	data, err := os.ReadFile(filepath.Join(ConfigDir(), "vault-items.json"))
+	if os.IsNotExist(err) {
+		return templates, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var config struct {
+		ItemTemplates map[string]VaultItemTemplate `json:"item_templates"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing vault-items.json: %w", err)
+	}
+	for name, t := range config.ItemTemplates {
+		if err := validateVaultTemplate(t); err != nil {
+			return nil, fmt.Errorf("item_templates.%s: %w", name, err)
+		}
+		templates[name] = t
+	}
+	return templates, nil
+}
+
+// validateVaultTemplate checks every placeholder in the body has a field
+func validateVaultTemplate(t VaultItemTemplate) error {
+	if t.Body == "" {
+		return fmt.Errorf("body is empty")
+	}
+	fields := map[string]bool{"item": true}
+	for _, f := range t.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("field without a name")
+		}
+		fields[f.Name] = true
+	}
+	for _, m := range vaultTemplatePlaceholder.FindAllStringSubmatch(t.Body, -1) {
+		if !fields[m[1]] {
+			return fmt.Errorf("body uses {{%s}} but no field defines it", m[1])
+		}
+	}
+	return nil
+}
+
+// renderVaultTemplate fills the body with values. Placeholders of secret
+// fields are replaced with mask instead when mask is non-empty.
+func renderVaultTemplate(t VaultItemTemplate, item string, values map[string]string, mask string) string {
+	secret := map[string]bool{}
+	for _, f := range t.Fields {
+		secret[f.Name] = f.Secret
+	}
+	return vaultTemplatePlaceholder.ReplaceAllStringFunc(t.Body, func(m string) string {
+		name := vaultTemplatePlaceholder.FindStringSubmatch(m)[1]
+		if name == "item" {
+			return item
+		}
+		if mask != "" && secret[name] && values[name] != "" {
+			return mask
+		}
+		return values[name]
+	})
+}
+
+// collectVaultTemplateValues resolves each field from --set values, then an
+// interactive prompt, then the field default. Without a terminal, missing
+// required values are an error.
+func collectVaultTemplateValues(t VaultItemTemplate, set map[string]string, in io.Reader, interactive bool) (map[string]string, error) {
+	values := make(map[string]string, len(t.Fields))
+	reader := bufio.NewReader(in)
+
+	for _, f := range t.Fields {
+		if v, ok := set[f.Name]; ok {
+			values[f.Name] = v
+			continue
+		}
+
+		if interactive {
+			prompt := f.Prompt
+			if prompt == "" {
+				prompt = f.Name
+			}
+			if f.Default != "" {
+				prompt += fmt.Sprintf(" [%s]", f.Default)
+			} else if f.Optional {
+				prompt += " (optional)"
+			}
+			fmt.Fprintf(os.Stderr, "%s: ", prompt)
+
+			var v string
+			if f.Secret {
+				v = readHiddenLine(reader)
+				fmt.Fprintln(os.Stderr)
+			} else {
+				line, _ := reader.ReadString('\n')
+				v = strings.TrimSpace(line)
+			}
+			values[f.Name] = v
+		}
+
+		if values[f.Name] == "" {
+			values[f.Name] = f.Default
+		}
+		if values[f.Name] == "" && !f.Optional {
+			if interactive {
+				return nil, fmt.Errorf("%s is required", f.Name)
+			}
+			return nil, fmt.Errorf("%s is required (use --set %s=VALUE)", f.Name, f.Name)
+		}
+	}
+
+	for name := range set {
+		found := false
+		for _, f := range t.Fields {
+			if f.Name == name {
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("template has no field %q", name)
+		}
+	}
+
+	return values, nil
+}
+
+// readHiddenLine reads a line with terminal echo disabled where supported
+func readHiddenLine(reader *bufio.Reader) string {
+	if runtime.GOOS != "windows" {
+		off := exec.Command("stty", "-echo")
+		off.Stdin = os.Stdin
+		if off.Run() == nil {
+			defer func() {
+				on := exec.Command("stty", "echo")
+				on.Stdin = os.Stdin
+				on.Run()
+			}()
+		}
+	}
+	line, _ := reader.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// parseTemplateSets parses repeated --set key=value flags
+func parseTemplateSets(sets []string) (map[string]string, error) {
+	values := make(map[string]string, len(sets))
+	for _, s := range sets {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --set %q (expected key=value)", s)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// vaultCreateFromTemplate renders a template and creates the item
+func vaultCreateFromTemplate(templateName, item string, sets []string, dryRun, force bool) error {
+	templates, err := loadVaultTemplates()
+	if err != nil {
+		return err
+	}
+	t, ok := templates[templateName]
+	if !ok {
+		Fail("Unknown template '%s'", templateName)
+		fmt.Println("List templates with: blackdot vault templates")
+		return fmt.Errorf("unknown template: %s", templateName)
+	}
+
+	set, err := parseTemplateSets(sets)
+	if err != nil {
+		return err
+	}
+	stat, _ := os.Stdin.Stat()
+	interactive := stat != nil && stat.Mode()&os.ModeCharDevice != 0
+
+	values, err := collectVaultTemplateValues(t, set, os.Stdin, interactive)
+	if err != nil {
+		Fail("%v", err)
+		return err
+	}
+
+	if dryRun {
+		// Never print secret values, even in a preview
+		return vaultCreate(item, renderVaultTemplate(t, item, values, "********"), true, force)
+	}
+	return vaultCreate(item, renderVaultTemplate(t, item, values, ""), false, force)
+}
+
+func newVaultTemplatesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "templates [name]",
+		Short: "List item templates for 'vault create --template'",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			templates, err := loadVaultTemplates()
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				t, ok := templates[args[0]]
+				if !ok {
+					return fmt.Errorf("unknown template: %s", args[0])
+				}
+				PrintHeader("Template: " + args[0])
+				if t.Description != "" {
+					fmt.Println(t.Description)
+					fmt.Println()
+				}
+				for _, f := range t.Fields {
+					var tags []string
+					if f.Secret {
+						tags = append(tags, "secret")
+					}
+					if f.Optional {
+						tags = append(tags, "optional")
+					}
+					if f.Default != "" {
+						tags = append(tags, "default: "+f.Default)
+					}
+					suffix := ""
+					if len(tags) > 0 {
+						suffix = Dim.Sprint(" (" + strings.Join(tags, ", ") + ")")
+					}
+					fmt.Printf("  %-16s %s%s\n", f.Name, f.Prompt, suffix)
+				}
+				fmt.Println()
+				fmt.Println("Body:")
+				fmt.Println(Dim.Sprint(t.Body))
+				return nil
+			}
+
+			names := make([]string, 0, len(templates))
+			for name := range templates {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			PrintHeader("Vault Item Templates")
+			for _, name := range names {
+				origin := ""
+				if _, builtin := builtinVaultTemplates[name]; !builtin {
+					origin = Dim.Sprint(" (vault-items.json)")
+				}
+				fmt.Printf("  %-16s %s%s\n", name, templates[name].Description, origin)
+			}
+			fmt.Println()
+			fmt.Println("Create with: blackdot vault create --template <name> <item-name>")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+)
+
+// TestLoadVaultTemplates verifies built-ins merge with vault-items.json
+func TestLoadVaultTemplates(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "blackdot")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Dir(configDir))
+	t.Setenv("BLACKDOT_PATHS_STRATEGY", "")
+	paths.ResetStrategy()
+	t.Cleanup(paths.ResetStrategy)
+
+	templates, err := loadVaultTemplates()
+	if err != nil {
+		t.Fatalf("without vault-items.json: %v", err)
+	}
+	if _, ok := templates["api-key"]; !ok {
+		t.Error("built-in api-key template missing")
+	}
+
+	os.MkdirAll(configDir, 0700)
+	os.WriteFile(filepath.Join(configDir, "vault-items.json"), []byte(`{
+  "item_templates": {
+    "api-key": {"fields": [{"name": "key"}], "body": "KEY={{key}}"},
+    "webhook": {"fields": [{"name": "url"}], "body": "URL={{url}}"}
+  }
+}`), 0600)
+	templates, err = loadVaultTemplates()
+	if err != nil {
+		t.Fatalf("loadVaultTemplates: %v", err)
+	}
+	if templates["api-key"].Body != "KEY={{key}}" {
+		t.Error("vault-items.json should override the built-in template")
+	}
+	if _, ok := templates["webhook"]; !ok {
+		t.Error("custom template missing")
+	}
+
+	os.WriteFile(filepath.Join(configDir, "vault-items.json"), []byte(`{
+  "item_templates": {"bad": {"fields": [], "body": "X={{undefined}}"}}
+}`), 0600)
+	if _, err := loadVaultTemplates(); err == nil || !strings.Contains(err.Error(), "undefined") {
+		t.Errorf("expected undefined placeholder error, got %v", err)
+	}
+}
+
+// TestVaultTemplateValuesAndRender verifies prompts, defaults, and masking
+func TestVaultTemplateValuesAndRender(t *testing.T) {
+	tmpl := builtinVaultTemplates["database"]
+
+	// Non-interactive: --set values plus defaults
+	set := map[string]string{"host": "db", "database": "app", "username": "u", "password": "p4ss"}
+	values, err := collectVaultTemplateValues(tmpl, set, strings.NewReader(""), false)
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	if values["port"] != "5432" {
+		t.Errorf("port default not applied: %q", values["port"])
+	}
+
+	body := renderVaultTemplate(tmpl, "Prod-DB", values, "")
+	for _, want := range []string{"# Prod-DB", "DB_HOST=db", "DB_PORT=5432", "DB_PASSWORD=p4ss"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("rendered body missing %q:\n%s", want, body)
+		}
+	}
+	if masked := renderVaultTemplate(tmpl, "Prod-DB", values, "***"); strings.Contains(masked, "p4ss") {
+		t.Errorf("secret not masked:\n%s", masked)
+	}
+
+	// Missing required value without a terminal
+	if _, err := collectVaultTemplateValues(tmpl, map[string]string{"host": "db"}, strings.NewReader(""), false); err == nil {
+		t.Error("expected error for missing required field")
+	}
+	// Unknown --set key
+	set["nope"] = "x"
+	if _, err := collectVaultTemplateValues(tmpl, set, strings.NewReader(""), false); err == nil {
+		t.Error("expected error for unknown field")
+	}
+
+	// Interactive: answers in field order, blank takes the default
+	api := builtinVaultTemplates["api-key"]
+	values, err = collectVaultTemplateValues(api, nil, strings.NewReader("sk-1\nhttps://api.example.com\n\n"), true)
+	if err != nil {
+		t.Fatalf("interactive collect: %v", err)
+	}
+	if values["api_key"] != "sk-1" || values["endpoint"] != "https://api.example.com" || values["notes"] != "" {
+		t.Errorf("values = %v", values)
+	}
+
+	if _, err := parseTemplateSets([]string{"novalue"}); err == nil {
+		t.Error("expected error for --set without =")
+	}
+}
@@ -1,10 +1,16 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/blackwell-systems/vaultmux/mock"
 )
 
 // TestVaultCommandExists verifies vault command is registered
@@ -236,6 +242,12 @@ func TestVaultPushFlags(t *testing.T) {
 	if flag == nil {
 		t.Error("push command should have --dry-run flag")
 	}
+
+	// Check for --report flag
+	flag = cmd.Flags().Lookup("report")
+	if flag == nil {
+		t.Error("push command should have --report flag")
+	}
 }
 
 // TestVaultRestoreFlags verifies restore command has expected flags
@@ -253,6 +265,18 @@ func TestVaultRestoreFlags(t *testing.T) {
 	if flag == nil {
 		t.Error("restore command should have --dry-run flag")
 	}
+
+	// Check for --report flag
+	flag = cmd.Flags().Lookup("report")
+	if flag == nil {
+		t.Error("restore command should have --report flag")
+	}
+
+	// Check for --concurrency flag
+	flag = cmd.Flags().Lookup("concurrency")
+	if flag == nil || flag.Shorthand != "j" {
+		t.Error("restore command should have --concurrency/-j flag")
+	}
 }
 
 // TestVaultDeleteFlags verifies delete command has expected flags
@@ -333,3 +357,215 @@ func TestNewVaultBackendError(t *testing.T) {
 		t.Logf("Got expected error: %v", err)
 	}
 }
+
+// TestResolveVaultReportFormat verifies format selection from flag and extension
+func TestResolveVaultReportFormat(t *testing.T) {
+	tests := []struct {
+		dest    string
+		format  string
+		wantErr bool
+	}{
+		{"-", "", false},
+		{"report.json", "", false},
+		{"report.md", "", false},
+		{"report.txt", "markdown", false},
+		{"report.json", "yaml", true},
+	}
+
+	for _, tt := range tests {
+		_, err := resolveVaultReportFormat(tt.dest, tt.format)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("resolveVaultReportFormat(%q, %q) error = %v, wantErr %v", tt.dest, tt.format, err, tt.wantErr)
+		}
+	}
+}
+
+// TestWriteVaultReport verifies JSON and markdown reports are written to disk
+func TestWriteVaultReport(t *testing.T) {
+	dir := t.TempDir()
+
+	report := newVaultReport("restore", "pass", false)
+	report.add("SSH-GitHub", "~/.ssh/id_ed25519", reportStatusRestored, "")
+	report.add("AWS-Config", "~/.aws/config", reportStatusFailed, "not found in vault (required)")
+	report.finish(nil)
+
+	if report.Summary[reportStatusRestored] != 1 || report.Summary[reportStatusFailed] != 1 {
+		t.Errorf("unexpected summary: %v", report.Summary)
+	}
+	if report.Items[0].Name != "AWS-Config" {
+		t.Errorf("expected items sorted by name, got %s first", report.Items[0].Name)
+	}
+
+	jsonPath := filepath.Join(dir, "report.json")
+	if err := writeVaultReport(report, jsonPath, ""); err != nil {
+		t.Fatalf("writeVaultReport(json) failed: %v", err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"operation": "restore"`) {
+		t.Errorf("JSON report missing operation: %s", data)
+	}
+
+	mdPath := filepath.Join(dir, "report.md")
+	if err := writeVaultReport(report, mdPath, ""); err != nil {
+		t.Fatalf("writeVaultReport(markdown) failed: %v", err)
+	}
+	data, err = os.ReadFile(mdPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# Vault Restore Report") {
+		t.Errorf("markdown report missing title: %s", data)
+	}
+}
+
+// TestResolveRestoreConflicts verifies per-item answers map to resolutions
+func TestResolveRestoreConflicts(t *testing.T) {
+	conflicts := []restoreConflict{
+		{Name: "Git-Config", Path: "/tmp/gitconfig"},
+		{Name: "SSH-Config", Path: "/tmp/ssh-config"},
+		{Name: "AWS-Config", Path: "/tmp/aws-config"},
+	}
+
+	var out strings.Builder
+	// Unknown answer prints help and re-prompts
+	resolutions, err := resolveRestoreConflicts(conflicts, strings.NewReader("x\nk\ns\nt\n"), &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]restoreResolution{
+		"Git-Config": resolveKeepLocal,
+		"SSH-Config": resolveSkip,
+		"AWS-Config": resolveTakeVault,
+	}
+	for name, want := range expected {
+		if resolutions[name] != want {
+			t.Errorf("%s: expected resolution %d, got %d", name, want, resolutions[name])
+		}
+	}
+	if !strings.Contains(out.String(), "take vault version") {
+		t.Error("expected help text after unknown answer")
+	}
+
+	// "a" takes the vault version for all remaining items
+	resolutions, err = resolveRestoreConflicts(conflicts, strings.NewReader("s\na\n"), &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolutions["Git-Config"] != resolveSkip || resolutions["AWS-Config"] != resolveTakeVault {
+		t.Errorf("unexpected resolutions after 'a': %v", resolutions)
+	}
+
+	// Quit and EOF both abort
+	for _, input := range []string{"q\n", ""} {
+		if _, err := resolveRestoreConflicts(conflicts, strings.NewReader(input), &out); err != errRestoreAborted {
+			t.Errorf("input %q: expected errRestoreAborted, got %v", input, err)
+		}
+	}
+}
+
+// TestFuzzyMatchItems verifies did-you-mean ranking for vault item names
+func TestFuzzyMatchItems(t *testing.T) {
+	names := []string{"SSH-GitHub", "SSH-GitLab", "AWS-Config", "Git-Config", "SSH-Config"}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"SSH-Githbu", []string{"SSH-GitHub", "SSH-GitLab"}}, // transposition ranks first
+		{"ssh_github", []string{"SSH-GitHub", "SSH-GitLab"}}, // case and separators
+		{"aws", []string{"AWS-Config"}},                      // substring
+		{"config", []string{"AWS-Config", "Git-Config", "SSH-Config"}},
+		{"completely-different", nil},
+	}
+
+	for _, tt := range tests {
+		got := fuzzyMatchItems(tt.query, names)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("fuzzyMatchItems(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+// TestSingleCloseMatch verifies when --fuzzy may auto-select a match
+func TestSingleCloseMatch(t *testing.T) {
+	if m, ok := singleCloseMatch("x", []string{"only"}); !ok || m != "only" {
+		t.Errorf("single match should be selected, got %q %v", m, ok)
+	}
+	if _, ok := singleCloseMatch("ssh", []string{"SSH-GitHub", "SSH-GitLab"}); ok {
+		t.Error("ambiguous matches should not be selected")
+	}
+	if m, ok := singleCloseMatch("SSH-Githbu", []string{"SSH-GitHub", "SSH-GitLab"}); !ok || m != "SSH-GitHub" {
+		t.Errorf("strictly closest match should be selected, got %q %v", m, ok)
+	}
+}
+
+// TestVaultIndexRoundTrip verifies the item index is cached per backend
+func TestVaultIndexRoundTrip(t *testing.T) {
+	origCache := os.Getenv("XDG_CACHE_HOME")
+	origBackend := os.Getenv("BLACKDOT_VAULT_BACKEND")
+	defer os.Setenv("XDG_CACHE_HOME", origCache)
+	defer os.Setenv("BLACKDOT_VAULT_BACKEND", origBackend)
+
+	os.Setenv("XDG_CACHE_HOME", t.TempDir())
+	os.Setenv("BLACKDOT_VAULT_BACKEND", "pass")
+
+	if loadVaultIndex() != nil {
+		t.Fatal("expected no index before saving")
+	}
+	items := []*vaultmux.Item{{Name: "b"}, {Name: "a"}}
+	if err := saveVaultIndex(items); err != nil {
+		t.Fatalf("saveVaultIndex: %v", err)
+	}
+
+	index := loadVaultIndex()
+	if index == nil || strings.Join(index.Names, ",") != "a,b" {
+		t.Fatalf("loaded index = %+v", index)
+	}
+	if index.stale() {
+		t.Error("fresh index should not be stale")
+	}
+	if got := completeVaultItems("B"); len(got) != 1 || got[0] != "b" {
+		t.Errorf("completeVaultItems(B) = %v", got)
+	}
+
+	os.Setenv("BLACKDOT_VAULT_BACKEND", "bitwarden")
+	if loadVaultIndex() != nil {
+		t.Error("index for another backend should be ignored")
+	}
+}
+
+// TestFetchVaultNotes verifies every item gets a result under concurrency
+func TestFetchVaultNotes(t *testing.T) {
+	backend := mock.New()
+	var names []string
+	for i := 0; i < 12; i++ {
+		name := fmt.Sprintf("Item-%02d", i)
+		backend.SetItem(name, "content "+name)
+		names = append(names, name)
+	}
+	names = append(names, "Missing")
+	reader := newVaultReader([]vaultmux.BackendType{"mock"}, func(ctx context.Context, _ vaultmux.BackendType) (vaultmux.Backend, vaultmux.Session, error) {
+		session, err := backend.Authenticate(ctx)
+		return backend, session, err
+	})
+
+	progress := newProgressBar("Fetching", len(names))
+	progress.enabled = false
+	results := fetchVaultNotes(reader, names, 4, progress)
+
+	if len(results) != len(names) {
+		t.Fatalf("got %d results for %d names", len(results), len(names))
+	}
+	if r := results["Item-07"]; r.Err != nil || r.Notes != "content Item-07" {
+		t.Errorf("Item-07 = %+v", r)
+	}
+	if r := results["Missing"]; !errors.Is(r.Err, vaultmux.ErrNotFound) {
+		t.Errorf("Missing err = %v", r.Err)
+	}
+	if progress.done != len(names) {
+		t.Errorf("progress done = %d", progress.done)
+	}
+}
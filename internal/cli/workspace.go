@@ -0,0 +1,399 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/spf13/cobra"
+)
+
+// Workspace symlink health states
+const (
+	workspaceOK          = "ok"
+	workspaceMissing     = "missing"      // no symlink at all
+	workspaceWrongTarget = "wrong-target" // symlink points elsewhere
+	workspaceDangling    = "dangling"     // symlink target does not exist
+	workspaceNotSymlink  = "not-symlink"  // a real file or directory is in the way
+)
+
+// Repair policies (workspace.repair config key)
+const (
+	workspaceRepairCached = "cached" // repair only with cached sudo credentials (default)
+	workspaceRepairPrompt = "prompt" // allow an interactive sudo prompt
+	workspaceRepairOff    = "off"    // never repair, only report
+)
+
+// workspaceHealth is the result of checking the workspace symlink
+type workspaceHealth struct {
+	Link     string `json:"link"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual,omitempty"`
+	Status   string `json:"status"`
+}
+
+// workspaceIncident is one line of the incident log
+type workspaceIncident struct {
+	Time     string `json:"time"`
+	Status   string `json:"status"`
+	Link     string `json:"link"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual,omitempty"`
+	Repaired bool   `json:"repaired"`
+	Error    string `json:"error,omitempty"`
+	Source   string `json:"source,omitempty"`
+}
+
+func newWorkspaceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workspace",
+		Short: "Check and repair the /workspace symlink",
+		Long: `Check and repair the /workspace symlink used for portable Claude Code sessions.
+
+The symlink must resolve and point to the configured workspace target
+(WORKSPACE_TARGET, then paths.workspace_target, then ~/workspace). OS updates
+can remove it, which breaks Claude Code sessions silently.
+
+The zsh integration runs 'workspace check --repair --quiet' at startup when
+/workspace is missing or dangling. Repairs follow workspace.repair:
+  cached  repair only when sudo credentials are cached (default)
+  prompt  allow an interactive sudo prompt
+  off     report only
+
+Every problem found is appended to the incident log.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWorkspaceCheck(false, false, "cli")
+		},
+	}
+
+	cmd.AddCommand(newWorkspaceCheckCmd(), newWorkspaceIncidentsCmd())
+	return cmd
+}
+
+func newWorkspaceCheckCmd() *cobra.Command {
+	var repair, quiet bool
+	var source string
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Verify the symlink and optionally repair it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWorkspaceCheck(repair, quiet, source)
+		},
+	}
+
+	cmd.Flags().BoolVar(&repair, "repair", false, "Repair according to workspace.repair")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Print nothing unless the symlink is still broken")
+	cmd.Flags().StringVar(&source, "source", "cli", "Recorded in the incident log (e.g. shell, doctor)")
+	cmd.Flags().MarkHidden("source")
+
+	return cmd
+}
+
+func newWorkspaceIncidentsCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "incidents",
+		Short: "Show logged workspace symlink incidents",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return showWorkspaceIncidents(limit)
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Number of incidents to show")
+	return cmd
+}
+
+// workspaceTarget returns the configured workspace directory
+func workspaceTarget() string {
+	target := os.Getenv("WORKSPACE_TARGET")
+	if target == "" {
+		target = configLookup("paths.workspace_target")
+	}
+	if target == "" {
+		return defaultWorkspaceDir()
+	}
+	if strings.HasPrefix(target, "~") {
+		home, _ := os.UserHomeDir()
+		target = filepath.Join(home, target[1:])
+	}
+	return filepath.Clean(target)
+}
+
+// workspaceRepairPolicy returns the workspace.repair setting
+func workspaceRepairPolicy() string {
+	switch policy := configLookup("workspace.repair"); policy {
+	case workspaceRepairPrompt, workspaceRepairOff:
+		return policy
+	default:
+		return workspaceRepairCached
+	}
+}
+
+// checkWorkspaceSymlink inspects link against the expected target
+func checkWorkspaceSymlink(link, expected string) workspaceHealth {
+	h := workspaceHealth{Link: link, Expected: expected}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		h.Status = workspaceMissing
+		return h
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		// Windows junctions report as directories; accept one that resolves
+		// to the expected target
+		if resolved, err := filepath.EvalSymlinks(link); err == nil && resolved != link {
+			h.Actual = resolved
+			if sameWorkspacePath(resolved, expected) {
+				h.Status = workspaceOK
+			} else {
+				h.Status = workspaceWrongTarget
+			}
+			return h
+		}
+		h.Status = workspaceNotSymlink
+		return h
+	}
+
+	h.Actual, _ = os.Readlink(link)
+	if !sameWorkspacePath(h.Actual, expected) {
+		h.Status = workspaceWrongTarget
+		return h
+	}
+	if _, err := os.Stat(link); err != nil {
+		h.Status = workspaceDangling
+		return h
+	}
+	h.Status = workspaceOK
+	return h
+}
+
+// sameWorkspacePath compares paths after cleaning and resolving symlinks
+func sameWorkspacePath(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	ra, errA := filepath.EvalSymlinks(a)
+	rb, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && ra == rb
+}
+
+// describe returns a one-line explanation of the health state
+func (h workspaceHealth) describe() string {
+	switch h.Status {
+	case workspaceOK:
+		return fmt.Sprintf("%s → %s", h.Link, h.Expected)
+	case workspaceMissing:
+		return fmt.Sprintf("%s is missing (expected → %s)", h.Link, h.Expected)
+	case workspaceWrongTarget:
+		return fmt.Sprintf("%s → %s (expected → %s)", h.Link, h.Actual, h.Expected)
+	case workspaceDangling:
+		return fmt.Sprintf("%s → %s, which does not exist", h.Link, h.Actual)
+	case workspaceNotSymlink:
+		return fmt.Sprintf("%s exists but is not a symlink", h.Link)
+	}
+	return h.Status
+}
+
+// repairWorkspaceSymlink recreates the symlink. Real files or directories
+// in the way are never touched, and a missing target is not created.
+func repairWorkspaceSymlink(h workspaceHealth, policy string) error {
+	switch h.Status {
+	case workspaceOK:
+		return nil
+	case workspaceNotSymlink:
+		return fmt.Errorf("%s is not a symlink; move it aside manually", h.Link)
+	}
+	if policy == workspaceRepairOff {
+		return fmt.Errorf("repair disabled (workspace.repair=off)")
+	}
+	if _, err := os.Stat(h.Expected); err != nil {
+		return fmt.Errorf("workspace target %s does not exist", h.Expected)
+	}
+
+	if isWindows() {
+		return createWorkspaceSymlink(h.Link, h.Expected)
+	}
+
+	// Try without elevation first (e.g. a writable parent directory)
+	if err := replaceSymlink(h.Link, h.Expected); err == nil {
+		return nil
+	}
+
+	if _, err := exec.LookPath("sudo"); err != nil {
+		return fmt.Errorf("sudo not available")
+	}
+	if policy == workspaceRepairCached {
+		// -n fails instead of prompting when credentials aren't cached
+		if err := exec.Command("sudo", "-n", "true").Run(); err != nil {
+			return fmt.Errorf("no cached sudo credentials (run: blackdot workspace check --repair)")
+		}
+		return exec.Command("sudo", "-n", "ln", "-sfn", h.Expected, h.Link).Run()
+	}
+	return createWorkspaceSymlink(h.Link, h.Expected)
+}
+
+// replaceSymlink atomically points link at target without elevation
+func replaceSymlink(link, target string) error {
+	tmp := link + ".blackdot-tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// getWorkspaceIncidentLog returns the incident log path
+func getWorkspaceIncidentLog() string {
+	return filepath.Join(paths.StateDir(), "workspace-incidents.jsonl")
+}
+
+// logWorkspaceIncident appends an incident to the log
+func logWorkspaceIncident(h workspaceHealth, repaired bool, repairErr error, source string) error {
+	incident := workspaceIncident{
+		Time:     time.Now().UTC().Format(time.RFC3339),
+		Status:   h.Status,
+		Link:     h.Link,
+		Expected: h.Expected,
+		Actual:   h.Actual,
+		Repaired: repaired,
+		Source:   source,
+	}
+	if repairErr != nil {
+		incident.Error = repairErr.Error()
+	}
+	data, err := json.Marshal(incident)
+	if err != nil {
+		return err
+	}
+	return appendFileWithPolicy(getWorkspaceIncidentLog(), append(data, '\n'), fileClassPrivate)
+}
+
+// loadWorkspaceIncidents reads the incident log, oldest first
+func loadWorkspaceIncidents() ([]workspaceIncident, error) {
+	f, err := os.Open(getWorkspaceIncidentLog())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var incidents []workspaceIncident
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var inc workspaceIncident
+		if json.Unmarshal(scanner.Bytes(), &inc) == nil {
+			incidents = append(incidents, inc)
+		}
+	}
+	return incidents, scanner.Err()
+}
+
+// workspaceWatchdog checks the symlink, repairs it when asked and allowed,
+// and logs any problem. The returned health reflects the state afterwards.
+func workspaceWatchdog(repair bool, policy, source string) (before, after workspaceHealth, repairErr error) {
+	before = checkWorkspaceSymlink(workspaceSymlinkPath(), workspaceTarget())
+	after = before
+	if before.Status == workspaceOK {
+		return before, after, nil
+	}
+
+	repaired := false
+	if repair {
+		repairErr = repairWorkspaceSymlink(before, policy)
+		after = checkWorkspaceSymlink(before.Link, before.Expected)
+		repaired = repairErr == nil && after.Status == workspaceOK
+		if repairErr == nil && !repaired {
+			repairErr = fmt.Errorf("still %s after repair", after.Status)
+		}
+	}
+	if err := logWorkspaceIncident(before, repaired, repairErr, source); err != nil {
+		Debug("failed to log workspace incident: %v", err)
+	}
+	return before, after, repairErr
+}
+
+func runWorkspaceCheck(repair, quiet bool, source string) error {
+	if !initRegistry().Enabled("workspace_symlink") {
+		if !quiet {
+			Info("workspace_symlink feature is disabled")
+		}
+		return nil
+	}
+
+	// The shell hook runs on every startup: stay silent for users who never
+	// set up /workspace rather than reporting it missing each time
+	if quiet && os.Getenv("WORKSPACE_TARGET") == "" && configLookup("paths.workspace_target") == "" {
+		if _, err := os.Lstat(workspaceSymlinkPath()); os.IsNotExist(err) {
+			return nil
+		}
+	}
+
+	before, after, repairErr := workspaceWatchdog(repair, workspaceRepairPolicy(), source)
+
+	switch {
+	case before.Status == workspaceOK:
+		if !quiet {
+			Pass("%s", before.describe())
+		}
+		return nil
+	case after.Status == workspaceOK:
+		if !quiet {
+			Warn("%s", before.describe())
+		}
+		Pass("Repaired %s → %s", after.Link, after.Expected)
+		return nil
+	}
+
+	Warn("%s", before.describe())
+	if repairErr != nil {
+		Warn("Not repaired: %v", repairErr)
+	}
+	if !quiet {
+		fmt.Printf("  Fix: sudo ln -sfn %s %s\n", before.Expected, before.Link)
+	}
+	return fmt.Errorf("workspace symlink %s", before.Status)
+}
+
+func showWorkspaceIncidents(limit int) error {
+	incidents, err := loadWorkspaceIncidents()
+	if err != nil {
+		Fail("Failed to read incident log: %v", err)
+		return err
+	}
+	if len(incidents) == 0 {
+		Info("No workspace incidents logged")
+		return nil
+	}
+	if limit > 0 && len(incidents) > limit {
+		incidents = incidents[len(incidents)-limit:]
+	}
+
+	PrintHeader("Workspace Incidents")
+	for _, inc := range incidents {
+		outcome := Yellow.Sprint("not repaired")
+		if inc.Repaired {
+			outcome = Green.Sprint("repaired")
+		}
+		fmt.Printf("  %s  %-13s %s  %s\n", Dim.Sprint(inc.Time), inc.Status, outcome, Dim.Sprint(inc.Source))
+		if inc.Error != "" {
+			fmt.Printf("  %s\n", Dim.Sprint("  "+inc.Error))
+		}
+	}
+	fmt.Println()
+	fmt.Printf("Log: %s\n", getWorkspaceIncidentLog())
+	return nil
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+)
+
+// TestCheckWorkspaceSymlink verifies each symlink health state
+func TestCheckWorkspaceSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "workspace")
+	other := filepath.Join(dir, "other")
+	os.Mkdir(target, 0755)
+	os.Mkdir(other, 0755)
+	link := filepath.Join(dir, "link")
+
+	if h := checkWorkspaceSymlink(link, target); h.Status != workspaceMissing {
+		t.Errorf("missing: got %s", h.Status)
+	}
+
+	os.Symlink(target, link)
+	if h := checkWorkspaceSymlink(link, target); h.Status != workspaceOK {
+		t.Errorf("ok: got %s", h.Status)
+	}
+	if h := checkWorkspaceSymlink(link, other); h.Status != workspaceWrongTarget || h.Actual != target {
+		t.Errorf("wrong target: got %+v", h)
+	}
+
+	os.Remove(target)
+	if h := checkWorkspaceSymlink(link, target); h.Status != workspaceDangling {
+		t.Errorf("dangling: got %s", h.Status)
+	}
+
+	os.Remove(link)
+	os.Mkdir(link, 0755)
+	if h := checkWorkspaceSymlink(link, target); h.Status != workspaceNotSymlink {
+		t.Errorf("not symlink: got %s", h.Status)
+	}
+}
+
+// TestRepairWorkspaceSymlink verifies repairs without elevation and the
+// cases that must never be repaired
+func TestRepairWorkspaceSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "workspace")
+	os.Mkdir(target, 0755)
+	link := filepath.Join(dir, "link")
+	os.Symlink(filepath.Join(dir, "gone"), link)
+
+	h := checkWorkspaceSymlink(link, target)
+	if err := repairWorkspaceSymlink(h, workspaceRepairOff); err == nil {
+		t.Error("workspace.repair=off should refuse to repair")
+	}
+	if err := repairWorkspaceSymlink(h, workspaceRepairCached); err != nil {
+		t.Fatalf("repair in a writable directory: %v", err)
+	}
+	if h := checkWorkspaceSymlink(link, target); h.Status != workspaceOK {
+		t.Errorf("after repair: %s", h.Status)
+	}
+
+	// A missing target is not created
+	missing := checkWorkspaceSymlink(link, filepath.Join(dir, "nope"))
+	if err := repairWorkspaceSymlink(missing, workspaceRepairCached); err == nil {
+		t.Error("expected error when the workspace target does not exist")
+	}
+
+	// A real directory in the way is left alone
+	os.Remove(link)
+	os.Mkdir(link, 0755)
+	if err := repairWorkspaceSymlink(checkWorkspaceSymlink(link, target), workspaceRepairPrompt); err == nil {
+		t.Error("expected error for a non-symlink in the way")
+	}
+}
+
+// TestWorkspaceIncidentLog verifies incidents are appended and read back
+func TestWorkspaceIncidentLog(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("BLACKDOT_PATHS_STRATEGY", "xdg")
+	paths.ResetStrategy()
+	t.Cleanup(paths.ResetStrategy)
+
+	h := workspaceHealth{Link: "/workspace", Expected: "/home/u/workspace", Status: workspaceMissing}
+	logWorkspaceIncident(h, false, nil, "shell")
+	logWorkspaceIncident(h, true, nil, "doctor")
+
+	incidents, err := loadWorkspaceIncidents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(incidents) != 2 || incidents[0].Source != "shell" || !incidents[1].Repaired {
+		t.Errorf("incidents = %+v", incidents)
+	}
+}
+
+// TestSaveSetupConfigPreservesKeys verifies setup keeps keys it doesn't model
+func TestSaveSetupConfigPreservesKeys(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("BLACKDOT_PATHS_STRATEGY", "xdg")
+	paths.ResetStrategy()
+	t.Cleanup(paths.ResetStrategy)
+
+	path := filepath.Join(ConfigDir(), "config.json")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte(`{"paths":{"strategy":"xdg"},"vault":{"verified_at":"2026-01-01T00:00:00Z"}}`), 0644)
+
+	cfg, err := loadSetupConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Paths.WorkspaceTarget = "/tmp/ws"
+	cfg.Vault.Backend = "pass"
+	if err := saveSetupConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved map[string]map[string]interface{}
+	data, _ := os.ReadFile(path)
+	json.Unmarshal(data, &saved)
+	if saved["paths"]["strategy"] != "xdg" || saved["paths"]["workspace_target"] != "/tmp/ws" {
+		t.Errorf("paths = %v", saved["paths"])
+	}
+	if saved["vault"]["verified_at"] == nil || saved["vault"]["backend"] != "pass" {
+		t.Errorf("vault = %v", saved["vault"])
+	}
+}
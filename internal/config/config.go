@@ -12,6 +12,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/safefile"
 )
 
 // Layer represents a configuration layer
@@ -47,6 +50,66 @@ type VaultConfig struct {
 	AutoSync  bool   `json:"auto_sync,omitempty"`
 	Location  string `json:"location,omitempty"`
 	Namespace string `json:"namespace,omitempty"`
+
+	// Server is a self-hosted server URL (e.g. Vaultwarden for bitwarden)
+	Server string `json:"server,omitempty"`
+
+	// Fallback lists backends reads fall back to, in order, when Backend
+	// is unavailable. Writes always go to Backend.
+	Fallback []string `json:"fallback,omitempty"`
+
+	// VerifiedAt and VerifiedBackend record the last successful end-to-end
+	// check (create, read back, delete a canary item). The backend is
+	// "verified working" only while VerifiedBackend matches Backend.
+	VerifiedAt      string `json:"verified_at,omitempty"`
+	VerifiedBackend string `json:"verified_backend,omitempty"`
+}
+
+// Verified reports whether the configured backend passed an end-to-end check
+func (v VaultConfig) Verified() bool {
+	return v.Backend != "" && v.VerifiedAt != "" && v.VerifiedBackend == v.Backend
+}
+
+// knownConfigKeys are the top-level keys mapped to Config fields
+var knownConfigKeys = []string{"version", "features", "vault", "setup"}
+
+// UnmarshalJSON keeps unknown top-level keys in Extra so they survive Save
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type plain Config
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for _, key := range knownConfigKeys {
+		delete(all, key)
+	}
+	c.Extra = nil
+	if len(all) > 0 {
+		c.Extra = all
+	}
+	return nil
+}
+
+// MarshalJSON writes Extra back alongside the known fields
+func (c Config) MarshalJSON() ([]byte, error) {
+	type plain Config
+	data, err := json.Marshal(plain(c))
+	if err != nil || len(c.Extra) == 0 {
+		return data, err
+	}
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for key, value := range c.Extra {
+		if _, known := all[key]; !known {
+			all[key] = value
+		}
+	}
+	return json.Marshal(all)
 }
 
 // SetupState tracks setup wizard progress
@@ -79,15 +142,7 @@ func NewManager(configDir, blackdotDir string) *Manager {
 
 // DefaultManager creates a manager with default paths
 func DefaultManager() *Manager {
-	configDir := os.Getenv("XDG_CONFIG_HOME")
-	if configDir == "" {
-		home, _ := os.UserHomeDir()
-		configDir = filepath.Join(home, ".config")
-	} else {
-		// Normalize path separators for cross-platform consistency
-		configDir = filepath.Clean(configDir)
-	}
-	configDir = filepath.Join(configDir, "blackdot")
+	configDir := paths.ConfigDir()
 
 	blackdotDir := os.Getenv("BLACKDOT_DIR")
 	if blackdotDir == "" {
@@ -133,8 +188,11 @@ func (m *Manager) ProjectConfigPath() string {
 	return ""
 }
 
-// Load reads the user config file
+// Load reads the user config file, migrating an older format first
 func (m *Manager) Load() (*Config, error) {
+	if _, err := m.MigrateUserConfig(); err != nil {
+		return nil, err
+	}
 	return m.loadFile(m.UserConfigPath())
 }
 
@@ -143,7 +201,7 @@ func (m *Manager) loadFile(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &Config{Version: 3}, nil
+			return &Config{Version: CurrentVersion}, nil
 		}
 		return nil, err
 	}
@@ -168,7 +226,7 @@ func (m *Manager) Save(cfg *Config) error {
 		return err
 	}
 
-	return os.WriteFile(m.UserConfigPath(), data, 0644)
+	return safefile.Write(m.UserConfigPath(), data, 0644)
 }
 
 // Get retrieves a config value using dot notation (e.g., "vault.backend")
@@ -181,13 +239,22 @@ func (m *Manager) Get(key string) (string, error) {
 	return getNestedValue(cfg, key)
 }
 
+// envKeyReplacer maps key separators to underscores
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// EnvKey returns the environment variable that overrides key in the env
+// layer: vault.backend -> BLACKDOT_VAULT_BACKEND,
+// doctor.min_versions.git-lfs -> BLACKDOT_DOCTOR_MIN_VERSIONS_GIT_LFS
+func EnvKey(key string) string {
+	return "BLACKDOT_" + strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
 // GetLayered retrieves a value with layer resolution
 func (m *Manager) GetLayered(key string) (*LayerResult, error) {
 	result := &LayerResult{Key: key}
 
 	// Layer 1: Environment variable
-	// vault.backend -> BLACKDOT_VAULT_BACKEND
-	envKey := "BLACKDOT_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	envKey := EnvKey(key)
 	if val := os.Getenv(envKey); val != "" {
 		result.Value = val
 		result.Source = LayerEnv
@@ -272,6 +339,14 @@ func getNestedValue(cfg *Config, key string) (string, error) {
 			return cfg.Vault.Location, nil
 		case "namespace":
 			return cfg.Vault.Namespace, nil
+		case "server":
+			return cfg.Vault.Server, nil
+		case "fallback":
+			return strings.Join(cfg.Vault.Fallback, ","), nil
+		case "verified_at":
+			return cfg.Vault.VerifiedAt, nil
+		case "verified_backend":
+			return cfg.Vault.VerifiedBackend, nil
 		}
 	case "features":
 		if len(parts) < 2 {
@@ -309,6 +384,19 @@ func setNestedValue(cfg *Config, key, value string) error {
 			cfg.Vault.Location = value
 		case "namespace":
 			cfg.Vault.Namespace = value
+		case "server":
+			cfg.Vault.Server = value
+		case "fallback":
+			cfg.Vault.Fallback = nil
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					cfg.Vault.Fallback = append(cfg.Vault.Fallback, name)
+				}
+			}
+		case "verified_at":
+			cfg.Vault.VerifiedAt = value
+		case "verified_backend":
+			cfg.Vault.VerifiedBackend = value
 		default:
 			return errors.New("unknown vault key: " + parts[1])
 		}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -199,6 +200,64 @@ func TestGetSetVaultAutoSync(t *testing.T) {
 	}
 }
 
+// TestGetSetVaultFallback verifies vault.fallback round-trips as a list
+func TestGetSetVaultFallback(t *testing.T) {
+	m := NewManager(t.TempDir(), t.TempDir())
+
+	if err := m.Set("vault.fallback", "pass, bitwarden"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	cfg, _ := m.Load()
+	if len(cfg.Vault.Fallback) != 2 || cfg.Vault.Fallback[0] != "pass" {
+		t.Errorf("Fallback = %v", cfg.Vault.Fallback)
+	}
+	if val, _ := m.Get("vault.fallback"); val != "pass,bitwarden" {
+		t.Errorf("expected 'pass,bitwarden', got '%s'", val)
+	}
+
+	m.Set("vault.fallback", "")
+	if val, _ := m.Get("vault.fallback"); val != "" {
+		t.Errorf("expected fallback cleared, got '%s'", val)
+	}
+}
+
+// TestVaultVerified verifies verified state only counts for the current backend
+func TestVaultVerified(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(tmpDir, tmpDir)
+
+	m.Set("vault.backend", "pass")
+	m.Set("vault.verified_at", "2026-01-01T00:00:00Z")
+	m.Set("vault.verified_backend", "pass")
+
+	cfg, _ := m.Load()
+	if !cfg.Vault.Verified() {
+		t.Error("expected pass backend to be verified")
+	}
+
+	m.Set("vault.backend", "bitwarden")
+	cfg, _ = m.Load()
+	if cfg.Vault.Verified() {
+		t.Error("switching backends should drop verified status")
+	}
+}
+
+// TestSavePreservesUnknownKeys verifies keys without Config fields survive Set
+func TestSavePreservesUnknownKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(tmpDir, tmpDir)
+
+	os.WriteFile(m.UserConfigPath(), []byte(`{"version":3,"paths":{"strategy":"xdg"}}`), 0644)
+	if err := m.Set("vault.backend", "pass"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(m.UserConfigPath())
+	if !strings.Contains(string(data), `"strategy": "xdg"`) {
+		t.Errorf("paths.strategy was dropped: %s", data)
+	}
+}
+
 // TestGetSetFeatures verifies Get/Set for features
 func TestGetSetFeatures(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "config-test-*")
@@ -259,6 +318,19 @@ func TestGetLayeredEnv(t *testing.T) {
 }
 
 // TestGetLayeredUser verifies user config fallback
+func TestEnvKey(t *testing.T) {
+	tests := map[string]string{
+		"vault.backend":               "BLACKDOT_VAULT_BACKEND",
+		"doctor.min_versions.git-lfs": "BLACKDOT_DOCTOR_MIN_VERSIONS_GIT_LFS",
+		"defaults.scan.no-entropy":    "BLACKDOT_DEFAULTS_SCAN_NO_ENTROPY",
+	}
+	for key, want := range tests {
+		if got := EnvKey(key); got != want {
+			t.Errorf("EnvKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
 func TestGetLayeredUser(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "config-test-*")
 	if err != nil {
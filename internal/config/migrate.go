@@ -0,0 +1,261 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+	"github.com/blackwell-systems/blackdot/internal/safefile"
+)
+
+// CurrentVersion is the config.json format this build writes
+const CurrentVersion = 3
+
+// Migration upgrades a config document from version From to From+1. Apply
+// edits doc in place and describes each change it made.
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(doc map[string]interface{}) []string
+}
+
+// Migrations upgrade older config.json files, in order.
+//
+// v1 is config.ini converted key by key (bin/blackdot-migrate-config before
+// 3.0): flat keys, and the [state] section as phase => "completed".
+//
+//	{"version": 1, "vault_backend": "bw", "workspace_target": "~/code",
+//	 "brewfile_tier": "full", "state": {"vault": "completed"}}
+//
+// v2 nested the keys but kept the older names and string values.
+//
+//	{"version": 2, "vault": {"preferred_backend": "bw"},
+//	 "setup": {"completed": "vault,packages"}, "features": {"vault": "true"}}
+var Migrations = []Migration{
+	{From: 1, Description: "nest flat config.ini keys", Apply: migrateV1},
+	{From: 2, Description: "rename vault.preferred_backend and normalize values", Apply: migrateV2},
+}
+
+// v1FlatKeys maps v1 top-level keys to their nested v2 location
+var v1FlatKeys = map[string]string{
+	"vault_backend":    "vault.preferred_backend",
+	"vault_auto_sync":  "vault.auto_sync",
+	"vault_location":   "vault.location",
+	"workspace_target": "paths.workspace_target",
+	"brewfile_tier":    "packages.tier",
+	"setup_timestamp":  "setup.timestamp",
+}
+
+func migrateV1(doc map[string]interface{}) []string {
+	var changes []string
+	for _, old := range sortedKeys(v1FlatKeys) {
+		value, ok := doc[old]
+		if !ok {
+			continue
+		}
+		delete(doc, old)
+		setPath(doc, v1FlatKeys[old], value)
+		changes = append(changes, fmt.Sprintf("moved %s to %s", old, v1FlatKeys[old]))
+	}
+	if state, ok := doc["state"].(map[string]interface{}); ok {
+		var done []string
+		for phase, v := range state {
+			if v == "completed" || v == true {
+				done = append(done, phase)
+			}
+		}
+		sort.Strings(done)
+		delete(doc, "state")
+		setPath(doc, "setup.completed", strings.Join(done, ","))
+		changes = append(changes, "moved [state] phases to setup.completed")
+	}
+	return changes
+}
+
+// backendAliases are backend names older versions accepted
+var backendAliases = map[string]string{"bw": "bitwarden", "op": "1password", "1pass": "1password"}
+
+func migrateV2(doc map[string]interface{}) []string {
+	var changes []string
+	if vault, ok := doc["vault"].(map[string]interface{}); ok {
+		if preferred, ok := vault["preferred_backend"]; ok {
+			delete(vault, "preferred_backend")
+			if _, set := vault["backend"]; !set {
+				vault["backend"] = preferred
+			}
+			changes = append(changes, "renamed vault.preferred_backend to vault.backend")
+		}
+		if backend, ok := vault["backend"].(string); ok && backendAliases[backend] != "" {
+			vault["backend"] = backendAliases[backend]
+			changes = append(changes, fmt.Sprintf("vault.backend %s is now %s", backend, backendAliases[backend]))
+		}
+		if v, ok := vault["auto_sync"].(string); ok {
+			vault["auto_sync"] = v == "true"
+			changes = append(changes, "vault.auto_sync is now a boolean")
+		}
+	}
+	if setup, ok := doc["setup"].(map[string]interface{}); ok {
+		if list, ok := setup["completed"].(string); ok {
+			phases := []interface{}{}
+			for _, p := range strings.Split(list, ",") {
+				if p = strings.TrimSpace(p); p != "" {
+					phases = append(phases, p)
+				}
+			}
+			setup["completed"] = phases
+			changes = append(changes, "setup.completed is now a list")
+		}
+	}
+	if features, ok := doc["features"].(map[string]interface{}); ok {
+		converted := false
+		for name, v := range features {
+			if s, ok := v.(string); ok {
+				features[name] = s == "true"
+				converted = true
+			}
+		}
+		if converted {
+			changes = append(changes, "feature flags are now booleans")
+		}
+	}
+	return changes
+}
+
+// DocumentVersion is a config document's format version. Files without a
+// version are v1 when they have flat config.ini keys, v2 when they have
+// v2 names, and current otherwise.
+func DocumentVersion(doc map[string]interface{}) int {
+	if v, ok := doc["version"].(float64); ok && v >= 1 {
+		return int(v)
+	}
+	for key := range v1FlatKeys {
+		if _, ok := doc[key]; ok {
+			return 1
+		}
+	}
+	if _, ok := doc["state"]; ok {
+		return 1
+	}
+	if vault, ok := doc["vault"].(map[string]interface{}); ok {
+		if _, ok := vault["preferred_backend"]; ok {
+			return 2
+		}
+	}
+	return CurrentVersion
+}
+
+// MigrateDocument upgrades doc to CurrentVersion in place. It returns the
+// version it started from and the changes made.
+func MigrateDocument(doc map[string]interface{}) (int, []string) {
+	from := DocumentVersion(doc)
+	var changes []string
+	for _, m := range Migrations {
+		if m.From < from {
+			continue
+		}
+		for _, change := range m.Apply(doc) {
+			changes = append(changes, fmt.Sprintf("v%d→v%d: %s", m.From, m.From+1, change))
+		}
+	}
+	if from < CurrentVersion {
+		doc["version"] = CurrentVersion
+	}
+	return from, changes
+}
+
+// MigrationRecord is one entry in the migration log
+type MigrationRecord struct {
+	Time    string   `json:"time"`
+	File    string   `json:"file"`
+	From    int      `json:"from"`
+	To      int      `json:"to"`
+	Backup  string   `json:"backup"`
+	Changes []string `json:"changes"`
+}
+
+// MigrationLogPath is the JSON lines log of config migrations
+func MigrationLogPath() string {
+	return filepath.Join(paths.StateDir(), "config-migrations.jsonl")
+}
+
+// MigrateUserConfig upgrades config.json when it is older than
+// CurrentVersion. The original is kept next to it as
+// config.json.v<N>.<timestamp>.bak and the migration is logged. It returns
+// nil when nothing needed migrating.
+func (m *Manager) MigrateUserConfig() (*MigrationRecord, error) {
+	path := m.UserConfigPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if DocumentVersion(doc) >= CurrentVersion {
+		return nil, nil
+	}
+
+	from, changes := MigrateDocument(doc)
+	now := time.Now().UTC()
+	record := &MigrationRecord{
+		Time:    now.Format(time.RFC3339),
+		File:    path,
+		From:    from,
+		To:      CurrentVersion,
+		Backup:  fmt.Sprintf("%s.v%d.%s.bak", path, from, now.Format("20060102T150405Z")),
+		Changes: changes,
+	}
+	if err := safefile.Write(record.Backup, data, 0600); err != nil {
+		return nil, fmt.Errorf("backing up %s: %w", path, err)
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := safefile.Write(path, append(out, '\n'), 0644); err != nil {
+		return nil, err
+	}
+
+	if line, err := json.Marshal(record); err == nil {
+		os.MkdirAll(filepath.Dir(MigrationLogPath()), 0700)
+		if f, err := os.OpenFile(MigrationLogPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600); err == nil {
+			f.Write(append(line, '\n'))
+			f.Close()
+		}
+	}
+	return record, nil
+}
+
+// setPath sets a dotted key, creating objects along the way
+func setPath(doc map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	current := doc
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+)
+
+// TestValidate verifies schema errors, unknown-key warnings, and that
+// a current config passes
+func TestValidate(t *testing.T) {
+	issues, err := ValidateJSON([]byte(`{
+		"version": 3,
+		"vault": {"backend": "bitwarden", "auto_sync": "yes", "colour": "red"},
+		"features": {"vault": true, "ai": 1},
+		"packages": {"tier": "huge"},
+		"mystery": {}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{
+		"features.ai: must be boolean, not integer":                       false,
+		"mystery: unknown key":                                            true,
+		"packages.tier: must be one of minimal, enhanced, full, not huge": false,
+		"vault.auto_sync: must be boolean, not string":                    false,
+		"vault.colour: unknown key":                                       true,
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("got %d issues, want %d: %v", len(issues), len(want), issues)
+	}
+	for _, issue := range issues {
+		warning, ok := want[issue.String()]
+		if !ok || warning != issue.Warning {
+			t.Errorf("unexpected issue %q (warning=%v)", issue, issue.Warning)
+		}
+	}
+
+	issues, _ = ValidateJSON([]byte(`{"version": 3, "vault": {"backend": "keychain"}, "setup": {"completed": ["vault"]}}`))
+	if len(issues) != 0 {
+		t.Errorf("valid config reported %v", issues)
+	}
+}
+
+// TestParseValue verifies command-line values take the schema's type for
+// their key and values the schema rejects are errors
+func TestParseValue(t *testing.T) {
+	for _, tc := range []struct {
+		key, raw string
+		want     interface{}
+	}{
+		{"vault.auto_sync", "yes", true},
+		{"backup.max_snapshots", "5", float64(5)},
+		{"vault.backend", "pass", "pass"},
+		{"setup.completed", "vault, shell", []interface{}{"vault", "shell"}},
+		{"setup.completed", `["vault"]`, []interface{}{"vault"}},
+		{"template.max_output", "1000", float64(1000)},
+		{"template.max_output", "1MB", "1MB"},
+		{"mystery.key", "42", float64(42)},
+		{"mystery.key", "plain", "plain"},
+	} {
+		got, err := ParseValue(tc.key, tc.raw)
+		if err != nil {
+			t.Errorf("ParseValue(%s, %q): %v", tc.key, tc.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseValue(%s, %q) = %#v, want %#v", tc.key, tc.raw, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct{ key, raw, want string }{
+		{"vault.auto_sync", "maybe", "vault.auto_sync must be boolean"},
+		{"vault.backend", "lastpass", "must be one of"},
+		{"backup.max_snapshots", "-1", "must be at least 0"},
+	} {
+		if _, err := ParseValue(tc.key, tc.raw); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("ParseValue(%s, %q) error = %v, want %q", tc.key, tc.raw, err, tc.want)
+		}
+	}
+}
+
+// TestMigrateDocument verifies v1 and v2 documents end up in the
+// current format
+func TestMigrateDocument(t *testing.T) {
+	want := map[string]interface{}{
+		"version":  float64(CurrentVersion),
+		"vault":    map[string]interface{}{"backend": "bitwarden", "auto_sync": true},
+		"paths":    map[string]interface{}{"workspace_target": "~/code"},
+		"packages": map[string]interface{}{"tier": "full"},
+		"setup":    map[string]interface{}{"completed": []interface{}{"packages", "vault"}},
+		"features": map[string]interface{}{"vault": true},
+	}
+
+	for name, input := range map[string]string{
+		"v1": `{"version": 1, "vault_backend": "bw", "vault_auto_sync": "true",
+			"workspace_target": "~/code", "brewfile_tier": "full",
+			"state": {"vault": "completed", "packages": "completed", "ssh": "skipped"},
+			"features": {"vault": "true"}}`,
+		"v2 without version": `{"vault": {"preferred_backend": "bw", "auto_sync": "true"},
+			"paths": {"workspace_target": "~/code"}, "packages": {"tier": "full"},
+			"setup": {"completed": "packages,vault"}, "features": {"vault": "true"}}`,
+	} {
+		var doc map[string]interface{}
+		json.Unmarshal([]byte(input), &doc)
+		MigrateDocument(doc)
+		// round-trip so numbers compare as they decode
+		data, _ := json.Marshal(doc)
+		doc = nil
+		json.Unmarshal(data, &doc)
+		if !reflect.DeepEqual(doc, want) {
+			t.Errorf("%s migrated to %s", name, data)
+		}
+		if issues := Validate(doc); len(issues) != 0 {
+			t.Errorf("%s migrated to an invalid config: %v", name, issues)
+		}
+	}
+}
+
+// TestMigrateUserConfig verifies the original is backed up, the
+// migration is logged, and current configs are left alone
+func TestMigrateUserConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("BLACKDOT_PATHS_STRATEGY", "xdg")
+	paths.ResetStrategy()
+	t.Cleanup(paths.ResetStrategy)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+	m := NewManager(dir, dir)
+	original := []byte(`{"version": 1, "vault_backend": "op"}`)
+	os.WriteFile(m.UserConfigPath(), original, 0644)
+
+	cfg, err := m.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != CurrentVersion || cfg.Vault.Backend != "1password" {
+		t.Errorf("loaded version %d backend %q", cfg.Version, cfg.Vault.Backend)
+	}
+
+	backups, _ := filepath.Glob(m.UserConfigPath() + ".v1.*.bak")
+	if len(backups) != 1 {
+		t.Fatalf("got backups %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != string(original) {
+		t.Errorf("backup holds %s", data)
+	}
+	log, err := os.ReadFile(MigrationLogPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(log), `"from":1,"to":3`) {
+		t.Errorf("migration log %s", log)
+	}
+
+	if record, err := m.MigrateUserConfig(); record != nil || err != nil {
+		t.Errorf("second migration: %v %v", record, err)
+	}
+	if backups, _ := filepath.Glob(m.UserConfigPath() + ".*.bak"); len(backups) != 1 {
+		t.Errorf("second migration made a backup: %v", backups)
+	}
+}
@@ -0,0 +1,299 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaJSON is the JSON Schema for blackdot config files
+//
+//go:embed config.schema.json
+var SchemaJSON []byte
+
+// schema is the subset of JSON Schema the config schema uses: type,
+// properties, additionalProperties, items, enum, and minimum
+type schema struct {
+	Type                 interface{}        `json:"type"` // string or []string
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Enum                 []interface{}      `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+}
+
+// Issue is one schema violation. Warnings are keys the schema does not
+// know; config layers may carry settings for other tools, so they do not
+// fail validation.
+type Issue struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	Warning bool   `json:"warning,omitempty"`
+}
+
+func (i Issue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return i.Path + ": " + i.Message
+}
+
+var rootSchema = func() *schema {
+	var s schema
+	if err := json.Unmarshal(SchemaJSON, &s); err != nil {
+		panic("config.schema.json: " + err.Error())
+	}
+	return &s
+}()
+
+// Validate checks a decoded config file against the schema
+func Validate(doc map[string]interface{}) []Issue {
+	var issues []Issue
+	rootSchema.validate("", doc, &issues)
+	return issues
+}
+
+// ValidateJSON decodes data and validates it
+func ValidateJSON(data []byte) ([]Issue, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return Validate(doc), nil
+}
+
+// ParseValue converts a command-line value to the type the schema declares
+// for key: true/false (or yes/no, on/off, 1/0) for booleans, numbers, a
+// JSON or comma-separated list for arrays, and JSON for objects. The value
+// must then pass the key's schema. Keys the schema does not describe are
+// decoded as JSON when they parse and kept as strings otherwise.
+func ParseValue(key, raw string) (interface{}, error) {
+	s := rootSchema.lookup(key)
+	if s == nil || len(s.types()) == 0 {
+		var v interface{}
+		if err := json.Unmarshal([]byte(raw), &v); err == nil {
+			return v, nil
+		}
+		return raw, nil
+	}
+	v, ok := s.parse(raw)
+	if !ok {
+		return nil, fmt.Errorf("%s must be %s, not %q", key, strings.Join(s.types(), " or "), raw)
+	}
+	var issues []Issue
+	s.validate(key, v, &issues)
+	for _, issue := range issues {
+		if !issue.Warning {
+			return nil, fmt.Errorf("%s", issue)
+		}
+	}
+	return v, nil
+}
+
+// lookup finds the schema for a dotted key, or nil when the schema does
+// not describe it
+func (s *schema) lookup(key string) *schema {
+	current := s
+	for _, part := range strings.Split(key, ".") {
+		if prop, ok := current.Properties[part]; ok {
+			current = prop
+		} else if current = current.additional(); current == nil {
+			return nil
+		}
+	}
+	return current
+}
+
+// parse reads raw as the first of the schema's types it fits, trying
+// string last so "true" becomes a boolean where either is allowed
+func (s *schema) parse(raw string) (interface{}, bool) {
+	types := s.types()
+	sort.SliceStable(types, func(i, j int) bool { return types[j] == "string" && types[i] != "string" })
+	for _, t := range types {
+		switch t {
+		case "string":
+			return raw, true
+		case "boolean":
+			switch strings.ToLower(raw) {
+			case "true", "yes", "on", "1":
+				return true, true
+			case "false", "no", "off", "0":
+				return false, true
+			}
+		case "integer":
+			if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				return float64(n), true
+			}
+		case "number":
+			if n, err := strconv.ParseFloat(raw, 64); err == nil {
+				return n, true
+			}
+		case "null":
+			if raw == "null" {
+				return nil, true
+			}
+		case "object":
+			var obj map[string]interface{}
+			if json.Unmarshal([]byte(raw), &obj) == nil && obj != nil {
+				return obj, true
+			}
+		case "array":
+			var list []interface{}
+			if json.Unmarshal([]byte(raw), &list) == nil && list != nil {
+				return list, true
+			}
+			list = []interface{}{}
+			for _, item := range strings.Split(raw, ",") {
+				if item = strings.TrimSpace(item); item == "" {
+					continue
+				}
+				var v interface{} = item
+				if s.Items != nil {
+					parsed, ok := s.Items.parse(item)
+					if !ok {
+						return nil, false
+					}
+					v = parsed
+				}
+				list = append(list, v)
+			}
+			return list, true
+		}
+	}
+	return nil, false
+}
+
+func (s *schema) validate(path string, value interface{}, issues *[]Issue) {
+	if types := s.types(); len(types) > 0 && !matchesType(value, types) {
+		*issues = append(*issues, Issue{Path: path, Message: fmt.Sprintf("must be %s, not %s", strings.Join(types, " or "), jsonType(value))})
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		*issues = append(*issues, Issue{Path: path, Message: fmt.Sprintf("must be one of %s, not %v", enumList(s.Enum), value)})
+	}
+	if s.Minimum != nil {
+		if n, ok := value.(float64); ok && n < *s.Minimum {
+			*issues = append(*issues, Issue{Path: path, Message: fmt.Sprintf("must be at least %v", *s.Minimum)})
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := joinPath(path, key)
+			if prop, ok := s.Properties[key]; ok {
+				prop.validate(child, v[key], issues)
+				continue
+			}
+			switch extra := s.additional(); {
+			case extra != nil:
+				extra.validate(child, v[key], issues)
+			case s.closed():
+				*issues = append(*issues, Issue{Path: child, Message: "unknown key", Warning: true})
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, issues)
+			}
+		}
+	}
+}
+
+func (s *schema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// additional is the schema for keys outside properties, when it is one
+func (s *schema) additional() *schema {
+	if len(s.AdditionalProperties) == 0 || s.AdditionalProperties[0] != '{' {
+		return nil
+	}
+	var extra schema
+	if json.Unmarshal(s.AdditionalProperties, &extra) != nil {
+		return nil
+	}
+	return &extra
+}
+
+// closed reports additionalProperties: false
+func (s *schema) closed() bool {
+	return string(s.AdditionalProperties) == "false"
+}
+
+func matchesType(value interface{}, types []string) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, e := range enum {
+		if e == value {
+			return true
+		}
+	}
+	return false
+}
+
+func enumList(enum []interface{}) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		parts[i] = fmt.Sprint(e)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -29,9 +29,12 @@ var presets = map[string]*Preset{
 			"go_tools",
 			"python_tools",
 			"ssh_tools",
+			"gpg_tools",
+			"k8s_tools",
 			"docker_tools",
 			"nvm_integration",
 			"sdkman_integration",
+			"tool_versions",
 			"git_hooks",
 			"modern_cli",
 			"config_layers",
@@ -65,6 +68,8 @@ var presets = map[string]*Preset{
 			"go_tools",
 			"python_tools",
 			"ssh_tools",
+			"gpg_tools",
+			"k8s_tools",
 			"docker_tools",
 			"git_hooks",
 			"drift_check",
@@ -74,6 +79,7 @@ var presets = map[string]*Preset{
 			"modern_cli",
 			"nvm_integration",
 			"sdkman_integration",
+			"tool_versions",
 		},
 	},
 }
@@ -91,9 +91,12 @@ func NewRegistry() *Registry {
 	r.register("go_tools", CategoryIntegration, "Go aliases and helpers", nil, DefaultTrue)
 	r.register("python_tools", CategoryIntegration, "Python/uv aliases, auto-venv, pytest helpers", nil, DefaultTrue)
 	r.register("ssh_tools", CategoryIntegration, "SSH config, key management, agent, and tunnel helpers", nil, DefaultTrue)
+	r.register("gpg_tools", CategoryIntegration, "GPG key management and vault-backed keyring", nil, DefaultTrue)
+	r.register("k8s_tools", CategoryIntegration, "Kubernetes context switching and kubeconfig merging", nil, DefaultTrue)
 	r.register("docker_tools", CategoryIntegration, "Docker container, compose, and network management", nil, DefaultTrue)
 	r.register("nvm_integration", CategoryIntegration, "Lazy-loaded NVM for Node.js version management", nil, DefaultTrue)
 	r.register("sdkman_integration", CategoryIntegration, "Lazy-loaded SDKMAN for Java/Gradle/Kotlin", nil, DefaultTrue)
+	r.register("tool_versions", CategoryIntegration, "Project runtimes from .tool-versions and mise.toml, checked on cd", nil, DefaultTrue)
 	r.register("dotclaude", CategoryIntegration, "dotclaude profile management for Claude Code", []string{"claude_integration"}, DefaultFalse)
 	r.register("devcontainer", CategoryIntegration, "Devcontainer support for VS Code, Codespaces, DevPod", nil, DefaultTrue)
 
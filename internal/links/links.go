@@ -0,0 +1,553 @@
+// Package links manages the symlinks declared in links.yaml.
+//
+// Each dotfiles root (the base repo and any overlay repos) may ship a
+// links.yaml at its top level:
+//
+//	backup: rename              # default policy for existing real files
+//	links:
+//	  - source: zsh/zshrc       # relative to the root, or absolute
+//	    target: ~/.zshrc
+//	  - source: ghostty/config
+//	    target: ~/Library/Application Support/com.mitchellh.ghostty/config
+//	    platforms: [darwin]
+//	  - source: claude/commands/*.md
+//	    target: ${WORKSPACE_TARGET}/.claude/commands/
+//
+// Targets ending in / are directories: each source is linked inside with its
+// own name, which is how globbed sources are declared. Later roots override
+// earlier ones for the same target.
+//
+// On Windows, creating a symlink needs Developer Mode or an elevated shell.
+// When it fails, directories are linked with a junction and files with a hard
+// link, falling back to a copy across volumes. Inspect reports which
+// mechanism links each target.
+package links
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the declaration file in each root
+const FileName = "links.yaml"
+
+// Backup policies for a target that exists and is not the expected link
+const (
+	BackupRename    = "rename"    // move aside to <target>.bak-<timestamp> (default)
+	BackupSkip      = "skip"      // leave it and report a conflict
+	BackupOverwrite = "overwrite" // delete it
+)
+
+// Link states
+const (
+	StateOK            = "ok"
+	StateMissing       = "missing"        // nothing at the target
+	StateWrongTarget   = "wrong-target"   // a symlink pointing elsewhere
+	StateBlocked       = "blocked"        // a real file or directory is in the way
+	StateSourceMissing = "source-missing" // the source does not exist
+)
+
+// Mechanisms that link a target to its source
+const (
+	MechanismSymlink  = "symlink"
+	MechanismJunction = "junction" // Windows directory junction
+	MechanismHardlink = "hardlink"
+	MechanismCopy     = "copy"
+)
+
+// fallbackAllowed reports whether Apply may use a junction, hard link or copy
+// when creating a symlink fails. Only Windows restricts symlinks.
+var fallbackAllowed = runtime.GOOS == "windows"
+
+// Link creation functions, replaceable in tests
+var (
+	symlink  = os.Symlink
+	hardlink = os.Link
+	junction = func(source, target string) error {
+		return exec.Command("cmd", "/c", "mklink", "/J", target, source).Run()
+	}
+)
+
+// Link is one entry in links.yaml
+type Link struct {
+	Source       string   `yaml:"source"`
+	Target       string   `yaml:"target"`
+	Platforms    []string `yaml:"platforms,omitempty"`
+	Backup       string   `yaml:"backup,omitempty"`
+	Optional     bool     `yaml:"optional,omitempty"`
+	Feature      string   `yaml:"feature,omitempty"`
+	CreateSource bool     `yaml:"create_source,omitempty"`
+}
+
+// File is a parsed links.yaml
+type File struct {
+	Backup string `yaml:"backup,omitempty"`
+	Links  []Link `yaml:"links"`
+}
+
+// Root is a directory that may contain links.yaml
+type Root struct {
+	Name string
+	Path string
+}
+
+// Resolved is a link with absolute paths, ready to check or apply
+type Resolved struct {
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	Backup       string `json:"backup"`
+	Optional     bool   `json:"optional,omitempty"`
+	CreateSource bool   `json:"-"`
+	Root         string `json:"root"`
+}
+
+// Options control how declarations are resolved
+type Options struct {
+	// Vars expand ${NAME} in sources and targets; the environment is the
+	// fallback
+	Vars map[string]string
+	// GOOS filters by platform (defaults to runtime.GOOS)
+	GOOS string
+	// FeatureEnabled reports whether a feature gate is on; nil treats every
+	// feature as enabled
+	FeatureEnabled func(name string) bool
+}
+
+// Load parses a links.yaml file
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := validPolicy(f.Backup); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, l := range f.Links {
+		if l.Source == "" || l.Target == "" {
+			return nil, fmt.Errorf("%s: link %d needs source and target", path, i+1)
+		}
+		if err := validPolicy(l.Backup); err != nil {
+			return nil, fmt.Errorf("%s: link %s: %w", path, l.Target, err)
+		}
+	}
+	return &f, nil
+}
+
+func validPolicy(p string) error {
+	switch p {
+	case "", BackupRename, BackupSkip, BackupOverwrite:
+		return nil
+	}
+	return fmt.Errorf("unknown backup policy %q (rename, skip, overwrite)", p)
+}
+
+// Resolve loads links.yaml from each root and returns the links that apply
+// to this machine, sorted by target. Missing links.yaml files are ignored.
+func Resolve(roots []Root, opts Options) ([]Resolved, error) {
+	goos := opts.GOOS
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+
+	byTarget := map[string]Resolved{}
+	for _, root := range roots {
+		f, err := Load(filepath.Join(root.Path, FileName))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, l := range f.Links {
+			if !matchesPlatform(l.Platforms, goos) {
+				continue
+			}
+			if l.Feature != "" && opts.FeatureEnabled != nil && !opts.FeatureEnabled(l.Feature) {
+				continue
+			}
+			policy := l.Backup
+			if policy == "" {
+				policy = f.Backup
+			}
+			if policy == "" {
+				policy = BackupRename
+			}
+
+			for _, pair := range expandLink(l, root.Path, opts.Vars) {
+				byTarget[pair[1]] = Resolved{
+					Source:       pair[0],
+					Target:       pair[1],
+					Backup:       policy,
+					Optional:     l.Optional,
+					CreateSource: l.CreateSource,
+					Root:         root.Name,
+				}
+			}
+		}
+	}
+
+	resolved := make([]Resolved, 0, len(byTarget))
+	for _, r := range byTarget {
+		resolved = append(resolved, r)
+	}
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].Target < resolved[j].Target })
+	return resolved, nil
+}
+
+// matchesPlatform reports whether a platform filter includes goos. "unix"
+// matches every non-Windows platform.
+func matchesPlatform(platforms []string, goos string) bool {
+	if len(platforms) == 0 {
+		return true
+	}
+	for _, p := range platforms {
+		p = strings.ToLower(p)
+		if p == goos || (p == "unix" && goos != "windows") || (p == "macos" && goos == "darwin") {
+			return true
+		}
+	}
+	return false
+}
+
+// expandLink returns absolute (source, target) pairs for a link, expanding
+// variables and globs
+func expandLink(l Link, rootDir string, vars map[string]string) [][2]string {
+	source := expandPath(l.Source, vars)
+	if !filepath.IsAbs(source) {
+		source = filepath.Join(rootDir, source)
+	}
+	target := expandPath(l.Target, vars)
+	intoDir := strings.HasSuffix(l.Target, "/") || strings.HasSuffix(l.Target, `\`)
+
+	var sources []string
+	if strings.ContainsAny(source, "*?[") {
+		sources, _ = filepath.Glob(source)
+		sort.Strings(sources)
+		intoDir = true
+	} else {
+		sources = []string{source}
+	}
+
+	pairs := make([][2]string, 0, len(sources))
+	for _, s := range sources {
+		t := target
+		if intoDir {
+			t = filepath.Join(target, filepath.Base(s))
+		}
+		pairs = append(pairs, [2]string{filepath.Clean(s), filepath.Clean(t)})
+	}
+	return pairs
+}
+
+// expandPath expands ~ and ${VAR}, preferring vars over the environment
+func expandPath(p string, vars map[string]string) string {
+	p = os.Expand(p, func(name string) string {
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return os.Getenv(name)
+	})
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, `~\`) {
+		home, _ := os.UserHomeDir()
+		p = filepath.Join(home, p[1:])
+	}
+	return p
+}
+
+// State returns the current state of a resolved link and, for a symlink,
+// where it points
+func State(r Resolved) (string, string) {
+	state, actual, _ := Inspect(r)
+	return state, actual
+}
+
+// Inspect returns the state of a resolved link, where it points, and the
+// mechanism linking it (empty when nothing links the target)
+func Inspect(r Resolved) (state, actual, mechanism string) {
+	info, err := os.Lstat(r.Target)
+	if err != nil {
+		if _, serr := os.Stat(r.Source); serr != nil && !r.CreateSource {
+			return StateSourceMissing, "", ""
+		}
+		return StateMissing, "", ""
+	}
+
+	mechanism = MechanismSymlink
+	if info.Mode()&os.ModeSymlink == 0 {
+		// Junctions are not reported as symlinks but can still be read
+		if _, err := os.Readlink(r.Target); err == nil {
+			mechanism = MechanismJunction
+		} else if m := fileMechanism(r, info); m != "" {
+			return StateOK, r.Source, m
+		} else {
+			return StateBlocked, "", ""
+		}
+	}
+
+	actual, _ = os.Readlink(r.Target)
+	if !filepath.IsAbs(actual) {
+		actual = filepath.Join(filepath.Dir(r.Target), actual)
+	}
+	if !samePath(actual, r.Source) {
+		return StateWrongTarget, actual, mechanism
+	}
+	if _, err := os.Stat(r.Source); err != nil && !r.CreateSource {
+		return StateSourceMissing, actual, mechanism
+	}
+	return StateOK, actual, mechanism
+}
+
+// fileMechanism recognises a regular file that is a hard link to the source
+// or, where copies are a fallback, an identical copy of it
+func fileMechanism(r Resolved, info os.FileInfo) string {
+	if !info.Mode().IsRegular() {
+		return ""
+	}
+	sourceInfo, err := os.Stat(r.Source)
+	if err != nil || !sourceInfo.Mode().IsRegular() {
+		return ""
+	}
+	if os.SameFile(info, sourceInfo) {
+		return MechanismHardlink
+	}
+	if fallbackAllowed && info.Size() == sourceInfo.Size() {
+		a, errA := os.ReadFile(r.Source)
+		b, errB := os.ReadFile(r.Target)
+		if errA == nil && errB == nil && bytes.Equal(a, b) {
+			return MechanismCopy
+		}
+	}
+	return ""
+}
+
+// samePath compares paths after cleaning and resolving symlinks where possible
+func samePath(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	ra, errA := filepath.EvalSymlinks(a)
+	rb, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && ra == rb
+}
+
+// Result describes what Apply or Remove did (or would do)
+type Result struct {
+	Link      Resolved `json:"link"`
+	State     string   `json:"state"`
+	Action    string   `json:"action"`
+	Mechanism string   `json:"mechanism,omitempty"`
+	Backup    string   `json:"backup,omitempty"`
+	Err       error    `json:"-"`
+}
+
+// Actions reported in a Result
+const (
+	ActionNone     = "none"
+	ActionCreated  = "created"
+	ActionReplaced = "replaced"
+	ActionSkipped  = "skipped"
+	ActionRemoved  = "removed"
+	ActionRestored = "restored"
+	ActionFailed   = "failed"
+)
+
+// Apply creates or repairs a link according to its backup policy. With
+// dryRun nothing is changed but the Result says what would happen.
+func Apply(r Resolved, dryRun bool) Result {
+	state, _, mechanism := Inspect(r)
+	res := Result{Link: r, State: state, Action: ActionNone, Mechanism: mechanism}
+
+	switch state {
+	case StateOK:
+		return res
+	case StateSourceMissing:
+		res.Action = ActionSkipped
+		res.Err = fmt.Errorf("source does not exist: %s", r.Source)
+		return res
+	case StateBlocked:
+		if r.Backup == BackupSkip {
+			res.Action = ActionSkipped
+			res.Err = fmt.Errorf("%s exists and is not a symlink (backup: skip)", r.Target)
+			return res
+		}
+	}
+
+	res.Action = ActionCreated
+	if state != StateMissing {
+		res.Action = ActionReplaced
+	}
+	if dryRun {
+		if state == StateBlocked && r.Backup == BackupRename {
+			res.Backup = backupName(r.Target)
+		}
+		return res
+	}
+
+	fail := func(err error) Result {
+		res.Action = ActionFailed
+		res.Err = err
+		return res
+	}
+
+	if r.CreateSource {
+		if err := os.MkdirAll(r.Source, 0755); err != nil {
+			return fail(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(r.Target), 0755); err != nil {
+		return fail(err)
+	}
+
+	switch state {
+	case StateWrongTarget:
+		if err := os.Remove(r.Target); err != nil {
+			return fail(err)
+		}
+	case StateBlocked:
+		if r.Backup == BackupRename {
+			res.Backup = backupName(r.Target)
+			if err := os.Rename(r.Target, res.Backup); err != nil {
+				return fail(err)
+			}
+		} else if err := os.RemoveAll(r.Target); err != nil {
+			return fail(err)
+		}
+	}
+
+	mechanism, err := createLink(r.Source, r.Target)
+	if err != nil {
+		// Put a renamed file back so a failed apply loses nothing
+		if res.Backup != "" {
+			os.Rename(res.Backup, r.Target)
+			res.Backup = ""
+		}
+		return fail(err)
+	}
+	res.Mechanism = mechanism
+	return res
+}
+
+// createLink symlinks target to source. Where symlinks are restricted it
+// falls back to a junction for directories, then a hard link or a copy for
+// files, and returns the mechanism used.
+func createLink(source, target string) (string, error) {
+	err := symlink(source, target)
+	if err == nil {
+		return MechanismSymlink, nil
+	}
+	if !fallbackAllowed {
+		return "", err
+	}
+
+	info, serr := os.Stat(source)
+	if serr != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		if jerr := junction(source, target); jerr != nil {
+			return "", fmt.Errorf("symlink: %v; junction: %v", err, jerr)
+		}
+		return MechanismJunction, nil
+	}
+	if hardlink(source, target) == nil {
+		return MechanismHardlink, nil
+	}
+	if cerr := copyFile(source, target, info.Mode().Perm()); cerr != nil {
+		return "", fmt.Errorf("symlink: %v; copy: %v", err, cerr)
+	}
+	return MechanismCopy, nil
+}
+
+// copyFile copies source to target, the last resort when neither a symlink
+// nor a hard link can be created
+func copyFile(source, target string, perm os.FileMode) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(target)
+		return err
+	}
+	return out.Close()
+}
+
+// Remove deletes a link if it points at the declared source. With restore,
+// the most recent <target>.bak-* is moved back into place.
+func Remove(r Resolved, restore, dryRun bool) Result {
+	state, _, mechanism := Inspect(r)
+	res := Result{Link: r, State: state, Action: ActionNone, Mechanism: mechanism}
+
+	// Only remove links we own
+	if mechanism == "" || (state != StateOK && state != StateSourceMissing) {
+		return res
+	}
+
+	res.Action = ActionRemoved
+	latest := ""
+	if restore {
+		if latest = LatestBackup(r.Target); latest != "" {
+			res.Action = ActionRestored
+			res.Backup = latest
+		}
+	}
+	if dryRun {
+		return res
+	}
+
+	if err := os.Remove(r.Target); err != nil {
+		res.Action = ActionFailed
+		res.Err = err
+		return res
+	}
+	if latest != "" {
+		if err := os.Rename(latest, r.Target); err != nil {
+			res.Action = ActionFailed
+			res.Err = fmt.Errorf("link removed but restoring %s failed: %w", latest, err)
+		}
+	}
+	return res
+}
+
+// backupName returns the path an existing target is renamed to. The format
+// matches the .bak-<timestamp> files the bootstrap script created.
+func backupName(target string) string {
+	name := target + ".bak-" + time.Now().Format("20060102150405")
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(name); os.IsNotExist(err) {
+			return name
+		}
+		name = fmt.Sprintf("%s.bak-%s-%d", target, time.Now().Format("20060102150405"), i)
+	}
+}
+
+// LatestBackup returns the newest <target>.bak-* path, or ""
+func LatestBackup(target string) string {
+	matches, _ := filepath.Glob(target + ".bak-*")
+	if len(matches) == 0 {
+		return ""
+	}
+	sort.Strings(matches)
+	return matches[len(matches)-1]
+}
@@ -0,0 +1,278 @@
+package links
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestResolve verifies platform filters, globs, variables, feature gates and
+// that later roots override earlier ones by target
+func TestResolve(t *testing.T) {
+	base := t.TempDir()
+	overlay := t.TempDir()
+	home := t.TempDir()
+
+	writeFile(t, filepath.Join(base, "zsh/zshrc"), "")
+	writeFile(t, filepath.Join(base, "cmds/a.md"), "")
+	writeFile(t, filepath.Join(base, "cmds/b.md"), "")
+	writeFile(t, filepath.Join(overlay, "zshrc"), "")
+	writeFile(t, filepath.Join(base, FileName), `backup: skip
+links:
+  - source: zsh/zshrc
+    target: ${HOME}/.zshrc
+    platforms: [unix]
+  - source: win.ps1
+    target: ${HOME}/profile.ps1
+    platforms: [windows]
+  - source: cmds/*.md
+    target: ${HOME}/cmds/
+  - source: gated
+    target: ${HOME}/gated
+    feature: off
+`)
+	writeFile(t, filepath.Join(overlay, FileName), `links:
+  - source: zshrc
+    target: ${HOME}/.zshrc
+    backup: overwrite
+`)
+
+	opts := Options{
+		Vars:           map[string]string{"HOME": home},
+		GOOS:           "linux",
+		FeatureEnabled: func(name string) bool { return name != "off" },
+	}
+	resolved, err := Resolve([]Root{{Name: "base", Path: base}, {Name: "work", Path: overlay}}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]Resolved{}
+	for _, r := range resolved {
+		got[r.Target] = r
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 links, got %d: %+v", len(got), resolved)
+	}
+
+	zshrc := got[filepath.Join(home, ".zshrc")]
+	if zshrc.Root != "work" || zshrc.Source != filepath.Join(overlay, "zshrc") || zshrc.Backup != BackupOverwrite {
+		t.Errorf("overlay should win for .zshrc, got %+v", zshrc)
+	}
+	if r := got[filepath.Join(home, "cmds", "a.md")]; r.Source != filepath.Join(base, "cmds", "a.md") || r.Backup != BackupSkip {
+		t.Errorf("glob link wrong: %+v", r)
+	}
+	if _, ok := got[filepath.Join(home, "profile.ps1")]; ok {
+		t.Error("windows-only link should be filtered on linux")
+	}
+}
+
+// TestLoadRejectsUnknownPolicy verifies backup policies are validated
+func TestLoadRejectsUnknownPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, "links:\n  - source: a\n    target: b\n    backup: shred\n")
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for unknown backup policy")
+	}
+}
+
+// TestApplyPolicies verifies rename, skip and overwrite for a blocked target
+func TestApplyPolicies(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source")
+	writeFile(t, source, "repo")
+
+	for _, policy := range []string{BackupRename, BackupSkip, BackupOverwrite} {
+		t.Run(policy, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "target")
+			writeFile(t, target, "local")
+			r := Resolved{Source: source, Target: target, Backup: policy}
+
+			if state, _ := State(r); state != StateBlocked {
+				t.Fatalf("state = %s, want %s", state, StateBlocked)
+			}
+
+			res := Apply(r, false)
+			state, _ := State(r)
+			switch policy {
+			case BackupSkip:
+				if res.Action != ActionSkipped || state != StateBlocked {
+					t.Errorf("skip: action=%s state=%s", res.Action, state)
+				}
+			case BackupRename:
+				if res.Action != ActionReplaced || state != StateOK || res.Backup == "" {
+					t.Fatalf("rename: %+v state=%s", res, state)
+				}
+				if data, _ := os.ReadFile(res.Backup); string(data) != "local" {
+					t.Errorf("backup content = %q", data)
+				}
+			case BackupOverwrite:
+				if res.Action != ActionReplaced || state != StateOK || res.Backup != "" {
+					t.Errorf("overwrite: %+v state=%s", res, state)
+				}
+			}
+		})
+	}
+}
+
+// TestApplyDryRunAndWrongTarget verifies dry runs change nothing and a link
+// pointing elsewhere is repaired
+func TestApplyDryRunAndWrongTarget(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source")
+	other := filepath.Join(dir, "other")
+	writeFile(t, source, "")
+	writeFile(t, other, "")
+	target := filepath.Join(dir, "nested", "target")
+
+	r := Resolved{Source: source, Target: target, Backup: BackupRename}
+	if res := Apply(r, true); res.Action != ActionCreated {
+		t.Errorf("dry run action = %s", res.Action)
+	}
+	if _, err := os.Lstat(target); !os.IsNotExist(err) {
+		t.Fatal("dry run created the link")
+	}
+
+	os.MkdirAll(filepath.Dir(target), 0755)
+	os.Symlink(other, target)
+	if state, actual := State(r); state != StateWrongTarget || actual != other {
+		t.Fatalf("state = %s (%s)", state, actual)
+	}
+	if res := Apply(r, false); res.Action != ActionReplaced || res.Err != nil {
+		t.Fatalf("apply: %+v", res)
+	}
+	if state, _ := State(r); state != StateOK {
+		t.Errorf("state after apply = %s", state)
+	}
+}
+
+// TestRemoveRestore verifies remove only deletes owned links and restores
+// the latest backup
+func TestRemoveRestore(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source")
+	target := filepath.Join(dir, "target")
+	writeFile(t, source, "repo")
+	writeFile(t, target, "local")
+
+	r := Resolved{Source: source, Target: target, Backup: BackupRename}
+	if res := Remove(r, true, false); res.Action != ActionNone {
+		t.Fatalf("removing a real file: action = %s", res.Action)
+	}
+
+	if res := Apply(r, false); res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	res := Remove(r, true, false)
+	if res.Action != ActionRestored {
+		t.Fatalf("action = %s", res.Action)
+	}
+	info, err := os.Lstat(target)
+	if err != nil || info.Mode()&os.ModeSymlink != 0 {
+		t.Fatalf("target should be a regular file again: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "local" {
+		t.Errorf("restored content = %q", data)
+	}
+}
+
+// withRestrictedSymlinks simulates Windows without Developer Mode
+func withRestrictedSymlinks(t *testing.T) {
+	t.Helper()
+	origSymlink, origHardlink, origJunction, origFallback := symlink, hardlink, junction, fallbackAllowed
+	t.Cleanup(func() {
+		symlink, hardlink, junction, fallbackAllowed = origSymlink, origHardlink, origJunction, origFallback
+	})
+	symlink = func(string, string) error { return errors.New("a required privilege is not held by the client") }
+	junction = os.Symlink
+	fallbackAllowed = true
+}
+
+// TestApplyFallbackMechanisms verifies directories fall back to junctions
+// and files to hard links, then copies, and that Inspect recognises them
+func TestApplyFallbackMechanisms(t *testing.T) {
+	withRestrictedSymlinks(t)
+	dir := t.TempDir()
+
+	srcDir := filepath.Join(dir, "module")
+	writeFile(t, filepath.Join(srcDir, "Blackdot.psm1"), "")
+	res := Apply(Resolved{Source: srcDir, Target: filepath.Join(dir, "Modules", "Blackdot"), Backup: BackupRename}, false)
+	if res.Err != nil || res.Mechanism != MechanismJunction {
+		t.Errorf("directory: %+v", res)
+	}
+
+	srcFile := filepath.Join(dir, "settings.json")
+	writeFile(t, srcFile, "{}")
+	r := Resolved{Source: srcFile, Target: filepath.Join(dir, "LocalState", "settings.json"), Backup: BackupRename}
+	if res := Apply(r, false); res.Err != nil || res.Mechanism != MechanismHardlink {
+		t.Fatalf("file: %+v", res)
+	}
+	if state, _, mechanism := Inspect(r); state != StateOK || mechanism != MechanismHardlink {
+		t.Errorf("Inspect = %s, %s", state, mechanism)
+	}
+
+	hardlink = func(string, string) error { return errors.New("not the same device") }
+	r.Target = filepath.Join(dir, "other-drive", "settings.json")
+	if res := Apply(r, false); res.Err != nil || res.Mechanism != MechanismCopy {
+		t.Fatalf("copy: %+v", res)
+	}
+	if state, _, mechanism := Inspect(r); state != StateOK || mechanism != MechanismCopy {
+		t.Errorf("Inspect = %s, %s", state, mechanism)
+	}
+
+	// A copy that no longer matches the source is replaced, keeping a backup
+	writeFile(t, srcFile, `{"changed": true}`)
+	if state, _ := State(r); state != StateBlocked {
+		t.Errorf("stale copy state = %s", state)
+	}
+	if res := Remove(r, false, false); res.Action != ActionNone {
+		t.Errorf("stale copy should not be removed: %s", res.Action)
+	}
+}
+
+// TestNoFallbackOffWindows verifies a failing symlink is an error elsewhere
+func TestNoFallbackOffWindows(t *testing.T) {
+	withRestrictedSymlinks(t)
+	fallbackAllowed = false
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "source"), "")
+	res := Apply(Resolved{Source: filepath.Join(dir, "source"), Target: filepath.Join(dir, "target")}, false)
+	if res.Action != ActionFailed {
+		t.Errorf("action = %s", res.Action)
+	}
+}
+
+// TestRepoLinksWindows verifies the repo's links.yaml resolves on Windows
+func TestRepoLinksWindows(t *testing.T) {
+	resolved, err := Resolve([]Root{{Name: "base", Path: "../.."}}, Options{
+		GOOS: "windows",
+		Vars: map[string]string{"LOCALAPPDATA": `C:\Users\me\AppData\Local`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, r := range resolved {
+		found[filepath.Base(r.Target)] = true
+		if filepath.Base(r.Target) == ".zshrc" {
+			t.Error("unix-only link resolved on windows")
+		}
+	}
+	for _, want := range []string{"settings.json", "Blackdot", ".gitconfig", "profile.ps1"} {
+		if !found[want] {
+			t.Errorf("missing windows link for %s", want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package paths
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Root is one dotfiles repository in the layer stack. The base repo
+// (BLACKDOT_DIR) is always first; overlays from the repos config key follow
+// in order, and later roots win when they define the same file.
+type Root struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// BaseRootName names the BLACKDOT_DIR root
+const BaseRootName = "base"
+
+// LayeredFile is a file resolved across roots
+type LayeredFile struct {
+	Rel  string // path relative to the root, e.g. zsh/zsh.d/50-work.zsh
+	Path string // absolute path in the winning root
+	Root string // name of the winning root
+}
+
+// Conflict is a relative path defined by more than one root
+type Conflict struct {
+	Rel    string   `json:"rel"`
+	Winner string   `json:"winner"`
+	Shadow []string `json:"shadowed"`
+}
+
+// Overlays reads the overlay repos from config.json in the config dir.
+// Paths starting with ~ are expanded.
+func Overlays() []Root {
+	data, err := os.ReadFile(filepath.Join(ConfigDir(), "config.json"))
+	if err != nil {
+		return nil
+	}
+	var cfg struct {
+		Repos []Root `json:"repos"`
+	}
+	if json.Unmarshal(data, &cfg) != nil {
+		return nil
+	}
+	home, _ := os.UserHomeDir()
+	roots := make([]Root, 0, len(cfg.Repos))
+	for _, r := range cfg.Repos {
+		if r.Path == "" {
+			continue
+		}
+		if strings.HasPrefix(r.Path, "~") {
+			r.Path = filepath.Join(home, r.Path[1:])
+		}
+		roots = append(roots, r)
+	}
+	return roots
+}
+
+// Roots returns the base root followed by the configured overlays
+func Roots(base string) []Root {
+	return append([]Root{{Name: BaseRootName, Path: base}}, Overlays()...)
+}
+
+// ResolveLayered merges the files matching pattern in subdir across roots.
+// Files are keyed by name within subdir; a later root replaces an earlier
+// one's file and the override is reported as a conflict. The result is
+// sorted by file name so numbered modules load in order.
+func ResolveLayered(roots []Root, subdir, pattern string) ([]LayeredFile, []Conflict) {
+	winners := make(map[string]LayeredFile)
+	defined := make(map[string][]string)
+
+	for _, root := range roots {
+		matches, _ := filepath.Glob(filepath.Join(root.Path, subdir, pattern))
+		for _, match := range matches {
+			if info, err := os.Stat(match); err != nil || info.IsDir() {
+				continue
+			}
+			rel := filepath.ToSlash(filepath.Join(subdir, filepath.Base(match)))
+			winners[rel] = LayeredFile{Rel: rel, Path: match, Root: root.Name}
+			defined[rel] = append(defined[rel], root.Name)
+		}
+	}
+
+	files := make([]LayeredFile, 0, len(winners))
+	for _, f := range winners {
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Rel < files[j].Rel })
+
+	var conflicts []Conflict
+	for rel, names := range defined {
+		if len(names) > 1 {
+			conflicts = append(conflicts, Conflict{Rel: rel, Winner: names[len(names)-1], Shadow: names[:len(names)-1]})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Rel < conflicts[j].Rel })
+
+	return files, conflicts
+}
+
+// FindLayered returns the winning path for a relative file, or "" when no
+// root defines it
+func FindLayered(roots []Root, rel string) string {
+	for i := len(roots) - 1; i >= 0; i-- {
+		p := filepath.Join(roots[i].Path, rel)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// LayeredDir is a directory merged across roots. Additive directories are
+// concatenated (every root contributes) rather than overridden, so a file in
+// more than one root is not a conflict.
+type LayeredDir struct {
+	Dir      string
+	Pattern  string
+	Additive bool
+}
+
+// LayeredDirs are the directories merged across roots
+var LayeredDirs = []LayeredDir{
+	{Dir: "zsh/zsh.d", Pattern: "*.zsh"},
+	{Dir: "templates/configs", Pattern: "*.tmpl"},
+	{Dir: "brew", Pattern: "Brewfile*", Additive: true},
+}
+
+// CollectLayered returns every root's copy of rel, base first. Used for
+// additive files such as Brewfiles.
+func CollectLayered(roots []Root, rel string) []LayeredFile {
+	var files []LayeredFile
+	for _, root := range roots {
+		p := filepath.Join(root.Path, rel)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			files = append(files, LayeredFile{Rel: filepath.ToSlash(rel), Path: p, Root: root.Name})
+		}
+	}
+	return files
+}
@@ -0,0 +1,277 @@
+// Package paths resolves where blackdot keeps its config, cache, data, and
+// state files.
+//
+// Two strategies are supported, selected by the paths.strategy config key
+// (or BLACKDOT_PATHS_STRATEGY):
+//
+//	xdg              ~/.config, ~/.cache, ~/.local/share, ~/.local/state
+//	                 (XDG_*_HOME variables honored) on every platform
+//	platform-native  ~/Library/Application Support and ~/Library/Caches on
+//	                 macOS, %APPDATA% and %LOCALAPPDATA% on Windows
+//
+// On Linux both strategies resolve to the XDG locations.
+package paths
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Strategy selects a directory layout
+type Strategy string
+
+const (
+	StrategyXDG            Strategy = "xdg"
+	StrategyPlatformNative Strategy = "platform-native"
+)
+
+// DefaultStrategy is used when nothing is configured
+const DefaultStrategy = StrategyXDG
+
+// appName is the directory name used under each base directory
+const appName = "blackdot"
+
+// goos is overridden in tests to resolve other platforms' layouts
+var goos = runtime.GOOS
+
+// Dirs holds the resolved directories for a strategy
+type Dirs struct {
+	Strategy Strategy `json:"strategy"`
+	Config   string   `json:"config"`
+	Cache    string   `json:"cache"`
+	Data     string   `json:"data"`
+	State    string   `json:"state"`
+}
+
+// ParseStrategy validates a strategy name
+func ParseStrategy(s string) (Strategy, error) {
+	switch Strategy(strings.ToLower(strings.TrimSpace(s))) {
+	case StrategyXDG:
+		return StrategyXDG, nil
+	case StrategyPlatformNative, "native":
+		return StrategyPlatformNative, nil
+	}
+	return "", fmt.Errorf("unknown paths strategy: %q (use xdg or platform-native)", s)
+}
+
+var (
+	strategyMu     sync.Mutex
+	strategyCached Strategy
+)
+
+// CurrentStrategy returns the configured strategy. It is resolved on the
+// first call and kept for the life of the process; ResetStrategy forgets
+// it.
+func CurrentStrategy() Strategy {
+	strategyMu.Lock()
+	defer strategyMu.Unlock()
+	if strategyCached == "" {
+		strategyCached = resolveStrategy()
+	}
+	return strategyCached
+}
+
+// ResetStrategy makes the next CurrentStrategy call resolve the strategy
+// again, for tests that change the environment and for commands that
+// change paths.strategy
+func ResetStrategy() {
+	strategyMu.Lock()
+	strategyCached = ""
+	strategyMu.Unlock()
+}
+
+// resolveStrategy reads the strategy from the environment or config.json.
+// The setting lives in config.json, so the XDG config file is consulted
+// first, then the platform-native one.
+func resolveStrategy() Strategy {
+	if s, err := ParseStrategy(os.Getenv("BLACKDOT_PATHS_STRATEGY")); err == nil {
+		return s
+	}
+	for _, strategy := range []Strategy{StrategyXDG, StrategyPlatformNative} {
+		configFile := filepath.Join(For(strategy).Config, "config.json")
+		if s, ok := readStrategy(configFile); ok {
+			return s
+		}
+	}
+	return DefaultStrategy
+}
+
+// readStrategy reads paths.strategy from a config.json file
+func readStrategy(configFile string) (Strategy, bool) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return "", false
+	}
+	var cfg struct {
+		Paths struct {
+			Strategy string `json:"strategy"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil || cfg.Paths.Strategy == "" {
+		return "", false
+	}
+	s, err := ParseStrategy(cfg.Paths.Strategy)
+	return s, err == nil
+}
+
+// Current returns the directories for the configured strategy
+func Current() Dirs {
+	return For(CurrentStrategy())
+}
+
+// For returns the directories a strategy resolves to on this platform
+func For(strategy Strategy) Dirs {
+	home, _ := os.UserHomeDir()
+
+	if strategy == StrategyPlatformNative {
+		switch goos {
+		case "darwin":
+			support := filepath.Join(home, "Library", "Application Support", appName)
+			return Dirs{
+				Strategy: strategy,
+				Config:   support,
+				Cache:    filepath.Join(home, "Library", "Caches", appName),
+				Data:     support,
+				State:    filepath.Join(support, "state"),
+			}
+		case "windows":
+			roaming := envOr("APPDATA", filepath.Join(home, "AppData", "Roaming"))
+			local := envOr("LOCALAPPDATA", filepath.Join(home, "AppData", "Local"))
+			return Dirs{
+				Strategy: strategy,
+				Config:   filepath.Join(roaming, appName),
+				Cache:    filepath.Join(local, appName, "cache"),
+				Data:     filepath.Join(local, appName),
+				State:    filepath.Join(local, appName, "state"),
+			}
+		}
+	}
+
+	return Dirs{
+		Strategy: strategy,
+		Config:   filepath.Join(envOr("XDG_CONFIG_HOME", filepath.Join(home, ".config")), appName),
+		Cache:    filepath.Join(envOr("XDG_CACHE_HOME", filepath.Join(home, ".cache")), appName),
+		Data:     filepath.Join(envOr("XDG_DATA_HOME", filepath.Join(home, ".local", "share")), appName),
+		State:    filepath.Join(envOr("XDG_STATE_HOME", filepath.Join(home, ".local", "state")), appName),
+	}
+}
+
+// ConfigDir returns the blackdot config directory
+func ConfigDir() string { return Current().Config }
+
+// CacheDir returns the blackdot cache directory
+func CacheDir() string { return Current().Cache }
+
+// DataDir returns the blackdot data directory
+func DataDir() string { return Current().Data }
+
+// StateDir returns the blackdot state directory
+func StateDir() string { return Current().State }
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return filepath.Clean(v)
+	}
+	return fallback
+}
+
+// Move is one directory relocation in a migration plan
+type Move struct {
+	Kind string `json:"kind"` // config, cache, data, state
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// MigrationPlan lists the directories that move when switching strategies.
+// Directories that resolve to the same place, or don't exist yet, are skipped.
+// When kinds share a source (Application Support holds both config and data
+// on macOS) it is moved once, to the first kind's destination. Nested sources
+// are ordered first so they move out before their parent does.
+func MigrationPlan(from, to Strategy) []Move {
+	src, dst := For(from), For(to)
+	pairs := []Move{
+		{"config", src.Config, dst.Config},
+		{"data", src.Data, dst.Data},
+		{"state", src.State, dst.State},
+		{"cache", src.Cache, dst.Cache},
+	}
+
+	var plan []Move
+	seen := make(map[string]bool)
+	for _, m := range pairs {
+		if m.From == m.To || seen[m.From] {
+			continue
+		}
+		if _, err := os.Stat(m.From); err != nil {
+			continue
+		}
+		seen[m.From] = true
+		plan = append(plan, m)
+	}
+	sep := string(os.PathSeparator)
+	sort.SliceStable(plan, func(i, j int) bool {
+		return strings.Count(plan[i].From, sep) > strings.Count(plan[j].From, sep)
+	})
+	return plan
+}
+
+// Migrate moves each directory in the plan. A destination that already
+// exists is merged: files are moved in only where no file exists yet, and
+// conflicting files are left in the source and reported.
+func Migrate(plan []Move) (conflicts []string, err error) {
+	for _, m := range plan {
+		if err := os.MkdirAll(filepath.Dir(m.To), 0700); err != nil {
+			return conflicts, err
+		}
+		if _, statErr := os.Stat(m.To); os.IsNotExist(statErr) {
+			if err := os.Rename(m.From, m.To); err == nil {
+				continue
+			}
+		}
+		c, err := mergeDir(m.From, m.To)
+		conflicts = append(conflicts, c...)
+		if err != nil {
+			return conflicts, err
+		}
+	}
+	return conflicts, nil
+}
+
+// mergeDir moves files from src into dst without overwriting, removing
+// src directories that end up empty
+func mergeDir(src, dst string) ([]string, error) {
+	var conflicts []string
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		from, to := filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())
+		if e.IsDir() {
+			c, err := mergeDir(from, to)
+			conflicts = append(conflicts, c...)
+			if err != nil {
+				return conflicts, err
+			}
+			continue
+		}
+		if _, err := os.Lstat(to); err == nil {
+			conflicts = append(conflicts, from)
+			continue
+		}
+		if err := os.Rename(from, to); err != nil {
+			return conflicts, err
+		}
+	}
+	os.Remove(src) // only succeeds when empty
+	return conflicts, nil
+}
@@ -0,0 +1,220 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setHome points HOME and the XDG variables at a temp directory
+func setHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	for _, key := range []string{"XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_DATA_HOME", "XDG_STATE_HOME", "BLACKDOT_PATHS_STRATEGY"} {
+		t.Setenv(key, "")
+	}
+	ResetStrategy()
+	t.Cleanup(ResetStrategy)
+	return home
+}
+
+func setGOOS(t *testing.T, value string) {
+	t.Helper()
+	orig := goos
+	goos = value
+	t.Cleanup(func() { goos = orig })
+}
+
+// TestParseStrategy verifies strategy names and aliases
+func TestParseStrategy(t *testing.T) {
+	for input, want := range map[string]Strategy{
+		"xdg":             StrategyXDG,
+		"XDG":             StrategyXDG,
+		"platform-native": StrategyPlatformNative,
+		"native":          StrategyPlatformNative,
+	} {
+		got, err := ParseStrategy(input)
+		if err != nil || got != want {
+			t.Errorf("ParseStrategy(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseStrategy("library"); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
+
+// TestForXDG verifies XDG defaults and overrides
+func TestForXDG(t *testing.T) {
+	home := setHome(t)
+
+	dirs := For(StrategyXDG)
+	if dirs.Config != filepath.Join(home, ".config", "blackdot") {
+		t.Errorf("Config = %s", dirs.Config)
+	}
+	if dirs.State != filepath.Join(home, ".local", "state", "blackdot") {
+		t.Errorf("State = %s", dirs.State)
+	}
+
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+	if got := For(StrategyXDG).Cache; got != filepath.Join(home, "cache", "blackdot") {
+		t.Errorf("Cache with XDG_CACHE_HOME = %s", got)
+	}
+}
+
+// TestForPlatformNative verifies macOS and Linux native layouts
+func TestForPlatformNative(t *testing.T) {
+	home := setHome(t)
+
+	setGOOS(t, "darwin")
+	dirs := For(StrategyPlatformNative)
+	support := filepath.Join(home, "Library", "Application Support", "blackdot")
+	if dirs.Config != support || dirs.Data != support {
+		t.Errorf("darwin config/data = %s, %s", dirs.Config, dirs.Data)
+	}
+	if dirs.Cache != filepath.Join(home, "Library", "Caches", "blackdot") {
+		t.Errorf("darwin cache = %s", dirs.Cache)
+	}
+
+	setGOOS(t, "linux")
+	if For(StrategyPlatformNative) != (Dirs{Strategy: StrategyPlatformNative, Config: For(StrategyXDG).Config,
+		Cache: For(StrategyXDG).Cache, Data: For(StrategyXDG).Data, State: For(StrategyXDG).State}) {
+		t.Error("linux platform-native should match XDG locations")
+	}
+}
+
+// TestCurrentStrategy verifies env and config.json lookup order and that
+// the result is cached until reset
+func TestCurrentStrategy(t *testing.T) {
+	home := setHome(t)
+	setGOOS(t, "darwin")
+
+	if got := CurrentStrategy(); got != DefaultStrategy {
+		t.Errorf("default = %s", got)
+	}
+
+	// Strategy recorded in the native config is found after a migration
+	nativeConfig := For(StrategyPlatformNative).Config
+	os.MkdirAll(nativeConfig, 0700)
+	os.WriteFile(filepath.Join(nativeConfig, "config.json"), []byte(`{"paths":{"strategy":"platform-native"}}`), 0600)
+	if got := CurrentStrategy(); got != DefaultStrategy {
+		t.Errorf("strategy not cached, got %s", got)
+	}
+	ResetStrategy()
+	if got := CurrentStrategy(); got != StrategyPlatformNative {
+		t.Errorf("from native config = %s", got)
+	}
+	if got := ConfigDir(); got != nativeConfig {
+		t.Errorf("ConfigDir = %s", got)
+	}
+
+	// The XDG config wins when both exist
+	xdgConfig := filepath.Join(home, ".config", "blackdot")
+	os.MkdirAll(xdgConfig, 0700)
+	os.WriteFile(filepath.Join(xdgConfig, "config.json"), []byte(`{"paths":{"strategy":"xdg"}}`), 0600)
+	ResetStrategy()
+	if got := CurrentStrategy(); got != StrategyXDG {
+		t.Errorf("from xdg config = %s", got)
+	}
+
+	t.Setenv("BLACKDOT_PATHS_STRATEGY", "platform-native")
+	ResetStrategy()
+	if got := CurrentStrategy(); got != StrategyPlatformNative {
+		t.Errorf("env override = %s", got)
+	}
+}
+
+// TestMigrate verifies directories move and existing files are not overwritten
+func TestMigrate(t *testing.T) {
+	home := setHome(t)
+	setGOOS(t, "darwin")
+
+	xdg := For(StrategyXDG)
+	os.MkdirAll(xdg.Config, 0700)
+	os.WriteFile(filepath.Join(xdg.Config, "config.json"), []byte(`{}`), 0600)
+	os.WriteFile(filepath.Join(xdg.Config, "conflict.txt"), []byte("old"), 0600)
+	os.MkdirAll(xdg.Cache, 0700)
+	os.WriteFile(filepath.Join(xdg.Cache, "vault-state.json"), []byte(`{}`), 0600)
+
+	native := For(StrategyPlatformNative)
+	os.MkdirAll(native.Config, 0700)
+	os.WriteFile(filepath.Join(native.Config, "conflict.txt"), []byte("new"), 0600)
+
+	plan := MigrationPlan(StrategyXDG, StrategyPlatformNative)
+	if len(plan) != 2 {
+		t.Fatalf("expected config and cache moves, got %+v", plan)
+	}
+
+	conflicts, err := Migrate(plan)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0] != filepath.Join(xdg.Config, "conflict.txt") {
+		t.Errorf("conflicts = %v", conflicts)
+	}
+	if _, err := os.Stat(filepath.Join(native.Config, "config.json")); err != nil {
+		t.Error("config.json was not moved")
+	}
+	if data, _ := os.ReadFile(filepath.Join(native.Config, "conflict.txt")); string(data) != "new" {
+		t.Error("existing destination file was overwritten")
+	}
+	if _, err := os.Stat(filepath.Join(home, "Library", "Caches", "blackdot", "vault-state.json")); err != nil {
+		t.Error("cache was not moved")
+	}
+	if _, err := os.Stat(xdg.Cache); !os.IsNotExist(err) {
+		t.Error("moved cache directory should no longer exist")
+	}
+}
+
+// TestResolveLayered verifies overlay order, overrides, and conflict reports
+func TestResolveLayered(t *testing.T) {
+	home := setHome(t)
+	base := filepath.Join(home, "base")
+	work := filepath.Join(home, "work")
+	for _, f := range []string{
+		filepath.Join(base, "zsh", "zsh.d", "10-plugins.zsh"),
+		filepath.Join(base, "zsh", "zsh.d", "40-aliases.zsh"),
+		filepath.Join(work, "zsh", "zsh.d", "40-aliases.zsh"),
+		filepath.Join(work, "zsh", "zsh.d", "55-work.zsh"),
+		filepath.Join(work, "zsh", "zsh.d", "notes.txt"),
+	} {
+		os.MkdirAll(filepath.Dir(f), 0755)
+		os.WriteFile(f, []byte("# "+f), 0644)
+	}
+
+	configDir := filepath.Join(home, ".config", "blackdot")
+	os.MkdirAll(configDir, 0700)
+	os.WriteFile(filepath.Join(configDir, "config.json"), []byte(`{"repos":[{"name":"work","path":"~/work"}]}`), 0600)
+
+	roots := Roots(base)
+	if len(roots) != 2 || roots[1].Path != work {
+		t.Fatalf("roots = %+v", roots)
+	}
+
+	files, conflicts := ResolveLayered(roots, "zsh/zsh.d", "*.zsh")
+	var got []string
+	for _, f := range files {
+		got = append(got, filepath.Base(f.Path)+"@"+f.Root)
+	}
+	want := []string{"10-plugins.zsh@base", "40-aliases.zsh@work", "55-work.zsh@work"}
+	if len(got) != len(want) {
+		t.Fatalf("files = %v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("files[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+
+	if len(conflicts) != 1 || conflicts[0].Rel != "zsh/zsh.d/40-aliases.zsh" || conflicts[0].Winner != "work" {
+		t.Errorf("conflicts = %+v", conflicts)
+	}
+
+	if p := FindLayered(roots, "zsh/zsh.d/10-plugins.zsh"); p != filepath.Join(base, "zsh", "zsh.d", "10-plugins.zsh") {
+		t.Errorf("FindLayered fell through to %s", p)
+	}
+	if n := len(CollectLayered(roots, "zsh/zsh.d/40-aliases.zsh")); n != 2 {
+		t.Errorf("CollectLayered = %d copies", n)
+	}
+}
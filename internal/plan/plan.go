@@ -0,0 +1,101 @@
+// Package plan describes the changes a command would make, so every dry
+// run reports them the same way.
+//
+// A Plan is a list of operations, each naming its target and what it is
+// now and would become:
+//
+//	{"kind": "chmod", "target": "/home/me/.ssh/id_ed25519", "before": "0644", "after": "0600"}
+//
+// Commands record operations while they walk what they would do and print
+// the plan at the end, as a table or as JSON for scripts.
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Kind is what an operation does to its target
+type Kind string
+
+// Operation kinds, in the order summaries list them
+const (
+	Create  Kind = "create"
+	Update  Kind = "update"
+	Delete  Kind = "delete"
+	Chmod   Kind = "chmod"
+	Symlink Kind = "symlink"
+)
+
+// Kinds lists every operation kind
+var Kinds = []Kind{Create, Update, Delete, Chmod, Symlink}
+
+// Op is one change. Target is a path, or vault:<item> for vault items.
+// Before and After are short descriptions of the target's state, such as
+// a size, a mode, or a link destination; either is empty when it does
+// not exist or is unknown.
+type Op struct {
+	Kind   Kind   `json:"kind"`
+	Target string `json:"target"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Plan is the changes one command would make
+type Plan struct {
+	Command string `json:"command"`
+	Ops     []Op   `json:"ops"`
+}
+
+// New starts an empty plan for command
+func New(command string) *Plan {
+	return &Plan{Command: command, Ops: []Op{}}
+}
+
+// Add records op. Adding to a nil plan does nothing, so commands can
+// record unconditionally and only keep a plan when dry-running.
+func (p *Plan) Add(op Op) {
+	if p == nil {
+		return
+	}
+	p.Ops = append(p.Ops, op)
+}
+
+// Counts returns how many operations of each kind the plan holds
+func (p *Plan) Counts() map[Kind]int {
+	counts := make(map[Kind]int)
+	for _, op := range p.Ops {
+		counts[op.Kind]++
+	}
+	return counts
+}
+
+// Summary describes the plan in one line, e.g. "3 change(s): 1 create,
+// 2 chmod"
+func (p *Plan) Summary() string {
+	if len(p.Ops) == 0 {
+		return "no changes"
+	}
+	counts := p.Counts()
+	var parts []string
+	for _, kind := range Kinds {
+		if n := counts[kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	return fmt.Sprintf("%d change(s): %s", len(p.Ops), strings.Join(parts, ", "))
+}
+
+// WriteJSON writes the plan and its per-kind counts as indented JSON
+func (p *Plan) WriteJSON(w io.Writer) error {
+	out := struct {
+		*Plan
+		Summary map[Kind]int `json:"summary"`
+	}{p, p.Counts()}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
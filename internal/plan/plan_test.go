@@ -0,0 +1,44 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// TestPlan verifies nil plans ignore operations, the summary counts kinds
+// in order, and the JSON carries the operations and counts
+func TestPlan(t *testing.T) {
+	var none *Plan
+	none.Add(Op{Kind: Create, Target: "/tmp/x"})
+
+	p := New("links apply")
+	if got := p.Summary(); got != "no changes" {
+		t.Errorf("empty summary = %q", got)
+	}
+	p.Add(Op{Kind: Chmod, Target: "/home/me/.ssh", Before: "0755", After: "0700"})
+	p.Add(Op{Kind: Create, Target: "/home/me/.gitconfig", After: "1.2 KB"})
+	p.Add(Op{Kind: Chmod, Target: "/home/me/.aws/credentials", Before: "0644", After: "0600"})
+	if got, want := p.Summary(), "3 change(s): 1 create, 2 chmod"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Command string         `json:"command"`
+		Ops     []Op           `json:"ops"`
+		Summary map[string]int `json:"summary"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Command != "links apply" || len(decoded.Ops) != 3 || decoded.Ops[0].Before != "0755" {
+		t.Errorf("decoded = %+v", decoded)
+	}
+	if decoded.Summary["chmod"] != 2 || decoded.Summary["create"] != 1 {
+		t.Errorf("summary counts = %v", decoded.Summary)
+	}
+}
@@ -0,0 +1,286 @@
+// Package prompts asks the user questions: yes/no confirmations, free text
+// with defaults, numbered selections and type-to-confirm checks.
+//
+// Every prompt reads from one shared reader, so answers piped on stdin are
+// not lost between prompts, and tests swap the IO with SetDefault.
+//
+// Non-interactive policy:
+//
+//	BLACKDOT_NONINTERACTIVE=1  answer every prompt with its default
+//	BLACKDOT_ASSUME_YES=1      also answer yes to confirmations
+//
+// A prompt without a usable default returns ErrNonInteractive instead of
+// guessing. Type-to-confirm prompts are never answered automatically.
+// End of input is treated like an empty answer.
+package prompts
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNonInteractive is returned when a prompt needs an answer that the
+// non-interactive policy cannot supply
+var ErrNonInteractive = errors.New("prompt needs an answer but input is non-interactive (set a default or run interactively)")
+
+// Prompter asks questions on Out and reads answers from In
+type Prompter struct {
+	in  *bufio.Reader
+	Out io.Writer
+	// Interactive is false when answers must come from defaults
+	Interactive bool
+	// AssumeYes answers yes to Confirm when not interactive
+	AssumeYes bool
+	// hideInput disables terminal echo for Secret; only the real stdin
+	// prompter sets it
+	hideInput bool
+}
+
+// New returns an interactive prompter over in and out
+func New(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{in: bufio.NewReader(in), Out: out, Interactive: true}
+}
+
+var (
+	mu       sync.Mutex
+	defaultP *Prompter
+)
+
+// Default returns the shared prompter: stdin, stderr, and the policy from
+// BLACKDOT_NONINTERACTIVE and BLACKDOT_ASSUME_YES
+func Default() *Prompter {
+	mu.Lock()
+	defer mu.Unlock()
+	if defaultP == nil {
+		defaultP = New(os.Stdin, os.Stderr)
+		defaultP.hideInput = true
+		defaultP.Interactive = !envTrue("BLACKDOT_NONINTERACTIVE")
+		defaultP.AssumeYes = envTrue("BLACKDOT_ASSUME_YES")
+		if defaultP.AssumeYes {
+			defaultP.Interactive = false
+		}
+	}
+	return defaultP
+}
+
+// SetDefault replaces the shared prompter and returns a function that
+// restores the previous one. Intended for tests.
+func SetDefault(p *Prompter) (restore func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	prev := defaultP
+	defaultP = p
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		defaultP = prev
+	}
+}
+
+func envTrue(name string) bool {
+	switch strings.ToLower(os.Getenv(name)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// readLine reads one trimmed line. ok is false at end of input with
+// nothing read.
+func (p *Prompter) readLine() (string, bool) {
+	line, err := p.in.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if err != nil && line == "" {
+		return "", false
+	}
+	return line, true
+}
+
+// Confirm asks a yes/no question. An empty answer, or no answer in
+// non-interactive mode, returns def.
+func (p *Prompter) Confirm(question string, def bool) (bool, error) {
+	if !p.Interactive {
+		return def || p.AssumeYes, nil
+	}
+
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	for {
+		fmt.Fprintf(p.Out, "%s %s ", question, hint)
+		answer, ok := p.readLine()
+		if !ok {
+			fmt.Fprintln(p.Out)
+			return def, nil
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(p.Out, "Please answer y or n.")
+	}
+}
+
+// Input asks for a line of text. An empty answer returns def.
+func (p *Prompter) Input(question, def string) (string, error) {
+	if !p.Interactive {
+		if def == "" {
+			return "", ErrNonInteractive
+		}
+		return def, nil
+	}
+
+	if def != "" {
+		fmt.Fprintf(p.Out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.Out, "%s: ", question)
+	}
+	answer, ok := p.readLine()
+	if !ok {
+		fmt.Fprintln(p.Out)
+	}
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// Secret asks for a line of text without echoing it on a terminal. It has
+// no default and fails in non-interactive mode.
+func (p *Prompter) Secret(question string) (string, error) {
+	if !p.Interactive {
+		return "", ErrNonInteractive
+	}
+	fmt.Fprintf(p.Out, "%s: ", question)
+	if p.hideInput {
+		defer echo(true)
+		echo(false)
+	}
+	answer, _ := p.readLine()
+	fmt.Fprintln(p.Out)
+	return answer, nil
+}
+
+// echo toggles terminal echo with stty where available
+func echo(on bool) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	cmd.Run()
+}
+
+// Select prints numbered options and returns the chosen index. The answer
+// may be a number or an option's first word (case-insensitive). def is
+// the index chosen by an empty answer; -1 means there is no default.
+func (p *Prompter) Select(question string, options []string, def int) (int, error) {
+	if len(options) == 0 {
+		return -1, errors.New("no options to select from")
+	}
+	if def >= len(options) {
+		def = -1
+	}
+	if !p.Interactive {
+		if def < 0 {
+			return -1, ErrNonInteractive
+		}
+		return def, nil
+	}
+
+	for i, opt := range options {
+		fmt.Fprintf(p.Out, "  %d) %s\n", i+1, opt)
+	}
+	fmt.Fprintln(p.Out)
+
+	for {
+		if def >= 0 {
+			fmt.Fprintf(p.Out, "%s [%d]: ", question, def+1)
+		} else {
+			fmt.Fprintf(p.Out, "%s [1-%d]: ", question, len(options))
+		}
+		answer, ok := p.readLine()
+		if !ok {
+			fmt.Fprintln(p.Out)
+			if def < 0 {
+				return -1, io.ErrUnexpectedEOF
+			}
+			return def, nil
+		}
+		if answer == "" && def >= 0 {
+			return def, nil
+		}
+		if i := matchOption(answer, options); i >= 0 {
+			return i, nil
+		}
+		fmt.Fprintf(p.Out, "Invalid selection: %s\n", answer)
+	}
+}
+
+func matchOption(answer string, options []string) int {
+	if n, err := strconv.Atoi(answer); err == nil {
+		if n >= 1 && n <= len(options) {
+			return n - 1
+		}
+		return -1
+	}
+	for i, opt := range options {
+		if fields := strings.Fields(opt); len(fields) > 0 && strings.EqualFold(fields[0], answer) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ConfirmText asks the user to type expected exactly, for destructive
+// operations. It never succeeds in non-interactive mode.
+func (p *Prompter) ConfirmText(question, expected string) (bool, error) {
+	if !p.Interactive {
+		return false, ErrNonInteractive
+	}
+	fmt.Fprintf(p.Out, "%s ", question)
+	answer, _ := p.readLine()
+	return answer == expected, nil
+}
+
+// Confirm asks a yes/no question on the default prompter
+func Confirm(question string, def bool) (bool, error) {
+	return Default().Confirm(question, def)
+}
+
+// Input asks for text on the default prompter
+func Input(question, def string) (string, error) {
+	return Default().Input(question, def)
+}
+
+// Secret asks for hidden text on the default prompter
+func Secret(question string) (string, error) {
+	return Default().Secret(question)
+}
+
+// Select asks for one of options on the default prompter
+func Select(question string, options []string, def int) (int, error) {
+	return Default().Select(question, options, def)
+}
+
+// ConfirmText asks for exact text on the default prompter
+func ConfirmText(question, expected string) (bool, error) {
+	return Default().ConfirmText(question, expected)
+}
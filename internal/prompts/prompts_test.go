@@ -0,0 +1,114 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestConfirmDefaults verifies empty answers, end of input and retries
+// fall back to the default
+func TestConfirmDefaults(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		def   bool
+		want  bool
+	}{
+		{"\n", false, false},
+		{"\n", true, true},
+		{"", true, true}, // EOF
+		{"YES\n", false, true},
+		{"n\n", true, false},
+		{"maybe\ny\n", false, true},
+	} {
+		var out bytes.Buffer
+		got, err := New(strings.NewReader(tc.input), &out).Confirm("Go?", tc.def)
+		if err != nil || got != tc.want {
+			t.Errorf("Confirm(%q, %v) = %v, %v; want %v", tc.input, tc.def, got, err, tc.want)
+		}
+	}
+
+	var out bytes.Buffer
+	New(strings.NewReader("\n"), &out).Confirm("Go?", true)
+	if !strings.Contains(out.String(), "Go? [Y/n]") {
+		t.Errorf("prompt = %q", out.String())
+	}
+}
+
+// TestSelect verifies numbers, option names, defaults and invalid answers
+func TestSelect(t *testing.T) {
+	options := []string{"bedrock - AWS Bedrock", "max - Anthropic Max"}
+	for input, want := range map[string]int{
+		"2\n":        1,
+		"MAX\n":      1,
+		"\n":         0,
+		"9\n1\n":     0,
+		"bogus\n2\n": 1,
+	} {
+		var out bytes.Buffer
+		got, err := New(strings.NewReader(input), &out).Select("Pick", options, 0)
+		if err != nil || got != want {
+			t.Errorf("Select(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+
+	var out bytes.Buffer
+	if _, err := New(strings.NewReader(""), &out).Select("Pick", options, -1); err == nil {
+		t.Error("EOF without a default should fail")
+	}
+}
+
+// TestSharedReaderKeepsPipedAnswers verifies consecutive prompts each get
+// their own line of piped input
+func TestSharedReaderKeepsPipedAnswers(t *testing.T) {
+	var out bytes.Buffer
+	p := New(strings.NewReader("alice\ny\n"), &out)
+	restore := SetDefault(p)
+	defer restore()
+
+	name, _ := Input("Name", "")
+	ok, _ := Confirm("Sure?", false)
+	if name != "alice" || !ok {
+		t.Errorf("name = %q, ok = %v", name, ok)
+	}
+}
+
+// TestNonInteractivePolicy verifies defaults are used, confirmations can
+// be assumed and prompts without defaults fail
+func TestNonInteractivePolicy(t *testing.T) {
+	var out bytes.Buffer
+	p := New(strings.NewReader("y\n"), &out)
+	p.Interactive = false
+
+	if ok, _ := p.Confirm("Delete?", false); ok {
+		t.Error("non-interactive confirm should use the default")
+	}
+	if v, _ := p.Input("Dir", "~/workspace"); v != "~/workspace" {
+		t.Errorf("Input = %q", v)
+	}
+	if _, err := p.Input("Name", ""); !errors.Is(err, ErrNonInteractive) {
+		t.Errorf("Input without default: %v", err)
+	}
+	if _, err := p.Select("Pick", []string{"a", "b"}, -1); !errors.Is(err, ErrNonInteractive) {
+		t.Errorf("Select without default: %v", err)
+	}
+
+	p.AssumeYes = true
+	if ok, _ := p.Confirm("Delete?", false); !ok {
+		t.Error("AssumeYes should confirm")
+	}
+	if ok, _ := p.ConfirmText("Type name:", "item"); ok {
+		t.Error("type-to-confirm must never be assumed")
+	}
+	if out.Len() != 0 {
+		t.Errorf("non-interactive prompts should print nothing, got %q", out.String())
+	}
+
+	t.Setenv("BLACKDOT_NONINTERACTIVE", "1")
+	restore := SetDefault(nil)
+	defer restore()
+	if Default().Interactive {
+		t.Error("BLACKDOT_NONINTERACTIVE should disable prompts")
+	}
+}
@@ -0,0 +1,194 @@
+// Package safefile writes files so that a crash or a second blackdot
+// process never leaves a destination half-written.
+//
+// Write puts the content in a temp file in the destination's directory,
+// fsyncs it, renames it over the destination, and fsyncs the directory,
+// so the destination holds either the old or the new content. Writers to
+// the same destination take turns through an advisory lock file in the
+// state directory (locks/<hash>.lock); processes that don't use this
+// package are not blocked. A destination that is a symlink is written
+// through: the link's target is replaced and the link is left alone.
+package safefile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+)
+
+var (
+	// LockWait is how long Write waits for another writer to finish
+	LockWait = 5 * time.Second
+	// StaleLock is the age after which a lock is assumed abandoned by a
+	// crashed process and broken
+	StaleLock = 30 * time.Second
+	// lockPoll is how often a held lock is checked
+	lockPoll = 50 * time.Millisecond
+)
+
+// Write atomically replaces path with data
+func Write(path string, data []byte, perm os.FileMode) error {
+	return WriteFrom(path, bytes.NewReader(data), perm)
+}
+
+// WriteFrom atomically replaces path with everything read from r
+func WriteFrom(path string, r io.Reader, perm os.FileMode) error {
+	path = resolve(path)
+	release, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return writeLocked(path, r, perm)
+}
+
+// Update rewrites path with what fn returns for its current content (nil
+// when the file does not exist), holding the lock from read to write so
+// concurrent updates are not lost. fn returning nil data leaves the file
+// unchanged.
+func Update(path string, perm os.FileMode, fn func(current []byte) ([]byte, error)) error {
+	path = resolve(path)
+	release, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	current, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	data, err := fn(current)
+	if err != nil || data == nil {
+		return err
+	}
+	return writeLocked(path, bytes.NewReader(data), perm)
+}
+
+// resolve follows symlinks so the rename replaces the link's target
+// rather than the link. A path that doesn't exist yet, or a dangling
+// link, is returned unchanged.
+func resolve(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// writeLocked writes through a temp file; the caller holds the lock
+func writeLocked(path string, r io.Reader, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	cleanup := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// Symlink atomically points newname at oldname, replacing whatever is at
+// newname: the link is created under a temp name and renamed into place
+func Symlink(oldname, newname string) error {
+	release, err := Lock(newname)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	tmp := filepath.Join(filepath.Dir(newname), fmt.Sprintf(".%s.link-%d", filepath.Base(newname), os.Getpid()))
+	os.Remove(tmp)
+	if err := os.Symlink(oldname, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, newname); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(newname))
+	return nil
+}
+
+// syncDir makes a rename in dir durable. Not every platform can fsync a
+// directory (Windows can't open one for it), so failures are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// LockPath is the lock file guarding path
+func LockPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(paths.StateDir(), "locks", hex.EncodeToString(sum[:8])+".lock")
+}
+
+// Lock takes the advisory lock for path, waiting up to LockWait for
+// another writer. The returned function releases it. When the lock
+// directory can't be created the write goes ahead unlocked: the lock
+// only orders blackdot's own writers, and refusing to restore a secret
+// over it would be worse.
+func Lock(path string) (func(), error) {
+	lockPath := LockPath(path)
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0700); err != nil {
+		return func() {}, nil
+	}
+
+	deadline := time.Now().Add(LockWait)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(f, "%d %s\n", os.Getpid(), path)
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return func() {}, nil
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > StaleLock {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			holder, _ := os.ReadFile(lockPath)
+			pid, _, _ := strings.Cut(strings.TrimSpace(string(holder)), " ")
+			return nil, fmt.Errorf("%s is being written by another blackdot process (pid %s; lock %s)", path, pid, lockPath)
+		}
+		time.Sleep(lockPoll)
+	}
+}
@@ -0,0 +1,149 @@
+package safefile
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+)
+
+// setStateDir points lock files at a temp directory
+func setStateDir(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	t.Setenv("BLACKDOT_PATHS_STRATEGY", "xdg")
+	paths.ResetStrategy()
+	t.Cleanup(paths.ResetStrategy)
+}
+
+func TestWrite(t *testing.T) {
+	setStateDir(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials")
+	os.WriteFile(path, []byte("old"), 0644)
+
+	if err := Write(path, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	info, _ := os.Stat(path)
+	if string(data) != "new" || info.Mode().Perm() != 0600 {
+		t.Errorf("content %q mode %v", data, info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+	if _, err := os.Stat(LockPath(path)); !os.IsNotExist(err) {
+		t.Errorf("lock not released: %v", err)
+	}
+}
+
+func TestLockWaitsAndBreaksStaleLocks(t *testing.T) {
+	setStateDir(t)
+	path := filepath.Join(t.TempDir(), "config")
+	defer func(wait time.Duration) { LockWait = wait }(LockWait)
+	LockWait = 100 * time.Millisecond
+
+	release, err := Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Lock(path); err == nil {
+		t.Fatal("second lock acquired while the first is held")
+	}
+	release()
+	release, err = Lock(path)
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+
+	// A crashed writer's lock is broken once it is old enough
+	old := time.Now().Add(-2 * StaleLock)
+	os.Chtimes(LockPath(path), old, old)
+	release2, err := Lock(path)
+	if err != nil {
+		t.Fatalf("stale lock not broken: %v", err)
+	}
+	release2()
+	release()
+}
+
+// TestUpdateSerializes verifies concurrent read-modify-write updates are
+// not lost
+func TestUpdateSerializes(t *testing.T) {
+	setStateDir(t)
+	path := filepath.Join(t.TempDir(), "counter")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := Update(path, 0644, func(current []byte) ([]byte, error) {
+				n, _ := strconv.Atoi(string(current))
+				return []byte(strconv.Itoa(n + 1)), nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if data, _ := os.ReadFile(path); string(data) != "10" {
+		t.Errorf("counter = %q, want 10", data)
+	}
+
+	// nil data leaves the file alone
+	Update(path, 0644, func([]byte) ([]byte, error) { return nil, nil })
+	if data, _ := os.ReadFile(path); string(data) != "10" {
+		t.Errorf("unchanged update wrote %q", data)
+	}
+}
+
+func TestSymlinkReplaces(t *testing.T) {
+	setStateDir(t)
+	dir := t.TempDir()
+	source := filepath.Join(dir, "generated")
+	target := filepath.Join(dir, "target")
+	os.WriteFile(source, []byte("rendered"), 0644)
+	os.WriteFile(target, []byte("real file"), 0644)
+
+	if err := Symlink(source, target); err != nil {
+		t.Fatal(err)
+	}
+	if dest, err := os.Readlink(target); err != nil || dest != source {
+		t.Errorf("target links to %q, %v", dest, err)
+	}
+}
+
+func TestWriteThroughSymlink(t *testing.T) {
+	setStateDir(t)
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "gitconfig")
+	link := filepath.Join(dir, ".gitconfig")
+	os.MkdirAll(filepath.Dir(target), 0755)
+	os.WriteFile(target, []byte("old"), 0644)
+	os.Symlink(target, link)
+
+	if err := Write(link, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("link replaced: %v %v", info, err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "new" {
+		t.Errorf("target holds %q", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(target)); len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,21 @@
+// Package scaffold holds the skeleton of a new dotfiles repo, written out
+// by 'blackdot init'.
+package scaffold
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:skeleton
+var skeleton embed.FS
+
+// Files is the skeleton rooted at the repo directory: zsh/zshrc,
+// zsh/zsh.d, templates, vault, brew, features.yaml, and links.yaml
+func Files() fs.FS {
+	sub, err := fs.Sub(skeleton, "skeleton")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
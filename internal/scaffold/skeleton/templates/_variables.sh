@@ -0,0 +1,21 @@
+#!/usr/bin/env zsh
+# ============================================================
+# FILE: templates/_variables.sh
+# Default template variables
+#
+# Machine-specific overrides go in _variables.local.sh (gitignored).
+# Environment variables (BLACKDOT_TMPL_*) override both.
+#
+# Usage:
+#   Run 'blackdot template init' to create _variables.local.sh
+#   Run 'blackdot template vars' to see all current values
+# ============================================================
+
+typeset -gA TMPL_DEFAULTS=(
+    # Git
+    [git_name]=""                    # Your full name (required)
+    [git_email]=""                   # Your email (required)
+    [git_signing_key]=""             # GPG key ID (optional)
+    [git_default_branch]="main"      # Default branch name
+    [git_editor]="vim"               # Git editor
+)
@@ -0,0 +1,250 @@
+// Package scanners detects plaintext credentials in file content using
+// known token patterns and Shannon entropy.
+//
+// Findings never carry the full secret: Match is redacted so reports can be
+// printed and logged safely. A line containing "blackdot:allow" is skipped.
+package scanners
+
+import (
+	"bytes"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AllowMarker suppresses findings on the line that contains it
+const AllowMarker = "blackdot:allow"
+
+// MaxFileSize is the largest file ScanPath reads
+const MaxFileSize = 1 << 20
+
+// Rule is one credential pattern
+type Rule struct {
+	ID          string
+	Description string
+	Pattern     *regexp.Regexp
+	// Group is the capture group holding the secret (0 for the whole match)
+	Group int
+	// MinEntropy rejects low-entropy matches such as placeholders
+	MinEntropy float64
+}
+
+// Finding is one suspected credential
+type Finding struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+	Path        string `json:"path"`
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	Match       string `json:"match"` // redacted
+}
+
+// DefaultRules are patterns for common credentials. They favour precision:
+// each either has a distinctive prefix or requires an assignment context.
+func DefaultRules() []Rule {
+	return []Rule{
+		{ID: "aws-access-key", Description: "AWS access key ID", Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16})\b`), Group: 1},
+		{ID: "aws-secret-key", Description: "AWS secret access key", Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`), Group: 1, MinEntropy: 3.5},
+		{ID: "github-token", Description: "GitHub token", Pattern: regexp.MustCompile(`\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{60,})\b`), Group: 1},
+		{ID: "gitlab-token", Description: "GitLab personal access token", Pattern: regexp.MustCompile(`\b(glpat-[A-Za-z0-9_-]{20})\b`), Group: 1},
+		{ID: "slack-token", Description: "Slack token", Pattern: regexp.MustCompile(`\b(xox[abposr]-[A-Za-z0-9-]{10,})\b`), Group: 1},
+		{ID: "stripe-key", Description: "Stripe secret key", Pattern: regexp.MustCompile(`\b((?:sk|rk)_live_[A-Za-z0-9]{20,})\b`), Group: 1},
+		{ID: "google-api-key", Description: "Google API key", Pattern: regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})\b`), Group: 1},
+		{ID: "anthropic-key", Description: "Anthropic API key", Pattern: regexp.MustCompile(`\b(sk-ant-[A-Za-z0-9_-]{20,})\b`), Group: 1},
+		{ID: "openai-key", Description: "OpenAI API key", Pattern: regexp.MustCompile(`\b(sk-(?:proj-)?[A-Za-z0-9]{32,})\b`), Group: 1, MinEntropy: 3.5},
+		{ID: "npm-token", Description: "npm token", Pattern: regexp.MustCompile(`\b(npm_[A-Za-z0-9]{36})\b`), Group: 1},
+		{ID: "private-key", Description: "Private key", Pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY(?: BLOCK)?-----`)},
+		{ID: "jwt", Description: "JSON web token", Pattern: regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})\b`), Group: 1},
+		{ID: "url-credentials", Description: "Password in URL", Pattern: regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^/\s:@]+:([^/\s:@]{6,})@`), Group: 1, MinEntropy: 2.5},
+	}
+}
+
+// genericAssignment matches key = value pairs whose key suggests a secret.
+// The value is only reported when its entropy passes the threshold.
+var genericAssignment = regexp.MustCompile(`(?i)\b[a-z0-9_.-]*(?:password|passwd|secret|token|api_?key|access_?key|auth_?key|private_?key|client_?secret)[a-z0-9_.-]*["']?\s*[:=]\s*["']?([A-Za-z0-9+/=_.~-]{16,})(?:["'\s,;#]|$)`)
+
+// Scanner finds credentials in content
+type Scanner struct {
+	Rules []Rule
+	// Entropy enables the generic key = value check
+	Entropy bool
+	// EntropyThreshold is the minimum Shannon entropy (bits per character)
+	// for the generic check
+	EntropyThreshold float64
+}
+
+// New returns a scanner with the default rules and entropy detection
+func New() *Scanner {
+	return &Scanner{Rules: DefaultRules(), Entropy: true, EntropyThreshold: 3.5}
+}
+
+// Scan returns findings in data, reported against name
+func (s *Scanner) Scan(name string, data []byte) []Finding {
+	if isBinary(data) {
+		return nil
+	}
+
+	var findings []Finding
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.Contains(line, AllowMarker) {
+			continue
+		}
+		for _, m := range s.lineMatches(line) {
+			findings = append(findings, Finding{
+				Rule:        m.rule,
+				Description: m.description,
+				Path:        name,
+				Line:        i + 1,
+				Column:      m.start + 1,
+				Match:       Redact(line[m.start:m.end]),
+			})
+		}
+	}
+	return findings
+}
+
+// Mask returns text with every secret the scanner finds replaced by its
+// redacted form, for logging values that may contain credentials
+func (s *Scanner) Mask(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		matches := s.lineMatches(line)
+		sort.Slice(matches, func(a, b int) bool { return matches[a].start > matches[b].start })
+		end := len(line) + 1
+		for _, m := range matches {
+			if m.end > end { // overlaps a later match already masked
+				continue
+			}
+			line = line[:m.start] + Redact(line[m.start:m.end]) + line[m.end:]
+			end = m.start
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lineMatch is where a secret sits in a line
+type lineMatch struct {
+	rule, description string
+	start, end        int
+}
+
+// lineMatches finds the secrets in one line
+func (s *Scanner) lineMatches(line string) []lineMatch {
+	var matches []lineMatch
+	matched := map[string]bool{}
+
+	for _, rule := range s.Rules {
+		for _, m := range rule.Pattern.FindAllStringSubmatchIndex(line, -1) {
+			start, end := m[2*rule.Group], m[2*rule.Group+1]
+			if start < 0 {
+				continue
+			}
+			secret := line[start:end]
+			if rule.MinEntropy > 0 && Entropy(secret) < rule.MinEntropy {
+				continue
+			}
+			matched[secret] = true
+			matches = append(matches, lineMatch{rule: rule.ID, description: rule.Description, start: start, end: end})
+		}
+	}
+
+	if !s.Entropy {
+		return matches
+	}
+	for _, m := range genericAssignment.FindAllStringSubmatchIndex(line, -1) {
+		secret := line[m[2]:m[3]]
+		if matched[secret] || looksLikeReference(secret) || Entropy(secret) < s.EntropyThreshold {
+			continue
+		}
+		matches = append(matches, lineMatch{
+			rule:        "generic-secret",
+			description: "High-entropy value assigned to a secret-looking key",
+			start:       m[2],
+			end:         m[3],
+		})
+	}
+	return matches
+}
+
+// ScanPath scans a file, or every file under a directory. Binary files,
+// files over MaxFileSize, and .git and node_modules directories are skipped.
+func (s *Scanner) ScanPath(root string) ([]Finding, error) {
+	var findings []Finding
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (d.Name() == ".git" || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > MaxFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		findings = append(findings, s.Scan(path, data)...)
+		return nil
+	})
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, err
+}
+
+// Entropy returns the Shannon entropy of s in bits per character
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// Redact keeps the first four characters of a secret
+func Redact(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", 8)
+}
+
+// looksLikeReference skips values that point at a secret rather than
+// contain one, such as file paths
+func looksLikeReference(value string) bool {
+	return strings.HasPrefix(value, "/") || strings.HasPrefix(value, "~") || strings.HasPrefix(value, ".")
+}
+
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
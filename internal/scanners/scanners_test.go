@@ -0,0 +1,87 @@
+package scanners
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Fake credentials are assembled at runtime so the repo itself scans clean
+var (
+	fakeAWSKey  = "AKIA" + "Z7Q2M4X8K1L9P3R5"
+	fakeGitHub  = "ghp_" + "aB3dE5fG7hJ9kL1mN3pQ5rS7tU9vW1xY3zA5"
+	fakeGeneric = "q8Zr" + "T2vLx9WmK4pN7sYb"
+)
+
+// TestScanKnownPatterns verifies token rules report the line and redact
+func TestScanKnownPatterns(t *testing.T) {
+	content := "[user]\n\tname = me\n[github]\n\ttoken = " + fakeGitHub + "\n# " + fakeAWSKey + "\n"
+	findings := New().Scan("gitconfig", []byte(content))
+
+	rules := map[string]int{}
+	for _, f := range findings {
+		rules[f.Rule] = f.Line
+		if strings.Contains(f.Match, fakeGitHub) || strings.Contains(f.Match, fakeAWSKey) {
+			t.Errorf("match not redacted: %q", f.Match)
+		}
+	}
+	if rules["github-token"] != 4 || rules["aws-access-key"] != 5 {
+		t.Errorf("findings = %+v", findings)
+	}
+	if len(findings) != 2 {
+		t.Errorf("token should not also be reported as generic: %+v", findings)
+	}
+}
+
+// TestScanEntropy verifies the generic check needs a secret-looking key and
+// a high-entropy value, and can be disabled
+func TestScanEntropy(t *testing.T) {
+	s := New()
+	for content, want := range map[string]int{
+		"api_key = " + fakeGeneric:                        1,
+		"password: aaaaaaaaaaaaaaaaaaaa":                  0, // low entropy
+		"name = " + fakeGeneric:                           0, // not a secret key
+		"token_file = /home/me/.config/token.txt":         0, // a path
+		"password = " + fakeGeneric + " # " + AllowMarker: 0,
+	} {
+		if got := len(s.Scan("f", []byte(content))); got != want {
+			t.Errorf("%q: %d findings, want %d", content, got, want)
+		}
+	}
+
+	s.Entropy = false
+	if got := len(s.Scan("f", []byte("api_key = "+fakeGeneric))); got != 0 {
+		t.Errorf("entropy disabled: %d findings", got)
+	}
+}
+
+// TestMask verifies secrets are redacted in place and other text is kept
+func TestMask(t *testing.T) {
+	text := "auth failed for " + fakeGitHub + " (key " + fakeAWSKey + ")\napi_key = " + fakeGeneric
+	got := New().Mask(text)
+	want := "auth failed for " + Redact(fakeGitHub) + " (key " + Redact(fakeAWSKey) + ")\napi_key = " + Redact(fakeGeneric)
+	if got != want {
+		t.Errorf("Mask() = %q, want %q", got, want)
+	}
+	if plain := "item SSH-GitHub restored"; New().Mask(plain) != plain {
+		t.Errorf("Mask changed text without secrets")
+	}
+}
+
+// TestScanPathSkipsBinaryAndGit verifies directory walks skip binaries and .git
+func TestScanPathSkipsBinaryAndGit(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+	os.WriteFile(filepath.Join(dir, ".git", "config"), []byte(fakeAWSKey), 0644)
+	os.WriteFile(filepath.Join(dir, "blob"), []byte("\x00"+fakeAWSKey), 0644)
+	os.WriteFile(filepath.Join(dir, "env"), []byte("export KEY="+fakeAWSKey+"\n"), 0644)
+
+	findings, err := New().ScanPath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || filepath.Base(findings[0].Path) != "env" {
+		t.Errorf("findings = %+v", findings)
+	}
+}
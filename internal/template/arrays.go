@@ -0,0 +1,375 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ArraySpec describes an array for {{#each}} loops in
+// _variables.schema.json. In _arrays.local.json an array is a list of
+// objects; in _variables*.sh it is a shell array (named Shell, or the
+// upper-cased name) whose elements are the Fields joined with '|', in
+// order. Trailing empty fields may be left off.
+type ArraySpec struct {
+	Name        string         `json:"name"`
+	Shell       string         `json:"shell,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Fields      []VariableSpec `json:"fields"`
+}
+
+// BuiltinArrays are the arrays the shipped templates use. A schema entry
+// with the same name replaces one.
+var BuiltinArrays = []ArraySpec{
+	{
+		Name:        "ssh_hosts",
+		Description: "Hosts rendered into ssh-config, one include file per category",
+		Fields: []VariableSpec{
+			{Name: "name", Required: true},
+			{Name: "hostname", Required: true},
+			{Name: "user"},
+			{Name: "identity", Type: VarPath},
+			{Name: "extra"},
+			{Name: "category"},
+			{Name: "port", Type: VarInt},
+		},
+	},
+}
+
+// ShellName is the shell array holding the array in _variables*.sh
+func (a ArraySpec) ShellName() string {
+	if a.Shell != "" {
+		return a.Shell
+	}
+	return strings.ToUpper(a.Name)
+}
+
+// FieldNames returns the fields in shell order
+func (a ArraySpec) FieldNames() []string {
+	names := make([]string, len(a.Fields))
+	for i, f := range a.Fields {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// ArraySpecs returns the builtin arrays with the schema's added or
+// replacing them, sorted by name
+func (s *VariableSchema) ArraySpecs() []ArraySpec {
+	byName := make(map[string]ArraySpec)
+	for _, a := range BuiltinArrays {
+		byName[a.Name] = a
+	}
+	if s != nil {
+		for _, a := range s.Arrays {
+			byName[a.Name] = a
+		}
+	}
+	specs := make([]ArraySpec, 0, len(byName))
+	for _, a := range byName {
+		specs = append(specs, a)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+// checkArraySpec reports a malformed array declaration
+func checkArraySpec(a ArraySpec) error {
+	if a.Name == "" {
+		return fmt.Errorf("array without a name")
+	}
+	if len(a.Fields) == 0 {
+		return fmt.Errorf("array %s has no fields", a.Name)
+	}
+	seen := map[string]bool{}
+	for _, f := range a.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("array %s has a field without a name", a.Name)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("array %s declares %s twice", a.Name, f.Name)
+		}
+		seen[f.Name] = true
+		switch f.Type {
+		case "", VarString, VarBool, VarInt, VarEmail, VarPath:
+		default:
+			return fmt.Errorf("array %s: %s has unknown type %q", a.Name, f.Name, f.Type)
+		}
+	}
+	return nil
+}
+
+// ArrayProblem is an array element that does not match its spec
+type ArrayProblem struct {
+	Array string
+	Index int
+	Field string
+	Err   error
+}
+
+func (p ArrayProblem) String() string {
+	if p.Field == "" {
+		return fmt.Sprintf("%s[%d]: %v", p.Array, p.Index, p.Err)
+	}
+	return fmt.Sprintf("%s[%d].%s: %v", p.Array, p.Index, p.Field, p.Err)
+}
+
+// Check validates each element: only declared fields, string values that
+// fit in a shell element (no '|'), required fields set, and each value
+// valid for its field's type
+func (a ArraySpec) Check(items []map[string]interface{}) []ArrayProblem {
+	var problems []ArrayProblem
+	fields := make(map[string]VariableSpec, len(a.Fields))
+	for _, f := range a.Fields {
+		fields[f.Name] = f
+	}
+	for i, item := range items {
+		keys := make([]string, 0, len(item))
+		for k := range item {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if _, ok := fields[k]; !ok {
+				problems = append(problems, ArrayProblem{a.Name, i, k, fmt.Errorf("not a field of %s (fields: %s)", a.Name, strings.Join(a.FieldNames(), ", "))})
+			}
+		}
+		for _, f := range a.Fields {
+			value, err := arrayFieldString(item[f.Name])
+			switch {
+			case err != nil:
+				problems = append(problems, ArrayProblem{a.Name, i, f.Name, err})
+			case value == "":
+				if f.Required {
+					problems = append(problems, ArrayProblem{a.Name, i, f.Name, fmt.Errorf("required")})
+				}
+			case strings.Contains(value, "|"):
+				problems = append(problems, ArrayProblem{a.Name, i, f.Name, fmt.Errorf("must not contain '|'")})
+			default:
+				if err := f.Validate(value); err != nil {
+					problems = append(problems, ArrayProblem{a.Name, i, f.Name, err})
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// arrayFieldString is a field value as text. Numbers and booleans are
+// accepted since JSON writers produce them; lists and objects are not.
+func arrayFieldString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return fmt.Sprint(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("must be a string, number or boolean")
+}
+
+// LoadArraysFile reads _arrays.local.json: an object of arrays of objects
+func LoadArraysFile(path string) (map[string][]map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var arrays map[string][]map[string]interface{}
+	if err := json.Unmarshal(data, &arrays); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return arrays, nil
+}
+
+// ShellArray is an indexed array assignment in a variables file
+type ShellArray struct {
+	Name      string
+	Elements  []string
+	StartLine int // first line of the assignment, from 0
+	EndLine   int // line with the closing parenthesis
+}
+
+// FindShellArrays finds the indexed arrays assigned in a variables file,
+// NAME=( ... ) with or without typeset/declare, one or many elements per
+// line. Associative arrays (typeset -A, [key]=value) are skipped.
+func FindShellArrays(data string) []ShellArray {
+	var arrays []ShellArray
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		name, rest, ok := shellArrayStart(lines[i])
+		if !ok {
+			continue
+		}
+		a := ShellArray{Name: name, StartLine: i}
+		closed := false
+		for {
+			words, done := shellWords(rest)
+			a.Elements = append(a.Elements, words...)
+			if done {
+				closed = true
+				break
+			}
+			if i+1 >= len(lines) {
+				break
+			}
+			i++
+			rest = lines[i]
+		}
+		a.EndLine = i
+		if !closed || isAssociative(a.Elements) {
+			continue
+		}
+		arrays = append(arrays, a)
+	}
+	return arrays
+}
+
+// ParseShellArrays returns the elements of each array in a variables
+// file; a later assignment replaces an earlier one
+func ParseShellArrays(data string) map[string][]string {
+	arrays := make(map[string][]string)
+	for _, a := range FindShellArrays(data) {
+		arrays[a.Name] = a.Elements
+	}
+	return arrays
+}
+
+// shellArrayStart recognizes the line an array assignment starts on and
+// returns what follows the opening parenthesis
+func shellArrayStart(line string) (name, rest string, ok bool) {
+	line = strings.TrimSpace(line)
+	for {
+		word, after, _ := strings.Cut(line, " ")
+		switch {
+		case word == "typeset" || word == "declare" || word == "local" || word == "export":
+		case strings.HasPrefix(word, "-"):
+			if strings.Contains(word, "A") {
+				return "", "", false
+			}
+		default:
+			eq := strings.Index(line, "=(")
+			if eq <= 0 {
+				return "", "", false
+			}
+			name = line[:eq]
+			for _, r := range name {
+				if !(r == '_' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+					return "", "", false
+				}
+			}
+			return name, line[eq+2:], true
+		}
+		line = strings.TrimSpace(after)
+	}
+}
+
+// shellWords splits one line of an array body into its elements,
+// honoring quotes and comments, and reports whether the closing
+// parenthesis was reached
+func shellWords(line string) (words []string, closed bool) {
+	var cur strings.Builder
+	inWord := false
+	flush := func() {
+		if inWord {
+			words = append(words, cur.String())
+			cur.Reset()
+			inWord = false
+		}
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\'':
+			inWord = true
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				cur.WriteString(line[i+1:])
+				i = len(line)
+				continue
+			}
+			cur.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			inWord = true
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\\\"$`", line[i+1]) >= 0 {
+					i++
+				}
+				cur.WriteByte(line[i])
+			}
+		case c == '\\' && i+1 < len(line):
+			inWord = true
+			i++
+			cur.WriteByte(line[i])
+		case c == '#' && !inWord:
+			flush()
+			return words, false
+		case c == ')':
+			flush()
+			return words, true
+		case c == ' ' || c == '\t':
+			flush()
+		default:
+			inWord = true
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+	return words, false
+}
+
+// isAssociative reports whether the elements are [key]=value pairs
+func isAssociative(elements []string) bool {
+	return len(elements) > 0 && strings.HasPrefix(elements[0], "[") && strings.Contains(elements[0], "]=")
+}
+
+// ArrayFromShell splits shell elements into items by the spec's fields.
+// Empty fields are left out of the item.
+func (a ArraySpec) ArrayFromShell(elements []string) ([]map[string]interface{}, error) {
+	items := make([]map[string]interface{}, 0, len(elements))
+	for i, element := range elements {
+		values := strings.Split(element, "|")
+		if len(values) > len(a.Fields) {
+			return nil, fmt.Errorf("%s[%d]: %d fields, %s has %d (%s)", a.ShellName(), i, len(values), a.Name, len(a.Fields), strings.Join(a.FieldNames(), "|"))
+		}
+		item := make(map[string]interface{})
+		for j, value := range values {
+			if value = strings.TrimSpace(value); value != "" {
+				item[a.Fields[j].Name] = value
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// ArrayToShell writes items as a shell array assignment, preceded by a
+// comment naming the fields. Items should pass Check first.
+func (a ArraySpec) ArrayToShell(items []map[string]interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: %s\n", a.Name, strings.Join(a.FieldNames(), "|"))
+	fmt.Fprintf(&b, "%s=(\n", a.ShellName())
+	for _, item := range items {
+		values := make([]string, len(a.Fields))
+		for i, f := range a.Fields {
+			values[i], _ = arrayFieldString(item[f.Name])
+		}
+		for len(values) > 1 && values[len(values)-1] == "" {
+			values = values[:len(values)-1]
+		}
+		fmt.Fprintf(&b, "    \"%s\"\n", shellEscapeDouble(strings.Join(values, "|")))
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// shellEscapeDouble escapes what is special inside double quotes
+func shellEscapeDouble(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(s)
+}
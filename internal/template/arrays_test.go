@@ -0,0 +1,82 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseShellArrays(t *testing.T) {
+	data := `#!/usr/bin/env zsh
+typeset -gA TMPL_DEFAULTS=(
+    [git_name]="Me"
+)
+typeset -ga SSH_HOSTS=(
+    # "commented|out"
+    "github|github.com|git|~/.ssh/id_ed25519|"
+    'work|server|deploy||ProxyJump "bastion"'  # trailing comment
+)
+EMPTY=()
+INLINE=("a b" c "d\"e\$f")
+TMPL_DEFAULTS[aws_profile]="x"
+`
+	got := ParseShellArrays(data)
+	want := map[string][]string{
+		"SSH_HOSTS": {"github|github.com|git|~/.ssh/id_ed25519|", `work|server|deploy||ProxyJump "bastion"`},
+		"EMPTY":     nil,
+		"INLINE":    {"a b", "c", `d"e$f`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseShellArrays = %#v, want %#v", got, want)
+	}
+
+	arrays := FindShellArrays(data)
+	if arrays[0].Name != "SSH_HOSTS" || arrays[0].StartLine != 4 || arrays[0].EndLine != 8 {
+		t.Errorf("FindShellArrays = %+v", arrays[0])
+	}
+}
+
+func TestArrayShellRoundTrip(t *testing.T) {
+	spec := BuiltinArrays[0]
+	items, err := spec.ArrayFromShell([]string{"github|github.com|git|~/.ssh/id_ed25519|", "nas|10.0.0.2||||home|2222"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items[0]) != 4 || items[1]["category"] != "home" || items[1]["port"] != "2222" {
+		t.Errorf("items = %v", items)
+	}
+
+	shell := spec.ArrayToShell(items)
+	if !strings.Contains(shell, "SSH_HOSTS=(\n    \"github|github.com|git|~/.ssh/id_ed25519\"\n") {
+		t.Errorf("ArrayToShell:\n%s", shell)
+	}
+	back, err := spec.ArrayFromShell(ParseShellArrays(shell)["SSH_HOSTS"])
+	if err != nil || !reflect.DeepEqual(back, items) {
+		t.Errorf("round trip = %v, %v", back, err)
+	}
+
+	if _, err := spec.ArrayFromShell([]string{"a|b|c|d|e|f|g|h"}); err == nil {
+		t.Error("too many fields should be an error")
+	}
+}
+
+func TestArraySpecCheck(t *testing.T) {
+	spec := BuiltinArrays[0]
+	problems := spec.Check([]map[string]interface{}{
+		{"name": "ok", "hostname": "h", "port": float64(22)},
+		{"hostname": "h|x", "port": "twenty", "tags": []interface{}{"a"}},
+	})
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"ssh_hosts[1].tags: not a field of ssh_hosts (fields: name, hostname, user, identity, extra, category, port)",
+		"ssh_hosts[1].name: required",
+		"ssh_hosts[1].hostname: must not contain '|'",
+		"ssh_hosts[1].port: must be a whole number",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check = %q", got)
+	}
+}
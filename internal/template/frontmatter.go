@@ -0,0 +1,117 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Deploy strategies a template can declare in its front-matter
+const (
+	StrategyLink = "link"
+	StrategyCopy = "copy"
+)
+
+// FrontMatter is the deployment header a template may start with:
+//
+//	{{!-- target: ~/.gitconfig mode: 0644 copy --}}
+//
+// A standalone comment renders to nothing, so the header never reaches the
+// output. Only a leading comment with a target key is front-matter.
+type FrontMatter struct {
+	Target   string      // destination path, may start with ~
+	Mode     os.FileMode // 0 when unset
+	Strategy string      // StrategyLink (default) or StrategyCopy
+}
+
+const (
+	frontMatterOpen  = "{{!--"
+	frontMatterClose = "--}}"
+)
+
+// ParseFrontMatter reads the header at the start of a template. It returns
+// nil when the template has none.
+func ParseFrontMatter(input string) (*FrontMatter, error) {
+	body, ok := frontMatterBody(input)
+	if !ok {
+		return nil, nil
+	}
+
+	fields := strings.Fields(body)
+	fm := &FrontMatter{Strategy: StrategyLink}
+	hasTarget := false
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		key, value, isPair := strings.Cut(field, ":")
+		if !isPair {
+			switch field {
+			case StrategyLink, StrategyCopy:
+				fm.Strategy = field
+				continue
+			}
+			if !hasTarget {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("front-matter: unknown option %q (expected link or copy)", field)
+		}
+		// "key: value" splits into two fields
+		if value == "" && i+1 < len(fields) {
+			i++
+			value = fields[i]
+		}
+		switch key {
+		case "target":
+			fm.Target = value
+			hasTarget = true
+		case "mode":
+			mode, err := strconv.ParseUint(value, 8, 32)
+			if err != nil || mode > 0o777 {
+				return nil, fmt.Errorf("front-matter: invalid mode %q (expected octal like 0644)", value)
+			}
+			fm.Mode = os.FileMode(mode)
+		case "strategy":
+			if value != StrategyLink && value != StrategyCopy {
+				return nil, fmt.Errorf("front-matter: invalid strategy %q (expected link or copy)", value)
+			}
+			fm.Strategy = value
+		default:
+			if !hasTarget {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("front-matter: unknown key %q", key)
+		}
+	}
+	if !hasTarget {
+		return nil, nil
+	}
+	if fm.Target == "" {
+		return nil, fmt.Errorf("front-matter: target is empty")
+	}
+	return fm, nil
+}
+
+// ParseFrontMatterFile reads the header of a template file
+func ParseFrontMatterFile(path string) (*FrontMatter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fm, err := ParseFrontMatter(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return fm, nil
+}
+
+// frontMatterBody returns the text of a leading {{!-- --}} comment
+func frontMatterBody(input string) (string, bool) {
+	if !strings.HasPrefix(input, frontMatterOpen) {
+		return "", false
+	}
+	end := strings.Index(input, frontMatterClose)
+	if end < 0 {
+		return "", false
+	}
+	return input[len(frontMatterOpen):end], true
+}
@@ -0,0 +1,50 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestParseFrontMatter verifies inline and multi-line headers, and that
+// ordinary leading comments are not mistaken for front-matter
+func TestParseFrontMatter(t *testing.T) {
+	for _, tc := range []struct {
+		name, input string
+		want        *FrontMatter
+		wantErr     bool
+	}{
+		{"inline", "{{!-- target: ~/.gitconfig mode:0644 copy --}}\n[user]\n",
+			&FrontMatter{Target: "~/.gitconfig", Mode: 0o644, Strategy: StrategyCopy}, false},
+		{"multi-line", "{{!--\ntarget: ~/.ssh/config\nmode: 600\nstrategy: link\n--}}\nHost *\n",
+			&FrontMatter{Target: "~/.ssh/config", Mode: 0o600, Strategy: StrategyLink}, false},
+		{"default link", "{{!-- target:~/.zshrc.local --}}\n", &FrontMatter{Target: "~/.zshrc.local", Strategy: StrategyLink}, false},
+		{"plain comment", "{{!-- Generated by blackdot: do not edit --}}\n", nil, false},
+		{"not leading", "\n{{!-- target: ~/.gitconfig --}}\n", nil, false},
+		{"bad mode", "{{!-- target: ~/x mode: 0999 --}}\n", nil, true},
+		{"typo", "{{!-- target: ~/x moed: 0644 --}}\n", nil, true},
+	} {
+		got, err := ParseFrontMatter(tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: err = %v", tc.name, err)
+			continue
+		}
+		if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestRenderFileOmitsFrontMatter verifies the header leaves nothing in
+// the output, including its line break
+func TestRenderFileOmitsFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	engine := NewRaymondEngine(dir)
+	engine.SetVar("name", "alice")
+
+	path := filepath.Join(dir, "a.tmpl")
+	os.WriteFile(path, []byte("{{!--\ntarget: ~/.a\nmode: 0600\n--}}\n\nname = {{ name }}\n"), 0644)
+	if out, err := engine.RenderFile(path); err != nil || out != "\nname = alice\n" {
+		t.Errorf("RenderFile = %q, %v", out, err)
+	}
+}
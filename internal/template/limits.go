@@ -0,0 +1,113 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default render limits. A template that loops over a huge array or
+// recurses through partials is stopped instead of hanging the render.
+const (
+	DefaultRenderTimeout = 10 * time.Second
+	DefaultMaxOutput     = 10 << 20 // 10 MiB
+)
+
+// Render error kinds
+const (
+	ErrKindParse   = "parse"
+	ErrKindRender  = "render"
+	ErrKindTimeout = "timeout"
+	ErrKindSize    = "size"
+)
+
+// RenderError is a template failure with its position in the source.
+// Line and Column are 1-based and zero when unknown.
+type RenderError struct {
+	Template string
+	Line     int
+	Column   int
+	Kind     string
+	Err      error
+}
+
+// Error formats the error as "name:line:col: kind error: message", or
+// "line N, column C: ..." for templates without a name
+func (e *RenderError) Error() string {
+	var where string
+	switch {
+	case e.Template != "" && e.Line > 0 && e.Column > 0:
+		where = fmt.Sprintf("%s:%d:%d", e.Template, e.Line, e.Column)
+	case e.Template != "" && e.Line > 0:
+		where = fmt.Sprintf("%s:%d", e.Template, e.Line)
+	case e.Template != "":
+		where = e.Template
+	case e.Line > 0 && e.Column > 0:
+		where = fmt.Sprintf("line %d, column %d", e.Line, e.Column)
+	case e.Line > 0:
+		where = fmt.Sprintf("line %d", e.Line)
+	}
+	if where == "" {
+		return fmt.Sprintf("%s error: %v", e.Kind, e.Err)
+	}
+	return fmt.Sprintf("%s: %s error: %v", where, e.Kind, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// raymond: "Parse error on line N:\n<msg>[\nNode: X{Pos: P} | \nToken: ...]"
+	parseErrorRe = regexp.MustCompile(`(?s)^Parse error on line (\d+):\n(.*)$`)
+	// raymond: "Evaluation error: <msg>\nCurrent node:\n\tX{..., Pos: P}"
+	evalErrorRe = regexp.MustCompile(`(?s)^Evaluation error: (.*?)\nCurrent node:\n\t\w+\{[^\n]*?Pos: ?(\d+)\}`)
+	nodePosRe   = regexp.MustCompile(`\nNode: \w+\{[^\n]*?Pos: ?(\d+)\}`)
+)
+
+// newRenderError converts a raymond error into a RenderError positioned
+// in source
+func newRenderError(name, source string, err error) *RenderError {
+	msg := err.Error()
+
+	if m := parseErrorRe.FindStringSubmatch(msg); m != nil {
+		rerr := &RenderError{Template: name, Kind: ErrKindParse}
+		rerr.Line, _ = strconv.Atoi(m[1])
+		detail := m[2]
+		if pm := nodePosRe.FindStringSubmatch(detail); pm != nil {
+			pos, _ := strconv.Atoi(pm[1])
+			rerr.Line, rerr.Column = position(source, pos)
+		}
+		rerr.Err = fmt.Errorf("%s", firstLine(detail))
+		return rerr
+	}
+
+	if m := evalErrorRe.FindStringSubmatch(msg); m != nil {
+		pos, _ := strconv.Atoi(m[2])
+		line, col := position(source, pos)
+		return &RenderError{Template: name, Line: line, Column: col, Kind: ErrKindRender, Err: fmt.Errorf("%s", firstLine(m[1]))}
+	}
+
+	return &RenderError{Template: name, Kind: ErrKindRender, Err: err}
+}
+
+// position converts a byte offset to a 1-based line and column
+func position(source string, pos int) (line, col int) {
+	if pos < 0 || pos > len(source) {
+		return 0, 0
+	}
+	before := source[:pos]
+	line = strings.Count(before, "\n") + 1
+	col = pos - (strings.LastIndex(before, "\n") + 1) + 1
+	return line, col
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
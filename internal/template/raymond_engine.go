@@ -16,10 +16,12 @@
 package template
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/aymerick/raymond"
 )
@@ -32,6 +34,10 @@ type RaymondEngine struct {
 	vars        map[string]interface{}
 	arrays      map[string][]map[string]interface{}
 	templateDir string
+	timeout     time.Duration
+	maxOutput   int
+	observe     func(name string, elapsed time.Duration)
+	ignoreEnv   bool
 }
 
 // NewRaymondEngine creates a new raymond-based template engine
@@ -40,11 +46,20 @@ func NewRaymondEngine(templateDir string) *RaymondEngine {
 		vars:        make(map[string]interface{}),
 		arrays:      make(map[string][]map[string]interface{}),
 		templateDir: templateDir,
+		timeout:     DefaultRenderTimeout,
+		maxOutput:   DefaultMaxOutput,
 	}
 
 	return e
 }
 
+// SetLimits sets the per-template render timeout and output size limit.
+// Zero disables a limit.
+func (e *RaymondEngine) SetLimits(timeout time.Duration, maxOutput int) {
+	e.timeout = timeout
+	e.maxOutput = maxOutput
+}
+
 // registerHelpers registers all Handlebars helpers
 // This is called once globally (raymond uses a global helper registry)
 func registerHelpers() {
@@ -135,6 +150,18 @@ func registerAllHelpers() {
 	})
 }
 
+// SetRenderObserver registers fn to be called with the duration of every
+// render. name is the template file name, or "" for a string template.
+func (e *RaymondEngine) SetRenderObserver(fn func(name string, elapsed time.Duration)) {
+	e.observe = fn
+}
+
+// IgnoreEnvironment stops BLACKDOT_TMPL_* variables from overriding the
+// engine's variables, so a render depends only on what was set
+func (e *RaymondEngine) IgnoreEnvironment() {
+	e.ignoreEnv = true
+}
+
 // SetVar sets a template variable
 func (e *RaymondEngine) SetVar(name string, value interface{}) {
 	e.vars[name] = value
@@ -150,7 +177,7 @@ func (e *RaymondEngine) SetArray(name string, items []map[string]interface{}) {
 func (e *RaymondEngine) GetVar(name string) (interface{}, bool) {
 	// Check environment override first (highest priority)
 	envName := "BLACKDOT_TMPL_" + strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
-	if val := os.Getenv(envName); val != "" {
+	if val := os.Getenv(envName); val != "" && !e.ignoreEnv {
 		return val, true
 	}
 
@@ -158,6 +185,19 @@ func (e *RaymondEngine) GetVar(name string) (interface{}, bool) {
 	return val, ok
 }
 
+// Vars returns the string variables, with environment overrides applied
+func (e *RaymondEngine) Vars() map[string]string {
+	vars := make(map[string]string, len(e.vars))
+	for name := range e.vars {
+		if val, ok := e.GetVar(name); ok {
+			if s, ok := val.(string); ok {
+				vars[name] = s
+			}
+		}
+	}
+	return vars
+}
+
 // buildContext creates the template context with all variables and arrays
 func (e *RaymondEngine) buildContext() map[string]interface{} {
 	ctx := make(map[string]interface{})
@@ -173,6 +213,9 @@ func (e *RaymondEngine) buildContext() map[string]interface{} {
 	}
 
 	// Apply environment overrides
+	if e.ignoreEnv {
+		return ctx
+	}
 	for k := range e.vars {
 		envName := "BLACKDOT_TMPL_" + strings.ToUpper(strings.ReplaceAll(k, ".", "_"))
 		if val := os.Getenv(envName); val != "" {
@@ -190,8 +233,30 @@ func preprocessTemplate(input string) string {
 	return strings.ReplaceAll(input, "{{#else}}", "{{else}}")
 }
 
-// Render processes a template string and returns the result
+// Render processes a template string and returns the result. Errors are
+// *RenderError with the line and column of the failing expression.
 func (e *RaymondEngine) Render(input string) (string, error) {
+	return e.render("", input)
+}
+
+// RenderFile reads and renders a template file. Errors name the file.
+func (e *RaymondEngine) RenderFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return e.render(filepath.Base(path), string(data))
+}
+
+// render executes a template within the engine's limits. raymond cannot
+// be interrupted, so a render that times out is abandoned rather than
+// stopped; callers are short-lived commands.
+func (e *RaymondEngine) render(name, input string) (string, error) {
+	if e.observe != nil {
+		start := time.Now()
+		defer func() { e.observe(name, time.Since(start)) }()
+	}
+
 	// Register helpers (once globally)
 	registerHelpers()
 
@@ -201,22 +266,50 @@ func (e *RaymondEngine) Render(input string) (string, error) {
 	// Build context with all variables
 	ctx := e.buildContext()
 
-	// Parse and execute template
-	result, err := raymond.Render(input, ctx)
-	if err != nil {
-		return "", err
+	type outcome struct {
+		result string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		// raymond re-panics runtime errors raised inside helpers
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("%v", r)}
+			}
+		}()
+		result, err := raymond.Render(input, ctx)
+		done <- outcome{result, err}
+	}()
+
+	var out outcome
+	if e.timeout > 0 {
+		timer := time.NewTimer(e.timeout)
+		defer timer.Stop()
+		select {
+		case out = <-done:
+		case <-timer.C:
+			return "", &RenderError{
+				Template: name,
+				Kind:     ErrKindTimeout,
+				Err:      fmt.Errorf("did not finish within %s (runaway {{#each}} or recursive partial?)", e.timeout),
+			}
+		}
+	} else {
+		out = <-done
 	}
 
-	return result, nil
-}
-
-// RenderFile reads and renders a template file
-func (e *RaymondEngine) RenderFile(path string) (string, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
+	if out.err != nil {
+		return "", newRenderError(name, input, out.err)
 	}
-	return e.Render(string(data))
+	if e.maxOutput > 0 && len(out.result) > e.maxOutput {
+		return "", &RenderError{
+			Template: name,
+			Kind:     ErrKindSize,
+			Err:      fmt.Errorf("output is %d bytes, limit is %d", len(out.result), e.maxOutput),
+		}
+	}
+	return out.result, nil
 }
 
 // LoadVariablesFile loads variables from a shell-style variables file
@@ -227,10 +320,20 @@ func (e *RaymondEngine) LoadVariablesFile(path string) error {
 		return err
 	}
 
-	// Parse VAR=value or VAR="value" lines
+	// Parse VAR=value or VAR="value" lines. Arrays are read separately
+	// by ParseShellArrays.
 	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		if _, rest, ok := shellArrayStart(line); ok {
+			_, closed := shellWords(rest)
+			for !closed && i+1 < len(lines) {
+				i++
+				_, closed = shellWords(lines[i])
+			}
+			continue
+		}
 
 		// Skip comments and empty lines
 		if line == "" || strings.HasPrefix(line, "#") {
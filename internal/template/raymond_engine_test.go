@@ -1,8 +1,12 @@
 package template
 
 import (
+	"errors"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestRaymondEngineBasicSubstitution verifies simple variable substitution
@@ -351,3 +355,58 @@ Host github.com
 		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
 	}
 }
+
+// TestRenderErrorPositions verifies parse and runtime errors report the
+// template name, line and column
+func TestRenderErrorPositions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gitconfig.tmpl")
+	os.WriteFile(path, []byte("[user]\n\tname = {{ name }}\n{{#if x}}\nunclosed\n"), 0644)
+
+	e := NewRaymondEngine(dir)
+	_, err := e.RenderFile(path)
+	var rerr *RenderError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("expected RenderError, got %v", err)
+	}
+	if rerr.Template != "gitconfig.tmpl" || rerr.Kind != ErrKindParse || rerr.Line == 0 {
+		t.Errorf("parse error = %+v", rerr)
+	}
+	if !strings.HasPrefix(err.Error(), "gitconfig.tmpl:") {
+		t.Errorf("message should start with the template name: %q", err.Error())
+	}
+
+	_, err = e.Render("ok\n  {{ truncate name }}\n")
+	if !errors.As(err, &rerr) || rerr.Kind != ErrKindRender || rerr.Line != 2 || rerr.Column != 15 {
+		t.Errorf("runtime error = %+v (%v)", rerr, err)
+	}
+}
+
+// TestRenderLimits verifies runaway templates time out and oversized
+// output is rejected
+func TestRenderLimits(t *testing.T) {
+	items := make([]map[string]interface{}, 3000)
+	for i := range items {
+		items[i] = map[string]interface{}{"n": i}
+	}
+
+	e := NewRaymondEngine("")
+	e.SetArray("big", items)
+	e.SetLimits(50*time.Millisecond, 0)
+	_, err := e.Render("{{#each big}}{{#each ../big}}{{#each ../../big}}{{n}}{{/each}}{{/each}}{{/each}}")
+	var rerr *RenderError
+	if !errors.As(err, &rerr) || rerr.Kind != ErrKindTimeout {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	e.SetLimits(time.Second, 100)
+	_, err = e.Render("{{#each big}}{{n}}{{/each}}")
+	if !errors.As(err, &rerr) || rerr.Kind != ErrKindSize {
+		t.Errorf("expected size error, got %v", err)
+	}
+
+	e.SetLimits(0, 0)
+	if out, err := e.Render("{{#each big}}x{{/each}}"); err != nil || len(out) != 3000 {
+		t.Errorf("unlimited render: %d bytes, %v", len(out), err)
+	}
+}
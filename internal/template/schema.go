@@ -0,0 +1,151 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Variable types a schema can declare
+const (
+	VarString = "string"
+	VarBool   = "bool"
+	VarInt    = "int"
+	VarEmail  = "email"
+	VarPath   = "path"
+)
+
+// VariableSpec describes one template variable in _variables.schema.json
+type VariableSpec struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type,omitempty"` // VarString when empty
+	Description string   `json:"description,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Secret      bool     `json:"secret,omitempty"` // masked in diffs and listings
+}
+
+// VariableSchema lists template variables in the order they are asked
+// for, and the element fields of arrays for {{#each}} loops
+type VariableSchema struct {
+	Variables []VariableSpec `json:"variables"`
+	Arrays    []ArraySpec    `json:"arrays,omitempty"`
+}
+
+// SecretNames returns the variables marked secret
+func (s *VariableSchema) SecretNames() []string {
+	var names []string
+	for _, spec := range s.Variables {
+		if spec.Secret {
+			names = append(names, spec.Name)
+		}
+	}
+	return names
+}
+
+// VariableProblem is a variable whose value is missing or invalid
+type VariableProblem struct {
+	Spec  VariableSpec
+	Value string
+	Err   error
+}
+
+// LoadVariableSchema reads and checks a schema file
+func LoadVariableSchema(path string) (*VariableSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var schema VariableSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for _, spec := range schema.Variables {
+		if spec.Name == "" {
+			return nil, fmt.Errorf("%s: variable without a name", path)
+		}
+		if seen[spec.Name] {
+			return nil, fmt.Errorf("%s: %s is declared twice", path, spec.Name)
+		}
+		seen[spec.Name] = true
+		switch spec.Type {
+		case "", VarString, VarBool, VarInt, VarEmail, VarPath:
+		default:
+			return nil, fmt.Errorf("%s: %s has unknown type %q", path, spec.Name, spec.Type)
+		}
+		if spec.Default != "" {
+			if err := spec.Validate(spec.Default); err != nil {
+				return nil, fmt.Errorf("%s: %s default: %w", path, spec.Name, err)
+			}
+		}
+	}
+	arrays := map[string]bool{}
+	for _, a := range schema.Arrays {
+		if err := checkArraySpec(a); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if arrays[a.Name] {
+			return nil, fmt.Errorf("%s: array %s is declared twice", path, a.Name)
+		}
+		arrays[a.Name] = true
+	}
+	return &schema, nil
+}
+
+// Validate checks a non-empty value against the variable's type and enum
+func (s VariableSpec) Validate(value string) error {
+	if strings.ContainsAny(value, "\"\n") {
+		return fmt.Errorf("must not contain quotes or newlines")
+	}
+	if len(s.Enum) > 0 {
+		for _, allowed := range s.Enum {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(s.Enum, ", "))
+	}
+	switch s.Type {
+	case VarBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("must be true or false")
+		}
+	case VarInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("must be a whole number")
+		}
+	case VarEmail:
+		if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+			return fmt.Errorf("must be an email address")
+		}
+	case VarPath:
+		if !strings.HasPrefix(value, "/") && !strings.HasPrefix(value, "~") && !strings.HasPrefix(value, "$") {
+			return fmt.Errorf("must be an absolute path (/..., ~/..., or $VAR/...)")
+		}
+	}
+	return nil
+}
+
+// Check returns the variables in vars that are required but empty, or set
+// to a value that does not validate
+func (s *VariableSchema) Check(vars map[string]string) []VariableProblem {
+	var problems []VariableProblem
+	for _, spec := range s.Variables {
+		value := vars[spec.Name]
+		if value == "" {
+			if spec.Required {
+				problems = append(problems, VariableProblem{Spec: spec, Err: fmt.Errorf("required")})
+			}
+			continue
+		}
+		if err := spec.Validate(value); err != nil {
+			problems = append(problems, VariableProblem{Spec: spec, Value: value, Err: err})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,73 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVariableSpecValidate(t *testing.T) {
+	tests := []struct {
+		spec  VariableSpec
+		value string
+		ok    bool
+	}{
+		{VariableSpec{Type: VarEmail}, "me@example.com", true},
+		{VariableSpec{Type: VarEmail}, "Me <me@example.com>", false},
+		{VariableSpec{Type: VarEmail}, "not-an-email", false},
+		{VariableSpec{Type: VarBool}, "true", true},
+		{VariableSpec{Type: VarBool}, "yes", false},
+		{VariableSpec{Type: VarInt}, "42", true},
+		{VariableSpec{Type: VarInt}, "4.2", false},
+		{VariableSpec{Type: VarPath}, "~/projects", true},
+		{VariableSpec{Type: VarPath}, "$HOME/notes", true},
+		{VariableSpec{Type: VarPath}, "projects", false},
+		{VariableSpec{Enum: []string{"work", "personal"}}, "work", true},
+		{VariableSpec{Enum: []string{"work", "personal"}}, "home", false},
+		{VariableSpec{}, `say "hi"`, false},
+	}
+	for _, tt := range tests {
+		if err := tt.spec.Validate(tt.value); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v, %q) = %v", tt.spec, tt.value, err)
+		}
+	}
+}
+
+func TestVariableSchemaCheck(t *testing.T) {
+	schema := &VariableSchema{Variables: []VariableSpec{
+		{Name: "git_name", Required: true},
+		{Name: "git_email", Type: VarEmail, Required: true},
+		{Name: "github_user"},
+		{Name: "enable_nvm", Type: VarBool},
+	}}
+	problems := schema.Check(map[string]string{"git_email": "nope", "enable_nvm": "true"})
+	var names []string
+	for _, p := range problems {
+		names = append(names, p.Spec.Name)
+	}
+	if strings.Join(names, ",") != "git_name,git_email" {
+		t.Errorf("problems = %v", names)
+	}
+}
+
+func TestLoadVariableSchema(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"dup.json":     `{"variables": [{"name": "a"}, {"name": "a"}]}`,
+		"type.json":    `{"variables": [{"name": "a", "type": "float"}]}`,
+		"default.json": `{"variables": [{"name": "a", "type": "bool", "default": "maybe"}]}`,
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		if _, err := LoadVariableSchema(path); err == nil {
+			t.Errorf("%s: loaded without error", name)
+		}
+	}
+
+	// The schema shipped with the templates must load
+	schema, err := LoadVariableSchema(filepath.Join("..", "..", "templates", "_variables.schema.json"))
+	if err != nil || len(schema.Variables) == 0 {
+		t.Fatalf("shipped schema: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package vault
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/blackwell-systems/blackdot/internal/safefile"
+)
+
+// Audit results
+const (
+	AuditOK       = "ok"
+	AuditNotFound = "not_found"
+	AuditError    = "error"
+)
+
+// AuditEntry is one secret read or write in the audit log. Hash covers
+// every other field, including Prev (the hash of the entry before), so
+// changing, removing, or reordering an entry breaks the chain.
+type AuditEntry struct {
+	Seq     int    `json:"seq"`
+	Time    string `json:"time"`
+	Backend string `json:"backend"`
+	Op      string `json:"op"`
+	Item    string `json:"item"`
+	Result  string `json:"result"`
+	Prev    string `json:"prev"`
+	Hash    string `json:"hash"`
+}
+
+// auditHash is the hash of an entry with its Hash field empty
+func auditHash(e AuditEntry) string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditHeadPath is the file recording the last entry's sequence number
+// and hash, so a log cut short at the end is detected too
+func AuditHeadPath(path string) string {
+	return path + ".head"
+}
+
+// AppendAudit chains e onto the log at path: Seq, Prev, and Hash are
+// filled in from the last entry. Writers take turns through the file's
+// lock, so concurrent commands do not fork the chain.
+func AppendAudit(path string, e AuditEntry) error {
+	release, err := safefile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	last, err := lastAuditEntry(f)
+	if err != nil {
+		return err
+	}
+	e.Seq, e.Prev = last.Seq+1, last.Hash
+	e.Hash = auditHash(e)
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return safefile.Write(AuditHeadPath(path), []byte(fmt.Sprintf("%d %s\n", e.Seq, e.Hash)), 0600)
+}
+
+// lastAuditEntry reads the final line of the log; a zero entry when the
+// log is empty
+func lastAuditEntry(f *os.File) (AuditEntry, error) {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return AuditEntry{}, err
+	}
+	const tail = 64 * 1024
+	start := info.Size() - tail
+	if start < 0 {
+		start = 0
+	}
+	buf := make([]byte, info.Size()-start)
+	if _, err := f.ReadAt(buf, start); err != nil && err != io.EOF {
+		return AuditEntry{}, err
+	}
+	buf = bytes.TrimRight(buf, "\n")
+	if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+		buf = buf[i+1:]
+	}
+	var e AuditEntry
+	if err := json.Unmarshal(buf, &e); err != nil {
+		return AuditEntry{}, fmt.Errorf("last audit entry is unreadable: %w", err)
+	}
+	return e, nil
+}
+
+// ReadAudit returns the log's entries, oldest first. A line that is not
+// an entry is an error: a verifier must not skip it.
+func ReadAudit(path string) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, &AuditBreak{Seq: len(entries) + 1, Reason: fmt.Sprintf("line %d is not an audit entry", n)}
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// AuditBreak is where verification found the chain broken
+type AuditBreak struct {
+	Seq    int
+	Reason string
+}
+
+func (b *AuditBreak) Error() string {
+	return fmt.Sprintf("entry %d: %s", b.Seq, b.Reason)
+}
+
+// VerifyAudit checks the chain: sequence numbers count up from 1, each
+// entry names the previous one's hash, each hash matches its entry, and
+// the last entry matches head ("<seq> <hash>", empty to skip). The chain
+// shows the log was not edited piecemeal; whoever can write the log and
+// its head can still rewrite both.
+func VerifyAudit(entries []AuditEntry, head string) error {
+	prev := AuditEntry{}
+	for _, e := range entries {
+		switch {
+		case e.Seq != prev.Seq+1:
+			return &AuditBreak{Seq: prev.Seq + 1, Reason: fmt.Sprintf("found entry %d (entries missing or reordered)", e.Seq)}
+		case e.Prev != prev.Hash:
+			return &AuditBreak{Seq: e.Seq, Reason: "does not chain to the entry before it"}
+		case auditHash(e) != e.Hash:
+			return &AuditBreak{Seq: e.Seq, Reason: "was modified (hash mismatch)"}
+		}
+		prev = e
+	}
+
+	head = strings.TrimSpace(head)
+	if head == "" {
+		return nil
+	}
+	seqField, hash, _ := strings.Cut(head, " ")
+	seq, err := strconv.Atoi(seqField)
+	if err != nil {
+		return &AuditBreak{Seq: prev.Seq, Reason: "head file is unreadable"}
+	}
+	if seq != prev.Seq || hash != prev.Hash {
+		return &AuditBreak{Seq: prev.Seq, Reason: fmt.Sprintf("log ends at entry %d but the head records entry %d (log truncated or replaced)", prev.Seq, seq)}
+	}
+	return nil
+}
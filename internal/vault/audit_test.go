@@ -0,0 +1,91 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/blackwell-systems/blackdot/internal/paths"
+)
+
+func setAuditLog(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	t.Setenv("BLACKDOT_PATHS_STRATEGY", "xdg")
+	paths.ResetStrategy()
+	t.Cleanup(paths.ResetStrategy)
+	return filepath.Join(home, "state", "blackdot", "vault-audit.jsonl")
+}
+
+// TestAuditChain verifies appended entries chain and verify, including
+// appends from concurrent goroutines
+func TestAuditChain(t *testing.T) {
+	path := setAuditLog(t)
+	var wg sync.WaitGroup
+	for _, item := range []string{"SSH-Config", "Git-Config", "AWS-Credentials", "Env-Secrets"} {
+		wg.Add(1)
+		go func(item string) {
+			defer wg.Done()
+			if err := AppendAudit(path, AuditEntry{Backend: "pass", Op: "read", Item: item, Result: AuditOK}); err != nil {
+				t.Error(err)
+			}
+		}(item)
+	}
+	wg.Wait()
+
+	entries, err := ReadAudit(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 || entries[0].Prev != "" || entries[3].Seq != 4 {
+		t.Fatalf("entries = %+v", entries)
+	}
+	head, _ := os.ReadFile(AuditHeadPath(path))
+	if err := VerifyAudit(entries, string(head)); err != nil {
+		t.Errorf("intact log failed to verify: %v", err)
+	}
+}
+
+// TestVerifyAuditDetectsTampering verifies edits, deletions, and a
+// truncated tail each break verification at the right entry
+func TestVerifyAuditDetectsTampering(t *testing.T) {
+	path := setAuditLog(t)
+	for _, item := range []string{"a", "b", "c"} {
+		if err := AppendAudit(path, AuditEntry{Backend: "pass", Op: "update", Item: item, Result: AuditOK}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _ := ReadAudit(path)
+	headData, _ := os.ReadFile(AuditHeadPath(path))
+	head := string(headData)
+
+	edited := append([]AuditEntry(nil), entries...)
+	edited[1].Item = "other"
+	removed := []AuditEntry{entries[0], entries[2]}
+	cases := map[string]struct {
+		entries []AuditEntry
+		seq     int
+		reason  string
+	}{
+		"edited":    {edited, 2, "modified"},
+		"removed":   {removed, 2, "missing"},
+		"truncated": {entries[:2], 2, "truncated"},
+	}
+	for name, c := range cases {
+		err := VerifyAudit(c.entries, head)
+		var brk *AuditBreak
+		if !errors.As(err, &brk) || brk.Seq != c.seq || !strings.Contains(brk.Reason, c.reason) {
+			t.Errorf("%s: VerifyAudit = %v", name, err)
+		}
+	}
+
+	os.WriteFile(path, []byte("not json\n"), 0600)
+	if _, err := ReadAudit(path); err == nil {
+		t.Error("a garbage line must fail to read, not be skipped")
+	}
+}
@@ -0,0 +1,428 @@
+// Package doppler implements a vaultmux backend on Doppler, using its REST
+// API with a personal, CLI or service token.
+//
+// Doppler secret names are upper-case letters, digits and underscores, so
+// each item is stored as prefix + its name upper-cased with every other
+// character replaced by '_' ("SSH-Config" becomes BLACKDOT_SSH_CONFIG).
+// Values are the item content unchanged, so other tools can read them.
+// That mapping is not reversible, so the secret named prefix + "_INDEX"
+// records the item names; it is written in the same request as the item.
+package doppler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// BackendType is the vault.backend value selecting this backend
+const BackendType vaultmux.BackendType = "doppler"
+
+// DefaultAPIURL is Doppler's API
+const DefaultAPIURL = "https://api.doppler.com"
+
+// DefaultPrefix namespaces blackdot's secrets in a config
+const DefaultPrefix = "BLACKDOT_"
+
+// indexSuffix follows the prefix in the name of the index secret
+const indexSuffix = "_INDEX"
+
+func init() {
+	vaultmux.RegisterBackend(BackendType, func(cfg vaultmux.Config) (vaultmux.Backend, error) {
+		return New(cfg.Options), nil
+	})
+}
+
+// Backend implements vaultmux.Backend on a Doppler config
+type Backend struct {
+	token   string
+	project string // empty for service tokens, which are bound to one
+	config  string
+	prefix  string
+	apiURL  string
+	client  *http.Client
+}
+
+// New creates a backend from the options token, project, config, prefix
+// (default BLACKDOT_) and api_url
+func New(options map[string]string) *Backend {
+	b := &Backend{
+		token:   options["token"],
+		project: options["project"],
+		config:  options["config"],
+		prefix:  options["prefix"],
+		apiURL:  strings.TrimRight(options["api_url"], "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+	if b.prefix == "" {
+		b.prefix = DefaultPrefix
+	}
+	if b.apiURL == "" {
+		b.apiURL = DefaultAPIURL
+	}
+	return b
+}
+
+// SecretName returns the Doppler secret an item is stored in
+func SecretName(prefix, name string) string {
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// Name returns the backend name
+func (b *Backend) Name() string { return string(BackendType) }
+
+// Init checks a token is configured
+func (b *Backend) Init(ctx context.Context) error {
+	if b.token == "" {
+		return vaultmux.WrapError(b.Name(), "init", "", errors.New("no Doppler token (set DOPPLER_TOKEN or run 'doppler login')"))
+	}
+	return nil
+}
+
+// Close is a no-op
+func (b *Backend) Close() error { return nil }
+
+// IsAuthenticated reports whether the token is accepted
+func (b *Backend) IsAuthenticated(ctx context.Context) bool {
+	if b.token == "" {
+		return false
+	}
+	return b.do(ctx, http.MethodGet, "/v3/me", nil, nil, nil) == nil
+}
+
+// Authenticate returns a session for the token; Doppler tokens don't
+// expire unless revoked
+func (b *Backend) Authenticate(ctx context.Context) (vaultmux.Session, error) {
+	if !b.IsAuthenticated(ctx) {
+		return nil, vaultmux.ErrNotAuthenticated
+	}
+	return &dopplerSession{}, nil
+}
+
+// Sync is a no-op; every read goes to the API
+func (b *Backend) Sync(ctx context.Context, _ vaultmux.Session) error { return nil }
+
+// GetItem retrieves an item
+func (b *Backend) GetItem(ctx context.Context, name string, s vaultmux.Session) (*vaultmux.Item, error) {
+	notes, err := b.GetNotes(ctx, name, s)
+	if err != nil {
+		return nil, err
+	}
+	return &vaultmux.Item{ID: SecretName(b.prefix, name), Name: name, Type: vaultmux.ItemTypeSecureNote, Notes: notes}, nil
+}
+
+// GetNotes retrieves an item's content
+func (b *Backend) GetNotes(ctx context.Context, name string, _ vaultmux.Session) (string, error) {
+	if err := vaultmux.ValidateItemName(name); err != nil {
+		return "", vaultmux.WrapError(b.Name(), "get", name, err)
+	}
+	var resp struct {
+		Value struct {
+			Raw *string `json:"raw"`
+		} `json:"value"`
+	}
+	q := b.query()
+	q.Set("name", SecretName(b.prefix, name))
+	if err := b.do(ctx, http.MethodGet, "/v3/configs/config/secret", q, nil, &resp); err != nil {
+		return "", b.wrap("get", name, err)
+	}
+	if resp.Value.Raw == nil {
+		return "", vaultmux.ErrNotFound
+	}
+	return *resp.Value.Raw, nil
+}
+
+// ItemExists checks whether an item exists
+func (b *Backend) ItemExists(ctx context.Context, name string, s vaultmux.Session) (bool, error) {
+	_, err := b.GetNotes(ctx, name, s)
+	if errors.Is(err, vaultmux.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ListItems lists the indexed items that exist, and any other secret
+// with the prefix under its secret name
+func (b *Backend) ListItems(ctx context.Context, _ vaultmux.Session) ([]*vaultmux.Item, error) {
+	secrets, err := b.secrets(ctx)
+	if err != nil {
+		return nil, b.wrap("list", "", err)
+	}
+	index := b.index(secrets)
+	listed := make(map[string]bool)
+	var items []*vaultmux.Item
+	for _, name := range index {
+		secret := SecretName(b.prefix, name)
+		if _, ok := secrets[secret]; ok && !listed[secret] {
+			listed[secret] = true
+			items = append(items, &vaultmux.Item{ID: secret, Name: name, Type: vaultmux.ItemTypeSecureNote})
+		}
+	}
+	var other []string
+	for secret := range secrets {
+		if strings.HasPrefix(secret, b.prefix) && !listed[secret] && secret != b.indexName() {
+			other = append(other, secret)
+		}
+	}
+	sort.Strings(other)
+	for _, secret := range other {
+		items = append(items, &vaultmux.Item{ID: secret, Name: strings.TrimPrefix(secret, b.prefix), Type: vaultmux.ItemTypeSecureNote})
+	}
+	return items, nil
+}
+
+// CreateItem stores a new item and adds it to the index in one request
+func (b *Backend) CreateItem(ctx context.Context, name, content string, _ vaultmux.Session) error {
+	if err := vaultmux.ValidateItemName(name); err != nil {
+		return vaultmux.WrapError(b.Name(), "create", name, err)
+	}
+	secret := SecretName(b.prefix, name)
+	if secret == b.indexName() {
+		return vaultmux.WrapError(b.Name(), "create", name, fmt.Errorf("%s is reserved for the item index", secret))
+	}
+	secrets, err := b.secrets(ctx)
+	if err != nil {
+		return b.wrap("create", name, err)
+	}
+	if _, exists := secrets[secret]; exists {
+		return vaultmux.ErrAlreadyExists
+	}
+	update := map[string]string{secret: content}
+	if index, changed := addToIndex(b.index(secrets), name); changed {
+		update[b.indexName()] = index
+	}
+	return b.wrap("create", name, b.set(ctx, update))
+}
+
+// UpdateItem replaces an item's content
+func (b *Backend) UpdateItem(ctx context.Context, name, content string, s vaultmux.Session) error {
+	if exists, err := b.ItemExists(ctx, name, s); err != nil {
+		return err
+	} else if !exists {
+		return vaultmux.ErrNotFound
+	}
+	return b.wrap("update", name, b.set(ctx, map[string]string{SecretName(b.prefix, name): content}))
+}
+
+// DeleteItem removes an item and its index entry
+func (b *Backend) DeleteItem(ctx context.Context, name string, s vaultmux.Session) error {
+	if exists, err := b.ItemExists(ctx, name, s); err != nil {
+		return err
+	} else if !exists {
+		return vaultmux.ErrNotFound
+	}
+	q := b.query()
+	q.Set("name", SecretName(b.prefix, name))
+	if err := b.do(ctx, http.MethodDelete, "/v3/configs/config/secret", q, nil, nil); err != nil {
+		return b.wrap("delete", name, err)
+	}
+	secrets, err := b.secrets(ctx)
+	if err != nil {
+		return b.wrap("delete", name, err)
+	}
+	if index, changed := removeFromIndex(b.index(secrets), name); changed {
+		return b.wrap("delete", name, b.set(ctx, map[string]string{b.indexName(): index}))
+	}
+	return nil
+}
+
+// ListLocations returns no locations; a config is flat
+func (b *Backend) ListLocations(ctx context.Context, _ vaultmux.Session) ([]string, error) {
+	return []string{}, nil
+}
+
+// LocationExists always reports false; a config is flat
+func (b *Backend) LocationExists(ctx context.Context, name string, _ vaultmux.Session) (bool, error) {
+	return false, nil
+}
+
+// CreateLocation is a no-op; a config is flat
+func (b *Backend) CreateLocation(ctx context.Context, name string, _ vaultmux.Session) error {
+	return nil
+}
+
+// ListItemsInLocation lists every item; the project and config take the
+// place of folders, so a configured vault location is ignored
+func (b *Backend) ListItemsInLocation(ctx context.Context, locType, locValue string, s vaultmux.Session) ([]*vaultmux.Item, error) {
+	return b.ListItems(ctx, s)
+}
+
+func (b *Backend) indexName() string { return b.prefix + indexSuffix }
+
+// index returns the item names recorded in the index secret
+func (b *Backend) index(secrets map[string]string) []string {
+	var names []string
+	if raw, ok := secrets[b.indexName()]; ok {
+		_ = json.Unmarshal([]byte(raw), &names)
+	}
+	return names
+}
+
+func addToIndex(names []string, name string) (string, bool) {
+	for _, n := range names {
+		if n == name {
+			return "", false
+		}
+	}
+	names = append(names, name)
+	sort.Strings(names)
+	data, _ := json.Marshal(names)
+	return string(data), true
+}
+
+func removeFromIndex(names []string, name string) (string, bool) {
+	kept := make([]string, 0, len(names))
+	for _, n := range names {
+		if n != name {
+			kept = append(kept, n)
+		}
+	}
+	if len(kept) == len(names) {
+		return "", false
+	}
+	data, _ := json.Marshal(kept)
+	return string(data), true
+}
+
+// secrets downloads the raw value of every secret in the config
+func (b *Backend) secrets(ctx context.Context) (map[string]string, error) {
+	var resp struct {
+		Secrets map[string]struct {
+			Raw string `json:"raw"`
+		} `json:"secrets"`
+	}
+	q := b.query()
+	q.Set("include_dynamic_secrets", "false")
+	if err := b.do(ctx, http.MethodGet, "/v3/configs/config/secrets", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	secrets := make(map[string]string, len(resp.Secrets))
+	for name, v := range resp.Secrets {
+		secrets[name] = v.Raw
+	}
+	return secrets, nil
+}
+
+// set creates or replaces secrets in one request
+func (b *Backend) set(ctx context.Context, secrets map[string]string) error {
+	body := map[string]interface{}{"secrets": secrets}
+	if b.project != "" {
+		body["project"] = b.project
+	}
+	if b.config != "" {
+		body["config"] = b.config
+	}
+	return b.do(ctx, http.MethodPost, "/v3/configs/config/secrets", nil, body, nil)
+}
+
+// query holds the project and config, which service tokens imply
+func (b *Backend) query() url.Values {
+	q := url.Values{}
+	if b.project != "" {
+		q.Set("project", b.project)
+	}
+	if b.config != "" {
+		q.Set("config", b.config)
+	}
+	return q
+}
+
+// apiError is a non-2xx API response
+type apiError struct {
+	status   int
+	messages []string
+}
+
+func (e *apiError) Error() string {
+	if len(e.messages) == 0 {
+		return fmt.Sprintf("Doppler API: HTTP %d", e.status)
+	}
+	return fmt.Sprintf("Doppler API: %s (HTTP %d)", strings.Join(e.messages, "; "), e.status)
+}
+
+// wrap maps API errors to vaultmux errors
+func (b *Backend) wrap(op, name string, err error) error {
+	var ae *apiError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &ae) && ae.status == http.StatusNotFound && op != "list":
+		return vaultmux.ErrNotFound
+	case errors.As(err, &ae) && (ae.status == http.StatusUnauthorized || ae.status == http.StatusForbidden):
+		return vaultmux.WrapError(b.Name(), op, name, fmt.Errorf("%w: %v", vaultmux.ErrNotAuthenticated, err))
+	}
+	return vaultmux.WrapError(b.Name(), op, name, err)
+}
+
+// do sends an API request and decodes the JSON response into out
+func (b *Backend) do(ctx context.Context, method, path string, q url.Values, body, out interface{}) error {
+	u := b.apiURL + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Messages []string `json:"messages"`
+		}
+		_ = json.Unmarshal(data, &e)
+		return &apiError{status: resp.StatusCode, messages: e.Messages}
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+// dopplerSession is a no-op session; the token is sent with every request
+type dopplerSession struct{}
+
+func (s *dopplerSession) Token() string                     { return "" }
+func (s *dopplerSession) IsValid(ctx context.Context) bool  { return true }
+func (s *dopplerSession) Refresh(ctx context.Context) error { return nil }
+func (s *dopplerSession) ExpiresAt() time.Time              { return time.Time{} }
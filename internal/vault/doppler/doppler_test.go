@@ -0,0 +1,140 @@
+package doppler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// fakeDoppler serves the config secret endpoints from a map
+func fakeDoppler(t *testing.T, secrets map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer dp.pt.test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{"messages": []string{"Invalid Auth token"}})
+			return
+		}
+		if p := r.URL.Query().Get("project"); r.Method == http.MethodGet && p != "" && p != "dotfiles" {
+			t.Errorf("project = %s", p)
+		}
+		name := r.URL.Query().Get("name")
+		switch {
+		case r.URL.Path == "/v3/me":
+			w.Write([]byte(`{"name":"test"}`))
+		case r.URL.Path == "/v3/configs/config/secret" && r.Method == http.MethodGet:
+			v, ok := secrets[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"messages":["Could not find requested secret"]}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": name, "value": map[string]string{"raw": v, "computed": v}})
+		case r.URL.Path == "/v3/configs/config/secret" && r.Method == http.MethodDelete:
+			delete(secrets, name)
+		case r.URL.Path == "/v3/configs/config/secrets" && r.Method == http.MethodGet:
+			all := make(map[string]map[string]string)
+			for k, v := range secrets {
+				all[k] = map[string]string{"raw": v}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"secrets": all})
+		case r.URL.Path == "/v3/configs/config/secrets" && r.Method == http.MethodPost:
+			var body struct {
+				Project string            `json:"project"`
+				Secrets map[string]string `json:"secrets"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Project != "dotfiles" {
+				t.Errorf("write to project %q", body.Project)
+			}
+			for k, v := range body.Secrets {
+				secrets[k] = v
+			}
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestSecretName verifies item names map to valid Doppler secret names
+func TestSecretName(t *testing.T) {
+	for name, want := range map[string]string{
+		"SSH-Config":          "BLACKDOT_SSH_CONFIG",
+		"AWS-Credentials":     "BLACKDOT_AWS_CREDENTIALS",
+		"Git-Credential-host": "BLACKDOT_GIT_CREDENTIAL_HOST",
+		"git.config":          "BLACKDOT_GIT_CONFIG",
+	} {
+		if got := SecretName(DefaultPrefix, name); got != want {
+			t.Errorf("SecretName(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+// TestBackend verifies items round-trip, are listed by their item names
+// through the index, and map API errors to vaultmux errors
+func TestBackend(t *testing.T) {
+	ctx := context.Background()
+	secrets := map[string]string{"BLACKDOT_MANUAL": "x", "OTHER_APP_KEY": "y"}
+	srv := fakeDoppler(t, secrets)
+	b := New(map[string]string{"token": "dp.pt.test", "project": "dotfiles", "config": "dev", "api_url": srv.URL})
+
+	session, err := b.Authenticate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "Host *\n  AddKeysToAgent yes\n"
+	if err := b.CreateItem(ctx, "SSH-Config", content, session); err != nil {
+		t.Fatal(err)
+	}
+	if secrets["BLACKDOT_SSH_CONFIG"] != content || secrets["BLACKDOT__INDEX"] != `["SSH-Config"]` {
+		t.Errorf("stored secrets = %v", secrets)
+	}
+	if err := b.CreateItem(ctx, "SSH_Config", "other", session); !errors.Is(err, vaultmux.ErrAlreadyExists) {
+		t.Errorf("colliding create = %v", err)
+	}
+	if got, err := b.GetNotes(ctx, "SSH-Config", session); err != nil || got != content {
+		t.Errorf("GetNotes = %q, %v", got, err)
+	}
+	if _, err := b.GetNotes(ctx, "Missing", session); !errors.Is(err, vaultmux.ErrNotFound) {
+		t.Errorf("missing item = %v", err)
+	}
+	if err := b.UpdateItem(ctx, "SSH-Config", "Host work\n", session); err != nil || secrets["BLACKDOT_SSH_CONFIG"] != "Host work\n" {
+		t.Errorf("UpdateItem = %v", err)
+	}
+
+	items, err := b.ListItems(ctx, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	if strings.Join(names, " ") != "SSH-Config MANUAL" {
+		t.Errorf("ListItems = %v", names)
+	}
+
+	if err := b.DeleteItem(ctx, "SSH-Config", session); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := secrets["BLACKDOT_SSH_CONFIG"]; ok || secrets["BLACKDOT__INDEX"] != "[]" {
+		t.Errorf("after delete = %v", secrets)
+	}
+
+	bad := New(map[string]string{"token": "wrong", "api_url": srv.URL})
+	if bad.IsAuthenticated(ctx) {
+		t.Error("a rejected token should not authenticate")
+	}
+	if _, err := bad.GetNotes(ctx, "SSH-Config", nil); !errors.Is(err, vaultmux.ErrNotAuthenticated) || !strings.Contains(err.Error(), "Invalid Auth token") {
+		t.Errorf("rejected token error = %v", err)
+	}
+}
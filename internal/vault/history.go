@@ -0,0 +1,193 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// DefaultHistoryKeep is how many previous versions are kept per item
+const DefaultHistoryKeep = 5
+
+// versionSep separates an item name from its version number. Names ending
+// in .v<digits> are reserved for history.
+const versionSep = ".v"
+
+// VersionName is the vault item holding version n of name
+func VersionName(name string, n int) string {
+	return name + versionSep + strconv.Itoa(n)
+}
+
+// ParseVersionName splits a history item name into its item and version
+func ParseVersionName(itemName string) (string, int, bool) {
+	i := strings.LastIndex(itemName, versionSep)
+	if i <= 0 {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(itemName[i+len(versionSep):])
+	if err != nil || n <= 0 {
+		return "", 0, false
+	}
+	return itemName[:i], n, true
+}
+
+// Version is a previous content of an item
+type Version struct {
+	Number   int       `json:"version"`
+	Item     string    `json:"item"`
+	Modified time.Time `json:"modified,omitempty"`
+}
+
+// historyBackend saves the current content of an item as a version before
+// each update and hides versions from listings
+type historyBackend struct {
+	vaultmux.Backend
+	keep int
+}
+
+// WithHistory returns backend with versioning on updates, keeping the last
+// keep versions of each item. keep <= 0 turns history off.
+func WithHistory(backend vaultmux.Backend, keep int) vaultmux.Backend {
+	if keep <= 0 {
+		return backend
+	}
+	return &historyBackend{Backend: backend, keep: keep}
+}
+
+func (b *historyBackend) UpdateItem(ctx context.Context, name, content string, session vaultmux.Session) error {
+	if _, _, isVersion := ParseVersionName(name); !isVersion {
+		previous, err := b.Backend.GetNotes(ctx, name, session)
+		if err == nil && previous != "" && previous != content {
+			if err := saveVersion(ctx, b.Backend, name, previous, session, b.keep); err != nil {
+				return fmt.Errorf("saving previous version: %w", err)
+			}
+		}
+	}
+	return b.Backend.UpdateItem(ctx, name, content, session)
+}
+
+func (b *historyBackend) ListItems(ctx context.Context, session vaultmux.Session) ([]*vaultmux.Item, error) {
+	items, err := b.Backend.ListItems(ctx, session)
+	return withoutVersions(items), err
+}
+
+func (b *historyBackend) ListItemsInLocation(ctx context.Context, locType, locValue string, session vaultmux.Session) ([]*vaultmux.Item, error) {
+	items, err := b.Backend.ListItemsInLocation(ctx, locType, locValue, session)
+	return withoutVersions(items), err
+}
+
+func withoutVersions(items []*vaultmux.Item) []*vaultmux.Item {
+	out := items[:0:0]
+	for _, item := range items {
+		if _, _, isVersion := ParseVersionName(item.Name); !isVersion {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// unwrapHistory returns the backend under a history wrapper, which lists
+// versions
+func unwrapHistory(backend vaultmux.Backend) vaultmux.Backend {
+	if h, ok := backend.(*historyBackend); ok {
+		return h.Backend
+	}
+	return backend
+}
+
+// listVersions returns the versions of name, oldest first
+func listVersions(ctx context.Context, backend vaultmux.Backend, name string, session vaultmux.Session) ([]Version, error) {
+	items, err := backend.ListItems(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	var versions []Version
+	for _, item := range items {
+		if base, n, ok := ParseVersionName(item.Name); ok && base == name {
+			versions = append(versions, Version{Number: n, Item: item.Name, Modified: item.Modified})
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Number < versions[j].Number })
+	return versions, nil
+}
+
+// saveVersion stores content as the next version of name and prunes to keep
+func saveVersion(ctx context.Context, backend vaultmux.Backend, name, content string, session vaultmux.Session, keep int) error {
+	versions, err := listVersions(ctx, backend, name, session)
+	if err != nil {
+		return err
+	}
+	next := 1
+	if len(versions) > 0 {
+		next = versions[len(versions)-1].Number + 1
+	}
+	if err := backend.CreateItem(ctx, VersionName(name, next), content, session); err != nil {
+		return err
+	}
+	versions = append(versions, Version{Number: next, Item: VersionName(name, next)})
+	_, err = pruneVersions(ctx, backend, versions, keep, session)
+	return err
+}
+
+// pruneVersions deletes all but the newest keep versions
+func pruneVersions(ctx context.Context, backend vaultmux.Backend, versions []Version, keep int, session vaultmux.Session) ([]Version, error) {
+	if keep < 0 || len(versions) <= keep {
+		return nil, nil
+	}
+	var removed []Version
+	for _, v := range versions[:len(versions)-keep] {
+		if err := backend.DeleteItem(ctx, v.Item, session); err != nil {
+			return removed, err
+		}
+		removed = append(removed, v)
+	}
+	return removed, nil
+}
+
+// History returns the saved versions of name, oldest first
+func (s *Service) History(ctx context.Context, name string) ([]Version, error) {
+	session, err := s.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return listVersions(ctx, unwrapHistory(s.backend), name, session)
+}
+
+// GetVersion returns the content of version n of name
+func (s *Service) GetVersion(ctx context.Context, name string, n int) (string, error) {
+	session, err := s.Connect(ctx)
+	if err != nil {
+		return "", err
+	}
+	return s.backend.GetNotes(ctx, VersionName(name, n), session)
+}
+
+// RestoreVersion makes version n the current content of name. With history
+// on, the content it replaces becomes the newest version, so a restore can
+// itself be undone.
+func (s *Service) RestoreVersion(ctx context.Context, name string, n int) error {
+	content, err := s.GetVersion(ctx, name, n)
+	if err != nil {
+		return fmt.Errorf("version %d of %s: %w", n, name, err)
+	}
+	return s.backend.UpdateItem(ctx, name, content, s.session)
+}
+
+// PruneHistory deletes all but the newest keep versions of name
+func (s *Service) PruneHistory(ctx context.Context, name string, keep int) ([]Version, error) {
+	session, err := s.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	backend := unwrapHistory(s.backend)
+	versions, err := listVersions(ctx, backend, name, session)
+	if err != nil {
+		return nil, err
+	}
+	return pruneVersions(ctx, backend, versions, keep, session)
+}
@@ -0,0 +1,61 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blackwell-systems/vaultmux/mock"
+)
+
+// TestParseVersionName verifies only .v<positive number> suffixes count
+func TestParseVersionName(t *testing.T) {
+	cases := map[string]int{"Git-Config.v3": 3, "a.b.v12": 12, "Git-Config": 0, "x.v0": 0, "x.vx": 0, ".v1": 0}
+	for name, want := range cases {
+		_, n, ok := ParseVersionName(name)
+		if ok != (want > 0) || n != want {
+			t.Errorf("%s: got %d, %v", name, n, ok)
+		}
+	}
+}
+
+// TestHistory verifies updates save versions, prune to keep, stay out of
+// listings, and can be restored
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	raw := mock.New()
+	raw.SetItem("Git-Config", "one")
+	svc := New(WithHistory(raw, 2), nil)
+
+	for _, content := range []string{"two", "two", "three", "four"} {
+		if _, err := svc.Create(ctx, "Git-Config", content, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := svc.History(ctx, "Git-Config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one, two, three were saved; unchanged writes add nothing; keep 2
+	if len(versions) != 2 || versions[0].Number != 2 || versions[1].Number != 3 {
+		t.Fatalf("versions = %+v", versions)
+	}
+	if items, _ := svc.List(ctx, ""); len(items) != 1 {
+		t.Errorf("list shows versions: %d items", len(items))
+	}
+
+	if err := svc.RestoreVersion(ctx, "Git-Config", 2); err != nil {
+		t.Fatal(err)
+	}
+	if notes, _ := raw.GetNotes(ctx, "Git-Config", nil); notes != "two" {
+		t.Errorf("restored content = %q", notes)
+	}
+	if notes, _ := raw.GetNotes(ctx, VersionName("Git-Config", 4), nil); notes != "four" {
+		t.Errorf("restore should save the replaced content, got %q", notes)
+	}
+
+	removed, err := svc.PruneHistory(ctx, "Git-Config", 0)
+	if err != nil || len(removed) != 2 {
+		t.Errorf("prune: %v, %v", removed, err)
+	}
+}
@@ -0,0 +1,134 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Item is a vault item restored to a local file, as declared under
+// vault_items in vault-items.json
+type Item struct {
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	// After names items that must be restored before this one
+	After []string `json:"after,omitempty"`
+	// RequiresFeature names a feature the item is only needed with; while
+	// it is disabled, check and restore skip the item
+	RequiresFeature string `json:"requires_feature,omitempty"`
+}
+
+// ItemsFile is the parsed vault-items.json
+type ItemsFile struct {
+	VaultItems    map[string]Item   `json:"vault_items"`
+	SyncableItems map[string]string `json:"syncable_items"`
+}
+
+// LoadItemsFile reads vault-items.json. Read errors are returned as is, so
+// os.IsNotExist works on them.
+func LoadItemsFile(path string) (*ItemsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f ItemsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Syncable returns the items push compares against local files:
+// syncable_items when set, otherwise the paths of vault_items
+func (f *ItemsFile) Syncable() map[string]string {
+	if len(f.SyncableItems) > 0 {
+		return f.SyncableItems
+	}
+	result := make(map[string]string, len(f.VaultItems))
+	for name, item := range f.VaultItems {
+		result[name] = item.Path
+	}
+	return result
+}
+
+// protectedPrefixes name the items restore depends on
+var protectedPrefixes = []string{"SSH-", "AWS-", "Git-Config", "Environment-Secrets"}
+
+// IsProtected reports whether deleting the item would break restore
+func IsProtected(name string) bool {
+	for _, prefix := range protectedPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CycleError reports items whose after dependencies loop
+type CycleError struct {
+	Cycle []string // first item repeated at the end
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Cycle, " -> ")
+}
+
+// RestoreOrder returns item names so every item comes after the items in
+// its After list. Independent items are in alphabetical order. A
+// dependency on an undeclared item or a cycle is an error.
+func RestoreOrder(items map[string]Item) ([]string, error) {
+	names := make([]string, 0, len(items))
+	for name, item := range items {
+		names = append(names, name)
+		for _, dep := range item.After {
+			if _, ok := items[dep]; !ok {
+				return nil, fmt.Errorf("%s: after %q, which is not a vault item", name, dep)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(items))
+	order := make([]string, 0, len(items))
+	var stack []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			for i, n := range stack {
+				if n == name {
+					return &CycleError{Cycle: append(append([]string{}, stack[i:]...), name)}
+				}
+			}
+		}
+		state[name] = visiting
+		stack = append(stack, name)
+		deps := append([]string{}, items[name].After...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
@@ -0,0 +1,41 @@
+package vault
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRestoreOrder verifies dependencies come first, other items stay
+// alphabetical, and cycles and unknown dependencies are reported
+func TestRestoreOrder(t *testing.T) {
+	items := map[string]Item{
+		"AWS-Config":          {},
+		"Environment-Secrets": {After: []string{"Template-Variables"}},
+		"SSH-Config":          {},
+		"SSH-Personal":        {After: []string{"SSH-Config"}},
+		"Template-Variables":  {},
+		"Git-Config":          {},
+	}
+	got, err := RestoreOrder(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"AWS-Config", "Template-Variables", "Environment-Secrets", "Git-Config", "SSH-Config", "SSH-Personal"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	items["SSH-Config"] = Item{After: []string{"SSH-Personal"}}
+	_, err = RestoreOrder(items)
+	var cycle *CycleError
+	if !errors.As(err, &cycle) || err.Error() != "dependency cycle: SSH-Config -> SSH-Personal -> SSH-Config" {
+		t.Errorf("cycle: %v", err)
+	}
+
+	items["SSH-Config"] = Item{After: []string{"Missing"}}
+	if _, err := RestoreOrder(items); err == nil || !strings.Contains(err.Error(), `after "Missing"`) {
+		t.Errorf("unknown dependency: %v", err)
+	}
+}
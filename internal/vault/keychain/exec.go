@@ -0,0 +1,57 @@
+package keychain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// cmdError is a store CLI that exited non-zero
+type cmdError struct {
+	name   string
+	code   int
+	stderr string
+}
+
+func (e *cmdError) Error() string {
+	if e.stderr == "" {
+		return fmt.Sprintf("%s: exit status %d", e.name, e.code)
+	}
+	return fmt.Sprintf("%s: %s", e.name, e.stderr)
+}
+
+// exitedSilently reports whether err is an exit with code and no message
+func exitedSilently(err error, code int) bool {
+	var ce *cmdError
+	return errors.As(err, &ce) && ce.code == code && ce.stderr == ""
+}
+
+// exitCode returns the exit code carried by err, or -1
+func exitCode(err error) int {
+	var ce *cmdError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+	return -1
+}
+
+// run executes a store CLI with input on stdin, keeping secrets off the
+// command line, and returns its stdout
+func run(ctx context.Context, input, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), &cmdError{name: name, code: exitErr.ExitCode(), stderr: strings.TrimSpace(stderr.String())}
+	}
+	return stdout.String(), err
+}
@@ -0,0 +1,388 @@
+// Package keychain implements a vaultmux backend on the operating system's
+// credential store: the macOS Keychain, Windows Credential Manager, or the
+// Secret Service (libsecret) on Linux and other Unix systems.
+//
+// Credential stores hold small secrets, so each item is split into chunks.
+// The entry named after the item holds a header with the chunk count and a
+// checksum; the chunks are stored as "<name>#<generation>.<n>". Updates
+// write a new generation before switching the header over, so an
+// interrupted update leaves the previous content readable. Item names
+// cannot contain '#' (vaultmux.ValidateItemName), so chunk and index
+// entries never collide with items.
+package keychain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// BackendType is the vault.backend value selecting this backend
+const BackendType vaultmux.BackendType = "keychain"
+
+// indexAccount holds the item names, since not every store can enumerate
+// its entries
+const indexAccount = "#index"
+
+func init() {
+	vaultmux.RegisterBackend(BackendType, func(cfg vaultmux.Config) (vaultmux.Backend, error) {
+		return New(cfg.Prefix, newNativeStore()), nil
+	})
+}
+
+// StoreName names this platform's credential store
+func StoreName() string { return newNativeStore().name() }
+
+// Available reports why this platform's credential store cannot be used,
+// or nil when it can
+func Available(ctx context.Context) error { return newNativeStore().available(ctx) }
+
+// store is one platform credential store. Entries are addressed by service
+// and account; values are ASCII (the backend base64-encodes everything).
+type store interface {
+	// name describes the store for messages
+	name() string
+	// available reports why the store cannot be used, or nil
+	available(ctx context.Context) error
+	// get returns errNotFound when there is no such entry
+	get(ctx context.Context, service, account string) (string, error)
+	set(ctx context.Context, service, account, value string) error
+	// remove succeeds when there is no such entry
+	remove(ctx context.Context, service, account string) error
+	// chunkSize is the largest value set accepts, in bytes
+	chunkSize() int
+}
+
+var errNotFound = errors.New("entry not found")
+
+// header is the entry stored under the item's own name
+type header struct {
+	Version    int    `json:"v"`
+	Generation int    `json:"gen"`
+	Chunks     int    `json:"chunks"`
+	SHA256     string `json:"sha256"`
+	Modified   string `json:"modified"`
+}
+
+// Backend implements vaultmux.Backend on a credential store
+type Backend struct {
+	service string
+	store   store
+}
+
+// New creates a backend storing entries under service (default "blackdot")
+func New(service string, s store) *Backend {
+	if service == "" {
+		service = "blackdot"
+	}
+	return &Backend{service: service, store: s}
+}
+
+// Name returns the backend name
+func (b *Backend) Name() string { return string(BackendType) }
+
+// Init checks the credential store can be used
+func (b *Backend) Init(ctx context.Context) error {
+	if err := b.store.available(ctx); err != nil {
+		return fmt.Errorf("%s: %w", b.store.name(), err)
+	}
+	return nil
+}
+
+// Close is a no-op
+func (b *Backend) Close() error { return nil }
+
+// IsAuthenticated reports whether the store is usable. The OS unlocks it
+// with the login session.
+func (b *Backend) IsAuthenticated(ctx context.Context) bool {
+	return b.store.available(ctx) == nil
+}
+
+// Authenticate returns a no-op session
+func (b *Backend) Authenticate(ctx context.Context) (vaultmux.Session, error) {
+	if !b.IsAuthenticated(ctx) {
+		return nil, vaultmux.ErrNotAuthenticated
+	}
+	return &keychainSession{}, nil
+}
+
+// Sync is a no-op; the store is local
+func (b *Backend) Sync(ctx context.Context, _ vaultmux.Session) error { return nil }
+
+// GetItem retrieves an item
+func (b *Backend) GetItem(ctx context.Context, name string, s vaultmux.Session) (*vaultmux.Item, error) {
+	h, err := b.header(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := b.read(ctx, name, h)
+	if err != nil {
+		return nil, err
+	}
+	item := &vaultmux.Item{ID: name, Name: name, Type: vaultmux.ItemTypeSecureNote, Notes: notes}
+	item.Modified, _ = time.Parse(time.RFC3339, h.Modified)
+	return item, nil
+}
+
+// GetNotes retrieves an item's content
+func (b *Backend) GetNotes(ctx context.Context, name string, _ vaultmux.Session) (string, error) {
+	h, err := b.header(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return b.read(ctx, name, h)
+}
+
+// ItemExists checks whether an item exists
+func (b *Backend) ItemExists(ctx context.Context, name string, _ vaultmux.Session) (bool, error) {
+	_, err := b.header(ctx, name)
+	if errors.Is(err, vaultmux.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ListItems lists the items in the index
+func (b *Backend) ListItems(ctx context.Context, _ vaultmux.Session) ([]*vaultmux.Item, error) {
+	names, _, err := b.index(ctx)
+	if err != nil {
+		return nil, vaultmux.WrapError(b.Name(), "list", "", err)
+	}
+	items := make([]*vaultmux.Item, 0, len(names))
+	for _, name := range names {
+		items = append(items, &vaultmux.Item{ID: name, Name: name, Type: vaultmux.ItemTypeSecureNote})
+	}
+	return items, nil
+}
+
+// CreateItem stores a new item
+func (b *Backend) CreateItem(ctx context.Context, name, content string, _ vaultmux.Session) error {
+	if err := vaultmux.ValidateItemName(name); err != nil {
+		return vaultmux.WrapError(b.Name(), "create", name, err)
+	}
+	if exists, err := b.ItemExists(ctx, name, nil); err != nil {
+		return err
+	} else if exists {
+		return vaultmux.ErrAlreadyExists
+	}
+	if err := b.write(ctx, name, content, 1); err != nil {
+		return vaultmux.WrapError(b.Name(), "create", name, err)
+	}
+	if err := b.updateIndex(ctx, name, true); err != nil {
+		return vaultmux.WrapError(b.Name(), "create", name, err)
+	}
+	return nil
+}
+
+// UpdateItem replaces an item's content. The new chunks are written
+// before the header points at them, then the old chunks are removed.
+func (b *Backend) UpdateItem(ctx context.Context, name, content string, _ vaultmux.Session) error {
+	old, err := b.header(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := b.write(ctx, name, content, old.Generation+1); err != nil {
+		return vaultmux.WrapError(b.Name(), "update", name, err)
+	}
+	b.removeChunks(ctx, name, old)
+	return nil
+}
+
+// DeleteItem removes an item and its chunks
+func (b *Backend) DeleteItem(ctx context.Context, name string, _ vaultmux.Session) error {
+	h, err := b.header(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := b.store.remove(ctx, b.service, name); err != nil {
+		return vaultmux.WrapError(b.Name(), "delete", name, err)
+	}
+	b.removeChunks(ctx, name, h)
+	if err := b.updateIndex(ctx, name, false); err != nil {
+		return vaultmux.WrapError(b.Name(), "delete", name, err)
+	}
+	return nil
+}
+
+// ListLocations returns no locations; the store is flat
+func (b *Backend) ListLocations(ctx context.Context, _ vaultmux.Session) ([]string, error) {
+	return []string{}, nil
+}
+
+// LocationExists always reports false; the store is flat
+func (b *Backend) LocationExists(ctx context.Context, name string, _ vaultmux.Session) (bool, error) {
+	return false, nil
+}
+
+// CreateLocation is a no-op; the store is flat
+func (b *Backend) CreateLocation(ctx context.Context, name string, _ vaultmux.Session) error {
+	return nil
+}
+
+// ListItemsInLocation lists every item; the store has no folders, so a
+// configured vault location is ignored
+func (b *Backend) ListItemsInLocation(ctx context.Context, locType, locValue string, s vaultmux.Session) ([]*vaultmux.Item, error) {
+	return b.ListItems(ctx, s)
+}
+
+func chunkAccount(name string, gen, n int) string {
+	return name + "#" + strconv.Itoa(gen) + "." + strconv.Itoa(n)
+}
+
+// header reads an item's header entry
+func (b *Backend) header(ctx context.Context, name string) (header, error) {
+	if err := vaultmux.ValidateItemName(name); err != nil {
+		return header{}, vaultmux.WrapError(b.Name(), "get", name, err)
+	}
+	h, err := b.loadHeader(ctx, name)
+	if errors.Is(err, errNotFound) {
+		return h, vaultmux.ErrNotFound
+	}
+	if err != nil {
+		return h, vaultmux.WrapError(b.Name(), "get", name, err)
+	}
+	return h, nil
+}
+
+// loadHeader reads the header stored under account
+func (b *Backend) loadHeader(ctx context.Context, account string) (header, error) {
+	var h header
+	raw, err := b.store.get(ctx, b.service, account)
+	if err != nil {
+		return h, err
+	}
+	data, err := base64.StdEncoding.DecodeString(raw)
+	if err == nil {
+		err = json.Unmarshal(data, &h)
+	}
+	if err != nil {
+		return h, fmt.Errorf("not a blackdot entry: %w", err)
+	}
+	return h, nil
+}
+
+// read joins the chunks a header points at and checks them against it
+func (b *Backend) read(ctx context.Context, name string, h header) (string, error) {
+	var encoded []byte
+	for i := 0; i < h.Chunks; i++ {
+		chunk, err := b.store.get(ctx, b.service, chunkAccount(name, h.Generation, i))
+		if err != nil {
+			return "", vaultmux.WrapError(b.Name(), "get", name, fmt.Errorf("chunk %d of %d: %w", i+1, h.Chunks, err))
+		}
+		encoded = append(encoded, chunk...)
+	}
+	data, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return "", vaultmux.WrapError(b.Name(), "get", name, fmt.Errorf("corrupt content: %w", err))
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != h.SHA256 {
+		return "", vaultmux.WrapError(b.Name(), "get", name, errors.New("content does not match its checksum"))
+	}
+	return string(data), nil
+}
+
+// write stores content as generation gen, then points the header at it
+func (b *Backend) write(ctx context.Context, name, content string, gen int) error {
+	encoded := base64.StdEncoding.EncodeToString([]byte(content))
+	size := b.store.chunkSize()
+	h := header{Version: 1, Generation: gen, Modified: time.Now().UTC().Format(time.RFC3339)}
+	sum := sha256.Sum256([]byte(content))
+	h.SHA256 = hex.EncodeToString(sum[:])
+
+	for start := 0; start < len(encoded) || h.Chunks == 0; start += size {
+		end := min(start+size, len(encoded))
+		if err := b.store.set(ctx, b.service, chunkAccount(name, gen, h.Chunks), encoded[start:end]); err != nil {
+			b.removeChunks(ctx, name, h)
+			return err
+		}
+		h.Chunks++
+	}
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	if err := b.store.set(ctx, b.service, name, base64.StdEncoding.EncodeToString(data)); err != nil {
+		b.removeChunks(ctx, name, h)
+		return err
+	}
+	return nil
+}
+
+// removeChunks deletes the chunks of one generation. Failures leave
+// unreferenced entries behind, which is harmless.
+func (b *Backend) removeChunks(ctx context.Context, name string, h header) {
+	for i := 0; i < h.Chunks; i++ {
+		b.store.remove(ctx, b.service, chunkAccount(name, h.Generation, i))
+	}
+}
+
+// index returns the sorted item names. The index is stored like an item,
+// so it is chunked as it grows.
+func (b *Backend) index(ctx context.Context) ([]string, header, error) {
+	h, err := b.loadHeader(ctx, indexAccount)
+	if errors.Is(err, errNotFound) {
+		return nil, h, nil
+	}
+	if err != nil {
+		return nil, h, err
+	}
+	content, err := b.read(ctx, indexAccount, h)
+	if err != nil {
+		return nil, h, err
+	}
+	var names []string
+	return names, h, json.Unmarshal([]byte(content), &names)
+}
+
+// updateIndex adds or removes name from the index
+func (b *Backend) updateIndex(ctx context.Context, name string, add bool) error {
+	names, old, err := b.index(ctx)
+	if err != nil {
+		return err
+	}
+	set := make(map[string]bool, len(names)+1)
+	for _, n := range names {
+		set[n] = true
+	}
+	if set[name] == add {
+		return nil
+	}
+	if add {
+		set[name] = true
+	} else {
+		delete(set, name)
+	}
+	names = names[:0]
+	for n := range set {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	data, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	if err := b.write(ctx, indexAccount, string(data), old.Generation+1); err != nil {
+		return err
+	}
+	b.removeChunks(ctx, indexAccount, old)
+	return nil
+}
+
+// keychainSession is a no-op session; the OS unlocks the store at login
+type keychainSession struct{}
+
+func (s *keychainSession) Token() string                     { return "" }
+func (s *keychainSession) IsValid(ctx context.Context) bool  { return true }
+func (s *keychainSession) Refresh(ctx context.Context) error { return nil }
+func (s *keychainSession) ExpiresAt() time.Time              { return time.Time{} }
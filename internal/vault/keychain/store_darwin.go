@@ -0,0 +1,66 @@
+package keychain
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// securityNotFound is the exit code security uses for a missing item
+const securityNotFound = 44
+
+// macKeychain stores generic passwords in the login keychain via security(1)
+type macKeychain struct{}
+
+func newNativeStore() store { return macKeychain{} }
+
+func (macKeychain) name() string { return "macOS Keychain" }
+
+// chunkSize stays well under the line length security -i reads
+func (macKeychain) chunkSize() int { return 2048 }
+
+func (macKeychain) available(ctx context.Context) error {
+	if _, err := exec.LookPath("security"); err != nil {
+		return vaultmux.ErrBackendNotInstalled
+	}
+	_, err := run(ctx, "", "security", "default-keychain")
+	return err
+}
+
+func (macKeychain) get(ctx context.Context, service, account string) (string, error) {
+	out, err := run(ctx, "", "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	if exitCode(err) == securityNotFound {
+		return "", errNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// set goes through security's interactive mode so the value is read from
+// stdin rather than appearing in the process list. Accounts are validated
+// item names and values are base64, so neither needs escaping.
+func (macKeychain) set(ctx context.Context, service, account, value string) error {
+	line := fmt.Sprintf("add-generic-password -U -s %q -a %q -w %q\n", service, account, value)
+	out, err := run(ctx, line, "security", "-i")
+	if err != nil {
+		return err
+	}
+	// security -i reports command failures on stdout and still exits 0
+	if msg := strings.TrimSpace(out); msg != "" {
+		return fmt.Errorf("security: %s", msg)
+	}
+	return nil
+}
+
+func (macKeychain) remove(ctx context.Context, service, account string) error {
+	_, err := run(ctx, "", "security", "delete-generic-password", "-s", service, "-a", account)
+	if exitCode(err) == securityNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+//go:build !darwin && !windows
+
+package keychain
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// secretService stores secrets in the Secret Service (GNOME Keyring,
+// KWallet) via libsecret's secret-tool
+type secretService struct{}
+
+func newNativeStore() store { return secretService{} }
+
+func (secretService) name() string { return "Secret Service" }
+
+func (secretService) chunkSize() int { return 32 * 1024 }
+
+// available also catches a missing D-Bus session or keyring daemon, which
+// secret-tool reports on stderr
+func (s secretService) available(ctx context.Context) error {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return vaultmux.ErrBackendNotInstalled
+	}
+	_, err := s.get(ctx, "blackdot", indexAccount)
+	if errors.Is(err, errNotFound) {
+		return nil
+	}
+	return err
+}
+
+// get treats a silent exit 1 as a missing entry; secret-tool uses exit 1
+// for errors too, but prints a message for those
+func (secretService) get(ctx context.Context, service, account string) (string, error) {
+	out, err := run(ctx, "", "secret-tool", "lookup", "service", service, "account", account)
+	if exitedSilently(err, 1) {
+		return "", errNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// set passes the value on stdin; secret-tool reads it from there when
+// stdin is not a terminal
+func (secretService) set(ctx context.Context, service, account, value string) error {
+	_, err := run(ctx, value, "secret-tool", "store", "--label", service+" "+account,
+		"service", service, "account", account)
+	return err
+}
+
+func (secretService) remove(ctx context.Context, service, account string) error {
+	_, err := run(ctx, "", "secret-tool", "clear", "service", service, "account", account)
+	if exitedSilently(err, 1) {
+		return nil
+	}
+	return err
+}
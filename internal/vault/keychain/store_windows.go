@@ -0,0 +1,114 @@
+package keychain
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// credNotFound is the exit code credScript uses for a missing credential
+const credNotFound = 3
+
+// credScript calls CredRead/CredWrite/CredDelete. It reads the operation,
+// target, and value from stdin so secrets stay off the command line.
+const credScript = `$ErrorActionPreference = 'Stop'
+Add-Type -TypeDefinition @'
+using System;
+using System.Runtime.InteropServices;
+using System.Text;
+public static class BlackdotCred {
+  [StructLayout(LayoutKind.Sequential, CharSet = CharSet.Unicode)]
+  struct CREDENTIAL {
+    public int Flags; public int Type; public string TargetName; public string Comment;
+    public System.Runtime.InteropServices.ComTypes.FILETIME LastWritten;
+    public int CredentialBlobSize; public IntPtr CredentialBlob; public int Persist;
+    public int AttributeCount; public IntPtr Attributes; public string TargetAlias; public string UserName;
+  }
+  [DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+  static extern bool CredRead(string target, int type, int flags, out IntPtr cred);
+  [DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+  static extern bool CredWrite(ref CREDENTIAL cred, int flags);
+  [DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+  static extern bool CredDelete(string target, int type, int flags);
+  [DllImport("advapi32.dll")]
+  static extern void CredFree(IntPtr cred);
+  public static string Read(string target) {
+    IntPtr p;
+    if (!CredRead(target, 1, 0, out p)) return null;
+    try {
+      CREDENTIAL c = (CREDENTIAL)Marshal.PtrToStructure(p, typeof(CREDENTIAL));
+      return Marshal.PtrToStringUni(c.CredentialBlob, c.CredentialBlobSize / 2);
+    } finally { CredFree(p); }
+  }
+  public static void Write(string target, string user, string value) {
+    byte[] blob = Encoding.Unicode.GetBytes(value);
+    CREDENTIAL c = new CREDENTIAL();
+    c.Type = 1; c.TargetName = target; c.UserName = user; c.Persist = 2;
+    c.CredentialBlobSize = blob.Length;
+    c.CredentialBlob = Marshal.AllocHGlobal(blob.Length);
+    try {
+      Marshal.Copy(blob, 0, c.CredentialBlob, blob.Length);
+      if (!CredWrite(ref c, 0)) throw new System.ComponentModel.Win32Exception();
+    } finally { Marshal.FreeHGlobal(c.CredentialBlob); }
+  }
+  public static void Delete(string target) { CredDelete(target, 1, 0); }
+}
+'@
+$op = [Console]::In.ReadLine()
+$target = [Console]::In.ReadLine()
+switch ($op) {
+  'get' {
+    $v = [BlackdotCred]::Read($target)
+    if ($v -eq $null) { exit 3 }
+    [Console]::Out.Write($v)
+  }
+  'set' { [BlackdotCred]::Write($target, 'blackdot', [Console]::In.ReadLine()) }
+  'remove' { [BlackdotCred]::Delete($target) }
+}
+`
+
+// winCredentials stores generic credentials in Windows Credential Manager
+type winCredentials struct{}
+
+func newNativeStore() store { return winCredentials{} }
+
+func (winCredentials) name() string { return "Windows Credential Manager" }
+
+// chunkSize keeps each value under the 2560-byte credential blob limit,
+// which holds 1280 UTF-16 characters
+func (winCredentials) chunkSize() int { return 1200 }
+
+func (winCredentials) available(ctx context.Context) error {
+	if _, err := exec.LookPath("powershell"); err != nil {
+		return vaultmux.ErrBackendNotInstalled
+	}
+	return nil
+}
+
+func credTarget(service, account string) string {
+	return service + ":" + account
+}
+
+func (w winCredentials) call(ctx context.Context, lines ...string) (string, error) {
+	return run(ctx, strings.Join(lines, "\n")+"\n", "powershell", "-NoProfile", "-NonInteractive", "-Command", credScript)
+}
+
+func (w winCredentials) get(ctx context.Context, service, account string) (string, error) {
+	out, err := w.call(ctx, "get", credTarget(service, account))
+	if exitCode(err) == credNotFound {
+		return "", errNotFound
+	}
+	return out, err
+}
+
+func (w winCredentials) set(ctx context.Context, service, account, value string) error {
+	_, err := w.call(ctx, "set", credTarget(service, account), value)
+	return err
+}
+
+func (w winCredentials) remove(ctx context.Context, service, account string) error {
+	_, err := w.call(ctx, "remove", credTarget(service, account))
+	return err
+}
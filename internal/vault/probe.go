@@ -0,0 +1,141 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// Probe kinds
+const (
+	ProbeCLI  = "cli"  // the backend CLI is installed and initializes
+	ProbeAuth = "auth" // the backend is logged in and unlocked
+	ProbeItem = "item" // an item exists and has content
+)
+
+// Probe exit codes, one per kind of failure so pipelines can tell them
+// apart. 1 stays the generic error, including an invalid probe.
+const (
+	ExitOK          = 0
+	ExitCLIMissing  = 3
+	ExitAuthFailed  = 4
+	ExitItemMissing = 5
+	ExitItemError   = 6
+	ExitTimeout     = 7
+)
+
+// Probe statuses
+const (
+	ProbePass = "pass"
+	ProbeFail = "fail"
+)
+
+// Probe is one readiness check: cli, auth, or item:<name>
+type Probe struct {
+	Kind string
+	Item string
+}
+
+func (p Probe) String() string {
+	if p.Kind == ProbeItem {
+		return ProbeItem + ":" + p.Item
+	}
+	return p.Kind
+}
+
+// ParseProbe reads a probe spec: cli, auth, or item:<name>
+func ParseProbe(spec string) (Probe, error) {
+	kind, item, hasItem := strings.Cut(strings.TrimSpace(spec), ":")
+	switch {
+	case kind == ProbeItem && item != "":
+		return Probe{Kind: ProbeItem, Item: item}, nil
+	case (kind == ProbeCLI || kind == ProbeAuth) && !hasItem:
+		return Probe{Kind: kind}, nil
+	}
+	return Probe{}, fmt.Errorf("invalid probe %q (want cli, auth, or item:<name>)", spec)
+}
+
+// ProbeResult is the outcome of one probe
+type ProbeResult struct {
+	Probe      string `json:"probe"`
+	Status     string `json:"status"`
+	Code       int    `json:"code"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// RunProbes runs probes in order without prompting. Item probes need a
+// working, authenticated backend and fail with that stage's code when it
+// isn't. ctx bounds the whole run; a probe cut off by it fails with
+// ExitTimeout.
+func (s *Service) RunProbes(ctx context.Context, probes []Probe) []ProbeResult {
+	var initErr error
+	initDone := false
+	initialize := func() error {
+		if !initDone {
+			initErr, initDone = s.backend.Init(ctx), true
+		}
+		return initErr
+	}
+
+	results := make([]ProbeResult, 0, len(probes))
+	for _, p := range probes {
+		start := time.Now()
+		code, err := s.runProbe(ctx, p, initialize)
+		if err != nil && ctx.Err() != nil {
+			code, err = ExitTimeout, fmt.Errorf("timed out: %w", ctx.Err())
+		}
+		r := ProbeResult{Probe: p.String(), Status: ProbePass, Code: code, DurationMS: time.Since(start).Milliseconds()}
+		if err != nil {
+			r.Status, r.Error = ProbeFail, err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func (s *Service) runProbe(ctx context.Context, p Probe, initialize func() error) (int, error) {
+	if err := initialize(); err != nil {
+		return ExitCLIMissing, err
+	}
+	if p.Kind == ProbeCLI {
+		return ExitOK, nil
+	}
+	if !s.backend.IsAuthenticated(ctx) {
+		return ExitAuthFailed, errors.New("not authenticated")
+	}
+	if p.Kind == ProbeAuth {
+		return ExitOK, nil
+	}
+
+	session, err := s.Connect(ctx)
+	if err != nil {
+		return ExitAuthFailed, err
+	}
+	notes, err := s.backend.GetNotes(ctx, p.Item, session)
+	switch {
+	case errors.Is(err, vaultmux.ErrNotFound):
+		return ExitItemMissing, fmt.Errorf("item %s not found", p.Item)
+	case errors.Is(err, vaultmux.ErrNotAuthenticated), errors.Is(err, vaultmux.ErrSessionExpired), errors.Is(err, vaultmux.ErrBackendLocked):
+		return ExitAuthFailed, err
+	case err != nil:
+		return ExitItemError, err
+	case notes == "":
+		return ExitItemMissing, fmt.Errorf("item %s is empty", p.Item)
+	}
+	return ExitOK, nil
+}
+
+// ProbeExitCode is the code of the first failed probe, or ExitOK
+func ProbeExitCode(results []ProbeResult) int {
+	for _, r := range results {
+		if r.Status == ProbeFail {
+			return r.Code
+		}
+	}
+	return ExitOK
+}
@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blackwell-systems/vaultmux"
+	"github.com/blackwell-systems/vaultmux/mock"
+)
+
+// uninstalledBackend fails Init like a backend whose CLI is missing
+type uninstalledBackend struct{ *mock.Backend }
+
+func (uninstalledBackend) Init(context.Context) error { return vaultmux.ErrBackendNotInstalled }
+
+// slowBackend blocks reads until the context ends
+type slowBackend struct{ *mock.Backend }
+
+func (slowBackend) GetNotes(ctx context.Context, _ string, _ vaultmux.Session) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestParseProbe(t *testing.T) {
+	for spec, want := range map[string]Probe{
+		"cli":             {Kind: ProbeCLI},
+		" auth ":          {Kind: ProbeAuth},
+		"item:SSH-Config": {Kind: ProbeItem, Item: "SSH-Config"},
+	} {
+		if got, err := ParseProbe(spec); err != nil || got != want {
+			t.Errorf("ParseProbe(%q) = %+v, %v", spec, got, err)
+		}
+	}
+	for _, bad := range []string{"", "item", "item:", "auth:x", "sync"} {
+		if _, err := ParseProbe(bad); err == nil {
+			t.Errorf("ParseProbe(%q) accepted", bad)
+		}
+	}
+}
+
+// TestRunProbes verifies each failure gets its own exit code and the
+// first failure decides the overall code
+func TestRunProbes(t *testing.T) {
+	ctx := context.Background()
+	item, _ := ParseProbe("item:SSH-Config")
+	missing, _ := ParseProbe("item:AWS-Config")
+	probes := []Probe{{Kind: ProbeCLI}, {Kind: ProbeAuth}, item, missing}
+
+	backend := mock.New()
+	backend.SetItem("SSH-Config", "Host *")
+	results := New(backend, nil).RunProbes(ctx, probes)
+	codes := []int{results[0].Code, results[1].Code, results[2].Code, results[3].Code}
+	if codes[0] != ExitOK || codes[1] != ExitOK || codes[2] != ExitOK || codes[3] != ExitItemMissing {
+		t.Errorf("codes = %v (%+v)", codes, results)
+	}
+	if ProbeExitCode(results) != ExitItemMissing || results[3].Status != ProbeFail {
+		t.Errorf("results = %+v", results)
+	}
+
+	locked := mock.New()
+	locked.AuthError = errors.New("vault is locked")
+	if code := ProbeExitCode(New(locked, nil).RunProbes(ctx, probes)); code != ExitAuthFailed {
+		t.Errorf("locked vault: exit %d", code)
+	}
+
+	results = New(uninstalledBackend{mock.New()}, nil).RunProbes(ctx, probes)
+	for _, r := range results {
+		if r.Code != ExitCLIMissing {
+			t.Errorf("uninstalled backend: %+v", r)
+		}
+	}
+
+	unreadable := mock.New()
+	unreadable.GetError = errors.New("rate limited")
+	if code := ProbeExitCode(New(unreadable, nil).RunProbes(ctx, []Probe{item})); code != ExitItemError {
+		t.Errorf("read error: exit %d", code)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if code := ProbeExitCode(New(slowBackend{mock.New()}, nil).RunProbes(short, []Probe{item})); code != ExitTimeout {
+		t.Errorf("slow backend: exit %d", code)
+	}
+}
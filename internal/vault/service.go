@@ -0,0 +1,232 @@
+// Package vault implements the vault operations behind 'blackdot vault'
+// without printing or reading from the terminal.
+//
+// A Service wraps one vaultmux backend. Operations return typed results;
+// progress that is not part of a result (a failed sync before a write, say)
+// goes to the Reporter. Anything that needs a decision from the user is a
+// callback, so the CLI can prompt while tests and other front ends decide
+// on their own:
+//
+//	svc := vault.New(backend, nil)
+//	defer svc.Close()
+//	items, err := svc.List(ctx, "")
+package vault
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/blackwell-systems/vaultmux"
+)
+
+// Reporter receives progress messages from operations
+type Reporter interface {
+	Info(format string, args ...interface{})
+	Warn(format string, args ...interface{})
+}
+
+type discardReporter struct{}
+
+func (discardReporter) Info(string, ...interface{}) {}
+func (discardReporter) Warn(string, ...interface{}) {}
+
+// Stages at which connecting to a backend can fail
+const (
+	StageInit         = "init"
+	StageAuthenticate = "authenticate"
+)
+
+// StageError is a failure to connect, tagged with the stage that failed so
+// callers can tell a missing CLI from a locked vault
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string { return e.Stage + ": " + e.Err.Error() }
+func (e *StageError) Unwrap() error { return e.Err }
+
+// ErrItemExists is returned by Create when the item exists and force is off
+var ErrItemExists = errors.New("item exists, use --force to overwrite")
+
+// Service runs vault operations against one backend
+type Service struct {
+	backend vaultmux.Backend
+	session vaultmux.Session
+	report  Reporter
+}
+
+// New returns a service for backend. A nil reporter discards progress.
+func New(backend vaultmux.Backend, report Reporter) *Service {
+	if report == nil {
+		report = discardReporter{}
+	}
+	return &Service{backend: backend, report: report}
+}
+
+// Backend returns the wrapped backend
+func (s *Service) Backend() vaultmux.Backend { return s.backend }
+
+// Close releases the backend
+func (s *Service) Close() error { return s.backend.Close() }
+
+// Connect initializes the backend and authenticates, once
+func (s *Service) Connect(ctx context.Context) (vaultmux.Session, error) {
+	if s.session != nil {
+		return s.session, nil
+	}
+	if err := s.backend.Init(ctx); err != nil {
+		return nil, &StageError{Stage: StageInit, Err: err}
+	}
+	session, err := s.backend.Authenticate(ctx)
+	if err != nil {
+		return nil, &StageError{Stage: StageAuthenticate, Err: err}
+	}
+	s.session = session
+	return session, nil
+}
+
+// List returns the items in the vault, or in one folder when location is set
+func (s *Service) List(ctx context.Context, location string) ([]*vaultmux.Item, error) {
+	session, err := s.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if location != "" {
+		return s.backend.ListItemsInLocation(ctx, "folder", location, session)
+	}
+	return s.backend.ListItems(ctx, session)
+}
+
+// Sync pulls the latest vault state from the server
+func (s *Service) Sync(ctx context.Context) error {
+	session, err := s.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	return s.backend.Sync(ctx, session)
+}
+
+// syncBeforeWrite syncs so writes see current items; failure only warns
+func (s *Service) syncBeforeWrite(ctx context.Context) {
+	s.report.Info("Syncing vault...")
+	if err := s.Sync(ctx); err != nil {
+		s.report.Warn("Sync failed: %v", err)
+	}
+}
+
+// CreateResult describes a Create
+type CreateResult struct {
+	Name         string
+	Size         int  // bytes written
+	ExistingSize int  // size of the item it replaced, 0 if new
+	Updated      bool // an existing item was overwritten
+}
+
+// Create stores content as a secure note. An existing item is only
+// overwritten with force; otherwise the result and ErrItemExists are
+// returned.
+func (s *Service) Create(ctx context.Context, name, content string, force bool) (CreateResult, error) {
+	result := CreateResult{Name: name, Size: len(content)}
+	session, err := s.Connect(ctx)
+	if err != nil {
+		return result, err
+	}
+	s.syncBeforeWrite(ctx)
+
+	existing, _ := s.backend.GetNotes(ctx, name, session)
+	result.ExistingSize = len(existing)
+	if existing != "" {
+		if !force {
+			return result, ErrItemExists
+		}
+		result.Updated = true
+		return result, s.backend.UpdateItem(ctx, name, content, session)
+	}
+	return result, s.backend.CreateItem(ctx, name, content, session)
+}
+
+// Delete outcomes
+const (
+	DeleteDeleted  = "deleted"
+	DeleteNotFound = "not-found"
+	DeleteDeclined = "declined"
+	DeleteFailed   = "failed"
+)
+
+// DeleteResult describes the deletion of one item
+type DeleteResult struct {
+	Name      string
+	Size      int
+	Protected bool
+	Status    string
+	Err       error
+}
+
+// ConfirmDelete decides whether an existing item is deleted. It is called
+// for every item found; protected items are flagged so callers can ask for
+// more than a yes.
+type ConfirmDelete func(r DeleteResult) bool
+
+// PrepareDelete syncs the vault before a run of DeleteItem calls
+func (s *Service) PrepareDelete(ctx context.Context) error {
+	if _, err := s.Connect(ctx); err != nil {
+		return err
+	}
+	s.syncBeforeWrite(ctx)
+	return nil
+}
+
+// DeleteItem deletes one item if it exists and confirm agrees. A nil
+// confirm deletes without asking.
+func (s *Service) DeleteItem(ctx context.Context, name string, confirm ConfirmDelete) DeleteResult {
+	result := DeleteResult{Name: name, Protected: IsProtected(name)}
+	session, err := s.Connect(ctx)
+	if err != nil {
+		result.Status, result.Err = DeleteFailed, err
+		return result
+	}
+
+	existing, _ := s.backend.GetNotes(ctx, name, session)
+	if existing == "" {
+		result.Status = DeleteNotFound
+		return result
+	}
+	result.Size = len(existing)
+	if confirm != nil && !confirm(result) {
+		result.Status = DeleteDeclined
+		return result
+	}
+	if err := s.backend.DeleteItem(ctx, name, session); err != nil {
+		result.Status, result.Err = DeleteFailed, err
+		return result
+	}
+	result.Status = DeleteDeleted
+	return result
+}
+
+// Health is the state of the backend and its cached session
+type Health struct {
+	Backend       string
+	Available     bool
+	InitErr       error
+	Authenticated bool
+	SessionCached bool
+}
+
+// Health checks whether the backend CLI works and is logged in, without
+// prompting to authenticate
+func (s *Service) Health(ctx context.Context, sessionFile string) Health {
+	h := Health{Backend: s.backend.Name()}
+	if _, err := os.Stat(sessionFile); err == nil {
+		h.SessionCached = true
+	}
+	if err := s.backend.Init(ctx); err != nil {
+		h.InitErr = err
+		return h
+	}
+	h.Available = true
+	h.Authenticated = s.backend.IsAuthenticated(ctx)
+	return h
+}
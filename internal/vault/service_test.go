@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blackwell-systems/vaultmux/mock"
+)
+
+// TestServiceCreate verifies Create refuses to overwrite without force and
+// reports whether it created or updated
+func TestServiceCreate(t *testing.T) {
+	ctx := context.Background()
+	svc := New(mock.New(), nil)
+
+	result, err := svc.Create(ctx, "API-Key", "one", false)
+	if err != nil || result.Updated {
+		t.Fatalf("create: %+v, %v", result, err)
+	}
+	result, err = svc.Create(ctx, "API-Key", "two", false)
+	if !errors.Is(err, ErrItemExists) || result.ExistingSize != 3 {
+		t.Errorf("create over existing: %+v, %v", result, err)
+	}
+	result, err = svc.Create(ctx, "API-Key", "three", true)
+	if err != nil || !result.Updated {
+		t.Errorf("forced create: %+v, %v", result, err)
+	}
+	if notes, _ := svc.Backend().GetNotes(ctx, "API-Key", nil); notes != "three" {
+		t.Errorf("notes = %q", notes)
+	}
+}
+
+// TestServiceDeleteItem verifies missing, declined, and protected items
+func TestServiceDeleteItem(t *testing.T) {
+	ctx := context.Background()
+	backend := mock.New()
+	backend.SetItem("SSH-Work", "key")
+	backend.SetItem("Temp", "x")
+	svc := New(backend, nil)
+
+	if r := svc.DeleteItem(ctx, "Nope", nil); r.Status != DeleteNotFound {
+		t.Errorf("missing item: %+v", r)
+	}
+
+	var asked DeleteResult
+	r := svc.DeleteItem(ctx, "SSH-Work", func(r DeleteResult) bool { asked = r; return false })
+	if r.Status != DeleteDeclined || !asked.Protected || asked.Size != 3 {
+		t.Errorf("declined protected item: %+v, asked %+v", r, asked)
+	}
+	if r := svc.DeleteItem(ctx, "Temp", nil); r.Status != DeleteDeleted {
+		t.Errorf("delete: %+v", r)
+	}
+	if exists, _ := backend.ItemExists(ctx, "Temp", nil); exists {
+		t.Error("item still exists after delete")
+	}
+}
+
+// TestServiceConnectStage verifies authentication failures are tagged
+func TestServiceConnectStage(t *testing.T) {
+	backend := mock.New()
+	backend.AuthError = errors.New("locked")
+	_, err := New(backend, nil).List(context.Background(), "")
+	var stage *StageError
+	if !errors.As(err, &stage) || stage.Stage != StageAuthenticate {
+		t.Errorf("err = %v", err)
+	}
+}
+
+// TestItemsFileSyncable verifies syncable items fall back to vault_items
+func TestItemsFileSyncable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault-items.json")
+	os.WriteFile(path, []byte(`{"vault_items": {"Git-Config": {"path": "~/.gitconfig", "required": true}}}`), 0600)
+	f, err := LoadItemsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Syncable(); got["Git-Config"] != "~/.gitconfig" || len(got) != 1 {
+		t.Errorf("syncable = %v", got)
+	}
+	if _, err := LoadItemsFile(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("missing file err = %v", err)
+	}
+}
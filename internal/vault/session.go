@@ -0,0 +1,99 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sessionMagic starts every sealed session file. The rest of the first
+// line names the key source, so the file can be opened without guessing.
+const sessionMagic = "blackdot-session v1 "
+
+// SessionKeySize is the length of a session key (AES-256)
+const SessionKeySize = 32
+
+// ErrSessionKey is returned when a sealed session does not open with the
+// key given: the key changed or the file was tampered with
+var ErrSessionKey = errors.New("cached session does not decrypt with this key")
+
+// SealSession encrypts a cached session with AES-GCM. source names where
+// the key lives (keychain, machine) and is authenticated with the data.
+func SealSession(key []byte, source string, plaintext []byte) ([]byte, error) {
+	aead, err := sessionAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	header := sessionMagic + source
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(header))
+	return []byte(header + "\n" + base64.StdEncoding.EncodeToString(sealed) + "\n"), nil
+}
+
+// SessionSource returns the key source of a sealed session, and false for
+// anything else (a plaintext session from before encryption)
+func SessionSource(data []byte) (string, bool) {
+	if !bytes.HasPrefix(data, []byte(sessionMagic)) {
+		return "", false
+	}
+	line, _, _ := strings.Cut(string(data[len(sessionMagic):]), "\n")
+	return strings.TrimSpace(line), true
+}
+
+// OpenSession decrypts a sealed session
+func OpenSession(key []byte, data []byte) ([]byte, error) {
+	source, ok := SessionSource(data)
+	if !ok {
+		return nil, errors.New("not a sealed session")
+	}
+	_, body, _ := strings.Cut(string(data), "\n")
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(body))
+	if err != nil {
+		return nil, fmt.Errorf("sealed session: %w", err)
+	}
+	aead, err := sessionAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrSessionKey
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(sessionMagic+source))
+	if err != nil {
+		return nil, ErrSessionKey
+	}
+	return plaintext, nil
+}
+
+// MachineSessionKey derives a session key from a machine identifier and
+// the user, for systems without a usable credential store. It keeps the
+// session unreadable in backups and on other machines, not from other
+// processes of the same user.
+func MachineSessionKey(machineID, user string) ([]byte, error) {
+	if strings.TrimSpace(machineID) == "" {
+		return nil, errors.New("no machine identifier")
+	}
+	return hkdf.Key(sha256.New, []byte(strings.TrimSpace(machineID)), []byte("blackdot-session v1"), user, SessionKeySize)
+}
+
+func sessionAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != SessionKeySize {
+		return nil, fmt.Errorf("session key must be %d bytes", SessionKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
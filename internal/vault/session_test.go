@@ -0,0 +1,46 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealSession(t *testing.T) {
+	key, err := MachineSessionKey("4c4c4544-0042", "501:ada")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte(`{"token": "abc", "backend": "bitwarden"}`)
+
+	sealed, err := SealSession(key, "machine", plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("abc")) {
+		t.Errorf("token visible in sealed session:\n%s", sealed)
+	}
+	if source, ok := SessionSource(sealed); !ok || source != "machine" {
+		t.Errorf("source = %q, %v", source, ok)
+	}
+	if got, err := OpenSession(key, sealed); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("open = %q, %v", got, err)
+	}
+
+	other, _ := MachineSessionKey("another-machine", "501:ada")
+	if _, err := OpenSession(other, sealed); !errors.Is(err, ErrSessionKey) {
+		t.Errorf("wrong key: %v", err)
+	}
+	// The key source is authenticated with the data
+	relabeled := bytes.Replace(sealed, []byte("v1 machine"), []byte("v1 keychain"), 1)
+	if _, err := OpenSession(key, relabeled); !errors.Is(err, ErrSessionKey) {
+		t.Errorf("relabeled source: %v", err)
+	}
+
+	if _, ok := SessionSource(plaintext); ok {
+		t.Error("plaintext session reported as sealed")
+	}
+	if _, err := MachineSessionKey(" ", "u"); err == nil {
+		t.Error("empty machine id accepted")
+	}
+}
@@ -14,7 +14,7 @@
 #   pre_upgrade, post_upgrade, pre_vault_pull, post_vault_pull,
 #   pre_vault_push, post_vault_push, pre_doctor, post_doctor,
 #   doctor_check, shell_init, shell_exit, directory_change,
-#   pre_setup_phase, post_setup_phase, setup_complete
+#   pre_setup, pre_setup_phase, post_setup_phase, setup_complete
 #
 # Design Goals:
 # - User customization without modifying core scripts
@@ -68,7 +68,7 @@ HOOK_POINTS=(
     shell_init shell_exit directory_change
 
     # Setup wizard
-    pre_setup_phase post_setup_phase setup_complete
+    pre_setup pre_setup_phase post_setup_phase setup_complete
 
     # Template operations
     pre_template_render post_template_render